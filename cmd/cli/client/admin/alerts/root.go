@@ -0,0 +1,193 @@
+package alerts
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "List alerts and manage the rules raising them",
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the raised alerts, most recent first",
+	Args:  cobra.NoArgs,
+	RunE:  doList,
+}
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Show, set or reset the alert rules",
+}
+
+var rulesGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Show the alert rules in effect",
+	Args:  cobra.NoArgs,
+	RunE:  doRulesGet,
+}
+
+var rulesSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the alert rules",
+	Long: `Set the alert rules. The whole set of rules is replaced, so rules not given
+are disabled. The rules override those of the configuration, across restarts,
+until they are reset.`,
+	Args: cobra.NoArgs,
+	RunE: doRulesSet,
+}
+
+var rulesResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Restore the configured alert rules",
+	Args:  cobra.NoArgs,
+	RunE:  doRulesReset,
+}
+
+func init() {
+	listCmd.Flags().Int("limit", 0, "Maximum number of alerts to list (server default if 0)")
+
+	rulesSetCmd.Flags().Int64("deadline-epochs", 0, "Alert when a proving deadline is this many epochs away without a proof (0 disables)")
+	rulesSetCmd.Flags().Bool("fault-records", false, "Alert on FaultRecord events of the node's data sets")
+	rulesSetCmd.Flags().Bool("settlement-failures", false, "Alert on failed rail settlements")
+
+	rulesCmd.AddCommand(rulesGetCmd)
+	rulesCmd.AddCommand(rulesSetCmd)
+	rulesCmd.AddCommand(rulesResetCmd)
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(rulesCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	alerts, err := api.ListAlerts(cmd.Context(), limit)
+	if err != nil {
+		return fmt.Errorf("listing alerts: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FIRED\tKIND\tDATA SET\tRAIL\tDELIVERED\tMESSAGE")
+	for _, a := range alerts {
+		dataSet := "-"
+		if a.DataSetID != 0 {
+			dataSet = strconv.FormatInt(a.DataSetID, 10)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			a.FiredAt.Format(time.RFC3339), a.Kind, dataSet, orDash(a.RailID), delivered(a), a.Message)
+	}
+	return w.Flush()
+}
+
+func doRulesGet(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	rules, err := api.GetAlertRules(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("getting alert rules: %w", err)
+	}
+
+	return printRules(cmd, *rules)
+}
+
+func doRulesSet(cmd *cobra.Command, _ []string) error {
+	deadline, _ := cmd.Flags().GetInt64("deadline-epochs")
+	faults, _ := cmd.Flags().GetBool("fault-records")
+	settlements, _ := cmd.Flags().GetBool("settlement-failures")
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	rules, err := api.SetAlertRules(cmd.Context(), httpapi.AlertRules{
+		DeadlineEpochs:     deadline,
+		FaultRecords:       faults,
+		SettlementFailures: settlements,
+	})
+	if err != nil {
+		return fmt.Errorf("setting alert rules: %w", err)
+	}
+
+	return printRules(cmd, *rules)
+}
+
+func doRulesReset(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	rules, err := api.ResetAlertRules(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("resetting alert rules: %w", err)
+	}
+
+	return printRules(cmd, *rules)
+}
+
+func printRules(cmd *cobra.Command, rules httpapi.AlertRulesResponse) error {
+	source := "config"
+	if rules.Overridden {
+		source = "admin"
+	}
+	deadline := "-"
+	if rules.DeadlineEpochs > 0 {
+		deadline = fmt.Sprintf("%d epochs", rules.DeadlineEpochs)
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Proving deadline:\t%s\n", deadline)
+	fmt.Fprintf(w, "Fault records:\t%t\n", rules.FaultRecords)
+	fmt.Fprintf(w, "Settlement failures:\t%t\n", rules.SettlementFailures)
+	fmt.Fprintf(w, "Source:\t%s\n", source)
+	return w.Flush()
+}
+
+func delivered(a httpapi.Alert) string {
+	switch {
+	case a.Delivered:
+		return "yes"
+	case a.Attempts == 0:
+		return "pending"
+	default:
+		return fmt.Sprintf("no (%d attempts)", a.Attempts)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
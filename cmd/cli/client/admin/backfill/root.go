@@ -0,0 +1,137 @@
+package backfill
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Register blobs stored before PDP into a data set",
+	Long: `Register the blobs stored by a node upgraded from a version without PDP.
+
+Those blobs were uploaded but never aggregated, so they are not proven and the
+node is not paid for storing them. A backfill walks the allocations of the
+node and queues every stored blob without a piece for aggregation, through the
+same pipeline as new uploads: its piece is calculated, it is aggregated, and
+the aggregate is added to a data set.
+
+The backfill runs in the background on the node. Its progress is recorded, and
+a backfill interrupted by a restart continues when the node starts again.`,
+}
+
+var startCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start a backfill",
+	Long: `Start a backfill in the background. Blobs that already have a piece, that
+are not in the blob store, or that are too large to be proven are counted but
+not queued. Blobs queued by an earlier backfill are never queued again.
+
+With --resume, a cancelled or failed backfill continues with the blobs it has
+not examined yet.`,
+	Args: cobra.NoArgs,
+	RunE: doStart,
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the progress of the last backfill",
+	Args:  cobra.NoArgs,
+	RunE:  doStatus,
+}
+
+var cancelCmd = &cobra.Command{
+	Use:   "cancel",
+	Short: "Cancel the running backfill, it can be resumed later",
+	Args:  cobra.NoArgs,
+	RunE:  doCancel,
+}
+
+func init() {
+	startCmd.Flags().Bool("resume", false, "Continue the last backfill if it was cancelled or failed")
+
+	Cmd.AddCommand(startCmd)
+	Cmd.AddCommand(statusCmd)
+	Cmd.AddCommand(cancelCmd)
+}
+
+func doStart(cmd *cobra.Command, _ []string) error {
+	resume, _ := cmd.Flags().GetBool("resume")
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	s, err := api.StartBackfill(cmd.Context(), resume)
+	if err != nil {
+		return fmt.Errorf("starting backfill: %w", err)
+	}
+	printStatus(cmd.OutOrStdout(), s)
+	return nil
+}
+
+func doStatus(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	s, err := api.GetBackfill(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("getting backfill: %w", err)
+	}
+	printStatus(cmd.OutOrStdout(), s)
+	return nil
+}
+
+func doCancel(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	s, err := api.CancelBackfill(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("cancelling backfill: %w", err)
+	}
+	printStatus(cmd.OutOrStdout(), s)
+	return nil
+}
+
+func printStatus(w io.Writer, s *httpapi.BackfillStatus) {
+	fmt.Fprintf(w, "State:       %s\n", s.State)
+	if s.StartedAt != nil {
+		fmt.Fprintf(w, "Started:     %s\n", s.StartedAt.Local().Format(time.RFC3339))
+	}
+	if s.FinishedAt != nil {
+		fmt.Fprintf(w, "Finished:    %s\n", s.FinishedAt.Local().Format(time.RFC3339))
+	}
+	if s.Error != "" {
+		fmt.Fprintf(w, "Error:       %s\n", s.Error)
+	}
+	fmt.Fprintf(w, "Examined:    %d\n", s.Examined)
+	fmt.Fprintf(w, "  Queued:      %d\n", s.Queued)
+	fmt.Fprintf(w, "  Registered:  %d\n", s.Registered)
+	fmt.Fprintf(w, "  Missing:     %d\n", s.Missing)
+	fmt.Fprintf(w, "  Too large:   %d\n", s.TooLarge)
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
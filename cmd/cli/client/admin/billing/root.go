@@ -0,0 +1,254 @@
+package billing
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "billing",
+	Short: "Manage rate plans and export invoices of space usage",
+}
+
+var plansCmd = &cobra.Command{
+	Use:   "plans",
+	Short: "List rate plans and the spaces assigned a plan",
+	Args:  cobra.NoArgs,
+	RunE:  doPlans,
+}
+
+var assignCmd = &cobra.Command{
+	Use:   "assign <space>",
+	Short: "Assign a rate plan to a space",
+	Long: `Assign a rate plan, and optionally a tenant, to a space.
+
+The plan prices all usage of the space in invoices generated after the
+assignment, including usage earlier in the month. Omitting the plan removes the
+assignment, so the space is billed at the default plan, or not at all without
+one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doAssign,
+}
+
+var invoiceCmd = &cobra.Command{
+	Use:   "invoice",
+	Short: "Show the invoice of a month",
+	Long: `Show the invoice of a calendar month.
+
+The invoice of the current month is not final, it prices the usage metered so
+far. Use --format csv to export the line items for a billing system.`,
+	Args: cobra.NoArgs,
+	RunE: doInvoice,
+}
+
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Push the invoice of a month to the billing webhook",
+	Long: `Push the invoice of a calendar month to the billing webhook.
+
+The invoice of a month is pushed automatically once the month has ended. Use
+this command to push it again, or to push the invoice of a month in progress.`,
+	Args: cobra.NoArgs,
+	RunE: doPush,
+}
+
+var (
+	plan         string
+	tenant       string
+	period       string
+	outputFormat string
+)
+
+func init() {
+	assignCmd.Flags().StringVar(&plan, "plan", "", "Rate plan to assign, empty to remove the assignment")
+	assignCmd.Flags().StringVar(&tenant, "tenant", "", "Tenant the space is billed to")
+
+	invoiceCmd.Flags().StringVar(&period, "period", "", "Month to invoice, as YYYY-MM (default current month in UTC)")
+	invoiceCmd.Flags().StringVar(&outputFormat, "format", "table", "Output format: table, json or csv")
+
+	pushCmd.Flags().StringVar(&period, "period", "", "Month to push, as YYYY-MM (default previous month in UTC)")
+
+	Cmd.AddCommand(plansCmd)
+	Cmd.AddCommand(assignCmd)
+	Cmd.AddCommand(invoiceCmd)
+	Cmd.AddCommand(pushCmd)
+}
+
+func doPlans(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.ListRatePlans(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("listing rate plans: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "PLAN\tSTORAGE (%s/GiB-month)\tEGRESS (%s/GiB)\n", res.Currency, res.Currency)
+	for _, p := range res.Plans {
+		name := p.Name
+		if name == res.Default {
+			name += " (default)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, formatFloat(p.StorageGiBMonth), formatFloat(p.EgressGiB))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(res.Spaces) == 0 {
+		return nil
+	}
+	fmt.Fprintln(cmd.OutOrStdout())
+	w = tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SPACE\tPLAN\tTENANT")
+	for _, s := range res.Spaces {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Space, s.Plan, orDash(s.Tenant))
+	}
+	return w.Flush()
+}
+
+func doAssign(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.SetSpaceRatePlan(cmd.Context(), args[0], plan, tenant)
+	if err != nil {
+		return fmt.Errorf("assigning rate plan: %w", err)
+	}
+
+	if res.Plan == "" {
+		cmd.Printf("Removed the rate plan of %s\n", res.Space)
+		return nil
+	}
+	if res.Tenant != "" {
+		cmd.Printf("Assigned rate plan %s to %s, billed to %s\n", res.Plan, res.Space, res.Tenant)
+		return nil
+	}
+	cmd.Printf("Assigned rate plan %s to %s\n", res.Plan, res.Space)
+	return nil
+}
+
+func doInvoice(cmd *cobra.Command, _ []string) error {
+	if outputFormat != "table" && outputFormat != "json" && outputFormat != "csv" {
+		return fmt.Errorf("unknown format: %s (use 'table', 'json' or 'csv')", outputFormat)
+	}
+	p := period
+	if p == "" {
+		p = time.Now().UTC().Format("2006-01")
+	}
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	if outputFormat == "csv" {
+		data, err := api.GetInvoiceCSV(cmd.Context(), p)
+		if err != nil {
+			return fmt.Errorf("getting invoice: %w", err)
+		}
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+
+	res, err := api.GetInvoice(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("getting invoice: %w", err)
+	}
+	if outputFormat == "json" {
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return fmt.Errorf("rendering invoice: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+	return printInvoice(cmd, res)
+}
+
+func doPush(cmd *cobra.Command, _ []string) error {
+	p := period
+	if p == "" {
+		now := time.Now().UTC()
+		p = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format("2006-01")
+	}
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.PushInvoice(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("pushing invoice: %w", err)
+	}
+	cmd.Printf("Pushed invoice of %s: %d line items, total %s %s\n", res.Period, len(res.Lines), formatFloat(res.Total), res.Currency)
+	return nil
+}
+
+func printInvoice(cmd *cobra.Command, inv *httpapi.Invoice) error {
+	out := cmd.OutOrStdout()
+	status := "in progress"
+	if inv.Final {
+		status = "final"
+	}
+	fmt.Fprintf(out, "Invoice %s (%s), generated %s\n", inv.Period, status, inv.GeneratedAt.Format(time.RFC3339))
+	if inv.PushedAt != nil {
+		fmt.Fprintf(out, "Pushed %s\n", inv.PushedAt.Format(time.RFC3339))
+	}
+	if len(inv.Lines) == 0 {
+		fmt.Fprintln(out, "No billable usage")
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TENANT\tSPACE\tPLAN\tRESOURCE\tQUANTITY\tUNIT PRICE\tAMOUNT")
+	for _, l := range inv.Lines {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s %s\t%s\t%s\n",
+			orDash(l.Tenant), l.Space, l.Plan, l.Resource, formatFloat(l.Quantity), l.Unit, formatFloat(l.UnitPrice), formatFloat(l.Amount))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\nTotal: %s %s\n", formatFloat(inv.Total), inv.Currency)
+	return nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,223 @@
+package blocklist
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "blocklist",
+	Short: "Manage the content refused by the node",
+	Long: `Manage the content refused by the node. Blocked blobs cannot be allocated,
+uploaded, replicated or retrieved: blob/allocate, blob/fetch and
+replica/allocate invocations fail with a ContentBlocked error, blob uploads
+and downloads with 451 Unavailable For Legal Reasons, and retrievals over UCAN
+and Bitswap as if the node did not hold the blob.
+
+Entries are CIDs, multibase encoded digests, or badbits double hashes
+prefixed with "//".`,
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the blocked content and the status of the remote denylist",
+	Args:  cobra.NoArgs,
+	RunE:  doList,
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add <entry>...",
+	Short: "Block content",
+	Long: `Block content by CID, digest or badbits double hash. Blobs the node already
+holds are kept unless --purge is given, which deletes the bytes of the blobs
+blocked by CID or digest. Purged blobs aggregated for PDP can no longer be
+proven.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: doAdd,
+}
+
+var removeCmd = &cobra.Command{
+	Use:   "remove <entry>...",
+	Short: "Unblock content",
+	Long: `Unblock content by CID, digest or badbits double hash. Entries synced from
+the remote denylist are blocked again by the next sync if they are still
+listed.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: doRemove,
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check <digest>",
+	Short: "Show whether a blob is blocked",
+	Args:  cobra.ExactArgs(1),
+	RunE:  doCheck,
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync the remote denylist now",
+	Args:  cobra.NoArgs,
+	RunE:  doSync,
+}
+
+func init() {
+	listCmd.Flags().String("source", "", `Only list entries from the source, "admin" or the URL of the denylist`)
+	listCmd.Flags().Int("limit", 0, "Maximum number of entries to list (0 for all)")
+	addCmd.Flags().String("reason", "", "Reason the content is blocked, e.g. a takedown notice")
+	addCmd.Flags().Bool("purge", false, "Delete the bytes of the blocked blobs held by the node")
+
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(addCmd)
+	Cmd.AddCommand(removeCmd)
+	Cmd.AddCommand(checkCmd)
+	Cmd.AddCommand(syncCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	source, _ := cmd.Flags().GetString("source")
+	limit, _ := cmd.Flags().GetInt("limit")
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.ListBlocklist(cmd.Context(), source, limit)
+	if err != nil {
+		return fmt.Errorf("listing blocklist: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if res.Sync != nil {
+		printSyncStatus(out, res.Sync)
+		fmt.Fprintln(out)
+	}
+	if len(res.Entries) == 0 {
+		fmt.Fprintln(out, "no blocked content")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENTRY\tSOURCE\tADDED\tREASON")
+	for _, e := range res.Entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Key, e.Source, e.AddedAt.Local().Format(time.RFC3339), e.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(res.Entries) < res.Total {
+		fmt.Fprintf(out, "\n%d of %d entries listed\n", len(res.Entries), res.Total)
+	}
+	return nil
+}
+
+func doAdd(cmd *cobra.Command, args []string) error {
+	reason, _ := cmd.Flags().GetString("reason")
+	purge, _ := cmd.Flags().GetBool("purge")
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.AddBlocklist(cmd.Context(), httpapi.AddBlocklistRequest{Entries: args, Reason: reason, Purge: purge})
+	if err != nil {
+		return fmt.Errorf("adding to blocklist: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "blocked %d of %d entries, the others were already blocked\n", res.Added, len(args))
+	for _, digest := range res.Purged {
+		fmt.Fprintf(out, "purged %s\n", digest)
+	}
+	return nil
+}
+
+func doRemove(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	removed, err := api.RemoveBlocklist(cmd.Context(), args)
+	if err != nil {
+		return fmt.Errorf("removing from blocklist: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if len(removed) == 0 {
+		fmt.Fprintln(out, "no entries were blocked")
+		return nil
+	}
+	for _, e := range removed {
+		fmt.Fprintf(out, "unblocked %s (%s)\n", e.Key, e.Source)
+	}
+	return nil
+}
+
+func doCheck(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.CheckBlocklist(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("checking blocklist: %w", err)
+	}
+
+	if res.Blocked {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s is blocked\n", res.Digest)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s is not blocked\n", res.Digest)
+	}
+	return nil
+}
+
+func doSync(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	s, err := api.SyncBlocklist(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("syncing blocklist: %w", err)
+	}
+	printSyncStatus(cmd.OutOrStdout(), s)
+	return nil
+}
+
+func printSyncStatus(out io.Writer, s *httpapi.BlocklistSyncStatus) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Denylist:\t%s\n", s.URL)
+	if s.LastSync != nil {
+		fmt.Fprintf(w, "Last sync:\t%s\n", s.LastSync.Local().Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(w, "Last sync:\tnever\n")
+	}
+	if s.LastError != "" {
+		fmt.Fprintf(w, "Last error:\t%s\n", s.LastError)
+	}
+	fmt.Fprintf(w, "Entries:\t%d (%d skipped)\n", s.Entries, s.Skipped)
+	fmt.Fprintf(w, "Last changes:\t%d added, %d removed\n", s.Added, s.Removed)
+	w.Flush()
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
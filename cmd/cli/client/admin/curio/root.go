@@ -0,0 +1,79 @@
+package curio
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "curio",
+	Short: "Manage the pieces streamed to Curio for proof sets it proves",
+}
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Compare the pieces uploaded to Curio with the pieces it holds",
+	Long: `Compare the pieces the node uploaded to Curio with the pieces Curio holds,
+and fix the divergences:
+
+  missing     recorded as parked, but not held by Curio: uploaded again
+  unnotified  held by Curio, but its notification never arrived: recorded as parked
+  stalled     not parked within the upload timeout: uploaded again
+  orphaned    no longer held by the node: its upload record is removed
+
+With --dry-run, divergences are only reported.`,
+	Args: cobra.NoArgs,
+	RunE: doReconcile,
+}
+
+func init() {
+	reconcileCmd.Flags().Bool("dry-run", false, "Only report divergences, without fixing them")
+
+	Cmd.AddCommand(reconcileCmd)
+}
+
+func doReconcile(cmd *cobra.Command, _ []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.ReconcileCurio(cmd.Context(), dryRun)
+	if err != nil {
+		return fmt.Errorf("reconciling curio pieces: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "checked %d pieces, %d divergent\n", res.Checked, len(res.Divergences))
+	if len(res.Divergences) == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PIECE\tKIND\tRESOLVED\tERROR")
+	for _, d := range res.Divergences {
+		errMsg := "-"
+		if d.Error != "" {
+			errMsg = d.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", d.PieceCID, d.Kind, d.Resolved, errMsg)
+	}
+	return w.Flush()
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,53 @@
+package dashboard
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "dashboard",
+	Short: "Print the URL of the operator dashboard",
+	Long: `Print the URL of the operator dashboard served by the admin API.
+
+The dashboard shows the node's identity, storage usage, proof sets, proving
+deadlines, payment rails and recent errors. The URL carries an admin token,
+anyone with the URL can use the admin API of the node, so do not share it.`,
+	Args: cobra.NoArgs,
+	RunE: doDashboard,
+}
+
+func doDashboard(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	if _, err := api.GetOverview(cmd.Context()); err != nil {
+		return fmt.Errorf("checking the admin API is reachable: %w", err)
+	}
+
+	u, err := api.DashboardURL()
+	if err != nil {
+		return fmt.Errorf("creating dashboard URL: %w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), u)
+	return nil
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
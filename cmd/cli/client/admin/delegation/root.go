@@ -0,0 +1,245 @@
+package delegation
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "delegation",
+	Short: "Manage delegations granted to the node",
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import delegations granted to the node",
+	Long: `Import delegations granted to the node.
+
+The file holds one delegation per line, either formatted as by
+'piri delegate generate' or as a JSON object written by
+'piri delegate issue-batch', which may be encrypted to the node.
+All delegations must have the node as their audience.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doImport,
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the delegations held by the node",
+	Long: `List the delegations held by the node.
+
+Delegations from the node's configuration, such as the indexing service proof,
+are listed first, followed by imported delegations, soonest to expire first.
+Delegations that have expired or expire within a week are flagged.`,
+	Args: cobra.NoArgs,
+	RunE: doList,
+}
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <cid>",
+	Short: "Show the abilities, audience and expiry of a delegation",
+	Args:  cobra.ExactArgs(1),
+	RunE:  doInspect,
+}
+
+var removeCmd = &cobra.Command{
+	Use:   "remove <cid>",
+	Short: "Remove an imported delegation",
+	Long: `Remove an imported delegation.
+
+Delegations from the node's configuration cannot be removed here, remove them
+from the configuration instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doRemove,
+}
+
+func init() {
+	Cmd.AddCommand(importCmd)
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(inspectCmd)
+	Cmd.AddCommand(removeCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	dlgs, err := api.ListDelegations(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("listing delegations: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CID\tSOURCE\tISSUER\tABILITIES\tEXPIRES\tSTATUS")
+	for _, d := range dlgs {
+		can := make([]string, 0, len(d.Capabilities))
+		for _, c := range d.Capabilities {
+			can = append(can, c.Can)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.CID, d.Source, d.Issuer, strings.Join(can, ","), expires(d), status(d))
+	}
+	return w.Flush()
+}
+
+func doInspect(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	d, err := api.GetDelegation(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("getting delegation: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "CID:\t%s\n", d.CID)
+	fmt.Fprintf(w, "Source:\t%s\n", d.Source)
+	fmt.Fprintf(w, "Issuer:\t%s\n", d.Issuer)
+	fmt.Fprintf(w, "Audience:\t%s\n", d.Audience)
+	for i, c := range d.Capabilities {
+		label := ""
+		if i == 0 {
+			label = "Capabilities:"
+		}
+		fmt.Fprintf(w, "%s\t%s with %s\n", label, c.Can, c.With)
+	}
+	if d.NotBefore != nil {
+		fmt.Fprintf(w, "Not before:\t%s\n", d.NotBefore.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Expires:\t%s\n", expires(*d))
+	fmt.Fprintf(w, "Status:\t%s\n", status(*d))
+	for i, p := range d.Proofs {
+		label := ""
+		if i == 0 {
+			label = "Proofs:"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", label, p)
+	}
+	return w.Flush()
+}
+
+func doRemove(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	if err := api.RemoveDelegation(cmd.Context(), args[0]); err != nil {
+		return fmt.Errorf("removing delegation: %w", err)
+	}
+	cmd.Printf("Removed delegation %s\n", args[0])
+	return nil
+}
+
+func expires(d httpapi.Delegation) string {
+	if d.Expiration == nil {
+		return "never"
+	}
+	return d.Expiration.Format(time.RFC3339)
+}
+
+func status(d httpapi.Delegation) string {
+	switch {
+	case d.Expired:
+		return "expired"
+	case d.Expiring:
+		return fmt.Sprintf("expires in %s", time.Until(*d.Expiration).Round(time.Hour))
+	default:
+		return "valid"
+	}
+}
+
+func doImport(cmd *cobra.Command, args []string) error {
+	req, err := readDelegations(args[0])
+	if err != nil {
+		return err
+	}
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.ImportDelegations(cmd.Context(), req)
+	if err != nil {
+		return fmt.Errorf("importing delegations: %w", err)
+	}
+	for _, c := range res.Imported {
+		fmt.Fprintln(cmd.OutOrStdout(), c)
+	}
+	cmd.PrintErrf("imported %d delegations\n", len(res.Imported))
+	return nil
+}
+
+func readDelegations(path string) (httpapi.ImportDelegationsRequest, error) {
+	var req httpapi.ImportDelegationsRequest
+	f, err := os.Open(path)
+	if err != nil {
+		return req, fmt.Errorf("opening delegations file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// delegations with long proof chains exceed the default line limit
+	scanner.Buffer(nil, 4<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "{") {
+			req.Delegations = append(req.Delegations, line)
+			continue
+		}
+		var issued struct {
+			Delegation string `json:"delegation"`
+			Sealed     string `json:"sealed"`
+		}
+		if err := json.Unmarshal([]byte(line), &issued); err != nil {
+			return req, fmt.Errorf("line %d: decoding JSON: %w", n, err)
+		}
+		switch {
+		case issued.Sealed != "":
+			req.Sealed = append(req.Sealed, issued.Sealed)
+		case issued.Delegation != "":
+			req.Delegations = append(req.Delegations, issued.Delegation)
+		default:
+			return req, fmt.Errorf("line %d: no delegation found", n)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return req, fmt.Errorf("reading delegations file: %w", err)
+	}
+	if len(req.Delegations)+len(req.Sealed) == 0 {
+		return req, fmt.Errorf("no delegations found in %s", path)
+	}
+	return req, nil
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
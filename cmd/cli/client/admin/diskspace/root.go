@@ -0,0 +1,72 @@
+package diskspace
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "disk-space",
+	Short: "Show the disk usage of the node and whether allocations are rejected",
+	Long: `Show the disk usage of the data and temp directories at the last check.
+
+Allocations are rejected with an InsufficientDiskSpace error once the usage of
+a disk is above the high watermark, until the usage of every disk is below
+the low watermark.`,
+	Args: cobra.NoArgs,
+	RunE: doDiskSpace,
+}
+
+func doDiskSpace(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	s, err := api.GetDiskSpace(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("getting disk space: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if s.Pressure {
+		since := "-"
+		if s.Since != nil {
+			since = s.Since.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(out, "allocations rejected since %s, until usage is below %g%%\n", since, s.LowWatermark)
+	} else {
+		fmt.Fprintf(out, "allocations accepted, rejected above %g%%\n", s.HighWatermark)
+	}
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VOLUME\tPATH\tUSED\tTOTAL\tUSED %")
+	for _, u := range s.Volumes {
+		if u.Error != "" {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\terror: %s\n", u.Name, u.Path, u.Error)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.1f\n", u.Name, u.Path, u.Used, u.Total, u.UsedPercent)
+	}
+	return w.Flush()
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
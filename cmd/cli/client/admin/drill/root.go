@@ -0,0 +1,128 @@
+package drill
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "drill",
+	Short: "Simulate incidents to understand their impact",
+}
+
+var missedProofCmd = &cobra.Command{
+	Use:   "missed-proof",
+	Short: "Simulate missing the next proving deadline of a data set",
+	Long: `Simulate missing the next proving deadline of a data set.
+
+Reports the fault record the service contract would emit, the payment the
+validator would withhold, and the projected settlement with and without the
+proof. The simulation only reads chain state, no transactions are sent.`,
+	Args: cobra.NoArgs,
+	RunE: doMissedProof,
+}
+
+var (
+	dataSetID    uint64
+	dryRun       bool
+	outputFormat string
+)
+
+func init() {
+	Cmd.AddCommand(missedProofCmd)
+
+	missedProofCmd.Flags().Uint64Var(&dataSetID, "dataset", 0, "ID of the data set to simulate a missed proof for")
+	cobra.CheckErr(missedProofCmd.MarkFlagRequired("dataset"))
+	missedProofCmd.Flags().BoolVar(&dryRun, "dry-run", true, "Simulate without sending transactions (the only supported mode)")
+	missedProofCmd.Flags().StringVar(&outputFormat, "format", "table", "Output format: table or json")
+}
+
+func doMissedProof(cmd *cobra.Command, _ []string) error {
+	if !dryRun {
+		return fmt.Errorf("drills never send transactions, only --dry-run is supported")
+	}
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.DrillMissedProof(cmd.Context(), dataSetID)
+	if err != nil {
+		return fmt.Errorf("simulating missed proof: %w", err)
+	}
+
+	switch outputFormat {
+	case "json":
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return fmt.Errorf("rendering drill result: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	case "table":
+		return printMissedProof(cmd, res)
+	default:
+		return fmt.Errorf("unknown format: %s (use 'table' or 'json')", outputFormat)
+	}
+}
+
+func printMissedProof(cmd *cobra.Command, res *httpapi.DrillMissedProofResponse) error {
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "DRY RUN: simulated missed proof for data set %s, no transactions were sent\n\n", res.DataSetID)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Current epoch\t%s\n", res.CurrentEpoch)
+	fmt.Fprintf(w, "Challenge window start\t%s\n", res.ChallengeWindowStart)
+	fmt.Fprintf(w, "Proving deadline\t%s\n", res.Deadline)
+	fmt.Fprintf(w, "Proving period\t%s epochs\n", res.ProvingPeriod)
+	fmt.Fprintln(w, "\t")
+	fmt.Fprintf(w, "Projected fault record\tFaultRecord(dataSetId=%s, periodsFaulted=%s, deadline=%s)\n",
+		res.Fault.DataSetID, res.Fault.PeriodsFaulted, res.Fault.Deadline)
+	fmt.Fprintln(w, "\t")
+	fmt.Fprintf(w, "Rail\t%s\n", res.RailID)
+	fmt.Fprintf(w, "Payment rate\t%s / epoch\n", usdfc(res.PaymentRate))
+	fmt.Fprintf(w, "Validator penalty\t%s (%s epochs unpaid)\n", usdfc(res.PenaltyAmount), res.PenaltyEpochs)
+	fmt.Fprintf(w, "Gross payment to deadline\t%s\n", usdfc(res.GrossAmount))
+	fmt.Fprintf(w, "Net settlement if proven\t%s\n", usdfc(res.NetAmountIfProven))
+	fmt.Fprintf(w, "Net settlement if missed\t%s\n", usdfc(res.NetAmountIfMissed))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, n := range res.Notes {
+		fmt.Fprintf(out, "\nNote: %s\n", n)
+	}
+	return nil
+}
+
+// usdfc formats an amount in token base units (18 decimals) as dollars.
+func usdfc(amount string) string {
+	v, ok := new(big.Float).SetString(amount)
+	if !ok {
+		return amount
+	}
+	v.Quo(v, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)))
+	return "$" + v.Text('f', 6)
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "events",
+	Short: "Inspect the contract events recorded for the node's data sets",
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded contract events, most recent first",
+	Long: `List recorded contract events, most recent first.
+
+The node indexes the FaultRecord, PiecesAdded, NextProvingPeriod and
+PossessionProven events of its data sets once they are 6 epochs deep. Use
+--before with the block of the last event listed to page through older events.`,
+	Args: cobra.NoArgs,
+	RunE: doList,
+}
+
+func init() {
+	listCmd.Flags().Uint64("dataset", 0, "Only list events of this data set")
+	listCmd.Flags().StringSlice("name", nil, "Only list events with these names, e.g. FaultRecord")
+	listCmd.Flags().Int64("before", 0, "Only list events from blocks before this one")
+	listCmd.Flags().Int("limit", 50, "Maximum number of events to list")
+	Cmd.AddCommand(listCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	var q client.ChainEventsQuery
+	if cmd.Flags().Changed("dataset") {
+		id, _ := cmd.Flags().GetUint64("dataset")
+		q.DataSetID = &id
+	}
+	q.Names, _ = cmd.Flags().GetStringSlice("name")
+	q.Before, _ = cmd.Flags().GetInt64("before")
+	q.Limit, _ = cmd.Flags().GetInt("limit")
+
+	res, err := api.ListChainEvents(cmd.Context(), q)
+	if err != nil {
+		return fmt.Errorf("listing events: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Indexed to block %d\n", res.IndexedTo)
+	if len(res.Events) == 0 {
+		fmt.Fprintln(out, "no events found")
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BLOCK\tDATA SET\tEVENT\tARGS\tTX")
+	for _, e := range res.Events {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n", e.BlockNumber, e.DataSetID, e.Name, e.Args, e.TxHash)
+	}
+	return w.Flush()
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
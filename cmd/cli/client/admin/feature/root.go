@@ -0,0 +1,121 @@
+package feature
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "feature",
+	Short: "List and toggle feature flags",
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List feature flags and whether they are active on the node",
+	Args:  cobra.NoArgs,
+	RunE:  doList,
+}
+
+var setCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Enable or disable a feature flag, or change its rollout",
+	Args:  cobra.ExactArgs(1),
+	RunE:  doSet,
+}
+
+func init() {
+	setCmd.Flags().Bool("enable", false, "Enable the feature")
+	setCmd.Flags().Bool("disable", false, "Disable the feature")
+	setCmd.Flags().Uint("rollout", 0, "Percentage (0-100) of nodes the feature is enabled on")
+	setCmd.Flags().Bool("persist", false, "Write the change to the config file so it survives restarts")
+	setCmd.MarkFlagsMutuallyExclusive("enable", "disable")
+
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(setCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	flags, err := api.ListFeatureFlags(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("listing feature flags: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tENABLED\tROLLOUT\tACTIVE\tDESCRIPTION")
+	for _, f := range flags {
+		fmt.Fprintf(w, "%s\t%t\t%s\t%t\t%s\n", f.Name, f.Enabled, rollout(f), f.Active, f.Description)
+	}
+	return w.Flush()
+}
+
+func doSet(cmd *cobra.Command, args []string) error {
+	var req httpapi.SetFeatureFlagRequest
+	if cmd.Flags().Changed("enable") || cmd.Flags().Changed("disable") {
+		enable, _ := cmd.Flags().GetBool("enable")
+		disable, _ := cmd.Flags().GetBool("disable")
+		enabled := enable && !disable
+		req.Enabled = &enabled
+	}
+	if cmd.Flags().Changed("rollout") {
+		r, _ := cmd.Flags().GetUint("rollout")
+		if r > 100 {
+			return fmt.Errorf("rollout must be between 0 and 100")
+		}
+		req.Rollout = &r
+	}
+	if req.Enabled == nil && req.Rollout == nil {
+		return errors.New("one of --enable, --disable or --rollout is required")
+	}
+	req.Persist, _ = cmd.Flags().GetBool("persist")
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	f, err := api.SetFeatureFlag(cmd.Context(), args[0], req)
+	if err != nil {
+		return fmt.Errorf("setting feature flag: %w", err)
+	}
+
+	state := "inactive"
+	if f.Active {
+		state = "active"
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "feature %s enabled=%t rollout=%s (%s on this node)\n", f.Name, f.Enabled, rollout(*f), state)
+	return nil
+}
+
+func rollout(f httpapi.FeatureFlag) string {
+	if f.Rollout == nil {
+		return "-"
+	}
+	return strconv.FormatUint(uint64(*f.Rollout), 10) + "%"
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,75 @@
+package gas
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "gas",
+	Short: "Inspect the chain base fee history",
+}
+
+var estimatesCmd = &cobra.Command{
+	Use:   "estimates",
+	Short: "Show base fee percentiles of the recent chain history",
+	Long: `Show base fee percentiles of the recent chain history.
+
+The node records the base fee of every tipset. Messages of the categories
+configured in pdp.gas.wait are deferred while the latest base fee is above
+the configured percentile of this history.`,
+	Args: cobra.NoArgs,
+	RunE: doEstimates,
+}
+
+func init() {
+	Cmd.AddCommand(estimatesCmd)
+}
+
+func doEstimates(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.GetGasEstimates(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("getting gas estimates: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Samples: %d (window %s)\n", res.Samples, res.Window)
+	if res.LatestBaseFee != "" {
+		fmt.Fprintf(out, "Latest:  %s attoFIL (epoch %d)\n", res.LatestBaseFee, res.LatestEpoch)
+	}
+	if len(res.Percentiles) == 0 {
+		fmt.Fprintln(out, "not enough history for estimates yet")
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PERCENTILE\tBASE FEE (attoFIL)")
+	for _, p := range res.Percentiles {
+		fmt.Fprintf(w, "p%d\t%s\n", p.Percentile, p.BaseFee)
+	}
+	return w.Flush()
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
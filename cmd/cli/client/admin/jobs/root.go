@@ -0,0 +1,184 @@
+package jobs
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Inspect, retry and cancel the jobs of the node's job queues",
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List queued or dead-lettered jobs, oldest first",
+	Args:  cobra.NoArgs,
+	RunE:  doList,
+}
+
+var getCmd = &cobra.Command{
+	Use:   "get <queue> <id>",
+	Short: "Show a queued, running or dead-lettered job",
+	Args:  cobra.ExactArgs(2),
+	RunE:  doGet,
+}
+
+var retryCmd = &cobra.Command{
+	Use:   "retry <queue> <id>",
+	Short: "Move a dead-lettered job back to its queue to run again",
+	Args:  cobra.ExactArgs(2),
+	RunE:  doRetry,
+}
+
+var cancelCmd = &cobra.Command{
+	Use:   "cancel <queue> <id>",
+	Short: "Remove a queued or dead-lettered job",
+	Long: `Remove a queued or dead-lettered job.
+
+A cancelled job that is running completes, but it is not retried if it fails.`,
+	Args: cobra.ExactArgs(2),
+	RunE: doCancel,
+}
+
+func init() {
+	listCmd.Flags().String("queue", "", "Only list jobs of the named queue")
+	listCmd.Flags().Bool("dead", false, "List dead-lettered jobs instead of queued jobs")
+	listCmd.Flags().Int("limit", 0, "Maximum number of jobs listed per queue (default 100)")
+
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(getCmd)
+	Cmd.AddCommand(retryCmd)
+	Cmd.AddCommand(cancelCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	queueName, _ := cmd.Flags().GetString("queue")
+	dead, _ := cmd.Flags().GetBool("dead")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	jobs, err := api.ListJobs(cmd.Context(), queueName, dead, limit)
+	if err != nil {
+		return fmt.Errorf("listing jobs: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	if dead {
+		fmt.Fprintln(w, "QUEUE\tID\tNAME\tATTEMPTS\tMOVED AT\tREASON\tERROR")
+		for _, j := range jobs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n", j.Queue, j.ID, j.Name, j.Attempts, formatTime(j.MovedAt), j.FailureReason, j.Error)
+		}
+	} else {
+		fmt.Fprintln(w, "QUEUE\tID\tNAME\tSTATE\tATTEMPTS\tCREATED\tNEXT RUN")
+		for _, j := range jobs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", j.Queue, j.ID, j.Name, j.State, j.Attempts, formatTime(j.Created), nextRun(j))
+		}
+	}
+	return w.Flush()
+}
+
+func doGet(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	j, err := api.GetJob(cmd.Context(), args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("getting job: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Queue:\t%s\n", j.Queue)
+	fmt.Fprintf(w, "ID:\t%s\n", j.ID)
+	fmt.Fprintf(w, "Name:\t%s\n", j.Name)
+	fmt.Fprintf(w, "State:\t%s\n", j.State)
+	fmt.Fprintf(w, "Attempts:\t%d\n", j.Attempts)
+	fmt.Fprintf(w, "Created:\t%s\n", formatTime(j.Created))
+	if j.Dead {
+		fmt.Fprintf(w, "Moved at:\t%s\n", formatTime(j.MovedAt))
+		fmt.Fprintf(w, "Reason:\t%s\n", j.FailureReason)
+	} else {
+		fmt.Fprintf(w, "Next run:\t%s\n", nextRun(*j))
+	}
+	if j.Error != "" {
+		fmt.Fprintf(w, "Error:\t%s\n", j.Error)
+	}
+	return w.Flush()
+}
+
+func doRetry(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	j, err := api.RetryJob(cmd.Context(), args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("retrying job: %w", err)
+	}
+
+	if j.Dead {
+		fmt.Fprintf(cmd.OutOrStdout(), "job %s (%s) was already queued again, dead job discarded\n", j.ID, j.Name)
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "job %s (%s) queued to run again\n", j.ID, j.Name)
+	return nil
+}
+
+func doCancel(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	j, err := api.CancelJob(cmd.Context(), args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("cancelling job: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "job %s (%s) cancelled\n", j.ID, j.Name)
+	return nil
+}
+
+func nextRun(j httpapi.Job) string {
+	if j.State == httpapi.JobRunning {
+		return "-"
+	}
+	if j.AvailableAt == nil || !j.AvailableAt.After(time.Now()) {
+		return "now"
+	}
+	return formatTime(j.AvailableAt)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
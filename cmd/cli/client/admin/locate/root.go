@@ -0,0 +1,170 @@
+package locate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/ipfs/go-cid"
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "locate <digest>",
+	Short: "Show where a blob is in the proof system",
+	Long: `Show where a blob is in the proof system.
+
+Resolves the blob to its piece CID, the aggregates containing the piece with
+the inclusion proof of the piece in each, and the data sets the aggregates were
+added to with their piece ID on chain. Each location has a status:
+
+  live         the aggregate is active in the data set on chain
+  removed      the aggregate was added to the data set but is no longer active
+  pending      the addition of the aggregate awaits confirmation
+  unsubmitted  the aggregate has not been submitted to a data set yet
+
+With --aggregate the argument is the root CID of an aggregate built by the
+node, and its pieces and data sets are shown instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doLocate,
+}
+
+var (
+	aggregate    bool
+	outputFormat string
+)
+
+func init() {
+	Cmd.Flags().BoolVar(&aggregate, "aggregate", false, "Show the aggregate with the given root CID")
+	Cmd.Flags().StringVar(&outputFormat, "format", "table", "Output format: table or json")
+}
+
+func doLocate(cmd *cobra.Command, args []string) error {
+	if outputFormat != "table" && outputFormat != "json" {
+		return fmt.Errorf("unknown format: %s (use 'table' or 'json')", outputFormat)
+	}
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	var res any
+	if aggregate {
+		root, err := cid.Decode(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid aggregate root CID: %w", err)
+		}
+		res, err = api.GetAggregate(cmd.Context(), root)
+		if err != nil {
+			return fmt.Errorf("getting aggregate: %w", err)
+		}
+	} else {
+		digest, err := digestutil.Parse(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid digest: %w", err)
+		}
+		res, err = api.LocateBlob(cmd.Context(), digest)
+		if err != nil {
+			return fmt.Errorf("locating blob: %w", err)
+		}
+	}
+
+	if outputFormat == "json" {
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return fmt.Errorf("rendering result: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+	switch r := res.(type) {
+	case *httpapi.AggregateResponse:
+		return printAggregate(cmd, r)
+	case *httpapi.LocateBlobResponse:
+		return printLocations(cmd, r)
+	}
+	return nil
+}
+
+func printLocations(cmd *cobra.Command, res *httpapi.LocateBlobResponse) error {
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Blob   %s\n", res.Digest)
+	fmt.Fprintf(out, "Piece  %s\n\n", res.PieceCID)
+	if len(res.Locations) == 0 {
+		fmt.Fprintln(out, "the piece has not been aggregated")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tAGGREGATE\tDATA SET\tPIECE ID\tOFFSET\tPROOF INDEX")
+	for _, loc := range res.Locations {
+		dataSet, pieceID, offset, index := "-", "-", "-", "-"
+		if loc.DataSetID != 0 {
+			dataSet = strconv.FormatUint(loc.DataSetID, 10)
+		}
+		if loc.PieceID != nil {
+			pieceID = strconv.FormatUint(*loc.PieceID, 10)
+		}
+		if loc.Offset != nil {
+			offset = strconv.FormatInt(*loc.Offset, 10)
+		}
+		if loc.InclusionProof != nil {
+			index = strconv.FormatUint(loc.InclusionProof.Index, 10)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", loc.Status, loc.Aggregate, dataSet, pieceID, offset, index)
+	}
+	return w.Flush()
+}
+
+func printAggregate(cmd *cobra.Command, res *httpapi.AggregateResponse) error {
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Aggregate  %s\n\n", res.Root)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tDATA SET\tPIECE ID")
+	for _, ds := range res.DataSets {
+		pieceID := "-"
+		if ds.PieceID != nil {
+			pieceID = strconv.FormatUint(*ds.PieceID, 10)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\n", ds.Status, ds.DataSetID, pieceID)
+	}
+	if len(res.DataSets) == 0 {
+		fmt.Fprintf(w, "%s\t-\t-\n", httpapi.LocationUnsubmitted)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tPIECE\tBLOB\tPROOF INDEX")
+	for i, p := range res.Pieces {
+		digest := p.Digest
+		if digest == "" {
+			digest = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\n", i, p.PieceCID, digest, p.InclusionProof.Index)
+	}
+	return w.Flush()
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
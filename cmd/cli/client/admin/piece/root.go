@@ -0,0 +1,196 @@
+package piece
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "piece",
+	Short: "Inspect the lifecycle of the pieces stored on the node",
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status <digest>",
+	Short: "Show the lifecycle of a blob and the time its upload spent in each stage",
+	Long: `Show the lifecycle of a blob and the time its upload spent in each stage.
+
+The lifecycle lists the events recorded for the blob: allocated, uploaded,
+aggregated, submitted, proven, faulted and removed.
+
+Upload stages are allocate, presign, upload, accept, claim_publish and
+aggregate_enqueue. Accept includes claim publication and the aggregate
+enqueue. Breakdowns are kept in memory for an hour after the last stage.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doStatus,
+}
+
+var statesCmd = &cobra.Command{
+	Use:   "states",
+	Short: "Show the number of pieces in each lifecycle state",
+	Args:  cobra.NoArgs,
+	RunE:  doStates,
+}
+
+var stuckCmd = &cobra.Command{
+	Use:   "stuck",
+	Short: "List pieces that have not progressed through the pipeline",
+	Long: `List pieces that have not progressed through the pipeline.
+
+A piece is stuck if it has been in a state other than proven or removed for
+longer than --older-than: uploads never completed, pieces never aggregated or
+submitted, and pieces of faulted aggregates. The oldest are listed first.`,
+	Args: cobra.NoArgs,
+	RunE: doStuck,
+}
+
+func init() {
+	stuckCmd.Flags().Duration("older-than", 24*time.Hour, "How long a piece must have been in its state")
+
+	Cmd.AddCommand(statusCmd)
+	Cmd.AddCommand(statesCmd)
+	Cmd.AddCommand(stuckCmd)
+}
+
+func doStatus(cmd *cobra.Command, args []string) error {
+	digest, err := digestutil.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid digest: %w", err)
+	}
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.GetPieceStatus(cmd.Context(), digest)
+	if err != nil {
+		return fmt.Errorf("getting piece status: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if res.Lifecycle == nil {
+		fmt.Fprintf(out, "no lifecycle events for %s\n", res.Digest)
+	} else {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "EVENT\tAT\tDETAILS")
+		for _, ev := range res.Lifecycle.Events {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", ev.Kind, ev.At.Format(time.RFC3339), eventDetails(ev))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(out)
+
+	if res.Latency == nil {
+		fmt.Fprintf(out, "no recent upload of %s\n", res.Digest)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tSTARTED\tDURATION\tERROR")
+	for _, s := range res.Latency.Stages {
+		errMsg := s.Error
+		if errMsg == "" {
+			errMsg = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Stage, s.StartedAt.Format(time.RFC3339Nano), s.Duration, errMsg)
+	}
+	fmt.Fprintf(w, "TOTAL\t\t%s\t\n", res.Latency.Total)
+	return w.Flush()
+}
+
+func doStates(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.GetPieceStates(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("getting piece states: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATE\tPIECES")
+	for _, state := range []string{"allocated", "uploaded", "aggregated", "submitted", "proven", "faulted", "removed"} {
+		fmt.Fprintf(w, "%s\t%d\n", state, res.Counts[state])
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\n", res.Total)
+	return w.Flush()
+}
+
+func doStuck(cmd *cobra.Command, _ []string) error {
+	olderThan, _ := cmd.Flags().GetDuration("older-than")
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	pieces, err := api.ListStuckPieces(cmd.Context(), olderThan)
+	if err != nil {
+		return fmt.Errorf("listing stuck pieces: %w", err)
+	}
+	if len(pieces) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "no pieces stuck for more than %s\n", olderThan)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DIGEST\tSTATE\tSINCE\tAGGREGATE\tERROR")
+	for _, p := range pieces {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.Digest, p.State, p.Since.Format(time.RFC3339), orDash(p.Aggregate), orDash(p.Error))
+	}
+	return w.Flush()
+}
+
+func eventDetails(ev httpapi.PieceEvent) string {
+	var details []string
+	if ev.Space != "" {
+		details = append(details, "space="+ev.Space)
+	}
+	if ev.Piece != "" {
+		details = append(details, "piece="+ev.Piece)
+	}
+	if ev.Aggregate != "" {
+		details = append(details, "aggregate="+ev.Aggregate)
+	}
+	if ev.ProofSet != 0 {
+		details = append(details, fmt.Sprintf("proof_set=%d", ev.ProofSet))
+	}
+	if ev.Error != "" {
+		details = append(details, fmt.Sprintf("error=%q", ev.Error))
+	}
+	return orDash(strings.Join(details, " "))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,114 @@
+package proofs
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "proofs",
+	Short: "Show the proving history of the node's data sets and when proofs are due",
+	Long: `Show the proving history of the node's data sets and when their next proofs
+are due.
+
+For each data set, the last epoch a proof was accepted, the epochs the next
+challenge window opens and closes, and the time left until then are shown. The
+time is estimated from the timestamp of the chain head. Recent proofs are
+listed with the gas they spent, once their PossessionProven event is indexed
+6 epochs deep.`,
+	Args: cobra.NoArgs,
+	RunE: doProofs,
+}
+
+func init() {
+	Cmd.Flags().Int("limit", 3, "Maximum number of recent proofs to list per data set")
+}
+
+func doProofs(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	limit, _ := cmd.Flags().GetInt("limit")
+	res, err := api.ListProofs(cmd.Context(), limit)
+	if err != nil {
+		return fmt.Errorf("listing proofs: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Current epoch %d\n", res.CurrentEpoch)
+	for _, n := range res.Notes {
+		fmt.Fprintf(out, "Note: %s\n", n)
+	}
+	if len(res.DataSets) == 0 {
+		fmt.Fprintln(out, "no data sets found")
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATA SET\tLAST PROVEN\tWINDOW OPENS\tDEADLINE\tDUE IN")
+	for _, ds := range res.DataSets {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", ds.DataSetID, epoch(ds.LastProvenEpoch), epoch(ds.NextChallengeWindowStart), epoch(ds.ProvingDeadline), dueIn(ds))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATA SET\tBLOCK\tCHALLENGES\tGAS USED\tGAS COST\tTX")
+	for _, ds := range res.DataSets {
+		for _, p := range ds.RecentProofs {
+			gasCost := p.GasCost
+			if gasCost == "" {
+				gasCost = "-"
+			}
+			fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%s\t%s\n", ds.DataSetID, p.BlockNumber, p.Challenges, p.GasUsed, gasCost, p.TxHash)
+		}
+	}
+	return w.Flush()
+}
+
+func epoch(e int64) string {
+	if e == 0 {
+		return "-"
+	}
+	return fmt.Sprint(e)
+}
+
+// dueIn is the time left until the deadline of the data set, or until its
+// challenge window opens if it is not yet open.
+func dueIn(ds httpapi.DataSetProofs) string {
+	switch {
+	case ds.UntilChallenge == nil || ds.UntilDeadline == nil:
+		return "-"
+	case *ds.UntilDeadline < 0:
+		return fmt.Sprintf("overdue by %s", (-*ds.UntilDeadline).Round(time.Second))
+	case *ds.UntilChallenge > 0:
+		return fmt.Sprintf("opens in %s", ds.UntilChallenge.Round(time.Second))
+	default:
+		return fmt.Sprintf("closes in %s", ds.UntilDeadline.Round(time.Second))
+	}
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
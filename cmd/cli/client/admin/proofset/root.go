@@ -0,0 +1,161 @@
+package proofset
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "proofset",
+	Short: "Manage the proof sets new aggregates are added to",
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the proof sets managed by the node",
+	Args:  cobra.NoArgs,
+	RunE:  doList,
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add <proof-set-id>",
+	Short: "Start adding aggregates to a proof set created by the node",
+	Long: `Start adding aggregates to a proof set created by the node.
+
+Aggregates go to the first active proof set, by ID, whose size bounds accept
+the padded size of the aggregate. Adding a retired proof set reactivates it.
+
+Proof sets proven by a Curio node are added with --backend curio, the pieces of
+their aggregates are streamed to the Curio node the node was started with
+--curio-url for.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doAdd,
+}
+
+var retireCmd = &cobra.Command{
+	Use:   "retire <proof-set-id>",
+	Short: "Stop adding aggregates to a proof set, its roots continue to be proven",
+	Args:  cobra.ExactArgs(1),
+	RunE:  doRetire,
+}
+
+func init() {
+	addCmd.Flags().String("class", "", "Name of the proof set class, e.g. a customer or size class")
+	addCmd.Flags().Uint64("min-aggregate-size", 0, "Smallest padded aggregate size in bytes to add to the proof set (0 for no minimum)")
+	addCmd.Flags().Uint64("max-aggregate-size", 0, "Largest padded aggregate size in bytes to add to the proof set (0 for no maximum)")
+	addCmd.Flags().String("backend", "native", "Backend proving the proof set: native or curio")
+
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(addCmd)
+	Cmd.AddCommand(retireCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	sets, err := api.ListProofSets(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("listing proof sets: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCLASS\tMIN SIZE\tMAX SIZE\tBACKEND\tSTATE")
+	for _, ps := range sets {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", ps.ID, orDash(ps.Class), size(ps.MinAggregateSize), size(ps.MaxAggregateSize), orDash(ps.Backend), state(ps))
+	}
+	return w.Flush()
+}
+
+func doAdd(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid proof set id: %w", err)
+	}
+	class, _ := cmd.Flags().GetString("class")
+	minSize, _ := cmd.Flags().GetUint64("min-aggregate-size")
+	maxSize, _ := cmd.Flags().GetUint64("max-aggregate-size")
+	backend, _ := cmd.Flags().GetString("backend")
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	ps, err := api.AddProofSet(cmd.Context(), httpapi.AddProofSetRequest{
+		ID:               id,
+		Class:            class,
+		MinAggregateSize: minSize,
+		MaxAggregateSize: maxSize,
+		Backend:          backend,
+	})
+	if err != nil {
+		return fmt.Errorf("adding proof set: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "proof set %d %s\n", ps.ID, state(*ps))
+	return nil
+}
+
+func doRetire(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid proof set id: %w", err)
+	}
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	ps, err := api.RetireProofSet(cmd.Context(), id)
+	if err != nil {
+		return fmt.Errorf("retiring proof set: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "proof set %d %s\n", ps.ID, state(*ps))
+	return nil
+}
+
+func state(ps httpapi.ManagedProofSet) string {
+	if ps.Retired {
+		return "retired"
+	}
+	return "active"
+}
+
+func size(n uint64) string {
+	if n == 0 {
+		return "-"
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,140 @@
+package prove
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "prove <dataset>",
+	Short: "Prove the next challenge of a data set without submitting the proof",
+	Long: `Prove the next challenge of a data set without submitting the proof.
+
+Fetches the challenge seed of the data set, computes the proofs of the
+challenged leaves against the pieces stored by the node, and reports whether
+the node would succeed and how long proof generation took. No transactions are
+sent, so --dry-run is required.
+
+If the challenge epoch has not been reached yet its seed is not available, the
+randomness of the chain head is used instead and the challenged leaves differ
+from those of the actual challenge.
+
+Exits with an error if any challenge cannot be proven.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doProve,
+}
+
+var (
+	dryRun       bool
+	timeout      time.Duration
+	outputFormat string
+)
+
+func init() {
+	Cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Compute the proofs without sending a transaction (the only supported mode)")
+	Cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "Maximum time to wait for proof generation to complete")
+	Cmd.Flags().StringVar(&outputFormat, "format", "table", "Output format: table or json")
+}
+
+func doProve(cmd *cobra.Command, args []string) error {
+	if !dryRun {
+		return fmt.Errorf("proofs are submitted by the node when challenged, only --dry-run is supported")
+	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid data set ID %q: %w", args[0], err)
+	}
+	if outputFormat != "table" && outputFormat != "json" {
+		return fmt.Errorf("unknown format: %s (use 'table' or 'json')", outputFormat)
+	}
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.ProveDryRun(cmd.Context(), id)
+	if err != nil {
+		return fmt.Errorf("proving data set: %w", err)
+	}
+
+	if outputFormat == "json" {
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return fmt.Errorf("rendering dry run result: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	} else if err := printDryRun(cmd, res); err != nil {
+		return err
+	}
+
+	if !res.OK {
+		failed := 0
+		for _, c := range res.Challenges {
+			if c.Error != "" {
+				failed++
+			}
+		}
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d challenges of data set %d could not be proven", failed, len(res.Challenges), res.DataSetID)
+	}
+	return nil
+}
+
+func printDryRun(cmd *cobra.Command, res *httpapi.ProveDryRunResponse) error {
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "DRY RUN: proved data set %d, no transactions were sent\n\n", res.DataSetID)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Head epoch\t%d\n", res.HeadEpoch)
+	fmt.Fprintf(w, "Challenge epoch\t%d\n", res.ChallengeEpoch)
+	seed := strconv.FormatInt(res.SeedEpoch, 10)
+	if res.Simulated {
+		seed += " (challenge epoch not reached, challenges are simulated)"
+	}
+	fmt.Fprintf(w, "Seed epoch\t%s\n", seed)
+	fmt.Fprintf(w, "Proof generation\t%s\n", res.Duration.Round(time.Millisecond))
+	result := "would succeed"
+	if !res.OK {
+		result = "would fail"
+	}
+	fmt.Fprintf(w, "Result\t%s\n", result)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tPIECE ID\tLEAF\tDURATION\tERROR")
+	for i, c := range res.Challenges {
+		errMsg := c.Error
+		if errMsg == "" {
+			errMsg = "-"
+		}
+		fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\n", i, c.PieceID, c.Leaf, c.Duration.Round(time.Millisecond), errMsg)
+	}
+	return w.Flush()
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg, client.WithHTTPClient(&http.Client{Timeout: timeout}))
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,131 @@
+package quota
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "quota",
+	Short: "Manage the storage and egress quotas of spaces",
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the quotas and usage of spaces with limits",
+	Args:  cobra.NoArgs,
+	RunE:  doList,
+}
+
+var getCmd = &cobra.Command{
+	Use:   "get <space>",
+	Short: "Show the quota and usage of a space",
+	Args:  cobra.ExactArgs(1),
+	RunE:  doGet,
+}
+
+var setCmd = &cobra.Command{
+	Use:   "set <space>",
+	Short: "Set the quota of a space",
+	Long: `Set the quota of a space.
+
+Storage limits the total size of the blobs allocated in the space. Egress
+limits the bytes retrieved from the space per calendar month (UTC). A limit of
+0 removes it, and setting both to 0 removes the quota of the space.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doSet,
+}
+
+func init() {
+	setCmd.Flags().Uint64("storage", 0, "Bytes the space may allocate (0 for no limit)")
+	setCmd.Flags().Uint64("egress", 0, "Bytes the space may retrieve per calendar month (0 for no limit)")
+
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(getCmd)
+	Cmd.AddCommand(setCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	quotas, err := api.ListQuotas(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("listing quotas: %w", err)
+	}
+
+	return printQuotas(cmd, quotas...)
+}
+
+func doGet(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	q, err := api.GetQuota(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("getting quota: %w", err)
+	}
+
+	return printQuotas(cmd, *q)
+}
+
+func doSet(cmd *cobra.Command, args []string) error {
+	storage, _ := cmd.Flags().GetUint64("storage")
+	egress, _ := cmd.Flags().GetUint64("egress")
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	q, err := api.SetQuota(cmd.Context(), args[0], httpapi.SetQuotaRequest{
+		Storage: storage,
+		Egress:  egress,
+	})
+	if err != nil {
+		return fmt.Errorf("setting quota: %w", err)
+	}
+
+	return printQuotas(cmd, *q)
+}
+
+func printQuotas(cmd *cobra.Command, quotas ...httpapi.SpaceQuota) error {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SPACE\tSTORAGE USED\tSTORAGE LIMIT\tEGRESS PERIOD\tEGRESS USED\tEGRESS LIMIT")
+	for _, q := range quotas {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\t%s\n",
+			q.Space, q.Usage.Storage, limit(q.Limits.Storage), q.Usage.Period, q.Usage.Egress, limit(q.Limits.Egress))
+	}
+	return w.Flush()
+}
+
+func limit(n uint64) string {
+	if n == 0 {
+		return "-"
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,127 @@
+package readonly
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "readonly",
+	Short: "Put the node in and out of read-only mode",
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the node is read-only",
+	Args:  cobra.NoArgs,
+	RunE:  doStatus,
+}
+
+var enableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Refuse allocations, replicas and uploads until read-only mode is disabled",
+	Long: `Put the node in read-only mode. Retrievals and claims are still served, but
+blob/allocate, blob/fetch and replica/allocate invocations fail with a
+ServiceReadOnly error and blob uploads with 503 Service Unavailable. The mode
+stays enabled across restarts until it is disabled.`,
+	Args: cobra.NoArgs,
+	RunE: doEnable,
+}
+
+var disableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Take the node out of read-only mode",
+	Long: `Take the node out of read-only mode. Read-only mode enabled by the
+ucan.read_only configuration cannot be disabled while the node runs.`,
+	Args: cobra.NoArgs,
+	RunE: doDisable,
+}
+
+func init() {
+	enableCmd.Flags().String("reason", "", "Reason for the read-only mode, returned in refused requests")
+
+	Cmd.AddCommand(statusCmd)
+	Cmd.AddCommand(enableCmd)
+	Cmd.AddCommand(disableCmd)
+}
+
+func doStatus(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	s, err := api.GetReadOnly(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("getting read-only mode: %w", err)
+	}
+	printStatus(cmd.OutOrStdout(), s)
+	return nil
+}
+
+func doEnable(cmd *cobra.Command, _ []string) error {
+	reason, _ := cmd.Flags().GetString("reason")
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	s, err := api.EnableReadOnly(cmd.Context(), reason)
+	if err != nil {
+		return fmt.Errorf("enabling read-only mode: %w", err)
+	}
+	printStatus(cmd.OutOrStdout(), s)
+	return nil
+}
+
+func doDisable(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	s, err := api.DisableReadOnly(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("disabling read-only mode: %w", err)
+	}
+	printStatus(cmd.OutOrStdout(), s)
+	return nil
+}
+
+func printStatus(w io.Writer, s *httpapi.ReadOnlyStatus) {
+	if !s.Enabled {
+		fmt.Fprintln(w, "read-only mode disabled")
+		return
+	}
+	fmt.Fprint(w, "read-only mode enabled")
+	if s.Forced {
+		fmt.Fprint(w, " by configuration")
+	}
+	if s.Since != nil {
+		fmt.Fprintf(w, " since %s", s.Since.Local().Format(time.RFC3339))
+	}
+	fmt.Fprintln(w)
+	if s.Reason != "" {
+		fmt.Fprintf(w, "reason: %s\n", s.Reason)
+	}
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,122 @@
+package replication
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+)
+
+var deadLettersCmd = &cobra.Command{
+	Use:   "dead-letters",
+	Short: "Inspect, requeue and discard the replica transfers that failed",
+	Long: `Inspect, requeue and discard the replica transfers that failed permanently or
+after their last retry. Failed transfers are kept with the cause of their
+failure until they are requeued or discarded.`,
+}
+
+var deadLettersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the failed replica transfers, oldest first",
+	Args:  cobra.NoArgs,
+	RunE:  doDeadLettersList,
+}
+
+var deadLettersRequeueCmd = &cobra.Command{
+	Use:   "requeue <job-id>",
+	Short: "Queue a failed replica transfer to run again",
+	Args:  cobra.ExactArgs(1),
+	RunE:  doDeadLettersRequeue,
+}
+
+var deadLettersDiscardCmd = &cobra.Command{
+	Use:   "discard <job-id>",
+	Short: "Discard a failed replica transfer",
+	Args:  cobra.ExactArgs(1),
+	RunE:  doDeadLettersDiscard,
+}
+
+func init() {
+	deadLettersListCmd.Flags().Int("limit", 100, "Maximum number of transfers listed, at most 1000")
+
+	deadLettersCmd.AddCommand(deadLettersListCmd)
+	deadLettersCmd.AddCommand(deadLettersRequeueCmd)
+	deadLettersCmd.AddCommand(deadLettersDiscardCmd)
+	Cmd.AddCommand(deadLettersCmd)
+}
+
+func doDeadLettersList(cmd *cobra.Command, _ []string) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	letters, err := api.ListReplicaDeadLetters(cmd.Context(), limit)
+	if err != nil {
+		return fmt.Errorf("listing failed replica transfers: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB ID\tDIGEST\tSOURCE\tFAILED AT\tCAUSE\tERROR")
+	for _, l := range letters {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", l.JobID, l.Digest, l.Source, l.FailedAt.Local().Format(time.RFC3339), cause(l), rootCause(l))
+	}
+	return w.Flush()
+}
+
+func doDeadLettersRequeue(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	l, err := api.RequeueReplicaDeadLetter(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("requeueing failed replica transfer: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "transfer of %s (job %s) queued to run again\n", l.Digest, l.JobID)
+	return nil
+}
+
+func doDeadLettersDiscard(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	l, err := api.DiscardReplicaDeadLetter(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("discarding failed replica transfer: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "transfer of %s (job %s) discarded\n", l.Digest, l.JobID)
+	return nil
+}
+
+// cause summarises the cause of a failed transfer.
+func cause(l httpapi.ReplicaDeadLetter) string {
+	switch {
+	case l.DigestMismatch:
+		return "digest mismatch"
+	case l.Stalled:
+		return "source stalled"
+	case l.StatusCode != 0:
+		return fmt.Sprintf("status %d from %s", l.StatusCode, l.FailedURL)
+	default:
+		return "-"
+	}
+}
+
+// rootCause is the innermost error of a failed transfer.
+func rootCause(l httpapi.ReplicaDeadLetter) string {
+	if len(l.Causes) == 0 {
+		return l.Error
+	}
+	return l.Causes[len(l.Causes)-1]
+}
@@ -0,0 +1,136 @@
+package replication
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "replication",
+	Short: "Manage the replica policy and the replica transfers that failed",
+}
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Show or set the replica policy",
+}
+
+var policyGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Show the replica policy",
+	Args:  cobra.NoArgs,
+	RunE:  doPolicyGet,
+}
+
+var policySetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the replica policy",
+	Long: `Set the replica policy. The whole policy is replaced, so limits not given
+are removed.
+
+Replica allocations are rejected if the blob is larger than the maximum replica
+size, if the node holding the blob is not an allowed source or is an excluded
+source, if every location of the blob is on an excluded host, or if accepting
+the blob would leave less free disk space than the minimum free capacity.
+
+Excluded hosts starting with a dot exclude every host in the domain.`,
+	Args: cobra.NoArgs,
+	RunE: doPolicySet,
+}
+
+func init() {
+	policySetCmd.Flags().Uint64("max-replica-size", 0, "Largest replica in bytes accepted (0 for no limit)")
+	policySetCmd.Flags().StringSlice("allow-source", nil, "DID of a node replicas are accepted from, repeatable (default any)")
+	policySetCmd.Flags().StringSlice("exclude-source", nil, "DID of a node replicas are not accepted from, repeatable")
+	policySetCmd.Flags().StringSlice("exclude-host", nil, "Host replicas are not transferred from, repeatable")
+	policySetCmd.Flags().Uint64("min-free-capacity", 0, "Bytes of disk space left free after accepting a replica (0 for no limit)")
+
+	policyCmd.AddCommand(policyGetCmd)
+	policyCmd.AddCommand(policySetCmd)
+	Cmd.AddCommand(policyCmd)
+}
+
+func doPolicyGet(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	policy, err := api.GetReplicaPolicy(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("getting replica policy: %w", err)
+	}
+
+	return printPolicy(cmd, *policy)
+}
+
+func doPolicySet(cmd *cobra.Command, _ []string) error {
+	maxSize, _ := cmd.Flags().GetUint64("max-replica-size")
+	allowed, _ := cmd.Flags().GetStringSlice("allow-source")
+	excluded, _ := cmd.Flags().GetStringSlice("exclude-source")
+	hosts, _ := cmd.Flags().GetStringSlice("exclude-host")
+	minFree, _ := cmd.Flags().GetUint64("min-free-capacity")
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	policy, err := api.SetReplicaPolicy(cmd.Context(), httpapi.ReplicaPolicy{
+		MaxReplicaSize:  maxSize,
+		AllowedSources:  allowed,
+		ExcludedSources: excluded,
+		ExcludedHosts:   hosts,
+		MinFreeCapacity: minFree,
+	})
+	if err != nil {
+		return fmt.Errorf("setting replica policy: %w", err)
+	}
+
+	return printPolicy(cmd, *policy)
+}
+
+func printPolicy(cmd *cobra.Command, policy httpapi.ReplicaPolicy) error {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Max replica size:\t%s\n", limit(policy.MaxReplicaSize))
+	fmt.Fprintf(w, "Allowed sources:\t%s\n", list(policy.AllowedSources))
+	fmt.Fprintf(w, "Excluded sources:\t%s\n", list(policy.ExcludedSources))
+	fmt.Fprintf(w, "Excluded hosts:\t%s\n", list(policy.ExcludedHosts))
+	fmt.Fprintf(w, "Min free capacity:\t%s\n", limit(policy.MinFreeCapacity))
+	return w.Flush()
+}
+
+func limit(n uint64) string {
+	if n == 0 {
+		return "-"
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+func list(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ", ")
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
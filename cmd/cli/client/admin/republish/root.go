@@ -0,0 +1,81 @@
+package republish
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "republish",
+	Short: "Inspect the republication of location claims after a public URL change",
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the progress of republishing location claims",
+	Long: `Show the progress of republishing location claims.
+
+When the node starts with a public URL different from the one it last ran
+with, a new location claim is issued and published for every accepted blob.`,
+	Args: cobra.NoArgs,
+	RunE: doStatus,
+}
+
+func init() {
+	Cmd.AddCommand(statusCmd)
+}
+
+func doStatus(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.GetRepublishStatus(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("getting republish status: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if res.Progress == nil {
+		fmt.Fprintf(out, "public URL %s has not changed, nothing to republish\n", res.PublicURL)
+		return nil
+	}
+
+	p := res.Progress
+	state := "in progress"
+	if p.CompletedAt != nil {
+		state = "completed " + p.CompletedAt.Format(time.RFC3339)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "From:\t%s\n", p.From)
+	fmt.Fprintf(w, "To:\t%s\n", p.To)
+	fmt.Fprintf(w, "Started:\t%s\n", p.StartedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "State:\t%s\n", state)
+	fmt.Fprintf(w, "Republished:\t%d\n", p.Republished)
+	fmt.Fprintf(w, "Failed:\t%d\n", p.Failed)
+	if p.LastError != "" {
+		fmt.Fprintf(w, "Last error:\t%s\n", p.LastError)
+	}
+	return w.Flush()
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -3,9 +3,38 @@ package admin
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/storacha/piri/cmd/cli/client/admin/alerts"
+	"github.com/storacha/piri/cmd/cli/client/admin/backfill"
+	"github.com/storacha/piri/cmd/cli/client/admin/billing"
+	"github.com/storacha/piri/cmd/cli/client/admin/blocklist"
 	"github.com/storacha/piri/cmd/cli/client/admin/config"
+	"github.com/storacha/piri/cmd/cli/client/admin/curio"
+	"github.com/storacha/piri/cmd/cli/client/admin/dashboard"
+	"github.com/storacha/piri/cmd/cli/client/admin/delegation"
+	"github.com/storacha/piri/cmd/cli/client/admin/diskspace"
+	"github.com/storacha/piri/cmd/cli/client/admin/drill"
+	"github.com/storacha/piri/cmd/cli/client/admin/events"
+	"github.com/storacha/piri/cmd/cli/client/admin/feature"
+	"github.com/storacha/piri/cmd/cli/client/admin/gas"
+	"github.com/storacha/piri/cmd/cli/client/admin/jobs"
+	"github.com/storacha/piri/cmd/cli/client/admin/locate"
 	"github.com/storacha/piri/cmd/cli/client/admin/log"
 	"github.com/storacha/piri/cmd/cli/client/admin/payment"
+	"github.com/storacha/piri/cmd/cli/client/admin/piece"
+	"github.com/storacha/piri/cmd/cli/client/admin/proofs"
+	"github.com/storacha/piri/cmd/cli/client/admin/proofset"
+	"github.com/storacha/piri/cmd/cli/client/admin/prove"
+	"github.com/storacha/piri/cmd/cli/client/admin/quota"
+	"github.com/storacha/piri/cmd/cli/client/admin/readonly"
+	"github.com/storacha/piri/cmd/cli/client/admin/replication"
+	"github.com/storacha/piri/cmd/cli/client/admin/republish"
+	"github.com/storacha/piri/cmd/cli/client/admin/scrub"
+	"github.com/storacha/piri/cmd/cli/client/admin/signing"
+	"github.com/storacha/piri/cmd/cli/client/admin/storageclass"
+	"github.com/storacha/piri/cmd/cli/client/admin/subsystem"
+	"github.com/storacha/piri/cmd/cli/client/admin/usage"
+	"github.com/storacha/piri/cmd/cli/client/admin/verifydataset"
+	"github.com/storacha/piri/cmd/cli/client/admin/webhook"
 )
 
 var Cmd = &cobra.Command{
@@ -17,4 +46,33 @@ func init() {
 	Cmd.AddCommand(log.Cmd)
 	Cmd.AddCommand(payment.Cmd)
 	Cmd.AddCommand(config.Cmd)
+	Cmd.AddCommand(subsystem.Cmd)
+	Cmd.AddCommand(feature.Cmd)
+	Cmd.AddCommand(jobs.Cmd)
+	Cmd.AddCommand(drill.Cmd)
+	Cmd.AddCommand(delegation.Cmd)
+	Cmd.AddCommand(proofset.Cmd)
+	Cmd.AddCommand(curio.Cmd)
+	Cmd.AddCommand(republish.Cmd)
+	Cmd.AddCommand(piece.Cmd)
+	Cmd.AddCommand(quota.Cmd)
+	Cmd.AddCommand(scrub.Cmd)
+	Cmd.AddCommand(gas.Cmd)
+	Cmd.AddCommand(events.Cmd)
+	Cmd.AddCommand(proofs.Cmd)
+	Cmd.AddCommand(webhook.Cmd)
+	Cmd.AddCommand(alerts.Cmd)
+	Cmd.AddCommand(storageclass.Cmd)
+	Cmd.AddCommand(billing.Cmd)
+	Cmd.AddCommand(usage.Cmd)
+	Cmd.AddCommand(verifydataset.Cmd)
+	Cmd.AddCommand(prove.Cmd)
+	Cmd.AddCommand(locate.Cmd)
+	Cmd.AddCommand(backfill.Cmd)
+	Cmd.AddCommand(dashboard.Cmd)
+	Cmd.AddCommand(replication.Cmd)
+	Cmd.AddCommand(signing.Cmd)
+	Cmd.AddCommand(readonly.Cmd)
+	Cmd.AddCommand(diskspace.Cmd)
+	Cmd.AddCommand(blocklist.Cmd)
 }
@@ -0,0 +1,77 @@
+package scrub
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "scrub",
+	Short: "Inspect blobs found corrupt by the integrity scrubber",
+}
+
+var corruptCmd = &cobra.Command{
+	Use:   "corrupt",
+	Short: "List blobs whose content does not match their digest",
+	Long: `List blobs whose content does not match their digest.
+
+The scrubber periodically re-hashes every blob held by the node. Blobs found
+corrupt are listed until they verify again, either because they were repaired
+from a peer or uploaded again.`,
+	Args: cobra.NoArgs,
+	RunE: doCorrupt,
+}
+
+func init() {
+	Cmd.AddCommand(corruptCmd)
+}
+
+func doCorrupt(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.ListCorruptBlobs(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("listing corrupt blobs: %w", err)
+	}
+	if len(res.Blobs) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "no corrupt blobs found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DIGEST\tSIZE\tDETECTED\tREPAIRED\tREPAIR ERROR")
+	for _, b := range res.Blobs {
+		repaired := "-"
+		if b.RepairedAt != nil {
+			repaired = b.RepairedAt.Format(time.RFC3339)
+		}
+		repairErr := "-"
+		if b.RepairError != "" {
+			repairErr = b.RepairError
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", b.Digest, b.Size, b.DetectedAt.Format(time.RFC3339), repaired, repairErr)
+	}
+	return w.Flush()
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
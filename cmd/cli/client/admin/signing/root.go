@@ -0,0 +1,169 @@
+package signing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "signing",
+	Short: "Approve or reject the sign requests held for approval",
+}
+
+var pendingCmd = &cobra.Command{
+	Use:   "pending",
+	Short: "List the sign requests waiting for approval",
+	Long: `List the sign requests waiting for approval.
+
+Sign requests are held for approval when pdp.signing_service.approval is
+enabled and their operation is manual. The operation is retried by the node
+until its request is approved or rejected.`,
+	Args: cobra.NoArgs,
+	RunE: doPending,
+}
+
+var approveCmd = &cobra.Command{
+	Use:   "approve <id>",
+	Short: "Approve a sign request",
+	Long: `Approve a pending or rejected sign request. The operation is signed when the
+node next retries it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doApprove,
+}
+
+var rejectCmd = &cobra.Command{
+	Use:   "reject <id>",
+	Short: "Reject a sign request",
+	Long: `Reject a pending sign request. Retries of the operation fail until the
+request is approved.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doReject,
+}
+
+func init() {
+	pendingCmd.Flags().String("status", "pending", "Status of the requests to list: pending, approved, signing, rejected, signed or all")
+	pendingCmd.Flags().Int("limit", 0, "Maximum number of requests to list (server default if 0)")
+	rejectCmd.Flags().String("reason", "", "Reason for the rejection, returned to the operation")
+
+	Cmd.AddCommand(pendingCmd)
+	Cmd.AddCommand(approveCmd)
+	Cmd.AddCommand(rejectCmd)
+}
+
+func doPending(cmd *cobra.Command, _ []string) error {
+	status, _ := cmd.Flags().GetString("status")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	reqs, err := api.ListSignRequests(cmd.Context(), status, limit)
+	if err != nil {
+		return fmt.Errorf("listing sign requests: %w", err)
+	}
+	if len(reqs) == 0 {
+		cmd.Printf("no %s sign requests\n", status)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tOPERATION\tDATA SET\tDETAILS\tSTATUS\tCREATED")
+	for _, req := range reqs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			req.ID, req.Operation, orDash(req.DataSet), details(req), req.Status, req.CreatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func doApprove(cmd *cobra.Command, args []string) error {
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	req, err := api.ApproveSignRequest(cmd.Context(), id)
+	if err != nil {
+		return fmt.Errorf("approving sign request: %w", err)
+	}
+	cmd.Printf("Approved sign request %d (%s)\n", req.ID, req.Operation)
+	return nil
+}
+
+func doReject(cmd *cobra.Command, args []string) error {
+	reason, _ := cmd.Flags().GetString("reason")
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	req, err := api.RejectSignRequest(cmd.Context(), id, reason)
+	if err != nil {
+		return fmt.Errorf("rejecting sign request: %w", err)
+	}
+	cmd.Printf("Rejected sign request %d (%s)\n", req.ID, req.Operation)
+	return nil
+}
+
+// details summarizes what the request signs.
+func details(req httpapi.SignRequest) string {
+	switch {
+	case req.Payee != "":
+		return "payee " + req.Payee
+	case req.Operation == "add-pieces":
+		if req.Size == 0 {
+			return fmt.Sprintf("%d pieces, unknown size", len(req.Pieces))
+		}
+		return fmt.Sprintf("%d pieces, %d bytes", len(req.Pieces), req.Size)
+	case len(req.Pieces) > 0:
+		return "pieces " + strings.Join(req.Pieces, ",")
+	default:
+		return "-"
+	}
+}
+
+func parseID(s string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sign request ID: %s", s)
+	}
+	return uint(id), nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
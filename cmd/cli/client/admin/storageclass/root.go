@@ -0,0 +1,131 @@
+package storageclass
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "storageclass",
+	Short: "Inspect storage classes and assign them to spaces",
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List storage classes with their usage and the spaces assigned a class",
+	Args:  cobra.NoArgs,
+	RunE:  doList,
+}
+
+var assignCmd = &cobra.Command{
+	Use:   "assign <space> [class]",
+	Short: "Assign a storage class to a space",
+	Long: `Assign a storage class to a space.
+
+Blobs later allocated in the space are stored in the class, unless their
+allocation requests another class. Blobs already allocated keep their class.
+Omitting the class removes the assignment, so the space uses the default class.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: doAssign,
+}
+
+func init() {
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(assignCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.ListStorageClasses(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("listing storage classes: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLASS\tREPLICAS\tPROOF SET CLASS\tBLOBS\tBYTES")
+	for _, c := range res.Classes {
+		name := c.Name
+		if name == res.Default {
+			name += " (default)"
+		}
+		if !c.Configured {
+			name += " (not configured)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", name, orDash(c.Replicas), orAny(c.ProofSetClass), c.Blobs, c.Bytes)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(res.Spaces) == 0 {
+		return nil
+	}
+	fmt.Fprintln(cmd.OutOrStdout())
+	w = tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SPACE\tCLASS")
+	for _, s := range res.Spaces {
+		fmt.Fprintf(w, "%s\t%s\n", s.Space, s.Class)
+	}
+	return w.Flush()
+}
+
+func doAssign(cmd *cobra.Command, args []string) error {
+	class := ""
+	if len(args) > 1 {
+		class = args[1]
+	}
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.SetSpaceStorageClass(cmd.Context(), args[0], class)
+	if err != nil {
+		return fmt.Errorf("assigning storage class: %w", err)
+	}
+
+	if res.Class == "" {
+		cmd.Printf("Removed the storage class of %s\n", res.Space)
+		return nil
+	}
+	cmd.Printf("Assigned storage class %s to %s\n", res.Class, res.Space)
+	return nil
+}
+
+func orDash(n uint) string {
+	if n == 0 {
+		return "-"
+	}
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+func orAny(s string) string {
+	if s == "" {
+		return "any"
+	}
+	return s
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,121 @@
+package subsystem
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "subsystem",
+	Short: "Pause and resume node subsystems",
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List subsystems and whether they are paused",
+	Args:  cobra.NoArgs,
+	RunE:  doList,
+}
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause <name>",
+	Short: "Pause a subsystem, it stays paused across restarts until resumed",
+	Args:  cobra.ExactArgs(1),
+	RunE:  doPause,
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume <name>",
+	Short: "Resume a paused subsystem",
+	Args:  cobra.ExactArgs(1),
+	RunE:  doResume,
+}
+
+func init() {
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(pauseCmd)
+	Cmd.AddCommand(resumeCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	subsystems, err := api.ListSubsystems(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("listing subsystems: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tPAUSED AT")
+	for _, s := range subsystems {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, state(s), pausedAt(s))
+	}
+	return w.Flush()
+}
+
+func doPause(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	s, err := api.PauseSubsystem(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("pausing subsystem: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "subsystem %s %s\n", s.Name, state(*s))
+	return nil
+}
+
+func doResume(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	s, err := api.ResumeSubsystem(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("resuming subsystem: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "subsystem %s %s\n", s.Name, state(*s))
+	return nil
+}
+
+func state(s httpapi.SubsystemStatus) string {
+	if s.Paused {
+		return "paused"
+	}
+	return "running"
+}
+
+func pausedAt(s httpapi.SubsystemStatus) string {
+	if s.PausedAt == nil {
+		return "-"
+	}
+	return s.PausedAt.Local().Format(time.RFC3339)
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -0,0 +1,138 @@
+package usage
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Report the bytes spaces store over time",
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the bytes stored by each space",
+	Args:  cobra.NoArgs,
+	RunE:  doList,
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report <space>",
+	Short: "Report the usage of a space over a period",
+	Long: `Report the usage of a space over a period.
+
+Shows the bytes stored by the space at the start and end of the period, the
+allocations and removals in between with the invocations that caused them, and
+the snapshots taken in the period. The period defaults to the current calendar
+month (UTC) until now.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doReport,
+}
+
+func init() {
+	reportCmd.Flags().String("from", "", "Start of the period, RFC3339 (default start of the month)")
+	reportCmd.Flags().String("to", "", "End of the period, RFC3339 (default now)")
+
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(reportCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	spaces, err := api.ListSpaceUsage(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("listing space usage: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SPACE\tSIZE")
+	for _, s := range spaces {
+		fmt.Fprintf(w, "%s\t%d\n", s.Space, s.Size)
+	}
+	return w.Flush()
+}
+
+func doReport(cmd *cobra.Command, args []string) error {
+	from, err := parseTimeFlag(cmd, "from")
+	if err != nil {
+		return err
+	}
+	to, err := parseTimeFlag(cmd, "to")
+	if err != nil {
+		return err
+	}
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	report, err := api.GetUsageReport(cmd.Context(), args[0], from, to)
+	if err != nil {
+		return fmt.Errorf("getting usage report: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Space:   %s\n", report.Space)
+	fmt.Fprintf(out, "Period:  %s - %s\n", report.From.Format(time.RFC3339), report.To.Format(time.RFC3339))
+	fmt.Fprintf(out, "Initial: %d\n", report.Initial)
+	fmt.Fprintf(out, "Final:   %d\n", report.Final)
+
+	if len(report.Events) > 0 {
+		fmt.Fprintln(out)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "AT\tDELTA\tCAUSE")
+		for _, e := range report.Events {
+			fmt.Fprintf(w, "%s\t%+d\t%s\n", e.At.Format(time.RFC3339), e.Delta, e.Cause)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(report.Snapshots) > 0 {
+		fmt.Fprintln(out)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SNAPSHOT AT\tSIZE")
+		for _, s := range report.Snapshots {
+			fmt.Fprintf(w, "%s\t%d\n", s.At.Format(time.RFC3339), s.Size)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+func parseTimeFlag(cmd *cobra.Command, name string) (time.Time, error) {
+	v, _ := cmd.Flags().GetString(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s time %q, must be RFC3339: %w", name, v, err)
+	}
+	return t, nil
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
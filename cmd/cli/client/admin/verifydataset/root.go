@@ -0,0 +1,152 @@
+package verifydataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "verify-dataset <id>",
+	Short: "Verify the node holds the blobs of every piece in a data set",
+	Long: `Verify the node holds the blobs of every piece in a data set.
+
+Enumerates the pieces registered in the data set on chain and checks the node
+has a record of each piece and holds each of the blobs it was aggregated from.
+Pieces the node cannot prove are reported as:
+
+  unrecorded  the piece is on chain but the node has no record of it
+  missing     a blob of the piece is not held by the node
+  corrupt     the content of a blob does not match its digest (--deep only)
+
+By default blobs are only checked to exist. With --deep the content of every
+blob is read and hashed, which may take a long time on large data sets.
+
+With --repair-plan a plan to repair each failed piece is printed. The plan is
+advisory, nothing is changed.
+
+Exits with an error if any piece fails verification.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doVerify,
+}
+
+var (
+	deep         bool
+	repairPlan   bool
+	timeout      time.Duration
+	outputFormat string
+)
+
+func init() {
+	Cmd.Flags().BoolVar(&deep, "deep", false, "Hash the content of every blob rather than only checking it exists")
+	Cmd.Flags().BoolVar(&repairPlan, "repair-plan", false, "Print a plan to repair the pieces failing verification")
+	Cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Minute, "Maximum time to wait for the verification to complete")
+	Cmd.Flags().StringVar(&outputFormat, "format", "table", "Output format: table or json")
+}
+
+func doVerify(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid data set ID %q: %w", args[0], err)
+	}
+	if outputFormat != "table" && outputFormat != "json" {
+		return fmt.Errorf("unknown format: %s (use 'table' or 'json')", outputFormat)
+	}
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := api.VerifyDataSet(cmd.Context(), id, deep, repairPlan)
+	if err != nil {
+		return fmt.Errorf("verifying data set: %w", err)
+	}
+
+	if outputFormat == "json" {
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return fmt.Errorf("rendering verification result: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	} else if err := printVerification(cmd, res); err != nil {
+		return err
+	}
+
+	if len(res.Failed) > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d pieces in data set %d failed verification", len(res.Failed), res.Pieces, res.DataSetID)
+	}
+	return nil
+}
+
+func printVerification(cmd *cobra.Command, res *httpapi.VerifyDataSetResponse) error {
+	out := cmd.OutOrStdout()
+	mode := "existence"
+	if res.Deep {
+		mode = "content"
+	}
+	fmt.Fprintf(out, "Data set %d: %d pieces, %d ok, %d failed (%s check)\n", res.DataSetID, res.Pieces, res.OK, len(res.Failed), mode)
+	if len(res.Failed) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PIECE ID\tPIECE CID\tSTATUS\tSUB-PIECE\tBLOB\tERROR")
+	for _, p := range res.Failed {
+		if len(p.Blobs) == 0 {
+			fmt.Fprintf(w, "%d\t%s\t%s\t-\t-\t-\n", p.PieceID, p.PieceCID, p.Status)
+			continue
+		}
+		for _, b := range p.Blobs {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", p.PieceID, p.PieceCID, b.Status, b.SubPieceCID, orDash(b.Digest), orDash(b.Error))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(res.RepairPlan) == 0 {
+		return nil
+	}
+	fmt.Fprintln(out, "\nRepair plan:")
+	for _, s := range res.RepairPlan {
+		fmt.Fprintf(out, "  piece %d (%s): %s\n", s.PieceID, s.PieceCID, s.Action)
+		if len(s.Blobs) > 0 {
+			fmt.Fprintf(out, "    blobs: %s\n", strings.Join(s.Blobs, ", "))
+		}
+		fmt.Fprintf(out, "    %s\n", s.Detail)
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg, client.WithHTTPClient(&http.Client{Timeout: timeout}))
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
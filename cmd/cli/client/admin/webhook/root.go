@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/admin/httpapi/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Manage the webhooks notified of issued receipts",
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the registered webhooks and their deliveries",
+	Args:  cobra.NoArgs,
+	RunE:  doList,
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add <url>",
+	Short: "Register a webhook",
+	Long: `Register a webhook.
+
+The URL receives a signed JSON notification for every receipt of the given
+abilities, e.g. blob/accept, blob/replica/transfer or pdp/accept, or of every
+receipt the node stores if no ability is given. A signing secret is generated
+unless one is given, and is only shown when the webhook is added.`,
+	Args: cobra.ExactArgs(1),
+	RunE: doAdd,
+}
+
+var removeCmd = &cobra.Command{
+	Use:   "remove <id>",
+	Short: "Remove a webhook and drop its pending notifications",
+	Args:  cobra.ExactArgs(1),
+	RunE:  doRemove,
+}
+
+func init() {
+	addCmd.Flags().StringSlice("ability", nil, "Ability of the receipts to send (repeatable, all receipts if not set)")
+	addCmd.Flags().String("secret", "", "Secret to sign notifications with (generated if not set)")
+
+	Cmd.AddCommand(listCmd)
+	Cmd.AddCommand(addCmd)
+	Cmd.AddCommand(removeCmd)
+}
+
+func doList(cmd *cobra.Command, _ []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := api.ListWebhooks(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("listing webhooks: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tURL\tABILITIES\tDELIVERED\tFAILED\tPENDING\tLAST ERROR")
+	for _, wh := range webhooks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+			wh.ID, wh.URL, abilities(wh.Abilities), wh.Delivered, wh.Failed, wh.Pending, orDash(wh.LastError))
+	}
+	return w.Flush()
+}
+
+func doAdd(cmd *cobra.Command, args []string) error {
+	abilityFlags, _ := cmd.Flags().GetStringSlice("ability")
+	secret, _ := cmd.Flags().GetString("secret")
+
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	wh, err := api.AddWebhook(cmd.Context(), httpapi.AddWebhookRequest{
+		URL:       args[0],
+		Abilities: abilityFlags,
+		Secret:    secret,
+	})
+	if err != nil {
+		return fmt.Errorf("adding webhook: %w", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", wh.ID)
+	fmt.Fprintf(w, "URL:\t%s\n", wh.URL)
+	fmt.Fprintf(w, "Abilities:\t%s\n", abilities(wh.Abilities))
+	fmt.Fprintf(w, "Secret:\t%s\n", wh.Secret)
+	fmt.Fprintf(w, "Created:\t%s\n", wh.CreatedAt.Format(time.RFC3339))
+	return w.Flush()
+}
+
+func doRemove(cmd *cobra.Command, args []string) error {
+	api, err := loadClient()
+	if err != nil {
+		return err
+	}
+
+	if err := api.RemoveWebhook(cmd.Context(), args[0]); err != nil {
+		return fmt.Errorf("removing webhook: %w", err)
+	}
+	cmd.Printf("Removed webhook %s\n", args[0])
+	return nil
+}
+
+func abilities(abilities []string) string {
+	if len(abilities) == 0 {
+		return "*"
+	}
+	return strings.Join(abilities, ",")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func loadClient() (*client.Client, error) {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	api, err := client.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating admin client: %w", err)
+	}
+	return api, nil
+}
@@ -21,6 +21,7 @@ func init() {
 	cobra.CheckErr(viper.BindPFlag("api.endpoint", Cmd.PersistentFlags().Lookup("node-url")))
 
 	Cmd.AddCommand(ucan.Cmd)
+	Cmd.AddCommand(ucan.ImportCARCmd)
 	Cmd.AddCommand(admin.Cmd)
 	Cmd.AddCommand(pdp.Cmd)
 }
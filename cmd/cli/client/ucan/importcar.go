@@ -0,0 +1,168 @@
+package ucan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	carv2 "github.com/ipld/go-car/v2"
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-ucanto/did"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/storacha/piri/cmd/cli/delegate"
+	"github.com/storacha/piri/cmd/client"
+	"github.com/storacha/piri/pkg/config"
+)
+
+var ImportCARCmd = &cobra.Command{
+	Use:   "import-car <file>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Store every block of a CAR file on a Piri node",
+	Long: `Store every block of a CAR file on a Piri node.
+
+Each block of the CAR (v1 or v2) is stored as a blob in the space: it is
+allocated with blob/allocate, uploaded if the node does not already hold it,
+and accepted with blob/accept. Repeated blocks are stored once.
+
+One JSON object is written per line for each block stored, with the location
+claim issued by the node, formatted as by ` + "`piri delegate generate`" + `.`,
+	RunE: doImportCAR,
+}
+
+func init() {
+	ImportCARCmd.Flags().String("node-did", "", "DID of a Piri node")
+	cobra.CheckErr(ImportCARCmd.MarkFlagRequired("node-did"))
+
+	ImportCARCmd.Flags().String("space-did", "", "DID for the space to use")
+	cobra.CheckErr(ImportCARCmd.MarkFlagRequired("space-did"))
+
+	ImportCARCmd.Flags().String("proof", "", "CAR file containing storage proof authorizing client invocations")
+	cobra.CheckErr(ImportCARCmd.MarkFlagRequired("proof"))
+
+	ImportCARCmd.Flags().String("output", "", "File to write location claims to (default stdout)")
+	ImportCARCmd.Flags().Int("concurrency", 4, "Number of blocks to upload at once")
+}
+
+// ImportedBlock is written for each block stored.
+type ImportedBlock struct {
+	CID string `json:"cid"`
+	// Digest is the digest of the blob the block is stored as.
+	Digest   string `json:"digest"`
+	Size     int    `json:"size"`
+	Location string `json:"location"`
+	Claim    string `json:"claim"`
+}
+
+func doImportCAR(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	nodeDID, _ := cmd.Flags().GetString("node-did")
+	spaceDIDStr, _ := cmd.Flags().GetString("space-did")
+	spaceDID, err := did.Parse(spaceDIDStr)
+	if err != nil {
+		return fmt.Errorf("failed to parse space did: %w", err)
+	}
+	proof, _ := cmd.Flags().GetString("proof")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	if concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
+	}
+
+	c, err := client.New(client.Config{
+		KeyFile: cfg.Identity.KeyFile,
+		NodeURL: cfg.API.Endpoint,
+		Proof:   proof,
+		NodeDID: nodeDID,
+	})
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+
+	carFile, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("opening CAR file: %w", err)
+	}
+	defer carFile.Close()
+	blocks, err := carv2.NewBlockReader(carFile)
+	if err != nil {
+		return fmt.Errorf("reading CAR file: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if path, _ := cmd.Flags().GetString("output"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("creating output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	var mu sync.Mutex
+	enc := json.NewEncoder(out)
+
+	g, ctx := errgroup.WithContext(cmd.Context())
+	g.SetLimit(concurrency)
+	seen := map[cid.Cid]struct{}{}
+	for {
+		blk, err := blocks.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// stop reading, but report the uploads already started
+			g.Go(func() error { return fmt.Errorf("reading block: %w", err) })
+			break
+		}
+		if _, ok := seen[blk.Cid()]; ok {
+			continue
+		}
+		seen[blk.Cid()] = struct{}{}
+		if ctx.Err() != nil {
+			break
+		}
+
+		g.Go(func() error {
+			data := blk.RawData()
+			res, err := c.UploadBlob(ctx, spaceDID, data)
+			if err != nil {
+				return fmt.Errorf("storing block %s: %w", blk.Cid(), err)
+			}
+			archive, err := io.ReadAll(res.LocationClaim.Archive())
+			if err != nil {
+				return fmt.Errorf("archiving location claim for block %s: %w", blk.Cid(), err)
+			}
+			claim, err := delegate.FormatDelegationBytes(archive)
+			if err != nil {
+				return fmt.Errorf("formatting location claim for block %s: %w", blk.Cid(), err)
+			}
+			imported := ImportedBlock{
+				CID:      blk.Cid().String(),
+				Digest:   digestutil.Format(res.LocationCommitment.Content.Hash()),
+				Size:     len(data),
+				Location: res.LocationCommitment.Location[0].String(),
+				Claim:    claim,
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err := enc.Encode(imported); err != nil {
+				return fmt.Errorf("writing location claim: %w", err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	cmd.PrintErrf("stored %d blocks\n", len(seen))
+	return nil
+}
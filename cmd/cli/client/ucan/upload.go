@@ -1,16 +1,11 @@
 package ucan
 
 import (
-	"bytes"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 
-	"github.com/ipfs/go-cid"
-	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
 	"github.com/spf13/cobra"
-	"github.com/storacha/go-ucanto/core/ipld/hash/sha256"
 	"github.com/storacha/go-ucanto/did"
 
 	"github.com/storacha/piri/cmd/client"
@@ -90,37 +85,9 @@ func doUpload(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("reading blob file: %w", err)
 	}
-	digest, err := sha256.Hasher.Sum(blobData)
+	blobResult, err := c.UploadBlob(cmd.Context(), spaceDID, blobData)
 	if err != nil {
-		return fmt.Errorf("calculating blob digest: %w", err)
-	}
-	address, err := c.BlobAllocate(cmd.Context(), spaceDID, digest.Bytes(), uint64(len(blobData)), cidlink.Link{Cid: cid.NewCidV1(cid.Raw, digest.Bytes())})
-	if err != nil {
-		return fmt.Errorf("invocing blob allocation: %w", err)
-	}
-	if address != nil {
-		cmd.Printf("now uploading to: %s\n", address.URL.String())
-
-		req, err := http.NewRequest(http.MethodPut, address.URL.String(), bytes.NewReader(blobData))
-		if err != nil {
-			return fmt.Errorf("uploading blob: %w", err)
-		}
-		req.Header = address.Headers
-		res, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return fmt.Errorf("sending blob: %w", err)
-		}
-		if res.StatusCode >= 300 || res.StatusCode < 200 {
-			resData, err := io.ReadAll(res.Body)
-			if err != nil {
-				return fmt.Errorf("reading response body: %w", err)
-			}
-			return fmt.Errorf("unsuccessful put, status: %s, message: %s", res.Status, string(resData))
-		}
-	}
-	blobResult, err := c.BlobAccept(cmd.Context(), spaceDID, digest.Bytes(), uint64(len(blobData)), cidlink.Link{Cid: cid.NewCidV1(cid.Raw, digest.Bytes())})
-	if err != nil {
-		return fmt.Errorf("accepting blob: %w", err)
+		return err
 	}
 	cmd.Printf("uploaded blob available at: %s\n", blobResult.LocationCommitment.Location[0].String())
 	if blobResult.PDPAccept != nil {
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/storacha/piri/pkg/build"
+	piriconfig "github.com/storacha/piri/pkg/config"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "config",
+		Short: "Inspect piri configuration",
+	}
+
+	showCmd = &cobra.Command{
+		Use:   "show",
+		Args:  cobra.NoArgs,
+		Short: "Print the configuration",
+		Long: `Print the configuration file in use.
+
+With --effective, print the configuration the node would run with: built-in
+network presets, overlaid by the matching profile from the config file
+([profiles.<network>]), then explicit config file keys, environment variables
+and flags.`,
+		RunE: doShow,
+	}
+
+	showEffective bool
+	showNetwork   string
+)
+
+func init() {
+	showCmd.Flags().BoolVar(&showEffective, "effective", false, "Print the merged configuration from all sources")
+	showCmd.Flags().StringVar(&showNetwork, "network", build.Network, "Network (preset or profile name) to apply when printing the effective configuration")
+
+	Cmd.AddCommand(showCmd)
+}
+
+func doShow(cmd *cobra.Command, _ []string) error {
+	if !showEffective {
+		cfgFile := viper.ConfigFileUsed()
+		if cfgFile == "" {
+			return fmt.Errorf("no config file in use, use --config to specify one or --effective to print the merged configuration")
+		}
+		data, err := os.ReadFile(cfgFile)
+		if err != nil {
+			return fmt.Errorf("reading config file: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "# %s\n%s", cfgFile, data)
+		return nil
+	}
+
+	network := viper.GetString("network")
+	if cmd.Flags().Changed("network") || network == "" {
+		network = showNetwork
+		viper.Set("network", network)
+	}
+	if err := piriconfig.ApplyNetwork(network); err != nil {
+		return fmt.Errorf("applying network %q: %w", network, err)
+	}
+	piriconfig.SetDefaults()
+
+	// not validated, so an incomplete configuration can still be inspected
+	var cfg piriconfig.FullServerConfig
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	cfg.Normalize()
+
+	out, err := toml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("rendering config: %w", err)
+	}
+	fmt.Fprint(cmd.OutOrStdout(), string(out))
+	return nil
+}
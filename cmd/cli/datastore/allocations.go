@@ -0,0 +1,79 @@
+package datastore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/config"
+	"github.com/storacha/piri/pkg/fx/database"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+)
+
+var migrateAllocationsCmd = &cobra.Command{
+	Use:   "migrate-allocations",
+	Short: "Copy the allocations of the datastore into the PDP database",
+	Long: `Copy the allocations of the allocation datastore into the allocations table
+of the PDP database, SQLite or PostgreSQL as set by repo.database.
+
+The node keeps allocations in the PDP database, and imports those of the
+datastore the first time it starts with an empty allocations table. Run this
+command to import them ahead of the upgrade, or again to copy allocations
+written to the datastore since. Allocations already in the table are replaced.
+The datastore is left in place. The node must be stopped.`,
+	Args: cobra.NoArgs,
+	RunE: doMigrateAllocations,
+}
+
+func init() {
+	Cmd.AddCommand(migrateAllocationsCmd)
+}
+
+func doMigrateAllocations(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+
+	cfg, err := config.Load[config.LocalConfig]()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	storageCfg, err := cfg.Repo.ToAppConfig()
+	if err != nil {
+		return fmt.Errorf("loading repo config: %w", err)
+	}
+
+	s, err := openStores(true)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if s.s3 {
+		return errors.New("allocations are kept in S3, they are not moved to the PDP database")
+	}
+	ds, err := s.Open("allocation")
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewTaskEngineDB(storageCfg)
+	if err != nil {
+		return fmt.Errorf("opening PDP database: %w", err)
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+	if err := models.AutoMigrateDB(ctx, db); err != nil {
+		return fmt.Errorf("migrating PDP database: %w", err)
+	}
+	dst := allocationstore.NewSQLStore(db)
+
+	n, err := dst.Import(ctx, allocationstore.NewDatastoreStore(ds).List(ctx))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "migrated %d allocations\n", n)
+	return nil
+}
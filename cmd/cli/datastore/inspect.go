@@ -0,0 +1,274 @@
+package datastore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/store/inspect"
+)
+
+var (
+	prefixesCmd = &cobra.Command{
+		Use:   "prefixes <store>",
+		Short: "Count the entries and bytes of a store by key prefix",
+		Long: `Count the entries and bytes of a store by key prefix.
+
+The store is named by its directory in the data directory, e.g. "receipt" or
+"aggregator/datastore". The node must be stopped.`,
+		Args: cobra.ExactArgs(1),
+		RunE: doPrefixes,
+	}
+
+	dumpCmd = &cobra.Command{
+		Use:   "dump <store>",
+		Short: "Write the entries of a store to a file",
+		Long: `Write the entries of a store, or those under a key prefix, to a file.
+
+Each line of the dump is a JSON object with the key and the base64 encoded
+value of an entry. Dumps are read back by restore. The node must be stopped.`,
+		Args: cobra.ExactArgs(1),
+		RunE: doDump,
+	}
+
+	restoreCmd = &cobra.Command{
+		Use:   "restore <store> <file>",
+		Short: "Write the entries of a dump to a store",
+		Long: `Write the entries of a dump to a store, "-" reads the dump from stdin.
+
+Entries already in the store with the same keys are overwritten, other entries
+are left in place. The node must be stopped.`,
+		Args: cobra.ExactArgs(2),
+		RunE: doRestore,
+	}
+
+	verifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Check the allocation, acceptance, claim and receipt stores for inconsistencies",
+		Long: `Check the allocation, acceptance, claim and receipt stores for inconsistencies.
+
+Every record is decoded, and the references between the stores are checked:
+accepted blobs must have an allocation and a location claim, location claims
+an acceptance, and the index of receipts by invocation must match the stored
+receipts. Allocations are read from the PDP database once the node keeps them
+there. The node must be stopped.`,
+		Args: cobra.NoArgs,
+		RunE: doVerify,
+	}
+
+	repairCmd = &cobra.Command{
+		Use:   "repair",
+		Short: "Repair the inconsistencies found by verify that can be fixed automatically",
+		Long: `Repair the inconsistencies found by verify that can be fixed automatically.
+
+The index of receipts by invocation is rebuilt for receipts missing from it,
+and entries pointing at receipts that are not stored are removed. Records that
+cannot be decoded are only deleted with --drop-corrupt, dump them first to keep
+a copy. The node must be stopped.`,
+		Args: cobra.NoArgs,
+		RunE: doRepair,
+	}
+)
+
+func init() {
+	prefixesCmd.Flags().Int("depth", 1, "Number of key namespaces to group entries by")
+	dumpCmd.Flags().String("prefix", "", "Only dump the entries under this key prefix")
+	dumpCmd.Flags().StringP("output", "o", "", "File to write the dump to (default stdout)")
+	repairCmd.Flags().Bool("drop-corrupt", false, "Delete records that cannot be decoded")
+
+	Cmd.AddCommand(prefixesCmd)
+	Cmd.AddCommand(dumpCmd)
+	Cmd.AddCommand(restoreCmd)
+	Cmd.AddCommand(verifyCmd)
+	Cmd.AddCommand(repairCmd)
+}
+
+func doPrefixes(cmd *cobra.Command, args []string) error {
+	depth, _ := cmd.Flags().GetInt("depth")
+
+	s, err := openStores(true)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	ds, err := s.Open(args[0])
+	if err != nil {
+		return err
+	}
+
+	stats, err := inspect.Prefixes(cmd.Context(), ds, depth)
+	if err != nil {
+		return fmt.Errorf("counting entries: %w", err)
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PREFIX\tENTRIES\tBYTES")
+	for _, st := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", st.Prefix, st.Entries, st.Bytes)
+	}
+	return w.Flush()
+}
+
+func doDump(cmd *cobra.Command, args []string) error {
+	prefix, _ := cmd.Flags().GetString("prefix")
+	output, _ := cmd.Flags().GetString("output")
+
+	s, err := openStores(true)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	ds, err := s.Open(args[0])
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("creating dump file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	n, err := inspect.Dump(cmd.Context(), w, ds, prefix)
+	if err != nil {
+		return fmt.Errorf("dumping store: %w", err)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "dumped %d entries\n", n)
+	return nil
+}
+
+func doRestore(cmd *cobra.Command, args []string) error {
+	var r io.Reader = cmd.InOrStdin()
+	if args[1] != "-" {
+		f, err := os.Open(args[1])
+		if err != nil {
+			return fmt.Errorf("opening dump file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	s, err := openStores(false)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	ds, err := s.Open(args[0])
+	if err != nil {
+		return err
+	}
+
+	n, err := inspect.Restore(cmd.Context(), ds, r)
+	if err != nil {
+		return fmt.Errorf("restoring store: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "restored %d entries\n", n)
+	return nil
+}
+
+func doVerify(cmd *cobra.Command, _ []string) error {
+	s, err := openStores(true)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	issues, err := verify(cmd, s)
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "no inconsistencies found")
+		return nil
+	}
+	if err := printIssues(cmd.OutOrStdout(), issues); err != nil {
+		return err
+	}
+	repairable := 0
+	for _, i := range issues {
+		if i.Repairable() {
+			repairable++
+		}
+	}
+	// a non-zero exit status for scripts, the issues are the report
+	cmd.SilenceUsage = true
+	return fmt.Errorf("found %d inconsistencies, %d can be fixed by repair", len(issues), repairable)
+}
+
+func doRepair(cmd *cobra.Command, _ []string) error {
+	dropCorrupt, _ := cmd.Flags().GetBool("drop-corrupt")
+
+	s, err := openStores(false)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	issues, err := verify(cmd, s)
+	if err != nil {
+		return err
+	}
+	var fix, remaining []inspect.Issue
+	for _, i := range issues {
+		if i.Repairable() && (i.Kind != inspect.CorruptRecord || dropCorrupt) {
+			fix = append(fix, i)
+		} else {
+			remaining = append(remaining, i)
+		}
+	}
+
+	n, err := inspect.Repair(cmd.Context(), fix)
+	if err != nil {
+		return fmt.Errorf("repaired %d of %d inconsistencies: %w", n, len(fix), err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "repaired %d inconsistencies\n", n)
+	if len(remaining) == 0 {
+		return nil
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "\nnot repaired:")
+	return printIssues(cmd.OutOrStdout(), remaining)
+}
+
+func verify(cmd *cobra.Command, s *stores) ([]inspect.Issue, error) {
+	var (
+		in  inspect.Stores
+		err error
+	)
+	// the node reads its allocations from the PDP database once it has them
+	if in.Allocations, err = s.sqlAllocations(cmd.Context()); err != nil {
+		return nil, fmt.Errorf("reading allocations of the PDP database: %w", err)
+	}
+	if in.Allocations == nil {
+		if in.Allocations, err = s.Open(inspect.AllocationStore); err != nil {
+			return nil, err
+		}
+	}
+	if in.Acceptances, err = s.Open(inspect.AcceptanceStore); err != nil {
+		return nil, err
+	}
+	if in.Claims, err = s.Open(inspect.ClaimStore); err != nil {
+		return nil, err
+	}
+	if in.Receipts, err = s.Open(inspect.ReceiptStore); err != nil {
+		return nil, err
+	}
+	issues, err := inspect.Verify(cmd.Context(), in)
+	if err != nil {
+		return nil, fmt.Errorf("verifying stores: %w", err)
+	}
+	return issues, nil
+}
+
+func printIssues(out io.Writer, issues []inspect.Issue) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tSTORE\tKEY\tDETAIL")
+	for _, i := range issues {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", i.Kind, i.Store, i.Key, i.Detail)
+	}
+	return w.Flush()
+}
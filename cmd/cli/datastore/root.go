@@ -0,0 +1,91 @@
+package datastore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/ipfs/go-datastore/namespace"
+	leveldb "github.com/ipfs/go-ds-leveldb"
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/config"
+	"github.com/storacha/piri/pkg/fx/store/filesystem"
+	"github.com/storacha/piri/pkg/store/sqliteds"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "datastore",
+	Short: "Manage the datastore of the local stores",
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy the LevelDB stores into a single SQLite datastore",
+	Long: `Copy the LevelDB stores in the data directory into a single SQLite datastore.
+
+The node must be stopped. After the copy, set repo.datastore to "sqlite" to use
+the SQLite datastore. The LevelDB directories are left in place and can be
+removed once the node runs correctly. Migrating again overwrites the copied
+entries.`,
+	Args: cobra.NoArgs,
+	RunE: doMigrate,
+}
+
+func init() {
+	Cmd.AddCommand(migrateCmd)
+}
+
+func doMigrate(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+
+	cfg, err := config.Load[config.LocalConfig]()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	dataDir := cfg.Repo.DataDir
+	if _, err := os.Stat(dataDir); err != nil {
+		return fmt.Errorf("data dir %s: %w", dataDir, err)
+	}
+
+	path := filepath.Join(dataDir, config.DatastoreFile)
+	dst, err := sqliteds.New(path)
+	if err != nil {
+		return fmt.Errorf("opening datastore: %w", err)
+	}
+	defer dst.Close()
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STORE\tENTRIES")
+	for _, rel := range filesystem.UnifiedStoreDirs {
+		dir := filepath.Join(dataDir, rel)
+		if _, err := os.Stat(dir); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(w, "%s\t-\n", rel)
+				continue
+			}
+			return fmt.Errorf("store %s: %w", rel, err)
+		}
+
+		src, err := leveldb.NewDatastore(dir, &leveldb.Options{ReadOnly: true})
+		if err != nil {
+			return fmt.Errorf("opening store %s, is the node running?: %w", rel, err)
+		}
+		n, err := sqliteds.Copy(ctx, namespace.Wrap(dst, filesystem.StoreNamespace(rel)), src)
+		if cerr := src.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("copying store %s: %w", rel, err)
+		}
+		fmt.Fprintf(w, "%s\t%d\n", rel, n)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "\nmigrated to %s, set repo.datastore = \"sqlite\" to use it\n", path)
+	return nil
+}
@@ -0,0 +1,323 @@
+package datastore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/fx/store/filesystem"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/storacha/piri/pkg/store/inspect"
+	"github.com/storacha/piri/pkg/store/objectstore/flatfs"
+)
+
+var (
+	snapshotCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Archive the local stores to move a node or recover it",
+	}
+
+	snapshotCreateCmd = &cobra.Command{
+		Use:   "create <file>",
+		Short: "Write a snapshot of the local stores and a manifest of the blobs",
+		Long: `Write a snapshot of the local stores to a file, "-" writes it to stdout.
+
+The snapshot is a gzipped tar archive holding a manifest, the entries of every
+local store (allocations, acceptances, claims, receipts, publisher head,
+aggregation buffer and the others kept in the datastore) and a list of the
+blobs in the blob store. Allocations are read from the PDP database once the
+node keeps them there. The blobs themselves are not included, copy the blob
+store directory, and the pack directory if blobs are packed, separately. The node must be stopped for the snapshot to be
+consistent.`,
+		Args: cobra.ExactArgs(1),
+		RunE: doSnapshotCreate,
+	}
+
+	snapshotRestoreCmd = &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Write the stores of a snapshot to the local stores",
+		Long: `Write the stores of a snapshot to the local stores, "-" reads the snapshot
+from stdin.
+
+Restoring to stores that already hold entries fails unless --force is set, in
+which case entries with the same keys are overwritten. Allocations are also
+written to the PDP database if it has an allocations table. Blobs listed in the
+snapshot are checked against the blob store, and those missing are reported.
+The node must be stopped.`,
+		Args: cobra.ExactArgs(1),
+		RunE: doSnapshotRestore,
+	}
+)
+
+func init() {
+	snapshotRestoreCmd.Flags().Bool("force", false, "Restore to stores that already hold entries")
+	snapshotRestoreCmd.Flags().String("missing-blobs", "", "File to write the digests of blobs missing from the blob store to")
+
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+	Cmd.AddCommand(snapshotCmd)
+}
+
+func doSnapshotCreate(cmd *cobra.Command, args []string) error {
+	s, err := openStores(true)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if s.s3 {
+		return errors.New("stores are kept in S3, snapshot the buckets instead")
+	}
+
+	allocations, err := s.sqlAllocations(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("reading allocations of the PDP database: %w", err)
+	}
+	var stores []inspect.SnapshotStore
+	for _, rel := range filesystem.UnifiedStoreDirs {
+		if rel == inspect.AllocationStore && allocations != nil {
+			// the node reads its allocations from the PDP database
+			stores = append(stores, inspect.SnapshotStore{Name: rel, DS: allocations})
+			continue
+		}
+		if s.shared == nil {
+			if _, err := os.Stat(filepath.Join(s.dataDir, rel)); errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+		}
+		ds, err := s.Open(rel)
+		if err != nil {
+			return err
+		}
+		stores = append(stores, inspect.SnapshotStore{Name: filepath.ToSlash(rel), DS: ds})
+	}
+
+	var blobs iter.Seq2[inspect.Blob, error]
+	dir := s.blobDir()
+	if _, err := os.Stat(dir); err == nil {
+		bs, err := s.openBlobstore()
+		if err != nil {
+			return err
+		}
+		blobs = listBlobs(cmd, bs)
+	}
+
+	w := cmd.OutOrStdout()
+	if args[0] != "-" {
+		f, err := os.Create(args[0])
+		if err != nil {
+			return fmt.Errorf("creating snapshot file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	manifest, err := inspect.CreateSnapshot(cmd.Context(), w, stores, blobs)
+	if err != nil {
+		return fmt.Errorf("creating snapshot: %w", err)
+	}
+	if f, ok := w.(*os.File); ok && args[0] != "-" {
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("writing snapshot file: %w", err)
+		}
+	}
+	return printManifest(cmd.ErrOrStderr(), manifest)
+}
+
+func doSnapshotRestore(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+	missingPath, _ := cmd.Flags().GetString("missing-blobs")
+
+	var r io.Reader = cmd.InOrStdin()
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("opening snapshot file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	s, err := openStores(false)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if s.s3 {
+		return errors.New("stores are kept in S3, restore the buckets instead")
+	}
+
+	sqlAllocations, err := s.openSQLAllocations()
+	if err != nil {
+		return err
+	}
+	if !force {
+		if sqlAllocations != nil {
+			n, err := sqlAllocations.Count(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				return errors.New("the PDP database already holds allocations, set --force to restore over them")
+			}
+		}
+		for _, rel := range filesystem.UnifiedStoreDirs {
+			ds, err := s.Open(rel)
+			if err != nil {
+				return err
+			}
+			empty, err := isEmpty(cmd, ds)
+			if err != nil {
+				return fmt.Errorf("checking store %s: %w", rel, err)
+			}
+			if !empty {
+				return fmt.Errorf("store %s already holds entries, set --force to restore over them", rel)
+			}
+		}
+	}
+
+	bs, err := s.openBlobstore()
+	if err != nil {
+		return err
+	}
+	var missing io.Writer = io.Discard
+	if missingPath != "" {
+		f, err := os.Create(missingPath)
+		if err != nil {
+			return fmt.Errorf("creating missing blobs file: %w", err)
+		}
+		defer f.Close()
+		missing = f
+	}
+	var listed, absent int
+	checkBlob := func(b inspect.Blob) error {
+		listed++
+		digest, err := digestutil.Parse(b.Digest)
+		if err != nil {
+			return fmt.Errorf("parsing blob digest %s: %w", b.Digest, err)
+		}
+		obj, err := bs.Get(cmd.Context(), digest)
+		if err == nil {
+			obj.Body().Close()
+			if obj.Size() == b.Size {
+				return nil
+			}
+		} else if !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("checking blob %s: %w", b.Digest, err)
+		}
+		absent++
+		_, err = fmt.Fprintln(missing, b.Digest)
+		return err
+	}
+
+	manifest, err := inspect.RestoreSnapshot(cmd.Context(), r, s.Open, checkBlob)
+	if err != nil {
+		return fmt.Errorf("restoring snapshot: %w", err)
+	}
+	if err := printManifest(cmd.OutOrStdout(), manifest); err != nil {
+		return err
+	}
+	// a node without the table imports the allocation store when it starts
+	if sqlAllocations != nil {
+		ds, err := s.Open(inspect.AllocationStore)
+		if err != nil {
+			return err
+		}
+		n, err := sqlAllocations.Import(cmd.Context(), allocationstore.NewDatastoreStore(ds).List(cmd.Context()))
+		if err != nil {
+			return fmt.Errorf("restoring allocations to the PDP database: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "\nrestored %d allocations to the PDP database\n", n)
+	}
+	if absent > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "\n%d of %d blobs are missing from the blob store, copy them from the old node\n", absent, listed)
+	}
+	return nil
+}
+
+// blobDir is the directory of the blob store.
+func (s *stores) blobDir() string {
+	return filepath.Join(s.dataDir, "pdp", "datastore")
+}
+
+// packDir is the directory of the packs of small blobs.
+func (s *stores) packDir() string {
+	return filepath.Join(s.dataDir, "pdp", "packs")
+}
+
+// openBlobstore opens the blob store, including the blobs packed in pack
+// files. The store is closed with the stores.
+func (s *stores) openBlobstore() (*blobstore.Store, error) {
+	objStore, err := flatfs.New(s.blobDir(), flatfs.NextToLast(2), false)
+	if err != nil {
+		return nil, fmt.Errorf("opening blob store: %w", err)
+	}
+	packStore, packIndex, err := filesystem.OpenPackStore(app.PackConfig{Dir: s.packDir()}, objStore)
+	if err != nil {
+		objStore.Close()
+		return nil, fmt.Errorf("opening blob packs: %w", err)
+	}
+	s.closers = append(s.closers, objStore)
+	if packStore == nil {
+		return blobstore.NewFlatfsStore(objStore), nil
+	}
+	// closed in reverse, the packs before their index
+	s.closers = append(s.closers, packIndex, packStore)
+	return blobstore.NewPackStore(packStore), nil
+}
+
+// listBlobs lists the digests and sizes of the blobs in the blob store.
+func listBlobs(cmd *cobra.Command, bs *blobstore.Store) iter.Seq2[inspect.Blob, error] {
+	return func(yield func(inspect.Blob, error) bool) {
+		for digest, err := range bs.List(cmd.Context()) {
+			if err != nil {
+				yield(inspect.Blob{}, err)
+				return
+			}
+			obj, err := bs.Get(cmd.Context(), digest)
+			if err != nil {
+				yield(inspect.Blob{}, fmt.Errorf("getting blob %s: %w", digestutil.Format(digest), err))
+				return
+			}
+			obj.Body().Close()
+			if !yield(inspect.Blob{Digest: digestutil.Format(digest), Size: obj.Size()}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func isEmpty(cmd *cobra.Command, ds datastore.Read) (bool, error) {
+	results, err := ds.Query(cmd.Context(), query.Query{KeysOnly: true, Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	defer results.Close()
+	for r := range results.Next() {
+		if r.Error != nil {
+			return false, r.Error
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+func printManifest(out io.Writer, m inspect.Manifest) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STORE\tENTRIES")
+	for _, st := range m.Stores {
+		fmt.Fprintf(w, "%s\t%d\n", st.Name, st.Entries)
+	}
+	fmt.Fprintf(w, "\nblobs\t%d (%d bytes)\n", m.Blobs.Count, m.Blobs.Bytes)
+	return w.Flush()
+}
@@ -0,0 +1,159 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	dssync "github.com/ipfs/go-datastore/sync"
+	leveldb "github.com/ipfs/go-ds-leveldb"
+
+	"github.com/storacha/piri/pkg/config"
+	"github.com/storacha/piri/pkg/fx/database"
+	"github.com/storacha/piri/pkg/fx/store/filesystem"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/sqliteds"
+)
+
+// stores opens the datastores of the local stores of a stopped node, with the
+// backend set by repo.datastore.
+type stores struct {
+	repo     config.RepoConfig
+	dataDir  string
+	readOnly bool
+	// s3 is set when the stores are kept in S3 rather than the data directory.
+	s3     bool
+	shared *sqliteds.Datastore
+	// opened holds the stores already opened, as a LevelDB store can only be
+	// opened once at a time.
+	opened  map[string]datastore.Batching
+	closers []io.Closer
+}
+
+func openStores(readOnly bool) (*stores, error) {
+	cfg, err := config.Load[config.LocalConfig]()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	dataDir := cfg.Repo.DataDir
+	if _, err := os.Stat(dataDir); err != nil {
+		return nil, fmt.Errorf("data dir %s: %w", dataDir, err)
+	}
+
+	s := &stores{
+		repo:     cfg.Repo,
+		dataDir:  dataDir,
+		readOnly: readOnly,
+		s3:       cfg.Repo.S3.IsConfigured(),
+		opened:   map[string]datastore.Batching{},
+	}
+	if cfg.Repo.Datastore == "sqlite" {
+		shared, err := sqliteds.New(filepath.Join(dataDir, config.DatastoreFile))
+		if err != nil {
+			return nil, fmt.Errorf("opening datastore: %w", err)
+		}
+		s.shared = shared
+		s.closers = append(s.closers, shared)
+	}
+	return s, nil
+}
+
+// Open opens the datastore of the store in the directory rel of the data
+// directory, one of [filesystem.UnifiedStoreDirs].
+func (s *stores) Open(rel string) (datastore.Batching, error) {
+	rel = filepath.Clean(filepath.FromSlash(rel))
+	if !slices.Contains(filesystem.UnifiedStoreDirs, rel) {
+		return nil, fmt.Errorf("unknown store %q, expected one of %v", rel, filesystem.UnifiedStoreDirs)
+	}
+	if s.shared != nil {
+		return namespace.Wrap(s.shared, filesystem.StoreNamespace(rel)), nil
+	}
+	if ds, ok := s.opened[rel]; ok {
+		return ds, nil
+	}
+
+	dir := filepath.Join(s.dataDir, rel)
+	if _, err := os.Stat(dir); err != nil {
+		if errors.Is(err, os.ErrNotExist) && s.readOnly {
+			return nil, fmt.Errorf("store %s has no directory in %s", rel, s.dataDir)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("store %s: %w", rel, err)
+		}
+	}
+	ds, err := leveldb.NewDatastore(dir, &leveldb.Options{ReadOnly: s.readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("opening store %s, is the node running?: %w", rel, err)
+	}
+	s.closers = append(s.closers, ds)
+	s.opened[rel] = ds
+	return ds, nil
+}
+
+// openSQLAllocations opens the allocations table of the PDP database, where
+// the node keeps its allocations once it has started with it, rather than in
+// the allocation store. It returns nil if the database has no allocations
+// table. The database is closed with the stores.
+func (s *stores) openSQLAllocations() (*allocationstore.SQLStore, error) {
+	storageCfg, err := s.repo.ToAppConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading repo config: %w", err)
+	}
+	if !storageCfg.Database.IsPostgres() {
+		// do not create the database of a node that never started
+		if _, err := os.Stat(filepath.Join(s.dataDir, "pdp", "state", "state.db")); errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+	}
+	db, err := database.NewTaskEngineDB(storageCfg)
+	if err != nil {
+		return nil, fmt.Errorf("opening PDP database: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("opening PDP database: %w", err)
+	}
+	s.closers = append(s.closers, sqlDB)
+	if !db.Migrator().HasTable(&models.AllocationRecord{}) {
+		return nil, nil
+	}
+	return allocationstore.NewSQLStore(db), nil
+}
+
+// sqlAllocations returns the allocations of the PDP database copied to an
+// in-memory datastore, in the format of the allocation store, so they are
+// snapshotted and verified like the other stores. It returns nil if the
+// database has no allocations table.
+func (s *stores) sqlAllocations(ctx context.Context) (datastore.Batching, error) {
+	src, err := s.openSQLAllocations()
+	if err != nil || src == nil {
+		return nil, err
+	}
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+	dst := allocationstore.NewDatastoreStore(ds)
+	for alloc, err := range src.List(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		if err := dst.Put(ctx, alloc); err != nil {
+			return nil, fmt.Errorf("copying allocation: %w", err)
+		}
+	}
+	return ds, nil
+}
+
+func (s *stores) Close() error {
+	var errs []error
+	// stores are closed before the shared datastore they may be namespaces of
+	for _, c := range slices.Backward(s.closers) {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
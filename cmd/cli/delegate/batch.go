@@ -0,0 +1,265 @@
+package delegate
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/capabilities/blob/replica"
+	"github.com/storacha/go-libstoracha/capabilities/pdp"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+
+	"github.com/storacha/piri/lib/sealbox"
+	"github.com/storacha/piri/pkg/config"
+)
+
+// templates are named sets of capabilities that may be used in place of
+// abilities in a batch.
+var templates = map[string][]string{
+	// the capabilities granted by `piri delegate generate`
+	"storage": {
+		blob.AllocateAbility,
+		blob.AcceptAbility,
+		pdp.InfoAbility,
+		replica.AllocateAbility,
+	},
+}
+
+var IssueBatchCmd = &cobra.Command{
+	Use:   "issue-batch <file>",
+	Args:  cobra.ExactArgs(1),
+	Short: `Issue a delegation to each audience listed in a CSV or JSON file`,
+	Long: `Issue a delegation to each audience listed in a CSV or JSON file.
+
+A JSON file holds an array of objects:
+
+  [{"audience": "did:key:...", "capabilities": ["storage"], "expiration": 1767225600}]
+
+A CSV file has a header row with the columns audience, capabilities and
+optionally expiration. Capabilities are separated by spaces:
+
+  audience,capabilities,expiration
+  did:key:...,storage,1767225600
+
+Capabilities are either abilities such as blob/allocate, or the name of a
+template: storage. Entries default to the storage template and delegations
+do not expire unless an expiration (unix seconds) is given.
+
+One JSON object is written per line for each delegation issued. With
+--encrypt, delegations are sealed to their audience, which must be an
+ed25519 did:key, and can only be read by the holder of its private key.`,
+	RunE: doIssueBatch,
+}
+
+func init() {
+	IssueBatchCmd.Flags().Bool("encrypt", false, "Encrypt each delegation to its audience")
+	IssueBatchCmd.Flags().String("output", "", "File to write issued delegations to (default stdout)")
+	Cmd.AddCommand(IssueBatchCmd)
+}
+
+// BatchEntry describes a delegation to issue.
+type BatchEntry struct {
+	Audience     string   `json:"audience"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Expiration   *int     `json:"expiration,omitempty"`
+}
+
+// IssuedDelegation is written for each delegation issued. Delegation holds
+// the delegation formatted as by `piri delegate generate`, or Sealed holds
+// the base64 encoded archive encrypted to the audience.
+type IssuedDelegation struct {
+	Audience   string `json:"audience"`
+	CID        string `json:"cid"`
+	Delegation string `json:"delegation,omitempty"`
+	Sealed     string `json:"sealed,omitempty"`
+}
+
+func doIssueBatch(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load[config.Client]()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	id, err := cfg.Identity.LoadSigner()
+	if err != nil {
+		return fmt.Errorf("loading identity: %w", err)
+	}
+	encrypt, err := cmd.Flags().GetBool("encrypt")
+	if err != nil {
+		return fmt.Errorf("getting --encrypt flag: %w", err)
+	}
+
+	entries, err := readBatch(args[0])
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if path, _ := cmd.Flags().GetString("output"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("creating output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	for i, e := range entries {
+		issued, err := issue(id, e, encrypt)
+		if err != nil {
+			return fmt.Errorf("entry %d (%s): %w", i+1, e.Audience, err)
+		}
+		if err := enc.Encode(issued); err != nil {
+			return fmt.Errorf("writing delegation: %w", err)
+		}
+	}
+	cmd.PrintErrf("issued %d delegations\n", len(entries))
+	return nil
+}
+
+func issue(issuer ucan.Signer, e BatchEntry, encrypt bool) (IssuedDelegation, error) {
+	audience, err := did.Parse(e.Audience)
+	if err != nil {
+		return IssuedDelegation{}, fmt.Errorf("parsing audience: %w", err)
+	}
+	abilities, err := expandCapabilities(e.Capabilities)
+	if err != nil {
+		return IssuedDelegation{}, err
+	}
+	opts := []delegation.Option{delegation.WithNoExpiration()}
+	if e.Expiration != nil {
+		opts = []delegation.Option{delegation.WithExpiration(*e.Expiration)}
+	}
+
+	d, err := MakeDelegation(issuer, audience, abilities, opts...)
+	if err != nil {
+		return IssuedDelegation{}, fmt.Errorf("creating delegation: %w", err)
+	}
+	archive, err := io.ReadAll(d.Archive())
+	if err != nil {
+		return IssuedDelegation{}, fmt.Errorf("archiving delegation: %w", err)
+	}
+
+	issued := IssuedDelegation{Audience: audience.String(), CID: d.Link().String()}
+	if encrypt {
+		sealed, err := sealbox.Seal(archive, audience)
+		if err != nil {
+			return IssuedDelegation{}, fmt.Errorf("encrypting delegation: %w", err)
+		}
+		issued.Sealed = base64.StdEncoding.EncodeToString(sealed)
+		return issued, nil
+	}
+	issued.Delegation, err = FormatDelegationBytes(archive)
+	if err != nil {
+		return IssuedDelegation{}, fmt.Errorf("formatting delegation: %w", err)
+	}
+	return issued, nil
+}
+
+// expandCapabilities replaces template names with their abilities. No
+// capabilities means the storage template.
+func expandCapabilities(caps []string) ([]string, error) {
+	if len(caps) == 0 {
+		caps = []string{"storage"}
+	}
+	var abilities []string
+	seen := map[string]bool{}
+	for _, c := range caps {
+		expanded, ok := templates[c]
+		if !ok {
+			if !strings.Contains(c, "/") {
+				return nil, fmt.Errorf("unknown capability template: %s", c)
+			}
+			expanded = []string{c}
+		}
+		for _, a := range expanded {
+			if !seen[a] {
+				seen[a] = true
+				abilities = append(abilities, a)
+			}
+		}
+	}
+	return abilities, nil
+}
+
+func readBatch(path string) ([]BatchEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening batch file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []BatchEntry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.NewDecoder(f).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("decoding batch file: %w", err)
+		}
+	case ".csv":
+		entries, err = readCSVBatch(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading batch file: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported batch file type %q, expected .json or .csv", filepath.Ext(path))
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("batch file contains no entries")
+	}
+	return entries, nil
+}
+
+func readCSVBatch(r io.Reader) ([]BatchEntry, error) {
+	cr := csv.NewReader(r)
+	// trailing optional columns may be omitted
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	cols := map[string]int{}
+	for i, name := range rows[0] {
+		cols[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	if _, ok := cols["audience"]; !ok {
+		return nil, fmt.Errorf("missing audience column")
+	}
+	field := func(row []string, name string) string {
+		i, ok := cols[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	entries := make([]BatchEntry, 0, len(rows)-1)
+	for n, row := range rows[1:] {
+		e := BatchEntry{
+			Audience:     field(row, "audience"),
+			Capabilities: strings.Fields(field(row, "capabilities")),
+		}
+		if exp := field(row, "expiration"); exp != "" {
+			v, err := strconv.Atoi(exp)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: parsing expiration: %w", n+2, err)
+			}
+			e.Expiration = &v
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
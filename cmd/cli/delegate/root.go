@@ -14,7 +14,6 @@ import (
 	"github.com/storacha/go-ucanto/did"
 	"github.com/storacha/go-ucanto/principal/signer"
 
-	"github.com/storacha/piri/cmd/cliutil"
 	"github.com/storacha/piri/pkg/config"
 )
 
@@ -66,9 +65,9 @@ func doGenerate(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("loading config: %w", err)
 	}
 
-	id, err := cliutil.ReadPrivateKeyFromPEM(cfg.Identity.KeyFile)
+	id, err := cfg.Identity.LoadSigner()
 	if err != nil {
-		return fmt.Errorf("parsing private key: %w", err)
+		return fmt.Errorf("loading identity: %w", err)
 	}
 
 	if cmd.Flags().Changed("client-web-did") {
@@ -4,13 +4,18 @@ import (
 	"bytes"
 	crypto_ed25519 "crypto/ed25519"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"io"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/storacha/go-ucanto/principal"
 	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+
+	"github.com/storacha/piri/lib"
+	"github.com/storacha/piri/pkg/didweb"
 )
 
 var (
@@ -22,6 +27,7 @@ var (
 Specifically for generating and managing Ed25519 keys used in DID (Decentralized Identifier) systems.
   - Generate new Ed25519 key pairs encoded in PEM-format
   - Extract DID from PEM file
+  - Print the DID document of a did:web identity
 `,
 	}
 
@@ -45,14 +51,31 @@ The DID is printed to stderr for convenience.
 		Example: `piri identity parse my-key.pem`,
 		RunE:    doParse,
 	}
+
+	DocumentCmd = &cobra.Command{
+		Use:   "document <pem-file>",
+		Short: "print the DID document of a did:web identity signing with an Ed25519 key",
+		Long: `Print the DID document a node operating as did:web serves at /.well-known/did.json.
+The key in the PEM file is the current key, keys rotated out are passed with --previous.
+`,
+		Args:    cobra.ExactArgs(1),
+		Example: `piri identity document new-key.pem --did did:web:piri.example.com --previous old-key.pem`,
+		RunE:    doDocument,
+	}
 )
 
 func init() {
 	Cmd.AddCommand(GenerateCmd)
 	Cmd.AddCommand(ParseCmd)
+	Cmd.AddCommand(DocumentCmd)
 	Cmd.SetHelpFunc(identityHelpFunc())
 	GenerateCmd.SetHelpFunc(identityHelpFunc())
 	ParseCmd.SetHelpFunc(identityHelpFunc())
+	DocumentCmd.SetHelpFunc(identityHelpFunc())
+
+	DocumentCmd.Flags().String("did", "", "did:web the node operates as")
+	DocumentCmd.Flags().StringSlice("previous", nil, "PEM files of keys rotated out, most recent first")
+	cobra.CheckErr(DocumentCmd.MarkFlagRequired("did"))
 }
 
 func doGenerate(cmd *cobra.Command, _ []string) error {
@@ -119,6 +142,36 @@ func doParse(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func doDocument(cmd *cobra.Command, args []string) error {
+	didStr, _ := cmd.Flags().GetString("did")
+	previousFiles, _ := cmd.Flags().GetStringSlice("previous")
+
+	id, err := didweb.Parse(didStr)
+	if err != nil {
+		return fmt.Errorf("parsing did: %w", err)
+	}
+	key, err := lib.SignerFromEd25519PEMFile(args[0])
+	if err != nil {
+		return fmt.Errorf("loading key: %w", err)
+	}
+	var previous []principal.Verifier
+	for _, f := range previousFiles {
+		prev, err := lib.SignerFromEd25519PEMFile(f)
+		if err != nil {
+			return fmt.Errorf("loading previous key %s: %w", f, err)
+		}
+		previous = append(previous, prev.Verifier())
+	}
+
+	out, err := json.MarshalIndent(didweb.NewDocument(id, key.Verifier(), previous...), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding DID document: %w", err)
+	}
+	cmd.SetOut(os.Stdout)
+	cmd.Println(string(out))
+	return nil
+}
+
 func identityHelpFunc() func(cmd *cobra.Command, args []string) {
 	return func(cmd *cobra.Command, args []string) {
 		fmt.Printf("Usage:\n  %s\n\n", cmd.UseLine())
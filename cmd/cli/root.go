@@ -14,12 +14,15 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/storacha/piri/cmd/cli/client"
+	"github.com/storacha/piri/cmd/cli/config"
+	"github.com/storacha/piri/cmd/cli/datastore"
 	"github.com/storacha/piri/cmd/cli/delegate"
 	"github.com/storacha/piri/cmd/cli/identity"
 	"github.com/storacha/piri/cmd/cli/serve"
 	"github.com/storacha/piri/cmd/cli/setup"
 	"github.com/storacha/piri/cmd/cli/status"
 	"github.com/storacha/piri/cmd/cli/wallet"
+	"github.com/storacha/piri/cmd/cliutil"
 	"github.com/storacha/piri/pkg/build"
 )
 
@@ -58,18 +61,18 @@ func init() {
 	rootCmd.PersistentFlags().String("data-dir", filepath.Join(lo.Must(os.UserHomeDir()), ".storacha"), "Storage service data directory")
 	cobra.CheckErr(viper.BindPFlag("repo.data_dir", rootCmd.PersistentFlags().Lookup("data-dir")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("repo.data_dir", "PIRI_DATA_DIR"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("repo.data_dir", "PIRI_DATA_DIR"))
 
 	rootCmd.PersistentFlags().String("temp-dir", filepath.Join(os.TempDir(), "storage"), "Storage service temp directory")
 	cobra.CheckErr(viper.BindPFlag("repo.temp_dir", rootCmd.PersistentFlags().Lookup("temp-dir")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("repo.temp_dir", "PIRI_TEMP_DIR"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("repo.temp_dir", "PIRI_TEMP_DIR"))
 
 	rootCmd.PersistentFlags().String("key-file", "", "Path to a PEM file containing ed25519 private key")
 	cobra.CheckErr(rootCmd.MarkPersistentFlagFilename("key-file", "pem"))
 	cobra.CheckErr(viper.BindPFlag("identity.key_file", rootCmd.PersistentFlags().Lookup("key-file")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("identity.key_file", "PIRI_KEY_FILE"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("identity.key_file", "PIRI_KEY_FILE"))
 
 	// register all commands and their subcommands
 	rootCmd.AddCommand(serve.Cmd)
@@ -78,6 +81,8 @@ func init() {
 	rootCmd.AddCommand(delegate.Cmd)
 	rootCmd.AddCommand(client.Cmd)
 	rootCmd.AddCommand(status.Cmd)
+	rootCmd.AddCommand(config.Cmd)
+	rootCmd.AddCommand(datastore.Cmd)
 
 	rootCmd.AddCommand(setup.InitCmd)
 	rootCmd.AddCommand(setup.InstallCmd)
@@ -90,7 +95,8 @@ func init() {
 func initConfig() {
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	viper.SetEnvPrefix("PIRI")
+	viper.SetEnvPrefix(cliutil.EnvPrefix)
+	cliutil.WarnLegacyEnv()
 
 	if cfgFile == "" {
 		if configDir, err := os.UserConfigDir(); err == nil {
@@ -7,7 +7,6 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"github.com/storacha/go-ucanto/did"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 
@@ -17,307 +16,287 @@ import (
 
 	"github.com/storacha/piri/cmd/cli/setup"
 	"github.com/storacha/piri/cmd/cliutil"
+	"github.com/storacha/piri/pkg/anchor"
+	"github.com/storacha/piri/pkg/build"
+	"github.com/storacha/piri/pkg/cdn"
 	"github.com/storacha/piri/pkg/config"
 	appconfig "github.com/storacha/piri/pkg/config/app"
 	"github.com/storacha/piri/pkg/fx/app"
 	"github.com/storacha/piri/pkg/health"
 	"github.com/storacha/piri/pkg/presets"
+	"github.com/storacha/piri/pkg/service/renewer"
 	"github.com/storacha/piri/pkg/telemetry"
+	"github.com/storacha/piri/pkg/webhook"
 )
 
 var (
+	// FullCmd is kept so existing scripts running `piri serve full` keep
+	// working, it accepts the same flags as `piri serve`.
 	FullCmd = &cobra.Command{
-		Use:   "full",
-		Short: "Start the full piri server!",
-		Args:  cobra.NoArgs,
-		RunE:  fullServer,
+		Use:        "full",
+		Short:      "Start the full piri server!",
+		Args:       cobra.NoArgs,
+		RunE:       fullServer,
+		Deprecated: "use 'piri serve' instead.",
 	}
 )
 
 func init() {
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"pdp-server-url",
 		"",
 		"URL used to connect to pdp server",
 	)
-	cobra.CheckErr(viper.BindPFlag("pdp_server_url", FullCmd.Flags().Lookup("pdp-server-url")))
+	cobra.CheckErr(viper.BindPFlag("pdp_server_url", Cmd.PersistentFlags().Lookup("pdp-server-url")))
 
-	FullCmd.Flags().Uint64(
+	Cmd.PersistentFlags().Uint64(
 		"proof-set",
 		0,
 		"Proofset to use with PDP",
 	)
-	cobra.CheckErr(viper.BindPFlag("ucan.proof_set", FullCmd.Flags().Lookup("proof-set")))
+	cobra.CheckErr(viper.BindPFlag("ucan.proof_set", Cmd.PersistentFlags().Lookup("proof-set")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("ucan.proof_set", "PIRI_PROOF_SET"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("ucan.proof_set", "PIRI_PROOF_SET"))
+
+	Cmd.PersistentFlags().Bool(
+		"auto-create-proof-set",
+		false,
+		"Create a proof set for the first aggregate when the node has none",
+	)
+	cobra.CheckErr(viper.BindPFlag("ucan.auto_create_proof_set", Cmd.PersistentFlags().Lookup("auto-create-proof-set")))
+
+	Cmd.PersistentFlags().Bool(
+		"read-only",
+		false,
+		"Refuse allocations, replicas and uploads while serving retrievals and claims",
+	)
+	cobra.CheckErr(viper.BindPFlag("ucan.read_only", Cmd.PersistentFlags().Lookup("read-only")))
 
 	// Developer only: enable HTTP (instead of HTTPS) for did:web resolution
 	cobra.CheckErr(viper.BindEnv("ucan.insecure_did_resolution", "PIRI_INSECURE_DID_RESOLUTION"))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"network",
-		"",
-		fmt.Sprintf("Network the node will operate on. This will set default values for service URLs and DIDs and contract addresses. Available values are: %q", presets.AvailableNetworks),
+		build.Network,
+		fmt.Sprintf("Network the node will operate on. This will set default values for service URLs and DIDs and contract addresses. Available values are: %q, or the name of a profile in the config file", presets.AvailableNetworks),
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("network"))
-	cobra.CheckErr(viper.BindPFlag("network", FullCmd.Flags().Lookup("network")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("network"))
+	cobra.CheckErr(viper.BindPFlag("network", Cmd.PersistentFlags().Lookup("network")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"indexing-service-proof",
 		"",
 		"A delegation that allows the node to cache claims with the indexing service",
 	)
-	cobra.CheckErr(viper.BindPFlag("ucan.services.indexer.proof", FullCmd.Flags().Lookup("indexing-service-proof")))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.indexer.proof", Cmd.PersistentFlags().Lookup("indexing-service-proof")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("ucan.services.indexer.proof", "PIRI_INDEXING_SERVICE_PROOF"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("ucan.services.indexer.proof", "PIRI_INDEXING_SERVICE_PROOF"))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"indexing-service-did",
 		"",
 		"[Advanced] DID of the indexing service. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("indexing-service-did"))
-	cobra.CheckErr(viper.BindPFlag("ucan.services.indexer.did", FullCmd.Flags().Lookup("indexing-service-did")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("indexing-service-did"))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.indexer.did", Cmd.PersistentFlags().Lookup("indexing-service-did")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("ucan.services.indexer.did", "PIRI_INDEXING_SERVICE_DID"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("ucan.services.indexer.did", "PIRI_INDEXING_SERVICE_DID"))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"indexing-service-url",
 		"",
 		"[Advanced] URL of the indexing service. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("indexing-service-url"))
-	cobra.CheckErr(viper.BindPFlag("ucan.services.indexer.url", FullCmd.Flags().Lookup("indexing-service-url")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("indexing-service-url"))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.indexer.url", Cmd.PersistentFlags().Lookup("indexing-service-url")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("ucan.services.indexer.url", "PIRI_INDEXING_SERVICE_URL"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("ucan.services.indexer.url", "PIRI_INDEXING_SERVICE_URL"))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"egress-tracker-service-proof",
 		"",
 		"A delegation that allows the node to track egress with the egress tracker service",
 	)
-	cobra.CheckErr(viper.BindPFlag("ucan.services.etracker.proof", FullCmd.Flags().Lookup("egress-tracker-service-proof")))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.etracker.proof", Cmd.PersistentFlags().Lookup("egress-tracker-service-proof")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"egress-tracker-service-did",
 		"",
 		"[Advanced] DID of the egress tracker service. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("egress-tracker-service-did"))
-	cobra.CheckErr(viper.BindPFlag("ucan.services.etracker.did", FullCmd.Flags().Lookup("egress-tracker-service-did")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("egress-tracker-service-did"))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.etracker.did", Cmd.PersistentFlags().Lookup("egress-tracker-service-did")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"egress-tracker-service-url",
 		"",
 		"[Advanced] URL of the egress tracker service. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("egress-tracker-service-url"))
-	cobra.CheckErr(viper.BindPFlag("ucan.services.etracker.url", FullCmd.Flags().Lookup("egress-tracker-service-url")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("egress-tracker-service-url"))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.etracker.url", Cmd.PersistentFlags().Lookup("egress-tracker-service-url")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"egress-tracker-service-receipts-endpoint",
 		"",
 		"[Advanced] URL of the egress tracker service receipts endpoint. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("egress-tracker-service-receipts-endpoint"))
-	cobra.CheckErr(viper.BindPFlag("ucan.services.etracker.receipts_endpoint", FullCmd.Flags().Lookup("egress-tracker-service-receipts-endpoint")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("egress-tracker-service-receipts-endpoint"))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.etracker.receipts_endpoint", Cmd.PersistentFlags().Lookup("egress-tracker-service-receipts-endpoint")))
 
-	FullCmd.Flags().Int64(
+	Cmd.PersistentFlags().Int64(
 		"egress-tracker-service-max-batch-size-bytes",
 		config.DefaultMinimumEgressBatchSize,
 		"Maximum batch size in bytes for egress tracker service. It should be between 10MiB and 1GiB",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("egress-tracker-service-max-batch-size-bytes"))
-	cobra.CheckErr(viper.BindPFlag("ucan.services.etracker.max_batch_size_bytes", FullCmd.Flags().Lookup("egress-tracker-service-max-batch-size-bytes")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("egress-tracker-service-max-batch-size-bytes"))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.etracker.max_batch_size_bytes", Cmd.PersistentFlags().Lookup("egress-tracker-service-max-batch-size-bytes")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"upload-service-did",
 		"",
 		"[Advanced] DID of the upload service. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("upload-service-did"))
-	cobra.CheckErr(viper.BindPFlag("ucan.services.upload.did", FullCmd.Flags().Lookup("upload-service-did")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("upload-service-did"))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.upload.did", Cmd.PersistentFlags().Lookup("upload-service-did")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("ucan.services.upload.did", "PIRI_UPLOAD_SERVICE_DID"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("ucan.services.upload.did", "PIRI_UPLOAD_SERVICE_DID"))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"upload-service-url",
 		"",
 		"[Advanced] URL of the upload service. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("upload-service-url"))
-	cobra.CheckErr(viper.BindPFlag("ucan.services.upload.url", FullCmd.Flags().Lookup("upload-service-url")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("upload-service-url"))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.upload.url", Cmd.PersistentFlags().Lookup("upload-service-url")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("ucan.services.upload.url", "PIRI_UPLOAD_SERVICE_URL"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("ucan.services.upload.url", "PIRI_UPLOAD_SERVICE_URL"))
 
-	FullCmd.Flags().StringSlice(
+	Cmd.PersistentFlags().StringSlice(
 		"ipni-announce-urls",
 		[]string{},
 		"[Advanced] A list of IPNI announce URLs. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("ipni-announce-urls"))
-	cobra.CheckErr(viper.BindPFlag("ucan.services.publisher.ipni_announce_urls", FullCmd.Flags().Lookup("ipni-announce-urls")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("ipni-announce-urls"))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.publisher.ipni_announce_urls", Cmd.PersistentFlags().Lookup("ipni-announce-urls")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("ucan.services.publisher.ipni_announce_urls", "PIRI_IPNI_ANNOUNCE_URLS"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("ucan.services.publisher.ipni_announce_urls", "PIRI_IPNI_ANNOUNCE_URLS"))
 
-	FullCmd.Flags().StringToString(
+	Cmd.PersistentFlags().StringToString(
 		"service-principal-mapping",
 		map[string]string{},
 		"[Advanced] Mapping of service DIDs to principal DIDs. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("service-principal-mapping"))
-	cobra.CheckErr(viper.BindPFlag("ucan.services.principal_mapping", FullCmd.Flags().Lookup("service-principal-mapping")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("service-principal-mapping"))
+	cobra.CheckErr(viper.BindPFlag("ucan.services.principal_mapping", Cmd.PersistentFlags().Lookup("service-principal-mapping")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("ucan.services.principal_mapping", "PIRI_SERVICE_PRINCIPAL_MAPPING"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("ucan.services.principal_mapping", "PIRI_SERVICE_PRINCIPAL_MAPPING"))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"lotus-url",
 		"",
 		"A websocket url for lotus node",
 	)
-	cobra.CheckErr(viper.BindPFlag("pdp.lotus_endpoint", FullCmd.Flags().Lookup("lotus-url")))
+	cobra.CheckErr(viper.BindPFlag("pdp.lotus_endpoint", Cmd.PersistentFlags().Lookup("lotus-url")))
+
+	Cmd.PersistentFlags().String(
+		"curio-url",
+		"",
+		"URL of the PDP API of a Curio node, proof sets added with the curio backend are proven by it",
+	)
+	cobra.CheckErr(viper.BindPFlag("pdp.curio.url", Cmd.PersistentFlags().Lookup("curio-url")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"owner-address",
 		"",
 		"The ethereum address to submit PDP Proofs with (must be in piri wallet - see `piri wallet` command for help)",
 	)
-	cobra.CheckErr(viper.BindPFlag("pdp.owner_address", FullCmd.Flags().Lookup("owner-address")))
+	cobra.CheckErr(viper.BindPFlag("pdp.owner_address", Cmd.PersistentFlags().Lookup("owner-address")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"verifier-address",
 		"",
 		"[Advanced] PDP Verifier contract address. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("verifier-address"))
-	cobra.CheckErr(viper.BindPFlag("pdp.contracts.verifier", FullCmd.Flags().Lookup("verifier-address")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("verifier-address"))
+	cobra.CheckErr(viper.BindPFlag("pdp.contracts.verifier", Cmd.PersistentFlags().Lookup("verifier-address")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"provider-registry-address",
 		"",
 		"[Advanced] Provider Registry contract address. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("provider-registry-address"))
-	cobra.CheckErr(viper.BindPFlag("pdp.contracts.provider_registry", FullCmd.Flags().Lookup("provider-registry-address")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("provider-registry-address"))
+	cobra.CheckErr(viper.BindPFlag("pdp.contracts.provider_registry", Cmd.PersistentFlags().Lookup("provider-registry-address")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"service-address",
 		"",
 		"[Advanced] PDP Service contract address. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("service-address"))
-	cobra.CheckErr(viper.BindPFlag("pdp.contracts.service", FullCmd.Flags().Lookup("service-address")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("service-address"))
+	cobra.CheckErr(viper.BindPFlag("pdp.contracts.service", Cmd.PersistentFlags().Lookup("service-address")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"service-view-address",
 		"",
 		"[Advanced] Service View contract address. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("service-view-address"))
-	cobra.CheckErr(viper.BindPFlag("pdp.contracts.service_view", FullCmd.Flags().Lookup("service-view-address")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("service-view-address"))
+	cobra.CheckErr(viper.BindPFlag("pdp.contracts.service_view", Cmd.PersistentFlags().Lookup("service-view-address")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"chain-id",
 		"",
 		"[Advanced] Filecoin chain ID (314 for mainnet, 314159 for calibration). Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("chain-id"))
-	cobra.CheckErr(viper.BindPFlag("pdp.chain_id", FullCmd.Flags().Lookup("chain-id")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("chain-id"))
+	cobra.CheckErr(viper.BindPFlag("pdp.chain_id", Cmd.PersistentFlags().Lookup("chain-id")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"payer-address",
 		"",
 		"[Advanced] Address of the wallet that pays SPs. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("payer-address"))
-	cobra.CheckErr(viper.BindPFlag("pdp.payer_address", FullCmd.Flags().Lookup("payer-address")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("payer-address"))
+	cobra.CheckErr(viper.BindPFlag("pdp.payer_address", Cmd.PersistentFlags().Lookup("payer-address")))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"contract-address",
 		"",
 		"The ethereum address of the PDP Contract",
 	)
-	cobra.CheckErr(FullCmd.Flags().MarkDeprecated("contract-address", "The contract-address flag is deprecated. Use --verifier-address instead."))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkDeprecated("contract-address", "use --verifier-address instead."))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"contract-signing-service-did",
 		"",
 		"[Advanced] DID of the contract signing service. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(viper.BindPFlag("pdp.signing_service.did", FullCmd.Flags().Lookup("contract-signing-service-did")))
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("contract-signing-service-did"))
+	cobra.CheckErr(viper.BindPFlag("pdp.signing_service.did", Cmd.PersistentFlags().Lookup("contract-signing-service-did")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("contract-signing-service-did"))
 
-	FullCmd.Flags().String(
+	Cmd.PersistentFlags().String(
 		"contract-signing-service-url",
 		"",
 		"[Advanced] URL of the contract signing service. Only change if you know what you're doing. Use --network flag to set proper defaults.",
 	)
-	cobra.CheckErr(viper.BindPFlag("pdp.signing_service.url", FullCmd.Flags().Lookup("contract-signing-service-url")))
-	cobra.CheckErr(FullCmd.Flags().MarkHidden("contract-signing-service-url"))
+	cobra.CheckErr(viper.BindPFlag("pdp.signing_service.url", Cmd.PersistentFlags().Lookup("contract-signing-service-url")))
+	cobra.CheckErr(Cmd.PersistentFlags().MarkHidden("contract-signing-service-url"))
 }
 
-func loadPresets() error {
-	networkStr := viper.GetString("network")
-	if networkStr == "" {
-		// No network specified - all values must come from config/env/flags
-		return nil
-	}
-
-	network, err := presets.ParseNetwork(networkStr)
-	if err != nil {
-		return err
-	}
-
-	preset, err := presets.GetPreset(network)
-	if err != nil {
-		return err
-	}
-
-	// given the network, set the _default_ configuration values. These values will apply iff other config: flag, envvar,
-	// file are not provided. This allows users to selectively apply the changes they want via config sources, while
-	// using the remaining defaults for the provided network
-	urls := make([]string, len(preset.Services.IPNIAnnounceURLs))
-	for i, u := range preset.Services.IPNIAnnounceURLs {
-		urls[i] = u.String()
-	}
-	viper.SetDefault("ucan.services.publisher.ipni_announce_urls", urls)
-	viper.SetDefault("ucan.services.principal_mapping", preset.Services.PrincipalMapping)
-
-	viper.SetDefault("ucan.services.indexer.url", preset.Services.IndexingServiceURL.String())
-	viper.SetDefault("ucan.services.indexer.did", preset.Services.IndexingServiceDID.String())
-	viper.SetDefault("ucan.services.etracker.url", preset.Services.EgressTrackerServiceURL.String())
-	viper.SetDefault("ucan.services.etracker.did", preset.Services.EgressTrackerServiceDID.String())
-	viper.SetDefault("ucan.services.etracker.receipts_endpoint", preset.Services.EgressTrackerServiceURL.JoinPath("/receipts").String())
-	viper.SetDefault("ucan.services.upload.url", preset.Services.UploadServiceURL.String())
-	viper.SetDefault("ucan.services.upload.did", preset.Services.UploadServiceDID.String())
-
-	// the registrar and the signing service are not present in all environments
-	if preset.Services.SigningServiceURL != nil {
-		viper.SetDefault("pdp.signing_service.url", preset.Services.SigningServiceURL.String())
-	}
-	if preset.Services.SigningServiceDID != did.Undef {
-		viper.SetDefault("pdp.signing_service.did", preset.Services.SigningServiceDID.String())
-	}
-	if preset.Services.RegistrarServiceURL != nil {
-		viper.SetDefault("pdp.registrar_service.url", preset.Services.RegistrarServiceURL.String())
+func fullServer(cmd *cobra.Command, _ []string) error {
+	// the deprecated contract-address flag sets the verifier address, unless
+	// the verifier address is given explicitly
+	if flags := cmd.Flags(); flags.Changed("contract-address") && !flags.Changed("verifier-address") {
+		addr, err := flags.GetString("contract-address")
+		if err != nil {
+			return err
+		}
+		viper.Set("pdp.contracts.verifier", addr)
 	}
 
-	// smart contract defaults
-	viper.SetDefault("pdp.contracts.verifier", preset.SmartContracts.Verifier.String())
-	viper.SetDefault("pdp.contracts.provider_registry", preset.SmartContracts.ProviderRegistry.String())
-	viper.SetDefault("pdp.contracts.service", preset.SmartContracts.Service.String())
-	viper.SetDefault("pdp.contracts.service_view", preset.SmartContracts.ServiceView.String())
-	viper.SetDefault("pdp.contracts.payments", preset.SmartContracts.Payments.String())
-	viper.SetDefault("pdp.contracts.usdfc_token", preset.SmartContracts.USDFCToken.String())
-	viper.SetDefault("pdp.chain_id", preset.SmartContracts.ChainID.String())
-	viper.SetDefault("pdp.payer_address", preset.SmartContracts.PayerAddress.String())
-
-	return nil
-}
-
-func fullServer(cmd *cobra.Command, _ []string) error {
-	// Apply network presets before loading config, but only for flags that weren't explicitly set
-	if err := loadPresets(); err != nil {
+	// Apply network presets and profiles before loading config, but only for keys that weren't explicitly set
+	if err := config.ApplyNetwork(viper.GetString("network")); err != nil {
 		return fmt.Errorf("loading presets: %w", err)
 	}
 
@@ -333,7 +312,7 @@ func fullServer(cmd *cobra.Command, _ []string) error {
 
 	if err := initTelemetry(
 		cmd.Context(),
-		appCfg.Identity.Signer.DID().String(),
+		appCfg.Identity.ID().String(),
 		userCfg.Network,
 		appCfg.Storage.DataDir,
 		appCfg.Telemetry,
@@ -379,12 +358,28 @@ func fullServer(cmd *cobra.Command, _ []string) error {
 		//  - address wallet
 		app.PDPModule,
 
+		// optional anchoring of issued receipts and claims on chain. Included at
+		// the root since it decorates the receipt and claim stores.
+		anchor.Module,
+
+		// optional offload of blob downloads to a CDN. Included at the root
+		// since it decorates the blob store.
+		cdn.Module,
+
+		// notifications of issued receipts to registered webhooks. Included at
+		// the root since it decorates the receipt store.
+		webhook.Module,
+
+		// renewal of expiring location claims. Included at the root since it
+		// decorates the publisher.
+		renewer.Module,
+
 		// Post-startup operations: print server info and record telemetry
 		fx.Invoke(func(lc fx.Lifecycle) {
 			lc.Append(fx.Hook{
 				OnStart: func(ctx context.Context) error {
 					// Print server startup information
-					cliutil.PrintHero(cmd.OutOrStdout(), appCfg.Identity.Signer.DID())
+					cliutil.PrintHero(cmd.OutOrStdout(), appCfg.Identity.ID())
 					cmd.Println("Piri Running on: " + appCfg.Server.Host + ":" + strconv.Itoa(int(appCfg.Server.Port)))
 					cmd.Println("Piri Public Endpoint: " + appCfg.Server.PublicURL.String())
 
@@ -393,7 +388,7 @@ func fullServer(cmd *cobra.Command, _ []string) error {
 						"com/storacha/piri/cli/serve"),
 						ctx,
 						"full",
-						attribute.String("did", appCfg.Identity.Signer.DID().String()),
+						attribute.String("did", appCfg.Identity.ID().String()),
 						attribute.String("owner_address", appCfg.PDPService.OwnerAddress.String()),
 						attribute.String("public_url", appCfg.Server.PublicURL.String()),
 						attribute.Int64("proof_set", int64(appCfg.UCANService.ProofSetID)),
@@ -423,8 +418,10 @@ func fullServer(cmd *cobra.Command, _ []string) error {
 }
 
 func initTelemetry(ctx context.Context, instanceID, network string, dataDir string, cfg appconfig.TelemetryConfig) error {
-	// If no Storacha analytics AND no user collectors, skip setup entirely
-	if cfg.DisableStorachaAnalytics && len(cfg.Metrics) == 0 && len(cfg.Traces) == 0 {
+	// If no Storacha analytics AND no user collectors or endpoint, skip setup
+	// entirely, only keeping trace IDs to correlate logs
+	if cfg.DisableStorachaAnalytics && len(cfg.Metrics) == 0 && len(cfg.Traces) == 0 && !cfg.Prometheus && !telemetry.TracesFromEnv() {
+		telemetry.SetupCorrelation()
 		return nil
 	}
 
@@ -4,6 +4,8 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"github.com/storacha/piri/cmd/cliutil"
 )
 
 var log = logging.Logger("cmd/serve")
@@ -38,6 +40,6 @@ func init() {
 	)
 	cobra.CheckErr(viper.BindPFlag("server.public_url", Cmd.PersistentFlags().Lookup("public-url")))
 	// backwards compatibility
-	cobra.CheckErr(viper.BindEnv("server.public_url", "PIRI_PUBLIC_URL"))
+	cobra.CheckErr(cliutil.BindLegacyEnv("server.public_url", "PIRI_PUBLIC_URL"))
 
 }
@@ -0,0 +1,195 @@
+package setup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/piri/pkg/build"
+)
+
+// ReleaseTagURL is the GitHub API URL template for fetching a release by tag.
+const ReleaseTagURL = "https://api.github.com/repos/storacha/piri/releases/tags/%s"
+
+// errNoProvenance is returned when a release does not publish SLSA provenance.
+var errNoProvenance = errors.New("release does not include SLSA provenance")
+
+var (
+	VerifyCmd = &cobra.Command{
+		Use:   "verify",
+		Args:  cobra.NoArgs,
+		Short: "Verify the piri binary against published SLSA provenance",
+		Long: `Verify that the piri binary was produced by the official release workflow.
+
+The sha256 digest of the binary is checked against the subjects of the SLSA
+provenance published alongside the GitHub release matching the binary version.
+Use --provenance to verify against a local *.intoto.jsonl file instead.
+
+Note: this checks the provenance contents only. Use slsa-verifier to also
+check the attestation signature.`,
+		RunE: doVerify,
+	}
+
+	verifyBinaryPath     string
+	verifyProvenancePath string
+)
+
+func init() {
+	VerifyCmd.Flags().StringVar(&verifyBinaryPath, "binary", "", "Path to the binary to verify (defaults to the running executable)")
+	VerifyCmd.Flags().StringVar(&verifyProvenancePath, "provenance", "", "Path to a local provenance file (defaults to fetching it from the release)")
+}
+
+func doVerify(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+
+	binaryPath := verifyBinaryPath
+	if binaryPath == "" {
+		execPath, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to get executable path: %w", err)
+		}
+		binaryPath = execPath
+	}
+
+	digest, err := build.FileDigest(binaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to hash binary: %w", err)
+	}
+
+	var prov *build.Provenance
+	if verifyProvenancePath != "" {
+		f, err := os.Open(verifyProvenancePath)
+		if err != nil {
+			return fmt.Errorf("failed to open provenance: %w", err)
+		}
+		defer f.Close()
+		prov, err = build.ParseProvenance(f)
+		if err != nil {
+			return fmt.Errorf("failed to parse provenance: %w", err)
+		}
+	} else {
+		// strip the git revision suffix to get the release tag
+		tag := strings.Split(build.Version, "-")[0]
+		release, err := getReleaseByTag(ctx, tag)
+		if err != nil {
+			return fmt.Errorf("failed to get release %s: %w", tag, err)
+		}
+		prov, err = getReleaseProvenance(ctx, release)
+		if err != nil {
+			return fmt.Errorf("failed to get provenance for release %s: %w", tag, err)
+		}
+	}
+
+	subject, err := prov.VerifyDigest(digest)
+	if err != nil {
+		return fmt.Errorf("verification failed for %s: %w", binaryPath, err)
+	}
+
+	// only compare commits if both are known, dev builds have no commit set
+	if verifyBinaryPath == "" && prov.SourceCommit != "" && build.Commit != "unknown" &&
+		!strings.HasPrefix(prov.SourceCommit, build.Commit) {
+		return fmt.Errorf("verification failed: binary commit %s does not match provenance commit %s", build.Commit, prov.SourceCommit)
+	}
+
+	cmd.Printf("Verified %s\n", binaryPath)
+	cmd.Printf("  subject: %s\n", subject.Name)
+	cmd.Printf("  sha256:  %s\n", subject.Digest["sha256"])
+	cmd.Printf("  source:  %s\n", prov.SourceURI)
+	cmd.Printf("  builder: %s\n", prov.BuilderID)
+	return nil
+}
+
+func getReleaseByTag(ctx context.Context, tag string) (*GitHubRelease, error) {
+	releaseURL := fmt.Sprintf(ReleaseTagURL, tag)
+	if testURL := os.Getenv("PIRI_TEST_GITHUB_API_URL"); testURL != "" {
+		releaseURL = testURL + "/repos/storacha/piri/releases/tags/" + tag
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", releaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "piri-updater")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	var release GitHubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// getReleaseProvenance downloads and parses the SLSA provenance published
+// with a release. It returns errNoProvenance if the release has none.
+func getReleaseProvenance(ctx context.Context, release *GitHubRelease) (*build.Provenance, error) {
+	var provenanceURL string
+	for _, asset := range release.Assets {
+		if strings.HasSuffix(strings.ToLower(asset.Name), ".intoto.jsonl") {
+			provenanceURL = asset.BrowserDownloadURL
+			break
+		}
+	}
+	if provenanceURL == "" {
+		return nil, errNoProvenance
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", provenanceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "piri-updater")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("failed to download provenance: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return build.ParseProvenance(resp.Body)
+}
+
+// verifyReleaseProvenance checks the archive digest against the provenance
+// published with the release. Releases predating provenance publication are
+// allowed through with a warning.
+func verifyReleaseProvenance(ctx context.Context, cmd *cobra.Command, release *GitHubRelease, archiveDigest []byte) error {
+	cmd.Println("Fetching provenance...")
+	prov, err := getReleaseProvenance(ctx, release)
+	if err != nil {
+		if errors.Is(err, errNoProvenance) {
+			cmd.Printf("Warning: release %s has no SLSA provenance, skipping provenance verification\n", release.TagName)
+			return nil
+		}
+		return err
+	}
+
+	subject, err := prov.VerifyDigest(archiveDigest)
+	if err != nil {
+		return err
+	}
+	cmd.Printf("Provenance verified for %s\n", subject.Name)
+	return nil
+}
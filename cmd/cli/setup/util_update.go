@@ -425,6 +425,11 @@ func downloadAndApplyUpdate(ctx context.Context, cmd *cobra.Command, release *Gi
 		return fmt.Errorf("failed to get asset checksum, aborting update: %w", err)
 	}
 
+	// Verify the published checksum is attested by the release provenance
+	if err := verifyReleaseProvenance(ctx, cmd, release, checksum); err != nil {
+		return fmt.Errorf("failed to verify release provenance, aborting update: %w", err)
+	}
+
 	// Download and verify the archive, then extract the binary
 	newBinary, err := downloadAndVerifyBinary(ctx, cmd, assetURL, checksum, showProgress)
 	if err != nil {
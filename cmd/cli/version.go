@@ -1,25 +1,57 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/spf13/cobra"
 
+	"github.com/storacha/piri/cmd/cli/setup"
 	"github.com/storacha/piri/pkg/build"
 )
 
+var versionJSON bool
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version of piri",
-	Long:  `Print the version of piri including the git revision.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("version: %s\n", build.Version)
-		fmt.Printf("commit: %s\n", build.Commit)
-		fmt.Printf("built at: %s\n", build.Date)
-		fmt.Printf("built by: %s\n", build.BuiltBy)
+	Long:  `Print the version of piri including the git revision and embedded build metadata.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := build.GetInfo()
+		if versionJSON {
+			data, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return fmt.Errorf("rendering version info: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return nil
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "version: %s\n", info.Version)
+		fmt.Fprintf(out, "commit: %s\n", info.Commit)
+		fmt.Fprintf(out, "built at: %s\n", info.Date)
+		fmt.Fprintf(out, "built by: %s\n", info.BuiltBy)
+		fmt.Fprintf(out, "go version: %s\n", info.GoVersion)
+		fmt.Fprintf(out, "platform: %s/%s\n", info.OS, info.Arch)
+		if info.Network != "" {
+			fmt.Fprintf(out, "network: %s\n", info.Network)
+		}
+		modules := make([]string, 0, len(info.Contracts))
+		for mod := range info.Contracts {
+			modules = append(modules, mod)
+		}
+		sort.Strings(modules)
+		for _, mod := range modules {
+			fmt.Fprintf(out, "contracts: %s %s\n", mod, info.Contracts[mod])
+		}
+		return nil
 	},
 }
 
 func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Print version info as JSON")
+	versionCmd.AddCommand(setup.VerifyCmd)
 	rootCmd.AddCommand(versionCmd)
 }
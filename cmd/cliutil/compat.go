@@ -0,0 +1,69 @@
+package cliutil
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/spf13/viper"
+)
+
+var compatLog = logging.Logger("cmd")
+
+// EnvPrefix is the prefix of environment variables derived from config keys,
+// e.g. repo.data_dir is set by PIRI_REPO_DATA_DIR.
+const EnvPrefix = "PIRI"
+
+// legacyEnv is an environment variable used by earlier releases that is
+// still honoured for a config key.
+type legacyEnv struct {
+	key string
+	env string
+}
+
+var (
+	legacyEnvMu sync.Mutex
+	legacyEnvs  []legacyEnv
+)
+
+// EnvName returns the environment variable that sets the config key.
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// BindLegacyEnv binds an environment variable used by earlier releases to a
+// config key, so existing deployment scripts keep working. The variable
+// derived from the key (see EnvName) takes precedence over the legacy one.
+// Use of the legacy variable is reported by WarnLegacyEnv.
+func BindLegacyEnv(key, env string) error {
+	if err := viper.BindEnv(key, EnvName(key), env); err != nil {
+		return fmt.Errorf("binding legacy environment variable %s: %w", env, err)
+	}
+	legacyEnvMu.Lock()
+	defer legacyEnvMu.Unlock()
+	legacyEnvs = append(legacyEnvs, legacyEnv{key: key, env: env})
+	return nil
+}
+
+// WarnLegacyEnv logs a deprecation warning for each legacy environment
+// variable that is set, and returns the names of those variables.
+func WarnLegacyEnv() []string {
+	legacyEnvMu.Lock()
+	defer legacyEnvMu.Unlock()
+
+	var used []string
+	for _, l := range legacyEnvs {
+		if _, ok := os.LookupEnv(l.env); !ok {
+			continue
+		}
+		used = append(used, l.env)
+		if _, ok := os.LookupEnv(EnvName(l.key)); ok {
+			compatLog.Warnf("environment variable %s is deprecated and ignored because %s is set", l.env, EnvName(l.key))
+			continue
+		}
+		compatLog.Warnf("environment variable %s is deprecated, use %s instead", l.env, EnvName(l.key))
+	}
+	return used
+}
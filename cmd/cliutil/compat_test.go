@@ -0,0 +1,32 @@
+package cliutil
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindLegacyEnv(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	require.Equal(t, "PIRI_REPO_DATA_DIR", EnvName("repo.data_dir"))
+
+	require.NoError(t, BindLegacyEnv("repo.data_dir", "PIRI_DATA_DIR"))
+	require.NoError(t, BindLegacyEnv("repo.temp_dir", "PIRI_TEMP_DIR"))
+
+	t.Run("legacy variable sets key", func(t *testing.T) {
+		t.Setenv("PIRI_DATA_DIR", "/legacy")
+		require.Equal(t, "/legacy", viper.GetString("repo.data_dir"))
+		require.Equal(t, []string{"PIRI_DATA_DIR"}, WarnLegacyEnv())
+	})
+
+	t.Run("current variable takes precedence", func(t *testing.T) {
+		t.Setenv("PIRI_DATA_DIR", "/legacy")
+		t.Setenv("PIRI_REPO_DATA_DIR", "/current")
+		require.Equal(t, "/current", viper.GetString("repo.data_dir"))
+	})
+
+	t.Run("nothing to warn about", func(t *testing.T) {
+		require.Empty(t, WarnLegacyEnv())
+	})
+}
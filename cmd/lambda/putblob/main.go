@@ -23,7 +23,7 @@ func makeHandler(cfg aws.Config) (http.Handler, error) {
 		return nil, err
 	}
 
-	handler := blobs.NewBlobPutHandler(service.Blobs().Presigner(), service.Blobs().Allocations(), service.Blobs().Store())
+	handler := blobs.NewBlobPutHandler(service.Blobs().Presigner(), service.Blobs().Allocations(), service.Blobs().Store(), service.Blobs().Latency())
 	return telemetry.NewErrorReportingHandler(func(w http.ResponseWriter, r *http.Request) error {
 		err := handler(aws.NewHandlerContext(w, r))
 		if err != nil {
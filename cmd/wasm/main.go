@@ -0,0 +1,99 @@
+//go:build js && wasm
+
+// Command wasm exposes the verification functions of lib/verify to
+// JavaScript. Build it with `make wasm` and load piri.wasm with the
+// wasm_exec.js shipped with Go. Once started it registers a global `piri`
+// object:
+//
+//	piri.pieceCID(data: Uint8Array): string
+//	piri.verifyReceipt(archive: Uint8Array, issuerKey?: string): object
+//	piri.parseClaim(archive: Uint8Array, issuerKey?: string): object
+//
+// Functions return an Error, rather than throwing it, if verification fails.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+
+	"github.com/storacha/piri/lib/verify"
+)
+
+func main() {
+	js.Global().Set("piri", js.ValueOf(map[string]any{
+		"pieceCID":      js.FuncOf(pieceCID),
+		"verifyReceipt": js.FuncOf(verifyReceipt),
+		"parseClaim":    js.FuncOf(parseClaim),
+	}))
+	// keep the functions available to callers
+	select {}
+}
+
+func pieceCID(_ js.Value, args []js.Value) any {
+	data, err := bytesArg(args, 0)
+	if err != nil {
+		return jsError(err)
+	}
+	c, err := verify.PieceCID(bytes.NewReader(data))
+	if err != nil {
+		return jsError(err)
+	}
+	return c.String()
+}
+
+func verifyReceipt(_ js.Value, args []js.Value) any {
+	archive, err := bytesArg(args, 0)
+	if err != nil {
+		return jsError(err)
+	}
+	rcpt, err := verify.VerifyReceipt(archive, stringArg(args, 1))
+	if err != nil {
+		return jsError(err)
+	}
+	return toJS(rcpt)
+}
+
+func parseClaim(_ js.Value, args []js.Value) any {
+	archive, err := bytesArg(args, 0)
+	if err != nil {
+		return jsError(err)
+	}
+	claim, err := verify.ParseClaim(archive, stringArg(args, 1))
+	if err != nil {
+		return jsError(err)
+	}
+	return toJS(claim)
+}
+
+func bytesArg(args []js.Value, i int) ([]byte, error) {
+	if len(args) <= i || !args[i].InstanceOf(js.Global().Get("Uint8Array")) {
+		return nil, fmt.Errorf("argument %d must be a Uint8Array", i)
+	}
+	data := make([]byte, args[i].Get("length").Int())
+	js.CopyBytesToGo(data, args[i])
+	return data, nil
+}
+
+func stringArg(args []js.Value, i int) string {
+	if len(args) <= i || args[i].Type() != js.TypeString {
+		return ""
+	}
+	return args[i].String()
+}
+
+// toJS converts v to a plain JavaScript object via its JSON encoding.
+func toJS(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return jsError(err)
+	}
+	return js.Global().Get("JSON").Call("parse", string(data))
+}
+
+// jsError returns err as a JavaScript Error. Throwing from a Go callback
+// would abort the program, so errors are returned to the caller instead.
+func jsError(err error) any {
+	return js.Global().Get("Error").New(err.Error())
+}
@@ -0,0 +1,227 @@
+package dedup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	internalsql "github.com/storacha/piri/lib/jobqueue/internal/sql"
+	"github.com/storacha/piri/lib/jobqueue/queue"
+)
+
+var _ queue.Manager = (*Queue)(nil)
+
+// List lists the jobs of the queue, oldest first.
+func (q *Queue) List(ctx context.Context, opts queue.ListOpts) ([]queue.Job, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = queue.DefaultListLimit
+	}
+	query := `
+		SELECT j.id, ns.name, j.attempts, j.created_s, j.avail_s
+		FROM jobs j
+		JOIN job_ns ns ON ns.id = j.ns_id
+		WHERE ns.queue = ?
+		ORDER BY j.created_s, j.id
+		LIMIT ?`
+	if opts.Dead {
+		query = `
+			SELECT d.id, ns.name, d.attempts, d.reason, d.error, d.moved_s
+			FROM job_dead d
+			JOIN job_ns ns ON ns.id = d.ns_id
+			WHERE ns.queue = ?
+			ORDER BY d.moved_s, d.id
+			LIMIT ?`
+	}
+	rows, err := q.db.QueryContext(ctx, q.dialect.Rebind(query), q.name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []queue.Job
+	for rows.Next() {
+		var (
+			j  queue.Job
+			id int64
+		)
+		if opts.Dead {
+			var moved int64
+			if err := rows.Scan(&id, &j.Name, &j.Received, &j.FailureReason, &j.Error, &moved); err != nil {
+				return nil, fmt.Errorf("scan dead job: %w", err)
+			}
+			j.Dead = true
+			j.MovedAt = time.Unix(moved, 0)
+		} else {
+			var created, avail int64
+			if err := rows.Scan(&id, &j.Name, &j.Received, &created, &avail); err != nil {
+				return nil, fmt.Errorf("scan job: %w", err)
+			}
+			j.Created = time.Unix(created, 0)
+			j.Available = time.Unix(avail, 0)
+		}
+		j.ID = queue.ID(strconv.FormatInt(id, 10))
+		jobs = append(jobs, j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Get returns a queued or dead-lettered job.
+func (q *Queue) Get(ctx context.Context, id queue.ID) (queue.Job, error) {
+	jobID, err := parseJobID(id)
+	if err != nil {
+		return queue.Job{}, err
+	}
+
+	j := queue.Job{ID: id}
+	var created, avail int64
+	query := q.dialect.Rebind(`
+		SELECT ns.name, j.attempts, j.created_s, j.avail_s
+		FROM jobs j
+		JOIN job_ns ns ON ns.id = j.ns_id
+		WHERE ns.queue = ? AND j.id = ?`)
+	err = q.db.QueryRowContext(ctx, query, q.name, jobID).Scan(&j.Name, &j.Received, &created, &avail)
+	if err == nil {
+		j.Created = time.Unix(created, 0)
+		j.Available = time.Unix(avail, 0)
+		return j, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return queue.Job{}, fmt.Errorf("fetch job: %w", err)
+	}
+
+	var moved int64
+	deadQuery := q.dialect.Rebind(`
+		SELECT ns.name, d.attempts, d.reason, d.error, d.moved_s
+		FROM job_dead d
+		JOIN job_ns ns ON ns.id = d.ns_id
+		WHERE ns.queue = ? AND d.id = ?`)
+	if err := q.db.QueryRowContext(ctx, deadQuery, q.name, jobID).Scan(&j.Name, &j.Received, &j.FailureReason, &j.Error, &moved); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return queue.Job{}, fmt.Errorf("%w: %s in queue %s", queue.ErrJobNotFound, id, q.name)
+		}
+		return queue.Job{}, fmt.Errorf("fetch dead job: %w", err)
+	}
+	j.Dead = true
+	j.MovedAt = time.Unix(moved, 0)
+	return j, nil
+}
+
+// Retry moves a dead-lettered job back to the queue, to be run again
+// immediately with a fresh retry count. The job is no longer recorded as done,
+// so it is retried even if repeats of dead-lettered jobs are blocked. If the
+// same job was enqueued again since, the dead-lettered job is discarded.
+func (q *Queue) Retry(ctx context.Context, id queue.ID) (queue.Job, error) {
+	deadID, err := parseJobID(id)
+	if err != nil {
+		return queue.Job{}, err
+	}
+
+	var j queue.Job
+	err = internalsql.InTx(q.db, func(tx *sql.Tx) error {
+		var (
+			nsID int64
+			key  []byte
+			body []byte
+		)
+		query := q.dialect.Rebind(`
+			SELECT d.ns_id, ns.name, d.key, d.body
+			FROM job_dead d
+			JOIN job_ns ns ON ns.id = d.ns_id
+			WHERE ns.queue = ? AND d.id = ?`)
+		if err := tx.QueryRowContext(ctx, query, q.name, deadID).Scan(&nsID, &j.Name, &key, &body); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s in dead letter queue %s", queue.ErrJobNotFound, id, q.name)
+			}
+			return fmt.Errorf("fetch dead job: %w", err)
+		}
+
+		now := q.clock.Now()
+		var newID int64
+		insertQuery := q.dialect.Rebind(`
+			INSERT INTO jobs(ns_id, key, body, avail_s)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(ns_id, key) DO NOTHING
+			RETURNING id`)
+		err := tx.QueryRowContext(ctx, insertQuery, nsID, key, body, now.Unix()).Scan(&newID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			q.logger.Infow("dead job already queued again, discarding it", "id", id, "task", j.Name)
+			j.ID = id
+			j.Dead = true
+		case err != nil:
+			return fmt.Errorf("requeue dead job: %w", err)
+		default:
+			j.ID = queue.ID(strconv.FormatInt(newID, 10))
+			j.Created = now
+			j.Available = now
+		}
+
+		deleteQuery := q.dialect.Rebind(`DELETE FROM job_dead WHERE id = ?`)
+		if _, err := tx.ExecContext(ctx, deleteQuery, deadID); err != nil {
+			return fmt.Errorf("delete dead job: %w", err)
+		}
+		doneQuery := q.dialect.Rebind(`DELETE FROM job_done WHERE ns_id = ? AND key = ? AND status = ?`)
+		if _, err := tx.ExecContext(ctx, doneQuery, nsID, key, int(jobDoneStatusDeadLetter)); err != nil {
+			return fmt.Errorf("delete job_done: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return queue.Job{}, err
+	}
+	q.logger.Infow("retrying dead job", "id", id, "task", j.Name, "new_id", j.ID)
+	return j, nil
+}
+
+// Cancel removes a queued or dead-lettered job. A cancelled job that is
+// running completes, but it is not retried if it fails. Cancelled jobs are not
+// recorded as done, so they can be enqueued again.
+func (q *Queue) Cancel(ctx context.Context, id queue.ID) (queue.Job, error) {
+	jobID, err := parseJobID(id)
+	if err != nil {
+		return queue.Job{}, err
+	}
+
+	var j queue.Job
+	err = internalsql.InTx(q.db, func(tx *sql.Tx) error {
+		query := q.dialect.Rebind(`
+			DELETE FROM jobs
+			WHERE id = ? AND ns_id IN (SELECT id FROM job_ns WHERE queue = ?)
+			RETURNING ns_id, attempts`)
+		var nsID int64
+		err := tx.QueryRowContext(ctx, query, jobID, q.name).Scan(&nsID, &j.Received)
+		if errors.Is(err, sql.ErrNoRows) {
+			j.Dead = true
+			deadQuery := q.dialect.Rebind(`
+				DELETE FROM job_dead
+				WHERE id = ? AND ns_id IN (SELECT id FROM job_ns WHERE queue = ?)
+				RETURNING ns_id, attempts`)
+			err = tx.QueryRowContext(ctx, deadQuery, jobID, q.name).Scan(&nsID, &j.Received)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s in queue %s", queue.ErrJobNotFound, id, q.name)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("cancel job: %w", err)
+		}
+
+		nameQuery := q.dialect.Rebind(`SELECT name FROM job_ns WHERE id = ?`)
+		if err := tx.QueryRowContext(ctx, nameQuery, nsID).Scan(&j.Name); err != nil {
+			return fmt.Errorf("fetch job name: %w", err)
+		}
+		j.ID = id
+		return nil
+	})
+	if err != nil {
+		return queue.Job{}, err
+	}
+	q.logger.Infow("cancelled job", "id", id, "task", j.Name, "dead", j.Dead)
+	return j, nil
+}
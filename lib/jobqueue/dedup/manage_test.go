@@ -0,0 +1,154 @@
+package dedup_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/lib/jobqueue/dedup"
+	internaltesting "github.com/storacha/piri/lib/jobqueue/internal/testing"
+	"github.com/storacha/piri/lib/jobqueue/queue"
+)
+
+func TestQueue_Manage(t *testing.T) {
+	internaltesting.RunForAllBackends(t, func(t *testing.T, backend internaltesting.Backend) {
+		t.Run("lists queued and dead jobs", func(t *testing.T) {
+			q, ctx := newTestQueueForBackend(t, dedup.NewOpts{}, backend)
+
+			_, err := q.SendAndGetID(ctx, queue.Message{Body: encodeEnvelope(t, "first", []byte("1"))})
+			require.NoError(t, err)
+			_, err = q.SendAndGetID(ctx, queue.Message{Body: encodeEnvelope(t, "second", []byte("2"))})
+			require.NoError(t, err)
+
+			jobs, err := q.List(ctx, queue.ListOpts{})
+			require.NoError(t, err)
+			require.Len(t, jobs, 2)
+			require.Equal(t, "first", jobs[0].Name)
+			require.Equal(t, "second", jobs[1].Name)
+
+			msg, err := q.Receive(ctx)
+			require.NoError(t, err)
+			require.NoError(t, q.MoveToDeadLetter(ctx, msg.ID, "first", "permanent_error", "boom"))
+
+			dead, err := q.List(ctx, queue.ListOpts{Dead: true})
+			require.NoError(t, err)
+			require.Len(t, dead, 1)
+			require.True(t, dead[0].Dead)
+			require.Equal(t, "first", dead[0].Name)
+			require.Equal(t, "permanent_error", dead[0].FailureReason)
+			require.Equal(t, "boom", dead[0].Error)
+			require.Equal(t, 1, dead[0].Received)
+
+			jobs, err = q.List(ctx, queue.ListOpts{Limit: 1})
+			require.NoError(t, err)
+			require.Len(t, jobs, 1)
+			require.Equal(t, "second", jobs[0].Name)
+		})
+
+		t.Run("gets queued and dead jobs", func(t *testing.T) {
+			q, ctx := newTestQueueForBackend(t, dedup.NewOpts{}, backend)
+
+			id, err := q.SendAndGetID(ctx, queue.Message{Body: encodeEnvelope(t, "job", []byte("payload"))})
+			require.NoError(t, err)
+
+			job, err := q.Get(ctx, id)
+			require.NoError(t, err)
+			require.Equal(t, id, job.ID)
+			require.Equal(t, "job", job.Name)
+			require.False(t, job.Dead)
+
+			msg, err := q.Receive(ctx)
+			require.NoError(t, err)
+			require.NoError(t, q.MoveToDeadLetter(ctx, msg.ID, "job", "max_retries", "boom"))
+
+			job, err = q.Get(ctx, msg.ID)
+			require.NoError(t, err)
+			require.True(t, job.Dead)
+			require.Equal(t, "max_retries", job.FailureReason)
+			require.Equal(t, "boom", job.Error)
+
+			_, err = q.Get(ctx, "12345")
+			require.ErrorIs(t, err, queue.ErrJobNotFound)
+		})
+
+		t.Run("retries a dead job even if repeats are blocked", func(t *testing.T) {
+			q, ctx := newTestQueueForBackend(t, dedup.NewOpts{}, backend)
+
+			body := encodeEnvelope(t, "job", []byte("payload"))
+			_, err := q.SendAndGetID(ctx, queue.Message{Body: body})
+			require.NoError(t, err)
+			msg, err := q.Receive(ctx)
+			require.NoError(t, err)
+			require.NoError(t, q.MoveToDeadLetter(ctx, msg.ID, "job", "max_retries", "boom"))
+
+			job, err := q.Retry(ctx, msg.ID)
+			require.NoError(t, err)
+			require.False(t, job.Dead)
+			require.Equal(t, "job", job.Name)
+
+			retried, err := q.Receive(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, retried)
+			require.Equal(t, body, retried.Body)
+			require.Equal(t, 1, retried.Received)
+
+			dead, err := q.List(ctx, queue.ListOpts{Dead: true})
+			require.NoError(t, err)
+			require.Empty(t, dead)
+
+			_, err = q.Retry(ctx, msg.ID)
+			require.ErrorIs(t, err, queue.ErrJobNotFound)
+		})
+
+		t.Run("cancels queued and dead jobs", func(t *testing.T) {
+			q, ctx := newTestQueueForBackend(t, dedup.NewOpts{}, backend)
+
+			body := encodeEnvelope(t, "job", []byte("payload"))
+			id, err := q.SendAndGetID(ctx, queue.Message{Body: body})
+			require.NoError(t, err)
+
+			job, err := q.Cancel(ctx, id)
+			require.NoError(t, err)
+			require.False(t, job.Dead)
+			require.Equal(t, "job", job.Name)
+
+			msg, err := q.Receive(ctx)
+			require.NoError(t, err)
+			require.Nil(t, msg)
+
+			// cancelled jobs can be enqueued again
+			_, err = q.SendAndGetID(ctx, queue.Message{Body: body})
+			require.NoError(t, err)
+			msg, err = q.Receive(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, msg)
+			require.NoError(t, q.MoveToDeadLetter(ctx, msg.ID, "job", "max_retries", "boom"))
+
+			job, err = q.Cancel(ctx, msg.ID)
+			require.NoError(t, err)
+			require.True(t, job.Dead)
+
+			_, err = q.Cancel(ctx, msg.ID)
+			require.ErrorIs(t, err, queue.ErrJobNotFound)
+		})
+
+		t.Run("does not manage jobs of other queues", func(t *testing.T) {
+			db := internaltesting.NewDBForBackend(t, backend)
+			q, ctx := newTestQueueForBackend(t, dedup.NewOpts{DB: db}, backend)
+			other, err := dedup.New(dedup.NewOpts{DB: db, Name: "other", Dialect: backend.Dialect()})
+			require.NoError(t, err)
+
+			id, err := other.SendAndGetID(ctx, queue.Message{Body: encodeEnvelope(t, "job", []byte("payload"))})
+			require.NoError(t, err)
+
+			jobs, err := q.List(ctx, queue.ListOpts{})
+			require.NoError(t, err)
+			require.Empty(t, jobs)
+
+			_, err = q.Get(ctx, id)
+			require.ErrorIs(t, err, queue.ErrJobNotFound)
+			_, err = q.Cancel(ctx, id)
+			require.ErrorIs(t, err, queue.ErrJobNotFound)
+		})
+	})
+}
@@ -11,6 +11,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/raulk/clock"
+
 	"github.com/storacha/piri/lib/jobqueue/dialect"
 	internalsql "github.com/storacha/piri/lib/jobqueue/internal/sql"
 	"github.com/storacha/piri/lib/jobqueue/logger"
@@ -47,6 +49,7 @@ type NewOpts struct {
 	HashFunc          HashFunc
 	Logger            logger.StandardLogger
 	Dialect           dialect.Dialect
+	Clock             clock.Clock
 }
 
 type Queue struct {
@@ -59,6 +62,7 @@ type Queue struct {
 	hash              HashFunc
 	logger            logger.StandardLogger
 	dialect           dialect.Dialect
+	clock             clock.Clock
 }
 
 // Setup sets up the dedup queue schema using SQLite dialect (default).
@@ -128,6 +132,10 @@ func New(opts NewOpts) (*Queue, error) {
 		opts.Logger = &logger.DiscardLogger{}
 	}
 
+	if opts.Clock == nil {
+		opts.Clock = clock.New()
+	}
+
 	err := ensureQueueConfigured(opts.DB, opts.Name, dedupeEnabled, opts.Dialect)
 	if err != nil {
 		return nil, err
@@ -143,6 +151,7 @@ func New(opts NewOpts) (*Queue, error) {
 		hash:              opts.HashFunc,
 		logger:            opts.Logger,
 		dialect:           opts.Dialect,
+		clock:             opts.Clock,
 	}, nil
 }
 
@@ -225,7 +234,7 @@ func (q *Queue) sendAndGetIDTx(ctx context.Context, tx *sql.Tx, m queue.Message)
 		}
 	}
 
-	available := time.Now().Add(m.Delay).Unix()
+	available := q.clock.Now().Add(m.Delay).Unix()
 
 	var id int64
 	insertQuery := q.dialect.Rebind(`
@@ -295,7 +304,7 @@ func (q *Queue) Receive(ctx context.Context) (*queue.Message, error) {
 }
 
 func (q *Queue) receiveTx(ctx context.Context, tx *sql.Tx) (*queue.Message, error) {
-	now := time.Now()
+	now := q.clock.Now()
 	nowSecs := now.Unix()
 	newAvail := now.Add(q.timeout).Unix()
 
@@ -339,7 +348,7 @@ func (q *Queue) receiveTx(ctx context.Context, tx *sql.Tx) (*queue.Message, erro
 }
 
 func (q *Queue) ReceiveAndWait(ctx context.Context, interval time.Duration) (*queue.Message, error) {
-	ticker := time.NewTicker(interval)
+	ticker := q.clock.Ticker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -374,7 +383,7 @@ func (q *Queue) extendTx(ctx context.Context, tx *sql.Tx, id queue.ID, delay tim
 		return err
 	}
 
-	newAvail := time.Now().Add(delay).Unix()
+	newAvail := q.clock.Now().Add(delay).Unix()
 	query := q.dialect.Rebind(`UPDATE jobs SET avail_s = ? WHERE id = ?`)
 	_, err = tx.ExecContext(ctx, query, newAvail, jobID)
 	if err != nil {
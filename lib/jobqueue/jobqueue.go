@@ -9,6 +9,7 @@ import (
 	"time"
 
 	logging "github.com/ipfs/go-log/v2"
+	"github.com/raulk/clock"
 
 	"github.com/storacha/piri/lib/jobqueue/dedup"
 	"github.com/storacha/piri/lib/jobqueue/dialect"
@@ -28,13 +29,40 @@ type Service[T any] interface {
 	Enqueue(ctx context.Context, name string, msg T) error
 }
 
+// Snapshotter is implemented by job queues that can report their state, e.g.
+// for diagnostics.
+type Snapshotter interface {
+	Snapshot() Snapshot
+}
+
+// Manager is implemented by job queues whose jobs can be listed, retried and
+// cancelled, e.g. by operators.
+type Manager interface {
+	// Name is the name of the queue.
+	Name() string
+	ListJobs(ctx context.Context, opts queue.ListOpts) ([]queue.Job, error)
+	GetJob(ctx context.Context, id queue.ID) (queue.Job, error)
+	RetryJob(ctx context.Context, id queue.ID) (queue.Job, error)
+	CancelJob(ctx context.Context, id queue.ID) (queue.Job, error)
+}
+
+// Snapshot describes the state of a job queue at a point in time.
+type Snapshot struct {
+	worker.Snapshot
+	Started  bool `json:"started"`
+	Stopping bool `json:"stopping"`
+}
+
 type Config struct {
 	Logger        logger.StandardLogger
 	MaxWorkers    uint
+	RateLimit     uint
 	MaxRetries    uint
 	MaxTimeout    time.Duration
 	ExtendDelay   time.Duration
+	RetryBackoff  worker.Backoff
 	Dialect       dialect.Dialect
+	Clock         clock.Clock
 	queueProvider QueueProvider
 	isDedupQueue  bool
 }
@@ -60,6 +88,15 @@ func WithMaxWorkers(maxWorkers uint) Option {
 	}
 }
 
+// WithRateLimit limits the number of jobs the queue starts per minute, zero,
+// the default, does not limit them.
+func WithRateLimit(jobsPerMinute uint) Option {
+	return func(c *Config) error {
+		c.RateLimit = jobsPerMinute
+		return nil
+	}
+}
+
 func WithMaxRetries(maxRetries uint) Option {
 	return func(c *Config) error {
 		c.MaxRetries = maxRetries
@@ -87,6 +124,22 @@ func WithExtendDelay(extendDelay time.Duration) Option {
 	}
 }
 
+// WithRetryBackoff delays the retry of a failed job exponentially, from min
+// after the first failed attempt up to max, or without limit if max is zero.
+// By default a failed job is retried once its timeout passes.
+func WithRetryBackoff(min, max time.Duration) Option {
+	return func(c *Config) error {
+		if min <= 0 {
+			return errors.New("retry backoff must be greater than zero")
+		}
+		if max != 0 && max < min {
+			return errors.New("max retry backoff cannot be less than min retry backoff")
+		}
+		c.RetryBackoff = worker.ExponentialBackoff{Min: min, Max: max}
+		return nil
+	}
+}
+
 // WithDialect sets the SQL dialect for the job queue.
 // Use dialect.Postgres for PostgreSQL or dialect.SQLite (default) for SQLite.
 func WithDialect(d dialect.Dialect) Option {
@@ -96,6 +149,18 @@ func WithDialect(d dialect.Dialect) Option {
 	}
 }
 
+// WithClock sets the clock message timeouts, and therefore retries, are
+// measured against. Defaults to the real clock.
+func WithClock(clock clock.Clock) Option {
+	return func(c *Config) error {
+		if clock == nil {
+			return errors.New("job queue clock cannot be nil")
+		}
+		c.Clock = clock
+		return nil
+	}
+}
+
 func defaultQueueProvider(d dialect.Dialect) QueueProvider {
 	setup := queue.Setup
 	if d.IsPostgres() {
@@ -110,6 +175,7 @@ func defaultQueueProvider(d dialect.Dialect) QueueProvider {
 				Name:       name,
 				Timeout:    opts.Timeout,
 				Dialect:    d,
+				Clock:      opts.Clock,
 			})
 		},
 	}
@@ -119,6 +185,7 @@ type QueueProviderOpts struct {
 	MaxReceive int
 	Timeout    time.Duration
 	Logger     logger.StandardLogger
+	Clock      clock.Clock
 }
 
 type QueueProvider struct {
@@ -176,6 +243,7 @@ func WithDedupQueue(cfg *DedupQueueConfig) Option {
 					Logger:     opts.Logger,
 					HashFunc:   dedupCfg.HashFunc,
 					Dialect:    d,
+					Clock:      opts.Clock,
 				}
 				if dedupCfg.DedupeEnabled != nil {
 					dOpts.DedupeEnabled = dedupCfg.DedupeEnabled
@@ -216,6 +284,7 @@ func New[T any](name string, db *sql.DB, ser serializer.Serializer[T], opts ...O
 		MaxTimeout:  5 * time.Second,
 		ExtendDelay: 5 * time.Second,
 		Dialect:     dialect.SQLite, // default dialect
+		Clock:       clock.New(),
 	}
 	// apply overrides of defaults
 	for _, opt := range opts {
@@ -260,6 +329,7 @@ func New[T any](name string, db *sql.DB, ser serializer.Serializer[T], opts ...O
 		MaxReceive: int(c.MaxRetries),
 		Timeout:    c.MaxTimeout,
 		Logger:     c.Logger,
+		Clock:      c.Clock,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create queue: %w", err)
@@ -269,7 +339,9 @@ func New[T any](name string, db *sql.DB, ser serializer.Serializer[T], opts ...O
 	w, err := worker.New[T](q, ser,
 		worker.WithLog(c.Logger),
 		worker.WithLimit(int(c.MaxWorkers)),
+		worker.WithRateLimit(c.RateLimit),
 		worker.WithExtend(c.ExtendDelay),
+		worker.WithBackoff(c.RetryBackoff),
 		worker.WithQueueName(name),
 	)
 	if err != nil {
@@ -303,6 +375,77 @@ func (j *JobQueue[T]) Start(ctx context.Context) error {
 	return nil
 }
 
+// Pause stops the queue from running new jobs until Resume is called. Jobs
+// may still be enqueued while paused.
+func (j *JobQueue[T]) Pause() {
+	j.worker.Pause()
+}
+
+// Resume continues running jobs after a Pause.
+func (j *JobQueue[T]) Resume() {
+	j.worker.Resume()
+}
+
+// Paused reports whether the queue is paused.
+func (j *JobQueue[T]) Paused() bool {
+	return j.worker.Paused()
+}
+
+// SetMaxWorkers changes the number of jobs the queue runs in parallel, taking
+// effect while the queue is running.
+func (j *JobQueue[T]) SetMaxWorkers(maxWorkers uint) error {
+	if maxWorkers < 1 {
+		return errors.New("job queue max workers must be greater than zero")
+	}
+	j.worker.SetLimit(int(maxWorkers))
+	return nil
+}
+
+// SetRateLimit changes the number of jobs the queue starts per minute, zero
+// removes the limit. It takes effect while the queue is running.
+func (j *JobQueue[T]) SetRateLimit(jobsPerMinute uint) {
+	j.worker.SetRateLimit(jobsPerMinute)
+}
+
+// Snapshot returns the current state of the queue.
+func (j *JobQueue[T]) Snapshot() Snapshot {
+	j.mu.Lock()
+	started, stopping := j.startCtx != nil, j.stopping
+	j.mu.Unlock()
+	return Snapshot{
+		Snapshot: j.worker.Snapshot(),
+		Started:  started,
+		Stopping: stopping,
+	}
+}
+
+// Name returns the name of the queue.
+func (j *JobQueue[T]) Name() string {
+	return j.name
+}
+
+// ListJobs lists the jobs of the queue, oldest first.
+func (j *JobQueue[T]) ListJobs(ctx context.Context, opts queue.ListOpts) ([]queue.Job, error) {
+	return j.worker.ListJobs(ctx, opts)
+}
+
+// GetJob returns a queued or dead-lettered job of the queue.
+func (j *JobQueue[T]) GetJob(ctx context.Context, id queue.ID) (queue.Job, error) {
+	return j.worker.GetJob(ctx, id)
+}
+
+// RetryJob moves a dead-lettered job back to the queue, to be run again
+// immediately with a fresh retry count.
+func (j *JobQueue[T]) RetryJob(ctx context.Context, id queue.ID) (queue.Job, error) {
+	return j.worker.RetryJob(ctx, id)
+}
+
+// CancelJob removes a queued or dead-lettered job. A cancelled job that is
+// running completes, but it is not retried if it fails.
+func (j *JobQueue[T]) CancelJob(ctx context.Context, id queue.ID) (queue.Job, error) {
+	return j.worker.CancelJob(ctx, id)
+}
+
 func (j *JobQueue[T]) Register(name string, fn func(context.Context, T) error, opts ...worker.JobOption[T]) error {
 	j.mu.Lock()
 	if j.startCtx != nil {
@@ -386,6 +529,12 @@ func WithOnFailure[T any](onFailure worker.OnFailureFn[T]) worker.JobOption[T] {
 	return worker.WithOnFailure[T](onFailure)
 }
 
+// JobID returns the ID of the job a job function or OnFailure callback is run
+// for with ctx.
+func JobID(ctx context.Context) (queue.ID, bool) {
+	return worker.JobID(ctx)
+}
+
 // NewPermanentError creates an error that will prevent the job queue from retrying the job
 func NewPermanentError(err error) error {
 	return worker.Permanent(err)
@@ -0,0 +1,272 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	internalsql "github.com/storacha/piri/lib/jobqueue/internal/sql"
+)
+
+// DefaultListLimit is the number of jobs listed if no limit is given.
+const DefaultListLimit = 100
+
+// ErrJobNotFound is returned when managing a job the queue does not hold.
+var ErrJobNotFound = errors.New("job not found")
+
+// Job is a job held by a queue, either waiting to run or dead-lettered.
+type Job struct {
+	ID ID
+	// Name is the name of the job function the job runs.
+	Name string
+	// Received is the number of times the job was received to run.
+	Received int
+	Created  time.Time
+	// Available is when a queued job can next be received, it is in the past
+	// for jobs waiting to run and in the future for jobs running or waiting
+	// to be retried. Zero for dead-lettered jobs.
+	Available time.Time
+	// Dead is whether the job was moved to the dead letter queue.
+	Dead bool
+	// FailureReason and Error describe why a dead-lettered job failed.
+	FailureReason string
+	Error         string
+	// MovedAt is when a dead-lettered job was moved to the dead letter queue.
+	MovedAt time.Time
+	// Running is whether the job is being run. It is set by the worker running
+	// the jobs of the queue, queues leave it unset.
+	Running bool
+}
+
+// ListOpts selects the jobs listed.
+type ListOpts struct {
+	// Dead lists dead-lettered jobs instead of queued jobs.
+	Dead bool
+	// Limit is the maximum number of jobs listed, oldest first. Defaults to
+	// DefaultListLimit.
+	Limit int
+}
+
+// Manager is implemented by queues whose jobs can be listed, retried and
+// cancelled, e.g. by operators.
+type Manager interface {
+	// List lists the jobs of the queue, oldest first.
+	List(context.Context, ListOpts) ([]Job, error)
+	// Get returns a queued or dead-lettered job.
+	Get(context.Context, ID) (Job, error)
+	// Retry moves a dead-lettered job back to the queue, to be run again
+	// immediately with a fresh retry count.
+	Retry(context.Context, ID) (Job, error)
+	// Cancel removes a queued or dead-lettered job. A cancelled job that is
+	// running completes, but it is not retried if it fails.
+	Cancel(context.Context, ID) (Job, error)
+}
+
+var _ Manager = (*Queue)(nil)
+
+// List lists the jobs of the queue, oldest first.
+func (q *Queue) List(ctx context.Context, opts ListOpts) ([]Job, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	query := `SELECT id, created, received, body, timeout FROM jobqueue WHERE queue = ? ORDER BY created LIMIT ?`
+	if opts.Dead {
+		query = `SELECT id, created, received, job_name, failure_reason, error_message, moved_at FROM jobqueue_dead WHERE queue = ? ORDER BY moved_at LIMIT ?`
+	}
+	rows, err := q.db.QueryContext(ctx, q.dialect.Rebind(query), q.name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var (
+			j       Job
+			created timestamp
+		)
+		if opts.Dead {
+			var moved timestamp
+			if err := rows.Scan(&j.ID, &created, &j.Received, &j.Name, &j.FailureReason, &j.Error, &moved); err != nil {
+				return nil, fmt.Errorf("scanning dead job: %w", err)
+			}
+			j.Dead = true
+			j.MovedAt = moved.Time
+		} else {
+			var (
+				body      []byte
+				available timestamp
+			)
+			if err := rows.Scan(&j.ID, &created, &j.Received, &body, &available); err != nil {
+				return nil, fmt.Errorf("scanning job: %w", err)
+			}
+			j.Name = jobName(body)
+			j.Available = available.Time
+		}
+		j.Created = created.Time
+		jobs = append(jobs, j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Get returns a queued or dead-lettered job.
+func (q *Queue) Get(ctx context.Context, id ID) (Job, error) {
+	var (
+		j         Job
+		created   timestamp
+		body      []byte
+		available timestamp
+	)
+	query := q.dialect.Rebind(`SELECT created, received, body, timeout FROM jobqueue WHERE queue = ? AND id = ?`)
+	err := q.db.QueryRowContext(ctx, query, q.name, id).Scan(&created, &j.Received, &body, &available)
+	if err == nil {
+		j.ID = id
+		j.Name = jobName(body)
+		j.Created = created.Time
+		j.Available = available.Time
+		return j, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Job{}, fmt.Errorf("fetching job: %w", err)
+	}
+
+	var moved timestamp
+	deadQuery := q.dialect.Rebind(`SELECT created, received, job_name, failure_reason, error_message, moved_at FROM jobqueue_dead WHERE queue = ? AND id = ?`)
+	if err := q.db.QueryRowContext(ctx, deadQuery, q.name, id).Scan(&created, &j.Received, &j.Name, &j.FailureReason, &j.Error, &moved); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Job{}, fmt.Errorf("%w: %s in queue %s", ErrJobNotFound, id, q.name)
+		}
+		return Job{}, fmt.Errorf("fetching dead job: %w", err)
+	}
+	j.ID = id
+	j.Created = created.Time
+	j.Dead = true
+	j.MovedAt = moved.Time
+	return j, nil
+}
+
+// Retry moves a dead-lettered job back to the queue, to be run again
+// immediately with a fresh retry count.
+func (q *Queue) Retry(ctx context.Context, id ID) (Job, error) {
+	var j Job
+	err := internalsql.InTx(q.db, func(tx *sql.Tx) error {
+		var created timestamp
+		query := q.dialect.Rebind(`SELECT created, job_name FROM jobqueue_dead WHERE queue = ? AND id = ?`)
+		if err := tx.QueryRowContext(ctx, query, q.name, id).Scan(&created, &j.Name); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s in dead letter queue %s", ErrJobNotFound, id, q.name)
+			}
+			return fmt.Errorf("fetching dead job: %w", err)
+		}
+
+		now := q.clock.Now()
+		insertQuery := q.dialect.Rebind(`
+			INSERT INTO jobqueue (id, created, queue, body, timeout, received)
+			SELECT id, created, queue, body, ?, 0
+			FROM jobqueue_dead
+			WHERE queue = ? AND id = ?`)
+		if _, err := tx.ExecContext(ctx, insertQuery, now.Format(rfc3339Milli), q.name, id); err != nil {
+			return fmt.Errorf("requeueing dead job: %w", err)
+		}
+		deleteQuery := q.dialect.Rebind(`DELETE FROM jobqueue_dead WHERE queue = ? AND id = ?`)
+		if _, err := tx.ExecContext(ctx, deleteQuery, q.name, id); err != nil {
+			return fmt.Errorf("deleting dead job: %w", err)
+		}
+
+		j.ID = id
+		j.Created = created.Time
+		j.Available = now
+		return nil
+	})
+	if err != nil {
+		return Job{}, err
+	}
+	q.logger.Infow("retrying dead job", "id", id, "job", j.Name)
+	return j, nil
+}
+
+// Cancel removes a queued or dead-lettered job. A cancelled job that is
+// running completes, but it is not retried if it fails.
+func (q *Queue) Cancel(ctx context.Context, id ID) (Job, error) {
+	var j Job
+	err := internalsql.InTx(q.db, func(tx *sql.Tx) error {
+		var body []byte
+		query := q.dialect.Rebind(`DELETE FROM jobqueue WHERE queue = ? AND id = ? RETURNING body, received`)
+		err := tx.QueryRowContext(ctx, query, q.name, id).Scan(&body, &j.Received)
+		if err == nil {
+			j.ID = id
+			j.Name = jobName(body)
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("cancelling job: %w", err)
+		}
+
+		deadQuery := q.dialect.Rebind(`DELETE FROM jobqueue_dead WHERE queue = ? AND id = ? RETURNING job_name, received`)
+		if err := tx.QueryRowContext(ctx, deadQuery, q.name, id).Scan(&j.Name, &j.Received); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s in queue %s", ErrJobNotFound, id, q.name)
+			}
+			return fmt.Errorf("cancelling dead job: %w", err)
+		}
+		j.ID = id
+		j.Dead = true
+		return nil
+	})
+	if err != nil {
+		return Job{}, err
+	}
+	q.logger.Infow("cancelled job", "id", id, "job", j.Name, "dead", j.Dead)
+	return j, nil
+}
+
+// jobName reads the name of the job from the body of a message sent by a
+// worker, empty if the body was not sent by one.
+func jobName(body []byte) string {
+	var m struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(body, &m); err != nil {
+		return ""
+	}
+	return m.Name
+}
+
+// timestamp scans timestamps stored as text by SQLite, or as timestamptz by
+// Postgres.
+type timestamp struct {
+	time.Time
+}
+
+func (t *timestamp) Scan(src any) error {
+	switch v := src.(type) {
+	case time.Time:
+		t.Time = v
+		return nil
+	case string:
+		return t.parse(v)
+	case []byte:
+		return t.parse(string(v))
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	default:
+		return fmt.Errorf("unsupported timestamp type %T", src)
+	}
+}
+
+func (t *timestamp) parse(s string) error {
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return fmt.Errorf("parsing timestamp %q: %w", s, err)
+	}
+	t.Time = parsed
+	return nil
+}
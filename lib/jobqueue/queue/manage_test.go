@@ -0,0 +1,157 @@
+package queue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+
+	testing2 "github.com/storacha/piri/lib/jobqueue/internal/testing"
+	"github.com/storacha/piri/lib/jobqueue/queue"
+)
+
+func TestQueue_Manage(t *testing.T) {
+	testing2.RunForAllBackends(t, func(t *testing.T, backend testing2.Backend) {
+		t.Run("lists queued and dead jobs", func(t *testing.T) {
+			clk := clock.NewMock()
+			clk.Set(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+			q := newQWithBackend(t, queue.NewOpts{Clock: clk}, backend)
+
+			_, err := q.SendAndGetID(t.Context(), queue.Message{Body: []byte(`{"name":"first"}`)})
+			require.NoError(t, err)
+			_, err = q.SendAndGetID(t.Context(), queue.Message{Body: []byte(`{"name":"second"}`), Delay: time.Minute})
+			require.NoError(t, err)
+
+			jobs, err := q.List(t.Context(), queue.ListOpts{})
+			require.NoError(t, err)
+			require.Len(t, jobs, 2)
+			available := map[string]time.Time{}
+			for _, j := range jobs {
+				available[j.Name] = j.Available
+			}
+			require.True(t, available["first"].Equal(clk.Now()))
+			require.True(t, available["second"].Equal(clk.Now().Add(time.Minute)))
+
+			m, err := q.Receive(t.Context())
+			require.NoError(t, err)
+			require.NoError(t, q.MoveToDeadLetter(t.Context(), m.ID, "first", "permanent_error", "boom"))
+
+			dead, err := q.List(t.Context(), queue.ListOpts{Dead: true})
+			require.NoError(t, err)
+			require.Len(t, dead, 1)
+			require.Equal(t, m.ID, dead[0].ID)
+			require.True(t, dead[0].Dead)
+			require.Equal(t, "first", dead[0].Name)
+			require.Equal(t, "permanent_error", dead[0].FailureReason)
+			require.Equal(t, "boom", dead[0].Error)
+			require.Equal(t, 1, dead[0].Received)
+
+			jobs, err = q.List(t.Context(), queue.ListOpts{Limit: 1})
+			require.NoError(t, err)
+			require.Len(t, jobs, 1)
+			require.Equal(t, "second", jobs[0].Name)
+		})
+
+		t.Run("gets queued and dead jobs", func(t *testing.T) {
+			q := newQWithBackend(t, queue.NewOpts{}, backend)
+
+			id, err := q.SendAndGetID(t.Context(), queue.Message{Body: []byte(`{"name":"job"}`)})
+			require.NoError(t, err)
+
+			job, err := q.Get(t.Context(), id)
+			require.NoError(t, err)
+			require.Equal(t, id, job.ID)
+			require.Equal(t, "job", job.Name)
+			require.False(t, job.Dead)
+			require.Zero(t, job.Received)
+
+			m, err := q.Receive(t.Context())
+			require.NoError(t, err)
+			require.NoError(t, q.MoveToDeadLetter(t.Context(), m.ID, "job", "max_retries", "boom"))
+
+			job, err = q.Get(t.Context(), id)
+			require.NoError(t, err)
+			require.True(t, job.Dead)
+			require.Equal(t, "max_retries", job.FailureReason)
+			require.Equal(t, "boom", job.Error)
+			require.Equal(t, 1, job.Received)
+
+			_, err = q.Get(t.Context(), "unknown")
+			require.ErrorIs(t, err, queue.ErrJobNotFound)
+		})
+
+		t.Run("retries a dead job with a fresh retry count", func(t *testing.T) {
+			q := newQWithBackend(t, queue.NewOpts{MaxReceive: 1}, backend)
+
+			_, err := q.SendAndGetID(t.Context(), queue.Message{Body: []byte(`{"name":"job"}`)})
+			require.NoError(t, err)
+			m, err := q.Receive(t.Context())
+			require.NoError(t, err)
+			require.NoError(t, q.MoveToDeadLetter(t.Context(), m.ID, "job", "max_retries", "boom"))
+
+			job, err := q.Retry(t.Context(), m.ID)
+			require.NoError(t, err)
+			require.Equal(t, m.ID, job.ID)
+			require.Equal(t, "job", job.Name)
+
+			retried, err := q.Receive(t.Context())
+			require.NoError(t, err)
+			require.NotNil(t, retried)
+			require.Equal(t, m.ID, retried.ID)
+			require.Equal(t, 1, retried.Received)
+
+			_, err = q.Retry(t.Context(), m.ID)
+			require.ErrorIs(t, err, queue.ErrJobNotFound)
+		})
+
+		t.Run("cancels queued and dead jobs", func(t *testing.T) {
+			q := newQWithBackend(t, queue.NewOpts{}, backend)
+
+			id, err := q.SendAndGetID(t.Context(), queue.Message{Body: []byte(`{"name":"job"}`)})
+			require.NoError(t, err)
+
+			job, err := q.Cancel(t.Context(), id)
+			require.NoError(t, err)
+			require.False(t, job.Dead)
+			require.Equal(t, "job", job.Name)
+
+			m, err := q.Receive(t.Context())
+			require.NoError(t, err)
+			require.Nil(t, m)
+
+			id, err = q.SendAndGetID(t.Context(), queue.Message{Body: []byte(`{"name":"job"}`)})
+			require.NoError(t, err)
+			m, err = q.Receive(t.Context())
+			require.NoError(t, err)
+			require.NoError(t, q.MoveToDeadLetter(t.Context(), m.ID, "job", "max_retries", "boom"))
+
+			job, err = q.Cancel(t.Context(), id)
+			require.NoError(t, err)
+			require.True(t, job.Dead)
+
+			_, err = q.Cancel(t.Context(), id)
+			require.ErrorIs(t, err, queue.ErrJobNotFound)
+		})
+
+		t.Run("does not manage jobs of other queues", func(t *testing.T) {
+			db := testing2.NewDBForBackend(t, backend)
+			q, err := queue.New(queue.NewOpts{DB: db, Name: "test", Dialect: backend.Dialect()})
+			require.NoError(t, err)
+			other, err := queue.New(queue.NewOpts{DB: db, Name: "other", Dialect: backend.Dialect()})
+			require.NoError(t, err)
+
+			id, err := other.SendAndGetID(t.Context(), queue.Message{Body: []byte(`{"name":"job"}`)})
+			require.NoError(t, err)
+
+			jobs, err := q.List(t.Context(), queue.ListOpts{})
+			require.NoError(t, err)
+			require.Empty(t, jobs)
+
+			_, err = q.Get(t.Context(), id)
+			require.ErrorIs(t, err, queue.ErrJobNotFound)
+			_, err = q.Cancel(t.Context(), id)
+			require.ErrorIs(t, err, queue.ErrJobNotFound)
+		})
+	})
+}
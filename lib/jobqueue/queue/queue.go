@@ -15,6 +15,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/raulk/clock"
+
 	"github.com/storacha/piri/lib/jobqueue/dialect"
 	internalsql "github.com/storacha/piri/lib/jobqueue/internal/sql"
 	"github.com/storacha/piri/lib/jobqueue/logger"
@@ -37,6 +39,7 @@ type NewOpts struct {
 	Timeout    time.Duration // Default timeout for messages before they can be re-received.
 	Logger     logger.StandardLogger
 	Dialect    dialect.Dialect // SQL dialect (SQLite or Postgres)
+	Clock      clock.Clock     // Clock message timeouts are measured against.
 }
 
 // New Queue with the given options.
@@ -44,6 +47,7 @@ type NewOpts struct {
 // - Logs are discarded.
 // - Max receive count is 3.
 // - Timeout is five seconds.
+// - Clock is the real clock.
 func New(opts NewOpts) (*Queue, error) {
 	if opts.DB == nil {
 		return nil, errors.New("db is required")
@@ -72,6 +76,9 @@ func New(opts NewOpts) (*Queue, error) {
 	if opts.Logger == nil {
 		opts.Logger = &logger.DiscardLogger{}
 	}
+	if opts.Clock == nil {
+		opts.Clock = clock.New()
+	}
 
 	return &Queue{
 		db:         opts.DB,
@@ -80,6 +87,7 @@ func New(opts NewOpts) (*Queue, error) {
 		timeout:    opts.Timeout,
 		logger:     opts.Logger,
 		dialect:    opts.Dialect,
+		clock:      opts.Clock,
 	}, nil
 }
 
@@ -90,6 +98,7 @@ type Queue struct {
 	timeout    time.Duration
 	logger     logger.StandardLogger
 	dialect    dialect.Dialect
+	clock      clock.Clock
 }
 
 type ID string
@@ -153,7 +162,7 @@ func (q *Queue) sendAndGetIDTx(ctx context.Context, tx *sql.Tx, m Message) (ID,
 		panic("delay cannot be negative")
 	}
 
-	timeout := time.Now().Add(m.Delay).Format(rfc3339Milli)
+	timeout := q.clock.Now().Add(m.Delay).Format(rfc3339Milli)
 
 	var id ID
 	query := q.dialect.Rebind(`INSERT INTO jobqueue (queue, body, timeout) VALUES (?, ?, ?) RETURNING id`)
@@ -176,7 +185,7 @@ func (q *Queue) Receive(ctx context.Context) (*Message, error) {
 
 // receiveTx is like Receive, but within an existing transaction.
 func (q *Queue) receiveTx(ctx context.Context, tx *sql.Tx) (*Message, error) {
-	now := time.Now()
+	now := q.clock.Now()
 	nowFormatted := now.Format(rfc3339Milli)
 	timeoutFormatted := now.Add(q.timeout).Format(rfc3339Milli)
 
@@ -209,7 +218,7 @@ func (q *Queue) receiveTx(ctx context.Context, tx *sql.Tx) (*Message, error) {
 // ReceiveAndWait for a Message from the queue, polling at the given interval, until the context is cancelled.
 // If the context is cancelled, the error will be non-nil. See [context.Context.Err].
 func (q *Queue) ReceiveAndWait(ctx context.Context, interval time.Duration) (*Message, error) {
-	ticker := time.NewTicker(interval)
+	ticker := q.clock.Ticker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -241,7 +250,7 @@ func (q *Queue) extendTx(ctx context.Context, tx *sql.Tx, id ID, delay time.Dura
 		panic("delay cannot be negative")
 	}
 
-	timeout := time.Now().Add(delay).Format(rfc3339Milli)
+	timeout := q.clock.Now().Add(delay).Format(rfc3339Milli)
 
 	query := q.dialect.Rebind(`UPDATE jobqueue SET timeout = ? WHERE queue = ? AND id = ?`)
 	_, err := tx.ExecContext(ctx, query, timeout, q.name, id)
@@ -273,7 +282,7 @@ func (q *Queue) MoveToDeadLetter(ctx context.Context, id ID, jobName, failureRea
 
 // moveToDeadLetterTx is like MoveToDeadLetter, but within an existing transaction.
 func (q *Queue) moveToDeadLetterTx(ctx context.Context, tx *sql.Tx, id ID, jobName, failureReason, errorMsg string) error {
-	movedAt := time.Now().Format(rfc3339Milli)
+	movedAt := q.clock.Now().Format(rfc3339Milli)
 
 	// First, copy the message to the dead letter queue
 	insertQuery := q.dialect.Rebind(`
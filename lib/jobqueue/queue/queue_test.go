@@ -17,6 +17,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/raulk/clock"
 	"github.com/stretchr/testify/require"
 
 	testing2 "github.com/storacha/piri/lib/jobqueue/internal/testing"
@@ -184,6 +185,31 @@ func TestQueue_Receive(t *testing.T) {
 			require.Nil(t, m)
 		})
 
+		t.Run("receives a message again once its timeout has passed on the clock", func(t *testing.T) {
+			clk := clock.NewMock()
+			clk.Set(time.Now())
+			q := newQWithBackend(t, queue.NewOpts{Timeout: time.Minute, Clock: clk}, backend)
+
+			err := q.Send(t.Context(), queue.Message{Body: []byte("yo")})
+			require.NoError(t, err)
+
+			m, err := q.Receive(t.Context())
+			require.NoError(t, err)
+			require.NotNil(t, m)
+			require.Equal(t, 1, m.Received)
+
+			clk.Add(59 * time.Second)
+			m, err = q.Receive(t.Context())
+			require.NoError(t, err)
+			require.Nil(t, m)
+
+			clk.Add(time.Second)
+			m, err = q.Receive(t.Context())
+			require.NoError(t, err)
+			require.NotNil(t, m)
+			require.Equal(t, 2, m.Received)
+		})
+
 		t.Run("does not receive a message from a different queue", func(t *testing.T) {
 			db := testing2.NewDBForBackend(t, backend)
 			q1, err := queue.New(queue.NewOpts{DB: db, Name: "q1", Dialect: backend.Dialect()})
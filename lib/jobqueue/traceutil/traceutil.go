@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 
 	"go.opentelemetry.io/otel/trace"
+
+	"github.com/storacha/piri/lib/telemetry/traces"
 )
 
 type linkContextKey struct{}
@@ -37,6 +39,35 @@ func StartSpan(ctx context.Context, tracer trace.Tracer, name string, opts ...tr
 	return tracer.Start(ctx, name, opts...)
 }
 
+// ExemplarContext returns a context suitable for recording metrics with
+// trace exemplars. Spans started with StartSpan are new roots that are usually
+// not sampled, so when the active span is not sampled the linked span (the one
+// active when the job was enqueued) is used instead. This ties job latency back
+// to the request that caused it.
+func ExemplarContext(ctx context.Context) context.Context {
+	if trace.SpanContextFromContext(ctx).IsSampled() {
+		return ctx
+	}
+	if link, ok := LinkFromContext(ctx); ok && link.SpanContext.IsSampled() {
+		return trace.ContextWithSpanContext(ctx, link.SpanContext)
+	}
+	return ctx
+}
+
+// LogFields returns the trace and span IDs for the logs of a job: those of the
+// active span if any, otherwise those of the span active when the job was
+// enqueued, so the logs of the job can be correlated with the request that
+// caused it.
+func LogFields(ctx context.Context) []any {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return traces.SpanLogFields(sc)
+	}
+	if link, ok := LinkFromContext(ctx); ok {
+		return traces.SpanLogFields(link.SpanContext)
+	}
+	return nil
+}
+
 // SpanContextPayload is a lightweight representation of a span context for persistence.
 type SpanContextPayload struct {
 	TraceID    string `json:"trace_id"`
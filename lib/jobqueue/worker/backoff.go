@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"math"
+	"time"
+)
+
+// Backoff computes how long a failed job waits before it is retried.
+type Backoff interface {
+	// Delay returns the delay before retrying a job that failed on the given
+	// attempt, starting at 1.
+	Delay(attempt int) time.Duration
+}
+
+// ExponentialBackoff doubles the delay between retries, from Min after the
+// first failed attempt up to Max, or without limit if Max is zero.
+type ExponentialBackoff struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+var _ Backoff = ExponentialBackoff{}
+
+func (b ExponentialBackoff) Delay(attempt int) time.Duration {
+	delay := b.Min
+	for i := 1; i < attempt && delay < math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	if b.Max > 0 && delay > b.Max {
+		return b.Max
+	}
+	return delay
+}
@@ -0,0 +1,27 @@
+package worker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/lib/jobqueue/worker"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	t.Run("doubles the delay up to the max", func(t *testing.T) {
+		b := worker.ExponentialBackoff{Min: time.Second, Max: 10 * time.Second}
+		require.Equal(t, time.Second, b.Delay(1))
+		require.Equal(t, 2*time.Second, b.Delay(2))
+		require.Equal(t, 8*time.Second, b.Delay(4))
+		require.Equal(t, 10*time.Second, b.Delay(5))
+		require.Equal(t, 10*time.Second, b.Delay(100))
+	})
+
+	t.Run("does not overflow without a max", func(t *testing.T) {
+		b := worker.ExponentialBackoff{Min: time.Second}
+		require.Equal(t, 16*time.Second, b.Delay(5))
+		require.Positive(t, b.Delay(1000))
+	})
+}
@@ -1,5 +1,7 @@
 package worker
 
+import "errors"
+
 // PermanentError signals that the operation should not be retried.
 type PermanentError struct {
 	Err error
@@ -24,3 +26,7 @@ func (e *PermanentError) Error() string {
 func (e *PermanentError) Unwrap() error {
 	return e.Err
 }
+
+// ErrUnmanaged is returned when managing the jobs of a queue that does not
+// implement [queue.Manager].
+var ErrUnmanaged = errors.New("queue does not support managing jobs")
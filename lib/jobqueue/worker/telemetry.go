@@ -7,6 +7,7 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/storacha/piri/lib/jobqueue/traceutil"
 	"github.com/storacha/piri/lib/telemetry"
 )
 
@@ -37,6 +38,8 @@ type metricsRecorder struct {
 	queuedJobs        *telemetry.UpDownCounter
 	failedJobsCounter *telemetry.Counter
 	jobDurationTimer  *telemetry.Timer
+	poolSize          *telemetry.Int64Gauge
+	poolUtilization   *telemetry.Int64Gauge
 }
 
 func newMetrics() (*metricsRecorder, error) {
@@ -77,11 +80,31 @@ func newMetrics() (*metricsRecorder, error) {
 	if err != nil {
 		return nil, err
 	}
+	poolSize, err := telemetry.NewInt64Gauge(
+		meter,
+		"worker_pool_size",
+		"maximum number of jobs the queue runs simultaneously",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	poolUtilization, err := telemetry.NewInt64Gauge(
+		meter,
+		"worker_pool_utilization",
+		"percentage of the worker pool running jobs",
+		"%",
+	)
+	if err != nil {
+		return nil, err
+	}
 	return &metricsRecorder{
 		activeJobs:        activeJobs,
 		queuedJobs:        queuedJobs,
 		failedJobsCounter: failedJobs,
 		jobDurationTimer:  jobDuration,
+		poolSize:          poolSize,
+		poolUtilization:   poolUtilization,
 	}, nil
 }
 
@@ -132,5 +155,15 @@ func (m *metricsRecorder) recordJobDuration(ctx context.Context, queueName, jobN
 		attrs = append(attrs, attribute.Int("attempt", attempt))
 	}
 
-	m.jobDurationTimer.Record(ctx, duration, attrs...)
+	m.jobDurationTimer.Record(traceutil.ExemplarContext(ctx), duration, attrs...)
+}
+
+func (m *metricsRecorder) recordPool(ctx context.Context, queueName string, running, limit int) {
+	if m == nil || m.poolSize == nil || m.poolUtilization == nil || limit <= 0 {
+		return
+	}
+	// running exceeds the limit while jobs started before it was lowered complete
+	utilization := min(int64(running)*100/int64(limit), 100)
+	m.poolSize.Record(ctx, int64(limit), attribute.String("queue", queueName))
+	m.poolUtilization.Record(ctx, utilization, attribute.String("queue", queueName))
 }
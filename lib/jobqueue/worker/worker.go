@@ -10,7 +10,9 @@
 //
 // It provides:
 //   - Limit on how many jobs can be run simultaneously
+//   - Limit on how many jobs are started per minute
 //   - Automatic message timeout extension while the job is running
+//   - Exponential backoff between retries of failed jobs
 //   - Graceful shutdown
 package worker
 
@@ -24,8 +26,11 @@ import (
 	"runtime"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"golang.org/x/time/rate"
+
 	"github.com/storacha/piri/lib/jobqueue/logger"
 	"github.com/storacha/piri/lib/jobqueue/queue"
 	"github.com/storacha/piri/lib/jobqueue/serializer"
@@ -38,6 +43,15 @@ type JobFn[T any] = func(ctx context.Context, msg T) error
 // OnFailureFn is the function that runs if the job never completes successfully after all retries or returns a PermanentError.
 type OnFailureFn[T any] = func(ctx context.Context, msg T, err error) error
 
+type jobIDKey struct{}
+
+// JobID returns the ID of the job a job function or OnFailure callback is run
+// for with ctx, e.g. to manage the job once it is dead-lettered.
+func JobID(ctx context.Context) (queue.ID, bool) {
+	id, ok := ctx.Value(jobIDKey{}).(queue.ID)
+	return id, ok
+}
+
 // jobRegistration holds a job function and its optional OnFailure callback
 type jobRegistration[T any] struct {
 	fn        JobFn[T]
@@ -49,13 +63,18 @@ type Worker[T any] struct {
 	jobs          map[string]*jobRegistration[T]
 	pollInterval  time.Duration
 	extend        time.Duration
+	backoff       Backoff
 	jobCount      int
+	running       map[queue.ID]struct{}
 	queueName     string
 	jobCountLimit int
 	jobCountLock  sync.RWMutex
+	rateLimit     uint
+	limiter       *rate.Limiter
 	log           logger.StandardLogger
 	serializer    serializer.Serializer[T]
 	metrics       *metricsRecorder
+	paused        atomic.Bool
 }
 
 // Config holds all parameters needed to initialize a Worker.
@@ -64,7 +83,11 @@ type Config struct {
 	JobCountLimit int
 	PollInterval  time.Duration
 	Extend        time.Duration
+	Backoff       Backoff
 	QueueName     string
+	// RateLimit is the maximum number of jobs started per minute, zero for no
+	// limit.
+	RateLimit uint
 }
 
 // Option modifies a Config before creating the Worker.
@@ -82,6 +105,14 @@ func WithLimit(limit int) Option {
 	}
 }
 
+// WithRateLimit limits the number of jobs started per minute, in addition to
+// the number run simultaneously. Zero, the default, does not limit them.
+func WithRateLimit(perMinute uint) Option {
+	return func(cfg *Config) {
+		cfg.RateLimit = perMinute
+	}
+}
+
 func WithPollInterval(interval time.Duration) Option {
 	return func(cfg *Config) {
 		cfg.PollInterval = interval
@@ -95,6 +126,14 @@ func WithExtend(d time.Duration) Option {
 	}
 }
 
+// WithBackoff delays the retries of failed jobs, by default a failed job is
+// retried once its message timeout passes.
+func WithBackoff(b Backoff) Option {
+	return func(cfg *Config) {
+		cfg.Backoff = b
+	}
+}
+
 // WithQueueName sets the queue name for telemetry labels.
 func WithQueueName(name string) Option {
 	return func(cfg *Config) {
@@ -122,7 +161,8 @@ func New[T any](q queue.Interface, ser serializer.Serializer[T], options ...Opti
 	}
 	// Construct the Worker using the final config
 	jq := &Worker[T]{
-		jobs: make(map[string]*jobRegistration[T]),
+		jobs:    make(map[string]*jobRegistration[T]),
+		running: make(map[queue.ID]struct{}),
 
 		queue:      q,
 		serializer: ser,
@@ -132,8 +172,13 @@ func New[T any](q queue.Interface, ser serializer.Serializer[T], options ...Opti
 		jobCountLimit: cfg.JobCountLimit,
 		pollInterval:  cfg.PollInterval,
 		extend:        cfg.Extend,
+		backoff:       cfg.Backoff,
 		metrics:       metricsRecorder,
 	}
+	if cfg.RateLimit > 0 {
+		jq.rateLimit = cfg.RateLimit
+		jq.limiter = rate.NewLimiter(jobsPerMinute(cfg.RateLimit), 1)
+	}
 	return jq, nil
 }
 
@@ -154,6 +199,11 @@ func (r *Worker[T]) Start(ctx context.Context) {
 
 	r.log.Infow("Starting", "jobs", names)
 
+	r.jobCountLock.RLock()
+	running, limit := r.jobCount, r.jobCountLimit
+	r.jobCountLock.RUnlock()
+	r.metrics.recordPool(ctx, r.queueName, running, limit)
+
 	var wg sync.WaitGroup
 
 	for {
@@ -245,16 +295,204 @@ func (r *Worker[T]) EnqueueTx(ctx context.Context, tx *sql.Tx, name string, msg
 	return r.queue.SendTx(ctx, tx, queue.Message{Body: buf.Bytes()})
 }
 
+// Pause stops the Worker from receiving new jobs. Running jobs are not
+// interrupted and queued jobs remain in the queue until the Worker is resumed.
+func (r *Worker[T]) Pause() {
+	if !r.paused.Swap(true) {
+		r.log.Infow("Paused")
+	}
+}
+
+// Resume continues receiving jobs after a Pause.
+func (r *Worker[T]) Resume() {
+	if r.paused.Swap(false) {
+		r.log.Infow("Resumed")
+	}
+}
+
+// Paused reports whether the Worker is paused.
+func (r *Worker[T]) Paused() bool {
+	return r.paused.Load()
+}
+
+// SetLimit changes the maximum number of jobs run simultaneously. Lowering
+// the limit does not interrupt running jobs, no new jobs are received until
+// enough of them complete.
+func (r *Worker[T]) SetLimit(limit int) {
+	r.jobCountLock.Lock()
+	old := r.jobCountLimit
+	r.jobCountLimit = limit
+	running := r.jobCount
+	r.jobCountLock.Unlock()
+	if old != limit {
+		r.log.Infow("Job limit changed", "old", old, "new", limit)
+	}
+	r.metrics.recordPool(context.Background(), r.queueName, running, limit)
+}
+
+// SetRateLimit changes the maximum number of jobs started per minute, zero
+// removes the limit. Jobs are started at an even pace, at most one every
+// minute divided by the limit.
+func (r *Worker[T]) SetRateLimit(perMinute uint) {
+	r.jobCountLock.Lock()
+	old := r.rateLimit
+	r.rateLimit = perMinute
+	switch {
+	case perMinute == 0:
+		r.limiter = nil
+	case r.limiter == nil:
+		r.limiter = rate.NewLimiter(jobsPerMinute(perMinute), 1)
+	default:
+		r.limiter.SetLimit(jobsPerMinute(perMinute))
+	}
+	r.jobCountLock.Unlock()
+	if old != perMinute {
+		r.log.Infow("Job rate limit changed", "old", old, "new", perMinute)
+	}
+}
+
+func jobsPerMinute(n uint) rate.Limit {
+	return rate.Limit(float64(n) / time.Minute.Seconds())
+}
+
+// Snapshot describes the state of a Worker at a point in time.
+type Snapshot struct {
+	Queue string `json:"queue"`
+	// Jobs are the names of the registered jobs.
+	Jobs []string `json:"jobs"`
+	// Running is the number of jobs currently running.
+	Running int `json:"running"`
+	// Limit is the maximum number of jobs run simultaneously.
+	Limit int `json:"limit"`
+	// RateLimit is the maximum number of jobs started per minute, zero if
+	// unlimited.
+	RateLimit uint `json:"rate_limit"`
+	Paused    bool `json:"paused"`
+}
+
+// Snapshot returns the current state of the Worker.
+func (r *Worker[T]) Snapshot() Snapshot {
+	names := make([]string, 0, len(r.jobs))
+	for k := range r.jobs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	r.jobCountLock.RLock()
+	running, limit, rateLimit := r.jobCount, r.jobCountLimit, r.rateLimit
+	r.jobCountLock.RUnlock()
+
+	return Snapshot{
+		Queue:     r.queueName,
+		Jobs:      names,
+		Running:   running,
+		Limit:     limit,
+		RateLimit: rateLimit,
+		Paused:    r.paused.Load(),
+	}
+}
+
+// ListJobs lists the jobs of the queue, marking those the Worker is running.
+func (r *Worker[T]) ListJobs(ctx context.Context, opts queue.ListOpts) ([]queue.Job, error) {
+	m, ok := r.queue.(queue.Manager)
+	if !ok {
+		return nil, ErrUnmanaged
+	}
+	jobs, err := m.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	r.jobCountLock.RLock()
+	defer r.jobCountLock.RUnlock()
+	for i := range jobs {
+		jobs[i].Running = r.isRunning(jobs[i])
+	}
+	return jobs, nil
+}
+
+// GetJob returns a queued or dead-lettered job of the queue, marked if the
+// Worker is running it.
+func (r *Worker[T]) GetJob(ctx context.Context, id queue.ID) (queue.Job, error) {
+	m, ok := r.queue.(queue.Manager)
+	if !ok {
+		return queue.Job{}, ErrUnmanaged
+	}
+	j, err := m.Get(ctx, id)
+	if err != nil {
+		return queue.Job{}, err
+	}
+	r.jobCountLock.RLock()
+	j.Running = r.isRunning(j)
+	r.jobCountLock.RUnlock()
+	return j, nil
+}
+
+// isRunning must be called with jobCountLock held.
+func (r *Worker[T]) isRunning(j queue.Job) bool {
+	if j.Dead {
+		return false
+	}
+	_, ok := r.running[j.ID]
+	return ok
+}
+
+// RetryJob moves a dead-lettered job back to the queue, to be run again
+// immediately with a fresh retry count.
+func (r *Worker[T]) RetryJob(ctx context.Context, id queue.ID) (queue.Job, error) {
+	m, ok := r.queue.(queue.Manager)
+	if !ok {
+		return queue.Job{}, ErrUnmanaged
+	}
+	j, err := m.Retry(ctx, id)
+	if err != nil {
+		return queue.Job{}, err
+	}
+	if !j.Dead {
+		r.metrics.recordQueuedDelta(ctx, r.queueName, j.Name, 1)
+	}
+	return j, nil
+}
+
+// CancelJob removes a queued or dead-lettered job. A cancelled job that is
+// running completes, but it is not retried if it fails.
+func (r *Worker[T]) CancelJob(ctx context.Context, id queue.ID) (queue.Job, error) {
+	m, ok := r.queue.(queue.Manager)
+	if !ok {
+		return queue.Job{}, ErrUnmanaged
+	}
+	j, err := m.Cancel(ctx, id)
+	if err != nil {
+		return queue.Job{}, err
+	}
+	if !j.Dead {
+		r.metrics.recordQueuedDelta(ctx, r.queueName, j.Name, -1)
+	}
+	return j, nil
+}
+
 func (r *Worker[T]) receiveAndRun(ctx context.Context, wg *sync.WaitGroup) {
+	if r.paused.Load() {
+		time.Sleep(r.pollInterval) // Avoid busy loop
+		return
+	}
+
 	// Check if we've reached the worker limit
 	r.jobCountLock.RLock()
-	if r.jobCount == r.jobCountLimit {
+	if r.jobCount >= r.jobCountLimit {
 		r.jobCountLock.RUnlock()
 		time.Sleep(r.pollInterval) // Avoid busy loop
 		return
 	}
+	limiter := r.limiter
 	r.jobCountLock.RUnlock()
 
+	// Check if we've started as many jobs as the rate limit allows, the token
+	// is only taken once a job is received
+	if limiter != nil && limiter.Tokens() < 1 {
+		time.Sleep(r.pollInterval) // Avoid busy loop
+		return
+	}
+
 	// Receive a message from the queue
 	m, err := r.queue.ReceiveAndWait(ctx, r.pollInterval)
 	if err != nil {
@@ -269,6 +507,9 @@ func (r *Worker[T]) receiveAndRun(ctx context.Context, wg *sync.WaitGroup) {
 	if m == nil {
 		return
 	}
+	if limiter != nil {
+		limiter.Allow()
+	}
 
 	// Decode and deserialize the message
 	jm, jobInput, err := r.decodeMessage(m.Body)
@@ -289,7 +530,10 @@ func (r *Worker[T]) receiveAndRun(ctx context.Context, wg *sync.WaitGroup) {
 	// Increment job count and run the job asynchronously
 	r.jobCountLock.Lock()
 	r.jobCount++
+	r.running[m.ID] = struct{}{}
+	running, limit := r.jobCount, r.jobCountLimit
 	r.jobCountLock.Unlock()
+	r.metrics.recordPool(ctx, r.queueName, running, limit)
 
 	wg.Add(1)
 	go r.runJob(ctx, wg, m, jm, jobInput, jobReg)
@@ -321,7 +565,10 @@ func (r *Worker[T]) runJob(ctx context.Context, wg *sync.WaitGroup, m *queue.Mes
 	defer func() {
 		r.jobCountLock.Lock()
 		r.jobCount--
+		delete(r.running, m.ID)
+		running, limit := r.jobCount, r.jobCountLimit
 		r.jobCountLock.Unlock()
+		r.metrics.recordPool(ctx, r.queueName, running, limit)
 	}()
 	defer func() {
 		if rec := recover(); rec != nil {
@@ -332,16 +579,25 @@ func (r *Worker[T]) runJob(ctx context.Context, wg *sync.WaitGroup, m *queue.Mes
 	r.metrics.recordActiveDelta(ctx, r.queueName, jm.Name, 1)
 	defer r.metrics.recordActiveDelta(ctx, r.queueName, jm.Name, -1)
 
-	jobCtx, cancel := context.WithCancel(ctx)
+	jobCtx, cancel := context.WithCancel(context.WithValue(ctx, jobIDKey{}, m.ID))
 	defer cancel()
 
-	// Start timeout extension goroutine
-	go r.extendMessageTimeout(jobCtx, m.ID, jm.Name)
+	// Start timeout extension goroutine, stopped once the job returns so it
+	// does not override the backoff of a failed job
+	extendCtx, stopExtend := context.WithCancel(jobCtx)
+	extendDone := make(chan struct{})
+	go func() {
+		defer close(extendDone)
+		r.extendMessageTimeout(extendCtx, m.ID, jm.Name)
+	}()
 
 	// Execute the job
-	r.log.Infow("Running job", "name", jm.Name, "attempt", m.Received)
+	r.log.Infow("Running job", withTrace(jobCtx, "name", jm.Name, "attempt", m.Received)...)
 	before := time.Now()
-	if err := jobReg.fn(jobCtx, jobInput); err != nil {
+	err := jobReg.fn(jobCtx, jobInput)
+	stopExtend()
+	<-extendDone
+	if err != nil {
 		r.metrics.recordJobDuration(jobCtx, r.queueName, jm.Name, "failure", m.Received, time.Since(before))
 		r.handleJobError(jobCtx, m, jm.Name, jobInput, jobReg, err)
 		return
@@ -349,24 +605,30 @@ func (r *Worker[T]) runJob(ctx context.Context, wg *sync.WaitGroup, m *queue.Mes
 
 	// Job succeeded
 	duration := time.Since(before)
-	r.log.Infow("Ran job", "name", jm.Name, "duration", duration, "attempt", m.Received)
+	r.log.Infow("Ran job", withTrace(jobCtx, "name", jm.Name, "duration", duration, "attempt", m.Received)...)
 	r.metrics.recordJobDuration(jobCtx, r.queueName, jm.Name, "success", m.Received, duration)
 	r.deleteMessage(jobCtx, m.ID, jm.Name)
 }
 
+// withTrace adds the trace and span IDs of the request that enqueued the job
+// to the fields of a log.
+func withTrace(ctx context.Context, keysAndValues ...any) []any {
+	return append(keysAndValues, traceutil.LogFields(ctx)...)
+}
+
 // extendMessageTimeout periodically extends the message timeout while the job is running
 func (r *Worker[T]) extendMessageTimeout(ctx context.Context, messageID queue.ID, jobName string) {
-	time.Sleep(r.extend - r.extend/5) // Initial sleep
+	ticker := time.NewTicker(r.extend - r.extend/5)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		default:
+		case <-ticker.C:
 			r.log.Infow("Extending message timeout", "name", jobName)
-			if err := r.queue.Extend(ctx, messageID, r.extend); err != nil {
+			if err := r.queue.Extend(ctx, messageID, r.extend); err != nil && ctx.Err() == nil {
 				r.log.Errorw("Error extending message timeout", "error", err)
 			}
-			time.Sleep(r.extend - r.extend/5)
 		}
 	}
 }
@@ -385,17 +647,35 @@ func (r *Worker[T]) handleJobError(ctx context.Context, m *queue.Message, jobNam
 	}
 
 	// Retryable error
-	r.log.Warnw("Error running job, retrying",
+	if r.backoff == nil {
+		r.log.Warnw("Error running job, retrying", withTrace(ctx,
+			"name", jobName,
+			"attempt", m.Received,
+			"max_attempts", r.queue.MaxReceive(),
+			"error", err,
+		)...)
+		return
+	}
+	delay := r.backoff.Delay(m.Received)
+	r.log.Warnw("Error running job, retrying", withTrace(ctx,
 		"name", jobName,
 		"attempt", m.Received,
 		"max_attempts", r.queue.MaxReceive(),
+		"retry_in", delay,
 		"error", err,
-	)
+	)...)
+	// the job may have failed because the worker is stopping, the retry is
+	// still delayed
+	backoffCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 3*time.Second)
+	defer cancel()
+	if extendErr := r.queue.Extend(backoffCtx, m.ID, delay); extendErr != nil {
+		r.log.Errorw("Error delaying job retry", "name", jobName, "error", extendErr)
+	}
 }
 
 // handlePermanentError handles errors that should not be retried
 func (r *Worker[T]) handlePermanentError(ctx context.Context, messageID queue.ID, jobName string, jobInput T, jobReg *jobRegistration[T], err error, attempt int) {
-	r.log.Errorw("Failed to run job, PermanentError occurred", "error", err, "name", jobName)
+	r.log.Errorw("Failed to run job, PermanentError occurred", withTrace(ctx, "error", err, "name", jobName)...)
 	r.metrics.recordJobFailure(ctx, r.queueName, jobName, "permanent_error", attempt)
 
 	// Invoke OnFailure callback if configured
@@ -409,13 +689,13 @@ func (r *Worker[T]) handlePermanentError(ctx context.Context, messageID queue.ID
 
 // handleMaxRetriesExceeded handles errors after all retries have been exhausted
 func (r *Worker[T]) handleMaxRetriesExceeded(ctx context.Context, messageID queue.ID, jobName string, jobInput T, jobReg *jobRegistration[T], err error, attempt int) {
-	r.log.Errorw("Failed to run job, max retries reached, will not retry",
+	r.log.Errorw("Failed to run job, max retries reached, will not retry", withTrace(ctx,
 		"name", jobName,
 		"attempt", attempt,
 		"next_attempt", r.queue.Timeout(),
 		"max_attempts", r.queue.MaxReceive(),
 		"error", err,
-	)
+	)...)
 	r.metrics.recordJobFailure(ctx, r.queueName, jobName, "max_retries", attempt)
 
 	// Invoke OnFailure callback if configured
@@ -12,6 +12,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -190,6 +191,45 @@ func TestOnFailure(t *testing.T) {
 			require.False(t, onFailureCalled, "OnFailure should not be called if job eventually succeeds")
 			require.Equal(t, 3, attempts, "Should have attempted 3 times")
 		})
+
+		t.Run("passes the job ID to the job and OnFailure", func(t *testing.T) {
+			q := internaltesting.NewQForBackend(t, queue.NewOpts{
+				MaxReceive: 3,
+				Timeout:    10 * time.Millisecond,
+			}, backend)
+			r, err := worker.New[[]byte](
+				q,
+				&PassThroughSerializer[[]byte]{},
+				worker.WithLimit(10),
+			)
+			require.NoError(t, err)
+
+			var jobID, failureID queue.ID
+
+			ctx, cancel := context.WithTimeout(t.Context(), 500*time.Millisecond)
+			defer cancel()
+
+			err = r.Register("permanent-failure",
+				func(ctx context.Context, m []byte) error {
+					jobID, _ = worker.JobID(ctx)
+					return worker.Permanent(fmt.Errorf("job failed"))
+				},
+				worker.WithOnFailure(func(ctx context.Context, msg []byte, err error) error {
+					failureID, _ = worker.JobID(ctx)
+					cancel()
+					return nil
+				}),
+			)
+			require.NoError(t, err)
+
+			err = r.Enqueue(ctx, "permanent-failure", []byte("test"))
+			require.NoError(t, err)
+
+			r.Start(ctx)
+
+			require.NotEmpty(t, jobID)
+			require.Equal(t, jobID, failureID)
+		})
 	})
 }
 
@@ -434,6 +474,54 @@ func TestRunner_Start(t *testing.T) {
 			r.Start(ctx)
 		})
 
+		t.Run("does not run jobs while paused", func(t *testing.T) {
+			_, r := newRunnerForBackend(t, backend)
+
+			var ran atomic.Bool
+			ctx, cancel := context.WithCancel(t.Context())
+			require.NoError(t, r.Register("test", func(ctx context.Context, m []byte) error {
+				ran.Store(true)
+				cancel()
+				return nil
+			}))
+
+			r.Pause()
+			require.True(t, r.Paused())
+			err := r.Enqueue(ctx, "test", []byte("yo"))
+			require.NoError(t, err)
+
+			var ranWhilePaused atomic.Bool
+			go func() {
+				time.Sleep(300 * time.Millisecond)
+				ranWhilePaused.Store(ran.Load())
+				r.Resume()
+			}()
+
+			r.Start(ctx)
+			require.False(t, ranWhilePaused.Load())
+			require.True(t, ran.Load())
+		})
+
+		t.Run("reports a snapshot", func(t *testing.T) {
+			_, r := newRunnerForBackend(t, backend)
+			noop := func(ctx context.Context, m []byte) error { return nil }
+			require.NoError(t, r.Register("b", noop))
+			require.NoError(t, r.Register("a", noop))
+			r.Pause()
+
+			s := r.Snapshot()
+			require.Equal(t, []string{"a", "b"}, s.Jobs)
+			require.Zero(t, s.Running)
+			require.Equal(t, 10, s.Limit)
+			require.True(t, s.Paused)
+		})
+
+		t.Run("changes the job limit", func(t *testing.T) {
+			_, r := newRunnerForBackend(t, backend)
+			r.SetLimit(2)
+			require.Equal(t, 2, r.Snapshot().Limit)
+		})
+
 		t.Run("extends a job's timeout if it takes longer than the default timeout", func(t *testing.T) {
 			_, r := newRunnerForBackend(t, backend)
 
@@ -517,3 +605,132 @@ func (p PassThroughSerializer[T]) Deserialize(data []byte) (T, error) {
 	}
 	return any(data).(T), nil
 }
+
+func TestBackoff(t *testing.T) {
+	internaltesting.RunForAllBackends(t, func(t *testing.T, backend internaltesting.Backend) {
+		t.Run("delays the retry of a failed job", func(t *testing.T) {
+			db := internaltesting.NewDBForBackend(t, backend)
+			q, err := queue.New(queue.NewOpts{
+				DB:         db,
+				Name:       "test",
+				MaxReceive: 3,
+				Timeout:    time.Second,
+				Dialect:    backend.Dialect(),
+			})
+			require.NoError(t, err)
+			r, err := worker.New[[]byte](
+				q,
+				&PassThroughSerializer[[]byte]{},
+				worker.WithBackoff(worker.ExponentialBackoff{Min: time.Hour}),
+			)
+			require.NoError(t, err)
+
+			var attempts atomic.Int32
+			err = r.Register("failing-job", func(ctx context.Context, m []byte) error {
+				attempts.Add(1)
+				return fmt.Errorf("failed")
+			})
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(t.Context(), 300*time.Millisecond)
+			defer cancel()
+			require.NoError(t, r.Enqueue(ctx, "failing-job", []byte("test-message")))
+			r.Start(ctx)
+
+			require.Equal(t, int32(1), attempts.Load(), "failed job should not be retried before its backoff")
+			jobs, err := r.ListJobs(t.Context(), queue.ListOpts{})
+			require.NoError(t, err)
+			require.Len(t, jobs, 1)
+			require.False(t, jobs[0].Running)
+			require.WithinDuration(t, time.Now().Add(time.Hour), jobs[0].Available, time.Minute)
+		})
+	})
+}
+
+func TestRunningJobs(t *testing.T) {
+	internaltesting.RunForAllBackends(t, func(t *testing.T, backend internaltesting.Backend) {
+		t.Run("marks the jobs being run", func(t *testing.T) {
+			_, r := newRunnerForBackend(t, backend)
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			err := r.Register("blocking-job", func(ctx context.Context, m []byte) error {
+				close(started)
+				<-release
+				return nil
+			})
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+			require.NoError(t, r.Enqueue(ctx, "blocking-job", []byte("test-message")))
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				r.Start(ctx)
+			}()
+			<-started
+
+			jobs, err := r.ListJobs(t.Context(), queue.ListOpts{})
+			require.NoError(t, err)
+			require.Len(t, jobs, 1)
+			require.True(t, jobs[0].Running)
+
+			job, err := r.GetJob(t.Context(), jobs[0].ID)
+			require.NoError(t, err)
+			require.True(t, job.Running)
+
+			close(release)
+			cancel()
+			<-done
+
+			_, err = r.GetJob(t.Context(), jobs[0].ID)
+			require.ErrorIs(t, err, queue.ErrJobNotFound)
+		})
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	internaltesting.RunForAllBackends(t, func(t *testing.T, backend internaltesting.Backend) {
+		t.Run("limits the jobs started per minute", func(t *testing.T) {
+			q := internaltesting.NewQForBackend(t, queue.NewOpts{Timeout: 100 * time.Millisecond}, backend)
+			r, err := worker.New[[]byte](
+				q,
+				&PassThroughSerializer[[]byte]{},
+				worker.WithLimit(10),
+				worker.WithExtend(100*time.Millisecond),
+				worker.WithRateLimit(1),
+			)
+			require.NoError(t, err)
+			require.Equal(t, uint(1), r.Snapshot().RateLimit)
+
+			var ran atomic.Int32
+			require.NoError(t, r.Register("test", func(ctx context.Context, m []byte) error {
+				ran.Add(1)
+				return nil
+			}))
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+			for range 3 {
+				require.NoError(t, r.Enqueue(ctx, "test", []byte("yo")))
+			}
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				r.Start(ctx)
+			}()
+
+			// one job is started, the next one a minute later
+			require.Eventually(t, func() bool { return ran.Load() == 1 }, time.Second, 10*time.Millisecond)
+			require.Never(t, func() bool { return ran.Load() > 1 }, 300*time.Millisecond, 10*time.Millisecond)
+
+			r.SetRateLimit(0)
+			require.Zero(t, r.Snapshot().RateLimit)
+			require.Eventually(t, func() bool { return ran.Load() == 3 }, time.Second, 10*time.Millisecond)
+
+			cancel()
+			<-done
+		})
+	})
+}
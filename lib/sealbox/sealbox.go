@@ -0,0 +1,70 @@
+// Package sealbox encrypts messages to an ed25519 did:key, such that only the
+// holder of the corresponding private key can decrypt them. The ed25519 keys
+// are converted to X25519 and messages are sealed with NaCl anonymous boxes.
+package sealbox
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+
+	"filippo.io/edwards25519"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	edverifier "github.com/storacha/go-ucanto/principal/ed25519/verifier"
+	"golang.org/x/crypto/nacl/box"
+)
+
+var ErrOpen = errors.New("sealed message cannot be opened")
+
+// Seal encrypts msg so it can only be opened by the private key of recipient,
+// which must be an ed25519 did:key.
+func Seal(msg []byte, recipient did.DID) ([]byte, error) {
+	v, err := edverifier.Parse(recipient.String())
+	if err != nil {
+		return nil, fmt.Errorf("parsing recipient %s: %w", recipient, err)
+	}
+	pub, err := publicKey(v.Raw())
+	if err != nil {
+		return nil, fmt.Errorf("converting recipient %s key: %w", recipient, err)
+	}
+	return box.SealAnonymous(nil, msg, pub, rand.Reader)
+}
+
+// Open decrypts a message sealed to the DID of id.
+func Open(sealed []byte, id principal.Signer) ([]byte, error) {
+	pub, err := publicKey(id.Verifier().Raw())
+	if err != nil {
+		return nil, err
+	}
+	priv := privateKey(id.Raw())
+	msg, ok := box.OpenAnonymous(nil, sealed, pub, priv)
+	if !ok {
+		return nil, ErrOpen
+	}
+	return msg, nil
+}
+
+// publicKey converts an ed25519 public key to X25519.
+func publicKey(edPub []byte) (*[32]byte, error) {
+	p, err := new(edwards25519.Point).SetBytes(edPub)
+	if err != nil {
+		return nil, err
+	}
+	var out [32]byte
+	copy(out[:], p.BytesMontgomery())
+	return &out, nil
+}
+
+// privateKey converts an ed25519 private key, seed followed by public key, to
+// X25519 as described in RFC 8032 section 5.1.5.
+func privateKey(edPriv []byte) *[32]byte {
+	h := sha512.Sum512(edPriv[:32])
+	var out [32]byte
+	copy(out[:], h[:32])
+	out[0] &= 248
+	out[31] &= 127
+	out[31] |= 64
+	return &out
+}
@@ -0,0 +1,27 @@
+package sealbox
+
+import (
+	"testing"
+
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	recipient, err := ed25519.Generate()
+	require.NoError(t, err)
+	other, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	msg := []byte("a delegation")
+	sealed, err := Seal(msg, recipient.DID())
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), string(msg))
+
+	opened, err := Open(sealed, recipient)
+	require.NoError(t, err)
+	require.Equal(t, msg, opened)
+
+	_, err = Open(sealed, other)
+	require.ErrorIs(t, err, ErrOpen)
+}
@@ -106,6 +106,39 @@ func (g *Int64Gauge) Record(ctx context.Context, value int64, attrs ...attribute
 	g.gauge.Record(ctx, value, metric.WithAttributes(attrs...))
 }
 
+type Int64Histogram struct {
+	histogram metric.Int64Histogram
+}
+
+func NewInt64Histogram(meter metric.Meter, name, description, unit string, boundaries []float64) (*Int64Histogram, error) {
+	if name == "" {
+		return nil, fmt.Errorf("histogram name required")
+	}
+	if description == "" {
+		return nil, fmt.Errorf("histogram description required")
+	}
+	if len(boundaries) == 0 {
+		return nil, fmt.Errorf("histogram boundaries required")
+	}
+	histogram, err := meter.Int64Histogram(
+		name,
+		metric.WithDescription(description),
+		metric.WithUnit(unit),
+		metric.WithExplicitBucketBoundaries(boundaries...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create histogram %s: %w", name, err)
+	}
+
+	return &Int64Histogram{
+		histogram: histogram,
+	}, nil
+}
+
+func (h *Int64Histogram) Record(ctx context.Context, value int64, attrs ...attribute.KeyValue) {
+	h.histogram.Record(ctx, value, metric.WithAttributes(attrs...))
+}
+
 type Timer struct {
 	histogram metric.Float64Histogram
 }
@@ -21,7 +21,10 @@ type CollectorConfig struct {
 
 type Config struct {
 	Collectors []CollectorConfig
-	Options    []sdkmetric.Option
+	// Readers are additional readers of the provider, e.g. a Prometheus
+	// exporter serving metrics for scraping.
+	Readers []sdkmetric.Reader
+	Options []sdkmetric.Option
 }
 
 func NewProvider(
@@ -29,13 +32,13 @@ func NewProvider(
 	res *resource.Resource,
 	cfg Config,
 ) (metric.MeterProvider, func(ctx2 context.Context) error, error) {
-	if len(cfg.Collectors) == 0 {
+	if len(cfg.Collectors) == 0 && len(cfg.Readers) == 0 {
 		return noop.NewMeterProvider(),
 			func(ctx context.Context) error { return nil },
 			nil
 	}
 
-	var readers []sdkmetric.Reader
+	readers := append([]sdkmetric.Reader{}, cfg.Readers...)
 	for _, collector := range cfg.Collectors {
 		if collector.Endpoint == "" {
 			return nil, nil, fmt.Errorf("telemetry provider endpoint is required")
@@ -0,0 +1,37 @@
+package traces
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Keys of the trace and span IDs in structured logs.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// LogFields returns the trace and span IDs of the span on the context as
+// key-value pairs for structured logs, or nil if the context has no span.
+func LogFields(ctx context.Context) []any {
+	return SpanLogFields(trace.SpanContextFromContext(ctx))
+}
+
+// SpanLogFields returns the trace and span IDs of sc as key-value pairs for
+// structured logs, or nil if sc is not valid.
+func SpanLogFields(sc trace.SpanContext) []any {
+	if !sc.IsValid() {
+		return nil
+	}
+	return []any{TraceIDKey, sc.TraceID().String(), SpanIDKey, sc.SpanID().String()}
+}
+
+// Logger returns a logger adding the trace and span IDs of the span on the
+// context to the logs of l, e.g. a go-log logger.
+func Logger(ctx context.Context, l interface {
+	With(args ...any) *zap.SugaredLogger
+}) *zap.SugaredLogger {
+	return l.With(LogFields(ctx)...)
+}
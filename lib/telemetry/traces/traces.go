@@ -11,7 +11,13 @@ import (
 	sdkresource "go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
-	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Propagator propagates trace context in W3C traceparent and tracestate
+// headers, and baggage in W3C baggage headers.
+var Propagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
 )
 
 type Config struct {
@@ -20,6 +26,9 @@ type Config struct {
 }
 
 type CollectorConfig struct {
+	// FromEnv configures the exporter from the standard OTEL_EXPORTER_OTLP_*
+	// environment variables instead of the fields below.
+	FromEnv         bool
 	Endpoint        string
 	Insecure        bool
 	Headers         map[string]string
@@ -31,27 +40,24 @@ func NewProvider(
 	res *sdkresource.Resource,
 	cfg Config,
 ) (trace.TracerProvider, func(ctx context.Context) error, error) {
-	defaultPropagator := propagation.NewCompositeTextMapPropagator(
-		propagation.TraceContext{},
-		propagation.Baggage{},
-	)
-	if len(cfg.Collectors) == 0 {
-		return noop.NewTracerProvider(),
-			func(ctx context.Context) error { return nil },
-			nil
-	}
+	// trace context is propagated even if no spans are exported, and spans
+	// are still started so their IDs can correlate logs
+	otel.SetTextMapPropagator(Propagator)
 
 	var processors []sdktrace.SpanProcessor
 	for _, collector := range cfg.Collectors {
-		if collector.Endpoint == "" {
-			return nil, nil, fmt.Errorf("collector endpoint required")
-		}
-		opts := []otlptracehttp.Option{
-			otlptracehttp.WithEndpoint(collector.Endpoint),
-			otlptracehttp.WithHeaders(collector.Headers),
-		}
-		if collector.Insecure {
-			opts = append(opts, otlptracehttp.WithInsecure())
+		var opts []otlptracehttp.Option
+		if !collector.FromEnv {
+			if collector.Endpoint == "" {
+				return nil, nil, fmt.Errorf("collector endpoint required")
+			}
+			opts = append(opts,
+				otlptracehttp.WithEndpoint(collector.Endpoint),
+				otlptracehttp.WithHeaders(collector.Headers),
+			)
+			if collector.Insecure {
+				opts = append(opts, otlptracehttp.WithInsecure())
+			}
 		}
 		exporter, err := otlptracehttp.New(ctx, opts...)
 		if err != nil {
@@ -76,6 +82,5 @@ func NewProvider(
 	providerOptions = append(providerOptions, cfg.Options...)
 
 	provider := sdktrace.NewTracerProvider(providerOptions...)
-	otel.SetTextMapPropagator(defaultPropagator)
 	return provider, provider.Shutdown, nil
 }
@@ -0,0 +1,242 @@
+// Package verify verifies piece CIDs, receipts and claims issued by a storage
+// node without trusting the node. It has no dependencies on the node itself,
+// the filesystem or the network, so it can be compiled to WebAssembly (see
+// cmd/wasm) and used by browser clients.
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	commp "github.com/filecoin-project/go-fil-commp-hashhash"
+	"github.com/ipfs/go-cid"
+	ipldprime "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-ucanto/core/car"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	edverifier "github.com/storacha/go-ucanto/principal/ed25519/verifier"
+	"github.com/storacha/go-ucanto/principal/verifier"
+	"github.com/storacha/go-ucanto/validator"
+)
+
+// ErrInvalidSignature is returned when a receipt or claim is not signed by
+// its issuer.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// PieceCID computes the piece CID (v2) of the data.
+func PieceCID(data io.Reader) (cid.Cid, error) {
+	cp := &commp.Calc{}
+	size, err := io.Copy(cp, data)
+	if err != nil {
+		return cid.Undef, fmt.Errorf("reading data: %w", err)
+	}
+	digest, _, err := cp.Digest()
+	if err != nil {
+		return cid.Undef, fmt.Errorf("computing commp digest: %w", err)
+	}
+	pieceCID, err := commcid.DataCommitmentToPieceCidv2(digest, uint64(size))
+	if err != nil {
+		return cid.Undef, fmt.Errorf("converting commp digest to piece cid v2: %w", err)
+	}
+	return pieceCID, nil
+}
+
+// VerifyPieceCID checks the data has the expected piece CID.
+func VerifyPieceCID(data io.Reader, expected cid.Cid) error {
+	actual, err := PieceCID(data)
+	if err != nil {
+		return err
+	}
+	if !actual.Equals(expected) {
+		return fmt.Errorf("piece cid mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// Receipt is a receipt whose issuer signature has been verified.
+type Receipt struct {
+	CID    string `json:"cid"`
+	Issuer string `json:"issuer"`
+	// Ran is the CID of the invocation the receipt is for.
+	Ran string `json:"ran"`
+	// Ability is the ability invoked, if the invocation is in the archive.
+	Ability string `json:"ability,omitempty"`
+	// Ok or Error holds the DAG-JSON encoded result.
+	Ok    json.RawMessage `json:"ok,omitempty"`
+	Error json.RawMessage `json:"error,omitempty"`
+}
+
+// VerifyReceipt decodes a receipt from a CAR archive and verifies it is
+// signed by its issuer. Receipts issued by a did:web principal can only be
+// verified given the did:key it resolves to, as issuerKey.
+func VerifyReceipt(archive []byte, issuerKey string) (Receipt, error) {
+	roots, blocks, err := car.Decode(bytes.NewReader(archive))
+	if err != nil {
+		return Receipt{}, fmt.Errorf("decoding car: %w", err)
+	}
+	if len(roots) == 0 {
+		return Receipt{}, errors.New("archive has no roots")
+	}
+	br, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(blocks))
+	if err != nil {
+		return Receipt{}, fmt.Errorf("creating block reader: %w", err)
+	}
+	rcpt, err := receipt.NewAnyReceipt(roots[0], br)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("decoding receipt: %w", err)
+	}
+	if rcpt.Issuer() == nil {
+		return Receipt{}, errors.New("receipt has no issuer")
+	}
+
+	v, err := resolveVerifier(rcpt.Issuer().DID(), issuerKey)
+	if err != nil {
+		return Receipt{}, err
+	}
+	ok, err := rcpt.VerifySignature(v)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("verifying signature: %w", err)
+	}
+	if !ok {
+		return Receipt{}, fmt.Errorf("%w: receipt is not signed by %s", ErrInvalidSignature, rcpt.Issuer().DID())
+	}
+
+	res := Receipt{
+		CID:    rcpt.Root().Link().String(),
+		Issuer: rcpt.Issuer().DID().String(),
+		Ran:    rcpt.Ran().Link().String(),
+	}
+	if inv, ok := rcpt.Ran().Invocation(); ok && len(inv.Capabilities()) > 0 {
+		res.Ability = inv.Capabilities()[0].Can()
+	}
+	o, x := result.Unwrap(rcpt.Out())
+	if x != nil {
+		res.Error, err = encodeNode(x)
+	} else {
+		res.Ok, err = encodeNode(o)
+	}
+	if err != nil {
+		return Receipt{}, fmt.Errorf("encoding result: %w", err)
+	}
+	return res, nil
+}
+
+// Claim is a claim whose issuer signature has been verified.
+type Claim struct {
+	CID        string `json:"cid"`
+	Issuer     string `json:"issuer"`
+	Audience   string `json:"audience"`
+	Ability    string `json:"ability"`
+	With       string `json:"with"`
+	Expiration *int   `json:"expiration,omitempty"`
+	// Location is set for location claims (assert/location).
+	Location *LocationClaim `json:"location,omitempty"`
+}
+
+// LocationClaim holds the caveats of a location claim.
+type LocationClaim struct {
+	Space     string   `json:"space"`
+	Content   string   `json:"content"`
+	Locations []string `json:"locations"`
+	Offset    uint64   `json:"offset"`
+	Length    *uint64  `json:"length,omitempty"`
+}
+
+// ParseClaim extracts a claim from a delegation archive, verifies it is
+// signed by its issuer and decodes the caveats of location claims. Claims
+// issued by a did:web principal can only be verified given the did:key it
+// resolves to, as issuerKey.
+func ParseClaim(archive []byte, issuerKey string) (Claim, error) {
+	dlg, err := delegation.Extract(archive)
+	if err != nil {
+		return Claim{}, fmt.Errorf("extracting claim: %w", err)
+	}
+	if len(dlg.Capabilities()) == 0 {
+		return Claim{}, errors.New("claim has no capabilities")
+	}
+
+	v, err := resolveVerifier(dlg.Issuer().DID(), issuerKey)
+	if err != nil {
+		return Claim{}, err
+	}
+	if _, fail := validator.VerifySignature(dlg, v); fail != nil {
+		if _, ok := fail.(validator.InvalidSignature); ok {
+			return Claim{}, fmt.Errorf("%w: claim is not signed by %s", ErrInvalidSignature, dlg.Issuer().DID())
+		}
+		return Claim{}, fmt.Errorf("verifying signature: %w", fail)
+	}
+
+	capability := dlg.Capabilities()[0]
+	claim := Claim{
+		CID:        dlg.Link().String(),
+		Issuer:     dlg.Issuer().DID().String(),
+		Audience:   dlg.Audience().DID().String(),
+		Ability:    capability.Can(),
+		With:       capability.With(),
+		Expiration: dlg.Expiration(),
+	}
+	if capability.Can() == assert.LocationAbility {
+		nb, err := assert.LocationCaveatsReader.Read(capability.Nb())
+		if err != nil {
+			return Claim{}, fmt.Errorf("reading location claim caveats: %w", err)
+		}
+		loc := &LocationClaim{
+			Space:     nb.Space.String(),
+			Content:   digestutil.Format(nb.Content.Hash()),
+			Locations: make([]string, 0, len(nb.Location)),
+		}
+		for _, u := range nb.Location {
+			loc.Locations = append(loc.Locations, u.String())
+		}
+		if nb.Range != nil {
+			loc.Offset = nb.Range.Offset
+			loc.Length = nb.Range.Length
+		}
+		claim.Location = loc
+	}
+	return claim, nil
+}
+
+// resolveVerifier returns a verifier for the issuer. did:key issuers are
+// verified directly, other issuers need the did:key they resolve to.
+func resolveVerifier(issuer did.DID, issuerKey string) (principal.Verifier, error) {
+	if issuerKey == "" {
+		v, err := edverifier.Parse(issuer.String())
+		if err != nil {
+			return nil, fmt.Errorf("issuer %s is not an ed25519 did:key, its did:key must be provided to verify the signature: %w", issuer, err)
+		}
+		return v, nil
+	}
+	v, err := edverifier.Parse(issuerKey)
+	if err != nil {
+		return nil, fmt.Errorf("parsing issuer key: %w", err)
+	}
+	if v.DID() == issuer {
+		return v, nil
+	}
+	wrapped, err := verifier.Wrap(v, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("wrapping issuer key: %w", err)
+	}
+	return wrapped, nil
+}
+
+func encodeNode(n ipld.Node) (json.RawMessage, error) {
+	data, err := ipldprime.Encode(n, dagjson.Encode)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(data), nil
+}
@@ -0,0 +1,167 @@
+package verify
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"testing"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	commp "github.com/filecoin-project/go-fil-commp-hashhash"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	blobcaps "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/core/car"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/receipt/ran"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPieceCID(t *testing.T) {
+	data := testutil.RandomBytes(t, 10*1024)
+
+	c := &commp.Calc{}
+	_, err := c.Write(data)
+	require.NoError(t, err)
+	digest, _, err := c.Digest()
+	require.NoError(t, err)
+	expected, err := commcid.DataCommitmentToPieceCidv2(digest, uint64(len(data)))
+	require.NoError(t, err)
+
+	actual, err := PieceCID(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, expected.String(), actual.String())
+
+	require.NoError(t, VerifyPieceCID(bytes.NewReader(data), expected))
+	require.Error(t, VerifyPieceCID(bytes.NewReader(data[1:]), expected))
+}
+
+func TestParseClaim(t *testing.T) {
+	space := testutil.RandomSigner(t)
+	digest := testutil.RandomMultihash(t)
+	loc, err := url.Parse("https://piri.example.com/blob/" + digestutil.Format(digest))
+	require.NoError(t, err)
+	length := uint64(256)
+
+	issue := func(t *testing.T) []byte {
+		t.Helper()
+		claim, err := assert.Location.Delegate(
+			testutil.Alice,
+			space,
+			testutil.Alice.DID().String(),
+			assert.LocationCaveats{
+				Space:    space.DID(),
+				Content:  types.FromHash(digest),
+				Location: []url.URL{*loc},
+				Range:    &assert.Range{Offset: 0, Length: &length},
+			},
+			delegation.WithNoExpiration(),
+		)
+		require.NoError(t, err)
+		archive, err := io.ReadAll(claim.Archive())
+		require.NoError(t, err)
+		return archive
+	}
+
+	t.Run("parses location claim", func(t *testing.T) {
+		claim, err := ParseClaim(issue(t), "")
+		require.NoError(t, err)
+		require.Equal(t, testutil.Alice.DID().String(), claim.Issuer)
+		require.Equal(t, space.DID().String(), claim.Audience)
+		require.Equal(t, assert.LocationAbility, claim.Ability)
+		require.Nil(t, claim.Expiration)
+		require.Equal(t, &LocationClaim{
+			Space:     space.DID().String(),
+			Content:   digestutil.Format(digest),
+			Locations: []string{loc.String()},
+			Length:    &length,
+		}, claim.Location)
+	})
+
+	t.Run("rejects signature from another key", func(t *testing.T) {
+		_, err := ParseClaim(issue(t), testutil.Bob.DID().String())
+		require.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("rejects invalid archive", func(t *testing.T) {
+		_, err := ParseClaim([]byte("not a claim"), "")
+		require.Error(t, err)
+	})
+}
+
+func TestVerifyReceipt(t *testing.T) {
+	inv, err := blobcaps.Accept.Invoke(
+		testutil.Alice,
+		testutil.Alice,
+		testutil.Alice.DID().String(),
+		blobcaps.AcceptCaveats{
+			Space: testutil.RandomSigner(t).DID(),
+			Blob: types.Blob{
+				Digest: testutil.RandomMultihash(t),
+				Size:   256,
+			},
+			Put: blobcaps.Promise{
+				UcanAwait: blobcaps.Await{
+					Selector: ".out.ok",
+					Link:     testutil.RandomCID(t),
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	archive := func(t *testing.T, rcpt receipt.AnyReceipt) []byte {
+		t.Helper()
+		data, err := io.ReadAll(car.Encode([]datamodel.Link{rcpt.Root().Link()}, rcpt.Blocks()))
+		require.NoError(t, err)
+		return data
+	}
+
+	t.Run("verifies receipt", func(t *testing.T) {
+		rcpt, err := receipt.Issue(
+			testutil.Alice,
+			result.Ok[blobcaps.AcceptOk, ipld.Builder](blobcaps.AcceptOk{Site: testutil.RandomCID(t)}),
+			ran.FromInvocation(inv),
+		)
+		require.NoError(t, err)
+
+		res, err := VerifyReceipt(archive(t, rcpt), "")
+		require.NoError(t, err)
+		require.Equal(t, rcpt.Root().Link().String(), res.CID)
+		require.Equal(t, testutil.Alice.DID().String(), res.Issuer)
+		require.Equal(t, inv.Link().String(), res.Ran)
+		require.Equal(t, blobcaps.AcceptAbility, res.Ability)
+		require.NotEmpty(t, res.Ok)
+		require.Empty(t, res.Error)
+	})
+
+	t.Run("rejects signature from another key", func(t *testing.T) {
+		rcpt, err := receipt.Issue(
+			testutil.Alice,
+			result.Ok[blobcaps.AcceptOk, ipld.Builder](blobcaps.AcceptOk{Site: testutil.RandomCID(t)}),
+			ran.FromInvocation(inv),
+		)
+		require.NoError(t, err)
+
+		_, err = VerifyReceipt(archive(t, rcpt), testutil.Bob.DID().String())
+		require.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("requires key for did:web issuer", func(t *testing.T) {
+		rcpt, err := receipt.Issue(
+			testutil.WebService,
+			result.Ok[blobcaps.AcceptOk, ipld.Builder](blobcaps.AcceptOk{Site: testutil.RandomCID(t)}),
+			ran.FromInvocation(inv),
+		)
+		require.NoError(t, err)
+
+		_, err = VerifyReceipt(archive(t, rcpt), "")
+		require.ErrorContains(t, err, "did:key must be provided")
+	})
+}
@@ -0,0 +1,39 @@
+// Package dashboard serves the operator dashboard, a single page reading the
+// admin API.
+//
+// The page itself holds no node data and is served without authentication.
+// It is opened with an admin token in the URL fragment, which browsers do not
+// send to the server, see `piri client admin dashboard`, and passes the token
+// on its requests to the admin API.
+package dashboard
+
+import (
+	"embed"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	echofx "github.com/storacha/piri/pkg/fx/echo"
+)
+
+// RoutePath is the path the dashboard is served at.
+const RoutePath = httpapi.AdminRoutePath + httpapi.DashboardRoutePath + "/"
+
+//go:embed static
+var static embed.FS
+
+// Routes serves the dashboard assets.
+type Routes struct{}
+
+// NewRoutes creates the routes serving the dashboard.
+func NewRoutes() echofx.RouteRegistrar {
+	return Routes{}
+}
+
+func (Routes) RegisterRoutes(e *echo.Echo) {
+	e.GET(httpapi.AdminRoutePath+httpapi.DashboardRoutePath, func(c echo.Context) error {
+		return c.Redirect(http.StatusMovedPermanently, RoutePath)
+	})
+	e.StaticFS(RoutePath, echo.MustSubFS(static, "static"))
+}
@@ -0,0 +1,36 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutes(t *testing.T) {
+	e := echo.New()
+	NewRoutes().RegisterRoutes(e)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/admin/dashboard")
+	require.Equal(t, http.StatusMovedPermanently, rec.Code)
+	require.Equal(t, RoutePath, rec.Header().Get(echo.HeaderLocation))
+
+	rec = get(RoutePath)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `<script src="app.js">`)
+
+	for _, asset := range []string{"app.js", "style.css"} {
+		rec = get(RoutePath + asset)
+		require.Equal(t, http.StatusOK, rec.Code, asset)
+	}
+
+	require.Equal(t, http.StatusNotFound, get(RoutePath+"missing.js").Code)
+}
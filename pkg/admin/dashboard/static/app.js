@@ -0,0 +1,232 @@
+'use strict'
+
+const API = '/admin'
+const REFRESH_INTERVAL = 30_000
+// Filecoin epochs are 30 seconds apart.
+const EPOCH_SECONDS = 30
+// Deadlines closer than this many epochs are highlighted.
+const DEADLINE_WARN_EPOCHS = 120
+
+// The token is passed in the URL fragment so it is never sent to the server
+// as part of the page URL. It is kept for the session and removed from the
+// address bar.
+function loadToken() {
+  const params = new URLSearchParams(location.hash.slice(1))
+  const token = params.get('token')
+  if (token) {
+    sessionStorage.setItem('piri-admin-token', token)
+    history.replaceState(null, '', location.pathname + location.search)
+  }
+  return sessionStorage.getItem('piri-admin-token')
+}
+
+class NotAvailable extends Error {}
+
+async function get(token, path) {
+  const res = await fetch(API + path, {
+    headers: { Authorization: `Bearer ${token}` },
+    cache: 'no-store',
+  })
+  if (res.status === 404) {
+    throw new NotAvailable(`${path} is not served by this node`)
+  }
+  if (res.status === 401 || res.status === 400) {
+    sessionStorage.removeItem('piri-admin-token')
+    throw new Error('the admin token was rejected, open a new dashboard URL')
+  }
+  if (!res.ok) {
+    throw new Error(`${path}: ${res.status} ${(await res.text()).trim()}`)
+  }
+  return res.json()
+}
+
+function el(tag, attrs = {}, ...children) {
+  const e = document.createElement(tag)
+  for (const [k, v] of Object.entries(attrs)) {
+    if (k === 'class') e.className = v
+    else e.setAttribute(k, v)
+  }
+  for (const c of children) {
+    e.append(c instanceof Node ? c : document.createTextNode(c ?? ''))
+  }
+  return e
+}
+
+// table renders rows under the columns, each a [title, value, class] where
+// value maps a row to its cell.
+function table(columns, rows) {
+  if (rows.length === 0) return el('p', { class: 'muted' }, 'None.')
+  return el('table', {},
+    el('thead', {}, el('tr', {}, ...columns.map(([title, , cls]) => el('th', { class: cls ?? '' }, title)))),
+    el('tbody', {}, ...rows.map((row) => el('tr', {}, ...columns.map(([, value, cls]) => {
+      const v = value(row)
+      return v instanceof Node ? el('td', { class: cls ?? '' }, v) : el('td', { class: cls ?? '' }, String(v))
+    })))),
+  )
+}
+
+function fill(id, ...children) {
+  document.getElementById(id).replaceChildren(...children)
+}
+
+function unavailable(id, err) {
+  fill(id, el('p', { class: err instanceof NotAvailable ? 'muted' : 'error' }, err.message))
+}
+
+function bytes(n) {
+  const units = ['B', 'KiB', 'MiB', 'GiB', 'TiB', 'PiB']
+  let i = 0
+  while (n >= 1024 && i < units.length - 1) {
+    n /= 1024
+    i++
+  }
+  return `${i === 0 ? n : n.toFixed(1)} ${units[i]}`
+}
+
+function duration(epochs) {
+  const s = Math.abs(epochs) * EPOCH_SECONDS
+  const text = s >= 3600 ? `${Math.floor(s / 3600)}h${Math.floor((s % 3600) / 60)}m` : `${Math.floor(s / 60)}m`
+  return epochs < 0 ? `${text} ago` : `in ${text}`
+}
+
+// token formats an amount in base units of a token with 18 decimals.
+function token(amount) {
+  if (!amount) return '0'
+  const v = BigInt(amount)
+  const whole = v / 10n ** 18n
+  const frac = (v % 10n ** 18n).toString().padStart(18, '0').slice(0, 6).replace(/0+$/, '')
+  return frac ? `${whole}.${frac}` : `${whole}`
+}
+
+function renderOverview(o) {
+  const notes = o.notes ?? []
+  const list = document.getElementById('notes')
+  list.replaceChildren(...notes.map((n) => el('li', {}, n)))
+  list.hidden = notes.length === 0
+
+  const v = o.version ?? {}
+  const identity = [
+    ['DID', o.did],
+    ['Public URL', o.public_url || '-'],
+    ['Version', `${v.version ?? ''} (${(v.commit ?? '').slice(0, 8)})`],
+    ['Network', v.network || '-'],
+  ]
+  fill('identity', ...identity.flatMap(([k, val]) => [el('dt', {}, k), el('dd', {}, val)]))
+
+  const classes = o.storage_classes ?? []
+  fill('storage', table([
+    ['Class', (c) => (c.configured ? c.name : `${c.name} (unconfigured)`)],
+    ['Blobs', (c) => c.blobs, 'num'],
+    ['Size', (c) => bytes(c.bytes), 'num'],
+  ], classes))
+
+  const ds = o.data_sets
+  if (!ds) {
+    fill('deadlines', el('p', { class: 'muted' }, 'Data sets are not available on this node.'))
+  } else {
+    fill('deadlines',
+      el('p', { class: 'muted' },
+        `${ds.initialized} of ${ds.total} data sets initialized, ${ds.proving} proving` +
+        (ds.current_epoch ? `, epoch ${ds.current_epoch}` : '')),
+      table([
+        ['Data set', (d) => d.data_set_id],
+        ['Deadline', (d) => d.deadline, 'num'],
+        ['Epochs left', (d) => {
+          const cls = d.epochs_left < 0 ? 'bad' : d.epochs_left < DEADLINE_WARN_EPOCHS ? 'warn' : ''
+          return el('span', { class: cls }, ds.current_epoch ? `${d.epochs_left} (${duration(d.epochs_left)})` : '-')
+        }, 'num'],
+      ], ds.deadlines ?? []))
+  }
+
+  if (!o.pieces) {
+    fill('pieces', el('p', { class: 'muted' }, 'Piece states are not available on this node.'))
+  } else {
+    fill('pieces', table([
+      ['State', ([state]) => state],
+      ['Pieces', ([, n]) => n, 'num'],
+    ], [...Object.entries(o.pieces.counts), ['total', o.pieces.total]]))
+  }
+
+  fill('errors', table([
+    ['Time', (e) => new Date(e.at).toLocaleString()],
+    ['Logger', (e) => e.logger],
+    ['Message', (e) => el('span', { class: 'error', title: JSON.stringify(e.fields ?? {}) }, e.error ? `${e.message}: ${e.error}` : e.message)],
+  ], o.recent_errors ?? []))
+}
+
+function renderProofSets(res) {
+  fill('proofsets', table([
+    ['ID', (p) => p.id],
+    ['Class', (p) => p.class || '-'],
+    ['Status', (p) => el('span', { class: p.retired ? 'muted' : 'good' }, p.retired ? 'retired' : 'active')],
+    ['Created', (p) => new Date(p.created_at).toLocaleDateString()],
+  ], res.proof_sets ?? []))
+}
+
+function renderPayment(acct) {
+  fill('payment',
+    el('dl', {},
+      el('dt', {}, 'Owner'), el('dd', {}, acct.owner_address),
+      el('dt', {}, 'Funds'), el('dd', {}, token(acct.funds)),
+      el('dt', {}, 'Withdrawable'), el('dd', {}, token(acct.available_to_withdraw)),
+    ),
+    table([
+      ['Rail', (r) => r.rail_id],
+      ['Data set', (r) => r.data_set_id || '-'],
+      ['Rate / epoch', (r) => token(r.payment_rate), 'num'],
+      ['Settled up to', (r) => r.settled_up_to, 'num'],
+      ['Settleable', (r) => token(r.net_settleable_amount), 'num'],
+      ['Status', (r) => el('span', { class: r.is_terminated ? 'bad' : 'good' }, r.is_terminated ? 'terminated' : 'active')],
+    ], acct.rails ?? []),
+  )
+}
+
+function renderDelegations(res) {
+  fill('delegations', table([
+    ['CID', (d) => el('span', { title: d.cid }, `${d.cid.slice(0, 12)}…`)],
+    ['Source', (d) => d.source],
+    ['Abilities', (d) => d.capabilities.map((c) => c.can).join(', ')],
+    ['Expires', (d) => {
+      if (!d.expiration) return 'never'
+      const cls = d.expired ? 'bad' : d.expiring ? 'warn' : ''
+      const text = d.expired ? 'expired' : new Date(d.expiration).toLocaleString()
+      return el('span', { class: cls }, text)
+    }],
+  ], res.delegations ?? []))
+}
+
+async function refresh(token) {
+  const sections = [
+    ['/overview', renderOverview, null],
+    ['/proofsets', renderProofSets, 'proofsets'],
+    ['/payment/account', renderPayment, 'payment'],
+    ['/delegations', renderDelegations, 'delegations'],
+  ]
+  const results = await Promise.allSettled(sections.map(([path]) => get(token, path)))
+  const errors = document.getElementById('error')
+  errors.hidden = true
+  results.forEach((r, i) => {
+    const [, render, id] = sections[i]
+    if (r.status === 'fulfilled') {
+      render(r.value)
+    } else if (id) {
+      unavailable(id, r.reason)
+    } else {
+      errors.textContent = r.reason.message
+      errors.hidden = false
+    }
+  })
+  document.getElementById('updated').textContent = `updated ${new Date().toLocaleTimeString()}`
+}
+
+function main() {
+  const token = loadToken()
+  if (!token) {
+    document.getElementById('auth').hidden = false
+    return
+  }
+  refresh(token)
+  setInterval(() => refresh(token), REFRESH_INTERVAL)
+}
+
+main()
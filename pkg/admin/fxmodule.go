@@ -3,16 +3,33 @@ package admin
 import (
 	"go.uber.org/fx"
 
+	"github.com/storacha/piri/pkg/admin/dashboard"
 	"github.com/storacha/piri/pkg/admin/httpapi/handlers"
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/delegations"
 	echofx "github.com/storacha/piri/pkg/fx/echo"
 )
 
 var Module = fx.Module("admin",
 	fx.Provide(
+		NewDelegationHandler,
 		fx.Annotate(
 			handlers.NewRoutes,
 			fx.As(new(echofx.RouteRegistrar)),
 			fx.ResultTags(`group:"route_registrar"`),
 		),
+		fx.Annotate(
+			dashboard.NewRoutes,
+			fx.ResultTags(`group:"route_registrar"`),
+		),
 	),
 )
+
+// NewDelegationHandler creates the handler managing delegations granted to
+// the node. It returns nil if the node keeps no delegations.
+func NewDelegationHandler(id app.IdentityConfig, dlgs *delegations.Manager) *handlers.DelegationHandler {
+	if dlgs == nil {
+		return nil
+	}
+	return handlers.NewDelegationHandler(id.Signer, dlgs)
+}
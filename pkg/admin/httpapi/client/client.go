@@ -3,20 +3,24 @@ package client
 import (
 	"bytes"
 	"context"
-	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
 	"github.com/storacha/go-ucanto/principal"
 
-	"github.com/storacha/piri/lib"
 	"github.com/storacha/piri/pkg/admin/httpapi"
 	"github.com/storacha/piri/pkg/config"
+	"github.com/storacha/piri/pkg/hwsigner"
 )
 
 type Client struct {
@@ -73,7 +77,7 @@ func New(endpoint *url.URL, opts ...Option) (*Client, error) {
 }
 
 // NewFromConfig builds a client using repository config defaults.
-func NewFromConfig(cfg config.Client) (*Client, error) {
+func NewFromConfig(cfg config.Client, opts ...Option) (*Client, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("validating client config: %w", err)
 	}
@@ -83,12 +87,24 @@ func NewFromConfig(cfg config.Client) (*Client, error) {
 		return nil, fmt.Errorf("parsing admin api endpoint: %w", err)
 	}
 
-	id, err := lib.SignerFromEd25519PEMFile(cfg.Identity.KeyFile)
+	id, err := cfg.Identity.LoadSigner()
 	if err != nil {
-		return nil, fmt.Errorf("loading identity key file: %w", err)
+		return nil, fmt.Errorf("loading identity: %w", err)
 	}
 
-	return New(endpoint, WithBearerFromSigner(id))
+	return New(endpoint, append([]Option{WithBearerFromSigner(id)}, opts...)...)
+}
+
+// GetVersion fetches the build metadata of the node.
+func (c *Client) GetVersion(ctx context.Context) (*httpapi.VersionResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.VersionRoutePath).String()
+
+	var resp httpapi.VersionResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
 }
 
 // ListLogLevels fetches the list of configured loggers and their levels.
@@ -321,14 +337,1183 @@ func (c *Client) ReloadConfig(ctx context.Context) (*httpapi.ConfigResponse, err
 	return &resp, nil
 }
 
-func createAuthBearerTokenFromID(id principal.Signer) (string, error) {
-	claims := jwt.MapClaims{
-		"service_name": "storacha",
+// ListSubsystems returns the pause state of all subsystems.
+func (c *Client) ListSubsystems(ctx context.Context) ([]httpapi.SubsystemStatus, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.SubsystemsRoutePath).String()
+
+	var resp httpapi.ListSubsystemsResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	return resp.Subsystems, nil
+}
+
+// PauseSubsystem pauses the named subsystem until it is resumed.
+func (c *Client) PauseSubsystem(ctx context.Context, name string) (*httpapi.SubsystemStatus, error) {
+	return c.setSubsystemState(ctx, name, httpapi.PauseRoutePath)
+}
+
+// ResumeSubsystem resumes the named subsystem.
+func (c *Client) ResumeSubsystem(ctx context.Context, name string) (*httpapi.SubsystemStatus, error) {
+	return c.setSubsystemState(ctx, name, httpapi.ResumeRoutePath)
+}
+
+func (c *Client) setSubsystemState(ctx context.Context, name, action string) (*httpapi.SubsystemStatus, error) {
+	if name == "" {
+		return nil, fmt.Errorf("subsystem name is required")
+	}
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.SubsystemsRoutePath, name, action).String()
+
+	res, err := c.postJSON(ctx, route, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.SubsystemStatus
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// GetReadOnly returns whether the node is read-only.
+func (c *Client) GetReadOnly(ctx context.Context) (*httpapi.ReadOnlyStatus, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.ReadOnlyRoutePath).String()
+
+	var resp httpapi.ReadOnlyStatus
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// EnableReadOnly puts the node in read-only mode until it is disabled.
+func (c *Client) EnableReadOnly(ctx context.Context, reason string) (*httpapi.ReadOnlyStatus, error) {
+	return c.setReadOnly(ctx, httpapi.EnableRoutePath, httpapi.EnableReadOnlyRequest{Reason: reason})
+}
+
+// DisableReadOnly takes the node out of read-only mode.
+func (c *Client) DisableReadOnly(ctx context.Context) (*httpapi.ReadOnlyStatus, error) {
+	return c.setReadOnly(ctx, httpapi.DisableRoutePath, nil)
+}
+
+func (c *Client) setReadOnly(ctx context.Context, action string, body any) (*httpapi.ReadOnlyStatus, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.ReadOnlyRoutePath, action).String()
+
+	res, err := c.postJSON(ctx, route, body)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.ReadOnlyStatus
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// GetDiskSpace returns the disk usage of the node's data and temp directories
+// and whether allocations are rejected.
+func (c *Client) GetDiskSpace(ctx context.Context) (*httpapi.DiskSpaceStatus, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.DiskSpaceRoutePath).String()
+
+	var resp httpapi.DiskSpaceStatus
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListBlocklist returns up to limit entries of the blocklist from the source,
+// or from every source if source is empty. A limit of zero returns every
+// entry.
+func (c *Client) ListBlocklist(ctx context.Context, source string, limit int) (*httpapi.ListBlocklistResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.BlocklistRoutePath)
+	query := url.Values{}
+	if source != "" {
+		query.Set("source", source)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	route.RawQuery = query.Encode()
+
+	var resp httpapi.ListBlocklistResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// AddBlocklist blocks content by CID, digest or badbits double hash, purging
+// the blobs held by the node if purge is set.
+func (c *Client) AddBlocklist(ctx context.Context, req httpapi.AddBlocklistRequest) (*httpapi.AddBlocklistResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.BlocklistRoutePath).String()
+
+	var resp httpapi.AddBlocklistResponse
+	if err := c.postBlocklist(ctx, route, req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// RemoveBlocklist unblocks content by CID, digest or badbits double hash.
+func (c *Client) RemoveBlocklist(ctx context.Context, entries []string) ([]httpapi.BlocklistEntry, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.BlocklistRoutePath, httpapi.RemoveRoutePath).String()
+
+	var resp httpapi.RemoveBlocklistResponse
+	if err := c.postBlocklist(ctx, route, httpapi.RemoveBlocklistRequest{Entries: entries}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Removed, nil
+}
+
+// CheckBlocklist returns whether the blob with the multibase encoded digest
+// is blocked.
+func (c *Client) CheckBlocklist(ctx context.Context, digest string) (*httpapi.CheckBlocklistResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.BlocklistRoutePath, httpapi.CheckRoutePath, digest).String()
+
+	var resp httpapi.CheckBlocklistResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// SyncBlocklist syncs the remote denylist now.
+func (c *Client) SyncBlocklist(ctx context.Context) (*httpapi.BlocklistSyncStatus, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.BlocklistRoutePath, httpapi.SyncRoutePath).String()
+
+	var resp httpapi.BlocklistSyncStatus
+	if err := c.postBlocklist(ctx, route, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) postBlocklist(ctx context.Context, route string, body any, target any) error {
+	res, err := c.postJSON(ctx, route, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return errFromResponse(res)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(target); err != nil {
+		return fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return nil
+}
+
+// ListFeatureFlags returns the state of the node's feature flags.
+func (c *Client) ListFeatureFlags(ctx context.Context) ([]httpapi.FeatureFlag, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.FeaturesRoutePath).String()
+
+	var resp httpapi.ListFeatureFlagsResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Flags, nil
+}
+
+// SetFeatureFlag enables or disables the named feature flag, or changes its
+// rollout.
+func (c *Client) SetFeatureFlag(ctx context.Context, name string, req httpapi.SetFeatureFlagRequest) (*httpapi.FeatureFlag, error) {
+	if name == "" {
+		return nil, fmt.Errorf("feature flag name is required")
+	}
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.FeaturesRoutePath, name).String()
+
+	res, err := c.postJSON(ctx, route, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.FeatureFlag
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+	return &resp, nil
+}
+
+// DrillMissedProof simulates missing the next proving deadline of a data set.
+// No transactions are sent.
+func (c *Client) DrillMissedProof(ctx context.Context, dataSetID uint64) (*httpapi.DrillMissedProofResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.DrillRoutePath + httpapi.MissedProofRoutePath)
+	route.RawQuery = url.Values{"dataset": {strconv.FormatUint(dataSetID, 10)}}.Encode()
+
+	var resp httpapi.DrillMissedProofResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListDataSets returns a summary of the data sets stored by the node.
+func (c *Client) ListDataSets(ctx context.Context) (*httpapi.ListDataSetsResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.DataSetsRoutePath).String()
+
+	var resp httpapi.ListDataSetsResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetDataSet returns the local and on-chain state of a data set. Faults
+// recorded within the last faultLookback epochs are included, or within the
+// node's default lookback if faultLookback is zero.
+func (c *Client) GetDataSet(ctx context.Context, dataSetID, faultLookback uint64) (*httpapi.GetDataSetResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.DataSetsRoutePath, strconv.FormatUint(dataSetID, 10))
+	if faultLookback > 0 {
+		route.RawQuery = url.Values{"fault_lookback": {strconv.FormatUint(faultLookback, 10)}}.Encode()
+	}
+
+	var resp httpapi.GetDataSetResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// VerifyDataSet checks the node holds every blob of the pieces in a data set
+// on chain. deep hashes the content of each blob, repairPlan requests a plan
+// to repair the pieces failing verification.
+func (c *Client) VerifyDataSet(ctx context.Context, dataSetID uint64, deep, repairPlan bool) (*httpapi.VerifyDataSetResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.DataSetsRoutePath, strconv.FormatUint(dataSetID, 10), httpapi.VerifyRoutePath)
+	route.RawQuery = url.Values{
+		"deep":        {strconv.FormatBool(deep)},
+		"repair_plan": {strconv.FormatBool(repairPlan)},
+	}.Encode()
+
+	var resp httpapi.VerifyDataSetResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ProveDryRun proves the next challenge of a data set against the pieces
+// stored by the node, without submitting the proof.
+func (c *Client) ProveDryRun(ctx context.Context, dataSetID uint64) (*httpapi.ProveDryRunResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.DataSetsRoutePath, strconv.FormatUint(dataSetID, 10), httpapi.ProveRoutePath, httpapi.DryRunRoutePath)
+
+	var resp httpapi.ProveDryRunResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// LocateBlob resolves a blob to its piece, the aggregates containing the
+// piece and the data sets the aggregates were added to.
+func (c *Client) LocateBlob(ctx context.Context, digest multihash.Multihash) (*httpapi.LocateBlobResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.LocateRoutePath, digestutil.Format(digest)).String()
+
+	var resp httpapi.LocateBlobResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetAggregate returns the pieces of an aggregate built by the node and the
+// data sets it was added to.
+func (c *Client) GetAggregate(ctx context.Context, root cid.Cid) (*httpapi.AggregateResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.AggregatesRoutePath, root.String()).String()
+
+	var resp httpapi.AggregateResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetBackfill returns the progress of the last backfill of blobs stored before
+// the node proved its content with PDP.
+func (c *Client) GetBackfill(ctx context.Context) (*httpapi.BackfillStatus, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.BackfillRoutePath).String()
+
+	var resp httpapi.BackfillStatus
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// StartBackfill starts a backfill in the background. With resume, the last
+// backfill continues if it was cancelled or failed.
+func (c *Client) StartBackfill(ctx context.Context, resume bool) (*httpapi.BackfillStatus, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.BackfillRoutePath).String()
+	return c.postBackfill(ctx, route, httpapi.StartBackfillRequest{Resume: resume})
+}
+
+// CancelBackfill stops the running backfill.
+func (c *Client) CancelBackfill(ctx context.Context) (*httpapi.BackfillStatus, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.BackfillRoutePath, httpapi.CancelRoutePath).String()
+	return c.postBackfill(ctx, route, nil)
+}
+
+func (c *Client) postBackfill(ctx context.Context, route string, body any) (*httpapi.BackfillStatus, error) {
+	res, err := c.postJSON(ctx, route, body)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.BackfillStatus
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// ImportDelegations registers delegations granted to the node.
+func (c *Client) ImportDelegations(ctx context.Context, req httpapi.ImportDelegationsRequest) (*httpapi.ImportDelegationsResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.DelegationsRoutePath).String()
+
+	res, err := c.postJSON(ctx, route, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.ImportDelegationsResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
 
-	tokenString, err := token.SignedString(ed25519.PrivateKey(id.Raw()))
+// ListDelegations returns the delegations held by the node.
+func (c *Client) ListDelegations(ctx context.Context) ([]httpapi.Delegation, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.DelegationsRoutePath).String()
+
+	var resp httpapi.ListDelegationsResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Delegations, nil
+}
+
+// GetDelegation returns a delegation held by the node by its CID.
+func (c *Client) GetDelegation(ctx context.Context, cid string) (*httpapi.Delegation, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.DelegationsRoutePath, cid).String()
+
+	var resp httpapi.Delegation
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// RemoveDelegation deletes a delegation imported into the node.
+func (c *Client) RemoveDelegation(ctx context.Context, cid string) error {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.DelegationsRoutePath, cid).String()
+	return c.verifySuccess(c.sendRequest(ctx, http.MethodDelete, route, nil, nil))
+}
+
+// ListProofSets returns the proof sets the node manages, including retired
+// ones.
+func (c *Client) ListProofSets(ctx context.Context) ([]httpapi.ManagedProofSet, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.ProofSetsRoutePath).String()
+
+	var resp httpapi.ListProofSetsResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.ProofSets, nil
+}
+
+// AddProofSet starts adding aggregates to a proof set created by the node.
+func (c *Client) AddProofSet(ctx context.Context, req httpapi.AddProofSetRequest) (*httpapi.ManagedProofSet, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.ProofSetsRoutePath).String()
+	return c.postProofSet(ctx, route, req)
+}
+
+// RetireProofSet stops adding aggregates to a proof set.
+func (c *Client) RetireProofSet(ctx context.Context, proofSetID uint64) (*httpapi.ManagedProofSet, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.ProofSetsRoutePath, strconv.FormatUint(proofSetID, 10), httpapi.RetireRoutePath).String()
+	return c.postProofSet(ctx, route, nil)
+}
+
+func (c *Client) postProofSet(ctx context.Context, route string, req any) (*httpapi.ManagedProofSet, error) {
+	res, err := c.postJSON(ctx, route, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.ManagedProofSet
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// GetRepublishStatus returns the progress of republishing location claims
+// after a change of the node's public URL.
+func (c *Client) GetRepublishStatus(ctx context.Context) (*httpapi.RepublishStatus, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.RepublishRoutePath).String()
+
+	var resp httpapi.RepublishStatus
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ReconcileCurio compares the pieces uploaded to Curio with the pieces it
+// holds, fixing divergences unless dryRun is set.
+func (c *Client) ReconcileCurio(ctx context.Context, dryRun bool) (*httpapi.ReconcileCurioResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.CurioRoutePath + httpapi.ReconcileRoutePath).String()
+	res, err := c.postJSON(ctx, route, httpapi.ReconcileCurioRequest{DryRun: dryRun})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.ReconcileCurioResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// ListCorruptBlobs returns the blobs the integrity scrubber found corrupt that
+// have not verified since.
+func (c *Client) ListCorruptBlobs(ctx context.Context) (*httpapi.CorruptBlobsResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.ScrubRoutePath + httpapi.CorruptRoutePath).String()
+
+	var resp httpapi.CorruptBlobsResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetGasEstimates returns base fee percentiles of the recent chain history.
+func (c *Client) GetGasEstimates(ctx context.Context) (*httpapi.GasEstimatesResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.GasRoutePath + httpapi.EstimatesRoutePath).String()
+
+	var resp httpapi.GasEstimatesResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ChainEventsQuery selects the events returned by ListChainEvents. Zero
+// values select everything.
+type ChainEventsQuery struct {
+	DataSetID *uint64
+	Names     []string
+	// Before restricts events to blocks before it.
+	Before int64
+	Limit  int
+}
+
+// ListChainEvents returns the contract events recorded for the node's data
+// sets, most recent first.
+func (c *Client) ListChainEvents(ctx context.Context, q ChainEventsQuery) (*httpapi.ChainEventsResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.EventsRoutePath)
+	params := url.Values{}
+	if q.DataSetID != nil {
+		params.Set("data_set", strconv.FormatUint(*q.DataSetID, 10))
+	}
+	if len(q.Names) > 0 {
+		params.Set("name", strings.Join(q.Names, ","))
+	}
+	if q.Before > 0 {
+		params.Set("before", strconv.FormatInt(q.Before, 10))
+	}
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+	route.RawQuery = params.Encode()
+
+	var resp httpapi.ChainEventsResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListProofs returns the proving history of the node's data sets and when
+// their next proofs are due. Up to limit recent proofs are listed per data
+// set, or the node's default if limit is zero.
+func (c *Client) ListProofs(ctx context.Context, limit int) (*httpapi.ListProofsResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.ProofsRoutePath)
+	if limit > 0 {
+		route.RawQuery = url.Values{"limit": {strconv.Itoa(limit)}}.Encode()
+	}
+
+	var resp httpapi.ListProofsResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetPieceStatus returns the lifecycle of a blob stored on the node, and the
+// time its upload spent in each stage of the pipeline if it was uploaded
+// recently.
+func (c *Client) GetPieceStatus(ctx context.Context, digest multihash.Multihash) (*httpapi.PieceStatus, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.PiecesRoutePath, digestutil.Format(digest), httpapi.StatusRoutePath).String()
+
+	var resp httpapi.PieceStatus
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetPieceStates returns the number of pieces in each lifecycle state.
+func (c *Client) GetPieceStates(ctx context.Context) (*httpapi.PieceStateCounts, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.PiecesRoutePath + httpapi.StatesRoutePath).String()
+
+	var resp httpapi.PieceStateCounts
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListStuckPieces returns the pieces that have been in a state other than
+// proven or removed for longer than olderThan, oldest first.
+func (c *Client) ListStuckPieces(ctx context.Context, olderThan time.Duration) ([]httpapi.PieceLifecycle, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.PiecesRoutePath + httpapi.StuckRoutePath)
+	route.RawQuery = url.Values{"older_than": {olderThan.String()}}.Encode()
+
+	var resp httpapi.StuckPiecesResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Pieces, nil
+}
+
+// ListQuotas returns the limits and usage of every space with limits.
+func (c *Client) ListQuotas(ctx context.Context) ([]httpapi.SpaceQuota, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.QuotasRoutePath).String()
+
+	var resp httpapi.ListQuotasResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Quotas, nil
+}
+
+// GetQuota returns the limits and usage of a space.
+func (c *Client) GetQuota(ctx context.Context, space string) (*httpapi.SpaceQuota, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.QuotasRoutePath, space).String()
+
+	var resp httpapi.SpaceQuota
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// SetQuota sets the limits of a space.
+func (c *Client) SetQuota(ctx context.Context, space string, req httpapi.SetQuotaRequest) (*httpapi.SpaceQuota, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.QuotasRoutePath, space).String()
+	res, err := c.postJSON(ctx, route, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.SpaceQuota
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// ListSpaceUsage returns the bytes stored by every space that has allocated
+// any.
+func (c *Client) ListSpaceUsage(ctx context.Context) ([]httpapi.SpaceSize, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.UsageRoutePath).String()
+
+	var resp httpapi.ListSpaceUsageResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Spaces, nil
+}
+
+// GetUsageReport returns the usage of a space from from until to. Zero times
+// use the start of the current calendar month and now.
+func (c *Client) GetUsageReport(ctx context.Context, space string, from, to time.Time) (*httpapi.UsageReport, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.UsageRoutePath, space)
+	query := url.Values{}
+	if !from.IsZero() {
+		query.Set("from", from.Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		query.Set("to", to.Format(time.RFC3339))
+	}
+	route.RawQuery = query.Encode()
+
+	var resp httpapi.UsageReport
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetReplicaPolicy returns the policy replica allocations are evaluated
+// against.
+func (c *Client) GetReplicaPolicy(ctx context.Context) (*httpapi.ReplicaPolicy, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.ReplicationRoutePath + httpapi.PolicyRoutePath).String()
+
+	var resp httpapi.ReplicaPolicy
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// SetReplicaPolicy replaces the policy replica allocations are evaluated
+// against.
+func (c *Client) SetReplicaPolicy(ctx context.Context, policy httpapi.ReplicaPolicy) (*httpapi.ReplicaPolicy, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.ReplicationRoutePath + httpapi.PolicyRoutePath).String()
+	res, err := c.postJSON(ctx, route, policy)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.ReplicaPolicy
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// ListWebhooks returns the webhooks notified of issued receipts.
+func (c *Client) ListWebhooks(ctx context.Context) ([]httpapi.Webhook, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.WebhooksRoutePath).String()
+
+	var resp httpapi.ListWebhooksResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Webhooks, nil
+}
+
+// AddWebhook registers a webhook. The returned webhook includes its secret.
+func (c *Client) AddWebhook(ctx context.Context, req httpapi.AddWebhookRequest) (*httpapi.Webhook, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.WebhooksRoutePath).String()
+	res, err := c.postJSON(ctx, route, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.Webhook
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// RemoveWebhook unregisters a webhook.
+func (c *Client) RemoveWebhook(ctx context.Context, id string) error {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.WebhooksRoutePath, id).String()
+	return c.verifySuccess(c.sendRequest(ctx, http.MethodDelete, route, nil, nil))
+}
+
+// ListAlerts returns the raised alerts, most recent first. A limit of zero
+// uses the server default.
+func (c *Client) ListAlerts(ctx context.Context, limit int) ([]httpapi.Alert, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.AlertsRoutePath)
+	if limit > 0 {
+		route.RawQuery = url.Values{"limit": {strconv.Itoa(limit)}}.Encode()
+	}
+
+	var resp httpapi.ListAlertsResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Alerts, nil
+}
+
+// GetAlertRules returns the alert rules in effect.
+func (c *Client) GetAlertRules(ctx context.Context) (*httpapi.AlertRulesResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.AlertsRoutePath + httpapi.RulesRoutePath).String()
+
+	var resp httpapi.AlertRulesResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// SetAlertRules replaces the alert rules until they are reset.
+func (c *Client) SetAlertRules(ctx context.Context, rules httpapi.AlertRules) (*httpapi.AlertRulesResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.AlertsRoutePath + httpapi.RulesRoutePath).String()
+	res, err := c.postJSON(ctx, route, rules)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAlertRules(res)
+}
+
+// ResetAlertRules restores the configured alert rules.
+func (c *Client) ResetAlertRules(ctx context.Context) (*httpapi.AlertRulesResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.AlertsRoutePath + httpapi.RulesRoutePath).String()
+	res, err := c.sendRequest(ctx, http.MethodDelete, route, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAlertRules(res)
+}
+
+func decodeAlertRules(res *http.Response) (*httpapi.AlertRulesResponse, error) {
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.AlertRulesResponse
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// ListReplicaDeadLetters returns the replica transfers that failed, oldest
+// first. A limit of zero uses the server default.
+func (c *Client) ListReplicaDeadLetters(ctx context.Context, limit int) ([]httpapi.ReplicaDeadLetter, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.ReplicationRoutePath + httpapi.DeadLettersRoutePath)
+	if limit > 0 {
+		route.RawQuery = url.Values{"limit": []string{strconv.Itoa(limit)}}.Encode()
+	}
+
+	var resp httpapi.ListReplicaDeadLettersResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.DeadLetters, nil
+}
+
+// RequeueReplicaDeadLetter moves a failed replica transfer back to the
+// replication queue to run again.
+func (c *Client) RequeueReplicaDeadLetter(ctx context.Context, id string) (*httpapi.ReplicaDeadLetter, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.ReplicationRoutePath+httpapi.DeadLettersRoutePath, id, httpapi.RequeueRoutePath).String()
+	res, err := c.postJSON(ctx, route, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeReplicaDeadLetter(res)
+}
+
+// DiscardReplicaDeadLetter removes a failed replica transfer.
+func (c *Client) DiscardReplicaDeadLetter(ctx context.Context, id string) (*httpapi.ReplicaDeadLetter, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.ReplicationRoutePath+httpapi.DeadLettersRoutePath, id).String()
+	res, err := c.sendRequest(ctx, http.MethodDelete, route, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeReplicaDeadLetter(res)
+}
+
+func decodeReplicaDeadLetter(res *http.Response) (*httpapi.ReplicaDeadLetter, error) {
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.ReplicaDeadLetter
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+	return &resp, nil
+}
+
+// ListJobs returns the queued jobs, or the dead-lettered jobs if dead is
+// set, of the named queue or of all queues if queueName is empty. A limit of
+// zero uses the server default.
+func (c *Client) ListJobs(ctx context.Context, queueName string, dead bool, limit int) ([]httpapi.Job, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.JobsRoutePath)
+	query := url.Values{}
+	if queueName != "" {
+		query.Set("queue", queueName)
+	}
+	if dead {
+		query.Set("dead", "true")
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	route.RawQuery = query.Encode()
+
+	var resp httpapi.ListJobsResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Jobs, nil
+}
+
+// GetJob returns a queued, running or dead-lettered job.
+func (c *Client) GetJob(ctx context.Context, queueName, id string) (*httpapi.Job, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.JobsRoutePath, queueName, id).String()
+	res, err := c.sendRequest(ctx, http.MethodGet, route, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJob(res)
+}
+
+// RetryJob moves a dead-lettered job back to its queue to run again.
+func (c *Client) RetryJob(ctx context.Context, queueName, id string) (*httpapi.Job, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.JobsRoutePath, queueName, id, httpapi.RetryRoutePath).String()
+	res, err := c.postJSON(ctx, route, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJob(res)
+}
+
+// CancelJob removes a queued or dead-lettered job.
+func (c *Client) CancelJob(ctx context.Context, queueName, id string) (*httpapi.Job, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.JobsRoutePath, queueName, id).String()
+	res, err := c.sendRequest(ctx, http.MethodDelete, route, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJob(res)
+}
+
+func decodeJob(res *http.Response) (*httpapi.Job, error) {
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.Job
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+	return &resp, nil
+}
+
+// ListSignRequests returns the sign requests held for approval of the given
+// status, pending ones if status is empty, or all of them if status is "all".
+// A limit of zero uses the server default.
+func (c *Client) ListSignRequests(ctx context.Context, status string, limit int) ([]httpapi.SignRequest, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.SigningRoutePath + httpapi.RequestsRoutePath)
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	route.RawQuery = query.Encode()
+
+	var resp httpapi.ListSignRequestsResponse
+	if err := c.getJSON(ctx, route.String(), &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Requests, nil
+}
+
+// ApproveSignRequest approves a pending or rejected sign request.
+func (c *Client) ApproveSignRequest(ctx context.Context, id uint) (*httpapi.SignRequest, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.SigningRoutePath+httpapi.RequestsRoutePath, strconv.FormatUint(uint64(id), 10), httpapi.ApproveRoutePath).String()
+	res, err := c.postJSON(ctx, route, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSignRequest(res)
+}
+
+// RejectSignRequest rejects a pending sign request.
+func (c *Client) RejectSignRequest(ctx context.Context, id uint, reason string) (*httpapi.SignRequest, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.SigningRoutePath+httpapi.RequestsRoutePath, strconv.FormatUint(uint64(id), 10), httpapi.RejectRoutePath).String()
+	res, err := c.postJSON(ctx, route, httpapi.RejectSignRequestRequest{Reason: reason})
+	if err != nil {
+		return nil, err
+	}
+	return decodeSignRequest(res)
+}
+
+func decodeSignRequest(res *http.Response) (*httpapi.SignRequest, error) {
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.SignRequest
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+	return &resp, nil
+}
+
+// ListStorageClasses returns the storage classes with their usage and the
+// spaces assigned a class.
+func (c *Client) ListStorageClasses(ctx context.Context) (*httpapi.ListStorageClassesResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.StorageClassesRoutePath).String()
+
+	var resp httpapi.ListStorageClassesResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// SetSpaceStorageClass assigns a storage class to the blobs later allocated
+// in a space. An empty class removes the assignment.
+func (c *Client) SetSpaceStorageClass(ctx context.Context, space string, class string) (*httpapi.SpaceStorageClass, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.StorageClassesRoutePath+httpapi.SpacesRoutePath, space).String()
+	res, err := c.postJSON(ctx, route, httpapi.SetSpaceStorageClassRequest{Class: class})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.SpaceStorageClass
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// ListRatePlans returns the billing rate plans and the spaces assigned a
+// plan.
+func (c *Client) ListRatePlans(ctx context.Context) (*httpapi.ListRatePlansResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.BillingRoutePath + httpapi.PlansRoutePath).String()
+
+	var resp httpapi.ListRatePlansResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// SetSpaceRatePlan assigns a rate plan, and optionally a tenant, to a space.
+// An empty plan removes the assignment.
+func (c *Client) SetSpaceRatePlan(ctx context.Context, space string, plan string, tenant string) (*httpapi.SpaceRatePlan, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.BillingRoutePath+httpapi.SpacesRoutePath, space).String()
+	res, err := c.postJSON(ctx, route, httpapi.SetSpaceRatePlanRequest{Plan: plan, Tenant: tenant})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.SpaceRatePlan
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// GetInvoice returns the invoice of a calendar month, formatted as YYYY-MM.
+func (c *Client) GetInvoice(ctx context.Context, period string) (*httpapi.Invoice, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.BillingRoutePath+httpapi.InvoicesRoutePath, period).String()
+
+	var resp httpapi.Invoice
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetInvoiceCSV returns the line items of the invoice of a calendar month as
+// CSV.
+func (c *Client) GetInvoiceCSV(ctx context.Context, period string) ([]byte, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.BillingRoutePath+httpapi.InvoicesRoutePath, period)
+	route.RawQuery = url.Values{"format": {"csv"}}.Encode()
+	res, err := c.sendRequest(ctx, http.MethodGet, route.String(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	return data, nil
+}
+
+// PushInvoice pushes the invoice of a calendar month to the billing webhook.
+func (c *Client) PushInvoice(ctx context.Context, period string) (*httpapi.Invoice, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath+httpapi.BillingRoutePath+httpapi.InvoicesRoutePath, period, httpapi.PushRoutePath).String()
+	res, err := c.postJSON(ctx, route, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errFromResponse(res)
+	}
+
+	var resp httpapi.Invoice
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decoding response JSON: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// GetOverview fetches the summary of the node shown on the dashboard.
+func (c *Client) GetOverview(ctx context.Context) (*httpapi.OverviewResponse, error) {
+	route := c.endpoint.JoinPath(httpapi.AdminRoutePath + httpapi.OverviewRoutePath).String()
+
+	var resp httpapi.OverviewResponse
+	if err := c.getJSON(ctx, route, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DashboardURL returns the URL of the dashboard, carrying the client's admin
+// token in its fragment.
+func (c *Client) DashboardURL() (string, error) {
+	token, ok := strings.CutPrefix(c.authHeader, "Bearer ")
+	if !ok {
+		return "", fmt.Errorf("client has no admin token")
+	}
+	u := c.endpoint.JoinPath(httpapi.AdminRoutePath, httpapi.DashboardRoutePath, "/")
+	u.Fragment = url.Values{"token": {token}}.Encode()
+	return u.String(), nil
+}
+
+func createAuthBearerTokenFromID(id principal.Signer) (string, error) {
+	claims := jwt.MapClaims{
+		"service_name": "storacha",
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+
+	key, err := hwsigner.KeySigner(id)
+	if err != nil {
+		return "", err
+	}
+	tokenString, err := token.SignedString(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %v", err)
 	}
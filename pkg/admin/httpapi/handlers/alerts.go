@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/pdp/alerting"
+)
+
+// maxAlertsLimit is the largest number of alerts returned by one request.
+const maxAlertsLimit = 1000
+
+// AlertHandler handles requests to list alerts and manage the rules raising
+// them.
+type AlertHandler struct {
+	alerter *alerting.Alerter
+}
+
+// NewAlertHandler creates a new AlertHandler.
+func NewAlertHandler(alerter *alerting.Alerter) *AlertHandler {
+	return &AlertHandler{alerter: alerter}
+}
+
+// ListAlerts returns the raised alerts, most recent first.
+// GET /admin/alerts?limit=<n>
+func (h *AlertHandler) ListAlerts(c echo.Context) error {
+	limit := 0
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		limit = min(n, maxAlertsLimit)
+	}
+	alerts, err := h.alerter.List(c.Request().Context(), limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.ListAlertsResponse{Alerts: make([]httpapi.Alert, 0, len(alerts))}
+	for _, a := range alerts {
+		res.Alerts = append(res.Alerts, httpapi.Alert{
+			Key:       a.Key,
+			Kind:      a.Kind,
+			DataSetID: a.DataSetID,
+			RailID:    a.RailID,
+			TxHash:    a.TxHash,
+			Epoch:     a.Epoch,
+			Message:   a.Message,
+			FiredAt:   a.FiredAt,
+			Delivered: a.DeliveredAt != nil,
+			Attempts:  a.Attempts,
+			LastError: a.LastError,
+		})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetRules returns the alert rules in effect.
+// GET /admin/alerts/rules
+func (h *AlertHandler) GetRules(c echo.Context) error {
+	return h.respondRules(c)
+}
+
+// SetRules replaces the alert rules until they are reset.
+// POST /admin/alerts/rules
+func (h *AlertHandler) SetRules(c echo.Context) error {
+	var req httpapi.AlertRules
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	rules := alerting.Rules{
+		DeadlineEpochs:     req.DeadlineEpochs,
+		FaultRecords:       req.FaultRecords,
+		SettlementFailures: req.SettlementFailures,
+	}
+	if err := rules.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.alerter.SetRules(c.Request().Context(), rules); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return h.respondRules(c)
+}
+
+// ResetRules restores the configured alert rules.
+// DELETE /admin/alerts/rules
+func (h *AlertHandler) ResetRules(c echo.Context) error {
+	if err := h.alerter.ResetRules(c.Request().Context()); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return h.respondRules(c)
+}
+
+func (h *AlertHandler) respondRules(c echo.Context) error {
+	rules, overridden, err := h.alerter.Rules(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, httpapi.AlertRulesResponse{
+		AlertRules: httpapi.AlertRules{
+			DeadlineEpochs:     rules.DeadlineEpochs,
+			FaultRecords:       rules.FaultRecords,
+			SettlementFailures: rules.SettlementFailures,
+		},
+		Overridden: overridden,
+	})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/pdp/backfill"
+)
+
+// BackfillHandler handles requests to register the blobs stored before the
+// node proved its content with PDP.
+type BackfillHandler struct {
+	backfill *backfill.Service
+}
+
+// NewBackfillHandler creates a new BackfillHandler.
+func NewBackfillHandler(backfill *backfill.Service) *BackfillHandler {
+	return &BackfillHandler{backfill: backfill}
+}
+
+// GetBackfill returns the progress of the last backfill.
+// GET /admin/backfill
+func (h *BackfillHandler) GetBackfill(c echo.Context) error {
+	return c.JSON(http.StatusOK, toBackfillStatus(h.backfill.Status()))
+}
+
+// StartBackfill starts a backfill in the background, or resumes the last one.
+// POST /admin/backfill
+func (h *BackfillHandler) StartBackfill(c echo.Context) error {
+	var body httpapi.StartBackfillRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	s, err := h.backfill.Run(c.Request().Context(), body.Resume)
+	if err != nil {
+		if errors.Is(err, backfill.ErrRunning) || errors.Is(err, backfill.ErrNothingToResume) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusAccepted, toBackfillStatus(s))
+}
+
+// CancelBackfill stops the running backfill, it can be resumed later.
+// POST /admin/backfill/cancel
+func (h *BackfillHandler) CancelBackfill(c echo.Context) error {
+	s, err := h.backfill.Cancel(c.Request().Context())
+	if err != nil {
+		if errors.Is(err, backfill.ErrNotRunning) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, toBackfillStatus(s))
+}
+
+func toBackfillStatus(s backfill.Status) httpapi.BackfillStatus {
+	out := httpapi.BackfillStatus{
+		State:      string(s.State),
+		Error:      s.Error,
+		Examined:   s.Counts.Examined(),
+		Queued:     s.Counts.Queued,
+		Registered: s.Counts.Registered,
+		Missing:    s.Counts.Missing,
+		TooLarge:   s.Counts.TooLarge,
+	}
+	if !s.StartedAt.IsZero() {
+		out.StartedAt = &s.StartedAt
+	}
+	if !s.FinishedAt.IsZero() {
+		out.FinishedAt = &s.FinishedAt
+	}
+	return out
+}
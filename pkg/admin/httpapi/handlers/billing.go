@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-ucanto/did"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/service/billing"
+)
+
+// BillingHandler handles requests to manage rate plans and export invoices.
+type BillingHandler struct {
+	billing *billing.Service
+}
+
+// NewBillingHandler creates a new BillingHandler.
+func NewBillingHandler(billing *billing.Service) *BillingHandler {
+	return &BillingHandler{billing: billing}
+}
+
+// ListRatePlans returns the configured plans and the spaces assigned a plan.
+// GET /admin/billing/plans
+func (h *BillingHandler) ListRatePlans(c echo.Context) error {
+	assignments, err := h.billing.Assignments(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := httpapi.ListRatePlansResponse{
+		Currency: h.billing.Currency(),
+		Default:  h.billing.DefaultPlan(),
+		Spaces:   make([]httpapi.SpaceRatePlan, 0, len(assignments)),
+	}
+	for _, p := range h.billing.Plans() {
+		res.Plans = append(res.Plans, httpapi.RatePlan{
+			Name:            p.Name,
+			StorageGiBMonth: p.StorageGiBMonth,
+			EgressGiB:       p.EgressGiB,
+		})
+	}
+	for _, a := range assignments {
+		res.Spaces = append(res.Spaces, httpapi.SpaceRatePlan{Space: a.Space.String(), Plan: a.Plan, Tenant: a.Tenant})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// SetSpaceRatePlan assigns a plan to a space. An empty plan removes the
+// assignment.
+// POST /admin/billing/spaces/:space
+func (h *BillingHandler) SetSpaceRatePlan(c echo.Context) error {
+	space, err := did.Parse(c.Param("space"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid space DID")
+	}
+	var req httpapi.SetSpaceRatePlanRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if err := h.billing.SetSpacePlan(c.Request().Context(), space, req.Plan, req.Tenant); err != nil {
+		if errors.Is(err, billing.ErrUnknownPlan) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, httpapi.SpaceRatePlan{Space: space.String(), Plan: req.Plan, Tenant: req.Tenant})
+}
+
+// GetInvoice returns the invoice of a calendar month, as JSON or, with
+// format=csv, as CSV line items.
+// GET /admin/billing/invoices/:period?format=<json|csv>
+func (h *BillingHandler) GetInvoice(c echo.Context) error {
+	ctx := c.Request().Context()
+	period := c.Param("period")
+	if _, _, err := billing.ParsePeriod(period); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	inv, err := h.billing.Invoice(ctx, period)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	switch c.QueryParam("format") {
+	case "", "json":
+	case "csv":
+		var buf bytes.Buffer
+		if err := billing.WriteCSV(&buf, inv); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="invoice-`+period+`.csv"`)
+		return c.Blob(http.StatusOK, "text/csv", buf.Bytes())
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid format, must be json or csv")
+	}
+
+	res := toInvoice(inv)
+	pushedAt, pushed, err := h.billing.Pushed(ctx, period)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if pushed {
+		res.PushedAt = &pushedAt
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// PushInvoice pushes the invoice of a calendar month to the billing webhook.
+// POST /admin/billing/invoices/:period/push
+func (h *BillingHandler) PushInvoice(c echo.Context) error {
+	ctx := c.Request().Context()
+	period := c.Param("period")
+	if _, _, err := billing.ParsePeriod(period); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	inv, err := h.billing.Push(ctx, period)
+	if err != nil {
+		if errors.Is(err, billing.ErrNoWebhook) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+
+	res := toInvoice(inv)
+	if pushedAt, pushed, err := h.billing.Pushed(ctx, period); err == nil && pushed {
+		res.PushedAt = &pushedAt
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func toInvoice(inv billing.Invoice) httpapi.Invoice {
+	res := httpapi.Invoice{
+		Period:      inv.Period,
+		Currency:    inv.Currency,
+		Final:       inv.Final,
+		GeneratedAt: inv.GeneratedAt,
+		Lines:       make([]httpapi.InvoiceLine, 0, len(inv.Lines)),
+		Total:       inv.Total,
+	}
+	for _, l := range inv.Lines {
+		res.Lines = append(res.Lines, httpapi.InvoiceLine{
+			Space:     l.Space,
+			Tenant:    l.Tenant,
+			Plan:      l.Plan,
+			Resource:  l.Resource,
+			Quantity:  l.Quantity,
+			Unit:      l.Unit,
+			UnitPrice: l.UnitPrice,
+			Amount:    l.Amount,
+		})
+	}
+	return res
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/blocklist"
+)
+
+// BlocklistHandler handles requests to inspect and change the content refused
+// by the node.
+type BlocklistHandler struct {
+	list *blocklist.List
+	// syncer is nil if no remote denylist is configured
+	syncer *blocklist.Syncer
+	// purger is nil if blocked blobs cannot be purged
+	purger *blocklist.Purger
+}
+
+// NewBlocklistHandler creates a new BlocklistHandler.
+func NewBlocklistHandler(list *blocklist.List, syncer *blocklist.Syncer, purger *blocklist.Purger) *BlocklistHandler {
+	return &BlocklistHandler{list: list, syncer: syncer, purger: purger}
+}
+
+// ListBlocklist returns the entries of the blocklist, and the status of the
+// remote denylist.
+// GET /admin/blocklist?source=<source>&limit=<n>
+func (h *BlocklistHandler) ListBlocklist(c echo.Context) error {
+	var limit int
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		limit = n
+	}
+	entries, err := h.list.Entries(c.Request().Context(), c.QueryParam("source"), limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.ListBlocklistResponse{
+		Entries: make([]httpapi.BlocklistEntry, 0, len(entries)),
+		Total:   h.list.Count(),
+	}
+	for _, e := range entries {
+		res.Entries = append(res.Entries, toBlocklistEntry(e))
+	}
+	if h.syncer != nil {
+		s := toBlocklistSyncStatus(h.syncer.Status())
+		res.Sync = &s
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// AddBlocklist blocks content, purging the blobs held by the node if
+// requested. Only blobs blocked by CID or digest can be purged.
+// POST /admin/blocklist
+func (h *BlocklistHandler) AddBlocklist(c echo.Context) error {
+	var body httpapi.AddBlocklistRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	keys, err := parseBlocklistKeys(body.Entries)
+	if err != nil {
+		return err
+	}
+	if body.Purge && h.purger == nil {
+		return echo.NewHTTPError(http.StatusNotImplemented, "purging blobs is not supported by this node")
+	}
+
+	ctx := c.Request().Context()
+	added, err := h.list.Add(ctx, keys, body.Reason)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.AddBlocklistResponse{Added: added, Purged: []string{}}
+	if !body.Purge {
+		return c.JSON(http.StatusOK, res)
+	}
+	for _, k := range keys {
+		if k.Kind != blocklist.KindDigest {
+			continue
+		}
+		digest, err := digestutil.Parse(k.Value)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		purged, err := h.purger.Purge(ctx, digest)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("purging %s: %s", k, err))
+		}
+		if purged {
+			res.Purged = append(res.Purged, k.Value)
+		}
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// RemoveBlocklist unblocks content. Entries synced from the remote denylist
+// are added again by the next sync if they are still listed.
+// POST /admin/blocklist/remove
+func (h *BlocklistHandler) RemoveBlocklist(c echo.Context) error {
+	var body httpapi.RemoveBlocklistRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	keys, err := parseBlocklistKeys(body.Entries)
+	if err != nil {
+		return err
+	}
+	res := httpapi.RemoveBlocklistResponse{Removed: []httpapi.BlocklistEntry{}}
+	for _, k := range keys {
+		e, err := h.list.Remove(c.Request().Context(), k)
+		if err != nil {
+			if errors.Is(err, blocklist.ErrNotFound) {
+				continue
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		res.Removed = append(res.Removed, toBlocklistEntry(e))
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// CheckBlocklist returns whether the blob is blocked, by digest or by the
+// double hash of its CIDs.
+// GET /admin/blocklist/check/:digest
+func (h *BlocklistHandler) CheckBlocklist(c echo.Context) error {
+	digest, err := digestutil.Parse(c.Param("digest"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid digest")
+	}
+	blocked, err := h.list.Blocked(c.Request().Context(), digest)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, httpapi.CheckBlocklistResponse{Digest: digestutil.Format(digest), Blocked: blocked})
+}
+
+// SyncBlocklist syncs the remote denylist now.
+// POST /admin/blocklist/sync
+func (h *BlocklistHandler) SyncBlocklist(c echo.Context) error {
+	if h.syncer == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "no denylist is configured")
+	}
+	s, err := h.syncer.Sync(c.Request().Context())
+	if err != nil {
+		if errors.Is(err, blocklist.ErrSyncing) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+	return c.JSON(http.StatusOK, toBlocklistSyncStatus(s))
+}
+
+func parseBlocklistKeys(entries []string) ([]blocklist.Key, error) {
+	if len(entries) == 0 {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "no entries")
+	}
+	keys := make([]blocklist.Key, 0, len(entries))
+	for _, s := range entries {
+		k, err := blocklist.ParseKey(s)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func toBlocklistEntry(e blocklist.Entry) httpapi.BlocklistEntry {
+	return httpapi.BlocklistEntry{Key: e.Key.String(), Reason: e.Reason, Source: e.Source, AddedAt: e.AddedAt}
+}
+
+func toBlocklistSyncStatus(s blocklist.SyncStatus) httpapi.BlocklistSyncStatus {
+	return httpapi.BlocklistSyncStatus{
+		URL:       s.URL,
+		LastSync:  s.LastSync,
+		LastError: s.LastError,
+		Entries:   s.Entries,
+		Skipped:   s.Skipped,
+		Added:     s.Added,
+		Removed:   s.Removed,
+	}
+}
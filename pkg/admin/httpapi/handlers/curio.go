@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/pdp/curio"
+)
+
+// CurioHandler reconciles the pieces uploaded to Curio with the pieces it
+// holds.
+type CurioHandler struct {
+	backend *curio.Backend
+}
+
+// NewCurioHandler creates a new CurioHandler.
+func NewCurioHandler(backend *curio.Backend) *CurioHandler {
+	return &CurioHandler{backend: backend}
+}
+
+// Reconcile compares the pieces uploaded to Curio with the pieces it holds,
+// and fixes divergences unless it is a dry run.
+// POST /admin/curio/reconcile
+func (h *CurioHandler) Reconcile(c echo.Context) error {
+	var body httpapi.ReconcileCurioRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	report, err := h.backend.Reconcile(c.Request().Context(), body.DryRun)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.ReconcileCurioResponse{
+		Checked:     report.Checked,
+		Divergences: make([]httpapi.CurioDivergence, 0, len(report.Divergences)),
+	}
+	for _, d := range report.Divergences {
+		res.Divergences = append(res.Divergences, httpapi.CurioDivergence{
+			PieceCID: d.PieceCID,
+			Kind:     string(d.Kind),
+			Resolved: d.Resolved,
+			Error:    d.Error,
+		})
+	}
+	return c.JSON(http.StatusOK, res)
+}
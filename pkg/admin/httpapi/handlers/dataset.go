@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/smartcontracts"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// defaultFaultLookback is the number of epochs searched for FaultRecord
+// events when none is given. It matches the default maximum range Lotus
+// allows for a log filter.
+const defaultFaultLookback = 2880
+
+// faultRecordTopic is the signature of the service contract event
+// FaultRecord(uint256 indexed dataSetId, uint256 periodsFaulted, uint256 deadline).
+var faultRecordTopic = crypto.Keccak256Hash([]byte("FaultRecord(uint256,uint256,uint256)"))
+
+// DataSetHandler handles requests to inspect the data sets stored by the node.
+type DataSetHandler struct {
+	db          *gorm.DB
+	verifier    smartcontracts.Verifier
+	serviceView smartcontracts.Service
+	ethClient   *ethclient.Client
+	pdpConfig   app.PDPServiceConfig
+	pieces      pieceStore
+}
+
+// NewDataSetHandler creates a new DataSetHandler. The resolver and reader are
+// used to verify the blobs of data sets, which is unavailable if either is
+// nil.
+func NewDataSetHandler(db *gorm.DB, verifier smartcontracts.Verifier, serviceView smartcontracts.Service, ethClient *ethclient.Client, pdpConfig app.PDPServiceConfig, resolver types.PieceResolverAPI, reader types.PieceReaderAPI) *DataSetHandler {
+	h := &DataSetHandler{
+		db:          db,
+		verifier:    verifier,
+		serviceView: serviceView,
+		ethClient:   ethClient,
+		pdpConfig:   pdpConfig,
+	}
+	if resolver != nil && reader != nil {
+		h.pieces = struct {
+			types.PieceResolverAPI
+			types.PieceReaderAPI
+		}{resolver, reader}
+	}
+	return h
+}
+
+// ListDataSets returns a summary of every data set known to the node, from
+// local state only.
+// GET /admin/datasets
+func (h *DataSetHandler) ListDataSets(ctx echo.Context) error {
+	res, err := h.listDataSets(ctx.Request().Context())
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (h *DataSetHandler) listDataSets(ctx context.Context) (*httpapi.ListDataSetsResponse, error) {
+	var currentEpoch uint64
+	if h.ethClient != nil {
+		blockNum, err := h.ethClient.BlockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting current block: %w", err)
+		}
+		currentEpoch = blockNum
+	}
+
+	var proofSets []models.PDPProofSet
+	if err := h.db.WithContext(ctx).Order("id").Find(&proofSets).Error; err != nil {
+		return nil, fmt.Errorf("listing data sets: %w", err)
+	}
+
+	summaries, err := h.summarize(ctx, proofSets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpapi.ListDataSetsResponse{
+		CurrentEpoch: currentEpoch,
+		DataSets:     summaries,
+	}, nil
+}
+
+// GetDataSet returns the local and on-chain state of a data set, and the
+// faults recorded for it within the lookback period.
+// GET /admin/datasets/:id?fault_lookback=<epochs>
+func (h *DataSetHandler) GetDataSet(ctx echo.Context) error {
+	reqCtx := ctx.Request().Context()
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "invalid data set ID")
+	}
+	lookback := uint64(defaultFaultLookback)
+	if v := ctx.QueryParam("fault_lookback"); v != "" {
+		lookback, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return ctx.String(http.StatusBadRequest, "invalid fault lookback")
+		}
+	}
+
+	var ps models.PDPProofSet
+	if err := h.db.WithContext(reqCtx).Where("id = ?", id).First(&ps).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.String(http.StatusNotFound, "data set not found")
+		}
+		return ctx.String(http.StatusInternalServerError, "getting data set: "+err.Error())
+	}
+	summaries, err := h.summarize(reqCtx, []models.PDPProofSet{ps})
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+
+	res := httpapi.GetDataSetResponse{
+		DataSetSummary: summaries[0],
+		Faults:         []httpapi.FaultRecord{},
+		FaultLookback:  lookback,
+	}
+	if h.ethClient == nil {
+		res.Notes = append(res.Notes, "eth client not available, chain state and faults are not shown")
+		return ctx.JSON(http.StatusOK, &res)
+	}
+
+	blockNum, err := h.ethClient.BlockNumber(reqCtx)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "getting current block: "+err.Error())
+	}
+	res.CurrentEpoch = blockNum
+
+	dataSetID := new(big.Int).SetUint64(id)
+	// the contracts know nothing of a data set until it is initialized
+	if ps.InitReady {
+		chain, err := h.chainState(ctx, dataSetID)
+		if err != nil {
+			res.Notes = append(res.Notes, "chain state unavailable: "+err.Error())
+		} else {
+			res.Chain = chain
+		}
+	}
+
+	faults, err := h.faults(ctx, dataSetID, blockNum, lookback)
+	if err != nil {
+		res.Notes = append(res.Notes, "fault history unavailable: "+err.Error())
+	} else {
+		res.Faults = faults
+	}
+
+	return ctx.JSON(http.StatusOK, &res)
+}
+
+// summarize combines the proof sets with their piece counts and proving
+// tasks.
+func (h *DataSetHandler) summarize(ctx context.Context, proofSets []models.PDPProofSet) ([]httpapi.DataSetSummary, error) {
+	db := h.db.WithContext(ctx)
+	ids := make([]int64, len(proofSets))
+	for i, ps := range proofSets {
+		ids[i] = ps.ID
+	}
+
+	type count struct {
+		ProofsetID int64
+		Count      int64
+	}
+	toMap := func(counts []count) map[int64]int64 {
+		m := make(map[int64]int64, len(counts))
+		for _, c := range counts {
+			m[c.ProofsetID] = c.Count
+		}
+		return m
+	}
+
+	var pieces []count
+	if err := db.Model(&models.PDPProofsetRoot{}).
+		Select("proofset_id, COUNT(DISTINCT root_id) AS count").
+		Where("proofset_id IN ?", ids).
+		Group("proofset_id").
+		Scan(&pieces).Error; err != nil {
+		return nil, fmt.Errorf("counting pieces: %w", err)
+	}
+	var pending []count
+	if err := db.Model(&models.PDPProofsetRootAdd{}).
+		Select("proofset_id, COUNT(DISTINCT root) AS count").
+		Where("proofset_id IN ? AND add_message_ok IS NULL", ids).
+		Group("proofset_id").
+		Scan(&pending).Error; err != nil {
+		return nil, fmt.Errorf("counting pending pieces: %w", err)
+	}
+	var proving []count
+	if err := db.Model(&models.PDPProveTask{}).
+		Select("proofset_id, COUNT(*) AS count").
+		Where("proofset_id IN ?", ids).
+		Group("proofset_id").
+		Scan(&proving).Error; err != nil {
+		return nil, fmt.Errorf("counting prove tasks: %w", err)
+	}
+
+	pieceCounts, pendingCounts, provingCounts := toMap(pieces), toMap(pending), toMap(proving)
+	summaries := make([]httpapi.DataSetSummary, 0, len(proofSets))
+	for _, ps := range proofSets {
+		s := httpapi.DataSetSummary{
+			ID:                 uint64(ps.ID),
+			Initialized:        ps.InitReady,
+			Pieces:             pieceCounts[ps.ID],
+			PendingPieces:      pendingCounts[ps.ID],
+			ProvingPeriod:      int64OrZero(ps.ProvingPeriod),
+			ChallengeWindow:    int64OrZero(ps.ChallengeWindow),
+			NextChallengeEpoch: int64OrZero(ps.ProveAtEpoch),
+			IsProving:          provingCounts[ps.ID] > 0,
+		}
+		if s.NextChallengeEpoch > 0 {
+			s.ProvingDeadline = s.NextChallengeEpoch + s.ChallengeWindow
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, nil
+}
+
+func (h *DataSetHandler) chainState(ctx echo.Context, dataSetID *big.Int) (*httpapi.DataSetChainState, error) {
+	reqCtx := ctx.Request().Context()
+	if h.verifier == nil || h.serviceView == nil {
+		return nil, fmt.Errorf("contracts not available")
+	}
+
+	leafCount, err := h.verifier.GetDataSetLeafCount(reqCtx, dataSetID)
+	if err != nil {
+		return nil, fmt.Errorf("getting leaf count: %w", err)
+	}
+	nextChallenge, err := h.verifier.GetNextChallengeEpoch(reqCtx, dataSetID)
+	if err != nil {
+		return nil, fmt.Errorf("getting next challenge epoch: %w", err)
+	}
+	windowStart, err := h.serviceView.NextPDPChallengeWindowStart(reqCtx, dataSetID)
+	if err != nil {
+		return nil, fmt.Errorf("getting next challenge window: %w", err)
+	}
+	pdpCfg, err := h.serviceView.PDPConfig(reqCtx)
+	if err != nil {
+		return nil, fmt.Errorf("getting pdp config: %w", err)
+	}
+
+	state := &httpapi.DataSetChainState{
+		LeafCount:                leafCount.Uint64(),
+		NextChallengeEpoch:       nextChallenge.Uint64(),
+		NextChallengeWindowStart: windowStart.Uint64(),
+		ProvingDeadline:          windowStart.Uint64() + pdpCfg.ChallengeWindow.Uint64(),
+		MaxProvingPeriod:         pdpCfg.MaxProvingPeriod,
+		ChallengeWindow:          pdpCfg.ChallengeWindow.Uint64(),
+	}
+	// data sets not created through the service contract have no payment info
+	if info, err := h.serviceView.GetDataSet(reqCtx, dataSetID); err == nil {
+		state.Payer = info.Payer.Hex()
+		state.PDPRailID = info.PdpRailId.String()
+		state.PDPEndEpoch = info.PdpEndEpoch.String()
+	}
+	return state, nil
+}
+
+// faults returns the FaultRecord events for a data set emitted within the
+// last lookback epochs.
+func (h *DataSetHandler) faults(ctx echo.Context, dataSetID *big.Int, currentEpoch, lookback uint64) ([]httpapi.FaultRecord, error) {
+	from := uint64(0)
+	if currentEpoch > lookback {
+		from = currentEpoch - lookback
+	}
+	logs, err := h.ethClient.FilterLogs(ctx.Request().Context(), ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(currentEpoch),
+		Addresses: []ethcommon.Address{h.pdpConfig.Contracts.Service},
+		Topics:    [][]ethcommon.Hash{{faultRecordTopic}, {ethcommon.BigToHash(dataSetID)}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("filtering logs: %w", err)
+	}
+
+	faults := make([]httpapi.FaultRecord, 0, len(logs))
+	for _, l := range logs {
+		f, err := parseFaultRecord(l)
+		if err != nil {
+			return nil, err
+		}
+		faults = append(faults, f)
+	}
+	return faults, nil
+}
+
+// parseFaultRecord decodes a FaultRecord event. The data set ID is the only
+// indexed argument, the remaining arguments are ABI encoded in the log data.
+func parseFaultRecord(l ethtypes.Log) (httpapi.FaultRecord, error) {
+	if len(l.Topics) != 2 || l.Topics[0] != faultRecordTopic {
+		return httpapi.FaultRecord{}, fmt.Errorf("log %s:%d is not a FaultRecord event", l.TxHash, l.Index)
+	}
+	if len(l.Data) != 64 {
+		return httpapi.FaultRecord{}, fmt.Errorf("FaultRecord event %s:%d has %d bytes of data, expected 64", l.TxHash, l.Index, len(l.Data))
+	}
+	return httpapi.FaultRecord{
+		DataSetID:      l.Topics[1].Big().Uint64(),
+		PeriodsFaulted: new(big.Int).SetBytes(l.Data[:32]).Uint64(),
+		Deadline:       new(big.Int).SetBytes(l.Data[32:]).Uint64(),
+		BlockNumber:    l.BlockNumber,
+		TxHash:         l.TxHash.Hex(),
+	}, nil
+}
+
+func int64OrZero(in *int64) int64 {
+	if in == nil {
+		return 0
+	}
+	return *in
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+func TestParseFaultRecord(t *testing.T) {
+	data := append(ethcommon.BigToHash(big.NewInt(2)).Bytes(), ethcommon.BigToHash(big.NewInt(3051360)).Bytes()...)
+	txHash := ethcommon.HexToHash("0x01")
+
+	t.Run("decodes event", func(t *testing.T) {
+		f, err := parseFaultRecord(ethtypes.Log{
+			Topics:      []ethcommon.Hash{faultRecordTopic, ethcommon.BigToHash(big.NewInt(42))},
+			Data:        data,
+			BlockNumber: 3051400,
+			TxHash:      txHash,
+		})
+		require.NoError(t, err)
+		require.Equal(t, httpapi.FaultRecord{
+			DataSetID:      42,
+			PeriodsFaulted: 2,
+			Deadline:       3051360,
+			BlockNumber:    3051400,
+			TxHash:         txHash.Hex(),
+		}, f)
+	})
+
+	t.Run("rejects other events", func(t *testing.T) {
+		_, err := parseFaultRecord(ethtypes.Log{
+			Topics: []ethcommon.Hash{ethcommon.HexToHash("0x02"), ethcommon.BigToHash(big.NewInt(42))},
+			Data:   data,
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("rejects truncated data", func(t *testing.T) {
+		_, err := parseFaultRecord(ethtypes.Log{
+			Topics: []ethcommon.Hash{faultRecordTopic, ethcommon.BigToHash(big.NewInt(42))},
+			Data:   data[:32],
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestListDataSets(t *testing.T) {
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+
+	provingPeriod, challengeWindow, proveAt := int64(2880), int64(20), int64(1000)
+	require.NoError(t, db.Create(&models.PDPProofSet{
+		ID:                1,
+		InitReady:         true,
+		ProvingPeriod:     &provingPeriod,
+		ChallengeWindow:   &challengeWindow,
+		ProveAtEpoch:      &proveAt,
+		CreateMessageHash: "0x01",
+		Service:           "storacha",
+	}).Error)
+	require.NoError(t, db.Create(&models.PDPProofSet{
+		ID:                2,
+		CreateMessageHash: "0x02",
+		Service:           "storacha",
+	}).Error)
+
+	h := NewDataSetHandler(db, nil, nil, nil, app.PDPServiceConfig{}, nil, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/admin/datasets", nil), rec)
+	require.NoError(t, h.ListDataSets(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res httpapi.ListDataSetsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, []httpapi.DataSetSummary{
+		{
+			ID:                 1,
+			Initialized:        true,
+			ProvingPeriod:      2880,
+			ChallengeWindow:    20,
+			NextChallengeEpoch: 1000,
+			ProvingDeadline:    1020,
+		},
+		{ID: 2},
+	}, res.DataSets)
+}
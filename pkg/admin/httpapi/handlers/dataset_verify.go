@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/ipfs/go-cid"
+	"github.com/labstack/echo/v4"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/smartcontracts"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// activePiecesPageSize is the number of pieces read from the verifier
+// contract per call.
+const activePiecesPageSize = 100
+
+// activePieces lists the pieces of a data set on chain.
+type activePieces interface {
+	GetActivePieces(ctx context.Context, setID *big.Int, offset *big.Int, limit *big.Int) (*smartcontracts.ActivePieces, error)
+}
+
+// pieceStore resolves sub-pieces to the blobs they were computed from and
+// reads them, wherever blobs are stored.
+type pieceStore interface {
+	types.PieceResolverAPI
+	types.PieceReaderAPI
+}
+
+// VerifyDataSet checks the node holds every blob of every piece in a data set
+// on chain. With deep=true the content of each blob is hashed and compared
+// to its digest, otherwise blobs are only checked to exist. With
+// repair_plan=true the response suggests how to repair the pieces failing
+// verification.
+// GET /admin/datasets/:id/verify?deep=<bool>&repair_plan=<bool>
+func (h *DataSetHandler) VerifyDataSet(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "invalid data set ID")
+	}
+	var deep, plan bool
+	if v := ctx.QueryParam("deep"); v != "" {
+		if deep, err = strconv.ParseBool(v); err != nil {
+			return ctx.String(http.StatusBadRequest, "invalid deep parameter")
+		}
+	}
+	if v := ctx.QueryParam("repair_plan"); v != "" {
+		if plan, err = strconv.ParseBool(v); err != nil {
+			return ctx.String(http.StatusBadRequest, "invalid repair_plan parameter")
+		}
+	}
+	if h.verifier == nil {
+		return ctx.String(http.StatusServiceUnavailable, "verifier contract not available")
+	}
+	if h.pieces == nil {
+		return ctx.String(http.StatusServiceUnavailable, "piece store not available")
+	}
+
+	res, err := verifyDataSet(ctx.Request().Context(), h.db, h.verifier, h.pieces, id, deep)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	if plan {
+		res.RepairPlan = repairPlan(id, res.Failed)
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// verifyDataSet verifies the pieces of data set id on chain against the
+// node's records and blobs.
+func verifyDataSet(ctx context.Context, db *gorm.DB, chain activePieces, pieces pieceStore, id uint64, deep bool) (*httpapi.VerifyDataSetResponse, error) {
+	res := &httpapi.VerifyDataSetResponse{
+		DataSetID: id,
+		Deep:      deep,
+		Failed:    []httpapi.PieceVerification{},
+	}
+
+	setID := new(big.Int).SetUint64(id)
+	limit := big.NewInt(activePiecesPageSize)
+	for offset := big.NewInt(0); ; offset = new(big.Int).Add(offset, limit) {
+		page, err := chain.GetActivePieces(ctx, setID, offset, limit)
+		if err != nil {
+			return nil, fmt.Errorf("getting active pieces at offset %s: %w", offset, err)
+		}
+		for i, pieceCID := range page.Pieces {
+			v, err := verifyPiece(ctx, db, pieces, id, page.PieceIds[i].Uint64(), pieceCID, deep)
+			if err != nil {
+				return nil, err
+			}
+			res.Pieces++
+			if v.Status == httpapi.PieceOK {
+				res.OK++
+				continue
+			}
+			res.Failed = append(res.Failed, v)
+		}
+		if !page.HasMore {
+			return res, nil
+		}
+	}
+}
+
+func verifyPiece(ctx context.Context, db *gorm.DB, pieces pieceStore, dataSetID, pieceID uint64, pieceCID cid.Cid, deep bool) (httpapi.PieceVerification, error) {
+	v := httpapi.PieceVerification{
+		PieceID:  pieceID,
+		PieceCID: pieceCID.String(),
+		Status:   httpapi.PieceOK,
+	}
+
+	var roots []models.PDPProofsetRoot
+	if err := db.WithContext(ctx).
+		Where("proofset_id = ? AND root_id = ? AND root = ?", dataSetID, pieceID, pieceCID.String()).
+		Order("subroot_offset").
+		Find(&roots).Error; err != nil {
+		return v, fmt.Errorf("getting sub-pieces of piece %d: %w", pieceID, err)
+	}
+	if len(roots) == 0 {
+		v.Status = httpapi.PieceUnrecorded
+		var pending int64
+		if err := db.WithContext(ctx).Model(&models.PDPProofsetRootAdd{}).
+			Where("proofset_id = ? AND root = ?", dataSetID, pieceCID.String()).
+			Count(&pending).Error; err != nil {
+			return v, fmt.Errorf("counting pending adds of piece %d: %w", pieceID, err)
+		}
+		v.Pending = pending > 0
+		return v, nil
+	}
+
+	for _, root := range roots {
+		b := verifyBlob(ctx, pieces, root.Subroot, deep)
+		if b.Status == httpapi.PieceOK {
+			continue
+		}
+		v.Blobs = append(v.Blobs, b)
+		// a missing blob needs restoring whether or not others are corrupt
+		if v.Status != httpapi.PieceMissing {
+			v.Status = b.Status
+		}
+	}
+	return v, nil
+}
+
+func verifyBlob(ctx context.Context, pieces pieceStore, subPiece string, deep bool) httpapi.BlobVerification {
+	b := httpapi.BlobVerification{SubPieceCID: subPiece, Status: httpapi.PieceOK}
+	missing := func(format string, args ...any) httpapi.BlobVerification {
+		b.Status = httpapi.PieceMissing
+		b.Error = fmt.Sprintf(format, args...)
+		return b
+	}
+
+	c, err := cid.Parse(subPiece)
+	if err != nil {
+		return missing("parsing sub-piece CID: %s", err)
+	}
+	blob, found, err := pieces.ResolveToBlob(ctx, c.Hash())
+	if err != nil {
+		return missing("resolving sub-piece to blob: %s", err)
+	}
+	if !found {
+		return missing("sub-piece does not resolve to a blob")
+	}
+	b.Digest = digestutil.Format(blob)
+
+	if !deep {
+		has, err := pieces.Has(ctx, blob)
+		if err != nil {
+			return missing("checking blob: %s", err)
+		}
+		if !has {
+			return missing("blob not found")
+		}
+		return b
+	}
+
+	decoded, err := multihash.Decode(blob)
+	if err != nil {
+		return missing("decoding blob digest: %s", err)
+	}
+	r, err := pieces.Read(ctx, blob)
+	if err != nil {
+		return missing("reading blob: %s", err)
+	}
+	defer r.Data.Close()
+	sum, err := multihash.SumStream(r.Data, decoded.Code, decoded.Length)
+	if err != nil {
+		return missing("hashing blob: %s", err)
+	}
+	if string(sum) != string(blob) {
+		b.Status = httpapi.PieceCorrupt
+		b.Error = fmt.Sprintf("content hashes to %s", digestutil.Format(sum))
+	}
+	return b
+}
+
+// repairPlan suggests how to repair each of the failed pieces of a data set.
+func repairPlan(dataSetID uint64, failed []httpapi.PieceVerification) []httpapi.RepairStep {
+	steps := make([]httpapi.RepairStep, 0, len(failed))
+	for _, p := range failed {
+		step := httpapi.RepairStep{PieceID: p.PieceID, PieceCID: p.PieceCID}
+		switch p.Status {
+		case httpapi.PieceUnrecorded:
+			if !p.Pending {
+				step.Action = httpapi.RepairRemove
+				step.Detail = fmt.Sprintf("the node has no record of the piece nor its blobs, remove piece %d from the data set before its next challenge", p.PieceID)
+				break
+			}
+			step.Action = httpapi.RepairRecord
+			step.Detail = fmt.Sprintf("run 'piri client pdp proofset repair --proofset-id %d' to record the piece from its pending add, then verify again", dataSetID)
+		default:
+			step.Action = httpapi.RepairRestore
+			for _, b := range p.Blobs {
+				if b.Digest != "" {
+					step.Blobs = append(step.Blobs, b.Digest)
+				}
+			}
+			if len(step.Blobs) == 0 {
+				step.Action = httpapi.RepairRemove
+				step.Detail = fmt.Sprintf("no blob of the piece is known, remove piece %d from the data set before its next challenge", p.PieceID)
+				break
+			}
+			step.Detail = fmt.Sprintf("restore the blobs from a replica or the uploader; if they cannot be restored, remove piece %d from the data set before its next challenge", p.PieceID)
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
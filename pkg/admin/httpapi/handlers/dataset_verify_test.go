@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/smartcontracts"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// mockChain serves the pieces of a data set a page at a time.
+type mockChain struct {
+	pieces []cid.Cid
+	ids    []*big.Int
+}
+
+func (m *mockChain) GetActivePieces(_ context.Context, _ *big.Int, offset *big.Int, limit *big.Int) (*smartcontracts.ActivePieces, error) {
+	start := min(int(offset.Int64()), len(m.pieces))
+	end := min(start+int(limit.Int64()), len(m.pieces))
+	return &smartcontracts.ActivePieces{
+		Pieces:   m.pieces[start:end],
+		PieceIds: m.ids[start:end],
+		HasMore:  end < len(m.pieces),
+	}, nil
+}
+
+// mockPieceStore resolves sub-pieces to blobs and holds the blob content.
+type mockPieceStore struct {
+	blobs   map[string]multihash.Multihash // sub-piece hash -> blob digest
+	content map[string][]byte              // blob digest -> content
+}
+
+func (m *mockPieceStore) Resolve(context.Context, multihash.Multihash) (multihash.Multihash, bool, error) {
+	return nil, false, errors.New("not implemented")
+}
+
+func (m *mockPieceStore) ResolveToPiece(context.Context, multihash.Multihash) (multihash.Multihash, bool, error) {
+	return nil, false, errors.New("not implemented")
+}
+
+func (m *mockPieceStore) ResolveToBlob(_ context.Context, piece multihash.Multihash) (multihash.Multihash, bool, error) {
+	blob, ok := m.blobs[string(piece)]
+	return blob, ok, nil
+}
+
+func (m *mockPieceStore) Read(_ context.Context, data multihash.Multihash, _ ...types.ReadPieceOption) (*types.PieceReader, error) {
+	content, ok := m.content[string(data)]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &types.PieceReader{Size: int64(len(content)), Data: io.NopCloser(bytes.NewReader(content))}, nil
+}
+
+func (m *mockPieceStore) Has(_ context.Context, blob multihash.Multihash) (bool, error) {
+	_, ok := m.content[string(blob)]
+	return ok, nil
+}
+
+func testCID(t *testing.T, data string) cid.Cid {
+	h, err := multihash.Sum([]byte(data), multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	return cid.NewCidV1(cid.Raw, h)
+}
+
+type verifyFixture struct {
+	db     *gorm.DB
+	chain  *mockChain
+	pieces *mockPieceStore
+}
+
+func newVerifyFixture(t *testing.T) *verifyFixture {
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+	require.NoError(t, db.Create(&models.MessageWaitsEth{SignedTxHash: "0x01", TxStatus: "confirmed"}).Error)
+	require.NoError(t, db.Create(&models.PDPProofSet{ID: 1, CreateMessageHash: "0x01", Service: "storacha"}).Error)
+	return &verifyFixture{
+		db:    db,
+		chain: &mockChain{},
+		pieces: &mockPieceStore{
+			blobs:   map[string]multihash.Multihash{},
+			content: map[string][]byte{},
+		},
+	}
+}
+
+// addPiece adds a piece aggregated from blobs to the data set on chain and,
+// if recorded, to the node's records. The node holds the content of the
+// blobs, which the caller may remove or corrupt.
+func (f *verifyFixture) addPiece(t *testing.T, pieceID int64, recorded bool, blobs ...string) (cid.Cid, []multihash.Multihash) {
+	piece := testCID(t, "piece"+blobs[0])
+	f.chain.pieces = append(f.chain.pieces, piece)
+	f.chain.ids = append(f.chain.ids, big.NewInt(pieceID))
+
+	var digests []multihash.Multihash
+	for i, content := range blobs {
+		subPiece := testCID(t, "subpiece"+content)
+		digest, err := multihash.Sum([]byte(content), multihash.SHA2_256, -1)
+		require.NoError(t, err)
+		f.pieces.blobs[string(subPiece.Hash())] = digest
+		f.pieces.content[string(digest)] = []byte(content)
+		digests = append(digests, digest)
+
+		if recorded {
+			require.NoError(t, f.db.Create(&models.PDPProofsetRoot{
+				ProofsetID:     1,
+				RootID:         pieceID,
+				SubrootOffset:  int64(i) * 128,
+				Root:           piece.String(),
+				AddMessageHash: "0x01",
+				Subroot:        subPiece.String(),
+				SubrootSize:    128,
+			}).Error)
+		}
+	}
+	return piece, digests
+}
+
+func TestVerifyDataSet(t *testing.T) {
+	t.Run("verifies pieces held by the node", func(t *testing.T) {
+		f := newVerifyFixture(t)
+		for i := range activePiecesPageSize + 1 {
+			f.addPiece(t, int64(i), true, fmt.Sprintf("blob-a%d", i), fmt.Sprintf("blob-b%d", i))
+		}
+
+		for _, deep := range []bool{false, true} {
+			res, err := verifyDataSet(t.Context(), f.db, f.chain, f.pieces, 1, deep)
+			require.NoError(t, err)
+			require.Equal(t, activePiecesPageSize+1, res.Pieces)
+			require.Equal(t, res.Pieces, res.OK)
+			require.Empty(t, res.Failed)
+		}
+	})
+
+	t.Run("reports missing blobs", func(t *testing.T) {
+		f := newVerifyFixture(t)
+		f.addPiece(t, 0, true, "ok")
+		piece, digests := f.addPiece(t, 1, true, "held", "lost")
+		delete(f.pieces.content, string(digests[1]))
+
+		res, err := verifyDataSet(t.Context(), f.db, f.chain, f.pieces, 1, false)
+		require.NoError(t, err)
+		require.Equal(t, 2, res.Pieces)
+		require.Equal(t, 1, res.OK)
+		require.Len(t, res.Failed, 1)
+		require.Equal(t, uint64(1), res.Failed[0].PieceID)
+		require.Equal(t, piece.String(), res.Failed[0].PieceCID)
+		require.Equal(t, httpapi.PieceMissing, res.Failed[0].Status)
+		require.Len(t, res.Failed[0].Blobs, 1)
+		require.Equal(t, digestutil.Format(digests[1]), res.Failed[0].Blobs[0].Digest)
+	})
+
+	t.Run("reports corrupt blobs only when deep", func(t *testing.T) {
+		f := newVerifyFixture(t)
+		_, digests := f.addPiece(t, 0, true, "original")
+		f.pieces.content[string(digests[0])] = []byte("bitrot")
+
+		res, err := verifyDataSet(t.Context(), f.db, f.chain, f.pieces, 1, false)
+		require.NoError(t, err)
+		require.Empty(t, res.Failed)
+
+		res, err = verifyDataSet(t.Context(), f.db, f.chain, f.pieces, 1, true)
+		require.NoError(t, err)
+		require.Len(t, res.Failed, 1)
+		require.Equal(t, httpapi.PieceCorrupt, res.Failed[0].Status)
+		require.Equal(t, httpapi.PieceCorrupt, res.Failed[0].Blobs[0].Status)
+	})
+
+	t.Run("reports pieces the node has no record of", func(t *testing.T) {
+		f := newVerifyFixture(t)
+		f.addPiece(t, 0, false, "unknown")
+		pending, _ := f.addPiece(t, 1, false, "pending")
+		require.NoError(t, f.db.Create(&models.PDPProofsetRootAdd{
+			ProofsetID:     1,
+			AddMessageHash: "0x01",
+			Root:           pending.String(),
+			Subroot:        testCID(t, "subpiecepending").String(),
+			SubrootSize:    128,
+		}).Error)
+
+		res, err := verifyDataSet(t.Context(), f.db, f.chain, f.pieces, 1, false)
+		require.NoError(t, err)
+		require.Len(t, res.Failed, 2)
+		require.Equal(t, httpapi.PieceUnrecorded, res.Failed[0].Status)
+		require.False(t, res.Failed[0].Pending)
+		require.Equal(t, httpapi.PieceUnrecorded, res.Failed[1].Status)
+		require.True(t, res.Failed[1].Pending)
+	})
+}
+
+func TestRepairPlan(t *testing.T) {
+	steps := repairPlan(1, []httpapi.PieceVerification{
+		{PieceID: 0, Status: httpapi.PieceUnrecorded},
+		{PieceID: 1, Status: httpapi.PieceUnrecorded, Pending: true},
+		{PieceID: 2, Status: httpapi.PieceMissing, Blobs: []httpapi.BlobVerification{
+			{Status: httpapi.PieceMissing, Digest: "zQmA"},
+			{Status: httpapi.PieceCorrupt, Digest: "zQmB"},
+		}},
+		{PieceID: 3, Status: httpapi.PieceMissing, Blobs: []httpapi.BlobVerification{
+			{Status: httpapi.PieceMissing, Error: "sub-piece does not resolve to a blob"},
+		}},
+	})
+
+	require.Len(t, steps, 4)
+	require.Equal(t, httpapi.RepairRemove, steps[0].Action)
+	require.Equal(t, httpapi.RepairRecord, steps[1].Action)
+	require.Contains(t, steps[1].Detail, "--proofset-id 1")
+	require.Equal(t, httpapi.RepairRestore, steps[2].Action)
+	require.Equal(t, []string{"zQmA", "zQmB"}, steps[2].Blobs)
+	require.Equal(t, httpapi.RepairRemove, steps[3].Action)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/service/replicator"
+)
+
+// DeadLetterHandler handles requests to inspect, requeue and discard the
+// replica transfers that failed.
+type DeadLetterHandler struct {
+	replicator *replicator.Service
+}
+
+// NewDeadLetterHandler creates a new DeadLetterHandler.
+func NewDeadLetterHandler(replicator *replicator.Service) *DeadLetterHandler {
+	return &DeadLetterHandler{replicator: replicator}
+}
+
+// ListDeadLetters returns the failed transfers, oldest first.
+// GET /admin/replication/dead-letters?limit=<n>
+func (h *DeadLetterHandler) ListDeadLetters(c echo.Context) error {
+	var limit int
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		limit = min(n, maxJobsLimit)
+	}
+	letters, err := h.replicator.DeadLetters(c.Request().Context(), limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.ListReplicaDeadLettersResponse{DeadLetters: make([]httpapi.ReplicaDeadLetter, 0, len(letters))}
+	for _, l := range letters {
+		res.DeadLetters = append(res.DeadLetters, toReplicaDeadLetter(l))
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// RequeueDeadLetter moves a failed transfer back to the replication queue,
+// to run again with a fresh retry count.
+// POST /admin/replication/dead-letters/:id/requeue
+func (h *DeadLetterHandler) RequeueDeadLetter(c echo.Context) error {
+	l, err := h.replicator.RequeueDeadLetter(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return mapDeadLetterError(err)
+	}
+	return c.JSON(http.StatusOK, toReplicaDeadLetter(l))
+}
+
+// DiscardDeadLetter removes a failed transfer.
+// DELETE /admin/replication/dead-letters/:id
+func (h *DeadLetterHandler) DiscardDeadLetter(c echo.Context) error {
+	l, err := h.replicator.DiscardDeadLetter(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return mapDeadLetterError(err)
+	}
+	return c.JSON(http.StatusOK, toReplicaDeadLetter(l))
+}
+
+func mapDeadLetterError(err error) error {
+	if errors.Is(err, replicator.ErrDeadLetterNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
+
+func toReplicaDeadLetter(l replicator.DeadLetter) httpapi.ReplicaDeadLetter {
+	causes := l.Cause.Chain
+	if causes == nil {
+		causes = []string{}
+	}
+	return httpapi.ReplicaDeadLetter{
+		JobID:          l.JobID,
+		Digest:         digestutil.Format(l.Digest),
+		Space:          l.Space,
+		Source:         l.Source,
+		Sink:           l.Sink,
+		Error:          l.Error,
+		Causes:         causes,
+		FailedURL:      l.Cause.URL,
+		StatusCode:     l.Cause.StatusCode,
+		DigestMismatch: l.Cause.DigestMismatch,
+		Stalled:        l.Cause.Stalled,
+		FailedAt:       l.FailedAt,
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/ucan"
+
+	"github.com/storacha/piri/lib/sealbox"
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/delegations"
+)
+
+// DelegationHandler handles requests to manage delegations granted to the
+// node.
+type DelegationHandler struct {
+	id          principal.Signer
+	delegations *delegations.Manager
+}
+
+// NewDelegationHandler creates a new DelegationHandler.
+func NewDelegationHandler(id principal.Signer, delegations *delegations.Manager) *DelegationHandler {
+	return &DelegationHandler{id: id, delegations: delegations}
+}
+
+// ListDelegations returns the delegations held by the node, those from its
+// configuration first, then imported ones, soonest to expire first.
+// GET /admin/delegations
+func (h *DelegationHandler) ListDelegations(c echo.Context) error {
+	entries, err := h.delegations.List(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.ListDelegationsResponse{Delegations: make([]httpapi.Delegation, 0, len(entries))}
+	for _, e := range entries {
+		res.Delegations = append(res.Delegations, h.toDelegation(e))
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetDelegation returns a delegation held by the node.
+// GET /admin/delegations/:cid
+func (h *DelegationHandler) GetDelegation(c echo.Context) error {
+	link, err := parseLink(c.Param("cid"))
+	if err != nil {
+		return err
+	}
+	e, err := h.delegations.Get(c.Request().Context(), link)
+	if err != nil {
+		if errors.Is(err, delegations.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, h.toDelegation(e))
+}
+
+// RemoveDelegation deletes an imported delegation. Delegations from the
+// node's configuration cannot be removed.
+// DELETE /admin/delegations/:cid
+func (h *DelegationHandler) RemoveDelegation(c echo.Context) error {
+	link, err := parseLink(c.Param("cid"))
+	if err != nil {
+		return err
+	}
+	if err := h.delegations.Remove(c.Request().Context(), link); err != nil {
+		switch {
+		case errors.Is(err, delegations.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case errors.Is(err, delegations.ErrConfigured):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func parseLink(s string) (ucan.Link, error) {
+	c, err := cid.Decode(s)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid delegation CID: %s", err))
+	}
+	return cidlink.Link{Cid: c}, nil
+}
+
+func (h *DelegationHandler) toDelegation(e delegations.Entry) httpapi.Delegation {
+	dlg := e.Delegation
+	out := httpapi.Delegation{
+		CID:          dlg.Link().String(),
+		Source:       e.Source,
+		Issuer:       dlg.Issuer().DID().String(),
+		Audience:     dlg.Audience().DID().String(),
+		Capabilities: make([]httpapi.DelegationCapability, 0, len(dlg.Capabilities())),
+		Expiration:   e.Expiration(),
+		Expired:      h.delegations.Expired(e),
+		Expiring:     h.delegations.Expiring(e),
+	}
+	for _, capability := range dlg.Capabilities() {
+		out.Capabilities = append(out.Capabilities, httpapi.DelegationCapability{Can: capability.Can(), With: capability.With()})
+	}
+	if nbf := dlg.NotBefore(); nbf > 0 {
+		t := time.Unix(int64(nbf), 0).UTC()
+		out.NotBefore = &t
+	}
+	for _, p := range dlg.Proofs() {
+		out.Proofs = append(out.Proofs, p.String())
+	}
+	return out
+}
+
+// ImportDelegations registers delegations granted to the node in the
+// delegation store. Sealed delegations are decrypted with the node's key. All
+// delegations must have the node as their audience; nothing is imported if
+// any is invalid.
+// POST /admin/delegations
+func (h *DelegationHandler) ImportDelegations(c echo.Context) error {
+	var req httpapi.ImportDelegationsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if len(req.Delegations)+len(req.Sealed) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "no delegations to import")
+	}
+
+	dlgs := make([]delegation.Delegation, 0, len(req.Delegations)+len(req.Sealed))
+	for i, s := range req.Delegations {
+		dlg, err := delegation.Parse(s)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("parsing delegation %d: %s", i, err))
+		}
+		dlgs = append(dlgs, dlg)
+	}
+	for i, s := range req.Sealed {
+		dlg, err := h.open(s)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("opening sealed delegation %d: %s", i, err))
+		}
+		dlgs = append(dlgs, dlg)
+	}
+	for _, dlg := range dlgs {
+		if dlg.Audience().DID() != h.id.DID() {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("delegation %s is not granted to this node, audience is %s", dlg.Link(), dlg.Audience().DID()))
+		}
+	}
+
+	if err := h.delegations.Import(c.Request().Context(), dlgs...); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.ImportDelegationsResponse{Imported: make([]string, 0, len(dlgs))}
+	for _, dlg := range dlgs {
+		res.Imported = append(res.Imported, dlg.Link().String())
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *DelegationHandler) open(sealed string) (delegation.Delegation, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("decoding base64: %w", err)
+	}
+	archive, err := sealbox.Open(data, h.id)
+	if err != nil {
+		return nil, err
+	}
+	return delegation.Extract(archive)
+}
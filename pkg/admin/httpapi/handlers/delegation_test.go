@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/labstack/echo/v4"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/principal"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/lib/sealbox"
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/delegations"
+	"github.com/storacha/piri/pkg/store/delegationstore"
+)
+
+func TestImportDelegations(t *testing.T) {
+	node, err := ed25519.Generate()
+	require.NoError(t, err)
+	issuer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	grant := func(t *testing.T, audience principal.Signer) delegation.Delegation {
+		dlg, err := delegation.Delegate(issuer, audience, []ucan.Capability[ucan.NoCaveats]{
+			ucan.NewCapability("blob/allocate", issuer.DID().String(), ucan.NoCaveats{}),
+		})
+		require.NoError(t, err)
+		return dlg
+	}
+	// format as by `piri delegate generate`: a base64 CAR CID with the
+	// archive inlined
+	format := func(t *testing.T, dlg delegation.Delegation) string {
+		archive, err := io.ReadAll(dlg.Archive())
+		require.NoError(t, err)
+		digest, err := multihash.Sum(archive, multihash.IDENTITY, -1)
+		require.NoError(t, err)
+		s, err := cid.NewCidV1(uint64(multicodec.Car), digest).StringOfBase(multibase.Base64)
+		require.NoError(t, err)
+		return s
+	}
+	importReq := func(t *testing.T, h *DelegationHandler, req httpapi.ImportDelegationsRequest) (*httptest.ResponseRecorder, error) {
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+		r := httptest.NewRequest(http.MethodPost, "/admin/delegations", bytes.NewReader(body))
+		r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		return rec, h.ImportDelegations(echo.New().NewContext(r, rec))
+	}
+
+	t.Run("imports plain and sealed delegations", func(t *testing.T) {
+		store := delegationstore.NewDatastoreStore(datastore.NewMapDatastore())
+		h := NewDelegationHandler(node, delegations.New(store, nil))
+
+		plain := grant(t, node)
+		sealedDlg := grant(t, node)
+		archive, err := io.ReadAll(sealedDlg.Archive())
+		require.NoError(t, err)
+		sealed, err := sealbox.Seal(archive, node.DID())
+		require.NoError(t, err)
+
+		rec, err := importReq(t, h, httpapi.ImportDelegationsRequest{
+			Delegations: []string{format(t, plain)},
+			Sealed:      []string{base64.StdEncoding.EncodeToString(sealed)},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var res httpapi.ImportDelegationsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Equal(t, []string{plain.Link().String(), sealedDlg.Link().String()}, res.Imported)
+
+		for _, dlg := range []delegation.Delegation{plain, sealedDlg} {
+			got, err := store.Get(t.Context(), dlg.Link())
+			require.NoError(t, err)
+			require.Equal(t, dlg.Link(), got.Link())
+		}
+	})
+
+	t.Run("rejects delegations to another audience", func(t *testing.T) {
+		store := delegationstore.NewDatastoreStore(datastore.NewMapDatastore())
+		h := NewDelegationHandler(node, delegations.New(store, nil))
+
+		other, err := ed25519.Generate()
+		require.NoError(t, err)
+		ok, notOK := grant(t, node), grant(t, other)
+
+		_, err = importReq(t, h, httpapi.ImportDelegationsRequest{
+			Delegations: []string{format(t, ok), format(t, notOK)},
+		})
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		require.Equal(t, http.StatusBadRequest, httpErr.Code)
+
+		// nothing is imported
+		_, err = store.Get(t.Context(), ok.Link())
+		require.Error(t, err)
+	})
+}
+
+func TestManageDelegations(t *testing.T) {
+	node, err := ed25519.Generate()
+	require.NoError(t, err)
+	issuer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	configured, err := delegation.Delegate(issuer, node, []ucan.Capability[ucan.NoCaveats]{
+		ucan.NewCapability("claim/cache", issuer.DID().String(), ucan.NoCaveats{}),
+	}, delegation.WithNoExpiration())
+	require.NoError(t, err)
+	imported, err := delegation.Delegate(issuer, node, []ucan.Capability[ucan.NoCaveats]{
+		ucan.NewCapability("blob/allocate", issuer.DID().String(), ucan.NoCaveats{}),
+	}, delegation.WithExpiration(int(time.Now().Add(time.Hour).Unix())))
+	require.NoError(t, err)
+
+	store := delegationstore.NewDatastoreStore(datastore.NewMapDatastore())
+	require.NoError(t, store.Put(t.Context(), imported))
+	h := NewDelegationHandler(node, delegations.New(store, []delegations.Configured{
+		{Source: "ucan.services.indexer.proof", Delegation: configured},
+	}))
+	request := func(method, cid string, handle echo.HandlerFunc) (*httptest.ResponseRecorder, error) {
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(method, "/admin/delegations/"+cid, nil), rec)
+		c.SetParamNames("cid")
+		c.SetParamValues(cid)
+		return rec, handle(c)
+	}
+
+	t.Run("lists configured and imported delegations", func(t *testing.T) {
+		rec, err := request(http.MethodGet, "", h.ListDelegations)
+		require.NoError(t, err)
+		var res httpapi.ListDelegationsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Len(t, res.Delegations, 2)
+
+		require.Equal(t, configured.Link().String(), res.Delegations[0].CID)
+		require.Equal(t, "ucan.services.indexer.proof", res.Delegations[0].Source)
+		require.Nil(t, res.Delegations[0].Expiration)
+		require.False(t, res.Delegations[0].Expiring)
+
+		require.Equal(t, imported.Link().String(), res.Delegations[1].CID)
+		require.Equal(t, delegations.SourceImported, res.Delegations[1].Source)
+		require.Equal(t, node.DID().String(), res.Delegations[1].Audience)
+		require.Equal(t, []httpapi.DelegationCapability{{Can: "blob/allocate", With: issuer.DID().String()}}, res.Delegations[1].Capabilities)
+		require.NotNil(t, res.Delegations[1].Expiration)
+		require.False(t, res.Delegations[1].Expired)
+		require.True(t, res.Delegations[1].Expiring)
+	})
+
+	t.Run("configured delegations cannot be removed", func(t *testing.T) {
+		_, err := request(http.MethodDelete, configured.Link().String(), h.RemoveDelegation)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		require.Equal(t, http.StatusConflict, httpErr.Code)
+	})
+
+	t.Run("removes imported delegations", func(t *testing.T) {
+		rec, err := request(http.MethodDelete, imported.Link().String(), h.RemoveDelegation)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+
+		_, err = request(http.MethodGet, imported.Link().String(), h.GetDelegation)
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		require.Equal(t, http.StatusNotFound, httpErr.Code)
+	})
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/diskspace"
+)
+
+// DiskSpaceHandler handles requests for the disk usage of the node.
+type DiskSpaceHandler struct {
+	monitor *diskspace.Monitor
+}
+
+// NewDiskSpaceHandler creates a new DiskSpaceHandler.
+func NewDiskSpaceHandler(monitor *diskspace.Monitor) *DiskSpaceHandler {
+	return &DiskSpaceHandler{monitor: monitor}
+}
+
+// GetDiskSpace returns the disk usage of the data and temp directories at the
+// last check, and whether allocations are rejected.
+// GET /admin/disk-space
+func (h *DiskSpaceHandler) GetDiskSpace(c echo.Context) error {
+	s := h.monitor.Status()
+	res := httpapi.DiskSpaceStatus{
+		Pressure:      s.Pressure,
+		Since:         s.Since,
+		HighWatermark: s.HighWatermark,
+		LowWatermark:  s.LowWatermark,
+		CheckedAt:     s.CheckedAt,
+		Volumes:       make([]httpapi.DiskUsage, 0, len(s.Volumes)),
+	}
+	for _, u := range s.Volumes {
+		res.Volumes = append(res.Volumes, httpapi.DiskUsage{
+			Name:        u.Name,
+			Path:        u.Path,
+			Total:       u.Total,
+			Used:        u.Used,
+			Free:        u.Free,
+			UsedPercent: u.UsedPercent,
+			Error:       u.Error,
+		})
+	}
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"math/big"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+)
+
+// DrillMissedProof simulates the consequences of missing the next proving
+// deadline of a data set. It only reads chain state and never sends
+// transactions.
+func (h *PaymentHandler) DrillMissedProof(ctx echo.Context) error {
+	reqCtx := ctx.Request().Context()
+
+	dataSetID, ok := new(big.Int).SetString(ctx.QueryParam("dataset"), 10)
+	if !ok {
+		return ctx.String(http.StatusBadRequest, "invalid data set ID")
+	}
+	if h.ethClient == nil {
+		return ctx.String(http.StatusServiceUnavailable, "eth client not available")
+	}
+	if h.serviceView == nil {
+		return ctx.String(http.StatusServiceUnavailable, "service view contract not available")
+	}
+
+	dataSet, err := h.serviceView.GetDataSet(reqCtx, dataSetID)
+	if err != nil {
+		return ctx.String(http.StatusNotFound, "getting data set: "+err.Error())
+	}
+	if dataSet.ServiceProvider != h.pdpConfig.OwnerAddress && dataSet.Payee != h.pdpConfig.OwnerAddress {
+		return ctx.String(http.StatusForbidden, "data set is not stored by this node")
+	}
+
+	pdpCfg, err := h.serviceView.PDPConfig(reqCtx)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "getting pdp config: "+err.Error())
+	}
+	windowStart, err := h.serviceView.NextPDPChallengeWindowStart(reqCtx, dataSetID)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "getting next challenge window: "+err.Error())
+	}
+
+	blockNum, err := h.ethClient.BlockNumber(reqCtx)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "getting current block: "+err.Error())
+	}
+	currentEpoch := new(big.Int).SetUint64(blockNum)
+
+	rail, err := h.payment.GetRail(reqCtx, dataSet.PdpRailId)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "getting rail: "+err.Error())
+	}
+	payerInfo, err := h.payment.Account(reqCtx, h.pdpConfig.Contracts.USDFCToken, rail.From)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, "getting payer account: "+err.Error())
+	}
+
+	// What the validator would pay today, accounting for past missed proofs
+	_, _, settleableEpochs, settleableAmount, _ := h.calculateSettlement(
+		rail, false, currentEpoch, payerInfo.LockupLastSettledAt,
+	)
+	settleableUntil := new(big.Int).Add(rail.SettledUpTo, settleableEpochs)
+	netSettleable := new(big.Int).Set(settleableAmount)
+	var notes []string
+	if h.serviceValidator != nil && settleableAmount.Sign() > 0 {
+		res, err := h.serviceValidator.ValidatePayment(reqCtx, rail.RailId, settleableAmount, rail.SettledUpTo, settleableUntil)
+		if err == nil && res != nil {
+			netSettleable = res.ModifiedAmount
+		} else {
+			notes = append(notes, "payment validator unavailable, past missed proofs are not accounted for")
+		}
+	}
+
+	sim := simulateMissedProof(missedProofParams{
+		currentEpoch:    currentEpoch,
+		windowStart:     windowStart,
+		challengeWindow: pdpCfg.ChallengeWindow,
+		provingPeriod:   new(big.Int).SetUint64(pdpCfg.MaxProvingPeriod),
+		paymentRate:     rail.PaymentRate,
+		settledUpTo:     rail.SettledUpTo,
+		settleableUntil: settleableUntil,
+		netSettleable:   netSettleable,
+	})
+	if rail.EndEpoch != nil && rail.EndEpoch.Sign() > 0 {
+		notes = append(notes, "rail is terminated, payment ends at epoch "+rail.EndEpoch.String())
+	}
+	if currentEpoch.Cmp(sim.deadline) > 0 {
+		notes = append(notes, "the deadline has already passed, the data set may already be faulted")
+	}
+
+	return ctx.JSON(http.StatusOK, &httpapi.DrillMissedProofResponse{
+		DryRun:               true,
+		DataSetID:            dataSetID.String(),
+		RailID:               rail.RailId.String(),
+		CurrentEpoch:         currentEpoch.String(),
+		ProvingPeriod:        new(big.Int).SetUint64(pdpCfg.MaxProvingPeriod).String(),
+		ChallengeWindowStart: windowStart.String(),
+		Deadline:             sim.deadline.String(),
+		Fault: httpapi.ProjectedFaultRecord{
+			DataSetID:      dataSetID.String(),
+			PeriodsFaulted: "1",
+			Deadline:       sim.deadline.String(),
+		},
+		PaymentRate:       rail.PaymentRate.String(),
+		PenaltyEpochs:     sim.penaltyEpochs.String(),
+		PenaltyAmount:     sim.penaltyAmount.String(),
+		GrossAmount:       sim.grossAmount.String(),
+		NetAmountIfProven: sim.netIfProven.String(),
+		NetAmountIfMissed: sim.netIfMissed.String(),
+		Notes:             notes,
+	})
+}
+
+type missedProofParams struct {
+	currentEpoch    *big.Int
+	windowStart     *big.Int // start of the challenge window of the next deadline
+	challengeWindow *big.Int
+	provingPeriod   *big.Int
+	paymentRate     *big.Int // per epoch
+	settledUpTo     *big.Int
+	settleableUntil *big.Int // epoch netSettleable was validated up to
+	netSettleable   *big.Int // validated payment for (settledUpTo, settleableUntil]
+}
+
+type missedProofSimulation struct {
+	deadline      *big.Int
+	penaltyEpochs *big.Int
+	penaltyAmount *big.Int
+	grossAmount   *big.Int
+	netIfProven   *big.Int
+	netIfMissed   *big.Int
+}
+
+// simulateMissedProof projects the payment settleable up to the next proving
+// deadline, with and without a proof for the period ending at the deadline.
+// The payment validator pays nothing for the epochs of an unproven period, so
+// a missed proof forfeits the unsettled part of that period. Net amounts are
+// after the network fee.
+func simulateMissedProof(p missedProofParams) missedProofSimulation {
+	deadline := new(big.Int).Add(p.windowStart, p.challengeWindow)
+
+	// epochs of the faulted period that have not been settled yet
+	periodStart := new(big.Int).Sub(deadline, p.provingPeriod)
+	if periodStart.Cmp(p.settledUpTo) < 0 {
+		periodStart = p.settledUpTo
+	}
+	penaltyEpochs := clampZero(new(big.Int).Sub(deadline, periodStart))
+	penaltyAmount := new(big.Int).Mul(penaltyEpochs, p.paymentRate)
+
+	grossEpochs := clampZero(new(big.Int).Sub(deadline, p.settledUpTo))
+	grossAmount := new(big.Int).Mul(grossEpochs, p.paymentRate)
+
+	// epochs after those already validated are assumed to be proven
+	remaining := clampZero(new(big.Int).Sub(deadline, p.settleableUntil))
+	ifProven := new(big.Int).Add(p.netSettleable, new(big.Int).Mul(remaining, p.paymentRate))
+	ifMissed := clampZero(new(big.Int).Sub(ifProven, penaltyAmount))
+
+	return missedProofSimulation{
+		deadline:      deadline,
+		penaltyEpochs: penaltyEpochs,
+		penaltyAmount: penaltyAmount,
+		grossAmount:   grossAmount,
+		netIfProven:   afterNetworkFee(ifProven),
+		netIfMissed:   afterNetworkFee(ifMissed),
+	}
+}
+
+// afterNetworkFee deducts the network fee of ceil(amount / 200), i.e. 0.5%.
+func afterNetworkFee(amount *big.Int) *big.Int {
+	if amount.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+	fee := new(big.Int).Add(amount, big.NewInt(199))
+	fee.Div(fee, big.NewInt(200))
+	return clampZero(new(big.Int).Sub(amount, fee))
+}
+
+func clampZero(v *big.Int) *big.Int {
+	if v.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return v
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateMissedProof(t *testing.T) {
+	params := func() missedProofParams {
+		return missedProofParams{
+			currentEpoch:    big.NewInt(1000),
+			windowStart:     big.NewInt(1100),
+			challengeWindow: big.NewInt(20),
+			provingPeriod:   big.NewInt(240),
+			paymentRate:     big.NewInt(1000),
+			settledUpTo:     big.NewInt(500),
+			settleableUntil: big.NewInt(1000),
+			netSettleable:   big.NewInt(500_000),
+		}
+	}
+
+	t.Run("forfeits the faulted period", func(t *testing.T) {
+		sim := simulateMissedProof(params())
+		require.Equal(t, int64(1120), sim.deadline.Int64())
+		require.Equal(t, int64(240), sim.penaltyEpochs.Int64())
+		require.Equal(t, int64(240_000), sim.penaltyAmount.Int64())
+		require.Equal(t, int64(620_000), sim.grossAmount.Int64())
+		// 500_000 validated + 120 epochs to the deadline, less the 0.5% network fee
+		require.Equal(t, int64(620_000-3_100), sim.netIfProven.Int64())
+		require.Equal(t, int64(380_000-1_900), sim.netIfMissed.Int64())
+	})
+
+	t.Run("settled epochs are not forfeited", func(t *testing.T) {
+		p := params()
+		p.settledUpTo = big.NewInt(1000)
+		p.netSettleable = big.NewInt(0)
+		sim := simulateMissedProof(p)
+		require.Equal(t, int64(120), sim.penaltyEpochs.Int64())
+		require.Equal(t, int64(0), sim.netIfMissed.Int64())
+	})
+
+	t.Run("past missed proofs reduce both outcomes", func(t *testing.T) {
+		p := params()
+		p.netSettleable = big.NewInt(100_000)
+		sim := simulateMissedProof(p)
+		require.Equal(t, int64(220_000-1_100), sim.netIfProven.Int64())
+		require.Equal(t, int64(0), sim.netIfMissed.Int64())
+	})
+}
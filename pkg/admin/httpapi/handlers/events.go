@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/pdp/chainevents"
+)
+
+// maxEventsLimit is the largest number of events returned by one request.
+const maxEventsLimit = 1000
+
+// EventHandler reports the contract events recorded by the chain event
+// indexer.
+type EventHandler struct {
+	indexer *chainevents.Indexer
+}
+
+// NewEventHandler creates a new EventHandler.
+func NewEventHandler(indexer *chainevents.Indexer) *EventHandler {
+	return &EventHandler{indexer: indexer}
+}
+
+// ListEvents returns the indexed events, most recent first.
+// GET /admin/events?data_set=<id>&name=<name,...>&before=<block>&limit=<n>
+func (h *EventHandler) ListEvents(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	var q chainevents.Query
+	if v := c.QueryParam("data_set"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid data set ID")
+		}
+		q.DataSetID = &id
+	}
+	if v := c.QueryParam("name"); v != "" {
+		q.Names = strings.Split(v, ",")
+	}
+	if v := c.QueryParam("before"); v != "" {
+		before, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid block number")
+		}
+		q.Before = before
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		q.Limit = min(limit, maxEventsLimit)
+	}
+
+	cursor, err := h.indexer.Cursor(reqCtx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	events, err := h.indexer.List(reqCtx, q)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := httpapi.ChainEventsResponse{
+		IndexedTo: cursor,
+		Events:    make([]httpapi.ChainEvent, 0, len(events)),
+	}
+	for _, e := range events {
+		res.Events = append(res.Events, httpapi.ChainEvent{
+			Name:        e.Name,
+			DataSetID:   uint64(e.DataSetID),
+			BlockNumber: e.BlockNumber,
+			TxHash:      e.TxHash,
+			LogIndex:    e.LogIndex,
+			Contract:    e.Contract,
+			Args:        json.RawMessage(e.Args),
+		})
+	}
+	return c.JSON(http.StatusOK, res)
+}
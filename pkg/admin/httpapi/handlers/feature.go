@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/config/dynamic"
+	"github.com/storacha/piri/pkg/config/feature"
+)
+
+// FeatureHandler handles requests to list and toggle feature flags.
+type FeatureHandler struct {
+	flags *feature.Flags
+}
+
+// NewFeatureHandler creates a new FeatureHandler.
+func NewFeatureHandler(flags *feature.Flags) *FeatureHandler {
+	return &FeatureHandler{flags: flags}
+}
+
+// ListFeatureFlags returns the state of all feature flags.
+// GET /admin/features
+func (h *FeatureHandler) ListFeatureFlags(c echo.Context) error {
+	res := httpapi.ListFeatureFlagsResponse{Flags: []httpapi.FeatureFlag{}}
+	for _, s := range h.flags.Status() {
+		res.Flags = append(res.Flags, toFeatureFlag(s))
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// SetFeatureFlag enables or disables a feature flag, or changes its rollout.
+// POST /admin/features/:name
+func (h *FeatureHandler) SetFeatureFlag(c echo.Context) error {
+	var req httpapi.SetFeatureFlagRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.Enabled == nil && req.Rollout == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "no changes provided")
+	}
+	s, err := h.flags.Set(c.Param("name"), req.Enabled, req.Rollout, req.Persist)
+	if err != nil {
+		return mapFeatureError(err)
+	}
+	return c.JSON(http.StatusOK, toFeatureFlag(s))
+}
+
+func toFeatureFlag(s feature.Status) httpapi.FeatureFlag {
+	return httpapi.FeatureFlag{
+		Name:        s.Name,
+		Description: s.Description,
+		Enabled:     s.Enabled,
+		Rollout:     s.Rollout,
+		Active:      s.Active,
+	}
+}
+
+func mapFeatureError(err error) error {
+	var persistErr *dynamic.PersistError
+	switch {
+	case errors.Is(err, feature.ErrUnknownFlag):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.As(err, &persistErr):
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	default:
+		// invalid rollouts, or rollouts of flags that do not support them
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+}
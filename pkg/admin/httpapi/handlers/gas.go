@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/pdp/gasoracle"
+)
+
+// GasHandler reports the base fee history recorded by the gas oracle.
+type GasHandler struct {
+	oracle *gasoracle.Oracle
+}
+
+// NewGasHandler creates a new GasHandler.
+func NewGasHandler(oracle *gasoracle.Oracle) *GasHandler {
+	return &GasHandler{oracle: oracle}
+}
+
+// GetEstimates returns base fee percentiles of the recent chain history.
+// GET /admin/gas/estimates
+func (h *GasHandler) GetEstimates(c echo.Context) error {
+	est := h.oracle.Estimates()
+	res := httpapi.GasEstimatesResponse{
+		Samples:     est.Samples,
+		Window:      est.Window,
+		Percentiles: make([]httpapi.GasPercentile, 0, len(est.Percentiles)),
+	}
+	if est.Latest != nil {
+		res.LatestEpoch = est.Latest.Epoch
+		res.LatestBaseFee = est.Latest.BaseFee.String()
+	}
+	for _, p := range gasoracle.EstimatePercentiles {
+		if fee, ok := est.Percentiles[p]; ok {
+			res.Percentiles = append(res.Percentiles, httpapi.GasPercentile{Percentile: p, BaseFee: fee.String()})
+		}
+	}
+	return c.JSON(http.StatusOK, res)
+}
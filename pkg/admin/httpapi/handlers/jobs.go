@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/lib/jobqueue"
+	"github.com/storacha/piri/lib/jobqueue/queue"
+	"github.com/storacha/piri/lib/jobqueue/worker"
+	"github.com/storacha/piri/pkg/admin/httpapi"
+)
+
+const maxJobsLimit = 1000
+
+// JobHandler handles requests to inspect, retry and cancel the jobs of the
+// node's job queues.
+type JobHandler struct {
+	queues map[string]jobqueue.Manager
+	names  []string
+}
+
+// NewJobHandler creates a new JobHandler managing the given queues.
+func NewJobHandler(queues ...jobqueue.Manager) *JobHandler {
+	h := &JobHandler{queues: make(map[string]jobqueue.Manager, len(queues))}
+	for _, q := range queues {
+		h.queues[q.Name()] = q
+		h.names = append(h.names, q.Name())
+	}
+	slices.Sort(h.names)
+	return h
+}
+
+// ListJobs returns the queued jobs, or the dead-lettered jobs, of one or all
+// queues, oldest first.
+// GET /admin/jobs?queue=<name>&dead=<bool>&limit=<n>
+func (h *JobHandler) ListJobs(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	var opts queue.ListOpts
+	if v := c.QueryParam("dead"); v != "" {
+		dead, err := strconv.ParseBool(v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid dead flag")
+		}
+		opts.Dead = dead
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		opts.Limit = min(limit, maxJobsLimit)
+	}
+	names := h.names
+	if name := c.QueryParam("queue"); name != "" {
+		if _, ok := h.queues[name]; !ok {
+			return echo.NewHTTPError(http.StatusNotFound, "unknown queue: "+name)
+		}
+		names = []string{name}
+	}
+
+	res := httpapi.ListJobsResponse{Jobs: []httpapi.Job{}}
+	for _, name := range names {
+		jobs, err := h.queues[name].ListJobs(reqCtx, opts)
+		if err != nil {
+			if errors.Is(err, worker.ErrUnmanaged) {
+				continue
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		for _, j := range jobs {
+			res.Jobs = append(res.Jobs, toJob(name, j))
+		}
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetJob returns a queued, running or dead-lettered job.
+// GET /admin/jobs/:queue/:id
+func (h *JobHandler) GetJob(c echo.Context) error {
+	q, err := h.queue(c)
+	if err != nil {
+		return err
+	}
+	j, err := q.GetJob(c.Request().Context(), queue.ID(c.Param("id")))
+	if err != nil {
+		return mapJobError(err)
+	}
+	return c.JSON(http.StatusOK, toJob(q.Name(), j))
+}
+
+// RetryJob moves a dead-lettered job back to its queue, to run again with a
+// fresh retry count.
+// POST /admin/jobs/:queue/:id/retry
+func (h *JobHandler) RetryJob(c echo.Context) error {
+	q, err := h.queue(c)
+	if err != nil {
+		return err
+	}
+	j, err := q.RetryJob(c.Request().Context(), queue.ID(c.Param("id")))
+	if err != nil {
+		return mapJobError(err)
+	}
+	return c.JSON(http.StatusOK, toJob(q.Name(), j))
+}
+
+// CancelJob removes a queued or dead-lettered job.
+// DELETE /admin/jobs/:queue/:id
+func (h *JobHandler) CancelJob(c echo.Context) error {
+	q, err := h.queue(c)
+	if err != nil {
+		return err
+	}
+	j, err := q.CancelJob(c.Request().Context(), queue.ID(c.Param("id")))
+	if err != nil {
+		return mapJobError(err)
+	}
+	return c.JSON(http.StatusOK, toJob(q.Name(), j))
+}
+
+func (h *JobHandler) queue(c echo.Context) (jobqueue.Manager, error) {
+	name := c.Param("queue")
+	q, ok := h.queues[name]
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "unknown queue: "+name)
+	}
+	return q, nil
+}
+
+func mapJobError(err error) error {
+	switch {
+	case errors.Is(err, queue.ErrJobNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, worker.ErrUnmanaged):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
+
+func toJob(queueName string, j queue.Job) httpapi.Job {
+	return httpapi.Job{
+		Queue:         queueName,
+		ID:            string(j.ID),
+		Name:          j.Name,
+		State:         jobState(j),
+		Attempts:      j.Received,
+		Created:       timeOrNil(j.Created),
+		AvailableAt:   timeOrNil(j.Available),
+		Dead:          j.Dead,
+		FailureReason: j.FailureReason,
+		Error:         j.Error,
+		MovedAt:       timeOrNil(j.MovedAt),
+	}
+}
+
+func jobState(j queue.Job) string {
+	switch {
+	case j.Dead:
+		return httpapi.JobDead
+	case j.Running:
+		return httpapi.JobRunning
+	case j.Received > 0:
+		return httpapi.JobRetrying
+	default:
+		return httpapi.JobQueued
+	}
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
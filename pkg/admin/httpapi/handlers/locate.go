@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/filecoin-project/go-data-segment/merkletree"
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/labstack/echo/v4"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-libstoracha/ipnipublisher/store"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	aggtypes "github.com/storacha/piri/pkg/pdp/aggregation/types"
+	"github.com/storacha/piri/pkg/pdp/piece"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/piecelog"
+)
+
+var errBlobNotFound = errors.New("blob not found")
+
+// LocateHandler handles requests to locate blobs in the proof system, from
+// their piece to the aggregates and data sets the piece is proven in.
+type LocateHandler struct {
+	db         *gorm.DB
+	chain      activePieces
+	resolver   types.PieceResolverAPI
+	aggregates aggtypes.Store
+	pieces     *piecelog.Log
+}
+
+// NewLocateHandler creates a new LocateHandler. Blobs cannot be located if
+// resolver is nil. If pieces is nil aggregates not yet submitted to a data set
+// are not reported.
+func NewLocateHandler(db *gorm.DB, chain activePieces, resolver types.PieceResolverAPI, aggregates aggtypes.Store, pieces *piecelog.Log) *LocateHandler {
+	return &LocateHandler{db: db, chain: chain, resolver: resolver, aggregates: aggregates, pieces: pieces}
+}
+
+// LocateBlob resolves a blob to its piece CID, the aggregates containing the
+// piece with its inclusion proof, and the data sets and on-chain piece IDs of
+// the aggregates.
+// GET /admin/locate/:digest
+func (h *LocateHandler) LocateBlob(ctx echo.Context) error {
+	digest, err := digestutil.Parse(ctx.Param("digest"))
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "invalid digest")
+	}
+	if h.resolver == nil {
+		return ctx.String(http.StatusServiceUnavailable, "piece resolver not available")
+	}
+
+	res, err := h.locate(ctx.Request().Context(), digest)
+	switch {
+	case errors.Is(err, errBlobNotFound):
+		return ctx.String(http.StatusNotFound, err.Error())
+	case err != nil:
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (h *LocateHandler) locate(ctx context.Context, digest multihash.Multihash) (*httpapi.LocateBlobResponse, error) {
+	pieceHash, ok, err := h.resolver.ResolveToPiece(ctx, digest)
+	if err != nil {
+		return nil, fmt.Errorf("resolving blob to piece: %w", err)
+	}
+	if !ok {
+		return nil, errBlobNotFound
+	}
+	pieceCID := piece.MultihashToCommpCID(pieceHash).String()
+	res := &httpapi.LocateBlobResponse{
+		Digest:    digestutil.Format(digest),
+		PieceCID:  pieceCID,
+		Locations: []httpapi.BlobLocation{},
+	}
+	active := &activeSet{chain: h.chain}
+	located := map[string]bool{}
+
+	var roots []models.PDPProofsetRoot
+	if err := h.db.WithContext(ctx).
+		Where("subroot = ?", pieceCID).
+		Order("proofset_id, root_id").
+		Find(&roots).Error; err != nil {
+		return nil, fmt.Errorf("finding roots of piece: %w", err)
+	}
+	for _, r := range roots {
+		loc, err := h.location(ctx, active, uint64(r.ProofsetID), &r.RootID, r.Root, pieceCID, r.SubrootOffset)
+		if err != nil {
+			return nil, err
+		}
+		res.Locations = append(res.Locations, loc)
+		located[r.Root] = true
+	}
+
+	var adds []models.PDPProofsetRootAdd
+	if err := h.db.WithContext(ctx).
+		Where("subroot = ?", pieceCID).
+		Order("proofset_id").
+		Find(&adds).Error; err != nil {
+		return nil, fmt.Errorf("finding pending adds of piece: %w", err)
+	}
+	for _, a := range adds {
+		loc, err := h.location(ctx, active, uint64(a.ProofsetID), nil, a.Root, pieceCID, a.SubrootOffset)
+		if err != nil {
+			return nil, err
+		}
+		res.Locations = append(res.Locations, loc)
+		located[a.Root] = true
+	}
+
+	// aggregates not yet submitted are only known from the piece log
+	state, ok, err := h.pieces.State(ctx, digest)
+	if err != nil {
+		return nil, fmt.Errorf("getting piece state: %w", err)
+	}
+	if ok && state.Kind == piecelog.Aggregated && state.Aggregate != "" && !located[state.Aggregate] {
+		proof, err := h.inclusionProof(ctx, state.Aggregate, pieceCID)
+		if err != nil {
+			return nil, err
+		}
+		res.Locations = append(res.Locations, httpapi.BlobLocation{
+			Status:         httpapi.LocationUnsubmitted,
+			Aggregate:      state.Aggregate,
+			InclusionProof: proof,
+		})
+	}
+	return res, nil
+}
+
+// location describes the piece at offset in the aggregate, added to a data
+// set as pieceID, or pending addition if pieceID is nil.
+func (h *LocateHandler) location(ctx context.Context, active *activeSet, dataSetID uint64, pieceID *int64, aggregate, pieceCID string, offset int64) (httpapi.BlobLocation, error) {
+	loc := httpapi.BlobLocation{
+		Status:    httpapi.LocationPending,
+		Aggregate: aggregate,
+		DataSetID: dataSetID,
+		Offset:    &offset,
+	}
+	if pieceID != nil {
+		id := uint64(*pieceID)
+		loc.PieceID = &id
+		live, err := active.has(ctx, dataSetID, id, aggregate)
+		if err != nil {
+			return loc, err
+		}
+		loc.Status = httpapi.LocationRemoved
+		if live {
+			loc.Status = httpapi.LocationLive
+		}
+	}
+	proof, err := h.inclusionProof(ctx, aggregate, pieceCID)
+	if err != nil {
+		return loc, err
+	}
+	loc.InclusionProof = proof
+	return loc, nil
+}
+
+// GetAggregate returns the pieces of an aggregate built by the node with
+// their inclusion proofs, and the data sets it was added to.
+// GET /admin/aggregates/:root
+func (h *LocateHandler) GetAggregate(ctx echo.Context) error {
+	root, err := cid.Decode(ctx.Param("root"))
+	if err != nil {
+		return ctx.String(http.StatusBadRequest, "invalid aggregate root CID")
+	}
+
+	reqCtx := ctx.Request().Context()
+	agg, ok, err := h.aggregate(reqCtx, root)
+	if err != nil {
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	if !ok {
+		return ctx.String(http.StatusNotFound, "aggregate not found")
+	}
+
+	res := &httpapi.AggregateResponse{
+		Root:     root.String(),
+		Pieces:   make([]httpapi.AggregatePiece, 0, len(agg.Pieces)),
+		DataSets: []httpapi.AggregatePlacement{},
+	}
+	for _, p := range agg.Pieces {
+		ap := httpapi.AggregatePiece{
+			PieceCID:       p.Link.Link().String(),
+			InclusionProof: toInclusionProof(p.InclusionProof),
+		}
+		if h.resolver != nil {
+			blob, found, err := h.resolver.ResolveToBlob(reqCtx, p.Link.Link().(cidlink.Link).Cid.Hash())
+			if err != nil {
+				return ctx.String(http.StatusInternalServerError, fmt.Sprintf("resolving piece %s to blob: %s", ap.PieceCID, err))
+			}
+			if found {
+				ap.Digest = digestutil.Format(blob)
+			}
+		}
+		res.Pieces = append(res.Pieces, ap)
+	}
+
+	// every root has a sub-piece at offset 0, so it lists each data set the
+	// aggregate was added to once
+	var roots []models.PDPProofsetRoot
+	if err := h.db.WithContext(reqCtx).
+		Where("root = ? AND subroot_offset = ?", root.String(), 0).
+		Order("proofset_id, root_id").
+		Find(&roots).Error; err != nil {
+		return ctx.String(http.StatusInternalServerError, fmt.Sprintf("finding data sets of aggregate: %s", err))
+	}
+	active := &activeSet{chain: h.chain}
+	for _, r := range roots {
+		id := uint64(r.RootID)
+		live, err := active.has(reqCtx, uint64(r.ProofsetID), id, r.Root)
+		if err != nil {
+			return ctx.String(http.StatusInternalServerError, err.Error())
+		}
+		placement := httpapi.AggregatePlacement{Status: httpapi.LocationRemoved, DataSetID: uint64(r.ProofsetID), PieceID: &id}
+		if live {
+			placement.Status = httpapi.LocationLive
+		}
+		res.DataSets = append(res.DataSets, placement)
+	}
+
+	var adds []models.PDPProofsetRootAdd
+	if err := h.db.WithContext(reqCtx).
+		Where("root = ? AND subroot_offset = ?", root.String(), 0).
+		Order("proofset_id").
+		Find(&adds).Error; err != nil {
+		return ctx.String(http.StatusInternalServerError, fmt.Sprintf("finding pending adds of aggregate: %s", err))
+	}
+	for _, a := range adds {
+		res.DataSets = append(res.DataSets, httpapi.AggregatePlacement{Status: httpapi.LocationPending, DataSetID: uint64(a.ProofsetID)})
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// aggregate reads an aggregate built by the node. It returns false if the
+// node did not build it.
+func (h *LocateHandler) aggregate(ctx context.Context, root cid.Cid) (aggtypes.Aggregate, bool, error) {
+	agg, err := h.aggregates.Get(ctx, cidlink.Link{Cid: root})
+	if err != nil {
+		if store.IsNotFound(err) {
+			return aggtypes.Aggregate{}, false, nil
+		}
+		return aggtypes.Aggregate{}, false, fmt.Errorf("reading aggregate %s: %w", root, err)
+	}
+	return agg, true, nil
+}
+
+// inclusionProof returns the proof of the piece in the aggregate, nil if the
+// node did not build the aggregate.
+func (h *LocateHandler) inclusionProof(ctx context.Context, aggregate, pieceCID string) (*httpapi.InclusionProof, error) {
+	root, err := cid.Decode(aggregate)
+	if err != nil {
+		return nil, fmt.Errorf("decoding aggregate root CID %s: %w", aggregate, err)
+	}
+	agg, ok, err := h.aggregate(ctx, root)
+	if err != nil || !ok {
+		return nil, err
+	}
+	for _, p := range agg.Pieces {
+		if p.Link.Link().String() == pieceCID {
+			proof := toInclusionProof(p.InclusionProof)
+			return &proof, nil
+		}
+	}
+	return nil, nil
+}
+
+func toInclusionProof(p merkletree.ProofData) httpapi.InclusionProof {
+	proof := httpapi.InclusionProof{Index: p.Index, Path: make([]string, 0, len(p.Path))}
+	for _, n := range p.Path {
+		proof.Path = append(proof.Path, hex.EncodeToString(n[:]))
+	}
+	return proof
+}
+
+// activeSet caches the pieces of data sets active on chain, so each data set
+// is read from the verifier contract once per request.
+type activeSet struct {
+	chain activePieces
+	sets  map[uint64]map[uint64]string
+}
+
+// has reports whether the piece with the ID and CID is active in the data
+// set.
+func (a *activeSet) has(ctx context.Context, dataSetID, pieceID uint64, pieceCID string) (bool, error) {
+	if a.sets == nil {
+		a.sets = map[uint64]map[uint64]string{}
+	}
+	pieces, ok := a.sets[dataSetID]
+	if !ok {
+		pieces = map[uint64]string{}
+		setID := new(big.Int).SetUint64(dataSetID)
+		limit := big.NewInt(activePiecesPageSize)
+		for offset := big.NewInt(0); ; offset = new(big.Int).Add(offset, limit) {
+			page, err := a.chain.GetActivePieces(ctx, setID, offset, limit)
+			if err != nil {
+				return false, fmt.Errorf("getting active pieces of data set %d at offset %s: %w", dataSetID, offset, err)
+			}
+			for i, c := range page.Pieces {
+				pieces[page.PieceIds[i].Uint64()] = c.String()
+			}
+			if !page.HasMore {
+				break
+			}
+		}
+		a.sets[dataSetID] = pieces
+	}
+	return pieces[pieceID] == pieceCID, nil
+}
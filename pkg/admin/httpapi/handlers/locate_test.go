@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/labstack/echo/v4"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-libstoracha/ipnipublisher/store"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	aggtypes "github.com/storacha/piri/pkg/pdp/aggregation/types"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+// mockResolver resolves blobs to the sub-pieces of the verify fixture.
+type mockResolver struct {
+	*mockPieceStore
+}
+
+func (m mockResolver) ResolveToPiece(_ context.Context, blob multihash.Multihash) (multihash.Multihash, bool, error) {
+	for piece, b := range m.blobs {
+		if string(b) == string(blob) {
+			return multihash.Multihash(piece), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// noAggregates is an aggregate store holding no aggregates, as on a node that
+// did not build them.
+type noAggregates struct{}
+
+func (noAggregates) Get(context.Context, datamodel.Link) (aggtypes.Aggregate, error) {
+	return aggtypes.Aggregate{}, store.NewErrNotFound(errors.New("no aggregates"))
+}
+
+func (noAggregates) Put(context.Context, datamodel.Link, aggtypes.Aggregate) error {
+	return errors.New("not implemented")
+}
+
+func TestLocateBlob(t *testing.T) {
+	f := newVerifyFixture(t)
+	live, liveBlobs := f.addPiece(t, 0, true, "a", "b")
+	removed, removedBlobs := f.addPiece(t, 1, true, "c")
+	// the piece was removed from the data set on chain
+	f.chain.pieces, f.chain.ids = f.chain.pieces[:1], f.chain.ids[:1]
+
+	h := NewLocateHandler(f.db, f.chain, mockResolver{f.pieces}, noAggregates{}, nil)
+	locate := func(t *testing.T, digest string) *httptest.ResponseRecorder {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		c.SetParamNames("digest")
+		c.SetParamValues(digest)
+		require.NoError(t, h.LocateBlob(c))
+		return rec
+	}
+
+	t.Run("live", func(t *testing.T) {
+		rec := locate(t, digestutil.Format(liveBlobs[1]))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res httpapi.LocateBlobResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Equal(t, testCID(t, "subpieceb").String(), res.PieceCID)
+		require.Len(t, res.Locations, 1)
+		loc := res.Locations[0]
+		require.Equal(t, httpapi.LocationLive, loc.Status)
+		require.Equal(t, live.String(), loc.Aggregate)
+		require.Equal(t, uint64(1), loc.DataSetID)
+		require.Equal(t, uint64(0), *loc.PieceID)
+		require.Equal(t, int64(128), *loc.Offset)
+		require.Nil(t, loc.InclusionProof)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		rec := locate(t, digestutil.Format(removedBlobs[0]))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res httpapi.LocateBlobResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Len(t, res.Locations, 1)
+		require.Equal(t, httpapi.LocationRemoved, res.Locations[0].Status)
+		require.Equal(t, removed.String(), res.Locations[0].Aggregate)
+	})
+
+	t.Run("pending", func(t *testing.T) {
+		pending := testCID(t, "piecepending")
+		subPiece := testCID(t, "subpiecepending")
+		digest, err := multihash.Sum([]byte("pending"), multihash.SHA2_256, -1)
+		require.NoError(t, err)
+		f.pieces.blobs[string(subPiece.Hash())] = digest
+		require.NoError(t, f.db.Create(&models.PDPProofsetRootAdd{
+			ProofsetID:     1,
+			AddMessageHash: "0x01",
+			Root:           pending.String(),
+			Subroot:        subPiece.String(),
+			SubrootSize:    128,
+		}).Error)
+
+		rec := locate(t, digestutil.Format(digest))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res httpapi.LocateBlobResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Len(t, res.Locations, 1)
+		require.Equal(t, httpapi.LocationPending, res.Locations[0].Status)
+		require.Equal(t, pending.String(), res.Locations[0].Aggregate)
+		require.Nil(t, res.Locations[0].PieceID)
+	})
+
+	t.Run("not aggregated", func(t *testing.T) {
+		subPiece := testCID(t, "subpiecelone")
+		digest, err := multihash.Sum([]byte("lone"), multihash.SHA2_256, -1)
+		require.NoError(t, err)
+		f.pieces.blobs[string(subPiece.Hash())] = digest
+
+		rec := locate(t, digestutil.Format(digest))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res httpapi.LocateBlobResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Empty(t, res.Locations)
+	})
+
+	t.Run("unknown blob", func(t *testing.T) {
+		digest, err := multihash.Sum([]byte("unknown"), multihash.SHA2_256, -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, locate(t, digestutil.Format(digest)).Code)
+		require.Equal(t, http.StatusBadRequest, locate(t, "nope").Code)
+	})
+}
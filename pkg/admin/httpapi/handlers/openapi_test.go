@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/openapi"
+)
+
+// TestOperationsDescribeRoutes checks the OpenAPI operations of the admin API
+// and the routes it registers do not drift apart.
+func TestOperationsDescribeRoutes(t *testing.T) {
+	passthrough := func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	routes := &AdminRoutes{
+		jwtMiddleware:  passthrough,
+		paymentHandler: &PaymentHandler{},
+		dataSetHandler: &DataSetHandler{},
+		locate:         &LocateHandler{},
+		backfill:       &BackfillHandler{},
+		prove:          &ProveHandler{},
+		proofHandler:   &ProofHandler{},
+		dlgHandler:     &DelegationHandler{},
+		proofSets:      &ProofSetHandler{},
+		curio:          &CurioHandler{},
+		republish:      &RepublishHandler{},
+		pieces:         &PieceHandler{},
+		quotas:         &QuotaHandler{},
+		replicaPolicy:  &ReplicaPolicyHandler{},
+		deadLetters:    &DeadLetterHandler{},
+		scrub:          &ScrubHandler{},
+		gas:            &GasHandler{},
+		events:         &EventHandler{},
+		webhooks:       &WebhookHandler{},
+		alerts:         &AlertHandler{},
+		storageClasses: &StorageClassHandler{},
+		billing:        &BillingHandler{},
+		usage:          &UsageHandler{},
+		overview:       &OverviewHandler{},
+		configHandler:  &ConfigHandler{},
+		subsysHandler:  &SubsystemHandler{},
+		features:       &FeatureHandler{},
+		jobs:           &JobHandler{},
+		signing:        &SigningHandler{},
+		readOnly:       &ReadOnlyHandler{},
+		diskSpace:      &DiskSpaceHandler{},
+		blocklist:      &BlocklistHandler{},
+	}
+	e := echo.New()
+	routes.RegisterRoutes(e)
+	registered := openapi.Routes(e)
+
+	spec := openapi.New("admin", "test", httpapi.Operations)
+	require.Empty(t, spec.Undescribed(registered), "routes without an operation")
+	require.Empty(t, openapi.Unregistered(httpapi.Operations, registered), "operations without a route")
+
+	doc := spec.Document(registered)
+	require.Len(t, doc.Paths, countPaths(httpapi.Operations))
+}
+
+func countPaths(ops []openapi.Operation) int {
+	paths := map[string]bool{}
+	for _, op := range ops {
+		paths[op.Path] = true
+	}
+	return len(paths)
+}
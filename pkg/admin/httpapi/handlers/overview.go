@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/build"
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/piecelog"
+	"github.com/storacha/piri/pkg/storageclass"
+	"github.com/storacha/piri/pkg/telemetry/errorlog"
+)
+
+// RecentErrors is the number of error logs returned in the overview.
+const RecentErrors = 20
+
+// OverviewHandler summarises the state of the node from the sources running
+// on it. Any of the sources may be nil.
+type OverviewHandler struct {
+	identity  app.IdentityConfig
+	server    app.ServerConfig
+	dataSets  *DataSetHandler
+	classes   *storageclass.Manager
+	pieces    *piecelog.Log
+	errorLogs *errorlog.Log
+}
+
+// NewOverviewHandler creates a new OverviewHandler.
+func NewOverviewHandler(identity app.IdentityConfig, server app.ServerConfig, dataSets *DataSetHandler, classes *storageclass.Manager, pieces *piecelog.Log, errorLogs *errorlog.Log) *OverviewHandler {
+	return &OverviewHandler{
+		identity:  identity,
+		server:    server,
+		dataSets:  dataSets,
+		classes:   classes,
+		pieces:    pieces,
+		errorLogs: errorLogs,
+	}
+}
+
+// GetOverview returns the identity, storage usage, proving deadlines, piece
+// states and recent errors of the node. A section that cannot be read is
+// left out with a note rather than failing the request.
+// GET /admin/overview
+func (h *OverviewHandler) GetOverview(c echo.Context) error {
+	ctx := c.Request().Context()
+	res := httpapi.OverviewResponse{Version: build.GetInfo()}
+	if h.identity.Signer != nil {
+		res.DID = h.identity.Signer.DID().String()
+	}
+	if h.server.PublicURL.Host != "" {
+		res.PublicURL = h.server.PublicURL.String()
+	}
+
+	if h.classes != nil {
+		classes, err := classUsage(ctx, h.classes)
+		if err != nil {
+			res.Notes = append(res.Notes, "storage usage unavailable: "+err.Error())
+		} else {
+			res.StorageClasses = classes
+		}
+	}
+
+	if h.dataSets != nil {
+		list, err := h.dataSets.listDataSets(ctx)
+		if err != nil {
+			res.Notes = append(res.Notes, "data sets unavailable: "+err.Error())
+		} else {
+			res.DataSets = overviewDataSets(list)
+		}
+	}
+
+	if h.pieces != nil {
+		counts, err := h.pieces.Counts(ctx)
+		if err != nil {
+			res.Notes = append(res.Notes, "piece states unavailable: "+err.Error())
+		} else {
+			states := httpapi.PieceStateCounts{Counts: map[string]int{}}
+			for _, k := range piecelog.Kinds {
+				states.Counts[string(k)] = counts[k]
+				states.Total += counts[k]
+			}
+			res.Pieces = &states
+		}
+	}
+
+	if h.errorLogs != nil {
+		for _, e := range h.errorLogs.Recent(RecentErrors) {
+			res.RecentErrors = append(res.RecentErrors, httpapi.ErrorLogEntry{
+				At:      e.At,
+				Level:   e.Level,
+				Logger:  e.Logger,
+				Message: e.Message,
+				Error:   e.Error,
+				Fields:  e.Fields,
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, &res)
+}
+
+// overviewDataSets counts the data sets in each proving state and orders the
+// deadlines of those initialized.
+func overviewDataSets(list *httpapi.ListDataSetsResponse) *httpapi.DataSetsOverview {
+	res := &httpapi.DataSetsOverview{
+		CurrentEpoch: list.CurrentEpoch,
+		Total:        len(list.DataSets),
+		Deadlines:    []httpapi.ProvingDeadline{},
+	}
+	for _, ds := range list.DataSets {
+		if !ds.Initialized {
+			continue
+		}
+		res.Initialized++
+		if ds.IsProving {
+			res.Proving++
+		}
+		if ds.ProvingDeadline > 0 {
+			res.Deadlines = append(res.Deadlines, httpapi.ProvingDeadline{
+				DataSetID:  ds.ID,
+				Deadline:   ds.ProvingDeadline,
+				EpochsLeft: ds.ProvingDeadline - int64(list.CurrentEpoch),
+			})
+		}
+	}
+	sort.Slice(res.Deadlines, func(i, j int) bool {
+		return res.Deadlines[i].Deadline < res.Deadlines[j].Deadline
+	})
+	return res
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/telemetry/errorlog"
+)
+
+func TestOverviewDataSets(t *testing.T) {
+	res := overviewDataSets(&httpapi.ListDataSetsResponse{
+		CurrentEpoch: 1000,
+		DataSets: []httpapi.DataSetSummary{
+			{ID: 1, Initialized: true, ProvingDeadline: 1500},
+			{ID: 2, Initialized: true, IsProving: true, ProvingDeadline: 990},
+			{ID: 3},
+		},
+	})
+	require.Equal(t, &httpapi.DataSetsOverview{
+		CurrentEpoch: 1000,
+		Total:        3,
+		Initialized:  2,
+		Proving:      1,
+		Deadlines: []httpapi.ProvingDeadline{
+			{DataSetID: 2, Deadline: 990, EpochsLeft: -10},
+			{DataSetID: 1, Deadline: 1500, EpochsLeft: 500},
+		},
+	}, res)
+}
+
+func TestGetOverview(t *testing.T) {
+	errorLogs := errorlog.New(10)
+	errorLogs.Add(errorlog.Entry{Logger: "pdp", Message: "proving failed", Error: "boom"})
+
+	h := NewOverviewHandler(app.IdentityConfig{Signer: testutil.Alice}, app.ServerConfig{}, nil, nil, nil, errorLogs)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/admin/overview", nil), rec)
+	require.NoError(t, h.GetOverview(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res httpapi.OverviewResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, testutil.Alice.DID().String(), res.DID)
+	require.Nil(t, res.DataSets)
+	require.Nil(t, res.Pieces)
+	require.Len(t, res.RecentErrors, 1)
+	require.Equal(t, "proving failed", res.RecentErrors[0].Message)
+	require.Equal(t, "boom", res.RecentErrors[0].Error)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/piecelog"
+	"github.com/storacha/piri/pkg/telemetry/latency"
+)
+
+// DefaultStuckAge is how long a piece must have been in its state to be
+// reported as stuck, if no age is requested.
+const DefaultStuckAge = 24 * time.Hour
+
+// PieceHandler handles requests for the status of the pieces stored on the
+// node.
+type PieceHandler struct {
+	latency *latency.Tracker
+	pieces  *piecelog.Log
+}
+
+// NewPieceHandler creates a new PieceHandler. Either of latency and pieces
+// may be nil.
+func NewPieceHandler(latency *latency.Tracker, pieces *piecelog.Log) *PieceHandler {
+	return &PieceHandler{latency: latency, pieces: pieces}
+}
+
+// GetPieceStatus returns the lifecycle of a blob, and the time its upload
+// spent in each stage of the pipeline if it was uploaded recently.
+// GET /admin/pieces/:digest/status
+func (h *PieceHandler) GetPieceStatus(c echo.Context) error {
+	digest, err := digestutil.Parse(c.Param("digest"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid digest")
+	}
+
+	res := httpapi.PieceStatus{Digest: digestutil.Format(digest)}
+	if b, ok := h.latency.Get(digest); ok {
+		res.Latency = &httpapi.UploadLatency{
+			Total:  b.Total(),
+			Stages: make([]httpapi.StageLatency, 0, len(b.Stages)),
+		}
+		for _, s := range b.Stages {
+			res.Latency.Stages = append(res.Latency.Stages, httpapi.StageLatency{
+				Stage:     string(s.Stage),
+				StartedAt: s.StartedAt,
+				Duration:  s.Duration,
+				Error:     s.Error,
+			})
+		}
+	}
+
+	ctx := c.Request().Context()
+	state, ok, err := h.pieces.State(ctx, digest)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if ok {
+		events, err := h.pieces.Events(ctx, digest)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		lc := toPieceLifecycle(state)
+		lc.Events = make([]httpapi.PieceEvent, 0, len(events))
+		for _, ev := range events {
+			lc.Events = append(lc.Events, httpapi.PieceEvent{
+				Seq:       ev.Seq,
+				Kind:      string(ev.Kind),
+				At:        ev.At,
+				Space:     ev.Space,
+				Piece:     ev.Piece,
+				Aggregate: ev.Aggregate,
+				ProofSet:  ev.ProofSet,
+				Error:     ev.Error,
+			})
+		}
+		res.Lifecycle = &lc
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetPieceStates returns the number of pieces in each lifecycle state.
+// GET /admin/pieces/states
+func (h *PieceHandler) GetPieceStates(c echo.Context) error {
+	counts, err := h.pieces.Counts(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.PieceStateCounts{Counts: map[string]int{}}
+	for _, k := range piecelog.Kinds {
+		res.Counts[string(k)] = counts[k]
+		res.Total += counts[k]
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// ListStuckPieces returns the pieces that have been in a state other than
+// proven or removed for longer than the older_than duration, 24h by default.
+// GET /admin/pieces/stuck
+func (h *PieceHandler) ListStuckPieces(c echo.Context) error {
+	age := DefaultStuckAge
+	if v := c.QueryParam("older_than"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid older_than duration")
+		}
+		age = d
+	}
+
+	stuck, err := h.pieces.Stuck(c.Request().Context(), age)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.StuckPiecesResponse{Pieces: make([]httpapi.PieceLifecycle, 0, len(stuck))}
+	for _, s := range stuck {
+		res.Pieces = append(res.Pieces, toPieceLifecycle(s))
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func toPieceLifecycle(s piecelog.State) httpapi.PieceLifecycle {
+	return httpapi.PieceLifecycle{
+		Digest:    digestutil.Format(s.Blob),
+		State:     string(s.Kind),
+		Since:     s.Since,
+		Space:     s.Space,
+		Piece:     s.Piece,
+		Aggregate: s.Aggregate,
+		ProofSet:  s.ProofSet,
+		Error:     s.Error,
+	}
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/pdp/chainevents"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+const (
+	// defaultRecentProofs is the number of proofs listed per data set when
+	// no limit is given.
+	defaultRecentProofs = 5
+	// maxRecentProofs is the largest number of proofs listed per data set.
+	maxRecentProofs = 100
+)
+
+// headReader reads the chain head. It is satisfied by the eth client.
+type headReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error)
+}
+
+// ProofHandler reports the proving history of the data sets and when their
+// next proofs are due.
+type ProofHandler struct {
+	db    *gorm.DB
+	chain headReader
+	now   func() time.Time
+}
+
+// NewProofHandler creates a new ProofHandler. Without an eth client, the
+// time until the next proofs are due is not estimated.
+func NewProofHandler(db *gorm.DB, ethClient *ethclient.Client) *ProofHandler {
+	h := &ProofHandler{db: db, now: time.Now}
+	if ethClient != nil {
+		h.chain = ethClient
+	}
+	return h
+}
+
+// ListProofs returns, for every data set, its last accepted proofs and an
+// estimate of when the next one is due.
+// GET /admin/proofs?limit=<n>
+func (h *ProofHandler) ListProofs(c echo.Context) error {
+	limit := defaultRecentProofs
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		limit = min(n, maxRecentProofs)
+	}
+	res, err := h.listProofs(c.Request().Context(), limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *ProofHandler) listProofs(ctx context.Context, limit int) (*httpapi.ListProofsResponse, error) {
+	res := &httpapi.ListProofsResponse{DataSets: []httpapi.DataSetProofs{}}
+
+	var head *ethtypes.Header
+	if h.chain == nil {
+		res.Notes = append(res.Notes, "eth client not available, due times are not estimated")
+	} else {
+		var err error
+		head, err = h.chain.HeaderByNumber(ctx, nil)
+		if err != nil {
+			res.Notes = append(res.Notes, "chain head unavailable, due times are not estimated: "+err.Error())
+		} else {
+			res.CurrentEpoch = head.Number.Uint64()
+			headTime := time.Unix(int64(head.Time), 0).UTC()
+			res.HeadTime = &headTime
+		}
+	}
+
+	db := h.db.WithContext(ctx)
+	var proofSets []models.PDPProofSet
+	if err := db.Order("id").Find(&proofSets).Error; err != nil {
+		return nil, fmt.Errorf("listing data sets: %w", err)
+	}
+
+	for _, ps := range proofSets {
+		var events []models.ChainEvent
+		if err := db.Where("data_set_id = ? AND name = ?", ps.ID, chainevents.PossessionProven).
+			Order("block_number DESC, log_index DESC").
+			Limit(max(limit, 1)).
+			Find(&events).Error; err != nil {
+			return nil, fmt.Errorf("listing proofs of data set %d: %w", ps.ID, err)
+		}
+
+		proofs := httpapi.DataSetProofs{
+			DataSetID:    uint64(ps.ID),
+			RecentProofs: []httpapi.ProofTx{},
+		}
+		if len(events) > 0 {
+			proofs.LastProvenEpoch = events[0].BlockNumber
+		}
+		if limit < len(events) {
+			events = events[:limit]
+		}
+		txs, err := h.proofTxs(ctx, events)
+		if err != nil {
+			return nil, err
+		}
+		proofs.RecentProofs = append(proofs.RecentProofs, txs...)
+
+		if ps.InitReady && ps.ProveAtEpoch != nil {
+			proofs.NextChallengeWindowStart = *ps.ProveAtEpoch
+			proofs.ProvingDeadline = *ps.ProveAtEpoch + int64OrZero(ps.ChallengeWindow)
+			if head != nil {
+				now := h.now()
+				proofs.NextChallengeAt, proofs.UntilChallenge = estimate(head, proofs.NextChallengeWindowStart, now)
+				proofs.DeadlineAt, proofs.UntilDeadline = estimate(head, proofs.ProvingDeadline, now)
+			}
+		}
+		res.DataSets = append(res.DataSets, proofs)
+	}
+	return res, nil
+}
+
+// proofTxs converts PossessionProven events to their transactions, with the
+// gas they spent when the node recorded their receipt.
+func (h *ProofHandler) proofTxs(ctx context.Context, events []models.ChainEvent) ([]httpapi.ProofTx, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	hashes := make([]string, len(events))
+	for i, e := range events {
+		hashes[i] = e.TxHash
+	}
+	// a proof replaced to bump its fee is waited on by its original hash
+	var waits []models.MessageWaitsEth
+	if err := h.db.WithContext(ctx).
+		Where("signed_tx_hash IN ? OR confirmed_tx_hash IN ?", hashes, hashes).
+		Find(&waits).Error; err != nil {
+		return nil, fmt.Errorf("getting proof receipts: %w", err)
+	}
+	receipts := make(map[string]*ethtypes.Receipt, len(waits))
+	for _, w := range waits {
+		if len(w.TxReceipt) == 0 {
+			continue
+		}
+		var receipt ethtypes.Receipt
+		if err := json.Unmarshal(w.TxReceipt, &receipt); err != nil {
+			log.Warnw("skipping undecodable proof receipt", "tx", w.SignedTxHash, "error", err)
+			continue
+		}
+		receipts[w.SignedTxHash] = &receipt
+		if w.ConfirmedTxHash != "" {
+			receipts[w.ConfirmedTxHash] = &receipt
+		}
+	}
+
+	txs := make([]httpapi.ProofTx, 0, len(events))
+	for _, e := range events {
+		tx := httpapi.ProofTx{TxHash: e.TxHash, BlockNumber: e.BlockNumber}
+		var args chainevents.PossessionProvenArgs
+		if err := json.Unmarshal(e.Args, &args); err == nil {
+			tx.Challenges = args.Challenges
+		}
+		if receipt, ok := receipts[e.TxHash]; ok {
+			tx.GasUsed = receipt.GasUsed
+			if receipt.EffectiveGasPrice != nil {
+				tx.GasCost = new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed)).String()
+			}
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// estimate returns when the epoch is reached, from the timestamp and height of
+// the head, and the time until then from now.
+func estimate(head *ethtypes.Header, epoch int64, now time.Time) (*time.Time, *time.Duration) {
+	at := time.Unix(int64(head.Time), 0).UTC().
+		Add(time.Duration(epoch-head.Number.Int64()) * builtin.EpochDurationSeconds * time.Second)
+	until := at.Sub(now)
+	return &at, &until
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/chainevents"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+type staticHead struct {
+	header *ethtypes.Header
+}
+
+func (s staticHead) HeaderByNumber(context.Context, *big.Int) (*ethtypes.Header, error) {
+	return s.header, nil
+}
+
+func TestListProofs(t *testing.T) {
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+
+	provingPeriod, challengeWindow, proveAt := int64(2880), int64(20), int64(1100)
+	require.NoError(t, db.Create(&models.PDPProofSet{
+		ID:                1,
+		InitReady:         true,
+		ProvingPeriod:     &provingPeriod,
+		ChallengeWindow:   &challengeWindow,
+		ProveAtEpoch:      &proveAt,
+		CreateMessageHash: "0x01",
+		Service:           "storacha",
+	}).Error)
+	require.NoError(t, db.Create(&models.PDPProofSet{
+		ID:                2,
+		CreateMessageHash: "0x02",
+		Service:           "storacha",
+	}).Error)
+
+	args, err := json.Marshal(chainevents.PossessionProvenArgs{Challenges: 5})
+	require.NoError(t, err)
+	for i, block := range []int64{900, 960} {
+		require.NoError(t, db.Create(&models.ChainEvent{
+			BlockNumber: block,
+			TxHash:      []string{"0xaa", "0xbb"}[i],
+			Contract:    "0x0c",
+			Name:        chainevents.PossessionProven,
+			DataSetID:   1,
+			Args:        args,
+		}).Error)
+	}
+	receipt, err := json.Marshal(&ethtypes.Receipt{
+		Status:            ethtypes.ReceiptStatusSuccessful,
+		GasUsed:           1000,
+		EffectiveGasPrice: big.NewInt(3),
+		Logs:              []*ethtypes.Log{},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.MessageWaitsEth{
+		SignedTxHash:    "0xbb",
+		ConfirmedTxHash: "0xbb",
+		TxReceipt:       receipt,
+	}).Error)
+
+	headTime := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	h := NewProofHandler(db, nil)
+	h.chain = staticHead{&ethtypes.Header{Number: big.NewInt(1000), Time: uint64(headTime.Unix())}}
+	// the head is a minute old
+	h.now = func() time.Time { return headTime.Add(time.Minute) }
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/admin/proofs", nil), rec)
+	require.NoError(t, h.ListProofs(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res httpapi.ListProofsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, uint64(1000), res.CurrentEpoch)
+	require.Len(t, res.DataSets, 2)
+
+	proving := res.DataSets[0]
+	require.Equal(t, int64(960), proving.LastProvenEpoch)
+	require.Equal(t, int64(1100), proving.NextChallengeWindowStart)
+	require.Equal(t, int64(1120), proving.ProvingDeadline)
+	require.Equal(t, headTime.Add(100*30*time.Second), proving.NextChallengeAt.UTC())
+	require.Equal(t, 50*time.Minute-time.Minute, *proving.UntilChallenge)
+	require.Equal(t, 60*time.Minute-time.Minute, *proving.UntilDeadline)
+	require.Equal(t, []httpapi.ProofTx{
+		{TxHash: "0xbb", BlockNumber: 960, Challenges: 5, GasUsed: 1000, GasCost: "3000"},
+		{TxHash: "0xaa", BlockNumber: 900, Challenges: 5},
+	}, proving.RecentProofs)
+
+	require.Equal(t, httpapi.DataSetProofs{DataSetID: 2, RecentProofs: []httpapi.ProofTx{}}, res.DataSets[1])
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/pdp/proofset"
+)
+
+// ProofSetHandler handles requests to manage the proof sets new aggregates
+// are added to.
+type ProofSetHandler struct {
+	registry *proofset.Registry
+}
+
+// NewProofSetHandler creates a new ProofSetHandler.
+func NewProofSetHandler(registry *proofset.Registry) *ProofSetHandler {
+	return &ProofSetHandler{registry: registry}
+}
+
+// ListProofSets returns the managed proof sets, including retired ones.
+// GET /admin/proofsets
+func (h *ProofSetHandler) ListProofSets(c echo.Context) error {
+	sets, err := h.registry.List(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.ListProofSetsResponse{ProofSets: make([]httpapi.ManagedProofSet, 0, len(sets))}
+	for _, ps := range sets {
+		res.ProofSets = append(res.ProofSets, toManagedProofSet(ps))
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// AddProofSet starts adding aggregates to a proof set created by the node,
+// or by Curio for the curio backend.
+// POST /admin/proofsets
+func (h *ProofSetHandler) AddProofSet(c echo.Context) error {
+	var req httpapi.AddProofSetRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.ID == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "proof set id is required")
+	}
+	ps, err := h.registry.Add(c.Request().Context(), proofset.ProofSet{
+		ID:               req.ID,
+		Class:            req.Class,
+		MinAggregateSize: req.MinAggregateSize,
+		MaxAggregateSize: req.MaxAggregateSize,
+		Backend:          req.Backend,
+	})
+	if err != nil {
+		if errors.Is(err, proofset.ErrUnknownProofSet) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toManagedProofSet(ps))
+}
+
+// RetireProofSet stops adding aggregates to a proof set. Its roots continue
+// to be proven.
+// POST /admin/proofsets/:id/retire
+func (h *ProofSetHandler) RetireProofSet(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid proof set id")
+	}
+	ps, err := h.registry.Retire(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, proofset.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, toManagedProofSet(ps))
+}
+
+func toManagedProofSet(ps proofset.ProofSet) httpapi.ManagedProofSet {
+	return httpapi.ManagedProofSet{
+		ID:               ps.ID,
+		Class:            ps.Class,
+		MinAggregateSize: ps.MinAggregateSize,
+		MaxAggregateSize: ps.MaxAggregateSize,
+		Backend:          ps.Backend,
+		Retired:          ps.Retired,
+		CreatedAt:        ps.CreatedAt,
+		RetiredAt:        ps.RetiredAt,
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/pdp/tasks"
+)
+
+// dryRunProver proves data sets without submitting the proofs.
+type dryRunProver interface {
+	DryRun(ctx context.Context, proofSetID int64) (*tasks.DryRunResult, error)
+}
+
+// ProveHandler handles requests to prove data sets.
+type ProveHandler struct {
+	prover dryRunProver
+}
+
+// NewProveHandler creates a new ProveHandler.
+func NewProveHandler(prover dryRunProver) *ProveHandler {
+	return &ProveHandler{prover: prover}
+}
+
+// DryRunProof proves the next challenge of a data set against the stored
+// pieces and reports whether the proof would succeed and how long it took to
+// generate. No transactions are sent.
+// GET /admin/datasets/:id/prove/dry-run
+func (h *ProveHandler) DryRunProof(ctx echo.Context) error {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id < 0 {
+		return ctx.String(http.StatusBadRequest, "invalid data set ID")
+	}
+
+	res, err := h.prover.DryRun(ctx.Request().Context(), id)
+	switch {
+	case errors.Is(err, tasks.ErrUnknownProofSet):
+		return ctx.String(http.StatusNotFound, err.Error())
+	case errors.Is(err, tasks.ErrNoChallenge):
+		return ctx.String(http.StatusConflict, err.Error())
+	case err != nil:
+		return ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	return ctx.JSON(http.StatusOK, dryRunResponse(res))
+}
+
+func dryRunResponse(res *tasks.DryRunResult) *httpapi.ProveDryRunResponse {
+	resp := &httpapi.ProveDryRunResponse{
+		DryRun:         true,
+		DataSetID:      uint64(res.ProofSetID),
+		ChallengeEpoch: res.ChallengeEpoch,
+		HeadEpoch:      res.HeadEpoch,
+		SeedEpoch:      res.SeedEpoch,
+		Simulated:      res.Simulated,
+		OK:             res.OK(),
+		Duration:       res.Duration,
+		Challenges:     make([]httpapi.ChallengeDryRun, 0, len(res.Challenges)),
+	}
+	for _, c := range res.Challenges {
+		ch := httpapi.ChallengeDryRun{PieceID: c.PieceID, Leaf: c.Leaf, Duration: c.Duration}
+		if c.Err != nil {
+			ch.Error = c.Err.Error()
+		}
+		resp.Challenges = append(resp.Challenges, ch)
+	}
+	return resp
+}
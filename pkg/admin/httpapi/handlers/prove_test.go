@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/pdp/tasks"
+)
+
+type dryRunProverFunc func(ctx context.Context, proofSetID int64) (*tasks.DryRunResult, error)
+
+func (f dryRunProverFunc) DryRun(ctx context.Context, proofSetID int64) (*tasks.DryRunResult, error) {
+	return f(ctx, proofSetID)
+}
+
+func TestDryRunProof(t *testing.T) {
+	dryRun := func(t *testing.T, h *ProveHandler, id string) *httptest.ResponseRecorder {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		require.NoError(t, h.DryRunProof(c))
+		return rec
+	}
+
+	h := NewProveHandler(dryRunProverFunc(func(_ context.Context, id int64) (*tasks.DryRunResult, error) {
+		switch id {
+		case 1:
+			return nil, fmt.Errorf("%w: %d", tasks.ErrUnknownProofSet, id)
+		case 2:
+			return nil, fmt.Errorf("%w for proof set %d", tasks.ErrNoChallenge, id)
+		}
+		return &tasks.DryRunResult{
+			ProofSetID:     id,
+			ChallengeEpoch: 1200,
+			HeadEpoch:      1100,
+			SeedEpoch:      1100,
+			Simulated:      true,
+			Duration:       3 * time.Second,
+			Challenges: []tasks.ChallengeResult{
+				{PieceID: 4, Leaf: 17, Duration: time.Second},
+				{PieceID: 9, Leaf: 3, Duration: 2 * time.Second, Err: errors.New("blob not found")},
+			},
+		}, nil
+	}))
+
+	rec := dryRun(t, h, "42")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var res httpapi.ProveDryRunResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, httpapi.ProveDryRunResponse{
+		DryRun:         true,
+		DataSetID:      42,
+		ChallengeEpoch: 1200,
+		HeadEpoch:      1100,
+		SeedEpoch:      1100,
+		Simulated:      true,
+		OK:             false,
+		Duration:       3 * time.Second,
+		Challenges: []httpapi.ChallengeDryRun{
+			{PieceID: 4, Leaf: 17, Duration: time.Second},
+			{PieceID: 9, Leaf: 3, Duration: 2 * time.Second, Error: "blob not found"},
+		},
+	}, res)
+
+	require.Equal(t, http.StatusNotFound, dryRun(t, h, "1").Code)
+	require.Equal(t, http.StatusConflict, dryRun(t, h, "2").Code)
+	require.Equal(t, http.StatusBadRequest, dryRun(t, h, "nope").Code)
+}
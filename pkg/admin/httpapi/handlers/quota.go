@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-ucanto/did"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/service/quota"
+)
+
+// QuotaHandler handles requests to manage the storage and egress quotas of
+// spaces.
+type QuotaHandler struct {
+	quotas *quota.Manager
+}
+
+// NewQuotaHandler creates a new QuotaHandler.
+func NewQuotaHandler(quotas *quota.Manager) *QuotaHandler {
+	return &QuotaHandler{quotas: quotas}
+}
+
+// ListQuotas returns the limits and usage of every space with limits.
+// GET /admin/quotas
+func (h *QuotaHandler) ListQuotas(c echo.Context) error {
+	ctx := c.Request().Context()
+	limits, err := h.quotas.ListLimits(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.ListQuotasResponse{Quotas: make([]httpapi.SpaceQuota, 0, len(limits))}
+	for space, l := range limits {
+		q, err := h.spaceQuota(ctx, space, l)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		res.Quotas = append(res.Quotas, q)
+	}
+	sort.Slice(res.Quotas, func(i, j int) bool { return res.Quotas[i].Space < res.Quotas[j].Space })
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetQuota returns the limits and usage of a space.
+// GET /admin/quotas/:space
+func (h *QuotaHandler) GetQuota(c echo.Context) error {
+	ctx := c.Request().Context()
+	space, err := did.Parse(c.Param("space"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid space DID")
+	}
+	limits, err := h.quotas.Limits(ctx, space)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	q, err := h.spaceQuota(ctx, space, limits)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, q)
+}
+
+// SetQuota sets the limits of a space. Zero limits remove them.
+// POST /admin/quotas/:space
+func (h *QuotaHandler) SetQuota(c echo.Context) error {
+	ctx := c.Request().Context()
+	space, err := did.Parse(c.Param("space"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid space DID")
+	}
+	var req httpapi.SetQuotaRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	limits := quota.Limits{Storage: req.Storage, Egress: req.Egress}
+	if err := h.quotas.SetLimits(ctx, space, limits); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	q, err := h.spaceQuota(ctx, space, limits)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, q)
+}
+
+func (h *QuotaHandler) spaceQuota(ctx context.Context, space did.DID, limits quota.Limits) (httpapi.SpaceQuota, error) {
+	usage, err := h.quotas.Usage(ctx, space)
+	if err != nil {
+		return httpapi.SpaceQuota{}, err
+	}
+	return httpapi.SpaceQuota{
+		Space:  space.String(),
+		Limits: httpapi.QuotaLimits{Storage: limits.Storage, Egress: limits.Egress},
+		Usage:  httpapi.QuotaUsage{Storage: usage.Storage, Egress: usage.Egress, Period: usage.Period},
+	}, nil
+}
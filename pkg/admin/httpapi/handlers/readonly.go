@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/readonly"
+)
+
+// ReadOnlyHandler handles requests to put the node in and out of read-only
+// mode.
+type ReadOnlyHandler struct {
+	mode *readonly.Mode
+}
+
+// NewReadOnlyHandler creates a new ReadOnlyHandler.
+func NewReadOnlyHandler(mode *readonly.Mode) *ReadOnlyHandler {
+	return &ReadOnlyHandler{mode: mode}
+}
+
+// GetReadOnly returns whether the node is read-only.
+// GET /admin/read-only
+func (h *ReadOnlyHandler) GetReadOnly(c echo.Context) error {
+	return c.JSON(http.StatusOK, toReadOnlyStatus(h.mode.Status()))
+}
+
+// EnableReadOnly puts the node in read-only mode until it is disabled, across
+// restarts.
+// POST /admin/read-only/enable
+func (h *ReadOnlyHandler) EnableReadOnly(c echo.Context) error {
+	var body httpapi.EnableReadOnlyRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	s, err := h.mode.Enable(body.Reason)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, toReadOnlyStatus(s))
+}
+
+// DisableReadOnly takes the node out of read-only mode, unless it is enabled
+// by configuration.
+// POST /admin/read-only/disable
+func (h *ReadOnlyHandler) DisableReadOnly(c echo.Context) error {
+	s, err := h.mode.Disable()
+	if err != nil {
+		if errors.Is(err, readonly.ErrForced) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, toReadOnlyStatus(s))
+}
+
+func toReadOnlyStatus(s readonly.Status) httpapi.ReadOnlyStatus {
+	return httpapi.ReadOnlyStatus{Enabled: s.Enabled, Forced: s.Forced, Reason: s.Reason, Since: s.Since}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/service/replicapolicy"
+)
+
+// ReplicaPolicyHandler handles requests to manage the policy deciding which
+// replica allocations the node accepts.
+type ReplicaPolicyHandler struct {
+	engine *replicapolicy.Engine
+}
+
+// NewReplicaPolicyHandler creates a new ReplicaPolicyHandler.
+func NewReplicaPolicyHandler(engine *replicapolicy.Engine) *ReplicaPolicyHandler {
+	return &ReplicaPolicyHandler{engine: engine}
+}
+
+// GetPolicy returns the replica policy in effect.
+// GET /admin/replication/policy
+func (h *ReplicaPolicyHandler) GetPolicy(c echo.Context) error {
+	return c.JSON(http.StatusOK, toReplicaPolicy(h.engine.Policy()))
+}
+
+// SetPolicy replaces the replica policy.
+// POST /admin/replication/policy
+func (h *ReplicaPolicyHandler) SetPolicy(c echo.Context) error {
+	var req httpapi.ReplicaPolicy
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	policy := replicapolicy.Policy{
+		MaxReplicaSize:  req.MaxReplicaSize,
+		AllowedSources:  req.AllowedSources,
+		ExcludedSources: req.ExcludedSources,
+		ExcludedHosts:   req.ExcludedHosts,
+		MinFreeCapacity: req.MinFreeCapacity,
+	}
+	if err := policy.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.engine.SetPolicy(c.Request().Context(), policy); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, toReplicaPolicy(policy))
+}
+
+func toReplicaPolicy(p replicapolicy.Policy) httpapi.ReplicaPolicy {
+	return httpapi.ReplicaPolicy{
+		MaxReplicaSize:  p.MaxReplicaSize,
+		AllowedSources:  p.AllowedSources,
+		ExcludedSources: p.ExcludedSources,
+		ExcludedHosts:   p.ExcludedHosts,
+		MinFreeCapacity: p.MinFreeCapacity,
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/service/republisher"
+)
+
+// RepublishHandler reports the republication of location claims following a
+// change of the node's public URL.
+type RepublishHandler struct {
+	republisher *republisher.Service
+}
+
+// NewRepublishHandler creates a new RepublishHandler.
+func NewRepublishHandler(republisher *republisher.Service) *RepublishHandler {
+	return &RepublishHandler{republisher: republisher}
+}
+
+// GetStatus returns the progress of the last republication.
+// GET /admin/republish
+func (h *RepublishHandler) GetStatus(c echo.Context) error {
+	res := httpapi.RepublishStatus{PublicURL: h.republisher.PublicURL()}
+	if p := h.republisher.Progress(); p != nil {
+		res.Progress = &httpapi.RepublishProgress{
+			From:        p.From,
+			To:          p.To,
+			StartedAt:   p.StartedAt,
+			CompletedAt: p.CompletedAt,
+			Republished: p.Republished,
+			Failed:      p.Failed,
+			LastError:   p.LastError,
+		}
+	}
+	return c.JSON(http.StatusOK, res)
+}
@@ -9,25 +9,126 @@ import (
 	"github.com/labstack/echo/v4"
 	"go.uber.org/fx"
 
+	"github.com/storacha/piri/lib/jobqueue"
 	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/blocklist"
 	"github.com/storacha/piri/pkg/config/app"
 	"github.com/storacha/piri/pkg/config/dynamic"
+	"github.com/storacha/piri/pkg/config/feature"
+	"github.com/storacha/piri/pkg/diskspace"
 	echofx "github.com/storacha/piri/pkg/fx/echo"
+	"github.com/storacha/piri/pkg/pdp/alerting"
+	"github.com/storacha/piri/pkg/pdp/backfill"
+	"github.com/storacha/piri/pkg/pdp/chainevents"
+	"github.com/storacha/piri/pkg/pdp/curio"
+	"github.com/storacha/piri/pkg/pdp/gasoracle"
+	"github.com/storacha/piri/pkg/pdp/proofset"
+	"github.com/storacha/piri/pkg/pdp/scheduler"
+	"github.com/storacha/piri/pkg/pdp/tasks"
+	"github.com/storacha/piri/pkg/piecelog"
+	"github.com/storacha/piri/pkg/readonly"
+	"github.com/storacha/piri/pkg/service/billing"
+	"github.com/storacha/piri/pkg/service/quota"
+	"github.com/storacha/piri/pkg/service/replicapolicy"
+	"github.com/storacha/piri/pkg/service/replicator"
+	"github.com/storacha/piri/pkg/service/republisher"
+	"github.com/storacha/piri/pkg/service/scrubber"
+	"github.com/storacha/piri/pkg/service/signer/approval"
+	"github.com/storacha/piri/pkg/service/usage"
+	"github.com/storacha/piri/pkg/storageclass"
+	"github.com/storacha/piri/pkg/subsystem"
+	"github.com/storacha/piri/pkg/telemetry/errorlog"
+	"github.com/storacha/piri/pkg/telemetry/latency"
+	"github.com/storacha/piri/pkg/webhook"
 )
 
 type AdminRoutes struct {
 	jwtMiddleware  echo.MiddlewareFunc
 	paymentHandler *PaymentHandler
+	dataSetHandler *DataSetHandler
+	locate         *LocateHandler
+	backfill       *BackfillHandler
+	prove          *ProveHandler
+	proofHandler   *ProofHandler
+	dlgHandler     *DelegationHandler
+	proofSets      *ProofSetHandler
+	curio          *CurioHandler
+	republish      *RepublishHandler
+	pieces         *PieceHandler
+	quotas         *QuotaHandler
+	replicaPolicy  *ReplicaPolicyHandler
+	deadLetters    *DeadLetterHandler
+	scrub          *ScrubHandler
+	gas            *GasHandler
+	events         *EventHandler
+	webhooks       *WebhookHandler
+	alerts         *AlertHandler
+	storageClasses *StorageClassHandler
+	billing        *BillingHandler
+	usage          *UsageHandler
+	overview       *OverviewHandler
 	configHandler  *ConfigHandler
+	subsysHandler  *SubsystemHandler
+	features       *FeatureHandler
+	jobs           *JobHandler
+	signing        *SigningHandler
+	readOnly       *ReadOnlyHandler
+	diskSpace      *DiskSpaceHandler
+	blocklist      *BlocklistHandler
 }
 
 type AdminRoutesParams struct {
 	fx.In
 
 	Identity       app.IdentityConfig
-	PaymentHandler *PaymentHandler `optional:"true"`
+	Server         app.ServerConfig      `optional:"true"`
+	PaymentHandler *PaymentHandler       `optional:"true"`
+	DataSetHandler *DataSetHandler       `optional:"true"`
+	LocateHandler  *LocateHandler        `optional:"true"`
+	ProofHandler   *ProofHandler         `optional:"true"`
+	DlgHandler     *DelegationHandler    `optional:"true"`
+	ProofSets      *proofset.Registry    `optional:"true"`
+	Curio          *curio.Backend        `optional:"true"`
+	Republisher    *republisher.Service  `optional:"true"`
+	Latency        *latency.Tracker      `optional:"true"`
+	PieceLog       *piecelog.Log         `optional:"true"`
+	Quotas         *quota.Manager        `optional:"true"`
+	ReplicaPolicy  *replicapolicy.Engine `optional:"true"`
+	Replicator     *replicator.Service   `optional:"true"`
+	Scrubber       *scrubber.Service     `optional:"true"`
+	GasOracle      *gasoracle.Oracle     `optional:"true"`
+	Events         *chainevents.Indexer  `optional:"true"`
+	Webhooks       *webhook.Service      `optional:"true"`
+	Alerter        *alerting.Alerter     `optional:"true"`
+	StorageClasses *storageclass.Manager `optional:"true"`
+	Billing        *billing.Service      `optional:"true"`
+	Usage          *usage.Tracker        `optional:"true"`
+	ErrorLog       *errorlog.Log         `optional:"true"`
 	Registry       *dynamic.Registry
 	Bridge         *dynamic.ViperBridge
+	Subsystems     *subsystem.Registry `optional:"true"`
+	Features       *feature.Flags      `optional:"true"`
+	// Queues are the job queues reported by diagnostics, those implementing
+	// jobqueue.Manager have their jobs managed through the admin API.
+	Queues []jobqueue.Snapshotter `group:"diagnostics_queues"`
+	// Tasks is the PDP task engine, its tasks are listed as a read-only queue.
+	Tasks *scheduler.TaskEngine `optional:"true"`
+	// ProveTask proves data sets without submitting the proofs for dry runs.
+	ProveTask *tasks.ProveTask `optional:"true"`
+	// Backfill registers blobs stored before the node proved its content
+	// with PDP.
+	Backfill *backfill.Service `optional:"true"`
+	// SignRequests holds sign requests for approval, nil if approval is
+	// disabled.
+	SignRequests *approval.Queue `optional:"true"`
+	ReadOnly     *readonly.Mode  `optional:"true"`
+	// DiskSpace monitors the disk usage of the node, nil if disk space is not
+	// monitored.
+	DiskSpace *diskspace.Monitor `optional:"true"`
+	Blocklist *blocklist.List    `optional:"true"`
+	// BlocklistSyncer syncs the remote denylist, nil if none is configured.
+	BlocklistSyncer *blocklist.Syncer `optional:"true"`
+	Purger          *blocklist.Purger `optional:"true"`
 }
 
 func NewRoutes(params AdminRoutesParams) (echofx.RouteRegistrar, error) {
@@ -45,16 +146,154 @@ func NewRoutes(params AdminRoutesParams) (echofx.RouteRegistrar, error) {
 		configHandler = NewConfigHandler(params.Registry, params.Bridge)
 
 	}
+	var subsysHandler *SubsystemHandler
+	if params.Subsystems != nil {
+		subsysHandler = NewSubsystemHandler(params.Subsystems)
+	}
+	var featureHandler *FeatureHandler
+	if params.Features != nil {
+		featureHandler = NewFeatureHandler(params.Features)
+	}
+	var jobHandler *JobHandler
+	var managers []jobqueue.Manager
+	for _, q := range params.Queues {
+		if m, ok := q.(jobqueue.Manager); ok {
+			managers = append(managers, m)
+		}
+	}
+	if params.Tasks != nil {
+		managers = append(managers, scheduler.NewTaskJobs(params.Tasks))
+	}
+	if len(managers) > 0 {
+		jobHandler = NewJobHandler(managers...)
+	}
+	var proofSetHandler *ProofSetHandler
+	if params.ProofSets != nil {
+		proofSetHandler = NewProofSetHandler(params.ProofSets)
+	}
+	var curioHandler *CurioHandler
+	if params.Curio != nil {
+		curioHandler = NewCurioHandler(params.Curio)
+	}
+	var republishHandler *RepublishHandler
+	if params.Republisher != nil {
+		republishHandler = NewRepublishHandler(params.Republisher)
+	}
+	var pieceHandler *PieceHandler
+	if params.Latency != nil || params.PieceLog != nil {
+		pieceHandler = NewPieceHandler(params.Latency, params.PieceLog)
+	}
+	var quotaHandler *QuotaHandler
+	if params.Quotas != nil {
+		quotaHandler = NewQuotaHandler(params.Quotas)
+	}
+	var replicaPolicyHandler *ReplicaPolicyHandler
+	if params.ReplicaPolicy != nil {
+		replicaPolicyHandler = NewReplicaPolicyHandler(params.ReplicaPolicy)
+	}
+	var deadLetterHandler *DeadLetterHandler
+	if params.Replicator != nil {
+		deadLetterHandler = NewDeadLetterHandler(params.Replicator)
+	}
+	var scrubHandler *ScrubHandler
+	if params.Scrubber != nil {
+		scrubHandler = NewScrubHandler(params.Scrubber)
+	}
+	var gasHandler *GasHandler
+	if params.GasOracle != nil {
+		gasHandler = NewGasHandler(params.GasOracle)
+	}
+	var eventHandler *EventHandler
+	if params.Events != nil {
+		eventHandler = NewEventHandler(params.Events)
+	}
+	var webhookHandler *WebhookHandler
+	if params.Webhooks != nil {
+		webhookHandler = NewWebhookHandler(params.Webhooks)
+	}
+	var alertHandler *AlertHandler
+	if params.Alerter != nil {
+		alertHandler = NewAlertHandler(params.Alerter)
+	}
+	var storageClassHandler *StorageClassHandler
+	if params.StorageClasses != nil {
+		storageClassHandler = NewStorageClassHandler(params.StorageClasses)
+	}
+	var billingHandler *BillingHandler
+	if params.Billing != nil {
+		billingHandler = NewBillingHandler(params.Billing)
+	}
+	var usageHandler *UsageHandler
+	if params.Usage != nil {
+		usageHandler = NewUsageHandler(params.Usage)
+	}
+	var signingHandler *SigningHandler
+	if params.SignRequests != nil {
+		signingHandler = NewSigningHandler(params.SignRequests)
+	}
+	var readOnlyHandler *ReadOnlyHandler
+	if params.ReadOnly != nil {
+		readOnlyHandler = NewReadOnlyHandler(params.ReadOnly)
+	}
+	var diskSpaceHandler *DiskSpaceHandler
+	if params.DiskSpace != nil {
+		diskSpaceHandler = NewDiskSpaceHandler(params.DiskSpace)
+	}
+	var proveHandler *ProveHandler
+	if params.ProveTask != nil {
+		proveHandler = NewProveHandler(params.ProveTask)
+	}
+	var backfillHandler *BackfillHandler
+	if params.Backfill != nil {
+		backfillHandler = NewBackfillHandler(params.Backfill)
+	}
+	var blocklistHandler *BlocklistHandler
+	if params.Blocklist != nil {
+		blocklistHandler = NewBlocklistHandler(params.Blocklist, params.BlocklistSyncer, params.Purger)
+	}
+	overviewHandler := NewOverviewHandler(params.Identity, params.Server, params.DataSetHandler, params.StorageClasses, params.PieceLog, params.ErrorLog)
 	return &AdminRoutes{
 		jwtMiddleware:  jwtMiddleware,
 		paymentHandler: params.PaymentHandler,
+		dataSetHandler: params.DataSetHandler,
+		locate:         params.LocateHandler,
+		backfill:       backfillHandler,
+		prove:          proveHandler,
+		proofHandler:   params.ProofHandler,
+		dlgHandler:     params.DlgHandler,
+		proofSets:      proofSetHandler,
+		curio:          curioHandler,
+		republish:      republishHandler,
+		pieces:         pieceHandler,
+		quotas:         quotaHandler,
+		replicaPolicy:  replicaPolicyHandler,
+		deadLetters:    deadLetterHandler,
+		scrub:          scrubHandler,
+		gas:            gasHandler,
+		events:         eventHandler,
+		webhooks:       webhookHandler,
+		alerts:         alertHandler,
+		storageClasses: storageClassHandler,
+		billing:        billingHandler,
+		usage:          usageHandler,
+		overview:       overviewHandler,
 		configHandler:  configHandler,
+		subsysHandler:  subsysHandler,
+		features:       featureHandler,
+		jobs:           jobHandler,
+		signing:        signingHandler,
+		readOnly:       readOnlyHandler,
+		diskSpace:      diskSpaceHandler,
+		blocklist:      blocklistHandler,
 	}, nil
 }
 
 func (a *AdminRoutes) RegisterRoutes(e *echo.Echo) {
 	adminGroup := e.Group(httpapi.AdminRoutePath, a.jwtMiddleware)
 
+	adminGroup.GET(httpapi.VersionRoutePath, getVersion)
+	adminGroup.GET(httpapi.OverviewRoutePath, a.overview.GetOverview)
+
 	// Log routes
 	logGroup := adminGroup.Group(httpapi.LogRoutePath)
 	logGroup.GET("/list", listLogLevels)
@@ -70,6 +309,135 @@ func (a *AdminRoutes) RegisterRoutes(e *echo.Echo) {
 		paymentGroup.POST("/withdraw/estimate", a.paymentHandler.EstimateWithdraw)
 		paymentGroup.POST("/withdraw", a.paymentHandler.Withdraw)
 		paymentGroup.GET("/withdraw/status", a.paymentHandler.GetWithdrawalStatus)
+
+		drillGroup := adminGroup.Group(httpapi.DrillRoutePath)
+		drillGroup.GET(httpapi.MissedProofRoutePath, a.paymentHandler.DrillMissedProof)
+	}
+
+	if a.dataSetHandler != nil {
+		dataSetGroup := adminGroup.Group(httpapi.DataSetsRoutePath)
+		dataSetGroup.GET("", a.dataSetHandler.ListDataSets)
+		dataSetGroup.GET("/:id", a.dataSetHandler.GetDataSet)
+		dataSetGroup.GET("/:id"+httpapi.VerifyRoutePath, a.dataSetHandler.VerifyDataSet)
+	}
+
+	if a.locate != nil {
+		adminGroup.GET(httpapi.LocateRoutePath+"/:digest", a.locate.LocateBlob)
+		adminGroup.GET(httpapi.AggregatesRoutePath+"/:root", a.locate.GetAggregate)
+	}
+
+	if a.backfill != nil {
+		backfillGroup := adminGroup.Group(httpapi.BackfillRoutePath)
+		backfillGroup.GET("", a.backfill.GetBackfill)
+		backfillGroup.POST("", a.backfill.StartBackfill)
+		backfillGroup.POST(httpapi.CancelRoutePath, a.backfill.CancelBackfill)
+	}
+
+	if a.prove != nil {
+		adminGroup.GET(httpapi.DataSetsRoutePath+"/:id"+httpapi.ProveRoutePath+httpapi.DryRunRoutePath, a.prove.DryRunProof)
+	}
+
+	if a.proofHandler != nil {
+		adminGroup.GET(httpapi.ProofsRoutePath, a.proofHandler.ListProofs)
+	}
+
+	if a.dlgHandler != nil {
+		dlgGroup := adminGroup.Group(httpapi.DelegationsRoutePath)
+		dlgGroup.GET("", a.dlgHandler.ListDelegations)
+		dlgGroup.POST("", a.dlgHandler.ImportDelegations)
+		dlgGroup.GET("/:cid", a.dlgHandler.GetDelegation)
+		dlgGroup.DELETE("/:cid", a.dlgHandler.RemoveDelegation)
+	}
+
+	if a.proofSets != nil {
+		proofSetGroup := adminGroup.Group(httpapi.ProofSetsRoutePath)
+		proofSetGroup.GET("", a.proofSets.ListProofSets)
+		proofSetGroup.POST("", a.proofSets.AddProofSet)
+		proofSetGroup.POST("/:id"+httpapi.RetireRoutePath, a.proofSets.RetireProofSet)
+	}
+
+	if a.curio != nil {
+		curioGroup := adminGroup.Group(httpapi.CurioRoutePath)
+		curioGroup.POST(httpapi.ReconcileRoutePath, a.curio.Reconcile)
+	}
+
+	if a.republish != nil {
+		adminGroup.GET(httpapi.RepublishRoutePath, a.republish.GetStatus)
+	}
+
+	if a.pieces != nil {
+		pieceGroup := adminGroup.Group(httpapi.PiecesRoutePath)
+		pieceGroup.GET(httpapi.StatesRoutePath, a.pieces.GetPieceStates)
+		pieceGroup.GET(httpapi.StuckRoutePath, a.pieces.ListStuckPieces)
+		pieceGroup.GET("/:digest"+httpapi.StatusRoutePath, a.pieces.GetPieceStatus)
+	}
+
+	if a.quotas != nil {
+		quotaGroup := adminGroup.Group(httpapi.QuotasRoutePath)
+		quotaGroup.GET("", a.quotas.ListQuotas)
+		quotaGroup.GET("/:space", a.quotas.GetQuota)
+		quotaGroup.POST("/:space", a.quotas.SetQuota)
+	}
+
+	replicationGroup := adminGroup.Group(httpapi.ReplicationRoutePath)
+	if a.replicaPolicy != nil {
+		replicationGroup.GET(httpapi.PolicyRoutePath, a.replicaPolicy.GetPolicy)
+		replicationGroup.POST(httpapi.PolicyRoutePath, a.replicaPolicy.SetPolicy)
+	}
+	if a.deadLetters != nil {
+		deadLetterGroup := replicationGroup.Group(httpapi.DeadLettersRoutePath)
+		deadLetterGroup.GET("", a.deadLetters.ListDeadLetters)
+		deadLetterGroup.POST("/:id"+httpapi.RequeueRoutePath, a.deadLetters.RequeueDeadLetter)
+		deadLetterGroup.DELETE("/:id", a.deadLetters.DiscardDeadLetter)
+	}
+
+	if a.scrub != nil {
+		scrubGroup := adminGroup.Group(httpapi.ScrubRoutePath)
+		scrubGroup.GET(httpapi.CorruptRoutePath, a.scrub.ListCorruptBlobs)
+	}
+
+	if a.gas != nil {
+		gasGroup := adminGroup.Group(httpapi.GasRoutePath)
+		gasGroup.GET(httpapi.EstimatesRoutePath, a.gas.GetEstimates)
+	}
+
+	if a.events != nil {
+		adminGroup.GET(httpapi.EventsRoutePath, a.events.ListEvents)
+	}
+
+	if a.webhooks != nil {
+		webhookGroup := adminGroup.Group(httpapi.WebhooksRoutePath)
+		webhookGroup.GET("", a.webhooks.ListWebhooks)
+		webhookGroup.POST("", a.webhooks.AddWebhook)
+		webhookGroup.DELETE("/:id", a.webhooks.RemoveWebhook)
+	}
+
+	if a.alerts != nil {
+		alertGroup := adminGroup.Group(httpapi.AlertsRoutePath)
+		alertGroup.GET("", a.alerts.ListAlerts)
+		alertGroup.GET(httpapi.RulesRoutePath, a.alerts.GetRules)
+		alertGroup.POST(httpapi.RulesRoutePath, a.alerts.SetRules)
+		alertGroup.DELETE(httpapi.RulesRoutePath, a.alerts.ResetRules)
+	}
+
+	if a.storageClasses != nil {
+		storageClassGroup := adminGroup.Group(httpapi.StorageClassesRoutePath)
+		storageClassGroup.GET("", a.storageClasses.ListStorageClasses)
+		storageClassGroup.POST(httpapi.SpacesRoutePath+"/:space", a.storageClasses.SetSpaceStorageClass)
+	}
+
+	if a.billing != nil {
+		billingGroup := adminGroup.Group(httpapi.BillingRoutePath)
+		billingGroup.GET(httpapi.PlansRoutePath, a.billing.ListRatePlans)
+		billingGroup.POST(httpapi.SpacesRoutePath+"/:space", a.billing.SetSpaceRatePlan)
+		billingGroup.GET(httpapi.InvoicesRoutePath+"/:period", a.billing.GetInvoice)
+		billingGroup.POST(httpapi.InvoicesRoutePath+"/:period"+httpapi.PushRoutePath, a.billing.PushInvoice)
+	}
+
+	if a.usage != nil {
+		usageGroup := adminGroup.Group(httpapi.UsageRoutePath)
+		usageGroup.GET("", a.usage.ListSpaceUsage)
+		usageGroup.GET("/:space", a.usage.GetUsageReport)
 	}
 
 	// Config routes (only if dynamic config is enabled)
@@ -79,4 +447,52 @@ func (a *AdminRoutes) RegisterRoutes(e *echo.Echo) {
 		configGroup.PATCH("", a.configHandler.UpdateConfig)
 		configGroup.POST(httpapi.ConfigReloadRoutePath, a.configHandler.ReloadConfig)
 	}
+
+	if a.subsysHandler != nil {
+		subsystemGroup := adminGroup.Group(httpapi.SubsystemsRoutePath)
+		subsystemGroup.GET("", a.subsysHandler.ListSubsystems)
+		subsystemGroup.POST("/:name"+httpapi.PauseRoutePath, a.subsysHandler.PauseSubsystem)
+		subsystemGroup.POST("/:name"+httpapi.ResumeRoutePath, a.subsysHandler.ResumeSubsystem)
+	}
+
+	if a.features != nil {
+		featureGroup := adminGroup.Group(httpapi.FeaturesRoutePath)
+		featureGroup.GET("", a.features.ListFeatureFlags)
+		featureGroup.POST("/:name", a.features.SetFeatureFlag)
+	}
+
+	if a.jobs != nil {
+		jobGroup := adminGroup.Group(httpapi.JobsRoutePath)
+		jobGroup.GET("", a.jobs.ListJobs)
+		jobGroup.GET("/:queue/:id", a.jobs.GetJob)
+		jobGroup.POST("/:queue/:id"+httpapi.RetryRoutePath, a.jobs.RetryJob)
+		jobGroup.DELETE("/:queue/:id", a.jobs.CancelJob)
+	}
+
+	if a.signing != nil {
+		signingGroup := adminGroup.Group(httpapi.SigningRoutePath + httpapi.RequestsRoutePath)
+		signingGroup.GET("", a.signing.ListSignRequests)
+		signingGroup.POST("/:id"+httpapi.ApproveRoutePath, a.signing.ApproveSignRequest)
+		signingGroup.POST("/:id"+httpapi.RejectRoutePath, a.signing.RejectSignRequest)
+	}
+
+	if a.readOnly != nil {
+		readOnlyGroup := adminGroup.Group(httpapi.ReadOnlyRoutePath)
+		readOnlyGroup.GET("", a.readOnly.GetReadOnly)
+		readOnlyGroup.POST(httpapi.EnableRoutePath, a.readOnly.EnableReadOnly)
+		readOnlyGroup.POST(httpapi.DisableRoutePath, a.readOnly.DisableReadOnly)
+	}
+
+	if a.diskSpace != nil {
+		adminGroup.GET(httpapi.DiskSpaceRoutePath, a.diskSpace.GetDiskSpace)
+	}
+
+	if a.blocklist != nil {
+		blocklistGroup := adminGroup.Group(httpapi.BlocklistRoutePath)
+		blocklistGroup.GET("", a.blocklist.ListBlocklist)
+		blocklistGroup.POST("", a.blocklist.AddBlocklist)
+		blocklistGroup.POST(httpapi.RemoveRoutePath, a.blocklist.RemoveBlocklist)
+		blocklistGroup.GET(httpapi.CheckRoutePath+"/:digest", a.blocklist.CheckBlocklist)
+		blocklistGroup.POST(httpapi.SyncRoutePath, a.blocklist.SyncBlocklist)
+	}
 }
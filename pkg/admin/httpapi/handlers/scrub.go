@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/service/scrubber"
+)
+
+// ScrubHandler reports the blobs found corrupt by the integrity scrubber.
+type ScrubHandler struct {
+	scrubber *scrubber.Service
+}
+
+// NewScrubHandler creates a new ScrubHandler.
+func NewScrubHandler(scrubber *scrubber.Service) *ScrubHandler {
+	return &ScrubHandler{scrubber: scrubber}
+}
+
+// ListCorruptBlobs returns the blobs found corrupt that have not verified
+// since, oldest first.
+// GET /admin/scrub/corrupt
+func (h *ScrubHandler) ListCorruptBlobs(c echo.Context) error {
+	corrupt, err := h.scrubber.Corrupt(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.CorruptBlobsResponse{Blobs: make([]httpapi.CorruptBlob, 0, len(corrupt))}
+	for _, cb := range corrupt {
+		blob := httpapi.CorruptBlob{
+			Digest:      digestutil.Format(cb.Digest),
+			Size:        cb.Size,
+			DetectedAt:  cb.DetectedAt,
+			RepairError: cb.RepairError,
+		}
+		if !cb.RepairedAt.IsZero() {
+			blob.RepairedAt = &cb.RepairedAt
+		}
+		res.Blobs = append(res.Blobs, blob)
+	}
+	return c.JSON(http.StatusOK, res)
+}
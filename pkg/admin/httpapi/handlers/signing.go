@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/service/signer/approval"
+)
+
+// SigningHandler handles requests to approve or reject the sign requests
+// held for approval.
+type SigningHandler struct {
+	queue *approval.Queue
+}
+
+// NewSigningHandler creates a new SigningHandler.
+func NewSigningHandler(queue *approval.Queue) *SigningHandler {
+	return &SigningHandler{queue: queue}
+}
+
+// ListSignRequests returns the sign requests of a status, pending ones by
+// default, or all of them with status=all, most recent first.
+// GET /admin/signing/requests?status=<status>&limit=<n>
+func (h *SigningHandler) ListSignRequests(c echo.Context) error {
+	status := approval.StatusPending
+	switch s := approval.Status(c.QueryParam("status")); s {
+	case "":
+	case "all":
+		status = ""
+	case approval.StatusPending, approval.StatusApproved, approval.StatusSigning, approval.StatusRejected, approval.StatusSigned:
+		status = s
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid status")
+	}
+	var limit int
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		limit = n
+	}
+
+	reqs, err := h.queue.List(c.Request().Context(), status, limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.ListSignRequestsResponse{Requests: make([]httpapi.SignRequest, 0, len(reqs))}
+	for _, req := range reqs {
+		res.Requests = append(res.Requests, toSignRequest(req))
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// ApproveSignRequest approves a pending or rejected sign request.
+// POST /admin/signing/requests/:id/approve
+func (h *SigningHandler) ApproveSignRequest(c echo.Context) error {
+	id, err := signRequestID(c)
+	if err != nil {
+		return err
+	}
+	req, err := h.queue.Approve(c.Request().Context(), id)
+	if err != nil {
+		return decisionError(err)
+	}
+	return c.JSON(http.StatusOK, toSignRequest(req))
+}
+
+// RejectSignRequest rejects a pending sign request.
+// POST /admin/signing/requests/:id/reject
+func (h *SigningHandler) RejectSignRequest(c echo.Context) error {
+	id, err := signRequestID(c)
+	if err != nil {
+		return err
+	}
+	var body httpapi.RejectSignRequestRequest
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	req, err := h.queue.Reject(c.Request().Context(), id, body.Reason)
+	if err != nil {
+		return decisionError(err)
+	}
+	return c.JSON(http.StatusOK, toSignRequest(req))
+}
+
+func signRequestID(c echo.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "invalid sign request ID")
+	}
+	return uint(id), nil
+}
+
+func decisionError(err error) error {
+	switch {
+	case errors.Is(err, approval.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, approval.ErrDecided):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
+
+func toSignRequest(req approval.Request) httpapi.SignRequest {
+	out := httpapi.SignRequest{
+		ID:        req.ID,
+		Operation: string(req.Operation),
+		DataSet:   req.DataSet,
+		Payee:     req.Payee,
+		Size:      req.Size,
+		Status:    string(req.Status),
+		Reason:    req.Reason,
+		CreatedAt: req.CreatedAt,
+		DecidedAt: req.DecidedAt,
+	}
+	if len(req.Pieces) > 0 {
+		// written by the queue, a list of strings
+		_ = json.Unmarshal(req.Pieces, &out.Pieces)
+	}
+	if len(req.Metadata) > 0 && string(req.Metadata) != "null" {
+		out.Metadata = json.RawMessage(req.Metadata)
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-ucanto/did"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/storageclass"
+)
+
+// StorageClassHandler handles requests to inspect storage classes and assign
+// them to spaces.
+type StorageClassHandler struct {
+	classes *storageclass.Manager
+}
+
+// NewStorageClassHandler creates a new StorageClassHandler.
+func NewStorageClassHandler(classes *storageclass.Manager) *StorageClassHandler {
+	return &StorageClassHandler{classes: classes}
+}
+
+// ListStorageClasses returns the classes with their usage and the spaces
+// assigned a class.
+// GET /admin/storage-classes
+func (h *StorageClassHandler) ListStorageClasses(c echo.Context) error {
+	ctx := c.Request().Context()
+	classes, err := classUsage(ctx, h.classes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	assignments, err := h.classes.Assignments(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := httpapi.ListStorageClassesResponse{
+		Default: h.classes.Default().Name,
+		Classes: classes,
+		Spaces:  make([]httpapi.SpaceStorageClass, 0, len(assignments)),
+	}
+	for _, a := range assignments {
+		res.Spaces = append(res.Spaces, httpapi.SpaceStorageClass{Space: a.Space.String(), Class: a.Class})
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// SetSpaceStorageClass assigns a class to the blobs later allocated in a
+// space. An empty class removes the assignment.
+// POST /admin/storage-classes/spaces/:space
+func (h *StorageClassHandler) SetSpaceStorageClass(c echo.Context) error {
+	ctx := c.Request().Context()
+	space, err := did.Parse(c.Param("space"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid space DID")
+	}
+	var req httpapi.SetSpaceStorageClassRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if err := h.classes.SetSpaceClass(ctx, space, req.Class); err != nil {
+		var ue *storageclass.UnknownClassError
+		if errors.As(err, &ue) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, httpapi.SpaceStorageClass{Space: space.String(), Class: req.Class})
+}
+
+// classUsage returns the configured classes followed by the classes no longer
+// configured that blobs were allocated in, with their usage.
+func classUsage(ctx context.Context, classes *storageclass.Manager) ([]httpapi.StorageClass, error) {
+	usage, err := classes.Usage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var res []httpapi.StorageClass
+	for _, class := range classes.Classes() {
+		u := usage[class.Name]
+		delete(usage, class.Name)
+		res = append(res, httpapi.StorageClass{
+			Name:          class.Name,
+			Replicas:      class.Replicas,
+			ProofSetClass: class.ProofSetClass,
+			Configured:    true,
+			Blobs:         u.Blobs,
+			Bytes:         u.Bytes,
+		})
+	}
+	for name, u := range usage {
+		res = append(res, httpapi.StorageClass{Name: name, Blobs: u.Blobs, Bytes: u.Bytes})
+	}
+	return res, nil
+}
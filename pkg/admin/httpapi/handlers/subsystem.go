@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/subsystem"
+)
+
+// SubsystemHandler handles requests to pause and resume subsystems.
+type SubsystemHandler struct {
+	registry *subsystem.Registry
+}
+
+// NewSubsystemHandler creates a new SubsystemHandler.
+func NewSubsystemHandler(registry *subsystem.Registry) *SubsystemHandler {
+	return &SubsystemHandler{registry: registry}
+}
+
+// ListSubsystems returns the state of all subsystems.
+// GET /admin/subsystems
+func (h *SubsystemHandler) ListSubsystems(c echo.Context) error {
+	res := httpapi.ListSubsystemsResponse{Subsystems: []httpapi.SubsystemStatus{}}
+	for _, s := range h.registry.Status() {
+		res.Subsystems = append(res.Subsystems, toSubsystemStatus(s))
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// PauseSubsystem pauses a subsystem. The subsystem stays paused across
+// restarts until it is resumed.
+// POST /admin/subsystems/:name/pause
+func (h *SubsystemHandler) PauseSubsystem(c echo.Context) error {
+	s, err := h.registry.Pause(c.Param("name"))
+	if err != nil {
+		return mapSubsystemError(err)
+	}
+	return c.JSON(http.StatusOK, toSubsystemStatus(s))
+}
+
+// ResumeSubsystem resumes a paused subsystem.
+// POST /admin/subsystems/:name/resume
+func (h *SubsystemHandler) ResumeSubsystem(c echo.Context) error {
+	s, err := h.registry.Resume(c.Param("name"))
+	if err != nil {
+		return mapSubsystemError(err)
+	}
+	return c.JSON(http.StatusOK, toSubsystemStatus(s))
+}
+
+func toSubsystemStatus(s subsystem.Status) httpapi.SubsystemStatus {
+	return httpapi.SubsystemStatus{Name: s.Name, Paused: s.Paused, PausedAt: s.PausedAt}
+}
+
+func mapSubsystemError(err error) error {
+	if errors.Is(err, subsystem.ErrUnknownSubsystem) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
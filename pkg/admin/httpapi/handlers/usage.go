@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-ucanto/did"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/service/usage"
+)
+
+// UsageHandler handles requests to report the bytes spaces store over time.
+type UsageHandler struct {
+	tracker *usage.Tracker
+}
+
+// NewUsageHandler creates a new UsageHandler.
+func NewUsageHandler(tracker *usage.Tracker) *UsageHandler {
+	return &UsageHandler{tracker: tracker}
+}
+
+// ListSpaceUsage returns the bytes stored by every space that has allocated
+// any.
+// GET /admin/usage
+func (h *UsageHandler) ListSpaceUsage(c echo.Context) error {
+	sizes, err := h.tracker.Sizes(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.ListSpaceUsageResponse{Spaces: make([]httpapi.SpaceSize, 0, len(sizes))}
+	for space, size := range sizes {
+		res.Spaces = append(res.Spaces, httpapi.SpaceSize{Space: space.String(), Size: size})
+	}
+	sort.Slice(res.Spaces, func(i, j int) bool { return res.Spaces[i].Space < res.Spaces[j].Space })
+	return c.JSON(http.StatusOK, res)
+}
+
+// GetUsageReport returns the usage of a space over a period, with the
+// snapshots taken in it. The period defaults to the current calendar month
+// (UTC) until now.
+// GET /admin/usage/:space?from=<RFC3339>&to=<RFC3339>
+func (h *UsageHandler) GetUsageReport(c echo.Context) error {
+	ctx := c.Request().Context()
+	space, err := did.Parse(c.Param("space"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid space DID")
+	}
+	to := time.Now().UTC()
+	if v := c.QueryParam("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid to time, must be RFC3339")
+		}
+	}
+	from := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if v := c.QueryParam("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid from time, must be RFC3339")
+		}
+	}
+	if !from.Before(to) {
+		return echo.NewHTTPError(http.StatusBadRequest, "from must be before to")
+	}
+
+	report, err := h.tracker.Report(ctx, space, from, to)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	snapshots, err := h.tracker.Snapshots(ctx, space, from, to)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	res := httpapi.UsageReport{
+		Space:     space.String(),
+		From:      report.From,
+		To:        report.To,
+		Initial:   report.Initial,
+		Final:     report.Final,
+		Events:    make([]httpapi.UsageEvent, 0, len(report.Events)),
+		Snapshots: make([]httpapi.UsageSnapshot, 0, len(snapshots)),
+	}
+	for _, e := range report.Events {
+		res.Events = append(res.Events, httpapi.UsageEvent{Cause: e.Cause.String(), Delta: e.Delta, At: e.At})
+	}
+	for _, s := range snapshots {
+		res.Snapshots = append(res.Snapshots, httpapi.UsageSnapshot{Size: s.Size, At: s.At})
+	}
+	return c.JSON(http.StatusOK, res)
+}
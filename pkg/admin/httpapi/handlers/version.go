@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/build"
+)
+
+// getVersion returns the build metadata embedded in the running binary.
+// GET /admin/version
+func getVersion(ctx echo.Context) error {
+	var resp httpapi.VersionResponse = build.GetInfo()
+	return ctx.JSON(http.StatusOK, &resp)
+}
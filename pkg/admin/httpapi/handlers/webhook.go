@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/webhook"
+)
+
+// WebhookHandler handles requests to manage the endpoints notified of issued
+// receipts.
+type WebhookHandler struct {
+	webhooks *webhook.Service
+}
+
+// NewWebhookHandler creates a new WebhookHandler.
+func NewWebhookHandler(webhooks *webhook.Service) *WebhookHandler {
+	return &WebhookHandler{webhooks: webhooks}
+}
+
+// ListWebhooks returns the registered webhooks and their delivery counts.
+// GET /admin/webhooks
+func (h *WebhookHandler) ListWebhooks(c echo.Context) error {
+	endpoints, err := h.webhooks.List(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	res := httpapi.ListWebhooksResponse{Webhooks: make([]httpapi.Webhook, 0, len(endpoints))}
+	for _, ep := range endpoints {
+		wh := toWebhook(ep)
+		wh.Secret = ""
+		res.Webhooks = append(res.Webhooks, wh)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// AddWebhook registers a webhook. The response includes its secret.
+// POST /admin/webhooks
+func (h *WebhookHandler) AddWebhook(c echo.Context) error {
+	var req httpapi.AddWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	ep, err := h.webhooks.Add(c.Request().Context(), webhook.Endpoint{
+		URL:       req.URL,
+		Abilities: req.Abilities,
+		Secret:    req.Secret,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, toWebhook(ep))
+}
+
+// RemoveWebhook unregisters a webhook and drops its pending notifications.
+// DELETE /admin/webhooks/:id
+func (h *WebhookHandler) RemoveWebhook(c echo.Context) error {
+	if err := h.webhooks.Remove(c.Request().Context(), c.Param("id")); err != nil {
+		if errors.Is(err, webhook.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func toWebhook(ep webhook.Endpoint) httpapi.Webhook {
+	return httpapi.Webhook{
+		ID:        ep.ID,
+		URL:       ep.URL,
+		Abilities: ep.Abilities,
+		Secret:    ep.Secret,
+		CreatedAt: ep.CreatedAt,
+		Delivered: ep.Delivered,
+		Failed:    ep.Failed,
+		Pending:   ep.Pending,
+		LastError: ep.LastError,
+	}
+}
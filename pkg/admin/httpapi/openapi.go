@@ -0,0 +1,169 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/storacha/piri/pkg/openapi"
+)
+
+// Operations describes the routes of the admin API, all of which require a
+// bearer token signed by the node's identity.
+var Operations = adminOperations([]openapi.Operation{
+	{Method: http.MethodGet, Path: VersionRoutePath, ID: "getVersion", Summary: "Build metadata of the running node", Response: VersionResponse{}},
+	{Method: http.MethodGet, Path: OverviewRoutePath, ID: "getOverview", Summary: "Health, data sets, pieces and recent errors of the node", Response: OverviewResponse{}},
+
+	{Method: http.MethodGet, Path: LogRoutePath + "/list", ID: "listLogLevels", Summary: "Level of each logging system", Response: ListLogLevelsResponse{}},
+	{Method: http.MethodPost, Path: LogRoutePath + "/set", ID: "setLogLevel", Summary: "Set the level of a logging system", Request: SetLogLevelRequest{}},
+	{Method: http.MethodPost, Path: LogRoutePath + "/set-regex", ID: "setLogLevelRegex", Summary: "Set the level of the logging systems matching an expression", Request: SetLogLevelRegexRequest{}},
+
+	{Method: http.MethodGet, Path: PaymentRoutePath + "/account", ID: "getAccountInfo", Summary: "Payment account and rails of the node", Response: GetAccountInfoResponse{}},
+	{Method: http.MethodGet, Path: PaymentRoutePath + "/settle/:railId/estimate", ID: "estimateSettlement", Summary: "Estimate the settlement of a rail", Response: EstimateSettlementResponse{}},
+	{Method: http.MethodGet, Path: PaymentRoutePath + "/settle/:railId/status", ID: "getSettlementStatus", Summary: "Status of the settlement of a rail", Response: SettlementStatusResponse{}},
+	{Method: http.MethodPost, Path: PaymentRoutePath + "/settle/:railId", ID: "settleRail", Summary: "Settle a rail", Response: SettleRailResponse{}},
+	{Method: http.MethodPost, Path: PaymentRoutePath + "/withdraw/estimate", ID: "estimateWithdraw", Summary: "Estimate a withdrawal of the account funds", Request: EstimateWithdrawRequest{}, Response: EstimateWithdrawResponse{}},
+	{Method: http.MethodPost, Path: PaymentRoutePath + "/withdraw", ID: "withdraw", Summary: "Withdraw the account funds", Request: WithdrawRequest{}, Response: WithdrawResponse{}},
+	{Method: http.MethodGet, Path: PaymentRoutePath + "/withdraw/status", ID: "getWithdrawalStatus", Summary: "Status of the last withdrawal", Response: WithdrawalStatusResponse{}},
+	{Method: http.MethodGet, Path: DrillRoutePath + MissedProofRoutePath, ID: "drillMissedProof", Summary: "Simulate the cost of missing the next proof of a data set", Query: []openapi.Param{
+		{Name: "dataset", Description: "ID of the data set", Type: "integer", Required: true},
+	}, Response: DrillMissedProofResponse{}},
+
+	{Method: http.MethodGet, Path: DataSetsRoutePath, ID: "listDataSets", Summary: "Data sets of the node", Response: ListDataSetsResponse{}},
+	{Method: http.MethodGet, Path: DataSetsRoutePath + "/:id", ID: "getDataSet", Summary: "Data set with its chain state and recent faults", Query: []openapi.Param{
+		{Name: "fault_lookback", Description: "Number of epochs to look back for faults", Type: "integer"},
+	}, Response: GetDataSetResponse{}},
+	{Method: http.MethodGet, Path: DataSetsRoutePath + "/:id" + VerifyRoutePath, ID: "verifyDataSet", Summary: "Verify the pieces of a data set are stored", Query: []openapi.Param{
+		{Name: "deep", Description: "Read and hash the blobs of the pieces", Type: "boolean"},
+		{Name: "repair_plan", Description: "Include the steps repairing failed pieces", Type: "boolean"},
+	}, Response: VerifyDataSetResponse{}},
+	{Method: http.MethodGet, Path: DataSetsRoutePath + "/:id" + ProveRoutePath + DryRunRoutePath, ID: "proveDataSetDryRun", Summary: "Prove the next challenge of a data set without submitting the proof", Response: ProveDryRunResponse{}},
+
+	{Method: http.MethodGet, Path: LocateRoutePath + "/:digest", ID: "locateBlob", Summary: "Piece, aggregates and data sets of a blob", Response: LocateBlobResponse{}},
+	{Method: http.MethodGet, Path: AggregatesRoutePath + "/:root", ID: "getAggregate", Summary: "Pieces of an aggregate and the data sets it was added to", Response: AggregateResponse{}},
+	{Method: http.MethodGet, Path: BackfillRoutePath, ID: "getBackfill", Summary: "Progress of the backfill of blobs stored before PDP", Response: BackfillStatus{}},
+	{Method: http.MethodPost, Path: BackfillRoutePath, ID: "startBackfill", Summary: "Queue the blobs stored before PDP for aggregation", Request: StartBackfillRequest{}, Response: BackfillStatus{}},
+	{Method: http.MethodPost, Path: BackfillRoutePath + CancelRoutePath, ID: "cancelBackfill", Summary: "Cancel the running backfill", Response: BackfillStatus{}},
+
+	{Method: http.MethodGet, Path: ProofsRoutePath, ID: "listProofs", Summary: "Recent proofs of each data set", Query: []openapi.Param{
+		{Name: "limit", Description: "Maximum number of proofs per data set", Type: "integer"},
+	}, Response: ListProofsResponse{}},
+
+	{Method: http.MethodGet, Path: DelegationsRoutePath, ID: "listDelegations", Summary: "Delegations granted to the node", Response: ListDelegationsResponse{}},
+	{Method: http.MethodPost, Path: DelegationsRoutePath, ID: "importDelegations", Summary: "Import delegations granted to the node", Request: ImportDelegationsRequest{}, Response: ImportDelegationsResponse{}},
+	{Method: http.MethodGet, Path: DelegationsRoutePath + "/:cid", ID: "getDelegation", Summary: "Delegation granted to the node", Response: Delegation{}},
+	{Method: http.MethodDelete, Path: DelegationsRoutePath + "/:cid", ID: "removeDelegation", Summary: "Remove a delegation", Status: http.StatusNoContent},
+
+	{Method: http.MethodGet, Path: ProofSetsRoutePath, ID: "listProofSets", Summary: "Proof sets the node adds aggregates to", Response: ListProofSetsResponse{}},
+	{Method: http.MethodPost, Path: ProofSetsRoutePath, ID: "addProofSet", Summary: "Add a proof set the node adds aggregates to", Request: AddProofSetRequest{}, Response: ManagedProofSet{}},
+	{Method: http.MethodPost, Path: ProofSetsRoutePath + "/:id" + RetireRoutePath, ID: "retireProofSet", Summary: "Stop adding aggregates to a proof set", Response: ManagedProofSet{}},
+
+	{Method: http.MethodGet, Path: RepublishRoutePath, ID: "getRepublishStatus", Summary: "Republication of location claims after a change of the public URL", Response: RepublishStatus{}},
+
+	{Method: http.MethodGet, Path: PiecesRoutePath + StatesRoutePath, ID: "getPieceStates", Summary: "Number of pieces in each lifecycle state", Response: PieceStateCounts{}},
+	{Method: http.MethodGet, Path: PiecesRoutePath + StuckRoutePath, ID: "listStuckPieces", Summary: "Pieces stuck in a lifecycle state", Query: []openapi.Param{
+		{Name: "older_than", Description: "Minimum time in the state, as a Go duration"},
+	}, Response: StuckPiecesResponse{}},
+	{Method: http.MethodGet, Path: PiecesRoutePath + "/:digest" + StatusRoutePath, ID: "getPieceStatus", Summary: "Lifecycle of a blob stored on the node", Response: PieceStatus{}},
+
+	{Method: http.MethodGet, Path: QuotasRoutePath, ID: "listQuotas", Summary: "Quotas of the spaces", Response: ListQuotasResponse{}},
+	{Method: http.MethodGet, Path: QuotasRoutePath + "/:space", ID: "getQuota", Summary: "Quota and usage of a space", Response: SpaceQuota{}},
+	{Method: http.MethodPost, Path: QuotasRoutePath + "/:space", ID: "setQuota", Summary: "Set the quota of a space", Request: SetQuotaRequest{}, Response: SpaceQuota{}},
+
+	{Method: http.MethodGet, Path: ReplicationRoutePath + PolicyRoutePath, ID: "getReplicaPolicy", Summary: "Policy of the replicas the node accepts", Response: ReplicaPolicy{}},
+	{Method: http.MethodPost, Path: ReplicationRoutePath + PolicyRoutePath, ID: "setReplicaPolicy", Summary: "Set the policy of the replicas the node accepts", Request: ReplicaPolicy{}, Response: ReplicaPolicy{}},
+	{Method: http.MethodGet, Path: ReplicationRoutePath + DeadLettersRoutePath, ID: "listReplicaDeadLetters", Summary: "Replica transfers that failed, with their causes", Query: []openapi.Param{
+		{Name: "limit", Description: "Maximum number of transfers", Type: "integer"},
+	}, Response: ListReplicaDeadLettersResponse{}},
+	{Method: http.MethodPost, Path: ReplicationRoutePath + DeadLettersRoutePath + "/:id" + RequeueRoutePath, ID: "requeueReplicaDeadLetter", Summary: "Requeue a failed replica transfer", Response: ReplicaDeadLetter{}},
+	{Method: http.MethodDelete, Path: ReplicationRoutePath + DeadLettersRoutePath + "/:id", ID: "discardReplicaDeadLetter", Summary: "Discard a failed replica transfer", Response: ReplicaDeadLetter{}},
+
+	{Method: http.MethodPost, Path: CurioRoutePath + ReconcileRoutePath, ID: "reconcileCurio", Summary: "Compare the pieces uploaded to Curio with the pieces it holds, and fix divergences", Request: ReconcileCurioRequest{}, Response: ReconcileCurioResponse{}},
+
+	{Method: http.MethodGet, Path: ScrubRoutePath + CorruptRoutePath, ID: "listCorruptBlobs", Summary: "Blobs found corrupt by the scrubber", Response: CorruptBlobsResponse{}},
+	{Method: http.MethodGet, Path: GasRoutePath + EstimatesRoutePath, ID: "getGasEstimates", Summary: "Gas price estimates", Response: GasEstimatesResponse{}},
+	{Method: http.MethodGet, Path: EventsRoutePath, ID: "listChainEvents", Summary: "Indexed chain events", Query: []openapi.Param{
+		{Name: "data_set", Description: "ID of the data set of the events", Type: "integer"},
+		{Name: "name", Description: "Comma separated names of the events"},
+		{Name: "before", Description: "Block number the events precede", Type: "integer"},
+		{Name: "limit", Description: "Maximum number of events", Type: "integer"},
+	}, Response: ChainEventsResponse{}},
+
+	{Method: http.MethodGet, Path: WebhooksRoutePath, ID: "listWebhooks", Summary: "Webhooks notified of events", Response: ListWebhooksResponse{}},
+	{Method: http.MethodPost, Path: WebhooksRoutePath, ID: "addWebhook", Summary: "Add a webhook", Request: AddWebhookRequest{}, Response: Webhook{}},
+	{Method: http.MethodDelete, Path: WebhooksRoutePath + "/:id", ID: "removeWebhook", Summary: "Remove a webhook", Status: http.StatusNoContent},
+
+	{Method: http.MethodGet, Path: AlertsRoutePath, ID: "listAlerts", Summary: "Recent alerts", Query: []openapi.Param{
+		{Name: "limit", Description: "Maximum number of alerts", Type: "integer"},
+	}, Response: ListAlertsResponse{}},
+	{Method: http.MethodGet, Path: AlertsRoutePath + RulesRoutePath, ID: "getAlertRules", Summary: "Rules raising alerts", Response: AlertRulesResponse{}},
+	{Method: http.MethodPost, Path: AlertsRoutePath + RulesRoutePath, ID: "setAlertRules", Summary: "Set the rules raising alerts", Request: AlertRules{}, Response: AlertRulesResponse{}},
+	{Method: http.MethodDelete, Path: AlertsRoutePath + RulesRoutePath, ID: "resetAlertRules", Summary: "Reset the rules raising alerts to the configured ones", Response: AlertRulesResponse{}},
+
+	{Method: http.MethodGet, Path: StorageClassesRoutePath, ID: "listStorageClasses", Summary: "Storage classes and their usage", Response: ListStorageClassesResponse{}},
+	{Method: http.MethodPost, Path: StorageClassesRoutePath + SpacesRoutePath + "/:space", ID: "setSpaceStorageClass", Summary: "Set the storage class of a space", Request: SetSpaceStorageClassRequest{}, Response: SpaceStorageClass{}},
+
+	{Method: http.MethodGet, Path: BillingRoutePath + PlansRoutePath, ID: "listRatePlans", Summary: "Rate plans and the spaces on them", Response: ListRatePlansResponse{}},
+	{Method: http.MethodPost, Path: BillingRoutePath + SpacesRoutePath + "/:space", ID: "setSpaceRatePlan", Summary: "Set the rate plan of a space", Request: SetSpaceRatePlanRequest{}, Response: SpaceRatePlan{}},
+	{Method: http.MethodGet, Path: BillingRoutePath + InvoicesRoutePath + "/:period", ID: "getInvoice", Summary: "Invoice of a billing period", Query: []openapi.Param{
+		{Name: "format", Description: "json, or csv to download the invoice as text/csv"},
+	}, Response: Invoice{}},
+	{Method: http.MethodPost, Path: BillingRoutePath + InvoicesRoutePath + "/:period" + PushRoutePath, ID: "pushInvoice", Summary: "Push the invoice of a billing period to the billing endpoint", Response: Invoice{}},
+
+	{Method: http.MethodGet, Path: UsageRoutePath, ID: "listSpaceUsage", Summary: "Bytes stored for each space", Response: ListSpaceUsageResponse{}},
+	{Method: http.MethodGet, Path: UsageRoutePath + "/:space", ID: "getUsageReport", Summary: "Usage report of a space", Query: []openapi.Param{
+		{Name: "from", Description: "Start of the report, RFC 3339"},
+		{Name: "to", Description: "End of the report, RFC 3339"},
+	}, Response: UsageReport{}},
+
+	{Method: http.MethodGet, Path: ConfigRoutePath, ID: "getConfig", Summary: "Dynamic configuration", Response: ConfigResponse{}},
+	{Method: http.MethodPatch, Path: ConfigRoutePath, ID: "updateConfig", Summary: "Update the dynamic configuration", Request: UpdateConfigRequest{}, Response: ConfigResponse{}},
+	{Method: http.MethodPost, Path: ConfigRoutePath + ConfigReloadRoutePath, ID: "reloadConfig", Summary: "Reload the dynamic configuration from the config file", Response: ConfigResponse{}},
+
+	{Method: http.MethodGet, Path: SubsystemsRoutePath, ID: "listSubsystems", Summary: "Subsystems and whether they are paused", Response: ListSubsystemsResponse{}},
+	{Method: http.MethodPost, Path: SubsystemsRoutePath + "/:name" + PauseRoutePath, ID: "pauseSubsystem", Summary: "Pause a subsystem", Response: SubsystemStatus{}},
+	{Method: http.MethodPost, Path: SubsystemsRoutePath + "/:name" + ResumeRoutePath, ID: "resumeSubsystem", Summary: "Resume a subsystem", Response: SubsystemStatus{}},
+
+	{Method: http.MethodGet, Path: FeaturesRoutePath, ID: "listFeatureFlags", Summary: "Feature flags", Response: ListFeatureFlagsResponse{}},
+	{Method: http.MethodPost, Path: FeaturesRoutePath + "/:name", ID: "setFeatureFlag", Summary: "Set a feature flag", Request: SetFeatureFlagRequest{}, Response: FeatureFlag{}},
+
+	{Method: http.MethodGet, Path: JobsRoutePath, ID: "listJobs", Summary: "Jobs of the job queues", Query: []openapi.Param{
+		{Name: "queue", Description: "Name of the queue"},
+		{Name: "dead", Description: "List dead-lettered jobs", Type: "boolean"},
+		{Name: "limit", Description: "Maximum number of jobs per queue", Type: "integer"},
+	}, Response: ListJobsResponse{}},
+	{Method: http.MethodGet, Path: JobsRoutePath + "/:queue/:id", ID: "getJob", Summary: "Job of a queue", Response: Job{}},
+	{Method: http.MethodPost, Path: JobsRoutePath + "/:queue/:id" + RetryRoutePath, ID: "retryJob", Summary: "Retry a dead-lettered job", Response: Job{}},
+	{Method: http.MethodDelete, Path: JobsRoutePath + "/:queue/:id", ID: "cancelJob", Summary: "Cancel a job", Response: Job{}},
+
+	{Method: http.MethodGet, Path: SigningRoutePath + RequestsRoutePath, ID: "listSignRequests", Summary: "Sign requests held for approval", Query: []openapi.Param{
+		{Name: "status", Description: "pending, approved, rejected, signed or all"},
+		{Name: "limit", Description: "Maximum number of requests", Type: "integer"},
+	}, Response: ListSignRequestsResponse{}},
+	{Method: http.MethodPost, Path: SigningRoutePath + RequestsRoutePath + "/:id" + ApproveRoutePath, ID: "approveSignRequest", Summary: "Approve a sign request", Response: SignRequest{}},
+	{Method: http.MethodPost, Path: SigningRoutePath + RequestsRoutePath + "/:id" + RejectRoutePath, ID: "rejectSignRequest", Summary: "Reject a sign request", Request: RejectSignRequestRequest{}, Response: SignRequest{}},
+
+	{Method: http.MethodGet, Path: ReadOnlyRoutePath, ID: "getReadOnly", Summary: "Whether the node is read-only", Response: ReadOnlyStatus{}},
+	{Method: http.MethodPost, Path: ReadOnlyRoutePath + EnableRoutePath, ID: "enableReadOnly", Summary: "Make the node read-only", Request: EnableReadOnlyRequest{}, Response: ReadOnlyStatus{}},
+	{Method: http.MethodPost, Path: ReadOnlyRoutePath + DisableRoutePath, ID: "disableReadOnly", Summary: "Make the node writable again", Response: ReadOnlyStatus{}},
+
+	{Method: http.MethodGet, Path: DiskSpaceRoutePath, ID: "getDiskSpace", Summary: "Disk usage and whether allocations are rejected", Response: DiskSpaceStatus{}},
+
+	{Method: http.MethodGet, Path: BlocklistRoutePath, ID: "listBlocklist", Summary: "Content refused by the node", Query: []openapi.Param{
+		{Name: "source", Description: "Source of the entries, admin or the URL of the denylist"},
+		{Name: "limit", Description: "Maximum number of entries", Type: "integer"},
+	}, Response: ListBlocklistResponse{}},
+	{Method: http.MethodPost, Path: BlocklistRoutePath, ID: "addBlocklist", Summary: "Block content", Request: AddBlocklistRequest{}, Response: AddBlocklistResponse{}},
+	{Method: http.MethodPost, Path: BlocklistRoutePath + RemoveRoutePath, ID: "removeBlocklist", Summary: "Unblock content", Request: RemoveBlocklistRequest{}, Response: RemoveBlocklistResponse{}},
+	{Method: http.MethodGet, Path: BlocklistRoutePath + CheckRoutePath + "/:digest", ID: "checkBlocklist", Summary: "Whether a blob is blocked", Response: CheckBlocklistResponse{}},
+	{Method: http.MethodPost, Path: BlocklistRoutePath + SyncRoutePath, ID: "syncBlocklist", Summary: "Sync the remote denylist now", Response: BlocklistSyncStatus{}},
+})
+
+// adminOperations prefixes the paths of the operations with the admin route
+// and requires a bearer token for them.
+func adminOperations(ops []openapi.Operation) []openapi.Operation {
+	for i := range ops {
+		ops[i].Path = AdminRoutePath + ops[i].Path
+		ops[i].Tag = "admin"
+		ops[i].Auth = true
+	}
+	return ops
+}
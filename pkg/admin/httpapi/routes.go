@@ -2,9 +2,71 @@ package httpapi
 
 const (
 	// Route path segments used by both server handlers and HTTP clients.
-	AdminRoutePath        = "/admin"
-	LogRoutePath          = "/log"
-	PaymentRoutePath      = "/payment"
-	ConfigRoutePath       = "/config"
-	ConfigReloadRoutePath = "/reload"
+	AdminRoutePath          = "/admin"
+	LogRoutePath            = "/log"
+	PaymentRoutePath        = "/payment"
+	ConfigRoutePath         = "/config"
+	ConfigReloadRoutePath   = "/reload"
+	VersionRoutePath        = "/version"
+	SubsystemsRoutePath     = "/subsystems"
+	PauseRoutePath          = "/pause"
+	ResumeRoutePath         = "/resume"
+	DrillRoutePath          = "/drill"
+	MissedProofRoutePath    = "/missed-proof"
+	DataSetsRoutePath       = "/datasets"
+	VerifyRoutePath         = "/verify"
+	ProveRoutePath          = "/prove"
+	DryRunRoutePath         = "/dry-run"
+	DelegationsRoutePath    = "/delegations"
+	ProofSetsRoutePath      = "/proofsets"
+	RetireRoutePath         = "/retire"
+	CurioRoutePath          = "/curio"
+	ReconcileRoutePath      = "/reconcile"
+	RepublishRoutePath      = "/republish"
+	PiecesRoutePath         = "/pieces"
+	StatusRoutePath         = "/status"
+	StatesRoutePath         = "/states"
+	StuckRoutePath          = "/stuck"
+	QuotasRoutePath         = "/quotas"
+	ScrubRoutePath          = "/scrub"
+	CorruptRoutePath        = "/corrupt"
+	GasRoutePath            = "/gas"
+	EstimatesRoutePath      = "/estimates"
+	EventsRoutePath         = "/events"
+	ProofsRoutePath         = "/proofs"
+	WebhooksRoutePath       = "/webhooks"
+	AlertsRoutePath         = "/alerts"
+	RulesRoutePath          = "/rules"
+	StorageClassesRoutePath = "/storage-classes"
+	SpacesRoutePath         = "/spaces"
+	BillingRoutePath        = "/billing"
+	PlansRoutePath          = "/plans"
+	InvoicesRoutePath       = "/invoices"
+	PushRoutePath           = "/push"
+	UsageRoutePath          = "/usage"
+	OverviewRoutePath       = "/overview"
+	DashboardRoutePath      = "/dashboard"
+	ReplicationRoutePath    = "/replication"
+	PolicyRoutePath         = "/policy"
+	DeadLettersRoutePath    = "/dead-letters"
+	RequeueRoutePath        = "/requeue"
+	FeaturesRoutePath       = "/features"
+	JobsRoutePath           = "/jobs"
+	RetryRoutePath          = "/retry"
+	SigningRoutePath        = "/signing"
+	RequestsRoutePath       = "/requests"
+	ApproveRoutePath        = "/approve"
+	RejectRoutePath         = "/reject"
+	ReadOnlyRoutePath       = "/read-only"
+	EnableRoutePath         = "/enable"
+	DisableRoutePath        = "/disable"
+	DiskSpaceRoutePath      = "/disk-space"
+	BlocklistRoutePath      = "/blocklist"
+	RemoveRoutePath         = "/remove"
+	CheckRoutePath          = "/check"
+	SyncRoutePath           = "/sync"
+	LocateRoutePath         = "/locate"
+	AggregatesRoutePath     = "/aggregates"
+	BackfillRoutePath       = "/backfill"
+	CancelRoutePath         = "/cancel"
 )
@@ -1,5 +1,18 @@
 package httpapi
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/storacha/piri/pkg/build"
+)
+
+// Version
+type (
+	// VersionResponse describes the build provenance of the running node.
+	VersionResponse = build.Info
+)
+
 // Logging
 type (
 	ListLogLevelsResponse struct {
@@ -134,3 +147,1144 @@ type (
 		Persist bool `json:"persist"`
 	}
 )
+
+// Subsystems
+type (
+	// SubsystemStatus describes whether a subsystem has been paused.
+	SubsystemStatus struct {
+		Name     string     `json:"name"`
+		Paused   bool       `json:"paused"`
+		PausedAt *time.Time `json:"paused_at,omitempty"`
+	}
+
+	ListSubsystemsResponse struct {
+		Subsystems []SubsystemStatus `json:"subsystems"`
+	}
+)
+
+// Read-only mode
+type (
+	// ReadOnlyStatus describes whether the node refuses allocations, replicas
+	// and uploads.
+	ReadOnlyStatus struct {
+		Enabled bool `json:"enabled"`
+		// Forced is true if read-only mode is enabled by the node
+		// configuration, and cannot be disabled through the admin API.
+		Forced bool       `json:"forced,omitempty"`
+		Reason string     `json:"reason,omitempty"`
+		Since  *time.Time `json:"since,omitempty"`
+	}
+
+	// EnableReadOnlyRequest puts the node in read-only mode.
+	EnableReadOnlyRequest struct {
+		Reason string `json:"reason,omitempty"`
+	}
+)
+
+// Disk space
+type (
+	// DiskUsage is the disk usage of a node directory.
+	DiskUsage struct {
+		Name        string  `json:"name"`
+		Path        string  `json:"path"`
+		Total       uint64  `json:"total"`
+		Used        uint64  `json:"used"`
+		Free        uint64  `json:"free"`
+		UsedPercent float64 `json:"used_percent"`
+		Error       string  `json:"error,omitempty"`
+	}
+
+	// DiskSpaceStatus is the disk usage of the data and temp directories at
+	// the last check, and whether allocations are rejected.
+	DiskSpaceStatus struct {
+		Pressure      bool        `json:"pressure"`
+		Since         *time.Time  `json:"since,omitempty"`
+		HighWatermark float64     `json:"high_watermark"`
+		LowWatermark  float64     `json:"low_watermark"`
+		CheckedAt     time.Time   `json:"checked_at"`
+		Volumes       []DiskUsage `json:"volumes"`
+	}
+)
+
+// Blocklist
+type (
+	// BlocklistEntry is content refused by the node. Key is a blob digest, or
+	// a badbits double hash prefixed with "//".
+	BlocklistEntry struct {
+		Key     string    `json:"key"`
+		Reason  string    `json:"reason,omitempty"`
+		Source  string    `json:"source"`
+		AddedAt time.Time `json:"added_at"`
+	}
+
+	// BlocklistSyncStatus describes the last sync of the remote denylist.
+	BlocklistSyncStatus struct {
+		URL       string     `json:"url"`
+		LastSync  *time.Time `json:"last_sync,omitempty"`
+		LastError string     `json:"last_error,omitempty"`
+		Entries   int        `json:"entries"`
+		Skipped   int        `json:"skipped,omitempty"`
+		Added     int        `json:"added"`
+		Removed   int        `json:"removed"`
+	}
+
+	// ListBlocklistResponse lists the entries of the blocklist. Total is the
+	// number of entries, whether or not they are all listed.
+	ListBlocklistResponse struct {
+		Entries []BlocklistEntry `json:"entries"`
+		Total   int              `json:"total"`
+		// Sync is the status of the remote denylist, nil if none is
+		// configured.
+		Sync *BlocklistSyncStatus `json:"sync,omitempty"`
+	}
+
+	// AddBlocklistRequest blocks content by CID, digest or badbits double
+	// hash. With Purge, the bytes of the blobs held by the node are deleted.
+	AddBlocklistRequest struct {
+		Entries []string `json:"entries"`
+		Reason  string   `json:"reason,omitempty"`
+		Purge   bool     `json:"purge,omitempty"`
+	}
+
+	// AddBlocklistResponse is the number of entries added, and the digests of
+	// the blobs purged.
+	AddBlocklistResponse struct {
+		Added  int      `json:"added"`
+		Purged []string `json:"purged"`
+	}
+
+	// RemoveBlocklistRequest unblocks content by CID, digest or badbits double
+	// hash.
+	RemoveBlocklistRequest struct {
+		Entries []string `json:"entries"`
+	}
+
+	// RemoveBlocklistResponse lists the entries removed.
+	RemoveBlocklistResponse struct {
+		Removed []BlocklistEntry `json:"removed"`
+	}
+
+	// CheckBlocklistResponse is whether a blob is refused by the node.
+	CheckBlocklistResponse struct {
+		Digest  string `json:"digest"`
+		Blocked bool   `json:"blocked"`
+	}
+)
+
+// Drills
+type (
+	// ProjectedFaultRecord is the FaultRecord event the service contract would
+	// emit for a missed proving deadline.
+	ProjectedFaultRecord struct {
+		DataSetID      string `json:"data_set_id"`
+		PeriodsFaulted string `json:"periods_faulted"`
+		Deadline       string `json:"deadline"`
+	}
+
+	// DrillMissedProofResponse describes the simulated impact of missing the
+	// next proving deadline of a data set. Amounts are in token base units.
+	DrillMissedProofResponse struct {
+		DryRun               bool                 `json:"dry_run"`
+		DataSetID            string               `json:"data_set_id"`
+		RailID               string               `json:"rail_id"`
+		CurrentEpoch         string               `json:"current_epoch"`
+		ProvingPeriod        string               `json:"proving_period"`
+		ChallengeWindowStart string               `json:"challenge_window_start"`
+		Deadline             string               `json:"deadline"`
+		Fault                ProjectedFaultRecord `json:"fault"`
+		PaymentRate          string               `json:"payment_rate"`         // per epoch
+		PenaltyEpochs        string               `json:"penalty_epochs"`       // epochs the validator would not pay for
+		PenaltyAmount        string               `json:"penalty_amount"`       // payment forfeited by the missed proof
+		GrossAmount          string               `json:"gross_amount"`         // payment up to the deadline before proof reduction
+		NetAmountIfProven    string               `json:"net_amount_if_proven"` // settleable at the deadline after fees
+		NetAmountIfMissed    string               `json:"net_amount_if_missed"` // settleable at the deadline after fees
+		Notes                []string             `json:"notes,omitempty"`
+	}
+
+	// ChallengeDryRun is the outcome of proving one challenged leaf.
+	ChallengeDryRun struct {
+		PieceID  int64         `json:"piece_id"`
+		Leaf     int64         `json:"leaf"`
+		Duration time.Duration `json:"duration"`
+		Error    string        `json:"error,omitempty"`
+	}
+
+	// ProveDryRunResponse is the outcome of proving the next challenge of a
+	// data set against the stored pieces, without submitting the proof.
+	ProveDryRunResponse struct {
+		DryRun         bool   `json:"dry_run"`
+		DataSetID      uint64 `json:"data_set_id"`
+		ChallengeEpoch int64  `json:"challenge_epoch"`
+		HeadEpoch      int64  `json:"head_epoch"`
+		// SeedEpoch is the epoch of the randomness the challenges are drawn
+		// from.
+		SeedEpoch int64 `json:"seed_epoch"`
+		// Simulated is true if the challenge epoch has not been reached, the
+		// challenged leaves are drawn from the randomness of the head and
+		// differ from those of the actual challenge.
+		Simulated bool `json:"simulated"`
+		// OK is true if every challenge was proven, i.e. the node would
+		// succeed.
+		OK         bool              `json:"ok"`
+		Duration   time.Duration     `json:"duration"` // time taken to generate every proof
+		Challenges []ChallengeDryRun `json:"challenges"`
+	}
+)
+
+// Data sets
+type (
+	// DataSetSummary describes a data set from the node's local state.
+	DataSetSummary struct {
+		ID                 uint64 `json:"id"`
+		Initialized        bool   `json:"initialized"`
+		Pieces             int64  `json:"pieces"`         // pieces added on chain
+		PendingPieces      int64  `json:"pending_pieces"` // pieces awaiting add confirmation
+		ProvingPeriod      int64  `json:"proving_period"`
+		ChallengeWindow    int64  `json:"challenge_window"`
+		NextChallengeEpoch int64  `json:"next_challenge_epoch"`
+		ProvingDeadline    int64  `json:"proving_deadline"` // last epoch a proof is accepted for the current period
+		IsProving          bool   `json:"is_proving"`
+	}
+
+	ListDataSetsResponse struct {
+		CurrentEpoch uint64           `json:"current_epoch"`
+		DataSets     []DataSetSummary `json:"data_sets"`
+	}
+
+	// DataSetChainState describes a data set as recorded by the contracts.
+	DataSetChainState struct {
+		LeafCount                uint64 `json:"leaf_count"`
+		NextChallengeEpoch       uint64 `json:"next_challenge_epoch"`
+		NextChallengeWindowStart uint64 `json:"next_challenge_window_start"`
+		ProvingDeadline          uint64 `json:"proving_deadline"`
+		MaxProvingPeriod         uint64 `json:"max_proving_period"`
+		ChallengeWindow          uint64 `json:"challenge_window"`
+		Payer                    string `json:"payer,omitempty"`
+		PDPRailID                string `json:"pdp_rail_id,omitempty"`
+		PDPEndEpoch              string `json:"pdp_end_epoch,omitempty"`
+	}
+
+	// FaultRecord is a FaultRecord event emitted by the service contract for
+	// a missed proving deadline.
+	FaultRecord struct {
+		DataSetID      uint64 `json:"data_set_id"`
+		PeriodsFaulted uint64 `json:"periods_faulted"`
+		Deadline       uint64 `json:"deadline"`
+		BlockNumber    uint64 `json:"block_number"`
+		TxHash         string `json:"tx_hash"`
+	}
+
+	GetDataSetResponse struct {
+		DataSetSummary
+		CurrentEpoch uint64             `json:"current_epoch"`
+		Chain        *DataSetChainState `json:"chain,omitempty"`
+		// Faults recorded within the last FaultLookback epochs.
+		Faults        []FaultRecord `json:"faults"`
+		FaultLookback uint64        `json:"fault_lookback"`
+		Notes         []string      `json:"notes,omitempty"`
+	}
+)
+
+// Piece verification statuses, from best to worst.
+const (
+	PieceOK = "ok"
+	// PieceUnrecorded is a piece in the data set on chain that the node has
+	// no record of.
+	PieceUnrecorded = "unrecorded"
+	// PieceMissing is a piece some of whose blobs are not held by the node.
+	PieceMissing = "missing"
+	// PieceCorrupt is a piece some of whose blobs do not match their digest.
+	PieceCorrupt = "corrupt"
+)
+
+// Repair actions suggested for pieces failing verification.
+const (
+	// RepairRecord records a piece from its pending add, see
+	// `piri client pdp proofset repair`.
+	RepairRecord = "record"
+	// RepairRestore restores the blobs of a piece from a replica or the
+	// uploader.
+	RepairRestore = "restore"
+	// RepairRemove removes a piece that cannot be restored from the data set,
+	// so it stops failing proofs.
+	RepairRemove = "remove"
+)
+
+// Data set verification
+type (
+	// BlobVerification is the result of verifying a blob aggregated into a
+	// piece.
+	BlobVerification struct {
+		SubPieceCID string `json:"sub_piece_cid"`
+		Digest      string `json:"digest,omitempty"` // empty if the sub-piece does not resolve to a blob
+		Status      string `json:"status"`
+		Error       string `json:"error,omitempty"`
+	}
+
+	// PieceVerification is the result of verifying a piece of a data set.
+	PieceVerification struct {
+		PieceID  uint64 `json:"piece_id"`
+		PieceCID string `json:"piece_cid"`
+		Status   string `json:"status"`
+		// Pending is true for an unrecorded piece the node has a pending add
+		// for, from which it can be recorded.
+		Pending bool               `json:"pending,omitempty"`
+		Blobs   []BlobVerification `json:"blobs,omitempty"` // blobs failing verification
+	}
+
+	// RepairStep is an action suggested to repair a piece.
+	RepairStep struct {
+		PieceID  uint64   `json:"piece_id"`
+		PieceCID string   `json:"piece_cid"`
+		Action   string   `json:"action"`
+		Blobs    []string `json:"blobs,omitempty"` // digests of the blobs to restore
+		Detail   string   `json:"detail"`
+	}
+
+	VerifyDataSetResponse struct {
+		DataSetID uint64 `json:"data_set_id"`
+		// Deep is true if the content of blobs was hashed, rather than only
+		// checked to exist.
+		Deep   bool `json:"deep"`
+		Pieces int  `json:"pieces"` // pieces in the data set on chain
+		OK     int  `json:"ok"`
+		// Failed lists the pieces failing verification, by piece ID.
+		Failed     []PieceVerification `json:"failed"`
+		RepairPlan []RepairStep        `json:"repair_plan,omitempty"`
+	}
+)
+
+// Delegations
+type (
+	// ImportDelegationsRequest holds delegations granted to the node.
+	// Delegations are formatted as by `piri delegate generate`, sealed
+	// delegations are base64 encoded archives encrypted to the node's DID.
+	ImportDelegationsRequest struct {
+		Delegations []string `json:"delegations,omitempty"`
+		Sealed      []string `json:"sealed,omitempty"`
+	}
+
+	ImportDelegationsResponse struct {
+		// CIDs of the imported delegations.
+		Imported []string `json:"imported"`
+	}
+
+	// Delegation is a delegation held by the node.
+	Delegation struct {
+		CID string `json:"cid"`
+		// Source is "imported" for delegations imported through the admin
+		// API, otherwise the configuration key the delegation is set at.
+		Source       string                 `json:"source"`
+		Issuer       string                 `json:"issuer"`
+		Audience     string                 `json:"audience"`
+		Capabilities []DelegationCapability `json:"capabilities"`
+		NotBefore    *time.Time             `json:"not_before,omitempty"`
+		// Expiration is omitted for delegations that do not expire.
+		Expiration *time.Time `json:"expiration,omitempty"`
+		Expired    bool       `json:"expired"`
+		// Expiring is true for delegations that have expired or expire
+		// soon.
+		Expiring bool `json:"expiring"`
+		// Proofs are the CIDs of the delegations this one is derived from.
+		Proofs []string `json:"proofs,omitempty"`
+	}
+
+	DelegationCapability struct {
+		Can  string `json:"can"`
+		With string `json:"with"`
+	}
+
+	ListDelegationsResponse struct {
+		Delegations []Delegation `json:"delegations"`
+	}
+)
+
+// Proof sets
+type (
+	// ManagedProofSet is a proof set the node adds aggregates to.
+	ManagedProofSet struct {
+		ID    uint64 `json:"id"`
+		Class string `json:"class,omitempty"`
+		// Bounds on the padded size of aggregates added to the proof set, 0
+		// for no bound.
+		MinAggregateSize uint64 `json:"min_aggregate_size,omitempty"`
+		MaxAggregateSize uint64 `json:"max_aggregate_size,omitempty"`
+		// Backend proves the proof set: native for the PDP service of the
+		// node, curio for a Curio node.
+		Backend   string     `json:"backend,omitempty"`
+		Retired   bool       `json:"retired"`
+		CreatedAt time.Time  `json:"created_at"`
+		RetiredAt *time.Time `json:"retired_at,omitempty"`
+	}
+
+	ListProofSetsResponse struct {
+		ProofSets []ManagedProofSet `json:"proof_sets"`
+	}
+
+	// AddProofSetRequest adds a proof set created by the node, or by Curio
+	// for the curio backend, or reactivates a retired one.
+	AddProofSetRequest struct {
+		ID               uint64 `json:"id"`
+		Class            string `json:"class,omitempty"`
+		MinAggregateSize uint64 `json:"min_aggregate_size,omitempty"`
+		MaxAggregateSize uint64 `json:"max_aggregate_size,omitempty"`
+		// Backend proves the proof set, native if empty.
+		Backend string `json:"backend,omitempty"`
+	}
+)
+
+// Republication
+type (
+	// RepublishStatus reports the republication of location claims following
+	// a change of the node's public URL.
+	RepublishStatus struct {
+		PublicURL string `json:"public_url"`
+		// Progress is nil if the public URL has never changed.
+		Progress *RepublishProgress `json:"progress,omitempty"`
+	}
+
+	RepublishProgress struct {
+		From        string     `json:"from"`
+		To          string     `json:"to"`
+		StartedAt   time.Time  `json:"started_at"`
+		CompletedAt *time.Time `json:"completed_at,omitempty"`
+		Republished int        `json:"republished"`
+		Failed      int        `json:"failed"`
+		LastError   string     `json:"last_error,omitempty"`
+	}
+)
+
+// Pieces
+type (
+	// PieceStatus reports the lifecycle of a blob stored on the node.
+	PieceStatus struct {
+		Digest string `json:"digest"`
+		// Latency is nil if the blob has not been uploaded recently.
+		Latency *UploadLatency `json:"latency,omitempty"`
+		// Lifecycle is nil if no lifecycle event has been recorded for the
+		// blob.
+		Lifecycle *PieceLifecycle `json:"lifecycle,omitempty"`
+	}
+
+	// PieceLifecycle is the state of a piece, projected from its lifecycle
+	// events.
+	PieceLifecycle struct {
+		Digest    string    `json:"digest"`
+		State     string    `json:"state"`
+		Since     time.Time `json:"since"`
+		Space     string    `json:"space,omitempty"`
+		Piece     string    `json:"piece,omitempty"`
+		Aggregate string    `json:"aggregate,omitempty"`
+		ProofSet  uint64    `json:"proof_set,omitempty"`
+		Error     string    `json:"error,omitempty"`
+		// Events are the lifecycle events of the piece, oldest first. They
+		// are only included in the status of a single piece.
+		Events []PieceEvent `json:"events,omitempty"`
+	}
+
+	PieceEvent struct {
+		Seq       uint64    `json:"seq"`
+		Kind      string    `json:"kind"`
+		At        time.Time `json:"at"`
+		Space     string    `json:"space,omitempty"`
+		Piece     string    `json:"piece,omitempty"`
+		Aggregate string    `json:"aggregate,omitempty"`
+		ProofSet  uint64    `json:"proof_set,omitempty"`
+		Error     string    `json:"error,omitempty"`
+	}
+
+	// PieceStateCounts is the number of pieces in each lifecycle state.
+	PieceStateCounts struct {
+		Counts map[string]int `json:"counts"`
+		Total  int            `json:"total"`
+	}
+
+	// StuckPiecesResponse lists the pieces that have not progressed through
+	// the pipeline, oldest first.
+	StuckPiecesResponse struct {
+		Pieces []PieceLifecycle `json:"pieces"`
+	}
+
+	// UploadLatency is the time an upload spent in each stage of the
+	// pipeline, in pipeline order. Accept includes claim publication and the
+	// aggregate enqueue.
+	UploadLatency struct {
+		Total  time.Duration  `json:"total"`
+		Stages []StageLatency `json:"stages"`
+	}
+
+	StageLatency struct {
+		Stage     string        `json:"stage"`
+		StartedAt time.Time     `json:"started_at"`
+		Duration  time.Duration `json:"duration"`
+		Error     string        `json:"error,omitempty"`
+	}
+)
+
+// Statuses of the location of a blob in the proof system.
+const (
+	// LocationLive is an aggregate added to a data set and active on chain.
+	LocationLive = "live"
+	// LocationRemoved is an aggregate the node added to a data set that is no
+	// longer active on chain.
+	LocationRemoved = "removed"
+	// LocationPending is an aggregate whose addition to a data set awaits
+	// confirmation. Its piece ID is not known yet.
+	LocationPending = "pending"
+	// LocationUnsubmitted is an aggregate not yet submitted to a data set.
+	LocationUnsubmitted = "unsubmitted"
+)
+
+// Locate
+type (
+	// LocateBlobResponse maps a blob to its piece and the aggregates and
+	// data sets the piece is proven in.
+	LocateBlobResponse struct {
+		Digest string `json:"digest"`
+		// PieceCID is the piece CID of the blob.
+		PieceCID string `json:"piece_cid"`
+		// Locations are empty if the piece has not been aggregated.
+		Locations []BlobLocation `json:"locations"`
+	}
+
+	// BlobLocation is the position of a piece in an aggregate and of the
+	// aggregate in a data set.
+	BlobLocation struct {
+		Status    string `json:"status"`
+		Aggregate string `json:"aggregate"`
+		// DataSetID is 0 if the aggregate has not been submitted.
+		DataSetID uint64 `json:"data_set_id,omitempty"`
+		// PieceID is the on-chain ID of the aggregate in the data set, nil
+		// until its addition is confirmed.
+		PieceID *uint64 `json:"piece_id,omitempty"`
+		// Offset is the offset of the piece in the padded aggregate, in
+		// bytes, nil until the aggregate is submitted.
+		Offset *int64 `json:"offset,omitempty"`
+		// InclusionProof is nil if the aggregate was not built by the node.
+		InclusionProof *InclusionProof `json:"inclusion_proof,omitempty"`
+	}
+
+	// InclusionProof proves a piece is a leaf of the piece tree of an
+	// aggregate.
+	InclusionProof struct {
+		// Index is the index of the piece among the leaves of its level of
+		// the tree.
+		Index uint64 `json:"index"`
+		// Path are the hex encoded sibling nodes from the piece up to the
+		// root.
+		Path []string `json:"path"`
+	}
+
+	// AggregateResponse describes an aggregate built by the node, its
+	// pieces and the data sets it was added to.
+	AggregateResponse struct {
+		Root     string               `json:"root"`
+		Pieces   []AggregatePiece     `json:"pieces"`
+		DataSets []AggregatePlacement `json:"data_sets"`
+	}
+
+	AggregatePiece struct {
+		PieceCID string `json:"piece_cid"`
+		// Digest is the blob the piece was computed from, empty if it cannot
+		// be resolved.
+		Digest         string         `json:"digest,omitempty"`
+		InclusionProof InclusionProof `json:"inclusion_proof"`
+	}
+
+	// AggregatePlacement is an addition of an aggregate to a data set.
+	AggregatePlacement struct {
+		Status    string  `json:"status"`
+		DataSetID uint64  `json:"data_set_id"`
+		PieceID   *uint64 `json:"piece_id,omitempty"`
+	}
+)
+
+// Backfill
+type (
+	// BackfillStatus is the progress of the last backfill of blobs stored
+	// before the node proved its content with PDP.
+	BackfillStatus struct {
+		// State is idle, running, completed, cancelled or failed.
+		State      string     `json:"state"`
+		StartedAt  *time.Time `json:"started_at,omitempty"`
+		FinishedAt *time.Time `json:"finished_at,omitempty"`
+		// Error is why the backfill failed.
+		Error string `json:"error,omitempty"`
+		// Examined is the number of allocated blobs examined so far.
+		Examined int `json:"examined"`
+		// Queued is the number of blobs queued for aggregation.
+		Queued int `json:"queued"`
+		// Registered is the number of blobs that already had a piece.
+		Registered int `json:"registered"`
+		// Missing is the number of allocated blobs not in the blob store.
+		Missing int `json:"missing"`
+		// TooLarge is the number of blobs too large to be proven.
+		TooLarge int `json:"too_large"`
+	}
+
+	// StartBackfillRequest starts a backfill.
+	StartBackfillRequest struct {
+		// Resume continues the last backfill if it was cancelled or failed,
+		// rather than starting over.
+		Resume bool `json:"resume,omitempty"`
+	}
+)
+
+// Quotas
+type (
+	// QuotaLimits are the byte limits of a space, 0 for no limit. Egress is
+	// per calendar month (UTC).
+	QuotaLimits struct {
+		Storage uint64 `json:"storage"`
+		Egress  uint64 `json:"egress"`
+	}
+
+	QuotaUsage struct {
+		Storage uint64 `json:"storage"`
+		Egress  uint64 `json:"egress"`
+		// Period is the calendar month egress is counted in, e.g. 2026-10.
+		Period string `json:"period"`
+	}
+
+	SpaceQuota struct {
+		Space  string      `json:"space"`
+		Limits QuotaLimits `json:"limits"`
+		Usage  QuotaUsage  `json:"usage"`
+	}
+
+	ListQuotasResponse struct {
+		Quotas []SpaceQuota `json:"quotas"`
+	}
+
+	// SetQuotaRequest sets the limits of a space, zero limits remove them.
+	SetQuotaRequest struct {
+		Storage uint64 `json:"storage"`
+		Egress  uint64 `json:"egress"`
+	}
+)
+
+// Curio
+type (
+	// ReconcileCurioRequest compares the pieces uploaded to Curio with the
+	// pieces it holds.
+	ReconcileCurioRequest struct {
+		// DryRun only reports divergences, without fixing them.
+		DryRun bool `json:"dry_run,omitempty"`
+	}
+
+	// CurioDivergence is a piece whose state recorded by the node differs from
+	// the state of Curio.
+	CurioDivergence struct {
+		PieceCID string `json:"piece_cid"`
+		// Kind is one of missing, unnotified, stalled or orphaned.
+		Kind     string `json:"kind"`
+		Resolved bool   `json:"resolved"`
+		Error    string `json:"error,omitempty"`
+	}
+
+	ReconcileCurioResponse struct {
+		Checked     int               `json:"checked"`
+		Divergences []CurioDivergence `json:"divergences"`
+	}
+)
+
+// Scrubbing
+type (
+	// CorruptBlob is a blob whose content was found not to match its digest
+	// by the integrity scrubber.
+	CorruptBlob struct {
+		Digest     string    `json:"digest"`
+		Size       uint64    `json:"size"`
+		DetectedAt time.Time `json:"detected_at"`
+		// RepairedAt is nil if the blob has not been repaired from a peer.
+		RepairedAt  *time.Time `json:"repaired_at,omitempty"`
+		RepairError string     `json:"repair_error,omitempty"`
+	}
+
+	CorruptBlobsResponse struct {
+		Blobs []CorruptBlob `json:"blobs"`
+	}
+)
+
+// Gas
+type (
+	// GasPercentile is the base fee at a percentile of the recent history.
+	GasPercentile struct {
+		Percentile uint   `json:"percentile"`
+		BaseFee    string `json:"base_fee"`
+	}
+
+	// GasEstimatesResponse summarises the base fee history recorded by the gas
+	// oracle. Fees are in attoFIL.
+	GasEstimatesResponse struct {
+		Samples int           `json:"samples"`
+		Window  time.Duration `json:"window"`
+		// LatestEpoch and LatestBaseFee are empty if no tipset has been seen.
+		LatestEpoch   int64  `json:"latest_epoch,omitempty"`
+		LatestBaseFee string `json:"latest_base_fee,omitempty"`
+		// Percentiles is empty until enough history has been recorded.
+		Percentiles []GasPercentile `json:"percentiles"`
+	}
+)
+
+// Chain events
+type (
+	// ChainEvent is a contract event concerning one of the node's data sets.
+	ChainEvent struct {
+		Name        string `json:"name"`
+		DataSetID   uint64 `json:"data_set_id"`
+		BlockNumber int64  `json:"block_number"`
+		TxHash      string `json:"tx_hash"`
+		LogIndex    uint   `json:"log_index"`
+		Contract    string `json:"contract"`
+		// Args holds the arguments of the event that are not indexed.
+		Args json.RawMessage `json:"args,omitempty"`
+	}
+
+	ChainEventsResponse struct {
+		// IndexedTo is the last block searched for events.
+		IndexedTo int64        `json:"indexed_to"`
+		Events    []ChainEvent `json:"events"`
+	}
+)
+
+// Proofs
+type (
+	// DataSetProofs is the proving history of a data set and when its next
+	// proof is due.
+	DataSetProofs struct {
+		DataSetID uint64 `json:"data_set_id"`
+		// LastProvenEpoch is the block of the last accepted proof, zero if
+		// none has been recorded.
+		LastProvenEpoch int64 `json:"last_proven_epoch"`
+		// NextChallengeWindowStart is the first epoch the next proof is
+		// accepted, zero if the data set is not proving.
+		NextChallengeWindowStart int64 `json:"next_challenge_window_start"`
+		// ProvingDeadline is the last epoch the next proof is accepted.
+		ProvingDeadline int64 `json:"proving_deadline"`
+		// NextChallengeAt and DeadlineAt estimate when the epochs are reached,
+		// from the timestamp of the chain head. They are not set when the
+		// head is unavailable or the data set is not proving.
+		NextChallengeAt *time.Time `json:"next_challenge_at,omitempty"`
+		DeadlineAt      *time.Time `json:"deadline_at,omitempty"`
+		// UntilChallenge and UntilDeadline count down to them, negative once
+		// passed.
+		UntilChallenge *time.Duration `json:"until_challenge,omitempty"`
+		UntilDeadline  *time.Duration `json:"until_deadline,omitempty"`
+		// RecentProofs are the last accepted proofs, most recent first.
+		RecentProofs []ProofTx `json:"recent_proofs"`
+	}
+
+	// ProofTx is a transaction whose proof was accepted.
+	ProofTx struct {
+		TxHash      string `json:"tx_hash"`
+		BlockNumber int64  `json:"block_number"`
+		Challenges  int    `json:"challenges,omitempty"`
+		// GasUsed and GasCost, in attoFIL, are only known for transactions
+		// whose receipt the node recorded.
+		GasUsed uint64 `json:"gas_used,omitempty"`
+		GasCost string `json:"gas_cost,omitempty"`
+	}
+
+	ListProofsResponse struct {
+		CurrentEpoch uint64 `json:"current_epoch"`
+		// HeadTime is the timestamp of the chain head the estimates are
+		// made from.
+		HeadTime *time.Time      `json:"head_time,omitempty"`
+		DataSets []DataSetProofs `json:"data_sets"`
+		Notes    []string        `json:"notes,omitempty"`
+	}
+)
+
+// Webhooks
+type (
+	// Webhook is an endpoint notified of issued receipts.
+	Webhook struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+		// Abilities are the abilities of the invocations whose receipts are
+		// sent to the endpoint, every receipt if empty.
+		Abilities []string `json:"abilities,omitempty"`
+		// Secret is the key notifications are signed with. It is only
+		// returned when the webhook is added.
+		Secret    string    `json:"secret,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+		Delivered uint64    `json:"delivered"`
+		Failed    uint64    `json:"failed"`
+		Pending   int       `json:"pending"`
+		LastError string    `json:"last_error,omitempty"`
+	}
+
+	ListWebhooksResponse struct {
+		Webhooks []Webhook `json:"webhooks"`
+	}
+
+	// AddWebhookRequest registers a webhook. A secret is generated if none is
+	// given.
+	AddWebhookRequest struct {
+		URL       string   `json:"url"`
+		Abilities []string `json:"abilities,omitempty"`
+		Secret    string   `json:"secret,omitempty"`
+	}
+)
+
+// Signing approval
+type (
+	// SignRequest is a sign request held for approval by the operator.
+	SignRequest struct {
+		ID        uint   `json:"id"`
+		Operation string `json:"operation"`
+		// DataSet is the client data set ID, unset for create-dataset
+		// requests.
+		DataSet string `json:"data_set,omitempty"`
+		Payee   string `json:"payee,omitempty"`
+		// Pieces are the CIDs of the pieces added, or the IDs of those
+		// removed.
+		Pieces []string `json:"pieces,omitempty"`
+		// Size is the padded size of the pieces added, 0 if unknown.
+		Size      uint64          `json:"size,omitempty"`
+		Metadata  json.RawMessage `json:"metadata,omitempty"`
+		Status    string          `json:"status"`
+		Reason    string          `json:"reason,omitempty"`
+		CreatedAt time.Time       `json:"created_at"`
+		DecidedAt *time.Time      `json:"decided_at,omitempty"`
+	}
+
+	ListSignRequestsResponse struct {
+		Requests []SignRequest `json:"requests"`
+	}
+
+	// RejectSignRequestRequest rejects a sign request.
+	RejectSignRequestRequest struct {
+		Reason string `json:"reason,omitempty"`
+	}
+)
+
+// Alerts
+type (
+	// Alert is a condition the operator was alerted of.
+	Alert struct {
+		Key       string    `json:"key"`
+		Kind      string    `json:"kind"`
+		DataSetID int64     `json:"data_set_id,omitempty"`
+		RailID    string    `json:"rail_id,omitempty"`
+		TxHash    string    `json:"tx_hash,omitempty"`
+		Epoch     int64     `json:"epoch,omitempty"`
+		Message   string    `json:"message"`
+		FiredAt   time.Time `json:"fired_at"`
+		// Delivered is true once the alert was sent to every sink.
+		Delivered bool   `json:"delivered"`
+		Attempts  int    `json:"attempts"`
+		LastError string `json:"last_error,omitempty"`
+	}
+
+	ListAlertsResponse struct {
+		Alerts []Alert `json:"alerts"`
+	}
+
+	// AlertRules select the conditions alerts are raised for.
+	AlertRules struct {
+		// DeadlineEpochs alerts when a proving deadline is at most this many
+		// epochs away without a proof, 0 disables it.
+		DeadlineEpochs     int64 `json:"deadline_epochs"`
+		FaultRecords       bool  `json:"fault_records"`
+		SettlementFailures bool  `json:"settlement_failures"`
+	}
+
+	AlertRulesResponse struct {
+		AlertRules
+		// Overridden is true when the rules were set through the admin API
+		// rather than configured.
+		Overridden bool `json:"overridden"`
+	}
+)
+
+// Storage classes
+type (
+	// StorageClass is a storage class with the blobs allocated in it.
+	StorageClass struct {
+		Name string `json:"name"`
+		// Replicas is the replication factor advertised for blobs of the
+		// class, 0 if left to the upload service.
+		Replicas uint `json:"replicas,omitempty"`
+		// ProofSetClass is the class of the proof sets aggregates of the
+		// class are added to, empty for any proof set.
+		ProofSetClass string `json:"proof_set_class,omitempty"`
+		// Configured is false for classes no longer configured that blobs
+		// were allocated in.
+		Configured bool   `json:"configured"`
+		Blobs      uint64 `json:"blobs"`
+		Bytes      uint64 `json:"bytes"`
+	}
+
+	SpaceStorageClass struct {
+		Space string `json:"space"`
+		Class string `json:"class"`
+	}
+
+	ListStorageClassesResponse struct {
+		Default string              `json:"default"`
+		Classes []StorageClass      `json:"classes"`
+		Spaces  []SpaceStorageClass `json:"spaces"`
+	}
+
+	// SetSpaceStorageClassRequest assigns a class to the blobs later allocated
+	// in a space, an empty class removes the assignment.
+	SetSpaceStorageClassRequest struct {
+		Class string `json:"class"`
+	}
+)
+
+// Billing
+type (
+	// RatePlan is the prices the usage of a space is billed at.
+	RatePlan struct {
+		Name string `json:"name"`
+		// StorageGiBMonth is the price of storing a GiB for a month.
+		StorageGiBMonth float64 `json:"storage_gib_month"`
+		// EgressGiB is the price of a GiB downloaded.
+		EgressGiB float64 `json:"egress_gib"`
+	}
+
+	SpaceRatePlan struct {
+		Space  string `json:"space"`
+		Plan   string `json:"plan"`
+		Tenant string `json:"tenant,omitempty"`
+	}
+
+	ListRatePlansResponse struct {
+		Currency string `json:"currency"`
+		// Default is the plan of spaces not assigned one, empty if they are
+		// not billed.
+		Default string          `json:"default,omitempty"`
+		Plans   []RatePlan      `json:"plans"`
+		Spaces  []SpaceRatePlan `json:"spaces"`
+	}
+
+	// SetSpaceRatePlanRequest assigns a plan to a space, with the tenant it
+	// belongs to. An empty plan removes the assignment.
+	SetSpaceRatePlanRequest struct {
+		Plan   string `json:"plan"`
+		Tenant string `json:"tenant,omitempty"`
+	}
+
+	// InvoiceLine is the charge for the use of a resource by a space in a
+	// month.
+	InvoiceLine struct {
+		Space     string  `json:"space"`
+		Tenant    string  `json:"tenant,omitempty"`
+		Plan      string  `json:"plan"`
+		Resource  string  `json:"resource"` // storage or egress
+		Quantity  float64 `json:"quantity"`
+		Unit      string  `json:"unit"` // GiB-month or GiB
+		UnitPrice float64 `json:"unit_price"`
+		Amount    float64 `json:"amount"`
+	}
+
+	// Invoice lists the charges for the usage of spaces in a calendar month.
+	Invoice struct {
+		Period   string `json:"period"`
+		Currency string `json:"currency"`
+		// Final is true once the month has ended.
+		Final       bool          `json:"final"`
+		GeneratedAt time.Time     `json:"generated_at"`
+		Lines       []InvoiceLine `json:"lines"`
+		Total       float64       `json:"total"`
+		// PushedAt is when the invoice was last pushed to the billing
+		// webhook.
+		PushedAt *time.Time `json:"pushed_at,omitempty"`
+	}
+)
+
+// Usage
+type (
+	SpaceSize struct {
+		Space string `json:"space"`
+		Size  uint64 `json:"size"`
+	}
+
+	ListSpaceUsageResponse struct {
+		Spaces []SpaceSize `json:"spaces"`
+	}
+
+	// UsageEvent is a change of the bytes stored by a space.
+	UsageEvent struct {
+		// Cause is the CID of the invocation that allocated or removed the
+		// bytes.
+		Cause string `json:"cause"`
+		// Delta is the number of bytes allocated, negative if removed.
+		Delta int64     `json:"delta"`
+		At    time.Time `json:"at"`
+	}
+
+	UsageSnapshot struct {
+		Size uint64    `json:"size"`
+		At   time.Time `json:"at"`
+	}
+
+	// UsageReport is the usage of a space from From (inclusive) to To
+	// (exclusive).
+	UsageReport struct {
+		Space string    `json:"space"`
+		From  time.Time `json:"from"`
+		To    time.Time `json:"to"`
+		// Initial and Final are the bytes stored at From and To.
+		Initial   uint64          `json:"initial"`
+		Final     uint64          `json:"final"`
+		Events    []UsageEvent    `json:"events"`
+		Snapshots []UsageSnapshot `json:"snapshots"`
+	}
+)
+
+// Overview
+type (
+	// OverviewResponse summarises the state of the node. Sections whose
+	// source is not running on the node are omitted, as are those that could
+	// not be read, with the reason in Notes.
+	OverviewResponse struct {
+		DID       string          `json:"did"`
+		PublicURL string          `json:"public_url,omitempty"`
+		Version   VersionResponse `json:"version"`
+		// StorageClasses holds the blobs allocated in each class.
+		StorageClasses []StorageClass    `json:"storage_classes,omitempty"`
+		DataSets       *DataSetsOverview `json:"data_sets,omitempty"`
+		Pieces         *PieceStateCounts `json:"pieces,omitempty"`
+		RecentErrors   []ErrorLogEntry   `json:"recent_errors,omitempty"`
+		Notes          []string          `json:"notes,omitempty"`
+	}
+
+	// DataSetsOverview summarises the proving of the node's data sets.
+	DataSetsOverview struct {
+		CurrentEpoch uint64 `json:"current_epoch"`
+		Total        int    `json:"total"`
+		Initialized  int    `json:"initialized"`
+		Proving      int    `json:"proving"`
+		// Deadlines are the proving deadlines of the initialized data sets,
+		// soonest first.
+		Deadlines []ProvingDeadline `json:"deadlines"`
+	}
+
+	ProvingDeadline struct {
+		DataSetID uint64 `json:"data_set_id"`
+		Deadline  int64  `json:"deadline"`
+		// EpochsLeft is negative once the deadline has passed.
+		EpochsLeft int64 `json:"epochs_left"`
+	}
+
+	// ErrorLogEntry is an error logged by the node.
+	ErrorLogEntry struct {
+		At      time.Time      `json:"at"`
+		Level   string         `json:"level"`
+		Logger  string         `json:"logger"`
+		Message string         `json:"message"`
+		Error   string         `json:"error,omitempty"`
+		Fields  map[string]any `json:"fields,omitempty"`
+	}
+)
+
+// Replication
+type (
+	// ReplicaPolicy are the limits replica allocations must be within to be
+	// accepted. Zero values impose no limit.
+	ReplicaPolicy struct {
+		MaxReplicaSize  uint64   `json:"max_replica_size,omitempty"`
+		AllowedSources  []string `json:"allowed_sources,omitempty"`
+		ExcludedSources []string `json:"excluded_sources,omitempty"`
+		// ExcludedHosts starting with a dot exclude every host in the domain.
+		ExcludedHosts   []string `json:"excluded_hosts,omitempty"`
+		MinFreeCapacity uint64   `json:"min_free_capacity,omitempty"`
+	}
+
+	// ReplicaDeadLetter is a replica transfer that failed permanently or too
+	// many times, kept until it is requeued or discarded.
+	ReplicaDeadLetter struct {
+		// JobID is the ID of the transfer job in the replication queue.
+		JobID  string `json:"job_id"`
+		Digest string `json:"digest"`
+		Space  string `json:"space"`
+		Source string `json:"source"`
+		Sink   string `json:"sink,omitempty"`
+		// Error is the error of the last attempt, Causes the errors it wraps,
+		// outermost first.
+		Error  string   `json:"error"`
+		Causes []string `json:"causes"`
+		// FailedURL is the source or sink whose response failed the transfer,
+		// with its HTTP status.
+		FailedURL      string    `json:"failed_url,omitempty"`
+		StatusCode     int       `json:"status_code,omitempty"`
+		DigestMismatch bool      `json:"digest_mismatch,omitempty"`
+		Stalled        bool      `json:"stalled,omitempty"`
+		FailedAt       time.Time `json:"failed_at"`
+	}
+
+	ListReplicaDeadLettersResponse struct {
+		DeadLetters []ReplicaDeadLetter `json:"dead_letters"`
+	}
+)
+
+// Feature flags
+type (
+	// FeatureFlag is the state of a feature flag.
+	FeatureFlag struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Enabled     bool   `json:"enabled"`
+		// Rollout is the percentage of nodes or subjects the flag is enabled
+		// for, nil for flags without gradual rollout.
+		Rollout *uint `json:"rollout,omitempty"`
+		// Active is whether the flag is enabled on the node.
+		Active bool `json:"active"`
+	}
+
+	ListFeatureFlagsResponse struct {
+		Flags []FeatureFlag `json:"flags"`
+	}
+
+	// SetFeatureFlagRequest changes a feature flag. Nil values are left
+	// unchanged.
+	SetFeatureFlagRequest struct {
+		Enabled *bool `json:"enabled,omitempty"`
+		Rollout *uint `json:"rollout,omitempty"`
+		// Persist writes the change to the config file.
+		Persist bool `json:"persist,omitempty"`
+	}
+)
+
+// Job states.
+const (
+	// JobQueued is a job waiting to run for the first time.
+	JobQueued = "queued"
+	// JobRunning is a job being run.
+	JobRunning = "running"
+	// JobRetrying is a job that failed and is waiting to run again.
+	JobRetrying = "retrying"
+	// JobDead is a job that failed permanently or too many times.
+	JobDead = "dead"
+)
+
+// Jobs
+type (
+	// Job is a job held by a job queue, waiting to run, running or
+	// dead-lettered.
+	Job struct {
+		Queue    string `json:"queue"`
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		State    string `json:"state"`
+		Attempts int    `json:"attempts"`
+		// Created is when the job was enqueued, nil if unknown.
+		Created *time.Time `json:"created,omitempty"`
+		// AvailableAt is when a queued job can next run, nil for dead jobs.
+		AvailableAt *time.Time `json:"available_at,omitempty"`
+		Dead        bool       `json:"dead"`
+		// FailureReason describes why a dead job failed, Error is the error of
+		// its last run if known.
+		FailureReason string     `json:"failure_reason,omitempty"`
+		Error         string     `json:"error,omitempty"`
+		MovedAt       *time.Time `json:"moved_at,omitempty"`
+	}
+
+	ListJobsResponse struct {
+		Jobs []Job `json:"jobs"`
+	}
+)
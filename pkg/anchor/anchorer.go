@@ -0,0 +1,53 @@
+package anchor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/storacha/piri/pkg/pdp/ethereum"
+)
+
+// Anchorer commits a Merkle root somewhere third parties can later find it
+// along with a trusted timestamp.
+type Anchorer interface {
+	// Anchor commits the root of the tree of the entries, in leaf order, and
+	// returns a reference to the commitment, e.g. a transaction hash.
+	Anchor(ctx context.Context, root []byte, entries []Entry) (string, error)
+}
+
+// ChainAnchorer commits roots on chain as the calldata of a transaction.
+type ChainAnchorer struct {
+	sender ethereum.Sender
+	from   common.Address
+	to     common.Address
+}
+
+var _ Anchorer = (*ChainAnchorer)(nil)
+
+// NewChainAnchorer creates an Anchorer sending transactions from the passed
+// address. The calldata of each transaction is the 32 byte root, so to must be
+// an externally owned account (e.g. from itself) or a commitment contract that
+// accepts raw calldata in its fallback function.
+func NewChainAnchorer(sender ethereum.Sender, from, to common.Address) *ChainAnchorer {
+	return &ChainAnchorer{sender: sender, from: from, to: to}
+}
+
+func (a *ChainAnchorer) Anchor(ctx context.Context, root []byte, _ []Entry) (string, error) {
+	tx := ethtypes.NewTransaction(
+		0,             // nonce - will be set by sender
+		a.to,          // to
+		big.NewInt(0), // value
+		0,             // gas limit - will be estimated by sender
+		nil,           // gas price - will be set by sender
+		root,
+	)
+	hash, err := a.sender.Send(ctx, a.from, tx, "anchor")
+	if err != nil {
+		return "", fmt.Errorf("sending anchor transaction: %w", err)
+	}
+	return hash.Hex(), nil
+}
@@ -0,0 +1,155 @@
+package anchor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	leveldb "github.com/ipfs/go-ds-leveldb"
+	"github.com/storacha/go-ucanto/principal"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	echofx "github.com/storacha/piri/pkg/fx/echo"
+	"github.com/storacha/piri/pkg/httpclient"
+	"github.com/storacha/piri/pkg/pdp/ethereum"
+	"github.com/storacha/piri/pkg/pdp/service"
+	"github.com/storacha/piri/pkg/store/claimstore"
+	"github.com/storacha/piri/pkg/store/receiptstore"
+	"github.com/storacha/piri/pkg/subsystem"
+)
+
+// DataDir is the directory, relative to the data directory, holding pending
+// entries and inclusion proofs.
+const DataDir = "anchor"
+
+// Module anchors issued receipts and claims on chain or with the indexing
+// service, if enabled in config. It
+// decorates the receipt and claim stores, so it must be included at the root
+// of the app rather than in another module for the decoration to apply to all
+// consumers of the stores.
+var Module = fx.Options(
+	fx.Module("anchor",
+		fx.Provide(
+			NewStoreFromConfig,
+			NewServiceFromConfig,
+			fx.Annotate(
+				NewServer,
+				fx.As(new(echofx.RouteRegistrar)),
+				fx.ResultTags(`group:"route_registrar"`),
+			),
+		),
+		// force construction, nothing else depends on the service
+		fx.Invoke(func(*Service) {}),
+	),
+	fx.Decorate(
+		DecorateReceiptStore,
+		DecorateClaimStore,
+	),
+)
+
+// NewStoreFromConfig creates the anchor store in the data directory. It
+// returns nil if anchoring is disabled.
+func NewStoreFromConfig(lc fx.Lifecycle, storageCfg app.StorageConfig, pdpCfg app.PDPServiceConfig) (*Store, error) {
+	if !pdpCfg.Anchoring.Enabled {
+		return nil, nil
+	}
+	if storageCfg.DataDir == "" {
+		return nil, fmt.Errorf("no data dir provided for anchor store")
+	}
+
+	dir := filepath.Join(storageCfg.DataDir, DataDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating directory: %s: %w", dir, err)
+	}
+	ds, err := leveldb.NewDatastore(dir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating anchor store: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+	return NewStore(ds), nil
+}
+
+type ServiceParams struct {
+	fx.In
+
+	Config     app.PDPServiceConfig
+	Store      *Store
+	Sender     ethereum.Sender
+	Subsystems *subsystem.Registry
+	// Chain witnesses the time of claims cached with the indexing service.
+	Chain service.ChainClient
+	// App configures the indexing service roots are cached with.
+	App app.AppConfig
+	// ID issues the claims cached with the indexing service.
+	ID     principal.Signer
+	Policy httpclient.Policy
+}
+
+// NewServiceFromConfig creates the anchoring service. It returns nil if
+// anchoring is disabled.
+func NewServiceFromConfig(lc fx.Lifecycle, params ServiceParams) (*Service, error) {
+	if params.Store == nil {
+		return nil, nil
+	}
+	cfg := params.Config.Anchoring
+	var anchorer Anchorer
+	switch cfg.Target {
+	case app.AnchorTargetIndexer:
+		indexerCfg := params.App.UCANService.Services.Indexer
+		conn := indexerCfg.Connection
+		if conn == nil {
+			return nil, fmt.Errorf("anchoring with the indexing service requires an indexing service connection")
+		}
+		if indexerCfg.URL != nil {
+			c, err := httpclient.NewConnection(conn.ID(), indexerCfg.URL, httpclient.New("indexer", params.Policy))
+			if err != nil {
+				return nil, fmt.Errorf("creating indexing service connection: %w", err)
+			}
+			conn = c
+		}
+		anchorer = NewIndexerAnchorer(params.ID, conn, indexerCfg.Proofs, params.Chain)
+		log.Infow("anchoring receipts and claims with the indexing service", "indexer", conn.ID().DID(), "interval", cfg.Interval)
+	default:
+		to := cfg.Address
+		if to == (common.Address{}) {
+			to = params.Config.OwnerAddress
+		}
+		anchorer = NewChainAnchorer(params.Sender, params.Config.OwnerAddress, to)
+		log.Infow("anchoring receipts and claims", "address", to.Hex(), "interval", cfg.Interval)
+	}
+	svc := NewService(params.Store, anchorer, cfg.Interval)
+	params.Subsystems.Register(subsystem.Anchoring, svc)
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return svc.Start(context.Background())
+		},
+		OnStop: func(ctx context.Context) error {
+			return svc.Stop(ctx)
+		},
+	})
+	return svc, nil
+}
+
+// DecorateReceiptStore records receipts for anchoring, if enabled.
+func DecorateReceiptStore(receipts receiptstore.ReceiptStore, store *Store) receiptstore.ReceiptStore {
+	if store == nil {
+		return receipts
+	}
+	return NewReceiptStore(receipts, store)
+}
+
+// DecorateClaimStore records claims for anchoring, if enabled.
+func DecorateClaimStore(claims claimstore.ClaimStore, store *Store) claimstore.ClaimStore {
+	if store == nil {
+		return claims
+	}
+	return NewClaimStore(claims, store)
+}
@@ -0,0 +1,131 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	filtypes "github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld/block"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/ucan"
+
+	"github.com/storacha/piri/pkg/service/publisher"
+)
+
+// ChainHead returns the head of the chain, which witnesses the time roots
+// are committed with the indexing service.
+type ChainHead interface {
+	ChainHead(ctx context.Context) (*filtypes.TipSet, error)
+}
+
+// IndexerAnchorer commits roots by caching them with the indexing service in
+// an assert/inclusion claim issued by the node. The content of the claim is
+// the root, as a sha2-256 multihash, and it includes a witness block, attached
+// to the claim, binding the root to the head of the chain when it was
+// committed. The not before time of the claim is the timestamp of that head,
+// so the time of the claim is set by the chain rather than the node.
+type IndexerAnchorer struct {
+	id      principal.Signer
+	indexer client.Connection
+	proofs  delegation.Proofs
+	chain   ChainHead
+}
+
+var _ Anchorer = (*IndexerAnchorer)(nil)
+
+// NewIndexerAnchorer creates an Anchorer caching claims with the indexing
+// service, invoking claim/cache with the passed proofs. Claims are bound to
+// the head of the chain.
+func NewIndexerAnchorer(id principal.Signer, indexer client.Connection, proofs delegation.Proofs, chain ChainHead) *IndexerAnchorer {
+	return &IndexerAnchorer{id: id, indexer: indexer, proofs: proofs, chain: chain}
+}
+
+func (a *IndexerAnchorer) Anchor(ctx context.Context, root []byte, _ []Entry) (string, error) {
+	head, err := a.chain.ChainHead(ctx)
+	if err != nil {
+		return "", fmt.Errorf("getting chain head: %w", err)
+	}
+	claim, err := NewAnchorClaim(a.id, root, head)
+	if err != nil {
+		return "", err
+	}
+	if err := publisher.CacheClaim(ctx, a.id, a.indexer, a.proofs, claim, nil); err != nil {
+		return "", fmt.Errorf("caching anchor claim with indexing service: %w", err)
+	}
+	return claim.Link().String(), nil
+}
+
+// NewAnchorClaim creates the assert/inclusion claim committing the root,
+// issued by id and bound to the passed head of the chain. The claim includes
+// a witness block with the root, and the epoch, timestamp and key of the
+// tipset. The tipset key cannot be known before the tipset is produced, so
+// the claim was not issued before its not before time, the tipset timestamp.
+func NewAnchorClaim(id principal.Signer, root []byte, head *filtypes.TipSet) (delegation.Delegation, error) {
+	digest, err := multihash.Encode(root, multihash.SHA2_256)
+	if err != nil {
+		return nil, fmt.Errorf("encoding root %x: %w", root, err)
+	}
+	witness, err := encodeWitness(root, head)
+	if err != nil {
+		return nil, err
+	}
+
+	claim, err := assert.Inclusion.Delegate(
+		id,
+		id,
+		id.DID().String(),
+		assert.InclusionCaveats{
+			Content:  types.FromHash(digest),
+			Includes: witness.Link(),
+		},
+		delegation.WithNoExpiration(),
+		delegation.WithNotBefore(ucan.UTCUnixTimestamp(head.MinTimestamp())),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating anchor claim: %w", err)
+	}
+	if err := claim.Attach(witness); err != nil {
+		return nil, fmt.Errorf("attaching anchor witness: %w", err)
+	}
+	return claim, nil
+}
+
+// encodeWitness encodes the root and the tipset it is bound to as a dag-cbor
+// map with the keys root, epoch, timestamp and tipset, the CID of the tipset
+// key.
+func encodeWitness(root []byte, head *filtypes.TipSet) (block.Block, error) {
+	tsk, err := head.Key().Cid()
+	if err != nil {
+		return nil, fmt.Errorf("getting tipset key CID: %w", err)
+	}
+	node, err := qp.BuildMap(basicnode.Prototype.Map, 4, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "root", qp.Bytes(root))
+		qp.MapEntry(ma, "epoch", qp.Int(int64(head.Height())))
+		qp.MapEntry(ma, "timestamp", qp.Int(int64(head.MinTimestamp())))
+		qp.MapEntry(ma, "tipset", qp.Link(cidlink.Link{Cid: tsk}))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("building anchor witness: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := dagcbor.Encode(node, &buf); err != nil {
+		return nil, fmt.Errorf("encoding anchor witness: %w", err)
+	}
+	c, err := cid.V1Builder{Codec: cid.DagCBOR, MhType: multihash.SHA2_256}.Sum(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("hashing anchor witness: %w", err)
+	}
+	return block.NewBlock(cidlink.Link{Cid: c}, buf.Bytes()), nil
+}
@@ -0,0 +1,96 @@
+package anchor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	filtypes "github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAnchorClaim(t *testing.T) {
+	entries := []Entry{
+		{Link: testCID(t, "a"), Kind: KindReceipt},
+		{Link: testCID(t, "b"), Kind: KindClaim},
+	}
+	leaves := make([][]byte, len(entries))
+	for i, e := range entries {
+		leaves[i] = e.Link.Bytes()
+	}
+	root, _ := BuildTree(leaves)
+
+	miner, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	head, err := filtypes.NewTipSet([]*filtypes.BlockHeader{{
+		Height:                4200,
+		Timestamp:             1700000000,
+		Miner:                 miner,
+		Parents:               []cid.Cid{testCID(t, "parent")},
+		ParentStateRoot:       testCID(t, "state"),
+		ParentMessageReceipts: testCID(t, "receipts"),
+		Messages:              testCID(t, "messages"),
+	}})
+	require.NoError(t, err)
+
+	claim, err := NewAnchorClaim(testutil.Alice, root, head)
+	require.NoError(t, err)
+	require.Equal(t, testutil.Alice.DID(), claim.Issuer().DID())
+	// the time of the claim is that of the chain head
+	require.Equal(t, 1700000000, claim.NotBefore())
+	require.Nil(t, claim.Expiration())
+
+	capability := claim.Capabilities()[0]
+	require.Equal(t, assert.InclusionAbility, capability.Can())
+	nb, err := assert.InclusionCaveatsReader.Read(capability.Nb())
+	require.NoError(t, err)
+
+	digest, err := multihash.Decode(nb.Content.Hash())
+	require.NoError(t, err)
+	require.Equal(t, uint64(multihash.SHA2_256), digest.Code)
+	require.Equal(t, root, digest.Digest)
+
+	// only the witness is attached to the claim, not the anchored entries
+	var witness []byte
+	for b, err := range claim.Blocks() {
+		require.NoError(t, err)
+		if b.Link().String() == nb.Includes.String() {
+			witness = b.Bytes()
+		}
+		for _, e := range entries {
+			require.NotEqual(t, e.Link.String(), b.Link().String())
+		}
+	}
+	require.NotNil(t, witness, "anchor witness not attached")
+
+	nd := basicnode.Prototype.Map.NewBuilder()
+	require.NoError(t, dagcbor.Decode(nd, bytes.NewReader(witness)))
+	n := nd.Build()
+
+	r, err := n.LookupByString("root")
+	require.NoError(t, err)
+	rootBytes, err := r.AsBytes()
+	require.NoError(t, err)
+	require.Equal(t, root, rootBytes)
+
+	e, err := n.LookupByString("epoch")
+	require.NoError(t, err)
+	epoch, err := e.AsInt()
+	require.NoError(t, err)
+	require.Equal(t, int64(4200), epoch)
+
+	ts, err := n.LookupByString("tipset")
+	require.NoError(t, err)
+	l, err := ts.AsLink()
+	require.NoError(t, err)
+	tsk, err := head.Key().Cid()
+	require.NoError(t, err)
+	require.Equal(t, tsk, l.(cidlink.Link).Cid)
+}
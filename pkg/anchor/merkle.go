@@ -0,0 +1,93 @@
+package anchor
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Domain separation prefixes prevent an interior node from being presented as
+// a leaf (second preimage attacks).
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// Step is one level of a Merkle inclusion path.
+type Step struct {
+	// Hash is the sibling hash at this level.
+	Hash hexutil.Bytes `json:"hash"`
+	// Left is true when the sibling is the left hand node.
+	Left bool `json:"left"`
+}
+
+// LeafHash returns the hash of a leaf in the tree.
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// BuildTree computes the Merkle root of the passed leaves along with the
+// inclusion path of each leaf. A node without a sibling is promoted to the
+// next level unchanged. It returns a nil root when there are no leaves.
+func BuildTree(leaves [][]byte) ([]byte, [][]Step) {
+	if len(leaves) == 0 {
+		return nil, nil
+	}
+
+	level := make([][]byte, len(leaves))
+	for i, l := range leaves {
+		level[i] = LeafHash(l)
+	}
+	paths := make([][]Step, len(leaves))
+	// position of each leaf in the current level
+	pos := make([]int, len(leaves))
+	for i := range pos {
+		pos[i] = i
+	}
+
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 < len(level) {
+				next = append(next, nodeHash(level[i], level[i+1]))
+			} else {
+				next = append(next, level[i])
+			}
+		}
+		for leaf, p := range pos {
+			sibling := p ^ 1
+			if sibling < len(level) {
+				paths[leaf] = append(paths[leaf], Step{Hash: level[sibling], Left: sibling < p})
+			}
+			pos[leaf] = p / 2
+		}
+		level = next
+	}
+
+	return level[0], paths
+}
+
+// Verify reports whether data is included in the tree with the passed root.
+func Verify(root []byte, data []byte, path []Step) bool {
+	h := LeafHash(data)
+	for _, s := range path {
+		if s.Left {
+			h = nodeHash(s.Hash, h)
+		} else {
+			h = nodeHash(h, s.Hash)
+		}
+	}
+	return bytes.Equal(h, root)
+}
@@ -0,0 +1,47 @@
+package anchor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTree(t *testing.T) {
+	t.Run("no leaves", func(t *testing.T) {
+		root, paths := BuildTree(nil)
+		require.Nil(t, root)
+		require.Nil(t, paths)
+	})
+
+	t.Run("single leaf", func(t *testing.T) {
+		root, paths := BuildTree([][]byte{[]byte("a")})
+		require.Equal(t, LeafHash([]byte("a")), root)
+		require.Empty(t, paths[0])
+		require.True(t, Verify(root, []byte("a"), paths[0]))
+	})
+
+	for _, n := range []int{2, 3, 4, 5, 7, 8, 13} {
+		t.Run(fmt.Sprintf("%d leaves", n), func(t *testing.T) {
+			leaves := make([][]byte, n)
+			for i := range leaves {
+				leaves[i] = []byte(fmt.Sprintf("leaf-%d", i))
+			}
+			root, paths := BuildTree(leaves)
+			require.Len(t, paths, n)
+			for i, leaf := range leaves {
+				require.True(t, Verify(root, leaf, paths[i]), "leaf %d", i)
+				require.False(t, Verify(root, []byte("other"), paths[i]), "leaf %d", i)
+			}
+			// a proof is only valid for its own leaf
+			require.False(t, Verify(root, leaves[0], paths[1]))
+		})
+	}
+
+	t.Run("interior node is not a leaf", func(t *testing.T) {
+		leaves := [][]byte{[]byte("a"), []byte("b")}
+		root, _ := BuildTree(leaves)
+		interior := append(LeafHash(leaves[0]), LeafHash(leaves[1])...)
+		require.False(t, Verify(root, interior, nil))
+	})
+}
@@ -0,0 +1,56 @@
+package anchor
+
+import (
+	"context"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/receipt"
+
+	"github.com/storacha/piri/pkg/store/claimstore"
+	"github.com/storacha/piri/pkg/store/receiptstore"
+)
+
+// ReceiptStore records every receipt put in the wrapped store for anchoring.
+type ReceiptStore struct {
+	receiptstore.ReceiptStore
+	anchors *Store
+}
+
+var _ receiptstore.ReceiptStore = (*ReceiptStore)(nil)
+
+func NewReceiptStore(receipts receiptstore.ReceiptStore, anchors *Store) *ReceiptStore {
+	return &ReceiptStore{ReceiptStore: receipts, anchors: anchors}
+}
+
+func (s *ReceiptStore) Put(ctx context.Context, rcpt receipt.AnyReceipt) error {
+	if err := s.ReceiptStore.Put(ctx, rcpt); err != nil {
+		return err
+	}
+	// failing to record must not fail the operation issuing the receipt
+	if err := s.anchors.Record(ctx, rcpt.Root().Link(), KindReceipt); err != nil {
+		log.Errorw("recording receipt for anchoring", "receipt", rcpt.Root().Link(), "error", err)
+	}
+	return nil
+}
+
+// ClaimStore records every claim put in the wrapped store for anchoring.
+type ClaimStore struct {
+	claimstore.ClaimStore
+	anchors *Store
+}
+
+var _ claimstore.ClaimStore = (*ClaimStore)(nil)
+
+func NewClaimStore(claims claimstore.ClaimStore, anchors *Store) *ClaimStore {
+	return &ClaimStore{ClaimStore: claims, anchors: anchors}
+}
+
+func (s *ClaimStore) Put(ctx context.Context, dlg delegation.Delegation) error {
+	if err := s.ClaimStore.Put(ctx, dlg); err != nil {
+		return err
+	}
+	if err := s.anchors.Record(ctx, dlg.Link(), KindClaim); err != nil {
+		log.Errorw("recording claim for anchoring", "claim", dlg.Link(), "error", err)
+	}
+	return nil
+}
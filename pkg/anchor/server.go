@@ -0,0 +1,48 @@
+package anchor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ipfs/go-cid"
+	"github.com/labstack/echo/v4"
+
+	echofx "github.com/storacha/piri/pkg/fx/echo"
+	"github.com/storacha/piri/pkg/store"
+)
+
+var _ echofx.RouteRegistrar = (*Server)(nil)
+
+// Server serves the inclusion proofs of anchored receipts and claims.
+type Server struct {
+	store *Store
+}
+
+// NewServer creates the proof server. No routes are registered if store is
+// nil, i.e. anchoring is disabled.
+func NewServer(store *Store) *Server {
+	return &Server{store: store}
+}
+
+func (srv *Server) RegisterRoutes(e *echo.Echo) {
+	if srv.store == nil {
+		return
+	}
+	e.GET("/anchor/:cid", srv.getProof)
+}
+
+func (srv *Server) getProof(ctx echo.Context) error {
+	c, err := cid.Parse(ctx.Param("cid"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid CID: %w", err))
+	}
+	proof, err := srv.store.Proof(ctx.Request().Context(), c)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Errorf("not anchored: %s", c))
+		}
+		return fmt.Errorf("getting anchor proof: %w", err)
+	}
+	return ctx.JSON(http.StatusOK, proof)
+}
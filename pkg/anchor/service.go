@@ -0,0 +1,140 @@
+// Package anchor periodically commits a Merkle root of the receipts and claims
+// issued by the node to the chain or the indexing service. Inclusion proofs are kept locally and served
+// over HTTP, so third parties can verify when a receipt or claim was issued
+// long after it was issued, even if the node is no longer around to ask.
+package anchor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/storacha/piri/pkg/subsystem"
+)
+
+var log = logging.Logger("anchor")
+
+// Service periodically anchors recorded entries.
+type Service struct {
+	store    *Store
+	anchorer Anchorer
+	interval time.Duration
+	now      func() time.Time
+	// mu prevents concurrent anchoring of the same entries.
+	mu     sync.Mutex
+	pause  subsystem.Switch
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewService(store *Store, anchorer Anchorer, interval time.Duration) *Service {
+	return &Service{
+		store:    store,
+		anchorer: anchorer,
+		interval: interval,
+		now:      time.Now,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start starts anchoring periodically.
+func (s *Service) Start(ctx context.Context) error {
+	if s.interval <= 0 {
+		return fmt.Errorf("anchor interval must be greater than zero")
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	go s.run(runCtx)
+	log.Infof("anchoring started with interval: %v", s.interval)
+	return nil
+}
+
+// Stop stops anchoring. An anchor in progress is allowed to complete.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for anchoring to stop: %w", ctx.Err())
+	}
+}
+
+// Pause skips periodic anchoring until Resume is called. Entries continue to
+// be recorded and are anchored after resuming.
+func (s *Service) Pause() { s.pause.Pause() }
+
+// Resume continues periodic anchoring after a Pause.
+func (s *Service) Resume() { s.pause.Resume() }
+
+func (s *Service) run(ctx context.Context) {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.pause.Paused() {
+				continue
+			}
+			// do not abandon a submitted transaction when the node stops
+			if _, err := s.Anchor(context.WithoutCancel(ctx)); err != nil {
+				log.Errorw("anchoring entries", "error", err)
+			}
+		}
+	}
+}
+
+// Anchor commits the root of all pending entries and stores their inclusion
+// proofs. It returns nil if there was nothing to anchor.
+//
+// If the node stops after the root is committed but before the proofs are
+// stored, the entries remain pending and are included in the next anchor.
+func (s *Service) Anchor(ctx context.Context) (*Anchor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.store.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	leaves := make([][]byte, len(entries))
+	for i, e := range entries {
+		leaves[i] = e.Link.Bytes()
+	}
+	root, paths := BuildTree(leaves)
+
+	ref, err := s.anchorer.Anchor(ctx, root, entries)
+	if err != nil {
+		return nil, fmt.Errorf("anchoring root %x: %w", root, err)
+	}
+
+	anchor := Anchor{
+		Root:        root,
+		Transaction: ref,
+		Entries:     len(entries),
+		AnchoredAt:  s.now().UTC(),
+	}
+	proofs := make([]Proof, len(entries))
+	for i, e := range entries {
+		proofs[i] = Proof{Entry: e, Anchor: anchor, Path: paths[i]}
+	}
+	if err := s.store.Complete(ctx, anchor, proofs); err != nil {
+		return nil, err
+	}
+
+	log.Infow("anchored entries", "root", anchor.Root.String(), "transaction", ref, "entries", len(entries))
+	return &anchor, nil
+}
@@ -0,0 +1,95 @@
+package anchor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/store"
+)
+
+type mockAnchorer struct {
+	roots [][]byte
+	err   error
+}
+
+func (m *mockAnchorer) Anchor(ctx context.Context, root []byte, entries []Entry) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.roots = append(m.roots, root)
+	return "0xabc", nil
+}
+
+func testCID(t *testing.T, data string) cid.Cid {
+	t.Helper()
+	digest, err := multihash.Sum([]byte(data), multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	return cid.NewCidV1(cid.DagCBOR, digest)
+}
+
+func TestServiceAnchor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("anchors pending entries and stores proofs", func(t *testing.T) {
+		s := NewStore(dssync.MutexWrap(datastore.NewMapDatastore()))
+		anchorer := &mockAnchorer{}
+		svc := NewService(s, anchorer, 0)
+
+		links := []cid.Cid{testCID(t, "a"), testCID(t, "b"), testCID(t, "c")}
+		require.NoError(t, s.Record(ctx, cidlink.Link{Cid: links[0]}, KindReceipt))
+		require.NoError(t, s.Record(ctx, cidlink.Link{Cid: links[1]}, KindClaim))
+		require.NoError(t, s.Record(ctx, cidlink.Link{Cid: links[2]}, KindReceipt))
+
+		anchor, err := svc.Anchor(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, anchor)
+		require.Equal(t, 3, anchor.Entries)
+		require.Equal(t, "0xabc", anchor.Transaction)
+		require.Len(t, anchorer.roots, 1)
+		require.Equal(t, anchorer.roots[0], []byte(anchor.Root))
+
+		for _, l := range links {
+			proof, err := s.Proof(ctx, l)
+			require.NoError(t, err)
+			require.Equal(t, l, proof.Link)
+			require.Equal(t, anchor.Root, proof.Anchor.Root)
+			require.True(t, proof.Verify())
+		}
+
+		pending, err := s.Pending(ctx)
+		require.NoError(t, err)
+		require.Empty(t, pending)
+
+		// nothing left to anchor
+		anchor, err = svc.Anchor(ctx)
+		require.NoError(t, err)
+		require.Nil(t, anchor)
+		require.Len(t, anchorer.roots, 1)
+	})
+
+	t.Run("entries stay pending when anchoring fails", func(t *testing.T) {
+		s := NewStore(dssync.MutexWrap(datastore.NewMapDatastore()))
+		svc := NewService(s, &mockAnchorer{err: errors.New("boom")}, 0)
+
+		link := testCID(t, "a")
+		require.NoError(t, s.Record(ctx, cidlink.Link{Cid: link}, KindReceipt))
+
+		_, err := svc.Anchor(ctx)
+		require.Error(t, err)
+
+		pending, err := s.Pending(ctx)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+
+		_, err = s.Proof(ctx, link)
+		require.ErrorIs(t, err, store.ErrNotFound)
+	})
+}
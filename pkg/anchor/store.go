@@ -0,0 +1,166 @@
+package anchor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/ipld/go-ipld-prime/datamodel"
+
+	"github.com/storacha/piri/pkg/store"
+)
+
+// Kind is the kind of object recorded for anchoring.
+type Kind string
+
+const (
+	KindReceipt Kind = "receipt"
+	KindClaim   Kind = "claim"
+)
+
+var (
+	pendingPrefix = datastore.NewKey("pending")
+	proofPrefix   = datastore.NewKey("proofs")
+	anchorPrefix  = datastore.NewKey("anchors")
+)
+
+// Entry is an object recorded for inclusion in the next anchor.
+type Entry struct {
+	Link       cid.Cid   `json:"link"`
+	Kind       Kind      `json:"kind"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Anchor is a Merkle root committed on chain or with the indexing service.
+type Anchor struct {
+	// Root is the Merkle root of the anchored entries.
+	Root hexutil.Bytes `json:"root"`
+	// Transaction is the hash of the transaction committing the root, or the
+	// CID of the claim cached with the indexing service.
+	Transaction string `json:"transaction"`
+	// Entries is the number of entries in the tree.
+	Entries int `json:"entries"`
+	// AnchoredAt is the time the root was committed.
+	AnchoredAt time.Time `json:"anchored_at"`
+}
+
+// Proof allows a third party to verify an entry was included in an anchor. The
+// leaf of the tree is the binary CID of the entry.
+type Proof struct {
+	Entry
+	Anchor Anchor `json:"anchor"`
+	Path   []Step `json:"path"`
+}
+
+// Verify reports whether the proof is valid for its anchor root.
+func (p Proof) Verify() bool {
+	return Verify(p.Anchor.Root, p.Link.Bytes(), p.Path)
+}
+
+// Store persists entries awaiting anchoring, anchors, and the inclusion proofs
+// of anchored entries.
+type Store struct {
+	ds  datastore.Batching
+	now func() time.Time
+}
+
+func NewStore(ds datastore.Batching) *Store {
+	return &Store{ds: namespace.Wrap(ds, datastore.NewKey("anchor")), now: time.Now}
+}
+
+// Record adds a link to the next anchor.
+func (s *Store) Record(ctx context.Context, link datamodel.Link, kind Kind) error {
+	c, err := cid.Parse(link.String())
+	if err != nil {
+		return fmt.Errorf("parsing link: %w", err)
+	}
+	data, err := json.Marshal(Entry{Link: c, Kind: kind, RecordedAt: s.now().UTC()})
+	if err != nil {
+		return fmt.Errorf("encoding entry: %w", err)
+	}
+	if err := s.ds.Put(ctx, pendingPrefix.ChildString(c.String()), data); err != nil {
+		return fmt.Errorf("recording %s %s: %w", kind, c, err)
+	}
+	return nil
+}
+
+// Pending returns the entries awaiting anchoring.
+func (s *Store) Pending(ctx context.Context) ([]Entry, error) {
+	res, err := s.ds.Query(ctx, query.Query{Prefix: pendingPrefix.String()})
+	if err != nil {
+		return nil, fmt.Errorf("querying pending entries: %w", err)
+	}
+	defer res.Close()
+
+	var entries []Entry
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, fmt.Errorf("iterating pending entries: %w", r.Error)
+		}
+		var e Entry
+		if err := json.Unmarshal(r.Value, &e); err != nil {
+			return nil, fmt.Errorf("decoding pending entry %s: %w", r.Key, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Complete stores the anchor and the inclusion proofs of its entries, and
+// removes the entries from the pending set.
+func (s *Store) Complete(ctx context.Context, anchor Anchor, proofs []Proof) error {
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return fmt.Errorf("creating batch: %w", err)
+	}
+
+	data, err := json.Marshal(anchor)
+	if err != nil {
+		return fmt.Errorf("encoding anchor: %w", err)
+	}
+	if err := batch.Put(ctx, anchorPrefix.ChildString(anchor.Root.String()), data); err != nil {
+		return fmt.Errorf("storing anchor: %w", err)
+	}
+
+	for _, p := range proofs {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("encoding proof for %s: %w", p.Link, err)
+		}
+		if err := batch.Put(ctx, proofPrefix.ChildString(p.Link.String()), data); err != nil {
+			return fmt.Errorf("storing proof for %s: %w", p.Link, err)
+		}
+		if err := batch.Delete(ctx, pendingPrefix.ChildString(p.Link.String())); err != nil {
+			return fmt.Errorf("removing pending entry %s: %w", p.Link, err)
+		}
+	}
+
+	if err := batch.Commit(ctx); err != nil {
+		return fmt.Errorf("committing anchor %s: %w", anchor.Root, err)
+	}
+	return nil
+}
+
+// Proof returns the inclusion proof of an anchored link. It returns
+// store.ErrNotFound if the link has not been anchored.
+func (s *Store) Proof(ctx context.Context, link cid.Cid) (Proof, error) {
+	data, err := s.ds.Get(ctx, proofPrefix.ChildString(link.String()))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return Proof{}, store.ErrNotFound
+		}
+		return Proof{}, fmt.Errorf("getting proof for %s: %w", link, err)
+	}
+	var p Proof
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Proof{}, fmt.Errorf("decoding proof for %s: %w", link, err)
+	}
+	return p, nil
+}
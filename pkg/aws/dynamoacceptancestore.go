@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 	"fmt"
+	"iter"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -145,6 +146,54 @@ func (d *DynamoAcceptanceStore) Put(ctx context.Context, acc acceptance.Acceptan
 	return nil
 }
 
+// Delete implements acceptancestore.AcceptanceStore.
+func (d *DynamoAcceptanceStore) Delete(ctx context.Context, mh multihash.Multihash, space did.DID) error {
+	_, err := d.dynamoDbClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.tableName),
+		Key: map[string]types.AttributeValue{
+			"hash":  &types.AttributeValueMemberS{Value: digestutil.Format(mh)},
+			"space": &types.AttributeValueMemberS{Value: space.String()},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("deleting item: %w", err)
+	}
+	return nil
+}
+
+// List implements acceptancestore.AcceptanceStore. It performs a full table
+// scan, so should only be used by infrequent background tasks.
+func (d *DynamoAcceptanceStore) List(ctx context.Context) iter.Seq2[acceptance.Acceptance, error] {
+	return func(yield func(acceptance.Acceptance, error) bool) {
+		scanPaginator := dynamodb.NewScanPaginator(d.dynamoDbClient, &dynamodb.ScanInput{
+			TableName: aws.String(d.tableName),
+		})
+		for scanPaginator.HasMorePages() {
+			response, err := scanPaginator.NextPage(ctx)
+			if err != nil {
+				yield(acceptance.Acceptance{}, fmt.Errorf("scanning acceptances: %w", err))
+				return
+			}
+			var acceptancePage []acceptanceItem
+			err = attributevalue.UnmarshalListOfMaps(response.Items, &acceptancePage)
+			if err != nil {
+				yield(acceptance.Acceptance{}, fmt.Errorf("parsing scan responses: %w", err))
+				return
+			}
+			for _, item := range acceptancePage {
+				acc, err := acceptance.Decode(item.Acceptance, dagcbor.Decode)
+				if err != nil {
+					yield(acceptance.Acceptance{}, fmt.Errorf("decoding data: %w", err))
+					return
+				}
+				if !yield(acc, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
 type acceptanceItem struct {
 	Hash       string `dynamodbav:"hash"`
 	Space      string `dynamodbav:"space"`
@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 	"fmt"
+	"iter"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -195,6 +196,54 @@ func (d *DynamoAllocationStore) Put(ctx context.Context, alloc allocation.Alloca
 	return nil
 }
 
+// Delete implements allocationstore.AllocationStore.
+func (d *DynamoAllocationStore) Delete(ctx context.Context, mh multihash.Multihash, space did.DID) error {
+	_, err := d.dynamoDbClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.tableName),
+		Key: map[string]types.AttributeValue{
+			"hash":  &types.AttributeValueMemberS{Value: digestutil.Format(mh)},
+			"cause": &types.AttributeValueMemberS{Value: space.String()},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("deleting item: %w", err)
+	}
+	return nil
+}
+
+// List implements allocationstore.AllocationStore. It performs a full table
+// scan, so should only be used by infrequent background tasks.
+func (d *DynamoAllocationStore) List(ctx context.Context) iter.Seq2[allocation.Allocation, error] {
+	return func(yield func(allocation.Allocation, error) bool) {
+		scanPaginator := dynamodb.NewScanPaginator(d.dynamoDbClient, &dynamodb.ScanInput{
+			TableName: aws.String(d.tableName),
+		})
+		for scanPaginator.HasMorePages() {
+			response, err := scanPaginator.NextPage(ctx)
+			if err != nil {
+				yield(allocation.Allocation{}, fmt.Errorf("scanning allocations: %w", err))
+				return
+			}
+			var allocationPage []allocationItem
+			err = attributevalue.UnmarshalListOfMaps(response.Items, &allocationPage)
+			if err != nil {
+				yield(allocation.Allocation{}, fmt.Errorf("parsing scan responses: %w", err))
+				return
+			}
+			for _, item := range allocationPage {
+				a, err := allocation.Decode(item.Allocation, dagcbor.Decode)
+				if err != nil {
+					yield(allocation.Allocation{}, fmt.Errorf("decoding data: %w", err))
+					return
+				}
+				if !yield(a, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
 func (d *DynamoAllocationStore) list(ctx context.Context, mh multihash.Multihash) ([]allocation.Allocation, error) {
 	keyEx := expression.Key("hash").Equal(expression.Value(digestutil.Format(mh)))
 	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
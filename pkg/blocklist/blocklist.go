@@ -0,0 +1,381 @@
+// Package blocklist refuses content the operator must not store or serve,
+// e.g. following a takedown notice or because it is malware.
+//
+// Entries block a blob by its digest, or by the double hash used by badbits
+// denylists: the hex encoded sha2-256 of "<CIDv1 base32>/". Double hashes let
+// denylists be shared without listing the CIDs they block, and match a blob
+// if they hash any raw, dag-pb or CAR CID of its digest.
+//
+// Blocked blobs are refused on allocate, upload, replicate and retrieve.
+// Entries are added by operators, or synced from a remote denylist by a
+// [Syncer]. Blocking a blob does not delete the bytes already stored, which
+// a [Purger] does on request.
+package blocklist
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var log = logging.Logger("blocklist")
+
+// SourceAdmin is the source of entries added by an operator.
+const SourceAdmin = "admin"
+
+const (
+	digestsPrefix = "/digests/"
+	hashesPrefix  = "/hashes/"
+)
+
+// ErrNotFound is returned when removing an entry that is not in the list.
+var ErrNotFound = errors.New("blocklist entry not found")
+
+// doubleHashCodecs are the codecs of the CIDs of a blob matched against
+// double hash entries.
+var doubleHashCodecs = []multicodec.Code{multicodec.Raw, multicodec.DagPb, multicodec.Car}
+
+// Kind is the kind of an entry.
+type Kind string
+
+const (
+	// KindDigest blocks the blob with the digest.
+	KindDigest Kind = "digest"
+	// KindDoubleHash blocks the blobs whose CIDs hash to the double hash.
+	KindDoubleHash Kind = "double-hash"
+)
+
+// Key identifies what an entry blocks.
+type Key struct {
+	Kind Kind
+	// Value is the digest of the blob, multibase encoded, or the hex encoded
+	// double hash.
+	Value string
+}
+
+// DigestKey returns the key blocking the blob with the digest.
+func DigestKey(digest multihash.Multihash) Key {
+	return Key{Kind: KindDigest, Value: digestutil.Format(digest)}
+}
+
+// String returns the key as it is parsed by [ParseKey]: the digest of the
+// blob, or the double hash prefixed with "//" as in badbits denylists.
+func (k Key) String() string {
+	if k.Kind == KindDoubleHash {
+		return "//" + k.Value
+	}
+	return k.Value
+}
+
+func (k Key) dsKey() datastore.Key {
+	if k.Kind == KindDoubleHash {
+		return datastore.NewKey(hashesPrefix + k.Value)
+	}
+	return datastore.NewKey(digestsPrefix + k.Value)
+}
+
+// Entry is content blocked on the node.
+type Entry struct {
+	Key Key `json:"-"`
+	// Reason is why the content is blocked, e.g. a takedown notice.
+	Reason string `json:"reason,omitempty"`
+	// Source is SourceAdmin for entries added by an operator, or the URL of
+	// the denylist the entry was synced from.
+	Source  string    `json:"source"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// List persists the blocked content in a datastore. A nil List blocks
+// nothing.
+type List struct {
+	ds      datastore.Datastore
+	count   atomic.Int64
+	metrics *metrics
+	now     func() time.Time
+}
+
+// New creates a List persisting its entries in ds.
+func New(ctx context.Context, ds datastore.Datastore) (*List, error) {
+	metrics, err := newMetrics()
+	if err != nil {
+		return nil, err
+	}
+	l := &List{ds: ds, metrics: metrics, now: time.Now}
+	results, err := ds.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("counting blocklist entries: %w", err)
+	}
+	defer results.Close()
+	var n int64
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, fmt.Errorf("counting blocklist entries: %w", r.Error)
+		}
+		n++
+	}
+	l.setCount(ctx, n)
+	return l, nil
+}
+
+// Blocked reports whether the blob with the digest is blocked.
+func (l *List) Blocked(ctx context.Context, digest multihash.Multihash) (bool, error) {
+	if l == nil || l.count.Load() == 0 {
+		return false, nil
+	}
+	keys := []Key{DigestKey(digest)}
+	for _, codec := range doubleHashCodecs {
+		keys = append(keys, Key{Kind: KindDoubleHash, Value: doubleHash(cid.NewCidV1(uint64(codec), digest))})
+	}
+	for _, k := range keys {
+		has, err := l.ds.Has(ctx, k.dsKey())
+		if err != nil {
+			return false, fmt.Errorf("checking blocklist: %w", err)
+		}
+		if has {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Check returns a [ContentBlockedError] if the blob with the digest is
+// blocked, counting the refused operation.
+func (l *List) Check(ctx context.Context, op Operation, digest multihash.Multihash) error {
+	blocked, err := l.Blocked(ctx, digest)
+	if err != nil {
+		return err
+	}
+	if !blocked {
+		return nil
+	}
+	l.metrics.refused.Inc(ctx, attribute.String("operation", string(op)))
+	log.Infow("refused blocked content", "operation", op, "digest", digestutil.Format(digest))
+	return NewContentBlockedError(digest)
+}
+
+// Add blocks the content of the keys for the reason given. Entries synced
+// from a denylist are taken over by the operator, and kept when they are
+// removed from the denylist. It returns the number of keys not already
+// blocked.
+func (l *List) Add(ctx context.Context, keys []Key, reason string) (int, error) {
+	added := 0
+	for _, k := range keys {
+		has, err := l.ds.Has(ctx, k.dsKey())
+		if err != nil {
+			return added, fmt.Errorf("checking blocklist entry %s: %w", k, err)
+		}
+		if err := l.put(ctx, l.ds, Entry{Key: k, Reason: reason, Source: SourceAdmin, AddedAt: l.now().UTC()}); err != nil {
+			return added, err
+		}
+		if !has {
+			added++
+			l.setCount(ctx, l.count.Add(1))
+		}
+		log.Infow("blocked content", "entry", k, "reason", reason)
+	}
+	return added, nil
+}
+
+// Remove unblocks the content of the key. An entry synced from a denylist is
+// blocked again by the next sync if it is still listed.
+func (l *List) Remove(ctx context.Context, key Key) (Entry, error) {
+	e, err := l.Get(ctx, key)
+	if err != nil {
+		return Entry{}, err
+	}
+	if err := l.ds.Delete(ctx, key.dsKey()); err != nil {
+		return Entry{}, fmt.Errorf("deleting blocklist entry %s: %w", key, err)
+	}
+	l.setCount(ctx, l.count.Add(-1))
+	log.Infow("unblocked content", "entry", key)
+	return e, nil
+}
+
+// Get returns the entry of the key, or [ErrNotFound].
+func (l *List) Get(ctx context.Context, key Key) (Entry, error) {
+	data, err := l.ds.Get(ctx, key.dsKey())
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return Entry{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		return Entry{}, fmt.Errorf("getting blocklist entry %s: %w", key, err)
+	}
+	return decodeEntry(key.dsKey().String(), data)
+}
+
+// Entries returns up to limit entries from the source, or from every source
+// if source is empty. A limit of zero returns every entry.
+func (l *List) Entries(ctx context.Context, source string, limit int) ([]Entry, error) {
+	results, err := l.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return nil, fmt.Errorf("querying blocklist: %w", err)
+	}
+	defer results.Close()
+
+	var entries []Entry
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, fmt.Errorf("iterating blocklist: %w", r.Error)
+		}
+		e, err := decodeEntry(r.Key, r.Value)
+		if err != nil {
+			return nil, err
+		}
+		if source != "" && e.Source != source {
+			continue
+		}
+		entries = append(entries, e)
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// Count returns the number of entries.
+func (l *List) Count() int {
+	if l == nil {
+		return 0
+	}
+	return int(l.count.Load())
+}
+
+// ReplaceResult summarises the replacement of the entries of a source.
+type ReplaceResult struct {
+	// Added is the number of keys blocked that were not before.
+	Added int
+	// Removed is the number of entries of the source no longer listed.
+	Removed int
+}
+
+// Replace makes keys the entries of the source: keys not blocked yet are
+// added, and entries of the source not in keys are removed. Entries added by
+// operators are kept.
+func (l *List) Replace(ctx context.Context, source string, keys []Key) (ReplaceResult, error) {
+	var res ReplaceResult
+	listed := make(map[Key]struct{}, len(keys))
+	for _, k := range keys {
+		listed[k] = struct{}{}
+	}
+
+	results, err := l.ds.Query(ctx, query.Query{})
+	if err != nil {
+		return res, fmt.Errorf("querying blocklist: %w", err)
+	}
+	var stale []datastore.Key
+	for r := range results.Next() {
+		if r.Error != nil {
+			results.Close()
+			return res, fmt.Errorf("iterating blocklist: %w", r.Error)
+		}
+		e, err := decodeEntry(r.Key, r.Value)
+		if err != nil {
+			results.Close()
+			return res, err
+		}
+		if _, ok := listed[e.Key]; ok {
+			// already blocked, by the source or an operator
+			delete(listed, e.Key)
+			continue
+		}
+		if e.Source == source {
+			stale = append(stale, e.Key.dsKey())
+		}
+	}
+	results.Close()
+
+	w := l.writer(ctx)
+	for _, k := range stale {
+		if err := w.Delete(ctx, k); err != nil {
+			return res, fmt.Errorf("deleting blocklist entry %s: %w", k, err)
+		}
+	}
+	now := l.now().UTC()
+	for k := range listed {
+		if err := l.put(ctx, w, Entry{Key: k, Source: source, AddedAt: now}); err != nil {
+			return res, err
+		}
+	}
+	if err := w.Commit(ctx); err != nil {
+		return res, fmt.Errorf("committing blocklist: %w", err)
+	}
+
+	res.Added, res.Removed = len(listed), len(stale)
+	l.setCount(ctx, l.count.Add(int64(res.Added-res.Removed)))
+	return res, nil
+}
+
+type writer interface {
+	datastore.Write
+	Commit(ctx context.Context) error
+}
+
+// unbatched writes straight to a datastore that does not batch writes.
+type unbatched struct {
+	datastore.Write
+}
+
+func (unbatched) Commit(context.Context) error { return nil }
+
+func (l *List) writer(ctx context.Context) writer {
+	if bds, ok := l.ds.(datastore.Batching); ok {
+		if b, err := bds.Batch(ctx); err == nil {
+			return b
+		}
+	}
+	return unbatched{l.ds}
+}
+
+func (l *List) put(ctx context.Context, w datastore.Write, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding blocklist entry: %w", err)
+	}
+	if err := w.Put(ctx, e.Key.dsKey(), data); err != nil {
+		return fmt.Errorf("putting blocklist entry %s: %w", e.Key, err)
+	}
+	return nil
+}
+
+func (l *List) setCount(ctx context.Context, n int64) {
+	l.count.Store(n)
+	l.metrics.entries.Record(ctx, n)
+}
+
+func decodeEntry(key string, data []byte) (Entry, error) {
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Entry{}, fmt.Errorf("decoding blocklist entry %s: %w", key, err)
+	}
+	switch {
+	case strings.HasPrefix(key, digestsPrefix):
+		e.Key = Key{Kind: KindDigest, Value: strings.TrimPrefix(key, digestsPrefix)}
+	case strings.HasPrefix(key, hashesPrefix):
+		e.Key = Key{Kind: KindDoubleHash, Value: strings.TrimPrefix(key, hashesPrefix)}
+	default:
+		return Entry{}, fmt.Errorf("unknown blocklist key %s", key)
+	}
+	return e, nil
+}
+
+// doubleHash returns the hex encoded sha2-256 hash of "<CIDv1 base32>/", as
+// listed in badbits denylists.
+func doubleHash(c cid.Cid) string {
+	sum := sha256.Sum256([]byte(c.String() + "/"))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,192 @@
+package blocklist
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/multiformats/go-multicodec"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestList(t *testing.T) {
+	ctx := t.Context()
+	ds := datastore.NewMapDatastore()
+	l, err := New(ctx, ds)
+	require.NoError(t, err)
+	blob := testutil.RandomMultihash(t)
+
+	blocked, err := l.Blocked(ctx, blob)
+	require.NoError(t, err)
+	require.False(t, blocked)
+	require.NoError(t, l.Check(ctx, Retrieve, blob))
+
+	t.Run("blocks by digest", func(t *testing.T) {
+		added, err := l.Add(ctx, []Key{DigestKey(blob)}, "takedown notice")
+		require.NoError(t, err)
+		require.Equal(t, 1, added)
+		require.Equal(t, 1, l.Count())
+
+		var be *ContentBlockedError
+		require.ErrorAs(t, l.Check(ctx, Allocate, blob), &be)
+		require.Equal(t, ContentBlockedErrorName, be.Name())
+
+		entries, err := l.Entries(ctx, "", 0)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, DigestKey(blob), entries[0].Key)
+		require.Equal(t, "takedown notice", entries[0].Reason)
+		require.Equal(t, SourceAdmin, entries[0].Source)
+
+		// adding again is not counted
+		added, err = l.Add(ctx, []Key{DigestKey(blob)}, "takedown notice")
+		require.NoError(t, err)
+		require.Zero(t, added)
+
+		_, err = l.Remove(ctx, DigestKey(blob))
+		require.NoError(t, err)
+		require.NoError(t, l.Check(ctx, Allocate, blob))
+		require.Zero(t, l.Count())
+
+		_, err = l.Remove(ctx, DigestKey(blob))
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("blocks by double hash", func(t *testing.T) {
+		for _, codec := range []multicodec.Code{multicodec.Raw, multicodec.DagPb} {
+			c := cid.NewCidV1(uint64(codec), blob)
+			k, err := ParseKey("//" + doubleHash(c))
+			require.NoError(t, err)
+			_, err = l.Add(ctx, []Key{k}, "")
+			require.NoError(t, err)
+
+			blocked, err := l.Blocked(ctx, blob)
+			require.NoError(t, err)
+			require.True(t, blocked, codec.String())
+
+			_, err = l.Remove(ctx, k)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("counts persisted entries", func(t *testing.T) {
+		_, err := l.Add(ctx, []Key{DigestKey(blob), DigestKey(testutil.RandomMultihash(t))}, "")
+		require.NoError(t, err)
+
+		// simulate a restart
+		l, err := New(ctx, ds)
+		require.NoError(t, err)
+		require.Equal(t, 2, l.Count())
+		blocked, err := l.Blocked(ctx, blob)
+		require.NoError(t, err)
+		require.True(t, blocked)
+	})
+
+	t.Run("nil list blocks nothing", func(t *testing.T) {
+		var l *List
+		require.NoError(t, l.Check(ctx, Retrieve, blob))
+		require.Zero(t, l.Count())
+	})
+}
+
+func TestReplace(t *testing.T) {
+	ctx := t.Context()
+	l, err := New(ctx, datastore.NewMapDatastore())
+	require.NoError(t, err)
+	source := "https://badbits.example.com/deny.txt"
+
+	manual := DigestKey(testutil.RandomMultihash(t))
+	listed := DigestKey(testutil.RandomMultihash(t))
+	delisted := DigestKey(testutil.RandomMultihash(t))
+	_, err = l.Add(ctx, []Key{manual}, "malware")
+	require.NoError(t, err)
+
+	res, err := l.Replace(ctx, source, []Key{manual, listed, delisted})
+	require.NoError(t, err)
+	require.Equal(t, ReplaceResult{Added: 2}, res)
+	require.Equal(t, 3, l.Count())
+
+	res, err = l.Replace(ctx, source, []Key{listed})
+	require.NoError(t, err)
+	require.Equal(t, ReplaceResult{Removed: 1}, res)
+	require.Equal(t, 2, l.Count())
+
+	// the entry added by the operator is kept
+	e, err := l.Get(ctx, manual)
+	require.NoError(t, err)
+	require.Equal(t, SourceAdmin, e.Source)
+
+	_, err = l.Get(ctx, delisted)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	synced, err := l.Entries(ctx, source, 0)
+	require.NoError(t, err)
+	require.Len(t, synced, 1)
+	require.Equal(t, listed, synced[0].Key)
+}
+
+func TestSyncer(t *testing.T) {
+	ctx := t.Context()
+	l, err := New(ctx, datastore.NewMapDatastore())
+	require.NoError(t, err)
+	blob := testutil.RandomMultihash(t)
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprintf(w, "# badbits\n//%s\n/ipns/example.com\n", doubleHash(cid.NewCidV1(cid.Raw, blob)))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	s := NewSyncer(l, u, srv.Client(), time.Hour)
+	status, err := s.Sync(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, status.Entries)
+	require.Equal(t, 1, status.Skipped)
+	require.Equal(t, 1, status.Added)
+	require.NotNil(t, status.LastSync)
+
+	blocked, err := l.Blocked(ctx, blob)
+	require.NoError(t, err)
+	require.True(t, blocked)
+
+	// the denylist is unchanged
+	status, err = s.Sync(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, requests)
+	require.Equal(t, 1, status.Entries)
+	require.Zero(t, status.Added)
+
+	t.Run("records failures", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+		u, err := url.Parse(srv.URL)
+		require.NoError(t, err)
+
+		s := NewSyncer(l, u, srv.Client(), time.Hour)
+		status, err := s.Sync(ctx)
+		require.Error(t, err)
+		require.Contains(t, status.LastError, "503")
+		require.Nil(t, status.LastSync)
+
+		// entries synced from other denylists are kept
+		blocked, err := l.Blocked(ctx, blob)
+		require.NoError(t, err)
+		require.True(t, blocked)
+	})
+}
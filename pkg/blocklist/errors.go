@@ -0,0 +1,45 @@
+package blocklist
+
+import (
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/result/failure/datamodel"
+)
+
+// Operation is an operation on content refused when it is blocked.
+type Operation string
+
+const (
+	Allocate  Operation = "allocate"
+	Upload    Operation = "upload"
+	Replicate Operation = "replicate"
+	Retrieve  Operation = "retrieve"
+)
+
+// ContentBlockedErrorName is the name of the failure returned in receipts
+// when an invocation is refused because its blob is blocked.
+const ContentBlockedErrorName = "ContentBlocked"
+
+// ContentBlockedError is returned for operations on blobs the node blocks.
+type ContentBlockedError struct {
+	Digest multihash.Multihash
+}
+
+func (be ContentBlockedError) Name() string {
+	return ContentBlockedErrorName
+}
+
+func (be ContentBlockedError) Error() string {
+	return "content is blocked by the storage node: " + digestutil.Format(be.Digest)
+}
+
+func (be ContentBlockedError) ToIPLD() (ipld.Node, error) {
+	name := be.Name()
+	model := datamodel.FailureModel{Name: &name, Message: be.Error()}
+	return model.ToIPLD()
+}
+
+func NewContentBlockedError(digest multihash.Multihash) *ContentBlockedError {
+	return &ContentBlockedError{digest}
+}
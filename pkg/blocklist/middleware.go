@@ -0,0 +1,63 @@
+package blocklist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// Middleware rejects requests for blocked blobs, whose digest is the "blob"
+// path parameter, with 451 Unavailable For Legal Reasons. Requests with an
+// invalid digest are passed through for the handler to reject. With a nil
+// list requests are passed through.
+func (l *List) Middleware(op Operation) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			digest, err := digestutil.Parse(c.Param("blob"))
+			if err != nil {
+				return next(c)
+			}
+			if err := l.Check(c.Request().Context(), op, digest); err != nil {
+				var be *ContentBlockedError
+				if errors.As(err, &be) {
+					return echo.NewHTTPError(http.StatusUnavailableForLegalReasons, err.Error())
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			return next(c)
+		}
+	}
+}
+
+// BlobGetter wraps blobs so blocked blobs are not found, e.g. to refuse their
+// retrieval over UCAN or Bitswap. With a nil list blobs is returned as is.
+func (l *List) BlobGetter(blobs blobstore.BlobGetter) blobstore.BlobGetter {
+	if l == nil {
+		return blobs
+	}
+	return &blobGetter{list: l, blobs: blobs}
+}
+
+type blobGetter struct {
+	list  *List
+	blobs blobstore.BlobGetter
+}
+
+func (g *blobGetter) Get(ctx context.Context, digest multihash.Multihash, opts ...blobstore.GetOption) (blobstore.Object, error) {
+	if err := g.list.Check(ctx, Retrieve, digest); err != nil {
+		var be *ContentBlockedError
+		if errors.As(err, &be) {
+			return nil, fmt.Errorf("%w: %s", store.ErrNotFound, err)
+		}
+		return nil, err
+	}
+	return g.blobs.Get(ctx, digest, opts...)
+}
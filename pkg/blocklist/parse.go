@@ -0,0 +1,96 @@
+package blocklist
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+)
+
+// ParseKey parses what an entry blocks from:
+//
+//   - a double hash prefixed with "//", hex encoded as in the legacy badbits
+//     denylist, or a base58 sha2-256 multihash as in compact denylists
+//   - a CID, optionally prefixed with "/ipfs/"
+//   - a multibase encoded multihash, as blob digests are formatted
+func ParseKey(s string) (Key, error) {
+	s = strings.TrimSpace(s)
+	if h, ok := strings.CutPrefix(s, "//"); ok {
+		return parseDoubleHash(h)
+	}
+	if p, ok := strings.CutPrefix(s, "/ipfs/"); ok {
+		p = strings.TrimSuffix(p, "/")
+		if strings.Contains(p, "/") {
+			return Key{}, fmt.Errorf("blocking paths is not supported: %s", s)
+		}
+		s = p
+	}
+	if c, err := cid.Decode(s); err == nil {
+		return DigestKey(c.Hash()), nil
+	}
+	digest, err := digestutil.Parse(s)
+	if err != nil {
+		return Key{}, fmt.Errorf("%q is not a CID, digest or double hash", s)
+	}
+	return DigestKey(digest), nil
+}
+
+func parseDoubleHash(h string) (Key, error) {
+	if len(h) == 2*32 {
+		if b, err := hex.DecodeString(h); err == nil {
+			return Key{Kind: KindDoubleHash, Value: hex.EncodeToString(b)}, nil
+		}
+	}
+	mh, err := multihash.FromB58String(h)
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid double hash %q", h)
+	}
+	decoded, err := multihash.Decode(mh)
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid double hash %q: %w", h, err)
+	}
+	if decoded.Code != multihash.SHA2_256 {
+		return Key{}, fmt.Errorf("unsupported double hash function %s", decoded.Name)
+	}
+	return Key{Kind: KindDoubleHash, Value: hex.EncodeToString(decoded.Digest)}, nil
+}
+
+// Parse reads the keys of a denylist, one per line. Blank lines and comments
+// starting with "#" are ignored, as is the header of compact denylists,
+// ended by a "---" line. Lines that do not block a CID or digest, e.g. those
+// blocking IPNS names or paths or allowing content with "!", are skipped and
+// counted.
+func Parse(r io.Reader) ([]Key, int, error) {
+	var keys []Key
+	skipped := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "---" {
+			// the lines read so far were the header
+			keys, skipped = nil, 0
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// compact denylists may follow a rule with hints
+		rule, _, _ := strings.Cut(line, " ")
+		k, err := ParseKey(rule)
+		if err != nil {
+			skipped++
+			continue
+		}
+		keys = append(keys, k)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("reading denylist: %w", err)
+	}
+	return keys, skipped, nil
+}
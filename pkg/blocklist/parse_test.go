@@ -0,0 +1,73 @@
+package blocklist
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKey(t *testing.T) {
+	blob := testutil.RandomMultihash(t)
+	c := cid.NewCidV1(cid.Raw, blob)
+	sum := sha256.Sum256([]byte(c.String() + "/"))
+	hash := hex.EncodeToString(sum[:])
+	b58, err := multihash.Sum([]byte(c.String()+"/"), multihash.SHA2_256, -1)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		key   Key
+	}{
+		{"CID", c.String(), DigestKey(blob)},
+		{"IPFS path", "/ipfs/" + c.String(), DigestKey(blob)},
+		{"digest", digestutil.Format(blob), DigestKey(blob)},
+		{"badbits double hash", "//" + hash, Key{Kind: KindDoubleHash, Value: hash}},
+		{"upper case double hash", "//" + strings.ToUpper(hash), Key{Kind: KindDoubleHash, Value: hash}},
+		{"compact double hash", "//" + b58.B58String(), Key{Kind: KindDoubleHash, Value: hash}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			k, err := ParseKey(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.key, k)
+
+			// keys round trip
+			k, err = ParseKey(k.String())
+			require.NoError(t, err)
+			require.Equal(t, tc.key, k)
+		})
+	}
+
+	for _, input := range []string{"", "/ipns/example.com", "/ipfs/" + c.String() + "/path", "//nothex", "not a cid"} {
+		_, err := ParseKey(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestParse(t *testing.T) {
+	blob := testutil.RandomMultihash(t)
+	c := cid.NewCidV1(cid.DagProtobuf, blob)
+
+	t.Run("badbits", func(t *testing.T) {
+		list := "# comment\n\n//" + doubleHash(c) + "\n/ipns/example.com\n"
+		keys, skipped, err := Parse(strings.NewReader(list))
+		require.NoError(t, err)
+		require.Equal(t, []Key{{Kind: KindDoubleHash, Value: doubleHash(c)}}, keys)
+		require.Equal(t, 1, skipped)
+	})
+
+	t.Run("compact denylist", func(t *testing.T) {
+		list := "version: 1\nname: example\n---\n/ipfs/" + c.String() + " reason:malware\n!/ipfs/" + c.String() + "\n"
+		keys, skipped, err := Parse(strings.NewReader(list))
+		require.NoError(t, err)
+		require.Equal(t, []Key{DigestKey(blob)}, keys)
+		require.Equal(t, 1, skipped)
+	})
+}
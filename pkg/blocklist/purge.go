@@ -0,0 +1,51 @@
+package blocklist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/contentindex"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// Purger deletes the bytes of blocked blobs held by the node.
+//
+// Allocations and acceptances of the blob are kept, so spaces still account
+// for it, but it can no longer be retrieved. With PDP, the root the blob was
+// aggregated into can no longer be proven once its bytes are deleted.
+type Purger struct {
+	blobs blobstore.Blobstore
+	index *contentindex.Index
+}
+
+// NewPurger creates a Purger deleting blobs from the blob store, and from the
+// content index so they are uploaded again if allocated once unblocked.
+func NewPurger(blobs blobstore.Blobstore, index *contentindex.Index) *Purger {
+	return &Purger{blobs: blobs, index: index}
+}
+
+// Purge deletes the bytes of the blob, returning false if the node does not
+// hold it.
+func (p *Purger) Purge(ctx context.Context, digest multihash.Multihash) (bool, error) {
+	if err := p.index.Delete(ctx, digest); err != nil {
+		return false, err
+	}
+	obj, err := p.blobs.Get(ctx, digest)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting blob: %w", err)
+	}
+	obj.Body().Close()
+	if err := p.blobs.Delete(ctx, digest); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return false, fmt.Errorf("deleting blob: %w", err)
+	}
+	log.Warnw("purged blocked blob", "digest", digestutil.Format(digest))
+	return true, nil
+}
@@ -0,0 +1,194 @@
+package blocklist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultSyncInterval is how often the remote denylist is synced.
+const DefaultSyncInterval = time.Hour
+
+// ErrSyncing is returned when a sync is requested while one is running.
+var ErrSyncing = errors.New("blocklist sync already running")
+
+// SyncStatus describes the last sync of the remote denylist.
+type SyncStatus struct {
+	URL string `json:"url"`
+	// LastSync is when the denylist was last synced successfully.
+	LastSync *time.Time `json:"last_sync,omitempty"`
+	// LastError is the error of the last sync, if it failed.
+	LastError string `json:"last_error,omitempty"`
+	// Entries is the number of keys in the denylist at the last sync.
+	Entries int `json:"entries"`
+	// Skipped is the number of lines of the denylist that block neither a CID
+	// nor a digest, e.g. IPNS names or paths.
+	Skipped int `json:"skipped,omitempty"`
+	// Added and Removed are the changes made to the blocklist by the last
+	// sync.
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// Syncer keeps the entries of a remote denylist, in badbits or compact
+// denylist format, in the blocklist.
+type Syncer struct {
+	list     *List
+	url      *url.URL
+	client   *http.Client
+	interval time.Duration
+
+	running sync.Mutex
+	mu      sync.RWMutex
+	status  SyncStatus
+	etag    string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSyncer creates a Syncer of the denylist at u, fetched with client every
+// interval, or DefaultSyncInterval if zero.
+func NewSyncer(list *List, u *url.URL, client *http.Client, interval time.Duration) *Syncer {
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+	return &Syncer{
+		list:     list,
+		url:      u,
+		client:   client,
+		interval: interval,
+		status:   SyncStatus{URL: u.String()},
+	}
+}
+
+// Start syncs the denylist in the background, right away and every interval.
+func (s *Syncer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go s.run(ctx)
+}
+
+// Stop stops syncing, waiting for a running sync to be cancelled.
+func (s *Syncer) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Syncer) run(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Sync(ctx); err != nil && ctx.Err() == nil && !errors.Is(err, ErrSyncing) {
+			log.Errorw("syncing blocklist", "url", s.url.String(), "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the status of the last sync.
+func (s *Syncer) Status() SyncStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Sync fetches the denylist and replaces the entries synced from it. A
+// denylist unchanged since the last sync, according to its ETag, is not
+// fetched again.
+func (s *Syncer) Sync(ctx context.Context) (SyncStatus, error) {
+	if !s.running.TryLock() {
+		return s.Status(), ErrSyncing
+	}
+	defer s.running.Unlock()
+
+	res, skipped, unchanged, err := s.sync(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.status.LastError = err.Error()
+		s.list.metrics.synced.Inc(ctx, attribute.String("result", "failed"))
+		return s.status, err
+	}
+	now := time.Now().UTC()
+	s.status.LastSync = &now
+	s.status.LastError = ""
+	if unchanged {
+		s.status.Added, s.status.Removed = 0, 0
+		s.list.metrics.synced.Inc(ctx, attribute.String("result", "unchanged"))
+		return s.status, nil
+	}
+	s.status.Entries = res.entries
+	s.status.Skipped = skipped
+	s.status.Added, s.status.Removed = res.Added, res.Removed
+	s.list.metrics.synced.Inc(ctx, attribute.String("result", "updated"))
+	if res.Added > 0 || res.Removed > 0 {
+		log.Infow("synced blocklist", "url", s.url.String(), "entries", res.entries, "added", res.Added, "removed", res.Removed)
+	}
+	return s.status, nil
+}
+
+type syncResult struct {
+	ReplaceResult
+	entries int
+}
+
+func (s *Syncer) sync(ctx context.Context) (syncResult, int, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url.String(), nil)
+	if err != nil {
+		return syncResult{}, 0, false, fmt.Errorf("creating denylist request: %w", err)
+	}
+	s.mu.RLock()
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	s.mu.RUnlock()
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return syncResult{}, 0, false, fmt.Errorf("fetching denylist: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified {
+		return syncResult{}, 0, true, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return syncResult{}, 0, false, fmt.Errorf("fetching denylist: unexpected status %d: %s", res.StatusCode, body)
+	}
+
+	keys, skipped, err := Parse(res.Body)
+	if err != nil {
+		return syncResult{}, 0, false, err
+	}
+	replaced, err := s.list.Replace(ctx, s.url.String(), keys)
+	if err != nil {
+		return syncResult{}, 0, false, err
+	}
+	s.mu.Lock()
+	s.etag = res.Header.Get("ETag")
+	s.mu.Unlock()
+	return syncResult{ReplaceResult: replaced, entries: len(keys)}, skipped, false, nil
+}
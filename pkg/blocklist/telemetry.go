@@ -0,0 +1,45 @@
+package blocklist
+
+import (
+	"go.opentelemetry.io/otel"
+
+	"github.com/storacha/piri/lib/telemetry"
+)
+
+type metrics struct {
+	entries *telemetry.Int64Gauge
+	refused *telemetry.Counter
+	synced  *telemetry.Counter
+}
+
+func newMetrics() (*metrics, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/blocklist")
+	entries, err := telemetry.NewInt64Gauge(
+		meter,
+		"piri_blocklist_entries",
+		"digests and double hashes blocked on the node",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	refused, err := telemetry.NewCounter(
+		meter,
+		"piri_blocklist_refused",
+		"operations refused because their blob is blocked, by operation (allocate, upload, replicate or retrieve)",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	synced, err := telemetry.NewCounter(
+		meter,
+		"piri_blocklist_syncs",
+		"syncs of the blocklist from the remote denylist, by result (updated, unchanged or failed)",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &metrics{entries: entries, refused: refused, synced: synced}, nil
+}
@@ -0,0 +1,72 @@
+package build
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Network is the default network baked into the binary at build time.
+// Set with ldflags via -ldflags="-X github.com/storacha/piri/pkg/build.Network=forge-prod".
+// When empty, no network defaults are applied unless the operator passes --network.
+var Network string
+
+// contractBindingModules are the go modules that provide the generated smart
+// contract bindings used by piri. Their versions are reported as part of the
+// build info so operators can tell which contract ABIs a binary speaks.
+var contractBindingModules = []string{
+	"github.com/storacha/filecoin-services/go",
+}
+
+// Info describes the provenance of the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	BuiltBy   string `json:"built_by"`
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	// Network is the default network the binary was built for, if any.
+	Network string `json:"network,omitempty"`
+	// Contracts maps contract binding module paths to their versions.
+	Contracts map[string]string `json:"contracts,omitempty"`
+}
+
+// GetInfo returns the build metadata embedded in the running binary.
+func GetInfo() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		BuiltBy:   BuiltBy,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Network:   Network,
+		Contracts: map[string]string{},
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	for _, dep := range bi.Deps {
+		for _, mod := range contractBindingModules {
+			if dep.Path != mod {
+				continue
+			}
+			// honour replace directives so local contract checkouts are visible
+			if dep.Replace != nil {
+				info.Contracts[mod] = dep.Replace.Version
+				if dep.Replace.Version == "" {
+					info.Contracts[mod] = dep.Replace.Path
+				}
+				continue
+			}
+			info.Contracts[mod] = dep.Version
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,150 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// InTotoPayloadType is the DSSE payload type of in-toto attestations.
+	InTotoPayloadType = "application/vnd.in-toto+json"
+	// SourceRepository is the repository release provenance must reference.
+	SourceRepository = "github.com/storacha/piri"
+	// TrustedBuilderPrefix is the builder ID prefix of the SLSA generator used
+	// by the release workflow.
+	TrustedBuilderPrefix = "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml@"
+)
+
+// ErrSubjectNotFound is returned when a digest is not listed as a subject of
+// the provenance.
+var ErrSubjectNotFound = errors.New("digest not found in provenance subjects")
+
+// ProvenanceSubject is an artifact attested to by a provenance statement.
+type ProvenanceSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Provenance is the subset of a SLSA provenance statement piri verifies.
+type Provenance struct {
+	Subjects  []ProvenanceSubject
+	BuilderID string
+	// SourceURI is the URI of the source the artifacts were built from, e.g.
+	// git+https://github.com/storacha/piri@refs/tags/v1.2.3
+	SourceURI string
+	// SourceCommit is the git commit the artifacts were built from.
+	SourceCommit string
+}
+
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+}
+
+type inTotoStatement struct {
+	Subject   []ProvenanceSubject `json:"subject"`
+	Predicate struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		Invocation struct {
+			ConfigSource struct {
+				URI    string            `json:"uri"`
+				Digest map[string]string `json:"digest"`
+			} `json:"configSource"`
+		} `json:"invocation"`
+	} `json:"predicate"`
+}
+
+// ParseProvenance parses a SLSA provenance file in in-toto JSON lines format,
+// as published alongside release artifacts (*.intoto.jsonl). Subjects from all
+// attestations in the file are merged.
+//
+// Signatures on the DSSE envelopes are not checked here; use slsa-verifier for
+// full cryptographic verification of the attestation.
+func ParseProvenance(r io.Reader) (*Provenance, error) {
+	prov := &Provenance{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var env dsseEnvelope
+		if err := json.Unmarshal(line, &env); err != nil {
+			return nil, fmt.Errorf("decoding attestation envelope: %w", err)
+		}
+		if env.PayloadType != InTotoPayloadType {
+			return nil, fmt.Errorf("unexpected attestation payload type: %q", env.PayloadType)
+		}
+		payload, err := base64.StdEncoding.DecodeString(env.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("decoding attestation payload: %w", err)
+		}
+
+		var stmt inTotoStatement
+		if err := json.Unmarshal(payload, &stmt); err != nil {
+			return nil, fmt.Errorf("decoding in-toto statement: %w", err)
+		}
+		if prov.BuilderID != "" && prov.BuilderID != stmt.Predicate.Builder.ID {
+			return nil, fmt.Errorf("conflicting builder IDs in provenance: %q and %q", prov.BuilderID, stmt.Predicate.Builder.ID)
+		}
+		prov.BuilderID = stmt.Predicate.Builder.ID
+		prov.SourceURI = stmt.Predicate.Invocation.ConfigSource.URI
+		prov.SourceCommit = stmt.Predicate.Invocation.ConfigSource.Digest["sha1"]
+		prov.Subjects = append(prov.Subjects, stmt.Subject...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading provenance: %w", err)
+	}
+	if len(prov.Subjects) == 0 {
+		return nil, fmt.Errorf("provenance contains no subjects")
+	}
+	return prov, nil
+}
+
+// VerifyDigest checks that the provenance was produced by the trusted builder
+// from the piri source repository, and that it attests to an artifact with
+// the given sha256 digest. It returns the matching subject.
+func (p *Provenance) VerifyDigest(digest []byte) (ProvenanceSubject, error) {
+	if !strings.HasPrefix(p.BuilderID, TrustedBuilderPrefix) {
+		return ProvenanceSubject{}, fmt.Errorf("untrusted builder: %q", p.BuilderID)
+	}
+	if !strings.Contains(p.SourceURI, SourceRepository) {
+		return ProvenanceSubject{}, fmt.Errorf("provenance source %q is not %s", p.SourceURI, SourceRepository)
+	}
+
+	want := hex.EncodeToString(digest)
+	for _, s := range p.Subjects {
+		if strings.EqualFold(s.Digest["sha256"], want) {
+			return s, nil
+		}
+	}
+	return ProvenanceSubject{}, fmt.Errorf("%w: sha256:%s", ErrSubjectNotFound, want)
+}
+
+// FileDigest returns the sha256 digest of the file at the given path.
+func FileDigest(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,79 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testProvenance(t *testing.T, builderID string, subjects ...ProvenanceSubject) string {
+	t.Helper()
+	stmt := map[string]any{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"predicateType": "https://slsa.dev/provenance/v0.2",
+		"subject":       subjects,
+		"predicate": map[string]any{
+			"builder": map[string]any{"id": builderID},
+			"invocation": map[string]any{
+				"configSource": map[string]any{
+					"uri":    "git+https://github.com/storacha/piri@refs/tags/v1.2.3",
+					"digest": map[string]string{"sha1": "0123456789abcdef"},
+				},
+			},
+		},
+	}
+	payload, err := json.Marshal(stmt)
+	require.NoError(t, err)
+	env, err := json.Marshal(map[string]any{
+		"payloadType": InTotoPayloadType,
+		"payload":     base64.StdEncoding.EncodeToString(payload),
+		"signatures":  []any{},
+	})
+	require.NoError(t, err)
+	return string(env) + "\n"
+}
+
+func TestProvenance(t *testing.T) {
+	digest := sha256.Sum256([]byte("piri"))
+	subject := ProvenanceSubject{
+		Name:   "piri_1.2.3_linux_amd64.tar.gz",
+		Digest: map[string]string{"sha256": hex.EncodeToString(digest[:])},
+	}
+
+	t.Run("verifies attested digest", func(t *testing.T) {
+		prov, err := ParseProvenance(strings.NewReader(testProvenance(t, TrustedBuilderPrefix+"refs/tags/v2.1.0", subject)))
+		require.NoError(t, err)
+		require.Equal(t, "0123456789abcdef", prov.SourceCommit)
+
+		got, err := prov.VerifyDigest(digest[:])
+		require.NoError(t, err)
+		require.Equal(t, subject.Name, got.Name)
+	})
+
+	t.Run("rejects unknown digest", func(t *testing.T) {
+		prov, err := ParseProvenance(strings.NewReader(testProvenance(t, TrustedBuilderPrefix+"refs/tags/v2.1.0", subject)))
+		require.NoError(t, err)
+
+		other := sha256.Sum256([]byte("not piri"))
+		_, err = prov.VerifyDigest(other[:])
+		require.ErrorIs(t, err, ErrSubjectNotFound)
+	})
+
+	t.Run("rejects untrusted builder", func(t *testing.T) {
+		prov, err := ParseProvenance(strings.NewReader(testProvenance(t, "https://example.com/builder", subject)))
+		require.NoError(t, err)
+
+		_, err = prov.VerifyDigest(digest[:])
+		require.ErrorContains(t, err, "untrusted builder")
+	})
+
+	t.Run("rejects empty provenance", func(t *testing.T) {
+		_, err := ParseProvenance(strings.NewReader(""))
+		require.Error(t, err)
+	})
+}
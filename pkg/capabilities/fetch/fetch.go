@@ -0,0 +1,76 @@
+// Package fetch defines the blob/fetch capability, with which a storage node
+// is asked to pull a blob from a URL rather than have it uploaded.
+package fetch
+
+import (
+	"net/url"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/storacha/go-ucanto/core/schema"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/validator"
+)
+
+const FetchAbility = "blob/fetch"
+
+// FetchSiteSelector is the selector for extracting the location commitment
+// link from the receipt of the "blob/accept" task a fetch resolves to.
+const FetchSiteSelector = ".out.ok.site"
+
+var _ ipld.Builder = (*FetchCaveats)(nil)
+
+type FetchCaveats struct {
+	// Space is the space to allocate the blob in.
+	Space did.DID
+	// Blob is the blob to fetch. The content at URL must have exactly this
+	// size and digest.
+	Blob types.Blob
+	// URL is where the blob is fetched from, with HTTP GET requests.
+	URL url.URL
+}
+
+func (fc FetchCaveats) ToIPLD() (datamodel.Node, error) {
+	return ipld.WrapWithRecovery(&fc, FetchCaveatsType(), types.Converters...)
+}
+
+type FetchOk struct {
+	// Size is the number of bytes allocated for the blob, 0 if it was already
+	// allocated in the space.
+	Size uint64
+	// Site resolves to the location commitment for the blob once it has been
+	// fetched. The selector is [FetchSiteSelector] and it links to a
+	// "blob/accept" task issued by the node.
+	Site types.Promise
+}
+
+func (fo FetchOk) ToIPLD() (datamodel.Node, error) {
+	return ipld.WrapWithRecovery(&fo, FetchOkType(), types.Converters...)
+}
+
+var FetchOkReader = schema.Struct[FetchOk](FetchOkType(), nil, types.Converters...)
+
+type FetchReceipt receipt.Receipt[FetchOk, failure.Failure]
+type FetchReceiptReader receipt.ReceiptReader[FetchOk, failure.Failure]
+
+func NewFetchReceiptReader() (FetchReceiptReader, error) {
+	return receipt.NewReceiptReader[FetchOk, failure.Failure](fetchSchema)
+}
+
+var FetchCaveatsReader = schema.Struct[FetchCaveats](FetchCaveatsType(), nil, types.Converters...)
+
+// Fetch is a capability that allows an agent to have a storage node, the
+// did:key in the `with` field, fetch a blob from a URL into a space.
+//
+// The fetch runs asynchronously. The receipt of the invocation includes a
+// "blob/accept" task the node completes once the blob has been fetched and
+// verified, and a location commitment issued.
+var Fetch = validator.NewCapability(
+	FetchAbility,
+	schema.DIDString(),
+	FetchCaveatsReader,
+	validator.DefaultDerives,
+)
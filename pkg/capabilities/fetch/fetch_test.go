@@ -0,0 +1,47 @@
+package fetch
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTripFetchCaveats(t *testing.T) {
+	nb := FetchCaveats{
+		Space: testutil.RandomDID(t),
+		Blob: types.Blob{
+			Digest: testutil.RandomMultihash(t),
+			Size:   1 << 30,
+		},
+		URL: *testutil.Must(url.Parse("https://example.com/datasets/part-0001.car"))(t),
+	}
+
+	node, err := nb.ToIPLD()
+	require.NoError(t, err)
+
+	rnb, err := FetchCaveatsReader.Read(node)
+	require.NoError(t, err)
+	require.Equal(t, nb, rnb)
+}
+
+func TestRoundTripFetchOk(t *testing.T) {
+	ok := FetchOk{
+		Size: 1 << 30,
+		Site: types.Promise{
+			UcanAwait: types.Await{
+				Selector: FetchSiteSelector,
+				Link:     testutil.RandomCID(t),
+			},
+		},
+	}
+
+	node, err := ok.ToIPLD()
+	require.NoError(t, err)
+
+	rok, err := FetchOkReader.Read(node)
+	require.NoError(t, err)
+	require.Equal(t, ok, rok)
+}
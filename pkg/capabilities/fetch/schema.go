@@ -0,0 +1,31 @@
+package fetch
+
+import (
+	// for schema embed
+	_ "embed"
+	"fmt"
+
+	"github.com/ipld/go-ipld-prime/schema"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+)
+
+//go:embed fetch.ipldsch
+var fetchSchema []byte
+
+var fetchTS = mustLoadTS()
+
+func mustLoadTS() *schema.TypeSystem {
+	ts, err := types.LoadSchemaBytes(fetchSchema)
+	if err != nil {
+		panic(fmt.Errorf("loading fetch schema: %w", err))
+	}
+	return ts
+}
+
+func FetchCaveatsType() schema.Type {
+	return fetchTS.TypeByName("FetchCaveats")
+}
+
+func FetchOkType() schema.Type {
+	return fetchTS.TypeByName("FetchOk")
+}
@@ -0,0 +1,31 @@
+package usage
+
+import (
+	// for schema embed
+	_ "embed"
+	"fmt"
+
+	"github.com/ipld/go-ipld-prime/schema"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+)
+
+//go:embed usage.ipldsch
+var usageSchema []byte
+
+var usageTS = mustLoadTS()
+
+func mustLoadTS() *schema.TypeSystem {
+	ts, err := types.LoadSchemaBytes(usageSchema)
+	if err != nil {
+		panic(fmt.Errorf("loading usage schema: %w", err))
+	}
+	return ts
+}
+
+func ReportCaveatsType() schema.Type {
+	return usageTS.TypeByName("ReportCaveats")
+}
+
+func ReportOkType() schema.Type {
+	return usageTS.TypeByName("ReportOk")
+}
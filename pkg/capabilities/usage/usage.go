@@ -0,0 +1,86 @@
+// Package usage defines the usage/report capability, with which the upload
+// service asks a storage node for the bytes a space stored on it over a
+// period, to reconcile billing.
+package usage
+
+import (
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/storacha/go-ucanto/core/schema"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/validator"
+)
+
+const ReportAbility = "usage/report"
+
+// Period is a period of time, from From (inclusive) to To (exclusive), in
+// seconds since the Unix epoch.
+type Period struct {
+	From int64
+	To   int64
+}
+
+var _ ipld.Builder = (*ReportCaveats)(nil)
+
+type ReportCaveats struct {
+	// Space is the space to report the usage of.
+	Space  did.DID
+	Period Period
+}
+
+func (rc ReportCaveats) ToIPLD() (datamodel.Node, error) {
+	return ipld.WrapWithRecovery(&rc, ReportCaveatsType(), types.Converters...)
+}
+
+// Size is the number of bytes stored by a space at the start and end of the
+// period reported.
+type Size struct {
+	Initial uint64
+	Final   uint64
+}
+
+// Event is a change of the bytes stored by a space.
+type Event struct {
+	// Cause is the invocation that allocated or removed the bytes.
+	Cause ipld.Link
+	// Delta is the number of bytes allocated, negative if removed.
+	Delta int64
+	// ReceiptAt is when the change was recorded, in seconds since the Unix
+	// epoch.
+	ReceiptAt int64
+}
+
+type ReportOk struct {
+	Space  did.DID
+	Period Period
+	Size   Size
+	// Events are the changes in the period, in the order they were recorded.
+	Events []Event
+}
+
+func (ro ReportOk) ToIPLD() (datamodel.Node, error) {
+	return ipld.WrapWithRecovery(&ro, ReportOkType(), types.Converters...)
+}
+
+var ReportOkReader = schema.Struct[ReportOk](ReportOkType(), nil, types.Converters...)
+
+type ReportReceipt receipt.Receipt[ReportOk, failure.Failure]
+type ReportReceiptReader receipt.ReceiptReader[ReportOk, failure.Failure]
+
+func NewReportReceiptReader() (ReportReceiptReader, error) {
+	return receipt.NewReceiptReader[ReportOk, failure.Failure](usageSchema)
+}
+
+var ReportCaveatsReader = schema.Struct[ReportCaveats](ReportCaveatsType(), nil, types.Converters...)
+
+// Report is a capability that allows an agent to ask a storage node, the
+// did:key in the `with` field, for the usage of a space over a period.
+var Report = validator.NewCapability(
+	ReportAbility,
+	schema.DIDString(),
+	ReportCaveatsReader,
+	validator.DefaultDerives,
+)
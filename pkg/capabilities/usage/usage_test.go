@@ -0,0 +1,41 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTripReportCaveats(t *testing.T) {
+	nb := ReportCaveats{
+		Space:  testutil.RandomDID(t),
+		Period: Period{From: 1790812800, To: 1793491200},
+	}
+
+	node, err := nb.ToIPLD()
+	require.NoError(t, err)
+
+	rnb, err := ReportCaveatsReader.Read(node)
+	require.NoError(t, err)
+	require.Equal(t, nb, rnb)
+}
+
+func TestRoundTripReportOk(t *testing.T) {
+	ok := ReportOk{
+		Space:  testutil.RandomDID(t),
+		Period: Period{From: 1790812800, To: 1793491200},
+		Size:   Size{Initial: 1 << 30, Final: 1<<30 + 1024 - 4096},
+		Events: []Event{
+			{Cause: testutil.RandomCID(t), Delta: 1024, ReceiptAt: 1790899200},
+			{Cause: testutil.RandomCID(t), Delta: -4096, ReceiptAt: 1790985600},
+		},
+	}
+
+	node, err := ok.ToIPLD()
+	require.NoError(t, err)
+
+	rok, err := ReportOkReader.Read(node)
+	require.NoError(t, err)
+	require.Equal(t, ok, rok)
+}
@@ -0,0 +1,32 @@
+package cdn
+
+import (
+	"context"
+
+	"github.com/multiformats/go-multihash"
+
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// invalidatingBlobstore invalidates the CDN URL of deleted blobs.
+type invalidatingBlobstore struct {
+	blobstore.Blobstore
+	cdn *CDN
+}
+
+// InvalidateOnDelete wraps bs so deleting a blob also invalidates its CDN
+// URL. A failed invalidation is logged, the blob stays cached until its
+// signed URLs expire.
+func InvalidateOnDelete(bs blobstore.Blobstore, cdn *CDN) blobstore.Blobstore {
+	return &invalidatingBlobstore{Blobstore: bs, cdn: cdn}
+}
+
+func (s *invalidatingBlobstore) Delete(ctx context.Context, digest multihash.Multihash) error {
+	if err := s.Blobstore.Delete(ctx, digest); err != nil {
+		return err
+	}
+	if err := s.cdn.Invalidate(ctx, digest); err != nil {
+		log.Errorw("invalidating deleted blob", "digest", digest.B58String(), "error", err)
+	}
+	return nil
+}
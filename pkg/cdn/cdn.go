@@ -0,0 +1,105 @@
+// Package cdn offloads blob retrievals to a CDN.
+//
+// Blob GET requests are redirected to a URL on the CDN signed by the node, so
+// the node still decides which requests are served while the CDN serves the
+// bytes. CDN URLs use the same layout as the node, /blob/{digest}, so the
+// cache key of a blob is its digest and the CDN fetches misses from the node.
+// Origin fetches carry a shared secret header and are served by the node
+// rather than redirected. When a blob is deleted its CDN URL is invalidated.
+package cdn
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+)
+
+var log = logging.Logger("cdn")
+
+// OriginSecretHeader is the header the CDN sends with origin fetches, set to
+// the configured origin secret.
+const OriginSecretHeader = "X-Piri-CDN-Origin"
+
+// DefaultTTL is how long signed URLs are valid for by default.
+const DefaultTTL = time.Hour
+
+// URLSigner signs a CDN URL so it is valid for ttl from now.
+type URLSigner interface {
+	SignURL(u url.URL, now time.Time, ttl time.Duration) (url.URL, error)
+}
+
+// Invalidator removes cached URLs from the CDN.
+type Invalidator interface {
+	Invalidate(ctx context.Context, urls []url.URL) error
+}
+
+// CDN generates signed CDN URLs for blobs and invalidates them.
+type CDN struct {
+	base         url.URL
+	signer       URLSigner
+	invalidator  Invalidator
+	ttl          time.Duration
+	originSecret string
+	now          func() time.Time
+}
+
+// New creates a CDN serving blobs from base. The invalidator may be nil, in
+// which case deleted blobs stay cached until they expire.
+func New(base url.URL, signer URLSigner, invalidator Invalidator, ttl time.Duration, originSecret string) *CDN {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &CDN{
+		base:         base,
+		signer:       signer,
+		invalidator:  invalidator,
+		ttl:          ttl,
+		originSecret: originSecret,
+		now:          time.Now,
+	}
+}
+
+// BlobURL returns the signed CDN URL of a blob.
+func (c *CDN) BlobURL(digest multihash.Multihash) (url.URL, error) {
+	u, err := c.signer.SignURL(c.blobURL(digest), c.now(), c.ttl)
+	if err != nil {
+		return url.URL{}, fmt.Errorf("signing CDN URL: %w", err)
+	}
+	return u, nil
+}
+
+// IsOriginRequest reports whether r is a fetch by the CDN from the node.
+func (c *CDN) IsOriginRequest(r *http.Request) bool {
+	secret := r.Header.Get(OriginSecretHeader)
+	return secret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(c.originSecret)) == 1
+}
+
+// Invalidate removes a blob from the CDN cache.
+func (c *CDN) Invalidate(ctx context.Context, digest multihash.Multihash) error {
+	if c.invalidator == nil {
+		return nil
+	}
+	if err := c.invalidator.Invalidate(ctx, []url.URL{c.blobURL(digest)}); err != nil {
+		return fmt.Errorf("invalidating CDN URL: %w", err)
+	}
+	return nil
+}
+
+func (c *CDN) blobURL(digest multihash.Multihash) url.URL {
+	u := c.base.JoinPath("blob", digestutil.Format(digest))
+	// a base URL without a path joins to a relative path, which neither signs
+	// nor invalidates as the path requested from the CDN
+	if !strings.HasPrefix(u.Path, "/") {
+		u.Path = "/" + u.Path
+		u.RawPath = ""
+	}
+	return *u
+}
@@ -0,0 +1,133 @@
+package cdn
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+type mockInvalidator struct {
+	urls []url.URL
+}
+
+func (m *mockInvalidator) Invalidate(_ context.Context, urls []url.URL) error {
+	m.urls = append(m.urls, urls...)
+	return nil
+}
+
+func TestCloudFrontSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	signer, err := NewCloudFrontSigner("K2JCJMDEHXQW5F", keyPEM)
+	require.NoError(t, err)
+
+	now := time.Unix(1_700_000_000, 0)
+	u, err := signer.SignURL(url.URL{Scheme: "https", Host: "cdn.example.com", Path: "/blob/zQm"}, now, time.Hour)
+	require.NoError(t, err)
+
+	q := u.Query()
+	require.Equal(t, "1700003600", q.Get("Expires"))
+	require.Equal(t, "K2JCJMDEHXQW5F", q.Get("Key-Pair-Id"))
+
+	policy := `{"Statement":[{"Resource":"https://cdn.example.com/blob/zQm","Condition":{"DateLessThan":{"AWS:EpochTime":1700003600}}}]}`
+	sig, err := base64.StdEncoding.DecodeString(strings.NewReplacer("-", "+", "_", "=", "~", "/").Replace(q.Get("Signature")))
+	require.NoError(t, err)
+	digest := sha1.Sum([]byte(policy))
+	require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, digest[:], sig))
+}
+
+func TestCloudflareSigner(t *testing.T) {
+	secret := []byte("secret")
+	signer := NewCloudflareSigner(secret)
+
+	u, err := signer.SignURL(url.URL{Scheme: "https", Host: "cdn.example.com", Path: "/blob/zQm"}, time.Unix(1_700_000_000, 0), time.Hour)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(u.RawQuery, VerifyParam+"=1700000000-"))
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("/blob/zQm1700000000"))
+	require.Equal(t, "1700000000-"+base64.StdEncoding.EncodeToString(mac.Sum(nil)), u.Query().Get(VerifyParam))
+}
+
+func TestCloudflareInvalidator(t *testing.T) {
+	var purged []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/zones/zone/purge_cache", r.URL.Path)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		var body struct {
+			Files []string `json:"files"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		purged = body.Files
+		fmt.Fprint(w, `{"success":true,"errors":[]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	inv := NewCloudflareInvalidator("zone", "token", srv.URL, srv.Client())
+	u := url.URL{Scheme: "https", Host: "cdn.example.com", Path: "/blob/zQm"}
+	require.NoError(t, inv.Invalidate(t.Context(), []url.URL{u}))
+	require.Equal(t, []string{u.String()}, purged)
+}
+
+func TestCDN(t *testing.T) {
+	base := url.URL{Scheme: "https", Host: "cdn.example.com"}
+	inv := &mockInvalidator{}
+	c := New(base, NewCloudflareSigner([]byte("secret")), inv, 0, "origin-secret")
+	digest := testutil.RandomMultihash(t)
+
+	t.Run("blob URLs are keyed by digest", func(t *testing.T) {
+		u, err := c.BlobURL(digest)
+		require.NoError(t, err)
+		require.Equal(t, "cdn.example.com", u.Host)
+		require.Equal(t, "/blob/"+digestutil.Format(digest), u.Path)
+	})
+
+	t.Run("recognises origin requests", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/blob/"+digestutil.Format(digest), nil)
+		require.False(t, c.IsOriginRequest(r))
+		r.Header.Set(OriginSecretHeader, "wrong")
+		require.False(t, c.IsOriginRequest(r))
+		r.Header.Set(OriginSecretHeader, "origin-secret")
+		require.True(t, c.IsOriginRequest(r))
+	})
+
+	t.Run("invalidates deleted blobs", func(t *testing.T) {
+		bs := InvalidateOnDelete(nopDeleter{}, c)
+		require.NoError(t, bs.Delete(t.Context(), digest))
+		require.Len(t, inv.urls, 1)
+		require.Equal(t, "/blob/"+digestutil.Format(digest), inv.urls[0].Path)
+	})
+}
+
+// nopDeleter is a blob store whose deletes succeed, the other methods are
+// not used.
+type nopDeleter struct {
+	blobstore.Blobstore
+}
+
+func (nopDeleter) Delete(context.Context, multihash.Multihash) error {
+	return nil
+}
@@ -0,0 +1,158 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// CloudFrontSigner signs CloudFront URLs with a canned policy.
+type CloudFrontSigner struct {
+	keyPairID string
+	key       *rsa.PrivateKey
+}
+
+var _ URLSigner = (*CloudFrontSigner)(nil)
+
+// NewCloudFrontSigner creates a signer for the public key with ID keyPairID
+// in a CloudFront key group, from its PEM encoded RSA private key.
+func NewCloudFrontSigner(keyPairID string, keyPEM []byte) (*CloudFrontSigner, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block in CloudFront private key")
+	}
+	var key *rsa.PrivateKey
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = k
+	} else {
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing CloudFront private key: %w", err)
+		}
+		k, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("CloudFront private key is not an RSA key")
+		}
+		key = k
+	}
+	return &CloudFrontSigner{keyPairID: keyPairID, key: key}, nil
+}
+
+func (s *CloudFrontSigner) SignURL(u url.URL, now time.Time, ttl time.Duration) (url.URL, error) {
+	expires := now.Add(ttl).Unix()
+	policy := fmt.Sprintf(`{"Statement":[{"Resource":"%s","Condition":{"DateLessThan":{"AWS:EpochTime":%d}}}]}`, u.String(), expires)
+	digest := sha1.Sum([]byte(policy))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA1, digest[:])
+	if err != nil {
+		return url.URL{}, err
+	}
+
+	q := u.Query()
+	q.Set("Expires", strconv.FormatInt(expires, 10))
+	q.Set("Signature", cloudFrontEncoding.Replace(base64.StdEncoding.EncodeToString(sig)))
+	q.Set("Key-Pair-Id", s.keyPairID)
+	u.RawQuery = q.Encode()
+	return u, nil
+}
+
+// cloudFrontEncoding replaces the characters of base64 that are invalid in a
+// query string, as CloudFront expects.
+var cloudFrontEncoding = strings.NewReplacer("+", "-", "=", "_", "/", "~")
+
+// CloudFrontInvalidator invalidates paths of a CloudFront distribution with
+// the CloudFront API, using credentials from the AWS environment.
+type CloudFrontInvalidator struct {
+	distributionID string
+	endpoint       string
+	creds          aws.CredentialsProvider
+	signer         *v4.Signer
+	http           *http.Client
+}
+
+var _ Invalidator = (*CloudFrontInvalidator)(nil)
+
+// CloudFrontEndpoint is the global endpoint of the CloudFront API.
+const CloudFrontEndpoint = "https://cloudfront.amazonaws.com"
+
+func NewCloudFrontInvalidator(distributionID, endpoint string, creds aws.CredentialsProvider, httpClient *http.Client) *CloudFrontInvalidator {
+	if endpoint == "" {
+		endpoint = CloudFrontEndpoint
+	}
+	return &CloudFrontInvalidator{
+		distributionID: distributionID,
+		endpoint:       endpoint,
+		creds:          creds,
+		signer:         v4.NewSigner(),
+		http:           httpClient,
+	}
+}
+
+type invalidationBatch struct {
+	XMLName xml.Name `xml:"http://cloudfront.amazonaws.com/doc/2020-05-31/ InvalidationBatch"`
+	Paths   struct {
+		Quantity int      `xml:"Quantity"`
+		Items    []string `xml:"Items>Path"`
+	} `xml:"Paths"`
+	CallerReference string `xml:"CallerReference"`
+}
+
+func (i *CloudFrontInvalidator) Invalidate(ctx context.Context, urls []url.URL) error {
+	var batch invalidationBatch
+	for _, u := range urls {
+		batch.Paths.Items = append(batch.Paths.Items, u.Path)
+	}
+	batch.Paths.Quantity = len(batch.Paths.Items)
+	batch.CallerReference = strconv.FormatInt(time.Now().UnixNano(), 10)
+	body, err := xml.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("encoding invalidation: %w", err)
+	}
+	body = append([]byte(xml.Header), body...)
+
+	endpoint := fmt.Sprintf("%s/2020-05-31/distribution/%s/invalidation", i.endpoint, url.PathEscape(i.distributionID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/xml")
+
+	creds, err := i.creds.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieving AWS credentials: %w", err)
+	}
+	payloadHash := sha256.Sum256(body)
+	// CloudFront is a global service signed in us-east-1
+	if err := i.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), "cloudfront", "us-east-1", time.Now()); err != nil {
+		return fmt.Errorf("signing request: %w", err)
+	}
+
+	res, err := i.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("CloudFront invalidation failed with status %d: %s", res.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// Module redirects blob retrievals to a CDN, if enabled in config. It
+// decorates the blob store, so it must be included at the root of the app
+// rather than in another module for the decoration to apply to all consumers
+// of the store.
+var Module = fx.Options(
+	fx.Module("cdn",
+		fx.Provide(NewFromConfig),
+	),
+	fx.Decorate(DecorateBlobstore),
+)
+
+// NewFromConfig creates the CDN for the configured provider. It returns nil
+// if no CDN is configured.
+func NewFromConfig(cfg app.AppConfig) (*CDN, error) {
+	c := cfg.Server.CDN
+	var (
+		signer      URLSigner
+		invalidator Invalidator
+	)
+	switch c.Provider {
+	case "":
+		return nil, nil
+	case app.CDNProviderCloudFront:
+		key, err := os.ReadFile(c.CloudFront.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CloudFront private key: %w", err)
+		}
+		signer, err = NewCloudFrontSigner(c.CloudFront.KeyPairID, key)
+		if err != nil {
+			return nil, err
+		}
+		if c.CloudFront.DistributionID != "" {
+			awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
+			if err != nil {
+				return nil, fmt.Errorf("loading AWS config: %w", err)
+			}
+			invalidator = NewCloudFrontInvalidator(c.CloudFront.DistributionID, "", awsCfg.Credentials, http.DefaultClient)
+		}
+	case app.CDNProviderCloudflare:
+		signer = NewCloudflareSigner([]byte(c.Cloudflare.SigningSecret))
+		if c.Cloudflare.ZoneID != "" {
+			invalidator = NewCloudflareInvalidator(c.Cloudflare.ZoneID, c.Cloudflare.APIToken, "", http.DefaultClient)
+		}
+	default:
+		return nil, fmt.Errorf("unknown CDN provider: %s", c.Provider)
+	}
+	if invalidator == nil {
+		log.Warn("no CDN invalidation configured, deleted blobs stay cached until their signed URLs expire")
+	}
+	return New(c.URL, signer, invalidator, c.TTL, c.OriginSecret), nil
+}
+
+type DecorateParams struct {
+	fx.In
+
+	Blobstore blobstore.Blobstore
+	CDN       *CDN `optional:"true"`
+}
+
+// DecorateBlobstore invalidates the CDN URL of deleted blobs.
+func DecorateBlobstore(params DecorateParams) blobstore.Blobstore {
+	if params.CDN == nil {
+		return params.Blobstore
+	}
+	return InvalidateOnDelete(params.Blobstore, params.CDN)
+}
@@ -1,12 +1,32 @@
+// Package client is a Go SDK for talking to piri storage nodes. It builds and
+// signs the UCAN invocations the upload service sends to a node to allocate,
+// upload, accept and replicate blobs, and those a client sends to retrieve
+// them, and reads the results from the receipts the node responds with.
+//
+// A client is configured with a signer, the identity and URL of the node, and
+// a delegation from the node allowing the signer to invoke its capabilities:
+//
+//	c, err := client.NewClient(client.Config{
+//		ID:             signer,
+//		StorageNodeID:  nodeDID,
+//		StorageNodeURL: *nodeURL,
+//		StorageProof:   delegation.FromDelegation(dlg),
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	res, err := c.UploadBlob(ctx, space, data)
 package client
 
 import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 
 	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/schema"
 	"github.com/multiformats/go-multihash"
 	"github.com/storacha/go-libstoracha/capabilities/assert"
 	"github.com/storacha/go-libstoracha/capabilities/blob"
@@ -39,11 +59,22 @@ type Config struct {
 	StorageProof delegation.Proof
 }
 
-// Client simulates actions taken by the upload service in response to
-// client invocations.
+// Client invokes the capabilities of a storage node, as the upload service
+// does in response to client invocations.
 type Client struct {
-	cfg  Config
-	conn client.Connection
+	cfg        Config
+	conn       client.Connection
+	httpClient *http.Client
+}
+
+type Option func(c *Client)
+
+// WithHTTPClient sets the HTTP client used to upload and retrieve blobs. UCAN
+// invocations are sent with the default client of the UCAN HTTP transport.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
 }
 
 // BlobAllocate sends a blob/allocate invocation to the storage node and returns the
@@ -67,36 +98,25 @@ func (s *Client) BlobAllocate(ctx context.Context, space did.DID, digest multiha
 	if err != nil {
 		return nil, fmt.Errorf("generating invocation: %w", err)
 	}
-	res, err := client.Execute(ctx, []invocation.Invocation{inv}, s.conn)
-	if err != nil {
-		return nil, fmt.Errorf("sending invocation: %w", err)
-	}
-	reader, err := receipt.NewReceiptReaderFromTypes[blob.AllocateOk, failure.FailureModel](blob.AllocateOkType(), failure.FailureType(), types.Converters...)
-	if err != nil {
-		return nil, fmt.Errorf("generating receipt reader: %w", err)
-	}
-	rcptLink, ok := res.Get(inv.Link())
-	if !ok {
-		return nil, ErrNoReceipt
-	}
-	rcpt, err := reader.Read(rcptLink, res.Blocks())
-	if err != nil {
-		return nil, fmt.Errorf("reading receipt: %w", err)
-	}
-	alloc, err := result.Unwrap(result.MapError(rcpt.Out(), failure.FromFailureModel))
+	alloc, _, err := execute[blob.AllocateOk](ctx, s.conn, inv, blob.AllocateOkType())
 	if err != nil {
-		return nil, fmt.Errorf("received error from storage node: %w", err)
+		return nil, err
 	}
 	return alloc.Address, nil
 }
 
-// Blob sends a blob/accept invocation to the storage node and
-// returns the location commitment and piece/accept invocation
+// BlobAcceptResult holds the location commitment and piece/accept invocation
+// returned by the storage node on blob/accept.
 type BlobAcceptResult struct {
 	LocationCommitment assert.LocationCaveats
-	PDPAccept          *pdp.AcceptCaveats
+	// LocationClaim is the assert/location delegation issued by the node.
+	LocationClaim delegation.Delegation
+	PDPAccept     *pdp.AcceptCaveats
 }
 
+// BlobAccept sends a blob/accept invocation to the storage node, awaiting the
+// result of the http/put task putInv, and returns the location commitment and
+// piece/accept invocation issued by the node.
 func (s *Client) BlobAccept(ctx context.Context, space did.DID, digest multihash.Multihash, size uint64, putInv datamodel.Link) (*BlobAcceptResult, error) {
 
 	inv, err := blob.Accept.Invoke(
@@ -122,29 +142,9 @@ func (s *Client) BlobAccept(ctx context.Context, space did.DID, digest multihash
 		return nil, fmt.Errorf("generating invocation: %w", err)
 	}
 
-	res, err := client.Execute(ctx, []invocation.Invocation{inv}, s.conn)
-	if err != nil {
-		return nil, fmt.Errorf("sending invocation: %w", err)
-	}
-
-	reader, err := receipt.NewReceiptReaderFromTypes[blob.AcceptOk, failure.FailureModel](blob.AcceptOkType(), failure.FailureType(), types.Converters...)
+	acc, res, err := execute[blob.AcceptOk](ctx, s.conn, inv, blob.AcceptOkType())
 	if err != nil {
-		return nil, fmt.Errorf("generating receipt reader: %w", err)
-	}
-
-	rcptLink, ok := res.Get(inv.Link())
-	if !ok {
-		return nil, ErrNoReceipt
-	}
-
-	rcpt, err := reader.Read(rcptLink, res.Blocks())
-	if err != nil {
-		return nil, fmt.Errorf("reading receipt: %w", err)
-	}
-
-	acc, err := result.Unwrap(result.MapError(rcpt.Out(), failure.FromFailureModel))
-	if err != nil {
-		return nil, fmt.Errorf("received error from storage node: %w", err)
+		return nil, err
 	}
 
 	br, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(res.Blocks()))
@@ -166,6 +166,7 @@ func (s *Client) BlobAccept(ctx context.Context, space did.DID, digest multihash
 	}
 	result := &BlobAcceptResult{
 		LocationCommitment: lc,
+		LocationClaim:      claim,
 	}
 	if acc.PDP != nil {
 		pdpAccept, err := delegation.NewDelegationView(*acc.PDP, br)
@@ -184,6 +185,8 @@ func (s *Client) BlobAccept(ctx context.Context, space did.DID, digest multihash
 	return result, nil
 }
 
+// PDPInfo sends a pdp/info invocation to the storage node and returns the
+// aggregation and inclusion status of the blob.
 func (s *Client) PDPInfo(ctx context.Context, blob multihash.Multihash) (pdp.InfoOk, error) {
 
 	inv, err := pdp.Info.Invoke(
@@ -200,33 +203,63 @@ func (s *Client) PDPInfo(ctx context.Context, blob multihash.Multihash) (pdp.Inf
 		return pdp.InfoOk{}, fmt.Errorf("generating invocation: %w", err)
 	}
 
-	res, err := client.Execute(ctx, []invocation.Invocation{inv}, s.conn)
+	info, _, err := execute[pdp.InfoOk](ctx, s.conn, inv, pdp.InfoOkType())
+	return info, err
+}
+
+// NewClient creates a client invoking the capabilities of the configured
+// storage node.
+func NewClient(cfg Config, options ...Option) (*Client, error) {
+	ch := uhttp.NewChannel(&cfg.StorageNodeURL)
+	conn, err := client.NewConnection(cfg.StorageNodeID, ch)
 	if err != nil {
-		return pdp.InfoOk{}, fmt.Errorf("sending invocation: %w", err)
+		return nil, fmt.Errorf("setting up connection: %w", err)
+	}
+	c := Client{cfg: cfg, conn: conn}
+	for _, o := range options {
+		o(&c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
 	}
+	return &c, nil
+}
 
-	reader, err := receipt.NewReceiptReaderFromTypes[pdp.InfoOk, failure.FailureModel](pdp.InfoOkType(), failure.FailureType(), types.Converters...)
+// execute sends the invocation to the storage node and returns the result of
+// its receipt, or the failure as an error. The response is returned for the
+// blocks of the result to be read.
+func execute[O any](ctx context.Context, conn client.Connection, inv invocation.Invocation, okType schema.Type) (O, client.ExecutionResponse, error) {
+	var ok O
+	res, err := client.Execute(ctx, []invocation.Invocation{inv}, conn)
 	if err != nil {
-		return pdp.InfoOk{}, fmt.Errorf("generating receipt reader: %w", err)
+		return ok, nil, fmt.Errorf("sending invocation: %w", err)
 	}
-
-	rcptLink, ok := res.Get(inv.Link())
-	if !ok {
-		return pdp.InfoOk{}, ErrNoReceipt
+	ok, err = readResult[O](inv.Link(), res, okType)
+	if err != nil {
+		return ok, nil, err
 	}
+	return ok, res, nil
+}
 
+// readResult reads the result of the receipt of the invocation from the
+// response.
+func readResult[O any](inv ucan.Link, res client.ExecutionResponse, okType schema.Type) (O, error) {
+	var ok O
+	reader, err := receipt.NewReceiptReaderFromTypes[O, failure.FailureModel](okType, failure.FailureType(), types.Converters...)
+	if err != nil {
+		return ok, fmt.Errorf("generating receipt reader: %w", err)
+	}
+	rcptLink, found := res.Get(inv)
+	if !found {
+		return ok, ErrNoReceipt
+	}
 	rcpt, err := reader.Read(rcptLink, res.Blocks())
 	if err != nil {
-		return pdp.InfoOk{}, fmt.Errorf("reading receipt: %w", err)
+		return ok, fmt.Errorf("reading receipt: %w", err)
 	}
-	return result.Unwrap(result.MapError(rcpt.Out(), failure.FromFailureModel))
-}
-
-func NewClient(cfg Config) (*Client, error) {
-	ch := uhttp.NewChannel(&cfg.StorageNodeURL)
-	conn, err := client.NewConnection(cfg.StorageNodeID, ch)
+	ok, err = result.Unwrap(result.MapError(rcpt.Out(), failure.FromFailureModel))
 	if err != nil {
-		return nil, fmt.Errorf("setting up connection: %w", err)
+		return ok, fmt.Errorf("received error from storage node: %w", err)
 	}
-	return &Client{cfg, conn}, nil
+	return ok, nil
 }
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/blob/replica"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// ReplicaAllocateResult is the result of a blob/replica/allocate invocation.
+type ReplicaAllocateResult struct {
+	// Size is the number of bytes allocated for the blob, zero if the node
+	// already holds it.
+	Size uint64
+	// Transfer links to the blob/replica/transfer task the node runs to fetch
+	// the blob. Its receipt is delivered to the upload service once the
+	// transfer completes.
+	Transfer ucan.Link
+}
+
+// ReplicaAllocate sends a blob/replica/allocate invocation to the storage node,
+// asking it to fetch a replica of the blob from the location committed to by
+// site, the assert/location claim of another node. The claim is attached to
+// the invocation, for the node to read the location from.
+func (s *Client) ReplicaAllocate(ctx context.Context, space did.DID, digest multihash.Multihash, size uint64, site delegation.Delegation, cause ucan.Link) (*ReplicaAllocateResult, error) {
+	inv, err := replica.Allocate.Invoke(
+		s.cfg.ID,
+		s.cfg.StorageNodeID,
+		s.cfg.StorageNodeID.DID().String(),
+		replica.AllocateCaveats{
+			Space: space,
+			Blob: types.Blob{
+				Digest: digest,
+				Size:   size,
+			},
+			Site:  site.Link(),
+			Cause: cause,
+		},
+		delegation.WithProof(s.cfg.StorageProof),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("generating invocation: %w", err)
+	}
+	for b, err := range site.Export() {
+		if err != nil {
+			return nil, fmt.Errorf("exporting location claim: %w", err)
+		}
+		if err := inv.Attach(b); err != nil {
+			return nil, fmt.Errorf("attaching location claim: %w", err)
+		}
+	}
+
+	alloc, _, err := execute[replica.AllocateOk](ctx, s.conn, inv, replica.AllocateOkType())
+	if err != nil {
+		return nil, err
+	}
+	if alloc.Site.UcanAwait.Selector != replica.AllocateSiteSelector {
+		return nil, fmt.Errorf("unexpected transfer selector: %q", alloc.Site.UcanAwait.Selector)
+	}
+	return &ReplicaAllocateResult{Size: alloc.Size, Transfer: alloc.Site.UcanAwait.Link}, nil
+}
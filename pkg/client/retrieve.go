@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/space/content"
+	retrievalclient "github.com/storacha/go-ucanto/client/retrieval"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/did"
+)
+
+// RetrieveResult is the response to a space/content/retrieve invocation.
+type RetrieveResult struct {
+	// Status is the HTTP status of the response, [http.StatusPartialContent]
+	// unless the whole blob was requested.
+	Status int
+	// Headers are the HTTP headers of the response, including Content-Range.
+	Headers http.Header
+	// Body streams the requested bytes. It must be closed by the caller.
+	Body io.ReadCloser
+}
+
+// Retrieve sends a space/content/retrieve invocation to the retrieval endpoint
+// of the storage node, for the bytes start to end (inclusive) of the blob.
+// Unlike the other invocations, the proofs are delegations from the space
+// allowing the client to retrieve its content, not the storage proof.
+func (s *Client) Retrieve(ctx context.Context, space did.DID, digest multihash.Multihash, start, end uint64, proofs ...delegation.Proof) (*RetrieveResult, error) {
+	u := s.cfg.StorageNodeURL.ResolveReference(&url.URL{Path: "/piece/" + cid.NewCidV1(cid.Raw, digest).String()})
+	conn, err := retrievalclient.NewConnection(s.cfg.StorageNodeID, u, retrievalclient.WithClient(s.httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("setting up retrieval connection: %w", err)
+	}
+
+	inv, err := invocation.Invoke(
+		s.cfg.ID,
+		s.cfg.StorageNodeID,
+		content.Retrieve.New(
+			space.String(),
+			content.RetrieveCaveats{
+				Blob:  content.BlobDigest{Digest: digest},
+				Range: content.Range{Start: start, End: end},
+			},
+		),
+		delegation.WithProof(proofs...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("generating invocation: %w", err)
+	}
+
+	res, hres, err := retrievalclient.Execute(ctx, inv, conn)
+	if err != nil {
+		return nil, fmt.Errorf("sending invocation: %w", err)
+	}
+	if _, err := readResult[content.RetrieveOk](inv.Link(), res, content.RetrieveOkType()); err != nil {
+		hres.Body().Close()
+		return nil, err
+	}
+	return &RetrieveResult{Status: hres.Status(), Headers: hres.Headers(), Body: hres.Body()}, nil
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/ipld/hash/sha256"
+	"github.com/storacha/go-ucanto/did"
+)
+
+// Put uploads data to the address returned by [Client.BlobAllocate].
+func (s *Client) Put(ctx context.Context, address blob.Address, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, address.URL.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("creating put request: %w", err)
+	}
+	req.Header = address.Headers.Clone()
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending blob: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 || res.StatusCode < 200 {
+		resData, err := io.ReadAll(res.Body)
+		if err != nil {
+			return fmt.Errorf("reading response body: %w", err)
+		}
+		return fmt.Errorf("unsuccessful put, status: %s, message: %s", res.Status, string(resData))
+	}
+	return nil
+}
+
+// UploadBlob allocates data in the space, uploads it if the node does not
+// already hold it, and accepts it, as the upload service would.
+func (s *Client) UploadBlob(ctx context.Context, space did.DID, data []byte) (*BlobAcceptResult, error) {
+	digest, err := sha256.Hasher.Sum(data)
+	if err != nil {
+		return nil, fmt.Errorf("calculating blob digest: %w", err)
+	}
+	// there is no client invocation to cite as the cause, so the blob itself
+	// is used
+	cause := cidlink.Link{Cid: cid.NewCidV1(cid.Raw, digest.Bytes())}
+
+	address, err := s.BlobAllocate(ctx, space, digest.Bytes(), uint64(len(data)), cause)
+	if err != nil {
+		return nil, fmt.Errorf("invoking blob allocation: %w", err)
+	}
+	if address != nil {
+		if err := s.Put(ctx, *address, data); err != nil {
+			return nil, fmt.Errorf("uploading blob: %w", err)
+		}
+	}
+
+	result, err := s.BlobAccept(ctx, space, digest.Bytes(), uint64(len(data)), cause)
+	if err != nil {
+		return nil, fmt.Errorf("accepting blob: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package client_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/client"
+)
+
+func TestPut(t *testing.T) {
+	c, err := client.NewClient(client.Config{
+		ID:             testutil.Alice,
+		StorageNodeID:  testutil.Service,
+		StorageNodeURL: *testutil.Must(url.Parse("http://localhost"))(t),
+	})
+	require.NoError(t, err)
+
+	t.Run("uploads with the address headers", func(t *testing.T) {
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPut, r.Method)
+			require.Equal(t, "5", r.Header.Get("Content-Length"))
+			require.Equal(t, "sha256-abc", r.Header.Get("X-Amz-Checksum-Sha256"))
+			body = testutil.Must(io.ReadAll(r.Body))(t)
+		}))
+		defer server.Close()
+
+		address := blob.Address{
+			URL:     *testutil.Must(url.Parse(server.URL + "/blob/abc"))(t),
+			Headers: http.Header{"X-Amz-Checksum-Sha256": []string{"sha256-abc"}},
+		}
+		require.NoError(t, c.Put(t.Context(), address, []byte("hello")))
+		require.Equal(t, []byte("hello"), body)
+	})
+
+	t.Run("fails on an unsuccessful status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "checksum mismatch", http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		address := blob.Address{URL: *testutil.Must(url.Parse(server.URL))(t)}
+		err := c.Put(t.Context(), address, []byte("hello"))
+		require.ErrorContains(t, err, "400")
+		require.ErrorContains(t, err, "checksum mismatch")
+	})
+}
@@ -0,0 +1,91 @@
+// Package compression compresses blobs transferred between nodes. Compression
+// is negotiated with the Accept-Encoding and Content-Encoding headers, and is
+// only a transport encoding: blobs are stored and verified uncompressed.
+package compression
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Zstd is the content coding of zstd compressed bodies.
+const Zstd = "zstd"
+
+// Accepts reports whether an Accept-Encoding header value accepts the given
+// content coding, either by name or with a wildcard, and without a zero
+// quality value.
+func Accepts(header string, coding string) bool {
+	accepted := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.TrimSpace(name)
+		if !strings.EqualFold(name, coding) && name != "*" {
+			continue
+		}
+		ok := qualityNonZero(params)
+		// an explicit entry for the coding overrides the wildcard
+		if strings.EqualFold(name, coding) {
+			return ok
+		}
+		accepted = ok
+	}
+	return accepted
+}
+
+func qualityNonZero(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.TrimSpace(key) != "q" {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return err == nil && q > 0
+	}
+	return true
+}
+
+// NewZstdReader returns a reader of the zstd compressed content of r. The
+// content is compressed as it is read, and closing the returned reader closes
+// r.
+func NewZstdReader(r io.ReadCloser) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		enc, err := zstd.NewWriter(pw, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(enc, r); err != nil {
+			enc.Close()
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(enc.Close())
+	}()
+	return &zstdReader{PipeReader: pr, src: r}
+}
+
+type zstdReader struct {
+	*io.PipeReader
+	src io.Closer
+}
+
+func (z *zstdReader) Close() error {
+	// unblocks the encoder if it is writing to the pipe
+	z.PipeReader.Close()
+	return z.src.Close()
+}
+
+// NewZstdDecoder returns a reader of the decompressed content of the zstd
+// compressed stream r. Closing the returned reader releases the decoder, but
+// does not close r.
+func NewZstdDecoder(r io.Reader) (io.ReadCloser, error) {
+	dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return dec.IOReadCloser(), nil
+}
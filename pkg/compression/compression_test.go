@@ -0,0 +1,35 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccepts(t *testing.T) {
+	require.True(t, Accepts("zstd", Zstd))
+	require.True(t, Accepts("gzip, ZSTD;q=0.5", Zstd))
+	require.True(t, Accepts("*", Zstd))
+	require.False(t, Accepts("", Zstd))
+	require.False(t, Accepts("gzip, br", Zstd))
+	require.False(t, Accepts("zstd;q=0", Zstd))
+	require.False(t, Accepts("*, zstd;q=0", Zstd))
+	require.True(t, Accepts("*;q=0, zstd", Zstd))
+}
+
+func TestZstdRoundtrip(t *testing.T) {
+	content := bytes.Repeat([]byte("highly compressible "), 10000)
+
+	compressed, err := io.ReadAll(NewZstdReader(io.NopCloser(bytes.NewReader(content))))
+	require.NoError(t, err)
+	require.Less(t, len(compressed), len(content)/10)
+
+	dec, err := NewZstdDecoder(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	defer dec.Close()
+	data, err := io.ReadAll(dec)
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// AdmissionConfig configures admission control of blob allocations based on
+// their projected monthly cost against the payment rate of the proof set.
+type AdmissionConfig struct {
+	// Mode is "flag" to log and count uneconomic allocations, or "reject" to
+	// reject them. Empty to not estimate costs.
+	Mode string `mapstructure:"mode" validate:"omitempty,oneof=flag reject" toml:"mode,omitempty"`
+	// StorageCost is the cost of storing a TiB for a month, in payment tokens.
+	StorageCost float64 `mapstructure:"storage_cost" validate:"min=0" toml:"storage_cost,omitempty"`
+	// EgressReserve is the cost set aside per TiB stored each month for
+	// serving retrievals, in payment tokens.
+	EgressReserve float64 `mapstructure:"egress_reserve" validate:"min=0" toml:"egress_reserve,omitempty"`
+	// FILPrice is the price of a FIL in payment tokens.
+	FILPrice float64 `mapstructure:"fil_price" validate:"min=0" toml:"fil_price,omitempty"`
+	// ProofGas is the gas used by a proof submission.
+	ProofGas uint64 `mapstructure:"proof_gas" toml:"proof_gas,omitempty"`
+	// MinRatio is the ratio of revenue to cost below which an allocation is
+	// uneconomic.
+	MinRatio float64 `mapstructure:"min_ratio" validate:"min=0" toml:"min_ratio,omitempty"`
+}
+
+func (c AdmissionConfig) ToAppConfig() (app.AdmissionConfig, error) {
+	mode := app.AdmissionMode(c.Mode)
+	switch mode {
+	case app.AdmissionOff, app.AdmissionFlag, app.AdmissionReject:
+	default:
+		return app.AdmissionConfig{}, fmt.Errorf("invalid admission mode %q: must be flag or reject", c.Mode)
+	}
+	if mode != app.AdmissionOff && c.FILPrice <= 0 {
+		return app.AdmissionConfig{}, fmt.Errorf("admission fil_price must be set to estimate proof costs")
+	}
+	return app.AdmissionConfig{
+		Mode:          mode,
+		StorageCost:   c.StorageCost,
+		EgressReserve: c.EgressReserve,
+		FILPrice:      c.FILPrice,
+		ProofGas:      c.ProofGas,
+		MinRatio:      c.MinRatio,
+	}, nil
+}
@@ -0,0 +1,33 @@
+package app
+
+// AdmissionMode is what is done with allocations whose projected cost
+// exceeds the revenue they earn.
+type AdmissionMode string
+
+const (
+	// AdmissionOff does not estimate the cost of allocations.
+	AdmissionOff AdmissionMode = ""
+	// AdmissionFlag admits uneconomic allocations, logging and counting them.
+	AdmissionFlag AdmissionMode = "flag"
+	// AdmissionReject rejects uneconomic allocations.
+	AdmissionReject AdmissionMode = "reject"
+)
+
+// AdmissionConfig configures admission control of blob allocations based on
+// their projected monthly cost. Costs are in whole payment tokens.
+type AdmissionConfig struct {
+	Mode AdmissionMode
+	// StorageCost is the cost of storing a TiB for a month.
+	StorageCost float64
+	// EgressReserve is the cost set aside per TiB stored each month for
+	// serving retrievals.
+	EgressReserve float64
+	// FILPrice is the price of a FIL in payment tokens, used to convert the
+	// gas and fees of proofs.
+	FILPrice float64
+	// ProofGas is the gas used by a proof submission.
+	ProofGas uint64
+	// MinRatio is the ratio of revenue to cost below which an allocation is
+	// uneconomic.
+	MinRatio float64
+}
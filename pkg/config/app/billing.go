@@ -0,0 +1,34 @@
+package app
+
+import "time"
+
+// BillingConfig configures the rate plans usage of spaces is priced at, and
+// the export of monthly invoices. Billing is disabled without plans.
+type BillingConfig struct {
+	// Currency is the currency plan prices are in.
+	Currency string
+	// DefaultPlan is the plan of spaces not assigned one, empty to only bill
+	// spaces assigned a plan.
+	DefaultPlan string
+	// MeterInterval is how often the storage used by spaces is sampled.
+	MeterInterval time.Duration
+	Plans         []RatePlan
+	Webhook       BillingWebhookConfig
+}
+
+// RatePlan prices the usage of a space.
+type RatePlan struct {
+	Name string
+	// StorageGiBMonth is the price of storing a GiB for a month.
+	StorageGiBMonth float64
+	// EgressGiB is the price of a GiB downloaded.
+	EgressGiB float64
+}
+
+// BillingWebhookConfig configures the endpoint invoices are pushed to, none
+// if URL is empty.
+type BillingWebhookConfig struct {
+	URL string
+	// Secret is the key invoices are signed with.
+	Secret string
+}
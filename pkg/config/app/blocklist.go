@@ -0,0 +1,15 @@
+package app
+
+import (
+	"net/url"
+	"time"
+)
+
+// BlocklistConfig configures the sync of the blocklist from a remote
+// denylist.
+type BlocklistConfig struct {
+	// URL is the denylist synced into the blocklist, nil if none is synced.
+	URL *url.URL
+	// SyncInterval is how often the denylist is synced.
+	SyncInterval time.Duration
+}
@@ -20,6 +20,18 @@ type AppConfig struct {
 	// Telemetry configuration
 	Telemetry TelemetryConfig
 
+	// Background integrity scrubber configuration
+	Scrubber ScrubberConfig
+
+	// Pruning of old receipts
+	ReceiptRetention ReceiptRetentionConfig
+
+	// Backpressure on allocations while the disks are nearly full
+	DiskSpace DiskSpaceConfig
+
+	// Retries and circuit breaking of requests to other services
+	HTTPClient HTTPClientConfig
+
 	//
 	// Configs below are not exposed to users, they are hard coded with defaults
 	// their purpose is to allow configurable configuration injection in tests
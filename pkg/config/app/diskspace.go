@@ -0,0 +1,17 @@
+package app
+
+import "time"
+
+// DiskSpaceConfig configures the monitoring of the disk usage of the data
+// and temp directories, and the rejection of allocations while it is high.
+type DiskSpaceConfig struct {
+	Enabled bool
+	// HighWatermark is the percentage of a disk in use above which new
+	// allocations are rejected.
+	HighWatermark float64
+	// LowWatermark is the percentage of a disk in use below which allocations
+	// are accepted again, once the high watermark was crossed.
+	LowWatermark float64
+	// Interval is the time between checks of the disk usage.
+	Interval time.Duration
+}
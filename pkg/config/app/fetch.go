@@ -0,0 +1,23 @@
+package app
+
+import "time"
+
+// FetchConfig configures the `blob/fetch` capability. Zero values use the
+// defaults.
+type FetchConfig struct {
+	Enabled bool
+	// ChunkSize is the size in bytes of the ranges a blob is fetched in.
+	ChunkSize int64
+	// Concurrency is the number of ranges of a blob fetched in parallel.
+	Concurrency int
+	// MaxWorkers is the number of blobs fetched in parallel.
+	MaxWorkers uint
+	// MaxRetries is the number of times a failed fetch is retried.
+	MaxRetries uint
+	// StallTimeout is how long a source may send no data before the request
+	// to it fails.
+	StallTimeout time.Duration
+	// AllowPrivateAddresses allows fetching from loopback and private
+	// addresses.
+	AllowPrivateAddresses bool
+}
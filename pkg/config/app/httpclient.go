@@ -0,0 +1,21 @@
+package app
+
+import "time"
+
+// HTTPClientConfig configures the retries and circuit breaking of the
+// requests the node makes to other services.
+type HTTPClientConfig struct {
+	// MaxRetries is the number of times a failed request is retried, 0 to
+	// disable retries.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubled for each further
+	// retry up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// BreakerThreshold is the number of consecutive failed requests to a host
+	// that opens its circuit, 0 to disable circuit breaking.
+	BreakerThreshold int
+	// BreakerCooldown is how long requests to a host with an open circuit fail
+	// right away.
+	BreakerCooldown time.Duration
+}
@@ -1,11 +1,36 @@
 package app
 
 import (
+	"github.com/storacha/go-ucanto/did"
 	"github.com/storacha/go-ucanto/principal"
 )
 
 // IdentityConfig contains identity-related configuration
 type IdentityConfig struct {
-	// The principal signer for this service
+	// The principal signer for this service, connected to the signing agent
+	// or Vault with the agent and vault-transit backends.
 	Signer principal.Signer
+	// SignerBackend is what holds the node's key.
+	SignerBackend SignerBackend
+	// DID is the did:web the node operates as, did.Undef to operate as the
+	// did:key of Signer.
+	DID did.DID
+	// PreviousKeys are the keys rotated out of a did:web identity.
+	PreviousKeys []principal.Verifier
 }
+
+// ID is the DID the node operates as, its did:web or the did:key of its key.
+func (c IdentityConfig) ID() did.DID {
+	if c.DID.Defined() {
+		return c.DID
+	}
+	return c.Signer.DID()
+}
+
+type SignerBackend string
+
+const (
+	SignerBackendFile  SignerBackend = "file"
+	SignerBackendAgent SignerBackend = "agent"
+	SignerBackendVault SignerBackend = "vault-transit"
+)
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"net/url"
+	"time"
+)
+
+// IPNICheckConfig configures the periodic check that the node's IPNI
+// advertisements resolve to it on the indexers they are announced to.
+type IPNICheckConfig struct {
+	Enabled bool
+	// IndexerURLs are the base URLs of the indexers queried.
+	IndexerURLs []url.URL
+	// Interval is the time between checks.
+	Interval time.Duration
+	// SampleSize is the number of advertised multihashes looked up in each
+	// check.
+	SampleSize uint
+	// MinVisible is the fraction of the sampled multihashes that must resolve
+	// to the node for a check to pass.
+	MinVisible float64
+	// AlertAfter is the number of consecutive failed checks of an indexer
+	// after which the failure is reported.
+	AlertAfter uint
+}
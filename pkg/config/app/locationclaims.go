@@ -0,0 +1,18 @@
+package app
+
+import "time"
+
+// LocationClaimsConfig configures the expiration and renewal of location
+// claims.
+type LocationClaimsConfig struct {
+	// Expiration is how long location claims are valid for, 0 for claims that
+	// never expire.
+	Expiration time.Duration
+	// RenewBefore is how long before a claim expires it is renewed.
+	RenewBefore time.Duration
+	// RenewInterval is how often claims due for renewal are checked.
+	RenewInterval time.Duration
+	// AlertAfter is the number of consecutive failed renewals of a claim after
+	// which the failure is reported.
+	AlertAfter uint
+}
@@ -37,13 +37,151 @@ type PDPServiceConfig struct {
 	Aggregation AggregationConfig
 	// Gas contains gas fee limit configuration
 	Gas GasConfig
+	// Anchoring configures on-chain anchoring of issued receipts and claims
+	Anchoring AnchoringConfig
+	// Alerting configures alerts of approaching proving deadlines, faults and
+	// failed settlements
+	Alerting AlertingConfig
+	// Scheduler configures the priorities of the task scheduler
+	Scheduler TaskSchedulerConfig
+	// Confirmation configures when sent messages are confirmed, and how long
+	// confirmed messages are checked for reorgs
+	Confirmation ConfirmationConfig
+	// Curio configures the Curio node proof sets may be proven by
+	Curio CurioConfig
+}
+
+// CurioConfig configures the Curio node aggregates of proof sets added with
+// the curio backend are streamed to.
+type CurioConfig struct {
+	// URL of the PDP API of the Curio node, nil if the node runs without
+	// Curio.
+	URL *url.URL
+	// UploadTimeout is how long an uploaded piece may wait to be parked before
+	// it is uploaded again, the default if zero.
+	UploadTimeout time.Duration
+}
+
+// ConfirmationConfig configures the confirmation of sent messages.
+type ConfirmationConfig struct {
+	// Depth is the number of blocks a message must be buried under to be
+	// confirmed.
+	Depth uint64
+	// ReorgWindow is the number of blocks confirmed messages are checked for
+	// being reorged out of the chain for. 0 disables the check.
+	ReorgWindow uint64
+}
+
+// TaskSchedulerConfig configures the priority classes of the task scheduler.
+type TaskSchedulerConfig struct {
+	// UrgentWindow is how close to its deadline a task must be to hold back
+	// tasks of lower priority classes.
+	UrgentWindow time.Duration
+	// MaxProving, MaxChain, MaxMessages and MaxBackground cap the tasks of
+	// each class running at once. 0 is unlimited.
+	MaxProving    int
+	MaxChain      int
+	MaxMessages   int
+	MaxBackground int
+}
+
+// AlertingConfig configures the alerts raised when the node is at risk of
+// missing proofs or payments, and where they are sent. The rules are the
+// defaults, the admin API can override them.
+type AlertingConfig struct {
+	// DeadlineEpochs alerts when a proving deadline is at most this many
+	// epochs away and no proof of the period has been seen. 0 disables the
+	// alert.
+	DeadlineEpochs int64
+	// FaultRecords alerts when a FaultRecord event is emitted for a data set
+	// of the node.
+	FaultRecords bool
+	// SettlementFailures alerts when a rail settlement transaction fails.
+	SettlementFailures bool
+	// Webhooks are the URLs alerts are posted to.
+	Webhooks []AlertWebhookConfig
+	// Email sends alerts by email when set.
+	Email *AlertEmailConfig
+}
+
+// AlertWebhookConfig is a URL alerts are posted to.
+type AlertWebhookConfig struct {
+	URL *url.URL
+	// Secret signs the alerts when set.
+	Secret string
+}
+
+// AlertEmailConfig configures the SMTP server alerts are sent through.
+type AlertEmailConfig struct {
+	Host string
+	Port uint
+	// Username and Password authenticate with the server when set.
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// AnchorTarget is where the roots of anchored receipts and claims are
+// published.
+type AnchorTarget string
+
+const (
+	// AnchorTargetChain sends the root as the calldata of a transaction.
+	AnchorTargetChain AnchorTarget = "chain"
+	// AnchorTargetIndexer caches the root with the indexing service in a claim
+	// issued by the node.
+	AnchorTargetIndexer AnchorTarget = "indexer"
+)
+
+// AnchoringConfig configures periodic anchoring of a Merkle root of issued
+// receipts and claims on chain or with the indexing service.
+type AnchoringConfig struct {
+	Enabled bool
+	// Interval is how often pending receipts and claims are anchored.
+	Interval time.Duration
+	// Target is where roots are published.
+	Target AnchorTarget
+	// Address receives the anchoring transactions. The zero address sends them
+	// to the owner address.
+	Address common.Address
 }
 
 // GasConfig configures per-message-type gas fee limits.
 // Values are in wei. A value of 0 means no limit (default).
 type GasConfig struct {
-	MaxFee    GasMaxFeeConfig
+	MaxFee GasMaxFeeConfig
+	// Strategy sets how the fees of each message type are priced.
+	Strategy  GasStrategyConfig
 	RetryWait time.Duration
+	// History is how much base fee history the gas oracle keeps.
+	History time.Duration
+	// Wait defers non-urgent messages until base fees are low.
+	Wait GasWaitConfig
+	// Replace configures replacement of stalled and dropped messages.
+	Replace GasReplaceConfig
+}
+
+// GasReplaceConfig configures replacement of sent messages that are not
+// included on chain.
+type GasReplaceConfig struct {
+	// StallTimeout is how long a message may stay pending before it is
+	// replaced at a higher fee, or resubmitted if it was dropped.
+	StallTimeout time.Duration
+	// MaxBumps is the number of times the fee of a message is raised.
+	MaxBumps uint
+}
+
+// GasWaitConfig configures deferral of non-urgent messages to low-fee windows.
+type GasWaitConfig struct {
+	// Percentile of the base fee history the current base fee must be at or
+	// below for a message to be sent. 0 disables waiting.
+	Percentile uint
+	// MaxDelay is how long a message may wait for a low-fee window before it is
+	// sent regardless of fees.
+	MaxDelay time.Duration
+	// Categories are the message categories that wait for low fees.
+	Categories []string
 }
 
 // GasMaxFeeConfig holds per-message-type maximum gas fees in wei.
@@ -55,10 +193,33 @@ type GasMaxFeeConfig struct {
 	Default       uint
 }
 
+// GasStrategyConfig holds the per-message-type gas strategies, one of
+// "conservative", "aggressive" or "deadline-aware". Message types with no
+// strategy set use Default.
+type GasStrategyConfig struct {
+	Prove         string
+	ProvingPeriod string
+	ProvingInit   string
+	AddRoots      string
+	Default       string
+}
+
 // DefaultGasConfig returns a GasConfig with no limits set (all zero).
 func DefaultGasConfig() GasConfig {
 	return GasConfig{
+		Strategy: GasStrategyConfig{
+			Default: "conservative",
+		},
 		RetryWait: 5 * time.Minute,
+		History:   24 * time.Hour,
+		Wait: GasWaitConfig{
+			MaxDelay:   24 * time.Hour,
+			Categories: []string{"settlement"},
+		},
+		Replace: GasReplaceConfig{
+			StallTimeout: 10 * time.Minute,
+			MaxBumps:     5,
+		},
 	}
 }
 
@@ -69,6 +230,25 @@ type SigningServiceConfig struct {
 	// Private key for in-process signing (if using local signer)
 	// NB: this should only be used for development purposes
 	PrivateKey *ecdsa.PrivateKey
+	// KeyStoreAddress signs in-process with the key of this address in the
+	// wallet, which may be held in a KMS.
+	KeyStoreAddress common.Address
+	// Approval holds sign requests for approval by the operator.
+	Approval SigningApprovalConfig
+}
+
+// SigningApprovalConfig selects the operations whose sign requests wait for
+// approval by the operator before they are signed.
+type SigningApprovalConfig struct {
+	Enabled bool
+	// Manual lists the operations held for approval, e.g. "delete-dataset".
+	Manual []string
+	// AutoApproveAddPiecesBelow approves add-pieces requests whose pieces
+	// total fewer padded bytes, even if add-pieces is manual. 0 disables it.
+	AutoApproveAddPiecesBelow uint64
+	// Wait is how long a sign call waits for approval before failing so the
+	// operation is retried later.
+	Wait time.Duration
 }
 
 // AggregationConfig configures the PDP aggregation system.
@@ -80,6 +260,9 @@ type AggregationConfig struct {
 
 type CommpConfig struct {
 	JobQueue JobQueueConfig
+	// MemoryBudget bounds the memory held by concurrent commP calculations,
+	// in bytes. Zero uses the default.
+	MemoryBudget uint64
 }
 
 type AggregatorConfig struct {
@@ -100,6 +283,8 @@ type JobQueueConfig struct {
 	Retries uint
 	// The duration between successive retries
 	RetryDelay time.Duration
+	// The number of jobs the queue can start per minute, zero for no limit.
+	JobsPerMinute uint
 }
 
 // DefaultJobQueueConfig returns a JobQueueConfig with sensible defaults.
@@ -0,0 +1,14 @@
+package app
+
+import "time"
+
+// ReaperConfig configures the removal of expired allocations and the
+// reconciliation of accepted blobs with the blobs held by the node.
+type ReaperConfig struct {
+	// Interval is how often expired allocations for blobs that were never
+	// received are removed. 0 disables reaping.
+	Interval time.Duration
+	// ReconcileInterval is how often accepted blobs missing from the node are
+	// looked for. 0 disables reconciliation.
+	ReconcileInterval time.Duration
+}
@@ -0,0 +1,38 @@
+package app
+
+import "time"
+
+// ReceiptRetentionConfig configures the pruning of old receipts from the
+// receipt store. Receipts are kept forever unless MaxAge or MaxCount is set.
+type ReceiptRetentionConfig struct {
+	// MaxAge is how long receipts are kept after they are stored, 0 for no
+	// limit.
+	MaxAge time.Duration
+	// MaxCount is the number of most recent receipts kept, 0 for no limit.
+	MaxCount uint64
+	// Interval is the time between compaction passes.
+	Interval time.Duration
+	// BatchSize is the number of receipts pruned, and archived together in a
+	// CAR file, at a time.
+	BatchSize int
+	// Archive is where receipts are exported to before they are pruned.
+	Archive ReceiptArchiveConfig
+}
+
+// ReceiptArchiveConfig configures the export of pruned receipts. Receipts are
+// not exported if neither Dir nor S3 is set.
+type ReceiptArchiveConfig struct {
+	// Dir is a directory CAR files of pruned receipts are written to.
+	Dir string
+	// S3 is a bucket CAR files of pruned receipts are uploaded to.
+	S3 *ReceiptArchiveS3Config
+}
+
+// ReceiptArchiveS3Config configures an S3-compatible bucket receipts are
+// archived to.
+type ReceiptArchiveS3Config struct {
+	Endpoint    string
+	Bucket      string
+	Credentials Credentials
+	Insecure    bool
+}
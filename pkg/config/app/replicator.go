@@ -10,8 +10,31 @@ type ReplicatorConfig struct {
 	MaxRetries uint
 	// MaxWorkers configures the maximum workers ran by the replication queue
 	MaxWorkers uint
+	// JobsPerMinute limits the transfers the replication queue starts per
+	// minute, zero for no limit.
+	JobsPerMinute uint
 	// MaxTimeout configures timeout for jobs before they can be re-evaluated
 	MaxTimeout time.Duration
+	// PreferredSourceHosts are hosts favoured as replication sources, e.g.
+	// hosts in the same region as this node.
+	PreferredSourceHosts []string
+	// StallTimeout is how long a replication source may send no data before
+	// the transfer fails over to another source.
+	StallTimeout time.Duration
+	// ChunkSize is the size in bytes of the ranges large blobs are replicated
+	// in when they have several sources.
+	ChunkSize int64
+	// Concurrency is the number of ranges of a blob replicated in parallel, 1
+	// to replicate every blob from a single source.
+	Concurrency int
+	// RetryBackoff is the delay before the first retry of a failed transfer,
+	// doubled for each further retry up to MaxRetryBackoff, if set. Zero
+	// retries failed transfers once MaxTimeout passes.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// Compression has whole blobs transferred zstd compressed between nodes
+	// that both enable it, both when replicating from and serving to them.
+	Compression bool
 }
 
 func DefaultReplicatorConfig() ReplicatorConfig {
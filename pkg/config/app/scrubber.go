@@ -0,0 +1,22 @@
+package app
+
+import (
+	"net/url"
+	"time"
+)
+
+// ScrubberConfig configures the background integrity scrubber, which
+// re-hashes stored blobs to detect bitrot.
+type ScrubberConfig struct {
+	Enabled bool
+	// Interval is the time between the start of consecutive passes over the
+	// blob store.
+	Interval time.Duration
+	// BytesPerSecond limits the rate blobs are read at, 0 for no limit.
+	BytesPerSecond uint64
+	// Repair re-fetches corrupt blobs from Peers.
+	Repair bool
+	// Peers are the base URLs of nodes that may hold copies of the blobs of
+	// this node, e.g. https://piri-2.example.com.
+	Peers []url.URL
+}
@@ -2,6 +2,10 @@ package app
 
 import (
 	"net/url"
+	"time"
+
+	"github.com/multiformats/go-multiaddr"
+	"github.com/storacha/go-ucanto/did"
 )
 
 // ServerConfig contains HTTP server settings
@@ -9,4 +13,174 @@ type ServerConfig struct {
 	Host      string
 	Port      uint
 	PublicURL url.URL
+	// AdditionalPublicURLs are other URLs the node is reachable at, advertised
+	// alongside PublicURL while they pass health checks.
+	AdditionalPublicURLs []url.URL
+	// URLCheck configures the health checks of the public URLs.
+	URLCheck URLCheckConfig
+	// Health configures the probes of the readiness check and draining.
+	Health HealthConfig
+	// Diagnostics configures the optional diagnostics listener.
+	Diagnostics DiagnosticsConfig
+	// CDN configures the optional offload of blob retrievals to a CDN.
+	CDN CDNConfig
+	// RateLimit configures throttling of retrievals.
+	RateLimit RateLimitConfig
+	// PrivateRetrieval configures the spaces whose blobs are only retrieved
+	// by authorized clients.
+	PrivateRetrieval PrivateRetrievalConfig
+	// Libp2p configures the optional libp2p host serving blobs over Bitswap.
+	Libp2p Libp2pConfig
+	// GRPC configures the optional listener serving the PDP API over gRPC.
+	GRPC GRPCConfig
+	// ACME configures serving the public endpoint over TLS with certificates
+	// obtained from an ACME CA.
+	ACME ACMEConfig
+	// HTTP3 configures serving the public endpoint over HTTP/3 as well.
+	HTTP3 HTTP3Config
+}
+
+// URLCheckConfig configures health checks of the public URLs of the node,
+// made every Interval with requests timing out after Timeout.
+type URLCheckConfig struct {
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// HealthConfig configures the probes of the dependencies of the node, run
+// every ProbeInterval and timing out after ProbeTimeout, and how long the
+// node fails readiness checks before shutting down.
+type HealthConfig struct {
+	ProbeInterval time.Duration
+	ProbeTimeout  time.Duration
+	DrainDelay    time.Duration
+}
+
+// Libp2pConfig configures a libp2p host serving blobs over Bitswap, using the
+// node identity as its peer ID. The host is disabled when Enabled is false.
+type Libp2pConfig struct {
+	Enabled     bool
+	ListenAddrs []multiaddr.Multiaddr
+	// AnnounceAddrs are advertised to indexers, the listen addresses of the
+	// host if empty.
+	AnnounceAddrs []multiaddr.Multiaddr
+}
+
+// RateLimitConfig configures token bucket rate limits on retrievals. A zero
+// rate disables the limit.
+type RateLimitConfig struct {
+	// IP limits blob and claim downloads per client IP.
+	IP RateLimit
+	// Space limits content retrievals per space.
+	Space RateLimit
+	// TrustProxyHeaders identifies clients by the X-Forwarded-For header set
+	// by a reverse proxy on a private address.
+	TrustProxyHeaders bool
+}
+
+type RateLimit struct {
+	// Rate is the sustained number of requests per second.
+	Rate float64
+	// Burst is the number of requests allowed at once, the rate rounded up
+	// if 0.
+	Burst int
+}
+
+// PrivateRetrievalConfig configures private spaces. Their blobs are not
+// served from their public URL nor over Bitswap, but only to clients
+// invoking space/content/retrieve or holding a URL signed by the node.
+type PrivateRetrievalConfig struct {
+	Spaces []did.DID
+	// URLTTL is how long signed retrieval URLs are valid for.
+	URLTTL time.Duration
+}
+
+type CDNProvider string
+
+const (
+	CDNProviderCloudFront CDNProvider = "cloudfront"
+	CDNProviderCloudflare CDNProvider = "cloudflare"
+)
+
+// CDNConfig configures redirecting blob retrievals to a CDN. Offload is
+// disabled when Provider is empty.
+type CDNConfig struct {
+	Provider CDNProvider
+	// URL is the base URL of the CDN, blobs are served from URL/blob/{digest}.
+	URL url.URL
+	// TTL is how long signed URLs are valid for.
+	TTL time.Duration
+	// OriginSecret is sent by the CDN in origin fetches, which are served
+	// rather than redirected.
+	OriginSecret string
+	CloudFront   CloudFrontConfig
+	Cloudflare   CloudflareConfig
+}
+
+type CloudFrontConfig struct {
+	KeyPairID      string
+	PrivateKeyFile string
+	// DistributionID enables invalidation of deleted blobs.
+	DistributionID string
+}
+
+type CloudflareConfig struct {
+	SigningSecret string
+	// ZoneID and APIToken enable purging deleted blobs.
+	ZoneID   string
+	APIToken string
+}
+
+// DiagnosticsConfig configures a listener serving pprof profiles and runtime
+// diagnostics, separate from the public server.
+type DiagnosticsConfig struct {
+	Enabled bool
+	Host    string
+	Port    uint
+}
+
+// GRPCConfig configures a listener serving part of the PDP API over gRPC, for
+// internal services. Calls are made in plaintext when Insecure is true, and
+// must be protected by the network the listener is reachable from.
+type GRPCConfig struct {
+	Enabled  bool
+	Host     string
+	Port     uint
+	Insecure bool
+	// CertFile and KeyFile hold the TLS certificate of the listener, required
+	// unless Insecure is true.
+	CertFile string
+	KeyFile  string
+}
+
+// HTTP3Config configures serving the public endpoint over HTTP/3 (QUIC) on
+// the UDP port Port, alongside TCP, when Enabled is true. HTTP/3 is advertised
+// to clients with the Alt-Svc header on AdvertisedPort, remembered by them for
+// AltSvcMaxAge. When Required is false the node starts without HTTP/3 if the
+// UDP port cannot be listened on.
+type HTTP3Config struct {
+	Enabled        bool
+	Port           uint
+	AdvertisedPort uint
+	AltSvcMaxAge   time.Duration
+	Required       bool
+}
+
+// ACMEConfig configures obtaining and renewing the TLS certificates of the
+// public endpoint from an ACME CA, such as Let's Encrypt. The server listens
+// for TLS on its port when Enabled is true, answering TLS-ALPN-01 challenges,
+// and for HTTP-01 challenges on HTTPHost:HTTPPort unless DisableHTTPChallenge
+// is true.
+type ACMEConfig struct {
+	Enabled   bool
+	Hostnames []string
+	// Email is given to the CA to notify of problems with certificates.
+	Email string
+	// DirectoryURL is the ACME directory of the CA, Let's Encrypt if empty.
+	DirectoryURL string
+	// CacheDir holds the account key and certificates.
+	CacheDir             string
+	HTTPHost             string
+	HTTPPort             uint
+	DisableHTTPChallenge bool
 }
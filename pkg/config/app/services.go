@@ -22,7 +22,9 @@ type ExternalServicesConfig struct {
 // using the service
 type IndexingServiceConfig struct {
 	Connection client.Connection
-	Proofs     delegation.Proofs
+	// URL is the endpoint of the connection, to connect with another client.
+	URL    *url.URL
+	Proofs delegation.Proofs
 }
 
 type EgressTrackerServiceConfig struct {
@@ -35,6 +37,8 @@ type EgressTrackerServiceConfig struct {
 
 type UploadServiceConfig struct {
 	Connection client.Connection
+	// URL is the endpoint of the connection, to connect with another client.
+	URL *url.URL
 }
 
 type PublisherServiceConfig struct {
@@ -46,4 +50,11 @@ type PublisherServiceConfig struct {
 	BlobMaddr multiaddr.Multiaddr
 	// Indexer URLs to send direct HTTP announcements to
 	AnnounceURLs []url.URL
+	// BatchMaxSize is the maximum number of advertisements, one per published
+	// blob, committed to the advertisement chain and announced together. 1 or
+	// less publishes each advertisement on its own.
+	BatchMaxSize uint
+	// BatchMaxLatency is the maximum time an advertisement waits in a batch
+	// before the batch is committed.
+	BatchMaxLatency time.Duration
 }
@@ -59,6 +59,9 @@ type StorageConfig struct {
 	// Database configuration (sqlite or postgres)
 	Database DatabaseConfig
 
+	// Datastore backing the local key-value stores
+	Datastore DatastoreConfig
+
 	// Global S3 config - when set, all supported stores use S3 with separate buckets
 	// named using BucketPrefix (e.g., "piri-blobs", "piri-allocations")
 	S3 *S3Config
@@ -78,6 +81,37 @@ type StorageConfig struct {
 	SchedulerStorage SchedulerConfig
 	PDPStore         PDPStoreConfig
 	Consolidation    ConsolidationStorageConfig
+	Quota            QuotaStorageConfig
+	PieceLog         PieceLogStorageConfig
+	Scrubber         ScrubberStorageConfig
+	StorageClass     StorageClassStorageConfig
+	Collector        CollectorStorageConfig
+	ReplicaPolicy    ReplicaPolicyStorageConfig
+	ContentIndex     ContentIndexStorageConfig
+	Blocklist        BlocklistStorageConfig
+	Backfill         BackfillStorageConfig
+	Reaper           ReaperStorageConfig
+	IPNIBatch        IPNIBatchStorageConfig
+}
+
+// DatastoreBackend is the backend of the local key-value stores.
+type DatastoreBackend string
+
+const (
+	// DatastoreBackendLevelDB keeps each store in a LevelDB database in its
+	// own directory (default).
+	DatastoreBackendLevelDB DatastoreBackend = "leveldb"
+	// DatastoreBackendSQLite keeps the stores as namespaces of a single
+	// SQLite database.
+	DatastoreBackendSQLite DatastoreBackend = "sqlite"
+)
+
+// DatastoreConfig configures the datastore backing the local key-value
+// stores.
+type DatastoreConfig struct {
+	Backend DatastoreBackend
+	// Path of the SQLite database, only used by the sqlite backend.
+	Path string
 }
 
 // S3Config configures S3-compatible storage (e.g., MinIO, AWS S3).
@@ -87,6 +121,7 @@ type S3Config struct {
 	BucketPrefix string      // Prefix for bucket names (e.g., "piri-" creates piri-blobs, piri-allocations, etc.)
 	Credentials  Credentials // access credentials
 	Insecure     bool        // set to true to disable SSL (for development only)
+	PartSize     uint64      // multipart upload part size in bytes, 0 for automatic
 }
 
 // AggregatorStorageConfig contains aggregator-specific storage paths
@@ -136,6 +171,32 @@ type ReplicatorStorageConfig struct{}
 
 type KeyStoreConfig struct {
 	Dir string
+	// Backend holding the key that signs transactions. Dir is only used by
+	// the local backend.
+	Backend KeyStoreBackend
+	AWSKMS  AWSKMSConfig
+	GCPKMS  GCPKMSConfig
+}
+
+type KeyStoreBackend string
+
+const (
+	KeyStoreBackendLocal  KeyStoreBackend = "local"
+	KeyStoreBackendAWSKMS KeyStoreBackend = "aws-kms"
+	KeyStoreBackendGCPKMS KeyStoreBackend = "gcp-kms"
+)
+
+// AWSKMSConfig identifies a secp256k1 signing key in AWS KMS.
+type AWSKMSConfig struct {
+	KeyID    string
+	Region   string
+	Endpoint string
+}
+
+// GCPKMSConfig identifies a secp256k1 key version in GCP Cloud KMS.
+type GCPKMSConfig struct {
+	KeyName  string
+	Endpoint string
 }
 
 type StashStoreConfig struct {
@@ -144,6 +205,23 @@ type StashStoreConfig struct {
 
 type PDPStoreConfig struct {
 	Dir string
+	// Pack configures packing small blobs into pack files.
+	Pack PackConfig
+}
+
+// PackConfig configures packing small blobs into append-only pack files
+// rather than storing a file per blob.
+type PackConfig struct {
+	Enabled bool
+	// Dir holds the pack files and their index. Packed blobs stay readable
+	// while the directory exists, even once packing is disabled.
+	Dir string
+	// Threshold is the size up to which blobs are packed, 0 for the default.
+	Threshold uint64
+	// MaxPackSize is the size a pack is sealed at, 0 for the default.
+	MaxPackSize uint64
+	// CompactInterval is the time between compactions, 0 for the default.
+	CompactInterval time.Duration
 }
 
 // ConsolidationStorageConfig contains consolidation-specific storage paths
@@ -151,6 +229,62 @@ type ConsolidationStorageConfig struct {
 	Dir string
 }
 
+// QuotaStorageConfig contains quota-specific storage paths
+type QuotaStorageConfig struct {
+	Dir string
+}
+
+// PieceLogStorageConfig contains piece lifecycle log storage paths
+type PieceLogStorageConfig struct {
+	Dir string
+}
+
+// ScrubberStorageConfig contains integrity scrubber storage paths
+type ScrubberStorageConfig struct {
+	Dir string
+}
+
+// StorageClassStorageConfig contains storage class storage paths
+type StorageClassStorageConfig struct {
+	Dir string
+}
+
+// CollectorStorageConfig contains blob collector storage paths
+type CollectorStorageConfig struct {
+	Dir string
+}
+
+// ReplicaPolicyStorageConfig contains replica policy storage paths
+type ReplicaPolicyStorageConfig struct {
+	Dir string
+}
+
+// ContentIndexStorageConfig contains content index storage paths
+type ContentIndexStorageConfig struct {
+	Dir string
+}
+
+// BlocklistStorageConfig contains blocklist storage paths
+type BlocklistStorageConfig struct {
+	Dir string
+}
+
+// BackfillStorageConfig contains PDP backfill storage paths
+type BackfillStorageConfig struct {
+	Dir string
+}
+
+// ReaperStorageConfig contains allocation reaper storage paths
+type ReaperStorageConfig struct {
+	Dir string
+}
+
+// IPNIBatchStorageConfig contains paths of the storage of IPNI
+// advertisements batched but not yet published
+type IPNIBatchStorageConfig struct {
+	Dir string
+}
+
 // Credentials configures access credentials for S3-compatible storage.
 type Credentials struct {
 	AccessKeyID     string
@@ -0,0 +1,21 @@
+package app
+
+// StorageClassesConfig configures the storage classes blobs are allocated
+// in.
+type StorageClassesConfig struct {
+	// Default is the class of blobs whose allocation requests no class, in
+	// spaces not assigned one. Empty for the built-in "standard" class.
+	Default string
+	Classes []StorageClass
+}
+
+// StorageClass maps a class name to how blobs of the class are stored.
+type StorageClass struct {
+	Name string
+	// Replicas is the replication factor advertised for blobs of the class, 0
+	// to leave it to the upload service.
+	Replicas uint
+	// ProofSetClass restricts the aggregates of blobs of the class to proof
+	// sets of this class. Empty for any proof set.
+	ProofSetClass string
+}
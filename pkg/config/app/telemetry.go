@@ -6,6 +6,34 @@ type TelemetryConfig struct {
 	Metrics                  []TelemetryCollectorConfig
 	Traces                   []TelemetryCollectorConfig
 	DisableStorachaAnalytics bool
+	// TraceSampleRatio is the fraction of traces started locally (i.e. without a
+	// sampled parent) that are sampled. 0 disables sampling of local roots.
+	TraceSampleRatio float64
+	// Prometheus serves the node's metrics for scraping at /metrics on the
+	// diagnostics listener.
+	Prometheus bool
+	// Federation serves the metrics of this node and its peers at /federate
+	// on the diagnostics listener.
+	Federation FederationConfig
+}
+
+// FederationConfig configures scraping the metrics of peer nodes and serving
+// them, labelled by node, alongside the metrics of this node.
+type FederationConfig struct {
+	Enabled bool
+	// Node labels the metrics of this node, the node DID if empty.
+	Node string
+	// Timeout bounds the scrape of each peer.
+	Timeout time.Duration
+	// Peers is the registry of nodes in the fleet.
+	Peers []FederationPeer
+}
+
+type FederationPeer struct {
+	Name string
+	// URL is the metrics endpoint of the peer, e.g.
+	// http://10.0.0.2:6060/metrics.
+	URL string
 }
 
 type TelemetryCollectorConfig struct {
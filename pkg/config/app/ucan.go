@@ -1,7 +1,37 @@
 package app
 
+import "time"
+
 type UCANServiceConfig struct {
 	Services              ExternalServicesConfig
 	ProofSetID            uint64
+	AutoCreateProofSet    bool
+	ReadOnly              bool
 	InsecureDIDResolution bool
+	DIDResolution         DIDResolutionConfig
+	Batch                 BatchConfig
+	StorageClasses        StorageClassesConfig
+	LocationClaims        LocationClaimsConfig
+	Admission             AdmissionConfig
+	Billing               BillingConfig
+	IPNICheck             IPNICheckConfig
+	Fetch                 FetchConfig
+	Usage                 UsageConfig
+	Blocklist             BlocklistConfig
+	Reaper                ReaperConfig
+}
+
+// DIDResolutionConfig configures caching of keys resolved from the DID
+// documents of did:web principals. Zero values use the defaults.
+type DIDResolutionConfig struct {
+	CacheTTL         time.Duration
+	NegativeCacheTTL time.Duration
+}
+
+// BatchConfig limits execution of agent messages containing multiple
+// invocations. Zero values use the defaults.
+type BatchConfig struct {
+	MaxInvocations int
+	MaxConcurrency int
+	MaxBodySize    int64
 }
@@ -0,0 +1,9 @@
+package app
+
+import "time"
+
+// UsageConfig configures the accounting of the bytes spaces store over time.
+type UsageConfig struct {
+	// SnapshotInterval is how often the size of every space is snapshotted.
+	SnapshotInterval time.Duration
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// BillingConfig configures the rate plans usage of spaces is priced at, and
+// the export of monthly invoices. Billing is disabled without plans.
+type BillingConfig struct {
+	// Currency is the currency plan prices are in, e.g. USD.
+	Currency string `mapstructure:"currency" toml:"currency,omitempty"`
+	// DefaultPlan is the plan of spaces not assigned one. Empty to only bill
+	// spaces assigned a plan.
+	DefaultPlan string `mapstructure:"default_plan" toml:"default_plan,omitempty"`
+	// MeterInterval is how often the storage used by spaces is sampled.
+	MeterInterval time.Duration    `mapstructure:"meter_interval" toml:"meter_interval,omitempty"`
+	Plans         []RatePlanConfig `mapstructure:"plans" validate:"dive" toml:"plans,omitempty"`
+	// Webhook receives the invoice of each month once it has ended.
+	Webhook BillingWebhookConfig `mapstructure:"webhook" toml:"webhook,omitempty"`
+}
+
+// RatePlanConfig configures the prices of a rate plan.
+type RatePlanConfig struct {
+	Name string `mapstructure:"name" validate:"required" toml:"name"`
+	// StorageGiBMonth is the price of storing a GiB for a month.
+	StorageGiBMonth float64 `mapstructure:"storage_gib_month" validate:"min=0" toml:"storage_gib_month,omitempty"`
+	// EgressGiB is the price of a GiB downloaded.
+	EgressGiB float64 `mapstructure:"egress_gib" validate:"min=0" toml:"egress_gib,omitempty"`
+}
+
+// BillingWebhookConfig configures the endpoint invoices are pushed to.
+type BillingWebhookConfig struct {
+	URL string `mapstructure:"url" validate:"omitempty,url" toml:"url,omitempty"`
+	// Secret is the key invoices are signed with.
+	Secret string `mapstructure:"secret" toml:"secret,omitempty"`
+}
+
+func (c BillingConfig) ToAppConfig() (app.BillingConfig, error) {
+	if c.MeterInterval < 0 {
+		return app.BillingConfig{}, fmt.Errorf("billing meter_interval must not be negative")
+	}
+	out := app.BillingConfig{
+		Currency:      c.Currency,
+		DefaultPlan:   c.DefaultPlan,
+		MeterInterval: c.MeterInterval,
+		Webhook: app.BillingWebhookConfig{
+			URL:    c.Webhook.URL,
+			Secret: c.Webhook.Secret,
+		},
+	}
+	names := map[string]bool{}
+	for _, p := range c.Plans {
+		if names[p.Name] {
+			return app.BillingConfig{}, fmt.Errorf("rate plan %q configured more than once", p.Name)
+		}
+		names[p.Name] = true
+		out.Plans = append(out.Plans, app.RatePlan{
+			Name:            p.Name,
+			StorageGiBMonth: p.StorageGiBMonth,
+			EgressGiB:       p.EgressGiB,
+		})
+	}
+	if c.DefaultPlan != "" && !names[c.DefaultPlan] {
+		return app.BillingConfig{}, fmt.Errorf("default rate plan %q is not configured", c.DefaultPlan)
+	}
+	if c.Webhook.URL != "" {
+		u, err := url.Parse(c.Webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return app.BillingConfig{}, fmt.Errorf("invalid billing webhook URL %q: must be an absolute http or https URL", c.Webhook.URL)
+		}
+		if c.Webhook.Secret == "" {
+			return app.BillingConfig{}, fmt.Errorf("billing webhook secret must be set to sign invoices")
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// BlocklistConfig configures the sync of the blocklist of content refused by
+// the node from a remote denylist, e.g. badbits.
+type BlocklistConfig struct {
+	// URL is the denylist synced into the blocklist, in badbits or compact
+	// denylist format.
+	URL string `mapstructure:"url" validate:"omitempty,url" toml:"url,omitempty"`
+	// SyncInterval is how often the denylist is synced.
+	SyncInterval time.Duration `mapstructure:"sync_interval" toml:"sync_interval,omitempty"`
+}
+
+func (c BlocklistConfig) ToAppConfig() (app.BlocklistConfig, error) {
+	if c.SyncInterval < 0 {
+		return app.BlocklistConfig{}, fmt.Errorf("blocklist sync_interval must not be negative")
+	}
+	out := app.BlocklistConfig{SyncInterval: c.SyncInterval}
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return app.BlocklistConfig{}, fmt.Errorf("parsing blocklist URL %s: %w", c.URL, err)
+		}
+		out.URL = u
+	}
+	return out, nil
+}
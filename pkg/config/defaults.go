@@ -18,13 +18,20 @@ const (
 	CommPJobQueueWorkers    Key = "pdp.aggregation.commp.job_queue.workers"
 	CommPJobQueueRetries    Key = "pdp.aggregation.commp.job_queue.retries"
 	CommPJobQueueRetryDelay Key = "pdp.aggregation.commp.job_queue.retry_delay"
+	CommPJobQueueRateLimit  Key = "pdp.aggregation.commp.job_queue.jobs_per_minute"
+	CommPMemoryBudget       Key = "pdp.aggregation.commp.memory_budget"
 )
 
+// DefaultCommPMemoryBudget is the memory concurrent commP calculations may
+// hold (256 MiB).
+const DefaultCommPMemoryBudget uint64 = 256 << 20
+
 // PDP Aggregation - Aggregator
 const (
 	AggregatorJobQueueWorkers    Key = "pdp.aggregation.aggregator.job_queue.workers"
 	AggregatorJobQueueRetries    Key = "pdp.aggregation.aggregator.job_queue.retries"
 	AggregatorJobQueueRetryDelay Key = "pdp.aggregation.aggregator.job_queue.retry_delay"
+	AggregatorJobQueueRateLimit  Key = "pdp.aggregation.aggregator.job_queue.jobs_per_minute"
 )
 
 // PDP Aggregation - Manager (these are dynamic - can change at runtime)
@@ -34,6 +41,7 @@ const (
 	ManagerJobQueueWorkers    Key = "pdp.aggregation.manager.job_queue.workers"
 	ManagerJobQueueRetries    Key = "pdp.aggregation.manager.job_queue.retries"
 	ManagerJobQueueRetryDelay Key = "pdp.aggregation.manager.job_queue.retry_delay"
+	ManagerJobQueueRateLimit  Key = "pdp.aggregation.manager.job_queue.jobs_per_minute"
 )
 
 // PDP Gas Fee Limits (dynamic - can change at runtime)
@@ -44,28 +52,249 @@ const (
 	GasMaxFeeAddRoots      Key = "pdp.gas.max_fee.add_roots"
 	GasMaxFeeDefault       Key = "pdp.gas.max_fee.default"
 	GasRetryWait           Key = "pdp.gas.retry_wait"
+	GasWaitPercentile      Key = "pdp.gas.wait.percentile"
+	GasWaitMaxDelay        Key = "pdp.gas.wait.max_delay"
+)
+
+// PDP Gas Strategies (dynamic - can change at runtime)
+const (
+	GasStrategyProve         Key = "pdp.gas.strategy.prove"
+	GasStrategyProvingPeriod Key = "pdp.gas.strategy.proving_period"
+	GasStrategyProvingInit   Key = "pdp.gas.strategy.proving_init"
+	GasStrategyAddRoots      Key = "pdp.gas.strategy.add_roots"
+	GasStrategyDefault       Key = "pdp.gas.strategy.default"
+)
+
+// Logging (dynamic - can change at runtime)
+const (
+	LogLevels Key = "log.levels"
+)
+
+// Server diagnostics listener
+const (
+	DiagnosticsEnabled Key = "server.diagnostics.enabled"
+	DiagnosticsHost    Key = "server.diagnostics.host"
+	DiagnosticsPort    Key = "server.diagnostics.port"
+)
+
+// Server gRPC listener
+const (
+	GRPCEnabled  Key = "server.grpc.enabled"
+	GRPCHost     Key = "server.grpc.host"
+	GRPCPort     Key = "server.grpc.port"
+	GRPCInsecure Key = "server.grpc.insecure"
+)
+
+// Server ACME certificates
+const (
+	ACMEEnabled              Key = "server.acme.enabled"
+	ACMEHTTPHost             Key = "server.acme.http_host"
+	ACMEHTTPPort             Key = "server.acme.http_port"
+	ACMEDisableHTTPChallenge Key = "server.acme.disable_http_challenge"
+)
+
+// Server HTTP/3 listener
+const (
+	HTTP3Enabled        Key = "server.http3.enabled"
+	HTTP3Port           Key = "server.http3.port"
+	HTTP3AdvertisedPort Key = "server.http3.advertised_port"
+	HTTP3AltSvcMaxAge   Key = "server.http3.alt_svc_max_age"
+	HTTP3Required       Key = "server.http3.required"
+)
+
+// Server libp2p host
+const (
+	Libp2pEnabled     Key = "server.libp2p.enabled"
+	Libp2pListenAddrs Key = "server.libp2p.listen_addrs"
+)
+
+// PDP anchoring of receipts and claims
+const (
+	AnchoringEnabled  Key = "pdp.anchoring.enabled"
+	AnchoringInterval Key = "pdp.anchoring.interval"
+	AnchoringTarget   Key = "pdp.anchoring.target"
+)
+
+// PDP alerting rules
+const (
+	AlertingDeadlineEpochs     Key = "pdp.alerting.deadline_epochs"
+	AlertingFaultRecords       Key = "pdp.alerting.fault_records"
+	AlertingSettlementFailures Key = "pdp.alerting.settlement_failures"
+	AlertingEmailPort          Key = "pdp.alerting.email.port"
+)
+
+// Approval of sign requests by the operator
+const (
+	SigningApprovalEnabled               Key = "pdp.signing_service.approval.enabled"
+	SigningApprovalCreateDataSet         Key = "pdp.signing_service.approval.create_dataset"
+	SigningApprovalAddPieces             Key = "pdp.signing_service.approval.add_pieces"
+	SigningApprovalSchedulePieceRemovals Key = "pdp.signing_service.approval.schedule_piece_removals"
+	SigningApprovalDeleteDataSet         Key = "pdp.signing_service.approval.delete_dataset"
+	SigningApprovalWait                  Key = "pdp.signing_service.approval.wait"
+)
+
+// DefaultSigningApprovalWait is how long a sign call waits for approval.
+const DefaultSigningApprovalWait = time.Minute
+
+// Retries and circuit breaking of requests to other services
+const (
+	HTTPClientMaxRetries       Key = "http_client.max_retries"
+	HTTPClientMinBackoff       Key = "http_client.min_backoff"
+	HTTPClientMaxBackoff       Key = "http_client.max_backoff"
+	HTTPClientBreakerThreshold Key = "http_client.breaker_threshold"
+	HTTPClientBreakerCooldown  Key = "http_client.breaker_cooldown"
+)
+
+// Disk space watermarks
+const (
+	DiskSpaceEnabled       Key = "repo.disk_space.enabled"
+	DiskSpaceHighWatermark Key = "repo.disk_space.high_watermark"
+	DiskSpaceLowWatermark  Key = "repo.disk_space.low_watermark"
+	DiskSpaceInterval      Key = "repo.disk_space.interval"
+)
+
+// Confirmation of sent messages
+const (
+	ConfirmationDepth       Key = "pdp.confirmation.depth"
+	ConfirmationReorgWindow Key = "pdp.confirmation.reorg_window"
+)
+
+// PDP task scheduler priorities
+const (
+	SchedulerUrgentWindow Key = "pdp.scheduler.urgent_window"
+)
+
+// Check of advertisements on the indexers they are announced to
+const (
+	IPNICheckEnabled Key = "ucan.ipni_check.enabled"
+)
+
+// Batching of IPNI advertisements
+const (
+	PublisherBatchMaxSize    Key = "ucan.services.publisher.batch.max_size"
+	PublisherBatchMaxLatency Key = "ucan.services.publisher.batch.max_latency"
+)
+
+// Removal of expired allocations
+const (
+	ReaperInterval          Key = "ucan.reaper.interval"
+	ReaperReconcileInterval Key = "ucan.reaper.reconcile_interval"
+)
+
+// Replication transfer queue (dynamic - can change at runtime)
+const (
+	ReplicationWorkers   Key = "ucan.replication.workers"
+	ReplicationRateLimit Key = "ucan.replication.jobs_per_minute"
+)
+
+// Key store holding the transaction signing key
+const (
+	KeyStoreBackend Key = "keystore.backend"
+)
+
+// Signer of the node's UCANs
+const (
+	IdentitySignerBackend Key = "identity.signer.backend"
 )
 
 var defaultValues = map[Key]any{
+	KeyStoreBackend:       "local",
+	IdentitySignerBackend: "file",
+
+	DiagnosticsEnabled: false,
+	DiagnosticsHost:    DefaultDiagnosticsHost,
+	DiagnosticsPort:    DefaultDiagnosticsPort,
+
+	GRPCEnabled:  false,
+	GRPCHost:     DefaultGRPCHost,
+	GRPCPort:     DefaultGRPCPort,
+	GRPCInsecure: false,
+
+	ACMEEnabled:              false,
+	ACMEHTTPHost:             DefaultACMEHTTPHost,
+	ACMEHTTPPort:             DefaultACMEHTTPPort,
+	ACMEDisableHTTPChallenge: false,
+
+	HTTP3Enabled:        false,
+	HTTP3Port:           0,
+	HTTP3AdvertisedPort: 0,
+	HTTP3AltSvcMaxAge:   DefaultHTTP3AltSvcMaxAge,
+	HTTP3Required:       false,
+
+	Libp2pEnabled:     false,
+	Libp2pListenAddrs: DefaultLibp2pListenAddrs,
+
+	AnchoringEnabled:  false,
+	AnchoringInterval: DefaultAnchoringInterval,
+	AnchoringTarget:   "chain",
+
+	AlertingDeadlineEpochs:     DefaultAlertDeadlineEpochs,
+	AlertingFaultRecords:       true,
+	AlertingSettlementFailures: true,
+	AlertingEmailPort:          DefaultAlertEmailPort,
+
+	SigningApprovalEnabled:               false,
+	SigningApprovalCreateDataSet:         "manual",
+	SigningApprovalAddPieces:             "auto",
+	SigningApprovalSchedulePieceRemovals: "manual",
+	SigningApprovalDeleteDataSet:         "manual",
+	SigningApprovalWait:                  DefaultSigningApprovalWait,
+
+	HTTPClientMaxRetries:       3,
+	HTTPClientMinBackoff:       500 * time.Millisecond,
+	HTTPClientMaxBackoff:       10 * time.Second,
+	HTTPClientBreakerThreshold: 10,
+	HTTPClientBreakerCooldown:  30 * time.Second,
+
+	SchedulerUrgentWindow: DefaultSchedulerUrgentWindow,
+
+	DiskSpaceEnabled:       true,
+	DiskSpaceHighWatermark: 90.0,
+	DiskSpaceLowWatermark:  85.0,
+	DiskSpaceInterval:      time.Minute,
+
+	ConfirmationDepth:       DefaultConfirmationDepth,
+	ConfirmationReorgWindow: DefaultReorgWindow,
+
+	IPNICheckEnabled: true,
+
+	PublisherBatchMaxSize:    100,
+	PublisherBatchMaxLatency: 5 * time.Second,
+
+	ReaperInterval:          time.Hour,
+	ReaperReconcileInterval: 24 * time.Hour,
+
+	ReplicationWorkers:   runtime.NumCPU(),
+	ReplicationRateLimit: 0,
+
 	CommPJobQueueWorkers:    runtime.NumCPU(),
 	CommPJobQueueRetries:    50,
 	CommPJobQueueRetryDelay: 10 * time.Second,
+	CommPJobQueueRateLimit:  0,
+	CommPMemoryBudget:       DefaultCommPMemoryBudget,
 
 	AggregatorJobQueueWorkers:    runtime.NumCPU(),
 	AggregatorJobQueueRetries:    50,
 	AggregatorJobQueueRetryDelay: 10 * time.Second,
+	AggregatorJobQueueRateLimit:  0,
 
 	ManagerPollInterval:       30 * time.Second,
 	ManagerBatchSize:          10,
 	ManagerJobQueueWorkers:    3,
 	ManagerJobQueueRetries:    50,
 	ManagerJobQueueRetryDelay: time.Minute,
+	ManagerJobQueueRateLimit:  0,
 }
 
 // SetDefaults sets all viper defaults for configuration.
 // Called before viper.Unmarshal() to ensure defaults are available.
+// Keys that already have a value, e.g. from a network profile applied with
+// ApplyNetwork, are left untouched.
 func SetDefaults() {
 	for k, v := range defaultValues {
+		if viper.IsSet(string(k)) {
+			continue
+		}
 		viper.SetDefault(string(k), v)
 	}
 }
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// DIDResolutionConfig configures how long keys resolved from the DID
+// documents of did:web principals are cached. Zero values use the defaults.
+type DIDResolutionConfig struct {
+	// CacheTTL is how long a resolved key is used before the DID document is
+	// fetched again.
+	CacheTTL time.Duration `mapstructure:"cache_ttl" toml:"cache_ttl,omitempty"`
+	// NegativeCacheTTL is how long a DID document that could not be fetched
+	// is not fetched again.
+	NegativeCacheTTL time.Duration `mapstructure:"negative_cache_ttl" toml:"negative_cache_ttl,omitempty"`
+}
+
+func (c DIDResolutionConfig) ToAppConfig() (app.DIDResolutionConfig, error) {
+	if c.CacheTTL < 0 || c.NegativeCacheTTL < 0 {
+		return app.DIDResolutionConfig{}, fmt.Errorf("did resolution cache ttls must not be negative")
+	}
+	return app.DIDResolutionConfig{
+		CacheTTL:         c.CacheTTL,
+		NegativeCacheTTL: c.NegativeCacheTTL,
+	}, nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// DiskSpaceConfig configures the watermarks of the disk usage of the data
+// and temp directories above which new allocations are rejected.
+type DiskSpaceConfig struct {
+	Enabled       bool          `mapstructure:"enabled" toml:"enabled"`
+	HighWatermark float64       `mapstructure:"high_watermark" validate:"min=0,max=100" toml:"high_watermark,omitempty"`
+	LowWatermark  float64       `mapstructure:"low_watermark" validate:"min=0,max=100" toml:"low_watermark,omitempty"`
+	Interval      time.Duration `mapstructure:"interval" toml:"interval,omitempty"`
+}
+
+func (d DiskSpaceConfig) ToAppConfig() (app.DiskSpaceConfig, error) {
+	if !d.Enabled {
+		return app.DiskSpaceConfig{}, nil
+	}
+	if d.LowWatermark > d.HighWatermark {
+		return app.DiskSpaceConfig{}, fmt.Errorf("disk space low watermark %g must not exceed high watermark %g", d.LowWatermark, d.HighWatermark)
+	}
+	if d.Interval < 0 {
+		return app.DiskSpaceConfig{}, fmt.Errorf("disk space interval must not be negative")
+	}
+	return app.DiskSpaceConfig{
+		Enabled:       true,
+		HighWatermark: d.HighWatermark,
+		LowWatermark:  d.LowWatermark,
+		Interval:      d.Interval,
+	}, nil
+}
@@ -1,17 +1,25 @@
 package dynamic
 
 import (
+	"context"
+
 	"github.com/spf13/viper"
 	"go.uber.org/fx"
 )
 
 // Module provides the dynamic configuration registry and viper bridge.
 // Packages register their own config entries via Registry.RegisterEntries.
+// Changes to the config file are reloaded into the registry while the node
+// runs.
 var Module = fx.Module("config/dynamic",
 	fx.Provide(
 		ProvideRegistry,
 		ProvideViperBridge,
 	),
+	fx.Invoke(
+		InvokeLogLevels,
+		InvokeWatcher,
+	),
 )
 
 // ProvideRegistry creates an empty Registry.
@@ -32,3 +40,26 @@ func ProvideRegistry() *Registry {
 func ProvideViperBridge(registry *Registry) *ViperBridge {
 	return NewViperBridge(viper.GetViper(), registry)
 }
+
+// InvokeLogLevels registers the dynamic log levels.
+func InvokeLogLevels(registry *Registry) error {
+	return RegisterLogLevels(viper.GetViper(), registry)
+}
+
+// InvokeWatcher reloads the config file whenever it changes, if the node was
+// started with one.
+func InvokeWatcher(lc fx.Lifecycle, bridge *ViperBridge) {
+	configFile := viper.GetViper().ConfigFileUsed()
+	if configFile == "" {
+		return
+	}
+	w := NewWatcher(configFile, bridge.Reload)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return w.Start()
+		},
+		OnStop: func(context.Context) error {
+			return w.Stop()
+		},
+	})
+}
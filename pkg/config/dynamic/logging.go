@@ -0,0 +1,58 @@
+package dynamic
+
+import (
+	"fmt"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/spf13/viper"
+
+	"github.com/storacha/piri/pkg/config"
+)
+
+// RegisterLogLevels registers the levels of logging subsystems as a dynamic
+// config entry and applies them whenever they change. Levels set in the
+// config file are applied immediately.
+//
+// Removing a subsystem from the levels does not restore its previous level,
+// it keeps the last level set until it is set again or the node restarts.
+func RegisterLogLevels(v *viper.Viper, registry *Registry) error {
+	if err := registry.RegisterEntries(map[config.Key]ConfigEntry{
+		config.LogLevels: {Value: map[string]string{}, Schema: LogLevelsSchema{}},
+	}); err != nil {
+		return fmt.Errorf("registering log levels: %w", err)
+	}
+	if _, err := registry.SubscribeFunc(config.LogLevels, func(event ChangeEvent) {
+		if levels, ok := event.NewValue.(map[string]string); ok {
+			applyLogLevels(levels)
+		}
+	}); err != nil {
+		return fmt.Errorf("subscribing to log levels: %w", err)
+	}
+
+	key := string(config.LogLevels)
+	if v.IsSet(key) {
+		if err := registry.Update(map[string]any{key: v.Get(key)}, false, SourceFile); err != nil {
+			return fmt.Errorf("applying log levels from config file: %w", err)
+		}
+	}
+	return nil
+}
+
+// applyLogLevels sets the level of every subsystem, the "*" subsystem first
+// so the levels of individual subsystems override it.
+func applyLogLevels(levels map[string]string) {
+	if level, ok := levels["*"]; ok {
+		lvl, err := logging.LevelFromString(level)
+		if err == nil {
+			logging.SetAllLoggers(lvl)
+		}
+	}
+	for system, level := range levels {
+		if system == "*" {
+			continue
+		}
+		if err := logging.SetLogLevel(system, level); err != nil {
+			log.Warnw("Failed to set log level", "system", system, "level", level, "error", err)
+		}
+	}
+}
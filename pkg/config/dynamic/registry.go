@@ -202,6 +202,34 @@ func (r *Registry) GetUint(key config.Key, fallback uint) uint {
 	return fallback
 }
 
+// GetBool returns the bool value for the given key.
+// If the key doesn't exist or the value is not a bool, returns the fallback.
+func (r *Registry) GetBool(key config.Key, fallback bool) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if entry, ok := r.config[key]; ok {
+		if b, ok := entry.Value.(bool); ok {
+			return b
+		}
+	}
+	return fallback
+}
+
+// GetString returns the string value for the given key.
+// If the key doesn't exist or the value is not a string, returns the fallback.
+func (r *Registry) GetString(key config.Key, fallback string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if entry, ok := r.config[key]; ok {
+		if s, ok := entry.Value.(string); ok {
+			return s
+		}
+	}
+	return fallback
+}
+
 // GetAll returns all config values as a map (for API response).
 // Duration values are formatted as strings.
 func (r *Registry) GetAll() map[string]any {
@@ -296,3 +324,19 @@ func (r *Registry) Subscribe(key config.Key, observer Observer) (func(), error)
 func (r *Registry) SubscribeFunc(key config.Key, fn func(ChangeEvent)) (func(), error) {
 	return r.Subscribe(key, ObserverFunc(fn))
 }
+
+// BindUint registers a uint entry and calls apply with each new value of the
+// key, e.g. to resize a running component when the value is changed.
+func (r *Registry) BindUint(key config.Key, value uint, schema UintSchema, apply func(uint)) error {
+	if err := r.RegisterEntries(map[config.Key]ConfigEntry{
+		key: {Value: value, Schema: schema},
+	}); err != nil {
+		return err
+	}
+	_, err := r.SubscribeFunc(key, func(event ChangeEvent) {
+		if u, ok := event.NewValue.(uint); ok {
+			apply(u)
+		}
+	})
+	return err
+}
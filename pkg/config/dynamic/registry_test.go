@@ -338,6 +338,40 @@ func TestRegistry_GetUint(t *testing.T) {
 	})
 }
 
+func TestRegistry_GetBool(t *testing.T) {
+	r := NewRegistry(map[config.Key]ConfigEntry{
+		"test.bool": {
+			Value:  true,
+			Schema: BoolSchema{},
+		},
+		testKeyUint: {
+			Value:  uint(50),
+			Schema: UintSchema{Min: 0, Max: 100},
+		},
+	})
+
+	require.True(t, r.GetBool("test.bool", false))
+	require.True(t, r.GetBool("nonexistent", true))
+	require.False(t, r.GetBool(testKeyUint, false))
+}
+
+func TestRegistry_GetString(t *testing.T) {
+	r := NewRegistry(map[config.Key]ConfigEntry{
+		"test.string": {
+			Value:  "high",
+			Schema: EnumSchema{Values: []string{"low", "high"}},
+		},
+		testKeyUint: {
+			Value:  uint(50),
+			Schema: UintSchema{Min: 0, Max: 100},
+		},
+	})
+
+	require.Equal(t, "high", r.GetString("test.string", "low"))
+	require.Equal(t, "low", r.GetString("nonexistent", "low"))
+	require.Equal(t, "low", r.GetString(testKeyUint, "low"))
+}
+
 func TestRegistry_GetAll(t *testing.T) {
 	r := NewRegistry(map[config.Key]ConfigEntry{
 		testKeyDuration: {
@@ -426,6 +460,27 @@ func TestRegistry_Subscribe(t *testing.T) {
 	})
 }
 
+func TestRegistry_BindUint(t *testing.T) {
+	r := NewRegistry(nil)
+
+	var applied uint
+	err := r.BindUint(testKeyUint, 3, UintSchema{Min: 1, Max: 10}, func(u uint) {
+		applied = u
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint(3), r.GetUint(testKeyUint, 0))
+
+	require.NoError(t, r.Update(map[string]any{string(testKeyUint): 5}, false, SourceFile))
+	require.Equal(t, uint(5), applied)
+
+	// invalid values are not applied
+	require.Error(t, r.Update(map[string]any{string(testKeyUint): 50}, false, SourceFile))
+	require.Equal(t, uint(5), applied)
+
+	err = r.BindUint(testKeyUint, 3, UintSchema{Min: 1, Max: 10}, func(u uint) {})
+	require.Error(t, err)
+}
+
 func TestRegistry_ConcurrentAccess(t *testing.T) {
 	r := NewRegistry(map[config.Key]ConfigEntry{
 		testKeyDuration: {
@@ -2,8 +2,12 @@ package dynamic
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+
+	logging "github.com/ipfs/go-log/v2"
 )
 
 // ConfigSchema handles parsing raw JSON values and validating them.
@@ -183,3 +187,131 @@ func (s UintSchema) ParseAndValidate(raw any) (any, error) {
 
 	return u, nil
 }
+
+// BoolSchema parses boolean values.
+// Accepts bool or string representations ("true", "false", "1", "0"...).
+type BoolSchema struct{}
+
+func (s BoolSchema) TypeDescription() string {
+	return "boolean"
+}
+
+func (s BoolSchema) ParseAndValidate(raw any) (any, error) {
+	switch v := raw.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, &ParseError{Value: v, Expected: "boolean", Cause: err}
+		}
+		return b, nil
+	default:
+		return nil, &TypeError{
+			Expected: "boolean",
+			Got:      fmt.Sprintf("%T", raw),
+		}
+	}
+}
+
+// EnumSchema parses string values that must be one of Values.
+type EnumSchema struct {
+	Values []string
+	// Optional also accepts the empty string, leaving the value unset.
+	Optional bool
+}
+
+func (s EnumSchema) TypeDescription() string {
+	if s.Optional {
+		return fmt.Sprintf("one of %s, or empty", strings.Join(s.Values, ", "))
+	}
+	return fmt.Sprintf("one of %s", strings.Join(s.Values, ", "))
+}
+
+func (s EnumSchema) ParseAndValidate(raw any) (any, error) {
+	v, ok := raw.(string)
+	if !ok {
+		return nil, &TypeError{
+			Expected: "string",
+			Got:      fmt.Sprintf("%T", raw),
+		}
+	}
+	if !slices.Contains(s.Values, v) && (v != "" || !s.Optional) {
+		return nil, &ParseError{Value: v, Expected: s.TypeDescription()}
+	}
+	return v, nil
+}
+
+// LogLevelsSchema parses and validates the levels of logging subsystems.
+// Accepts a map of subsystem to level (from TOML or JSON) or a string of
+// comma separated subsystem=level pairs. The subsystem "*" sets all loggers.
+type LogLevelsSchema struct{}
+
+func (s LogLevelsSchema) TypeDescription() string {
+	return "map of logging subsystem to level, or 'subsystem=level,...' string"
+}
+
+func (s LogLevelsSchema) ParseAndValidate(raw any) (any, error) {
+	levels := make(map[string]string)
+
+	switch v := raw.(type) {
+	case map[string]string:
+		for system, level := range v {
+			levels[system] = level
+		}
+	case map[string]any:
+		if err := flattenLevels(levels, "", v); err != nil {
+			return nil, err
+		}
+	case string:
+		for _, pair := range strings.Split(v, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			system, level, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, &ParseError{Value: pair, Expected: "subsystem=level"}
+			}
+			levels[strings.TrimSpace(system)] = strings.TrimSpace(level)
+		}
+	default:
+		return nil, &TypeError{
+			Expected: "map of subsystem to level",
+			Got:      fmt.Sprintf("%T", raw),
+		}
+	}
+
+	for system, level := range levels {
+		if system == "" {
+			return nil, &ParseError{Value: level, Expected: "subsystem name"}
+		}
+		if _, err := logging.LevelFromString(level); err != nil {
+			return nil, &ParseError{Value: level, Expected: "log level (debug, info, warn, error)", Cause: err}
+		}
+	}
+
+	return levels, nil
+}
+
+// flattenLevels joins nested maps, which viper creates from subsystem names
+// containing dots, back into subsystem names.
+func flattenLevels(levels map[string]string, prefix string, m map[string]any) error {
+	for k, v := range m {
+		system := k
+		if prefix != "" {
+			system = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case string:
+			levels[system] = v
+		case map[string]any:
+			if err := flattenLevels(levels, system, v); err != nil {
+				return err
+			}
+		default:
+			return &TypeError{Expected: "log level string", Got: fmt.Sprintf("%T", v)}
+		}
+	}
+	return nil
+}
@@ -375,3 +375,110 @@ func TestUintSchema_TypeDescription(t *testing.T) {
 	require.Contains(t, desc, "1")
 	require.Contains(t, desc, "500")
 }
+
+func TestBoolSchema_ParseAndValidate(t *testing.T) {
+	schema := BoolSchema{}
+
+	got, err := schema.ParseAndValidate(true)
+	require.NoError(t, err)
+	require.Equal(t, true, got)
+
+	got, err = schema.ParseAndValidate("false")
+	require.NoError(t, err)
+	require.Equal(t, false, got)
+
+	_, err = schema.ParseAndValidate("maybe")
+	require.IsType(t, &ParseError{}, err)
+
+	_, err = schema.ParseAndValidate(1.0)
+	require.IsType(t, &TypeError{}, err)
+}
+
+func TestEnumSchema_ParseAndValidate(t *testing.T) {
+	schema := EnumSchema{Values: []string{"low", "high"}}
+
+	got, err := schema.ParseAndValidate("high")
+	require.NoError(t, err)
+	require.Equal(t, "high", got)
+
+	_, err = schema.ParseAndValidate("medium")
+	require.IsType(t, &ParseError{}, err)
+
+	_, err = schema.ParseAndValidate(1.0)
+	require.IsType(t, &TypeError{}, err)
+
+	_, err = schema.ParseAndValidate("")
+	require.IsType(t, &ParseError{}, err)
+
+	optional := EnumSchema{Values: []string{"low", "high"}, Optional: true}
+	got, err = optional.ParseAndValidate("")
+	require.NoError(t, err)
+	require.Equal(t, "", got)
+
+	_, err = optional.ParseAndValidate("medium")
+	require.IsType(t, &ParseError{}, err)
+}
+
+func TestLogLevelsSchema_ParseAndValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   any
+		want    map[string]string
+		wantErr bool
+		errType any
+	}{
+		{
+			name:  "parses map",
+			input: map[string]any{"pdp/service": "debug", "*": "warn"},
+			want:  map[string]string{"pdp/service": "debug", "*": "warn"},
+		},
+		{
+			name:  "joins subsystems split by viper",
+			input: map[string]any{"cli": map[string]any{"wallet": "info"}},
+			want:  map[string]string{"cli.wallet": "info"},
+		},
+		{
+			name:  "parses string",
+			input: "pdp/service=debug, telemetry=error",
+			want:  map[string]string{"pdp/service": "debug", "telemetry": "error"},
+		},
+		{
+			name:  "parses empty string",
+			input: "",
+			want:  map[string]string{},
+		},
+		{
+			name:    "rejects invalid level",
+			input:   map[string]any{"pdp/service": "loud"},
+			wantErr: true,
+			errType: &ParseError{},
+		},
+		{
+			name:    "rejects pair without level",
+			input:   "pdp/service",
+			wantErr: true,
+			errType: &ParseError{},
+		},
+		{
+			name:    "rejects non-string level",
+			input:   map[string]any{"pdp/service": 1},
+			wantErr: true,
+			errType: &TypeError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LogLevelsSchema{}.ParseAndValidate(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				if tt.errType != nil {
+					require.IsType(t, tt.errType, err)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
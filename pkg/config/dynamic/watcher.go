@@ -0,0 +1,118 @@
+package dynamic
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long the Watcher waits for a config file to stop
+// changing before reloading it. Editors often write a file in several steps.
+const DefaultWatchDebounce = 500 * time.Millisecond
+
+// Watcher reloads the config file when it changes on disk, so edits to the
+// file are applied without a restart. Reloads go through the Registry, which
+// validates the new values and notifies the observers of each key.
+type Watcher struct {
+	path     string
+	reload   func() error
+	debounce time.Duration
+
+	fsw  *fsnotify.Watcher
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// WatcherOption configures a Watcher.
+type WatcherOption func(*Watcher)
+
+// WithDebounce sets how long the Watcher waits for the file to stop changing
+// before reloading it.
+func WithDebounce(d time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		w.debounce = d
+	}
+}
+
+// NewWatcher creates a Watcher calling reload when the file at path changes,
+// typically ViperBridge.Reload.
+func NewWatcher(path string, reload func() error, opts ...WatcherOption) *Watcher {
+	w := &Watcher{
+		path:     filepath.Clean(path),
+		reload:   reload,
+		debounce: DefaultWatchDebounce,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Start starts watching the file.
+func (w *Watcher) Start() error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating config file watcher: %w", err)
+	}
+	// Watch the directory rather than the file: editors replace the file by
+	// renaming a new one over it, which drops a watch on the file itself.
+	if err := fsw.Add(filepath.Dir(w.path)); err != nil {
+		fsw.Close()
+		return fmt.Errorf("watching config file %s: %w", w.path, err)
+	}
+	w.fsw = fsw
+	w.done = make(chan struct{})
+
+	w.wg.Add(1)
+	go w.run()
+
+	log.Infow("Watching config file for changes", "file", w.path)
+	return nil
+}
+
+// Stop stops watching the file.
+func (w *Watcher) Stop() error {
+	if w.fsw == nil {
+		return nil
+	}
+	close(w.done)
+	w.wg.Wait()
+	return w.fsw.Close()
+}
+
+func (w *Watcher) run() {
+	defer w.wg.Done()
+
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != w.path || !event.Op.Has(fsnotify.Write) && !event.Op.Has(fsnotify.Create) {
+				continue
+			}
+			timer.Reset(w.debounce)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Warnw("Error watching config file", "file", w.path, "error", err)
+		case <-timer.C:
+			// On failure the running config is kept, the file is reloaded
+			// again on its next change.
+			if err := w.reload(); err != nil {
+				log.Errorw("Failed to reload changed config file, keeping current config", "file", w.path, "error", err)
+			}
+		}
+	}
+}
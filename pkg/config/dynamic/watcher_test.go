@@ -0,0 +1,121 @@
+package dynamic
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/config"
+)
+
+func TestWatcher(t *testing.T) {
+	t.Run("reloads once after the file stops changing", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.toml")
+		require.NoError(t, os.WriteFile(path, []byte("a = 1\n"), 0644))
+
+		var reloads atomic.Int32
+		w := NewWatcher(path, func() error {
+			reloads.Add(1)
+			return nil
+		}, WithDebounce(100*time.Millisecond))
+		require.NoError(t, w.Start())
+		t.Cleanup(func() { require.NoError(t, w.Stop()) })
+
+		for i := range 3 {
+			require.NoError(t, os.WriteFile(path, fmt.Appendf(nil, "a = %d\n", i+2), 0644))
+		}
+		require.Eventually(t, func() bool { return reloads.Load() == 1 }, 2*time.Second, 10*time.Millisecond)
+		time.Sleep(200 * time.Millisecond)
+		require.Equal(t, int32(1), reloads.Load())
+	})
+
+	t.Run("ignores other files in the directory", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.toml")
+		require.NoError(t, os.WriteFile(path, []byte("a = 1\n"), 0644))
+
+		var reloads atomic.Int32
+		w := NewWatcher(path, func() error {
+			reloads.Add(1)
+			return nil
+		}, WithDebounce(10*time.Millisecond))
+		require.NoError(t, w.Start())
+		t.Cleanup(func() { require.NoError(t, w.Stop()) })
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "other.toml"), []byte("a = 2\n"), 0644))
+		time.Sleep(200 * time.Millisecond)
+		require.Zero(t, reloads.Load())
+	})
+
+	t.Run("reloads a file replaced by rename", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.toml")
+		require.NoError(t, os.WriteFile(path, []byte("a = 1\n"), 0644))
+
+		var reloads atomic.Int32
+		w := NewWatcher(path, func() error {
+			reloads.Add(1)
+			return nil
+		}, WithDebounce(10*time.Millisecond))
+		require.NoError(t, w.Start())
+		t.Cleanup(func() { require.NoError(t, w.Stop()) })
+
+		tmp := filepath.Join(dir, "config.toml.tmp")
+		require.NoError(t, os.WriteFile(tmp, []byte("a = 2\n"), 0644))
+		require.NoError(t, os.Rename(tmp, path))
+		require.Eventually(t, func() bool { return reloads.Load() > 0 }, 2*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestWatcher_ReloadsRegistry(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write("[test]\nuint = 3\n")
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	require.NoError(t, v.ReadInConfig())
+
+	r := NewRegistry(nil)
+	var applied atomic.Uint64
+	require.NoError(t, r.BindUint(testKeyUint, uint(v.GetUint(string(testKeyUint))), UintSchema{Min: 1, Max: 10}, func(u uint) {
+		applied.Store(uint64(u))
+	}))
+
+	w := NewWatcher(path, NewViperBridge(v, r).Reload, WithDebounce(10*time.Millisecond))
+	require.NoError(t, w.Start())
+	t.Cleanup(func() { require.NoError(t, w.Stop()) })
+
+	write("[test]\nuint = 5\n")
+	require.Eventually(t, func() bool { return applied.Load() == 5 }, 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, uint(5), r.GetUint(testKeyUint, 0))
+
+	// an invalid value keeps the current config
+	write("[test]\nuint = 50\n")
+	time.Sleep(200 * time.Millisecond)
+	require.Equal(t, uint(5), r.GetUint(testKeyUint, 0))
+}
+
+func TestRegisterLogLevels(t *testing.T) {
+	v := viper.New()
+	v.Set(string(config.LogLevels), map[string]any{"config/dynamic": "debug"})
+
+	r := NewRegistry(nil)
+	require.NoError(t, RegisterLogLevels(v, r))
+	require.Equal(t, map[string]string{"config/dynamic": "debug"}, r.GetAll()[string(config.LogLevels)])
+
+	require.NoError(t, r.Update(map[string]any{string(config.LogLevels): "config/dynamic=info"}, false, SourceAPI))
+	require.Equal(t, map[string]string{"config/dynamic": "info"}, r.GetAll()[string(config.LogLevels)])
+
+	require.Error(t, r.Update(map[string]any{string(config.LogLevels): "config/dynamic=loud"}, false, SourceAPI))
+}
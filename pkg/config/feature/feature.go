@@ -0,0 +1,226 @@
+// Package feature provides flags guarding the rollout of new subsystems.
+//
+// Flags are dynamic configuration: each flag is enabled or disabled with the
+// key features.<name>.enabled, set in the config file or changed while the
+// node runs through the admin API. Flags supporting gradual rollout are only
+// active for a percentage of nodes, or of subjects such as spaces, set with
+// features.<name>.rollout. Nodes and subjects are assigned to the rollout by
+// hashing their identity, so the same ones stay enabled as the percentage
+// grows and fleets can canary a feature on a few nodes first.
+package feature
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/spf13/viper"
+
+	"github.com/storacha/piri/pkg/config"
+	"github.com/storacha/piri/pkg/config/dynamic"
+)
+
+var log = logging.Logger("config/feature")
+
+// ErrUnknownFlag is returned when setting a flag that is not registered.
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// Definition describes a feature flag. Subsystems guarded by a flag provide
+// its definition in the "feature_flags" fx group.
+type Definition struct {
+	// Name identifies the flag in config keys, e.g. "collector".
+	Name string
+	// Description is shown to operators listing flags.
+	Description string
+	// Default is whether the flag is enabled when it is not configured.
+	Default bool
+	// Rollout is whether the flag can be enabled for a percentage of nodes or
+	// subjects.
+	Rollout bool
+}
+
+// Status is the state of a flag.
+type Status struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Enabled is whether the flag is enabled.
+	Enabled bool `json:"enabled"`
+	// Rollout is the percentage of nodes or subjects the flag is enabled for,
+	// nil for flags without gradual rollout.
+	Rollout *uint `json:"rollout,omitempty"`
+	// Active is whether the flag is enabled on this node, taking the rollout
+	// into account.
+	Active bool `json:"active"`
+}
+
+// EnabledKey is the config key enabling the named flag.
+func EnabledKey(name string) config.Key {
+	return config.Key("features." + name + ".enabled")
+}
+
+// RolloutKey is the config key of the rollout percentage of the named flag.
+func RolloutKey(name string) config.Key {
+	return config.Key("features." + name + ".rollout")
+}
+
+// Flags holds the feature flags of a node. A nil *Flags reports every flag as
+// disabled.
+type Flags struct {
+	registry *dynamic.Registry
+	// node identifies this node in rollouts.
+	node string
+
+	mu   sync.RWMutex
+	defs map[string]Definition
+}
+
+// New creates flags stored in the registry. The node identity, e.g. its DID,
+// decides whether flags being rolled out are active on this node.
+func New(registry *dynamic.Registry, node string) *Flags {
+	return &Flags{
+		registry: registry,
+		node:     node,
+		defs:     map[string]Definition{},
+	}
+}
+
+// Register registers flags in the registry, applying the values set for them
+// in the config file.
+func (f *Flags) Register(v *viper.Viper, defs ...Definition) error {
+	for _, def := range defs {
+		if def.Name == "" {
+			return errors.New("feature flag has no name")
+		}
+		entries := map[config.Key]dynamic.ConfigEntry{
+			EnabledKey(def.Name): {Value: def.Default, Schema: dynamic.BoolSchema{}},
+		}
+		if def.Rollout {
+			entries[RolloutKey(def.Name)] = dynamic.ConfigEntry{Value: uint(100), Schema: dynamic.UintSchema{Min: 0, Max: 100}}
+		}
+		if err := f.registry.RegisterEntries(entries); err != nil {
+			return fmt.Errorf("registering feature flag %s: %w", def.Name, err)
+		}
+		f.mu.Lock()
+		f.defs[def.Name] = def
+		f.mu.Unlock()
+
+		updates := map[string]any{}
+		for key := range entries {
+			if v.IsSet(string(key)) {
+				updates[string(key)] = v.Get(string(key))
+			}
+		}
+		if err := f.registry.Update(updates, false, dynamic.SourceFile); err != nil {
+			return fmt.Errorf("applying feature flag %s from config file: %w", def.Name, err)
+		}
+		if f.Enabled(def.Name) != def.Default {
+			log.Infow("feature flag configured", "flag", def.Name, "active", f.Enabled(def.Name))
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether the flag is active on this node. Flags being rolled
+// out are active on the rollout percentage of nodes.
+func (f *Flags) Enabled(name string) bool {
+	if f == nil {
+		return false
+	}
+	return f.EnabledFor(name, f.node)
+}
+
+// EnabledFor reports whether the flag is active for the subject, e.g. a
+// space DID. Flags being rolled out are active for the rollout percentage of
+// subjects, flags without rollout for every subject if enabled.
+func (f *Flags) EnabledFor(name, subject string) bool {
+	if f == nil {
+		return false
+	}
+	f.mu.RLock()
+	def, ok := f.defs[name]
+	f.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	if !f.registry.GetBool(EnabledKey(name), def.Default) {
+		return false
+	}
+	if !def.Rollout {
+		return true
+	}
+	return bucket(name, subject) < f.registry.GetUint(RolloutKey(name), 100)
+}
+
+// Set enables or disables the flag and sets its rollout percentage. Nil
+// values are left unchanged. If persist is true the change is written to the
+// config file.
+func (f *Flags) Set(name string, enabled *bool, rollout *uint, persist bool) (Status, error) {
+	f.mu.RLock()
+	def, ok := f.defs[name]
+	f.mu.RUnlock()
+	if !ok {
+		return Status{}, fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+	}
+	updates := map[string]any{}
+	if enabled != nil {
+		updates[string(EnabledKey(name))] = *enabled
+	}
+	if rollout != nil {
+		if !def.Rollout {
+			return Status{}, fmt.Errorf("feature flag %s does not support gradual rollout", name)
+		}
+		updates[string(RolloutKey(name))] = *rollout
+	}
+	if err := f.registry.Update(updates, persist, dynamic.SourceAPI); err != nil {
+		return Status{}, err
+	}
+	return f.status(def), nil
+}
+
+// Status returns the state of all flags, sorted by name.
+func (f *Flags) Status() []Status {
+	if f == nil {
+		return nil
+	}
+	f.mu.RLock()
+	defs := make([]Definition, 0, len(f.defs))
+	for _, def := range f.defs {
+		defs = append(defs, def)
+	}
+	f.mu.RUnlock()
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+
+	out := make([]Status, 0, len(defs))
+	for _, def := range defs {
+		out = append(out, f.status(def))
+	}
+	return out
+}
+
+func (f *Flags) status(def Definition) Status {
+	s := Status{
+		Name:        def.Name,
+		Description: def.Description,
+		Enabled:     f.registry.GetBool(EnabledKey(def.Name), def.Default),
+		Active:      f.Enabled(def.Name),
+	}
+	if def.Rollout {
+		r := f.registry.GetUint(RolloutKey(def.Name), 100)
+		s.Rollout = &r
+	}
+	return s
+}
+
+// bucket assigns the subject a number from 0 to 99 for the named flag. The
+// flag name is hashed with the subject so different flags are rolled out to
+// different subjects.
+func bucket(name, subject string) uint {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(subject))
+	return uint(h.Sum32() % 100)
+}
@@ -0,0 +1,98 @@
+package feature
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/config/dynamic"
+)
+
+func TestFlags(t *testing.T) {
+	newFlags := func(t *testing.T, v *viper.Viper, defs ...Definition) *Flags {
+		f := New(dynamic.NewRegistry(nil), "did:key:node")
+		require.NoError(t, f.Register(v, defs...))
+		return f
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		f := newFlags(t, viper.New(),
+			Definition{Name: "on", Default: true},
+			Definition{Name: "off"},
+		)
+		require.True(t, f.Enabled("on"))
+		require.False(t, f.Enabled("off"))
+		require.False(t, f.Enabled("unknown"))
+	})
+
+	t.Run("config file", func(t *testing.T) {
+		v := viper.New()
+		v.Set("features.off.enabled", true)
+		f := newFlags(t, v, Definition{Name: "off"})
+		require.True(t, f.Enabled("off"))
+	})
+
+	t.Run("set", func(t *testing.T) {
+		f := newFlags(t, viper.New(), Definition{Name: "plain"})
+		enabled := true
+		s, err := f.Set("plain", &enabled, nil, false)
+		require.NoError(t, err)
+		require.True(t, s.Enabled)
+		require.True(t, s.Active)
+		require.Nil(t, s.Rollout)
+
+		rollout := uint(50)
+		_, err = f.Set("plain", nil, &rollout, false)
+		require.Error(t, err, "flag without rollout")
+
+		_, err = f.Set("unknown", &enabled, nil, false)
+		require.ErrorIs(t, err, ErrUnknownFlag)
+	})
+
+	t.Run("rollout", func(t *testing.T) {
+		f := newFlags(t, viper.New(), Definition{Name: "gradual", Default: true, Rollout: true})
+		require.True(t, f.EnabledFor("gradual", "space"), "fully rolled out by default")
+
+		rollout := uint(30)
+		_, err := f.Set("gradual", nil, &rollout, false)
+		require.NoError(t, err)
+
+		active := map[string]bool{}
+		for i := range 1000 {
+			subject := fmt.Sprintf("did:key:space%d", i)
+			active[subject] = f.EnabledFor("gradual", subject)
+		}
+		var n int
+		for _, on := range active {
+			if on {
+				n++
+			}
+		}
+		require.InDelta(t, 300, n, 60)
+
+		// subjects in the rollout stay in it as it grows
+		rollout = 60
+		_, err = f.Set("gradual", nil, &rollout, false)
+		require.NoError(t, err)
+		for subject, on := range active {
+			if on {
+				require.True(t, f.EnabledFor("gradual", subject))
+			}
+		}
+
+		disabled := false
+		_, err = f.Set("gradual", &disabled, nil, false)
+		require.NoError(t, err)
+		for subject := range active {
+			require.False(t, f.EnabledFor("gradual", subject))
+		}
+	})
+
+	t.Run("nil flags", func(t *testing.T) {
+		var f *Flags
+		require.False(t, f.Enabled("anything"))
+		require.Empty(t, f.Status())
+	})
+}
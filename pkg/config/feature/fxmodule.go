@@ -0,0 +1,47 @@
+package feature
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/storacha/go-ucanto/principal"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/dynamic"
+)
+
+// Module provides the feature flags of the node. Subsystems declare their
+// flags by providing a Definition in the "feature_flags" group.
+var Module = fx.Module("config/feature",
+	fx.Provide(
+		NewFlagsFromConfig,
+	),
+)
+
+type Params struct {
+	fx.In
+
+	Registry    *dynamic.Registry
+	ID          principal.Signer
+	Definitions []Definition `group:"feature_flags"`
+}
+
+// NewFlagsFromConfig registers the declared flags, and reports their state as
+// metrics while the node runs.
+func NewFlagsFromConfig(lc fx.Lifecycle, params Params) (*Flags, error) {
+	f := New(params.Registry, params.ID.DID().String())
+	if err := f.Register(viper.GetViper(), params.Definitions...); err != nil {
+		return nil, fmt.Errorf("registering feature flags: %w", err)
+	}
+	reg, err := registerMetrics(f)
+	if err != nil {
+		return nil, err
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return reg.Unregister()
+		},
+	})
+	return f, nil
+}
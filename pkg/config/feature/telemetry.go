@@ -0,0 +1,55 @@
+package feature
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// registerMetrics reports the state of each flag as piri_feature_flag_active,
+// 1 if the flag is active on this node and 0 otherwise, and the rollout
+// percentage of flags being rolled out as piri_feature_flag_rollout.
+func registerMetrics(f *Flags) (metric.Registration, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/config/feature")
+	active, err := meter.Int64ObservableGauge(
+		"piri_feature_flag_active",
+		metric.WithDescription("Whether a feature flag is active on the node (1) or not (0)"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create feature flag active gauge: %w", err)
+	}
+	rollout, err := meter.Int64ObservableGauge(
+		"piri_feature_flag_rollout",
+		metric.WithDescription("Percentage of nodes or subjects a feature flag is rolled out to"),
+		metric.WithUnit("%"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create feature flag rollout gauge: %w", err)
+	}
+	reg, err := meter.RegisterCallback(
+		func(ctx context.Context, o metric.Observer) error {
+			for _, s := range f.Status() {
+				attrs := metric.WithAttributes(attribute.String("flag", s.Name))
+				var v int64
+				if s.Active {
+					v = 1
+				}
+				o.ObserveInt64(active, v, attrs)
+				if s.Rollout != nil {
+					o.ObserveInt64(rollout, int64(*s.Rollout), attrs)
+				}
+			}
+			return nil
+		},
+		active,
+		rollout,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("register feature flag metrics callback: %w", err)
+	}
+	return reg, nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// FetchConfig configures the `blob/fetch` capability, which has the node pull
+// blobs from URLs instead of clients uploading them.
+type FetchConfig struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled,omitempty"`
+	// ChunkSize is the size in bytes of the ranges a blob is fetched in.
+	ChunkSize int64 `mapstructure:"chunk_size" validate:"min=0" toml:"chunk_size,omitempty"`
+	// Concurrency is the number of ranges of a blob fetched in parallel.
+	Concurrency int `mapstructure:"concurrency" validate:"min=0" toml:"concurrency,omitempty"`
+	// MaxWorkers is the number of blobs fetched in parallel.
+	MaxWorkers uint `mapstructure:"max_workers" toml:"max_workers,omitempty"`
+	// MaxRetries is the number of times a failed fetch is retried before
+	// the failure is reported.
+	MaxRetries uint `mapstructure:"max_retries" toml:"max_retries,omitempty"`
+	// StallTimeout is how long a source may send no data before the request
+	// to it fails.
+	StallTimeout time.Duration `mapstructure:"stall_timeout" toml:"stall_timeout,omitempty"`
+	// AllowPrivateAddresses allows fetching from loopback and private
+	// addresses. NB: this should only be used for development purposes.
+	AllowPrivateAddresses bool `mapstructure:"allow_private_addresses" toml:"allow_private_addresses,omitempty"`
+}
+
+func (c FetchConfig) ToAppConfig() (app.FetchConfig, error) {
+	if c.StallTimeout < 0 {
+		return app.FetchConfig{}, fmt.Errorf("fetch stall timeout must not be negative")
+	}
+	return app.FetchConfig{
+		Enabled:               c.Enabled,
+		ChunkSize:             c.ChunkSize,
+		Concurrency:           c.Concurrency,
+		MaxWorkers:            c.MaxWorkers,
+		MaxRetries:            c.MaxRetries,
+		StallTimeout:          c.StallTimeout,
+		AllowPrivateAddresses: c.AllowPrivateAddresses,
+	}, nil
+}
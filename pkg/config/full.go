@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/storacha/piri/pkg/config/app"
 )
@@ -12,8 +13,10 @@ type FullServerConfig struct {
 	Repo        RepoConfig        `mapstructure:"repo" toml:"repo"`
 	Server      ServerConfig      `mapstructure:"server" toml:"server"`
 	PDPService  PDPServiceConfig  `mapstructure:"pdp" toml:"pdp"`
+	KeyStore    KeyStoreConfig    `mapstructure:"keystore" toml:"keystore,omitempty"`
 	UCANService UCANServiceConfig `mapstructure:"ucan" toml:"ucan"`
 	Telemetry   TelemetryConfig   `mapstructure:"telemetry" toml:"telemetry,omitempty"`
+	HTTPClient  HTTPClientConfig  `mapstructure:"http_client" toml:"http_client,omitempty"`
 }
 
 func (f FullServerConfig) Validate() error {
@@ -48,6 +51,24 @@ func (f FullServerConfig) ToAppConfig() (app.AppConfig, error) {
 	if err != nil {
 		return app.AppConfig{}, fmt.Errorf("converting repo to app config: %s", err)
 	}
+	if out.Server.ACME.Enabled && out.Server.ACME.CacheDir == "" {
+		out.Server.ACME.CacheDir = filepath.Join(out.Storage.DataDir, DefaultACMECacheDir)
+	}
+	if err := f.KeyStore.ApplyTo(&out.Storage.KeyStore); err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting keystore to app config: %s", err)
+	}
+	out.Scrubber, err = f.Repo.Scrub.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting scrub config to app config: %s", err)
+	}
+	out.ReceiptRetention, err = f.Repo.ReceiptRetention.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting receipt retention config to app config: %s", err)
+	}
+	out.DiskSpace, err = f.Repo.DiskSpace.ToAppConfig()
+	if err != nil {
+		return app.AppConfig{}, fmt.Errorf("converting disk space config to app config: %s", err)
+	}
 
 	out.UCANService, err = f.UCANService.ToAppConfig(out.Server.PublicURL)
 	if err != nil {
@@ -60,11 +81,13 @@ func (f FullServerConfig) ToAppConfig() (app.AppConfig, error) {
 	}
 
 	out.Telemetry = f.Telemetry.ToAppConfig()
+	out.HTTPClient = f.HTTPClient.ToAppConfig()
 
 	//
 	// non-user configuration
 	//
 	out.Replicator = app.DefaultReplicatorConfig()
+	f.UCANService.Replication.ApplyTo(&out.Replicator)
 
 	return out, nil
 }
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// HTTPClientConfig configures the retries and circuit breaking of the
+// requests the node makes to other services: the PDP API, the indexing and
+// upload services and the nodes blobs are replicated to.
+type HTTPClientConfig struct {
+	MaxRetries       int           `mapstructure:"max_retries" validate:"min=0" toml:"max_retries,omitempty"`
+	MinBackoff       time.Duration `mapstructure:"min_backoff" toml:"min_backoff,omitempty"`
+	MaxBackoff       time.Duration `mapstructure:"max_backoff" toml:"max_backoff,omitempty"`
+	BreakerThreshold int           `mapstructure:"breaker_threshold" validate:"min=0" toml:"breaker_threshold,omitempty"`
+	BreakerCooldown  time.Duration `mapstructure:"breaker_cooldown" toml:"breaker_cooldown,omitempty"`
+}
+
+func (c HTTPClientConfig) Validate() error {
+	return validateConfig(c)
+}
+
+func (c HTTPClientConfig) ToAppConfig() app.HTTPClientConfig {
+	return app.HTTPClientConfig{
+		MaxRetries:       c.MaxRetries,
+		MinBackoff:       c.MinBackoff,
+		MaxBackoff:       c.MaxBackoff,
+		BreakerThreshold: c.BreakerThreshold,
+		BreakerCooldown:  c.BreakerCooldown,
+	}
+}
@@ -1,24 +1,159 @@
 package config
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/storacha/go-ucanto/principal"
+
 	"github.com/storacha/piri/lib"
 	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/didweb"
+	"github.com/storacha/piri/pkg/hwsigner"
 )
 
 type IdentityConfig struct {
-	KeyFile string `mapstructure:"key_file" validate:"required" flag:"key-file" toml:"key_file"`
+	// KeyFile is the node's key, required unless the key is held by a signing
+	// agent.
+	KeyFile string       `mapstructure:"key_file" flag:"key-file" toml:"key_file,omitempty"`
+	Signer  SignerConfig `mapstructure:"signer" toml:"signer,omitempty"`
+	// DID is a did:web the node operates as instead of the did:key of its
+	// key. The DID document is served from the public URL, which must be on
+	// the domain of the DID.
+	DID string `mapstructure:"did" toml:"did,omitempty"`
+	// PreviousKeyFiles are keys the node signed with before its key was
+	// rotated, most recent first. They stay in the DID document so
+	// delegations they signed continue to validate. Requires DID.
+	PreviousKeyFiles []string `mapstructure:"previous_key_files" toml:"previous_key_files,omitempty"`
+}
+
+// SignerConfig selects what signs the node's UCANs: location claims,
+// receipts and invocations.
+type SignerConfig struct {
+	// Backend is one of "file", the key file, "agent", a signing agent
+	// holding the key in hardware, or "vault-transit", an ed25519 key of the
+	// transit secrets engine of HashiCorp Vault.
+	Backend string `mapstructure:"backend" validate:"omitempty,oneof=file agent vault-transit" toml:"backend,omitempty"`
+	// AgentSocket is the path of the Unix socket the signing agent listens on.
+	AgentSocket string `mapstructure:"agent_socket" toml:"agent_socket,omitempty"`
+	// EmbedAttestation adds the agent's attestation to location claims.
+	EmbedAttestation bool              `mapstructure:"embed_attestation" toml:"embed_attestation,omitempty"`
+	Vault            VaultSignerConfig `mapstructure:"vault" toml:"vault,omitempty"`
+}
+
+// VaultSignerConfig is the transit key of the vault-transit signer backend.
+// The token is read from VAULT_TOKEN, as for the Vault CLI.
+type VaultSignerConfig struct {
+	// Address of the Vault server, defaults to VAULT_ADDR.
+	Address string `mapstructure:"address" toml:"address,omitempty"`
+	// Mount is the path the transit secrets engine is mounted at.
+	Mount string `mapstructure:"mount" toml:"mount,omitempty"`
+	// KeyName is the name of the ed25519 transit key.
+	KeyName string `mapstructure:"key_name" toml:"key_name,omitempty"`
 }
 
 func (i IdentityConfig) Validate() error {
 	return validateConfig(i)
 }
 
+// LoadSigner returns the signer of the node's key: the key file, or the
+// signing agent or Vault holding the key, which is then never read from
+// disk.
+func (i IdentityConfig) LoadSigner() (principal.Signer, error) {
+	switch i.Signer.Backend {
+	case "", string(app.SignerBackendFile):
+		if i.KeyFile == "" {
+			return nil, fmt.Errorf("key_file is required")
+		}
+		return lib.SignerFromEd25519PEMFile(i.KeyFile)
+	case string(app.SignerBackendAgent):
+		if i.Signer.AgentSocket == "" {
+			return nil, fmt.Errorf("signer backend %s requires agent_socket", i.Signer.Backend)
+		}
+		if i.KeyFile != "" {
+			return nil, fmt.Errorf("key_file must not be set with signer backend %s, the key is held by the agent", i.Signer.Backend)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), hwsigner.ConnectTimeout)
+		defer cancel()
+		s, err := hwsigner.New(ctx, i.Signer.AgentSocket, i.Signer.EmbedAttestation)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to signing agent: %w", err)
+		}
+		return s, nil
+	case string(app.SignerBackendVault):
+		return i.loadVaultSigner()
+	default:
+		return nil, fmt.Errorf("unknown signer backend: %s", i.Signer.Backend)
+	}
+}
+
+func (i IdentityConfig) loadVaultSigner() (principal.Signer, error) {
+	backend := i.Signer.Backend
+	if i.KeyFile != "" {
+		return nil, fmt.Errorf("key_file must not be set with signer backend %s, the key is held by vault", backend)
+	}
+	if i.Signer.EmbedAttestation {
+		return nil, fmt.Errorf("embed_attestation is not supported with signer backend %s", backend)
+	}
+	if i.Signer.Vault.KeyName == "" {
+		return nil, fmt.Errorf("signer backend %s requires vault.key_name", backend)
+	}
+	address := i.Signer.Vault.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return nil, fmt.Errorf("signer backend %s requires vault.address or VAULT_ADDR", backend)
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("signer backend %s requires VAULT_TOKEN", backend)
+	}
+	client, err := hwsigner.NewVaultClient(address, i.Signer.Vault.Mount, i.Signer.Vault.KeyName, token, http.DefaultClient)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hwsigner.ConnectTimeout)
+	defer cancel()
+	s, err := hwsigner.NewVault(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to vault: %w", err)
+	}
+	return s, nil
+}
+
 func (i IdentityConfig) ToAppConfig() (app.IdentityConfig, error) {
-	id, err := lib.SignerFromEd25519PEMFile(i.KeyFile)
+	id, err := i.LoadSigner()
 	if err != nil {
 		return app.IdentityConfig{}, err
 	}
-	return app.IdentityConfig{
+	out := app.IdentityConfig{
 		Signer: id,
-	}, nil
+	}
+	if i.DID != "" {
+		out.DID, err = didweb.Parse(i.DID)
+		if err != nil {
+			return app.IdentityConfig{}, fmt.Errorf("invalid identity did: %w", err)
+		}
+	}
+	if len(i.PreviousKeyFiles) > 0 && i.DID == "" {
+		return app.IdentityConfig{}, fmt.Errorf("previous_key_files requires a did:web identity, a did:key changes with its key")
+	}
+	for _, f := range i.PreviousKeyFiles {
+		prev, err := lib.SignerFromEd25519PEMFile(f)
+		if err != nil {
+			return app.IdentityConfig{}, fmt.Errorf("loading previous key %s: %w", f, err)
+		}
+		if prev.DID() == id.DID() {
+			return app.IdentityConfig{}, fmt.Errorf("previous key %s is the current key", f)
+		}
+		out.PreviousKeys = append(out.PreviousKeys, prev.Verifier())
+	}
+	out.SignerBackend = app.SignerBackendFile
+	if i.Signer.Backend != "" {
+		out.SignerBackend = app.SignerBackend(i.Signer.Backend)
+	}
+	return out, nil
 }
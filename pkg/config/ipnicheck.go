@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// IPNICheckConfig configures the periodic check that the node's IPNI
+// advertisements resolve to it on the indexers they are announced to.
+type IPNICheckConfig struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled,omitempty"`
+	// IndexerURLs are the base URLs of the indexers queried. Defaults to the
+	// IPNI announce URLs, without their /announce path.
+	IndexerURLs []string `mapstructure:"indexer_urls" validate:"dive,url" toml:"indexer_urls,omitempty"`
+	// Interval is the time between checks.
+	Interval time.Duration `mapstructure:"interval" toml:"interval,omitempty"`
+	// SampleSize is the number of advertised multihashes looked up in each
+	// check.
+	SampleSize uint `mapstructure:"sample_size" toml:"sample_size,omitempty"`
+	// MinVisible is the fraction of the sampled multihashes that must resolve
+	// to the node for a check to pass.
+	MinVisible float64 `mapstructure:"min_visible" validate:"min=0,max=1" toml:"min_visible,omitempty"`
+	// AlertAfter is the number of consecutive failed checks of an indexer
+	// after which the failure is reported.
+	AlertAfter uint `mapstructure:"alert_after" toml:"alert_after,omitempty"`
+}
+
+// ToAppConfig converts the config, querying the indexers announced to if no
+// indexer URLs are configured.
+func (c IPNICheckConfig) ToAppConfig(announceURLs []url.URL) (app.IPNICheckConfig, error) {
+	if c.Interval < 0 {
+		return app.IPNICheckConfig{}, fmt.Errorf("IPNI check interval must not be negative")
+	}
+	var indexers []url.URL
+	for _, s := range c.IndexerURLs {
+		u, err := url.Parse(s)
+		if err != nil {
+			return app.IPNICheckConfig{}, fmt.Errorf("parsing IPNI check indexer URL %s: %w", s, err)
+		}
+		indexers = append(indexers, *u)
+	}
+	if len(c.IndexerURLs) == 0 {
+		for _, u := range announceURLs {
+			u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/announce")
+			u.RawPath = ""
+			indexers = append(indexers, u)
+		}
+	}
+	return app.IPNICheckConfig{
+		Enabled:     c.Enabled,
+		IndexerURLs: indexers,
+		Interval:    c.Interval,
+		SampleSize:  c.SampleSize,
+		MinVisible:  c.MinVisible,
+		AlertAfter:  c.AlertAfter,
+	}, nil
+}
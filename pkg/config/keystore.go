@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// KeyStoreConfig selects where the key signing the node's transactions is
+// held.
+type KeyStoreConfig struct {
+	// Backend is one of "local", the wallet in the data directory, "aws-kms"
+	// or "gcp-kms".
+	Backend string       `mapstructure:"backend" validate:"omitempty,oneof=local aws-kms gcp-kms" toml:"backend,omitempty"`
+	AWSKMS  AWSKMSConfig `mapstructure:"aws_kms" toml:"aws_kms,omitempty"`
+	GCPKMS  GCPKMSConfig `mapstructure:"gcp_kms" toml:"gcp_kms,omitempty"`
+}
+
+// AWSKMSConfig identifies an asymmetric ECC_SECG_P256K1 signing key in AWS
+// KMS. Credentials are read from the environment as for other AWS clients.
+type AWSKMSConfig struct {
+	// KeyID is the ID, ARN or alias of the key.
+	KeyID string `mapstructure:"key_id" toml:"key_id,omitempty"`
+	// Region of the key, defaults to the region of the AWS environment.
+	Region string `mapstructure:"region" toml:"region,omitempty"`
+	// Endpoint overrides the KMS endpoint, e.g. for a VPC endpoint.
+	Endpoint string `mapstructure:"endpoint" toml:"endpoint,omitempty"`
+}
+
+// GCPKMSConfig identifies an EC_SIGN_SECP256K1_SHA256 key version in GCP
+// Cloud KMS. The access token is read from GOOGLE_OAUTH_ACCESS_TOKEN, or else
+// from the metadata server of the instance.
+type GCPKMSConfig struct {
+	// KeyName is the resource name of the key version,
+	// projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*.
+	KeyName string `mapstructure:"key_name" toml:"key_name,omitempty"`
+	// Endpoint overrides the Cloud KMS endpoint, e.g. for Private Service
+	// Connect.
+	Endpoint string `mapstructure:"endpoint" toml:"endpoint,omitempty"`
+}
+
+func (c KeyStoreConfig) Validate() error {
+	return validateConfig(c)
+}
+
+// ApplyTo sets the backend of the key store derived from the repo config.
+func (c KeyStoreConfig) ApplyTo(ks *app.KeyStoreConfig) error {
+	switch c.Backend {
+	case "", string(app.KeyStoreBackendLocal):
+		ks.Backend = app.KeyStoreBackendLocal
+	case string(app.KeyStoreBackendAWSKMS):
+		if c.AWSKMS.KeyID == "" {
+			return fmt.Errorf("keystore backend %s requires aws_kms.key_id", c.Backend)
+		}
+		ks.Backend = app.KeyStoreBackendAWSKMS
+		ks.AWSKMS = app.AWSKMSConfig{
+			KeyID:    c.AWSKMS.KeyID,
+			Region:   c.AWSKMS.Region,
+			Endpoint: c.AWSKMS.Endpoint,
+		}
+	case string(app.KeyStoreBackendGCPKMS):
+		if c.GCPKMS.KeyName == "" {
+			return fmt.Errorf("keystore backend %s requires gcp_kms.key_name", c.Backend)
+		}
+		ks.Backend = app.KeyStoreBackendGCPKMS
+		ks.GCPKMS = app.GCPKMSConfig{
+			KeyName:  c.GCPKMS.KeyName,
+			Endpoint: c.GCPKMS.Endpoint,
+		}
+	default:
+		return fmt.Errorf("unknown keystore backend: %s", c.Backend)
+	}
+	return nil
+}
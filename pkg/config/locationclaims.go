@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// LocationClaimsConfig configures the expiration and renewal of location
+// claims. Claims never expire unless an expiration is set.
+type LocationClaimsConfig struct {
+	// Expiration is how long location claims are valid for.
+	Expiration time.Duration `mapstructure:"expiration" toml:"expiration,omitempty"`
+	// RenewBefore is how long before a claim expires it is renewed, a quarter
+	// of the expiration if unset.
+	RenewBefore time.Duration `mapstructure:"renew_before" toml:"renew_before,omitempty"`
+	// RenewInterval is how often claims due for renewal are checked.
+	RenewInterval time.Duration `mapstructure:"renew_interval" toml:"renew_interval,omitempty"`
+	// AlertAfter is the number of consecutive failed renewals of a claim after
+	// which the failure is reported.
+	AlertAfter uint `mapstructure:"alert_after" toml:"alert_after,omitempty"`
+}
+
+func (c LocationClaimsConfig) ToAppConfig() (app.LocationClaimsConfig, error) {
+	if c.Expiration < 0 || c.RenewBefore < 0 || c.RenewInterval < 0 {
+		return app.LocationClaimsConfig{}, fmt.Errorf("location claim durations must not be negative")
+	}
+	if c.Expiration > 0 && c.Expiration < time.Minute {
+		return app.LocationClaimsConfig{}, fmt.Errorf("location claim expiration must be at least a minute")
+	}
+	if c.Expiration > 0 && c.RenewBefore >= c.Expiration {
+		return app.LocationClaimsConfig{}, fmt.Errorf("location claim renew_before (%s) must be less than the expiration (%s)", c.RenewBefore, c.Expiration)
+	}
+	return app.LocationClaimsConfig{
+		Expiration:    c.Expiration,
+		RenewBefore:   c.RenewBefore,
+		RenewInterval: c.RenewInterval,
+		AlertAfter:    c.AlertAfter,
+	}, nil
+}
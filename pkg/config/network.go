@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/viper"
+	"github.com/storacha/go-ucanto/did"
+
+	"github.com/storacha/piri/pkg/presets"
+)
+
+// ProfilesKey is the config file table holding named network profiles. A
+// profile contains any config keys, for example:
+//
+//	[profiles.calibration.pdp]
+//	lotus_endpoint = "wss://calibration.node.example/rpc/v1"
+//
+//	[profiles.calibration.ucan.services.indexer]
+//	url = "https://indexer.example"
+const ProfilesKey = "profiles"
+
+// ApplyNetwork sets the configuration defaults for the named network. Values
+// are layered from lowest to highest precedence:
+//
+//  1. built-in presets, if the network is a known network
+//  2. the matching profile from the config file ([profiles.<network>])
+//  3. explicit keys in the config file
+//  4. environment variables
+//  5. flags
+//
+// Only the first two layers are applied here. They are set as viper defaults
+// so every other source overrides them key by key. An empty network is a
+// no-op, all values must then come from config/env/flags.
+func ApplyNetwork(network string) error {
+	if network == "" {
+		return nil
+	}
+
+	hasProfile := viper.IsSet(ProfilesKey + "." + network)
+	n, err := presets.ParseNetwork(network)
+	if err != nil {
+		// a profile may describe a network piri has no presets for
+		if !hasProfile {
+			return fmt.Errorf("%w (or a profile defined in the config file: %q)", err, ProfileNames())
+		}
+	} else {
+		preset, err := presets.GetPreset(n)
+		if err != nil {
+			return err
+		}
+		applyPreset(preset)
+	}
+
+	if hasProfile {
+		profile := viper.Sub(ProfilesKey + "." + network)
+		for _, key := range profile.AllKeys() {
+			viper.SetDefault(key, profile.Get(key))
+		}
+	}
+	return nil
+}
+
+// ProfileNames returns the names of the profiles defined in the config file.
+func ProfileNames() []string {
+	var names []string
+	for name := range viper.GetStringMap(ProfilesKey) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func applyPreset(preset presets.Preset) {
+	// given the network, set the _default_ configuration values. These values will apply iff other config: flag, envvar,
+	// file are not provided. This allows users to selectively apply the changes they want via config sources, while
+	// using the remaining defaults for the provided network
+	urls := make([]string, len(preset.Services.IPNIAnnounceURLs))
+	for i, u := range preset.Services.IPNIAnnounceURLs {
+		urls[i] = u.String()
+	}
+	viper.SetDefault("ucan.services.publisher.ipni_announce_urls", urls)
+	viper.SetDefault("ucan.services.principal_mapping", preset.Services.PrincipalMapping)
+
+	viper.SetDefault("ucan.services.indexer.url", preset.Services.IndexingServiceURL.String())
+	viper.SetDefault("ucan.services.indexer.did", preset.Services.IndexingServiceDID.String())
+	viper.SetDefault("ucan.services.etracker.url", preset.Services.EgressTrackerServiceURL.String())
+	viper.SetDefault("ucan.services.etracker.did", preset.Services.EgressTrackerServiceDID.String())
+	viper.SetDefault("ucan.services.etracker.receipts_endpoint", preset.Services.EgressTrackerServiceURL.JoinPath("/receipts").String())
+	viper.SetDefault("ucan.services.upload.url", preset.Services.UploadServiceURL.String())
+	viper.SetDefault("ucan.services.upload.did", preset.Services.UploadServiceDID.String())
+
+	// the registrar and the signing service are not present in all environments
+	if preset.Services.SigningServiceURL != nil {
+		viper.SetDefault("pdp.signing_service.url", preset.Services.SigningServiceURL.String())
+	}
+	if preset.Services.SigningServiceDID != did.Undef {
+		viper.SetDefault("pdp.signing_service.did", preset.Services.SigningServiceDID.String())
+	}
+	if preset.Services.RegistrarServiceURL != nil {
+		viper.SetDefault("pdp.registrar_service.url", preset.Services.RegistrarServiceURL.String())
+	}
+
+	// smart contract defaults
+	viper.SetDefault("pdp.contracts.verifier", preset.SmartContracts.Verifier.String())
+	viper.SetDefault("pdp.contracts.provider_registry", preset.SmartContracts.ProviderRegistry.String())
+	viper.SetDefault("pdp.contracts.service", preset.SmartContracts.Service.String())
+	viper.SetDefault("pdp.contracts.service_view", preset.SmartContracts.ServiceView.String())
+	viper.SetDefault("pdp.contracts.payments", preset.SmartContracts.Payments.String())
+	viper.SetDefault("pdp.contracts.usdfc_token", preset.SmartContracts.USDFCToken.String())
+	viper.SetDefault("pdp.chain_id", preset.SmartContracts.ChainID.String())
+	viper.SetDefault("pdp.payer_address", preset.SmartContracts.PayerAddress.String())
+}
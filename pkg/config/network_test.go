@@ -0,0 +1,75 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/presets"
+)
+
+func readTestConfig(t *testing.T, data string) {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.SetConfigType("toml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(data)))
+}
+
+func TestApplyNetwork(t *testing.T) {
+	t.Run("layers profile over presets and config over profile", func(t *testing.T) {
+		readTestConfig(t, `
+[pdp]
+lotus_endpoint = "wss://explicit.example/rpc/v1"
+
+[profiles.prod.pdp]
+lotus_endpoint = "wss://profile.example/rpc/v1"
+chain_id = "1234"
+
+[profiles.prod.ucan.services.indexer]
+url = "https://indexer.profile.example"
+`)
+
+		require.NoError(t, ApplyNetwork(string(presets.Prod)))
+
+		preset, err := presets.GetPreset(presets.Prod)
+		require.NoError(t, err)
+
+		// explicit config wins over the profile
+		require.Equal(t, "wss://explicit.example/rpc/v1", viper.GetString("pdp.lotus_endpoint"))
+		// profile wins over presets
+		require.Equal(t, "1234", viper.GetString("pdp.chain_id"))
+		require.Equal(t, "https://indexer.profile.example", viper.GetString("ucan.services.indexer.url"))
+		// presets fill in the rest
+		require.Equal(t, preset.Services.UploadServiceURL.String(), viper.GetString("ucan.services.upload.url"))
+	})
+
+	t.Run("profile without presets", func(t *testing.T) {
+		readTestConfig(t, `
+[profiles.devnet.pdp]
+lotus_endpoint = "ws://localhost:1234/rpc/v1"
+`)
+
+		require.NoError(t, ApplyNetwork("devnet"))
+		require.Equal(t, "ws://localhost:1234/rpc/v1", viper.GetString("pdp.lotus_endpoint"))
+		require.Equal(t, []string{"devnet"}, ProfileNames())
+	})
+
+	t.Run("profile values are not overwritten by defaults", func(t *testing.T) {
+		readTestConfig(t, `
+[profiles.devnet.pdp.aggregation.manager]
+batch_size = 99
+`)
+
+		require.NoError(t, ApplyNetwork("devnet"))
+		SetDefaults()
+		require.Equal(t, 99, viper.GetInt(string(ManagerBatchSize)))
+	})
+
+	t.Run("unknown network", func(t *testing.T) {
+		readTestConfig(t, ``)
+		require.ErrorContains(t, ApplyNetwork("nope"), "unknown network")
+	})
+}
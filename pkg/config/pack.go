@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// PackConfig configures packing small blobs into append-only pack files.
+type PackConfig struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled,omitempty"`
+	// Threshold is the size in bytes up to which blobs are packed.
+	Threshold uint64 `mapstructure:"threshold" toml:"threshold,omitempty"`
+	// MaxPackSize is the size in bytes a pack is sealed at and a new one
+	// started.
+	MaxPackSize uint64 `mapstructure:"max_pack_size" toml:"max_pack_size,omitempty"`
+	// CompactInterval is the time between compactions of the packs.
+	CompactInterval time.Duration `mapstructure:"compact_interval" toml:"compact_interval,omitempty"`
+}
+
+func (p PackConfig) ToAppConfig(dir string) (app.PackConfig, error) {
+	if p.CompactInterval < 0 {
+		return app.PackConfig{}, fmt.Errorf("pack compact interval must not be negative")
+	}
+	if p.MaxPackSize != 0 && p.MaxPackSize < p.Threshold {
+		return app.PackConfig{}, fmt.Errorf("max pack size must not be smaller than the pack threshold")
+	}
+	return app.PackConfig{
+		Enabled:         p.Enabled,
+		Dir:             dir,
+		Threshold:       p.Threshold,
+		MaxPackSize:     p.MaxPackSize,
+		CompactInterval: p.CompactInterval,
+	}, nil
+}
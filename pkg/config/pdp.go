@@ -36,6 +36,11 @@ type PDPServiceConfig struct {
 	PayerAddress   string               `mapstructure:"payer_address" validate:"required" flag:"payer-address" toml:"payer_address,omitempty"`
 	Aggregation    AggregationConfig    `mapstructure:"aggregation" toml:"aggregation,omitempty"`
 	Gas            GasConfig            `mapstructure:"gas" toml:"gas,omitempty"`
+	Anchoring      AnchoringConfig      `mapstructure:"anchoring" toml:"anchoring,omitempty"`
+	Alerting       AlertingConfig       `mapstructure:"alerting" toml:"alerting,omitempty"`
+	Scheduler      SchedulerConfig      `mapstructure:"scheduler" toml:"scheduler,omitempty"`
+	Confirmation   ConfirmationConfig   `mapstructure:"confirmation" toml:"confirmation,omitempty"`
+	Curio          CurioConfig          `mapstructure:"curio" toml:"curio,omitempty"`
 }
 
 func (c PDPServiceConfig) Validate() error {
@@ -95,6 +100,26 @@ func (c PDPServiceConfig) ToAppConfig() (app.PDPServiceConfig, error) {
 		return app.PDPServiceConfig{}, fmt.Errorf("converting aggregation config: %w", err)
 	}
 
+	anchoringCfg, err := c.Anchoring.ToAppConfig()
+	if err != nil {
+		return app.PDPServiceConfig{}, fmt.Errorf("converting anchoring config: %w", err)
+	}
+
+	alertingCfg, err := c.Alerting.ToAppConfig()
+	if err != nil {
+		return app.PDPServiceConfig{}, fmt.Errorf("converting alerting config: %w", err)
+	}
+
+	schedulerCfg, err := c.Scheduler.ToAppConfig()
+	if err != nil {
+		return app.PDPServiceConfig{}, fmt.Errorf("converting scheduler config: %w", err)
+	}
+
+	curioCfg, err := c.Curio.ToAppConfig()
+	if err != nil {
+		return app.PDPServiceConfig{}, fmt.Errorf("converting curio config: %w", err)
+	}
+
 	return app.PDPServiceConfig{
 		OwnerAddress:   common.HexToAddress(c.OwnerAddress),
 		LotusEndpoint:  lotusEndpoint,
@@ -111,6 +136,11 @@ func (c PDPServiceConfig) ToAppConfig() (app.PDPServiceConfig, error) {
 		PayerAddress: common.HexToAddress(c.PayerAddress),
 		Aggregation:  aggregationCfg,
 		Gas:          c.Gas.ToAppConfig(),
+		Anchoring:    anchoringCfg,
+		Alerting:     alertingCfg,
+		Scheduler:    schedulerCfg,
+		Confirmation: c.Confirmation.ToAppConfig(),
+		Curio:        curioCfg,
 	}, nil
 }
 
@@ -124,6 +154,56 @@ type SigningServiceConfig struct {
 	// This should be a hex-encoded private key string
 	// NB: this should only be used for development purposes
 	PrivateKey string `mapstructure:"private_key" toml:"private_key,omitempty"`
+	// KeyStoreAddress signs in-process with the key of this address in the
+	// key store, so with a KMS key store backend the key never touches disk.
+	KeyStoreAddress string `mapstructure:"keystore_address" toml:"keystore_address,omitempty"`
+	// Approval holds sign requests for approval by the operator
+	Approval SigningApprovalConfig `mapstructure:"approval" toml:"approval,omitempty"`
+}
+
+// SigningApprovalConfig holds sign requests for approval by the operator.
+// Each operation is signed right away ("auto") or once approved ("manual").
+type SigningApprovalConfig struct {
+	Enabled       bool   `mapstructure:"enabled" toml:"enabled,omitempty"`
+	CreateDataSet string `mapstructure:"create_dataset" validate:"omitempty,oneof=auto manual" toml:"create_dataset,omitempty"`
+	AddPieces     string `mapstructure:"add_pieces" validate:"omitempty,oneof=auto manual" toml:"add_pieces,omitempty"`
+	// AddPiecesAutoApproveBelow approves add-pieces requests whose pieces
+	// total fewer padded bytes when add-pieces is manual. 0 disables it.
+	AddPiecesAutoApproveBelow uint64 `mapstructure:"add_pieces_auto_approve_below" toml:"add_pieces_auto_approve_below,omitempty"`
+	SchedulePieceRemovals     string `mapstructure:"schedule_piece_removals" validate:"omitempty,oneof=auto manual" toml:"schedule_piece_removals,omitempty"`
+	DeleteDataSet             string `mapstructure:"delete_dataset" validate:"omitempty,oneof=auto manual" toml:"delete_dataset,omitempty"`
+	// Wait is how long a sign call waits for approval before failing so the
+	// operation is retried later.
+	Wait time.Duration `mapstructure:"wait" toml:"wait,omitempty"`
+}
+
+func (c SigningApprovalConfig) ToAppConfig() (app.SigningApprovalConfig, error) {
+	if !c.Enabled {
+		return app.SigningApprovalConfig{}, nil
+	}
+	if c.Wait < 0 {
+		return app.SigningApprovalConfig{}, fmt.Errorf("signing approval wait must not be negative")
+	}
+	out := app.SigningApprovalConfig{
+		Enabled:                   true,
+		AutoApproveAddPiecesBelow: c.AddPiecesAutoApproveBelow,
+		Wait:                      c.Wait,
+	}
+	for _, op := range []struct{ name, mode string }{
+		{"create-dataset", c.CreateDataSet},
+		{"add-pieces", c.AddPieces},
+		{"schedule-piece-removals", c.SchedulePieceRemovals},
+		{"delete-dataset", c.DeleteDataSet},
+	} {
+		switch op.mode {
+		case "manual":
+			out.Manual = append(out.Manual, op.name)
+		case "", "auto":
+		default:
+			return app.SigningApprovalConfig{}, fmt.Errorf("invalid signing approval mode %q for %s: must be auto or manual", op.mode, op.name)
+		}
+	}
+	return out, nil
 }
 
 func (c SigningServiceConfig) Validate() error {
@@ -132,11 +212,22 @@ func (c SigningServiceConfig) Validate() error {
 
 func (c SigningServiceConfig) ToAppConfig() (app.SigningServiceConfig, error) {
 	// one and only one must be set
-	if c.PrivateKey == "" && (c.URL == "" || c.DID == "") {
-		return app.SigningServiceConfig{}, fmt.Errorf("signing service requires private_key or URL+DID")
+	remote := c.URL != "" || c.DID != ""
+	set := 0
+	for _, ok := range []bool{c.PrivateKey != "", c.KeyStoreAddress != "", remote} {
+		if ok {
+			set++
+		}
 	}
-	if c.PrivateKey != "" && (c.URL != "" || c.DID != "") {
-		return app.SigningServiceConfig{}, fmt.Errorf("signing service private_key and URL+DID are mutually exclusive")
+	if set == 0 || (remote && (c.URL == "" || c.DID == "")) {
+		return app.SigningServiceConfig{}, fmt.Errorf("signing service requires private_key, keystore_address or URL+DID")
+	}
+	if set > 1 {
+		return app.SigningServiceConfig{}, fmt.Errorf("signing service private_key, keystore_address and URL+DID are mutually exclusive")
+	}
+	approval, err := c.Approval.ToAppConfig()
+	if err != nil {
+		return app.SigningServiceConfig{}, err
 	}
 
 	if c.URL != "" && c.DID != "" {
@@ -157,6 +248,15 @@ func (c SigningServiceConfig) ToAppConfig() (app.SigningServiceConfig, error) {
 
 		return app.SigningServiceConfig{
 			Connection: conn,
+			Approval:   approval,
+		}, nil
+	} else if c.KeyStoreAddress != "" {
+		if !common.IsHexAddress(c.KeyStoreAddress) {
+			return app.SigningServiceConfig{}, fmt.Errorf("invalid signing service keystore address: %s", c.KeyStoreAddress)
+		}
+		return app.SigningServiceConfig{
+			KeyStoreAddress: common.HexToAddress(c.KeyStoreAddress),
+			Approval:        approval,
 		}, nil
 	} else {
 		// we should only use this for development and local testing.
@@ -173,6 +273,7 @@ func (c SigningServiceConfig) ToAppConfig() (app.SigningServiceConfig, error) {
 		log.Warn("signing service operating with local key")
 		return app.SigningServiceConfig{
 			PrivateKey: privateKey,
+			Approval:   approval,
 		}, nil
 	}
 }
@@ -186,6 +287,10 @@ type AggregationConfig struct {
 
 type CommpConfig struct {
 	JobQueue JobQueueConfig `mapstructure:"job_queue" toml:"job_queue,omitempty"`
+	// MemoryBudget bounds the memory in bytes held by concurrent commP
+	// calculations. Calculations that would exceed it wait for others to
+	// complete.
+	MemoryBudget uint64 `mapstructure:"memory_budget" validate:"omitempty,min=4194304" toml:"memory_budget,omitempty"`
 }
 
 type AggregatorConfig struct {
@@ -226,6 +331,8 @@ type JobQueueConfig struct {
 	Retries uint `mapstructure:"retries" toml:"retries,omitempty"`
 	// The duration between successive retries
 	RetryDelay time.Duration `mapstructure:"retry_delay" toml:"retry_delay,omitempty"`
+	// The number of jobs the queue can start per minute, zero for no limit.
+	JobsPerMinute uint `mapstructure:"jobs_per_minute" toml:"jobs_per_minute,omitempty"`
 }
 
 func (j JobQueueConfig) ToAppConfig() (app.JobQueueConfig, error) {
@@ -236,9 +343,10 @@ func (j JobQueueConfig) ToAppConfig() (app.JobQueueConfig, error) {
 		return app.JobQueueConfig{}, fmt.Errorf("job_queue retry delay must be greater than zero")
 	}
 	return app.JobQueueConfig{
-		Workers:    j.Workers,
-		Retries:    j.Retries,
-		RetryDelay: j.RetryDelay,
+		Workers:       j.Workers,
+		Retries:       j.Retries,
+		RetryDelay:    j.RetryDelay,
+		JobsPerMinute: j.JobsPerMinute,
 	}, nil
 }
 
@@ -257,7 +365,8 @@ func (c AggregationConfig) ToAppConfig() (app.AggregationConfig, error) {
 	}
 	return app.AggregationConfig{
 		CommP: app.CommpConfig{
-			JobQueue: commpJobQueueCfg,
+			JobQueue:     commpJobQueueCfg,
+			MemoryBudget: c.CommP.MemoryBudget,
 		},
 		Aggregator: app.AggregatorConfig{
 			JobQueue: aggregatorJobQueueCfg,
@@ -268,8 +377,37 @@ func (c AggregationConfig) ToAppConfig() (app.AggregationConfig, error) {
 
 // GasConfig configures per-message-type gas fee limits.
 type GasConfig struct {
-	MaxFee    GasMaxFeeConfig `mapstructure:"max_fee" toml:"max_fee,omitempty"`
-	RetryWait time.Duration   `mapstructure:"retry_wait" toml:"retry_wait,omitempty"`
+	MaxFee GasMaxFeeConfig `mapstructure:"max_fee" toml:"max_fee,omitempty"`
+	// Strategy sets how the fees of each message type are priced.
+	Strategy  GasStrategyConfig `mapstructure:"strategy" toml:"strategy,omitempty"`
+	RetryWait time.Duration     `mapstructure:"retry_wait" toml:"retry_wait,omitempty"`
+	// History is how much base fee history the gas oracle keeps.
+	History time.Duration `mapstructure:"history" toml:"history,omitempty"`
+	Wait    GasWaitConfig `mapstructure:"wait" toml:"wait,omitempty"`
+	// Replace configures replacement of stalled and dropped messages.
+	Replace GasReplaceConfig `mapstructure:"replace" toml:"replace,omitempty"`
+}
+
+// GasReplaceConfig configures replacement of sent messages that are not
+// included on chain.
+type GasReplaceConfig struct {
+	// StallTimeout is how long a message may stay pending before its fee is
+	// raised, or it is resubmitted if it was dropped.
+	StallTimeout time.Duration `mapstructure:"stall_timeout" toml:"stall_timeout,omitempty"`
+	// MaxBumps is the number of times the fee of a message is raised.
+	MaxBumps uint `mapstructure:"max_bumps" toml:"max_bumps,omitempty"`
+}
+
+// GasWaitConfig configures deferral of non-urgent messages to low-fee windows.
+type GasWaitConfig struct {
+	// Percentile of the base fee history the current base fee must be at or
+	// below for a message to be sent. 0 disables waiting.
+	Percentile uint `mapstructure:"percentile" validate:"max=100" toml:"percentile,omitempty"`
+	// MaxDelay is how long a message may wait before it is sent regardless of
+	// fees.
+	MaxDelay time.Duration `mapstructure:"max_delay" toml:"max_delay,omitempty"`
+	// Categories are the message categories that wait for low fees.
+	Categories []string `mapstructure:"categories" validate:"dive,oneof=settlement withdrawal anchoring add_roots" toml:"categories,omitempty"`
 }
 
 // GasMaxFeeConfig holds per-message-type maximum gas fees in wei.
@@ -281,10 +419,62 @@ type GasMaxFeeConfig struct {
 	Default       uint `mapstructure:"default" toml:"default,omitempty"`
 }
 
+// GasStrategyConfig holds the per-message-type gas strategies. Message types
+// without a strategy use the default strategy.
+type GasStrategyConfig struct {
+	Prove         string `mapstructure:"prove" validate:"omitempty,oneof=conservative aggressive deadline-aware" toml:"prove,omitempty"`
+	ProvingPeriod string `mapstructure:"proving_period" validate:"omitempty,oneof=conservative aggressive deadline-aware" toml:"proving_period,omitempty"`
+	ProvingInit   string `mapstructure:"proving_init" validate:"omitempty,oneof=conservative aggressive deadline-aware" toml:"proving_init,omitempty"`
+	AddRoots      string `mapstructure:"add_roots" validate:"omitempty,oneof=conservative aggressive deadline-aware" toml:"add_roots,omitempty"`
+	Default       string `mapstructure:"default" validate:"omitempty,oneof=conservative aggressive deadline-aware" toml:"default,omitempty"`
+}
+
+func (c GasStrategyConfig) ToAppConfig() app.GasStrategyConfig {
+	def := c.Default
+	if def == "" {
+		def = app.DefaultGasConfig().Strategy.Default
+	}
+	// types without their own strategy are left unset, so they follow the
+	// default when it is changed at runtime
+	return app.GasStrategyConfig{
+		Prove:         c.Prove,
+		ProvingPeriod: c.ProvingPeriod,
+		ProvingInit:   c.ProvingInit,
+		AddRoots:      c.AddRoots,
+		Default:       def,
+	}
+}
+
 func (c GasConfig) ToAppConfig() app.GasConfig {
+	defaults := app.DefaultGasConfig()
 	retryWait := c.RetryWait
 	if retryWait == 0 {
-		retryWait = 5 * time.Minute
+		retryWait = defaults.RetryWait
+	}
+	history := c.History
+	if history == 0 {
+		history = defaults.History
+	}
+	wait := app.GasWaitConfig{
+		Percentile: c.Wait.Percentile,
+		MaxDelay:   c.Wait.MaxDelay,
+		Categories: c.Wait.Categories,
+	}
+	if wait.MaxDelay == 0 {
+		wait.MaxDelay = defaults.Wait.MaxDelay
+	}
+	if len(wait.Categories) == 0 {
+		wait.Categories = defaults.Wait.Categories
+	}
+	replace := app.GasReplaceConfig{
+		StallTimeout: c.Replace.StallTimeout,
+		MaxBumps:     c.Replace.MaxBumps,
+	}
+	if replace.StallTimeout == 0 {
+		replace.StallTimeout = defaults.Replace.StallTimeout
+	}
+	if replace.MaxBumps == 0 {
+		replace.MaxBumps = defaults.Replace.MaxBumps
 	}
 	return app.GasConfig{
 		MaxFee: app.GasMaxFeeConfig{
@@ -294,8 +484,240 @@ func (c GasConfig) ToAppConfig() app.GasConfig {
 			AddRoots:      c.MaxFee.AddRoots,
 			Default:       c.MaxFee.Default,
 		},
+		Strategy:  c.Strategy.ToAppConfig(),
 		RetryWait: retryWait,
+		History:   history,
+		Wait:      wait,
+		Replace:   replace,
+	}
+}
+
+// DefaultSchedulerUrgentWindow is how close to its deadline a task must be to
+// hold back tasks of lower priority classes when no window is configured.
+const DefaultSchedulerUrgentWindow = 10 * time.Minute
+
+// SchedulerConfig configures the priorities of the PDP task scheduler.
+type SchedulerConfig struct {
+	// UrgentWindow is how close to its deadline, e.g. the close of the
+	// challenge window of a proof, a task must be to hold back tasks of lower
+	// priority classes.
+	UrgentWindow time.Duration `mapstructure:"urgent_window" toml:"urgent_window,omitempty"`
+	// MaxProving caps the proving tasks running at once. 0 is unlimited.
+	MaxProving int `mapstructure:"max_proving" toml:"max_proving,omitempty"`
+	// MaxChain caps the proving period tasks running at once. 0 is unlimited.
+	MaxChain int `mapstructure:"max_chain" toml:"max_chain,omitempty"`
+	// MaxMessages caps the message sending tasks running at once. 0 is
+	// unlimited.
+	MaxMessages int `mapstructure:"max_messages" toml:"max_messages,omitempty"`
+	// MaxBackground caps the background tasks running at once. 0 is
+	// unlimited.
+	MaxBackground int `mapstructure:"max_background" toml:"max_background,omitempty"`
+}
+
+func (c SchedulerConfig) ToAppConfig() (app.TaskSchedulerConfig, error) {
+	out := app.TaskSchedulerConfig{
+		UrgentWindow:  c.UrgentWindow,
+		MaxProving:    c.MaxProving,
+		MaxChain:      c.MaxChain,
+		MaxMessages:   c.MaxMessages,
+		MaxBackground: c.MaxBackground,
+	}
+	if out.UrgentWindow == 0 {
+		out.UrgentWindow = DefaultSchedulerUrgentWindow
+	}
+	if out.UrgentWindow < 0 {
+		return app.TaskSchedulerConfig{}, fmt.Errorf("scheduler urgent window must be greater than zero")
+	}
+	for name, limit := range map[string]int{
+		"max_proving":    c.MaxProving,
+		"max_chain":      c.MaxChain,
+		"max_messages":   c.MaxMessages,
+		"max_background": c.MaxBackground,
+	} {
+		if limit < 0 {
+			return app.TaskSchedulerConfig{}, fmt.Errorf("scheduler %s must not be negative: %d", name, limit)
+		}
+	}
+	return out, nil
+}
+
+// DefaultConfirmationDepth is the number of blocks a message must be buried
+// under to be confirmed when no depth is configured.
+const DefaultConfirmationDepth = 6
+
+// DefaultReorgWindow is the number of blocks confirmed messages are checked
+// for reorgs for.
+const DefaultReorgWindow = 30
+
+// ConfirmationConfig configures when sent messages are confirmed.
+type ConfirmationConfig struct {
+	// Depth is the number of blocks a message must be buried under to be
+	// confirmed. Deeper confirmations are less likely to be reorged out, at
+	// the cost of waiting longer for each message.
+	Depth uint64 `mapstructure:"depth" toml:"depth,omitempty"`
+	// ReorgWindow is the number of blocks confirmed messages are checked for
+	// being reorged out of the chain for. 0 disables the check.
+	ReorgWindow uint64 `mapstructure:"reorg_window" toml:"reorg_window,omitempty"`
+}
+
+func (c ConfirmationConfig) ToAppConfig() app.ConfirmationConfig {
+	out := app.ConfirmationConfig{
+		Depth:       c.Depth,
+		ReorgWindow: c.ReorgWindow,
+	}
+	if out.Depth == 0 {
+		out.Depth = DefaultConfirmationDepth
+	}
+	return out
+}
+
+// CurioConfig configures the Curio node proof sets added with the curio
+// backend are proven by.
+type CurioConfig struct {
+	// URL of the PDP API of the Curio node. Empty disables the curio backend.
+	URL string `mapstructure:"url" flag:"curio-url" toml:"url,omitempty"`
+	// UploadTimeout is how long a piece uploaded to Curio may wait to be
+	// parked before it is uploaded again.
+	UploadTimeout time.Duration `mapstructure:"upload_timeout" toml:"upload_timeout,omitempty"`
+}
+
+func (c CurioConfig) ToAppConfig() (app.CurioConfig, error) {
+	if c.URL == "" {
+		return app.CurioConfig{}, nil
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return app.CurioConfig{}, fmt.Errorf("invalid curio url: %s: %w", c.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return app.CurioConfig{}, fmt.Errorf("invalid curio url: %s: scheme must be http or https", c.URL)
+	}
+	if c.UploadTimeout < 0 {
+		return app.CurioConfig{}, fmt.Errorf("curio upload timeout must not be negative")
+	}
+	return app.CurioConfig{URL: u, UploadTimeout: c.UploadTimeout}, nil
+}
+
+// DefaultAnchoringInterval is how often receipts and claims are anchored when
+// anchoring is enabled and no interval is configured.
+const DefaultAnchoringInterval = 24 * time.Hour
+
+// AnchoringConfig configures periodic anchoring of a Merkle root of issued
+// receipts and claims on chain or with the indexing service.
+type AnchoringConfig struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled,omitempty"`
+	// Interval is how often pending receipts and claims are anchored.
+	Interval time.Duration `mapstructure:"interval" toml:"interval,omitempty"`
+	// Target is one of "chain", a transaction from the owner address, or
+	// "indexer", a claim cached with the indexing service. Defaults to "chain".
+	Target string `mapstructure:"target" validate:"omitempty,oneof=chain indexer" toml:"target,omitempty"`
+	// Address receives the anchoring transactions, e.g. a commitment contract.
+	// Defaults to the owner address.
+	Address string `mapstructure:"address" toml:"address,omitempty"`
+}
+
+func (c AnchoringConfig) ToAppConfig() (app.AnchoringConfig, error) {
+	out := app.AnchoringConfig{
+		Enabled:  c.Enabled,
+		Interval: c.Interval,
+	}
+	if out.Interval == 0 {
+		out.Interval = DefaultAnchoringInterval
+	}
+	if out.Interval < 0 {
+		return app.AnchoringConfig{}, fmt.Errorf("anchoring interval must be greater than zero")
+	}
+	switch c.Target {
+	case "", string(app.AnchorTargetChain):
+		out.Target = app.AnchorTargetChain
+	case string(app.AnchorTargetIndexer):
+		out.Target = app.AnchorTargetIndexer
+	default:
+		return app.AnchoringConfig{}, fmt.Errorf("unknown anchoring target: %s", c.Target)
+	}
+	if c.Address != "" {
+		if !common.IsHexAddress(c.Address) {
+			return app.AnchoringConfig{}, fmt.Errorf("invalid anchoring address: %s", c.Address)
+		}
+		out.Address = common.HexToAddress(c.Address)
+	}
+	return out, nil
+}
+
+const (
+	// DefaultAlertDeadlineEpochs is how many epochs before a proving deadline
+	// an alert is raised if no proof of the period has been seen.
+	DefaultAlertDeadlineEpochs = 10
+	// DefaultAlertEmailPort is the SMTP submission port.
+	DefaultAlertEmailPort = 587
+)
+
+// AlertingConfig configures the alerts raised when the node is at risk of
+// missing proofs or payments, and where they are sent.
+type AlertingConfig struct {
+	// DeadlineEpochs alerts when a proving deadline is at most this many
+	// epochs away and no proof of the period has been seen. 0 disables it.
+	DeadlineEpochs int64 `mapstructure:"deadline_epochs" toml:"deadline_epochs,omitempty"`
+	// FaultRecords alerts when a FaultRecord event is emitted for a data set.
+	FaultRecords bool `mapstructure:"fault_records" toml:"fault_records,omitempty"`
+	// SettlementFailures alerts when a rail settlement transaction fails.
+	SettlementFailures bool                 `mapstructure:"settlement_failures" toml:"settlement_failures,omitempty"`
+	Webhooks           []AlertWebhookConfig `mapstructure:"webhooks" toml:"webhooks,omitempty"`
+	Email              AlertEmailConfig     `mapstructure:"email" toml:"email,omitempty"`
+}
+
+// AlertWebhookConfig is a URL alerts are posted to, signed with the secret if
+// one is given.
+type AlertWebhookConfig struct {
+	URL    string `mapstructure:"url" toml:"url"`
+	Secret string `mapstructure:"secret" toml:"secret,omitempty"`
+}
+
+// AlertEmailConfig configures the SMTP server alerts are sent through. Alerts
+// are not sent by email if no host is given.
+type AlertEmailConfig struct {
+	Host     string   `mapstructure:"host" toml:"host,omitempty"`
+	Port     uint     `mapstructure:"port" toml:"port,omitempty"`
+	Username string   `mapstructure:"username" toml:"username,omitempty"`
+	Password string   `mapstructure:"password" toml:"password,omitempty"`
+	From     string   `mapstructure:"from" toml:"from,omitempty"`
+	To       []string `mapstructure:"to" toml:"to,omitempty"`
+}
+
+func (c AlertingConfig) ToAppConfig() (app.AlertingConfig, error) {
+	if c.DeadlineEpochs < 0 {
+		return app.AlertingConfig{}, fmt.Errorf("alerting deadline_epochs must not be negative")
+	}
+	out := app.AlertingConfig{
+		DeadlineEpochs:     c.DeadlineEpochs,
+		FaultRecords:       c.FaultRecords,
+		SettlementFailures: c.SettlementFailures,
+	}
+	for _, wh := range c.Webhooks {
+		u, err := url.Parse(wh.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return app.AlertingConfig{}, fmt.Errorf("invalid alert webhook URL %q: must be an absolute http or https URL", wh.URL)
+		}
+		out.Webhooks = append(out.Webhooks, app.AlertWebhookConfig{URL: u, Secret: wh.Secret})
+	}
+	if c.Email.Host != "" {
+		if c.Email.From == "" || len(c.Email.To) == 0 {
+			return app.AlertingConfig{}, fmt.Errorf("alert email requires from and to addresses")
+		}
+		port := c.Email.Port
+		if port == 0 {
+			port = DefaultAlertEmailPort
+		}
+		out.Email = &app.AlertEmailConfig{
+			Host:     c.Email.Host,
+			Port:     port,
+			Username: c.Email.Username,
+			Password: c.Email.Password,
+			From:     c.Email.From,
+			To:       c.Email.To,
+		}
 	}
+	return out, nil
 }
 
 // DefaultAggregationConfig returns an AggregationConfig with sensible defaults.
@@ -308,6 +730,7 @@ func DefaultAggregationConfig() AggregationConfig {
 				Retries:    50,
 				RetryDelay: 10 * time.Second,
 			},
+			MemoryBudget: DefaultCommPMemoryBudget,
 		},
 		Aggregator: AggregatorConfig{
 			JobQueue: JobQueueConfig{
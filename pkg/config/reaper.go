@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// ReaperConfig configures the removal of expired allocations and the
+// reconciliation of accepted blobs with the blobs held by the node.
+type ReaperConfig struct {
+	// Interval is how often expired allocations for blobs that were never
+	// received are removed. 0 disables reaping.
+	Interval time.Duration `mapstructure:"interval" toml:"interval,omitempty"`
+	// ReconcileInterval is how often accepted blobs missing from the node are
+	// looked for. 0 disables reconciliation.
+	ReconcileInterval time.Duration `mapstructure:"reconcile_interval" toml:"reconcile_interval,omitempty"`
+}
+
+func (c ReaperConfig) ToAppConfig() (app.ReaperConfig, error) {
+	if c.Interval < 0 {
+		return app.ReaperConfig{}, fmt.Errorf("reaper interval must not be negative")
+	}
+	if c.ReconcileInterval < 0 {
+		return app.ReaperConfig{}, fmt.Errorf("reaper reconcile_interval must not be negative")
+	}
+	return app.ReaperConfig{
+		Interval:          c.Interval,
+		ReconcileInterval: c.ReconcileInterval,
+	}, nil
+}
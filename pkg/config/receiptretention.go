@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// ReceiptRetentionConfig configures the pruning of old receipts from the
+// receipt store.
+type ReceiptRetentionConfig struct {
+	// MaxAge is how long receipts are kept after they are stored.
+	MaxAge time.Duration `mapstructure:"max_age" toml:"max_age,omitempty"`
+	// MaxCount is the number of most recent receipts kept.
+	MaxCount uint64 `mapstructure:"max_count" toml:"max_count,omitempty"`
+	// Interval is the time between compaction passes.
+	Interval time.Duration `mapstructure:"interval" toml:"interval,omitempty"`
+	// BatchSize is the number of receipts pruned, and archived together, at a
+	// time.
+	BatchSize int `mapstructure:"batch_size" validate:"min=0" toml:"batch_size,omitempty"`
+	// Archive configures the export of receipts before they are pruned.
+	Archive ReceiptArchiveConfig `mapstructure:"archive" toml:"archive,omitempty"`
+}
+
+// ReceiptArchiveConfig configures where receipts are exported to before they
+// are pruned, a directory or an S3-compatible bucket.
+type ReceiptArchiveConfig struct {
+	Dir string                  `mapstructure:"dir" toml:"dir,omitempty"`
+	S3  *ReceiptArchiveS3Config `mapstructure:"s3" toml:"s3,omitempty"`
+}
+
+// ReceiptArchiveS3Config configures an S3-compatible bucket receipts are
+// archived to.
+type ReceiptArchiveS3Config struct {
+	Endpoint    string      `mapstructure:"endpoint" toml:"endpoint"`
+	Bucket      string      `mapstructure:"bucket" toml:"bucket"`
+	Credentials Credentials `mapstructure:"credentials" toml:"credentials,omitempty"`
+	Insecure    bool        `mapstructure:"insecure" toml:"insecure,omitempty"`
+}
+
+func (r ReceiptRetentionConfig) ToAppConfig() (app.ReceiptRetentionConfig, error) {
+	if r.MaxAge < 0 || r.Interval < 0 {
+		return app.ReceiptRetentionConfig{}, fmt.Errorf("receipt retention durations must not be negative")
+	}
+	out := app.ReceiptRetentionConfig{
+		MaxAge:    r.MaxAge,
+		MaxCount:  r.MaxCount,
+		Interval:  r.Interval,
+		BatchSize: r.BatchSize,
+		Archive:   app.ReceiptArchiveConfig{Dir: r.Archive.Dir},
+	}
+	if s3 := r.Archive.S3; s3 != nil && (s3.Endpoint != "" || s3.Bucket != "") {
+		if r.Archive.Dir != "" {
+			return app.ReceiptRetentionConfig{}, fmt.Errorf("receipt archive must be either a dir or an s3 bucket, not both")
+		}
+		if s3.Endpoint == "" || s3.Bucket == "" {
+			return app.ReceiptRetentionConfig{}, fmt.Errorf("receipt archive s3 endpoint and bucket are required")
+		}
+		out.Archive.S3 = &app.ReceiptArchiveS3Config{
+			Endpoint: s3.Endpoint,
+			Bucket:   s3.Bucket,
+			Credentials: app.Credentials{
+				AccessKeyID:     s3.Credentials.AccessKeyID,
+				SecretAccessKey: s3.Credentials.SecretAccessKey,
+			},
+			Insecure: s3.Insecure,
+		}
+	}
+	return out, nil
+}
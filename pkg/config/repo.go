@@ -25,6 +25,10 @@ type S3Config struct {
 	BucketPrefix string      `mapstructure:"bucket_prefix" validate:"required" toml:"bucket_prefix"`
 	Credentials  Credentials `mapstructure:"credentials" toml:"credentials,omitempty"`
 	Insecure     bool        `mapstructure:"insecure" toml:"insecure,omitempty"`
+	// PartSize is the size in bytes of each part of a multipart upload. Blobs
+	// larger than this are streamed to the bucket in parts. Zero lets the
+	// client pick a part size from the blob size.
+	PartSize uint64 `mapstructure:"part_size" validate:"omitempty,min=5242880" toml:"part_size,omitempty"`
 }
 
 // IsConfigured returns true if any S3 configuration is provided.
@@ -34,7 +38,7 @@ func (c *S3Config) IsConfigured() bool {
 	}
 	return c.Endpoint != "" || c.BucketPrefix != "" ||
 		c.Credentials.AccessKeyID != "" || c.Credentials.SecretAccessKey != "" ||
-		c.Insecure
+		c.Insecure || c.PartSize != 0
 }
 
 // Validate checks that S3 configuration is complete.
@@ -123,11 +127,32 @@ func (c PostgresConfig) ToAppConfig() (app.PostgresConfig, error) {
 	}, nil
 }
 
+// DatastoreFile is the name of the SQLite database in the data directory
+// holding the local stores with the sqlite datastore backend.
+const DatastoreFile = "datastore.db"
+
 type RepoConfig struct {
 	DataDir  string         `mapstructure:"data_dir" validate:"required" flag:"data-dir" toml:"data_dir"`
 	TempDir  string         `mapstructure:"temp_dir" validate:"required" flag:"temp-dir" toml:"temp_dir"`
 	Database DatabaseConfig `mapstructure:"database" validate:"omitempty" toml:"database,omitempty"`
 	S3       *S3Config      `mapstructure:"s3" validate:"omitempty" toml:"s3,omitempty"`
+
+	// Datastore is the backend of the local key-value stores: "leveldb"
+	// (default), a database per store, or "sqlite", a single database.
+	Datastore string `mapstructure:"datastore" validate:"omitempty,oneof=leveldb sqlite" toml:"datastore,omitempty"`
+
+	// Scrub configures the background re-hashing of stored blobs.
+	Scrub ScrubConfig `mapstructure:"scrub" toml:"scrub,omitempty"`
+
+	// ReceiptRetention configures the pruning of old receipts.
+	ReceiptRetention ReceiptRetentionConfig `mapstructure:"receipt_retention" toml:"receipt_retention,omitempty"`
+
+	// Pack configures packing small blobs into pack files.
+	Pack PackConfig `mapstructure:"pack" toml:"pack,omitempty"`
+
+	// DiskSpace configures the rejection of allocations while the disks of
+	// the data and temp directories are nearly full.
+	DiskSpace DiskSpaceConfig `mapstructure:"disk_space" toml:"disk_space,omitempty"`
 }
 
 func (r RepoConfig) Validate() error {
@@ -160,11 +185,20 @@ func (r RepoConfig) ToAppConfig() (app.StorageConfig, error) {
 		return app.StorageConfig{}, err
 	}
 
+	packCfg, err := r.Pack.ToAppConfig(filepath.Join(r.DataDir, "pdp", "packs"))
+	if err != nil {
+		return app.StorageConfig{}, fmt.Errorf("pack config: %w", err)
+	}
+
 	// Build storage config - database paths are derived by providers, not set here
 	out := app.StorageConfig{
 		DataDir:  r.DataDir,
 		TempDir:  r.TempDir,
 		Database: dbCfg,
+		Datastore: app.DatastoreConfig{
+			Backend: app.DatastoreBackendLevelDB,
+			Path:    filepath.Join(r.DataDir, DatastoreFile),
+		},
 		Aggregator: app.AggregatorStorageConfig{
 			Dir: filepath.Join(r.DataDir, "aggregator", "datastore"),
 		},
@@ -195,15 +229,53 @@ func (r RepoConfig) ToAppConfig() (app.StorageConfig, error) {
 			Dir: filepath.Join(r.DataDir, "wallet"),
 		},
 		StashStore: app.StashStoreConfig{
-			Dir: filepath.Join(r.DataDir, "pdp"),
+			Dir: filepath.Join(r.DataDir, "pdp", "stash"),
 		},
 		SchedulerStorage: app.SchedulerConfig{},
 		PDPStore: app.PDPStoreConfig{
-			Dir: filepath.Join(r.DataDir, "pdp", "datastore"),
+			Dir:  filepath.Join(r.DataDir, "pdp", "datastore"),
+			Pack: packCfg,
 		},
 		Consolidation: app.ConsolidationStorageConfig{
 			Dir: filepath.Join(r.DataDir, "consolidation"),
 		},
+		Quota: app.QuotaStorageConfig{
+			Dir: filepath.Join(r.DataDir, "quota"),
+		},
+		PieceLog: app.PieceLogStorageConfig{
+			Dir: filepath.Join(r.DataDir, "piecelog"),
+		},
+		Scrubber: app.ScrubberStorageConfig{
+			Dir: filepath.Join(r.DataDir, "scrubber"),
+		},
+		StorageClass: app.StorageClassStorageConfig{
+			Dir: filepath.Join(r.DataDir, "storageclass"),
+		},
+		Collector: app.CollectorStorageConfig{
+			Dir: filepath.Join(r.DataDir, "collector"),
+		},
+		ReplicaPolicy: app.ReplicaPolicyStorageConfig{
+			Dir: filepath.Join(r.DataDir, "replicapolicy"),
+		},
+		ContentIndex: app.ContentIndexStorageConfig{
+			Dir: filepath.Join(r.DataDir, "contentindex"),
+		},
+		Blocklist: app.BlocklistStorageConfig{
+			Dir: filepath.Join(r.DataDir, "blocklist"),
+		},
+		Backfill: app.BackfillStorageConfig{
+			Dir: filepath.Join(r.DataDir, "backfill"),
+		},
+		Reaper: app.ReaperStorageConfig{
+			Dir: filepath.Join(r.DataDir, "reaper"),
+		},
+		IPNIBatch: app.IPNIBatchStorageConfig{
+			Dir: filepath.Join(r.DataDir, "ipnibatch"),
+		},
+	}
+
+	if r.Datastore == string(app.DatastoreBackendSQLite) {
+		out.Datastore.Backend = app.DatastoreBackendSQLite
 	}
 
 	// Copy S3 config if configured (already validated above)
@@ -216,6 +288,7 @@ func (r RepoConfig) ToAppConfig() (app.StorageConfig, error) {
 				SecretAccessKey: r.S3.Credentials.SecretAccessKey,
 			},
 			Insecure: r.S3.Insecure,
+			PartSize: r.S3.PartSize,
 		}
 	}
 
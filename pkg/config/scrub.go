@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// ScrubConfig configures the background integrity scrubber.
+type ScrubConfig struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled,omitempty"`
+	// Interval is the time between the start of consecutive passes over the
+	// blob store.
+	Interval time.Duration `mapstructure:"interval" toml:"interval,omitempty"`
+	// BytesPerSecond limits the rate blobs are read at.
+	BytesPerSecond uint64 `mapstructure:"bytes_per_second" toml:"bytes_per_second,omitempty"`
+	// Repair re-fetches corrupt blobs from Peers.
+	Repair bool `mapstructure:"repair" toml:"repair,omitempty"`
+	// Peers are the base URLs of nodes that may hold copies of the blobs of
+	// this node.
+	Peers []string `mapstructure:"peers" validate:"dive,url" toml:"peers,omitempty"`
+}
+
+func (s ScrubConfig) ToAppConfig() (app.ScrubberConfig, error) {
+	peers := make([]url.URL, 0, len(s.Peers))
+	for _, p := range s.Peers {
+		u, err := url.Parse(p)
+		if err != nil {
+			return app.ScrubberConfig{}, fmt.Errorf("parsing scrub peer URL %s: %w", p, err)
+		}
+		peers = append(peers, *u)
+	}
+	return app.ScrubberConfig{
+		Enabled:        s.Enabled,
+		Interval:       s.Interval,
+		BytesPerSecond: s.BytesPerSecond,
+		Repair:         s.Repair,
+		Peers:          peers,
+	}, nil
+}
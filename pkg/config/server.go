@@ -2,7 +2,13 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/multiformats/go-multiaddr"
+	"github.com/storacha/go-ucanto/did"
 
 	"github.com/storacha/piri/pkg/config/app"
 )
@@ -11,6 +17,425 @@ type ServerConfig struct {
 	Port      uint   `mapstructure:"port" validate:"required,min=1,max=65535" flag:"port" toml:"port"`
 	Host      string `mapstructure:"host" validate:"required" flag:"host" toml:"host"`
 	PublicURL string `mapstructure:"public_url" validate:"omitempty,url" flag:"public-url" toml:"public_url"`
+	// AdditionalPublicURLs are other URLs the node is reachable at, e.g. over
+	// IPv6, an onion service or a LAN address.
+	AdditionalPublicURLs []string `mapstructure:"additional_public_urls" validate:"omitempty,dive,url" toml:"additional_public_urls,omitempty"`
+	// URLCheck configures health checks of the public URLs.
+	URLCheck URLCheckConfig `mapstructure:"url_check" toml:"url_check,omitempty"`
+	// Health configures the readiness check and draining on shutdown.
+	Health HealthConfig `mapstructure:"health" toml:"health,omitempty"`
+	// Diagnostics configures a separate listener for pprof and runtime
+	// diagnostics, disabled by default.
+	Diagnostics DiagnosticsConfig `mapstructure:"diagnostics" toml:"diagnostics,omitempty"`
+	// CDN redirects blob retrievals to a CDN, disabled by default.
+	CDN CDNConfig `mapstructure:"cdn" toml:"cdn,omitempty"`
+	// RateLimit throttles retrievals, disabled by default.
+	RateLimit RateLimitConfig `mapstructure:"rate_limit" toml:"rate_limit,omitempty"`
+	// PrivateRetrieval restricts retrieval of the blobs of private spaces to
+	// authorized clients, no space is private by default.
+	PrivateRetrieval PrivateRetrievalConfig `mapstructure:"private_retrieval" toml:"private_retrieval,omitempty"`
+	// Libp2p serves blobs over Bitswap from a libp2p host, disabled by
+	// default.
+	Libp2p Libp2pConfig `mapstructure:"libp2p" toml:"libp2p,omitempty"`
+	// GRPC serves the PDP API to internal services over gRPC, disabled by
+	// default.
+	GRPC GRPCConfig `mapstructure:"grpc" toml:"grpc,omitempty"`
+	// ACME serves the public endpoint over TLS with certificates from an ACME
+	// CA, disabled by default.
+	ACME ACMEConfig `mapstructure:"acme" toml:"acme,omitempty"`
+	// HTTP3 serves the public endpoint over HTTP/3 as well, disabled by
+	// default. It requires ACME.
+	HTTP3 HTTP3Config `mapstructure:"http3" toml:"http3,omitempty"`
+}
+
+// Defaults for the health checks of the public URLs.
+const (
+	DefaultURLCheckInterval = time.Minute
+	DefaultURLCheckTimeout  = 10 * time.Second
+)
+
+// URLCheckConfig configures how often the public URLs of the node are checked
+// for reachability.
+type URLCheckConfig struct {
+	Interval time.Duration `mapstructure:"interval" toml:"interval,omitempty"`
+	Timeout  time.Duration `mapstructure:"timeout" toml:"timeout,omitempty"`
+}
+
+func (u URLCheckConfig) ToAppConfig() app.URLCheckConfig {
+	out := app.URLCheckConfig{
+		Interval: u.Interval,
+		Timeout:  u.Timeout,
+	}
+	if out.Interval <= 0 {
+		out.Interval = DefaultURLCheckInterval
+	}
+	if out.Timeout <= 0 {
+		out.Timeout = DefaultURLCheckTimeout
+	}
+	return out
+}
+
+// HealthConfig configures the probes of the dependencies of the node failing
+// its readiness check, and how long the readiness check fails before the
+// node shuts down.
+type HealthConfig struct {
+	ProbeInterval time.Duration `mapstructure:"probe_interval" toml:"probe_interval,omitempty"`
+	ProbeTimeout  time.Duration `mapstructure:"probe_timeout" toml:"probe_timeout,omitempty"`
+	DrainDelay    time.Duration `mapstructure:"drain_delay" toml:"drain_delay,omitempty"`
+}
+
+func (h HealthConfig) ToAppConfig() (app.HealthConfig, error) {
+	if h.ProbeInterval < 0 || h.ProbeTimeout < 0 || h.DrainDelay < 0 {
+		return app.HealthConfig{}, fmt.Errorf("health durations must not be negative")
+	}
+	return app.HealthConfig{
+		ProbeInterval: h.ProbeInterval,
+		ProbeTimeout:  h.ProbeTimeout,
+		DrainDelay:    h.DrainDelay,
+	}, nil
+}
+
+// DefaultLibp2pListenAddrs are the addresses the libp2p host listens on if
+// none are configured.
+var DefaultLibp2pListenAddrs = []string{
+	"/ip4/0.0.0.0/tcp/4001",
+	"/ip4/0.0.0.0/udp/4001/quic-v1",
+}
+
+// Libp2pConfig configures a libp2p host serving blobs over Bitswap.
+type Libp2pConfig struct {
+	Enabled     bool     `mapstructure:"enabled" toml:"enabled,omitempty"`
+	ListenAddrs []string `mapstructure:"listen_addrs" toml:"listen_addrs,omitempty"`
+	// AnnounceAddrs are the public addresses of the host advertised to
+	// indexers, the addresses it listens on if empty.
+	AnnounceAddrs []string `mapstructure:"announce_addrs" toml:"announce_addrs,omitempty"`
+}
+
+func (l Libp2pConfig) ToAppConfig() (app.Libp2pConfig, error) {
+	if !l.Enabled {
+		return app.Libp2pConfig{}, nil
+	}
+	listen := l.ListenAddrs
+	if len(listen) == 0 {
+		listen = DefaultLibp2pListenAddrs
+	}
+	listenAddrs, err := parseMultiaddrs(listen)
+	if err != nil {
+		return app.Libp2pConfig{}, fmt.Errorf("parsing libp2p listen addresses: %w", err)
+	}
+	announceAddrs, err := parseMultiaddrs(l.AnnounceAddrs)
+	if err != nil {
+		return app.Libp2pConfig{}, fmt.Errorf("parsing libp2p announce addresses: %w", err)
+	}
+	return app.Libp2pConfig{
+		Enabled:       true,
+		ListenAddrs:   listenAddrs,
+		AnnounceAddrs: announceAddrs,
+	}, nil
+}
+
+func parseMultiaddrs(addrs []string) ([]multiaddr.Multiaddr, error) {
+	out := make([]multiaddr.Multiaddr, 0, len(addrs))
+	for _, a := range addrs {
+		ma, err := multiaddr.NewMultiaddr(a)
+		if err != nil {
+			return nil, fmt.Errorf("parsing multiaddr %s: %w", a, err)
+		}
+		out = append(out, ma)
+	}
+	return out, nil
+}
+
+// RateLimitConfig configures per client IP and per space rate limits on
+// retrievals. Rates are in requests per second, 0 for no limit.
+type RateLimitConfig struct {
+	IPRate            float64 `mapstructure:"ip_rate" validate:"min=0" toml:"ip_rate,omitempty"`
+	IPBurst           int     `mapstructure:"ip_burst" validate:"min=0" toml:"ip_burst,omitempty"`
+	SpaceRate         float64 `mapstructure:"space_rate" validate:"min=0" toml:"space_rate,omitempty"`
+	SpaceBurst        int     `mapstructure:"space_burst" validate:"min=0" toml:"space_burst,omitempty"`
+	TrustProxyHeaders bool    `mapstructure:"trust_proxy_headers" toml:"trust_proxy_headers,omitempty"`
+}
+
+func (r RateLimitConfig) ToAppConfig() app.RateLimitConfig {
+	return app.RateLimitConfig{
+		IP:                app.RateLimit{Rate: r.IPRate, Burst: r.IPBurst},
+		Space:             app.RateLimit{Rate: r.SpaceRate, Burst: r.SpaceBurst},
+		TrustProxyHeaders: r.TrustProxyHeaders,
+	}
+}
+
+// DefaultPrivateRetrievalURLTTL is how long signed retrieval URLs of the blobs
+// of private spaces are valid for by default.
+const DefaultPrivateRetrievalURLTTL = 5 * time.Minute
+
+// PrivateRetrievalConfig lists the private spaces, by DID.
+type PrivateRetrievalConfig struct {
+	Spaces []string `mapstructure:"spaces" toml:"spaces,omitempty"`
+	// URLTTL is how long signed retrieval URLs are valid for, e.g. "5m".
+	URLTTL time.Duration `mapstructure:"url_ttl" toml:"url_ttl,omitempty"`
+}
+
+func (p PrivateRetrievalConfig) ToAppConfig() (app.PrivateRetrievalConfig, error) {
+	if p.URLTTL < 0 {
+		return app.PrivateRetrievalConfig{}, fmt.Errorf("private retrieval url_ttl must not be negative")
+	}
+	out := app.PrivateRetrievalConfig{URLTTL: p.URLTTL}
+	if out.URLTTL == 0 {
+		out.URLTTL = DefaultPrivateRetrievalURLTTL
+	}
+	for _, s := range p.Spaces {
+		space, err := did.Parse(s)
+		if err != nil {
+			return app.PrivateRetrievalConfig{}, fmt.Errorf("parsing private space DID %s: %w", s, err)
+		}
+		out.Spaces = append(out.Spaces, space)
+	}
+	return out, nil
+}
+
+// CDNConfig configures redirecting blob retrievals to URLs on a CDN signed by
+// the node.
+type CDNConfig struct {
+	// Provider is "cloudfront" or "cloudflare", empty to disable.
+	Provider string `mapstructure:"provider" validate:"omitempty,oneof=cloudfront cloudflare" toml:"provider,omitempty"`
+	URL      string `mapstructure:"url" validate:"omitempty,url" toml:"url,omitempty"`
+	// TTL is how long signed URLs are valid for, e.g. "1h".
+	TTL          string `mapstructure:"ttl" toml:"ttl,omitempty"`
+	OriginSecret string `mapstructure:"origin_secret" toml:"origin_secret,omitempty"`
+
+	CloudFront CloudFrontCDNConfig `mapstructure:"cloudfront" toml:"cloudfront,omitempty"`
+	Cloudflare CloudflareCDNConfig `mapstructure:"cloudflare" toml:"cloudflare,omitempty"`
+}
+
+type CloudFrontCDNConfig struct {
+	KeyPairID      string `mapstructure:"key_pair_id" toml:"key_pair_id,omitempty"`
+	PrivateKeyFile string `mapstructure:"private_key_file" toml:"private_key_file,omitempty"`
+	DistributionID string `mapstructure:"distribution_id" toml:"distribution_id,omitempty"`
+}
+
+type CloudflareCDNConfig struct {
+	SigningSecret string `mapstructure:"signing_secret" toml:"signing_secret,omitempty"`
+	ZoneID        string `mapstructure:"zone_id" toml:"zone_id,omitempty"`
+	APIToken      string `mapstructure:"api_token" toml:"api_token,omitempty"`
+}
+
+func (c CDNConfig) ToAppConfig() (app.CDNConfig, error) {
+	if c.Provider == "" {
+		return app.CDNConfig{}, nil
+	}
+	if c.URL == "" || c.OriginSecret == "" {
+		return app.CDNConfig{}, fmt.Errorf("CDN provider %s requires url and origin_secret", c.Provider)
+	}
+	base, err := url.Parse(c.URL)
+	if err != nil {
+		return app.CDNConfig{}, fmt.Errorf("parsing CDN URL: %w", err)
+	}
+	var ttl time.Duration
+	if c.TTL != "" {
+		if ttl, err = time.ParseDuration(c.TTL); err != nil {
+			return app.CDNConfig{}, fmt.Errorf("invalid CDN ttl %q: %w", c.TTL, err)
+		}
+	}
+	switch app.CDNProvider(c.Provider) {
+	case app.CDNProviderCloudFront:
+		if c.CloudFront.KeyPairID == "" || c.CloudFront.PrivateKeyFile == "" {
+			return app.CDNConfig{}, fmt.Errorf("cloudfront CDN requires key_pair_id and private_key_file")
+		}
+	case app.CDNProviderCloudflare:
+		if c.Cloudflare.SigningSecret == "" {
+			return app.CDNConfig{}, fmt.Errorf("cloudflare CDN requires signing_secret")
+		}
+		if c.Cloudflare.ZoneID != "" && c.Cloudflare.APIToken == "" {
+			return app.CDNConfig{}, fmt.Errorf("cloudflare CDN zone_id requires api_token")
+		}
+	}
+	return app.CDNConfig{
+		Provider:     app.CDNProvider(c.Provider),
+		URL:          *base,
+		TTL:          ttl,
+		OriginSecret: c.OriginSecret,
+		CloudFront: app.CloudFrontConfig{
+			KeyPairID:      c.CloudFront.KeyPairID,
+			PrivateKeyFile: c.CloudFront.PrivateKeyFile,
+			DistributionID: c.CloudFront.DistributionID,
+		},
+		Cloudflare: app.CloudflareConfig{
+			SigningSecret: c.Cloudflare.SigningSecret,
+			ZoneID:        c.Cloudflare.ZoneID,
+			APIToken:      c.Cloudflare.APIToken,
+		},
+	}, nil
+}
+
+// Defaults for the diagnostics listener. It listens on the loopback interface
+// so profiles are not exposed publicly unless explicitly configured.
+const (
+	DefaultDiagnosticsHost = "localhost"
+	DefaultDiagnosticsPort = 6060
+)
+
+type DiagnosticsConfig struct {
+	Enabled bool   `mapstructure:"enabled" toml:"enabled,omitempty"`
+	Host    string `mapstructure:"host" toml:"host,omitempty"`
+	Port    uint   `mapstructure:"port" validate:"omitempty,max=65535" toml:"port,omitempty"`
+}
+
+func (d DiagnosticsConfig) ToAppConfig() app.DiagnosticsConfig {
+	out := app.DiagnosticsConfig{
+		Enabled: d.Enabled,
+		Host:    d.Host,
+		Port:    d.Port,
+	}
+	if out.Host == "" {
+		out.Host = DefaultDiagnosticsHost
+	}
+	if out.Port == 0 {
+		out.Port = DefaultDiagnosticsPort
+	}
+	return out
+}
+
+// Defaults for the gRPC listener. Like the diagnostics listener, it listens
+// on the loopback interface unless explicitly configured.
+const (
+	DefaultGRPCHost = "localhost"
+	DefaultGRPCPort = 50051
+)
+
+type GRPCConfig struct {
+	Enabled  bool   `mapstructure:"enabled" toml:"enabled,omitempty"`
+	Host     string `mapstructure:"host" toml:"host,omitempty"`
+	Port     uint   `mapstructure:"port" validate:"omitempty,max=65535" toml:"port,omitempty"`
+	Insecure bool   `mapstructure:"insecure" toml:"insecure,omitempty"`
+	CertFile string `mapstructure:"cert_file" toml:"cert_file,omitempty"`
+	KeyFile  string `mapstructure:"key_file" toml:"key_file,omitempty"`
+}
+
+func (g GRPCConfig) ToAppConfig() (app.GRPCConfig, error) {
+	out := app.GRPCConfig{
+		Enabled:  g.Enabled,
+		Host:     g.Host,
+		Port:     g.Port,
+		Insecure: g.Insecure,
+		CertFile: g.CertFile,
+		KeyFile:  g.KeyFile,
+	}
+	if out.Host == "" {
+		out.Host = DefaultGRPCHost
+	}
+	if out.Port == 0 {
+		out.Port = DefaultGRPCPort
+	}
+	if out.Enabled && !out.Insecure && (out.CertFile == "" || out.KeyFile == "") {
+		return app.GRPCConfig{}, fmt.Errorf("grpc listener requires cert_file and key_file unless insecure is set")
+	}
+	return out, nil
+}
+
+// Defaults for ACME certificates. HTTP-01 challenges are only ever made on
+// port 80.
+const (
+	DefaultACMEHTTPHost = "0.0.0.0"
+	DefaultACMEHTTPPort = 80
+	// DefaultACMECacheDir is the directory, relative to the data directory,
+	// certificates are stored in if no cache directory is configured.
+	DefaultACMECacheDir = "acme"
+)
+
+type ACMEConfig struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled,omitempty"`
+	// Hostnames are the names certificates are obtained for, the host of the
+	// public URL if empty.
+	Hostnames            []string `mapstructure:"hostnames" toml:"hostnames,omitempty"`
+	Email                string   `mapstructure:"email" validate:"omitempty,email" toml:"email,omitempty"`
+	DirectoryURL         string   `mapstructure:"directory_url" validate:"omitempty,url" toml:"directory_url,omitempty"`
+	CacheDir             string   `mapstructure:"cache_dir" toml:"cache_dir,omitempty"`
+	HTTPHost             string   `mapstructure:"http_host" toml:"http_host,omitempty"`
+	HTTPPort             uint     `mapstructure:"http_port" validate:"omitempty,max=65535" toml:"http_port,omitempty"`
+	DisableHTTPChallenge bool     `mapstructure:"disable_http_challenge" toml:"disable_http_challenge,omitempty"`
+}
+
+// ToAppConfig returns the ACME config of a server reachable at publicURL.
+func (a ACMEConfig) ToAppConfig(publicURL *url.URL) (app.ACMEConfig, error) {
+	if !a.Enabled {
+		return app.ACMEConfig{}, nil
+	}
+	out := app.ACMEConfig{
+		Enabled:              true,
+		Hostnames:            a.Hostnames,
+		Email:                a.Email,
+		DirectoryURL:         a.DirectoryURL,
+		CacheDir:             a.CacheDir,
+		HTTPHost:             a.HTTPHost,
+		HTTPPort:             a.HTTPPort,
+		DisableHTTPChallenge: a.DisableHTTPChallenge,
+	}
+	if len(out.Hostnames) == 0 && publicURL != nil && publicURL.Hostname() != "" {
+		out.Hostnames = []string{publicURL.Hostname()}
+	}
+	if len(out.Hostnames) == 0 {
+		return app.ACMEConfig{}, fmt.Errorf("acme requires hostnames or a public_url")
+	}
+	for _, h := range out.Hostnames {
+		if net.ParseIP(h) != nil {
+			return app.ACMEConfig{}, fmt.Errorf("acme hostname %s is an IP address, certificates can only be obtained for domain names", h)
+		}
+	}
+	if out.HTTPHost == "" {
+		out.HTTPHost = DefaultACMEHTTPHost
+	}
+	if out.HTTPPort == 0 {
+		out.HTTPPort = DefaultACMEHTTPPort
+	}
+	return out, nil
+}
+
+// DefaultHTTP3AltSvcMaxAge is how long clients remember the node serves
+// HTTP/3 if not configured.
+const DefaultHTTP3AltSvcMaxAge = 24 * time.Hour
+
+type HTTP3Config struct {
+	Enabled bool `mapstructure:"enabled" toml:"enabled,omitempty"`
+	// Port is the UDP port listened on, the port of the server if 0.
+	Port uint `mapstructure:"port" validate:"omitempty,max=65535" toml:"port,omitempty"`
+	// AdvertisedPort is the UDP port clients are told to connect to, e.g. when
+	// a firewall forwards another port to Port. Port if 0.
+	AdvertisedPort uint          `mapstructure:"advertised_port" validate:"omitempty,max=65535" toml:"advertised_port,omitempty"`
+	AltSvcMaxAge   time.Duration `mapstructure:"alt_svc_max_age" toml:"alt_svc_max_age,omitempty"`
+	// Required fails the start of the node if the UDP port cannot be listened
+	// on, rather than serving over TCP only.
+	Required bool `mapstructure:"required" toml:"required,omitempty"`
+}
+
+// ToAppConfig returns the HTTP/3 config of a server listening on port, with
+// TLS when acmeEnabled is true.
+func (h HTTP3Config) ToAppConfig(port uint, acmeEnabled bool) (app.HTTP3Config, error) {
+	if !h.Enabled {
+		return app.HTTP3Config{}, nil
+	}
+	if !acmeEnabled {
+		return app.HTTP3Config{}, fmt.Errorf("http3 requires acme, HTTP/3 is only served over TLS")
+	}
+	if h.AltSvcMaxAge < 0 {
+		return app.HTTP3Config{}, fmt.Errorf("http3 alt_svc_max_age must not be negative")
+	}
+	out := app.HTTP3Config{
+		Enabled:        true,
+		Port:           h.Port,
+		AdvertisedPort: h.AdvertisedPort,
+		AltSvcMaxAge:   h.AltSvcMaxAge,
+		Required:       h.Required,
+	}
+	if out.Port == 0 {
+		out.Port = port
+	}
+	if out.AdvertisedPort == 0 {
+		out.AdvertisedPort = out.Port
+	}
+	if out.AltSvcMaxAge == 0 {
+		out.AltSvcMaxAge = DefaultHTTP3AltSvcMaxAge
+	}
+	return out, nil
 }
 
 func (s ServerConfig) Validate() error {
@@ -26,16 +451,92 @@ func (s ServerConfig) ToAppConfig() (app.ServerConfig, error) {
 			return app.ServerConfig{}, fmt.Errorf("parsing public URL: %w", err)
 		}
 	} else {
-		log.Warnf("public URL not set, using http://%s:%d", s.Host, s.Port)
-		publicURL, err = url.Parse(fmt.Sprintf("http://%s:%d", s.Host, s.Port))
+		publicURL, err = s.defaultPublicURL()
 		if err != nil {
 			return app.ServerConfig{}, fmt.Errorf("creating default public URL: %w", err)
 		}
 	}
 
+	var additionalURLs []url.URL
+	for _, a := range s.AdditionalPublicURLs {
+		u, err := url.Parse(a)
+		if err != nil {
+			return app.ServerConfig{}, fmt.Errorf("parsing additional public URL %s: %w", a, err)
+		}
+		if u.String() == publicURL.String() {
+			continue
+		}
+		additionalURLs = append(additionalURLs, *u)
+	}
+
+	health, err := s.Health.ToAppConfig()
+	if err != nil {
+		return app.ServerConfig{}, err
+	}
+
+	cdn, err := s.CDN.ToAppConfig()
+	if err != nil {
+		return app.ServerConfig{}, err
+	}
+
+	libp2p, err := s.Libp2p.ToAppConfig()
+	if err != nil {
+		return app.ServerConfig{}, err
+	}
+
+	privateRetrieval, err := s.PrivateRetrieval.ToAppConfig()
+	if err != nil {
+		return app.ServerConfig{}, err
+	}
+
+	grpc, err := s.GRPC.ToAppConfig()
+	if err != nil {
+		return app.ServerConfig{}, err
+	}
+
+	var acmePublicURL *url.URL
+	if s.PublicURL != "" {
+		acmePublicURL = publicURL
+	}
+	acme, err := s.ACME.ToAppConfig(acmePublicURL)
+	if err != nil {
+		return app.ServerConfig{}, err
+	}
+
+	http3, err := s.HTTP3.ToAppConfig(s.Port, acme.Enabled)
+	if err != nil {
+		return app.ServerConfig{}, err
+	}
+
 	return app.ServerConfig{
-		Host:      s.Host,
-		Port:      s.Port,
-		PublicURL: *publicURL,
+		Host:                 s.Host,
+		Port:                 s.Port,
+		PublicURL:            *publicURL,
+		AdditionalPublicURLs: additionalURLs,
+		URLCheck:             s.URLCheck.ToAppConfig(),
+		Health:               health,
+		Diagnostics:          s.Diagnostics.ToAppConfig(),
+		CDN:                  cdn,
+		RateLimit:            s.RateLimit.ToAppConfig(),
+		PrivateRetrieval:     privateRetrieval,
+		Libp2p:               libp2p,
+		GRPC:                 grpc,
+		ACME:                 acme,
+		HTTP3:                http3,
 	}, nil
 }
+
+// defaultPublicURL is the URL of the server when no public URL is set: the
+// first ACME hostname over HTTPS when ACME is enabled, the listening address
+// over HTTP otherwise.
+func (s ServerConfig) defaultPublicURL() (*url.URL, error) {
+	if s.ACME.Enabled && len(s.ACME.Hostnames) > 0 {
+		host := s.ACME.Hostnames[0]
+		if s.Port != 443 {
+			host = net.JoinHostPort(host, strconv.FormatUint(uint64(s.Port), 10))
+		}
+		return url.Parse("https://" + host)
+	}
+	log.Warnf("public URL not set, using http://%s:%d", s.Host, s.Port)
+	return url.Parse(fmt.Sprintf("http://%s:%d", s.Host, s.Port))
+}
@@ -96,6 +96,7 @@ func (s *IndexingServiceConfig) ToAppConfig() (app.IndexingServiceConfig, error)
 	}
 	out := app.IndexingServiceConfig{
 		Connection: sconn,
+		URL:        surl,
 	}
 	// Parse indexing service proofs if provided
 	if s.Proof != "" {
@@ -208,11 +209,26 @@ func (s *UploadServiceConfig) ToAppConfig() (app.UploadServiceConfig, error) {
 	}
 	return app.UploadServiceConfig{
 		Connection: sconn,
+		URL:        surl,
 	}, nil
 }
 
 type PublisherServiceConfig struct {
 	AnnounceURLs []string `mapstructure:"ipni_announce_urls" validate:"required,min=1,dive,url" flag:"ipni-announce-urls" toml:"ipni_announce_urls,omitempty"`
+	// Batch configures the batching of IPNI advertisements.
+	Batch PublisherBatchConfig `mapstructure:"batch" toml:"batch,omitempty"`
+}
+
+// PublisherBatchConfig configures the batching of IPNI advertisements, so the
+// advertisement chain is extended and indexers are announced to once per batch
+// rather than once per blob.
+type PublisherBatchConfig struct {
+	// MaxSize is the maximum number of advertisements in a batch. 1 disables
+	// batching.
+	MaxSize uint `mapstructure:"max_size" toml:"max_size,omitempty"`
+	// MaxLatency is the maximum time an advertisement waits before its batch
+	// is published.
+	MaxLatency time.Duration `mapstructure:"max_latency" toml:"max_latency,omitempty"`
 }
 
 func (s *PublisherServiceConfig) Validate() error {
@@ -243,10 +259,15 @@ func (s *PublisherServiceConfig) ToAppConfig(publicURL url.URL) (app.PublisherSe
 	if err != nil {
 		return app.PublisherServiceConfig{}, fmt.Errorf("creating blob multiaddr: %w", err)
 	}
+	if s.Batch.MaxSize > 1 && s.Batch.MaxLatency <= 0 {
+		return app.PublisherServiceConfig{}, fmt.Errorf("IPNI advertisement batch max_latency must be greater than zero")
+	}
 	return app.PublisherServiceConfig{
-		PublicMaddr:   pubMaddr,
-		AnnounceMaddr: pubMaddr,
-		AnnounceURLs:  announceURLs,
-		BlobMaddr:     blobMaddr,
+		PublicMaddr:     pubMaddr,
+		AnnounceMaddr:   pubMaddr,
+		AnnounceURLs:    announceURLs,
+		BlobMaddr:       blobMaddr,
+		BatchMaxSize:    s.Batch.MaxSize,
+		BatchMaxLatency: s.Batch.MaxLatency,
 	}, nil
 }
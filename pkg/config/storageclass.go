@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// StorageClassesConfig configures the storage classes blobs are allocated in.
+// The built-in "standard" class exists even if not configured.
+type StorageClassesConfig struct {
+	// Default is the class of blobs whose allocation requests no class, in
+	// spaces not assigned one.
+	Default string               `mapstructure:"default" toml:"default,omitempty"`
+	Classes []StorageClassConfig `mapstructure:"classes" validate:"dive" toml:"classes,omitempty"`
+}
+
+// StorageClassConfig configures a storage class.
+type StorageClassConfig struct {
+	Name string `mapstructure:"name" validate:"required" toml:"name"`
+	// Replicas is the replication factor advertised for blobs of the class.
+	Replicas uint `mapstructure:"replicas" toml:"replicas,omitempty"`
+	// ProofSetClass restricts the aggregates of blobs of the class to proof
+	// sets of this class.
+	ProofSetClass string `mapstructure:"proof_set_class" toml:"proof_set_class,omitempty"`
+}
+
+var storageClassName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+func (s StorageClassesConfig) ToAppConfig() (app.StorageClassesConfig, error) {
+	out := app.StorageClassesConfig{Default: s.Default}
+	// the built-in standard class may be overridden
+	names := map[string]bool{}
+	for _, c := range s.Classes {
+		if !storageClassName.MatchString(c.Name) {
+			return app.StorageClassesConfig{}, fmt.Errorf("invalid storage class name %q: must be lower case letters, digits and dashes", c.Name)
+		}
+		if names[c.Name] {
+			return app.StorageClassesConfig{}, fmt.Errorf("storage class %q configured more than once", c.Name)
+		}
+		names[c.Name] = true
+		out.Classes = append(out.Classes, app.StorageClass{
+			Name:          c.Name,
+			Replicas:      c.Replicas,
+			ProofSetClass: c.ProofSetClass,
+		})
+	}
+	if s.Default != "" && s.Default != "standard" && !names[s.Default] {
+		return app.StorageClassesConfig{}, fmt.Errorf("default storage class %q is not configured", s.Default)
+	}
+	return out, nil
+}
@@ -17,6 +17,21 @@ type TelemetryConfig struct {
 	Metrics                  []TelemetryCollectorConfig `mapstructure:"metrics" toml:"metrics,omitempty"`
 	Traces                   []TelemetryCollectorConfig `mapstructure:"traces" toml:"traces,omitempty"`
 	DisableStorachaAnalytics bool                       `mapstructure:"disable_storacha_analytics" toml:"disable_storacha_analytics,omitempty"`
+	TraceSampleRatio         float64                    `mapstructure:"trace_sample_ratio" validate:"min=0,max=1" toml:"trace_sample_ratio,omitempty"`
+	Prometheus               bool                       `mapstructure:"prometheus" toml:"prometheus,omitempty"`
+	Federation               FederationConfig           `mapstructure:"federation" toml:"federation,omitempty"`
+}
+
+type FederationConfig struct {
+	Enabled bool                   `mapstructure:"enabled" toml:"enabled,omitempty"`
+	Node    string                 `mapstructure:"node" toml:"node,omitempty"`
+	Timeout time.Duration          `mapstructure:"timeout" toml:"timeout,omitempty"`
+	Peers   []FederationPeerConfig `mapstructure:"peers" validate:"dive" toml:"peers,omitempty"`
+}
+
+type FederationPeerConfig struct {
+	Name string `mapstructure:"name" validate:"required" toml:"name"`
+	URL  string `mapstructure:"url" validate:"required,url" toml:"url"`
 }
 
 func (t TelemetryConfig) Validate() error {
@@ -37,9 +52,22 @@ func (t TelemetryConfig) ToAppConfig() app.TelemetryConfig {
 		return out
 	}
 
+	peers := make([]app.FederationPeer, 0, len(t.Federation.Peers))
+	for _, p := range t.Federation.Peers {
+		peers = append(peers, app.FederationPeer{Name: p.Name, URL: p.URL})
+	}
+
 	return app.TelemetryConfig{
 		Metrics:                  convert(t.Metrics),
 		Traces:                   convert(t.Traces),
 		DisableStorachaAnalytics: t.DisableStorachaAnalytics,
+		TraceSampleRatio:         t.TraceSampleRatio,
+		Prometheus:               t.Prometheus,
+		Federation: app.FederationConfig{
+			Enabled: t.Federation.Enabled,
+			Node:    t.Federation.Node,
+			Timeout: t.Federation.Timeout,
+			Peers:   peers,
+		},
 	}
 }
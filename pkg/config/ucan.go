@@ -2,6 +2,7 @@ package config
 
 import (
 	"net/url"
+	"time"
 
 	"github.com/storacha/piri/pkg/config/app"
 )
@@ -25,9 +26,109 @@ func (u *UCANServerConfig) Normalize() {
 type UCANServiceConfig struct {
 	Services   ServicesConfig `mapstructure:"services" toml:"services"`
 	ProofSetID uint64         `mapstructure:"proof_set" flag:"proof-set" toml:"proof_set"`
+	// AutoCreateProofSet has the node create a proof set for its first
+	// aggregate when it manages none.
+	AutoCreateProofSet bool `mapstructure:"auto_create_proof_set" flag:"auto-create-proof-set" toml:"auto_create_proof_set,omitempty"`
+	// ReadOnly has the node refuse allocations, replicas and uploads while
+	// still serving retrievals and claims.
+	ReadOnly bool `mapstructure:"read_only" flag:"read-only" toml:"read_only,omitempty"`
 	// InsecureDIDResolution enables HTTP (instead of HTTPS) for did:web resolution.
 	// NB: this should only be used for development purposes.
 	InsecureDIDResolution bool `mapstructure:"insecure_did_resolution" toml:"insecure_did_resolution,omitempty"`
+	// DIDResolution configures caching of the DID documents of did:web
+	// principals.
+	DIDResolution DIDResolutionConfig `mapstructure:"did_resolution" toml:"did_resolution,omitempty"`
+	// Batch limits how agent messages containing many invocations are executed.
+	Batch BatchConfig `mapstructure:"batch" toml:"batch,omitempty"`
+	// Replication configures how replicas are transferred from other nodes.
+	Replication ReplicationConfig `mapstructure:"replication" toml:"replication,omitempty"`
+	// StorageClasses configures the classes blobs can be allocated in.
+	StorageClasses StorageClassesConfig `mapstructure:"storage_classes" toml:"storage_classes,omitempty"`
+	// LocationClaims configures the expiration and renewal of location claims.
+	LocationClaims LocationClaimsConfig `mapstructure:"location_claims" toml:"location_claims,omitempty"`
+	// Admission configures rejection of allocations that cost more to prove
+	// and store than they earn.
+	Admission AdmissionConfig `mapstructure:"admission" toml:"admission,omitempty"`
+	// Billing configures rate plans and the export of invoices.
+	Billing BillingConfig `mapstructure:"billing" toml:"billing,omitempty"`
+	// IPNICheck configures the check that advertisements resolve to the node
+	// on the indexers they are announced to.
+	IPNICheck IPNICheckConfig `mapstructure:"ipni_check" toml:"ipni_check,omitempty"`
+	// Fetch configures the `blob/fetch` capability, which has the node pull
+	// blobs from URLs.
+	Fetch FetchConfig `mapstructure:"fetch" toml:"fetch,omitempty"`
+	// Usage configures the accounting of the bytes spaces store over time.
+	Usage UsageConfig `mapstructure:"usage" toml:"usage,omitempty"`
+	// Blocklist configures the sync of the blocklist from a remote denylist.
+	Blocklist BlocklistConfig `mapstructure:"blocklist" toml:"blocklist,omitempty"`
+	// Reaper configures the removal of expired allocations and the
+	// reconciliation of accepted blobs with the blobs held by the node.
+	Reaper ReaperConfig `mapstructure:"reaper" toml:"reaper,omitempty"`
+}
+
+// ReplicationConfig configures source selection for replica transfers.
+type ReplicationConfig struct {
+	// PreferredSourceHosts are hosts favoured when a blob can be replicated
+	// from several locations, e.g. hosts in the same region as this node.
+	PreferredSourceHosts []string `mapstructure:"preferred_source_hosts" toml:"preferred_source_hosts,omitempty"`
+	// StallTimeout is how long a source may send no data before the transfer
+	// fails over to the next source.
+	StallTimeout time.Duration `mapstructure:"stall_timeout" toml:"stall_timeout,omitempty"`
+	// ChunkSize is the size in bytes of the ranges large blobs with several
+	// sources are transferred in.
+	ChunkSize int64 `mapstructure:"chunk_size" validate:"min=0" toml:"chunk_size,omitempty"`
+	// Concurrency is the number of ranges transferred in parallel, 1 to
+	// transfer every blob from a single source.
+	Concurrency int `mapstructure:"concurrency" validate:"min=0" toml:"concurrency,omitempty"`
+	// RetryBackoff is the delay before the first retry of a failed transfer,
+	// doubled for each further retry up to MaxRetryBackoff, if set.
+	RetryBackoff    time.Duration `mapstructure:"retry_backoff" toml:"retry_backoff,omitempty"`
+	MaxRetryBackoff time.Duration `mapstructure:"max_retry_backoff" toml:"max_retry_backoff,omitempty"`
+	// Compression transfers whole blobs zstd compressed, when replicating from
+	// and serving replicas to nodes that also enable it.
+	Compression bool `mapstructure:"compression" toml:"compression,omitempty"`
+	// Workers is the number of replicas transferred in parallel.
+	Workers uint `mapstructure:"workers" toml:"workers,omitempty"`
+	// JobsPerMinute is the number of transfers started per minute, zero for
+	// no limit.
+	JobsPerMinute uint `mapstructure:"jobs_per_minute" toml:"jobs_per_minute,omitempty"`
+}
+
+// ApplyTo sets the replication options on the replicator config.
+func (r ReplicationConfig) ApplyTo(cfg *app.ReplicatorConfig) {
+	cfg.PreferredSourceHosts = r.PreferredSourceHosts
+	if r.StallTimeout > 0 {
+		cfg.StallTimeout = r.StallTimeout
+	}
+	if r.ChunkSize > 0 {
+		cfg.ChunkSize = r.ChunkSize
+	}
+	if r.Concurrency > 0 {
+		cfg.Concurrency = r.Concurrency
+	}
+	if r.RetryBackoff > 0 {
+		cfg.RetryBackoff = r.RetryBackoff
+		cfg.MaxRetryBackoff = r.MaxRetryBackoff
+	}
+	cfg.Compression = r.Compression
+	if r.Workers > 0 {
+		cfg.MaxWorkers = r.Workers
+	}
+	cfg.JobsPerMinute = r.JobsPerMinute
+}
+
+// BatchConfig configures execution of agent messages containing multiple
+// invocations. Zero values use the defaults.
+type BatchConfig struct {
+	// MaxInvocations is the maximum number of invocations accepted in a single
+	// agent message.
+	MaxInvocations int `mapstructure:"max_invocations" validate:"min=0" toml:"max_invocations,omitempty"`
+	// MaxConcurrency is the number of invocations from a single agent message
+	// executed in parallel.
+	MaxConcurrency int `mapstructure:"max_concurrency" validate:"min=0" toml:"max_concurrency,omitempty"`
+	// MaxBodySize is the maximum size in bytes of the body of a request
+	// carrying an agent message.
+	MaxBodySize int64 `mapstructure:"max_body_size" validate:"min=0" toml:"max_body_size,omitempty"`
 }
 
 func (s UCANServiceConfig) Validate() error {
@@ -44,9 +145,66 @@ func (s UCANServiceConfig) ToAppConfig(publicURL url.URL) (app.UCANServiceConfig
 	if err != nil {
 		return app.UCANServiceConfig{}, err
 	}
+	classes, err := s.StorageClasses.ToAppConfig()
+	if err != nil {
+		return app.UCANServiceConfig{}, err
+	}
+	locationClaims, err := s.LocationClaims.ToAppConfig()
+	if err != nil {
+		return app.UCANServiceConfig{}, err
+	}
+	admission, err := s.Admission.ToAppConfig()
+	if err != nil {
+		return app.UCANServiceConfig{}, err
+	}
+	billing, err := s.Billing.ToAppConfig()
+	if err != nil {
+		return app.UCANServiceConfig{}, err
+	}
+	ipniCheck, err := s.IPNICheck.ToAppConfig(svcCfg.Publisher.AnnounceURLs)
+	if err != nil {
+		return app.UCANServiceConfig{}, err
+	}
+	fetch, err := s.Fetch.ToAppConfig()
+	if err != nil {
+		return app.UCANServiceConfig{}, err
+	}
+	usage, err := s.Usage.ToAppConfig()
+	if err != nil {
+		return app.UCANServiceConfig{}, err
+	}
+	didResolution, err := s.DIDResolution.ToAppConfig()
+	if err != nil {
+		return app.UCANServiceConfig{}, err
+	}
+	blocklist, err := s.Blocklist.ToAppConfig()
+	if err != nil {
+		return app.UCANServiceConfig{}, err
+	}
+	reaper, err := s.Reaper.ToAppConfig()
+	if err != nil {
+		return app.UCANServiceConfig{}, err
+	}
 	return app.UCANServiceConfig{
 		Services:              svcCfg,
 		ProofSetID:            s.ProofSetID,
+		AutoCreateProofSet:    s.AutoCreateProofSet,
+		ReadOnly:              s.ReadOnly,
 		InsecureDIDResolution: s.InsecureDIDResolution,
+		DIDResolution:         didResolution,
+		Batch: app.BatchConfig{
+			MaxInvocations: s.Batch.MaxInvocations,
+			MaxConcurrency: s.Batch.MaxConcurrency,
+			MaxBodySize:    s.Batch.MaxBodySize,
+		},
+		StorageClasses: classes,
+		LocationClaims: locationClaims,
+		Admission:      admission,
+		Billing:        billing,
+		IPNICheck:      ipniCheck,
+		Fetch:          fetch,
+		Usage:          usage,
+		Blocklist:      blocklist,
+		Reaper:         reaper,
 	}, nil
 }
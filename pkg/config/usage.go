@@ -0,0 +1,21 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// UsageConfig configures the accounting of the bytes spaces store over time.
+type UsageConfig struct {
+	// SnapshotInterval is how often the size of every space is snapshotted.
+	SnapshotInterval time.Duration `mapstructure:"snapshot_interval" toml:"snapshot_interval,omitempty"`
+}
+
+func (c UsageConfig) ToAppConfig() (app.UsageConfig, error) {
+	if c.SnapshotInterval < 0 {
+		return app.UsageConfig{}, fmt.Errorf("usage snapshot_interval must not be negative")
+	}
+	return app.UsageConfig{SnapshotInterval: c.SnapshotInterval}, nil
+}
@@ -0,0 +1,107 @@
+// Package contentindex maps the multihash of every blob held by the node to
+// its physical copy, whichever space it was uploaded to.
+//
+// Blobs are stored once, keyed by multihash, however many spaces allocate
+// them. Allocations and acceptances record the logical ownership of a blob by
+// each space, the index records that its bytes are on the node, so an
+// allocation of the same content in another space skips the upload.
+//
+// Deletes are copy-on-write: removing a blob from a space only deletes the
+// records of that space. The physical copy, and its entry in the index, are
+// kept while any space references the blob, and dropped by the collector
+// once none does. An allocation after that uploads the blob again.
+package contentindex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+)
+
+const blobsPrefix = "/blobs/"
+
+// Entry is the physical copy of a blob held by the node.
+type Entry struct {
+	// Size is the size of the blob in bytes.
+	Size uint64 `json:"size"`
+	// StoredAt is when the blob was first indexed.
+	StoredAt time.Time `json:"stored_at"`
+}
+
+// Index persists the blobs held by the node in a datastore. A nil Index
+// holds nothing, so allocations upload every blob when no index is
+// configured.
+type Index struct {
+	ds  datastore.Datastore
+	now func() time.Time
+}
+
+// New creates an Index persisting entries in ds.
+func New(ds datastore.Datastore) *Index {
+	return &Index{ds: ds, now: time.Now}
+}
+
+// Get returns the entry of the blob, or false if the node does not hold it.
+func (i *Index) Get(ctx context.Context, digest multihash.Multihash) (Entry, bool, error) {
+	if i == nil {
+		return Entry{}, false, nil
+	}
+	data, err := i.ds.Get(ctx, blobKey(digest))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return Entry{}, false, nil
+		}
+		return Entry{}, false, fmt.Errorf("getting content index entry: %w", err)
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, false, fmt.Errorf("decoding content index entry: %w", err)
+	}
+	return entry, true, nil
+}
+
+// Add records that the node holds the blob. The entry of a blob already
+// indexed is kept.
+func (i *Index) Add(ctx context.Context, digest multihash.Multihash, size uint64) error {
+	if i == nil {
+		return nil
+	}
+	key := blobKey(digest)
+	has, err := i.ds.Has(ctx, key)
+	if err != nil {
+		return fmt.Errorf("checking content index entry: %w", err)
+	}
+	if has {
+		return nil
+	}
+	data, err := json.Marshal(Entry{Size: size, StoredAt: i.now().UTC()})
+	if err != nil {
+		return fmt.Errorf("encoding content index entry: %w", err)
+	}
+	if err := i.ds.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("putting content index entry: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the entry of the blob, once its physical copy is deleted or
+// about to be. Deleting a blob that is not indexed is not an error.
+func (i *Index) Delete(ctx context.Context, digest multihash.Multihash) error {
+	if i == nil {
+		return nil
+	}
+	if err := i.ds.Delete(ctx, blobKey(digest)); err != nil {
+		return fmt.Errorf("deleting content index entry: %w", err)
+	}
+	return nil
+}
+
+func blobKey(digest multihash.Multihash) datastore.Key {
+	return datastore.NewKey(blobsPrefix + digestutil.Format(digest))
+}
@@ -0,0 +1,53 @@
+package contentindex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex(t *testing.T) {
+	idx := New(datastore.NewMapDatastore())
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	idx.now = func() time.Time { return now }
+	ctx := t.Context()
+	blob := testutil.RandomMultihash(t)
+
+	_, ok, err := idx.Get(ctx, blob)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, idx.Add(ctx, blob, 128))
+	entry, ok, err := idx.Get(ctx, blob)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, Entry{Size: 128, StoredAt: now}, entry)
+
+	t.Run("keeps the first entry", func(t *testing.T) {
+		idx.now = func() time.Time { return now.Add(time.Hour) }
+		require.NoError(t, idx.Add(ctx, blob, 128))
+		entry, _, err := idx.Get(ctx, blob)
+		require.NoError(t, err)
+		require.Equal(t, now, entry.StoredAt)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, idx.Delete(ctx, blob))
+		_, ok, err := idx.Get(ctx, blob)
+		require.NoError(t, err)
+		require.False(t, ok)
+		// deleting again is not an error
+		require.NoError(t, idx.Delete(ctx, blob))
+	})
+
+	t.Run("nil index holds nothing", func(t *testing.T) {
+		var idx *Index
+		require.NoError(t, idx.Add(ctx, blob, 128))
+		_, ok, err := idx.Get(ctx, blob)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
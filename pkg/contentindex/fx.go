@@ -0,0 +1,20 @@
+package contentindex
+
+import (
+	"github.com/ipfs/go-datastore"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("contentindex",
+	fx.Provide(NewFromParams),
+)
+
+type Params struct {
+	fx.In
+
+	Datastore datastore.Datastore `name:"contentindex_datastore"`
+}
+
+func NewFromParams(params Params) *Index {
+	return New(params.Datastore)
+}
@@ -0,0 +1,216 @@
+// Package delegations tracks the UCAN delegations granted to the node: those
+// imported through the admin API and kept in a delegation store, and the
+// proofs passed in the node's configuration, such as the indexing service
+// proof. Delegations close to expiry are reported so they can be renewed
+// before invocations relying on them start failing.
+package delegations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/ucan"
+
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/delegationstore"
+)
+
+var log = logging.Logger("delegations")
+
+// SourceImported is the source of delegations imported through the admin API.
+const SourceImported = "imported"
+
+// DefaultExpiryWarning is how long before a delegation expires it is reported
+// as expiring.
+const DefaultExpiryWarning = 7 * 24 * time.Hour
+
+var (
+	// ErrNotFound is returned when the node does not hold a delegation.
+	ErrNotFound = errors.New("delegation not found")
+	// ErrConfigured is returned when removing a delegation passed in the
+	// node's configuration, which must be removed from the configuration.
+	ErrConfigured = errors.New("delegation is set in the node's configuration")
+)
+
+// Configured is a delegation passed in the node's configuration.
+type Configured struct {
+	// Source is the configuration key the delegation is set at.
+	Source     string
+	Delegation delegation.Delegation
+}
+
+// Entry is a delegation held by the node.
+type Entry struct {
+	Delegation delegation.Delegation
+	// Source is SourceImported or the configuration key the delegation is set
+	// at.
+	Source string
+}
+
+// Expiration returns when the delegation expires, nil if it does not.
+func (e Entry) Expiration() *time.Time {
+	exp := e.Delegation.Expiration()
+	if exp == nil {
+		return nil
+	}
+	t := time.Unix(int64(*exp), 0).UTC()
+	return &t
+}
+
+// Manager lists, imports and removes the delegations held by the node.
+type Manager struct {
+	store      delegationstore.ListableDelegationStore
+	configured []Configured
+	clock      clock.Clock
+	warning    time.Duration
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithClock sets the clock expiry is checked against.
+func WithClock(clk clock.Clock) Option {
+	return func(m *Manager) {
+		m.clock = clk
+	}
+}
+
+// WithExpiryWarning sets how long before a delegation expires it is reported
+// as expiring, DefaultExpiryWarning by default.
+func WithExpiryWarning(d time.Duration) Option {
+	return func(m *Manager) {
+		m.warning = d
+	}
+}
+
+// New creates a Manager holding the delegations in s along with those passed
+// in the node's configuration.
+func New(s delegationstore.ListableDelegationStore, configured []Configured, opts ...Option) *Manager {
+	m := &Manager{
+		store:      s,
+		configured: configured,
+		clock:      clock.New(),
+		warning:    DefaultExpiryWarning,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Import adds delegations to the store, replacing any already imported.
+func (m *Manager) Import(ctx context.Context, dlgs ...delegation.Delegation) error {
+	for _, dlg := range dlgs {
+		if err := m.store.Put(ctx, dlg); err != nil {
+			return fmt.Errorf("storing delegation %s: %w", dlg.Link(), err)
+		}
+		log.Infow("imported delegation", "delegation", dlg.Link(), "issuer", dlg.Issuer().DID())
+	}
+	return nil
+}
+
+// List returns the delegations held by the node, configured ones first, then
+// imported ones ordered by expiry, soonest first.
+func (m *Manager) List(ctx context.Context) ([]Entry, error) {
+	entries := make([]Entry, 0, len(m.configured))
+	for _, c := range m.configured {
+		entries = append(entries, Entry{Delegation: c.Delegation, Source: c.Source})
+	}
+	var imported []Entry
+	for dlg, err := range m.store.List(ctx) {
+		if err != nil {
+			return nil, fmt.Errorf("listing delegations: %w", err)
+		}
+		imported = append(imported, Entry{Delegation: dlg, Source: SourceImported})
+	}
+	sort.SliceStable(imported, func(i, j int) bool {
+		ei, ej := imported[i].Expiration(), imported[j].Expiration()
+		if ei == nil || ej == nil {
+			return ej == nil && ei != nil
+		}
+		return ei.Before(*ej)
+	})
+	return append(entries, imported...), nil
+}
+
+// Get returns a delegation held by the node.
+func (m *Manager) Get(ctx context.Context, link ucan.Link) (Entry, error) {
+	for _, c := range m.configured {
+		if c.Delegation.Link().String() == link.String() {
+			return Entry{Delegation: c.Delegation, Source: c.Source}, nil
+		}
+	}
+	dlg, err := m.store.Get(ctx, link)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return Entry{}, fmt.Errorf("%w: %s", ErrNotFound, link)
+		}
+		return Entry{}, err
+	}
+	return Entry{Delegation: dlg, Source: SourceImported}, nil
+}
+
+// Remove deletes an imported delegation.
+func (m *Manager) Remove(ctx context.Context, link ucan.Link) error {
+	entry, err := m.Get(ctx, link)
+	if err != nil {
+		return err
+	}
+	if entry.Source != SourceImported {
+		return fmt.Errorf("%w: remove it from %s", ErrConfigured, entry.Source)
+	}
+	if err := m.store.Delete(ctx, link); err != nil {
+		return err
+	}
+	log.Infow("removed delegation", "delegation", link)
+	return nil
+}
+
+// Expired reports whether the delegation has expired.
+func (m *Manager) Expired(e Entry) bool {
+	exp := e.Expiration()
+	return exp != nil && !m.clock.Now().Before(*exp)
+}
+
+// Expiring reports whether the delegation expires within the expiry warning,
+// including delegations that have already expired.
+func (m *Manager) Expiring(e Entry) bool {
+	exp := e.Expiration()
+	return exp != nil && m.clock.Now().Add(m.warning).After(*exp)
+}
+
+// ExpiresIn returns the time left before the delegation expires, negative
+// once it has, and false if it does not expire.
+func (m *Manager) ExpiresIn(e Entry) (time.Duration, bool) {
+	exp := e.Expiration()
+	if exp == nil {
+		return 0, false
+	}
+	return exp.Sub(m.clock.Now()), true
+}
+
+// warnExpiring logs the delegations that have expired or expire soon.
+func (m *Manager) warnExpiring(ctx context.Context) {
+	entries, err := m.List(ctx)
+	if err != nil {
+		log.Warnw("listing delegations", "error", err)
+		return
+	}
+	for _, e := range entries {
+		if !m.Expiring(e) {
+			continue
+		}
+		left, _ := m.ExpiresIn(e)
+		if left <= 0 {
+			log.Warnw("delegation has expired", "delegation", e.Delegation.Link(), "source", e.Source, "expired_at", e.Expiration())
+		} else {
+			log.Warnw("delegation expires soon", "delegation", e.Delegation.Link(), "source", e.Source, "expires_at", e.Expiration(), "expires_in", left.Round(time.Minute))
+		}
+	}
+}
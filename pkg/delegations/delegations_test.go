@@ -0,0 +1,75 @@
+package delegations_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/delegations"
+	"github.com/storacha/piri/pkg/store/delegationstore"
+)
+
+func TestManager(t *testing.T) {
+	clk := clock.NewMock()
+	clk.Set(time.Now())
+	grant := func(t *testing.T, opts ...delegation.Option) delegation.Delegation {
+		t.Helper()
+		dlg, err := delegation.Delegate(testutil.Alice, testutil.Bob, []ucan.Capability[ucan.NoCaveats]{
+			ucan.NewCapability("blob/allocate", testutil.Alice.DID().String(), ucan.NoCaveats{}),
+		}, opts...)
+		require.NoError(t, err)
+		return dlg
+	}
+	expiringIn := func(d time.Duration) delegation.Option {
+		return delegation.WithExpiration(int(clk.Now().Add(d).Unix()))
+	}
+
+	later := grant(t, expiringIn(30*24*time.Hour))
+	soon := grant(t, expiringIn(24*time.Hour))
+	never := grant(t, delegation.WithNoExpiration())
+	configured := grant(t, expiringIn(time.Hour))
+
+	m := delegations.New(
+		delegationstore.NewDatastoreStore(datastore.NewMapDatastore()),
+		[]delegations.Configured{{Source: "ucan.services.indexer.proof", Delegation: configured}},
+		delegations.WithClock(clk),
+	)
+	require.NoError(t, m.Import(t.Context(), later, never, soon))
+
+	entries, err := m.List(t.Context())
+	require.NoError(t, err)
+	var links []ucan.Link
+	for _, e := range entries {
+		links = append(links, e.Delegation.Link())
+	}
+	require.Equal(t, []ucan.Link{configured.Link(), soon.Link(), later.Link(), never.Link()}, links)
+
+	t.Run("reports delegations expiring within the warning", func(t *testing.T) {
+		require.True(t, m.Expiring(entries[1]))
+		require.False(t, m.Expired(entries[1]))
+		require.False(t, m.Expiring(entries[2]))
+		require.False(t, m.Expiring(entries[3]))
+
+		clk.Add(2 * 24 * time.Hour)
+		require.True(t, m.Expired(entries[1]))
+		left, ok := m.ExpiresIn(entries[1])
+		require.True(t, ok)
+		require.Negative(t, left)
+		_, ok = m.ExpiresIn(entries[3])
+		require.False(t, ok)
+	})
+
+	t.Run("removes only imported delegations", func(t *testing.T) {
+		require.ErrorIs(t, m.Remove(t.Context(), configured.Link()), delegations.ErrConfigured)
+		require.NoError(t, m.Remove(t.Context(), soon.Link()))
+		_, err := m.Get(t.Context(), soon.Link())
+		require.ErrorIs(t, err, delegations.ErrNotFound)
+		require.ErrorIs(t, m.Remove(t.Context(), soon.Link()), delegations.ErrNotFound)
+	})
+}
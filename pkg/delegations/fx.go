@@ -0,0 +1,83 @@
+package delegations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	leveldb "github.com/ipfs/go-ds-leveldb"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/store/delegationstore"
+)
+
+// Dir is the directory, relative to the data directory, holding delegations
+// imported through the admin API.
+const Dir = "delegations"
+
+var Module = fx.Module("delegations",
+	fx.Provide(NewManagerFromConfig),
+)
+
+type Params struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Storage   app.StorageConfig
+	UCAN      app.UCANServiceConfig
+	Clock     clock.Clock
+}
+
+// NewManagerFromConfig creates a Manager backed by a store in the data
+// directory, holding the service proofs from the node's configuration. It
+// logs the delegations that are expiring on start, and reports their expiry
+// as metrics while the node runs. It returns nil if there is no data
+// directory.
+func NewManagerFromConfig(params Params) (*Manager, error) {
+	if params.Storage.DataDir == "" {
+		return nil, nil
+	}
+
+	dir := filepath.Join(params.Storage.DataDir, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating directory: %s: %w", dir, err)
+	}
+	ds, err := leveldb.NewDatastore(dir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating delegation store: %w", err)
+	}
+
+	var configured []Configured
+	addProofs := func(source string, proofs delegation.Proofs) {
+		for _, p := range proofs {
+			if dlg, ok := p.Delegation(); ok {
+				configured = append(configured, Configured{Source: source, Delegation: dlg})
+			}
+		}
+	}
+	addProofs("ucan.services.indexer.proof", params.UCAN.Services.Indexer.Proofs)
+	addProofs("ucan.services.etracker.proof", params.UCAN.Services.EgressTracker.Proofs)
+
+	m := New(delegationstore.NewDatastoreStore(ds), configured, WithClock(params.Clock))
+	reg, err := registerMetrics(m)
+	if err != nil {
+		return nil, err
+	}
+	params.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			m.warnExpiring(ctx)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if err := reg.Unregister(); err != nil {
+				return err
+			}
+			return ds.Close()
+		},
+	})
+	return m, nil
+}
@@ -0,0 +1,65 @@
+package delegations
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// registerMetrics reports the time left before each delegation held by the
+// node expires as piri_delegation_expiry, negative once it has, and the
+// number of delegations expiring within the expiry warning as
+// piri_delegations_expiring. Delegations that do not expire are not reported
+// in piri_delegation_expiry.
+func registerMetrics(m *Manager) (metric.Registration, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/delegations")
+	expiry, err := meter.Int64ObservableGauge(
+		"piri_delegation_expiry",
+		metric.WithDescription("Time left before a delegation held by the node expires, negative once it has"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create delegation expiry gauge: %w", err)
+	}
+	expiring, err := meter.Int64ObservableGauge(
+		"piri_delegations_expiring",
+		metric.WithDescription("Number of delegations held by the node that have expired or expire soon"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create delegations expiring gauge: %w", err)
+	}
+	reg, err := meter.RegisterCallback(
+		func(ctx context.Context, o metric.Observer) error {
+			entries, err := m.List(ctx)
+			if err != nil {
+				return err
+			}
+			var n int64
+			for _, e := range entries {
+				left, ok := m.ExpiresIn(e)
+				if !ok {
+					continue
+				}
+				o.ObserveInt64(expiry, int64(left.Seconds()), metric.WithAttributes(
+					attribute.String("delegation", e.Delegation.Link().String()),
+					attribute.String("source", e.Source),
+				))
+				if m.Expiring(e) {
+					n++
+				}
+			}
+			o.ObserveInt64(expiring, n)
+			return nil
+		},
+		expiry,
+		expiring,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("register delegation metrics callback: %w", err)
+	}
+	return reg, nil
+}
@@ -0,0 +1,171 @@
+// Package diagnostics serves runtime diagnostics of a running node, for
+// operators debugging resource usage: net/http/pprof profiles, goroutine
+// dumps, runtime statistics and job queue snapshots.
+//
+// The handler exposes internals of the node and must not be served on a
+// public interface.
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+	"sort"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/storacha/piri/lib/jobqueue"
+	"github.com/storacha/piri/pkg/build"
+)
+
+var log = logging.Logger("diagnostics")
+
+// RuntimeStats is a summary of the Go runtime state.
+type RuntimeStats struct {
+	Version    string    `json:"version"`
+	GoVersion  string    `json:"go_version"`
+	StartedAt  time.Time `json:"started_at"`
+	Uptime     string    `json:"uptime"`
+	GOMAXPROCS int       `json:"gomaxprocs"`
+	NumCPU     int       `json:"num_cpu"`
+	Goroutines int       `json:"goroutines"`
+	Memory     Memory    `json:"memory"`
+	GC         GC        `json:"gc"`
+}
+
+// Memory summarises runtime.MemStats, in bytes.
+type Memory struct {
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+	Sys          uint64 `json:"sys"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+}
+
+// GC summarises garbage collector activity.
+type GC struct {
+	NumGC         uint32    `json:"num_gc"`
+	LastGC        time.Time `json:"last_gc"`
+	PauseTotal    string    `json:"pause_total"`
+	NextGC        uint64    `json:"next_gc"`
+	CPUFraction   float64   `json:"cpu_fraction"`
+	MemoryLimit   int64     `json:"memory_limit"`
+	ForcedGCCount uint32    `json:"forced_gc_count"`
+}
+
+// Handler serves diagnostics:
+//
+//	/debug/pprof/       net/http/pprof profiles (heap, goroutine, profile, trace...)
+//	/debug/goroutines   full goroutine dump in text format
+//	/debug/runtime      runtime statistics as JSON
+//	/debug/jobqueues    job queue snapshots as JSON
+type Handler struct {
+	mux       *http.ServeMux
+	queues    []jobqueue.Snapshotter
+	startedAt time.Time
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler creates a diagnostics handler reporting the given job queues.
+func NewHandler(queues ...jobqueue.Snapshotter) *Handler {
+	h := &Handler{startedAt: time.Now().UTC()}
+	for _, q := range queues {
+		if q != nil {
+			h.queues = append(h.queues, q)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /debug/goroutines", h.goroutines)
+	mux.HandleFunc("GET /debug/runtime", h.runtime)
+	mux.HandleFunc("GET /debug/jobqueues", h.jobQueues)
+	h.mux = mux
+	return h
+}
+
+// Handle serves handler at pattern alongside the diagnostics.
+func (h *Handler) Handle(pattern string, handler http.Handler) {
+	h.mux.Handle(pattern, handler)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) goroutines(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	// debug=2 prints each goroutine with its full stack, as for an unrecovered
+	// panic
+	if err := rpprof.Lookup("goroutine").WriteTo(w, 2); err != nil {
+		log.Errorw("writing goroutine dump", "error", err)
+	}
+}
+
+func (h *Handler) runtime(w http.ResponseWriter, r *http.Request) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	stats := RuntimeStats{
+		Version:    build.Version,
+		GoVersion:  runtime.Version(),
+		StartedAt:  h.startedAt,
+		Uptime:     time.Since(h.startedAt).Round(time.Second).String(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		NumCPU:     runtime.NumCPU(),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: Memory{
+			HeapAlloc:    ms.HeapAlloc,
+			HeapInuse:    ms.HeapInuse,
+			HeapIdle:     ms.HeapIdle,
+			HeapReleased: ms.HeapReleased,
+			HeapObjects:  ms.HeapObjects,
+			StackInuse:   ms.StackInuse,
+			Sys:          ms.Sys,
+			TotalAlloc:   ms.TotalAlloc,
+		},
+		GC: GC{
+			NumGC:       ms.NumGC,
+			PauseTotal:  time.Duration(ms.PauseTotalNs).String(),
+			NextGC:      ms.NextGC,
+			CPUFraction: ms.GCCPUFraction,
+			// a negative limit reads the current limit without changing it
+			MemoryLimit:   debug.SetMemoryLimit(-1),
+			ForcedGCCount: ms.NumForcedGC,
+		},
+	}
+	if ms.LastGC > 0 {
+		stats.GC.LastGC = time.Unix(0, int64(ms.LastGC)).UTC()
+	}
+	writeJSON(w, stats)
+}
+
+func (h *Handler) jobQueues(w http.ResponseWriter, r *http.Request) {
+	snapshots := make([]jobqueue.Snapshot, 0, len(h.queues))
+	for _, q := range h.queues {
+		snapshots = append(snapshots, q.Snapshot())
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Queue < snapshots[j].Queue })
+	writeJSON(w, snapshots)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Errorw("writing diagnostics response", "error", err)
+	}
+}
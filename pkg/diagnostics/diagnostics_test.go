@@ -0,0 +1,60 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/lib/jobqueue"
+	"github.com/storacha/piri/lib/jobqueue/worker"
+)
+
+type staticQueue struct {
+	snapshot jobqueue.Snapshot
+}
+
+func (q staticQueue) Snapshot() jobqueue.Snapshot { return q.snapshot }
+
+func TestHandler(t *testing.T) {
+	h := NewHandler(
+		staticQueue{jobqueue.Snapshot{Snapshot: worker.Snapshot{Queue: "replication", Running: 2, Limit: 4}, Started: true}},
+		nil,
+		staticQueue{jobqueue.Snapshot{Snapshot: worker.Snapshot{Queue: "commp", Paused: true}, Started: true}},
+	)
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return rec
+	}
+
+	t.Run("job queues", func(t *testing.T) {
+		var snapshots []jobqueue.Snapshot
+		require.NoError(t, json.Unmarshal(get(t, "/debug/jobqueues").Body.Bytes(), &snapshots))
+		require.Len(t, snapshots, 2)
+		require.Equal(t, "commp", snapshots[0].Queue)
+		require.True(t, snapshots[0].Paused)
+		require.Equal(t, "replication", snapshots[1].Queue)
+		require.Equal(t, 2, snapshots[1].Running)
+	})
+
+	t.Run("runtime", func(t *testing.T) {
+		var stats RuntimeStats
+		require.NoError(t, json.Unmarshal(get(t, "/debug/runtime").Body.Bytes(), &stats))
+		require.Positive(t, stats.Goroutines)
+		require.NotZero(t, stats.Memory.HeapAlloc)
+	})
+
+	t.Run("goroutines", func(t *testing.T) {
+		require.Contains(t, get(t, "/debug/goroutines").Body.String(), "goroutine ")
+	})
+
+	t.Run("pprof", func(t *testing.T) {
+		require.Contains(t, get(t, "/debug/pprof/").Body.String(), "heap")
+		require.NotEmpty(t, get(t, "/debug/pprof/heap").Body.Bytes())
+	})
+}
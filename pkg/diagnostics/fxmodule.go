@@ -0,0 +1,91 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/lib/jobqueue"
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/telemetry"
+	"github.com/storacha/piri/pkg/telemetry/federation"
+)
+
+// QueueGroup is the fx value group of job queues reported by the diagnostics
+// listener. Modules add their queues to it as jobqueue.Snapshotter.
+const QueueGroup = `group:"diagnostics_queues"`
+
+var Module = fx.Module("diagnostics",
+	fx.Invoke(Start),
+)
+
+type Params struct {
+	fx.In
+
+	Config    app.ServerConfig
+	Telemetry app.TelemetryConfig
+	Identity  app.IdentityConfig
+	Queues    []jobqueue.Snapshotter `group:"diagnostics_queues"`
+}
+
+// Start serves diagnostics on a separate listener for the lifetime of the
+// app, if enabled in config.
+func Start(lc fx.Lifecycle, params Params) {
+	cfg := params.Config.Diagnostics
+	if !cfg.Enabled {
+		return
+	}
+
+	handler := NewHandler(params.Queues...)
+	if params.Telemetry.Prometheus {
+		handler.Handle("GET /metrics", promhttp.HandlerFor(telemetry.Registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	}
+	if fed := params.Telemetry.Federation; fed.Enabled {
+		node := fed.Node
+		if node == "" {
+			node = params.Identity.ID().String()
+		}
+		var local prometheus.Gatherer
+		if params.Telemetry.Prometheus {
+			local = telemetry.Registry
+		}
+		peers := make([]federation.Peer, 0, len(fed.Peers))
+		for _, p := range fed.Peers {
+			peers = append(peers, federation.Peer{Name: p.Name, URL: p.URL})
+		}
+		handler.Handle("GET /federate", federation.New(node, local, peers, fed.Timeout, nil))
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(int(cfg.Port)))
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fmt.Errorf("starting diagnostics listener: %w", err)
+			}
+			log.Infow("serving diagnostics", "address", ln.Addr().String())
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Errorw("diagnostics server failed", "error", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return srv.Shutdown(ctx)
+		},
+	})
+}
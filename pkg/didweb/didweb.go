@@ -0,0 +1,120 @@
+// Package didweb lets a node operate as a did:web principal. The node signs
+// with its ed25519 key and publishes the key in the DID document served at
+// /.well-known/did.json of the domain named by the DID.
+//
+// Keys are rotated by signing with a new key and keeping the previous keys in
+// the DID document. Delegations signed with a previous key continue to
+// validate, since the identity of the node is the did:web and not the key.
+package didweb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	ucansigner "github.com/storacha/go-ucanto/principal/signer"
+	"github.com/storacha/go-ucanto/principal/verifier"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+
+	"github.com/storacha/piri/pkg/principalresolver"
+)
+
+const (
+	// DocumentContext is the JSON-LD context of the DID documents served.
+	DocumentContext = "https://w3id.org/did/v1"
+	// VerificationMethodType is the type of the verification methods of the
+	// node's keys.
+	VerificationMethodType = "Ed25519VerificationKey2018"
+)
+
+// Parse parses a did:web DID.
+func Parse(s string) (did.DID, error) {
+	id, err := did.Parse(s)
+	if err != nil {
+		return did.Undef, fmt.Errorf("parsing did: %w", err)
+	}
+	if _, err := principalresolver.ExtractDomainFromDID(id); err != nil {
+		return did.Undef, err
+	}
+	return id, nil
+}
+
+// NewSigner wraps the key of the node in a signer identified by the did:web
+// id. The verifier of the signer accepts signatures by the key or any of the
+// previous keys, so the node recognizes delegations it issued before the key
+// was rotated.
+func NewSigner(key principal.Signer, id did.DID, previous ...principal.Verifier) (principal.Signer, error) {
+	wrapped, err := ucansigner.Wrap(key, id)
+	if err != nil {
+		return nil, fmt.Errorf("wrapping key in %s: %w", id, err)
+	}
+	vfr, err := verifier.Wrap(key.Verifier(), id)
+	if err != nil {
+		return nil, fmt.Errorf("wrapping verifier in %s: %w", id, err)
+	}
+	return &Signer{
+		WrappedSigner: wrapped,
+		verifier:      &rotatedVerifier{WrappedVerifier: vfr, previous: previous},
+	}, nil
+}
+
+// Signer is a did:web signer signing with the current key of the node.
+type Signer struct {
+	ucansigner.WrappedSigner
+	verifier *rotatedVerifier
+}
+
+var _ ucansigner.WrappedSigner = (*Signer)(nil)
+
+func (s *Signer) Verifier() principal.Verifier {
+	return s.verifier
+}
+
+// Previous returns the verifiers of the keys rotated out.
+func (s *Signer) Previous() []principal.Verifier {
+	return s.verifier.previous
+}
+
+// rotatedVerifier verifies signatures by the current key, falling back to
+// the previous keys.
+type rotatedVerifier struct {
+	verifier.WrappedVerifier
+	previous []principal.Verifier
+}
+
+func (v *rotatedVerifier) Verify(msg []byte, sig signature.Signature) bool {
+	if v.WrappedVerifier.Verify(msg, sig) {
+		return true
+	}
+	for _, p := range v.previous {
+		if p.Verify(msg, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewDocument returns the DID document of the did:web id. The current key is
+// the first verification method, resolvers that only consider one key use
+// it. The previous keys follow, most recent first.
+func NewDocument(id did.DID, current principal.Verifier, previous ...principal.Verifier) principalresolver.Document {
+	keys := append([]principal.Verifier{current}, previous...)
+	doc := principalresolver.Document{
+		Context: principalresolver.FlexibleContext{DocumentContext},
+		ID:      id.String(),
+	}
+	for i, k := range keys {
+		methodID := id.String() + "#key" + strconv.Itoa(i+1)
+		doc.VerificationMethod = append(doc.VerificationMethod, principalresolver.VerificationMethod{
+			ID:                 methodID,
+			Type:               VerificationMethodType,
+			Controller:         id.String(),
+			PublicKeyMultibase: strings.TrimPrefix(k.DID().String(), did.KeyPrefix),
+		})
+		doc.Authentication = append(doc.Authentication, methodID)
+		doc.AssertionMethod = append(doc.AssertionMethod, methodID)
+	}
+	return doc
+}
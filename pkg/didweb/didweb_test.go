@@ -0,0 +1,66 @@
+package didweb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/storacha/go-ucanto/did"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/didweb"
+)
+
+func TestParse(t *testing.T) {
+	id, err := didweb.Parse("did:web:piri.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "did:web:piri.example.com", id.String())
+
+	_, err = didweb.Parse("did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK")
+	require.Error(t, err)
+	_, err = didweb.Parse("did:web:")
+	require.Error(t, err)
+}
+
+func TestSigner(t *testing.T) {
+	id, err := didweb.Parse("did:web:piri.example.com")
+	require.NoError(t, err)
+	current, err := ed25519.Generate()
+	require.NoError(t, err)
+	previous, err := ed25519.Generate()
+	require.NoError(t, err)
+	other, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	s, err := didweb.NewSigner(current, id, previous.Verifier())
+	require.NoError(t, err)
+	require.Equal(t, id, s.DID())
+	require.Equal(t, id, s.Verifier().DID())
+
+	msg := []byte("hello")
+	require.True(t, s.Verifier().Verify(msg, s.Sign(msg)))
+	require.True(t, s.Verifier().Verify(msg, previous.Sign(msg)), "signatures by previous keys verify")
+	require.False(t, s.Verifier().Verify(msg, other.Sign(msg)))
+}
+
+func TestNewDocument(t *testing.T) {
+	id, err := didweb.Parse("did:web:piri.example.com")
+	require.NoError(t, err)
+	current, err := ed25519.Generate()
+	require.NoError(t, err)
+	previous, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	doc := didweb.NewDocument(id, current.Verifier(), previous.Verifier())
+	require.Equal(t, id.String(), doc.ID)
+	require.Len(t, doc.VerificationMethod, 2)
+	require.Equal(t, "did:web:piri.example.com#key1", doc.VerificationMethod[0].ID)
+	require.Equal(t, doc.VerificationMethod[0].ID, doc.AssertionMethod[0])
+
+	// resolvers take the first verification method as the key of the DID
+	key, err := did.Parse(did.KeyPrefix + doc.VerificationMethod[0].PublicKeyMultibase)
+	require.NoError(t, err)
+	require.Equal(t, current.DID(), key)
+	require.True(t, strings.HasPrefix(doc.VerificationMethod[1].PublicKeyMultibase, "z"))
+	require.Equal(t, strings.TrimPrefix(previous.DID().String(), did.KeyPrefix), doc.VerificationMethod[1].PublicKeyMultibase)
+}
@@ -0,0 +1,280 @@
+// Package diskspace monitors the disk usage of the node's data and temp
+// directories and applies backpressure before they fill up. Once the usage of
+// any disk crosses the high watermark, new allocations are rejected with a
+// retryable error until the usage of every disk drops below the low
+// watermark.
+package diskspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+var log = logging.Logger("diskspace")
+
+// DefaultInterval is the time between checks of the disk usage.
+const DefaultInterval = time.Minute
+
+// Names of the monitored directories.
+const (
+	Data = "data"
+	Temp = "temp"
+)
+
+// Volume is a directory whose disk usage is monitored.
+type Volume struct {
+	Name string
+	Path string
+}
+
+// Usage is the disk usage of a volume.
+type Usage struct {
+	Name        string  `json:"name"`
+	Path        string  `json:"path"`
+	Total       uint64  `json:"total"`
+	Used        uint64  `json:"used"`
+	Free        uint64  `json:"free"`
+	UsedPercent float64 `json:"used_percent"`
+	// Error is set if the usage could not be read at the last check.
+	Error string `json:"error,omitempty"`
+}
+
+// Status is the disk usage of the monitored volumes and whether allocations
+// are rejected.
+type Status struct {
+	// Pressure is true while allocations are rejected.
+	Pressure      bool       `json:"pressure"`
+	Since         *time.Time `json:"since,omitempty"`
+	HighWatermark float64    `json:"high_watermark"`
+	LowWatermark  float64    `json:"low_watermark"`
+	CheckedAt     time.Time  `json:"checked_at"`
+	Volumes       []Usage    `json:"volumes"`
+}
+
+// UsageFunc returns the total and free bytes of the disk holding path.
+type UsageFunc func(ctx context.Context, path string) (total, free uint64, err error)
+
+// AlertFunc is called when the usage of a volume crosses the high watermark.
+type AlertFunc func(ctx context.Context, u Usage, since time.Time)
+
+type Option func(*Monitor)
+
+// WithUsageFunc sets the function reading the disk usage of a volume, e.g.
+// to simulate a full disk in tests.
+func WithUsageFunc(f UsageFunc) Option {
+	return func(m *Monitor) {
+		m.usage = f
+	}
+}
+
+// WithAlertFunc sets the function alerting the operator of disk pressure.
+func WithAlertFunc(f AlertFunc) Option {
+	return func(m *Monitor) {
+		m.alert = f
+	}
+}
+
+// WithInterval sets the time between checks of the disk usage.
+func WithInterval(d time.Duration) Option {
+	return func(m *Monitor) {
+		m.interval = d
+	}
+}
+
+// Monitor checks the disk usage of volumes and tracks whether allocations
+// must be rejected. A nil *Monitor never rejects allocations.
+type Monitor struct {
+	volumes  []Volume
+	high     float64
+	low      float64
+	interval time.Duration
+	usage    UsageFunc
+	alert    AlertFunc
+
+	mu     sync.RWMutex
+	status Status
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a monitor of the volumes rejecting allocations above the high
+// watermark until usage drops below the low watermark, both percentages.
+func New(volumes []Volume, high, low float64, opts ...Option) (*Monitor, error) {
+	if high <= 0 || high > 100 {
+		return nil, fmt.Errorf("high watermark must be between 0 and 100: %g", high)
+	}
+	if low < 0 || low > high {
+		return nil, fmt.Errorf("low watermark must be between 0 and the high watermark: %g", low)
+	}
+	m := &Monitor{
+		volumes:  volumes,
+		high:     high,
+		low:      low,
+		interval: DefaultInterval,
+		usage:    diskUsage,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.interval <= 0 {
+		m.interval = DefaultInterval
+	}
+	m.status = Status{HighWatermark: high, LowWatermark: low, Volumes: []Usage{}}
+	return m, nil
+}
+
+func diskUsage(ctx context.Context, path string) (uint64, uint64, error) {
+	u, err := disk.UsageWithContext(ctx, path)
+	if err != nil {
+		return 0, 0, err
+	}
+	return u.Total, u.Free, nil
+}
+
+// Check reads the disk usage of every volume and updates whether allocations
+// are rejected. Volumes whose usage cannot be read keep their last pressure
+// state.
+func (m *Monitor) Check(ctx context.Context) error {
+	now := time.Now().UTC()
+	usages := make([]Usage, 0, len(m.volumes))
+	var errs []error
+	for _, v := range m.volumes {
+		u := Usage{Name: v.Name, Path: v.Path}
+		total, free, err := m.usage(ctx, v.Path)
+		if err != nil {
+			u.Error = err.Error()
+			errs = append(errs, fmt.Errorf("reading disk usage of %s directory %s: %w", v.Name, v.Path, err))
+		} else {
+			u.Total, u.Free = total, free
+			u.Used = total - min(free, total)
+			if total > 0 {
+				u.UsedPercent = float64(u.Used) / float64(total) * 100
+			}
+		}
+		usages = append(usages, u)
+	}
+
+	m.mu.Lock()
+	prev := m.status
+	next := Status{
+		Pressure:      prev.Pressure,
+		Since:         prev.Since,
+		HighWatermark: m.high,
+		LowWatermark:  m.low,
+		CheckedAt:     now,
+		Volumes:       usages,
+	}
+	var crossed []Usage
+	above, below := false, true
+	for _, u := range usages {
+		if u.Error != "" {
+			continue
+		}
+		if u.UsedPercent >= m.high {
+			above = true
+			crossed = append(crossed, u)
+		}
+		if u.UsedPercent >= m.low {
+			below = false
+		}
+	}
+	switch {
+	case !prev.Pressure && above:
+		next.Pressure = true
+		next.Since = &now
+	case prev.Pressure && below:
+		next.Pressure = false
+		next.Since = nil
+	}
+	m.status = next
+	m.mu.Unlock()
+
+	if next.Pressure && !prev.Pressure {
+		for _, u := range crossed {
+			log.Errorw("disk usage above high watermark, rejecting allocations", "volume", u.Name, "path", u.Path, "used_percent", u.UsedPercent, "high_watermark", m.high)
+			if m.alert != nil {
+				m.alert(ctx, u, now)
+			}
+		}
+	}
+	if !next.Pressure && prev.Pressure {
+		log.Infow("disk usage below low watermark, accepting allocations", "low_watermark", m.low)
+	}
+	return errors.Join(errs...)
+}
+
+// Admit returns an [InsufficientDiskSpaceError] while allocations are
+// rejected.
+func (m *Monitor) Admit() error {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.status.Pressure {
+		return nil
+	}
+	var worst Usage
+	for _, u := range m.status.Volumes {
+		if u.UsedPercent > worst.UsedPercent {
+			worst = u
+		}
+	}
+	return NewInsufficientDiskSpaceError(worst.Name, worst.UsedPercent, m.high)
+}
+
+// Status returns the disk usage at the last check.
+func (m *Monitor) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s := m.status
+	s.Volumes = append([]Usage{}, m.status.Volumes...)
+	return s
+}
+
+// Start checks the disk usage right away, then every interval in the
+// background.
+func (m *Monitor) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	if err := m.Check(ctx); err != nil {
+		log.Errorw("checking disk usage", "error", err)
+	}
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := m.Check(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Errorw("checking disk usage", "error", err)
+			}
+		}
+	}()
+}
+
+// Stop stops checking the disk usage.
+func (m *Monitor) Stop(ctx context.Context) error {
+	if m.cancel == nil {
+		return nil
+	}
+	m.cancel()
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
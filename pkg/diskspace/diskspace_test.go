@@ -0,0 +1,86 @@
+package diskspace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDisks reports the free bytes of each path out of 100 total bytes.
+type fakeDisks map[string]uint64
+
+func (d fakeDisks) usage(_ context.Context, path string) (uint64, uint64, error) {
+	free, ok := d[path]
+	if !ok {
+		return 0, 0, errors.New("no such disk")
+	}
+	return 100, free, nil
+}
+
+func TestMonitor(t *testing.T) {
+	ctx := context.Background()
+	disks := fakeDisks{"/data": 50, "/tmp": 50}
+	var alerts []Usage
+	m, err := New(
+		[]Volume{{Name: Data, Path: "/data"}, {Name: Temp, Path: "/tmp"}},
+		90, 85,
+		WithUsageFunc(disks.usage),
+		WithAlertFunc(func(_ context.Context, u Usage, _ time.Time) { alerts = append(alerts, u) }),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Check(ctx))
+	require.NoError(t, m.Admit())
+	require.False(t, m.Status().Pressure)
+
+	t.Run("rejects allocations above the high watermark", func(t *testing.T) {
+		disks["/data"] = 8
+		require.NoError(t, m.Check(ctx))
+
+		var dsErr *InsufficientDiskSpaceError
+		require.ErrorAs(t, m.Admit(), &dsErr)
+		require.Equal(t, InsufficientDiskSpaceErrorName, dsErr.Name())
+		require.Equal(t, Data, dsErr.Volume)
+		require.InDelta(t, 92, dsErr.UsedPercent, 0.01)
+
+		s := m.Status()
+		require.True(t, s.Pressure)
+		require.NotNil(t, s.Since)
+		require.Len(t, alerts, 1)
+		require.Equal(t, Data, alerts[0].Name)
+	})
+
+	t.Run("keeps rejecting until below the low watermark", func(t *testing.T) {
+		disks["/data"] = 12
+		require.NoError(t, m.Check(ctx))
+		require.Error(t, m.Admit())
+
+		disks["/data"] = 20
+		require.NoError(t, m.Check(ctx))
+		require.NoError(t, m.Admit())
+		require.Nil(t, m.Status().Since)
+		require.Len(t, alerts, 1)
+	})
+
+	t.Run("keeps the pressure state of unreadable disks", func(t *testing.T) {
+		delete(disks, "/tmp")
+		require.Error(t, m.Check(ctx))
+		require.NoError(t, m.Admit())
+		require.NotEmpty(t, m.Status().Volumes[1].Error)
+	})
+}
+
+func TestNilMonitorAdmits(t *testing.T) {
+	var m *Monitor
+	require.NoError(t, m.Admit())
+}
+
+func TestWatermarks(t *testing.T) {
+	_, err := New(nil, 101, 85)
+	require.Error(t, err)
+	_, err = New(nil, 80, 85)
+	require.Error(t, err)
+}
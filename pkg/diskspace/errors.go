@@ -0,0 +1,39 @@
+package diskspace
+
+import (
+	"fmt"
+
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/result/failure/datamodel"
+)
+
+// InsufficientDiskSpaceErrorName is the name of the failure returned in
+// receipts when an allocation is rejected because the node's disks are
+// nearly full. The allocation may be retried later, or on another node.
+const InsufficientDiskSpaceErrorName = "InsufficientDiskSpace"
+
+// InsufficientDiskSpaceError is returned for allocations rejected while the
+// disk usage of the node is above its high watermark.
+type InsufficientDiskSpaceError struct {
+	Volume        string
+	UsedPercent   float64
+	HighWatermark float64
+}
+
+func (de InsufficientDiskSpaceError) Name() string {
+	return InsufficientDiskSpaceErrorName
+}
+
+func (de InsufficientDiskSpaceError) Error() string {
+	return fmt.Sprintf("insufficient disk space: %s disk is %.1f%% full, above the %g%% watermark, retry later", de.Volume, de.UsedPercent, de.HighWatermark)
+}
+
+func (de InsufficientDiskSpaceError) ToIPLD() (ipld.Node, error) {
+	name := de.Name()
+	model := datamodel.FailureModel{Name: &name, Message: de.Error()}
+	return model.ToIPLD()
+}
+
+func NewInsufficientDiskSpaceError(volume string, usedPercent, highWatermark float64) *InsufficientDiskSpaceError {
+	return &InsufficientDiskSpaceError{volume, usedPercent, highWatermark}
+}
@@ -0,0 +1,65 @@
+package diskspace
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RegisterMetrics reports the disk usage of each volume at the last check as
+// piri_disk_used_bytes and piri_disk_total_bytes, and whether allocations are
+// rejected as piri_disk_pressure.
+func RegisterMetrics(m *Monitor) (metric.Registration, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/diskspace")
+	used, err := meter.Int64ObservableGauge(
+		"piri_disk_used_bytes",
+		metric.WithDescription("Bytes in use on the disk of a node directory"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create disk used gauge: %w", err)
+	}
+	total, err := meter.Int64ObservableGauge(
+		"piri_disk_total_bytes",
+		metric.WithDescription("Size of the disk of a node directory"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create disk total gauge: %w", err)
+	}
+	pressure, err := meter.Int64ObservableGauge(
+		"piri_disk_pressure",
+		metric.WithDescription("Whether allocations are rejected because a disk is above its high watermark (1) or not (0)"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create disk pressure gauge: %w", err)
+	}
+	reg, err := meter.RegisterCallback(
+		func(ctx context.Context, o metric.Observer) error {
+			s := m.Status()
+			for _, u := range s.Volumes {
+				if u.Error != "" {
+					continue
+				}
+				attrs := metric.WithAttributes(attribute.String("volume", u.Name), attribute.String("path", u.Path))
+				o.ObserveInt64(used, int64(u.Used), attrs)
+				o.ObserveInt64(total, int64(u.Total), attrs)
+			}
+			var v int64
+			if s.Pressure {
+				v = 1
+			}
+			o.ObserveInt64(pressure, v)
+			return nil
+		},
+		used, total, pressure,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("register disk space metrics callback: %w", err)
+	}
+	return reg, nil
+}
@@ -1,17 +1,31 @@
 package app
 
 import (
+	"github.com/raulk/clock"
 	"go.uber.org/fx"
 
 	"github.com/storacha/piri/pkg/admin"
 	"github.com/storacha/piri/pkg/config/app"
 	"github.com/storacha/piri/pkg/config/dynamic"
+	"github.com/storacha/piri/pkg/config/feature"
+	"github.com/storacha/piri/pkg/contentindex"
+	"github.com/storacha/piri/pkg/delegations"
+	"github.com/storacha/piri/pkg/diagnostics"
 	"github.com/storacha/piri/pkg/fx/database"
+	"github.com/storacha/piri/pkg/fx/diskspace"
 	"github.com/storacha/piri/pkg/fx/echo"
 	"github.com/storacha/piri/pkg/fx/identity"
+	"github.com/storacha/piri/pkg/fx/openapi"
 	"github.com/storacha/piri/pkg/fx/proofs"
+	"github.com/storacha/piri/pkg/fx/readonly"
 	"github.com/storacha/piri/pkg/fx/store"
 	"github.com/storacha/piri/pkg/health"
+	"github.com/storacha/piri/pkg/httpclient"
+	"github.com/storacha/piri/pkg/piecelog"
+	"github.com/storacha/piri/pkg/storageclass"
+	"github.com/storacha/piri/pkg/subsystem"
+	"github.com/storacha/piri/pkg/telemetry/errorlog"
+	"github.com/storacha/piri/pkg/telemetry/latency"
 )
 
 func CommonModules(cfg app.AppConfig) fx.Option {
@@ -26,18 +40,50 @@ func CommonModules(cfg app.AppConfig) fx.Option {
 		fx.Supply(cfg.UCANService),
 		fx.Supply(cfg.PDPService),
 		fx.Supply(cfg.Replicator),
+		fx.Supply(cfg.Telemetry),
 		fx.Supply(cfg.PDPService.SigningService),
 		fx.Supply(cfg.PDPService.Aggregation.Manager),
 		fx.Supply(cfg.PDPService.Gas),
+		fx.Supply(cfg.HTTPClient),
+
+		// Provides the retry and circuit breaking policy of the clients of
+		// other services.
+		fx.Provide(ProvideHTTPClientPolicy),
+
+		// Provides the probes of the stores and job queues failing the
+		// readiness check while they are unavailable.
+		fx.Provide(fx.Annotate(ProvideStorageProbes, fx.ResultTags(`group:"health_probes,flatten"`))),
+
+		// Provides the clock of time-dependent services, tests may replace it
+		// with a mock to travel in time.
+		fx.Provide(clock.New),
 
 		identity.Module, // Provides principal.Signer
 		proofs.Module,   // Provides service for requesting service proofs
 		echo.Module,     // Provides Echo server with route registration
 		database.Module, // Provides SQLite database for job queues
 		dynamic.Module,  // Provides dynamic configuration registry
+		feature.Module,  // Provides feature flags guarding new subsystems
+
+		admin.Module,   // Provides admin module with http routes.
+		health.Module,  // Provides health check endpoints.
+		openapi.Module, // Serves the OpenAPI document of the HTTP APIs.
+
+		delegations.Module, // Provides the delegations granted to the node.
+
+		subsystem.Module, // Provides registry of subsystems that can be paused.
+		readonly.Module,  // Provides the read-only mode of the node.
+		diskspace.Module, // Monitors disk usage, rejecting allocations when nearly full.
 
-		admin.Module,  // Provides admin module with http routes.
-		health.Module, // Provides health check endpoints.
+		diagnostics.Module, // Serves pprof and runtime diagnostics, if enabled.
+
+		latency.Module,  // Provides per-upload stage latency tracker.
+		piecelog.Module, // Provides the piece lifecycle log.
+		errorlog.Module, // Provides the most recent error logs.
+
+		contentindex.Module, // Provides the index of blobs held by the node.
+
+		storageclass.Module, // Provides the storage classes of allocated blobs.
 
 		// StorageModule returns the appropriate storage module based on configuration.
 		// If S3 is configured, returns S3Module + KeyStoreModule (KeyStore always on disk).
@@ -48,3 +94,15 @@ func CommonModules(cfg app.AppConfig) fx.Option {
 	return fx.Module("common", modules...)
 
 }
+
+// ProvideHTTPClientPolicy provides the policy of the HTTP clients the node
+// makes requests to other services with.
+func ProvideHTTPClientPolicy(cfg app.HTTPClientConfig) httpclient.Policy {
+	return httpclient.Policy{
+		MaxRetries:       cfg.MaxRetries,
+		MinBackoff:       cfg.MinBackoff,
+		MaxBackoff:       cfg.MaxBackoff,
+		BreakerThreshold: cfg.BreakerThreshold,
+		BreakerCooldown:  cfg.BreakerCooldown,
+	}
+}
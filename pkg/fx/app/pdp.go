@@ -10,17 +10,25 @@ import (
 	"github.com/filecoin-project/lotus/api/client"
 	"github.com/storacha/piri/pkg/admin/httpapi/handlers"
 	"github.com/storacha/piri/pkg/pdp/aggregation"
+	aggtypes "github.com/storacha/piri/pkg/pdp/aggregation/types"
+	"github.com/storacha/piri/pkg/pdp/alerting"
+	"github.com/storacha/piri/pkg/pdp/backfill"
+	"github.com/storacha/piri/pkg/pdp/chainevents"
 	ethsender "github.com/storacha/piri/pkg/pdp/ethereum"
 	"github.com/storacha/piri/pkg/pdp/piece"
+	"github.com/storacha/piri/pkg/pdp/proofset"
 	"github.com/storacha/piri/pkg/pdp/smartcontracts"
 	"go.uber.org/fx"
 	"gorm.io/gorm"
 
 	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/fx/curio"
 	"github.com/storacha/piri/pkg/fx/pdp"
 	"github.com/storacha/piri/pkg/fx/scheduler"
 	"github.com/storacha/piri/pkg/fx/wallet"
 	"github.com/storacha/piri/pkg/pdp/service"
+	"github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/piecelog"
 )
 
 var PDPModule = fx.Module("pdp",
@@ -38,11 +46,22 @@ var PDPModule = fx.Module("pdp",
 			fx.As(new(service.ChainClient)),
 		),
 		ProvidePaymentHandler,
+		ProvideDataSetHandler,
+		ProvideLocateHandler,
+		ProvideProofHandler,
+		// probes of the chain failing the readiness check while it is
+		// unreachable
+		fx.Annotate(ProvideChainProbes, fx.ResultTags(`group:"health_probes,flatten"`)),
 	),
 	smartcontracts.Module,
 	aggregation.Module,
+	backfill.Module,
+	chainevents.Module,
+	alerting.Module,
+	proofset.Module,
 	scheduler.Module,
 	pdp.Module,
+	curio.Module,
 	piece.Module,
 	wallet.Module,
 )
@@ -107,3 +126,64 @@ func ProvidePaymentHandler(params ProvidePaymentHandlerParams) *handlers.Payment
 		params.DB,
 	)
 }
+
+// ProvideDataSetHandlerParams contains the dependencies for the data set handler
+type ProvideDataSetHandlerParams struct {
+	fx.In
+
+	DB          *gorm.DB `name:"engine_db"`
+	Verifier    smartcontracts.Verifier
+	ServiceView smartcontracts.Service `optional:"true"`
+	EthClient   *ethclient.Client
+	PDPConfig   app.PDPServiceConfig
+	Resolver    types.PieceResolverAPI `optional:"true"`
+	Reader      types.PieceReaderAPI   `optional:"true"`
+}
+
+// ProvideDataSetHandler creates the data set handler for admin routes
+func ProvideDataSetHandler(params ProvideDataSetHandlerParams) *handlers.DataSetHandler {
+	return handlers.NewDataSetHandler(
+		params.DB,
+		params.Verifier,
+		params.ServiceView,
+		params.EthClient,
+		params.PDPConfig,
+		params.Resolver,
+		params.Reader,
+	)
+}
+
+// ProvideLocateHandlerParams contains the dependencies for the locate handler
+type ProvideLocateHandlerParams struct {
+	fx.In
+
+	DB         *gorm.DB `name:"engine_db"`
+	Verifier   smartcontracts.Verifier
+	Resolver   types.PieceResolverAPI `optional:"true"`
+	Aggregates aggtypes.Store
+	PieceLog   *piecelog.Log `optional:"true"`
+}
+
+// ProvideLocateHandler creates the locate handler for admin routes
+func ProvideLocateHandler(params ProvideLocateHandlerParams) *handlers.LocateHandler {
+	return handlers.NewLocateHandler(
+		params.DB,
+		params.Verifier,
+		params.Resolver,
+		params.Aggregates,
+		params.PieceLog,
+	)
+}
+
+// ProvideProofHandlerParams contains the dependencies for the proof handler
+type ProvideProofHandlerParams struct {
+	fx.In
+
+	DB        *gorm.DB `name:"engine_db"`
+	EthClient *ethclient.Client
+}
+
+// ProvideProofHandler creates the proof handler for admin routes
+func ProvideProofHandler(params ProvideProofHandlerParams) *handlers.ProofHandler {
+	return handlers.NewProofHandler(params.DB, params.EthClient)
+}
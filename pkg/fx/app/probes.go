@@ -0,0 +1,152 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ipfs/go-datastore"
+	"github.com/multiformats/go-multihash"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/lib/jobqueue"
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/health"
+	"github.com/storacha/piri/pkg/pdp/smartcontracts"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// probeData is written to a temporary file of the blob store directory to
+// check it is writable. Its digest is looked up in blob stores without a
+// local directory, which must not find it.
+var probeData = []byte("piri readiness probe")
+
+// StorageProbeParams are the stores and job queues probed by the readiness
+// check.
+type StorageProbeParams struct {
+	fx.In
+
+	Datastore  datastore.Datastore    `name:"contentindex_datastore" optional:"true"`
+	Blobs      blobstore.Blobstore    `optional:"true"`
+	BlobConfig app.BlobStorageConfig  `optional:"true"`
+	Queues     []jobqueue.Snapshotter `group:"diagnostics_queues"`
+}
+
+// ProvideStorageProbes probes that the datastore is open, the blob store is
+// writable and the job queues are running. Blobs are not written by the
+// probe: the directory of a local blob store is checked with a temporary
+// file, and other blob stores with a lookup.
+func ProvideStorageProbes(params StorageProbeParams) ([]health.Probe, error) {
+	var probes []health.Probe
+	if params.Datastore != nil {
+		key := datastore.NewKey("/health/probe")
+		probes = append(probes, health.NewProbe("datastore", func(ctx context.Context) error {
+			if _, err := params.Datastore.Has(ctx, key); err != nil {
+				return fmt.Errorf("reading datastore: %w", err)
+			}
+			return nil
+		}))
+	}
+	if params.Blobs != nil {
+		probe, err := blobstoreProbe(params.Blobs, params.BlobConfig.Dir)
+		if err != nil {
+			return nil, err
+		}
+		probes = append(probes, health.NewProbe("blobstore", probe))
+	}
+	if len(params.Queues) > 0 {
+		probes = append(probes, health.NewProbe("job_queues", func(context.Context) error {
+			var stopped []string
+			for _, q := range params.Queues {
+				if s := q.Snapshot(); !s.Started || s.Stopping {
+					stopped = append(stopped, s.Queue)
+				}
+			}
+			if len(stopped) > 0 {
+				return fmt.Errorf("job queues not running: %s", strings.Join(stopped, ", "))
+			}
+			return nil
+		}))
+	}
+	return probes, nil
+}
+
+// blobstoreProbe checks dir is writable if it is the directory of the blob
+// store, and otherwise that blobs can be looked up.
+func blobstoreProbe(blobs blobstore.Blobstore, dir string) (func(ctx context.Context) error, error) {
+	if info, err := os.Stat(dir); dir != "" && err == nil && info.IsDir() {
+		return func(ctx context.Context) error {
+			return probeDir(dir)
+		}, nil
+	}
+	digest, err := multihash.Sum(probeData, multihash.SHA2_256, -1)
+	if err != nil {
+		return nil, fmt.Errorf("hashing probe data: %w", err)
+	}
+	return func(ctx context.Context) error {
+		obj, err := blobs.Get(ctx, digest)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil
+			}
+			return fmt.Errorf("reading blob store: %w", err)
+		}
+		return obj.Body().Close()
+	}, nil
+}
+
+// probeDir writes, syncs and removes a temporary file in dir.
+func probeDir(dir string) error {
+	f, err := os.CreateTemp(dir, ".readiness-*")
+	if err != nil {
+		return fmt.Errorf("creating probe file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(probeData); err != nil {
+		f.Close()
+		return fmt.Errorf("writing probe file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("syncing probe file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing probe file: %w", err)
+	}
+	return nil
+}
+
+// ChainProbeParams are the chain dependencies probed by the readiness check.
+type ChainProbeParams struct {
+	fx.In
+
+	EthClient *ethclient.Client
+	Verifier  smartcontracts.Verifier
+}
+
+// ProvideChainProbes probes that the eth RPC endpoint is reachable and the
+// PDP verifier contract can be called.
+func ProvideChainProbes(params ChainProbeParams) []health.Probe {
+	return []health.Probe{
+		health.NewProbe("eth_rpc", func(ctx context.Context) error {
+			if _, err := params.EthClient.BlockNumber(ctx); err != nil {
+				return fmt.Errorf("getting block number: %w", err)
+			}
+			return nil
+		}),
+		health.NewProbe("pdp_contract", func(ctx context.Context) error {
+			size, err := params.Verifier.MaxPieceSizeLog2(ctx)
+			if err != nil {
+				return fmt.Errorf("calling PDP verifier: %w", err)
+			}
+			if size == nil || size.Sign() <= 0 {
+				return errors.New("calling PDP verifier: no maximum piece size")
+			}
+			return nil
+		}),
+	}
+}
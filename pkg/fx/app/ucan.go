@@ -4,8 +4,10 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/storacha/piri/pkg/fx/blobs"
+	"github.com/storacha/piri/pkg/fx/blocklist"
 	"github.com/storacha/piri/pkg/fx/claims"
 	"github.com/storacha/piri/pkg/fx/claimvalidation"
+	"github.com/storacha/piri/pkg/fx/fetcher"
 	"github.com/storacha/piri/pkg/fx/presigner"
 	"github.com/storacha/piri/pkg/fx/principalresolver"
 	"github.com/storacha/piri/pkg/fx/publisher"
@@ -15,18 +17,50 @@ import (
 	"github.com/storacha/piri/pkg/fx/root"
 	"github.com/storacha/piri/pkg/fx/storage"
 	storageucan "github.com/storacha/piri/pkg/fx/storage/ucan"
+	"github.com/storacha/piri/pkg/p2p"
+	"github.com/storacha/piri/pkg/privatespace"
+	"github.com/storacha/piri/pkg/ratelimit"
+	"github.com/storacha/piri/pkg/service/admission"
+	"github.com/storacha/piri/pkg/service/billing"
+	"github.com/storacha/piri/pkg/service/collector"
+	"github.com/storacha/piri/pkg/service/compactor"
 	"github.com/storacha/piri/pkg/service/egresstracker"
+	"github.com/storacha/piri/pkg/service/ipnicheck"
+	"github.com/storacha/piri/pkg/service/quota"
+	"github.com/storacha/piri/pkg/service/reachability"
+	"github.com/storacha/piri/pkg/service/reaper"
+	"github.com/storacha/piri/pkg/service/replicapolicy"
+	"github.com/storacha/piri/pkg/service/republisher"
+	"github.com/storacha/piri/pkg/service/scrubber"
+	"github.com/storacha/piri/pkg/service/usage"
 )
 
 var UCANModule = fx.Module("ucan",
-	presigner.Module,         // Provides presigner.RequestPresigner
+	presigner.Module,         // Provides presigner.RequestPresigner and presigner.RetrievalPresigner
 	root.Module,              // Provides root http handler
 	blobs.Module,             // Provides blob service and handler
+	reachability.Module,      // Provides health checks of the public URLs
 	claims.Module,            // Provides claims service and handler
 	claimvalidation.Module,   // Provides context for validating UCANs
 	publisher.Module,         // Provides publisher service and handler
+	p2p.Module,               // Provides optional libp2p host serving blobs over Bitswap
 	egresstracker.Module,     // Provides egress tracker service
+	quota.Module,             // Provides per-space storage and egress quotas
+	billing.Module,           // Provides rate plans and monthly invoices of space usage
+	usage.Module,             // Provides accounting of the bytes spaces store over time
+	admission.Module,         // Provides admission control of allocations by projected cost
+	ratelimit.Module,         // Provides per-IP and per-space retrieval rate limits
+	blocklist.Module,         // Provides blocklist of content refused by the node
+	privatespace.Module,      // Provides restriction of retrievals of private spaces
+	reaper.Module,            // Provides stale allocation reaper
+	scrubber.Module,          // Provides background integrity scrubber
+	collector.Module,         // Provides collector of unreferenced blobs
+	compactor.Module,         // Provides pruning of old receipts
+	republisher.Module,       // Provides location claim republication on public URL change
+	ipnicheck.Module,         // Provides checks that advertisements resolve on the indexers
+	replicapolicy.Module,     // Provides policy engine deciding which replicas are accepted
 	replicator.Module,        // Provides replicator service (works with or without PDP)
+	fetcher.Module,           // Provides fetcher of blobs from URLs, if enabled
 	storage.Module,           // Provides storage service wrapper
 	retrieval.Module,         // Provides retrieval service wrapper
 	principalresolver.Module, // Provides principal resolver for UCAN
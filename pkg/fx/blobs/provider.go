@@ -7,13 +7,21 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/storacha/piri/pkg/access"
+	"github.com/storacha/piri/pkg/blocklist"
+	"github.com/storacha/piri/pkg/cdn"
 	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/contentindex"
 	echofx "github.com/storacha/piri/pkg/fx/echo"
+	"github.com/storacha/piri/pkg/piecelog"
 	"github.com/storacha/piri/pkg/presigner"
+	"github.com/storacha/piri/pkg/privatespace"
+	"github.com/storacha/piri/pkg/ratelimit"
+	"github.com/storacha/piri/pkg/readonly"
 	"github.com/storacha/piri/pkg/service/blobs"
 	"github.com/storacha/piri/pkg/store/acceptancestore"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/storacha/piri/pkg/telemetry/latency"
 )
 
 var Module = fx.Module("blobs",
@@ -26,7 +34,7 @@ var Module = fx.Module("blobs",
 			},
 		),
 		fx.Annotate(
-			blobs.NewServer,
+			NewServer,
 			fx.As(new(echofx.RouteRegistrar)),
 			fx.ResultTags(`group:"route_registrar"`),
 		),
@@ -42,6 +50,10 @@ type NewServiceParams struct {
 	BlobStore       blobstore.Blobstore
 	AllocationStore allocationstore.AllocationStore
 	AcceptanceStore acceptancestore.AcceptanceStore
+	Latency         *latency.Tracker
+	PieceLog        *piecelog.Log
+	CDN             *cdn.CDN `optional:"true"`
+	ContentIndex    *contentindex.Index
 }
 
 func NewService(params NewServiceParams) (*blobs.BlobService, error) {
@@ -66,5 +78,28 @@ func NewService(params NewServiceParams) (*blobs.BlobService, error) {
 		blobs.WithBlobstore(params.BlobStore),
 		blobs.WithAllocationStore(params.AllocationStore),
 		blobs.WithAcceptanceStore(params.AcceptanceStore),
+		blobs.WithLatencyTracker(params.Latency),
+		blobs.WithPieceLog(params.PieceLog),
+		blobs.WithCDN(params.CDN),
+		blobs.WithContentIndex(params.ContentIndex),
 	)
 }
+
+type NewServerParams struct {
+	fx.In
+
+	Service    blobs.Blobs
+	RateLimits *ratelimit.Limiters  `optional:"true"`
+	ReadOnly   *readonly.Mode       `optional:"true"`
+	Blocklist  *blocklist.List      `optional:"true"`
+	Private    *privatespace.Policy `optional:"true"`
+}
+
+// NewServer creates the blob HTTP server for the blob service, with downloads
+// rate limited per client IP, uploads refused while the node is read-only,
+// blocked blobs neither uploaded nor downloaded and blobs of private spaces
+// only downloaded from signed URLs.
+func NewServer(params NewServerParams) (*blobs.Server, error) {
+	svc := params.Service
+	return blobs.NewServer(svc.Presigner(), svc.Allocations(), svc.Store(), svc.Latency(), svc.CDN(), params.ReadOnly, params.Blocklist, params.Private, params.RateLimits.IPMiddleware())
+}
@@ -0,0 +1,70 @@
+package blocklist
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/blocklist"
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/httpclient"
+)
+
+// Module provides the blocklist of content refused by the node, synced from
+// the remote denylist if one is configured.
+var Module = fx.Module("blocklist",
+	fx.Provide(
+		NewList,
+		NewSyncer,
+		blocklist.NewPurger,
+	),
+	// the syncer is only depended on optionally, by the admin API
+	fx.Invoke(func(*blocklist.Syncer) {}),
+)
+
+type Params struct {
+	fx.In
+
+	Datastore datastore.Datastore `name:"blocklist_datastore"`
+}
+
+// NewList creates the blocklist persisted in the blocklist datastore.
+func NewList(params Params) (*blocklist.List, error) {
+	l, err := blocklist.New(context.Background(), params.Datastore)
+	if err != nil {
+		return nil, fmt.Errorf("creating blocklist: %w", err)
+	}
+	return l, nil
+}
+
+type SyncerParams struct {
+	fx.In
+
+	Lifecycle  fx.Lifecycle
+	Config     app.UCANServiceConfig
+	List       *blocklist.List
+	HTTPPolicy httpclient.Policy
+}
+
+// NewSyncer creates the syncer of the remote denylist, syncing it in the
+// background while the node is started. It returns nil if no denylist URL is
+// configured.
+func NewSyncer(params SyncerParams) *blocklist.Syncer {
+	cfg := params.Config.Blocklist
+	if cfg.URL == nil {
+		return nil
+	}
+	s := blocklist.NewSyncer(params.List, cfg.URL, httpclient.New("blocklist", params.HTTPPolicy), cfg.SyncInterval)
+	params.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			s.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return s.Stop(ctx)
+		},
+	})
+	return s
+}
@@ -3,9 +3,12 @@ package claims
 import (
 	"go.uber.org/fx"
 
+	"github.com/storacha/piri/pkg/config/app"
 	echofx "github.com/storacha/piri/pkg/fx/echo"
+	"github.com/storacha/piri/pkg/ratelimit"
 	"github.com/storacha/piri/pkg/service/claims"
 	publisherSvc "github.com/storacha/piri/pkg/service/publisher"
+	"github.com/storacha/piri/pkg/service/reachability"
 	"github.com/storacha/piri/pkg/store/claimstore"
 )
 
@@ -16,16 +19,42 @@ var Module = fx.Module("claims",
 			fx.As(new(claims.Claims)),
 		),
 		fx.Annotate(
-			claims.NewServer,
+			NewServer,
 			fx.As(new(echofx.RouteRegistrar)),
 			fx.ResultTags(`group:"route_registrar"`),
 		),
 	),
 )
 
-func NewService(
-	claimStore claimstore.ClaimStore,
-	pub publisherSvc.Publisher,
-) *claims.ClaimService {
-	return claims.NewV2(claimStore, pub)
+type NewServiceParams struct {
+	fx.In
+
+	Cfg        app.UCANServiceConfig
+	ClaimStore claimstore.ClaimStore
+	Publisher  publisherSvc.Publisher
+	// Reachability is nil if the node has a single public URL.
+	Reachability *reachability.Monitor `optional:"true"`
+}
+
+// NewService creates the claims service, locating blobs on each healthy
+// public URL of the node.
+func NewService(params NewServiceParams) *claims.ClaimService {
+	var locator claims.Locator
+	if params.Reachability != nil {
+		locator = params.Reachability
+	}
+	return claims.NewV2(params.ClaimStore, params.Publisher, params.Cfg.LocationClaims.Expiration, locator)
+}
+
+type NewServerParams struct {
+	fx.In
+
+	ClaimStore claimstore.ClaimStore
+	RateLimits *ratelimit.Limiters `optional:"true"`
+}
+
+// NewServer creates the claim HTTP server, with downloads rate limited per
+// client IP.
+func NewServer(params NewServerParams) (*claims.Server, error) {
+	return claims.NewServer(params.ClaimStore, params.RateLimits.IPMiddleware())
 }
@@ -0,0 +1,106 @@
+package curio
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/config/app"
+	echofx "github.com/storacha/piri/pkg/fx/echo"
+	"github.com/storacha/piri/pkg/httpclient"
+	"github.com/storacha/piri/pkg/pdp/curio"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// Module provides the Curio backend of proof sets proven by Curio, and the
+// route Curio notifies the node of parked pieces on.
+var Module = fx.Module("curio",
+	fx.Provide(
+		NewBackend,
+		fx.Annotate(
+			NewNotifyHandler,
+			fx.As(new(echofx.RouteRegistrar)),
+			fx.ResultTags(`group:"route_registrar"`),
+		),
+	),
+)
+
+type Params struct {
+	fx.In
+
+	Config     app.PDPServiceConfig
+	Server     app.ServerConfig
+	Identity   app.IdentityConfig
+	DB         *gorm.DB `name:"engine_db"`
+	Resolver   types.PieceResolverAPI
+	Reader     types.PieceReaderAPI
+	HTTPPolicy httpclient.Policy
+}
+
+// NewBackend creates the Curio backend, nil if no Curio URL is configured.
+func NewBackend(params Params) (*curio.Backend, error) {
+	cfg := params.Config.Curio
+	if cfg.URL == nil {
+		return nil, nil
+	}
+	pieces := struct {
+		types.PieceResolverAPI
+		types.PieceReaderAPI
+	}{params.Resolver, params.Reader}
+	b, err := curio.New(curio.Config{
+		Endpoint:      cfg.URL,
+		PublicURL:     params.Server.PublicURL,
+		UploadTimeout: cfg.UploadTimeout,
+	}, params.Identity.Signer, params.DB, pieces, httpclient.New("curio", params.HTTPPolicy))
+	if err != nil {
+		return nil, fmt.Errorf("creating curio backend: %w", err)
+	}
+	return b, nil
+}
+
+var _ echofx.RouteRegistrar = (*NotifyHandler)(nil)
+
+// NotifyHandler records the pieces Curio notifies the node it has parked.
+type NotifyHandler struct {
+	backend *curio.Backend
+}
+
+// NewNotifyHandler creates the handler of Curio notifications.
+func NewNotifyHandler(backend *curio.Backend) *NotifyHandler {
+	return &NotifyHandler{backend: backend}
+}
+
+// Notification is the body Curio posts to the notify URL of an upload once
+// its piece is parked.
+type Notification struct {
+	ID       string `json:"id"`
+	PieceCID string `json:"pieceCID,omitempty"`
+}
+
+// RegisterRoutes registers the notification route when a Curio URL is
+// configured.
+func (h *NotifyHandler) RegisterRoutes(e *echo.Echo) {
+	if h.backend == nil {
+		return
+	}
+	e.POST(curio.NotifyRoutePath, h.notify)
+}
+
+func (h *NotifyHandler) notify(c echo.Context) error {
+	var n Notification
+	if err := c.Bind(&n); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid notification")
+	}
+	id, err := uuid.Parse(n.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid upload id")
+	}
+	if err := h.backend.Notified(c.Request().Context(), id); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
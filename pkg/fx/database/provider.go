@@ -16,6 +16,7 @@ import (
 	"github.com/storacha/piri/pkg/database/gormdb"
 	"github.com/storacha/piri/pkg/database/postgresdb"
 	"github.com/storacha/piri/pkg/database/sqlitedb"
+	"github.com/storacha/piri/pkg/pdp/service/models"
 )
 
 // PostgreSQL schema names for each logical database
@@ -152,6 +153,35 @@ func ProvideAggregatorDB(lc fx.Lifecycle, cfg app.StorageConfig) (*sql.DB, error
 // ProvideTaskEngineDB provides the GORM database for the task engine scheduler.
 // Supports both SQLite (default) and PostgreSQL backends.
 func ProvideTaskEngineDB(lc fx.Lifecycle, cfg app.StorageConfig) (*gorm.DB, error) {
+	db, err := NewTaskEngineDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		// NB(forrest): we don't ping the gorm database on startup since the gorm package does so internally.
+		// The tables are created before the components using them are started.
+		OnStart: func(ctx context.Context) error {
+			return models.AutoMigrateDB(ctx, db)
+		},
+		OnStop: func(ctx context.Context) error {
+			ddb, err := db.DB()
+			if err != nil {
+				return fmt.Errorf("stopping task engine db: %w", err)
+			}
+			if err := ddb.Close(); err != nil {
+				return fmt.Errorf("stopping task engine db: %w", err)
+			}
+			return nil
+		},
+	})
+	return db, nil
+}
+
+// NewTaskEngineDB opens the GORM database of the task engine scheduler, which
+// also holds the PDP state. It is used by commands run while the node is
+// stopped.
+func NewTaskEngineDB(cfg app.StorageConfig) (*gorm.DB, error) {
 	var db *gorm.DB
 	var err error
 
@@ -197,19 +227,6 @@ func ProvideTaskEngineDB(lc fx.Lifecycle, cfg app.StorageConfig) (*gorm.DB, erro
 		configureSQLiteConnection(sqlDB)
 	}
 
-	lc.Append(fx.Hook{
-		// NB(forrest): we don't ping the gorm database on startup since the gorm package does so internally.
-		OnStop: func(ctx context.Context) error {
-			ddb, err := db.DB()
-			if err != nil {
-				return fmt.Errorf("stopping task engine db: %w", err)
-			}
-			if err := ddb.Close(); err != nil {
-				return fmt.Errorf("stopping task engine db: %w", err)
-			}
-			return nil
-		},
-	})
 	return db, nil
 }
 
@@ -0,0 +1,80 @@
+package diskspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/diskspace"
+	"github.com/storacha/piri/pkg/pdp/alerting"
+)
+
+var log = logging.Logger("diskspace")
+
+var Module = fx.Module("diskspace",
+	fx.Provide(NewMonitor),
+	// the monitor is only depended on optionally, by the storage service and
+	// the admin API
+	fx.Invoke(func(*diskspace.Monitor) {}),
+)
+
+type Params struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Config    app.AppConfig
+	// Alerter raises alerts of disk pressure, nil if the node runs no PDP
+	// alerting.
+	Alerter *alerting.Alerter `optional:"true"`
+}
+
+// NewMonitor creates the monitor of the disks of the data and temp
+// directories, checking them in the background while the node is started. It
+// returns nil if disk space is not monitored, or the node keeps no data on
+// disk.
+func NewMonitor(params Params) (*diskspace.Monitor, error) {
+	cfg := params.Config.DiskSpace
+	storage := params.Config.Storage
+	if !cfg.Enabled || storage.DataDir == "" {
+		return nil, nil
+	}
+
+	volumes := []diskspace.Volume{{Name: diskspace.Data, Path: storage.DataDir}}
+	if storage.TempDir != "" && storage.TempDir != storage.DataDir {
+		volumes = append(volumes, diskspace.Volume{Name: diskspace.Temp, Path: storage.TempDir})
+	}
+	opts := []diskspace.Option{diskspace.WithInterval(cfg.Interval)}
+	if a := params.Alerter; a != nil {
+		opts = append(opts, diskspace.WithAlertFunc(func(ctx context.Context, u diskspace.Usage, since time.Time) {
+			key := fmt.Sprintf("%s:%s:%d", alerting.KindDiskSpace, u.Name, since.Unix())
+			msg := fmt.Sprintf("%s directory %s is %.1f%% full, above the %g%% watermark, new allocations are rejected", u.Name, u.Path, u.UsedPercent, cfg.HighWatermark)
+			if err := a.Raise(ctx, alerting.KindDiskSpace, key, msg); err != nil {
+				log.Errorw("raising disk space alert", "error", err)
+			}
+		}))
+	}
+	m, err := diskspace.New(volumes, cfg.HighWatermark, cfg.LowWatermark, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating disk space monitor: %w", err)
+	}
+	reg, err := diskspace.RegisterMetrics(m)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			m.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return errors.Join(m.Stop(ctx), reg.Unregister())
+		},
+	})
+	return m, nil
+}
@@ -0,0 +1,49 @@
+package echo
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// NewACMEManager creates a manager obtaining certificates for the configured
+// hostnames from an ACME CA the first time a client connects for them, and
+// renewing them before they expire. Certificates and the account key are
+// stored in the cache directory, so they survive restarts.
+//
+// The TLS config of the manager answers TLS-ALPN-01 challenges on the TLS
+// listener, its HTTP handler answers HTTP-01 challenges, see
+// NewACMEChallengeServer.
+func NewACMEManager(cfg app.ACMEConfig) (*autocert.Manager, error) {
+	if len(cfg.Hostnames) == 0 {
+		return nil, errors.New("no hostnames to obtain certificates for")
+	}
+	if cfg.CacheDir == "" {
+		return nil, errors.New("no directory to store certificates in")
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		HostPolicy: autocert.HostWhitelist(cfg.Hostnames...),
+		Email:      cfg.Email,
+	}
+	if cfg.DirectoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	}
+	return m, nil
+}
+
+// NewACMEChallengeServer creates a server on addr answering HTTP-01 challenges
+// of the manager, and redirecting any other request to HTTPS.
+func NewACMEChallengeServer(addr string, m *autocert.Manager) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           m.HTTPHandler(nil),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
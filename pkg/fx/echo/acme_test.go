@@ -0,0 +1,35 @@
+package echo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+func TestNewACMEManager(t *testing.T) {
+	m, err := NewACMEManager(app.ACMEConfig{
+		Enabled:   true,
+		Hostnames: []string{"piri.example.com"},
+		CacheDir:  t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	// certificates are only obtained for the configured hostnames
+	require.NoError(t, m.HostPolicy(t.Context(), "piri.example.com"))
+	require.Error(t, m.HostPolicy(t.Context(), "other.example.com"))
+
+	// the TLS config answers TLS-ALPN-01 challenges
+	require.Contains(t, m.TLSConfig().NextProtos, "acme-tls/1")
+
+	t.Run("requires hostnames", func(t *testing.T) {
+		_, err := NewACMEManager(app.ACMEConfig{Enabled: true, CacheDir: t.TempDir()})
+		require.Error(t, err)
+	})
+
+	t.Run("requires cache directory", func(t *testing.T) {
+		_, err := NewACMEManager(app.ACMEConfig{Enabled: true, Hostnames: []string{"piri.example.com"}})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,87 @@
+package echo
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+	"github.com/quic-go/quic-go/http3"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+// HTTP3Server serves HTTP/3 over QUIC on a UDP port, alongside the TCP
+// listener of the public endpoint. Clients of the TCP listener are told of it
+// with the Alt-Svc header, and fall back to TCP when they cannot reach it,
+// e.g. because UDP is blocked on their network.
+type HTTP3Server struct {
+	server    *http3.Server
+	altSvc    string
+	required  bool
+	listening atomic.Bool
+}
+
+// NewHTTP3Server creates a server for the handler listening on host and the
+// configured UDP port. HTTP/3 is always encrypted, so tlsConf must provide
+// the certificates of the public endpoint.
+func NewHTTP3Server(cfg app.HTTP3Config, host string, tlsConf *tls.Config, handler http.Handler) *HTTP3Server {
+	return &HTTP3Server{
+		server: &http3.Server{
+			Addr:      net.JoinHostPort(host, strconv.Itoa(int(cfg.Port))),
+			TLSConfig: tlsConf,
+			Handler:   handler,
+		},
+		altSvc:   fmt.Sprintf(`h3=":%d"; ma=%d`, cfg.AdvertisedPort, int(cfg.AltSvcMaxAge.Seconds())),
+		required: cfg.Required,
+	}
+}
+
+// Start listens on the UDP port and serves HTTP/3 in the background. If the
+// port cannot be listened on, Start fails when HTTP/3 is required, and
+// otherwise logs the error and leaves clients on TCP.
+func (s *HTTP3Server) Start() error {
+	conn, err := net.ListenPacket("udp", s.server.Addr)
+	if err != nil {
+		if s.required {
+			return fmt.Errorf("listening for HTTP/3 on %s: %w", s.server.Addr, err)
+		}
+		log.Errorw("Not serving HTTP/3, clients will use TCP", "addr", s.server.Addr, "error", err)
+		return nil
+	}
+	log.Infof("Serving HTTP/3 on %s", conn.LocalAddr())
+	s.listening.Store(true)
+	go func() {
+		defer conn.Close()
+		if err := s.server.Serve(conn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("HTTP/3 server error: %v", err)
+		}
+		s.listening.Store(false)
+	}()
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for the requests in
+// progress to complete, or for the context to be done.
+func (s *HTTP3Server) Shutdown(ctx context.Context) error {
+	s.listening.Store(false)
+	return s.server.Shutdown(ctx)
+}
+
+// Middleware advertises HTTP/3 with the Alt-Svc header on responses to
+// requests made over TCP, while the server is listening.
+func (s *HTTP3Server) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().ProtoMajor < 3 && s.listening.Load() {
+				c.Response().Header().Add("Alt-Svc", s.altSvc)
+			}
+			return next(c)
+		}
+	}
+}
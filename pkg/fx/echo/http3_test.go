@@ -0,0 +1,62 @@
+package echo
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+func TestHTTP3Server(t *testing.T) {
+	newServer := func(t *testing.T, port uint, required bool) (*HTTP3Server, *echo.Echo) {
+		e := echo.New()
+		s := NewHTTP3Server(app.HTTP3Config{
+			Enabled:        true,
+			Port:           port,
+			AdvertisedPort: 443,
+			AltSvcMaxAge:   time.Hour,
+			Required:       required,
+		}, "127.0.0.1", &tls.Config{}, e)
+		e.Use(s.Middleware())
+		e.GET("/blob/:blob", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+		t.Cleanup(func() { require.NoError(t, s.Shutdown(t.Context())) })
+		return s, e
+	}
+	altSvc := func(e *echo.Echo) string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/blob/z", nil))
+		return rec.Header().Get("Alt-Svc")
+	}
+
+	t.Run("advertises HTTP/3 while listening", func(t *testing.T) {
+		s, e := newServer(t, 0, false)
+		require.Empty(t, altSvc(e))
+
+		require.NoError(t, s.Start())
+		require.Equal(t, `h3=":443"; ma=3600`, altSvc(e))
+
+		require.NoError(t, s.Shutdown(t.Context()))
+		require.Empty(t, altSvc(e))
+	})
+
+	t.Run("falls back to TCP if the port cannot be listened on", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+		port := uint(conn.LocalAddr().(*net.UDPAddr).Port)
+
+		s, e := newServer(t, port, false)
+		require.NoError(t, s.Start())
+		require.Empty(t, altSvc(e))
+
+		s, _ = newServer(t, port, true)
+		require.Error(t, s.Start())
+	})
+}
@@ -5,10 +5,13 @@ import (
 
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/lib/telemetry/traces"
 )
 
-// ErrorLogger is a middleware that logs errors to the provided logger.
-func ErrorLogger(log logging.EventLogger) echo.MiddlewareFunc {
+// ErrorLogger is a middleware that logs errors to the provided logger, along
+// with the trace and span IDs of the request.
+func ErrorLogger(log *logging.ZapEventLogger) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			err := next(c)
@@ -16,7 +19,7 @@ func ErrorLogger(log logging.EventLogger) echo.MiddlewareFunc {
 				// do not log HTTP errors, since they have been "handled" already
 				var HTTPError *echo.HTTPError
 				if !errors.As(err, &HTTPError) {
-					log.Error(err)
+					traces.Logger(c.Request().Context(), log).Error(err)
 				}
 			}
 			return err
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/labstack/echo/v4"
@@ -35,6 +37,20 @@ type RouteRegistrar interface {
 	RegisterRoutes(e *echo.Echo)
 }
 
+// Drainer is told the server is about to shut down, before it stops
+// accepting requests, e.g. to fail readiness checks so load balancers stop
+// routing requests to the node.
+type Drainer interface {
+	Drain(ctx context.Context)
+}
+
+// DrainParams collects the drainers of the server.
+type DrainParams struct {
+	fx.In
+
+	Drainers []Drainer `group:"drainers"`
+}
+
 // NewEcho creates a new Echo instance with default middleware
 func NewEcho() *echo.Echo {
 	e := echo.New()
@@ -44,16 +60,18 @@ func NewEcho() *echo.Echo {
 	// Set custom error handler
 	e.HTTPErrorHandler = pirimiddleware.CustomHTTPErrorHandler
 
-	// Add default middleware
-	e.Use(pirimiddleware.RequestLogger(log))
-	e.Use(middleware.Recover())
-	// Custom middlewares
-	e.Use(ErrorLogger(log))
+	// Start a span for each request first, continuing the trace of the
+	// traceparent header if any, so the logs of the request carry its IDs
 	e.Use(otelecho.Middleware(
 		"piri",
 		otelecho.WithPropagators(otel.GetTextMapPropagator()),
 		otelecho.WithMeterProvider(otel.GetMeterProvider()),
 	))
+	// Add default middleware
+	e.Use(pirimiddleware.RequestLogger(log))
+	e.Use(middleware.Recover())
+	// Custom middlewares
+	e.Use(ErrorLogger(log))
 
 	return e
 }
@@ -64,8 +82,12 @@ type EchoServer struct {
 	addr string
 }
 
-// StartEchoServer runs a Echo server with lifecycle management
-func StartEchoServer(cfg app.AppConfig, e *echo.Echo, lc fx.Lifecycle) (*EchoServer, error) {
+// StartEchoServer runs a Echo server with lifecycle management. When ACME is
+// enabled the server listens for TLS with certificates obtained for the public
+// hostnames, and HTTP-01 challenges are answered on a separate listener. With
+// TLS, requests are also served over HTTP/3 when it is enabled.
+// Drainers are drained before the server shuts down.
+func StartEchoServer(cfg app.AppConfig, e *echo.Echo, lc fx.Lifecycle, drain DrainParams) (*EchoServer, error) {
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 
 	server := &EchoServer{
@@ -73,13 +95,54 @@ func StartEchoServer(cfg app.AppConfig, e *echo.Echo, lc fx.Lifecycle) (*EchoSer
 		addr: addr,
 	}
 
+	start := func() error { return e.Start(addr) }
+	var challengeSrv *http.Server
+	var h3Srv *HTTP3Server
+	if acmeCfg := cfg.Server.ACME; acmeCfg.Enabled {
+		m, err := NewACMEManager(acmeCfg)
+		if err != nil {
+			return nil, fmt.Errorf("creating ACME certificate manager: %w", err)
+		}
+		e.TLSServer.Addr = addr
+		e.TLSServer.TLSConfig = m.TLSConfig()
+		start = func() error { return e.StartServer(e.TLSServer) }
+		if !acmeCfg.DisableHTTPChallenge {
+			challengeSrv = NewACMEChallengeServer(net.JoinHostPort(acmeCfg.HTTPHost, strconv.Itoa(int(acmeCfg.HTTPPort))), m)
+		}
+		log.Infow("Serving TLS with ACME certificates", "hostnames", acmeCfg.Hostnames, "cache", acmeCfg.CacheDir)
+
+		if cfg.Server.HTTP3.Enabled {
+			h3Srv = NewHTTP3Server(cfg.Server.HTTP3, cfg.Server.Host, m.TLSConfig(), e)
+			e.Use(h3Srv.Middleware())
+		}
+	}
+
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
+			if challengeSrv != nil {
+				ln, err := net.Listen("tcp", challengeSrv.Addr)
+				if err != nil {
+					return fmt.Errorf("starting ACME challenge listener: %w", err)
+				}
+				log.Infof("Answering ACME HTTP-01 challenges on %s", ln.Addr())
+				go func() {
+					if err := challengeSrv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+						log.Errorf("ACME challenge server error: %v", err)
+					}
+				}()
+			}
+
+			if h3Srv != nil {
+				if err := h3Srv.Start(); err != nil {
+					return err
+				}
+			}
+
 			log.Infof("Starting Echo server on %s", addr)
 
 			// Start server in a goroutine
 			go func() {
-				if err := e.Start(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				if err := start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 					log.Errorf("Echo server error: %v", err)
 				}
 			}()
@@ -87,8 +150,21 @@ func StartEchoServer(cfg app.AppConfig, e *echo.Echo, lc fx.Lifecycle) (*EchoSer
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
+			for _, d := range drain.Drainers {
+				d.Drain(ctx)
+			}
 			log.Info("Shutting down Echo server")
 			defer log.Info("Echo server stopped")
+			if challengeSrv != nil {
+				if err := challengeSrv.Shutdown(ctx); err != nil {
+					log.Errorf("ACME challenge server shutdown error: %v", err)
+				}
+			}
+			if h3Srv != nil {
+				if err := h3Srv.Shutdown(ctx); err != nil {
+					log.Errorf("HTTP/3 server shutdown error: %v", err)
+				}
+			}
 			// Per go docs on this method:
 			// Shutdown gracefully shuts down the server without interrupting any
 			// active connections. Shutdown works by first closing all open
@@ -0,0 +1,126 @@
+package fetcher
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-ucanto/principal"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/lib/jobqueue"
+	"github.com/storacha/piri/lib/jobqueue/dialect"
+	"github.com/storacha/piri/lib/jobqueue/serializer"
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/service/blobs"
+	"github.com/storacha/piri/pkg/service/claims"
+	"github.com/storacha/piri/pkg/service/fetcher"
+	fetchhandler "github.com/storacha/piri/pkg/service/storage/handlers/fetch"
+	"github.com/storacha/piri/pkg/storageclass"
+	"github.com/storacha/piri/pkg/store/receiptstore"
+)
+
+var log = logging.Logger("fetcher")
+
+var Module = fx.Module("fetcher",
+	fx.Provide(New),
+)
+
+type Params struct {
+	fx.In
+
+	Config         app.AppConfig
+	StorageConfig  app.StorageConfig
+	DB             *sql.DB `name:"replicator_db"`
+	Clock          clock.Clock
+	ID             principal.Signer
+	PDP            pdp.PDP `optional:"true"`
+	Blobs          blobs.Blobs
+	Claims         claims.Claims
+	ReceiptStore   receiptstore.ReceiptStore
+	StorageClasses *storageclass.Manager `optional:"true"`
+}
+
+// New creates the service fetching blobs for `blob/fetch` invocations, nil
+// if fetching is disabled. Fetches are queued in the replicator database.
+func New(lc fx.Lifecycle, params Params) (fetcher.Fetcher, error) {
+	cfg := params.Config.UCANService.Fetch
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	d := dialect.SQLite
+	if params.StorageConfig.Database.IsPostgres() {
+		d = dialect.Postgres
+	}
+	// unset limits default to those of replication
+	maxRetries, maxWorkers := cfg.MaxRetries, cfg.MaxWorkers
+	if maxRetries == 0 {
+		maxRetries = params.Config.Replicator.MaxRetries
+	}
+	if maxWorkers == 0 {
+		maxWorkers = params.Config.Replicator.MaxWorkers
+	}
+	opts := []jobqueue.Option{
+		jobqueue.WithLogger(log.With("queue", "fetch")),
+		jobqueue.WithMaxRetries(maxRetries),
+		jobqueue.WithMaxWorkers(maxWorkers),
+		jobqueue.WithMaxTimeout(params.Config.Replicator.MaxTimeout),
+		jobqueue.WithDialect(d),
+		jobqueue.WithClock(params.Clock),
+	}
+	if backoff := params.Config.Replicator.RetryBackoff; backoff > 0 {
+		opts = append(opts, jobqueue.WithRetryBackoff(backoff, params.Config.Replicator.MaxRetryBackoff))
+	}
+	queue, err := jobqueue.New[*fetchhandler.FetchRequest](
+		"fetch",
+		params.DB,
+		&serializer.JSON[*fetchhandler.FetchRequest]{},
+		opts...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating fetch queue: %w", err)
+	}
+
+	downloaderOpts := []fetchhandler.DownloaderOption{
+		fetchhandler.WithChunkSize(cfg.ChunkSize),
+		fetchhandler.WithConcurrency(cfg.Concurrency),
+		fetchhandler.WithStallTimeout(cfg.StallTimeout),
+	}
+	if cfg.AllowPrivateAddresses {
+		log.Warn("fetching from private addresses is allowed, this should only be used for development")
+		downloaderOpts = append(downloaderOpts, fetchhandler.WithHTTPClient(http.DefaultClient))
+	}
+
+	svc := fetcher.New(
+		params.ID,
+		params.PDP,
+		params.Blobs,
+		params.Claims,
+		params.ReceiptStore,
+		params.Config.UCANService.Services.Upload.Connection,
+		params.StorageClasses,
+		queue,
+		fetchhandler.NewDownloader(downloaderOpts...),
+	)
+	if err := svc.RegisterFetchTask(queue); err != nil {
+		return nil, fmt.Errorf("registering fetch task: %w", err)
+	}
+
+	queueCtx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return queue.Start(queueCtx)
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			return queue.Stop(ctx)
+		},
+	})
+
+	return svc, nil
+}
@@ -0,0 +1,54 @@
+package identity
+
+import (
+	"net/http"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/didweb"
+	echofx "github.com/storacha/piri/pkg/fx/echo"
+	"github.com/storacha/piri/pkg/principalresolver"
+)
+
+var log = logging.Logger("fx/identity")
+
+var _ echofx.RouteRegistrar = (*DocumentHandler)(nil)
+
+// DocumentHandler serves the DID document of a node operating as did:web.
+type DocumentHandler struct {
+	doc *principalresolver.Document
+}
+
+type DocumentParams struct {
+	fx.In
+
+	Identity app.IdentityConfig
+	Server   app.ServerConfig `optional:"true"`
+}
+
+func NewDocumentHandler(params DocumentParams) echofx.RouteRegistrar {
+	id := params.Identity
+	if !id.DID.Defined() {
+		return &DocumentHandler{}
+	}
+	if domain, _ := principalresolver.ExtractDomainFromDID(id.DID); params.Server.PublicURL.Host != "" && params.Server.PublicURL.Host != domain {
+		log.Warnw("DID document is served from the public URL, which is not on the domain of the did:web, resolvers will not find it",
+			"did", id.DID, "public_url", params.Server.PublicURL.String())
+	}
+	doc := didweb.NewDocument(id.DID, id.Signer.Verifier(), id.PreviousKeys...)
+	return &DocumentHandler{doc: &doc}
+}
+
+// RegisterRoutes registers the DID document route, if the node operates as
+// did:web.
+func (h *DocumentHandler) RegisterRoutes(e *echo.Echo) {
+	if h.doc == nil {
+		return
+	}
+	e.GET(principalresolver.WellKnownDIDPath, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, h.doc)
+	})
+}
@@ -2,16 +2,65 @@ package identity
 
 import (
 	"github.com/storacha/go-ucanto/principal"
+	ucanserver "github.com/storacha/go-ucanto/server"
+	ucanretrievalserver "github.com/storacha/go-ucanto/server/retrieval"
+	"github.com/storacha/go-ucanto/ucan"
 	"go.uber.org/fx"
 
 	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/didweb"
 )
 
 var Module = fx.Module("identity",
-	fx.Provide(ProvideIdentity),
+	fx.Provide(
+		ProvideIdentity,
+		fx.Annotate(
+			NewDocumentHandler,
+			fx.ResultTags(`group:"route_registrar"`),
+		),
+		fx.Annotate(
+			ProvideAudiencesUCANOption,
+			fx.ResultTags(`group:"ucan_options"`),
+		),
+		fx.Annotate(
+			ProvideAudiencesUCANRetrievalOption,
+			fx.ResultTags(`group:"ucan_retrieval_options"`),
+		),
+	),
 )
 
-// ProvideIdentity extracts the principal signer from the app config
-func ProvideIdentity(cfg app.AppConfig) principal.Signer {
-	return cfg.Identity.Signer
+// ProvideIdentity extracts the principal signer from the app config. With the
+// agent and vault-transit signer backends, the signer was connected to the
+// signing agent or Vault when the config was loaded. With a did:web identity, the signer is identified by the
+// did:web.
+func ProvideIdentity(cfg app.AppConfig) (principal.Signer, error) {
+	id := cfg.Identity.Signer
+	if !cfg.Identity.DID.Defined() {
+		return id, nil
+	}
+	return didweb.NewSigner(id, cfg.Identity.DID, cfg.Identity.PreviousKeys...)
+}
+
+// ProvideAudiencesUCANOption accepts invocations addressed to the did:key
+// identities of a did:web node, so delegations made to the node before it
+// moved to did:web, or before its key was rotated, remain usable.
+func ProvideAudiencesUCANOption(cfg app.IdentityConfig) ucanserver.Option {
+	return ucanserver.WithAlternativeAudiences(keyAudiences(cfg)...)
+}
+
+// ProvideAudiencesUCANRetrievalOption is ProvideAudiencesUCANOption for the
+// UCAN retrieval server.
+func ProvideAudiencesUCANRetrievalOption(cfg app.IdentityConfig) ucanretrievalserver.Option {
+	return ucanretrievalserver.WithAlternativeAudiences(keyAudiences(cfg)...)
+}
+
+func keyAudiences(cfg app.IdentityConfig) []ucan.Principal {
+	if !cfg.DID.Defined() {
+		return nil
+	}
+	audiences := []ucan.Principal{cfg.Signer.Verifier()}
+	for _, k := range cfg.PreviousKeys {
+		audiences = append(audiences, k)
+	}
+	return audiences
 }
@@ -0,0 +1,44 @@
+package openapi
+
+import (
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	adminhttpapi "github.com/storacha/piri/pkg/admin/httpapi"
+	"github.com/storacha/piri/pkg/build"
+	echofx "github.com/storacha/piri/pkg/fx/echo"
+	"github.com/storacha/piri/pkg/openapi"
+	pdpserver "github.com/storacha/piri/pkg/pdp/httpapi/server"
+)
+
+// Module serves the OpenAPI document of the admin and PDP APIs.
+var Module = fx.Module("openapi",
+	fx.Provide(
+		fx.Annotate(
+			NewHandler,
+			fx.As(new(echofx.RouteRegistrar)),
+			fx.ResultTags(`group:"route_registrar"`),
+		),
+	),
+)
+
+var _ echofx.RouteRegistrar = (*Handler)(nil)
+
+// Handler serves the OpenAPI document.
+type Handler struct {
+	spec *openapi.Spec
+}
+
+// NewHandler creates the handler of the document of the APIs of the node.
+func NewHandler() *Handler {
+	return &Handler{
+		spec: openapi.New("Piri", build.Version, adminhttpapi.Operations, pdpserver.Operations),
+	}
+}
+
+// RegisterRoutes registers the route of the document. Only the operations
+// whose routes are registered on e are described, e.g. the PDP API is left
+// out of the document of nodes that do not serve it.
+func (h *Handler) RegisterRoutes(e *echo.Echo) {
+	e.GET(openapi.RoutePath, h.spec.Handler(e))
+}
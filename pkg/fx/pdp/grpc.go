@@ -0,0 +1,71 @@
+package pdp
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net"
+	"strconv"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/pdp/grpcapi"
+	"github.com/storacha/piri/pkg/pdp/service"
+)
+
+var log = logging.Logger("fx/pdp")
+
+type GRPCParams struct {
+	fx.In
+
+	Config   app.ServerConfig
+	Identity app.IdentityConfig
+	Service  *service.PDPService
+}
+
+// StartGRPC serves the PDP API over gRPC for the lifetime of the app, if
+// enabled in config. Calls are authorized with the node's key, as on the REST
+// API.
+func StartGRPC(lc fx.Lifecycle, params GRPCParams) error {
+	cfg := params.Config.GRPC
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var opts []grpc.ServerOption
+	if !cfg.Insecure {
+		creds, err := credentials.NewServerTLSFromFile(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("loading grpc TLS certificate: %w", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	key := ed25519.PublicKey(params.Identity.Signer.Verifier().Raw())
+	srv := grpcapi.NewServer(params.Service, key, opts...)
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(int(cfg.Port)))
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fmt.Errorf("starting grpc listener: %w", err)
+			}
+			log.Infow("serving grpc", "address", ln.Addr().String(), "insecure", cfg.Insecure)
+			go func() {
+				if err := srv.Serve(ln); err != nil {
+					log.Errorw("grpc server failed", "error", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			srv.GracefulStop()
+			return nil
+		},
+	})
+	return nil
+}
@@ -3,6 +3,7 @@ package pdp
 import (
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/storacha/filecoin-services/go/eip712"
 	"go.uber.org/fx"
 	"gorm.io/gorm"
@@ -11,6 +12,7 @@ import (
 	signingservice "github.com/storacha/piri-signing-service/pkg/signer"
 	signertypes "github.com/storacha/piri-signing-service/pkg/types"
 
+	"github.com/storacha/piri/pkg/blocklist"
 	"github.com/storacha/piri/pkg/config/app"
 	echofx "github.com/storacha/piri/pkg/fx/echo"
 	"github.com/storacha/piri/pkg/pdp"
@@ -22,16 +24,21 @@ import (
 	"github.com/storacha/piri/pkg/pdp/service"
 	"github.com/storacha/piri/pkg/pdp/smartcontracts"
 	"github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/privatespace"
 	"github.com/storacha/piri/pkg/service/proofs"
 	"github.com/storacha/piri/pkg/service/signer"
+	"github.com/storacha/piri/pkg/service/signer/approval"
 	"github.com/storacha/piri/pkg/store/acceptancestore"
 	"github.com/storacha/piri/pkg/store/blobstore"
 	"github.com/storacha/piri/pkg/store/receiptstore"
+	"github.com/storacha/piri/pkg/telemetry/latency"
+	"github.com/storacha/piri/pkg/wallet"
 )
 
 var Module = fx.Module("pdp-service",
 	fx.Provide(
 		eip712.NewExtraDataEncoder,
+		ProvideApprovalQueue,
 		ProvideSigningService,
 		fx.Annotate(
 			ProvidePDPService,
@@ -51,11 +58,12 @@ var Module = fx.Module("pdp-service",
 			fx.As(new(pdp.PDP)),
 		),
 		fx.Annotate(
-			server.NewPDPHandler,
+			ProvidePDPHandler,
 			fx.As(new(echofx.RouteRegistrar)),
 			fx.ResultTags(`group:"route_registrar"`),
 		),
 	),
+	fx.Invoke(StartGRPC),
 )
 
 // TODO(forrest): this interface and it's impls need to be removed, renamed, or merged with the blob interface
@@ -131,17 +139,86 @@ func ProvideProofSetIDProvider(cfg app.UCANServiceConfig) (types.ProofSetIDProvi
 	return &service.ConfiguredProofSetProvider{ID: cfg.ProofSetID}, nil
 }
 
-func ProvideSigningService(cfg app.PDPServiceConfig, proofService proofs.ProofService) (signertypes.SigningService, error) {
+// ApprovalQueueParams contains the dependencies of the sign request approval
+// queue.
+type ApprovalQueueParams struct {
+	fx.In
+
+	Config app.PDPServiceConfig
+	DB     *gorm.DB `name:"engine_db"`
+}
+
+// ProvideApprovalQueue creates the queue holding sign requests for approval
+// by the operator, nil if approval is disabled.
+func ProvideApprovalQueue(params ApprovalQueueParams) (*approval.Queue, error) {
+	cfg := params.Config.SigningService.Approval
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	policy := approval.Policy{
+		AutoApproveAddPiecesBelow: cfg.AutoApproveAddPiecesBelow,
+		Wait:                      cfg.Wait,
+	}
+	for _, op := range cfg.Manual {
+		policy.Manual = append(policy.Manual, approval.Operation(op))
+	}
+	q, err := approval.New(params.DB, policy)
+	if err != nil {
+		return nil, fmt.Errorf("creating sign request approval queue: %w", err)
+	}
+	return q, nil
+}
+
+// PDPHandlerParams contains the dependencies of the PDP API handler.
+type PDPHandlerParams struct {
+	fx.In
+
+	Service   *service.PDPService
+	Identity  app.IdentityConfig
+	Latency   *latency.Tracker
+	Blocklist *blocklist.List      `optional:"true"`
+	Private   *privatespace.Policy `optional:"true"`
+}
+
+// ProvidePDPHandler creates the PDP API handler, which does not serve pieces
+// of blocked blobs or of blobs of private spaces.
+func ProvidePDPHandler(params PDPHandlerParams) (*server.PDPHandler, error) {
+	return server.NewPDPHandler(params.Service, params.Identity, params.Latency, params.Blocklist, params.Private)
+}
+
+// SigningServiceParams contains the dependencies of the signing service.
+type SigningServiceParams struct {
+	fx.In
+
+	Config       app.PDPServiceConfig
+	ProofService proofs.ProofService
+	Approvals    *approval.Queue `optional:"true"`
+	Wallet       wallet.Wallet   `optional:"true"`
+}
+
+func ProvideSigningService(params SigningServiceParams) (signertypes.SigningService, error) {
+	cfg := params.Config
+	var s signertypes.SigningService
 	if cfg.SigningService.Connection != nil {
-		return signer.NewProofServiceSigner(cfg.SigningService.Connection, proofService), nil
+		s = signer.NewProofServiceSigner(cfg.SigningService.Connection, params.ProofService)
 	} else if cfg.SigningService.PrivateKey != nil {
-		s := signingservice.NewSigner(
+		s = signerimpl.New(signingservice.NewSigner(
 			cfg.SigningService.PrivateKey,
 			cfg.ChainID,
 			cfg.Contracts.Service,
-		)
-		return signerimpl.New(s), nil
+		))
+	} else if cfg.SigningService.KeyStoreAddress != (common.Address{}) {
+		hs, ok := params.Wallet.(wallet.HashSigner)
+		if !ok {
+			return nil, fmt.Errorf("signing service keystore address requires a wallet that signs hashes")
+		}
+		s = signer.NewWalletSigner(hs, cfg.SigningService.KeyStoreAddress, cfg.ChainID, cfg.Contracts.Service)
+	} else {
+		return nil, fmt.Errorf("no signer configured")
 	}
 
-	return nil, fmt.Errorf("no signer configured")
+	if params.Approvals != nil {
+		return approval.NewSigner(s, params.Approvals), nil
+	}
+	return s, nil
 }
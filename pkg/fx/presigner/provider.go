@@ -14,12 +14,17 @@ import (
 
 var Module = fx.Module("presigner",
 	fx.Provide(
-		NewRequestPresigner,
+		fx.Annotate(
+			NewRequestPresigner,
+			fx.As(new(presigner.RequestPresigner)),
+			fx.As(new(presigner.RetrievalPresigner)),
+		),
 	),
 )
 
-// NewRequestPresigner creates a new S3 request presigner
-func NewRequestPresigner(cfg app.AppConfig, id principal.Signer) (presigner.RequestPresigner, error) {
+// NewRequestPresigner creates a new S3 request presigner, signing upload and
+// retrieval URLs.
+func NewRequestPresigner(cfg app.AppConfig, id principal.Signer) (*presigner.S3RequestPresigner, error) {
 	if cfg.Server.PublicURL.Scheme == "" {
 		return nil, fmt.Errorf("public URL required for presigner")
 	}
@@ -2,10 +2,7 @@ package principalresolver
 
 import (
 	"fmt"
-	"strings"
-	"time"
 
-	"github.com/storacha/go-ucanto/did"
 	ucanserver "github.com/storacha/go-ucanto/server"
 	ucanretrievalserver "github.com/storacha/go-ucanto/server/retrieval"
 	"github.com/storacha/go-ucanto/validator"
@@ -29,34 +26,31 @@ var Module = fx.Module("principalresolver",
 	),
 )
 
-// NewPrincipalResolver creates a principal resolver from configuration
+// NewPrincipalResolver creates a principal resolver from configuration. did:web
+// principals are resolved from their DID document, falling back to the
+// configured principal mapping when the document cannot be fetched.
 func NewPrincipalResolver(cfg app.AppConfig) (validator.PrincipalResolver, error) {
-	services := make([]did.DID, 0, 2)
-	if idxSvc := cfg.UCANService.Services.Indexer.Connection; idxSvc != nil {
-		if strings.HasPrefix(idxSvc.ID().DID().String(), "did:web:") {
-			services = append(services, idxSvc.ID().DID())
-		}
-	}
-	if uplSvc := cfg.UCANService.Services.Upload.Connection; uplSvc != nil {
-		if strings.HasPrefix(uplSvc.ID().DID().String(), "did:web:") {
-			services = append(services, uplSvc.ID().DID())
-		}
+	mr, err := principalresolver.NewMapResolver(cfg.UCANService.Services.PrincipalMapping)
+	if err != nil {
+		return nil, fmt.Errorf("creating principal mapping resolver: %w", err)
 	}
 	// Build resolver options
-	var opts []principalresolver.Option
+	opts := []principalresolver.Option{principalresolver.WithFallback(mr)}
 	if cfg.UCANService.InsecureDIDResolution {
 		opts = append(opts, principalresolver.InsecureResolution())
 	}
-
-	hr, err := principalresolver.NewHTTPResolver(services, opts...)
-	if err != nil {
-		return nil, fmt.Errorf("creating http principal resolver: %w", err)
+	if ttl := cfg.UCANService.DIDResolution.CacheTTL; ttl > 0 {
+		opts = append(opts, principalresolver.WithCacheTTL(ttl))
+	}
+	if ttl := cfg.UCANService.DIDResolution.NegativeCacheTTL; ttl > 0 {
+		opts = append(opts, principalresolver.WithNegativeCacheTTL(ttl))
 	}
-	cr, err := principalresolver.NewCachedResolver(hr, 24*time.Hour)
+
+	wr, err := principalresolver.NewWebResolver(opts...)
 	if err != nil {
-		return nil, fmt.Errorf("creating cached principal resolver: %w", err)
+		return nil, fmt.Errorf("creating did:web principal resolver: %w", err)
 	}
-	return cr, nil
+	return wr, nil
 }
 
 // ProvideAsUCANOption provides the principal resolver as a UCAN server option
@@ -1,15 +1,20 @@
 package publisher
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/ipfs/go-datastore"
 	"github.com/storacha/go-libstoracha/ipnipublisher/store"
 	"github.com/storacha/go-ucanto/principal"
 	"go.uber.org/fx"
 
 	"github.com/storacha/piri/pkg/config/app"
 	echofx "github.com/storacha/piri/pkg/fx/echo"
+	"github.com/storacha/piri/pkg/httpclient"
+	"github.com/storacha/piri/pkg/p2p"
 	"github.com/storacha/piri/pkg/service/publisher"
+	"github.com/storacha/piri/pkg/service/reachability"
 )
 
 var Module = fx.Module("publisher",
@@ -27,25 +32,65 @@ var Module = fx.Module("publisher",
 	),
 )
 
-func NewService(
-	cfg app.AppConfig,
-	id principal.Signer,
-	publisherStore store.PublisherStore,
-) (*publisher.PublisherService, error) {
-	pubCfg := cfg.UCANService.Services.Publisher
+type ServiceParams struct {
+	fx.In
+
+	Config         app.AppConfig
+	ID             principal.Signer
+	PublisherStore store.PublisherStore
+	BatchDatastore datastore.Datastore `name:"ipni_batch_datastore"`
+	Node           *p2p.Node
+	Monitor        *reachability.Monitor
+	Policy         httpclient.Policy
+}
+
+func NewService(lc fx.Lifecycle, params ServiceParams) (*publisher.PublisherService, error) {
+	pubCfg := params.Config.UCANService.Services.Publisher
 	if pubCfg.PublicMaddr.String() == "" {
 		return nil, fmt.Errorf("public address is required for publisher service")
 	}
 
-	return publisher.New(
-		id,
-		publisherStore,
-		pubCfg.PublicMaddr,
+	indexerCfg := params.Config.UCANService.Services.Indexer
+	indexerConn := indexerCfg.Connection
+	if indexerCfg.URL != nil {
+		conn, err := httpclient.NewConnection(indexerConn.ID(), indexerCfg.URL, httpclient.New("indexer", params.Policy))
+		if err != nil {
+			return nil, fmt.Errorf("creating indexing service connection: %w", err)
+		}
+		indexerConn = conn
+	}
+
+	opts := []publisher.Option{
 		publisher.WithDirectAnnounce(pubCfg.AnnounceURLs...),
-		publisher.WithIndexingService(cfg.UCANService.Services.Indexer.Connection),
-		publisher.WithIndexingServiceProof(cfg.UCANService.Services.Indexer.Proofs...),
+		publisher.WithIndexingService(indexerConn),
+		publisher.WithIndexingServiceProof(indexerCfg.Proofs...),
 		publisher.WithAnnounceAddress(pubCfg.AnnounceMaddr),
 		publisher.WithBlobAddress(pubCfg.BlobMaddr),
-	)
+		publisher.WithBatching(int(pubCfg.BatchMaxSize), pubCfg.BatchMaxLatency, params.BatchDatastore),
+	}
+	// advertise the libp2p addresses blobs are also served over Bitswap from
+	if params.Node != nil {
+		opts = append(opts, publisher.WithPeerAddresses(params.Node.Addrs()...))
+	}
 
+	// advertise the healthy public URLs of the node, fastest first
+	if params.Monitor != nil {
+		opts = append(opts, publisher.WithPublicURLs(params.Monitor.URLs))
+	}
+
+	svc, err := publisher.New(params.ID, params.PublisherStore, pubCfg.PublicMaddr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// publish the advertisements still batched when the node stops
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			svc.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return svc.Stop(ctx)
+		},
+	})
+	return svc, nil
 }
@@ -0,0 +1,61 @@
+package readonly
+
+import (
+	"fmt"
+	"path/filepath"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/health"
+	"github.com/storacha/piri/pkg/readonly"
+)
+
+var log = logging.Logger("readonly")
+
+var Module = fx.Module("readonly",
+	fx.Provide(
+		NewModeFromConfig,
+		fx.Annotate(
+			NewHealthChecks,
+			fx.As(new(health.CheckProvider)),
+			fx.ResultTags(`group:"health_checks"`),
+		),
+	),
+)
+
+// NewModeFromConfig creates the read-only mode persisted in the data
+// directory, forced on if the node is configured read-only.
+func NewModeFromConfig(storage app.StorageConfig, ucan app.UCANServiceConfig) (*readonly.Mode, error) {
+	path := ""
+	if storage.DataDir != "" {
+		path = filepath.Join(storage.DataDir, readonly.StateFile)
+	}
+	m, err := readonly.New(path, ucan.ReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("creating read-only mode: %w", err)
+	}
+	if s := m.Status(); s.Enabled {
+		log.Warnw("storage node is read-only", "reason", s.Reason)
+	}
+	return m, nil
+}
+
+// HealthChecks reports the read-only mode in the health check.
+type HealthChecks struct {
+	mode *readonly.Mode
+}
+
+func NewHealthChecks(m *readonly.Mode) *HealthChecks {
+	return &HealthChecks{mode: m}
+}
+
+// Checks implements health.CheckProvider.
+func (h *HealthChecks) Checks() []health.Check {
+	status := health.StatusOK
+	if h.mode.Enabled() {
+		status = health.StatusPaused
+	}
+	return []health.Check{{Name: "read_only", Status: status}}
+}
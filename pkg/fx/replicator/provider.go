@@ -6,19 +6,26 @@ import (
 	"fmt"
 
 	logging "github.com/ipfs/go-log/v2"
+	"github.com/raulk/clock"
 	"github.com/storacha/go-ucanto/principal"
 	"go.uber.org/fx"
 
 	"github.com/storacha/piri/lib/jobqueue"
 	"github.com/storacha/piri/lib/jobqueue/dialect"
 	"github.com/storacha/piri/lib/jobqueue/serializer"
+	"github.com/storacha/piri/pkg/blocklist"
+	"github.com/storacha/piri/pkg/config"
 	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/config/dynamic"
+	"github.com/storacha/piri/pkg/diagnostics"
+	"github.com/storacha/piri/pkg/httpclient"
 	"github.com/storacha/piri/pkg/pdp"
 	"github.com/storacha/piri/pkg/service/blobs"
 	"github.com/storacha/piri/pkg/service/claims"
 	"github.com/storacha/piri/pkg/service/replicator"
 	replicahandler "github.com/storacha/piri/pkg/service/storage/handlers/replica"
 	"github.com/storacha/piri/pkg/store/receiptstore"
+	"github.com/storacha/piri/pkg/subsystem"
 )
 
 var log = logging.Logger("replicator")
@@ -26,6 +33,11 @@ var log = logging.Logger("replicator")
 var Module = fx.Module("replicator",
 	fx.Provide(
 		ProvideReplicationQueue,
+		ProvideDeadLetters,
+		fx.Annotate(
+			func(q *jobqueue.JobQueue[*replicahandler.TransferRequest]) jobqueue.Snapshotter { return q },
+			fx.ResultTags(diagnostics.QueueGroup),
+		),
 		fx.Annotate(
 			New,
 			fx.As(fx.Self()),                  // provide as concrete type for RegisterReplicationJobs
@@ -34,6 +46,7 @@ var Module = fx.Module("replicator",
 	),
 	fx.Invoke(
 		RegisterReplicationJobs,
+		RegisterSubsystem,
 	),
 )
 
@@ -42,6 +55,10 @@ type QueueParams struct {
 	DB            *sql.DB `name:"replicator_db"`
 	Config        app.ReplicatorConfig
 	StorageConfig app.StorageConfig
+	Clock         clock.Clock
+	// Registry is optional, when present the workers and rate limit of the
+	// queue can be changed while it runs.
+	Registry *dynamic.Registry `optional:"true"`
 }
 
 func ProvideReplicationQueue(lc fx.Lifecycle, params QueueParams) (*jobqueue.JobQueue[*replicahandler.TransferRequest], error) {
@@ -51,20 +68,41 @@ func ProvideReplicationQueue(lc fx.Lifecycle, params QueueParams) (*jobqueue.Job
 		d = dialect.Postgres
 	}
 
-	replicationQueue, err := jobqueue.New[*replicahandler.TransferRequest](
-		"replication",
-		params.DB,
-		&serializer.JSON[*replicahandler.TransferRequest]{},
+	opts := []jobqueue.Option{
 		jobqueue.WithLogger(log.With("queue", "replication")),
 		jobqueue.WithMaxRetries(params.Config.MaxRetries),
 		jobqueue.WithMaxWorkers(params.Config.MaxWorkers),
+		jobqueue.WithRateLimit(params.Config.JobsPerMinute),
 		jobqueue.WithMaxTimeout(params.Config.MaxTimeout),
 		jobqueue.WithDialect(d),
+		jobqueue.WithClock(params.Clock),
+	}
+	if backoff := params.Config.RetryBackoff; backoff > 0 {
+		opts = append(opts, jobqueue.WithRetryBackoff(backoff, params.Config.MaxRetryBackoff))
+	}
+	replicationQueue, err := jobqueue.New[*replicahandler.TransferRequest](
+		"replication",
+		params.DB,
+		&serializer.JSON[*replicahandler.TransferRequest]{},
+		opts...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("creating replication queue: %w", err)
 	}
 
+	if params.Registry != nil {
+		if err := params.Registry.BindUint(config.ReplicationWorkers, params.Config.MaxWorkers, dynamic.UintSchema{Min: 1, Max: 1024}, func(n uint) {
+			if err := replicationQueue.SetMaxWorkers(n); err != nil {
+				log.Warnw("changing replication queue workers", "workers", n, "error", err)
+			}
+		}); err != nil {
+			return nil, fmt.Errorf("registering replication queue workers: %w", err)
+		}
+		if err := params.Registry.BindUint(config.ReplicationRateLimit, params.Config.JobsPerMinute, dynamic.UintSchema{Min: 0, Max: 60000}, replicationQueue.SetRateLimit); err != nil {
+			return nil, fmt.Errorf("registering replication queue rate limit: %w", err)
+		}
+	}
+
 	queueCtx, cancel := context.WithCancel(context.Background())
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -79,6 +117,26 @@ func ProvideReplicationQueue(lc fx.Lifecycle, params QueueParams) (*jobqueue.Job
 	return replicationQueue, nil
 }
 
+type DeadLetterParams struct {
+	fx.In
+	DB            *sql.DB `name:"replicator_db"`
+	StorageConfig app.StorageConfig
+}
+
+// ProvideDeadLetters creates the store of failed transfers in the
+// replicator database.
+func ProvideDeadLetters(params DeadLetterParams) (*replicator.DeadLetters, error) {
+	d := dialect.SQLite
+	if params.StorageConfig.Database.IsPostgres() {
+		d = dialect.Postgres
+	}
+	dl, err := replicator.NewDeadLetters(context.Background(), params.DB, d)
+	if err != nil {
+		return nil, fmt.Errorf("creating replication dead letters: %w", err)
+	}
+	return dl, nil
+}
+
 type Params struct {
 	fx.In
 
@@ -89,17 +147,43 @@ type Params struct {
 	Claims       claims.Claims
 	ReceiptStore receiptstore.ReceiptStore
 	Queue        *jobqueue.JobQueue[*replicahandler.TransferRequest]
+	DeadLetters  *replicator.DeadLetters
+	HTTPPolicy   httpclient.Policy
+	Blocklist    *blocklist.List `optional:"true"`
 }
 
 func New(params Params) (*replicator.Service, error) {
+	client := httpclient.New("replicator", params.HTTPPolicy)
+	uploadCfg := params.Config.UCANService.Services.Upload
+	uploadConn := uploadCfg.Connection
+	if uploadCfg.URL != nil {
+		conn, err := httpclient.NewConnection(uploadConn.ID(), uploadCfg.URL, httpclient.New("upload", params.HTTPPolicy))
+		if err != nil {
+			return nil, fmt.Errorf("creating upload service connection: %w", err)
+		}
+		uploadConn = conn
+	}
+
 	r, err := replicator.New(
 		params.ID,
 		params.PDP,
 		params.Blobs,
 		params.Claims,
 		params.ReceiptStore,
-		params.Config.UCANService.Services.Upload.Connection,
+		uploadConn,
 		params.Queue,
+		replicahandler.NewSourceSelector(
+			replicahandler.WithPreferredHosts(params.Config.Replicator.PreferredSourceHosts...),
+			replicahandler.WithStallTimeout(params.Config.Replicator.StallTimeout),
+			replicahandler.WithCompression(params.Config.Replicator.Compression),
+		),
+		replicahandler.NewScheduler(
+			replicahandler.WithChunkSize(params.Config.Replicator.ChunkSize),
+			replicahandler.WithConcurrency(params.Config.Replicator.Concurrency),
+			replicahandler.WithHTTPClient(client),
+		),
+		replicator.WithDeadLetters(params.DeadLetters),
+		replicator.WithBlocklist(params.Blocklist),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("new replicator: %w", err)
@@ -114,3 +198,9 @@ func RegisterReplicationJobs(
 ) error {
 	return service.RegisterTransferTask(queue)
 }
+
+// RegisterSubsystem allows operators to pause replication through the admin
+// API. Replication requests are still accepted and queued while paused.
+func RegisterSubsystem(r *subsystem.Registry, queue *jobqueue.JobQueue[*replicahandler.TransferRequest]) {
+	r.Register(subsystem.Replication, queue)
+}
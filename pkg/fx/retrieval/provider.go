@@ -5,7 +5,11 @@ import (
 	"github.com/storacha/piri/pkg/pdp/types"
 	"go.uber.org/fx"
 
+	"github.com/storacha/piri/pkg/blocklist"
 	"github.com/storacha/piri/pkg/pdp/store/adapter"
+	"github.com/storacha/piri/pkg/privatespace"
+	"github.com/storacha/piri/pkg/ratelimit"
+	"github.com/storacha/piri/pkg/service/quota"
 	"github.com/storacha/piri/pkg/service/retrieval"
 	"github.com/storacha/piri/pkg/service/retrieval/ucan"
 	"github.com/storacha/piri/pkg/store/allocationstore"
@@ -30,6 +34,10 @@ type RetrievalServiceParams struct {
 	Allocations allocationstore.AllocationStore
 	Blobs       blobstore.BlobGetter
 	API         types.PieceReaderAPI `optional:"true"`
+	Quotas      quota.Enforcer       `optional:"true"`
+	RateLimits  *ratelimit.Limiters  `optional:"true"`
+	Blocklist   *blocklist.List      `optional:"true"`
+	Private     *privatespace.Policy `optional:"true"`
 }
 
 func NewRetrievalService(params RetrievalServiceParams) *retrieval.RetrievalService {
@@ -40,5 +48,7 @@ func NewRetrievalService(params RetrievalServiceParams) *retrieval.RetrievalServ
 	if params.API != nil {
 		blobs = adapter.NewBlobGetterAdapter(params.API)
 	}
-	return retrieval.New(params.ID, blobs, params.Allocations)
+	// Blocked blobs are retrieved as if the node did not hold them.
+	blobs = params.Blocklist.BlobGetter(blobs)
+	return retrieval.New(params.ID, blobs, params.Allocations, params.Quotas, params.RateLimits.SpaceLimiter(), params.Private)
 }
@@ -13,6 +13,7 @@ import (
 	ucanserver "github.com/storacha/go-ucanto/server"
 	ucanretrieval "github.com/storacha/go-ucanto/server/retrieval"
 
+	"github.com/storacha/piri/pkg/config/app"
 	"github.com/storacha/piri/pkg/service/egresstracker"
 	"github.com/storacha/piri/pkg/service/retrieval/ucan"
 )
@@ -22,7 +23,7 @@ var log = logging.Logger("retrieval/ucan")
 var Module = fx.Module("retrieval/ucan/handlers",
 	fx.Provide(
 		fx.Annotate(
-			ucan.WithBlobRetrieveMethod,
+			withBlobRetrieveMethod,
 			fx.ResultTags(`group:"ucan_retrieval_options"`),
 		),
 		fx.Annotate(
@@ -40,6 +41,12 @@ var Module = fx.Module("retrieval/ucan/handlers",
 	),
 )
 
+// withBlobRetrieveMethod serves blobs to replicating nodes, compressed if
+// compression of replica transfers is enabled.
+func withBlobRetrieveMethod(service ucan.BlobRetrievalService, cfg app.AppConfig) ucanretrieval.Option {
+	return ucan.WithBlobRetrieveMethod(service, ucan.WithCompression(cfg.Replicator.Compression))
+}
+
 func withErrorHandler() ucanretrieval.Option {
 	return ucanretrieval.WithErrorHandler(func(err ucanserver.HandlerExecutionError[any]) {
 		l := log.With("error", err.Error())
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/raulk/clock"
 	"go.uber.org/fx"
 	"gorm.io/gorm"
 
@@ -11,15 +12,18 @@ import (
 	"github.com/storacha/piri/pkg/config/dynamic"
 	"github.com/storacha/piri/pkg/pdp/chainsched"
 	"github.com/storacha/piri/pkg/pdp/ethereum"
+	"github.com/storacha/piri/pkg/pdp/gasoracle"
 	"github.com/storacha/piri/pkg/pdp/scheduler"
 	"github.com/storacha/piri/pkg/pdp/service"
 	"github.com/storacha/piri/pkg/pdp/smartcontracts"
 	"github.com/storacha/piri/pkg/pdp/tasks"
+	"github.com/storacha/piri/pkg/piecelog"
 	"github.com/storacha/piri/pkg/wallet"
 )
 
 var MessageModule = fx.Module("scheduler-messages",
 	fx.Provide(
+		ProvideGasOracle,
 		// This setup is required to prevent a circular dependency
 		// - SenderETH (implements ethereum.Sender) depends on SendTaskETH
 		// - SendTaskETH is registered as a scheduler task
@@ -40,12 +44,34 @@ var MessageModule = fx.Module("scheduler-messages",
 	// NB: these methods are invoked as they do not provide any types in their return or nothing depends on their return
 	fx.Invoke(
 		StartWatcherMessageEth,
+		StartTxManagerETH,
 		StartWatcherCreate,
 		StartWatcherRootAdd,
+		StartWatcherProve,
 		StartWatcherProviderRegister,
 	),
 )
 
+type GasOracleParams struct {
+	fx.In
+	DB        *gorm.DB `name:"engine_db"`
+	Scheduler *chainsched.Scheduler
+	GasConfig app.GasConfig
+}
+
+// ProvideGasOracle restores the persisted base fee history and records the
+// base fee of every new tipset.
+func ProvideGasOracle(params GasOracleParams) (*gasoracle.Oracle, error) {
+	oracle := gasoracle.New(params.DB, params.GasConfig.History)
+	if err := oracle.Load(context.Background()); err != nil {
+		return nil, err
+	}
+	if err := oracle.Watch(params.Scheduler); err != nil {
+		return nil, fmt.Errorf("watching base fees: %w", err)
+	}
+	return oracle, nil
+}
+
 type SenderETHParams struct {
 	fx.In
 	DB        *gorm.DB `name:"engine_db"`
@@ -53,6 +79,8 @@ type SenderETHParams struct {
 	Wallet    wallet.Wallet
 	Registry  *dynamic.Registry
 	GasConfig app.GasConfig
+	GasOracle *gasoracle.Oracle
+	Clock     clock.Clock
 }
 
 // SenderETHPair holds both the sender and task to ensure they're created together
@@ -62,7 +90,15 @@ type SenderETHPair struct {
 }
 
 func ProvideSenderETHPair(params SenderETHParams) (*SenderETHPair, error) {
-	sender, sendTask, err := tasks.NewSenderETH(params.Client, params.Wallet, params.DB, tasks.WithGasConfig(params.Registry), tasks.WithGasDefaults(params.GasConfig))
+	sender, sendTask, err := tasks.NewSenderETH(
+		params.Client,
+		params.Wallet,
+		params.DB,
+		tasks.WithGasConfig(params.Registry),
+		tasks.WithGasDefaults(params.GasConfig),
+		tasks.WithGasOracle(params.GasOracle),
+		tasks.WithSenderClock(params.Clock),
+	)
 	return &SenderETHPair{
 		Sender:   sender,
 		SendTask: sendTask,
@@ -82,13 +118,20 @@ type WatcherMessageEthParams struct {
 	DB        *gorm.DB `name:"engine_db"`
 	Client    service.EthClient
 	Scheduler *chainsched.Scheduler
+	Config    app.PDPServiceConfig
 }
 
 func StartWatcherMessageEth(
 	lc fx.Lifecycle,
 	params WatcherMessageEthParams,
 ) (*tasks.MessageWatcherEth, error) {
-	ew, err := tasks.NewMessageWatcherEth(params.DB, params.Scheduler, params.Client)
+	ew, err := tasks.NewMessageWatcherEth(
+		params.DB,
+		params.Scheduler,
+		params.Client,
+		tasks.WithConfirmations(params.Config.Confirmation.Depth),
+		tasks.WithReorgWindow(params.Config.Confirmation.ReorgWindow),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("creating message watcher: %w", err)
 	}
@@ -104,6 +147,35 @@ func StartWatcherMessageEth(
 	return ew, nil
 }
 
+type TxManagerETHParams struct {
+	fx.In
+	DB        *gorm.DB `name:"engine_db"`
+	Client    service.EthClient
+	Wallet    wallet.Wallet
+	Registry  *dynamic.Registry
+	GasConfig app.GasConfig
+	Clock     clock.Clock
+}
+
+// StartTxManagerETH replaces stalled and resubmits dropped transactions.
+func StartTxManagerETH(lc fx.Lifecycle, params TxManagerETHParams) (*tasks.TxManagerETH, error) {
+	tm, err := tasks.NewTxManagerETH(params.DB, params.Client, params.Wallet, params.Registry, params.GasConfig.Replace, params.Clock)
+	if err != nil {
+		return nil, fmt.Errorf("creating transaction manager: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			// the start context is cancelled once the node has started
+			tm.Start(context.WithoutCancel(ctx))
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return tm.Stop(ctx)
+		},
+	})
+	return tm, nil
+}
+
 type WatcherCreateParams struct {
 	fx.In
 	DB          *gorm.DB `name:"engine_db"`
@@ -136,6 +208,21 @@ func StartWatcherRootAdd(params WatcherRootAddParams) error {
 	)
 }
 
+type WatcherProveParams struct {
+	fx.In
+	DB        *gorm.DB `name:"engine_db"`
+	Scheduler *chainsched.Scheduler
+	PieceLog  *piecelog.Log `optional:"true"`
+}
+
+func StartWatcherProve(params WatcherProveParams) error {
+	return tasks.NewWatcherProve(
+		params.DB,
+		params.Scheduler,
+		params.PieceLog,
+	)
+}
+
 type WatcherProviderRegisterParams struct {
 	fx.In
 	DB        *gorm.DB `name:"engine_db"`
@@ -7,6 +7,7 @@ import (
 	"go.uber.org/fx"
 	"gorm.io/gorm"
 
+	"github.com/storacha/piri/pkg/config/app"
 	"github.com/storacha/piri/pkg/pdp/chainsched"
 	"github.com/storacha/piri/pkg/pdp/scheduler"
 	"github.com/storacha/piri/pkg/pdp/service"
@@ -27,12 +28,23 @@ var Module = fx.Module("scheduler",
 type EngineParams struct {
 	fx.In
 
-	DB    *gorm.DB                  `name:"engine_db"`
-	Tasks []scheduler.TaskInterface `group:"scheduler_tasks"`
+	DB     *gorm.DB                  `name:"engine_db"`
+	Tasks  []scheduler.TaskInterface `group:"scheduler_tasks"`
+	Config app.PDPServiceConfig      `optional:"true"`
 }
 
 func ProvideEngine(lc fx.Lifecycle, params EngineParams) (*scheduler.TaskEngine, error) {
-	engine, err := scheduler.NewEngine(params.DB, params.Tasks)
+	cfg := params.Config.Scheduler
+	opts := []scheduler.Option{
+		scheduler.WithClassLimit(scheduler.PriorityProving, cfg.MaxProving),
+		scheduler.WithClassLimit(scheduler.PriorityChain, cfg.MaxChain),
+		scheduler.WithClassLimit(scheduler.PriorityMessages, cfg.MaxMessages),
+		scheduler.WithClassLimit(scheduler.PriorityBackground, cfg.MaxBackground),
+	}
+	if cfg.UrgentWindow > 0 {
+		opts = append(opts, scheduler.WithUrgentWindow(cfg.UrgentWindow))
+	}
+	engine, err := scheduler.NewEngine(params.DB, params.Tasks, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("creating scheduler engine: %w", err)
 	}
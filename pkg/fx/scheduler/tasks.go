@@ -11,7 +11,9 @@ import (
 	"github.com/storacha/piri/pkg/pdp/service"
 	"github.com/storacha/piri/pkg/pdp/smartcontracts"
 	"github.com/storacha/piri/pkg/pdp/tasks"
+	"github.com/storacha/piri/pkg/piecelog"
 	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/storacha/piri/pkg/subsystem"
 )
 
 var TasksModule = fx.Module("scheduler-tasks",
@@ -26,12 +28,16 @@ var TasksModule = fx.Module("scheduler-tasks",
 			fx.As(new(scheduler.TaskInterface)),
 			fx.ResultTags(`group:"scheduler_tasks"`),
 		),
+		ProvidePDPProveTask,
 		fx.Annotate(
-			ProvidePDPProveTask,
-			fx.As(new(scheduler.TaskInterface)),
+			func(t *tasks.ProveTask) scheduler.TaskInterface { return t },
 			fx.ResultTags(`group:"scheduler_tasks"`),
 		),
 	),
+	// proving can be paused by operators through the admin API
+	fx.Invoke(func(r *subsystem.Registry, t *tasks.ProveTask) {
+		r.Register(subsystem.Proving, t)
+	}),
 )
 
 type InitProvingPeriodTaskParams struct {
@@ -91,6 +97,7 @@ type PDPProveTaskParams struct {
 	Store     blobstore.PDPStore
 	Reader    types.PieceReaderAPI
 	Resolver  types.PieceResolverAPI
+	PieceLog  *piecelog.Log
 }
 
 func ProvidePDPProveTask(params PDPProveTaskParams) (*tasks.ProveTask, error) {
@@ -104,5 +111,6 @@ func ProvidePDPProveTask(params PDPProveTaskParams) (*tasks.ProveTask, error) {
 		params.Store,
 		params.Reader,
 		params.Resolver,
+		params.PieceLog,
 	)
 }
@@ -6,13 +6,23 @@ import (
 	"github.com/storacha/go-ucanto/validator"
 	"go.uber.org/fx"
 
+	"github.com/storacha/piri/pkg/blocklist"
 	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/diskspace"
 	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/readonly"
+	"github.com/storacha/piri/pkg/service/admission"
 	"github.com/storacha/piri/pkg/service/blobs"
 	"github.com/storacha/piri/pkg/service/claims"
+	"github.com/storacha/piri/pkg/service/collector"
+	"github.com/storacha/piri/pkg/service/fetcher"
+	"github.com/storacha/piri/pkg/service/quota"
+	"github.com/storacha/piri/pkg/service/replicapolicy"
 	"github.com/storacha/piri/pkg/service/replicator"
 	"github.com/storacha/piri/pkg/service/storage"
 	"github.com/storacha/piri/pkg/service/storage/ucan"
+	"github.com/storacha/piri/pkg/service/usage"
+	"github.com/storacha/piri/pkg/storageclass"
 	"github.com/storacha/piri/pkg/store/receiptstore"
 )
 
@@ -24,8 +34,11 @@ var Module = fx.Module("storage",
 			fx.As(new(ucan.AccessGrantService)),
 			fx.As(new(ucan.BlobAllocateService)),
 			fx.As(new(ucan.BlobAcceptService)),
+			fx.As(new(ucan.BlobFetchService)),
+			fx.As(new(ucan.BlobRemoveService)),
 			fx.As(new(ucan.PDPInfoService)),
 			fx.As(new(ucan.ReplicaAllocateService)),
+			fx.As(new(ucan.UsageReportService)),
 		),
 	),
 )
@@ -41,32 +54,62 @@ type StorageServiceParams struct {
 	PDP                    pdp.PDP `optional:"true"`
 	ReceiptStore           receiptstore.ReceiptStore
 	Replicator             replicator.Replicator
+	ReplicaPolicy          *replicapolicy.Engine `optional:"true"`
+	Fetcher                fetcher.Fetcher       `optional:"true"`
 	ClaimValidationContext validator.ClaimContext
+	Quotas                 quota.Enforcer        `optional:"true"`
+	StorageClasses         *storageclass.Manager `optional:"true"`
+	Collector              *collector.Service
+	Admission              *admission.Calculator
+	Usage                  *usage.Tracker     `optional:"true"`
+	ReadOnly               *readonly.Mode     `optional:"true"`
+	DiskSpace              *diskspace.Monitor `optional:"true"`
+	Blocklist              *blocklist.List    `optional:"true"`
 }
 
 // storageServiceWrapper wraps the storage service to implement the storage.Service interface
 type storageServiceWrapper struct {
-	id           principal.Signer
-	blobs        blobs.Blobs
-	claims       claims.Claims
-	pdp          pdp.PDP
-	receiptStore receiptstore.ReceiptStore
-	replicator   replicator.Replicator
-	uploadConn   client.Connection
-	claimCtx     validator.ClaimContext
+	id            principal.Signer
+	blobs         blobs.Blobs
+	claims        claims.Claims
+	pdp           pdp.PDP
+	receiptStore  receiptstore.ReceiptStore
+	replicator    replicator.Replicator
+	replicaPolicy *replicapolicy.Engine
+	fetcher       fetcher.Fetcher
+	uploadConn    client.Connection
+	claimCtx      validator.ClaimContext
+	quotas        quota.Enforcer
+	classes       *storageclass.Manager
+	collector     *collector.Service
+	admission     *admission.Calculator
+	usage         *usage.Tracker
+	readOnly      *readonly.Mode
+	diskSpace     *diskspace.Monitor
+	blocklist     *blocklist.List
 }
 
 // NewStorageService creates a new storage service
 func NewStorageService(params StorageServiceParams) (storage.Service, error) {
 	svc := &storageServiceWrapper{
-		id:           params.ID,
-		blobs:        params.Blobs,
-		claims:       params.Claims,
-		pdp:          params.PDP,
-		receiptStore: params.ReceiptStore,
-		replicator:   params.Replicator,
-		uploadConn:   params.Config.UCANService.Services.Upload.Connection,
-		claimCtx:     params.ClaimValidationContext,
+		id:            params.ID,
+		blobs:         params.Blobs,
+		claims:        params.Claims,
+		pdp:           params.PDP,
+		receiptStore:  params.ReceiptStore,
+		replicator:    params.Replicator,
+		replicaPolicy: params.ReplicaPolicy,
+		fetcher:       params.Fetcher,
+		uploadConn:    params.Config.UCANService.Services.Upload.Connection,
+		claimCtx:      params.ClaimValidationContext,
+		quotas:        params.Quotas,
+		classes:       params.StorageClasses,
+		collector:     params.Collector,
+		admission:     params.Admission,
+		usage:         params.Usage,
+		readOnly:      params.ReadOnly,
+		diskSpace:     params.DiskSpace,
+		blocklist:     params.Blocklist,
 	}
 
 	return svc, nil
@@ -97,6 +140,14 @@ func (s *storageServiceWrapper) Replicator() replicator.Replicator {
 	return s.replicator
 }
 
+func (s *storageServiceWrapper) ReplicaPolicy() *replicapolicy.Engine {
+	return s.replicaPolicy
+}
+
+func (s *storageServiceWrapper) Fetcher() fetcher.Fetcher {
+	return s.fetcher
+}
+
 func (s *storageServiceWrapper) UploadConnection() client.Connection {
 	return s.uploadConn
 }
@@ -104,3 +155,35 @@ func (s *storageServiceWrapper) UploadConnection() client.Connection {
 func (s *storageServiceWrapper) ClaimValidationContext() validator.ClaimContext {
 	return s.claimCtx
 }
+
+func (s *storageServiceWrapper) Quotas() quota.Enforcer {
+	return s.quotas
+}
+
+func (s *storageServiceWrapper) StorageClasses() *storageclass.Manager {
+	return s.classes
+}
+
+func (s *storageServiceWrapper) Collector() *collector.Service {
+	return s.collector
+}
+
+func (s *storageServiceWrapper) Admission() *admission.Calculator {
+	return s.admission
+}
+
+func (s *storageServiceWrapper) Usage() *usage.Tracker {
+	return s.usage
+}
+
+func (s *storageServiceWrapper) ReadOnly() *readonly.Mode {
+	return s.readOnly
+}
+
+func (s *storageServiceWrapper) DiskSpace() *diskspace.Monitor {
+	return s.diskSpace
+}
+
+func (s *storageServiceWrapper) Blocklist() *blocklist.List {
+	return s.blocklist
+}
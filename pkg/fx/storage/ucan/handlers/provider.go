@@ -9,9 +9,11 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/storacha/go-libstoracha/capabilities/blob"
 	"github.com/storacha/go-libstoracha/capabilities/blob/replica"
+	spaceblob "github.com/storacha/go-libstoracha/capabilities/space/blob"
 	"github.com/storacha/go-ucanto/core/invocation"
 	"github.com/storacha/go-ucanto/core/receipt"
 	"github.com/storacha/go-ucanto/server"
+	"github.com/storacha/piri/pkg/capabilities/fetch"
 	"github.com/storacha/piri/pkg/service/storage/ucan"
 	"github.com/storacha/piri/pkg/store/receiptstore"
 )
@@ -32,6 +34,14 @@ var Module = fx.Module("storage/ucan/handlers",
 			ucan.WithBlobAcceptMethod,
 			fx.ResultTags(`group:"ucan_options"`),
 		),
+		fx.Annotate(
+			ucan.WithBlobFetchMethod,
+			fx.ResultTags(`group:"ucan_options"`),
+		),
+		fx.Annotate(
+			ucan.WithBlobRemoveMethod,
+			fx.ResultTags(`group:"ucan_options"`),
+		),
 		fx.Annotate(
 			ucan.WithPDPInfoMethod,
 			fx.ResultTags(`group:"ucan_options"`),
@@ -40,6 +50,10 @@ var Module = fx.Module("storage/ucan/handlers",
 			ucan.WithReplicaAllocateMethod,
 			fx.ResultTags(`group:"ucan_options"`),
 		),
+		fx.Annotate(
+			ucan.WithUsageReportMethod,
+			fx.ResultTags(`group:"ucan_options"`),
+		),
 		fx.Annotate(
 			withReceiptLogger,
 			fx.ResultTags(`group:"ucan_options"`),
@@ -50,6 +64,8 @@ var Module = fx.Module("storage/ucan/handlers",
 var receiptLogAllowList = []string{
 	blob.AllocateAbility,
 	blob.AcceptAbility,
+	fetch.FetchAbility,
+	spaceblob.RemoveAbility,
 	replica.AllocateAbility,
 }
 
@@ -9,6 +9,7 @@ import (
 	ucanserver "github.com/storacha/go-ucanto/server"
 	"go.uber.org/fx"
 
+	"github.com/storacha/piri/pkg/config/app"
 	echofx "github.com/storacha/piri/pkg/fx/echo"
 	"github.com/storacha/piri/pkg/fx/storage/ucan/handlers"
 	"github.com/storacha/piri/pkg/service/storage"
@@ -18,6 +19,7 @@ var log = logging.Logger("fx/storage/ucan")
 
 type Handler struct {
 	ucanServer ucanserver.ServerView[ucanserver.Service]
+	options    []storage.HandlerOption
 }
 
 var Module = fx.Module("storage/ucan/server",
@@ -36,6 +38,7 @@ type Params struct {
 	fx.In
 
 	ID      principal.Signer
+	Cfg     app.AppConfig
 	Options []ucanserver.Option `group:"ucan_options"`
 }
 
@@ -55,12 +58,19 @@ func NewHandler(p Params) (*Handler, error) {
 		return nil, fmt.Errorf("creating ucan server: %w", err)
 	}
 
-	return &Handler{ucanSvr}, nil
+	return &Handler{
+		ucanServer: ucanSvr,
+		options: []storage.HandlerOption{
+			storage.WithMaxBatchInvocations(p.Cfg.UCANService.Batch.MaxInvocations),
+			storage.WithMaxBatchConcurrency(p.Cfg.UCANService.Batch.MaxConcurrency),
+			storage.WithMaxBatchBodySize(p.Cfg.UCANService.Batch.MaxBodySize),
+		},
+	}, nil
 }
 
 // RegisterRoutes registers the UCAN routes with Echo
 func (h *Handler) RegisterRoutes(e *echo.Echo) {
-	handler := storage.NewHandler(h.ucanServer).ToEcho()
+	handler := storage.NewHandler(h.ucanServer, h.options...).ToEcho()
 	e.POST("/", handler)
 	e.POST("/piece/:cid", handler)
 }
@@ -2,15 +2,20 @@ package filesystem
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
 	leveldb "github.com/ipfs/go-ds-leveldb"
+	logging "github.com/ipfs/go-log/v2"
 	"github.com/storacha/go-libstoracha/ipnipublisher/store"
 	"github.com/storacha/go-libstoracha/metadata"
 	"go.uber.org/fx"
+	"gorm.io/gorm"
 
 	"github.com/storacha/piri/pkg/config/app"
 	"github.com/storacha/piri/pkg/store/acceptancestore"
@@ -22,13 +27,19 @@ import (
 	"github.com/storacha/piri/pkg/store/local/keystore"
 	"github.com/storacha/piri/pkg/store/local/retrievaljournal"
 	"github.com/storacha/piri/pkg/store/objectstore/flatfs"
+	"github.com/storacha/piri/pkg/store/objectstore/pack"
 	"github.com/storacha/piri/pkg/store/receiptstore"
+	"github.com/storacha/piri/pkg/store/sqliteds"
+	"github.com/storacha/piri/pkg/store/stash"
 )
 
+var log = logging.Logger("fx/store/filesystem")
+
 // Module provides all stores backed by the local filesystem.
 var Module = fx.Module("filesystem-store",
 	fx.Provide(
 		ProvideConfigs,
+		NewDatastores,
 		fx.Annotate(
 			NewAggregatorDatastore,
 			// tagged as aggregator_datastore since this returns a datastore.Datastore which is too generic to
@@ -46,10 +57,59 @@ var Module = fx.Module("filesystem-store",
 		NewAllocationStore,
 		NewAcceptanceStore,
 		NewClaimStore,
-		NewReceiptStore,
+		fx.Annotate(
+			NewReceiptStore,
+			fx.As(new(receiptstore.ReceiptStore)),
+			// the log of stored receipts allows them to be pruned
+			fx.As(new(receiptstore.Pruner)),
+		),
 		NewRetrievalJournal,
 		NewKeyStore,
 		NewConsolidationStore,
+		fx.Annotate(
+			NewQuotaDatastore,
+			fx.ResultTags(`name:"quota_datastore"`),
+		),
+		fx.Annotate(
+			NewPieceLogDatastore,
+			fx.ResultTags(`name:"piecelog_datastore"`),
+		),
+		fx.Annotate(
+			NewScrubberDatastore,
+			fx.ResultTags(`name:"scrubber_datastore"`),
+		),
+		fx.Annotate(
+			NewStorageClassDatastore,
+			fx.ResultTags(`name:"storageclass_datastore"`),
+		),
+		fx.Annotate(
+			NewCollectorDatastore,
+			fx.ResultTags(`name:"collector_datastore"`),
+		),
+		fx.Annotate(
+			NewReplicaPolicyDatastore,
+			fx.ResultTags(`name:"replicapolicy_datastore"`),
+		),
+		fx.Annotate(
+			NewContentIndexDatastore,
+			fx.ResultTags(`name:"contentindex_datastore"`),
+		),
+		fx.Annotate(
+			NewBlocklistDatastore,
+			fx.ResultTags(`name:"blocklist_datastore"`),
+		),
+		fx.Annotate(
+			NewBackfillDatastore,
+			fx.ResultTags(`name:"backfill_datastore"`),
+		),
+		fx.Annotate(
+			NewReaperDatastore,
+			fx.ResultTags(`name:"reaper_datastore"`),
+		),
+		fx.Annotate(
+			NewIPNIBatchDatastore,
+			fx.ResultTags(`name:"ipni_batch_datastore"`),
+		),
 		fx.Annotate(
 			NewPDPStore,
 			fx.As(fx.Self()),
@@ -70,11 +130,23 @@ var KeyStoreModule = fx.Module("filesystem-keystore",
 // - PublisherStore: IPNI advertisement chain state
 // - RetrievalJournal: periodic filesystem-based journal with GC
 // - KeyStore: private keys must never leave disk
+// - QuotaDatastore: usage counters updated on every allocation and retrieval
+// - PieceLogDatastore: lifecycle events appended at every stage of the pipeline
+// - ScrubberDatastore: corrupt blobs found by the integrity scrubber
+// - StorageClassDatastore: storage class of every allocated blob
+// - CollectorDatastore: blobs marked for collection once unreferenced
+// - ReplicaPolicyDatastore: replica policy set through the admin API
+// - ContentIndexDatastore: blobs held by the node, looked up on every allocation
+// - BlocklistDatastore: content refused by the node, looked up on every request
+// - BackfillDatastore: progress of the registration of blobs stored before PDP
+// - ReaperDatastore: missing blobs already reported by the reaper
+// - IPNIBatchDatastore: IPNI advertisements batched but not yet published
 //
 // Use this module alongside s3.Module when S3 is configured.
 var LocalOnlyModule = fx.Module("local-only-store",
 	fx.Provide(
 		ProvideLocalOnlyConfigs,
+		NewDatastores,
 		fx.Annotate(
 			NewAggregatorDatastore,
 			fx.ResultTags(`name:"aggregator_datastore"`),
@@ -87,6 +159,50 @@ var LocalOnlyModule = fx.Module("local-only-store",
 		),
 		NewRetrievalJournal,
 		NewKeyStore,
+		fx.Annotate(
+			NewQuotaDatastore,
+			fx.ResultTags(`name:"quota_datastore"`),
+		),
+		fx.Annotate(
+			NewPieceLogDatastore,
+			fx.ResultTags(`name:"piecelog_datastore"`),
+		),
+		fx.Annotate(
+			NewScrubberDatastore,
+			fx.ResultTags(`name:"scrubber_datastore"`),
+		),
+		fx.Annotate(
+			NewStorageClassDatastore,
+			fx.ResultTags(`name:"storageclass_datastore"`),
+		),
+		fx.Annotate(
+			NewCollectorDatastore,
+			fx.ResultTags(`name:"collector_datastore"`),
+		),
+		fx.Annotate(
+			NewReplicaPolicyDatastore,
+			fx.ResultTags(`name:"replicapolicy_datastore"`),
+		),
+		fx.Annotate(
+			NewContentIndexDatastore,
+			fx.ResultTags(`name:"contentindex_datastore"`),
+		),
+		fx.Annotate(
+			NewBlocklistDatastore,
+			fx.ResultTags(`name:"blocklist_datastore"`),
+		),
+		fx.Annotate(
+			NewBackfillDatastore,
+			fx.ResultTags(`name:"backfill_datastore"`),
+		),
+		fx.Annotate(
+			NewReaperDatastore,
+			fx.ResultTags(`name:"reaper_datastore"`),
+		),
+		fx.Annotate(
+			NewIPNIBatchDatastore,
+			fx.ResultTags(`name:"ipni_batch_datastore"`),
+		),
 	),
 )
 
@@ -97,6 +213,17 @@ type LocalOnlyConfigs struct {
 	Publisher     app.PublisherStorageConfig
 	EgressTracker app.EgressTrackerStorageConfig
 	KeyStore      app.KeyStoreConfig
+	Quota         app.QuotaStorageConfig
+	PieceLog      app.PieceLogStorageConfig
+	Scrubber      app.ScrubberStorageConfig
+	StorageClass  app.StorageClassStorageConfig
+	Collector     app.CollectorStorageConfig
+	ReplicaPolicy app.ReplicaPolicyStorageConfig
+	ContentIndex  app.ContentIndexStorageConfig
+	Blocklist     app.BlocklistStorageConfig
+	Backfill      app.BackfillStorageConfig
+	Reaper        app.ReaperStorageConfig
+	IPNIBatch     app.IPNIBatchStorageConfig
 }
 
 // ProvideLocalOnlyConfigs extracts configs for local-only stores.
@@ -106,6 +233,17 @@ func ProvideLocalOnlyConfigs(cfg app.StorageConfig) LocalOnlyConfigs {
 		Publisher:     cfg.Publisher,
 		EgressTracker: cfg.EgressTracker,
 		KeyStore:      cfg.KeyStore,
+		Quota:         cfg.Quota,
+		PieceLog:      cfg.PieceLog,
+		Scrubber:      cfg.Scrubber,
+		StorageClass:  cfg.StorageClass,
+		Collector:     cfg.Collector,
+		ReplicaPolicy: cfg.ReplicaPolicy,
+		ContentIndex:  cfg.ContentIndex,
+		Blocklist:     cfg.Blocklist,
+		Backfill:      cfg.Backfill,
+		Reaper:        cfg.Reaper,
+		IPNIBatch:     cfg.IPNIBatch,
 	}
 }
 
@@ -123,6 +261,17 @@ type Configs struct {
 	PDP           app.PDPStoreConfig
 	Acceptance    app.AcceptanceStorageConfig
 	Consolidation app.ConsolidationStorageConfig
+	Quota         app.QuotaStorageConfig
+	PieceLog      app.PieceLogStorageConfig
+	Scrubber      app.ScrubberStorageConfig
+	StorageClass  app.StorageClassStorageConfig
+	Collector     app.CollectorStorageConfig
+	ReplicaPolicy app.ReplicaPolicyStorageConfig
+	ContentIndex  app.ContentIndexStorageConfig
+	Blocklist     app.BlocklistStorageConfig
+	Backfill      app.BackfillStorageConfig
+	Reaper        app.ReaperStorageConfig
+	IPNIBatch     app.IPNIBatchStorageConfig
 }
 
 // ProvideConfigs provides the fields of a storage config
@@ -140,15 +289,26 @@ func ProvideConfigs(cfg app.StorageConfig) Configs {
 		PDP:           cfg.PDPStore,
 		Acceptance:    cfg.Acceptance,
 		Consolidation: cfg.Consolidation,
+		Quota:         cfg.Quota,
+		PieceLog:      cfg.PieceLog,
+		Scrubber:      cfg.Scrubber,
+		StorageClass:  cfg.StorageClass,
+		Collector:     cfg.Collector,
+		ReplicaPolicy: cfg.ReplicaPolicy,
+		ContentIndex:  cfg.ContentIndex,
+		Blocklist:     cfg.Blocklist,
+		Backfill:      cfg.Backfill,
+		Reaper:        cfg.Reaper,
+		IPNIBatch:     cfg.IPNIBatch,
 	}
 }
 
-func NewAggregatorDatastore(cfg app.AggregatorStorageConfig, lc fx.Lifecycle) (datastore.Datastore, error) {
+func NewAggregatorDatastore(cfg app.AggregatorStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
 	if cfg.Dir == "" {
 		return nil, fmt.Errorf("no data dir provided for aggregator store")
 	}
 
-	ds, err := newDs(cfg.Dir)
+	ds, err := dss.Open(cfg.Dir)
 	if err != nil {
 		return nil, fmt.Errorf("creating aggregator store: %w", err)
 	}
@@ -161,12 +321,37 @@ func NewAggregatorDatastore(cfg app.AggregatorStorageConfig, lc fx.Lifecycle) (d
 	return ds, nil
 }
 
-func NewAllocationStore(cfg app.AllocationStorageConfig, lc fx.Lifecycle) (allocationstore.AllocationStore, error) {
+type AllocationStoreParams struct {
+	fx.In
+
+	Config     app.AllocationStorageConfig
+	Datastores *Datastores
+	// DB is the PDP database. When provided, allocations are kept in it
+	// rather than in the datastore.
+	DB *gorm.DB `name:"engine_db" optional:"true"`
+}
+
+// NewAllocationStore provides the allocation store, in the PDP database when
+// it is provided. The first time the node starts with allocations in the
+// database, those of the datastore are imported into it.
+func NewAllocationStore(params AllocationStoreParams, lc fx.Lifecycle) (allocationstore.AllocationStore, error) {
+	cfg := params.Config
 	if cfg.Dir == "" {
 		return nil, fmt.Errorf("no data dir provided for allocation store")
 	}
 
-	ds, err := newDs(cfg.Dir)
+	if params.DB != nil {
+		sqlStore := allocationstore.NewSQLStore(params.DB)
+		// the allocations table exists once the PDP database is started
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				return importAllocations(ctx, sqlStore, cfg, params.Datastores)
+			},
+		})
+		return sqlStore, nil
+	}
+
+	ds, err := params.Datastores.Open(cfg.Dir)
 	if err != nil {
 		return nil, fmt.Errorf("creating allocation store: %w", err)
 	}
@@ -180,12 +365,44 @@ func NewAllocationStore(cfg app.AllocationStorageConfig, lc fx.Lifecycle) (alloc
 	return allocationstore.NewDatastoreStore(ds), nil
 }
 
-func NewAcceptanceStore(cfg app.AcceptanceStorageConfig, lc fx.Lifecycle) (acceptancestore.AcceptanceStore, error) {
+// importAllocations imports the allocations of the datastore into an empty
+// SQL store. The datastore is left in place.
+func importAllocations(ctx context.Context, sqlStore *allocationstore.SQLStore, cfg app.AllocationStorageConfig, dss *Datastores) error {
+	n, err := sqlStore.Count(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	if dss.shared == nil {
+		if _, err := os.Stat(cfg.Dir); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return fmt.Errorf("opening allocation datastore: %w", err)
+	}
+	defer ds.Close()
+
+	imported, err := sqlStore.Import(ctx, allocationstore.NewDatastoreStore(ds).List(ctx))
+	if err != nil {
+		return fmt.Errorf("importing allocations from %s: %w", cfg.Dir, err)
+	}
+	if imported > 0 {
+		log.Infow("imported allocations into the PDP database", "count", imported, "from", cfg.Dir)
+	}
+	return nil
+}
+
+func NewAcceptanceStore(cfg app.AcceptanceStorageConfig, dss *Datastores, lc fx.Lifecycle) (acceptancestore.AcceptanceStore, error) {
 	if cfg.Dir == "" {
 		return nil, fmt.Errorf("no data dir provided for acceptance store")
 	}
 
-	ds, err := newDs(cfg.Dir)
+	ds, err := dss.Open(cfg.Dir)
 	if err != nil {
 		return nil, fmt.Errorf("creating acceptance store: %w", err)
 	}
@@ -199,12 +416,12 @@ func NewAcceptanceStore(cfg app.AcceptanceStorageConfig, lc fx.Lifecycle) (accep
 	return acceptancestore.NewDatastoreStore(ds), nil
 }
 
-func NewClaimStore(cfg app.ClaimStorageConfig, lc fx.Lifecycle) (claimstore.ClaimStore, error) {
+func NewClaimStore(cfg app.ClaimStorageConfig, dss *Datastores, lc fx.Lifecycle) (claimstore.ClaimStore, error) {
 	if cfg.Dir == "" {
 		return nil, fmt.Errorf("no data dir provided for claim store")
 	}
 
-	ds, err := newDs(cfg.Dir)
+	ds, err := dss.Open(cfg.Dir)
 	if err != nil {
 		return nil, fmt.Errorf("creating claim store: %w", err)
 	}
@@ -217,12 +434,12 @@ func NewClaimStore(cfg app.ClaimStorageConfig, lc fx.Lifecycle) (claimstore.Clai
 	return delegationstore.NewDatastoreStore(ds), nil
 }
 
-func NewPublisherStore(cfg app.PublisherStorageConfig, lc fx.Lifecycle) (store.FullStore, error) {
+func NewPublisherStore(cfg app.PublisherStorageConfig, dss *Datastores, lc fx.Lifecycle) (store.FullStore, error) {
 	if cfg.Dir == "" {
 		return nil, fmt.Errorf("no data dir provided for publisher store")
 	}
 
-	ds, err := newDs(cfg.Dir)
+	ds, err := dss.Open(cfg.Dir)
 	if err != nil {
 		return nil, fmt.Errorf("creating publisher store: %w", err)
 	}
@@ -235,12 +452,12 @@ func NewPublisherStore(cfg app.PublisherStorageConfig, lc fx.Lifecycle) (store.F
 	return store.FromDatastore(ds, store.WithMetadataContext(metadata.MetadataContext)), nil
 }
 
-func NewReceiptStore(cfg app.ReceiptStorageConfig, lc fx.Lifecycle) (receiptstore.ReceiptStore, error) {
+func NewReceiptStore(cfg app.ReceiptStorageConfig, dss *Datastores, lc fx.Lifecycle) (*receiptstore.Store, error) {
 	if cfg.Dir == "" {
 		return nil, fmt.Errorf("no data dir provided for receipt store")
 	}
 
-	ds, err := newDs(cfg.Dir)
+	ds, err := dss.Open(cfg.Dir)
 	if err != nil {
 		return nil, fmt.Errorf("creating receipt store: %w", err)
 	}
@@ -289,28 +506,111 @@ func NewKeyStore(cfg app.KeyStoreConfig, lc fx.Lifecycle) (keystore.KeyStore, er
 	return keystore.NewKeyStore(ds)
 }
 
-func NewPDPStore(cfg app.PDPStoreConfig, lc fx.Lifecycle) (blobstore.Blobstore, error) {
+// NewPDPStore provides the blob store. Uploads are written to the stash
+// before they are moved to the store, and uploads left in the stash when the
+// node stopped are resumed or discarded before the store is provided.
+func NewPDPStore(cfg app.PDPStoreConfig, stashCfg app.StashStoreConfig, lc fx.Lifecycle) (blobstore.Blobstore, error) {
 	if cfg.Dir == "" {
 		return nil, fmt.Errorf("no data dir provided for pdp store")
 	}
+	if stashCfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for stash")
+	}
 	objStore, err := flatfs.New(cfg.Dir, flatfs.NextToLast(2), false)
 	if err != nil {
 		return nil, fmt.Errorf("creating pdp object store: %w", err)
 	}
+	stashMgr, err := stash.New(stashCfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating stash: %w", err)
+	}
+	packStore, packIndex, err := OpenPackStore(cfg.Pack, objStore)
+	if err != nil {
+		return nil, err
+	}
+	var bs *blobstore.Store
+	if packStore != nil {
+		bs = blobstore.NewPackStore(packStore, blobstore.WithStash(stashMgr))
+	} else {
+		bs = blobstore.NewFlatfsStore(objStore, blobstore.WithStash(stashMgr))
+	}
+	if _, err := stashMgr.Recover(context.Background(), bs.Resume); err != nil {
+		return nil, fmt.Errorf("recovering stash: %w", err)
+	}
+	if packStore == nil {
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return errors.Join(stashMgr.Close(), objStore.Close())
+			},
+		})
+		return bs, nil
+	}
+
+	compactor := pack.NewCompactor(packStore, cfg.Pack.CompactInterval)
 	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			compactor.Start()
+			return nil
+		},
 		OnStop: func(ctx context.Context) error {
-			return objStore.Close()
+			return errors.Join(
+				compactor.Stop(ctx),
+				stashMgr.Close(),
+				packStore.Close(),
+				packIndex.Close(),
+				objStore.Close(),
+			)
 		},
 	})
-	return blobstore.NewFlatfsStore(objStore), nil
+	return bs, nil
+}
+
+// OpenPackStore opens the pack store over the flatfs store of the blobs when
+// packing is enabled, or when blobs were packed before it was disabled so
+// they stay readable. It returns a nil store otherwise. The caller closes the
+// store and then its index.
+func OpenPackStore(cfg app.PackConfig, objStore *flatfs.Store) (*pack.Store, datastore.Batching, error) {
+	if cfg.Dir == "" {
+		return nil, nil, nil
+	}
+	if !cfg.Enabled {
+		if _, err := os.Stat(cfg.Dir); err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil, nil
+			}
+			return nil, nil, fmt.Errorf("reading pack directory: %w", err)
+		}
+	}
+	index, err := newDs(filepath.Join(cfg.Dir, "index"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating pack index: %w", err)
+	}
+	threshold := cfg.Threshold
+	if threshold == 0 {
+		threshold = pack.DefaultThreshold
+	}
+	if !cfg.Enabled {
+		// keep serving packed blobs, write new ones to flatfs
+		threshold = 0
+	}
+	opts := []pack.Option{pack.WithThreshold(threshold)}
+	if cfg.MaxPackSize != 0 {
+		opts = append(opts, pack.WithMaxPackSize(cfg.MaxPackSize))
+	}
+	packStore, err := pack.New(cfg.Dir, objStore, index, opts...)
+	if err != nil {
+		index.Close()
+		return nil, nil, fmt.Errorf("creating pack store: %w", err)
+	}
+	return packStore, index, nil
 }
 
-func NewConsolidationStore(cfg app.ConsolidationStorageConfig, lc fx.Lifecycle) (consolidationstore.Store, error) {
+func NewConsolidationStore(cfg app.ConsolidationStorageConfig, dss *Datastores, lc fx.Lifecycle) (consolidationstore.Store, error) {
 	if cfg.Dir == "" {
 		return nil, fmt.Errorf("no data dir provided for consolidation store")
 	}
 
-	ds, err := newDs(cfg.Dir)
+	ds, err := dss.Open(cfg.Dir)
 	if err != nil {
 		return nil, fmt.Errorf("creating consolidation store: %w", err)
 	}
@@ -323,6 +623,290 @@ func NewConsolidationStore(cfg app.ConsolidationStorageConfig, lc fx.Lifecycle)
 	return consolidationstore.NewDatastoreStore(ds), nil
 }
 
+func NewQuotaDatastore(cfg app.QuotaStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for quota store")
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating quota store: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	return ds, nil
+}
+
+func NewPieceLogDatastore(cfg app.PieceLogStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for piece log")
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating piece log: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	return ds, nil
+}
+
+func NewScrubberDatastore(cfg app.ScrubberStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for scrubber store")
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating scrubber store: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	return ds, nil
+}
+
+func NewStorageClassDatastore(cfg app.StorageClassStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for storage class store")
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating storage class store: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	return ds, nil
+}
+
+func NewCollectorDatastore(cfg app.CollectorStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for collector store")
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating collector store: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	return ds, nil
+}
+
+func NewReplicaPolicyDatastore(cfg app.ReplicaPolicyStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for replica policy store")
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating replica policy store: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	return ds, nil
+}
+
+func NewContentIndexDatastore(cfg app.ContentIndexStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for content index store")
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating content index store: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	return ds, nil
+}
+
+func NewBlocklistDatastore(cfg app.BlocklistStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for blocklist store")
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating blocklist store: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	return ds, nil
+}
+
+func NewBackfillDatastore(cfg app.BackfillStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for backfill store")
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating backfill store: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	return ds, nil
+}
+
+func NewReaperDatastore(cfg app.ReaperStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for reaper store")
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating reaper store: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	return ds, nil
+}
+
+func NewIPNIBatchDatastore(cfg app.IPNIBatchStorageConfig, dss *Datastores, lc fx.Lifecycle) (datastore.Datastore, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("no data dir provided for IPNI batch store")
+	}
+
+	ds, err := dss.Open(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("creating IPNI batch store: %w", err)
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return ds.Close()
+		},
+	})
+
+	return ds, nil
+}
+
+// UnifiedStoreDirs are the directories, relative to the data directory, of
+// the stores kept in a single database with the sqlite datastore backend.
+// The key store stays in its own LevelDB database, which the wallet commands
+// open directly.
+var UnifiedStoreDirs = []string{
+	filepath.Join("aggregator", "datastore"),
+	"allocation",
+	"acceptance",
+	"claim",
+	"publisher",
+	"receipt",
+	"consolidation",
+	"quota",
+	"piecelog",
+	"scrubber",
+	"storageclass",
+	"collector",
+	"replicapolicy",
+	"contentindex",
+	"blocklist",
+	"backfill",
+	"reaper",
+	"ipnibatch",
+}
+
+// Datastores opens the datastores of the local stores. With the leveldb
+// backend each store has a database in its own directory, with the sqlite
+// backend each store is a namespace of a single database, named after its
+// directory relative to the data directory.
+type Datastores struct {
+	dataDir string
+	shared  *sqliteds.Datastore
+}
+
+func NewDatastores(cfg app.StorageConfig, lc fx.Lifecycle) (*Datastores, error) {
+	dss := &Datastores{dataDir: cfg.DataDir}
+	if cfg.Datastore.Backend != app.DatastoreBackendSQLite {
+		return dss, nil
+	}
+
+	shared, err := sqliteds.New(cfg.Datastore.Path)
+	if err != nil {
+		return nil, fmt.Errorf("opening datastore at %s: %w", cfg.Datastore.Path, err)
+	}
+	// stores depend on the datastores, so their stop hooks run first
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return shared.Close()
+		},
+	})
+	dss.shared = shared
+	return dss, nil
+}
+
+// Open opens the datastore of the store in dir.
+func (d *Datastores) Open(dir string) (datastore.Batching, error) {
+	if d.shared == nil {
+		ds, err := newDs(dir)
+		if err != nil {
+			return nil, err
+		}
+		return ds, nil
+	}
+	rel, err := filepath.Rel(d.dataDir, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("store directory %s is not in the data directory %s", dir, d.dataDir)
+	}
+	return unclosable{namespace.Wrap(d.shared, StoreNamespace(rel))}, nil
+}
+
+// StoreNamespace returns the namespace, in the shared SQLite datastore, of
+// the store in the directory rel relative to the data directory.
+func StoreNamespace(rel string) datastore.Key {
+	return datastore.NewKey(filepath.ToSlash(rel))
+}
+
+// unclosable is a namespace of the shared datastore, which is closed once
+// all stores have stopped.
+type unclosable struct {
+	datastore.Batching
+}
+
+func (unclosable) Close() error {
+	return nil
+}
+
 func newDs(path string) (*leveldb.Datastore, error) {
 	dirPath, err := mkdirp(path)
 	if err != nil {
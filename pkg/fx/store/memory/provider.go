@@ -42,10 +42,59 @@ var Module = fx.Module("memory-store",
 		NewAllocationStore,
 		NewAcceptanceStore,
 		NewClaimStore,
-		NewReceiptStore,
+		fx.Annotate(
+			NewReceiptStore,
+			fx.As(new(receiptstore.ReceiptStore)),
+			// the log of stored receipts allows them to be pruned
+			fx.As(new(receiptstore.Pruner)),
+		),
 		NewRetrievalJournal,
 		NewKeyStore,
 		NewConsolidationStore,
+		fx.Annotate(
+			NewQuotaDatastore,
+			fx.ResultTags(`name:"quota_datastore"`),
+		),
+		fx.Annotate(
+			NewPieceLogDatastore,
+			fx.ResultTags(`name:"piecelog_datastore"`),
+		),
+		fx.Annotate(
+			NewScrubberDatastore,
+			fx.ResultTags(`name:"scrubber_datastore"`),
+		),
+		fx.Annotate(
+			NewStorageClassDatastore,
+			fx.ResultTags(`name:"storageclass_datastore"`),
+		),
+		fx.Annotate(
+			NewCollectorDatastore,
+			fx.ResultTags(`name:"collector_datastore"`),
+		),
+		fx.Annotate(
+			NewReplicaPolicyDatastore,
+			fx.ResultTags(`name:"replicapolicy_datastore"`),
+		),
+		fx.Annotate(
+			NewContentIndexDatastore,
+			fx.ResultTags(`name:"contentindex_datastore"`),
+		),
+		fx.Annotate(
+			NewBlocklistDatastore,
+			fx.ResultTags(`name:"blocklist_datastore"`),
+		),
+		fx.Annotate(
+			NewBackfillDatastore,
+			fx.ResultTags(`name:"backfill_datastore"`),
+		),
+		fx.Annotate(
+			NewReaperDatastore,
+			fx.ResultTags(`name:"reaper_datastore"`),
+		),
+		fx.Annotate(
+			NewIPNIBatchDatastore,
+			fx.ResultTags(`name:"ipni_batch_datastore"`),
+		),
 		fx.Annotate(
 			NewPDPStore,
 			fx.As(fx.Self()),
@@ -78,7 +127,7 @@ func NewPublisherStore() store.FullStore {
 	return store.FromDatastore(ds, store.WithMetadataContext(metadata.MetadataContext))
 }
 
-func NewReceiptStore() receiptstore.ReceiptStore {
+func NewReceiptStore() *receiptstore.Store {
 	ds := sync.MutexWrap(datastore.NewMapDatastore())
 	return receiptstore.NewDatastoreStore(ds)
 }
@@ -113,3 +162,47 @@ func NewConsolidationStore() consolidationstore.Store {
 	ds := sync.MutexWrap(datastore.NewMapDatastore())
 	return consolidationstore.NewDatastoreStore(ds)
 }
+
+func NewQuotaDatastore() datastore.Datastore {
+	return sync.MutexWrap(datastore.NewMapDatastore())
+}
+
+func NewPieceLogDatastore() datastore.Datastore {
+	return sync.MutexWrap(datastore.NewMapDatastore())
+}
+
+func NewScrubberDatastore() datastore.Datastore {
+	return sync.MutexWrap(datastore.NewMapDatastore())
+}
+
+func NewStorageClassDatastore() datastore.Datastore {
+	return sync.MutexWrap(datastore.NewMapDatastore())
+}
+
+func NewCollectorDatastore() datastore.Datastore {
+	return sync.MutexWrap(datastore.NewMapDatastore())
+}
+
+func NewReplicaPolicyDatastore() datastore.Datastore {
+	return sync.MutexWrap(datastore.NewMapDatastore())
+}
+
+func NewContentIndexDatastore() datastore.Datastore {
+	return sync.MutexWrap(datastore.NewMapDatastore())
+}
+
+func NewBlocklistDatastore() datastore.Datastore {
+	return sync.MutexWrap(datastore.NewMapDatastore())
+}
+
+func NewBackfillDatastore() datastore.Datastore {
+	return sync.MutexWrap(datastore.NewMapDatastore())
+}
+
+func NewReaperDatastore() datastore.Datastore {
+	return sync.MutexWrap(datastore.NewMapDatastore())
+}
+
+func NewIPNIBatchDatastore() datastore.Datastore {
+	return sync.MutexWrap(datastore.NewMapDatastore())
+}
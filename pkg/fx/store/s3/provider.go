@@ -90,7 +90,7 @@ func NewStores(cfg app.StorageConfig) (*Stores, error) {
 	if stores.Receipts, err = minio_store.New(endpoint, prefix+"receipts", options); err != nil {
 		return nil, fmt.Errorf("creating receipts s3 store: %w", err)
 	}
-	if stores.PDP, err = minio_store.New(endpoint, prefix+"pdp", options); err != nil {
+	if stores.PDP, err = minio_store.New(endpoint, prefix+"pdp", options, minio_store.WithPartSize(cfg.S3.PartSize)); err != nil {
 		return nil, fmt.Errorf("creating pdp s3 store: %w", err)
 	}
 	if stores.Consolidation, err = minio_store.New(endpoint, prefix+"consolidation", options); err != nil {
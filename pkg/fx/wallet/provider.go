@@ -12,28 +12,45 @@ import (
 )
 
 var Module = fx.Module("wallet",
-	fx.Provide(
-		fx.Annotate(
-			NewWallet,
-			fx.As(fx.Self()),
-			fx.As(new(wallet.Wallet)),
-		),
-	),
+	fx.Provide(NewWallet),
 	fx.Invoke(InitializeWallet),
 )
 
-func NewWallet(ks keystore.KeyStore) (*wallet.LocalWallet, error) {
-	return wallet.NewWallet(ks)
+type WalletParams struct {
+	fx.In
+
+	// not provided by the in-memory stores, which always use the local backend
+	Config   app.KeyStoreConfig `optional:"true"`
+	KeyStore keystore.KeyStore
+}
+
+// NewWallet creates the wallet for the configured key store backend.
+func NewWallet(params WalletParams) (wallet.Wallet, error) {
+	switch params.Config.Backend {
+	case app.KeyStoreBackendAWSKMS:
+		return wallet.NewKMSWalletFromConfig(context.Background(), params.Config.AWSKMS)
+	case app.KeyStoreBackendGCPKMS:
+		return wallet.NewGCPKMSWalletFromConfig(context.Background(), params.Config.GCPKMS)
+	default:
+		return wallet.NewWallet(params.KeyStore)
+	}
 }
 
-func InitializeWallet(lc fx.Lifecycle, cfg app.PDPServiceConfig, wlt *wallet.LocalWallet) {
+func InitializeWallet(lc fx.Lifecycle, cfg app.PDPServiceConfig, wlt wallet.Wallet) {
 	addr := cfg.OwnerAddress
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			if has, err := wlt.Has(ctx, addr); err != nil {
-				return fmt.Errorf("failed to read wallet for address %s: %w", addr, err)
-			} else if !has {
-				return fmt.Errorf("wallet for address %s not found, please import with 'piri wallet import ...'", addr)
+			switch w := wlt.(type) {
+			case *wallet.LocalWallet:
+				if has, err := w.Has(ctx, addr); err != nil {
+					return fmt.Errorf("failed to read wallet for address %s: %w", addr, err)
+				} else if !has {
+					return fmt.Errorf("wallet for address %s not found, please import with 'piri wallet import ...'", addr)
+				}
+			case *wallet.KMSWallet:
+				if w.Address() != addr {
+					return fmt.Errorf("KMS key has address %s, which does not match owner address %s", w.Address(), addr)
+				}
 			}
 			return nil
 		},
@@ -1,8 +1,11 @@
 package health
 
 import (
+	"context"
+
 	"go.uber.org/fx"
 
+	"github.com/storacha/piri/pkg/config/app"
 	echofx "github.com/storacha/piri/pkg/fx/echo"
 )
 
@@ -10,16 +13,38 @@ import (
 type CheckerParams struct {
 	fx.In
 
-	Mode ServerMode `optional:"true"`
+	Lifecycle fx.Lifecycle
+	Mode      ServerMode       `optional:"true"`
+	Config    app.ServerConfig `optional:"true"`
+	Providers []CheckProvider  `group:"health_checks"`
+	// Probes are the dependencies failing the readiness check while they are
+	// unavailable.
+	Probes []Probe `group:"health_probes"`
 }
 
-// NewCheckerFromParams creates a new Checker from fx parameters
+// NewCheckerFromParams creates a new Checker from fx parameters, probing the
+// dependencies of the node while it is started.
 func NewCheckerFromParams(params CheckerParams) *Checker {
 	mode := params.Mode
 	if mode == "" {
 		mode = ModeFull // Default to full mode for backwards compatibility
 	}
-	return NewChecker(mode)
+	c := NewChecker(mode, params.Providers...)
+	c.drainDelay = params.Config.Health.DrainDelay
+	if len(params.Probes) == 0 {
+		return c
+	}
+	c.prober = NewProber(params.Config.Health.ProbeInterval, params.Config.Health.ProbeTimeout, params.Probes...)
+	params.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			c.prober.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return c.prober.Stop(ctx)
+		},
+	})
+	return c
 }
 
 // Module provides health check functionality
@@ -31,5 +56,11 @@ var Module = fx.Module("health",
 			fx.As(new(echofx.RouteRegistrar)),
 			fx.ResultTags(`group:"route_registrar"`),
 		),
+		// readiness checks fail before the server shuts down
+		fx.Annotate(
+			func(c *Checker) *Checker { return c },
+			fx.As(new(echofx.Drainer)),
+			fx.ResultTags(`group:"drainers"`),
+		),
 	),
 )
@@ -1,6 +1,7 @@
 package health
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -23,6 +24,9 @@ type Status string
 const (
 	StatusOK     Status = "ok"
 	StatusFailed Status = "failed"
+	// StatusPaused is reported by checks of components paused by an operator.
+	// It does not fail the overall health check.
+	StatusPaused Status = "paused"
 )
 
 // Response represents a health check response
@@ -38,20 +42,35 @@ type Response struct {
 type Check struct {
 	Name   string `json:"name"`
 	Status Status `json:"status"`
+	// Error is why a dependency probe failed.
+	Error string `json:"error,omitempty"`
+}
+
+// CheckProvider provides additional checks reported by the health check
+type CheckProvider interface {
+	Checks() []Check
 }
 
 // Checker provides health check functionality
 type Checker struct {
-	mode  ServerMode
-	mu    sync.RWMutex
-	ready bool
+	mode      ServerMode
+	mu        sync.RWMutex
+	ready     bool
+	draining  bool
+	providers []CheckProvider
+	// prober probes the dependencies of the node, nil if none are probed
+	prober *Prober
+	// drainDelay is how long Drain waits for load balancers to notice the
+	// node is draining
+	drainDelay time.Duration
 }
 
 // NewChecker creates a new health checker
-func NewChecker(mode ServerMode) *Checker {
+func NewChecker(mode ServerMode, providers ...CheckProvider) *Checker {
 	return &Checker{
-		mode:  mode,
-		ready: mode != ModeInit, // Ready by default except in init mode
+		mode:      mode,
+		ready:     mode != ModeInit, // Ready by default except in init mode
+		providers: providers,
 	}
 }
 
@@ -74,6 +93,30 @@ func (c *Checker) IsReady() bool {
 	return c.ready
 }
 
+// Drain fails readiness checks from now on, so load balancers stop routing
+// requests to the node before it shuts down, and waits for the drain delay
+// or for ctx to be done.
+func (c *Checker) Drain(ctx context.Context) {
+	c.mu.Lock()
+	c.draining = true
+	c.mu.Unlock()
+	if c.drainDelay <= 0 {
+		return
+	}
+	log.Infow("draining, failing readiness checks before shutting down", "delay", c.drainDelay)
+	select {
+	case <-time.After(c.drainDelay):
+	case <-ctx.Done():
+	}
+}
+
+// IsDraining returns whether the node is draining.
+func (c *Checker) IsDraining() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.draining
+}
+
 // LivenessCheck performs a liveness check
 func (c *Checker) LivenessCheck() Response {
 	return Response{
@@ -83,18 +126,34 @@ func (c *Checker) LivenessCheck() Response {
 	}
 }
 
-// ReadinessCheck performs a readiness check
+// ReadinessCheck performs a readiness check. The node is not ready while it
+// is draining or while a probe of its dependencies fails.
 func (c *Checker) ReadinessCheck() Response {
 	status := StatusOK
 	if !c.IsReady() {
 		status = StatusFailed
 	}
 
+	var checks []Check
+	if c.prober != nil {
+		for _, check := range c.prober.Checks() {
+			if check.Status == StatusFailed {
+				status = StatusFailed
+			}
+			checks = append(checks, check)
+		}
+	}
+	if c.IsDraining() {
+		status = StatusFailed
+		checks = append(checks, Check{Name: "draining", Status: StatusFailed})
+	}
+
 	return Response{
 		Status:    status,
 		Timestamp: time.Now().UTC(),
 		Version:   build.Version,
 		Mode:      string(c.mode),
+		Checks:    checks,
 	}
 }
 
@@ -108,14 +167,25 @@ func (c *Checker) HealthCheck() Response {
 		status = StatusFailed
 	}
 
+	checks := []Check{
+		{Name: "liveness", Status: liveness.Status},
+		{Name: "readiness", Status: readiness.Status},
+	}
+	checks = append(checks, readiness.Checks...)
+	for _, p := range c.providers {
+		for _, check := range p.Checks() {
+			if check.Status == StatusFailed {
+				status = StatusFailed
+			}
+			checks = append(checks, check)
+		}
+	}
+
 	return Response{
 		Status:    status,
 		Timestamp: time.Now().UTC(),
 		Version:   build.Version,
 		Mode:      string(c.mode),
-		Checks: []Check{
-			{Name: "liveness", Status: liveness.Status},
-			{Name: "readiness", Status: readiness.Status},
-		},
+		Checks:    checks,
 	}
 }
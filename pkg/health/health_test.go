@@ -83,3 +83,20 @@ func TestChecker_HealthCheck_NotHealthy(t *testing.T) {
 	assert.Equal(t, "readiness", resp.Checks[1].Name)
 	assert.Equal(t, StatusFailed, resp.Checks[1].Status)
 }
+
+type staticChecks []Check
+
+func (s staticChecks) Checks() []Check { return s }
+
+func TestChecker_HealthCheck_Providers(t *testing.T) {
+	c := NewChecker(ModeFull, staticChecks{{Name: "subsystem:proving", Status: StatusPaused}})
+
+	resp := c.HealthCheck()
+	assert.Equal(t, StatusOK, resp.Status, "paused checks should not fail the health check")
+	assert.Len(t, resp.Checks, 3)
+	assert.Equal(t, "subsystem:proving", resp.Checks[2].Name)
+	assert.Equal(t, StatusPaused, resp.Checks[2].Status)
+
+	c = NewChecker(ModeFull, staticChecks{{Name: "broken", Status: StatusFailed}})
+	assert.Equal(t, StatusFailed, c.HealthCheck().Status)
+}
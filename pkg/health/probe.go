@@ -0,0 +1,163 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+var log = logging.Logger("health")
+
+// Defaults for probing the dependencies of the node.
+const (
+	DefaultProbeInterval = 15 * time.Second
+	DefaultProbeTimeout  = 5 * time.Second
+)
+
+// failedProbeRetry is how soon probes are run again after one failed, so the
+// node is ready soon after its dependencies recover, e.g. while it starts.
+const failedProbeRetry = time.Second
+
+var errNotProbed = errors.New("not probed yet")
+
+// Probe verifies a dependency the node cannot serve requests without, e.g.
+// that the blob store is writable.
+type Probe interface {
+	Name() string
+	// Probe returns an error if the dependency is unavailable.
+	Probe(ctx context.Context) error
+}
+
+type probeFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (p probeFunc) Name() string                    { return p.name }
+func (p probeFunc) Probe(ctx context.Context) error { return p.fn(ctx) }
+
+// NewProbe creates a Probe named name calling fn.
+func NewProbe(name string, fn func(ctx context.Context) error) Probe {
+	return probeFunc{name: name, fn: fn}
+}
+
+// Prober runs probes in the background every interval, so readiness checks
+// report their last results without waiting on the dependencies.
+type Prober struct {
+	probes   []Probe
+	interval time.Duration
+	timeout  time.Duration
+
+	mu      sync.RWMutex
+	results []Check
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewProber creates a Prober running the probes every interval, each timing
+// out after timeout. Zero durations use the defaults.
+func NewProber(interval, timeout time.Duration, probes ...Probe) *Prober {
+	if interval <= 0 {
+		interval = DefaultProbeInterval
+	}
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+	results := make([]Check, 0, len(probes))
+	for _, p := range probes {
+		results = append(results, Check{Name: p.Name(), Status: StatusFailed, Error: errNotProbed.Error()})
+	}
+	return &Prober{probes: probes, interval: interval, timeout: timeout, results: results}
+}
+
+// Start runs the probes in the background, right away and every interval,
+// or sooner while a probe fails.
+func (p *Prober) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		for {
+			next := p.interval
+			if !p.Run(ctx) {
+				next = min(next, failedProbeRetry)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(next):
+			}
+		}
+	}()
+}
+
+// Stop stops running the probes, waiting for running probes to be
+// cancelled.
+func (p *Prober) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run runs every probe concurrently and records their results, returning
+// whether they all succeeded.
+func (p *Prober) Run(ctx context.Context) bool {
+	results := make([]Check, len(p.probes))
+	var wg sync.WaitGroup
+	for i, probe := range p.probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, p.timeout)
+			defer cancel()
+			results[i] = Check{Name: probe.Name(), Status: StatusOK}
+			if err := probe.Probe(probeCtx); err != nil {
+				results[i].Status = StatusFailed
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		// the node is stopping, keep the results of the last complete run
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ok := true
+	for i, r := range results {
+		if r.Status == StatusFailed {
+			ok = false
+		}
+		prev := p.results[i]
+		switch {
+		case r.Status == StatusFailed && prev.Status == StatusOK:
+			log.Warnw("dependency probe failed", "probe", r.Name, "error", r.Error)
+		case r.Status == StatusOK && prev.Status == StatusFailed && prev.Error != errNotProbed.Error():
+			log.Infow("dependency probe recovered", "probe", r.Name)
+		}
+	}
+	p.results = results
+	return ok
+}
+
+// Checks returns the results of the last run of the probes. Probes not run
+// yet are reported failed.
+func (p *Prober) Checks() []Check {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]Check(nil), p.results...)
+}
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProber(t *testing.T) {
+	var rpcErr error
+	p := NewProber(time.Hour, time.Second,
+		NewProbe("datastore", func(context.Context) error { return nil }),
+		NewProbe("eth_rpc", func(context.Context) error { return rpcErr }),
+	)
+
+	checks := p.Checks()
+	require.Len(t, checks, 2)
+	for _, check := range checks {
+		assert.Equal(t, StatusFailed, check.Status, "probes not run yet should fail")
+	}
+
+	require.True(t, p.Run(t.Context()))
+	assert.Equal(t, []Check{
+		{Name: "datastore", Status: StatusOK},
+		{Name: "eth_rpc", Status: StatusOK},
+	}, p.Checks())
+
+	rpcErr = errors.New("connection refused")
+	require.False(t, p.Run(t.Context()))
+	assert.Equal(t, []Check{
+		{Name: "datastore", Status: StatusOK},
+		{Name: "eth_rpc", Status: StatusFailed, Error: "connection refused"},
+	}, p.Checks())
+}
+
+func TestProber_Timeout(t *testing.T) {
+	p := NewProber(time.Hour, 10*time.Millisecond, NewProbe("hung", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	require.False(t, p.Run(t.Context()))
+	checks := p.Checks()
+	require.Len(t, checks, 1)
+	assert.Equal(t, StatusFailed, checks[0].Status)
+	assert.Contains(t, checks[0].Error, "deadline exceeded")
+}
+
+func TestChecker_ReadinessCheck_Probes(t *testing.T) {
+	var blobErr error
+	c := NewChecker(ModeFull)
+	c.prober = NewProber(time.Hour, time.Second, NewProbe("blobstore", func(context.Context) error { return blobErr }))
+	c.prober.Run(t.Context())
+
+	resp := c.ReadinessCheck()
+	assert.Equal(t, StatusOK, resp.Status)
+	assert.Equal(t, []Check{{Name: "blobstore", Status: StatusOK}}, resp.Checks)
+
+	blobErr = errors.New("read-only file system")
+	c.prober.Run(t.Context())
+	resp = c.ReadinessCheck()
+	assert.Equal(t, StatusFailed, resp.Status)
+
+	health := c.HealthCheck()
+	assert.Equal(t, StatusFailed, health.Status)
+	assert.Contains(t, health.Checks, Check{Name: "blobstore", Status: StatusFailed, Error: "read-only file system"})
+
+	// liveness does not depend on the dependencies
+	assert.Equal(t, StatusOK, c.LivenessCheck().Status)
+}
+
+func TestChecker_Drain(t *testing.T) {
+	c := NewChecker(ModeFull)
+	require.Equal(t, StatusOK, c.ReadinessCheck().Status)
+
+	c.drainDelay = 20 * time.Millisecond
+	start := time.Now()
+	c.Drain(t.Context())
+	assert.GreaterOrEqual(t, time.Since(start), c.drainDelay)
+
+	assert.True(t, c.IsDraining())
+	resp := c.ReadinessCheck()
+	assert.Equal(t, StatusFailed, resp.Status)
+	assert.Contains(t, resp.Checks, Check{Name: "draining", Status: StatusFailed})
+	assert.Equal(t, StatusOK, c.LivenessCheck().Status)
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		c := NewChecker(ModeFull)
+		c.drainDelay = time.Hour
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		c.Drain(ctx)
+		assert.True(t, c.IsDraining())
+	})
+}
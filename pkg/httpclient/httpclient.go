@@ -0,0 +1,418 @@
+// Package httpclient provides the HTTP client of the requests the node makes
+// to other services: curio and PDP APIs, the indexing and upload services and
+// the nodes blobs are replicated to.
+//
+// Failed requests are retried with exponential backoff, as long as retrying
+// them is safe: requests that may have been processed by the server are only
+// retried if they are idempotent, and requests whose body cannot be replayed
+// are never retried. Hosts failing too many requests in a row have their
+// circuit opened, and requests to them fail right away until the circuit
+// cools down. Requests, retries and circuit state are reported per client and
+// destination host, and each request is sent in a span whose trace context is
+// propagated to the server.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/storacha/go-ucanto/client"
+	ucanhttp "github.com/storacha/go-ucanto/transport/http"
+	"github.com/storacha/go-ucanto/ucan"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/storacha/piri/lib/telemetry/traces"
+)
+
+var log = logging.Logger("httpclient")
+
+const (
+	DefaultMaxRetries       = 3
+	DefaultMinBackoff       = 500 * time.Millisecond
+	DefaultMaxBackoff       = 10 * time.Second
+	DefaultBreakerThreshold = 10
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// DefaultPolicy is the policy of clients not configured by the node, e.g. in
+// the CLI.
+var DefaultPolicy = Policy{
+	MaxRetries:       DefaultMaxRetries,
+	MinBackoff:       DefaultMinBackoff,
+	MaxBackoff:       DefaultMaxBackoff,
+	BreakerThreshold: DefaultBreakerThreshold,
+	BreakerCooldown:  DefaultBreakerCooldown,
+}
+
+// ErrCircuitOpen is returned for requests to a host whose circuit is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// Policy configures the retries and circuit breaking of a client.
+type Policy struct {
+	// MaxRetries is the number of times a failed request is retried. Zero
+	// disables retries.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubled for each further
+	// retry up to MaxBackoff. A Retry-After header sent by the server is
+	// honoured up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// BreakerThreshold is the number of consecutive failed requests that opens
+	// the circuit of a host. Zero disables circuit breaking.
+	BreakerThreshold int
+	// BreakerCooldown is how long the circuit of a host stays open before a
+	// request is let through to probe it.
+	BreakerCooldown time.Duration
+}
+
+// New creates an HTTP client applying the policy to its requests. The name
+// identifies the client in metrics and logs.
+func New(name string, policy Policy) *http.Client {
+	return &http.Client{Transport: NewTransport(name, http.DefaultTransport, policy)}
+}
+
+// NewConnection creates a UCAN connection to the service at url, whose
+// requests are made by the client.
+func NewConnection(id ucan.Principal, url *url.URL, c *http.Client) (client.Connection, error) {
+	return client.NewConnection(id, ucanhttp.NewChannel(url, ucanhttp.WithClient(c)))
+}
+
+// Transport is an [http.RoundTripper] retrying failed requests and breaking
+// the circuit of failing hosts.
+type Transport struct {
+	name    string
+	base    http.RoundTripper
+	policy  Policy
+	metrics *metrics
+	now     func() time.Time
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// NewTransport creates a transport sending requests with base according to
+// the policy.
+func NewTransport(name string, base http.RoundTripper, policy Policy) *Transport {
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = DefaultMinBackoff
+	}
+	if policy.MaxBackoff < policy.MinBackoff {
+		policy.MaxBackoff = policy.MinBackoff
+	}
+	if policy.BreakerCooldown <= 0 {
+		policy.BreakerCooldown = DefaultBreakerCooldown
+	}
+	return &Transport{
+		name:     name,
+		base:     base,
+		policy:   policy,
+		metrics:  metricsFor(),
+		now:      time.Now,
+		breakers: map[string]*breaker{},
+	}
+}
+
+// RoundTrip sends the request in a client span, propagating its trace context
+// to the server in the traceparent header.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.client", t.name),
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+		),
+	)
+	defer span.End()
+
+	// the request of the caller must not be modified
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := t.roundTrip(req)
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	default:
+		span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+		if res.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, res.Status)
+		}
+	}
+	return res, err
+}
+
+func (t *Transport) roundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Host
+	b := t.breakerFor(host)
+
+	for attempt := 0; ; attempt++ {
+		if err := b.allow(ctx); err != nil {
+			t.metrics.request(ctx, t.name, host, "circuit_open", 0)
+			return nil, fmt.Errorf("%w: %s", err, host)
+		}
+
+		r := req
+		if attempt > 0 {
+			var err error
+			if r, err = rewind(req); err != nil {
+				b.release(ctx)
+				return nil, fmt.Errorf("rewinding request body: %w", err)
+			}
+		}
+		start := t.now()
+		res, err := t.base.RoundTrip(r)
+		t.metrics.request(ctx, t.name, host, result(res, err), t.now().Sub(start))
+		if err != nil && ctx.Err() != nil {
+			// the caller gave up, the host did not fail
+			b.release(ctx)
+			return nil, err
+		}
+		b.done(ctx, failed(res, err))
+
+		if attempt >= t.policy.MaxRetries || !retryable(req, res, err) {
+			return res, err
+		}
+		wait := t.backoff(attempt, res)
+		if res != nil {
+			io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+			res.Body.Close()
+		}
+		traces.Logger(ctx, log).Debugw("retrying request", "client", t.name, "method", req.Method, "host", host, "attempt", attempt+1, "wait", wait, "status", result(res, err), "error", err)
+		t.metrics.retry(ctx, t.name, host)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *Transport) breakerFor(host string) *breaker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.breakers[host]
+	if !ok {
+		b = &breaker{
+			name:      t.name,
+			host:      host,
+			threshold: t.policy.BreakerThreshold,
+			cooldown:  t.policy.BreakerCooldown,
+			now:       t.now,
+			metrics:   t.metrics,
+		}
+		t.breakers[host] = b
+	}
+	return b
+}
+
+// backoff returns the delay before retrying a request failed for the given
+// attempt, honouring the Retry-After header of the response if any.
+func (t *Transport) backoff(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if d, ok := retryAfter(res.Header.Get("Retry-After"), t.now()); ok {
+			return min(d, t.policy.MaxBackoff)
+		}
+	}
+	d := t.policy.MaxBackoff
+	if attempt < 32 {
+		d = min(t.policy.MinBackoff<<attempt, t.policy.MaxBackoff)
+	}
+	// jitter the delay between half and all of it, so clients failed by the
+	// same outage do not retry in lockstep
+	return d/2 + rand.N(d/2+1)
+}
+
+func retryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0), true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// retryable reports whether the failed request can safely be retried.
+func retryable(req *http.Request, res *http.Response, err error) bool {
+	if req.Context().Err() != nil || !replayable(req) {
+		return false
+	}
+	if err != nil {
+		// the request may have reached the server, unless it could not connect
+		return idempotent(req) || dialFailed(err)
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		// the server did not process the request
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent(req)
+	}
+	return false
+}
+
+// failed reports whether the request counts as a failure of the host.
+func failed(res *http.Response, err error) bool {
+	return err != nil || res.StatusCode >= http.StatusInternalServerError
+}
+
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+func dialFailed(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// rewind returns a copy of the request with a fresh body to send it again.
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// result labels the outcome of a request in metrics.
+func result(res *http.Response, err error) string {
+	if err != nil {
+		return "error"
+	}
+	return strconv.Itoa(res.StatusCode/100) + "xx"
+}
+
+// breaker is the circuit breaker of a host.
+type breaker struct {
+	name      string
+	host      string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+	metrics   *metrics
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// allow returns [ErrCircuitOpen] if a request to the host must not be sent.
+// Once the circuit has cooled down, a single request is let through to probe
+// the host.
+func (b *breaker) allow(ctx context.Context) error {
+	if b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.set(ctx, circuitHalfOpen)
+	case circuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+	}
+	b.probing = b.state == circuitHalfOpen
+	return nil
+}
+
+// done records the outcome of an allowed request.
+func (b *breaker) done(ctx context.Context, failed bool) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		if b.state != circuitClosed {
+			log.Infow("circuit closed", "client", b.name, "host", b.host)
+			b.set(ctx, circuitClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		if b.state == circuitClosed {
+			log.Warnw("circuit opened", "client", b.name, "host", b.host, "failures", b.failures, "cooldown", b.cooldown)
+		}
+		b.openedAt = b.now()
+		b.set(ctx, circuitOpen)
+	}
+}
+
+// release lets another request probe the host after an allowed request was
+// abandoned without an outcome.
+func (b *breaker) release(context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) set(ctx context.Context, state circuitState) {
+	b.state = state
+	b.metrics.circuit(ctx, b.name, b.host, int64(state))
+}
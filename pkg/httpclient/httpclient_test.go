@@ -0,0 +1,177 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/storacha/piri/lib/telemetry/traces"
+)
+
+var testPolicy = Policy{
+	MaxRetries:       2,
+	MinBackoff:       time.Millisecond,
+	MaxBackoff:       5 * time.Millisecond,
+	BreakerThreshold: 3,
+	BreakerCooldown:  time.Hour,
+}
+
+// failing serves the given status to the first n requests, then 200.
+func failing(t *testing.T, n int32, status int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if calls.Add(1) <= n {
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetries(t *testing.T) {
+	t.Run("retries until the request succeeds", func(t *testing.T) {
+		srv, calls := failing(t, 2, http.StatusServiceUnavailable)
+		res, err := New("test", testPolicy).Get(srv.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("returns the last response once retries are exhausted", func(t *testing.T) {
+		srv, calls := failing(t, 10, http.StatusBadGateway)
+		res, err := New("test", testPolicy).Get(srv.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusBadGateway, res.StatusCode)
+		require.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		srv, calls := failing(t, 1, http.StatusNotFound)
+		res, err := New("test", testPolicy).Get(srv.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+		require.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("retries non-idempotent requests the server did not process", func(t *testing.T) {
+		srv, calls := failing(t, 1, http.StatusServiceUnavailable)
+		res, err := New("test", testPolicy).Post(srv.URL, "text/plain", bytes.NewReader([]byte("hello")))
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.EqualValues(t, 2, calls.Load())
+
+		srv, calls = failing(t, 1, http.StatusBadGateway)
+		res, err = New("test", testPolicy).Post(srv.URL, "text/plain", bytes.NewReader([]byte("hello")))
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusBadGateway, res.StatusCode)
+		require.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("does not retry requests whose body cannot be replayed", func(t *testing.T) {
+		srv, calls := failing(t, 1, http.StatusServiceUnavailable)
+		req, err := http.NewRequest(http.MethodPut, srv.URL, io.NopCloser(strings.NewReader("hello")))
+		require.NoError(t, err)
+		res, err := New("test", testPolicy).Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		require.EqualValues(t, 1, calls.Load())
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	srv, calls := failing(t, 100, http.StatusInternalServerError)
+	tr := NewTransport("test", http.DefaultTransport, Policy{BreakerThreshold: 3, BreakerCooldown: time.Minute})
+	now := time.Now()
+	tr.now = func() time.Time { return now }
+	c := &http.Client{Transport: tr}
+
+	for range 3 {
+		res, err := c.Get(srv.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+	}
+	_, err := c.Get(srv.URL)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.EqualValues(t, 3, calls.Load())
+
+	// a single request probes the host once the circuit cooled down
+	now = now.Add(time.Minute)
+	res, err := c.Get(srv.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.EqualValues(t, 4, calls.Load())
+	_, err = c.Get(srv.URL)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	// a successful probe closes the circuit
+	calls.Store(100)
+	now = now.Add(time.Minute)
+	for range 2 {
+		res, err := c.Get(srv.URL)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Now()
+	d, ok := retryAfter("3", now)
+	require.True(t, ok)
+	require.Equal(t, 3*time.Second, d)
+	d, ok = retryAfter(now.Add(time.Minute).UTC().Format(http.TimeFormat), now)
+	require.True(t, ok)
+	require.InDelta(t, time.Minute, d, float64(time.Second))
+	_, ok = retryAfter("soon", now)
+	require.False(t, ok)
+}
+
+func TestTracePropagation(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(traces.Propagator)
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	ctx := trace.ContextWithSpanContext(t.Context(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	res, err := New("test", testPolicy).Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	require.True(t, strings.HasPrefix(traceparent, "00-"+traceID.String()+"-"), "traceparent %q does not continue the trace", traceparent)
+	require.Empty(t, req.Header.Get("traceparent"), "request of the caller was modified")
+}
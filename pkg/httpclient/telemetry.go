@@ -0,0 +1,117 @@
+package httpclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/storacha/piri/lib/telemetry"
+)
+
+var tracer = otel.Tracer("github.com/storacha/piri/pkg/httpclient")
+
+// requestDurationBounds covers a quick API call up to a large blob upload.
+var requestDurationBounds = []float64{
+	(10 * time.Millisecond).Seconds(),
+	(50 * time.Millisecond).Seconds(),
+	(100 * time.Millisecond).Seconds(),
+	(500 * time.Millisecond).Seconds(),
+	(time.Second).Seconds(),
+	(5 * time.Second).Seconds(),
+	(10 * time.Second).Seconds(),
+	(30 * time.Second).Seconds(),
+	(time.Minute).Seconds(),
+	(5 * time.Minute).Seconds(),
+}
+
+type metrics struct {
+	requests *telemetry.Counter
+	retries  *telemetry.Counter
+	duration *telemetry.Timer
+	circuits *telemetry.Int64Gauge
+}
+
+// metricsFor returns the client metrics. It is nil if they could not be
+// created.
+var metricsFor = sync.OnceValue(func() *metrics {
+	m, err := newMetrics()
+	if err != nil {
+		log.Warnw("creating http client metrics", "error", err)
+		return nil
+	}
+	return m
+})
+
+func newMetrics() (*metrics, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/httpclient")
+	requests, err := telemetry.NewCounter(
+		meter,
+		"piri_http_client_requests",
+		"requests sent to other services, by result",
+		"",
+	)
+	if err != nil {
+		return nil, err
+	}
+	retries, err := telemetry.NewCounter(
+		meter,
+		"piri_http_client_retries",
+		"failed requests to other services that were retried",
+		"",
+	)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := telemetry.NewTimer(
+		meter,
+		"piri_http_client_request_duration",
+		"time until the response headers of a request to another service were received",
+		requestDurationBounds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	circuits, err := telemetry.NewInt64Gauge(
+		meter,
+		"piri_http_client_circuit_state",
+		"circuit of a host: 0 closed, 1 open, 2 half-open",
+		"",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &metrics{
+		requests: requests,
+		retries:  retries,
+		duration: duration,
+		circuits: circuits,
+	}, nil
+}
+
+func (m *metrics) request(ctx context.Context, client, host, result string, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	attrs := []attribute.KeyValue{attribute.String("client", client), attribute.String("host", host)}
+	m.requests.Inc(ctx, append(attrs, attribute.String("result", result))...)
+	if result != "circuit_open" {
+		m.duration.Record(ctx, elapsed, attrs...)
+	}
+}
+
+func (m *metrics) retry(ctx context.Context, client, host string) {
+	if m == nil {
+		return
+	}
+	m.retries.Inc(ctx, attribute.String("client", client), attribute.String("host", host))
+}
+
+func (m *metrics) circuit(ctx context.Context, client, host string, state int64) {
+	if m == nil {
+		return
+	}
+	m.circuits.Record(ctx, state, attribute.String("client", client), attribute.String("host", host))
+}
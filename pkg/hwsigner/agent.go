@@ -0,0 +1,100 @@
+package hwsigner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// KeyResponse is the response of the agent's GET /v1/key endpoint.
+type KeyResponse struct {
+	// PublicKey is the raw 32 byte ed25519 public key, base64 encoded.
+	PublicKey []byte `json:"public_key"`
+	// Attestation describes the environment holding the key, if the agent
+	// can attest to it.
+	Attestation *Attestation `json:"attestation,omitempty"`
+}
+
+// SignRequest is the body of the agent's POST /v1/sign endpoint.
+type SignRequest struct {
+	Message []byte `json:"message"`
+}
+
+// SignResponse is the response of the agent's POST /v1/sign endpoint.
+type SignResponse struct {
+	// Signature is the raw 64 byte ed25519 signature, base64 encoded.
+	Signature []byte `json:"signature"`
+}
+
+// Client talks to a signing agent over HTTP on a Unix socket.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient creates a client for the agent listening on socket.
+func NewClient(socket string) *Client {
+	return &Client{
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+// Key returns the public key held by the agent and its attestation.
+func (c *Client) Key(ctx context.Context) (KeyResponse, error) {
+	var res KeyResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/key", nil, &res); err != nil {
+		return KeyResponse{}, fmt.Errorf("getting agent key: %w", err)
+	}
+	return res, nil
+}
+
+// Sign signs msg with the key held by the agent.
+func (c *Client) Sign(ctx context.Context, msg []byte) ([]byte, error) {
+	var res SignResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/sign", SignRequest{Message: msg}, &res); err != nil {
+		return nil, fmt.Errorf("signing with agent: %w", err)
+	}
+	return res.Signature, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		r = bytes.NewReader(data)
+	}
+	// the host is ignored, requests are sent to the socket
+	req, err := http.NewRequestWithContext(ctx, method, "http://agent"+path, r)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("agent returned %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package hwsigner
+
+import (
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// AttestationFactKey is the key of the fact carrying the attestation in
+// location claims.
+const AttestationFactKey = "attestation"
+
+// Attestation is evidence, produced by the signing environment, that the
+// key is held in hardware. Piri does not interpret it, consumers verify it
+// against the format, e.g. a TPM2 quote or an Apple App Attest statement.
+type Attestation struct {
+	// Format names the kind of statement, e.g. "tpm2-quote".
+	Format string `json:"format"`
+	// Statement is the attestation statement, base64 encoded.
+	Statement []byte `json:"statement"`
+}
+
+// ToIPLD implements [ucan.FactBuilder]. The fact is
+// {"attestation": {"format": string, "statement": bytes}}.
+func (a Attestation) ToIPLD() (map[string]ipld.Node, error) {
+	n, err := qp.BuildMap(basicnode.Prototype.Map, 2, func(ma ipld.MapAssembler) {
+		qp.MapEntry(ma, "format", qp.String(a.Format))
+		qp.MapEntry(ma, "statement", qp.Bytes(a.Statement))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]ipld.Node{AttestationFactKey: n}, nil
+}
+
+// ClaimOptions returns the delegation options embedding the attestation of
+// id in a claim, if id is a [Signer] configured to embed it.
+func ClaimOptions(id principal.Signer) []delegation.Option {
+	facts := ClaimFacts(id)
+	if len(facts) == 0 {
+		return nil
+	}
+	return []delegation.Option{delegation.WithFacts(facts)}
+}
+
+// ClaimFacts returns the facts embedding the attestation of id in a claim,
+// for claims carrying other facts too, since [delegation.WithFacts] replaces
+// the facts of previous options.
+func ClaimFacts(id principal.Signer) []ucan.FactBuilder {
+	s := unwrap(id)
+	if s == nil || !s.embed || s.attestation == nil {
+		return nil
+	}
+	return []ucan.FactBuilder{*s.attestation}
+}
@@ -0,0 +1,102 @@
+package hwsigner
+
+import (
+	"context"
+	stdcrypto "crypto"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/crypto/pb"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/principal/signer"
+)
+
+// unwrap returns the agent signer of id, nil if id signs with a key it
+// holds.
+func unwrap(id principal.Signer) *Signer {
+	// a did:web node wraps its signer
+	if w, ok := id.(signer.Unwrapper); ok {
+		id = w.Unwrap()
+	}
+	s, _ := id.(*Signer)
+	return s
+}
+
+// PrivKey returns the libp2p private key of id, used for the peer ID of the
+// node and to sign IPNI advertisements. The key of an agent signer signs
+// with the agent.
+func PrivKey(id principal.Signer) (crypto.PrivKey, error) {
+	if s := unwrap(id); s != nil {
+		pub, err := crypto.UnmarshalEd25519PublicKey(s.verifier.Raw())
+		if err != nil {
+			return nil, fmt.Errorf("unmarshaling agent public key: %w", err)
+		}
+		return &privKey{signer: s, pub: pub}, nil
+	}
+	priv, err := crypto.UnmarshalEd25519PrivateKey(id.Raw())
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling private key: %w", err)
+	}
+	return priv, nil
+}
+
+// KeySigner returns id as a [stdcrypto.Signer], e.g. to sign JWTs. The key
+// of an agent signer signs with the agent.
+func KeySigner(id principal.Signer) (stdcrypto.Signer, error) {
+	if s := unwrap(id); s != nil {
+		return keySigner{s}, nil
+	}
+	if len(id.Raw()) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid ed25519 private key")
+	}
+	return ed25519.PrivateKey(id.Raw()), nil
+}
+
+type privKey struct {
+	signer *Signer
+	pub    crypto.PubKey
+}
+
+var _ crypto.PrivKey = (*privKey)(nil)
+
+func (k *privKey) Sign(msg []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), SignTimeout)
+	defer cancel()
+	return k.signer.SignContext(ctx, msg)
+}
+
+func (k *privKey) GetPublic() crypto.PubKey {
+	return k.pub
+}
+
+func (k *privKey) Equals(o crypto.Key) bool {
+	other, ok := o.(*privKey)
+	return ok && k.pub.Equals(other.pub)
+}
+
+// Raw returns an error, the private key is held by the agent.
+func (k *privKey) Raw() ([]byte, error) {
+	return nil, errors.New("private key is held outside the node")
+}
+
+func (k *privKey) Type() pb.KeyType {
+	return pb.KeyType_Ed25519
+}
+
+type keySigner struct {
+	signer *Signer
+}
+
+func (k keySigner) Public() stdcrypto.PublicKey {
+	return ed25519.PublicKey(k.signer.verifier.Raw())
+}
+
+// Sign signs msg unhashed, as ed25519 requires.
+func (k keySigner) Sign(_ io.Reader, msg []byte, _ stdcrypto.SignerOpts) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), SignTimeout)
+	defer cancel()
+	return k.signer.SignContext(ctx, msg)
+}
@@ -0,0 +1,157 @@
+// Package hwsigner signs the node's UCANs with a key held outside the node:
+// in hardware, such as a TPM or secure enclave, through a signing agent, or
+// in the transit secrets engine of HashiCorp Vault.
+//
+// The agent is a separate process listening on a Unix socket. It holds the
+// node's ed25519 key and exposes two endpoints:
+//
+//	GET  /v1/key   -> {"public_key": <base64>, "attestation": {"format": <string>, "statement": <base64>}}
+//	POST /v1/sign  {"message": <base64>} -> {"signature": <base64>}
+//
+// The attestation is optional. When configured, it is embedded in location
+// claims as a fact so consumers can verify the signing environment.
+//
+// Vault holds the key as an ed25519 transit key, see [NewVault].
+package hwsigner
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	ed25519signer "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/principal/ed25519/verifier"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+)
+
+// ConnectTimeout bounds connecting to the agent and checking its key.
+const ConnectTimeout = 10 * time.Second
+
+// SignTimeout bounds a signing request to the agent.
+const SignTimeout = 10 * time.Second
+
+// ErrSign is the error of a signature the agent or Vault failed to make.
+var ErrSign = errors.New("remote signing failed")
+
+// Signer is a [principal.Signer] whose signatures are made by a signing
+// agent or Vault. The private key never leaves them: Raw and Encode return
+// nil, and the libp2p and JWT keys of the node are derived with [PrivKey]
+// and [KeySigner], which sign with the agent or Vault too.
+type Signer struct {
+	verifier    principal.Verifier
+	client      signingClient
+	attestation *Attestation
+	embed       bool
+}
+
+// signingClient signs with a key the node does not hold.
+type signingClient interface {
+	Sign(ctx context.Context, msg []byte) ([]byte, error)
+}
+
+var _ principal.Signer = (*Signer)(nil)
+
+// New creates a Signer for the agent listening on socket, identified by the
+// agent's key. The key is checked by signing a random message.
+func New(ctx context.Context, socket string, embedAttestation bool) (*Signer, error) {
+	client := NewClient(socket)
+	key, err := client.Key(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if embedAttestation && key.Attestation == nil {
+		return nil, fmt.Errorf("embedding attestation requires the agent to provide one")
+	}
+	return newSigner(ctx, client, key.PublicKey, key.Attestation, embedAttestation)
+}
+
+// newSigner creates a Signer signing with client, identified by publicKey.
+// The key is checked by signing a random message.
+func newSigner(ctx context.Context, client signingClient, publicKey []byte, attestation *Attestation, embed bool) (*Signer, error) {
+	v, err := verifier.FromRaw(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signer public key: %w", err)
+	}
+	s := &Signer{
+		verifier:    v,
+		client:      client,
+		attestation: attestation,
+		embed:       embed,
+	}
+	probe := make([]byte, 32)
+	if _, err := rand.Read(probe); err != nil {
+		return nil, fmt.Errorf("generating probe message: %w", err)
+	}
+	if _, err := s.SignContext(ctx, probe); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// DID is the did:key of the signer's key.
+func (s *Signer) DID() did.DID {
+	return s.verifier.DID()
+}
+
+func (s *Signer) Code() uint64 {
+	return ed25519signer.Code
+}
+
+func (s *Signer) SignatureCode() uint64 {
+	return ed25519signer.SignatureCode
+}
+
+func (s *Signer) SignatureAlgorithm() string {
+	return ed25519signer.SignatureAlgorithm
+}
+
+func (s *Signer) Verifier() principal.Verifier {
+	return s.verifier
+}
+
+// Encode returns nil, the private key is held by the agent or Vault.
+func (s *Signer) Encode() []byte {
+	return nil
+}
+
+// Raw returns nil, the private key is held by the agent or Vault.
+func (s *Signer) Raw() []byte {
+	return nil
+}
+
+// SignContext signs msg with the agent or Vault, returning the raw ed25519
+// signature. A signature that does not verify is an error.
+func (s *Signer) SignContext(ctx context.Context, msg []byte) ([]byte, error) {
+	sig, err := s.client.Sign(ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSign, err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(s.verifier.Raw()), msg, sig) {
+		return nil, fmt.Errorf("%w: signer returned an invalid signature", ErrSign)
+	}
+	return sig, nil
+}
+
+// Sign signs msg with the agent or Vault. The UCAN libraries calling it have no way
+// to report a signing error, so rather than returning a signature that fails
+// verification, Sign panics with an error wrapping [ErrSign]. HTTP servers
+// and job queues recover, failing the request or job that needed it.
+func (s *Signer) Sign(msg []byte) signature.SignatureView {
+	ctx, cancel := context.WithTimeout(context.Background(), SignTimeout)
+	defer cancel()
+	sig, err := s.SignContext(ctx, msg)
+	if err != nil {
+		panic(err)
+	}
+	return signature.NewSignatureView(signature.NewSignature(s.SignatureCode(), sig))
+}
+
+// Attestation returns the attestation provided by the agent, or nil.
+func (s *Signer) Attestation() *Attestation {
+	return s.attestation
+}
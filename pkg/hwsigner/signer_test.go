@@ -0,0 +1,135 @@
+package hwsigner
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/stretchr/testify/require"
+)
+
+// startAgent serves the agent protocol for key on a Unix socket, returning
+// its path.
+func startAgent(t *testing.T, key principal.Signer, attestation *Attestation) string {
+	t.Helper()
+	return startAgentSigningWith(t, key, key, attestation)
+}
+
+// startAgentSigningWith serves the agent protocol for key, signing with
+// signer.
+func startAgentSigningWith(t *testing.T, key, signer principal.Signer, attestation *Attestation) string {
+	t.Helper()
+	// t.TempDir() can exceed the maximum length of a socket path
+	dir, err := os.MkdirTemp("", "agent")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "agent.sock")
+
+	priv := ed25519.PrivateKey(signer.Raw())
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/key", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(KeyResponse{PublicKey: key.Verifier().Raw(), Attestation: attestation})
+	})
+	mux.HandleFunc("POST /v1/sign", func(w http.ResponseWriter, r *http.Request) {
+		var req SignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(SignResponse{Signature: ed25519.Sign(priv, req.Message)})
+	})
+
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return socket
+}
+
+func TestSigner(t *testing.T) {
+	id := testutil.RandomSigner(t)
+	attestation := &Attestation{Format: "tpm2-quote", Statement: []byte("quote")}
+	socket := startAgent(t, id, attestation)
+
+	t.Run("signs with the agent", func(t *testing.T) {
+		s, err := New(t.Context(), socket, false)
+		require.NoError(t, err)
+		require.Equal(t, id.DID(), s.DID())
+
+		msg := []byte("hello")
+		sig := s.Sign(msg)
+		require.True(t, id.Verifier().Verify(msg, sig))
+		require.Empty(t, ClaimOptions(s))
+	})
+
+	t.Run("embeds the attestation in claims", func(t *testing.T) {
+		s, err := New(t.Context(), socket, true)
+		require.NoError(t, err)
+		require.Equal(t, attestation, s.Attestation())
+		require.Len(t, ClaimOptions(s), 1)
+
+		fact, err := attestation.ToIPLD()
+		require.NoError(t, err)
+		statement, err := fact[AttestationFactKey].LookupByString("statement")
+		require.NoError(t, err)
+		b, err := statement.AsBytes()
+		require.NoError(t, err)
+		require.Equal(t, []byte("quote"), b)
+	})
+
+	t.Run("rejects an agent signing with another key", func(t *testing.T) {
+		_, err := New(t.Context(), startAgentSigningWith(t, id, testutil.RandomSigner(t), nil), false)
+		require.ErrorIs(t, err, ErrSign)
+	})
+
+	t.Run("embedding requires an attestation", func(t *testing.T) {
+		_, err := New(t.Context(), startAgent(t, id, nil), true)
+		require.Error(t, err)
+	})
+
+	t.Run("fails to sign once the agent is gone", func(t *testing.T) {
+		dir, err := os.MkdirTemp("", "agent")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		s, err := New(t.Context(), socket, false)
+		require.NoError(t, err)
+		s.client = NewClient(filepath.Join(dir, "gone.sock"))
+
+		_, err = s.SignContext(t.Context(), []byte("hello"))
+		require.ErrorIs(t, err, ErrSign)
+		require.Panics(t, func() { s.Sign([]byte("hello")) })
+	})
+
+	t.Run("derives keys signing with the agent", func(t *testing.T) {
+		s, err := New(t.Context(), socket, false)
+		require.NoError(t, err)
+		require.Nil(t, s.Raw())
+
+		msg := []byte("hello")
+		priv, err := PrivKey(s)
+		require.NoError(t, err)
+		sig, err := priv.Sign(msg)
+		require.NoError(t, err)
+		require.True(t, ed25519.Verify(ed25519.PublicKey(id.Verifier().Raw()), msg, sig))
+
+		ks, err := KeySigner(s)
+		require.NoError(t, err)
+		sig, err = ks.Sign(nil, msg, crypto.Hash(0))
+		require.NoError(t, err)
+		require.True(t, ed25519.Verify(ed25519.PublicKey(id.Verifier().Raw()), msg, sig))
+	})
+
+	t.Run("file signers have no claim options", func(t *testing.T) {
+		require.Empty(t, ClaimOptions(id))
+	})
+}
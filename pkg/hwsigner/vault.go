@@ -0,0 +1,167 @@
+package hwsigner
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultVaultMount is the path the transit secrets engine is mounted at by
+// default.
+const DefaultVaultMount = "transit"
+
+// VaultClient signs with an ed25519 key of the transit secrets engine of
+// HashiCorp Vault, using the endpoints:
+//
+//	GET  /v1/<mount>/keys/<key>  -> {"data": {"type": "ed25519", "latest_version": <int>, "keys": {"<version>": {"public_key": <base64>}}}}
+//	POST /v1/<mount>/sign/<key>  {"input": <base64>, "key_version": <int>} -> {"data": {"signature": "vault:v<version>:<base64>"}}
+//
+// Signatures are made with the version of the key that was latest when the
+// client was created, so rotating the key in Vault does not change the
+// node's identity.
+type VaultClient struct {
+	http     *http.Client
+	endpoint *url.URL
+	mount    string
+	key      string
+	token    string
+	version  int
+}
+
+// NewVaultClient creates a client of the Vault server at address, signing
+// with key of the transit engine mounted at mount, authenticated with token.
+func NewVaultClient(address, mount, key, token string, httpClient *http.Client) (*VaultClient, error) {
+	endpoint, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("parsing vault address: %w", err)
+	}
+	if mount == "" {
+		mount = DefaultVaultMount
+	}
+	return &VaultClient{
+		http:     httpClient,
+		endpoint: endpoint,
+		mount:    strings.Trim(mount, "/"),
+		key:      key,
+		token:    token,
+	}, nil
+}
+
+type vaultKeyResponse struct {
+	Data struct {
+		Type          string `json:"type"`
+		LatestVersion int    `json:"latest_version"`
+		Keys          map[string]struct {
+			PublicKey string `json:"public_key"`
+		} `json:"keys"`
+	} `json:"data"`
+}
+
+type vaultSignRequest struct {
+	Input      string `json:"input"`
+	KeyVersion int    `json:"key_version"`
+}
+
+type vaultSignResponse struct {
+	Data struct {
+		Signature string `json:"signature"`
+	} `json:"data"`
+}
+
+// Key returns the raw ed25519 public key of the latest version of the key,
+// and pins the client to that version.
+func (c *VaultClient) Key(ctx context.Context) ([]byte, error) {
+	var res vaultKeyResponse
+	if err := c.do(ctx, http.MethodGet, "keys", nil, &res); err != nil {
+		return nil, fmt.Errorf("getting vault key: %w", err)
+	}
+	if res.Data.Type != "ed25519" {
+		return nil, fmt.Errorf("vault key %s has type %q, must be ed25519", c.key, res.Data.Type)
+	}
+	version, ok := res.Data.Keys[strconv.Itoa(res.Data.LatestVersion)]
+	if !ok {
+		return nil, fmt.Errorf("vault key %s has no version %d", c.key, res.Data.LatestVersion)
+	}
+	pub, err := base64.StdEncoding.DecodeString(version.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding vault public key: %w", err)
+	}
+	c.version = res.Data.LatestVersion
+	return pub, nil
+}
+
+// Sign signs msg with the pinned version of the key.
+func (c *VaultClient) Sign(ctx context.Context, msg []byte) ([]byte, error) {
+	req := vaultSignRequest{
+		Input:      base64.StdEncoding.EncodeToString(msg),
+		KeyVersion: c.version,
+	}
+	var res vaultSignResponse
+	if err := c.do(ctx, http.MethodPost, "sign", req, &res); err != nil {
+		return nil, fmt.Errorf("signing with vault: %w", err)
+	}
+	// signatures are formatted as vault:v<version>:<base64 signature>
+	parts := strings.SplitN(res.Data.Signature, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, fmt.Errorf("unexpected vault signature format")
+	}
+	if want := "v" + strconv.Itoa(c.version); parts[1] != want {
+		return nil, fmt.Errorf("vault signed with key version %s, expected %s", parts[1], want)
+	}
+	sig, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decoding vault signature: %w", err)
+	}
+	return sig, nil
+}
+
+func (c *VaultClient) do(ctx context.Context, method, op string, body, out any) error {
+	var r io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		r = bytes.NewReader(data)
+	}
+	route := c.endpoint.JoinPath("v1", c.mount, op, c.key)
+	req, err := http.NewRequestWithContext(ctx, method, route.String(), r)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("vault returned %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// NewVault creates a Signer for an ed25519 key of the transit engine of
+// Vault, identified by the latest version of the key. The key is checked by
+// signing a random message.
+func NewVault(ctx context.Context, client *VaultClient) (*Signer, error) {
+	pub, err := client.Key(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newSigner(ctx, client, pub, nil, false)
+}
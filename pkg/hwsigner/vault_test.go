@@ -0,0 +1,144 @@
+package hwsigner
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/stretchr/testify/require"
+)
+
+const testVaultToken = "s.token"
+
+// fakeVault serves the transit endpoints for the key "node" mounted at
+// "transit", whose versions are keys, signing version v with signers[v-1].
+type fakeVault struct {
+	keys    []principal.Signer
+	signers []principal.Signer
+	keyType string
+	signed  []int
+}
+
+func (f *fakeVault) start(t *testing.T) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/transit/keys/node", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != testVaultToken {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		var res vaultKeyResponse
+		res.Data.Type = f.keyType
+		res.Data.LatestVersion = len(f.keys)
+		res.Data.Keys = map[string]struct {
+			PublicKey string `json:"public_key"`
+		}{}
+		for i, k := range f.keys {
+			res.Data.Keys[strconv.Itoa(i+1)] = struct {
+				PublicKey string `json:"public_key"`
+			}{base64.StdEncoding.EncodeToString(k.Verifier().Raw())}
+		}
+		json.NewEncoder(w).Encode(res)
+	})
+	mux.HandleFunc("POST /v1/transit/sign/node", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != testVaultToken {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		var req vaultSignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		msg, err := base64.StdEncoding.DecodeString(req.Input)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.signed = append(f.signed, req.KeyVersion)
+		sig := ed25519.Sign(ed25519.PrivateKey(f.signers[req.KeyVersion-1].Raw()), msg)
+		var res vaultSignResponse
+		res.Data.Signature = fmt.Sprintf("vault:v%d:%s", req.KeyVersion, base64.StdEncoding.EncodeToString(sig))
+		json.NewEncoder(w).Encode(res)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func newFakeVault(keys ...principal.Signer) *fakeVault {
+	return &fakeVault{keys: keys, signers: keys, keyType: "ed25519"}
+}
+
+func newVaultSigner(t *testing.T, address, token string) (*Signer, error) {
+	t.Helper()
+	client, err := NewVaultClient(address, "", "node", token, http.DefaultClient)
+	require.NoError(t, err)
+	return NewVault(t.Context(), client)
+}
+
+func TestVaultSigner(t *testing.T) {
+	t.Run("signs with the latest version of the key", func(t *testing.T) {
+		old, id := testutil.RandomSigner(t), testutil.RandomSigner(t)
+		vault := newFakeVault(old, id)
+		s, err := newVaultSigner(t, vault.start(t), testVaultToken)
+		require.NoError(t, err)
+		require.Equal(t, id.DID(), s.DID())
+		require.Nil(t, s.Raw())
+		require.Empty(t, ClaimOptions(s))
+
+		msg := []byte("hello")
+		require.True(t, id.Verifier().Verify(msg, s.Sign(msg)))
+
+		ks, err := KeySigner(s)
+		require.NoError(t, err)
+		sig, err := ks.Sign(nil, msg, nil)
+		require.NoError(t, err)
+		require.True(t, ed25519.Verify(ed25519.PublicKey(id.Verifier().Raw()), msg, sig))
+
+		// the startup probe and both signatures
+		require.Equal(t, []int{2, 2, 2}, vault.signed)
+	})
+
+	t.Run("stays on the version of the key it started with", func(t *testing.T) {
+		id := testutil.RandomSigner(t)
+		vault := newFakeVault(id)
+		s, err := newVaultSigner(t, vault.start(t), testVaultToken)
+		require.NoError(t, err)
+
+		// the key is rotated in vault
+		rotated := testutil.RandomSigner(t)
+		vault.keys = append(vault.keys, rotated)
+		vault.signers = append(vault.signers, rotated)
+
+		msg := []byte("hello")
+		require.True(t, id.Verifier().Verify(msg, s.Sign(msg)))
+	})
+
+	t.Run("rejects vault signing with another key", func(t *testing.T) {
+		vault := newFakeVault(testutil.RandomSigner(t))
+		vault.signers = []principal.Signer{testutil.RandomSigner(t)}
+		_, err := newVaultSigner(t, vault.start(t), testVaultToken)
+		require.ErrorIs(t, err, ErrSign)
+	})
+
+	t.Run("rejects keys that are not ed25519", func(t *testing.T) {
+		vault := newFakeVault(testutil.RandomSigner(t))
+		vault.keyType = "ecdsa-p256"
+		_, err := newVaultSigner(t, vault.start(t), testVaultToken)
+		require.ErrorContains(t, err, "must be ed25519")
+	})
+
+	t.Run("fails without a valid token", func(t *testing.T) {
+		vault := newFakeVault(testutil.RandomSigner(t))
+		_, err := newVaultSigner(t, vault.start(t), "s.wrong")
+		require.ErrorContains(t, err, "403")
+	})
+}
@@ -0,0 +1,89 @@
+// Package periodic runs a task in the background every interval, from when it
+// is started until it is stopped.
+package periodic
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/raulk/clock"
+	"go.uber.org/fx"
+)
+
+// Loop runs a task every interval.
+type Loop struct {
+	clock    clock.Clock
+	interval time.Duration
+	run      func(context.Context)
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a loop running run every interval of clock. run is passed a
+// context cancelled when the loop is stopped.
+func New(clock clock.Clock, interval time.Duration, run func(context.Context)) *Loop {
+	return &Loop{clock: clock, interval: interval, run: run}
+}
+
+// Start runs the task once, then every interval until stopped or ctx is
+// done.
+func (l *Loop) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	l.cancel = cancel
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		ticker := l.clock.Ticker(l.interval)
+		defer ticker.Stop()
+		l.run(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				l.run(ctx)
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop cancels the running task and waits for it to return, or for ctx to be
+// done.
+func (l *Loop) Stop(ctx context.Context) error {
+	if l.cancel != nil {
+		l.cancel()
+	}
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Service is started and stopped with the application.
+type Service interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// Hook returns the lifecycle hook starting s with the application and
+// stopping it with it. s runs in a context outliving the start hook.
+func Hook(s Service) fx.Hook {
+	return fx.Hook{
+		OnStart: func(context.Context) error {
+			return s.Start(context.Background())
+		},
+		OnStop: func(ctx context.Context) error {
+			return s.Stop(ctx)
+		},
+	}
+}
@@ -0,0 +1,157 @@
+// Package ranged reads content fetched in byte ranges, in parallel and in
+// order, and verifies the content read against its size and digest.
+package ranged
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrSizeMismatch is returned when content does not have the expected
+	// size.
+	ErrSizeMismatch = errors.New("content does not match blob size")
+	// ErrDigestMismatch is returned when content does not match the expected
+	// digest.
+	ErrDigestMismatch = errors.New("content does not match blob digest")
+)
+
+// FetchFunc fetches the i-th range of content, the bytes from start to end
+// inclusive.
+type FetchFunc func(ctx context.Context, i int, start, end int64) ([]byte, error)
+
+// NewReader returns a reader of content of size bytes, starting with first,
+// the bytes of its first range, and continuing with ranges of chunkSize bytes
+// fetched by fetch. Up to concurrency ranges are in flight or unread at a
+// time, so memory use is bounded by chunkSize times concurrency. Reads fail
+// with the error of the first range that could not be fetched. Closing the
+// reader cancels the fetches in flight.
+func NewReader(ctx context.Context, first []byte, size, chunkSize int64, concurrency int, fetch FetchFunc) io.ReadCloser {
+	ctx, cancel := context.WithCancel(ctx)
+	chunks := make(chan chan chunk, concurrency)
+	go func() {
+		defer close(chunks)
+		i := 1
+		for start := int64(len(first)); start < size; start += chunkSize {
+			end := min(start+chunkSize, size) - 1
+			result := make(chan chunk, 1)
+			// blocks while concurrency ranges are in flight or unread
+			select {
+			case chunks <- result:
+			case <-ctx.Done():
+				return
+			}
+			go func(i int) {
+				data, err := fetch(ctx, i, start, end)
+				result <- chunk{data: data, err: err}
+			}(i)
+			i++
+		}
+	}()
+	return &reader{cur: first, chunks: chunks, cancel: cancel}
+}
+
+type chunk struct {
+	data []byte
+	err  error
+}
+
+type reader struct {
+	cur    []byte
+	chunks chan chan chunk
+	cancel context.CancelFunc
+	err    error
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.cur) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		next, ok := <-r.chunks
+		if !ok {
+			r.err = io.EOF
+			continue
+		}
+		c := <-next
+		if c.err != nil {
+			r.err = c.err
+			continue
+		}
+		r.cur = c.data
+	}
+	n := copy(p, r.cur)
+	r.cur = r.cur[n:]
+	return n, nil
+}
+
+func (r *reader) Close() error {
+	r.cancel()
+	return nil
+}
+
+// ContentRangeTotal returns the complete length of the content a
+// Content-Range header is for.
+func ContentRangeTotal(header string) (int64, error) {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok || total == "*" {
+		return 0, fmt.Errorf("source returned invalid Content-Range: %q", header)
+	}
+	n, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("source returned invalid Content-Range: %q", header)
+	}
+	return n, nil
+}
+
+// VerifyingReader returns a reader of r checking the content read has size
+// bytes and hashes to digest with hasher. It fails with [ErrSizeMismatch] or
+// [ErrDigestMismatch] before returning the last bytes of content that does
+// not match.
+func VerifyingReader(r io.Reader, size int64, hasher hash.Hash, digest []byte) io.Reader {
+	return &verifyingReader{r: r, size: size, hasher: hasher, digest: digest}
+}
+
+type verifyingReader struct {
+	r      io.Reader
+	size   int64
+	hasher hash.Hash
+	digest []byte
+	n      int64
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n, err := v.r.Read(p)
+	v.hasher.Write(p[:n])
+	v.n += int64(n)
+	if v.n > v.size {
+		return 0, fmt.Errorf("%w: source has more than %d bytes", ErrSizeMismatch, v.size)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, err
+	}
+	if v.n < v.size {
+		if errors.Is(err, io.EOF) {
+			return n, fmt.Errorf("%w: source has %d bytes, expected %d", ErrSizeMismatch, v.n, v.size)
+		}
+		return n, nil
+	}
+	// all the bytes have been read, fail rather than hand over the end of
+	// content that does not match
+	if !bytes.Equal(v.hasher.Sum(nil), v.digest) {
+		return 0, ErrDigestMismatch
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return 0, io.EOF
+}
@@ -0,0 +1,74 @@
+package ranged
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	content := []byte("abcdefg")
+	fetch := func(_ context.Context, _ int, start, end int64) ([]byte, error) {
+		return content[start : end+1], nil
+	}
+
+	data, err := io.ReadAll(NewReader(t.Context(), content[:2], int64(len(content)), 2, 2, fetch))
+	require.NoError(t, err)
+	require.Equal(t, content, data)
+
+	t.Run("fails with the error of a range", func(t *testing.T) {
+		boom := errors.New("boom")
+		fetch := func(_ context.Context, i int, start, end int64) ([]byte, error) {
+			if i == 2 {
+				return nil, boom
+			}
+			return content[start : end+1], nil
+		}
+		r := NewReader(t.Context(), content[:2], int64(len(content)), 2, 2, fetch)
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		require.ErrorIs(t, err, boom)
+		require.Equal(t, content[:4], data)
+	})
+}
+
+func TestVerifyingReader(t *testing.T) {
+	content := bytes.Repeat([]byte("replica"), 100)
+	digest := sha256.Sum256(content)
+
+	t.Run("matching content", func(t *testing.T) {
+		data, err := io.ReadAll(VerifyingReader(bytes.NewReader(content), int64(len(content)), sha256.New(), digest[:]))
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+	})
+
+	t.Run("mismatched content", func(t *testing.T) {
+		other := bytes.Repeat([]byte("REPLICA"), 100)
+		_, err := io.ReadAll(VerifyingReader(bytes.NewReader(other), int64(len(other)), sha256.New(), digest[:]))
+		require.ErrorIs(t, err, ErrDigestMismatch)
+	})
+
+	t.Run("short content", func(t *testing.T) {
+		_, err := io.ReadAll(VerifyingReader(bytes.NewReader(content[1:]), int64(len(content)), sha256.New(), digest[:]))
+		require.ErrorIs(t, err, ErrSizeMismatch)
+	})
+
+	t.Run("long content", func(t *testing.T) {
+		_, err := io.ReadAll(VerifyingReader(bytes.NewReader(append(content, 'x')), int64(len(content)), sha256.New(), digest[:]))
+		require.ErrorIs(t, err, ErrSizeMismatch)
+	})
+}
+
+func TestContentRangeTotal(t *testing.T) {
+	n, err := ContentRangeTotal("bytes 0-9/100")
+	require.NoError(t, err)
+	require.Equal(t, int64(100), n)
+
+	_, err = ContentRangeTotal("bytes 0-9/*")
+	require.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package openapi
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler serves the document of the routes registered on e. It is built on
+// first request, once every route has been registered.
+func (s *Spec) Handler(e *echo.Echo) echo.HandlerFunc {
+	doc := sync.OnceValue(func() *Document {
+		return s.Document(Routes(e))
+	})
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, doc())
+	}
+}
+
+// Routes returns the routes registered on e, leaving out those echo
+// registers to route requests to the middleware of groups.
+func Routes(e *echo.Echo) []Route {
+	var routes []Route
+	for _, r := range e.Routes() {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+			routes = append(routes, Route{Method: r.Method, Path: r.Path})
+		}
+	}
+	return routes
+}
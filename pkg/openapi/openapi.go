@@ -0,0 +1,312 @@
+// Package openapi describes the HTTP APIs of the node as an OpenAPI 3
+// document.
+//
+// The APIs declare their operations next to the request and response types
+// they share with their clients. The document is built from the routes
+// registered on the server, so it only describes the routes the node serves,
+// and the schemas are derived from the types the handlers encode and the
+// clients decode, so neither can drift from it. Tests of each API check
+// every route it registers is described, and every operation it describes is
+// registered.
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the OpenAPI specification of the documents.
+const Version = "3.0.3"
+
+// RoutePath is the path the document is served at.
+const RoutePath = "/openapi.json"
+
+// bearerAuth is the name of the security scheme of authenticated operations.
+const bearerAuth = "bearerAuth"
+
+// Operation describes a route of an API.
+type Operation struct {
+	// Method and Path identify the route, with path parameters in the echo
+	// syntax, e.g. /admin/jobs/:queue/:id.
+	Method string
+	Path   string
+	// ID is the unique name of the operation, e.g. listJobs.
+	ID      string
+	Summary string
+	Tag     string
+	// Auth is true if the route requires a bearer token.
+	Auth  bool
+	Query []Param
+	// Request is a value of the type of the JSON request body, nil if the
+	// route has none.
+	Request any
+	// Response is a value of the type of the JSON response body, nil if the
+	// route responds with no content.
+	Response any
+	// Status is the status of a successful response, 200 if zero.
+	Status int
+}
+
+// Binary is the type of request and response bodies that are raw bytes
+// rather than JSON.
+type Binary []byte
+
+// Key identifies the route of the operation.
+func (op Operation) Key() string {
+	return RouteKey(op.Method, op.Path)
+}
+
+// RouteKey identifies a route by method and path.
+func RouteKey(method, path string) string {
+	return method + " " + path
+}
+
+// Param is a query parameter of an operation.
+type Param struct {
+	Name        string
+	Description string
+	// Type is the JSON schema type of the parameter, string if empty.
+	Type     string
+	Required bool
+}
+
+// Route is a route registered on the server.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+	Tags       []Tag               `json:"tags,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem maps the lowercase methods of a path to their operation.
+type PathItem map[string]*OperationObject
+
+type OperationObject struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []ParameterObject     `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type ParameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Spec holds the operations of the APIs served by the node.
+type Spec struct {
+	title   string
+	version string
+	ops     map[string]Operation
+}
+
+// New creates a spec of the operations, titled and versioned as given.
+func New(title, version string, ops ...[]Operation) *Spec {
+	s := &Spec{title: title, version: version, ops: map[string]Operation{}}
+	for _, list := range ops {
+		for _, op := range list {
+			s.ops[op.Key()] = op
+		}
+	}
+	return s
+}
+
+// Operation returns the operation of the route, false if it is not
+// described.
+func (s *Spec) Operation(method, path string) (Operation, bool) {
+	op, ok := s.ops[RouteKey(method, path)]
+	return op, ok
+}
+
+// Document builds the document of the described operations among the
+// routes. Routes that are not described, e.g. pages of the dashboard, are
+// left out.
+func (s *Spec) Document(routes []Route) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: s.title, Version: s.version},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+		},
+	}
+	schemas := newSchemaSet(doc.Components.Schemas)
+
+	var ops []Operation
+	for _, r := range routes {
+		if op, ok := s.Operation(r.Method, r.Path); ok {
+			ops = append(ops, op)
+		}
+	}
+	// register schemas in a stable order, the name of a schema depends on
+	// the types registered before it
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Key() < ops[j].Key() })
+
+	tags := map[string]bool{}
+	auth := false
+	for _, op := range ops {
+		path, params := convertPath(op.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		obj := &OperationObject{
+			OperationID: op.ID,
+			Summary:     op.Summary,
+			Responses:   map[string]Response{},
+		}
+		if op.Tag != "" {
+			obj.Tags = []string{op.Tag}
+			tags[op.Tag] = true
+		}
+		for _, p := range params {
+			obj.Parameters = append(obj.Parameters, ParameterObject{Name: p, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+		for _, q := range op.Query {
+			typ := q.Type
+			if typ == "" {
+				typ = "string"
+			}
+			obj.Parameters = append(obj.Parameters, ParameterObject{Name: q.Name, In: "query", Description: q.Description, Required: q.Required, Schema: &Schema{Type: typ}})
+		}
+		if op.Request != nil {
+			obj.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{contentType(op.Request): {Schema: schemas.of(op.Request)}},
+			}
+		}
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		res := Response{Description: http.StatusText(status)}
+		if op.Response != nil {
+			res.Content = map[string]MediaType{contentType(op.Response): {Schema: schemas.of(op.Response)}}
+		}
+		obj.Responses[strconv.Itoa(status)] = res
+		obj.Responses["default"] = Response{
+			Description: "Error",
+			Content:     map[string]MediaType{"application/json": {Schema: schemas.of(ErrorResponse{})}},
+		}
+		if op.Auth {
+			obj.Security = []map[string][]string{{bearerAuth: {}}}
+			auth = true
+		}
+		item[strings.ToLower(op.Method)] = obj
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	if auth {
+		doc.Components.SecuritySchemes = map[string]SecurityScheme{
+			bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		}
+	}
+	return doc
+}
+
+// Undescribed returns the routes among routes that have no operation.
+func (s *Spec) Undescribed(routes []Route) []string {
+	var keys []string
+	for _, r := range routes {
+		if _, ok := s.Operation(r.Method, r.Path); !ok {
+			keys = append(keys, RouteKey(r.Method, r.Path))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Unregistered returns the operations of ops whose route is not among
+// routes.
+func Unregistered(ops []Operation, routes []Route) []string {
+	registered := map[string]bool{}
+	for _, r := range routes {
+		registered[RouteKey(r.Method, r.Path)] = true
+	}
+	var keys []string
+	for _, op := range ops {
+		if !registered[op.Key()] {
+			keys = append(keys, op.Key())
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ErrorResponse is the body of error responses.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func contentType(body any) string {
+	if _, ok := body.(Binary); ok {
+		return "application/octet-stream"
+	}
+	return "application/json"
+}
+
+var pathParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// convertPath converts an echo path to an OpenAPI path, returning the names
+// of its parameters.
+func convertPath(path string) (string, []string) {
+	var params []string
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		params = append(params, m[1])
+	}
+	return pathParam.ReplaceAllString(path, "{$1}"), params
+}
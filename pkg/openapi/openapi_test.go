@@ -0,0 +1,69 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Name    string            `json:"name"`
+	Size    int64             `json:"size,omitempty"`
+	Created time.Time         `json:"created"`
+	Parent  *item             `json:"parent,omitempty"`
+	Labels  map[string]string `json:"labels"`
+	Hidden  string            `json:"-"`
+}
+
+type listItemsResponse struct {
+	Items []item `json:"items"`
+}
+
+func TestDocument(t *testing.T) {
+	ops := []Operation{
+		{Method: http.MethodGet, Path: "/items", ID: "listItems", Tag: "items", Auth: true, Query: []Param{
+			{Name: "limit", Type: "integer"},
+		}, Response: listItemsResponse{}},
+		{Method: http.MethodPut, Path: "/items/:id/data", ID: "putItemData", Request: Binary{}, Status: http.StatusNoContent},
+		{Method: http.MethodDelete, Path: "/items/:id", ID: "deleteItem"},
+	}
+	spec := New("test", "v1.0.0", ops)
+	routes := []Route{
+		{Method: http.MethodGet, Path: "/items"},
+		{Method: http.MethodPut, Path: "/items/:id/data"},
+		{Method: http.MethodGet, Path: "/"},
+	}
+
+	doc := spec.Document(routes)
+	require.Equal(t, Version, doc.OpenAPI)
+	require.Equal(t, Info{Title: "test", Version: "v1.0.0"}, doc.Info)
+	require.Len(t, doc.Paths, 2)
+	require.Equal(t, []Tag{{Name: "items"}}, doc.Tags)
+	require.Contains(t, doc.Components.SecuritySchemes, bearerAuth)
+
+	list := doc.Paths["/items"]["get"]
+	require.Equal(t, "listItems", list.OperationID)
+	require.Equal(t, []map[string][]string{{bearerAuth: {}}}, list.Security)
+	require.Equal(t, "query", list.Parameters[0].In)
+	require.Equal(t, "#/components/schemas/listItemsResponse", list.Responses["200"].Content["application/json"].Schema.Ref)
+	require.Contains(t, list.Responses, "default")
+
+	put := doc.Paths["/items/{id}/data"]["put"]
+	require.Equal(t, []ParameterObject{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}}, put.Parameters)
+	require.Equal(t, &Schema{Type: "string", Format: "binary"}, put.RequestBody.Content["application/octet-stream"].Schema)
+	require.Contains(t, put.Responses, "204")
+	require.Empty(t, put.Security)
+
+	schema := doc.Components.Schemas["item"]
+	require.Equal(t, []string{"name", "created", "labels"}, schema.Required)
+	require.Equal(t, &Schema{Type: "integer", Format: "int64"}, schema.Properties["size"])
+	require.Equal(t, &Schema{Type: "string", Format: "date-time"}, schema.Properties["created"])
+	require.Equal(t, "#/components/schemas/item", schema.Properties["parent"].Ref)
+	require.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, schema.Properties["labels"])
+	require.NotContains(t, schema.Properties, "Hidden")
+
+	require.Equal(t, []string{"DELETE /items/:id"}, Unregistered(ops, routes))
+	require.Equal(t, []string{"GET /"}, spec.Undescribed(routes))
+}
@@ -0,0 +1,176 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Schema is the schema of a value, a subset of the OpenAPI schema object.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	binaryType        = reflect.TypeFor[Binary]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// schemaSet derives the schemas of Go types as encoding/json encodes them.
+// Named struct types are added to the components of the document and
+// referenced.
+type schemaSet struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+	types   map[string]reflect.Type
+}
+
+func newSchemaSet(schemas map[string]*Schema) *schemaSet {
+	return &schemaSet{
+		schemas: schemas,
+		names:   map[reflect.Type]string{},
+		types:   map[string]reflect.Type{},
+	}
+}
+
+// of returns the schema of the type of v.
+func (s *schemaSet) of(v any) *Schema {
+	return s.schema(reflect.TypeOf(v))
+}
+
+func (s *schemaSet) schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case binaryType:
+		return &Schema{Type: "string", Format: "binary"}
+	}
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface {
+		if reflect.PointerTo(t).Implements(textMarshalerType) && !reflect.PointerTo(t).Implements(jsonMarshalerType) {
+			return &Schema{Type: "string"}
+		}
+		if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+			// encoded by the type itself, e.g. big numbers or raw JSON
+			return &Schema{}
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem := s.schema(t.Elem())
+		if elem.Ref != "" {
+			return elem
+		}
+		nullable := *elem
+		nullable.Nullable = true
+		return &nullable
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.ref(t)
+	default:
+		// interfaces hold any value
+		return &Schema{}
+	}
+}
+
+// ref returns a reference to the schema of the named struct type, adding it
+// to the components on first use.
+func (s *schemaSet) ref(t reflect.Type) *Schema {
+	if name, ok := s.names[t]; ok {
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	name := schemaName(t.Name())
+	if _, taken := s.types[name]; taken {
+		// types of different APIs may share a name
+		name = schemaName(strings.TrimPrefix(t.PkgPath(), "github.com/storacha/piri/") + "." + t.Name())
+	}
+	s.names[t] = name
+	s.types[name] = t
+	// register the name before the fields, which may refer to the type
+	s.schemas[name] = &Schema{}
+	*s.schemas[name] = *s.object(t)
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (s *schemaSet) object(t reflect.Type) *Schema {
+	obj := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.fields(obj, t)
+	return obj
+}
+
+func (s *schemaSet) fields(obj *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// fields of embedded structs are promoted
+				s.fields(obj, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := s.schema(f.Type)
+		if strings.Contains(opts, "string") && fs.Type != "" && fs.Type != "string" {
+			fs = &Schema{Type: "string"}
+		}
+		obj.Properties[name] = fs
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			obj.Required = append(obj.Required, name)
+		}
+	}
+}
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// schemaName returns a valid component name, type names of generic types
+// hold brackets.
+func schemaName(name string) string {
+	return invalidNameChars.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,109 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/boxo/blockstore"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// MaxBlockSize is the size of the largest blob served over Bitswap. Bitswap
+// messages are limited to 4MiB, clients refuse larger blocks, so larger blobs
+// are only served over HTTP.
+const MaxBlockSize = 2 << 20
+
+// ErrReadOnly is returned when writing to or deleting from a [Blockstore].
+var ErrReadOnly = errors.New("blockstore is read only")
+
+// Blockstore is a read only [blockstore.Blockstore] serving blobs as blocks.
+// The block of a CID is the blob with its multihash, whatever the codec of the
+// CID, so blobs are retrievable by raw or CAR CID.
+type Blockstore struct {
+	blobs blobstore.BlobGetter
+}
+
+var _ blockstore.Blockstore = (*Blockstore)(nil)
+
+// NewBlockstore creates a Blockstore serving the blobs of the blob store.
+func NewBlockstore(blobs blobstore.BlobGetter) *Blockstore {
+	return &Blockstore{blobs: blobs}
+}
+
+func (bs *Blockstore) Has(ctx context.Context, c cid.Cid) (bool, error) {
+	_, err := bs.GetSize(ctx, c)
+	if err != nil {
+		if ipld.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (bs *Blockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	obj, err := bs.get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	body := obj.Body()
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("reading blob %s: %w", c, err)
+	}
+	return blocks.NewBlockWithCid(data, c)
+}
+
+func (bs *Blockstore) GetSize(ctx context.Context, c cid.Cid) (int, error) {
+	obj, err := bs.get(ctx, c)
+	if err != nil {
+		return -1, err
+	}
+	obj.Body().Close()
+	return int(obj.Size()), nil
+}
+
+// get returns the blob of the CID, not found if it is larger than
+// [MaxBlockSize].
+func (bs *Blockstore) get(ctx context.Context, c cid.Cid) (blobstore.Object, error) {
+	obj, err := bs.blobs.Get(ctx, c.Hash())
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ipld.ErrNotFound{Cid: c}
+		}
+		return nil, fmt.Errorf("getting blob %s: %w", c, err)
+	}
+	if obj.Size() > MaxBlockSize {
+		obj.Body().Close()
+		return nil, ipld.ErrNotFound{Cid: c}
+	}
+	return obj, nil
+}
+
+func (bs *Blockstore) Put(context.Context, blocks.Block) error {
+	return ErrReadOnly
+}
+
+func (bs *Blockstore) PutMany(context.Context, []blocks.Block) error {
+	return ErrReadOnly
+}
+
+func (bs *Blockstore) DeleteBlock(context.Context, cid.Cid) error {
+	return ErrReadOnly
+}
+
+// AllKeysChan is not supported, blobs are only served by CID.
+func (bs *Blockstore) AllKeysChan(context.Context) (<-chan cid.Cid, error) {
+	return nil, errors.New("listing blocks is not supported")
+}
+
+// HashOnRead is a no-op, Bitswap clients verify the blocks they receive.
+func (bs *Blockstore) HashOnRead(bool) {}
@@ -0,0 +1,75 @@
+package p2p
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+func TestBlockstore(t *testing.T) {
+	blobs := blobstore.NewDatastoreStore(datastore.NewMapDatastore())
+	bs := NewBlockstore(blobs)
+
+	put := func(t *testing.T, size int) multihash.Multihash {
+		t.Helper()
+		data := testutil.RandomBytes(t, size)
+		digest, err := multihash.Sum(data, multihash.SHA2_256, -1)
+		require.NoError(t, err)
+		require.NoError(t, blobs.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data)))
+		return digest
+	}
+
+	t.Run("serves blobs by CID", func(t *testing.T) {
+		digest := put(t, 1024)
+		for _, codec := range []uint64{cid.Raw, 0x0202 /* CAR */} {
+			c := cid.NewCidV1(codec, digest)
+
+			has, err := bs.Has(t.Context(), c)
+			require.NoError(t, err)
+			require.True(t, has)
+
+			size, err := bs.GetSize(t.Context(), c)
+			require.NoError(t, err)
+			require.Equal(t, 1024, size)
+
+			blk, err := bs.Get(t.Context(), c)
+			require.NoError(t, err)
+			require.Equal(t, c, blk.Cid())
+			require.Len(t, blk.RawData(), 1024)
+		}
+	})
+
+	t.Run("does not serve blobs larger than the max block size", func(t *testing.T) {
+		c := cid.NewCidV1(cid.Raw, put(t, MaxBlockSize+1))
+
+		has, err := bs.Has(t.Context(), c)
+		require.NoError(t, err)
+		require.False(t, has)
+
+		_, err = bs.Get(t.Context(), c)
+		require.True(t, ipld.IsNotFound(err))
+	})
+
+	t.Run("missing blobs are not found", func(t *testing.T) {
+		c := cid.NewCidV1(cid.Raw, testutil.RandomMultihash(t))
+
+		has, err := bs.Has(t.Context(), c)
+		require.NoError(t, err)
+		require.False(t, has)
+
+		_, err = bs.Get(t.Context(), c)
+		require.True(t, ipld.IsNotFound(err))
+	})
+
+	t.Run("is read only", func(t *testing.T) {
+		require.ErrorIs(t, bs.DeleteBlock(t.Context(), cid.NewCidV1(cid.Raw, testutil.RandomMultihash(t))), ErrReadOnly)
+	})
+}
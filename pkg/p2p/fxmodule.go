@@ -0,0 +1,57 @@
+package p2p
+
+import (
+	"context"
+
+	"github.com/storacha/go-ucanto/principal"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/blocklist"
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/privatespace"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// Module provides the libp2p Node serving blobs over Bitswap, or a nil Node
+// if it is disabled.
+var Module = fx.Module("p2p",
+	fx.Provide(NewFromConfig),
+	// force construction, the node serves blobs whether or not its addresses
+	// are advertised
+	fx.Invoke(func(*Node) {}),
+)
+
+type Params struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Config    app.ServerConfig
+	ID        principal.Signer
+	Blobs     blobstore.Blobstore
+	Blocklist *blocklist.List      `optional:"true"`
+	Private   *privatespace.Policy `optional:"true"`
+}
+
+// NewFromConfig creates the libp2p Node, if enabled. Blocked blobs and blobs
+// of private spaces are not served.
+func NewFromConfig(params Params) (*Node, error) {
+	if !params.Config.Libp2p.Enabled {
+		return nil, nil
+	}
+	node, err := New(params.ID, params.Private.BlobGetter(params.Blocklist.BlobGetter(params.Blobs)), params.Config.Libp2p)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	params.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return node.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			return node.Stop(ctx)
+		},
+	})
+	return node, nil
+}
@@ -0,0 +1,101 @@
+// Package p2p serves blobs to IPFS clients and other nodes over Bitswap, from
+// a libp2p host using the node identity as its peer ID.
+//
+// Blobs are served as blocks keyed by their multihash, so a blob is
+// retrievable by any CID of its digest. Blobs larger than [MaxBlockSize] are
+// only retrievable over HTTP.
+package p2p
+
+import (
+	"context"
+	"fmt"
+
+	bsnet "github.com/ipfs/boxo/bitswap/network"
+	bsserver "github.com/ipfs/boxo/bitswap/server"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/storacha/go-ucanto/principal"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/hwsigner"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+var log = logging.Logger("p2p")
+
+// Node is a libp2p host serving blobs over Bitswap.
+type Node struct {
+	host    host.Host
+	network bsnet.BitSwapNetwork
+	bstore  *Blockstore
+	server  *bsserver.Server
+	addrs   []multiaddr.Multiaddr
+}
+
+// New creates a Node listening on the configured addresses. Blobs are served
+// once the node is started.
+func New(id principal.Signer, blobs blobstore.BlobGetter, cfg app.Libp2pConfig) (*Node, error) {
+	priv, err := hwsigner.PrivKey(id)
+	if err != nil {
+		return nil, err
+	}
+	h, err := libp2p.New(
+		libp2p.Identity(priv),
+		libp2p.ListenAddrs(cfg.ListenAddrs...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating libp2p host: %w", err)
+	}
+
+	addrs := cfg.AnnounceAddrs
+	if len(addrs) == 0 {
+		addrs = h.Addrs()
+		log.Warnw("no libp2p announce addresses configured, advertising the listen addresses of the host", "addrs", addrs)
+	}
+
+	return &Node{
+		host:    h,
+		network: bsnet.NewFromIpfsHost(h, nil),
+		bstore:  NewBlockstore(blobs),
+		addrs:   addrs,
+	}, nil
+}
+
+// ID returns the peer ID of the node.
+func (n *Node) ID() peer.ID {
+	return n.host.ID()
+}
+
+// Addrs returns the addresses of the node advertised to indexers.
+func (n *Node) Addrs() []multiaddr.Multiaddr {
+	return n.addrs
+}
+
+// Start starts serving blobs over Bitswap.
+func (n *Node) Start(ctx context.Context) error {
+	n.server = bsserver.New(ctx, n.network, n.bstore)
+	n.network.Start(receiver{n.server})
+	log.Infow("Serving blobs over Bitswap", "peer", n.host.ID(), "addrs", n.host.Addrs())
+	return nil
+}
+
+// Stop stops serving blobs and closes the host.
+func (n *Node) Stop(context.Context) error {
+	if n.server != nil {
+		n.network.Stop()
+		n.server.Close()
+	}
+	return n.host.Close()
+}
+
+// receiver passes the messages received by the Bitswap network to the server.
+type receiver struct {
+	*bsserver.Server
+}
+
+func (r receiver) ReceiveError(err error) {
+	log.Debugw("Bitswap network error", "error", err)
+}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log/v2"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
 	captypes "github.com/storacha/go-libstoracha/capabilities/types"
 	"github.com/storacha/go-libstoracha/piece/piece"
 	"go.opentelemetry.io/otel/attribute"
@@ -22,9 +24,14 @@ import (
 	"github.com/storacha/piri/lib/jobqueue/dialect"
 	"github.com/storacha/piri/lib/jobqueue/serializer"
 	"github.com/storacha/piri/lib/jobqueue/traceutil"
+	"github.com/storacha/piri/pkg/config"
 	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/config/dynamic"
 	"github.com/storacha/piri/pkg/pdp/aggregation/manager"
 	"github.com/storacha/piri/pkg/pdp/aggregation/types"
+	apitypes "github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/piecelog"
+	"github.com/storacha/piri/pkg/storageclass"
 )
 
 var log = logging.Logger("aggregation/aggregator")
@@ -78,6 +85,10 @@ type QueueParams struct {
 	fx.In
 	DB            *sql.DB `name:"aggregator_db"`
 	StorageConfig app.StorageConfig
+	PDPConfig     app.PDPServiceConfig
+	// Registry is optional, with it the number of workers can be changed
+	// while the queue runs.
+	Registry *dynamic.Registry `optional:"true"`
 }
 
 func NewQueue(params QueueParams) (jobqueue.Service[piece.PieceLink], error) {
@@ -95,6 +106,11 @@ func NewQueue(params QueueParams) (jobqueue.Service[piece.PieceLink], error) {
 	dedupEnabled := true
 	// Allow jobs in dead letter queue (failed) to run again.
 	blockDLQRetries := false
+	workers := params.PDPConfig.Aggregation.Aggregator.JobQueue.Workers
+	if workers == 0 {
+		workers = uint(runtime.NumCPU())
+	}
+	jobsPerMinute := params.PDPConfig.Aggregation.Aggregator.JobQueue.JobsPerMinute
 	linkQueue, err := jobqueue.New[piece.PieceLink](
 		QueueName,
 		params.DB,
@@ -104,8 +120,8 @@ func NewQueue(params QueueParams) (jobqueue.Service[piece.PieceLink], error) {
 		},
 		jobqueue.WithLogger(log.With("queue", QueueName)),
 		jobqueue.WithMaxRetries(50),
-		// one worker to keep things serial
-		jobqueue.WithMaxWorkers(uint(runtime.NumCPU())),
+		jobqueue.WithMaxWorkers(workers),
+		jobqueue.WithRateLimit(jobsPerMinute),
 		// one filecoin epoch since this is wrongly running tasks, we need yet another queue.....
 		jobqueue.WithMaxTimeout(30*time.Second),
 		jobqueue.WithDialect(d),
@@ -118,6 +134,19 @@ func NewQueue(params QueueParams) (jobqueue.Service[piece.PieceLink], error) {
 	if err != nil {
 		return nil, fmt.Errorf("creating aggregator job-queue: %w", err)
 	}
+
+	if params.Registry != nil {
+		if err := params.Registry.BindUint(config.AggregatorJobQueueWorkers, workers, dynamic.UintSchema{Min: 1, Max: 1024}, func(n uint) {
+			if err := linkQueue.SetMaxWorkers(n); err != nil {
+				log.Warnw("changing aggregator queue workers", "workers", n, "error", err)
+			}
+		}); err != nil {
+			return nil, fmt.Errorf("registering aggregator queue workers: %w", err)
+		}
+		if err := params.Registry.BindUint(config.AggregatorJobQueueRateLimit, jobsPerMinute, dynamic.UintSchema{Min: 0, Max: 60000}, linkQueue.SetRateLimit); err != nil {
+			return nil, fmt.Errorf("registering aggregator queue rate limit: %w", err)
+		}
+	}
 	return linkQueue, nil
 }
 
@@ -126,6 +155,11 @@ type HandlerParams struct {
 	Store     types.Store
 	Datastore datastore.Datastore `name:"aggregator_datastore"`
 	Manager   *manager.Manager
+	PieceLog  *piecelog.Log
+	// Classes and Resolver are optional, without them all pieces are
+	// aggregated in the default class.
+	Classes  *storageclass.Manager     `optional:"true"`
+	Resolver apitypes.PieceResolverAPI `optional:"true"`
 }
 
 func NewHandler(params HandlerParams) jobqueue.TaskHandler[piece.PieceLink] {
@@ -133,6 +167,9 @@ func NewHandler(params HandlerParams) jobqueue.TaskHandler[piece.PieceLink] {
 		workspace: newInProgressWorkspace(params.Datastore),
 		store:     params.Store,
 		manager:   params.Manager,
+		pieces:    params.PieceLog,
+		classes:   params.Classes,
+		resolver:  params.Resolver,
 	}
 }
 
@@ -140,6 +177,9 @@ type Handler struct {
 	workspace InProgressWorkspace
 	store     types.Store
 	manager   *manager.Manager
+	pieces    *piecelog.Log
+	classes   *storageclass.Manager
+	resolver  apitypes.PieceResolverAPI
 }
 
 func (p *Handler) Handle(ctx context.Context, piece piece.PieceLink) (retErr error) {
@@ -152,8 +192,14 @@ func (p *Handler) Handle(ctx context.Context, piece piece.PieceLink) (retErr err
 		span.End()
 	}()
 
-	log.Infow("aggregating piece", "link", piece.Link())
-	buffer, err := p.workspace.GetBuffer(ctx)
+	class, err := p.classOf(ctx, piece)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.String("storage.class", class))
+
+	log.Infow("aggregating piece", "link", piece.Link(), "class", class)
+	buffer, err := p.workspace.GetBuffer(ctx, class)
 	if err != nil {
 		return fmt.Errorf("reading in progress pieces from work space: %w", err)
 	}
@@ -161,7 +207,7 @@ func (p *Handler) Handle(ctx context.Context, piece piece.PieceLink) (retErr err
 	if err != nil {
 		return fmt.Errorf("calculating aggegates: %w", err)
 	}
-	if err := p.workspace.PutBuffer(ctx, buffer); err != nil {
+	if err := p.workspace.PutBuffer(ctx, class, buffer); err != nil {
 		return fmt.Errorf("updating work space: %w", err)
 	}
 	if a != nil {
@@ -169,6 +215,20 @@ func (p *Handler) Handle(ctx context.Context, piece piece.PieceLink) (retErr err
 		if err := p.store.Put(ctx, a.Root.Link(), *a); err != nil {
 			return fmt.Errorf("storing aggregate: %w", err)
 		}
+		if p.classes != nil {
+			// recorded before submitting, the proof set of the aggregate is
+			// chosen by its class
+			if err := p.classes.RecordAggregate(ctx, a.Root.Link(), class); err != nil {
+				return fmt.Errorf("recording storage class of aggregate: %w", err)
+			}
+		}
+		pieceLinks := make([]datamodel.Link, 0, len(a.Pieces))
+		for _, ap := range a.Pieces {
+			pieceLinks = append(pieceLinks, ap.Link.Link())
+		}
+		if err := p.pieces.AppendAggregated(ctx, a.Root.Link(), pieceLinks); err != nil {
+			log.Warnw("recording aggregate in piece log", "aggregate", a.Root.Link(), "error", err)
+		}
 		if err := p.manager.Submit(ctx, a.Root.Link()); err != nil {
 			return fmt.Errorf("submitting aggregate to manager: %w", err)
 		}
@@ -180,6 +240,32 @@ func (p *Handler) Name() string {
 	return TaskName
 }
 
+// classOf returns the storage class of the blob the piece was computed from.
+// Pieces of blobs with no recorded class are in the default class.
+func (p *Handler) classOf(ctx context.Context, piece piece.PieceLink) (string, error) {
+	if p.classes == nil {
+		return storageclass.Standard, nil
+	}
+	if p.resolver == nil {
+		return p.classes.Default().Name, nil
+	}
+	blob, found, err := p.resolver.ResolveToBlob(ctx, piece.Link().(cidlink.Link).Cid.Hash())
+	if err != nil {
+		return "", fmt.Errorf("resolving blob of piece: %w", err)
+	}
+	if !found {
+		return p.classes.Default().Name, nil
+	}
+	class, ok, err := p.classes.ClassOf(ctx, blob)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return p.classes.Default().Name, nil
+	}
+	return class, nil
+}
+
 // MinAggregateSize is 128MB
 // Max size is 256MB -- this means we will never see an individual piece larger
 // than 256MB -- the upload will fail otherwise
@@ -9,32 +9,45 @@ import (
 	"github.com/storacha/go-libstoracha/ipnipublisher/store"
 	"github.com/storacha/piri/internal/ipldstore"
 	"github.com/storacha/piri/pkg/pdp/aggregation/types"
+	"github.com/storacha/piri/pkg/storageclass"
 )
 
+// InProgressWorkspace holds the pieces not yet aggregated, in a buffer per
+// storage class so aggregates never mix classes.
 type InProgressWorkspace interface {
-	GetBuffer(context.Context) (types.Buffer, error)
-	PutBuffer(context.Context, types.Buffer) error
+	GetBuffer(ctx context.Context, class string) (types.Buffer, error)
+	PutBuffer(ctx context.Context, class string, buffer types.Buffer) error
 }
 
-type bufferKey struct{}
+type bufferKey struct {
+	class string
+}
 
-func (bufferKey) String() string { return "buffer" }
+// String keeps the key of the standard class, which was the only buffer
+// before storage classes, so pieces buffered before an upgrade are kept.
+func (k bufferKey) String() string {
+	if k.class == "" || k.class == storageclass.Standard {
+		return "buffer"
+	}
+	return "buffer-" + k.class
+}
 
 type inProgressWorkSpace struct {
 	store ipldstore.KVStore[bufferKey, types.Buffer]
 }
 
-func (i *inProgressWorkSpace) GetBuffer(ctx context.Context) (types.Buffer, error) {
-	buf, err := i.store.Get(ctx, bufferKey{})
+func (i *inProgressWorkSpace) GetBuffer(ctx context.Context, class string) (types.Buffer, error) {
+	key := bufferKey{class}
+	buf, err := i.store.Get(ctx, key)
 	if store.IsNotFound(err) {
-		err := i.store.Put(ctx, bufferKey{}, types.Buffer{})
+		err := i.store.Put(ctx, key, types.Buffer{})
 		return types.Buffer{}, err
 	}
 	return buf, err
 }
 
-func (i *inProgressWorkSpace) PutBuffer(ctx context.Context, buffer types.Buffer) error {
-	return i.store.Put(ctx, bufferKey{}, buffer)
+func (i *inProgressWorkSpace) PutBuffer(ctx context.Context, class string, buffer types.Buffer) error {
+	return i.store.Put(ctx, bufferKey{class}, buffer)
 }
 
 const WorkspaceKey = "workspace/"
@@ -20,15 +20,22 @@ import (
 	"github.com/storacha/piri/lib/jobqueue/dialect"
 	"github.com/storacha/piri/lib/jobqueue/serializer"
 	"github.com/storacha/piri/lib/jobqueue/traceutil"
+	"github.com/storacha/piri/pkg/config"
 	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/config/dynamic"
 	"github.com/storacha/piri/pkg/pdp/aggregation/aggregator"
 	"github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/piecelog"
 )
 
 type CommpQueueParams struct {
 	fx.In
 	DB            *sql.DB `name:"aggregator_db"`
 	StorageConfig app.StorageConfig
+	PDPConfig     app.PDPServiceConfig
+	// Registry is optional, with it the number of workers can be changed
+	// while the queue runs.
+	Registry *dynamic.Registry `optional:"true"`
 }
 
 const (
@@ -43,6 +50,12 @@ func NewQueue(params CommpQueueParams) (jobqueue.Service[multihash.Multihash], e
 		d = dialect.Postgres
 	}
 
+	workers := params.PDPConfig.Aggregation.CommP.JobQueue.Workers
+	if workers == 0 {
+		workers = uint(runtime.NumCPU())
+	}
+	jobsPerMinute := params.PDPConfig.Aggregation.CommP.JobQueue.JobsPerMinute
+
 	var commpQueue, err = jobqueue.New[multihash.Multihash](
 		TaskName,
 		params.DB,
@@ -53,23 +66,38 @@ func NewQueue(params CommpQueueParams) (jobqueue.Service[multihash.Multihash], e
 		jobqueue.WithLogger(log.With("queue", QueueName)),
 		// TODO(forrest) make these configuration parameters.
 		jobqueue.WithMaxRetries(50),
-		jobqueue.WithMaxWorkers(uint(runtime.NumCPU())),
+		jobqueue.WithMaxWorkers(workers),
+		jobqueue.WithRateLimit(jobsPerMinute),
 		jobqueue.WithDialect(d),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("creating commp queue: %w", err)
 	}
 
+	if params.Registry != nil {
+		if err := params.Registry.BindUint(config.CommPJobQueueWorkers, workers, dynamic.UintSchema{Min: 1, Max: 1024}, func(n uint) {
+			if err := commpQueue.SetMaxWorkers(n); err != nil {
+				log.Warnw("changing commp queue workers", "workers", n, "error", err)
+			}
+		}); err != nil {
+			return nil, fmt.Errorf("registering commp queue workers: %w", err)
+		}
+		if err := params.Registry.BindUint(config.CommPJobQueueRateLimit, jobsPerMinute, dynamic.UintSchema{Min: 0, Max: 60000}, commpQueue.SetRateLimit); err != nil {
+			return nil, fmt.Errorf("registering commp queue rate limit: %w", err)
+		}
+	}
+
 	return commpQueue, nil
 }
 
-func NewHandler(api types.PieceAPI, a *aggregator.Aggregator) jobqueue.TaskHandler[multihash.Multihash] {
-	return &ComperTaskHandler{api: api, aggregator: a}
+func NewHandler(api types.PieceAPI, a *aggregator.Aggregator, pieces *piecelog.Log) jobqueue.TaskHandler[multihash.Multihash] {
+	return &ComperTaskHandler{api: api, aggregator: a, pieces: pieces}
 }
 
 type ComperTaskHandler struct {
 	api        types.PieceAPI
 	aggregator *aggregator.Aggregator
+	pieces     *piecelog.Log
 }
 
 func (h *ComperTaskHandler) Handle(ctx context.Context, blob multihash.Multihash) error {
@@ -97,6 +125,12 @@ func (h *ComperTaskHandler) Handle(ctx context.Context, blob multihash.Multihash
 	}
 	span.AddEvent("parked piece")
 
+	// link the piece to its blob so the events of its aggregate are recorded
+	// in the lifecycle of the blob
+	if err := h.pieces.LinkPiece(ctx, cidlink.Link{Cid: res.PieceCID}, blob); err != nil {
+		log.Warnw("linking piece in piece log", "blob", blob.String(), "piece", res.PieceCID.String(), "error", err)
+	}
+
 	p, err := piece.FromLink(cidlink.Link{Cid: res.PieceCID})
 	if err != nil {
 		span.RecordError(err)
@@ -1,11 +1,18 @@
 package aggregation
 
 import (
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/piece/piece"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/lib/jobqueue"
+	"github.com/storacha/piri/pkg/diagnostics"
 	"github.com/storacha/piri/pkg/pdp/aggregation/aggregator"
 	"github.com/storacha/piri/pkg/pdp/aggregation/commp"
 	"github.com/storacha/piri/pkg/pdp/aggregation/manager"
 	"github.com/storacha/piri/pkg/pdp/aggregation/types"
-	"go.uber.org/fx"
+	"github.com/storacha/piri/pkg/subsystem"
 )
 
 var Module = fx.Module("aggregation",
@@ -14,5 +21,40 @@ var Module = fx.Module("aggregation",
 	manager.Module,
 	fx.Provide(
 		types.NewStore,
+		fx.Annotate(
+			func(q jobqueue.Service[multihash.Multihash]) jobqueue.Snapshotter { return snapshotter(q) },
+			fx.ResultTags(diagnostics.QueueGroup),
+		),
+		fx.Annotate(
+			func(q jobqueue.Service[piece.PieceLink]) jobqueue.Snapshotter { return snapshotter(q) },
+			fx.ResultTags(diagnostics.QueueGroup),
+		),
+		fx.Annotate(
+			func(q jobqueue.Service[[]datamodel.Link]) jobqueue.Snapshotter { return snapshotter(q) },
+			fx.ResultTags(diagnostics.QueueGroup),
+		),
 	),
+	fx.Invoke(RegisterSubsystem),
 )
+
+// snapshotter returns q as a jobqueue.Snapshotter, nil if q can not report
+// its state.
+func snapshotter(q any) jobqueue.Snapshotter {
+	s, _ := q.(jobqueue.Snapshotter)
+	return s
+}
+
+// RegisterSubsystem allows operators to pause the aggregation job queues
+// through the admin API. Pieces are still accepted and queued while paused.
+func RegisterSubsystem(
+	r *subsystem.Registry,
+	commpQueue jobqueue.Service[multihash.Multihash],
+	aggregatorQueue jobqueue.Service[piece.PieceLink],
+	managerQueue jobqueue.Service[[]datamodel.Link],
+) {
+	for _, q := range []any{commpQueue, aggregatorQueue, managerQueue} {
+		if p, ok := q.(subsystem.Pausable); ok {
+			r.Register(subsystem.Aggregation, p)
+		}
+	}
+}
@@ -54,11 +54,13 @@ func (aggBufferKey) String() string { return "aggregate_buffer" }
 type submissionWorkspace struct {
 	storeMu sync.RWMutex
 	store   ipldstore.KVStore[aggBufferKey, Aggregation]
+	metrics *Metrics
 }
 
 type SubmissionWorkspaceParams struct {
 	fx.In
 	Datastore datastore.Datastore `name:"aggregator_datastore"`
+	Metrics   *Metrics
 }
 
 const ManagerKey = "manager/"
@@ -72,6 +74,7 @@ func NewSubmissionWorkspace(params SubmissionWorkspaceParams) (BufferStore, erro
 			bufferTS.TypeByName("Aggregates"),
 			types.Converters...,
 		),
+		metrics: params.Metrics,
 	}
 
 	// Initialize empty buffer at creation time to avoid race conditions
@@ -80,10 +83,10 @@ func NewSubmissionWorkspace(params SubmissionWorkspaceParams) (BufferStore, erro
 	emptyBuffer := Aggregation{
 		Roots: []datamodel.Link{},
 	}
-	err := sw.store.Put(ctx, aggBufferKey{}, emptyBuffer)
-	if err != nil {
+	if err := sw.store.Put(ctx, aggBufferKey{}, emptyBuffer); err != nil {
 		return nil, fmt.Errorf("putting empty buffer: %w", err)
 	}
+	sw.metrics.bufferRoots.Record(ctx, 0)
 
 	return sw, nil
 }
@@ -126,6 +129,7 @@ func (sw *submissionWorkspace) AppendRoots(ctx context.Context, aggregates []dat
 	if err := sw.store.Put(ctx, aggBufferKey{}, buffer); err != nil {
 		return fmt.Errorf("saving buffer after append: %w", err)
 	}
+	sw.metrics.bufferRoots.Record(ctx, int64(len(buffer.Roots)))
 
 	return nil
 }
@@ -135,7 +139,11 @@ func (sw *submissionWorkspace) ClearRoots(ctx context.Context) error {
 	sw.storeMu.Lock()
 	defer sw.storeMu.Unlock()
 
-	return sw.store.Put(ctx, aggBufferKey{}, Aggregation{
+	if err := sw.store.Put(ctx, aggBufferKey{}, Aggregation{
 		Roots: []datamodel.Link{},
-	})
+	}); err != nil {
+		return err
+	}
+	sw.metrics.bufferRoots.Record(ctx, 0)
+	return nil
 }
@@ -7,8 +7,10 @@ import (
 var Module = fx.Module("aggregation/manager",
 	fx.Provide(
 		NewConfigProvider,
+		NewMetrics,
 		NewManager,
 		NewSubmissionWorkspace,
+		NewProofSetChecker,
 		NewAddRootsTaskHandler,
 		NewPieceAccepter,
 		NewQueue,
@@ -18,9 +18,13 @@ import (
 	"github.com/storacha/piri/lib/jobqueue/dialect"
 	"github.com/storacha/piri/lib/jobqueue/serializer"
 	"github.com/storacha/piri/lib/jobqueue/traceutil"
+	"github.com/storacha/piri/pkg/config"
 	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/config/dynamic"
 	"github.com/storacha/piri/pkg/pdp/aggregation/types"
+	"github.com/storacha/piri/pkg/pdp/proofset"
 	pdptypes "github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/piecelog"
 )
 
 const (
@@ -28,26 +32,33 @@ const (
 	HandlerName = "add_roots"
 )
 
-// NewAddRootsTaskHandler creates a TaskHandler that submits aggregate roots to the PDP Service
+// NewAddRootsTaskHandler creates a TaskHandler that submits aggregate roots
+// to the backend proving the proof set of each aggregate
 func NewAddRootsTaskHandler(
-	api pdptypes.ProofSetAPI,
-	proofSet pdptypes.ProofSetIDProvider,
+	api proofset.RootAdder,
+	proofSets proofset.Selector,
 	store types.Store,
 	accepter *PieceAcceptor,
-) jobqueue.TaskHandler[[]datamodel.Link] {
+	pieces *piecelog.Log,
+	metrics *Metrics,
+) (jobqueue.TaskHandler[[]datamodel.Link], error) {
 	return &AddRootsTaskHandler{
 		api:           api,
-		proofSet:      proofSet,
+		proofSets:     proofSets,
 		store:         store,
 		pieceAcceptor: accepter,
-	}
+		pieces:        pieces,
+		metrics:       metrics,
+	}, nil
 }
 
 type AddRootsTaskHandler struct {
-	api           pdptypes.ProofSetAPI
-	proofSet      pdptypes.ProofSetIDProvider
+	api           proofset.RootAdder
+	proofSets     proofset.Selector
 	store         types.Store
 	pieceAcceptor *PieceAcceptor
+	pieces        *piecelog.Log
+	metrics       *Metrics
 }
 
 func (a *AddRootsTaskHandler) Name() string {
@@ -56,11 +67,15 @@ func (a *AddRootsTaskHandler) Name() string {
 
 func (a *AddRootsTaskHandler) Handle(ctx context.Context, links []datamodel.Link) (retErr error) {
 	ctx, span := traceutil.StartSpan(ctx, tracer, "manager.Handle")
+	stopwatch := a.metrics.submitDuration.Start()
 	defer func() {
+		status := "success"
 		if retErr != nil {
+			status = "failure"
 			span.RecordError(retErr)
 			span.SetStatus(codes.Error, "failed to submit aggregation")
 		}
+		stopwatch.Stop(ctx, attribute.String("status", status))
 		span.End()
 	}()
 
@@ -69,47 +84,66 @@ func (a *AddRootsTaskHandler) Handle(ctx context.Context, links []datamodel.Link
 	}
 	span.AddEvent("accepted pieces")
 
-	proofSetID, err := a.proofSet.ProofSetID(ctx)
-	if err != nil {
-		return fmt.Errorf("getting proof set ID from proof set provider: %w", err)
-	}
-	span.SetAttributes(attribute.Int64("dataset.id", int64(proofSetID)))
-
-	// build the set of roots we will add
-	roots := make([]pdptypes.RootAdd, len(links))
-	for i, aggregateLink := range links {
+	// build the roots to add, grouped by the proof set each aggregate goes to
+	var proofSetIDs []uint64
+	roots := map[uint64][]pdptypes.RootAdd{}
+	aggregates := map[uint64][]datamodel.Link{}
+	for _, aggregateLink := range links {
 		// fetch each aggregate to submit
-		a, err := a.store.Get(ctx, aggregateLink)
+		agg, err := a.store.Get(ctx, aggregateLink)
 		if err != nil {
 			return fmt.Errorf("reading aggregates: %w", err)
 		}
+		proofSetID, err := a.proofSets.SelectProofSet(ctx, agg)
+		if err != nil {
+			return fmt.Errorf("selecting proof set for aggregate %s: %w", aggregateLink, err)
+		}
 		// record its root
-		rootCID, err := cid.Decode(a.Root.Link().String())
+		rootCID, err := cid.Decode(agg.Root.Link().String())
 		if err != nil {
 			return fmt.Errorf("failed to decode aggregate root CID: %w", err)
 		}
 		// subroots
-		subRoots := make([]cid.Cid, len(a.Pieces))
-		for j, p := range a.Pieces {
+		subRoots := make([]cid.Cid, len(agg.Pieces))
+		for j, p := range agg.Pieces {
 			pcid, err := cid.Decode(p.Link.Link().String())
 			if err != nil {
 				return fmt.Errorf("failed to decode piece CID: %w", err)
 			}
 			subRoots[j] = pcid
 		}
-		roots[i] = pdptypes.RootAdd{
+		if _, ok := roots[proofSetID]; !ok {
+			proofSetIDs = append(proofSetIDs, proofSetID)
+		}
+		roots[proofSetID] = append(roots[proofSetID], pdptypes.RootAdd{
 			Root:     rootCID,
 			SubRoots: subRoots,
-		}
-		log.Infow("root aggregate added", "root", aggregateLink.String())
+		})
+		aggregates[proofSetID] = append(aggregates[proofSetID], aggregateLink)
+		log.Infow("root aggregate added", "root", aggregateLink.String(), "proof_set", proofSetID)
 	}
 
-	txHash, err := a.api.AddRoots(ctx, proofSetID, roots)
-	if err != nil {
-		return fmt.Errorf("adding roots: %w", err)
+	// AddRoots skips roots already added to the proof set, so a retry after a
+	// partial failure does not add them twice.
+	for _, proofSetID := range proofSetIDs {
+		txHash, err := a.api.AddRoots(ctx, proofSetID, roots[proofSetID])
+		if err != nil {
+			return fmt.Errorf("adding roots to proof set %d: %w", proofSetID, err)
+		}
+		span.AddEvent("added roots", trace.WithAttributes(
+			attribute.Stringer("tx", txHash),
+			attribute.Int64("dataset.id", int64(proofSetID)),
+		))
+		log.Infow("added roots", "count", len(roots[proofSetID]), "proof_set", proofSetID, "tx", txHash)
+		for _, aggregateLink := range aggregates[proofSetID] {
+			if err := a.pieces.AppendAggregate(ctx, aggregateLink, piecelog.Event{
+				Kind:     piecelog.Submitted,
+				ProofSet: proofSetID,
+			}); err != nil {
+				log.Warnw("recording submission in piece log", "aggregate", aggregateLink, "error", err)
+			}
+		}
 	}
-	span.AddEvent("added roots", trace.WithAttributes(attribute.Stringer("tx", txHash)))
-	log.Infow("added roots", "count", len(roots), "tx", txHash)
 
 	return nil
 }
@@ -118,6 +152,10 @@ type QueueParams struct {
 	fx.In
 	DB            *sql.DB `name:"aggregator_db"`
 	StorageConfig app.StorageConfig
+	Config        app.AggregateManagerConfig
+	// Registry is optional, with it the number of workers can be changed
+	// while the queue runs.
+	Registry *dynamic.Registry `optional:"true"`
 }
 
 func NewQueue(params QueueParams) (jobqueue.Service[[]datamodel.Link], error) {
@@ -127,6 +165,13 @@ func NewQueue(params QueueParams) (jobqueue.Service[[]datamodel.Link], error) {
 		d = dialect.Postgres
 	}
 
+	// 3 workers means 3 roots can be added in parallel.
+	workers := params.Config.JobQueue.Workers
+	if workers == 0 {
+		workers = 3
+	}
+	jobsPerMinute := params.Config.JobQueue.JobsPerMinute
+
 	managerQueue, err := jobqueue.New[[]datamodel.Link](
 		QueueName,
 		params.DB,
@@ -136,8 +181,8 @@ func NewQueue(params QueueParams) (jobqueue.Service[[]datamodel.Link], error) {
 		},
 		jobqueue.WithLogger(log.With("queue", QueueName)),
 		jobqueue.WithMaxRetries(50),
-		// 3 workers means 3 roots can be added in parallel.
-		jobqueue.WithMaxWorkers(uint(3)),
+		jobqueue.WithMaxWorkers(workers),
+		jobqueue.WithRateLimit(jobsPerMinute),
 		// wait for twice a filecoin epoch to submit
 		jobqueue.WithMaxTimeout(time.Minute),
 		jobqueue.WithDialect(d),
@@ -145,6 +190,19 @@ func NewQueue(params QueueParams) (jobqueue.Service[[]datamodel.Link], error) {
 	if err != nil {
 		return nil, fmt.Errorf("creating piece_link job-queue: %w", err)
 	}
+
+	if params.Registry != nil {
+		if err := params.Registry.BindUint(config.ManagerJobQueueWorkers, workers, dynamic.UintSchema{Min: 1, Max: 1024}, func(n uint) {
+			if err := managerQueue.SetMaxWorkers(n); err != nil {
+				log.Warnw("changing manager queue workers", "workers", n, "error", err)
+			}
+		}); err != nil {
+			return nil, fmt.Errorf("registering manager queue workers: %w", err)
+		}
+		if err := params.Registry.BindUint(config.ManagerJobQueueRateLimit, jobsPerMinute, dynamic.UintSchema{Min: 0, Max: 60000}, managerQueue.SetRateLimit); err != nil {
+			return nil, fmt.Errorf("registering manager queue rate limit: %w", err)
+		}
+	}
 	// NB: queue lifecycle is handled by manager since it must register with queue before starting it
 	return managerQueue, nil
 }
@@ -36,9 +36,14 @@ type Manager struct {
 	// configuration provider (abstracts static vs dynamic config)
 	configProvider ConfigProvider
 
+	// checker reconciles the buffer on start, nil to skip reconciliation
+	checker SubmissionChecker
+
 	// clock for testing
 	clock clock.Clock
 
+	metrics *Metrics
+
 	// dynamic configuration support
 	tickerResetCh chan struct{}
 	unsubscribers []func()
@@ -60,7 +65,14 @@ type ManagerParams struct {
 	TaskHandler    jobqueue.TaskHandler[[]datamodel.Link]
 	Buffer         BufferStore
 	ConfigProvider ConfigProvider
-	Options        []ManagerOption `group:"manager_options"`
+	Metrics        *Metrics
+	// Checker is optional, with it aggregates left in the buffer by a
+	// previous run are reconciled on start.
+	Checker SubmissionChecker `optional:"true"`
+	// Clock is the clock submissions are polled by, the real clock if not
+	// provided. A WithClock option takes precedence.
+	Clock   clock.Clock     `optional:"true"`
+	Options []ManagerOption `group:"manager_options"`
 }
 
 type ManagerOption func(*Manager)
@@ -79,7 +91,9 @@ func NewManager(lc fx.Lifecycle, params ManagerParams) (*Manager, error) {
 		buffer:         params.Buffer,
 		queue:          params.Queue,
 		configProvider: params.ConfigProvider,
-		clock:          clock.New(),
+		checker:        params.Checker,
+		clock:          params.Clock,
+		metrics:        params.Metrics,
 
 		// channel for signaling ticker reset on config changes
 		tickerResetCh: make(chan struct{}, 1),
@@ -89,6 +103,9 @@ func NewManager(lc fx.Lifecycle, params ManagerParams) (*Manager, error) {
 		done:   make(chan struct{}),
 	}
 
+	if m.clock == nil {
+		m.clock = clock.New()
+	}
 	for _, opt := range params.Options {
 		opt(m)
 	}
@@ -256,6 +273,13 @@ func (m *Manager) Start() error {
 		return fmt.Errorf("failed to start batch queue: %w", err)
 	}
 
+	// failing to reconcile leaves the buffer to be submitted by the process loop
+	if res, err := m.Recover(m.ctx); err != nil {
+		log.Warnw("Failed to reconcile buffered aggregates", "error", err)
+	} else if res.Discarded > 0 || res.Requeued > 0 {
+		log.Infow("Reconciled buffered aggregates", "discarded", res.Discarded, "requeued", res.Requeued)
+	}
+
 	go m.processLoop()
 	return nil
 }
@@ -347,6 +371,8 @@ func (m *Manager) doSubmit(aggregates Aggregation) error {
 	if err := m.queue.Enqueue(m.ctx, m.taskHandler.Name(), aggregates.Roots); err != nil {
 		return fmt.Errorf("failed to enqueue batch submission roots: %w", err)
 	}
+	m.metrics.batchesSubmitted.Inc(m.ctx)
+	m.metrics.batchSize.Record(m.ctx, int64(len(aggregates.Roots)))
 
 	// only clear the buffer if we successfully submit to our stateful job queue
 	if err := m.buffer.ClearRoots(m.ctx); err != nil {
@@ -120,10 +120,17 @@ func (mq *mockQueue) Enqueue(ctx context.Context, name string, msg []datamodel.L
 	return mq.taskHandler.Handle(ctx, msg)
 }
 
+func newMetrics(t *testing.T) *manager.Metrics {
+	m, err := manager.NewMetrics()
+	require.NoError(t, err)
+	return m
+}
+
 func newBufferStore(t *testing.T) manager.BufferStore {
 	ds := ds_sync.MutexWrap(datastore.NewMapDatastore())
 	buf, err := manager.NewSubmissionWorkspace(manager.SubmissionWorkspaceParams{
 		Datastore: ds,
+		Metrics:   newMetrics(t),
 	})
 	require.NoError(t, err)
 	return buf
@@ -206,6 +213,7 @@ func setupTestManager(t *testing.T, cfgProvider *mockConfigProvider, opts ...man
 			return cfgProvider
 		}),
 		fx.Options(optProviders...),
+		fx.Provide(manager.NewMetrics),
 		fx.Provide(manager.NewManager),
 		fx.Populate(&m),
 	)
@@ -912,3 +920,106 @@ func TestManagerDynamicConfig(t *testing.T) {
 		require.Equal(t, int64(10), handler.totalLinks.Load(), "Should have processed 10 links")
 	})
 }
+
+// mockChecker reports the aggregates in submitted as already added to a
+// proof set.
+type mockChecker struct {
+	submitted map[string]bool
+	err       error
+}
+
+func (c *mockChecker) Submitted(ctx context.Context, aggregate datamodel.Link) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	return c.submitted[aggregate.String()], nil
+}
+
+// startRecoveringManager starts a manager over buffer, reconciling it with
+// checker. The clock never advances, so nothing is submitted by polling.
+func startRecoveringManager(t *testing.T, buffer manager.BufferStore, checker manager.SubmissionChecker) *fakeTaskHandler {
+	t.Helper()
+	taskHandler := &fakeTaskHandler{processedLinks: []datamodel.Link{}}
+	app := fxtest.New(t,
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(
+				&mockQueue{taskHandler: taskHandler},
+				fx.As(new(jobqueue.Service[[]datamodel.Link])),
+			),
+		),
+		fx.Provide(func() jobqueue.TaskHandler[[]datamodel.Link] {
+			return taskHandler
+		}),
+		fx.Provide(func() manager.BufferStore {
+			return buffer
+		}),
+		fx.Provide(func() manager.ConfigProvider {
+			return &mockConfigProvider{
+				pollInterval: manager.DefaultPollInterval,
+				batchSize:    manager.DefaultMaxBatchSizeBytes,
+			}
+		}),
+		fx.Provide(func() manager.SubmissionChecker {
+			return checker
+		}),
+		fx.Provide(func() clock.Clock {
+			return clock.NewMock()
+		}),
+		fx.Provide(manager.NewMetrics),
+		fx.Provide(manager.NewManager),
+		fx.Invoke(func(*manager.Manager) {}),
+	)
+	app.RequireStart()
+	t.Cleanup(func() {
+		app.RequireStop()
+	})
+	return taskHandler
+}
+
+func TestManagerRecover(t *testing.T) {
+	t.Run("discards submitted aggregates and requeues the rest", func(t *testing.T) {
+		buffer := newBufferStore(t)
+		submitted := testutil.RandomCID(t)
+		pending := testutil.RandomCID(t)
+		require.NoError(t, buffer.AppendRoots(t.Context(), []datamodel.Link{submitted, pending}))
+
+		handler := startRecoveringManager(t, buffer, &mockChecker{
+			submitted: map[string]bool{submitted.String(): true},
+		})
+
+		require.Equal(t, int64(1), handler.called.Load())
+		require.Equal(t, []datamodel.Link{pending}, handler.processedLinks)
+		aggs, err := buffer.Aggregation(t.Context())
+		require.NoError(t, err)
+		require.Empty(t, aggs.Roots)
+	})
+
+	t.Run("clears a buffer of submitted aggregates", func(t *testing.T) {
+		buffer := newBufferStore(t)
+		submitted := testutil.RandomCID(t)
+		require.NoError(t, buffer.AppendRoots(t.Context(), []datamodel.Link{submitted}))
+
+		handler := startRecoveringManager(t, buffer, &mockChecker{
+			submitted: map[string]bool{submitted.String(): true},
+		})
+
+		require.Zero(t, handler.called.Load())
+		aggs, err := buffer.Aggregation(t.Context())
+		require.NoError(t, err)
+		require.Empty(t, aggs.Roots)
+	})
+
+	t.Run("keeps the buffer when aggregates cannot be checked", func(t *testing.T) {
+		buffer := newBufferStore(t)
+		links := []datamodel.Link{testutil.RandomCID(t), testutil.RandomCID(t)}
+		require.NoError(t, buffer.AppendRoots(t.Context(), links))
+
+		handler := startRecoveringManager(t, buffer, &mockChecker{err: fmt.Errorf("PDP server unavailable")})
+
+		require.Zero(t, handler.called.Load())
+		aggs, err := buffer.Aggregation(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, links, aggs.Roots)
+	})
+}
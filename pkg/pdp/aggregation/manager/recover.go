@@ -0,0 +1,130 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/datamodel"
+
+	"github.com/storacha/piri/pkg/pdp/aggregation/types"
+	"github.com/storacha/piri/pkg/pdp/proofset"
+	pdptypes "github.com/storacha/piri/pkg/pdp/types"
+)
+
+// SubmissionChecker reports whether an aggregate has already been added to a
+// proof set.
+type SubmissionChecker interface {
+	Submitted(ctx context.Context, aggregate datamodel.Link) (bool, error)
+}
+
+// ProofSetLister lists the proof sets managed by the node.
+type ProofSetLister interface {
+	List(ctx context.Context) ([]proofset.ProofSet, error)
+}
+
+// ProofSetChecker checks for an aggregate among the roots of every proof set
+// managed by the node, retired ones included. It only reads: no proof set is
+// selected or created for the aggregate. Proof sets proven by other backends
+// than the PDP service of the node cannot be read by it and are not checked.
+type ProofSetChecker struct {
+	api       pdptypes.ProofSetAPI
+	proofSets ProofSetLister
+	store     types.Store
+}
+
+var _ SubmissionChecker = (*ProofSetChecker)(nil)
+
+// NewProofSetChecker creates a checker reading aggregates from store, and the
+// roots of the proof sets of the registry from api.
+func NewProofSetChecker(api pdptypes.ProofSetAPI, registry *proofset.Registry, store types.Store) SubmissionChecker {
+	return &ProofSetChecker{api: api, proofSets: registry, store: store}
+}
+
+func (c *ProofSetChecker) Submitted(ctx context.Context, aggregate datamodel.Link) (bool, error) {
+	agg, err := c.store.Get(ctx, aggregate)
+	if err != nil {
+		return false, fmt.Errorf("reading aggregate %s: %w", aggregate, err)
+	}
+	root, err := cid.Decode(agg.Root.Link().String())
+	if err != nil {
+		return false, fmt.Errorf("decoding aggregate root CID: %w", err)
+	}
+	sets, err := c.proofSets.List(ctx)
+	if err != nil {
+		return false, fmt.Errorf("listing proof sets: %w", err)
+	}
+	for _, set := range sets {
+		if set.Backend != proofset.NativeBackend {
+			continue
+		}
+		ps, err := c.api.GetProofSet(ctx, set.ID)
+		if err != nil {
+			return false, fmt.Errorf("getting proof set %d: %w", set.ID, err)
+		}
+		for _, r := range ps.Roots {
+			if r.RootCID.Equals(root) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// RecoveryResult summarises the reconciliation of the buffer on start.
+type RecoveryResult struct {
+	// Discarded is the number of buffered aggregates already in a proof set.
+	Discarded int
+	// Requeued is the number of buffered aggregates queued for submission.
+	Requeued int
+}
+
+// Recover reconciles the aggregates left in the buffer by a previous run
+// against the PDP server. Aggregates already added to their proof set are
+// discarded, the rest are queued for submission immediately rather than
+// waiting for the next poll. The buffer is left as it is if any aggregate
+// cannot be checked, to be submitted by the process loop as usual.
+func (m *Manager) Recover(ctx context.Context) (RecoveryResult, error) {
+	var res RecoveryResult
+	if m.checker == nil {
+		return res, nil
+	}
+	m.submitMu.Lock()
+	defer m.submitMu.Unlock()
+
+	aggregates, err := m.buffer.Aggregation(ctx)
+	if err != nil {
+		return res, fmt.Errorf("getting buffered aggregates: %w", err)
+	}
+	if len(aggregates.Roots) == 0 {
+		return res, nil
+	}
+
+	var pending []datamodel.Link
+	for _, link := range aggregates.Roots {
+		submitted, err := m.checker.Submitted(ctx, link)
+		if err != nil {
+			return res, fmt.Errorf("checking aggregate %s: %w", link, err)
+		}
+		if submitted {
+			log.Infow("Discarding buffered aggregate already in proof set", "aggregate", link)
+			res.Discarded++
+			continue
+		}
+		pending = append(pending, link)
+	}
+
+	if len(pending) == 0 {
+		if err := m.buffer.ClearRoots(ctx); err != nil {
+			return res, fmt.Errorf("clearing buffered aggregates: %w", err)
+		}
+		return res, nil
+	}
+	// enqueueing before clearing the buffer keeps the pending aggregates if
+	// the node crashes again
+	if err := m.doSubmit(Aggregation{Roots: pending}); err != nil {
+		return res, err
+	}
+	res.Requeued = len(pending)
+	return res, nil
+}
@@ -1,9 +1,88 @@
 package manager
 
 import (
+	"time"
+
 	"go.opentelemetry.io/otel"
+
+	"github.com/storacha/piri/lib/telemetry"
 )
 
 var (
 	tracer = otel.Tracer("github.com/storacha/piri/pkg/pdp/aggregation/manager")
 )
+
+// batchSizeBounds covers batches of a single root up to well above the
+// default maximum batch size.
+var batchSizeBounds = []float64{1, 2, 3, 5, 10, 15, 20, 30, 50, 100}
+
+// submitDurationBounds covers 100ms up to the queue's maximum job timeout.
+var submitDurationBounds = []float64{
+	(100 * time.Millisecond).Seconds(),
+	(500 * time.Millisecond).Seconds(),
+	(time.Second).Seconds(),
+	(3 * time.Second).Seconds(),
+	(5 * time.Second).Seconds(),
+	(10 * time.Second).Seconds(),
+	(20 * time.Second).Seconds(),
+	(30 * time.Second).Seconds(),
+	(45 * time.Second).Seconds(),
+	(time.Minute).Seconds(),
+}
+
+// Metrics are the metrics of the aggregation manager, created once and shared
+// by the manager, its submission workspace and its add roots task handler.
+type Metrics struct {
+	bufferRoots      *telemetry.Int64Gauge
+	batchesSubmitted *telemetry.Counter
+	batchSize        *telemetry.Int64Histogram
+	submitDuration   *telemetry.Timer
+}
+
+// NewMetrics creates the metrics of the aggregation manager.
+func NewMetrics() (*Metrics, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/pdp/aggregation/manager")
+	bufferRoots, err := telemetry.NewInt64Gauge(
+		meter,
+		"aggregation_manager_buffer_roots",
+		"number of aggregate roots buffered awaiting submission",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	batchesSubmitted, err := telemetry.NewCounter(
+		meter,
+		"aggregation_manager_batches_submitted",
+		"number of batches of aggregate roots queued for submission",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	batchSize, err := telemetry.NewInt64Histogram(
+		meter,
+		"aggregation_manager_batch_size",
+		"number of aggregate roots in a submitted batch",
+		"1",
+		batchSizeBounds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	submitDuration, err := telemetry.NewTimer(
+		meter,
+		"aggregation_manager_submit_duration",
+		"duration of accepting pieces and adding a batch of roots to the proof set",
+		submitDurationBounds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Metrics{
+		bufferRoots:      bufferRoots,
+		batchesSubmitted: batchesSubmitted,
+		batchSize:        batchSize,
+		submitDuration:   submitDuration,
+	}, nil
+}
@@ -0,0 +1,532 @@
+// Package alerting raises alerts when the node is at risk of losing rewards:
+// when a proving deadline approaches and no proof of the period has been
+// seen, when a FaultRecord event is emitted for one of its data sets, and when
+// a rail settlement transaction fails. Other components, such as the disk
+// space monitor, raise alerts of their own conditions with [Alerter.Raise].
+//
+// Conditions are checked for every tipset applied by the chain scheduler.
+// Every alert is recorded once in the database, keyed by the condition that
+// raised it, and sent to the configured sinks, webhooks and email. Failed
+// deliveries are retried with backoff, across restarts.
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	chaintypes "github.com/filecoin-project/lotus/chain/types"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/raulk/clock"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/storacha/piri/pkg/pdp/chainevents"
+	"github.com/storacha/piri/pkg/pdp/chainsched"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/webhook"
+)
+
+var log = logging.Logger("pdp/alerting")
+
+// Kinds of alerts.
+const (
+	// KindProvingDeadline is raised when a proving deadline is within the
+	// configured number of epochs and no proof of the period has been seen.
+	KindProvingDeadline = "proving_deadline"
+	// KindFaultRecord is raised when a FaultRecord event is emitted for a data
+	// set of the node.
+	KindFaultRecord = "fault_record"
+	// KindSettlementFailed is raised when a rail settlement transaction fails
+	// to be sent or is reverted.
+	KindSettlementFailed = "settlement_failed"
+	// KindDiskSpace is raised when the disk usage of a node directory crosses
+	// its high watermark and allocations are rejected.
+	KindDiskSpace = "disk_space"
+)
+
+const (
+	// MaxAttempts is the number of times an alert is sent before it is given
+	// up on. Retries are delayed by [webhook.Backoff].
+	MaxAttempts = 10
+	// DefaultLimit is the number of alerts returned by [Alerter.List] when no
+	// limit is given.
+	DefaultLimit = 100
+
+	// settleReasonPrefix is the send reason of settlement transactions,
+	// followed by the rail ID.
+	settleReasonPrefix = "settle_rail_"
+	// singletonID is the ID of the single row of the rules and state tables.
+	singletonID = 1
+)
+
+// Rules select the conditions alerts are raised for.
+type Rules struct {
+	// DeadlineEpochs raises an alert when a proving deadline is at most this
+	// many epochs away and no proof of the period has been seen. 0 disables
+	// the alert.
+	DeadlineEpochs int64 `json:"deadline_epochs"`
+	// FaultRecords raises an alert for every FaultRecord event of a data set.
+	FaultRecords bool `json:"fault_records"`
+	// SettlementFailures raises an alert for every failed settlement.
+	SettlementFailures bool `json:"settlement_failures"`
+}
+
+// Validate checks the deadline is not negative.
+func (r Rules) Validate() error {
+	if r.DeadlineEpochs < 0 {
+		return errors.New("deadline epochs must not be negative")
+	}
+	return nil
+}
+
+// Alert is a condition the operator is notified of. It is the JSON body
+// posted to webhooks.
+type Alert = models.Alert
+
+// rulesRecord holds the rules set through the admin API.
+type rulesRecord = models.AlertRules
+
+// stateRecord records what has been checked.
+type stateRecord = models.AlertState
+
+// Sink is a destination alerts are sent to.
+type Sink interface {
+	// Name identifies the sink in errors.
+	Name() string
+	Send(ctx context.Context, alert Alert) error
+}
+
+type Option func(*Alerter)
+
+// WithClock sets the clock alerts are timed with.
+func WithClock(clk clock.Clock) Option {
+	return func(a *Alerter) {
+		a.clock = clk
+	}
+}
+
+// Alerter checks the alert conditions and sends raised alerts to its sinks.
+type Alerter struct {
+	db       *gorm.DB
+	defaults Rules
+	sinks    []Sink
+	clock    clock.Clock
+
+	head   atomic.Int64
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates an Alerter applying the given rules until others are set with
+// [Alerter.SetRules]. The alert tables are created by the shared migrations
+// of the PDP database, [models.AutoMigrateDB].
+func New(db *gorm.DB, defaults Rules, sinks []Sink, opts ...Option) (*Alerter, error) {
+	if err := defaults.Validate(); err != nil {
+		return nil, err
+	}
+	a := &Alerter{
+		db:       db,
+		defaults: defaults,
+		sinks:    sinks,
+		clock:    clock.New(),
+		wake:     make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a, nil
+}
+
+// Watch wakes the alerter for every tipset applied by the chain scheduler. It
+// must be called before the scheduler is started.
+func (a *Alerter) Watch(sched *chainsched.Scheduler) error {
+	return sched.AddHandler(func(ctx context.Context, revert, apply *chaintypes.TipSet) error {
+		if apply == nil {
+			return nil
+		}
+		a.head.Store(int64(apply.Height()))
+		select {
+		case a.wake <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+}
+
+// Start checks the alert conditions in the background every time the
+// alerter is woken by a new tipset.
+func (a *Alerter) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.done = make(chan struct{})
+	go func() {
+		defer close(a.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-a.wake:
+			}
+			if err := a.Evaluate(ctx, a.head.Load()); err != nil && !errors.Is(err, context.Canceled) {
+				log.Errorw("evaluating alerts", "error", err)
+			}
+		}
+	}()
+}
+
+// Stop stops checking the alert conditions.
+func (a *Alerter) Stop(ctx context.Context) error {
+	if a.cancel == nil {
+		return nil
+	}
+	a.cancel()
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Rules returns the rules in effect, and whether they were set through
+// [Alerter.SetRules] rather than configured.
+func (a *Alerter) Rules(ctx context.Context) (Rules, bool, error) {
+	var rec rulesRecord
+	err := a.db.WithContext(ctx).Where("id = ?", singletonID).Take(&rec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return a.defaults, false, nil
+	}
+	if err != nil {
+		return Rules{}, false, fmt.Errorf("getting alert rules: %w", err)
+	}
+	return Rules{
+		DeadlineEpochs:     rec.DeadlineEpochs,
+		FaultRecords:       rec.FaultRecords,
+		SettlementFailures: rec.SettlementFailures,
+	}, true, nil
+}
+
+// SetRules replaces the rules in effect, until they are reset. They persist
+// across restarts.
+func (a *Alerter) SetRules(ctx context.Context, rules Rules) error {
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+	rec := rulesRecord{
+		ID:                 singletonID,
+		DeadlineEpochs:     rules.DeadlineEpochs,
+		FaultRecords:       rules.FaultRecords,
+		SettlementFailures: rules.SettlementFailures,
+	}
+	err := a.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&rec).Error
+	if err != nil {
+		return fmt.Errorf("setting alert rules: %w", err)
+	}
+	log.Infow("alert rules updated", "deadline_epochs", rules.DeadlineEpochs, "fault_records", rules.FaultRecords, "settlement_failures", rules.SettlementFailures)
+	return nil
+}
+
+// ResetRules restores the configured rules.
+func (a *Alerter) ResetRules(ctx context.Context) error {
+	if err := a.db.WithContext(ctx).Where("id = ?", singletonID).Delete(&rulesRecord{}).Error; err != nil {
+		return fmt.Errorf("resetting alert rules: %w", err)
+	}
+	log.Info("alert rules reset to the configured rules")
+	return nil
+}
+
+// List returns the most recent alerts, most recent first. A limit of zero
+// returns [DefaultLimit] alerts.
+func (a *Alerter) List(ctx context.Context, limit int) ([]Alert, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	var alerts []Alert
+	if err := a.db.WithContext(ctx).Order("id DESC").Limit(limit).Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("listing alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+// Evaluate checks the alert conditions at the given chain head, raises
+// alerts for those met and sends the alerts that are due.
+func (a *Alerter) Evaluate(ctx context.Context, head int64) error {
+	rules, _, err := a.Rules(ctx)
+	if err != nil {
+		return err
+	}
+	state, err := a.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if rules.DeadlineEpochs > 0 {
+		errs = append(errs, a.checkDeadlines(ctx, head, rules.DeadlineEpochs))
+	}
+	// the events are consumed even if the rule is disabled, so enabling it
+	// does not raise alerts of past faults
+	errs = append(errs, a.checkFaults(ctx, state, rules.FaultRecords))
+	if rules.SettlementFailures {
+		errs = append(errs, a.checkSettlements(ctx, state))
+	}
+	errs = append(errs, a.deliver(ctx))
+	return errors.Join(errs...)
+}
+
+// state returns the state of the alerter, recording it on the first call.
+// Faults and settlements before the first call are not alerted on.
+func (a *Alerter) state(ctx context.Context) (stateRecord, error) {
+	var state stateRecord
+	err := a.db.WithContext(ctx).Where("id = ?", singletonID).Take(&state).Error
+	if err == nil {
+		return state, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return stateRecord{}, fmt.Errorf("getting alert state: %w", err)
+	}
+	last, err := a.lastChainEventID(ctx)
+	if err != nil {
+		return stateRecord{}, err
+	}
+	state = stateRecord{
+		ID:               singletonID,
+		Since:            a.clock.Now().UTC(),
+		LastChainEventID: last,
+	}
+	if err := a.db.WithContext(ctx).Create(&state).Error; err != nil {
+		return stateRecord{}, fmt.Errorf("creating alert state: %w", err)
+	}
+	return state, nil
+}
+
+func (a *Alerter) lastChainEventID(ctx context.Context) (uint, error) {
+	var last uint
+	err := a.db.WithContext(ctx).Model(&models.ChainEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&last).Error
+	if err != nil {
+		return 0, fmt.Errorf("getting last chain event: %w", err)
+	}
+	return last, nil
+}
+
+// checkDeadlines raises an alert for every data set whose challenge window is
+// open and closes within n epochs of head, without a PossessionProven event
+// since it opened. Events are indexed [chainevents.Confidence] epochs behind
+// the head, so n should leave time for a submitted proof to be indexed.
+func (a *Alerter) checkDeadlines(ctx context.Context, head, n int64) error {
+	var sets []models.PDPProofSet
+	err := a.db.WithContext(ctx).
+		Where("init_ready = ? AND prove_at_epoch IS NOT NULL AND challenge_window IS NOT NULL", true).
+		Find(&sets).Error
+	if err != nil {
+		return fmt.Errorf("listing proof sets: %w", err)
+	}
+	for _, ps := range sets {
+		opens := *ps.ProveAtEpoch
+		deadline := opens + *ps.ChallengeWindow
+		if head < opens || head >= deadline || deadline-head > n {
+			continue
+		}
+		var proven int64
+		err := a.db.WithContext(ctx).Model(&models.ChainEvent{}).
+			Where("name = ? AND data_set_id = ? AND block_number >= ?", chainevents.PossessionProven, ps.ID, opens).
+			Count(&proven).Error
+		if err != nil {
+			return fmt.Errorf("checking proofs of data set %d: %w", ps.ID, err)
+		}
+		if proven > 0 {
+			continue
+		}
+		err = a.fire(ctx, Alert{
+			Key:       fmt.Sprintf("%s/%d/%d", KindProvingDeadline, ps.ID, opens),
+			Kind:      KindProvingDeadline,
+			DataSetID: ps.ID,
+			Epoch:     deadline,
+			Message:   fmt.Sprintf("data set %d has no proof %d epochs before its proving deadline at epoch %d", ps.ID, deadline-head, deadline),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFaults raises an alert for every FaultRecord event indexed since the
+// last check, if enabled.
+func (a *Alerter) checkFaults(ctx context.Context, state stateRecord, enabled bool) error {
+	last, err := a.lastChainEventID(ctx)
+	if err != nil {
+		return err
+	}
+	if last <= state.LastChainEventID {
+		return nil
+	}
+	if enabled {
+		var events []models.ChainEvent
+		err := a.db.WithContext(ctx).
+			Where("id > ? AND id <= ? AND name = ?", state.LastChainEventID, last, chainevents.FaultRecord).
+			Order("id").
+			Find(&events).Error
+		if err != nil {
+			return fmt.Errorf("listing fault records: %w", err)
+		}
+		for _, e := range events {
+			var args chainevents.FaultRecordArgs
+			if err := json.Unmarshal(e.Args, &args); err != nil {
+				log.Warnw("decoding fault record", "tx", e.TxHash, "error", err)
+			}
+			err := a.fire(ctx, Alert{
+				Key:       fmt.Sprintf("%s/%s/%d", KindFaultRecord, e.TxHash, e.LogIndex),
+				Kind:      KindFaultRecord,
+				DataSetID: e.DataSetID,
+				TxHash:    e.TxHash,
+				Epoch:     e.BlockNumber,
+				Message:   fmt.Sprintf("data set %d faulted %d proving period(s) with deadline %d", e.DataSetID, args.PeriodsFaulted, args.Deadline),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	err = a.db.WithContext(ctx).Model(&stateRecord{}).
+		Where("id = ?", singletonID).
+		Update("last_chain_event_id", last).Error
+	if err != nil {
+		return fmt.Errorf("updating alert state: %w", err)
+	}
+	return nil
+}
+
+// failedSettlement is a settlement transaction that failed to be sent or was
+// reverted.
+type failedSettlement struct {
+	SendTaskID int
+	SendReason string
+	SignedHash *string
+	SendError  *string
+}
+
+// checkSettlements raises an alert for every settlement transaction sent since
+// alerting started that failed to be sent or was reverted.
+func (a *Alerter) checkSettlements(ctx context.Context, state stateRecord) error {
+	var failed []failedSettlement
+	err := a.db.WithContext(ctx).
+		Table("message_sends_eth AS s").
+		Select("s.send_task_id, s.send_reason, s.signed_hash, s.send_error").
+		Joins("LEFT JOIN message_waits_eth AS w ON w.signed_tx_hash = s.signed_hash").
+		Where("s.send_reason LIKE ? AND s.send_time >= ?", settleReasonPrefix+"%", state.Since).
+		Where("s.send_success = ? OR (w.tx_status = ? AND w.tx_success = ?)", false, "confirmed", false).
+		Scan(&failed).Error
+	if err != nil {
+		return fmt.Errorf("listing failed settlements: %w", err)
+	}
+	for _, f := range failed {
+		rail := strings.TrimPrefix(f.SendReason, settleReasonPrefix)
+		alert := Alert{
+			Key:     fmt.Sprintf("%s/%d", KindSettlementFailed, f.SendTaskID),
+			Kind:    KindSettlementFailed,
+			RailID:  rail,
+			Message: fmt.Sprintf("settlement of rail %s was reverted", rail),
+		}
+		if f.SignedHash != nil {
+			alert.TxHash = *f.SignedHash
+		}
+		if f.SendError != nil && *f.SendError != "" {
+			alert.Message = fmt.Sprintf("settlement of rail %s failed to send: %s", rail, *f.SendError)
+		}
+		if err := a.fire(ctx, alert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Raise records an alert of a condition detected outside the alerter, e.g.
+// by the disk space monitor, unless one with the same key was raised before.
+// It is sent with the next tipset.
+func (a *Alerter) Raise(ctx context.Context, kind, key, message string) error {
+	return a.fire(ctx, Alert{Key: key, Kind: kind, Message: message})
+}
+
+// fire records an alert, unless one with the same key was raised before. It
+// is sent by the next delivery.
+func (a *Alerter) fire(ctx context.Context, alert Alert) error {
+	now := a.clock.Now().UTC()
+	alert.FiredAt = now
+	alert.NextAttemptAt = now
+	res := a.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "alert_key"}}, DoNothing: true}).
+		Create(&alert)
+	if res.Error != nil {
+		return fmt.Errorf("recording alert: %w", res.Error)
+	}
+	if res.RowsAffected > 0 {
+		log.Warnw("alert raised", "kind", alert.Kind, "key", alert.Key, "message", alert.Message)
+	}
+	return nil
+}
+
+// deliver sends the alerts that are due to every sink. An alert failing to
+// be sent to any sink is retried, so sinks may receive it more than once.
+func (a *Alerter) deliver(ctx context.Context) error {
+	if len(a.sinks) == 0 {
+		return nil
+	}
+	now := a.clock.Now().UTC()
+	var due []Alert
+	err := a.db.WithContext(ctx).
+		Where("delivered_at IS NULL AND attempts < ? AND next_attempt_at <= ?", MaxAttempts, now).
+		Order("id").
+		Find(&due).Error
+	if err != nil {
+		return fmt.Errorf("listing undelivered alerts: %w", err)
+	}
+	for _, alert := range due {
+		var errs []error
+		for _, sink := range a.sinks {
+			if err := sink.Send(ctx, alert); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			}
+		}
+		if ctx.Err() != nil {
+			// interrupted by shutdown, not a failure of the sinks
+			return ctx.Err()
+		}
+		now := a.clock.Now().UTC()
+		alert.Attempts++
+		if err := errors.Join(errs...); err != nil {
+			alert.LastError = trimError(err.Error())
+			alert.NextAttemptAt = now.Add(webhook.Backoff(alert.Attempts))
+			if alert.Attempts >= MaxAttempts {
+				log.Errorw("giving up on alert", "key", alert.Key, "attempts", alert.Attempts, "error", err)
+			} else {
+				log.Warnw("sending alert failed, will retry", "key", alert.Key, "attempts", alert.Attempts, "error", err)
+			}
+		} else {
+			alert.LastError = ""
+			alert.DeliveredAt = &now
+		}
+		err := a.db.WithContext(ctx).Model(&alert).
+			Select("attempts", "next_attempt_at", "delivered_at", "last_error").
+			Updates(&alert).Error
+		if err != nil {
+			return fmt.Errorf("updating alert %s: %w", alert.Key, err)
+		}
+	}
+	return nil
+}
+
+// trimError shortens an error stored with an alert.
+func trimError(msg string) string {
+	const max = 256
+	msg = strings.TrimSpace(msg)
+	if len(msg) > max {
+		return msg[:max] + "..."
+	}
+	return msg
+}
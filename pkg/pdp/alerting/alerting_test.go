@@ -0,0 +1,268 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/chainevents"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/webhook"
+)
+
+var defaultRules = Rules{DeadlineEpochs: 10, FaultRecords: true, SettlementFailures: true}
+
+// fakeSink records the alerts sent to it, failing while err is set.
+type fakeSink struct {
+	alerts []Alert
+	err    error
+}
+
+func (s *fakeSink) Name() string { return "fake" }
+
+func (s *fakeSink) Send(ctx context.Context, alert Alert) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+func setup(t *testing.T, sinks ...Sink) (*Alerter, *gorm.DB, *clock.Mock) {
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+	clk := clock.NewMock()
+	clk.Set(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	a, err := New(db, defaultRules, sinks, WithClock(clk))
+	require.NoError(t, err)
+	return a, db, clk
+}
+
+func addProofSet(t *testing.T, db *gorm.DB, id, proveAt, window int64) {
+	require.NoError(t, db.Create(&models.PDPProofSet{
+		ID:                id,
+		ProveAtEpoch:      &proveAt,
+		ChallengeWindow:   &window,
+		InitReady:         true,
+		CreateMessageHash: "0x01",
+		Service:           "storacha",
+	}).Error)
+}
+
+func addEvent(t *testing.T, db *gorm.DB, name string, dataSet, block int64, args any) {
+	data, err := json.Marshal(args)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.ChainEvent{
+		BlockNumber: block,
+		TxHash:      fmt.Sprintf("0x%s%d", name, block),
+		Contract:    "0xaa",
+		Name:        name,
+		DataSetID:   dataSet,
+		Args:        data,
+	}).Error)
+}
+
+func TestProvingDeadline(t *testing.T) {
+	sink := &fakeSink{}
+	a, db, _ := setup(t, sink)
+	addProofSet(t, db, 1, 100, 30)
+	addProofSet(t, db, 2, 100, 30)
+	addEvent(t, db, chainevents.PossessionProven, 2, 105, nil)
+
+	// the deadline is 20 epochs away
+	require.NoError(t, a.Evaluate(t.Context(), 110))
+	require.Empty(t, sink.alerts)
+
+	require.NoError(t, a.Evaluate(t.Context(), 121))
+	require.NoError(t, a.Evaluate(t.Context(), 122))
+	require.Len(t, sink.alerts, 1)
+	require.Equal(t, KindProvingDeadline, sink.alerts[0].Kind)
+	require.Equal(t, int64(1), sink.alerts[0].DataSetID)
+	require.Equal(t, int64(130), sink.alerts[0].Epoch)
+
+	// past the deadline the fault is alerted on instead
+	require.NoError(t, a.Evaluate(t.Context(), 130))
+	require.Len(t, sink.alerts, 1)
+}
+
+func TestFaultRecords(t *testing.T) {
+	sink := &fakeSink{}
+	a, db, _ := setup(t, sink)
+
+	// faults before alerting started are not alerted on
+	addEvent(t, db, chainevents.FaultRecord, 1, 50, chainevents.FaultRecordArgs{PeriodsFaulted: 1, Deadline: 40})
+	require.NoError(t, a.Evaluate(t.Context(), 100))
+	require.Empty(t, sink.alerts)
+
+	addEvent(t, db, chainevents.FaultRecord, 1, 150, chainevents.FaultRecordArgs{PeriodsFaulted: 2, Deadline: 140})
+	require.NoError(t, a.Evaluate(t.Context(), 160))
+	require.NoError(t, a.Evaluate(t.Context(), 161))
+	require.Len(t, sink.alerts, 1)
+	require.Equal(t, KindFaultRecord, sink.alerts[0].Kind)
+	require.Equal(t, int64(150), sink.alerts[0].Epoch)
+	require.Contains(t, sink.alerts[0].Message, "faulted 2 proving period(s)")
+
+	t.Run("disabled", func(t *testing.T) {
+		require.NoError(t, a.SetRules(t.Context(), Rules{}))
+		addEvent(t, db, chainevents.FaultRecord, 1, 250, chainevents.FaultRecordArgs{PeriodsFaulted: 1, Deadline: 240})
+		require.NoError(t, a.Evaluate(t.Context(), 260))
+
+		// re-enabling does not alert on the faults seen while disabled
+		require.NoError(t, a.ResetRules(t.Context()))
+		require.NoError(t, a.Evaluate(t.Context(), 261))
+		require.Len(t, sink.alerts, 1)
+	})
+}
+
+func TestSettlementFailures(t *testing.T) {
+	sink := &fakeSink{}
+	a, db, clk := setup(t, sink)
+	require.NoError(t, a.Evaluate(t.Context(), 100))
+
+	sent := clk.Now().Add(time.Minute)
+	require.NoError(t, db.Create(&models.MessageSendsEth{
+		FromAddress:  "0x01",
+		ToAddress:    "0x02",
+		SendReason:   "settle_rail_7",
+		UnsignedTx:   []byte{1},
+		UnsignedHash: "0x03",
+		SignedHash:   models.Ptr("0xaaa"),
+		SendTime:     &sent,
+		SendSuccess:  models.Ptr(true),
+	}).Error)
+	require.NoError(t, db.Create(&models.MessageWaitsEth{
+		SignedTxHash: "0xaaa",
+		TxStatus:     "confirmed",
+		TxSuccess:    models.Ptr(false),
+	}).Error)
+	// other transactions are not alerted on
+	require.NoError(t, db.Create(&models.MessageSendsEth{
+		FromAddress:  "0x01",
+		ToAddress:    "0x02",
+		SendReason:   "pdp-prove",
+		UnsignedTx:   []byte{1},
+		UnsignedHash: "0x04",
+		SendTime:     &sent,
+		SendSuccess:  models.Ptr(false),
+		SendError:    models.Ptr("out of gas"),
+	}).Error)
+
+	require.NoError(t, a.Evaluate(t.Context(), 101))
+	require.Len(t, sink.alerts, 1)
+	require.Equal(t, KindSettlementFailed, sink.alerts[0].Kind)
+	require.Equal(t, "7", sink.alerts[0].RailID)
+	require.Equal(t, "0xaaa", sink.alerts[0].TxHash)
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	sink := &fakeSink{err: errors.New("unavailable")}
+	a, db, clk := setup(t, sink)
+	addProofSet(t, db, 1, 100, 30)
+
+	require.NoError(t, a.Evaluate(t.Context(), 125))
+	alerts, err := a.List(t.Context(), 0)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	require.Equal(t, 1, alerts[0].Attempts)
+	require.Nil(t, alerts[0].DeliveredAt)
+	require.Contains(t, alerts[0].LastError, "unavailable")
+
+	// not retried before the backoff
+	sink.err = nil
+	require.NoError(t, a.Evaluate(t.Context(), 126))
+	require.Empty(t, sink.alerts)
+
+	clk.Add(webhook.Backoff(1))
+	require.NoError(t, a.Evaluate(t.Context(), 127))
+	require.Len(t, sink.alerts, 1)
+	alerts, err = a.List(t.Context(), 0)
+	require.NoError(t, err)
+	require.NotNil(t, alerts[0].DeliveredAt)
+	require.Empty(t, alerts[0].LastError)
+}
+
+func TestRules(t *testing.T) {
+	a, _, _ := setup(t)
+
+	rules, overridden, err := a.Rules(t.Context())
+	require.NoError(t, err)
+	require.False(t, overridden)
+	require.Equal(t, defaultRules, rules)
+
+	set := Rules{DeadlineEpochs: 5, SettlementFailures: true}
+	require.NoError(t, a.SetRules(t.Context(), set))
+	rules, overridden, err = a.Rules(t.Context())
+	require.NoError(t, err)
+	require.True(t, overridden)
+	require.Equal(t, set, rules)
+
+	require.Error(t, a.SetRules(t.Context(), Rules{DeadlineEpochs: -1}))
+
+	require.NoError(t, a.ResetRules(t.Context()))
+	rules, overridden, err = a.Rules(t.Context())
+	require.NoError(t, err)
+	require.False(t, overridden)
+	require.Equal(t, defaultRules, rules)
+}
+
+func TestWebhookSink(t *testing.T) {
+	alert := Alert{Key: "fault_record/0xaaa/0", Kind: KindFaultRecord, DataSetID: 1, Message: "data set 1 faulted"}
+	var got Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, alert.Key, r.Header.Get(AlertHeader))
+		require.NoError(t, webhook.Verify([]byte("secret"), r.Header.Get(webhook.SignatureHeader), body, time.Now(), time.Minute))
+		require.NoError(t, json.Unmarshal(body, &got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	require.NoError(t, NewWebhookSink(srv.URL, "secret", nil).Send(t.Context(), alert))
+	require.Equal(t, alert.Key, got.Key)
+	require.Equal(t, alert.Message, got.Message)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	require.ErrorContains(t, NewWebhookSink(failing.URL, "", nil).Send(t.Context(), alert), "503")
+}
+
+func TestEmailSink(t *testing.T) {
+	var addr, from string
+	var to []string
+	var msg []byte
+	sink := NewEmailSink(app.AlertEmailConfig{
+		Host: "smtp.example.com",
+		Port: 587,
+		From: "piri@example.com",
+		To:   []string{"oncall@example.com"},
+	}, func(a string, _ smtp.Auth, f string, rcpt []string, m []byte) error {
+		addr, from, to, msg = a, f, rcpt, m
+		return nil
+	})
+
+	alert := Alert{Kind: KindSettlementFailed, RailID: "7", Message: "settlement of rail 7 was reverted"}
+	require.NoError(t, sink.Send(t.Context(), alert))
+	require.Equal(t, "smtp.example.com:587", addr)
+	require.Equal(t, "piri@example.com", from)
+	require.Equal(t, []string{"oncall@example.com"}, to)
+	require.Contains(t, string(msg), "Subject: [piri] settlement of rail 7 was reverted\r\n")
+	require.Contains(t, string(msg), "Rail: 7\r\n")
+}
@@ -0,0 +1,64 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/pdp/chainsched"
+)
+
+var Module = fx.Module("pdp/alerting",
+	fx.Provide(NewAlerterFromConfig),
+	// the alerter is only depended on optionally, by the admin API
+	fx.Invoke(func(*Alerter) {}),
+)
+
+type AlerterParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	DB        *gorm.DB `name:"engine_db"`
+	Scheduler *chainsched.Scheduler
+	Config    app.PDPServiceConfig
+}
+
+// NewAlerterFromConfig creates an Alerter of the configured rules, sending
+// alerts to the configured webhooks and email. It checks the alert
+// conditions in the background while the node is started.
+func NewAlerterFromConfig(params AlerterParams) (*Alerter, error) {
+	cfg := params.Config.Alerting
+	var sinks []Sink
+	for _, wh := range cfg.Webhooks {
+		sinks = append(sinks, NewWebhookSink(wh.URL.String(), wh.Secret, nil))
+	}
+	if cfg.Email != nil {
+		sinks = append(sinks, NewEmailSink(*cfg.Email, nil))
+	}
+	rules := Rules{
+		DeadlineEpochs:     cfg.DeadlineEpochs,
+		FaultRecords:       cfg.FaultRecords,
+		SettlementFailures: cfg.SettlementFailures,
+	}
+	a, err := New(params.DB, rules, sinks)
+	if err != nil {
+		return nil, fmt.Errorf("creating alerter: %w", err)
+	}
+	if err := a.Watch(params.Scheduler); err != nil {
+		return nil, fmt.Errorf("watching chain for alerts: %w", err)
+	}
+	if len(sinks) == 0 {
+		log.Warn("no alert webhooks or email configured, alerts are only logged and listed by the admin API")
+	}
+	params.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			a.Start()
+			return nil
+		},
+		OnStop: a.Stop,
+	})
+	return a, nil
+}
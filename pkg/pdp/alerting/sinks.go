@@ -0,0 +1,150 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/webhook"
+)
+
+const (
+	// AlertHeader holds the key of the alert, for receivers to ignore alerts
+	// they have already processed.
+	AlertHeader = "X-Piri-Alert"
+	// Timeout bounds sending an alert to a webhook.
+	Timeout = 10 * time.Second
+)
+
+// WebhookSink posts alerts as JSON to a URL. Alerts are signed like receipt
+// notifications, in the [webhook.SignatureHeader], when a secret is given.
+type WebhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+var _ Sink = (*WebhookSink)(nil)
+
+// NewWebhookSink creates a sink posting alerts to url.
+func NewWebhookSink(url, secret string, client *http.Client) *WebhookSink {
+	if client == nil {
+		client = &http.Client{Timeout: Timeout}
+	}
+	return &WebhookSink{url: url, secret: []byte(secret), client: client}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook " + s.url
+}
+
+func (s *WebhookSink) Send(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("encoding alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AlertHeader, alert.Key)
+	if len(s.secret) > 0 {
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign(s.secret, time.Now(), body))
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		err := fmt.Errorf("unexpected status: %d %s", res.StatusCode, http.StatusText(res.StatusCode))
+		if msg, _ := io.ReadAll(io.LimitReader(res.Body, 256)); len(bytes.TrimSpace(msg)) > 0 {
+			err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(msg))
+		}
+		return err
+	}
+	return nil
+}
+
+// SendMailFunc sends an email, see [smtp.SendMail].
+type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// EmailSink sends alerts by email through an SMTP server. The connection is
+// upgraded with STARTTLS when the server supports it.
+type EmailSink struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	to       []string
+	sendMail SendMailFunc
+}
+
+var _ Sink = (*EmailSink)(nil)
+
+// NewEmailSink creates a sink sending alerts through the configured server.
+// If sendMail is nil, [smtp.SendMail] is used.
+func NewEmailSink(cfg app.AlertEmailConfig, sendMail SendMailFunc) *EmailSink {
+	if sendMail == nil {
+		sendMail = smtp.SendMail
+	}
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return &EmailSink{
+		addr:     net.JoinHostPort(cfg.Host, strconv.FormatUint(uint64(cfg.Port), 10)),
+		auth:     auth,
+		from:     cfg.From,
+		to:       cfg.To,
+		sendMail: sendMail,
+	}
+}
+
+func (s *EmailSink) Name() string {
+	return "email " + s.addr
+}
+
+func (s *EmailSink) Send(ctx context.Context, alert Alert) error {
+	if err := s.sendMail(s.addr, s.auth, s.from, s.to, s.message(alert)); err != nil {
+		return fmt.Errorf("sending email: %w", err)
+	}
+	return nil
+}
+
+func (s *EmailSink) message(alert Alert) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&b, "Subject: [piri] %s\r\n", alert.Message)
+	fmt.Fprintf(&b, "Date: %s\r\n", alert.FiredAt.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "%s\r\n\r\n", alert.Message)
+	fmt.Fprintf(&b, "Kind: %s\r\n", alert.Kind)
+	if alert.DataSetID != 0 {
+		fmt.Fprintf(&b, "Data set: %d\r\n", alert.DataSetID)
+	}
+	if alert.RailID != "" {
+		fmt.Fprintf(&b, "Rail: %s\r\n", alert.RailID)
+	}
+	if alert.TxHash != "" {
+		fmt.Fprintf(&b, "Transaction: %s\r\n", alert.TxHash)
+	}
+	if alert.Epoch != 0 {
+		fmt.Fprintf(&b, "Epoch: %d\r\n", alert.Epoch)
+	}
+	fmt.Fprintf(&b, "Raised at: %s\r\n", alert.FiredAt.Format(time.RFC3339))
+	return []byte(b.String())
+}
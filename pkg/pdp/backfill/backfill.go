@@ -0,0 +1,436 @@
+// Package backfill registers the blobs stored by nodes upgraded from versions
+// without PDP. Those blobs were allocated and uploaded, but their piece was
+// never calculated, so they were never aggregated nor added to a data set and
+// the node is not paid for storing them.
+//
+// A backfill walks the allocations of the node and queues the blobs without a
+// piece for aggregation, through the same pipeline as new uploads. It records
+// the outcome of every blob it examines, so a backfill interrupted by a
+// restart, a failure or a cancellation resumes where it stopped.
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/pdp/aggregation/commp"
+	pdpservice "github.com/storacha/piri/pkg/pdp/service"
+	"github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+const (
+	statusKey  = "/status"
+	blobPrefix = "/blobs/"
+)
+
+var (
+	// ErrRunning is returned when a backfill is started while one is running.
+	ErrRunning = errors.New("a backfill is already running")
+	// ErrNotRunning is returned when no backfill is running to be cancelled.
+	ErrNotRunning = errors.New("no backfill is running")
+	// ErrNothingToResume is returned when resuming without a cancelled or
+	// failed backfill.
+	ErrNothingToResume = errors.New("no cancelled or failed backfill to resume")
+	// ErrNotStarted is returned when a backfill is run before the service is
+	// started.
+	ErrNotStarted = errors.New("backfill service is not started")
+)
+
+// State is the state of the last backfill.
+type State string
+
+const (
+	// StateIdle is the state of a node that never ran a backfill.
+	StateIdle      State = "idle"
+	StateRunning   State = "running"
+	StateCompleted State = "completed"
+	// StateCancelled backfills were cancelled, they can be resumed.
+	StateCancelled State = "cancelled"
+	// StateFailed backfills stopped on an error, they can be resumed.
+	StateFailed State = "failed"
+)
+
+// Outcome is what a backfill did with a blob.
+type Outcome string
+
+const (
+	// OutcomeQueued blobs had no piece and were queued for aggregation.
+	OutcomeQueued Outcome = "queued"
+	// OutcomeRegistered blobs already have a piece, they were aggregated
+	// when uploaded or queued by an earlier backfill.
+	OutcomeRegistered Outcome = "registered"
+	// OutcomeMissing blobs are allocated but not in the blob store, they were
+	// never uploaded or were removed since.
+	OutcomeMissing Outcome = "missing"
+	// OutcomeTooLarge blobs exceed the size of a piece that can be proven.
+	OutcomeTooLarge Outcome = "too_large"
+)
+
+// Status is the progress of the last backfill.
+type Status struct {
+	State      State     `json:"state"`
+	StartedAt  time.Time `json:"started_at,omitzero"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+	// Error is why the backfill failed.
+	Error string `json:"error,omitempty"`
+	// Counts are the number of blobs examined by outcome. They are counted
+	// from the recorded outcomes rather than persisted.
+	Counts Counts `json:"-"`
+}
+
+// Counts are the number of blobs examined by a backfill, by outcome.
+type Counts struct {
+	Queued     int
+	Registered int
+	Missing    int
+	TooLarge   int
+}
+
+// Examined is the number of blobs examined.
+func (c Counts) Examined() int {
+	return c.Queued + c.Registered + c.Missing + c.TooLarge
+}
+
+func (c *Counts) add(o Outcome) {
+	switch o {
+	case OutcomeQueued:
+		c.Queued++
+	case OutcomeRegistered:
+		c.Registered++
+	case OutcomeMissing:
+		c.Missing++
+	case OutcomeTooLarge:
+		c.TooLarge++
+	}
+}
+
+// Service runs backfills in the background, one at a time.
+type Service struct {
+	allocations allocationstore.AllocationStore
+	blobs       blobstore.BlobGetter
+	resolver    types.PieceResolverAPI
+	calculator  commp.Calculator
+	ds          datastore.Datastore
+	now         func() time.Time
+
+	mu     sync.Mutex
+	status Status
+	// ctx is the context of the service, backfills run until it is done.
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a backfill service queuing the allocated blobs without a piece
+// to the calculator, recording its progress in ds.
+func New(
+	allocations allocationstore.AllocationStore,
+	blobs blobstore.BlobGetter,
+	resolver types.PieceResolverAPI,
+	calculator commp.Calculator,
+	ds datastore.Datastore,
+) *Service {
+	return &Service{
+		allocations: allocations,
+		blobs:       blobs,
+		resolver:    resolver,
+		calculator:  calculator,
+		ds:          ds,
+		now:         time.Now,
+		status:      Status{State: StateIdle},
+	}
+}
+
+// Start loads the progress of the last backfill, resuming it if it was
+// running when the node stopped. Backfills run until ctx is done.
+func (s *Service) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	s.status = status
+	s.ctx = ctx
+	if status.State == StateRunning {
+		log.Infow("resuming backfill interrupted by a restart", "examined", status.Counts.Examined())
+		s.start()
+	}
+	return nil
+}
+
+// Stop interrupts a running backfill, which resumes when the service starts
+// again.
+func (s *Service) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for backfill to stop: %w", ctx.Err())
+	}
+}
+
+// Run starts a backfill in the background. With resume, it continues the last
+// backfill if it was cancelled or failed, skipping the blobs already examined.
+// Otherwise it examines every allocated blob again, except the blobs queued by
+// earlier backfills, which are never queued twice.
+func (s *Service) Run(ctx context.Context, resume bool) (Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx == nil {
+		return Status{}, ErrNotStarted
+	}
+	if s.status.State == StateRunning {
+		return s.status, ErrRunning
+	}
+	if resume {
+		if s.status.State != StateCancelled && s.status.State != StateFailed {
+			return s.status, ErrNothingToResume
+		}
+	} else {
+		if err := s.reset(ctx); err != nil {
+			return s.status, err
+		}
+		counts, err := s.count(ctx)
+		if err != nil {
+			return s.status, err
+		}
+		s.status = Status{StartedAt: s.now(), Counts: counts}
+	}
+	s.status.State = StateRunning
+	s.status.FinishedAt = time.Time{}
+	s.status.Error = ""
+	if err := s.putStatus(ctx, s.status); err != nil {
+		return s.status, err
+	}
+	s.start()
+	return s.status, nil
+}
+
+// Cancel stops the running backfill. It can be resumed with Run.
+func (s *Service) Cancel(ctx context.Context) (Status, error) {
+	s.mu.Lock()
+	if s.status.State != StateRunning || s.cancel == nil {
+		defer s.mu.Unlock()
+		return s.status, ErrNotRunning
+	}
+	s.status.State = StateCancelled
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+
+	cancel()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return Status{}, ctx.Err()
+	}
+	return s.Status(), nil
+}
+
+// Status returns the progress of the last backfill.
+func (s *Service) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// start runs the backfill in the background. The caller must hold the lock.
+func (s *Service) start() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	done := make(chan struct{})
+	s.cancel, s.done = cancel, done
+	go s.run(ctx, done)
+}
+
+func (s *Service) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	err := s.backfill(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancel = nil
+	switch {
+	case s.status.State == StateCancelled:
+		log.Infow("backfill cancelled", "examined", s.status.Counts.Examined())
+	case err == nil:
+		s.status.State = StateCompleted
+		log.Infow("backfill completed", "queued", s.status.Counts.Queued, "registered", s.status.Counts.Registered,
+			"missing", s.status.Counts.Missing, "too_large", s.status.Counts.TooLarge)
+	case ctx.Err() != nil:
+		// the node is stopping, the backfill resumes when it starts again
+		return
+	default:
+		s.status.State = StateFailed
+		s.status.Error = err.Error()
+		log.Errorw("backfill failed", "examined", s.status.Counts.Examined(), "error", err)
+	}
+	s.status.FinishedAt = s.now()
+	if err := s.putStatus(context.Background(), s.status); err != nil {
+		log.Errorw("recording backfill status", "error", err)
+	}
+}
+
+// backfill examines the allocated blobs not examined yet.
+func (s *Service) backfill(ctx context.Context) error {
+	for alloc, err := range s.allocations.List(ctx) {
+		if err != nil {
+			return fmt.Errorf("listing allocations: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// a blob allocated in several spaces is examined once
+		examined, err := s.ds.Has(ctx, blobKey(alloc.Blob.Digest))
+		if err != nil {
+			return fmt.Errorf("checking backfill record: %w", err)
+		}
+		if examined {
+			continue
+		}
+
+		outcome, err := s.examine(ctx, alloc.Blob)
+		if err != nil {
+			return fmt.Errorf("backfilling blob %s: %w", digestutil.Format(alloc.Blob.Digest), err)
+		}
+		if err := s.ds.Put(ctx, blobKey(alloc.Blob.Digest), []byte(outcome)); err != nil {
+			return fmt.Errorf("recording backfill of blob: %w", err)
+		}
+		s.mu.Lock()
+		s.status.Counts.add(outcome)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// examine queues the blob for aggregation if it has no piece and can be
+// proven.
+func (s *Service) examine(ctx context.Context, blob allocation.Blob) (Outcome, error) {
+	_, found, err := s.resolver.ResolveToPiece(ctx, blob.Digest)
+	if err != nil {
+		return "", fmt.Errorf("resolving piece: %w", err)
+	}
+	if found {
+		return OutcomeRegistered, nil
+	}
+	if blob.Size > uint64(pdpservice.PieceSizeLimit) {
+		log.Warnw("blob is too large to be proven", "digest", digestutil.Format(blob.Digest), "size", blob.Size)
+		return OutcomeTooLarge, nil
+	}
+	obj, err := s.blobs.Get(ctx, blob.Digest)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return OutcomeMissing, nil
+		}
+		return "", fmt.Errorf("getting blob: %w", err)
+	}
+	if err := obj.Body().Close(); err != nil {
+		return "", fmt.Errorf("closing blob: %w", err)
+	}
+	if err := s.calculator.Enqueue(ctx, blob.Digest); err != nil {
+		return "", fmt.Errorf("queuing piece calculation: %w", err)
+	}
+	return OutcomeQueued, nil
+}
+
+// load reads the status of the last backfill.
+func (s *Service) load(ctx context.Context) (Status, error) {
+	b, err := s.ds.Get(ctx, datastore.NewKey(statusKey))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return Status{State: StateIdle}, nil
+		}
+		return Status{}, fmt.Errorf("getting backfill status: %w", err)
+	}
+	var status Status
+	if err := json.Unmarshal(b, &status); err != nil {
+		return Status{}, fmt.Errorf("decoding backfill status: %w", err)
+	}
+	status.Counts, err = s.count(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+	return status, nil
+}
+
+func (s *Service) putStatus(ctx context.Context, status Status) error {
+	b, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("encoding backfill status: %w", err)
+	}
+	if err := s.ds.Put(ctx, datastore.NewKey(statusKey), b); err != nil {
+		return fmt.Errorf("recording backfill status: %w", err)
+	}
+	return nil
+}
+
+// count counts the recorded outcomes of the examined blobs.
+func (s *Service) count(ctx context.Context) (Counts, error) {
+	results, err := s.ds.Query(ctx, query.Query{Prefix: blobPrefix})
+	if err != nil {
+		return Counts{}, fmt.Errorf("querying backfill records: %w", err)
+	}
+	defer results.Close()
+
+	var counts Counts
+	for r := range results.Next() {
+		if r.Error != nil {
+			return Counts{}, fmt.Errorf("iterating backfill records: %w", r.Error)
+		}
+		counts.add(Outcome(r.Value))
+	}
+	return counts, nil
+}
+
+// reset forgets the examined blobs, except the queued ones.
+func (s *Service) reset(ctx context.Context) error {
+	results, err := s.ds.Query(ctx, query.Query{Prefix: blobPrefix})
+	if err != nil {
+		return fmt.Errorf("querying backfill records: %w", err)
+	}
+	var keys []string
+	for r := range results.Next() {
+		if r.Error != nil {
+			results.Close()
+			return fmt.Errorf("iterating backfill records: %w", r.Error)
+		}
+		if Outcome(r.Value) != OutcomeQueued {
+			keys = append(keys, r.Key)
+		}
+	}
+	results.Close()
+
+	for _, k := range keys {
+		if err := s.ds.Delete(ctx, datastore.NewKey(k)); err != nil {
+			return fmt.Errorf("removing backfill record %s: %w", strings.TrimPrefix(k, blobPrefix), err)
+		}
+	}
+	return nil
+}
+
+func blobKey(digest multihash.Multihash) datastore.Key {
+	return datastore.NewKey(blobPrefix + digestutil.Format(digest))
+}
@@ -0,0 +1,226 @@
+package backfill
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	pdpservice "github.com/storacha/piri/pkg/pdp/service"
+	"github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// pieces resolves the blobs it holds to a piece.
+type pieces struct {
+	types.PieceResolverAPI
+	blobs map[string]bool
+}
+
+func (p pieces) ResolveToPiece(_ context.Context, blob multihash.Multihash) (multihash.Multihash, bool, error) {
+	if p.blobs[string(blob)] {
+		return blob, true, nil
+	}
+	return nil, false, nil
+}
+
+// calculator records the queued blobs, failing with the error returned by fail
+// if set.
+type calculator struct {
+	mu     sync.Mutex
+	queued []multihash.Multihash
+	fail   func(ctx context.Context) error
+}
+
+func (c *calculator) Enqueue(ctx context.Context, blob multihash.Multihash) error {
+	if c.fail != nil {
+		if err := c.fail(ctx); err != nil {
+			return err
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queued = append(c.queued, blob)
+	return nil
+}
+
+func (c *calculator) Queued() []multihash.Multihash {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]multihash.Multihash(nil), c.queued...)
+}
+
+type fixture struct {
+	allocations allocationstore.AllocationStore
+	blobs       blobstore.Blobstore
+	pieces      pieces
+	ds          datastore.Datastore
+}
+
+func newFixture() *fixture {
+	return &fixture{
+		allocations: allocationstore.NewDatastoreStore(datastore.NewMapDatastore()),
+		blobs:       blobstore.NewDatastoreStore(dssync.MutexWrap(datastore.NewMapDatastore())),
+		pieces:      pieces{blobs: map[string]bool{}},
+		ds:          dssync.MutexWrap(datastore.NewMapDatastore()),
+	}
+}
+
+// allocate allocates a blob of the size, storing it if stored is set.
+func (f *fixture) allocate(t *testing.T, size uint64, stored bool) multihash.Multihash {
+	data := testutil.RandomBytes(t, 128)
+	digest := testutil.Must(multihash.Sum(data, multihash.SHA2_256, -1))(t)
+	if stored {
+		require.NoError(t, f.blobs.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data)))
+	}
+	f.allocateIn(t, digest, size)
+	return digest
+}
+
+func (f *fixture) allocateIn(t *testing.T, digest multihash.Multihash, size uint64) {
+	require.NoError(t, f.allocations.Put(t.Context(), allocation.Allocation{
+		Space:   testutil.RandomDID(t),
+		Blob:    allocation.Blob{Digest: digest, Size: size},
+		Expires: uint64(time.Now().Unix()),
+		Cause:   testutil.RandomCID(t),
+	}))
+}
+
+func (f *fixture) service(t *testing.T, calc *calculator) *Service {
+	svc := New(f.allocations, f.blobs, f.pieces, calc, f.ds)
+	require.NoError(t, svc.Start(t.Context()))
+	t.Cleanup(func() { require.NoError(t, svc.Stop(context.Background())) })
+	return svc
+}
+
+func waitFor(t *testing.T, svc *Service, state State) Status {
+	require.Eventually(t, func() bool { return svc.Status().State == state }, time.Second, time.Millisecond)
+	return svc.Status()
+}
+
+func TestBackfill(t *testing.T) {
+	t.Run("queues stored blobs without a piece", func(t *testing.T) {
+		f := newFixture()
+		legacy := f.allocate(t, 128, true)
+		// allocated in two spaces, examined once
+		f.allocateIn(t, legacy, 128)
+		registered := f.allocate(t, 128, true)
+		f.pieces.blobs[string(registered)] = true
+		f.allocate(t, 128, false)
+		f.allocate(t, uint64(pdpservice.PieceSizeLimit)+1, true)
+
+		calc := &calculator{}
+		svc := f.service(t, calc)
+		require.Equal(t, StateIdle, svc.Status().State)
+
+		_, err := svc.Run(t.Context(), false)
+		require.NoError(t, err)
+		s := waitFor(t, svc, StateCompleted)
+		require.Equal(t, Counts{Queued: 1, Registered: 1, Missing: 1, TooLarge: 1}, s.Counts)
+		require.False(t, s.FinishedAt.IsZero())
+		require.Equal(t, []multihash.Multihash{legacy}, calc.Queued())
+
+		// starting over does not queue the blob again
+		_, err = svc.Run(t.Context(), false)
+		require.NoError(t, err)
+		s = waitFor(t, svc, StateCompleted)
+		require.Equal(t, 1, s.Counts.Queued)
+		require.Len(t, calc.Queued(), 1)
+	})
+
+	t.Run("resumes a failed backfill", func(t *testing.T) {
+		f := newFixture()
+		for range 3 {
+			f.allocate(t, 128, true)
+		}
+
+		calls := 0
+		failing := &calculator{fail: func(context.Context) error {
+			calls++
+			if calls == 2 {
+				return errors.New("queue unavailable")
+			}
+			return nil
+		}}
+		svc := f.service(t, failing)
+		_, err := svc.Run(t.Context(), false)
+		require.NoError(t, err)
+		s := waitFor(t, svc, StateFailed)
+		require.Contains(t, s.Error, "queue unavailable")
+		require.Equal(t, 1, s.Counts.Queued)
+
+		_, err = svc.Run(t.Context(), true)
+		require.NoError(t, err)
+		s = waitFor(t, svc, StateCompleted)
+		require.Equal(t, 3, s.Counts.Queued)
+		require.Len(t, failing.Queued(), 3)
+
+		_, err = svc.Run(t.Context(), true)
+		require.ErrorIs(t, err, ErrNothingToResume)
+	})
+
+	t.Run("resumes a backfill interrupted by a restart", func(t *testing.T) {
+		f := newFixture()
+		for range 2 {
+			f.allocate(t, 128, true)
+		}
+
+		// the first blob is queued, the node stops while queuing the second
+		calls := 0
+		blocking := &calculator{fail: func(ctx context.Context) error {
+			calls++
+			if calls == 2 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		}}
+		svc := New(f.allocations, f.blobs, f.pieces, blocking, f.ds)
+		require.NoError(t, svc.Start(t.Context()))
+		_, err := svc.Run(t.Context(), false)
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return len(blocking.Queued()) == 1 }, time.Second, time.Millisecond)
+		require.NoError(t, svc.Stop(t.Context()))
+		require.Equal(t, StateRunning, svc.Status().State)
+
+		calc := &calculator{}
+		svc = f.service(t, calc)
+		s := waitFor(t, svc, StateCompleted)
+		require.Equal(t, 2, s.Counts.Queued)
+		require.Len(t, calc.Queued(), 1)
+		require.NotEqual(t, blocking.Queued()[0], calc.Queued()[0])
+	})
+
+	t.Run("cancels a running backfill", func(t *testing.T) {
+		f := newFixture()
+		f.allocate(t, 128, true)
+
+		blocking := &calculator{fail: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+		svc := f.service(t, blocking)
+		_, err := svc.Cancel(t.Context())
+		require.ErrorIs(t, err, ErrNotRunning)
+
+		_, err = svc.Run(t.Context(), false)
+		require.NoError(t, err)
+		_, err = svc.Run(t.Context(), false)
+		require.ErrorIs(t, err, ErrRunning)
+
+		s, err := svc.Cancel(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, StateCancelled, s.State)
+		require.Zero(t, s.Counts.Examined())
+	})
+}
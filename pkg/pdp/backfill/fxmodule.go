@@ -0,0 +1,48 @@
+package backfill
+
+import (
+	"context"
+
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/pdp/aggregation/commp"
+	"github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+var log = logging.Logger("pdp/backfill")
+
+var Module = fx.Module("pdp/backfill",
+	fx.Provide(NewService),
+)
+
+type Params struct {
+	fx.In
+
+	Allocations allocationstore.AllocationStore
+	Blobs       blobstore.Blobstore
+	Resolver    types.PieceResolverAPI
+	Calculator  commp.Calculator
+	Datastore   datastore.Datastore `name:"backfill_datastore"`
+}
+
+// NewService creates the backfill service, resuming on start a backfill that
+// was running when the node stopped.
+func NewService(lc fx.Lifecycle, params Params) *Service {
+	svc := New(params.Allocations, params.Blobs, params.Resolver, params.Calculator, params.Datastore)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(startCtx context.Context) error {
+			return svc.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			return svc.Stop(ctx)
+		},
+	})
+	return svc
+}
@@ -0,0 +1,49 @@
+package chainevents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/pdp/chainsched"
+)
+
+var Module = fx.Module("pdp/chainevents",
+	fx.Provide(NewIndexerFromConfig),
+	// the indexer is only depended on optionally, by the admin API
+	fx.Invoke(func(*Indexer) {}),
+)
+
+type IndexerParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	DB        *gorm.DB `name:"engine_db"`
+	EthClient *ethclient.Client
+	Scheduler *chainsched.Scheduler
+	Config    app.PDPServiceConfig
+}
+
+// NewIndexerFromConfig creates an Indexer of the configured contracts, run in
+// the background while the node is started.
+func NewIndexerFromConfig(params IndexerParams) (*Indexer, error) {
+	ix, err := New(params.DB, params.EthClient, params.Config.Contracts.Verifier, params.Config.Contracts.Service)
+	if err != nil {
+		return nil, err
+	}
+	if err := ix.Watch(params.Scheduler); err != nil {
+		return nil, fmt.Errorf("watching chain events: %w", err)
+	}
+	params.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ix.Start()
+			return nil
+		},
+		OnStop: ix.Stop,
+	})
+	return ix, nil
+}
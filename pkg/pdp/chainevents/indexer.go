@@ -0,0 +1,438 @@
+// Package chainevents indexes the contract events concerning the node's data
+// sets into the database, so that their fault and proof history can be shown
+// without an external indexer.
+package chainevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	chaintypes "github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/storacha/filecoin-services/go/bindings"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/storacha/piri/pkg/pdp/chainsched"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+var log = logging.Logger("pdp/chainevents")
+
+// Names of the indexed events.
+const (
+	// FaultRecord is emitted by the service contract when a data set misses
+	// a proving period.
+	FaultRecord = "FaultRecord"
+	// PiecesAdded is emitted by the verifier when pieces are added to a data
+	// set.
+	PiecesAdded = "PiecesAdded"
+	// NextProvingPeriod is emitted by the verifier when a proving period is
+	// scheduled.
+	NextProvingPeriod = "NextProvingPeriod"
+	// PossessionProven is emitted by the verifier when a proof is accepted.
+	PossessionProven = "PossessionProven"
+)
+
+const (
+	// Confidence is how many epochs behind the head events are indexed, so
+	// that indexed events are not reverted. It matches the confidence the
+	// message watcher waits for.
+	Confidence = 6
+	// MaxRange is the largest number of epochs searched by a single log
+	// filter, the default maximum Lotus allows.
+	MaxRange = 2880
+	// DefaultLookback is how far behind the head indexing starts when nothing
+	// has been indexed yet.
+	DefaultLookback = 2880
+	// DefaultLimit is the number of events returned by [Indexer.List] when no
+	// limit is given.
+	DefaultLimit = 100
+)
+
+// cursorID is the ID of the single row of the cursor table.
+const cursorID = 1
+
+// faultRecordTopic is the signature of the service contract event
+// FaultRecord(uint256 indexed dataSetId, uint256 periodsFaulted, uint256 deadline).
+var faultRecordTopic = crypto.Keccak256Hash([]byte("FaultRecord(uint256,uint256,uint256)"))
+
+// LogFilterer fetches contract logs. It is satisfied by the eth client.
+type LogFilterer interface {
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// Query selects indexed events.
+type Query struct {
+	// DataSetID restricts events to a data set when non-nil.
+	DataSetID *uint64
+	// Names restricts events to the given names when non-empty.
+	Names []string
+	// Before restricts events to blocks before it when non-zero.
+	Before int64
+	// Limit is the maximum number of events returned, [DefaultLimit] if zero.
+	Limit int
+}
+
+// Indexer records the events of the verifier and service contracts that
+// concern the node's data sets. Events are indexed once they are
+// [Confidence] epochs deep, in the background as new tipsets arrive.
+type Indexer struct {
+	db       *gorm.DB
+	client   LogFilterer
+	verifier common.Address
+	service  common.Address
+	abi      *abi.ABI
+	// names of the indexed events by topic
+	topics map[common.Hash]string
+
+	head   atomic.Int64
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates an indexer of the events emitted by the verifier and service
+// contracts.
+func New(db *gorm.DB, client LogFilterer, verifier, service common.Address) (*Indexer, error) {
+	verifierABI, err := bindings.PDPVerifierMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("getting verifier ABI: %w", err)
+	}
+	topics := map[common.Hash]string{faultRecordTopic: FaultRecord}
+	for _, name := range []string{PiecesAdded, NextProvingPeriod, PossessionProven} {
+		event, ok := verifierABI.Events[name]
+		if !ok {
+			return nil, fmt.Errorf("%s event not found in verifier ABI", name)
+		}
+		topics[event.ID] = name
+	}
+	return &Indexer{
+		db:       db,
+		client:   client,
+		verifier: verifier,
+		service:  service,
+		abi:      verifierABI,
+		topics:   topics,
+		wake:     make(chan struct{}, 1),
+	}, nil
+}
+
+// Watch wakes the indexer for every tipset applied by the chain scheduler. It
+// must be called before the scheduler is started.
+func (ix *Indexer) Watch(sched *chainsched.Scheduler) error {
+	return sched.AddHandler(func(ctx context.Context, revert, apply *chaintypes.TipSet) error {
+		if apply == nil {
+			return nil
+		}
+		ix.head.Store(int64(apply.Height()))
+		select {
+		case ix.wake <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+}
+
+// Start indexes in the background whenever a new tipset is seen, so that
+// searching for logs does not hold up the other chain handlers.
+func (ix *Indexer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ix.cancel = cancel
+	ix.done = make(chan struct{})
+	go func() {
+		defer close(ix.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ix.wake:
+			}
+			if _, err := ix.Sync(ctx, ix.head.Load()); err != nil && !errors.Is(err, context.Canceled) {
+				log.Errorw("indexing chain events", "error", err)
+			}
+		}
+	}()
+}
+
+// Stop stops background indexing.
+func (ix *Indexer) Stop(ctx context.Context) error {
+	if ix.cancel == nil {
+		return nil
+	}
+	ix.cancel()
+	select {
+	case <-ix.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cursor returns the last block searched for events, or zero if none has
+// been.
+func (ix *Indexer) Cursor(ctx context.Context) (int64, error) {
+	var cursor models.ChainEventCursor
+	err := ix.db.WithContext(ctx).Where("id = ?", cursorID).First(&cursor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("getting chain event cursor: %w", err)
+	}
+	return cursor.BlockNumber, nil
+}
+
+// Sync indexes the events of the blocks after the cursor that are at least
+// [Confidence] epochs behind head, and returns the number of events recorded.
+func (ix *Indexer) Sync(ctx context.Context, head int64) (int, error) {
+	target := head - Confidence
+	if target <= 0 {
+		return 0, nil
+	}
+	from, err := ix.Cursor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if from == 0 {
+		from = max(target-DefaultLookback, 0)
+	}
+	from++
+
+	recorded := 0
+	for from <= target {
+		to := min(from+MaxRange-1, target)
+		n, err := ix.index(ctx, from, to)
+		if err != nil {
+			return recorded, fmt.Errorf("indexing blocks %d-%d: %w", from, to, err)
+		}
+		recorded += n
+		from = to + 1
+	}
+	return recorded, nil
+}
+
+// index records the events of blocks from to to, inclusive, and moves the
+// cursor to to.
+func (ix *Indexer) index(ctx context.Context, from, to int64) (int, error) {
+	var ids []int64
+	if err := ix.db.WithContext(ctx).Model(&models.PDPProofSet{}).Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("listing data sets: %w", err)
+	}
+
+	var events []models.ChainEvent
+	if len(ids) > 0 {
+		dataSets := make([]common.Hash, len(ids))
+		for i, id := range ids {
+			dataSets[i] = common.BigToHash(big.NewInt(id))
+		}
+		topics := make([]common.Hash, 0, len(ix.topics))
+		for topic := range ix.topics {
+			topics = append(topics, topic)
+		}
+		logs, err := ix.client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: big.NewInt(from),
+			ToBlock:   big.NewInt(to),
+			Addresses: []common.Address{ix.verifier, ix.service},
+			Topics:    [][]common.Hash{topics, dataSets},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("filtering logs: %w", err)
+		}
+		for _, l := range logs {
+			event, ok, err := ix.decode(l)
+			if err != nil {
+				log.Warnw("skipping undecodable event", "tx", l.TxHash, "index", l.Index, "error", err)
+				continue
+			}
+			if ok {
+				events = append(events, event)
+			}
+		}
+	}
+
+	err := ix.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(events) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&events).Error; err != nil {
+				return fmt.Errorf("recording events: %w", err)
+			}
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&models.ChainEventCursor{
+			ID:          cursorID,
+			BlockNumber: to,
+		}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(events) > 0 {
+		log.Infow("indexed chain events", "from", from, "to", to, "events", len(events))
+	}
+	return len(events), nil
+}
+
+// decode converts a log into an event. It returns false for logs that are not
+// indexed events.
+func (ix *Indexer) decode(l types.Log) (models.ChainEvent, bool, error) {
+	if l.Removed || len(l.Topics) == 0 {
+		return models.ChainEvent{}, false, nil
+	}
+	name, ok := ix.topics[l.Topics[0]]
+	if !ok {
+		return models.ChainEvent{}, false, nil
+	}
+	// every indexed event has the data set ID as its only indexed argument
+	if len(l.Topics) != 2 {
+		return models.ChainEvent{}, false, fmt.Errorf("%s event has %d topics, expected 2", name, len(l.Topics))
+	}
+
+	var args any
+	if name == FaultRecord {
+		if len(l.Data) != 64 {
+			return models.ChainEvent{}, false, fmt.Errorf("FaultRecord event has %d bytes of data, expected 64", len(l.Data))
+		}
+		args = FaultRecordArgs{
+			PeriodsFaulted: new(big.Int).SetBytes(l.Data[:32]).Uint64(),
+			Deadline:       new(big.Int).SetBytes(l.Data[32:]).Uint64(),
+		}
+	} else {
+		values, err := ix.abi.Events[name].Inputs.NonIndexed().Unpack(l.Data)
+		if err != nil {
+			return models.ChainEvent{}, false, fmt.Errorf("unpacking %s event: %w", name, err)
+		}
+		args, err = verifierArgs(name, values)
+		if err != nil {
+			return models.ChainEvent{}, false, err
+		}
+	}
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return models.ChainEvent{}, false, fmt.Errorf("encoding %s event arguments: %w", name, err)
+	}
+
+	return models.ChainEvent{
+		BlockNumber: int64(l.BlockNumber),
+		TxHash:      l.TxHash.Hex(),
+		LogIndex:    l.Index,
+		Contract:    l.Address.Hex(),
+		Name:        name,
+		DataSetID:   l.Topics[1].Big().Int64(),
+		Args:        argsJSON,
+	}, true, nil
+}
+
+// FaultRecordArgs are the arguments of a [FaultRecord] event.
+type FaultRecordArgs struct {
+	PeriodsFaulted uint64 `json:"periods_faulted"`
+	Deadline       uint64 `json:"deadline"`
+}
+
+// PiecesAddedArgs are the arguments of a [PiecesAdded] event.
+type PiecesAddedArgs struct {
+	PieceIDs []uint64 `json:"piece_ids"`
+	Pieces   []string `json:"pieces"`
+}
+
+// NextProvingPeriodArgs are the arguments of a [NextProvingPeriod] event.
+type NextProvingPeriodArgs struct {
+	ChallengeEpoch uint64 `json:"challenge_epoch"`
+	LeafCount      uint64 `json:"leaf_count"`
+}
+
+// PossessionProvenArgs are the arguments of a [PossessionProven] event.
+type PossessionProvenArgs struct {
+	Challenges int `json:"challenges"`
+}
+
+// verifierArgs converts the unpacked arguments of a verifier event.
+func verifierArgs(name string, values []any) (any, error) {
+	switch name {
+	case PiecesAdded:
+		// PiecesAdded(uint256 indexed setId, uint256[] pieceIds, Cids.Cid[] pieceCids)
+		if len(values) != 2 {
+			return nil, fmt.Errorf("PiecesAdded event has %d arguments, expected 2", len(values))
+		}
+		ids, ok := values[0].([]*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("unexpected PiecesAdded piece IDs type %T", values[0])
+		}
+		args := PiecesAddedArgs{PieceIDs: make([]uint64, len(ids))}
+		for i, id := range ids {
+			args.PieceIDs[i] = id.Uint64()
+		}
+		// the piece CIDs are a slice of anonymous structs generated for the
+		// Cids.Cid tuple
+		pieces := reflect.ValueOf(values[1])
+		if pieces.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("unexpected PiecesAdded piece CIDs type %T", values[1])
+		}
+		for i := range pieces.Len() {
+			data := reflect.Indirect(pieces.Index(i)).FieldByName("Data")
+			if !data.IsValid() || data.Kind() != reflect.Slice {
+				return nil, fmt.Errorf("unexpected PiecesAdded piece CID type %s", pieces.Index(i).Type())
+			}
+			c, err := cid.Cast(data.Bytes())
+			if err != nil {
+				return nil, fmt.Errorf("decoding piece CID %d: %w", i, err)
+			}
+			args.Pieces = append(args.Pieces, c.String())
+		}
+		return args, nil
+	case NextProvingPeriod:
+		// NextProvingPeriod(uint256 indexed setId, uint256 challengeEpoch, uint256 leafCount)
+		if len(values) != 2 {
+			return nil, fmt.Errorf("NextProvingPeriod event has %d arguments, expected 2", len(values))
+		}
+		epoch, ok1 := values[0].(*big.Int)
+		leaves, ok2 := values[1].(*big.Int)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("unexpected NextProvingPeriod argument types %T, %T", values[0], values[1])
+		}
+		return NextProvingPeriodArgs{ChallengeEpoch: epoch.Uint64(), LeafCount: leaves.Uint64()}, nil
+	case PossessionProven:
+		// PossessionProven(uint256 indexed setId, IPDPTypes.PieceIdAndOffset[] challenges)
+		if len(values) != 1 {
+			return nil, fmt.Errorf("PossessionProven event has %d arguments, expected 1", len(values))
+		}
+		challenges := reflect.ValueOf(values[0])
+		if challenges.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("unexpected PossessionProven challenges type %T", values[0])
+		}
+		return PossessionProvenArgs{Challenges: challenges.Len()}, nil
+	}
+	return nil, fmt.Errorf("unknown event %s", name)
+}
+
+// List returns the indexed events selected by q, most recent first.
+func (ix *Indexer) List(ctx context.Context, q Query) ([]models.ChainEvent, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	db := ix.db.WithContext(ctx).Order("block_number DESC, log_index DESC").Limit(limit)
+	if q.DataSetID != nil {
+		db = db.Where("data_set_id = ?", *q.DataSetID)
+	}
+	if len(q.Names) > 0 {
+		db = db.Where("name IN ?", q.Names)
+	}
+	if q.Before > 0 {
+		db = db.Where("block_number < ?", q.Before)
+	}
+	var events []models.ChainEvent
+	if err := db.Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("listing chain events: %w", err)
+	}
+	return events, nil
+}
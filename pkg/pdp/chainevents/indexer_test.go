@@ -0,0 +1,161 @@
+package chainevents
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/storacha/filecoin-services/go/bindings"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+var (
+	verifierAddr = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	serviceAddr  = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+)
+
+// fakeFilterer returns the logs within the queried block range.
+type fakeFilterer struct {
+	logs    []types.Log
+	queries []ethereum.FilterQuery
+}
+
+func (f *fakeFilterer) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	f.queries = append(f.queries, q)
+	var out []types.Log
+	for _, l := range f.logs {
+		if l.BlockNumber >= q.FromBlock.Uint64() && l.BlockNumber <= q.ToBlock.Uint64() {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func setupDB(t *testing.T, dataSets ...int64) *gorm.DB {
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+	for _, id := range dataSets {
+		require.NoError(t, db.Create(&models.PDPProofSet{ID: id, CreateMessageHash: "0x01", Service: "storacha"}).Error)
+	}
+	return db
+}
+
+func verifierLog(t *testing.T, ix *Indexer, name string, dataSet int64, block uint64, args ...any) types.Log {
+	event := ix.abi.Events[name]
+	data, err := event.Inputs.NonIndexed().Pack(args...)
+	require.NoError(t, err)
+	return types.Log{
+		Address:     verifierAddr,
+		Topics:      []common.Hash{event.ID, common.BigToHash(big.NewInt(dataSet))},
+		Data:        data,
+		BlockNumber: block,
+		TxHash:      common.BigToHash(big.NewInt(int64(block))),
+	}
+}
+
+func faultLog(dataSet int64, block uint64, periods, deadline int64) types.Log {
+	return types.Log{
+		Address:     serviceAddr,
+		Topics:      []common.Hash{faultRecordTopic, common.BigToHash(big.NewInt(dataSet))},
+		Data:        append(common.BigToHash(big.NewInt(periods)).Bytes(), common.BigToHash(big.NewInt(deadline)).Bytes()...),
+		BlockNumber: block,
+		TxHash:      common.BigToHash(big.NewInt(int64(block))),
+		Index:       1,
+	}
+}
+
+func TestSync(t *testing.T) {
+	t.Run("records events of the node's data sets", func(t *testing.T) {
+		client := &fakeFilterer{}
+		ix, err := New(setupDB(t, 1, 2), client, verifierAddr, serviceAddr)
+		require.NoError(t, err)
+
+		piece := testutil.RandomCID(t).(cidlink.Link).Cid
+		client.logs = []types.Log{
+			verifierLog(t, ix, PiecesAdded, 1, 100, []*big.Int{big.NewInt(0)}, []bindings.CidsCid{{Data: piece.Bytes()}}),
+			verifierLog(t, ix, NextProvingPeriod, 1, 110, big.NewInt(2990), big.NewInt(64)),
+			faultLog(2, 120, 2, 3000),
+			// not yet deep enough to be indexed
+			faultLog(1, 200, 1, 3100),
+		}
+
+		n, err := ix.Sync(t.Context(), 200)
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		require.Len(t, client.queries, 1)
+		require.Len(t, client.queries[0].Topics[1], 2, "filters by data set")
+
+		cursor, err := ix.Cursor(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, int64(200-Confidence), cursor)
+
+		events, err := ix.List(t.Context(), Query{})
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+		require.Equal(t, FaultRecord, events[0].Name)
+		require.Equal(t, int64(2), events[0].DataSetID)
+		require.Equal(t, serviceAddr.Hex(), events[0].Contract)
+		var fault FaultRecordArgs
+		require.NoError(t, json.Unmarshal(events[0].Args, &fault))
+		require.Equal(t, FaultRecordArgs{PeriodsFaulted: 2, Deadline: 3000}, fault)
+
+		dataSet := uint64(1)
+		events, err = ix.List(t.Context(), Query{DataSetID: &dataSet, Names: []string{PiecesAdded}})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		var added PiecesAddedArgs
+		require.NoError(t, json.Unmarshal(events[0].Args, &added))
+		require.Equal(t, PiecesAddedArgs{PieceIDs: []uint64{0}, Pieces: []string{piece.String()}}, added)
+
+		events, err = ix.List(t.Context(), Query{Before: 110})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, int64(100), events[0].BlockNumber)
+
+		// the remaining event is indexed once it is deep enough
+		n, err = ix.Sync(t.Context(), 200+Confidence)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		require.Equal(t, big.NewInt(200-Confidence+1), client.queries[1].FromBlock)
+	})
+
+	t.Run("searches long ranges in chunks", func(t *testing.T) {
+		db := setupDB(t, 1)
+		client := &fakeFilterer{}
+		ix, err := New(db, client, verifierAddr, serviceAddr)
+		require.NoError(t, err)
+		require.NoError(t, db.Create(&models.ChainEventCursor{ID: cursorID, BlockNumber: 10}).Error)
+
+		_, err = ix.Sync(t.Context(), 10+MaxRange+100+Confidence)
+		require.NoError(t, err)
+		require.Len(t, client.queries, 2)
+		require.Equal(t, big.NewInt(11), client.queries[0].FromBlock)
+		require.Equal(t, big.NewInt(10+MaxRange), client.queries[0].ToBlock)
+		require.Equal(t, big.NewInt(10+MaxRange+100), client.queries[1].ToBlock)
+	})
+
+	t.Run("advances without data sets", func(t *testing.T) {
+		client := &fakeFilterer{}
+		ix, err := New(setupDB(t), client, verifierAddr, serviceAddr)
+		require.NoError(t, err)
+
+		_, err = ix.Sync(t.Context(), 100)
+		require.NoError(t, err)
+		require.Empty(t, client.queries)
+		cursor, err := ix.Cursor(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, int64(100-Confidence), cursor)
+	})
+}
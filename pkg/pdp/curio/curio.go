@@ -0,0 +1,308 @@
+// Package curio adds aggregates to proof sets proven by a Curio node, for
+// operators who run Curio next to the PDP service of the node.
+//
+// Curio only adds roots whose pieces it holds, so the pieces of an aggregate
+// are streamed to its piece upload endpoints first. Each upload asks Curio to
+// call back the node once the piece is parked, and roots are only added once
+// every piece of their aggregate has been. Until then AddRoots fails with
+// ErrPiecesPending, and the submission is retried.
+//
+// Reconcile compares the recorded uploads with the pieces Curio holds, to
+// recover from lost notifications and pieces Curio dropped.
+package curio
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-ucanto/principal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/storacha/piri/pkg/pdp/httpapi"
+	"github.com/storacha/piri/pkg/pdp/httpapi/client"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+var log = logging.Logger("pdp/curio")
+
+// NotifyRoutePath is the path of the node Curio calls back once an uploaded
+// piece is parked.
+const NotifyRoutePath = "/curio/notify"
+
+// DefaultUploadTimeout is how long a piece uploaded to Curio may wait for its
+// notification before it is uploaded again.
+const DefaultUploadTimeout = time.Hour
+
+// ErrPiecesPending is returned by AddRoots while pieces of the roots have not
+// been parked by Curio yet.
+var ErrPiecesPending = errors.New("pieces are not parked by curio yet")
+
+// Pieces reads the pieces held by the node.
+type Pieces interface {
+	ResolveToBlob(ctx context.Context, piece multihash.Multihash) (multihash.Multihash, bool, error)
+	Read(ctx context.Context, blob multihash.Multihash, options ...types.ReadPieceOption) (*types.PieceReader, error)
+}
+
+// Config configures the Curio backend.
+type Config struct {
+	// Endpoint is the URL of the PDP API of the Curio node.
+	Endpoint *url.URL
+	// PublicURL is the URL Curio reaches the node at, to notify it of parked
+	// pieces.
+	PublicURL url.URL
+	// UploadTimeout is how long an upload may wait for its notification
+	// before the piece is uploaded again. DefaultUploadTimeout if zero.
+	UploadTimeout time.Duration
+}
+
+// upload is a piece streamed to Curio.
+type upload = models.CurioPieceUpload
+
+// Backend adds roots to proof sets of a Curio node.
+type Backend struct {
+	endpoint      *url.URL
+	notifyURL     *url.URL
+	authHeader    string
+	http          *http.Client
+	roots         *client.Client
+	pieces        Pieces
+	db            *gorm.DB
+	uploadTimeout time.Duration
+}
+
+// New creates a Curio backend authenticating with the identity of the node,
+// recording the uploaded pieces in db. The upload table is created by the
+// shared migrations of the PDP database, [models.AutoMigrateDB].
+func New(cfg Config, id principal.Signer, db *gorm.DB, pieces Pieces, hc *http.Client) (*Backend, error) {
+	if cfg.Endpoint == nil {
+		return nil, fmt.Errorf("curio endpoint is required")
+	}
+	token, err := httpapi.NewAuthToken(id)
+	if err != nil {
+		return nil, fmt.Errorf("creating curio auth token: %w", err)
+	}
+	roots, err := client.New(cfg.Endpoint,
+		client.WithBearerFromSigner(id),
+		client.WithEndpointType(client.GenericEndpoint),
+		client.WithHTTPClient(hc),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating curio client: %w", err)
+	}
+	timeout := cfg.UploadTimeout
+	if timeout == 0 {
+		timeout = DefaultUploadTimeout
+	}
+	return &Backend{
+		endpoint:      cfg.Endpoint,
+		notifyURL:     cfg.PublicURL.JoinPath(NotifyRoutePath),
+		authHeader:    "Bearer " + token,
+		http:          hc,
+		roots:         roots,
+		pieces:        pieces,
+		db:            db,
+		uploadTimeout: timeout,
+	}, nil
+}
+
+// AddRoots streams the pieces of the roots to Curio, and adds the roots to
+// the proof set once Curio has parked all of them. Curio sends the
+// transaction adding the roots itself, so no transaction hash is returned.
+func (b *Backend) AddRoots(ctx context.Context, proofSetID uint64, roots []types.RootAdd) (common.Hash, error) {
+	seen := map[cid.Cid]bool{}
+	pending := 0
+	for _, root := range roots {
+		for _, sub := range root.SubRoots {
+			if seen[sub] {
+				continue
+			}
+			seen[sub] = true
+			parked, err := b.push(ctx, sub)
+			if err != nil {
+				return common.Hash{}, fmt.Errorf("pushing piece %s to curio: %w", sub, err)
+			}
+			if !parked {
+				pending++
+			}
+		}
+	}
+	if pending > 0 {
+		return common.Hash{}, fmt.Errorf("%w: %d of %d pieces", ErrPiecesPending, pending, len(seen))
+	}
+
+	if _, err := b.roots.AddRoots(ctx, proofSetID, roots); err != nil {
+		return common.Hash{}, fmt.Errorf("adding roots to curio proof set %d: %w", proofSetID, err)
+	}
+	return common.Hash{}, nil
+}
+
+// push uploads the piece to Curio unless it is parked there, or an upload of
+// it is waiting for its notification. It returns true if the piece is parked.
+func (b *Backend) push(ctx context.Context, pieceCID cid.Cid) (bool, error) {
+	var rec upload
+	err := b.db.WithContext(ctx).First(&rec, "piece_cid = ?", pieceCID.String()).Error
+	switch {
+	case err == nil:
+		if rec.ParkedAt != nil {
+			return true, nil
+		}
+		if rec.UploadedAt != nil && time.Since(*rec.UploadedAt) < b.uploadTimeout {
+			return false, nil
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return false, fmt.Errorf("reading upload: %w", err)
+	}
+
+	blob, found, err := b.pieces.ResolveToBlob(ctx, pieceCID.Hash())
+	if err != nil {
+		return false, fmt.Errorf("resolving blob: %w", err)
+	}
+	if !found {
+		return false, fmt.Errorf("blob of piece not found")
+	}
+	pr, err := b.pieces.Read(ctx, blob)
+	if err != nil {
+		return false, fmt.Errorf("reading blob %s: %w", blob, err)
+	}
+	defer pr.Data.Close()
+
+	uploadID, allocated, err := b.allocate(ctx, blob, pr.Size)
+	if err != nil {
+		return false, err
+	}
+	if !allocated {
+		// curio already holds the piece
+		now := time.Now()
+		return true, b.save(ctx, upload{PieceCID: pieceCID.String(), UploadID: uuid.Nil.String(), ParkedAt: &now})
+	}
+	if err := b.save(ctx, upload{PieceCID: pieceCID.String(), UploadID: uploadID.String()}); err != nil {
+		return false, err
+	}
+	if err := b.upload(ctx, uploadID, pr); err != nil {
+		return false, err
+	}
+	if err := b.db.WithContext(ctx).Model(&upload{}).
+		Where("piece_cid = ?", pieceCID.String()).
+		Update("uploaded_at", time.Now()).Error; err != nil {
+		return false, fmt.Errorf("recording upload: %w", err)
+	}
+	log.Infow("uploaded piece to curio", "piece", pieceCID, "upload", uploadID, "size", pr.Size)
+	return false, nil
+}
+
+// allocate asks Curio for an upload of the blob, returning false if Curio
+// already holds it.
+func (b *Backend) allocate(ctx context.Context, blob multihash.Multihash, size int64) (uuid.UUID, bool, error) {
+	decoded, err := multihash.Decode(blob)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("decoding blob multihash: %w", err)
+	}
+	name, ok := multihash.Codes[decoded.Code]
+	if !ok {
+		return uuid.Nil, false, fmt.Errorf("unsupported blob hash function: %d", decoded.Code)
+	}
+	req := httpapi.AddPieceRequest{
+		Check: httpapi.PieceHash{
+			Name: name,
+			Hash: hex.EncodeToString(decoded.Digest),
+			Size: size,
+		},
+		Notify: b.notifyURL.String(),
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("encoding piece allocation: %w", err)
+	}
+	res, err := b.do(ctx, http.MethodPost, b.endpoint.JoinPath("/pdp/piece").String(), bytes.NewReader(body), "application/json", int64(len(body)))
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("allocating piece upload: %w", err)
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+		return uuid.Nil, false, nil
+	case http.StatusCreated:
+		// the location is the path of the upload, ending with its ID
+		id, err := uuid.Parse(path.Base(res.Header.Get("Location")))
+		if err != nil {
+			return uuid.Nil, false, fmt.Errorf("parsing upload ID from location %q: %w", res.Header.Get("Location"), err)
+		}
+		return id, true, nil
+	default:
+		return uuid.Nil, false, responseError("allocating piece upload", res)
+	}
+}
+
+func (b *Backend) upload(ctx context.Context, id uuid.UUID, pr *types.PieceReader) error {
+	route := b.endpoint.JoinPath("/pdp/piece/upload", id.String()).String()
+	res, err := b.do(ctx, http.MethodPut, route, pr.Data, "application/octet-stream", pr.Size)
+	if err != nil {
+		return fmt.Errorf("uploading piece: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return responseError("uploading piece", res)
+	}
+	return nil
+}
+
+func (b *Backend) save(ctx context.Context, rec upload) error {
+	if err := b.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "piece_cid"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"upload_id":   rec.UploadID,
+			"uploaded_at": rec.UploadedAt,
+			"parked_at":   rec.ParkedAt,
+		}),
+	}).Create(&rec).Error; err != nil {
+		return fmt.Errorf("recording upload of piece %s: %w", rec.PieceCID, err)
+	}
+	return nil
+}
+
+// Notified records that Curio parked the piece of the upload. Notifications
+// of unknown uploads are ignored.
+func (b *Backend) Notified(ctx context.Context, uploadID uuid.UUID) error {
+	res := b.db.WithContext(ctx).Model(&upload{}).
+		Where("upload_id = ? AND parked_at IS NULL", uploadID.String()).
+		Update("parked_at", time.Now())
+	if res.Error != nil {
+		return fmt.Errorf("recording parked upload %s: %w", uploadID, res.Error)
+	}
+	if res.RowsAffected > 0 {
+		log.Infow("curio parked piece", "upload", uploadID)
+	}
+	return nil
+}
+
+func (b *Backend) do(ctx context.Context, method, route string, body io.Reader, contentType string, size int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, route, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", b.authHeader)
+	return b.http.Do(req)
+}
+
+func responseError(op string, res *http.Response) error {
+	msg, _ := io.ReadAll(res.Body)
+	return fmt.Errorf("%s: %w", op, client.ErrFailedResponse{StatusCode: res.StatusCode, Body: string(msg)})
+}
@@ -0,0 +1,128 @@
+package curio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/httpapi"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+type fakePieces struct {
+	data []byte
+}
+
+func (p fakePieces) ResolveToBlob(_ context.Context, piece multihash.Multihash) (multihash.Multihash, bool, error) {
+	return piece, true, nil
+}
+
+func (p fakePieces) Read(_ context.Context, _ multihash.Multihash, _ ...types.ReadPieceOption) (*types.PieceReader, error) {
+	return &types.PieceReader{Size: int64(len(p.data)), Data: io.NopCloser(bytes.NewReader(p.data))}, nil
+}
+
+// fakeCurio records the requests the backend makes to the PDP API of Curio.
+type fakeCurio struct {
+	mu       sync.Mutex
+	uploadID uuid.UUID
+	notify   []string
+	uploaded [][]byte
+	roots    int
+	// held are the hex encoded digests of the pieces Curio holds
+	held map[string]bool
+}
+
+func (f *fakeCurio) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/pdp/piece":
+		if !f.held[r.URL.Query().Get("hash")] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPost && r.URL.Path == "/pdp/piece":
+		var req httpapi.AddPieceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.notify = append(f.notify, req.Notify)
+		w.Header().Set("Location", "/pdp/piece/upload/"+f.uploadID.String())
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && r.URL.Path == "/pdp/piece/upload/"+f.uploadID.String():
+		data, _ := io.ReadAll(r.Body)
+		f.uploaded = append(f.uploaded, data)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/roots"):
+		f.roots++
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestAddRoots(t *testing.T) {
+	fake := &fakeCurio{uploadID: uuid.New()}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	endpoint, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	publicURL, err := url.Parse("https://piri.example.com")
+	require.NoError(t, err)
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+
+	data := testutil.RandomBytes(t, 256)
+	b, err := New(Config{Endpoint: endpoint, PublicURL: *publicURL}, testutil.Alice, db, fakePieces{data: data}, srv.Client())
+	require.NoError(t, err)
+
+	sub := cid.NewCidV1(cid.Raw, testutil.RandomMultihash(t))
+	roots := []types.RootAdd{{Root: sub, SubRoots: []cid.Cid{sub}}}
+
+	t.Run("waits for curio to park the pieces", func(t *testing.T) {
+		_, err := b.AddRoots(t.Context(), 1, roots)
+		require.ErrorIs(t, err, ErrPiecesPending)
+		require.Equal(t, []string{"https://piri.example.com" + NotifyRoutePath}, fake.notify)
+		require.Equal(t, [][]byte{data}, fake.uploaded)
+		require.Zero(t, fake.roots)
+
+		// the upload waits for its notification instead of being repeated
+		_, err = b.AddRoots(t.Context(), 1, roots)
+		require.ErrorIs(t, err, ErrPiecesPending)
+		require.Len(t, fake.uploaded, 1)
+	})
+
+	t.Run("ignores notifications of unknown uploads", func(t *testing.T) {
+		require.NoError(t, b.Notified(t.Context(), uuid.New()))
+		_, err := b.AddRoots(t.Context(), 1, roots)
+		require.ErrorIs(t, err, ErrPiecesPending)
+	})
+
+	t.Run("adds the roots once the pieces are parked", func(t *testing.T) {
+		require.NoError(t, b.Notified(t.Context(), fake.uploadID))
+		_, err := b.AddRoots(t.Context(), 1, roots)
+		require.NoError(t, err)
+		require.Equal(t, 1, fake.roots)
+		require.Len(t, fake.uploaded, 1)
+	})
+}
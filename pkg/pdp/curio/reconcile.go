@@ -0,0 +1,185 @@
+package curio
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+)
+
+// DivergenceKind is how the state the node records for a piece uploaded to
+// Curio differs from the state of Curio.
+type DivergenceKind string
+
+const (
+	// DivergenceMissing is a piece the node believes Curio parked, that Curio
+	// does not hold. It is uploaded again.
+	DivergenceMissing DivergenceKind = "missing"
+	// DivergenceUnnotified is a piece Curio holds, whose notification never
+	// reached the node. It is recorded as parked.
+	DivergenceUnnotified DivergenceKind = "unnotified"
+	// DivergenceStalled is a piece whose upload did not complete, or was not
+	// parked by Curio within the upload timeout. It is uploaded again.
+	DivergenceStalled DivergenceKind = "stalled"
+	// DivergenceOrphaned is an upload of a piece the node no longer holds. Its
+	// record is removed.
+	DivergenceOrphaned DivergenceKind = "orphaned"
+)
+
+// Divergence is a piece whose recorded state differs from the state of Curio.
+type Divergence struct {
+	PieceCID string
+	Kind     DivergenceKind
+	// Resolved is true once the divergence has been fixed, false for dry runs
+	// and failed fixes.
+	Resolved bool
+	// Error is why the divergence could not be fixed.
+	Error string
+}
+
+// Report is the outcome of a reconciliation of the pieces uploaded to Curio.
+type Report struct {
+	// Checked is the number of uploads compared with Curio.
+	Checked int
+	// Divergences are the uploads whose state differs from Curio.
+	Divergences []Divergence
+}
+
+// Reconcile compares the pieces the node recorded as uploaded to or parked by
+// Curio with the pieces Curio holds. Divergent pieces are uploaded again,
+// recorded as parked or removed, unless dryRun is set, in which case they are
+// only reported. Reconciliation stops at the first error querying Curio or
+// the database, while failures to fix a piece are recorded in the report.
+func (b *Backend) Reconcile(ctx context.Context, dryRun bool) (Report, error) {
+	var uploads []upload
+	if err := b.db.WithContext(ctx).Order("created_at").Find(&uploads).Error; err != nil {
+		return Report{}, fmt.Errorf("listing curio uploads: %w", err)
+	}
+
+	var report Report
+	for _, rec := range uploads {
+		kind, err := b.diverges(ctx, rec)
+		if err != nil {
+			return report, fmt.Errorf("checking piece %s: %w", rec.PieceCID, err)
+		}
+		report.Checked++
+		if kind == "" {
+			continue
+		}
+		d := Divergence{PieceCID: rec.PieceCID, Kind: kind}
+		if !dryRun {
+			if err := b.resolve(ctx, rec, kind); err != nil {
+				d.Error = err.Error()
+			} else {
+				d.Resolved = true
+			}
+		}
+		log.Warnw("curio piece state diverges", "piece", rec.PieceCID, "kind", kind, "resolved", d.Resolved, "error", d.Error)
+		report.Divergences = append(report.Divergences, d)
+	}
+	return report, nil
+}
+
+// diverges returns how the recorded state of the upload differs from Curio,
+// empty if it does not.
+func (b *Backend) diverges(ctx context.Context, rec upload) (DivergenceKind, error) {
+	pieceCID, err := cid.Decode(rec.PieceCID)
+	if err != nil {
+		return "", fmt.Errorf("decoding piece CID: %w", err)
+	}
+	blob, found, err := b.pieces.ResolveToBlob(ctx, pieceCID.Hash())
+	if err != nil {
+		return "", fmt.Errorf("resolving blob: %w", err)
+	}
+	if !found {
+		return DivergenceOrphaned, nil
+	}
+	pr, err := b.pieces.Read(ctx, blob)
+	if err != nil {
+		return "", fmt.Errorf("reading blob %s: %w", blob, err)
+	}
+	pr.Data.Close()
+
+	held, err := b.find(ctx, blob, pr.Size)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case rec.ParkedAt != nil && !held:
+		return DivergenceMissing, nil
+	case rec.ParkedAt == nil && held:
+		return DivergenceUnnotified, nil
+	case rec.ParkedAt == nil && time.Since(rec.CreatedAt) >= b.uploadTimeout &&
+		(rec.UploadedAt == nil || time.Since(*rec.UploadedAt) >= b.uploadTimeout):
+		return DivergenceStalled, nil
+	default:
+		return "", nil
+	}
+}
+
+func (b *Backend) resolve(ctx context.Context, rec upload, kind DivergenceKind) error {
+	switch kind {
+	case DivergenceUnnotified:
+		now := time.Now()
+		rec.ParkedAt = &now
+		return b.save(ctx, rec)
+	case DivergenceOrphaned:
+		return b.forget(ctx, rec.PieceCID)
+	default:
+		// forget the upload so the piece is uploaded again
+		if err := b.forget(ctx, rec.PieceCID); err != nil {
+			return err
+		}
+		pieceCID, err := cid.Decode(rec.PieceCID)
+		if err != nil {
+			return fmt.Errorf("decoding piece CID: %w", err)
+		}
+		if _, err := b.push(ctx, pieceCID); err != nil {
+			return fmt.Errorf("uploading piece again: %w", err)
+		}
+		return nil
+	}
+}
+
+func (b *Backend) forget(ctx context.Context, pieceCID string) error {
+	if err := b.db.WithContext(ctx).Delete(&upload{}, "piece_cid = ?", pieceCID).Error; err != nil {
+		return fmt.Errorf("removing upload of piece %s: %w", pieceCID, err)
+	}
+	return nil
+}
+
+// find reports whether Curio holds the blob.
+func (b *Backend) find(ctx context.Context, blob multihash.Multihash, size int64) (bool, error) {
+	decoded, err := multihash.Decode(blob)
+	if err != nil {
+		return false, fmt.Errorf("decoding blob multihash: %w", err)
+	}
+	name, ok := multihash.Codes[decoded.Code]
+	if !ok {
+		return false, fmt.Errorf("unsupported blob hash function: %d", decoded.Code)
+	}
+	route := b.endpoint.JoinPath("/pdp/piece")
+	q := route.Query()
+	q.Set("name", name)
+	q.Set("hash", hex.EncodeToString(decoded.Digest))
+	q.Set("size", strconv.FormatInt(size, 10))
+	route.RawQuery = q.Encode()
+	res, err := b.do(ctx, http.MethodGet, route.String(), http.NoBody, "application/json", 0)
+	if err != nil {
+		return false, fmt.Errorf("finding piece: %w", err)
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, responseError("finding piece", res)
+	}
+}
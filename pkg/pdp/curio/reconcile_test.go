@@ -0,0 +1,128 @@
+package curio
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+// orphanPieces are pieces of which the node no longer holds some.
+type orphanPieces struct {
+	fakePieces
+	orphaned cid.Cid
+}
+
+func (p orphanPieces) ResolveToBlob(ctx context.Context, piece multihash.Multihash) (multihash.Multihash, bool, error) {
+	if string(piece) == string(p.orphaned.Hash()) {
+		return nil, false, nil
+	}
+	return p.fakePieces.ResolveToBlob(ctx, piece)
+}
+
+func TestReconcile(t *testing.T) {
+	fake := &fakeCurio{uploadID: uuid.New(), held: map[string]bool{}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	endpoint, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+
+	randomPiece := func() cid.Cid {
+		return cid.NewCidV1(cid.Raw, testutil.RandomMultihash(t))
+	}
+	hold := func(piece cid.Cid) {
+		decoded, err := multihash.Decode(piece.Hash())
+		require.NoError(t, err)
+		fake.held[hex.EncodeToString(decoded.Digest)] = true
+	}
+	now := time.Now()
+	longAgo := now.Add(-2 * time.Hour)
+
+	missing, unnotified, stalled, orphaned, parked, pending := randomPiece(), randomPiece(), randomPiece(), randomPiece(), randomPiece(), randomPiece()
+	hold(unnotified)
+	hold(parked)
+	for _, rec := range []models.CurioPieceUpload{
+		{PieceCID: missing.String(), UploadID: uuid.NewString(), UploadedAt: &longAgo, ParkedAt: &longAgo, CreatedAt: longAgo},
+		{PieceCID: unnotified.String(), UploadID: uuid.NewString(), UploadedAt: &now, CreatedAt: now},
+		{PieceCID: stalled.String(), UploadID: uuid.NewString(), UploadedAt: &longAgo, CreatedAt: longAgo},
+		{PieceCID: orphaned.String(), UploadID: uuid.NewString(), UploadedAt: &longAgo, ParkedAt: &longAgo, CreatedAt: longAgo},
+		{PieceCID: parked.String(), UploadID: uuid.NewString(), UploadedAt: &longAgo, ParkedAt: &longAgo, CreatedAt: longAgo},
+		{PieceCID: pending.String(), UploadID: uuid.NewString(), UploadedAt: &now, CreatedAt: now},
+	} {
+		require.NoError(t, db.Create(&rec).Error)
+	}
+
+	publicURL, err := url.Parse("https://piri.example.com")
+	require.NoError(t, err)
+	pieces := orphanPieces{fakePieces: fakePieces{data: testutil.RandomBytes(t, 256)}, orphaned: orphaned}
+	b, err := New(Config{Endpoint: endpoint, PublicURL: *publicURL, UploadTimeout: time.Hour}, testutil.Alice, db, pieces, srv.Client())
+	require.NoError(t, err)
+
+	divergent := []Divergence{
+		{PieceCID: missing.String(), Kind: DivergenceMissing},
+		{PieceCID: stalled.String(), Kind: DivergenceStalled},
+		{PieceCID: orphaned.String(), Kind: DivergenceOrphaned},
+		{PieceCID: unnotified.String(), Kind: DivergenceUnnotified},
+	}
+
+	t.Run("only reports divergences on dry runs", func(t *testing.T) {
+		report, err := b.Reconcile(t.Context(), true)
+		require.NoError(t, err)
+		require.Equal(t, 6, report.Checked)
+		require.ElementsMatch(t, divergent, report.Divergences)
+		require.Empty(t, fake.uploaded)
+
+		var count int64
+		require.NoError(t, db.Model(&models.CurioPieceUpload{}).Where("parked_at IS NOT NULL").Count(&count).Error)
+		require.Equal(t, int64(3), count)
+	})
+
+	t.Run("resolves divergences", func(t *testing.T) {
+		report, err := b.Reconcile(t.Context(), false)
+		require.NoError(t, err)
+		require.Equal(t, 6, report.Checked)
+		for i := range divergent {
+			divergent[i].Resolved = true
+		}
+		require.ElementsMatch(t, divergent, report.Divergences)
+
+		// the missing and stalled pieces are uploaded again
+		require.Len(t, fake.uploaded, 2)
+		for _, piece := range []cid.Cid{missing, stalled} {
+			var rec models.CurioPieceUpload
+			require.NoError(t, db.Take(&rec, "piece_cid = ?", piece.String()).Error)
+			require.Nil(t, rec.ParkedAt)
+			require.NotNil(t, rec.UploadedAt)
+			require.WithinDuration(t, time.Now(), *rec.UploadedAt, time.Minute)
+		}
+
+		var rec models.CurioPieceUpload
+		require.NoError(t, db.Take(&rec, "piece_cid = ?", unnotified.String()).Error)
+		require.NotNil(t, rec.ParkedAt)
+
+		var count int64
+		require.NoError(t, db.Model(&models.CurioPieceUpload{}).Where("piece_cid = ?", orphaned.String()).Count(&count).Error)
+		require.Zero(t, count)
+
+		report, err = b.Reconcile(t.Context(), false)
+		require.NoError(t, err)
+		require.Equal(t, 5, report.Checked)
+		require.Empty(t, report.Divergences)
+	})
+}
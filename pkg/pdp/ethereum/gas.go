@@ -0,0 +1,109 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// Names of the gas strategies.
+const (
+	// GasStrategyConservative pays the current base fee plus the suggested tip.
+	GasStrategyConservative = "conservative"
+	// GasStrategyAggressive pays double the suggested tip, with a fee cap of
+	// twice the current base fee, so the message survives several blocks of
+	// rising fees.
+	GasStrategyAggressive = "aggressive"
+	// GasStrategyDeadlineAware scales from conservative to aggressive fees as
+	// the deadline of the message approaches.
+	GasStrategyDeadlineAware = "deadline-aware"
+)
+
+// GasStrategies are the names accepted by NewGasStrategy.
+var GasStrategies = []string{GasStrategyConservative, GasStrategyAggressive, GasStrategyDeadlineAware}
+
+// FeeInputs are the chain conditions a transaction is priced at.
+type FeeInputs struct {
+	// BaseFee is the base fee of the latest block.
+	BaseFee *big.Int
+	// GasTipCap is the tip suggested by the node.
+	GasTipCap *big.Int
+	// Epoch is the height of the latest block.
+	Epoch int64
+}
+
+// GasStrategy sets the fee cap and tip cap of a transaction.
+type GasStrategy interface {
+	Fees(ctx context.Context, in FeeInputs) (gasFeeCap, gasTipCap *big.Int)
+}
+
+// NewGasStrategy returns the strategy with the given name.
+func NewGasStrategy(name string) (GasStrategy, error) {
+	switch name {
+	case GasStrategyConservative:
+		return scaledStrategy{}, nil
+	case GasStrategyAggressive:
+		return scaledStrategy{urgency: func(context.Context, int64) float64 { return 1 }}, nil
+	case GasStrategyDeadlineAware:
+		return scaledStrategy{urgency: deadlineUrgency}, nil
+	default:
+		return nil, fmt.Errorf("unknown gas strategy %q, expected one of %v", name, GasStrategies)
+	}
+}
+
+// Deadline is the range of epochs a transaction must land in, such as the
+// challenge window of a proof.
+type Deadline struct {
+	// Opens is the first epoch of the range.
+	Opens int64
+	// Closes is the epoch the transaction must land before.
+	Closes int64
+}
+
+type deadlineKey struct{}
+
+// WithDeadline attaches the deadline of the transaction sent with ctx, used by
+// the deadline-aware strategy.
+func WithDeadline(ctx context.Context, deadline Deadline) context.Context {
+	return context.WithValue(ctx, deadlineKey{}, deadline)
+}
+
+// DeadlineFromContext returns the deadline attached by WithDeadline.
+func DeadlineFromContext(ctx context.Context) (Deadline, bool) {
+	d, ok := ctx.Value(deadlineKey{}).(Deadline)
+	return d, ok
+}
+
+// deadlineUrgency is the fraction of the deadline range that has elapsed at
+// epoch. Transactions without a deadline are not urgent.
+func deadlineUrgency(ctx context.Context, epoch int64) float64 {
+	d, ok := DeadlineFromContext(ctx)
+	if !ok || d.Closes <= d.Opens {
+		return 0
+	}
+	elapsed := float64(epoch-d.Opens) / float64(d.Closes-d.Opens)
+	return min(max(elapsed, 0), 1)
+}
+
+// urgencyScale is the precision fees are scaled with.
+const urgencyScale = 1000
+
+// scaledStrategy multiplies the suggested tip and the base fee headroom of the
+// fee cap by 1 + urgency, from conservative fees at 0 to aggressive fees at 1.
+type scaledStrategy struct {
+	urgency func(ctx context.Context, epoch int64) float64
+}
+
+func (s scaledStrategy) Fees(ctx context.Context, in FeeInputs) (*big.Int, *big.Int) {
+	var urgency float64
+	if s.urgency != nil {
+		urgency = s.urgency(ctx, in.Epoch)
+	}
+	factor := big.NewInt(urgencyScale + int64(urgency*urgencyScale))
+	scale := func(v *big.Int) *big.Int {
+		out := new(big.Int).Mul(v, factor)
+		return out.Div(out, big.NewInt(urgencyScale))
+	}
+	gasTipCap := scale(in.GasTipCap)
+	return new(big.Int).Add(scale(in.BaseFee), gasTipCap), gasTipCap
+}
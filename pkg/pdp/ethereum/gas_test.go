@@ -0,0 +1,58 @@
+package ethereum_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/pdp/ethereum"
+)
+
+func TestGasStrategies(t *testing.T) {
+	in := ethereum.FeeInputs{BaseFee: big.NewInt(1000), GasTipCap: big.NewInt(100), Epoch: 150}
+	fees := func(t *testing.T, ctx context.Context, name string) (int64, int64) {
+		t.Helper()
+		strategy, err := ethereum.NewGasStrategy(name)
+		require.NoError(t, err)
+		feeCap, tipCap := strategy.Fees(ctx, in)
+		return feeCap.Int64(), tipCap.Int64()
+	}
+
+	t.Run("conservative pays the base fee and suggested tip", func(t *testing.T) {
+		feeCap, tipCap := fees(t, t.Context(), ethereum.GasStrategyConservative)
+		require.Equal(t, int64(1100), feeCap)
+		require.Equal(t, int64(100), tipCap)
+	})
+
+	t.Run("aggressive doubles the fees", func(t *testing.T) {
+		feeCap, tipCap := fees(t, t.Context(), ethereum.GasStrategyAggressive)
+		require.Equal(t, int64(2200), feeCap)
+		require.Equal(t, int64(200), tipCap)
+	})
+
+	t.Run("deadline-aware scales fees across the deadline", func(t *testing.T) {
+		feeCap, tipCap := fees(t, t.Context(), ethereum.GasStrategyDeadlineAware)
+		require.Equal(t, int64(1100), feeCap, "no deadline is not urgent")
+		require.Equal(t, int64(100), tipCap)
+
+		ctx := ethereum.WithDeadline(t.Context(), ethereum.Deadline{Opens: 100, Closes: 200})
+		feeCap, tipCap = fees(t, ctx, ethereum.GasStrategyDeadlineAware)
+		require.Equal(t, int64(1650), feeCap, "half way through the window")
+		require.Equal(t, int64(150), tipCap)
+
+		ctx = ethereum.WithDeadline(t.Context(), ethereum.Deadline{Opens: 200, Closes: 300})
+		feeCap, _ = fees(t, ctx, ethereum.GasStrategyDeadlineAware)
+		require.Equal(t, int64(1100), feeCap, "window not yet open")
+
+		ctx = ethereum.WithDeadline(t.Context(), ethereum.Deadline{Opens: 0, Closes: 100})
+		feeCap, _ = fees(t, ctx, ethereum.GasStrategyDeadlineAware)
+		require.Equal(t, int64(2200), feeCap, "window closed")
+	})
+
+	t.Run("rejects unknown strategies", func(t *testing.T) {
+		_, err := ethereum.NewGasStrategy("reckless")
+		require.Error(t, err)
+	})
+}
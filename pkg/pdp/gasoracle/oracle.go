@@ -0,0 +1,212 @@
+// Package gasoracle records the base fee history of the chain and estimates
+// fee percentiles from it, so that non-urgent messages can wait for a cheap
+// window before being sent.
+package gasoracle
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	chaintypes "github.com/filecoin-project/lotus/chain/types"
+	logging "github.com/ipfs/go-log/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/storacha/piri/pkg/pdp/chainsched"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+var log = logging.Logger("pdp/gasoracle")
+
+const (
+	// DefaultWindow is how much base fee history is kept when no window is
+	// configured.
+	DefaultWindow = 24 * time.Hour
+	// MinSamples is the number of samples required before percentile estimates
+	// are reported. Below it the history is too short to be representative.
+	MinSamples = 60
+)
+
+// EstimatePercentiles are the percentiles reported by [Oracle.Estimates].
+var EstimatePercentiles = []uint{10, 25, 50, 75, 90}
+
+// Sample is the base fee observed at a chain epoch.
+type Sample struct {
+	Epoch      int64
+	BaseFee    *big.Int
+	RecordedAt time.Time
+}
+
+// Estimates summarises the base fee history held by the oracle.
+type Estimates struct {
+	// Samples is the number of epochs in the history.
+	Samples int
+	// Window is how much history is kept.
+	Window time.Duration
+	// Latest is the most recent sample, nil if there is no history.
+	Latest *Sample
+	// Percentiles maps each of [EstimatePercentiles] to the base fee at that
+	// percentile. Empty when there are fewer than [MinSamples] samples.
+	Percentiles map[uint]*big.Int
+}
+
+// Oracle keeps a rolling window of chain base fees, persisted to the database
+// so that estimates survive restarts.
+type Oracle struct {
+	db     *gorm.DB
+	window time.Duration
+	now    func() time.Time
+
+	mu sync.RWMutex
+	// samples are ordered by epoch
+	samples []Sample
+}
+
+// New creates an oracle keeping window worth of history. A zero window uses
+// [DefaultWindow]. Call [Oracle.Load] to restore the persisted history.
+func New(db *gorm.DB, window time.Duration) *Oracle {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Oracle{db: db, window: window, now: time.Now}
+}
+
+// Load restores the history persisted within the window.
+func (o *Oracle) Load(ctx context.Context) error {
+	var rows []models.GasBaseFeeSample
+	err := o.db.WithContext(ctx).
+		Where("recorded_at >= ?", o.now().Add(-o.window)).
+		Order("epoch ASC").
+		Find(&rows).Error
+	if err != nil {
+		return fmt.Errorf("loading base fee history: %w", err)
+	}
+	samples := make([]Sample, 0, len(rows))
+	for _, r := range rows {
+		fee, ok := new(big.Int).SetString(r.BaseFee, 10)
+		if !ok {
+			log.Warnw("ignoring invalid base fee sample", "epoch", r.Epoch, "base_fee", r.BaseFee)
+			continue
+		}
+		samples = append(samples, Sample{Epoch: r.Epoch, BaseFee: fee, RecordedAt: r.RecordedAt})
+	}
+	o.mu.Lock()
+	o.samples = samples
+	o.mu.Unlock()
+	return nil
+}
+
+// Watch records the base fee of every tipset applied by the chain scheduler.
+// It must be called before the scheduler is started.
+func (o *Oracle) Watch(sched *chainsched.Scheduler) error {
+	return sched.AddHandler(func(ctx context.Context, revert, apply *chaintypes.TipSet) error {
+		if apply == nil || len(apply.Blocks()) == 0 {
+			return nil
+		}
+		baseFee := apply.Blocks()[0].ParentBaseFee
+		if baseFee.Int == nil {
+			return nil
+		}
+		return o.Record(ctx, int64(apply.Height()), baseFee.Int)
+	})
+}
+
+// Record adds the base fee observed at epoch to the history and drops samples
+// that have fallen out of the window. Recording an epoch at or below the latest
+// one (a reorg) replaces the samples from that epoch onwards.
+func (o *Oracle) Record(ctx context.Context, epoch int64, baseFee *big.Int) error {
+	now := o.now()
+	cutoff := now.Add(-o.window)
+	sample := Sample{Epoch: epoch, BaseFee: new(big.Int).Set(baseFee), RecordedAt: now}
+
+	o.mu.Lock()
+	i := sort.Search(len(o.samples), func(i int) bool { return o.samples[i].Epoch >= epoch })
+	samples := append(o.samples[:i:i], sample)
+	drop := sort.Search(len(samples), func(i int) bool { return !samples[i].RecordedAt.Before(cutoff) })
+	o.samples = samples[drop:]
+	o.mu.Unlock()
+
+	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("epoch > ?", epoch).Delete(&models.GasBaseFeeSample{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&models.GasBaseFeeSample{
+			Epoch:      epoch,
+			BaseFee:    baseFee.String(),
+			RecordedAt: now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("recorded_at < ?", cutoff).Delete(&models.GasBaseFeeSample{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("recording base fee for epoch %d: %w", epoch, err)
+	}
+	return nil
+}
+
+// Latest returns the most recently recorded sample.
+func (o *Oracle) Latest() (Sample, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if len(o.samples) == 0 {
+		return Sample{}, false
+	}
+	return o.samples[len(o.samples)-1], true
+}
+
+// Percentile returns the base fee at percentile p (0-100) of the history,
+// using the nearest-rank method. It returns false when there are fewer than
+// [MinSamples] samples.
+func (o *Oracle) Percentile(p uint) (*big.Int, bool) {
+	fees := o.sortedFees()
+	if len(fees) < MinSamples {
+		return nil, false
+	}
+	return percentile(fees, p), true
+}
+
+// Estimates summarises the current history.
+func (o *Oracle) Estimates() Estimates {
+	fees := o.sortedFees()
+	est := Estimates{
+		Samples:     len(fees),
+		Window:      o.window,
+		Percentiles: map[uint]*big.Int{},
+	}
+	if latest, ok := o.Latest(); ok {
+		est.Latest = &latest
+	}
+	if len(fees) >= MinSamples {
+		for _, p := range EstimatePercentiles {
+			est.Percentiles[p] = percentile(fees, p)
+		}
+	}
+	return est
+}
+
+func (o *Oracle) sortedFees() []*big.Int {
+	o.mu.RLock()
+	fees := make([]*big.Int, 0, len(o.samples))
+	for _, s := range o.samples {
+		fees = append(fees, s.BaseFee)
+	}
+	o.mu.RUnlock()
+	slices.SortFunc(fees, func(a, b *big.Int) int { return a.Cmp(b) })
+	return fees
+}
+
+// percentile returns the nearest-rank percentile p of the sorted, non-empty
+// fees.
+func percentile(fees []*big.Int, p uint) *big.Int {
+	p = min(p, 100)
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(fees))))
+	rank = max(rank, 1)
+	return new(big.Int).Set(fees[rank-1])
+}
@@ -0,0 +1,108 @@
+package gasoracle
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+	return db
+}
+
+// newTestOracle returns an oracle whose clock advances 30s per call to now,
+// the length of an epoch.
+func newTestOracle(t *testing.T, db *gorm.DB, window time.Duration) *Oracle {
+	o := New(db, window)
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	o.now = func() time.Time {
+		clock = clock.Add(30 * time.Second)
+		return clock
+	}
+	return o
+}
+
+func TestPercentile(t *testing.T) {
+	o := newTestOracle(t, setupDB(t), time.Hour)
+
+	for i := range MinSamples - 1 {
+		require.NoError(t, o.Record(t.Context(), int64(i), big.NewInt(int64(i+1))))
+	}
+	_, ok := o.Percentile(50)
+	require.False(t, ok, "too few samples for an estimate")
+	require.Empty(t, o.Estimates().Percentiles)
+
+	require.NoError(t, o.Record(t.Context(), MinSamples-1, big.NewInt(MinSamples)))
+
+	// fees are 1..60
+	for p, want := range map[uint]int64{0: 1, 10: 6, 50: 30, 90: 54, 100: 60} {
+		got, ok := o.Percentile(p)
+		require.True(t, ok)
+		require.Equal(t, want, got.Int64(), "p%d", p)
+	}
+
+	est := o.Estimates()
+	require.Equal(t, MinSamples, est.Samples)
+	require.Len(t, est.Percentiles, len(EstimatePercentiles))
+	require.Equal(t, int64(MinSamples), est.Latest.BaseFee.Int64())
+}
+
+func TestRecord(t *testing.T) {
+	t.Run("drops samples outside the window", func(t *testing.T) {
+		db := setupDB(t)
+		o := newTestOracle(t, db, 5*time.Minute)
+		for i := range 20 {
+			require.NoError(t, o.Record(t.Context(), int64(i), big.NewInt(100)))
+		}
+		require.Equal(t, 11, o.Estimates().Samples)
+
+		var count int64
+		require.NoError(t, db.Model(&models.GasBaseFeeSample{}).Count(&count).Error)
+		require.Equal(t, int64(11), count)
+	})
+
+	t.Run("replaces reorged epochs", func(t *testing.T) {
+		db := setupDB(t)
+		o := newTestOracle(t, db, time.Hour)
+		for i := range 5 {
+			require.NoError(t, o.Record(t.Context(), int64(i), big.NewInt(100)))
+		}
+		require.NoError(t, o.Record(t.Context(), 3, big.NewInt(200)))
+
+		latest, ok := o.Latest()
+		require.True(t, ok)
+		require.Equal(t, int64(3), latest.Epoch)
+		require.Equal(t, int64(200), latest.BaseFee.Int64())
+		require.Equal(t, 4, o.Estimates().Samples)
+
+		var count int64
+		require.NoError(t, db.Model(&models.GasBaseFeeSample{}).Count(&count).Error)
+		require.Equal(t, int64(4), count)
+	})
+
+	t.Run("history survives a restart", func(t *testing.T) {
+		db := setupDB(t)
+		o := newTestOracle(t, db, time.Hour)
+		for i := range 5 {
+			require.NoError(t, o.Record(t.Context(), int64(i), big.NewInt(int64(i))))
+		}
+
+		restored := New(db, time.Hour)
+		restored.now = o.now
+		require.NoError(t, restored.Load(t.Context()))
+		require.Equal(t, 5, restored.Estimates().Samples)
+		latest, ok := restored.Latest()
+		require.True(t, ok)
+		require.Equal(t, int64(4), latest.Epoch)
+	})
+}
@@ -0,0 +1,125 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/storacha/go-ucanto/principal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/storacha/piri/pkg/pdp/grpcapi/pdpv1"
+	"github.com/storacha/piri/pkg/pdp/httpapi"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// Client calls the PDP service of a node over gRPC.
+type Client struct {
+	conn   *grpc.ClientConn
+	client pdpv1.PDPClient
+}
+
+type clientOptions struct {
+	signer   principal.Signer
+	insecure bool
+	dialOpts []grpc.DialOption
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*clientOptions)
+
+// WithSigner authorizes calls with a JWT signed by the node's identity.
+func WithSigner(id principal.Signer) ClientOption {
+	return func(o *clientOptions) {
+		o.signer = id
+	}
+}
+
+// WithInsecure sends calls in plaintext rather than over TLS, for nodes
+// reached over a private network.
+func WithInsecure() ClientOption {
+	return func(o *clientOptions) {
+		o.insecure = true
+	}
+}
+
+// WithDialOptions adds options to the gRPC connection.
+func WithDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(o *clientOptions) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+// NewClient returns a client of the node at target, a host:port address.
+func NewClient(target string, opts ...ClientOption) (*Client, error) {
+	o := &clientOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var dialOpts []grpc.DialOption
+	if o.insecure {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(nil, "")))
+	}
+	if o.signer != nil {
+		token, err := httpapi.NewAuthToken(o.signer)
+		if err != nil {
+			return nil, fmt.Errorf("creating auth token: %w", err)
+		}
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(bearerToken{token: token, insecure: o.insecure}))
+	}
+	dialOpts = append(dialOpts, o.dialOpts...)
+
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating grpc client: %w", err)
+	}
+	return &Client{conn: conn, client: pdpv1.NewPDPClient(conn)}, nil
+}
+
+// Close closes the connection to the node.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// AllocatePiece records the intent to upload a piece, returning the ID to
+// upload it with if the node does not hold it already.
+func (c *Client) AllocatePiece(ctx context.Context, allocation types.PieceAllocation) (*types.AllocatedPiece, error) {
+	res, err := c.client.AllocatePiece(ctx, newAllocatePieceRequest(allocation))
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return allocatedPiece(res)
+}
+
+// AddRoots submits aggregates to a proof set, returning the hash of the
+// transaction adding them.
+func (c *Client) AddRoots(ctx context.Context, proofSetID uint64, roots []types.RootAdd) (common.Hash, error) {
+	res, err := c.client.AddRoots(ctx, newAddRootsRequest(proofSetID, roots))
+	if err != nil {
+		return common.Hash{}, fromStatus(err)
+	}
+	return common.HexToHash(res.GetTxHash()), nil
+}
+
+// GetProofSetStatus returns the status of the creation of a proof set.
+func (c *Client) GetProofSetStatus(ctx context.Context, txHash common.Hash) (*types.ProofSetStatus, error) {
+	res, err := c.client.GetProofSetStatus(ctx, &pdpv1.GetProofSetStatusRequest{TxHash: txHash.String()})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return proofSetStatus(res), nil
+}
+
+// GetProofSetState returns the proving state of a proof set.
+func (c *Client) GetProofSetState(ctx context.Context, proofSetID uint64) (*types.ProofSetState, error) {
+	res, err := c.client.GetProofSetState(ctx, &pdpv1.GetProofSetStateRequest{ProofSetId: proofSetID})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return proofSetState(res), nil
+}
@@ -0,0 +1,213 @@
+package grpcapi
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+
+	"github.com/storacha/piri/pkg/pdp/grpcapi/pdpv1"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+func newAllocatePieceRequest(allocation types.PieceAllocation) *pdpv1.AllocatePieceRequest {
+	req := &pdpv1.AllocatePieceRequest{
+		Piece: &pdpv1.Piece{
+			Name: allocation.Piece.Name,
+			Hash: allocation.Piece.Hash,
+			Size: allocation.Piece.Size,
+		},
+	}
+	if allocation.Notify != nil {
+		req.Notify = allocation.Notify.String()
+	}
+	return req
+}
+
+func pieceAllocation(req *pdpv1.AllocatePieceRequest) (types.PieceAllocation, error) {
+	piece := req.GetPiece()
+	if piece == nil {
+		return types.PieceAllocation{}, types.NewError(types.KindInvalidInput, "no piece provided")
+	}
+	hash, err := multihash.Cast(piece.GetHash())
+	if err != nil {
+		return types.PieceAllocation{}, types.WrapError(types.KindInvalidInput, "invalid piece hash", err)
+	}
+	params := types.PieceAllocation{
+		Piece: types.Piece{
+			Name: piece.GetName(),
+			Hash: hash,
+			Size: piece.GetSize(),
+		},
+	}
+	if req.GetNotify() != "" {
+		notify, err := url.Parse(req.GetNotify())
+		if err != nil {
+			return types.PieceAllocation{}, types.WrapError(types.KindInvalidInput, "invalid notify url", err)
+		}
+		params.Notify = notify
+	}
+	return params, nil
+}
+
+func newAllocatePieceResponse(res *types.AllocatedPiece) *pdpv1.AllocatePieceResponse {
+	resp := &pdpv1.AllocatePieceResponse{
+		Allocated: res.Allocated,
+		Piece:     res.Piece,
+	}
+	if res.Allocated {
+		resp.UploadId = res.UploadID.String()
+	}
+	return resp
+}
+
+func allocatedPiece(res *pdpv1.AllocatePieceResponse) (*types.AllocatedPiece, error) {
+	piece, err := multihash.Cast(res.GetPiece())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse allocated piece hash: %w", err)
+	}
+	allocated := &types.AllocatedPiece{
+		Allocated: res.GetAllocated(),
+		Piece:     piece,
+		UploadID:  uuid.Nil,
+	}
+	if allocated.Allocated {
+		uid, err := uuid.Parse(res.GetUploadId())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse piece's upload UUID: %w", err)
+		}
+		allocated.UploadID = uid
+	}
+	return allocated, nil
+}
+
+func newAddRootsRequest(proofSetID uint64, roots []types.RootAdd) *pdpv1.AddRootsRequest {
+	req := &pdpv1.AddRootsRequest{
+		ProofSetId: proofSetID,
+		Roots:      make([]*pdpv1.RootAdd, 0, len(roots)),
+	}
+	for _, root := range roots {
+		subRoots := make([]string, 0, len(root.SubRoots))
+		for _, sub := range root.SubRoots {
+			subRoots = append(subRoots, sub.String())
+		}
+		req.Roots = append(req.Roots, &pdpv1.RootAdd{
+			Root:     root.Root.String(),
+			SubRoots: subRoots,
+		})
+	}
+	return req
+}
+
+func rootAdds(req *pdpv1.AddRootsRequest) ([]types.RootAdd, error) {
+	if len(req.GetRoots()) == 0 {
+		return nil, types.NewError(types.KindInvalidInput, "no roots provided")
+	}
+	roots := make([]types.RootAdd, 0, len(req.GetRoots()))
+	for _, root := range req.GetRoots() {
+		rcid, err := cid.Decode(root.GetRoot())
+		if err != nil {
+			return nil, types.WrapError(types.KindInvalidInput, "invalid root cid", err)
+		}
+		subroots := make([]cid.Cid, 0, len(root.GetSubRoots()))
+		for _, s := range root.GetSubRoots() {
+			scid, err := cid.Decode(s)
+			if err != nil {
+				return nil, types.WrapError(types.KindInvalidInput, "invalid subroot cid", err)
+			}
+			subroots = append(subroots, scid)
+		}
+		roots = append(roots, types.RootAdd{
+			Root:     rcid,
+			SubRoots: subroots,
+		})
+	}
+	return roots, nil
+}
+
+func newProofSetStatus(status *types.ProofSetStatus) *pdpv1.ProofSetStatus {
+	return &pdpv1.ProofSetStatus{
+		TxHash:   status.TxHash.String(),
+		TxStatus: status.TxStatus,
+		Created:  status.Created,
+		Id:       status.ID,
+	}
+}
+
+func proofSetStatus(status *pdpv1.ProofSetStatus) *types.ProofSetStatus {
+	return &types.ProofSetStatus{
+		TxHash:   common.HexToHash(status.GetTxHash()),
+		TxStatus: status.GetTxStatus(),
+		Created:  status.GetCreated(),
+		ID:       status.GetId(),
+	}
+}
+
+func newProofSetState(ps types.ProofSetState) *pdpv1.ProofSetState {
+	cs := ps.ContractState
+	owners := make([]string, 0, len(cs.Owners))
+	for _, owner := range cs.Owners {
+		owners = append(owners, owner.Hex())
+	}
+	return &pdpv1.ProofSetState{
+		Id:                     ps.ID,
+		Initialized:            ps.Initialized,
+		NextChallengeEpoch:     ps.NextChallengeEpoch,
+		PreviousChallengeEpoch: ps.PreviousChallengeEpoch,
+		ProvingPeriod:          ps.ProvingPeriod,
+		ChallengeWindow:        ps.ChallengeWindow,
+		CurrentEpoch:           ps.CurrentEpoch,
+		ChallengedIssued:       ps.ChallengedIssued,
+		InChallengeWindow:      ps.InChallengeWindow,
+		IsInFaultState:         ps.IsInFaultState,
+		HasProven:              ps.HasProven,
+		IsProving:              ps.IsProving,
+		ContractState: &pdpv1.ProofSetContractState{
+			Owners:                   owners,
+			NextChallengeWindowStart: cs.NextChallengeWindowStart,
+			NextChallengeEpoch:       cs.NextChallengeEpoch,
+			MaxProvingPeriod:         cs.MaxProvingPeriod,
+			ChallengeWindow:          cs.ChallengeWindow,
+			ChallengeRange:           cs.ChallengeRange,
+			ScheduledRemovals:        cs.ScheduledRemovals,
+			ProofFee:                 cs.ProofFee,
+			ProofFeeBuffered:         cs.ProofFeeBuffered,
+		},
+	}
+}
+
+func proofSetState(ps *pdpv1.ProofSetState) *types.ProofSetState {
+	cs := ps.GetContractState()
+	var owners []common.Address
+	for _, owner := range cs.GetOwners() {
+		owners = append(owners, common.HexToAddress(owner))
+	}
+	return &types.ProofSetState{
+		ID:                     ps.GetId(),
+		Initialized:            ps.GetInitialized(),
+		NextChallengeEpoch:     ps.GetNextChallengeEpoch(),
+		PreviousChallengeEpoch: ps.GetPreviousChallengeEpoch(),
+		ProvingPeriod:          ps.GetProvingPeriod(),
+		ChallengeWindow:        ps.GetChallengeWindow(),
+		CurrentEpoch:           ps.GetCurrentEpoch(),
+		ChallengedIssued:       ps.GetChallengedIssued(),
+		InChallengeWindow:      ps.GetInChallengeWindow(),
+		IsInFaultState:         ps.GetIsInFaultState(),
+		HasProven:              ps.GetHasProven(),
+		IsProving:              ps.GetIsProving(),
+		ContractState: types.ProofSetContractState{
+			Owners:                   owners,
+			NextChallengeWindowStart: cs.GetNextChallengeWindowStart(),
+			NextChallengeEpoch:       cs.GetNextChallengeEpoch(),
+			MaxProvingPeriod:         cs.GetMaxProvingPeriod(),
+			ChallengeWindow:          cs.GetChallengeWindow(),
+			ChallengeRange:           cs.GetChallengeRange(),
+			ScheduledRemovals:        cs.GetScheduledRemovals(),
+			ProofFee:                 cs.GetProofFee(),
+			ProofFeeBuffered:         cs.GetProofFeeBuffered(),
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// kindCodes maps the kinds of service errors to status codes, as
+// middleware.HandleError maps them to HTTP statuses.
+var kindCodes = map[types.Kind]codes.Code{
+	types.KindNotFound:     codes.NotFound,
+	types.KindInvalidInput: codes.InvalidArgument,
+	types.KindUnauthorized: codes.Unauthenticated,
+	types.KindConflict:     codes.AlreadyExists,
+}
+
+// errorInterceptor converts the errors returned by the service to status
+// errors, so clients can tell invalid calls from failures of the node.
+func errorInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	res, err := handler(ctx, req)
+	if err == nil {
+		return res, nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return nil, err
+	}
+	var tErr *types.Error
+	if errors.As(err, &tErr) {
+		if code, ok := kindCodes[tErr.Kind()]; ok {
+			return nil, status.Error(code, tErr.Error())
+		}
+	}
+	log.Errorw("call failed", "method", info.FullMethod, "error", err)
+	return nil, status.Error(codes.Internal, err.Error())
+}
+
+// fromStatus converts a status error received by a client back to a service
+// error of the matching kind.
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	for kind, code := range kindCodes {
+		if st.Code() == code {
+			return types.NewError(kind, st.Message())
+		}
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+// Package grpcapi serves a subset of the PDP API over gRPC, for internal
+// services making frequent calls to the node, such as the aggregator. The
+// service and its messages are defined in pdpv1/pdp.proto, from which the
+// code in pdpv1 is generated.
+package grpcapi
+
+import (
+	"context"
+	"crypto/ed25519"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	logging "github.com/ipfs/go-log/v2"
+	"google.golang.org/grpc"
+
+	"github.com/storacha/piri/pkg/pdp/grpcapi/pdpv1"
+	"github.com/storacha/piri/pkg/pdp/httpapi"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+var log = logging.Logger("pdp/grpc")
+
+// Service is the part of the PDP service exposed over gRPC.
+type Service interface {
+	AllocatePiece(ctx context.Context, allocation types.PieceAllocation) (*types.AllocatedPiece, error)
+	AddRoots(ctx context.Context, proofSetID uint64, roots []types.RootAdd) (common.Hash, error)
+	GetProofSetStatus(ctx context.Context, txHash common.Hash) (*types.ProofSetStatus, error)
+	GetProofSetState(ctx context.Context, id uint64) (types.ProofSetState, error)
+}
+
+// Server implements the gRPC service on top of the PDP service.
+type Server struct {
+	pdpv1.UnimplementedPDPServer
+	service Service
+}
+
+// NewServer returns a gRPC server serving the PDP service. Calls must carry a
+// JWT signed by the node's key, as for the REST API.
+func NewServer(service Service, key ed25519.PublicKey, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(errorInterceptor, authInterceptor(key)),
+	}, opts...)
+	srv := grpc.NewServer(opts...)
+	pdpv1.RegisterPDPServer(srv, &Server{service: service})
+	return srv
+}
+
+func (s *Server) AllocatePiece(ctx context.Context, req *pdpv1.AllocatePieceRequest) (*pdpv1.AllocatePieceResponse, error) {
+	params, err := pieceAllocation(req)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	res, err := s.service.AllocatePiece(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	log.Infow("Successfully prepared piece",
+		"uploadID", res.UploadID,
+		"allocated", res.Allocated,
+		"duration", time.Since(start))
+	return newAllocatePieceResponse(res), nil
+}
+
+func (s *Server) AddRoots(ctx context.Context, req *pdpv1.AddRootsRequest) (*pdpv1.AddRootsResponse, error) {
+	roots, err := rootAdds(req)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	txHash, err := s.service.AddRoots(ctx, req.GetProofSetId(), roots)
+	if err != nil {
+		return nil, err
+	}
+	log.Infow("Successfully added roots to proofSet",
+		"proofSetID", req.GetProofSetId(),
+		"rootCount", len(roots),
+		"duration", time.Since(start))
+	return &pdpv1.AddRootsResponse{TxHash: txHash.String()}, nil
+}
+
+func (s *Server) GetProofSetStatus(ctx context.Context, req *pdpv1.GetProofSetStatusRequest) (*pdpv1.ProofSetStatus, error) {
+	txHash, err := httpapi.ParseTxHash(req.GetTxHash())
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.service.GetProofSetStatus(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	return newProofSetStatus(status), nil
+}
+
+func (s *Server) GetProofSetState(ctx context.Context, req *pdpv1.GetProofSetStateRequest) (*pdpv1.ProofSetState, error) {
+	state, err := s.service.GetProofSetState(ctx, req.GetProofSetId())
+	if err != nil {
+		return nil, err
+	}
+	return newProofSetState(state), nil
+}
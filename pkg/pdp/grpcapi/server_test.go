@@ -0,0 +1,149 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+type mockService struct {
+	uploadID uuid.UUID
+	roots    []types.RootAdd
+}
+
+func (m *mockService) AllocatePiece(ctx context.Context, allocation types.PieceAllocation) (*types.AllocatedPiece, error) {
+	return &types.AllocatedPiece{Allocated: true, Piece: allocation.Piece.Hash, UploadID: m.uploadID}, nil
+}
+
+func (m *mockService) AddRoots(ctx context.Context, proofSetID uint64, roots []types.RootAdd) (common.Hash, error) {
+	m.roots = roots
+	return common.HexToHash("0x01"), nil
+}
+
+func (m *mockService) GetProofSetStatus(ctx context.Context, txHash common.Hash) (*types.ProofSetStatus, error) {
+	return &types.ProofSetStatus{TxHash: txHash, TxStatus: "confirmed", Created: true, ID: 7}, nil
+}
+
+func (m *mockService) GetProofSetState(ctx context.Context, id uint64) (types.ProofSetState, error) {
+	if id != 7 {
+		return types.ProofSetState{}, types.NewErrorf(types.KindNotFound, "no proof set found")
+	}
+	return testProofSetState, nil
+}
+
+var testProofSetState = types.ProofSetState{
+	ID:                 7,
+	Initialized:        true,
+	NextChallengeEpoch: 120,
+	ProvingPeriod:      60,
+	ChallengeWindow:    20,
+	CurrentEpoch:       110,
+	HasProven:          true,
+	ContractState: types.ProofSetContractState{
+		Owners:             []common.Address{common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")},
+		NextChallengeEpoch: 120,
+		MaxProvingPeriod:   60,
+		ScheduledRemovals:  []uint64{3, 4},
+		ProofFee:           100,
+	},
+}
+
+// newTestClient serves service with the key of node, and returns a client
+// authorized by signer if it is not nil.
+func newTestClient(t *testing.T, service Service, node, signer principal.Signer) *Client {
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(service, ed25519.PublicKey(node.Verifier().Raw()))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	opts := []ClientOption{
+		WithInsecure(),
+		WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		})),
+	}
+	if signer != nil {
+		opts = append(opts, WithSigner(signer))
+	}
+	client, err := NewClient("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestServer(t *testing.T) {
+	node := testutil.RandomSigner(t)
+	service := &mockService{uploadID: uuid.New()}
+	client := newTestClient(t, service, node, node)
+
+	t.Run("allocate piece", func(t *testing.T) {
+		digest := testutil.Must(multihash.Sum([]byte("piece"), multihash.SHA2_256, -1))(t)
+		res, err := client.AllocatePiece(t.Context(), types.PieceAllocation{
+			Piece: types.Piece{Name: "sha2-256", Hash: digest, Size: 5},
+		})
+		require.NoError(t, err)
+		require.True(t, res.Allocated)
+		require.Equal(t, service.uploadID, res.UploadID)
+	})
+
+	t.Run("add roots", func(t *testing.T) {
+		root := cid.NewCidV1(cid.Raw, testutil.Must(multihash.Sum([]byte("root"), multihash.SHA2_256, -1))(t))
+		sub := cid.NewCidV1(cid.Raw, testutil.Must(multihash.Sum([]byte("sub"), multihash.SHA2_256, -1))(t))
+		txHash, err := client.AddRoots(t.Context(), 7, []types.RootAdd{{Root: root, SubRoots: []cid.Cid{sub}}})
+		require.NoError(t, err)
+		require.Equal(t, common.HexToHash("0x01"), txHash)
+		require.Equal(t, []types.RootAdd{{Root: root, SubRoots: []cid.Cid{sub}}}, service.roots)
+
+		_, err = client.AddRoots(t.Context(), 7, nil)
+		var tErr *types.Error
+		require.ErrorAs(t, err, &tErr)
+		require.Equal(t, types.KindInvalidInput, tErr.Kind())
+	})
+
+	t.Run("proof set status", func(t *testing.T) {
+		txHash := common.HexToHash("0x02")
+		st, err := client.GetProofSetStatus(t.Context(), txHash)
+		require.NoError(t, err)
+		require.Equal(t, &types.ProofSetStatus{TxHash: txHash, TxStatus: "confirmed", Created: true, ID: 7}, st)
+	})
+
+	t.Run("proof set state", func(t *testing.T) {
+		st, err := client.GetProofSetState(t.Context(), 7)
+		require.NoError(t, err)
+		require.Equal(t, &testProofSetState, st)
+
+		_, err = client.GetProofSetState(t.Context(), 8)
+		var tErr *types.Error
+		require.ErrorAs(t, err, &tErr)
+		require.Equal(t, types.KindNotFound, tErr.Kind())
+	})
+}
+
+func TestServerRejectsUnauthenticatedCalls(t *testing.T) {
+	node := testutil.RandomSigner(t)
+	for name, signer := range map[string]principal.Signer{
+		"no token":    nil,
+		"wrong token": testutil.RandomSigner(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := newTestClient(t, &mockService{}, node, signer)
+			_, err := client.GetProofSetState(t.Context(), 7)
+			var tErr *types.Error
+			require.ErrorAs(t, err, &tErr)
+			require.Equal(t, types.KindUnauthorized, tErr.Kind())
+		})
+	}
+}
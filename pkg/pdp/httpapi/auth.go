@@ -0,0 +1,32 @@
+package httpapi
+
+import (
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/storacha/go-ucanto/principal"
+
+	"github.com/storacha/piri/pkg/hwsigner"
+)
+
+// NewAuthToken returns a JWT signed by the identity of a node, authorizing
+// calls to the PDP API of that node.
+func NewAuthToken(id principal.Signer) (string, error) {
+	claims := jwt.MapClaims{
+		"service_name": "storacha",
+	}
+
+	// Create the token
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+
+	// Sign the token
+	key, err := hwsigner.KeySigner(id)
+	if err != nil {
+		return "", err
+	}
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %v", err)
+	}
+	return tokenString, nil
+}
@@ -3,7 +3,6 @@ package client
 import (
 	"bytes"
 	"context"
-	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,7 +13,6 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
@@ -23,6 +21,7 @@ import (
 
 	"github.com/storacha/piri/lib"
 	"github.com/storacha/piri/pkg/config"
+	"github.com/storacha/piri/pkg/httpclient"
 	"github.com/storacha/piri/pkg/pdp/httpapi"
 	"github.com/storacha/piri/pkg/pdp/types"
 )
@@ -112,14 +111,16 @@ func WithEndpointType(t EndpointType) Option {
 	}
 }
 
-// New creates a new PDP API client and automatically detects the server type
+// New creates a new PDP API client and automatically detects the server type.
+// Failed requests are retried according to [httpclient.DefaultPolicy] unless
+// another client is set with [WithHTTPClient].
 func New(endpoint *url.URL, opts ...Option) (*Client, error) {
 	if endpoint == nil {
 		return nil, fmt.Errorf("endpoint is required")
 	}
 	c := &Client{
 		endpoint: endpoint,
-		client:   http.DefaultClient,
+		client:   httpclient.New("pdp", httpclient.DefaultPolicy),
 	}
 	for _, opt := range opts {
 		if err := opt(c); err != nil {
@@ -154,20 +155,11 @@ func NewFromConfig(cfg config.Client) (*Client, error) {
 }
 
 func createAuthBearerTokenFromID(id principal.Signer) (string, error) {
-	claims := jwt.MapClaims{
-		"service_name": "storacha",
-	}
-
-	// Create the token
-	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
-
-	// Sign the token
-	tokenString, err := token.SignedString(ed25519.PrivateKey(id.Raw()))
+	token, err := httpapi.NewAuthToken(id)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %v", err)
+		return "", err
 	}
-
-	return "Bearer " + tokenString, nil
+	return "Bearer " + token, nil
 }
 
 func (c *Client) CreateProofSet(ctx context.Context) (common.Hash, error) {
@@ -198,16 +190,7 @@ func (c *Client) GetProofSetStatus(ctx context.Context, txHash common.Hash) (*ty
 		return nil, err
 	}
 
-	id := uint64(0)
-	if resp.ProofSetId != nil {
-		id = *resp.ProofSetId
-	}
-	return &types.ProofSetStatus{
-		TxHash:   common.HexToHash(resp.CreateMessageHash),
-		TxStatus: resp.TxStatus,
-		Created:  resp.ProofsetCreated,
-		ID:       id,
-	}, nil
+	return resp.ProofSetStatus(), nil
 }
 
 func (c *Client) GetProofSet(ctx context.Context, proofSetID uint64) (*types.ProofSet, error) {
@@ -273,31 +256,7 @@ func (c *Client) GetProofSetState(ctx context.Context, proofSetID uint64) (*type
 		return nil, fmt.Errorf("failed to get proof-set: %w", err)
 	}
 
-	return &types.ProofSetState{
-		ID:                     state.ID,
-		Initialized:            state.Initialized,
-		NextChallengeEpoch:     state.NextChallengeEpoch,
-		PreviousChallengeEpoch: state.PreviousChallengeEpoch,
-		ProvingPeriod:          state.ProvingPeriod,
-		ChallengeWindow:        state.ChallengeWindow,
-		CurrentEpoch:           state.CurrentEpoch,
-		ChallengedIssued:       state.ChallengedIssued,
-		InChallengeWindow:      state.InChallengeWindow,
-		IsInFaultState:         state.IsInFaultState,
-		HasProven:              state.HasProven,
-		IsProving:              state.IsProving,
-		ContractState: types.ProofSetContractState{
-			Owners:                   state.ContractState.Owners,
-			NextChallengeWindowStart: state.ContractState.NextChallengeWindowStart,
-			NextChallengeEpoch:       state.ContractState.NextChallengeEpoch,
-			MaxProvingPeriod:         state.ContractState.MaxProvingPeriod,
-			ChallengeWindow:          state.ContractState.ChallengeWindow,
-			ChallengeRange:           state.ContractState.ChallengeRange,
-			ScheduledRemovals:        state.ContractState.ScheduledRemovals,
-			ProofFee:                 state.ContractState.ProofFee,
-			ProofFeeBuffered:         state.ContractState.ProofFeeBuffered,
-		},
-	}, nil
+	return state.ProofSetState(), nil
 }
 
 func (c *Client) ListProofSet(ctx context.Context) ([]types.ProofSet, error) {
@@ -403,20 +362,7 @@ func (c *Client) RepairProofSet(ctx context.Context, proofSetID uint64) (*types.
 func (c *Client) AddRoots(ctx context.Context, proofSetID uint64, roots []types.RootAdd) (common.Hash, error) {
 	route := c.endpoint.JoinPath(pdpRoutePath, proofSetsPath, "/", strconv.FormatUint(proofSetID, 10), rootsPath).String()
 
-	addRoots := make([]httpapi.Root, 0, len(roots))
-	for _, root := range roots {
-		subRoots := make([]httpapi.SubrootEntry, 0, len(root.SubRoots))
-		for _, sub := range root.SubRoots {
-			subRoots = append(subRoots, httpapi.SubrootEntry{
-				SubrootCID: sub.String(),
-			})
-		}
-		addRoots = append(addRoots, httpapi.Root{
-			RootCID:  root.Root.String(),
-			Subroots: subRoots,
-		})
-	}
-	payload := httpapi.AddRootsRequest{Roots: addRoots}
+	payload := httpapi.NewAddRootsRequest(roots)
 	if !c.isPiriServer() {
 		return common.Hash{}, c.verifySuccess(c.postJson(ctx, route, payload))
 	}
@@ -452,16 +398,7 @@ func (c *Client) RemoveRoot(ctx context.Context, proofSetID uint64, rootID uint6
 
 func (c *Client) AllocatePiece(ctx context.Context, allocation types.PieceAllocation) (*types.AllocatedPiece, error) {
 	route := c.endpoint.JoinPath(pdpRoutePath, piecePath).String()
-	req := httpapi.AddPieceRequest{
-		Check: httpapi.PieceHash{
-			Name: allocation.Piece.Name,
-			Hash: allocation.Piece.Hash.String(),
-			Size: allocation.Piece.Size,
-		},
-	}
-	if allocation.Notify != nil {
-		req.Notify = allocation.Notify.String()
-	}
+	req := httpapi.NewAddPieceRequest(allocation)
 	res, err := c.postJson(ctx, route, req)
 	if err != nil {
 		return nil, err
@@ -497,23 +434,7 @@ func (c *Client) AllocatePiece(ctx context.Context, allocation types.PieceAlloca
 	if err := json.NewDecoder(res.Body).Decode(&payload); err != nil {
 		return nil, fmt.Errorf("failed to decode response for piece: %w", err)
 	}
-	if res.StatusCode == http.StatusCreated {
-		uid, err := uuid.Parse(payload.UploadID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse piece's upload UUID: %w", err)
-		}
-		return &types.AllocatedPiece{
-			Allocated: payload.Allocated,
-			Piece:     multihash.Multihash(allocation.Piece.Hash),
-			UploadID:  uid,
-		}, nil
-	}
-	// else, already exists
-	return &types.AllocatedPiece{
-		Allocated: payload.Allocated,
-		Piece:     multihash.Multihash(allocation.Piece.Hash),
-		UploadID:  uuid.Nil,
-	}, nil
+	return payload.AllocatedPiece(multihash.Multihash(allocation.Piece.Hash))
 }
 
 func (c *Client) UploadPiece(ctx context.Context, upload types.PieceUpload) error {
@@ -562,6 +483,46 @@ func (c *Client) Read(ctx context.Context, piece multihash.Multihash, options ..
 	}, nil
 }
 
+// ReadPiece reads a piece by its v2 piece CID from the PDP server. Unlike
+// Read it is only supported by Piri servers.
+func (c *Client) ReadPiece(ctx context.Context, piece cid.Cid, options ...types.ReadPieceOption) (*types.PieceReader, error) {
+	cfg := types.ReadPieceConfig{}
+	cfg.ProcessOptions(options)
+
+	route := c.endpoint.JoinPath(pdpRoutePath, piecePath, piece.String()).String()
+	headers := http.Header{}
+	if cfg.ByteRange.Start != 0 || cfg.ByteRange.End != nil {
+		rangeString := fmt.Sprintf("bytes=%d-", cfg.ByteRange.Start)
+		if cfg.ByteRange.End != nil {
+			rangeString += strconv.FormatUint(*cfg.ByteRange.End, 10)
+		}
+		headers.Add("Range", rangeString)
+	}
+
+	res, err := c.sendRequest(ctx, http.MethodGet, route, nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, errFromResponse(res)
+	}
+
+	size := res.ContentLength
+	if res.StatusCode == http.StatusPartialContent {
+		_, after, found := strings.Cut(res.Header.Get("Content-Range"), "/")
+		if found {
+			s, err := strconv.ParseInt(after, 10, 64)
+			if err == nil {
+				size = s
+			}
+		}
+	}
+	return &types.PieceReader{
+		Size: size,
+		Data: res.Body,
+	}, nil
+}
+
 func (c *Client) RegisterProvider(ctx context.Context, params types.RegisterProviderParams) (types.RegisterProviderResults, error) {
 	route := c.endpoint.JoinPath(pdpRoutePath, "/provider/register").String()
 	request := httpapi.RegisterProviderRequest{
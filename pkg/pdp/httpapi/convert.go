@@ -0,0 +1,228 @@
+package httpapi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// The conversions below are shared by the REST handlers and the gRPC server,
+// so both transports accept and return the same messages for a call to the
+// PDP service, and by the clients of either.
+
+// NewAddPieceRequest returns the request allocating a piece.
+func NewAddPieceRequest(allocation types.PieceAllocation) AddPieceRequest {
+	req := AddPieceRequest{
+		Check: PieceHash{
+			Name: allocation.Piece.Name,
+			Hash: allocation.Piece.Hash.String(),
+			Size: allocation.Piece.Size,
+		},
+	}
+	if allocation.Notify != nil {
+		req.Notify = allocation.Notify.String()
+	}
+	return req
+}
+
+// PieceAllocation returns the allocation requested.
+func (r AddPieceRequest) PieceAllocation() (types.PieceAllocation, error) {
+	params := types.PieceAllocation{
+		Piece: types.Piece{
+			Name: r.Check.Name,
+			Hash: multihash.Multihash(r.Check.Hash),
+			Size: r.Check.Size,
+		},
+	}
+	if r.Notify != "" {
+		notify, err := url.Parse(r.Notify)
+		if err != nil {
+			return types.PieceAllocation{}, types.WrapError(types.KindInvalidInput, "invalid notify url", err)
+		}
+		params.Notify = notify
+	}
+	return params, nil
+}
+
+// NewAddPieceResponse returns the response to a piece allocation.
+func NewAddPieceResponse(res *types.AllocatedPiece) AddPieceResponse {
+	return AddPieceResponse{
+		Allocated: res.Allocated,
+		PieceCID:  res.Piece.String(),
+		UploadID:  res.UploadID.String(),
+	}
+}
+
+// AllocatedPiece returns the allocation of the piece with the given hash. The
+// upload ID is only set if the piece was allocated, rather than found.
+func (r AddPieceResponse) AllocatedPiece(piece multihash.Multihash) (*types.AllocatedPiece, error) {
+	res := &types.AllocatedPiece{
+		Allocated: r.Allocated,
+		Piece:     piece,
+		UploadID:  uuid.Nil,
+	}
+	if r.Allocated {
+		uid, err := uuid.Parse(r.UploadID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse piece's upload UUID: %w", err)
+		}
+		res.UploadID = uid
+	}
+	return res, nil
+}
+
+// NewAddRootsRequest returns the request adding roots to a proof set.
+func NewAddRootsRequest(roots []types.RootAdd) AddRootsRequest {
+	addRoots := make([]Root, 0, len(roots))
+	for _, root := range roots {
+		subRoots := make([]SubrootEntry, 0, len(root.SubRoots))
+		for _, sub := range root.SubRoots {
+			subRoots = append(subRoots, SubrootEntry{
+				SubrootCID: sub.String(),
+			})
+		}
+		addRoots = append(addRoots, Root{
+			RootCID:  root.Root.String(),
+			Subroots: subRoots,
+		})
+	}
+	return AddRootsRequest{Roots: addRoots}
+}
+
+// RootAdds returns the roots to add to the proof set.
+func (r AddRootsRequest) RootAdds() ([]types.RootAdd, error) {
+	if len(r.Roots) == 0 {
+		return nil, types.NewError(types.KindInvalidInput, "no roots provided")
+	}
+	roots := make([]types.RootAdd, 0, len(r.Roots))
+	for _, root := range r.Roots {
+		rcid, err := cid.Decode(root.RootCID)
+		if err != nil {
+			return nil, types.WrapError(types.KindInvalidInput, "invalid root cid", err)
+		}
+		subroots := make([]cid.Cid, 0, len(root.Subroots))
+		for _, s := range root.Subroots {
+			scid, err := cid.Decode(s.SubrootCID)
+			if err != nil {
+				return nil, types.WrapError(types.KindInvalidInput, "invalid subroot cid", err)
+			}
+			subroots = append(subroots, scid)
+		}
+		roots = append(roots, types.RootAdd{
+			Root:     rcid,
+			SubRoots: subroots,
+		})
+	}
+	return roots, nil
+}
+
+// ParseTxHash parses the hash of a transaction, with or without its 0x
+// prefix.
+func ParseTxHash(txHash string) (common.Hash, error) {
+	if !strings.HasPrefix(txHash, "0x") {
+		txHash = "0x" + txHash
+	}
+	txHash = strings.ToLower(txHash)
+	if len(txHash) != 66 { // '0x' + 64 hex chars
+		return common.Hash{}, types.NewError(types.KindInvalidInput, "Invalid txHash length")
+	}
+	if _, err := hex.DecodeString(txHash[2:]); err != nil {
+		return common.Hash{}, types.NewError(types.KindInvalidInput, "Invalid txHash format")
+	}
+	return common.HexToHash(txHash), nil
+}
+
+// NewProofSetStatusResponse returns the response to a proof set creation
+// status request.
+func NewProofSetStatusResponse(status *types.ProofSetStatus) ProofSetStatusResponse {
+	return ProofSetStatusResponse{
+		CreateMessageHash: status.TxHash.String(),
+		ProofsetCreated:   status.Created,
+		Service:           "storacha",
+		TxStatus:          status.TxStatus,
+		OK:                nil,
+		ProofSetId:        &status.ID,
+	}
+}
+
+// ProofSetStatus returns the status of the proof set creation.
+func (r ProofSetStatusResponse) ProofSetStatus() *types.ProofSetStatus {
+	id := uint64(0)
+	if r.ProofSetId != nil {
+		id = *r.ProofSetId
+	}
+	return &types.ProofSetStatus{
+		TxHash:   common.HexToHash(r.CreateMessageHash),
+		TxStatus: r.TxStatus,
+		Created:  r.ProofsetCreated,
+		ID:       id,
+	}
+}
+
+// NewGetProofSetStateResponse returns the response to a proof set state
+// request.
+func NewGetProofSetStateResponse(ps types.ProofSetState) GetProofSetStateResponse {
+	cs := ps.ContractState
+	return GetProofSetStateResponse{
+		ID:                     ps.ID,
+		Initialized:            ps.Initialized,
+		NextChallengeEpoch:     ps.NextChallengeEpoch,
+		PreviousChallengeEpoch: ps.PreviousChallengeEpoch,
+		ProvingPeriod:          ps.ProvingPeriod,
+		ChallengeWindow:        ps.ChallengeWindow,
+		CurrentEpoch:           ps.CurrentEpoch,
+		ChallengedIssued:       ps.ChallengedIssued,
+		InChallengeWindow:      ps.InChallengeWindow,
+		IsInFaultState:         ps.IsInFaultState,
+		HasProven:              ps.HasProven,
+		IsProving:              ps.IsProving,
+		ContractState: ProofSetContractState{
+			Owners:                   cs.Owners,
+			NextChallengeWindowStart: cs.NextChallengeWindowStart,
+			NextChallengeEpoch:       cs.NextChallengeEpoch,
+			MaxProvingPeriod:         cs.MaxProvingPeriod,
+			ChallengeWindow:          cs.ChallengeWindow,
+			ChallengeRange:           cs.ChallengeRange,
+			ScheduledRemovals:        cs.ScheduledRemovals,
+			ProofFee:                 cs.ProofFee,
+			ProofFeeBuffered:         cs.ProofFeeBuffered,
+		},
+	}
+}
+
+// ProofSetState returns the state of the proof set.
+func (r GetProofSetStateResponse) ProofSetState() *types.ProofSetState {
+	return &types.ProofSetState{
+		ID:                     r.ID,
+		Initialized:            r.Initialized,
+		NextChallengeEpoch:     r.NextChallengeEpoch,
+		PreviousChallengeEpoch: r.PreviousChallengeEpoch,
+		ProvingPeriod:          r.ProvingPeriod,
+		ChallengeWindow:        r.ChallengeWindow,
+		CurrentEpoch:           r.CurrentEpoch,
+		ChallengedIssued:       r.ChallengedIssued,
+		InChallengeWindow:      r.InChallengeWindow,
+		IsInFaultState:         r.IsInFaultState,
+		HasProven:              r.HasProven,
+		IsProving:              r.IsProving,
+		ContractState: types.ProofSetContractState{
+			Owners:                   r.ContractState.Owners,
+			NextChallengeWindowStart: r.ContractState.NextChallengeWindowStart,
+			NextChallengeEpoch:       r.ContractState.NextChallengeEpoch,
+			MaxProvingPeriod:         r.ContractState.MaxProvingPeriod,
+			ChallengeWindow:          r.ContractState.ChallengeWindow,
+			ChallengeRange:           r.ContractState.ChallengeRange,
+			ScheduledRemovals:        r.ContractState.ScheduledRemovals,
+			ProofFee:                 r.ContractState.ProofFee,
+			ProofFeeBuffered:         r.ContractState.ProofFeeBuffered,
+		},
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/ipfs/go-cid"
 	"github.com/labstack/echo/v4"
 
 	"github.com/storacha/piri/pkg/pdp/httpapi"
@@ -30,29 +29,9 @@ func (p *PDPHandler) handleAddRootToProofSet(c echo.Context) error {
 		return types.WrapError(types.KindInvalidInput, "failed to bind request", err)
 	}
 
-	if len(req.Roots) == 0 {
-		return types.NewError(types.KindInvalidInput, "no roots provided")
-	}
-
-	t := make([]types.RootAdd, 0, len(req.Roots))
-
-	for _, r := range req.Roots {
-		rcid, err := cid.Decode(r.RootCID)
-		if err != nil {
-			return types.WrapError(types.KindInvalidInput, "invalid root cid", err)
-		}
-		subroots := make([]cid.Cid, 0, len(r.Subroots))
-		for _, s := range r.Subroots {
-			scid, err := cid.Decode(s.SubrootCID)
-			if err != nil {
-				return types.WrapError(types.KindInvalidInput, "invalid subroot cid", err)
-			}
-			subroots = append(subroots, scid)
-		}
-		t = append(t, types.RootAdd{
-			Root:     rcid,
-			SubRoots: subroots,
-		})
+	t, err := req.RootAdds()
+	if err != nil {
+		return err
 	}
 
 	log.Debugw("Processing add root request",
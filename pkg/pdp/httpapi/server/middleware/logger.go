@@ -6,7 +6,10 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/labstack/echo/v4"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+
+	"github.com/storacha/piri/lib/telemetry/traces"
 )
 
 func RequestLogger(logger *logging.ZapEventLogger) echo.MiddlewareFunc {
@@ -38,6 +41,12 @@ func RequestLogger(logger *logging.ZapEventLogger) echo.MiddlewareFunc {
 			if v.Error != nil {
 				fields = append(fields, zap.Error(v.Error))
 			}
+			if sc := trace.SpanContextFromContext(c.Request().Context()); sc.IsValid() {
+				fields = append(fields,
+					zap.String(traces.TraceIDKey, sc.TraceID().String()),
+					zap.String(traces.SpanIDKey, sc.SpanID().String()),
+				)
+			}
 			switch {
 			case v.Status >= http.StatusInternalServerError:
 				logger.WithOptions(zap.Fields(fields...)).Error("server error")
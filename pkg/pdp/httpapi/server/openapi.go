@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/storacha/piri/pkg/openapi"
+	"github.com/storacha/piri/pkg/pdp/httpapi"
+)
+
+// Operations describes the routes of the PDP API.
+var Operations = pdpOperations([]openapi.Operation{
+	{Method: http.MethodPost, Path: PRoofSetRoutPath, ID: "createProofSet", Summary: "Create a proof set", Auth: true, Request: httpapi.CreateProofSetRequest{}, Response: httpapi.CreateProofSetResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: PRoofSetRoutPath + "/created/:txHash", ID: "getProofSetCreationStatus", Summary: "Status of the creation of a proof set", Auth: true, Response: httpapi.ProofSetStatusResponse{}},
+	{Method: http.MethodGet, Path: PRoofSetRoutPath, ID: "listProofSets", Summary: "Proof sets with their roots", Auth: true, Response: httpapi.ListProofSetsResponse{}},
+	{Method: http.MethodGet, Path: PRoofSetRoutPath + "/:proofSetID", ID: "getProofSet", Summary: "Proof set with its roots", Auth: true, Response: httpapi.GetProofSetResponse{}},
+	{Method: http.MethodDelete, Path: PRoofSetRoutPath + "/:proofSetID", ID: "deleteProofSet", Summary: "Delete a proof set, not implemented", Auth: true, Status: http.StatusNotImplemented},
+	{Method: http.MethodGet, Path: PRoofSetRoutPath + "/:proofSetID/state", ID: "getProofSetState", Summary: "Chain state of a proof set", Auth: true, Response: httpapi.GetProofSetStateResponse{}},
+	{Method: http.MethodPost, Path: PRoofSetRoutPath + "/:proofSetID/repair", ID: "repairProofSet", Summary: "Reconcile the roots of a proof set with the chain", Auth: true, Response: httpapi.RepairProofSetResponse{}},
+
+	{Method: http.MethodPost, Path: PRoofSetRoutPath + "/:proofSetID/roots", ID: "addRoots", Summary: "Add roots to a proof set", Auth: true, Request: httpapi.AddRootsRequest{}, Response: httpapi.AddRootsResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: PRoofSetRoutPath + "/:proofSetID/roots/:rootID", ID: "getProofSetRoot", Summary: "Root of a proof set, not implemented", Auth: true, Status: http.StatusNotImplemented},
+	{Method: http.MethodDelete, Path: PRoofSetRoutPath + "/:proofSetID/roots/:rootID", ID: "removeRoot", Summary: "Schedule the removal of a root from a proof set", Auth: true, Status: http.StatusNoContent},
+
+	{Method: http.MethodGet, Path: "/ping", ID: "ping", Summary: "Type and version of the node", Response: map[string]string{}},
+
+	{Method: http.MethodPost, Path: PiecePrefix, ID: "preparePiece", Summary: "Allocate the upload of a piece, 200 with no upload URL if it is already stored", Auth: true, Request: httpapi.AddPieceRequest{}, Response: httpapi.AddPieceResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPut, Path: PiecePrefix + "/upload/:uploadUUID", ID: "uploadPiece", Summary: "Upload the bytes of an allocated piece", Request: openapi.Binary{}, Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: PiecePrefix, ID: "findPiece", Summary: "Piece CID of a stored blob", Auth: true, Query: []openapi.Param{
+		{Name: "name", Description: "Name of the hash function of the blob, e.g. sha2-256", Required: true},
+		{Name: "hash", Description: "Hex encoded digest of the blob", Required: true},
+	}, Response: httpapi.FoundPieceResponse{}},
+	{Method: http.MethodGet, Path: PiecePrefix + "/:pieceCid", ID: "readPiece", Summary: "Unpadded bytes of a piece, byte ranges are supported", Response: openapi.Binary{}},
+
+	{Method: http.MethodPost, Path: "/provider/register", ID: "registerProvider", Summary: "Register the node as a service provider", Auth: true, Request: httpapi.RegisterProviderRequest{}, Response: httpapi.RegisterProviderResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/provider/status", ID: "getProviderStatus", Summary: "Registration status of the node", Auth: true, Response: httpapi.GetProviderStatusResponse{}},
+})
+
+// pdpOperations prefixes the paths of the operations with the PDP route.
+func pdpOperations(ops []openapi.Operation) []openapi.Operation {
+	for i := range ops {
+		ops[i].Path = PDPRoutePath + ops[i].Path
+		ops[i].Tag = "pdp"
+	}
+	return ops
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/openapi"
+)
+
+// TestOperationsDescribeRoutes checks the OpenAPI operations of the PDP API
+// and the routes it registers do not drift apart.
+func TestOperationsDescribeRoutes(t *testing.T) {
+	handler := &PDPHandler{
+		jwtMiddleware: func(next echo.HandlerFunc) echo.HandlerFunc { return next },
+	}
+	e := echo.New()
+	handler.RegisterRoutes(e)
+	registered := openapi.Routes(e)
+
+	spec := openapi.New("pdp", "test", Operations)
+	require.Empty(t, spec.Undescribed(registered), "routes without an operation")
+	require.Empty(t, openapi.Unregistered(Operations, registered), "operations without a route")
+}
@@ -2,12 +2,10 @@ package server
 
 import (
 	"net/http"
-	"net/url"
 	"time"
 
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/labstack/echo/v4"
-	"github.com/multiformats/go-multihash"
 
 	"github.com/storacha/piri/pkg/pdp/httpapi"
 	"github.com/storacha/piri/pkg/pdp/proof"
@@ -34,30 +32,16 @@ func (p *PDPHandler) handlePreparePiece(c echo.Context) error {
 		"hash", req.Check.Hash,
 		"size", req.Check.Size)
 	start := time.Now()
-	params := types.PieceAllocation{
-		Piece: types.Piece{
-			Name: req.Check.Name,
-			Hash: multihash.Multihash(req.Check.Hash),
-			Size: req.Check.Size,
-		},
-	}
-	if req.Notify != "" {
-		var err error
-		params.Notify, err = url.Parse(req.Notify)
-		if err != nil {
-			return types.WrapError(types.KindInvalidInput, "invalid notify url", err)
-		}
+	params, err := req.PieceAllocation()
+	if err != nil {
+		return err
 	}
 	res, err := p.Service.AllocatePiece(ctx, params)
 	if err != nil {
 		return err
 	}
 
-	resp := httpapi.AddPieceResponse{
-		Allocated: res.Allocated,
-		PieceCID:  res.Piece.String(),
-		UploadID:  res.UploadID.String(),
-	}
+	resp := httpapi.NewAddPieceResponse(res)
 	log.Infow("Successfully prepared piece",
 		"uploadID", resp.UploadID,
 		"allocated", resp.Allocated,
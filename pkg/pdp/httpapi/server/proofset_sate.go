@@ -28,32 +28,7 @@ func (p *PDPHandler) handleGetProofSetState(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	cs := ps.ContractState
-
-	resp := httpapi.GetProofSetStateResponse{
-		ID:                     ps.ID,
-		Initialized:            ps.Initialized,
-		NextChallengeEpoch:     ps.NextChallengeEpoch,
-		PreviousChallengeEpoch: ps.PreviousChallengeEpoch,
-		ProvingPeriod:          ps.ProvingPeriod,
-		ChallengeWindow:        ps.ChallengeWindow,
-		CurrentEpoch:           ps.CurrentEpoch,
-		ChallengedIssued:       ps.ChallengedIssued,
-		InChallengeWindow:      ps.InChallengeWindow,
-		IsInFaultState:         ps.IsInFaultState,
-		HasProven:              ps.HasProven,
-		IsProving:              ps.IsProving,
-		ContractState: httpapi.ProofSetContractState{
-			Owners:                   cs.Owners,
-			NextChallengeWindowStart: cs.NextChallengeWindowStart,
-			NextChallengeEpoch:       cs.NextChallengeEpoch,
-			MaxProvingPeriod:         cs.MaxProvingPeriod,
-			ChallengeWindow:          cs.ChallengeWindow,
-			ChallengeRange:           cs.ChallengeRange,
-			ScheduledRemovals:        cs.ScheduledRemovals,
-			ProofFee:                 cs.ProofFee,
-			ProofFeeBuffered:         cs.ProofFeeBuffered,
-		},
-	}
+
+	resp := httpapi.NewGetProofSetStateResponse(ps)
 	return c.JSON(http.StatusOK, resp)
 }
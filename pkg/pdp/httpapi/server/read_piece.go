@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/storacha/piri/pkg/blocklist"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// handleReadPiece -> GET /pdp/piece/:pieceCid
+//
+// Streams the unpadded bytes of a piece, the blob it was derived from followed
+// by its zero padding, by its v2 piece CID. Single byte ranges and
+// conditional requests against the piece CID are supported.
+//
+// Pieces of blocked blobs are refused with 451. Pieces of blobs in private
+// spaces are refused with 403, since piece reads carry no authorization; they
+// are retrieved as blobs.
+func (p *PDPHandler) handleReadPiece(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	pieceCID, err := cid.Decode(c.Param("pieceCid"))
+	if err != nil {
+		return types.WrapError(types.KindInvalidInput, "invalid piece CID", err)
+	}
+
+	if p.blocked != nil || p.private != nil {
+		blob, found, err := p.Service.ResolveToBlob(ctx, pieceCID.Hash())
+		if err != nil {
+			return types.WrapError(types.KindInternal, "failed to resolve piece", err)
+		}
+		if !found {
+			return types.NewError(types.KindNotFound, "piece not found")
+		}
+		if err := p.blocked.Check(ctx, blocklist.Retrieve, blob); err != nil {
+			var be *blocklist.ContentBlockedError
+			if errors.As(err, &be) {
+				return echo.NewHTTPError(http.StatusUnavailableForLegalReasons, err.Error())
+			}
+			return types.WrapError(types.KindInternal, "checking blocklist", err)
+		}
+		private, err := p.private.IsPrivate(ctx, blob)
+		if err != nil {
+			return types.WrapError(types.KindInternal, "checking private spaces", err)
+		}
+		if private {
+			return echo.NewHTTPError(http.StatusForbidden, "piece is in a private space, retrieve its blob with a space/content/retrieve invocation or a signed URL")
+		}
+	}
+
+	pr, err := p.Service.ReadPiece(ctx, pieceCID)
+	if err != nil {
+		return err
+	}
+	rs := &pieceReadSeeker{
+		ctx:   ctx,
+		api:   p.Service,
+		piece: pieceCID,
+		size:  pr.Size,
+		r:     pr.Data,
+	}
+	defer rs.Close()
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, echo.MIMEOctetStream)
+	w.Header().Set("ETag", `"`+pieceCID.String()+`"`)
+	if p.blocked != nil {
+		// pieces never change but may be blocked later, so shared caches
+		// must revalidate before serving them again
+		w.Header().Set("Cache-Control", "public, no-cache")
+	} else {
+		// pieces are content addressed and never change
+		w.Header().Set("Cache-Control", "public, max-age=29030400, immutable")
+	}
+	http.ServeContent(w, c.Request(), "", time.Time{}, rs)
+	return nil
+}
+
+// pieceReadSeeker lets http.ServeContent seek within a piece, reopening the
+// piece at the new offset only when it is read from somewhere other than
+// where the open reader is.
+type pieceReadSeeker struct {
+	ctx   context.Context
+	api   types.PieceAPI
+	piece cid.Cid
+	size  int64
+	// off is the offset of the next read
+	off int64
+	// r is the open reader, positioned at rOff
+	r    io.ReadCloser
+	rOff int64
+}
+
+func (s *pieceReadSeeker) Read(b []byte) (int, error) {
+	if s.off >= s.size {
+		return 0, io.EOF
+	}
+	if s.r != nil && s.rOff != s.off {
+		s.r.Close()
+		s.r = nil
+	}
+	if s.r == nil {
+		pr, err := s.api.ReadPiece(s.ctx, s.piece, types.WithRange(uint64(s.off), nil))
+		if err != nil {
+			return 0, err
+		}
+		s.r, s.rOff = pr.Data, s.off
+	}
+	n, err := s.r.Read(b)
+	s.off += int64(n)
+	s.rOff += int64(n)
+	return n, err
+}
+
+func (s *pieceReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.off
+	case io.SeekEnd:
+		offset += s.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	s.off = offset
+	return offset, nil
+}
+
+func (s *pieceReadSeeker) Close() error {
+	if s.r == nil {
+		return nil
+	}
+	return s.r.Close()
+}
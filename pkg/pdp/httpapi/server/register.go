@@ -10,8 +10,11 @@ import (
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 
+	"github.com/storacha/piri/pkg/blocklist"
 	"github.com/storacha/piri/pkg/config/app"
 	"github.com/storacha/piri/pkg/pdp/service"
+	"github.com/storacha/piri/pkg/privatespace"
+	"github.com/storacha/piri/pkg/telemetry/latency"
 )
 
 var log = logging.Logger("pdp/api")
@@ -25,9 +28,15 @@ const (
 type PDPHandler struct {
 	Service       *service.PDPService
 	jwtMiddleware echo.MiddlewareFunc
+	latency       *latency.Tracker
+	blocked       *blocklist.List
+	private       *privatespace.Policy
 }
 
-func NewPDPHandler(service *service.PDPService, identity app.IdentityConfig) (*PDPHandler, error) {
+// NewPDPHandler creates the handler of the PDP API. Pieces of blocked blobs,
+// if blocked is not nil, and of blobs of private spaces, if private is not
+// nil, are not served.
+func NewPDPHandler(service *service.PDPService, identity app.IdentityConfig, latency *latency.Tracker, blocked *blocklist.List, private *privatespace.Policy) (*PDPHandler, error) {
 	if identity.Signer == nil {
 		return nil, fmt.Errorf("missing identity signer for jwt auth")
 	}
@@ -40,6 +49,9 @@ func NewPDPHandler(service *service.PDPService, identity app.IdentityConfig) (*P
 	return &PDPHandler{
 		Service:       service,
 		jwtMiddleware: jwtMiddleware,
+		latency:       latency,
+		blocked:       blocked,
+		private:       private,
 	}, nil
 }
 
@@ -72,6 +84,7 @@ func (p *PDPHandler) RegisterRoutes(e *echo.Echo) {
 	authenticated.POST(PiecePrefix, p.handlePreparePiece)
 	pdpGroup.PUT(path.Join(PiecePrefix, "/upload/:uploadUUID"), p.handlePieceUpload)
 	authenticated.GET(PiecePrefix, p.handleFindPiece)
+	pdpGroup.GET(path.Join(PiecePrefix, "/:pieceCid"), p.handleReadPiece)
 
 	// /pdp/provider
 	authenticated.POST(path.Join("/provider/register"), p.handleRegisterProvider)
@@ -8,6 +8,7 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/telemetry/latency"
 )
 
 func (p *PDPHandler) handlePieceUpload(c echo.Context) error {
@@ -25,10 +26,20 @@ func (p *PDPHandler) handlePieceUpload(c echo.Context) error {
 
 	log.Debugw("Processing prepare piece request", "uploadID", uploadID)
 	start := time.Now()
-	if err := p.Service.UploadPiece(ctx, types.PieceUpload{
+	var timer *latency.Timer
+	if p.latency != nil {
+		if digest, err := p.Service.PieceUploadDigest(ctx, uploadID); err == nil {
+			ctx, timer = p.latency.Start(ctx, digest, latency.StageUpload)
+		}
+	}
+	err = p.Service.UploadPiece(ctx, types.PieceUpload{
 		ID:   uploadID,
 		Data: c.Request().Body,
-	}); err != nil {
+	})
+	if timer != nil {
+		timer.End(err)
+	}
+	if err != nil {
 		return c.String(http.StatusBadRequest, "Failed to upload piece")
 	}
 
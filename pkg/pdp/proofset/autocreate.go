@@ -0,0 +1,106 @@
+package proofset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+
+	aggtypes "github.com/storacha/piri/pkg/pdp/aggregation/types"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// ErrProofSetPending is returned while the proof set created for the first
+// aggregate is waiting to be confirmed on chain.
+var ErrProofSetPending = errors.New("proof set creation is pending")
+
+// Creator creates proof sets on chain.
+type Creator interface {
+	CreateProofSet(ctx context.Context) (common.Hash, error)
+	GetProofSetStatus(ctx context.Context, txHash common.Hash) (*types.ProofSetStatus, error)
+}
+
+// AutoCreator is a Selector that creates a proof set when the node manages
+// none, so aggregates can be submitted without first creating one by hand.
+//
+// Creation does not wait for the transaction to land: selection fails with
+// ErrProofSetPending until the proof set exists, and callers retry. The
+// pending transaction is recorded in the proof set creations of the PDP
+// service, so a restart waits for it instead of creating another proof set.
+type AutoCreator struct {
+	registry *Registry
+	creator  Creator
+	// mu stops concurrent submissions creating a proof set each.
+	mu sync.Mutex
+}
+
+var _ Selector = (*AutoCreator)(nil)
+
+// NewAutoCreator creates an AutoCreator selecting from the registry and
+// creating proof sets with creator.
+func NewAutoCreator(registry *Registry, creator Creator) *AutoCreator {
+	return &AutoCreator{registry: registry, creator: creator}
+}
+
+// SelectProofSet chooses an active proof set for the aggregate. When the node
+// manages no proof sets at all it adopts the latest proof set it created, or
+// creates one. Retired proof sets, or active ones not accepting the
+// aggregate, are left to the operator.
+func (a *AutoCreator) SelectProofSet(ctx context.Context, aggregate aggtypes.Aggregate) (uint64, error) {
+	id, err := a.registry.SelectProofSet(ctx, aggregate)
+	if !errors.Is(err, ErrNoActiveProofSet) {
+		return id, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	sets, err := a.registry.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(sets) == 0 {
+		if err := a.ensureProofSet(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return a.registry.SelectProofSet(ctx, aggregate)
+}
+
+// ensureProofSet adds the latest proof set created by the node to the
+// registry, creating one if there is none.
+func (a *AutoCreator) ensureProofSet(ctx context.Context) error {
+	var create models.PDPProofsetCreate
+	err := a.registry.db.WithContext(ctx).
+		Where("ok IS NULL OR ok = ?", true).
+		Order("created_at DESC").
+		First(&create).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		txHash, err := a.creator.CreateProofSet(ctx)
+		if err != nil {
+			return fmt.Errorf("creating proof set: %w", err)
+		}
+		log.Infow("creating proof set for first aggregate", "tx", txHash)
+		return fmt.Errorf("%w: transaction %s", ErrProofSetPending, txHash)
+	}
+	if err != nil {
+		return fmt.Errorf("looking up proof set creations: %w", err)
+	}
+
+	txHash := common.HexToHash(create.CreateMessageHash)
+	status, err := a.creator.GetProofSetStatus(ctx, txHash)
+	if err != nil {
+		return fmt.Errorf("getting status of proof set creation %s: %w", txHash, err)
+	}
+	if !status.Created || status.ID == 0 {
+		return fmt.Errorf("%w: transaction %s is %s", ErrProofSetPending, txHash, status.TxStatus)
+	}
+	if _, err := a.registry.Add(ctx, ProofSet{ID: status.ID}); err != nil {
+		return err
+	}
+	log.Infow("managing created proof set", "proof_set", status.ID, "tx", txHash)
+	return nil
+}
@@ -0,0 +1,87 @@
+package proofset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	aggtypes "github.com/storacha/piri/pkg/pdp/aggregation/types"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// fakeCreator records proof set creations like the PDP service, the test
+// lands them on chain by setting their proof set ID.
+type fakeCreator struct {
+	db      *gorm.DB
+	creates int
+	ids     map[common.Hash]uint64
+}
+
+func (f *fakeCreator) CreateProofSet(ctx context.Context) (common.Hash, error) {
+	f.creates++
+	txHash := common.BigToHash(common.Big1)
+	if err := f.db.WithContext(ctx).Create(&models.MessageWaitsEth{SignedTxHash: txHash.Hex(), TxStatus: "pending"}).Error; err != nil {
+		return common.Hash{}, err
+	}
+	if err := f.db.WithContext(ctx).Create(&models.PDPProofsetCreate{CreateMessageHash: txHash.Hex(), Service: "storacha"}).Error; err != nil {
+		return common.Hash{}, err
+	}
+	return txHash, nil
+}
+
+func (f *fakeCreator) GetProofSetStatus(_ context.Context, txHash common.Hash) (*types.ProofSetStatus, error) {
+	id, ok := f.ids[txHash]
+	status := "pending"
+	if ok {
+		status = "confirmed"
+	}
+	return &types.ProofSetStatus{TxHash: txHash, TxStatus: status, Created: ok, ID: id}, nil
+}
+
+func TestAutoCreator(t *testing.T) {
+	aggregate := aggtypes.Aggregate{Root: testutil.RandomPiece(t, 1024)}
+
+	t.Run("creates a proof set for the first aggregate", func(t *testing.T) {
+		r := newTestRegistry(t)
+		creator := &fakeCreator{db: r.db, ids: map[common.Hash]uint64{}}
+		a := NewAutoCreator(r, creator)
+
+		_, err := a.SelectProofSet(t.Context(), aggregate)
+		require.ErrorIs(t, err, ErrProofSetPending)
+		// waits for the pending creation rather than creating another
+		_, err = a.SelectProofSet(t.Context(), aggregate)
+		require.ErrorIs(t, err, ErrProofSetPending)
+		require.Equal(t, 1, creator.creates)
+
+		createProofSet(t, r.db, 5)
+		creator.ids[common.BigToHash(common.Big1)] = 5
+		id, err := a.SelectProofSet(t.Context(), aggregate)
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), id)
+		require.Equal(t, 1, creator.creates)
+
+		sets, err := r.List(t.Context())
+		require.NoError(t, err)
+		require.Len(t, sets, 1)
+		require.Equal(t, uint64(5), sets[0].ID)
+	})
+
+	t.Run("leaves retired proof sets to the operator", func(t *testing.T) {
+		r := newTestRegistry(t, 1)
+		creator := &fakeCreator{db: r.db, ids: map[common.Hash]uint64{}}
+		a := NewAutoCreator(r, creator)
+		_, err := r.Add(t.Context(), ProofSet{ID: 1})
+		require.NoError(t, err)
+		_, err = r.Retire(t.Context(), 1)
+		require.NoError(t, err)
+
+		_, err = a.SelectProofSet(t.Context(), aggregate)
+		require.ErrorIs(t, err, ErrNoActiveProofSet)
+		require.Zero(t, creator.creates)
+	})
+}
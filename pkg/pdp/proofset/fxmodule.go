@@ -0,0 +1,108 @@
+package proofset
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/pdp/curio"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+var Module = fx.Module("pdp/proofset",
+	fx.Provide(
+		NewPolicy,
+		NewRegistryFromConfig,
+		NewSelector,
+		fx.Annotate(
+			NewRouterFromBackends,
+			fx.As(new(RootAdder)),
+		),
+	),
+)
+
+func NewSizeClassPolicy() SizeClassPolicy {
+	return SizeClassPolicy{}
+}
+
+type PolicyParams struct {
+	fx.In
+
+	Classes ClassResolver `optional:"true"`
+}
+
+// NewPolicy places aggregates by size class, restricted to the proof sets of
+// their storage class when storage classes are tracked.
+func NewPolicy(params PolicyParams) Policy {
+	if params.Classes == nil {
+		return NewSizeClassPolicy()
+	}
+	return ClassPolicy{Classes: params.Classes, Next: NewSizeClassPolicy()}
+}
+
+type RegistryParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	DB        *gorm.DB `name:"engine_db"`
+	Policy    Policy
+	Config    app.UCANServiceConfig
+	// Curio proves proof sets added with the curio backend, nil if the node
+	// runs without Curio.
+	Curio *curio.Backend `optional:"true"`
+}
+
+// NewRegistryFromConfig creates a Registry, seeding it with the proof set
+// from the node's configuration on start.
+func NewRegistryFromConfig(params RegistryParams) *Registry {
+	var opts []Option
+	if params.Curio != nil {
+		opts = append(opts, WithBackends(CurioBackend))
+	}
+	r := NewRegistry(params.DB, params.Policy, opts...)
+	if params.Config.ProofSetID != 0 {
+		params.Lifecycle.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				return r.Seed(ctx, params.Config.ProofSetID)
+			},
+		})
+	}
+	return r
+}
+
+type SelectorParams struct {
+	fx.In
+
+	Registry *Registry
+	API      types.ProofSetAPI
+	Config   app.UCANServiceConfig
+}
+
+type RouterParams struct {
+	fx.In
+
+	Registry *Registry
+	API      types.ProofSetAPI
+	Curio    *curio.Backend `optional:"true"`
+}
+
+// NewRouterFromBackends routes roots to the PDP service of the node, and to
+// Curio when it is configured.
+func NewRouterFromBackends(params RouterParams) *Router {
+	backends := map[string]RootAdder{NativeBackend: params.API}
+	if params.Curio != nil {
+		backends[CurioBackend] = params.Curio
+	}
+	return NewRouter(params.Registry, backends)
+}
+
+// NewSelector selects proof sets from the registry, creating one for the
+// first aggregate when the node is configured to.
+func NewSelector(params SelectorParams) Selector {
+	if params.Config.AutoCreateProofSet {
+		return NewAutoCreator(params.Registry, params.API)
+	}
+	return params.Registry
+}
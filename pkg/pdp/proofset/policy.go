@@ -0,0 +1,70 @@
+package proofset
+
+import (
+	"context"
+	"fmt"
+
+	aggtypes "github.com/storacha/piri/pkg/pdp/aggregation/types"
+)
+
+// Policy decides which proof set a new aggregate is added to.
+type Policy interface {
+	// Select returns the ID of one of the active proof sets, which are ordered
+	// by ID and never empty.
+	Select(ctx context.Context, aggregate aggtypes.Aggregate, active []ProofSet) (uint64, error)
+}
+
+// PolicyFunc adapts a function to a Policy.
+type PolicyFunc func(ctx context.Context, aggregate aggtypes.Aggregate, active []ProofSet) (uint64, error)
+
+func (f PolicyFunc) Select(ctx context.Context, aggregate aggtypes.Aggregate, active []ProofSet) (uint64, error) {
+	return f(ctx, aggregate, active)
+}
+
+// SizeClassPolicy adds an aggregate to the first active proof set, by ID,
+// whose size bounds accept the padded size of the aggregate. With a single
+// unbounded proof set every aggregate goes to it.
+type SizeClassPolicy struct{}
+
+func (SizeClassPolicy) Select(_ context.Context, aggregate aggtypes.Aggregate, active []ProofSet) (uint64, error) {
+	size := aggregate.Root.PaddedSize()
+	for _, ps := range active {
+		if ps.Accepts(size) {
+			return ps.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: padded size %d", ErrNoActiveProofSet, size)
+}
+
+// ClassResolver returns the class of the proof sets an aggregate may be added
+// to, empty if it may be added to any proof set.
+type ClassResolver interface {
+	ProofSetClass(ctx context.Context, aggregate aggtypes.Aggregate) (string, error)
+}
+
+// ClassPolicy restricts an aggregate to the active proof sets of its class,
+// and selects one of them with the Next policy.
+type ClassPolicy struct {
+	Classes ClassResolver
+	Next    Policy
+}
+
+func (p ClassPolicy) Select(ctx context.Context, aggregate aggtypes.Aggregate, active []ProofSet) (uint64, error) {
+	class, err := p.Classes.ProofSetClass(ctx, aggregate)
+	if err != nil {
+		return 0, fmt.Errorf("resolving proof set class: %w", err)
+	}
+	if class == "" {
+		return p.Next.Select(ctx, aggregate, active)
+	}
+	var matching []ProofSet
+	for _, ps := range active {
+		if ps.Class == class {
+			matching = append(matching, ps)
+		}
+	}
+	if len(matching) == 0 {
+		return 0, fmt.Errorf("%w: no active proof set of class %q", ErrNoActiveProofSet, class)
+	}
+	return p.Next.Select(ctx, aggregate, matching)
+}
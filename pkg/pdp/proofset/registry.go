@@ -0,0 +1,316 @@
+// Package proofset manages the proof sets a node adds aggregates to. A node
+// may prove into several proof sets, e.g. one per customer or size class. A
+// Policy decides which of the active proof sets each new aggregate goes to.
+// Retired proof sets receive no new aggregates but continue to be proven.
+package proofset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	aggtypes "github.com/storacha/piri/pkg/pdp/aggregation/types"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+var log = logging.Logger("pdp/proofset")
+
+var (
+	// ErrNotFound is returned when a proof set is not managed by the registry.
+	ErrNotFound = errors.New("proof set not found")
+	// ErrUnknownProofSet is returned when adding a proof set the node has not
+	// created.
+	ErrUnknownProofSet = errors.New("proof set was not created by this node")
+	// ErrNoActiveProofSet is returned when no active proof set accepts an
+	// aggregate.
+	ErrNoActiveProofSet = errors.New("no active proof set accepts the aggregate")
+	// ErrUnavailableBackend is returned when adding a proof set, or roots to
+	// it, with a backend the node does not run.
+	ErrUnavailableBackend = errors.New("proof set backend is not available")
+)
+
+// Backends proving proof sets.
+const (
+	// NativeBackend is the PDP service of the node.
+	NativeBackend = "native"
+	// CurioBackend is a Curio node the pieces of aggregates are streamed to.
+	CurioBackend = "curio"
+)
+
+// ProofSet is a proof set managed by the registry.
+type ProofSet struct {
+	ID uint64
+	// Class names the proof set for policies, e.g. a customer or size class.
+	Class string
+	// MinAggregateSize and MaxAggregateSize bound the padded size of the
+	// aggregates added to the proof set. 0 means no bound.
+	MinAggregateSize uint64
+	MaxAggregateSize uint64
+	// Backend proves the proof set, NativeBackend if empty when adding it.
+	Backend   string
+	Retired   bool
+	CreatedAt time.Time
+	RetiredAt *time.Time
+}
+
+// Accepts reports whether an aggregate with the padded size fits the size
+// bounds of the proof set.
+func (p ProofSet) Accepts(paddedSize uint64) bool {
+	if p.MinAggregateSize > 0 && paddedSize < p.MinAggregateSize {
+		return false
+	}
+	if p.MaxAggregateSize > 0 && paddedSize > p.MaxAggregateSize {
+		return false
+	}
+	return true
+}
+
+// Selector chooses the proof set an aggregate is added to.
+type Selector interface {
+	SelectProofSet(ctx context.Context, aggregate aggtypes.Aggregate) (uint64, error)
+}
+
+// Registry stores the proof sets managed by the node in the database.
+type Registry struct {
+	db     *gorm.DB
+	policy Policy
+	// backends are the backends proof sets may be added with
+	backends map[string]bool
+}
+
+var _ Selector = (*Registry)(nil)
+
+// Option configures a Registry.
+type Option func(r *Registry)
+
+// WithBackends allows adding proof sets proven by the backends, in addition
+// to the PDP service of the node.
+func WithBackends(backends ...string) Option {
+	return func(r *Registry) {
+		for _, b := range backends {
+			r.backends[b] = true
+		}
+	}
+}
+
+// NewRegistry creates a Registry placing aggregates with the policy.
+func NewRegistry(db *gorm.DB, policy Policy, opts ...Option) *Registry {
+	r := &Registry{db: db, policy: policy, backends: map[string]bool{NativeBackend: true}}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Seed adds the proof set if the registry is empty. It is used to manage the
+// proof set from the node's configuration when upgrading from a single proof
+// set.
+func (r *Registry) Seed(ctx context.Context, id uint64) error {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.PDPManagedProofSet{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("counting managed proof sets: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.PDPManagedProofSet{ProofSetID: int64(id)}).Error; err != nil {
+		return fmt.Errorf("adding proof set %d: %w", id, err)
+	}
+	log.Infow("managing configured proof set", "proof_set", id)
+	return nil
+}
+
+// Add starts adding aggregates to a proof set created by the node, or by
+// Curio for the curio backend. Adding a retired proof set reactivates it with
+// the new class, size bounds and backend.
+func (r *Registry) Add(ctx context.Context, ps ProofSet) (ProofSet, error) {
+	if ps.MaxAggregateSize > 0 && ps.MinAggregateSize > ps.MaxAggregateSize {
+		return ProofSet{}, fmt.Errorf("minimum aggregate size %d exceeds maximum %d", ps.MinAggregateSize, ps.MaxAggregateSize)
+	}
+	if ps.Backend == "" {
+		ps.Backend = NativeBackend
+	}
+	if !r.backends[ps.Backend] {
+		return ProofSet{}, fmt.Errorf("%w: %s", ErrUnavailableBackend, ps.Backend)
+	}
+	// proof sets of other backends are created by them, not the node
+	if ps.Backend == NativeBackend {
+		var created int64
+		if err := r.db.WithContext(ctx).Model(&models.PDPProofSet{}).Where("id = ?", ps.ID).Count(&created).Error; err != nil {
+			return ProofSet{}, fmt.Errorf("looking up proof set %d: %w", ps.ID, err)
+		}
+		if created == 0 {
+			return ProofSet{}, fmt.Errorf("%w: %d", ErrUnknownProofSet, ps.ID)
+		}
+	}
+
+	m := models.PDPManagedProofSet{
+		ProofSetID:       int64(ps.ID),
+		Class:            ps.Class,
+		MinAggregateSize: ps.MinAggregateSize,
+		MaxAggregateSize: ps.MaxAggregateSize,
+		Backend:          ps.Backend,
+	}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "proof_set_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"class":              m.Class,
+				"min_aggregate_size": m.MinAggregateSize,
+				"max_aggregate_size": m.MaxAggregateSize,
+				"backend":            m.Backend,
+				"retired":            false,
+				"retired_at":         nil,
+			}),
+		}).
+		Create(&m).Error; err != nil {
+		return ProofSet{}, fmt.Errorf("adding proof set %d: %w", ps.ID, err)
+	}
+	log.Infow("added proof set", "proof_set", ps.ID, "class", ps.Class, "backend", ps.Backend)
+	return r.Get(ctx, ps.ID)
+}
+
+// Retire stops adding aggregates to a proof set. Its roots continue to be
+// proven.
+func (r *Registry) Retire(ctx context.Context, id uint64) (ProofSet, error) {
+	now := time.Now()
+	res := r.db.WithContext(ctx).
+		Model(&models.PDPManagedProofSet{}).
+		Where("proof_set_id = ? AND retired = ?", id, false).
+		Updates(map[string]any{"retired": true, "retired_at": now})
+	if res.Error != nil {
+		return ProofSet{}, fmt.Errorf("retiring proof set %d: %w", id, res.Error)
+	}
+	ps, err := r.Get(ctx, id)
+	if err != nil {
+		return ProofSet{}, err
+	}
+	if res.RowsAffected > 0 {
+		log.Infow("retired proof set", "proof_set", id)
+	}
+	return ps, nil
+}
+
+// Get returns a managed proof set.
+func (r *Registry) Get(ctx context.Context, id uint64) (ProofSet, error) {
+	var m models.PDPManagedProofSet
+	if err := r.db.WithContext(ctx).First(&m, "proof_set_id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ProofSet{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+		}
+		return ProofSet{}, fmt.Errorf("getting proof set %d: %w", id, err)
+	}
+	return fromModel(m), nil
+}
+
+// List returns all managed proof sets, ordered by ID.
+func (r *Registry) List(ctx context.Context) ([]ProofSet, error) {
+	return r.list(r.db.WithContext(ctx))
+}
+
+// Active returns the proof sets that are not retired, ordered by ID.
+func (r *Registry) Active(ctx context.Context) ([]ProofSet, error) {
+	return r.list(r.db.WithContext(ctx).Where("retired = ?", false))
+}
+
+func (r *Registry) list(q *gorm.DB) ([]ProofSet, error) {
+	var rows []models.PDPManagedProofSet
+	if err := q.Order("proof_set_id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("listing proof sets: %w", err)
+	}
+	sets := make([]ProofSet, 0, len(rows))
+	for _, m := range rows {
+		sets = append(sets, fromModel(m))
+	}
+	return sets, nil
+}
+
+// SelectProofSet chooses an active proof set for the aggregate using the
+// registry's policy. The choice is recorded, and the proof set an aggregate
+// was selected for is returned again while the registry manages it, even once
+// retired, so a retried submission does not add the aggregate to a second
+// proof set.
+func (r *Registry) SelectProofSet(ctx context.Context, aggregate aggtypes.Aggregate) (uint64, error) {
+	key := aggregate.Root.Link().String()
+	if id, ok, err := r.selected(ctx, key); err != nil || ok {
+		return id, err
+	}
+
+	id, err := r.selectActive(ctx, aggregate)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.PDPAggregateProofSet{AggregateCID: key, ProofSetID: int64(id)}).Error; err != nil {
+		return 0, fmt.Errorf("recording proof set %d of aggregate %s: %w", id, key, err)
+	}
+	// a concurrent selection may have been recorded first
+	var rec models.PDPAggregateProofSet
+	if err := r.db.WithContext(ctx).First(&rec, "aggregate_cid = ?", key).Error; err != nil {
+		return 0, fmt.Errorf("reading proof set of aggregate %s: %w", key, err)
+	}
+	return uint64(rec.ProofSetID), nil
+}
+
+// selected returns the proof set recorded for the aggregate, if the registry
+// still manages it. A record of a proof set no longer managed is removed.
+func (r *Registry) selected(ctx context.Context, key string) (uint64, bool, error) {
+	var rec models.PDPAggregateProofSet
+	err := r.db.WithContext(ctx).First(&rec, "aggregate_cid = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("reading proof set of aggregate %s: %w", key, err)
+	}
+	if _, err := r.Get(ctx, uint64(rec.ProofSetID)); err == nil {
+		return uint64(rec.ProofSetID), true, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return 0, false, err
+	}
+	if err := r.db.WithContext(ctx).Delete(&rec).Error; err != nil {
+		return 0, false, fmt.Errorf("removing proof set of aggregate %s: %w", key, err)
+	}
+	return 0, false, nil
+}
+
+func (r *Registry) selectActive(ctx context.Context, aggregate aggtypes.Aggregate) (uint64, error) {
+	active, err := r.Active(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(active) == 0 {
+		return 0, ErrNoActiveProofSet
+	}
+	id, err := r.policy.Select(ctx, aggregate, active)
+	if err != nil {
+		return 0, err
+	}
+	for _, ps := range active {
+		if ps.ID == id {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("policy selected proof set %d, which is not active", id)
+}
+
+func fromModel(m models.PDPManagedProofSet) ProofSet {
+	return ProofSet{
+		ID:               uint64(m.ProofSetID),
+		Class:            m.Class,
+		MinAggregateSize: m.MinAggregateSize,
+		MaxAggregateSize: m.MaxAggregateSize,
+		Backend:          m.Backend,
+		Retired:          m.Retired,
+		CreatedAt:        m.CreatedAt,
+		RetiredAt:        m.RetiredAt,
+	}
+}
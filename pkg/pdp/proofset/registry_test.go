@@ -0,0 +1,170 @@
+package proofset
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/database/gormdb"
+	aggtypes "github.com/storacha/piri/pkg/pdp/aggregation/types"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+func newTestRegistry(t *testing.T, proofSetIDs ...int64) *Registry {
+	t.Helper()
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+	for _, id := range proofSetIDs {
+		createProofSet(t, db, id)
+	}
+	return NewRegistry(db, SizeClassPolicy{})
+}
+
+func createProofSet(t *testing.T, db *gorm.DB, id int64) {
+	t.Helper()
+	require.NoError(t, db.Create(&models.PDPProofSet{
+		ID:                id,
+		InitReady:         true,
+		CreateMessageHash: "0x01",
+		Service:           "storacha",
+	}).Error)
+}
+
+func TestSeed(t *testing.T) {
+	r := newTestRegistry(t, 1, 2)
+
+	require.NoError(t, r.Seed(t.Context(), 1))
+	// already managing a proof set, so this is a no-op
+	require.NoError(t, r.Seed(t.Context(), 2))
+
+	sets, err := r.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, sets, 1)
+	require.Equal(t, uint64(1), sets[0].ID)
+	require.False(t, sets[0].Retired)
+}
+
+func TestAddAndRetire(t *testing.T) {
+	r := newTestRegistry(t, 1)
+
+	t.Run("rejects proof sets not created by the node", func(t *testing.T) {
+		_, err := r.Add(t.Context(), ProofSet{ID: 7})
+		require.ErrorIs(t, err, ErrUnknownProofSet)
+	})
+
+	t.Run("rejects inverted size bounds", func(t *testing.T) {
+		_, err := r.Add(t.Context(), ProofSet{ID: 1, MinAggregateSize: 2048, MaxAggregateSize: 1024})
+		require.Error(t, err)
+	})
+
+	t.Run("retires and reactivates", func(t *testing.T) {
+		ps, err := r.Add(t.Context(), ProofSet{ID: 1, Class: "small", MaxAggregateSize: 1024})
+		require.NoError(t, err)
+		require.Equal(t, "small", ps.Class)
+		require.False(t, ps.Retired)
+
+		ps, err = r.Retire(t.Context(), 1)
+		require.NoError(t, err)
+		require.True(t, ps.Retired)
+		require.NotNil(t, ps.RetiredAt)
+
+		active, err := r.Active(t.Context())
+		require.NoError(t, err)
+		require.Empty(t, active)
+
+		ps, err = r.Add(t.Context(), ProofSet{ID: 1, Class: "any"})
+		require.NoError(t, err)
+		require.False(t, ps.Retired)
+		require.Nil(t, ps.RetiredAt)
+		require.Equal(t, "any", ps.Class)
+		require.Zero(t, ps.MaxAggregateSize)
+	})
+
+	t.Run("retiring unmanaged proof set fails", func(t *testing.T) {
+		_, err := r.Retire(t.Context(), 7)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestSelectProofSet(t *testing.T) {
+	r := newTestRegistry(t, 1, 2, 3)
+
+	small := aggtypes.Aggregate{Root: testutil.RandomPiece(t, 1024)}
+	large := aggtypes.Aggregate{Root: testutil.RandomPiece(t, 256*1024*1024)}
+	boundary := small.Root.PaddedSize()
+
+	_, err := r.SelectProofSet(t.Context(), small)
+	require.ErrorIs(t, err, ErrNoActiveProofSet)
+
+	_, err = r.Add(t.Context(), ProofSet{ID: 1, Class: "small", MaxAggregateSize: boundary})
+	require.NoError(t, err)
+	_, err = r.Add(t.Context(), ProofSet{ID: 2, Class: "large", MinAggregateSize: boundary + 1})
+	require.NoError(t, err)
+
+	id, err := r.SelectProofSet(t.Context(), small)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+
+	id, err = r.SelectProofSet(t.Context(), large)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), id)
+
+	_, err = r.Retire(t.Context(), 2)
+	require.NoError(t, err)
+	// a retried aggregate keeps the proof set it was selected for
+	id, err = r.SelectProofSet(t.Context(), large)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), id)
+	larger := aggtypes.Aggregate{Root: testutil.RandomPiece(t, 256*1024*1024)}
+	_, err = r.SelectProofSet(t.Context(), larger)
+	require.ErrorIs(t, err, ErrNoActiveProofSet)
+
+	// an unbounded proof set takes whatever the others do not
+	_, err = r.Add(t.Context(), ProofSet{ID: 3})
+	require.NoError(t, err)
+	id, err = r.SelectProofSet(t.Context(), larger)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), id)
+
+	// the selection holds when the size bounds change
+	_, err = r.Add(t.Context(), ProofSet{ID: 1, Class: "small", MaxAggregateSize: 1})
+	require.NoError(t, err)
+	id, err = r.SelectProofSet(t.Context(), small)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+}
+
+func TestClassPolicy(t *testing.T) {
+	archive := aggtypes.Aggregate{Root: testutil.RandomPiece(t, 1024)}
+	other := aggtypes.Aggregate{Root: testutil.RandomPiece(t, 1024)}
+	classes := classResolverFunc(func(aggregate aggtypes.Aggregate) string {
+		if aggregate.Root.Link() == archive.Root.Link() {
+			return "archive"
+		}
+		return ""
+	})
+	policy := ClassPolicy{Classes: classes, Next: SizeClassPolicy{}}
+	active := []ProofSet{{ID: 1}, {ID: 2, Class: "archive"}, {ID: 3, Class: "archive"}}
+
+	id, err := policy.Select(t.Context(), archive, active)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), id)
+
+	id, err = policy.Select(t.Context(), other, active)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+
+	_, err = policy.Select(t.Context(), archive, active[:1])
+	require.ErrorIs(t, err, ErrNoActiveProofSet)
+}
+
+type classResolverFunc func(aggtypes.Aggregate) string
+
+func (f classResolverFunc) ProofSetClass(_ context.Context, aggregate aggtypes.Aggregate) (string, error) {
+	return f(aggregate), nil
+}
@@ -0,0 +1,48 @@
+package proofset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// RootAdder adds the roots of aggregates to a proof set.
+type RootAdder interface {
+	AddRoots(ctx context.Context, proofSetID uint64, roots []types.RootAdd) (common.Hash, error)
+}
+
+// Router adds roots to each proof set through the backend proving it.
+type Router struct {
+	registry *Registry
+	backends map[string]RootAdder
+}
+
+var _ RootAdder = (*Router)(nil)
+
+// NewRouter creates a Router adding roots with the adders of backends, by
+// backend name.
+func NewRouter(registry *Registry, backends map[string]RootAdder) *Router {
+	return &Router{registry: registry, backends: backends}
+}
+
+// AddRoots adds the roots with the backend of the proof set. Proof sets the
+// registry does not manage are proven by the PDP service of the node.
+func (r *Router) AddRoots(ctx context.Context, proofSetID uint64, roots []types.RootAdd) (common.Hash, error) {
+	backend := NativeBackend
+	ps, err := r.registry.Get(ctx, proofSetID)
+	switch {
+	case err == nil:
+		backend = ps.Backend
+	case !errors.Is(err, ErrNotFound):
+		return common.Hash{}, err
+	}
+	adder, ok := r.backends[backend]
+	if !ok {
+		return common.Hash{}, fmt.Errorf("%w: %s, for proof set %d", ErrUnavailableBackend, backend, proofSetID)
+	}
+	return adder.AddRoots(ctx, proofSetID, roots)
+}
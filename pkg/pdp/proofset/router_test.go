@@ -0,0 +1,69 @@
+package proofset
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+type recordingAdder struct {
+	proofSets []uint64
+}
+
+func (a *recordingAdder) AddRoots(_ context.Context, proofSetID uint64, _ []types.RootAdd) (common.Hash, error) {
+	a.proofSets = append(a.proofSets, proofSetID)
+	return common.Hash{}, nil
+}
+
+func TestCurioBackend(t *testing.T) {
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+	createProofSet(t, db, 1)
+
+	t.Run("rejects the curio backend unless available", func(t *testing.T) {
+		r := NewRegistry(db, SizeClassPolicy{})
+		_, err := r.Add(t.Context(), ProofSet{ID: 7, Backend: CurioBackend})
+		require.ErrorIs(t, err, ErrUnavailableBackend)
+	})
+
+	r := NewRegistry(db, SizeClassPolicy{}, WithBackends(CurioBackend))
+
+	t.Run("accepts curio proof sets not created by the node", func(t *testing.T) {
+		ps, err := r.Add(t.Context(), ProofSet{ID: 7, Backend: CurioBackend})
+		require.NoError(t, err)
+		require.Equal(t, CurioBackend, ps.Backend)
+	})
+
+	t.Run("defaults to the native backend", func(t *testing.T) {
+		ps, err := r.Add(t.Context(), ProofSet{ID: 1})
+		require.NoError(t, err)
+		require.Equal(t, NativeBackend, ps.Backend)
+	})
+
+	t.Run("routes roots to the backend of the proof set", func(t *testing.T) {
+		native, curio := &recordingAdder{}, &recordingAdder{}
+		router := NewRouter(r, map[string]RootAdder{NativeBackend: native, CurioBackend: curio})
+
+		for _, id := range []uint64{1, 7, 9} {
+			_, err := router.AddRoots(t.Context(), id, nil)
+			require.NoError(t, err)
+		}
+		// 9 is not managed by the registry, so it is proven natively
+		require.Equal(t, []uint64{1, 9}, native.proofSets)
+		require.Equal(t, []uint64{7}, curio.proofSets)
+	})
+
+	t.Run("fails for unavailable backends", func(t *testing.T) {
+		router := NewRouter(r, map[string]RootAdder{NativeBackend: &recordingAdder{}})
+		_, err := router.AddRoots(t.Context(), 7, nil)
+		require.ErrorIs(t, err, ErrUnavailableBackend)
+	})
+}
@@ -4,31 +4,58 @@
 // 2. Clean Session Boundaries: Tasks are tied to specific sessions, not just owners
 // 3. Automatic Cleanup: Previous sessions are cleaned up on startup
 // 4. Graceful Termination: Tasks are released when an engine shuts down
+// 5. Priorities: Tasks of higher priority classes, and with earlier deadlines,
+// are started first, and tasks close to their deadline hold back lower classes
 package scheduler
 
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	logging "github.com/ipfs/go-log/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/gorm"
 
+	"github.com/storacha/piri/lib/telemetry"
 	"github.com/storacha/piri/pkg/pdp/service/models"
 )
 
 var log = logging.Logger("pdp/scheduler")
 
+// DefaultUrgentWindow is how close to its deadline a task must be to hold
+// back tasks of lower priority classes.
+const DefaultUrgentWindow = 10 * time.Minute
+
+var queueWaitBounds = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800}
+
 // TaskEngine is the central scheduler.
 type TaskEngine struct {
-	ctx         context.Context
-	cancel      context.CancelFunc
-	db          *gorm.DB
-	sessionID   string
-	handlers    []*taskTypeHandler
-	activeTasks atomic.Int32
+	ctx          context.Context
+	cancel       context.CancelFunc
+	db           *gorm.DB
+	sessionID    string
+	handlers     []*taskTypeHandler
+	activeTasks  atomic.Int32
+	urgentWindow time.Duration
+	classLimits  map[Priority]int
+
+	// mu guards the running and urgent task counts of each class.
+	mu      sync.Mutex
+	running map[Priority]int
+	urgent  map[Priority]int
+
+	// deadlines caches the deadlines of the pending tasks seen by the last
+	// poll. It is only used by the poller.
+	deadlines map[TaskID]time.Time
+
+	queueWait    *telemetry.Timer
+	runningTasks *telemetry.UpDownCounter
 }
 
 // Option is a functional option for configuring a TaskEngine.
@@ -43,6 +70,30 @@ func WithSessionID(sessionID string) Option {
 	}
 }
 
+// WithUrgentWindow sets how close to its deadline a task must be to hold back
+// tasks of lower priority classes. Defaults to DefaultUrgentWindow.
+func WithUrgentWindow(window time.Duration) Option {
+	return func(e *TaskEngine) error {
+		if window < 0 {
+			return fmt.Errorf("urgent window must not be negative: %s", window)
+		}
+		e.urgentWindow = window
+		return nil
+	}
+}
+
+// WithClassLimit caps the number of tasks of the priority class running at
+// once. A limit of 0, the default, is unlimited.
+func WithClassLimit(p Priority, limit int) Option {
+	return func(e *TaskEngine) error {
+		if limit < 0 {
+			return fmt.Errorf("%s task limit must not be negative: %d", p, limit)
+		}
+		e.classLimits[p] = limit
+		return nil
+	}
+}
+
 // NewEngine creates a new TaskEngine with the provided task implementations.
 // The engine manages task scheduling with session-based ownership, ensuring
 // clean boundaries between different engine instances and automatic cleanup
@@ -52,10 +103,18 @@ func WithSessionID(sessionID string) Option {
 //   - db: The database connection for task persistence
 //   - impls: Task implementations that define the work to be scheduled
 //   - opts: Optional configuration (e.g., WithSessionID)
+//
+// Handlers are ordered by the priority class of their task type, highest
+// first, so ready tasks of a higher class are started before any of a lower
+// one.
 func NewEngine(db *gorm.DB, impls []TaskInterface, opts ...Option) (*TaskEngine, error) {
 	e := &TaskEngine{
-		sessionID: mustGenerateSessionID(),
-		db:        db,
+		sessionID:    mustGenerateSessionID(),
+		db:           db,
+		urgentWindow: DefaultUrgentWindow,
+		classLimits:  map[Priority]int{},
+		running:      map[Priority]int{},
+		urgent:       map[Priority]int{},
 	}
 
 	for _, opt := range opts {
@@ -64,6 +123,28 @@ func NewEngine(db *gorm.DB, impls []TaskInterface, opts ...Option) (*TaskEngine,
 		}
 	}
 
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/pdp/scheduler")
+	queueWait, err := telemetry.NewTimer(
+		meter,
+		"piri_scheduler_queue_wait",
+		"records how long tasks wait to be started once ready, by priority class",
+		queueWaitBounds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	runningTasks, err := telemetry.NewUpDownCounter(
+		meter,
+		"piri_scheduler_running_tasks",
+		"number of tasks running, by priority class",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	e.queueWait = queueWait
+	e.runningTasks = runningTasks
+
 	for _, impl := range impls {
 		h := &taskTypeHandler{
 			TaskInterface:   impl,
@@ -72,6 +153,9 @@ func NewEngine(db *gorm.DB, impls []TaskInterface, opts ...Option) (*TaskEngine,
 		}
 		e.handlers = append(e.handlers, h)
 	}
+	sort.SliceStable(e.handlers, func(i, j int) bool {
+		return e.handlers[i].TaskTypeDetails.Priority > e.handlers[j].TaskTypeDetails.Priority
+	})
 
 	return e, nil
 }
@@ -180,11 +264,32 @@ func (e *TaskEngine) poller() {
 	}
 }
 
+// taskCandidate is an unclaimed task that is ready to run.
+type taskCandidate struct {
+	id TaskID
+	// ready is when the task became ready to run, after any retry wait.
+	ready time.Time
+	// deadline is when the task must be done by, zero if it has none.
+	deadline time.Time
+	// urgent is set when the deadline is within the urgent window.
+	urgent bool
+}
+
 // pollerTryAllWork attempts to find and schedule unassigned tasks for all registered task types.
 // It returns true if any work was accepted, which signals the poller to check again sooner.
-// Tasks are fetched in order of update time to ensure fair scheduling.
+//
+// Task types are tried in priority order, and the tasks of a type in order of
+// deadline, then update time. While a task within the urgent window is
+// pending or running, ready background tasks are held back. Messages and
+// proving periods are not, as proofs need them to land on chain. A task whose
+// deadline has passed is no longer urgent.
 func (e *TaskEngine) pollerTryAllWork() bool {
-	for _, h := range e.handlers {
+	now := time.Now()
+	urgentClass, holding := e.urgentClass()
+	candidates := make([][]taskCandidate, len(e.handlers))
+	deadlines := make(map[TaskID]time.Time)
+	defer func() { e.deadlines = deadlines }()
+	for i, h := range e.handlers {
 		var tasks []models.Task
 		if err := e.db.WithContext(e.ctx).
 			Where("name = ? AND session_id IS NULL", h.TaskTypeDetails.Name).
@@ -194,27 +299,141 @@ func (e *TaskEngine) pollerTryAllWork() bool {
 			continue
 		}
 
-		var taskIDs []TaskID
 		for _, t := range tasks {
-			if h.TaskTypeDetails.RetryWait == nil ||
-				t.Retries == 0 || // New task - pick up immediately
-				time.Since(t.UpdateTime) > h.TaskTypeDetails.RetryWait(int(t.Retries)) {
-				taskIDs = append(taskIDs, TaskID(t.ID))
+			c := taskCandidate{id: TaskID(t.ID), ready: t.UpdateTime}
+			if t.RunAt != nil {
+				if t.RunAt.After(now) {
+					continue
+				}
+				c.ready = *t.RunAt
+			}
+			if h.TaskTypeDetails.RetryWait != nil && t.Retries > 0 {
+				wait := h.TaskTypeDetails.RetryWait(int(t.Retries))
+				if time.Since(t.UpdateTime) <= wait {
+					continue
+				}
+				c.ready = t.UpdateTime.Add(wait)
+			}
+			if deadline := e.deadline(h, c.id); !deadline.IsZero() {
+				deadlines[c.id] = deadline
+				c.deadline = deadline
+				c.urgent = deadline.After(now) && deadline.Sub(now) <= e.urgentWindow
 			}
+			if c.urgent && (!holding || h.TaskTypeDetails.Priority > urgentClass) {
+				urgentClass, holding = h.TaskTypeDetails.Priority, true
+			}
+			candidates[i] = append(candidates[i], c)
 		}
+		sort.SliceStable(candidates[i], func(a, b int) bool {
+			return deadlineBefore(candidates[i][a].deadline, candidates[i][b].deadline)
+		})
+	}
 
-		if len(taskIDs) > 0 {
-			accepted := h.considerWork(taskIDs, e.db)
-			if accepted {
-				return true
-			}
-			log.Warnf("Work not accepted for %d %s task(s)", len(taskIDs), h.TaskTypeDetails.Name)
+	for i, h := range e.handlers {
+		if len(candidates[i]) == 0 {
+			continue
+		}
+		p := h.TaskTypeDetails.Priority
+		if holding && p < urgentClass && p == PriorityBackground {
+			log.Debugf("Holding back %d %s task(s) for urgent %s tasks", len(candidates[i]), h.TaskTypeDetails.Name, urgentClass)
+			continue
+		}
+		if e.classFull(p) {
+			log.Debugf("Not starting %d %s task(s), %s class is at its limit", len(candidates[i]), h.TaskTypeDetails.Name, p)
+			continue
+		}
+		accepted := h.considerWork(candidates[i], e.db)
+		if accepted {
+			return true
 		}
+		log.Warnf("Work not accepted for %d %s task(s)", len(candidates[i]), h.TaskTypeDetails.Name)
 	}
 
 	return false
 }
 
+// deadline returns the deadline of a pending task, zero if it has none. A
+// deadline is cached while the task stays pending, as it does not change
+// once known.
+func (e *TaskEngine) deadline(h *taskTypeHandler, id TaskID) time.Time {
+	if h.TaskTypeDetails.Deadline == nil {
+		return time.Time{}
+	}
+	if deadline, ok := e.deadlines[id]; ok {
+		return deadline
+	}
+	deadline, err := h.TaskTypeDetails.Deadline(id)
+	if err != nil {
+		log.Warnw("Unable to get task deadline", "name", h.TaskTypeDetails.Name, "task_id", id, "error", err)
+		return time.Time{}
+	}
+	return deadline
+}
+
+// deadlineBefore orders deadlines earliest first, with tasks without a
+// deadline last.
+func deadlineBefore(a, b time.Time) bool {
+	if a.IsZero() {
+		return false
+	}
+	return b.IsZero() || a.Before(b)
+}
+
+// urgentClass returns the highest class of the urgent tasks running, or false
+// if none is.
+func (e *TaskEngine) urgentClass() (Priority, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, p := range Priorities {
+		if e.urgent[p] > 0 {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+// classFull reports whether the class is running as many tasks as its limit.
+func (e *TaskEngine) classFull(p Priority) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	limit := e.classLimits[p]
+	return limit > 0 && e.running[p] >= limit
+}
+
+// reserve takes a slot of the class for a task about to be claimed, or returns
+// false if the class is at its limit.
+func (e *TaskEngine) reserve(p Priority) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if limit := e.classLimits[p]; limit > 0 && e.running[p] >= limit {
+		return false
+	}
+	e.running[p]++
+	return true
+}
+
+// release gives back a slot taken by reserve.
+func (e *TaskEngine) release(p Priority, urgent bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.running[p]--
+	if urgent {
+		e.urgent[p]--
+	}
+}
+
+// markUrgent records that a claimed task of the class is urgent, until it is
+// released.
+func (e *TaskEngine) markUrgent(p Priority) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.urgent[p]++
+}
+
+func classAttr(p Priority) attribute.KeyValue {
+	return attribute.String("class", p.String())
+}
+
 // cleanupPreviousSessions releases tasks that were owned by previous engine sessions.
 // This ensures that if an engine instance crashes or stops ungracefully, its tasks
 // can be picked up by new engine instances. Only tasks with session IDs different
@@ -254,3 +254,58 @@ func TestTaskEngineGasTooHighVsRealFailure(t *testing.T) {
 		assert.False(t, h.Result, "no attempt should have succeeded")
 	}
 }
+
+// TestTaskEngineDeferredTaskWaitsForRunAt tests that a task deferred with
+// DeferUntil is not picked up again before its run time, and that its retry
+// counter is not incremented.
+func TestTaskEngineDeferredTaskWaitsForRunAt(t *testing.T) {
+	db := setupTestDB(t)
+
+	var mu sync.Mutex
+	var runs []time.Time
+	delay := 2 * time.Second
+
+	mockTask := NewMockTask("test_task", false)
+	mockTask.typeDetails.MaxFailures = 1
+	mockTask.doFunc = func(taskID scheduler.TaskID) (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		runs = append(runs, time.Now())
+		if len(runs) == 1 {
+			return false, scheduler.DeferUntil(time.Now().Add(delay), scheduler.ErrGasTooHigh)
+		}
+		return true, nil
+	}
+
+	engine, err := scheduler.NewEngine(db, []scheduler.TaskInterface{mockTask})
+	require.NoError(t, err)
+	require.NoError(t, engine.Start(t.Context()))
+	t.Cleanup(func() {
+		if err := engine.Stop(context.Background()); err != nil {
+			t.Logf("failed to stop engine: %v", err)
+		}
+	})
+
+	mockTask.WaitForReady()
+	mockTask.AddTask(func(tID scheduler.TaskID, tx *gorm.DB) (bool, error) {
+		return true, nil
+	})
+
+	require.Eventually(t, func() bool {
+		var task models.Task
+		if err := db.Where("name = ?", "test_task").First(&task).Error; err != nil {
+			return false
+		}
+		return task.RunAt != nil && task.SessionID == nil && task.Retries == 0
+	}, 10*time.Second, 50*time.Millisecond, "task should be requeued with a run time")
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(runs) == 2
+	}, 30*time.Second, 100*time.Millisecond, "task should run again once its run time passes")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.GreaterOrEqual(t, runs[1].Sub(runs[0]), delay, "task should not run before its run time")
+}
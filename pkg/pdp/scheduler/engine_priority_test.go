@@ -0,0 +1,220 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/pdp/scheduler"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+// insertTasks adds ready tasks of the type directly to the database, so they
+// are all pending when the engine first polls.
+func insertTasks(t *testing.T, db *gorm.DB, name string, n int) []scheduler.TaskID {
+	var ids []scheduler.TaskID
+	for i := 0; i < n; i++ {
+		task := models.Task{Name: name, PostedTime: time.Now(), UpdateTime: time.Now(), AddedBy: "test"}
+		require.NoError(t, db.Create(&task).Error)
+		ids = append(ids, scheduler.TaskID(task.ID))
+	}
+	return ids
+}
+
+func startEngine(t *testing.T, db *gorm.DB, tasks []scheduler.TaskInterface, opts ...scheduler.Option) {
+	engine, err := scheduler.NewEngine(db, tasks, opts...)
+	require.NoError(t, err)
+	require.NoError(t, engine.Start(t.Context()))
+	t.Cleanup(func() {
+		if err := engine.Stop(context.Background()); err != nil {
+			t.Logf("failed to stop engine: %v", err)
+		}
+	})
+}
+
+// executionLog records the order tasks are started in.
+type executionLog struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (l *executionLog) record(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order = append(l.order, name)
+}
+
+func (l *executionLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.order...)
+}
+
+func TestTaskEnginePriorityOrder(t *testing.T) {
+	db := setupTestDB(t)
+	var started executionLog
+
+	background := NewMockTask("background", true)
+	background.doFunc = func(scheduler.TaskID) (bool, error) {
+		started.record("background")
+		return true, nil
+	}
+	proving := NewMockTask("proving", true)
+	proving.typeDetails.Priority = scheduler.PriorityProving
+	proving.doFunc = func(scheduler.TaskID) (bool, error) {
+		started.record("proving")
+		return true, nil
+	}
+
+	insertTasks(t, db, "background", 3)
+	insertTasks(t, db, "proving", 3)
+	// registered lowest first, the engine must still start proving tasks first
+	startEngine(t, db, []scheduler.TaskInterface{background, proving})
+
+	require.Eventually(t, func() bool {
+		return len(started.get()) == 6
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Equal(t, []string{"proving", "proving", "proving", "background", "background", "background"}, started.get())
+}
+
+func TestTaskEngineDeadlineOrder(t *testing.T) {
+	db := setupTestDB(t)
+	var started executionLog
+
+	ids := insertTasks(t, db, "proving", 3)
+	// the last task added is due first
+	deadlines := map[scheduler.TaskID]time.Time{
+		ids[0]: time.Now().Add(3 * time.Hour),
+		ids[1]: time.Now().Add(2 * time.Hour),
+		ids[2]: time.Now().Add(time.Hour),
+	}
+	names := map[scheduler.TaskID]string{ids[0]: "third", ids[1]: "second", ids[2]: "first"}
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	proving := NewMockTask("proving", true)
+	proving.typeDetails.Priority = scheduler.PriorityProving
+	proving.typeDetails.Deadline = func(id scheduler.TaskID) (time.Time, error) {
+		return deadlines[id], nil
+	}
+	proving.doFunc = func(id scheduler.TaskID) (bool, error) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		started.record(names[id])
+		time.Sleep(100 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return true, nil
+	}
+
+	startEngine(t, db, []scheduler.TaskInterface{proving}, scheduler.WithClassLimit(scheduler.PriorityProving, 1))
+
+	require.Eventually(t, func() bool {
+		return len(started.get()) == 3
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Equal(t, []string{"first", "second", "third"}, started.get())
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 1, maxRunning, "proving class limit should be respected")
+}
+
+func TestTaskEngineUrgentTaskHoldsBackBackground(t *testing.T) {
+	db := setupTestDB(t)
+	var started executionLog
+	release := make(chan struct{})
+
+	proving := NewMockTask("proving", true)
+	proving.typeDetails.Priority = scheduler.PriorityProving
+	proving.typeDetails.Deadline = func(scheduler.TaskID) (time.Time, error) {
+		return time.Now().Add(time.Minute), nil
+	}
+	proving.doFunc = func(scheduler.TaskID) (bool, error) {
+		started.record("proving")
+		<-release
+		return true, nil
+	}
+	chain := NewMockTask("chain", true)
+	chain.typeDetails.Priority = scheduler.PriorityChain
+	chain.doFunc = func(scheduler.TaskID) (bool, error) {
+		started.record("chain")
+		return true, nil
+	}
+	messages := NewMockTask("messages", true)
+	messages.typeDetails.Priority = scheduler.PriorityMessages
+	messages.doFunc = func(scheduler.TaskID) (bool, error) {
+		started.record("messages")
+		return true, nil
+	}
+	background := NewMockTask("background", true)
+	background.doFunc = func(scheduler.TaskID) (bool, error) {
+		started.record("background")
+		return true, nil
+	}
+
+	insertTasks(t, db, "proving", 1)
+	insertTasks(t, db, "chain", 1)
+	insertTasks(t, db, "messages", 1)
+	insertTasks(t, db, "background", 1)
+	startEngine(t, db, []scheduler.TaskInterface{proving, chain, messages, background})
+	var releaseOnce sync.Once
+	unblock := func() { releaseOnce.Do(func() { close(release) }) }
+	// unblock before the engine stops, or it waits on the proof forever
+	t.Cleanup(unblock)
+
+	// proving periods and messages are never held back, proofs need them
+	require.Eventually(t, func() bool {
+		return len(started.get()) == 3
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Equal(t, []string{"proving", "chain", "messages"}, started.get())
+
+	require.Never(t, func() bool {
+		return len(started.get()) > 3
+	}, time.Second, 100*time.Millisecond, "background task should be held back while the urgent proof runs")
+
+	unblock()
+	require.Eventually(t, func() bool {
+		return len(started.get()) == 4
+	}, 10*time.Second, 50*time.Millisecond)
+	require.Equal(t, "background", started.get()[3])
+}
+
+func TestTaskEngineOverdueTaskIsNotUrgent(t *testing.T) {
+	db := setupTestDB(t)
+	var started executionLog
+	release := make(chan struct{})
+
+	proving := NewMockTask("proving", true)
+	proving.typeDetails.Priority = scheduler.PriorityProving
+	proving.typeDetails.Deadline = func(scheduler.TaskID) (time.Time, error) {
+		return time.Now().Add(-time.Minute), nil
+	}
+	proving.doFunc = func(scheduler.TaskID) (bool, error) {
+		started.record("proving")
+		<-release
+		return true, nil
+	}
+	background := NewMockTask("background", true)
+	background.doFunc = func(scheduler.TaskID) (bool, error) {
+		started.record("background")
+		return true, nil
+	}
+
+	insertTasks(t, db, "proving", 1)
+	insertTasks(t, db, "background", 1)
+	startEngine(t, db, []scheduler.TaskInterface{proving, background})
+	var releaseOnce sync.Once
+	unblock := func() { releaseOnce.Do(func() { close(release) }) }
+	t.Cleanup(unblock)
+
+	require.Eventually(t, func() bool {
+		return len(started.get()) == 2
+	}, 10*time.Second, 50*time.Millisecond, "a task past its deadline should not hold back background tasks")
+	require.Equal(t, []string{"proving", "background"}, started.get())
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/gorm"
 
 	"github.com/storacha/piri/pkg/database"
@@ -87,13 +88,20 @@ retryAddTask:
 
 }
 
-// considerWork claims and executes tasks.
-func (h *taskTypeHandler) considerWork(taskIDs []TaskID, db *gorm.DB) bool {
+// considerWork claims and executes tasks, in the order given, until the
+// priority class of the task type is at its limit.
+func (h *taskTypeHandler) considerWork(candidates []taskCandidate, db *gorm.DB) bool {
 	acceptedAny := false
+	e := h.TaskEngine
+	p := h.TaskTypeDetails.Priority
 
-	log.Debugf("Considering work for tasks %v", taskIDs)
-	for _, id := range taskIDs {
+	for _, c := range candidates {
+		id := c.id
 		log.Debugf("Considering work for task %d", id)
+		if !e.reserve(p) {
+			log.Debugf("Not claiming task %d, %s class is at its limit", id, p)
+			break
+		}
 		result := db.
 			WithContext(h.TaskEngine.ctx).
 			Model(&models.Task{}).
@@ -104,10 +112,12 @@ func (h *taskTypeHandler) considerWork(taskIDs []TaskID, db *gorm.DB) bool {
 			})
 
 		if result.Error != nil {
+			e.release(p, false)
 			log.Errorw("Could not claim task", "task_id", id, "error", result.Error)
 			continue
 		}
 		if result.RowsAffected == 0 {
+			e.release(p, false)
 			// Already taken by someone else (or in race condition). Skip it.
 			log.Debugf("Task %d was already claimed; skipping", id)
 			continue
@@ -115,9 +125,18 @@ func (h *taskTypeHandler) considerWork(taskIDs []TaskID, db *gorm.DB) bool {
 
 		// Successfully claimed this task, so let’s run it in a goroutine:
 		acceptedAny = true
-		go func(taskID TaskID) {
+		if c.urgent {
+			e.markUrgent(p)
+		}
+		e.queueWait.Record(e.ctx, time.Since(c.ready), classAttr(p), attribute.String("task", h.TaskTypeDetails.Name))
+		e.runningTasks.Inc(e.ctx, classAttr(p))
+		go func(taskID TaskID, urgent bool) {
 			h.TaskEngine.activeTasks.Add(1)
 			defer h.TaskEngine.activeTasks.Add(-1)
+			defer func() {
+				e.runningTasks.Add(e.ctx, -1, classAttr(p))
+				e.release(p, urgent)
+			}()
 			tlog := log.With("name", h.TaskTypeDetails.Name, "task_id", taskID, "session_id", h.TaskEngine.sessionID)
 			var (
 				done    bool
@@ -143,7 +162,7 @@ func (h *taskTypeHandler) considerWork(taskIDs []TaskID, db *gorm.DB) bool {
 			if doErr != nil {
 				tlog.Errorw("Task execution failed", "error", doErr, "done", done, "duration", time.Since(doStart))
 			}
-		}(id)
+		}(id, c.urgent)
 	}
 
 	return acceptedAny
@@ -196,7 +215,24 @@ retryHandleDoneTask:
 				taskErrMsg = "error: " + doErr.Error()
 			}
 
-			if doErr != nil && errors.Is(doErr, ErrGasTooHigh) {
+			var deferred *DeferredError
+			if errors.As(doErr, &deferred) {
+				// requeued without incrementing retries, not picked up
+				// before its run time
+				taskErrMsg = "deferred: " + doErr.Error()
+				tlog.Infow("Task deferred", "run_at", deferred.RunAt, "error", deferred.Err)
+				runAt := deferred.RunAt
+				if err := tx.Model(&models.Task{}).
+					Where(&models.Task{ID: int64(id)}).
+					Select("session_id", "update_time", "run_at").
+					Updates(models.Task{
+						SessionID:  nil,
+						UpdateTime: time.Now(),
+						RunAt:      &runAt,
+					}).Error; err != nil {
+					return fmt.Errorf("failed to requeue deferred task %d: %w", id, err)
+				}
+			} else if doErr != nil && errors.Is(doErr, ErrGasTooHigh) {
 				// Gas deferral: requeue WITHOUT incrementing retries so gas spikes
 				// never exhaust the task's retry budget.
 				taskErrMsg = "deferred: " + doErr.Error()
@@ -272,6 +308,37 @@ var ErrDoNotCommit = errors.New("do not commit")
 // incrementing the retry counter, so gas deferral never exhausts retries.
 var ErrGasTooHigh = errors.New("gas fee exceeds configured maximum")
 
+// DeferredError is returned by a task's Do() to run the task again at RunAt.
+// Like [ErrGasTooHigh], the task is requeued without incrementing the retry
+// counter.
+type DeferredError struct {
+	RunAt time.Time
+	Err   error
+}
+
+// DeferUntil returns a [DeferredError] running the task again at runAt,
+// because of err.
+func DeferUntil(runAt time.Time, err error) error {
+	return &DeferredError{RunAt: runAt, Err: err}
+}
+
+func (e *DeferredError) Error() string {
+	return fmt.Sprintf("deferred until %s: %s", e.RunAt.Format(time.RFC3339), e.Err)
+}
+
+func (e *DeferredError) Unwrap() error {
+	return e.Err
+}
+
+// DeferTask sets when the task id may be started, e.g. by the AddTaskFunc
+// callback of a task that should not run right away.
+func DeferTask(tx *gorm.DB, id TaskID, runAt time.Time) error {
+	if err := tx.Model(&models.Task{}).Where("id = ?", int64(id)).Update("run_at", runAt).Error; err != nil {
+		return fmt.Errorf("deferring task %d: %w", id, err)
+	}
+	return nil
+}
+
 // runPeriodicTask runs a periodic task at the specified interval
 func (h *taskTypeHandler) runPeriodicTask() {
 	scheduler := h.TaskTypeDetails.PeriodicScheduler
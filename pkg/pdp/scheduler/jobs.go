@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/lib/jobqueue"
+	"github.com/storacha/piri/lib/jobqueue/queue"
+	"github.com/storacha/piri/lib/jobqueue/worker"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+// TaskJobsQueueName is the queue name the tasks of the engine are listed
+// under.
+const TaskJobsQueueName = "pdp-tasks"
+
+// ErrTasksUnmanaged is returned when retrying or cancelling a task, tasks are
+// only run, retried and removed by the engine.
+var ErrTasksUnmanaged = fmt.Errorf("%w: tasks are managed by the task engine", worker.ErrUnmanaged)
+
+// TaskJobs exposes the tasks of a TaskEngine as the jobs of a read-only job
+// queue, so they can be inspected alongside the node's job queues. Tasks
+// waiting to run or running are queued jobs, tasks dropped after failing too
+// many times are dead jobs.
+type TaskJobs struct {
+	engine *TaskEngine
+}
+
+var _ jobqueue.Manager = (*TaskJobs)(nil)
+
+func NewTaskJobs(engine *TaskEngine) *TaskJobs {
+	return &TaskJobs{engine: engine}
+}
+
+func (t *TaskJobs) Name() string {
+	return TaskJobsQueueName
+}
+
+// ListJobs lists the tasks waiting to run or running, or the dropped tasks,
+// oldest first.
+func (t *TaskJobs) ListJobs(ctx context.Context, opts queue.ListOpts) ([]queue.Job, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = queue.DefaultListLimit
+	}
+	db := t.engine.db.WithContext(ctx)
+
+	if opts.Dead {
+		var failed []failedTask
+		if err := t.failedTasks(db).Order("h.work_end, h.task_id").Limit(limit).Find(&failed).Error; err != nil {
+			return nil, fmt.Errorf("listing dropped tasks: %w", err)
+		}
+		jobs := make([]queue.Job, 0, len(failed))
+		for _, f := range failed {
+			jobs = append(jobs, f.job())
+		}
+		return jobs, nil
+	}
+
+	var tasks []models.Task
+	if err := db.Order("posted_time, id").Limit(limit).Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("listing tasks: %w", err)
+	}
+	jobs := make([]queue.Job, 0, len(tasks))
+	for _, task := range tasks {
+		jobs = append(jobs, t.taskJob(task))
+	}
+	return jobs, nil
+}
+
+// GetJob returns a task waiting to run or running, with the error of its last
+// failed run, or a dropped task.
+func (t *TaskJobs) GetJob(ctx context.Context, id queue.ID) (queue.Job, error) {
+	taskID, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil {
+		return queue.Job{}, fmt.Errorf("%w: invalid task id %q", queue.ErrJobNotFound, id)
+	}
+	db := t.engine.db.WithContext(ctx)
+
+	var task models.Task
+	err = db.Where("id = ?", taskID).First(&task).Error
+	if err == nil {
+		j := t.taskJob(task)
+		var last models.TaskHistory
+		err := db.Where("task_id = ? AND result = ?", taskID, false).Order("id DESC").First(&last).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return queue.Job{}, fmt.Errorf("fetching task history: %w", err)
+		}
+		j.Error = last.Err
+		return j, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return queue.Job{}, fmt.Errorf("fetching task: %w", err)
+	}
+
+	var failed failedTask
+	if err := t.failedTasks(db).Where("h.task_id = ?", taskID).Take(&failed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return queue.Job{}, fmt.Errorf("%w: task %s", queue.ErrJobNotFound, id)
+		}
+		return queue.Job{}, fmt.Errorf("fetching dropped task: %w", err)
+	}
+	return failed.job(), nil
+}
+
+// RetryJob is not supported, dropped tasks are not run again.
+func (t *TaskJobs) RetryJob(context.Context, queue.ID) (queue.Job, error) {
+	return queue.Job{}, ErrTasksUnmanaged
+}
+
+// CancelJob is not supported, tasks may have state in other tables that is
+// removed when they complete.
+func (t *TaskJobs) CancelJob(context.Context, queue.ID) (queue.Job, error) {
+	return queue.Job{}, ErrTasksUnmanaged
+}
+
+func (t *TaskJobs) taskJob(task models.Task) queue.Job {
+	return queue.Job{
+		ID:        queue.ID(strconv.FormatInt(task.ID, 10)),
+		Name:      task.Name,
+		Received:  int(task.Retries),
+		Created:   task.PostedTime,
+		Available: t.availableAt(task),
+		Running:   task.SessionID != nil,
+	}
+}
+
+// availableAt is when the poller next picks up the task, a failed task waits
+// the retry wait of its task type, and a deferred task its run time.
+func (t *TaskJobs) availableAt(task models.Task) time.Time {
+	at := task.PostedTime
+	if task.Retries > 0 {
+		at = task.UpdateTime
+		for _, h := range t.engine.handlers {
+			if h.TaskTypeDetails.Name == task.Name && h.TaskTypeDetails.RetryWait != nil {
+				at = task.UpdateTime.Add(h.TaskTypeDetails.RetryWait(int(task.Retries)))
+				break
+			}
+		}
+	}
+	if task.RunAt != nil && task.RunAt.After(at) {
+		at = *task.RunAt
+	}
+	return at
+}
+
+// failedTask is the last run of a task that was dropped after failing.
+type failedTask struct {
+	TaskID   int64
+	Name     string
+	Posted   time.Time
+	WorkEnd  time.Time
+	Err      string
+	Attempts int
+}
+
+// failedTasks selects the last run of the tasks that failed and were removed,
+// which the engine only does once a task fails too many times.
+func (t *TaskJobs) failedTasks(db *gorm.DB) *gorm.DB {
+	return db.Table("task_history AS h").
+		Select("h.task_id, h.name, h.posted, h.work_end, h.err, "+
+			"(SELECT COUNT(*) FROM task_history a WHERE a.task_id = h.task_id) AS attempts").
+		Where("h.result = ?", false).
+		Where("h.id IN (?)", db.Model(&models.TaskHistory{}).Select("MAX(id)").Group("task_id")).
+		Where("h.task_id NOT IN (?)", db.Model(&models.Task{}).Select("id"))
+}
+
+func (f failedTask) job() queue.Job {
+	return queue.Job{
+		ID:            queue.ID(strconv.FormatInt(f.TaskID, 10)),
+		Name:          f.Name,
+		Received:      f.Attempts,
+		Created:       f.Posted,
+		Dead:          true,
+		FailureReason: "max_retries",
+		Error:         f.Err,
+		MovedAt:       f.WorkEnd,
+	}
+}
@@ -0,0 +1,76 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/lib/jobqueue/queue"
+	"github.com/storacha/piri/lib/jobqueue/worker"
+	"github.com/storacha/piri/pkg/pdp/scheduler"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+func TestTaskJobs(t *testing.T) {
+	db := setupTestDB(t)
+	mockTask := NewMockTask("test_task", true)
+	engine, err := scheduler.NewEngine(db, []scheduler.TaskInterface{mockTask})
+	require.NoError(t, err)
+	jobs := scheduler.NewTaskJobs(engine)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	session := "session"
+	require.NoError(t, db.Create(&models.Task{ID: 1, Name: "test_task", PostedTime: now, UpdateTime: now, AddedBy: session}).Error)
+	require.NoError(t, db.Create(&models.Task{ID: 2, Name: "test_task", PostedTime: now.Add(time.Second), UpdateTime: now, AddedBy: session, SessionID: &session, Retries: 1}).Error)
+	require.NoError(t, db.Create(&models.TaskHistory{TaskID: 2, Name: "test_task", Posted: now, WorkStart: now, WorkEnd: now, Err: "error: first", CompletedBySessionID: session}).Error)
+	// task 3 was dropped after failing twice, task 4 completed
+	require.NoError(t, db.Create(&models.TaskHistory{TaskID: 3, Name: "test_task", Posted: now, WorkStart: now, WorkEnd: now, Err: "error: first", CompletedBySessionID: session}).Error)
+	require.NoError(t, db.Create(&models.TaskHistory{TaskID: 3, Name: "test_task", Posted: now, WorkStart: now, WorkEnd: now.Add(time.Minute), Err: "error: last", CompletedBySessionID: session}).Error)
+	require.NoError(t, db.Create(&models.TaskHistory{TaskID: 4, Name: "test_task", Posted: now, WorkStart: now, WorkEnd: now, Result: true, CompletedBySessionID: session}).Error)
+
+	t.Run("lists queued and running tasks", func(t *testing.T) {
+		list, err := jobs.ListJobs(t.Context(), queue.ListOpts{})
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		require.Equal(t, queue.ID("1"), list[0].ID)
+		require.False(t, list[0].Running)
+		require.Equal(t, queue.ID("2"), list[1].ID)
+		require.True(t, list[1].Running)
+		require.Equal(t, 1, list[1].Received)
+		require.WithinDuration(t, now.Add(50*time.Millisecond), list[1].Available, time.Millisecond)
+	})
+
+	t.Run("lists dropped tasks", func(t *testing.T) {
+		list, err := jobs.ListJobs(t.Context(), queue.ListOpts{Dead: true})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.Equal(t, queue.ID("3"), list[0].ID)
+		require.True(t, list[0].Dead)
+		require.Equal(t, 2, list[0].Received)
+		require.Equal(t, "error: last", list[0].Error)
+	})
+
+	t.Run("gets a task with its last error", func(t *testing.T) {
+		j, err := jobs.GetJob(t.Context(), "2")
+		require.NoError(t, err)
+		require.True(t, j.Running)
+		require.Equal(t, "error: first", j.Error)
+
+		j, err = jobs.GetJob(t.Context(), "3")
+		require.NoError(t, err)
+		require.True(t, j.Dead)
+
+		_, err = jobs.GetJob(t.Context(), "4")
+		require.ErrorIs(t, err, queue.ErrJobNotFound)
+		_, err = jobs.GetJob(t.Context(), "invalid")
+		require.ErrorIs(t, err, queue.ErrJobNotFound)
+	})
+
+	t.Run("does not retry or cancel tasks", func(t *testing.T) {
+		_, err := jobs.RetryJob(t.Context(), "3")
+		require.ErrorIs(t, err, worker.ErrUnmanaged)
+		_, err = jobs.CancelJob(t.Context(), "1")
+		require.ErrorIs(t, err, worker.ErrUnmanaged)
+	})
+}
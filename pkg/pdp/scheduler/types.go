@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -19,6 +20,41 @@ type TaskInterface interface {
 	Adder(AddTaskFunc)
 }
 
+// Priority is the class of a task type. When tasks of several classes are
+// ready, those of the higher class are started first.
+type Priority int
+
+const (
+	// PriorityBackground is for housekeeping work that can wait. It is the
+	// class of task types that do not set one.
+	PriorityBackground Priority = iota
+	// PriorityMessages is for sending messages to the chain.
+	PriorityMessages
+	// PriorityChain is for managing proving periods on chain.
+	PriorityChain
+	// PriorityProving is for computing and submitting proofs, which must land
+	// within their challenge window.
+	PriorityProving
+)
+
+// Priorities lists the priority classes, highest first.
+var Priorities = []Priority{PriorityProving, PriorityChain, PriorityMessages, PriorityBackground}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityBackground:
+		return "background"
+	case PriorityMessages:
+		return "messages"
+	case PriorityChain:
+		return "chain"
+	case PriorityProving:
+		return "proving"
+	default:
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+}
+
 // TaskTypeDetails defines static properties for each task type.
 type TaskTypeDetails struct {
 	// Task name (should be unique and short)
@@ -29,6 +65,15 @@ type TaskTypeDetails struct {
 	RetryWait func(retries int) time.Duration
 	// PeriodicScheduler defines a task that should run on a fixed interval
 	PeriodicScheduler *PeriodicScheduler
+	// Priority is the class of the task type.
+	Priority Priority
+	// Deadline optionally returns when a task must be done by, or the zero
+	// time if it has no deadline. Ready tasks of the type are started
+	// earliest deadline first, and a task whose deadline is within the
+	// engine's urgent window holds back background tasks until it is done.
+	// Its result is cached while the task is pending, so it must not return
+	// a deadline that changes later.
+	Deadline func(TaskID) (time.Time, error)
 }
 
 // PeriodicScheduler defines a periodic task scheduler that runs on a fixed interval
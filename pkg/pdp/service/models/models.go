@@ -28,6 +28,9 @@ type Task struct {
 	Name         string    `gorm:"size:16;not null;column:name;comment:The name of the task type"`
 	Retries      uint      `gorm:"not null;column:retries"`
 	// Note: The "retries" field was commented out in the original schema.
+	// RunAt is when a deferred task may be started, nil if it may be started
+	// right away.
+	RunAt *time.Time `gorm:"column:run_at"`
 
 	// TODO consider adding this in when/if we allow more machines
 	// OwnerMachine *Machine  `gorm:"foreignKey:OwnerID;references:ID;constraint:OnDelete:SET NULL"` // matches "owner_id references machines(id) on delete set null"
@@ -179,6 +182,19 @@ func (PDPProveTask) TableName() string {
 	return "pdp_prove_tasks"
 }
 
+// PDPProveWait tracks a proof sent for a proof set until its message is
+// confirmed, when the roots of the proof set are recorded as proven, or as
+// faulted if the message failed.
+type PDPProveWait struct {
+	ProveMessageHash string    `gorm:"primaryKey;column:prove_message_hash;not null"`
+	ProofsetID       int64     `gorm:"column:proofset_id;not null;index"`
+	CreatedAt        time.Time `gorm:"column:created_at"`
+}
+
+func (PDPProveWait) TableName() string {
+	return "pdp_prove_waits"
+}
+
 // pdp_proofset_creates
 type PDPProofsetCreate struct {
 	CreateMessageHash string           `gorm:"primaryKey"` // references message_waits_eth(signed_tx_hash)
@@ -261,6 +277,38 @@ func (PDPProviderRegistration) TableName() string {
 	return "pdp_provider_registrations"
 }
 
+// pdp_managed_proof_sets holds the proof sets new aggregates may be added to
+type PDPManagedProofSet struct {
+	ProofSetID int64 `gorm:"primaryKey;autoIncrement:false"` // references pdp_proof_sets(id)
+	// Class names the proof set for placement policies, e.g. a customer or size class
+	Class            string `gorm:"not null;default:''"`
+	MinAggregateSize uint64 `gorm:"not null;default:0"` // padded bytes, 0 for no minimum
+	MaxAggregateSize uint64 `gorm:"not null;default:0"` // padded bytes, 0 for no maximum
+	Retired          bool   `gorm:"not null;default:false"`
+	// Backend proves the proof set: native for the PDP service of the node,
+	// curio for a Curio node
+	Backend string `gorm:"not null;default:'native'"`
+
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;not null"`
+	RetiredAt *time.Time
+}
+
+func (PDPManagedProofSet) TableName() string {
+	return "pdp_managed_proof_sets"
+}
+
+// PDPAggregateProofSet records the proof set an aggregate was selected for, so
+// retried submissions of the aggregate add it to the same proof set.
+type PDPAggregateProofSet struct {
+	AggregateCID string    `gorm:"primaryKey;column:aggregate_cid"`
+	ProofSetID   int64     `gorm:"not null;index"` // references pdp_managed_proof_sets(proof_set_id)
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP;not null"`
+}
+
+func (PDPAggregateProofSet) TableName() string {
+	return "pdp_aggregate_proof_sets"
+}
+
 // MessageSendsEth represents the message_sends_eth table.
 type MessageSendsEth struct {
 	FromAddress  string     `gorm:"not null;column:from_address"`
@@ -297,6 +345,7 @@ type MessageWaitsEth struct {
 	WaiterMachineID      *int64         `gorm:"column:waiter_machine_id"`
 	SignedTxHash         string         `gorm:"primaryKey;column:signed_tx_hash;not null"`
 	ConfirmedBlockNumber *int64         `gorm:"column:confirmed_block_number"`
+	ConfirmedBlockHash   string         `gorm:"column:confirmed_block_hash"` // detects the block being reorged out
 	ConfirmedTxHash      string         `gorm:"column:confirmed_tx_hash"`
 	ConfirmedTxData      datatypes.JSON `gorm:"column:confirmed_tx_data"`
 	TxStatus             string         `gorm:"column:tx_status"`
@@ -308,6 +357,21 @@ func (MessageWaitsEth) TableName() string {
 	return "message_waits_eth"
 }
 
+// MessageReplacementsEth records transactions sent to replace a stalled
+// transaction, reusing its nonce at a higher fee. Waits keep referencing the
+// original transaction hash.
+type MessageReplacementsEth struct {
+	SignedHash   string    `gorm:"primaryKey;column:signed_hash;not null"`
+	OriginalHash string    `gorm:"not null;index;column:original_hash"`
+	SendTaskID   int       `gorm:"not null;index;column:send_task_id"`
+	SignedTx     []byte    `gorm:"not null;column:signed_tx"`
+	SentAt       time.Time `gorm:"not null;column:sent_at"`
+}
+
+func (MessageReplacementsEth) TableName() string {
+	return "message_replacements_eth"
+}
+
 // RailSettlementWaits tracks pending settlement transactions per rail.
 // Used to prevent duplicate settlements and to poll for confirmation status.
 type RailSettlementWaits struct {
@@ -320,6 +384,19 @@ func (RailSettlementWaits) TableName() string {
 	return "rail_settlement_waits"
 }
 
+// GasBaseFeeSample records the base fee of a chain epoch. The gas oracle keeps
+// a rolling window of samples to estimate fee percentiles.
+type GasBaseFeeSample struct {
+	Epoch int64 `gorm:"primaryKey;autoIncrement:false;column:epoch"`
+	// BaseFee is the base fee in attoFIL, as a decimal string.
+	BaseFee    string    `gorm:"column:base_fee;not null"`
+	RecordedAt time.Time `gorm:"column:recorded_at;not null;index"`
+}
+
+func (GasBaseFeeSample) TableName() string {
+	return "gas_base_fee_samples"
+}
+
 // WithdrawalWaits tracks pending withdrawal transactions.
 // Used to prevent duplicate withdrawals and to poll for confirmation status.
 type WithdrawalWaits struct {
@@ -332,6 +409,158 @@ func (WithdrawalWaits) TableName() string {
 	return "withdrawal_waits"
 }
 
+// ChainEvent is a contract event concerning one of the node's data sets,
+// recorded by the chain event indexer.
+type ChainEvent struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	BlockNumber int64  `gorm:"column:block_number;not null;index"`
+	TxHash      string `gorm:"column:tx_hash;not null;uniqueIndex:idx_chain_event_log"`
+	LogIndex    uint   `gorm:"column:log_index;not null;uniqueIndex:idx_chain_event_log"`
+	Contract    string `gorm:"column:contract;not null"`
+	Name        string `gorm:"column:name;not null;index"`
+	DataSetID   int64  `gorm:"column:data_set_id;not null;index"`
+	// Args holds the decoded arguments of the event that are not indexed.
+	Args      datatypes.JSON `gorm:"column:args"`
+	CreatedAt time.Time      `gorm:"column:created_at"`
+}
+
+func (ChainEvent) TableName() string {
+	return "chain_events"
+}
+
+// ChainEventCursor records the last block searched for events by the chain
+// event indexer. There is a single row.
+type ChainEventCursor struct {
+	ID          uint      `gorm:"primaryKey"`
+	BlockNumber int64     `gorm:"column:block_number;not null"`
+	UpdatedAt   time.Time `gorm:"column:updated_at"`
+}
+
+func (ChainEventCursor) TableName() string {
+	return "chain_event_cursor"
+}
+
+// SignOperation is an operation of the warm storage service signed by the
+// signing service.
+type SignOperation string
+
+// SignStatus is the state of a sign request.
+type SignStatus string
+
+// SignRequest is an EIP-712 sign request held for approval by the operator.
+type SignRequest struct {
+	ID uint `gorm:"primaryKey;autoIncrement"`
+	// Fingerprint identifies the operation and the arguments that are signed,
+	// apart from nonces, to match retried sign calls to the request.
+	Fingerprint string        `gorm:"column:fingerprint;not null;index"`
+	Operation   SignOperation `gorm:"column:operation;not null"`
+	// DataSet is the client data set ID, the nonce of create-dataset
+	// requests.
+	DataSet string `gorm:"column:data_set"`
+	// Payee is the address paid for the data set of create-dataset requests.
+	Payee string `gorm:"column:payee"`
+	// Pieces are the CIDs of the pieces added, or the IDs of those removed.
+	Pieces datatypes.JSON `gorm:"column:pieces"`
+	// Size is the padded size of the pieces added, 0 if unknown.
+	Size     uint64         `gorm:"column:size"`
+	Metadata datatypes.JSON `gorm:"column:metadata"`
+
+	Status    SignStatus `gorm:"column:status;not null;index"`
+	Reason    string     `gorm:"column:reason"`
+	CreatedAt time.Time  `gorm:"column:created_at;not null"`
+	DecidedAt *time.Time `gorm:"column:decided_at"`
+}
+
+func (SignRequest) TableName() string {
+	return "sign_requests"
+}
+
+// Alert is a condition the operator is notified of, raised by the alerter.
+// It is the JSON body posted to webhooks.
+type Alert struct {
+	ID uint `gorm:"primaryKey;autoIncrement" json:"-"`
+	// Key identifies the condition that raised the alert, an alert is raised
+	// once per key.
+	Key       string `gorm:"column:alert_key;not null;uniqueIndex" json:"key"`
+	Kind      string `gorm:"column:kind;not null;index" json:"kind"`
+	DataSetID int64  `gorm:"column:data_set_id" json:"data_set_id,omitempty"`
+	RailID    string `gorm:"column:rail_id" json:"rail_id,omitempty"`
+	TxHash    string `gorm:"column:tx_hash" json:"tx_hash,omitempty"`
+	// Epoch is the proving deadline of a proving_deadline alert, the block of
+	// the event of a fault_record alert.
+	Epoch   int64     `gorm:"column:epoch" json:"epoch,omitempty"`
+	Message string    `gorm:"column:message;not null" json:"message"`
+	FiredAt time.Time `gorm:"column:fired_at;not null" json:"fired_at"`
+
+	Attempts      int        `gorm:"column:attempts;not null" json:"-"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;not null" json:"-"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at" json:"-"`
+	LastError     string     `gorm:"column:last_error" json:"-"`
+}
+
+func (Alert) TableName() string {
+	return "alerts"
+}
+
+// AlertRules holds the alert rules set through the admin API. There is a
+// single row, absent while the configured rules apply.
+type AlertRules struct {
+	ID                 uint  `gorm:"primaryKey"`
+	DeadlineEpochs     int64 `gorm:"column:deadline_epochs;not null"`
+	FaultRecords       bool  `gorm:"column:fault_records;not null"`
+	SettlementFailures bool  `gorm:"column:settlement_failures;not null"`
+	UpdatedAt          time.Time
+}
+
+func (AlertRules) TableName() string {
+	return "alert_rules"
+}
+
+// AlertState records what the alerter has checked. There is a single row.
+type AlertState struct {
+	ID uint `gorm:"primaryKey"`
+	// Since is when alerting first started, failed settlements sent before
+	// are not alerted on.
+	Since time.Time `gorm:"column:since;not null"`
+	// LastChainEventID is the last chain event checked for fault records.
+	LastChainEventID uint `gorm:"column:last_chain_event_id;not null"`
+}
+
+func (AlertState) TableName() string {
+	return "alert_state"
+}
+
+// CurioPieceUpload is a piece streamed to the Curio node proving proof sets
+// of the node.
+type CurioPieceUpload struct {
+	PieceCID string `gorm:"primaryKey;column:piece_cid"`
+	UploadID string `gorm:"not null;index"`
+	// UploadedAt is set once the bytes of the piece are uploaded.
+	UploadedAt *time.Time
+	// ParkedAt is set once Curio notifies the node the piece is parked.
+	ParkedAt  *time.Time
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;not null"`
+}
+
+func (CurioPieceUpload) TableName() string {
+	return "curio_piece_uploads"
+}
+
+// AllocationRecord is a blob allocation of the SQL allocation store. The
+// primary key is (digest, space), so there is a single allocation of a blob
+// per space.
+type AllocationRecord struct {
+	Digest  []byte `gorm:"primaryKey;column:digest;index:idx_allocations_digest;index:idx_allocations_space_digest,priority:2"`
+	Space   string `gorm:"primaryKey;column:space;index:idx_allocations_space_digest,priority:1"`
+	Size    int64  `gorm:"not null;column:size"`
+	Expires int64  `gorm:"not null;column:expires;index:idx_allocations_expires"`
+	Cause   string `gorm:"not null;column:cause"`
+}
+
+func (AllocationRecord) TableName() string {
+	return "allocations"
+}
+
 func Ptr[T any](v T) *T {
 	return &v
 }
@@ -349,17 +578,30 @@ func AutoMigrateDB(ctx context.Context, db *gorm.DB) error {
 			&PDPPieceRef{},
 			&PDPProofSet{},
 			&PDPProveTask{},
+			&PDPProveWait{},
 			&PDPProofsetCreate{},
 			&PDPProofsetRoot{},
 			&PDPProofsetRootAdd{},
 			&PDPPieceMHToCommp{},
 			&PDPProviderRegistration{},
+			&PDPManagedProofSet{},
+			&PDPAggregateProofSet{},
 
 			&MessageSendsEth{},
 			&MessageSendEthLock{},
 			&MessageWaitsEth{},
+			&MessageReplacementsEth{},
 			&RailSettlementWaits{},
 			&WithdrawalWaits{},
+			&GasBaseFeeSample{},
+			&ChainEvent{},
+			&ChainEventCursor{},
+			&SignRequest{},
+			&Alert{},
+			&AlertRules{},
+			&AlertState{},
+			&CurioPieceUpload{},
+			&AllocationRecord{},
 		); err != nil {
 		return fmt.Errorf("failed to auto migrate database: %s", err)
 	}
@@ -4,15 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	commcid "github.com/filecoin-project/go-fil-commcid"
 	commp "github.com/filecoin-project/go-fil-commp-hashhash"
 	"github.com/ipfs/go-cid"
 	"github.com/multiformats/go-multicodec"
 	"github.com/multiformats/go-multihash"
-	"github.com/storacha/piri/pkg/pdp/service/models"
 	"github.com/storacha/piri/pkg/pdp/types"
-	"gorm.io/gorm/clause"
 )
 
 func (p *PDPService) CalculateCommP(ctx context.Context, blob multihash.Multihash) (types.CalculateCommPResponse, error) {
@@ -21,21 +20,10 @@ func (p *PDPService) CalculateCommP(ctx context.Context, blob multihash.Multihas
 	// use singleflight to prevent duplicate commp calculations
 	v, err, _ := p.commPGroup.Do(key, func() (interface{}, error) {
 		// 1. check if we have already calculated commp for this piece
-		var existing models.PDPPieceMHToCommp
-		if err := p.db.WithContext(ctx).First(&existing, "mhash = ?", blob).Error; err == nil {
-			pieceCID, err := cid.Parse(existing.Commp)
-			if err != nil {
-				return types.CalculateCommPResponse{}, fmt.Errorf("failed to parse existing commp cid %s: %w", existing.Commp, err)
-			}
-			treeHeight, _, err := commcid.PayloadSizeToV1TreeHeightAndPadding(uint64(existing.Size))
-			if err != nil {
-				return types.CalculateCommPResponse{}, err
-			}
-			return types.CalculateCommPResponse{
-				PieceCID:   pieceCID,
-				RawSize:    int64(existing.Size),
-				PaddedSize: int64(32) << treeHeight,
-			}, nil
+		if res, ok, err := p.commPCache.get(ctx, blob); err != nil {
+			return types.CalculateCommPResponse{}, err
+		} else if ok {
+			return res, nil
 		}
 		// 2. calculate commp since we don't have it yet
 		readObj, err := p.pieceReader.Read(ctx, blob)
@@ -44,28 +32,26 @@ func (p *PDPService) CalculateCommP(ctx context.Context, blob multihash.Multihas
 		}
 		defer readObj.Data.Close()
 
-		pieceCID, paddedSize, err := doCommp(blob, readObj.Data, uint64(readObj.Size))
+		pieceCID, paddedSize, err := p.computeCommp(ctx, blob, readObj.Data, uint64(readObj.Size))
 		if err != nil {
 			return types.CalculateCommPResponse{}, err
 		}
 
-		// 3. insert into pdp_piece_mh_to_commp to avoid recalculation
+		res := types.CalculateCommPResponse{
+			PieceCID:   pieceCID,
+			RawSize:    readObj.Size,
+			PaddedSize: int64(paddedSize),
+		}
+
+		// 3. record the commp to avoid recalculation, unless the blob is
+		// addressed by it
 		if pieceCID.Hash().HexString() != blob.HexString() {
-			mhToCommp := models.PDPPieceMHToCommp{
-				Mhash: blob,
-				Size:  int64(readObj.Size),
-				Commp: pieceCID.String(),
-			}
-			if err := p.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&mhToCommp).Error; err != nil {
-				return types.CalculateCommPResponse{}, fmt.Errorf("failed to insert into %s: %w", mhToCommp.TableName(), err)
+			if err := p.commPCache.put(ctx, blob, res); err != nil {
+				return types.CalculateCommPResponse{}, err
 			}
 		}
 
-		return types.CalculateCommPResponse{
-			PieceCID:   pieceCID,
-			RawSize:    readObj.Size,
-			PaddedSize: int64(paddedSize),
-		}, nil
+		return res, nil
 	})
 
 	if err != nil {
@@ -75,6 +61,44 @@ func (p *PDPService) CalculateCommP(ctx context.Context, blob multihash.Multihas
 	return v.(types.CalculateCommPResponse), nil
 }
 
+// computeCommp calculates the commP of a blob within the memory budget,
+// recording the rate it was hashed at. Blobs addressed by their piece
+// commitment are not hashed.
+func (p *PDPService) computeCommp(ctx context.Context, blob multihash.Multihash, data io.Reader, size uint64) (cid.Cid, uint64, error) {
+	if isPieceDigest(blob) {
+		return doCommp(blob, data, size)
+	}
+	release, err := p.commPBudget.acquire(ctx, size)
+	if err != nil {
+		return cid.Undef, 0, fmt.Errorf("waiting for commp memory budget: %w", err)
+	}
+	defer release()
+
+	start := time.Now()
+	pieceCID, paddedSize, err := doCommp(blob, data, size)
+	if err != nil {
+		return cid.Undef, 0, err
+	}
+	if m := commPMetricsFor(); m != nil {
+		elapsed := time.Since(start)
+		m.bytes.Add(ctx, int64(size))
+		m.duration.Record(ctx, elapsed)
+		if elapsed > 0 {
+			m.throughput.Record(ctx, int64(float64(size)/elapsed.Seconds()))
+		}
+	}
+	return pieceCID, paddedSize, nil
+}
+
+func isPieceDigest(blob multihash.Multihash) bool {
+	piece, err := multihash.Decode(blob)
+	if err != nil {
+		return false
+	}
+	return piece.Code == uint64(multicodec.Sha2_256Trunc254Padded) ||
+		piece.Code == uint64(multicodec.Fr32Sha256Trunc254Padbintree)
+}
+
 func doCommp(blob multihash.Multihash, data io.Reader, size uint64) (cid.Cid, uint64, error) {
 	piece, err := multihash.Decode(blob)
 	if err != nil {
@@ -111,7 +135,11 @@ func doCommp(blob multihash.Multihash, data io.Reader, size uint64) (cid.Cid, ui
 	default:
 		// need to calculate commp
 		cp := &commp.Calc{}
-		written, err := io.Copy(cp, data)
+		buf := commPReadBuffers.Get().(*[]byte)
+		// hide any WriterTo of the reader, which would copy through a small
+		// buffer of its own
+		written, err := io.CopyBuffer(cp, struct{ io.Reader }{data}, *buf)
+		commPReadBuffers.Put(buf)
 		if err != nil {
 			return cid.Undef, 0, err
 		}
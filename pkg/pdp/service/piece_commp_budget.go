@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	// DefaultCommPMemoryBudget is the memory concurrent commP calculations
+	// may hold if no budget is configured.
+	DefaultCommPMemoryBudget = 256 << 20
+	// commPReadBufferSize is the size of the reads of a blob being hashed. It
+	// is a multiple of the 127 byte blocks the calculator consumes, so writes
+	// are hashed without being copied to its carry buffer.
+	commPReadBufferSize = 8192 * 127
+	// commPHasherMemory bounds the memory a calculator holds in the queues of
+	// its tree layers.
+	commPHasherMemory = 2 << 20
+)
+
+var commPReadBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, commPReadBufferSize)
+		return &buf
+	},
+}
+
+// commPMemory is the memory reserved to calculate the commP of a blob of the
+// size: a read buffer, and layer queues that fill up to their bound on large
+// blobs.
+func commPMemory(size uint64) int64 {
+	return int64(commPReadBufferSize + min(2*size, commPHasherMemory))
+}
+
+// commPBudget bounds the memory held by concurrent commP calculations. The
+// job queue runs as many calculations as it has workers, those that would
+// exceed the budget wait for others to complete.
+type commPBudget struct {
+	sem   *semaphore.Weighted
+	total int64
+}
+
+func newCommPBudget(total uint64) *commPBudget {
+	if total == 0 {
+		total = DefaultCommPMemoryBudget
+	}
+	return &commPBudget{sem: semaphore.NewWeighted(int64(total)), total: int64(total)}
+}
+
+// acquire reserves the memory to calculate the commP of a blob of the size,
+// and returns the func releasing it. A calculation needing more than the
+// whole budget reserves all of it. A nil budget is unbounded.
+func (b *commPBudget) acquire(ctx context.Context, size uint64) (func(), error) {
+	if b == nil {
+		return func() {}, nil
+	}
+	weight := min(commPMemory(size), b.total)
+	m := commPMetricsFor()
+	start := time.Now()
+	if err := b.sem.Acquire(ctx, weight); err != nil {
+		return nil, err
+	}
+	if m != nil {
+		m.budgetWait.Record(ctx, time.Since(start))
+		m.reserved.Add(ctx, weight)
+	}
+	return func() {
+		b.sem.Release(weight)
+		if m != nil {
+			m.reserved.Add(context.Background(), -weight)
+		}
+	}, nil
+}
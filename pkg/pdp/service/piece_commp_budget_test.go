@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommPBudget(t *testing.T) {
+	large := uint64(1 << 30)
+	// room for two calculations over large blobs
+	b := newCommPBudget(uint64(2 * commPMemory(large)))
+
+	release1, err := b.acquire(t.Context(), large)
+	require.NoError(t, err)
+	release2, err := b.acquire(t.Context(), large)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	_, err = b.acquire(ctx, large)
+	require.ErrorIs(t, err, context.DeadlineExceeded, "a third calculation should wait for the budget")
+
+	acquired := make(chan func())
+	go func() {
+		release, err := b.acquire(t.Context(), large)
+		if err == nil {
+			acquired <- release
+		}
+	}()
+	release1()
+	select {
+	case release := <-acquired:
+		release()
+	case <-time.After(5 * time.Second):
+		t.Fatal("calculation did not start once the budget was released")
+	}
+	release2()
+
+	t.Run("larger than the budget", func(t *testing.T) {
+		b := newCommPBudget(1 << 20)
+		release, err := b.acquire(t.Context(), large)
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("small blobs reserve less", func(t *testing.T) {
+		require.Less(t, commPMemory(1024), commPMemory(large))
+	})
+
+	t.Run("nil budget is unbounded", func(t *testing.T) {
+		var b *commPBudget
+		release, err := b.acquire(t.Context(), large)
+		require.NoError(t, err)
+		release()
+	})
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// commPCacheSize is the number of piece commitments kept in memory in front
+// of the pdp_piece_mh_to_commp table. An entry is a blob digest and a piece
+// CID of ~100 bytes each, ~20MiB when full.
+const commPCacheSize = 100_000
+
+// commPCache maps the digest of a blob to its piece commitment, so the commP
+// of a blob is calculated once whether it is requested again by a retried
+// aggregation task, a re-aggregation or a pdp/info invocation. Entries are
+// persisted to the pdp_piece_mh_to_commp table, recently used ones are also
+// kept in memory.
+type commPCache struct {
+	db  *gorm.DB
+	lru *lru.Cache[string, types.CalculateCommPResponse]
+}
+
+func newCommPCache(db *gorm.DB) (*commPCache, error) {
+	c, err := lru.New[string, types.CalculateCommPResponse](commPCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("creating commp cache: %w", err)
+	}
+	return &commPCache{db: db, lru: c}, nil
+}
+
+// get returns the piece commitment of the blob, false if it has not been
+// calculated yet.
+func (c *commPCache) get(ctx context.Context, blob multihash.Multihash) (types.CalculateCommPResponse, bool, error) {
+	if res, ok := c.lru.Get(blob.String()); ok {
+		c.record(ctx, "hit")
+		return res, true, nil
+	}
+
+	var existing models.PDPPieceMHToCommp
+	if err := c.db.WithContext(ctx).First(&existing, "mhash = ?", blob).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.record(ctx, "miss")
+			return types.CalculateCommPResponse{}, false, nil
+		}
+		return types.CalculateCommPResponse{}, false, fmt.Errorf("reading commp of blob %s: %w", blob, err)
+	}
+	pieceCID, err := cid.Parse(existing.Commp)
+	if err != nil {
+		return types.CalculateCommPResponse{}, false, fmt.Errorf("failed to parse existing commp cid %s: %w", existing.Commp, err)
+	}
+	res, err := commPResponse(pieceCID, existing.Size)
+	if err != nil {
+		return types.CalculateCommPResponse{}, false, err
+	}
+	c.lru.Add(blob.String(), res)
+	c.record(ctx, "hit")
+	return res, true, nil
+}
+
+// put records the piece commitment of the blob.
+func (c *commPCache) put(ctx context.Context, blob multihash.Multihash, res types.CalculateCommPResponse) error {
+	if err := c.insert(c.db.WithContext(ctx), blob, res.PieceCID, res.RawSize); err != nil {
+		return err
+	}
+	c.lru.Add(blob.String(), res)
+	return nil
+}
+
+// insert persists the piece commitment of the blob with tx, e.g. in the
+// transaction of an upload. Blobs whose commitment is known are skipped.
+func (c *commPCache) insert(tx *gorm.DB, blob multihash.Multihash, pieceCID cid.Cid, rawSize int64) error {
+	if _, ok := c.lru.Peek(blob.String()); ok {
+		return nil
+	}
+	mhToCommp := models.PDPPieceMHToCommp{
+		Mhash: blob,
+		Size:  rawSize,
+		Commp: pieceCID.String(),
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&mhToCommp).Error; err != nil {
+		return fmt.Errorf("failed to insert into %s: %w", mhToCommp.TableName(), err)
+	}
+	return nil
+}
+
+func (c *commPCache) record(ctx context.Context, result string) {
+	if m := commPMetricsFor(); m != nil {
+		m.cacheLookups.Inc(ctx, attribute.String("result", result))
+	}
+}
+
+// commPResponse returns the piece commitment of a blob of the raw size.
+func commPResponse(pieceCID cid.Cid, rawSize int64) (types.CalculateCommPResponse, error) {
+	treeHeight, _, err := commcid.PayloadSizeToV1TreeHeightAndPadding(uint64(rawSize))
+	if err != nil {
+		return types.CalculateCommPResponse{}, err
+	}
+	return types.CalculateCommPResponse{
+		PieceCID:   pieceCID,
+		RawSize:    rawSize,
+		PaddedSize: int64(32) << treeHeight,
+	}, nil
+}
@@ -70,6 +70,12 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	return db
 }
 
+func newTestCommPCache(t *testing.T, db *gorm.DB) *commPCache {
+	c, err := newCommPCache(db)
+	require.NoError(t, err)
+	return c
+}
+
 func TestCalculateCommP_Singleflight(t *testing.T) {
 	db := setupTestDB(t)
 	synctest.Test(t, func(t *testing.T) {
@@ -105,6 +111,7 @@ func TestCalculateCommP_Singleflight(t *testing.T) {
 			db:          db,
 			pieceReader: reader,
 			commPGroup:  singleflight.Group{},
+			commPCache:  newTestCommPCache(t, db),
 		}
 
 		// Launch multiple concurrent calls to CalculateCommP with the same blob
@@ -170,6 +177,7 @@ func TestCalculateCommP_DifferentBlobs(t *testing.T) {
 			db:          db,
 			pieceReader: reader,
 			commPGroup:  singleflight.Group{},
+			commPCache:  newTestCommPCache(t, db),
 		}
 
 		// Launch concurrent calls for DIFFERENT blobs
@@ -220,6 +228,7 @@ func TestCalculateCommP_DatabaseCache(t *testing.T) {
 		db:          db,
 		pieceReader: reader,
 		commPGroup:  singleflight.Group{},
+		commPCache:  newTestCommPCache(t, db),
 	}
 
 	// First call - should calculate and cache
@@ -238,6 +247,13 @@ func TestCalculateCommP_DatabaseCache(t *testing.T) {
 
 	// pieceReader should still only have been called once (second call used DB cache)
 	require.Equal(t, int32(1), reader.callCount.Load())
+
+	// the commp survives a restart, losing the entries kept in memory
+	service.commPCache = newTestCommPCache(t, db)
+	result3, err := service.CalculateCommP(ctx, blob)
+	require.NoError(t, err)
+	require.Equal(t, result1, result3)
+	require.Equal(t, int32(1), reader.callCount.Load())
 }
 
 func TestCalculateCommP_ConcurrentWithCache(t *testing.T) {
@@ -259,6 +275,7 @@ func TestCalculateCommP_ConcurrentWithCache(t *testing.T) {
 			db:          db,
 			pieceReader: reader,
 			commPGroup:  singleflight.Group{},
+			commPCache:  newTestCommPCache(t, db),
 		}
 
 		// First, populate the cache
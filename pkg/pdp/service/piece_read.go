@@ -3,8 +3,12 @@ package service
 import (
 	"context"
 	"errors"
+	"io"
 
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
 	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/piece/piece"
 
 	"github.com/storacha/piri/pkg/pdp/types"
 	"github.com/storacha/piri/pkg/store"
@@ -37,3 +41,75 @@ func (p *PDPService) Has(ctx context.Context, blob multihash.Multihash) (bool, e
 	}
 	return has, nil
 }
+
+// ReadPiece returns a PieceReader for the piece with the given v2 piece CID.
+// The piece is read as its unpadded bytes: the blob it was derived from,
+// followed by the zero padding up to the unpadded size of the piece. The Size
+// of the reader is the unpadded size of the piece, whatever the range read.
+func (p *PDPService) ReadPiece(ctx context.Context, pieceCID cid.Cid, options ...types.ReadPieceOption) (*types.PieceReader, error) {
+	cfg := types.ReadPieceConfig{}
+	cfg.ProcessOptions(options)
+
+	pl, err := piece.FromLink(cidlink.Link{Cid: pieceCID})
+	if err != nil {
+		return nil, types.WrapError(types.KindInvalidInput, "invalid piece CID, expected a v2 piece CID", err)
+	}
+	blob, found, err := p.ResolveToBlob(ctx, pieceCID.Hash())
+	if err != nil {
+		return nil, types.WrapError(types.KindInternal, "failed to resolve piece", err)
+	}
+	if !found {
+		return nil, types.NewError(types.KindNotFound, "piece not found")
+	}
+
+	size := pl.PaddedSize() - pl.PaddedSize()/128
+	if pl.Padding() > size {
+		return nil, types.NewErrorf(types.KindInvalidInput, "piece padding %d exceeds piece size %d", pl.Padding(), size)
+	}
+	dataSize := size - pl.Padding()
+
+	start := cfg.ByteRange.Start
+	end := size - 1
+	if cfg.ByteRange.End != nil && *cfg.ByteRange.End < end {
+		end = *cfg.ByteRange.End
+	}
+	if start > end {
+		return nil, types.NewErrorf(types.KindInvalidInput, "range %d-%d not satisfiable for piece of %d bytes", start, end, size)
+	}
+
+	var readers []io.Reader
+	var closer io.Closer = io.NopCloser(nil)
+	if start < dataSize {
+		dataEnd := min(end, dataSize-1)
+		pr, err := p.Read(ctx, blob, types.WithRange(start, &dataEnd))
+		if err != nil {
+			return nil, err
+		}
+		if uint64(pr.Size) != dataSize {
+			pr.Data.Close()
+			return nil, types.NewErrorf(types.KindInternal, "blob %s is %d bytes, piece expects %d", blob, pr.Size, dataSize)
+		}
+		readers = append(readers, pr.Data)
+		closer = pr.Data
+	}
+	if end >= dataSize {
+		padStart := max(start, dataSize)
+		readers = append(readers, io.LimitReader(zeros{}, int64(end-padStart+1)))
+	}
+
+	return &types.PieceReader{
+		Size: int64(size),
+		Data: struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(readers...), closer},
+	}, nil
+}
+
+// zeros reads an endless stream of zero bytes.
+type zeros struct{}
+
+func (zeros) Read(b []byte) (int, error) {
+	clear(b)
+	return len(b), nil
+}
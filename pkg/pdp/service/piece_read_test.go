@@ -0,0 +1,116 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	commp "github.com/filecoin-project/go-fil-commp-hashhash"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/store"
+)
+
+type memPieceReader struct {
+	blobs map[string][]byte
+}
+
+func (m memPieceReader) Read(_ context.Context, blob multihash.Multihash, options ...types.ReadPieceOption) (*types.PieceReader, error) {
+	cfg := types.ReadPieceConfig{}
+	cfg.ProcessOptions(options)
+	data, ok := m.blobs[blob.String()]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	end := uint64(len(data)) - 1
+	if cfg.ByteRange.End != nil {
+		end = *cfg.ByteRange.End
+	}
+	return &types.PieceReader{
+		Size: int64(len(data)),
+		Data: io.NopCloser(bytes.NewReader(data[cfg.ByteRange.Start : end+1])),
+	}, nil
+}
+
+func (m memPieceReader) Has(_ context.Context, blob multihash.Multihash) (bool, error) {
+	_, ok := m.blobs[blob.String()]
+	return ok, nil
+}
+
+type memPieceResolver struct {
+	blobs map[string]multihash.Multihash
+}
+
+func (m memPieceResolver) Resolve(ctx context.Context, data multihash.Multihash) (multihash.Multihash, bool, error) {
+	return m.ResolveToBlob(ctx, data)
+}
+
+func (m memPieceResolver) ResolveToPiece(context.Context, multihash.Multihash) (multihash.Multihash, bool, error) {
+	return nil, false, nil
+}
+
+func (m memPieceResolver) ResolveToBlob(_ context.Context, piece multihash.Multihash) (multihash.Multihash, bool, error) {
+	blob, ok := m.blobs[piece.String()]
+	return blob, ok, nil
+}
+
+func TestReadPiece(t *testing.T) {
+	data := testutil.RandomBytes(t, 1000)
+	blob := testutil.MultihashFromBytes(t, data)
+	c := &commp.Calc{}
+	_, err := c.Write(data)
+	require.NoError(t, err)
+	digest, _, err := c.Digest()
+	require.NoError(t, err)
+	pieceCID, err := commcid.DataCommitmentToPieceCidv2(digest, uint64(len(data)))
+	require.NoError(t, err)
+
+	svc := &PDPService{
+		pieceReader:   memPieceReader{blobs: map[string][]byte{blob.String(): data}},
+		pieceResolver: memPieceResolver{blobs: map[string]multihash.Multihash{pieceCID.Hash().String(): blob}},
+	}
+	// 1000 bytes pad to a 1024 byte piece, 1016 bytes unpadded
+	expected := append(bytes.Clone(data), make([]byte, 16)...)
+
+	read := func(t *testing.T, options ...types.ReadPieceOption) []byte {
+		t.Helper()
+		pr, err := svc.ReadPiece(t.Context(), pieceCID, options...)
+		require.NoError(t, err)
+		defer pr.Data.Close()
+		require.Equal(t, int64(len(expected)), pr.Size)
+		b, err := io.ReadAll(pr.Data)
+		require.NoError(t, err)
+		return b
+	}
+
+	t.Run("reads the blob and its padding", func(t *testing.T) {
+		require.Equal(t, expected, read(t))
+	})
+
+	t.Run("reads ranges across the padding", func(t *testing.T) {
+		end := uint64(1009)
+		require.Equal(t, expected[990:1010], read(t, types.WithRange(990, &end)))
+		require.Equal(t, expected[1005:], read(t, types.WithRange(1005, nil)))
+		end = 99
+		require.Equal(t, expected[10:100], read(t, types.WithRange(10, &end)))
+	})
+
+	t.Run("rejects ranges past the end", func(t *testing.T) {
+		_, err := svc.ReadPiece(t.Context(), pieceCID, types.WithRange(uint64(len(expected)), nil))
+		require.Error(t, err)
+	})
+
+	t.Run("unknown pieces are not found", func(t *testing.T) {
+		other, err := commcid.DataCommitmentToPieceCidv2(testutil.RandomBytes(t, 32), 1000)
+		require.NoError(t, err)
+		_, err = svc.ReadPiece(t.Context(), other)
+		var tErr *types.Error
+		require.ErrorAs(t, err, &tErr)
+		require.Equal(t, types.KindNotFound, tErr.Kind())
+	})
+}
@@ -6,10 +6,10 @@ import (
 	"fmt"
 
 	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/google/uuid"
 	"github.com/hashicorp/go-multierror"
 	"github.com/multiformats/go-multicodec"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 
 	"github.com/storacha/piri/lib/verifyread"
 	"github.com/storacha/piri/pkg/pdp/piece"
@@ -21,6 +21,19 @@ import (
 	"github.com/storacha/piri/pkg/pdp/types"
 )
 
+// PieceUploadDigest returns the digest of the blob expected by a pending
+// upload.
+func (p *PDPService) PieceUploadDigest(ctx context.Context, id uuid.UUID) (multihash.Multihash, error) {
+	var upload models.PDPPieceUpload
+	if err := p.db.WithContext(ctx).Select("check_hash").First(&upload, "id = ?", id.String()).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.NewErrorf(types.KindNotFound, "upload ID %s not found", id)
+		}
+		return nil, types.WrapError(types.KindInternal, "failed to query for piece upload", err)
+	}
+	return upload.CheckHash, nil
+}
+
 func (p *PDPService) UploadPiece(ctx context.Context, pieceUpload types.PieceUpload) (retErr error) {
 	var upload models.PDPPieceUpload
 	if err := p.db.First(&upload, "id = ?", pieceUpload.ID.String()).Error; err != nil {
@@ -57,14 +70,10 @@ func (p *PDPService) UploadPiece(ctx context.Context, pieceUpload types.PieceUpl
 			return types.WrapError(types.KindInternal, fmt.Sprintf("failed to delete piece upload ID %s from pdp_piece_uploads", upload.ID), err)
 		}
 
-		// if the upload was done with commp create a mapping for it now
+		// if the upload was done with commp record it now, so it is never
+		// calculated
 		if upload.CheckHashCodec == multicodec.Fr32Sha256Trunc254Padbintree.String() {
-			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
-				Create(&models.PDPPieceMHToCommp{
-					Mhash: upload.CheckHash,
-					Size:  upload.CheckSize,
-					Commp: piece.MultihashToCommpCID(upload.CheckHash).String(),
-				}).Error; err != nil {
+			if err := p.commPCache.insert(tx, upload.CheckHash, piece.MultihashToCommpCID(upload.CheckHash), upload.CheckSize); err != nil {
 				return types.WrapError(types.KindInternal, "failed to create pieceMH to commp", err)
 			}
 		} else if upload.CheckHashCodec == multicodec.Sha2_256Trunc254Padded.String() {
@@ -76,12 +85,7 @@ func (p *PDPService) UploadPiece(ctx context.Context, pieceUpload types.PieceUpl
 			if err != nil {
 				return fmt.Errorf("failed to convert pieceCid %s from v1 to v2: %w", pv1, err)
 			}
-			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
-				Create(&models.PDPPieceMHToCommp{
-					Mhash: upload.CheckHash,
-					Size:  upload.CheckSize,
-					Commp: piece.MultihashToCommpCID(pieceCID.Hash()).String(),
-				}).Error; err != nil {
+			if err := p.commPCache.insert(tx, upload.CheckHash, piece.MultihashToCommpCID(pieceCID.Hash()), upload.CheckSize); err != nil {
 				return types.WrapError(types.KindInternal, "failed to create pieceMH to commp", err)
 			}
 		}
@@ -31,22 +31,10 @@ func (p *PDPService) CreateProofSet(ctx context.Context) (res common.Hash, retEr
 		return common.Hash{}, err
 	}
 
-	nonceBytes := make([]byte, 32)
-	if _, err := rand.Read(nonceBytes); err != nil {
-		return common.Hash{}, fmt.Errorf("failed to generate nonce: %w", err)
-	}
-	nonce := new(big.Int).SetBytes(nonceBytes)
-
 	var metadataEntries []eip712.MetadataEntry
-	// request a signature for creating the dataset from the signing service
-	signature, err := p.signingService.SignCreateDataSet(ctx,
-		p.id,
-		nonce,
-		p.address, // Use the nodes address as the address receiving payment for storage
-		metadataEntries,
-	)
+	nonce, signature, err := p.signCreateDataSet(ctx, metadataEntries)
 	if err != nil {
-		return common.Hash{}, fmt.Errorf("failed to sign CreateDataSet: %w", err)
+		return common.Hash{}, err
 	}
 
 	// Encode the extraData with payer, metadata, and signature
@@ -113,3 +101,31 @@ func (p *PDPService) CreateProofSet(ctx context.Context) (res common.Hash, retEr
 
 	return txHash, nil
 }
+
+// signCreateDataSet requests a signature for creating a data set from the
+// signing service. The client data set ID is kept until it is signed, so a
+// retry of a creation held for approval matches the approved request.
+func (p *PDPService) signCreateDataSet(ctx context.Context, metadataEntries []eip712.MetadataEntry) (*big.Int, *eip712.AuthSignature, error) {
+	p.createNonceMu.Lock()
+	defer p.createNonceMu.Unlock()
+
+	if p.createNonce == nil {
+		nonceBytes := make([]byte, 32)
+		if _, err := rand.Read(nonceBytes); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		p.createNonce = new(big.Int).SetBytes(nonceBytes)
+	}
+	signature, err := p.signingService.SignCreateDataSet(ctx,
+		p.id,
+		p.createNonce,
+		p.address, // Use the nodes address as the address receiving payment for storage
+		metadataEntries,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign CreateDataSet: %w", err)
+	}
+	nonce := p.createNonce
+	p.createNonce = nil
+	return nonce, signature, nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/pdp/piece"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/types"
+)
+
+// PieceRoot finds the proof set root a blob was aggregated into, with the
+// blobs of all the subroots of that root. It returns false if the blob has
+// not been added to a proof set.
+func (p *PDPService) PieceRoot(ctx context.Context, blob multihash.Multihash) (types.PieceRoot, bool, error) {
+	pieceHash, ok, err := p.ResolveToPiece(ctx, blob)
+	if err != nil {
+		return types.PieceRoot{}, false, fmt.Errorf("resolving blob to piece: %w", err)
+	}
+	if !ok {
+		return types.PieceRoot{}, false, nil
+	}
+
+	var root models.PDPProofsetRoot
+	if err := p.db.WithContext(ctx).
+		Where("subroot = ?", piece.MultihashToCommpCID(pieceHash).String()).
+		First(&root).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.PieceRoot{}, false, nil
+		}
+		return types.PieceRoot{}, false, fmt.Errorf("finding root of piece: %w", err)
+	}
+
+	var subroots []models.PDPProofsetRoot
+	if err := p.db.WithContext(ctx).
+		Where("proofset_id = ? AND root_id = ?", root.ProofsetID, root.RootID).
+		Order("subroot_offset").
+		Find(&subroots).Error; err != nil {
+		return types.PieceRoot{}, false, fmt.Errorf("listing subroots of root %d: %w", root.RootID, err)
+	}
+
+	res := types.PieceRoot{
+		ProofSetID: uint64(root.ProofsetID),
+		RootID:     uint64(root.RootID),
+		Root:       root.Root,
+		Blobs:      make([]multihash.Multihash, 0, len(subroots)),
+	}
+	for _, sr := range subroots {
+		c, err := cid.Decode(sr.Subroot)
+		if err != nil {
+			return types.PieceRoot{}, false, fmt.Errorf("decoding subroot %s: %w", sr.Subroot, err)
+		}
+		b, ok, err := p.ResolveToBlob(ctx, c.Hash())
+		if err != nil {
+			return types.PieceRoot{}, false, fmt.Errorf("resolving subroot %s to blob: %w", sr.Subroot, err)
+		}
+		if !ok {
+			return types.PieceRoot{}, false, fmt.Errorf("resolving subroot %s to blob: not found", sr.Subroot)
+		}
+		res.Blobs = append(res.Blobs, b)
+	}
+	return res, true, nil
+}
@@ -6,12 +6,14 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	"gorm.io/gorm"
 
 	"github.com/storacha/piri/pkg/pdp/service/models"
 )
 
+// RemoveRoot schedules the removal of a root from the proof set. The root is
+// removed from the proof set at the next proving period, after which its data
+// is no longer proven.
 func (p *PDPService) RemoveRoot(ctx context.Context, proofSetID uint64, rootID uint64) (res common.Hash, retErr error) {
 	log.Infow("removing root", "proofSetID", proofSetID, "rootID", rootID)
 	defer func() {
@@ -21,53 +23,45 @@ func (p *PDPService) RemoveRoot(ctx context.Context, proofSetID uint64, rootID u
 			log.Infow("removed root", "proofSetID", proofSetID, "rootID", rootID, "response", res)
 		}
 	}()
-	// Get the ABI and pack the transaction data
-	abiData, err := p.verifierContract.GetABI()
-	if err != nil {
-		return common.Hash{}, fmt.Errorf("get contract ABI: %w", err)
-	}
 
-	// TODO should probably check if we even have the proof set before scheduling a removal
+	var count int64
+	if err := p.db.WithContext(ctx).
+		Model(&models.PDPProofsetRoot{}).
+		Where("proofset_id = ? AND root_id = ?", proofSetID, rootID).
+		Count(&count).Error; err != nil {
+		return common.Hash{}, fmt.Errorf("checking root %d of proofset %d: %w", rootID, proofSetID, err)
+	}
+	if count == 0 {
+		return common.Hash{}, fmt.Errorf("root %d not found in proofset %d", rootID, proofSetID)
+	}
 
-	// TODO this will surely fail without extraData as a signature.
-	// Pack the method call data
-	data, err := abiData.Pack("schedulePieceDeletions",
-		big.NewInt(int64(proofSetID)),
-		[]*big.Int{big.NewInt(int64(rootID))},
-		[]byte{},
+	// the removal is authorized by a signature from the signing service,
+	// which the service contract checks when the verifier calls into it
+	call, err := p.warmStorage.SchedulePieceRemovals(ctx,
+		new(big.Int).SetUint64(proofSetID),
+		[]*big.Int{new(big.Int).SetUint64(rootID)},
 	)
 	if err != nil {
-		return common.Hash{}, fmt.Errorf("pack ABI method call: %w", err)
+		return common.Hash{}, fmt.Errorf("building schedulePieceDeletions call: %w", err)
 	}
 
-	// Prepare the transaction
-	ethTx := types.NewTransaction(
-		0, // nonce will be set by SenderETH
-		p.cfg.Contracts.Verifier,
-		big.NewInt(0), // value
-		0,             // gas limit (will be estimated)
-		nil,           // gas price (will be set by SenderETH)
-		data,
-	)
-
 	// Send the transaction
 	reason := "pdp-delete-root"
-	txHash, err := p.sender.Send(ctx, p.address, ethTx, reason)
+	txHash, err := p.sender.Send(ctx, p.address, call.Transaction(), reason)
 	if err != nil {
 		return common.Hash{}, fmt.Errorf("send transaction: %w", err)
 	}
 
-	// Schedule deletion of the root from the proof set using a transaction
+	// Track the transaction, the root is deleted from the database once the
+	// removal takes effect at the end of the proving period
 	if err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Insert into message_waits_eth
 		m := models.MessageWaitsEth{
-			SignedTxHash: txHash.String(),
+			SignedTxHash: txHash.Hex(),
 			TxStatus:     "pending",
 		}
-		tx.WithContext(ctx).Create(&m)
-		return nil
+		return tx.Create(&m).Error
 	}); err != nil {
-		return common.Hash{}, fmt.Errorf("shceduling delete root %d from proofset %d: %w", rootID, proofSetID, err)
+		return common.Hash{}, fmt.Errorf("scheduling delete root %d from proofset %d: %w", rootID, proofSetID, err)
 	}
 
 	return txHash, nil
@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"math/big"
 	"net/url"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
@@ -65,12 +68,20 @@ type PDPService struct {
 	engine         *scheduler.TaskEngine
 	signingService signer.SigningService
 
-	commPGroup singleflight.Group
+	// createNonce is the client data set ID of the data set creation waiting
+	// for a signature, reused by retries so they match its sign request.
+	createNonceMu sync.Mutex
+	createNonce   *big.Int
+
+	commPGroup  singleflight.Group
+	commPBudget *commPBudget
+	commPCache  *commPCache
 
 	edc              *eip712.ExtraDataEncoder
 	verifierContract smartcontracts.Verifier
 	serviceContract  smartcontracts.Service
 	registryContract smartcontracts.Registry
+	warmStorage      smartcontracts.WarmStorage
 
 	maxPieceSizeLog2Cache bigIntCache
 }
@@ -95,6 +106,14 @@ func New(
 	serviceContract smartcontracts.Service,
 	registryContract smartcontracts.Registry,
 ) (*PDPService, error) {
+	warmStorage, err := smartcontracts.NewWarmStorage(cfg.Contracts.Service, cfg.Contracts.Verifier, serviceContract, signingService, edc, id)
+	if err != nil {
+		return nil, fmt.Errorf("creating warm storage service contract: %w", err)
+	}
+	commPCache, err := newCommPCache(db)
+	if err != nil {
+		return nil, err
+	}
 	return &PDPService{
 		cfg:              cfg,
 		id:               id,
@@ -116,5 +135,8 @@ func New(
 		verifierContract: verifier,
 		serviceContract:  serviceContract,
 		registryContract: registryContract,
+		warmStorage:      warmStorage,
+		commPBudget:      newCommPBudget(cfg.Aggregation.CommP.MemoryBudget),
+		commPCache:       commPCache,
 	}, nil
 }
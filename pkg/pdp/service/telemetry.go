@@ -1,9 +1,128 @@
 package service
 
 import (
+	"sync"
+	"time"
+
 	"go.opentelemetry.io/otel"
+
+	"github.com/storacha/piri/lib/telemetry"
 )
 
 var (
 	tracer = otel.Tracer("github.com/storacha/piri/pkg/pdp/service")
 )
+
+// commPDurationBounds covers hashing small blobs up to the largest pieces.
+var commPDurationBounds = []float64{
+	(10 * time.Millisecond).Seconds(),
+	(50 * time.Millisecond).Seconds(),
+	(100 * time.Millisecond).Seconds(),
+	(500 * time.Millisecond).Seconds(),
+	(time.Second).Seconds(),
+	(5 * time.Second).Seconds(),
+	(10 * time.Second).Seconds(),
+	(30 * time.Second).Seconds(),
+	(time.Minute).Seconds(),
+	(5 * time.Minute).Seconds(),
+}
+
+// commPThroughputBounds covers a slow disk up to a fast core, in bytes per
+// second.
+var commPThroughputBounds = []float64{
+	1 << 20,
+	10 << 20,
+	50 << 20,
+	100 << 20,
+	200 << 20,
+	400 << 20,
+	800 << 20,
+	1600 << 20,
+}
+
+type commPMetrics struct {
+	bytes        *telemetry.Counter
+	duration     *telemetry.Timer
+	throughput   *telemetry.Int64Histogram
+	budgetWait   *telemetry.Timer
+	reserved     *telemetry.UpDownCounter
+	cacheLookups *telemetry.Counter
+}
+
+// commPMetricsFor returns the commP calculation metrics. It is nil if they
+// could not be created.
+var commPMetricsFor = sync.OnceValue(func() *commPMetrics {
+	m, err := newCommPMetrics()
+	if err != nil {
+		log.Warnw("creating commp metrics", "error", err)
+		return nil
+	}
+	return m
+})
+
+func newCommPMetrics() (*commPMetrics, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/pdp/service")
+	bytes, err := telemetry.NewCounter(
+		meter,
+		"piri_commp_bytes",
+		"bytes of blobs hashed to calculate their piece commitment",
+		"By",
+	)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := telemetry.NewTimer(
+		meter,
+		"piri_commp_duration",
+		"time spent reading and hashing a blob to calculate its piece commitment",
+		commPDurationBounds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	throughput, err := telemetry.NewInt64Histogram(
+		meter,
+		"piri_commp_throughput",
+		"rate a blob was read and hashed at to calculate its piece commitment",
+		"By/s",
+		commPThroughputBounds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	budgetWait, err := telemetry.NewTimer(
+		meter,
+		"piri_commp_budget_wait",
+		"time a piece commitment calculation waited for the memory budget",
+		commPDurationBounds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := telemetry.NewUpDownCounter(
+		meter,
+		"piri_commp_memory_reserved",
+		"memory of the budget reserved by running piece commitment calculations",
+		"By",
+	)
+	if err != nil {
+		return nil, err
+	}
+	cacheLookups, err := telemetry.NewCounter(
+		meter,
+		"piri_commp_cache_lookups",
+		"lookups of the piece commitment of a blob in the commp cache, by result (hit or miss)",
+		"",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &commPMetrics{
+		bytes:        bytes,
+		duration:     duration,
+		throughput:   throughput,
+		budgetWait:   budgetWait,
+		reserved:     reserved,
+		cacheLookups: cacheLookups,
+	}, nil
+}
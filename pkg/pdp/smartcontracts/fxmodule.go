@@ -4,6 +4,9 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/storacha/filecoin-services/go/eip712"
+	signertypes "github.com/storacha/piri-signing-service/pkg/types"
+
 	appconfig "github.com/storacha/piri/pkg/config/app"
 )
 
@@ -14,6 +17,7 @@ var Module = fx.Module("smartcontracts",
 		ProvideServiceValidator,
 		ProvideVerifierContract,
 		ProvidePayment,
+		ProvideWarmStorage,
 	),
 )
 
@@ -36,3 +40,13 @@ func ProvideVerifierContract(cfg appconfig.PDPServiceConfig, client bind.Contrac
 func ProvidePayment(cfg appconfig.PDPServiceConfig, client bind.ContractBackend) (Payment, error) {
 	return NewPaymentContract(cfg.Contracts.Payments, client)
 }
+
+func ProvideWarmStorage(
+	cfg appconfig.PDPServiceConfig,
+	id appconfig.IdentityConfig,
+	view Service,
+	signer signertypes.SigningService,
+	edc *eip712.ExtraDataEncoder,
+) (WarmStorage, error) {
+	return NewWarmStorage(cfg.Contracts.Service, cfg.Contracts.Verifier, view, signer, edc, id.Signer)
+}
@@ -0,0 +1,231 @@
+package smartcontracts
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ipfs/go-cid"
+	"github.com/storacha/filecoin-services/go/bindings"
+	"github.com/storacha/filecoin-services/go/eip712"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// WarmStorage builds the PDPVerifier calls that manage the lifecycle of data
+// sets operated by the FilecoinWarmStorageService contract. Each operation is
+// authorized by an EIP-712 signature obtained from the signing service, which
+// the service contract checks when the verifier calls back into it.
+//
+// The returned calls are not sent; submit them with an ethereum.Sender.
+type WarmStorage interface {
+	// CreateDataSet returns the call creating a data set paid for by payer,
+	// with payments for storage going to payee.
+	CreateDataSet(ctx context.Context, payer, payee common.Address, metadata []eip712.MetadataEntry) (*Call, error)
+	// AddPieces returns the call adding pieces to an existing data set.
+	AddPieces(ctx context.Context, dataSetID *big.Int, pieces []PieceAddition) (*Call, error)
+	// SchedulePieceRemovals returns the call scheduling the removal of pieces
+	// from a data set at the next proving period.
+	SchedulePieceRemovals(ctx context.Context, dataSetID *big.Int, pieceIDs []*big.Int) (*Call, error)
+	// DeleteDataSet returns the call deleting a data set.
+	DeleteDataSet(ctx context.Context, dataSetID *big.Int) (*Call, error)
+
+	// Address returns the service contract address
+	Address() common.Address
+}
+
+// WarmStorageSigner signs the FilecoinWarmStorageService operations. It is
+// satisfied by the signing service client.
+type WarmStorageSigner interface {
+	SignCreateDataSet(ctx context.Context, issuer ucan.Signer, dataSet *big.Int, payee common.Address, metadata []eip712.MetadataEntry, options ...delegation.Option) (*eip712.AuthSignature, error)
+	SignAddPieces(ctx context.Context, issuer ucan.Signer, dataSet *big.Int, firstAdded *big.Int, pieceData [][]byte, metadata [][]eip712.MetadataEntry, prfs [][]ipld.Link, prfData [][]message.AgentMessage, options ...delegation.Option) (*eip712.AuthSignature, error)
+	SignSchedulePieceRemovals(ctx context.Context, issuer ucan.Signer, dataSet *big.Int, pieceIds []*big.Int, options ...delegation.Option) (*eip712.AuthSignature, error)
+	SignDeleteDataSet(ctx context.Context, issuer ucan.Signer, dataSet *big.Int, options ...delegation.Option) (*eip712.AuthSignature, error)
+}
+
+// PieceAddition is a piece to add to a data set.
+type PieceAddition struct {
+	Piece    cid.Cid
+	Metadata []eip712.MetadataEntry
+	// Proofs are the tasks that justify storing the piece, presented to the
+	// signing service along with the messages holding them.
+	Proofs    []ipld.Link
+	ProofData []message.AgentMessage
+}
+
+// Call is a contract call ready to be sent.
+type Call struct {
+	To    common.Address
+	Value *big.Int
+	Data  []byte
+}
+
+// Transaction returns the call as a transaction. The nonce and gas are left
+// for the sender to fill in.
+func (c *Call) Transaction() *ethtypes.Transaction {
+	return ethtypes.NewTransaction(0, c.To, c.Value, 0, nil, c.Data)
+}
+
+type warmStorage struct {
+	address  common.Address
+	verifier common.Address
+	view     Service
+	signer   WarmStorageSigner
+	edc      *eip712.ExtraDataEncoder
+	issuer   ucan.Signer
+	abi      *abi.ABI
+}
+
+// NewWarmStorage creates a wrapper around the FilecoinWarmStorageService
+// contract at address, with data sets held by the PDPVerifier at verifier.
+// Signatures are requested from signer on behalf of issuer, the node identity.
+func NewWarmStorage(
+	address, verifier common.Address,
+	view Service,
+	signer WarmStorageSigner,
+	edc *eip712.ExtraDataEncoder,
+	issuer ucan.Signer,
+) (WarmStorage, error) {
+	verifierABI, err := bindings.PDPVerifierMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("getting verifier ABI: %w", err)
+	}
+	return &warmStorage{
+		address:  address,
+		verifier: verifier,
+		view:     view,
+		signer:   signer,
+		edc:      edc,
+		issuer:   issuer,
+		abi:      verifierABI,
+	}, nil
+}
+
+func (w *warmStorage) CreateDataSet(ctx context.Context, payer, payee common.Address, metadata []eip712.MetadataEntry) (*Call, error) {
+	// the nonce becomes the client data set ID, so it must never be reused
+	clientDataSetID, err := randomNonce()
+	if err != nil {
+		return nil, err
+	}
+	signature, err := w.signer.SignCreateDataSet(ctx, w.issuer, clientDataSetID, payee, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("signing CreateDataSet: %w", err)
+	}
+	extraData, err := w.edc.EncodeCreateDataSetExtraData(payer, clientDataSetID, metadata, signature)
+	if err != nil {
+		return nil, fmt.Errorf("encoding CreateDataSet extraData: %w", err)
+	}
+	data, err := w.abi.Pack("createDataSet", w.address, extraData)
+	if err != nil {
+		return nil, fmt.Errorf("packing createDataSet: %w", err)
+	}
+	return &Call{To: w.verifier, Value: SybilFee, Data: data}, nil
+}
+
+func (w *warmStorage) AddPieces(ctx context.Context, dataSetID *big.Int, pieces []PieceAddition) (*Call, error) {
+	if len(pieces) == 0 {
+		return nil, fmt.Errorf("no pieces to add")
+	}
+	info, err := w.view.GetDataSet(ctx, dataSetID)
+	if err != nil {
+		return nil, err
+	}
+
+	pieceData := make([]CidsCid, len(pieces))
+	pieceBytes := make([][]byte, len(pieces))
+	metadata := make([][]eip712.MetadataEntry, len(pieces))
+	proofs := make([][]ipld.Link, len(pieces))
+	proofData := make([][]message.AgentMessage, len(pieces))
+	for i, p := range pieces {
+		pieceData[i] = CidsCid{Data: p.Piece.Bytes()}
+		pieceBytes[i] = p.Piece.Bytes()
+		metadata[i] = p.Metadata
+		if metadata[i] == nil {
+			metadata[i] = []eip712.MetadataEntry{}
+		}
+		proofs[i] = p.Proofs
+		proofData[i] = p.ProofData
+	}
+
+	// a random nonce never collides with the client data set IDs stored in
+	// the same nonce space by CreateDataSet
+	nonce, err := randomNonce()
+	if err != nil {
+		return nil, err
+	}
+	signature, err := w.signer.SignAddPieces(ctx, w.issuer, info.ClientDataSetId, nonce, pieceBytes, metadata, proofs, proofData)
+	if err != nil {
+		return nil, fmt.Errorf("signing AddPieces: %w", err)
+	}
+	extraData, err := w.edc.EncodeAddPiecesExtraData(nonce, signature, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("encoding AddPieces extraData: %w", err)
+	}
+	// the listener must be the zero address for data sets that already exist
+	data, err := w.abi.Pack("addPieces", dataSetID, common.Address{}, pieceData, extraData)
+	if err != nil {
+		return nil, fmt.Errorf("packing addPieces: %w", err)
+	}
+	return &Call{To: w.verifier, Value: big.NewInt(0), Data: data}, nil
+}
+
+func (w *warmStorage) SchedulePieceRemovals(ctx context.Context, dataSetID *big.Int, pieceIDs []*big.Int) (*Call, error) {
+	if len(pieceIDs) == 0 {
+		return nil, fmt.Errorf("no pieces to remove")
+	}
+	info, err := w.view.GetDataSet(ctx, dataSetID)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := w.signer.SignSchedulePieceRemovals(ctx, w.issuer, info.ClientDataSetId, pieceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("signing SchedulePieceRemovals: %w", err)
+	}
+	extraData, err := w.edc.EncodeSchedulePieceRemovalsExtraData(signature)
+	if err != nil {
+		return nil, fmt.Errorf("encoding SchedulePieceRemovals extraData: %w", err)
+	}
+	data, err := w.abi.Pack("schedulePieceDeletions", dataSetID, pieceIDs, extraData)
+	if err != nil {
+		return nil, fmt.Errorf("packing schedulePieceDeletions: %w", err)
+	}
+	return &Call{To: w.verifier, Value: big.NewInt(0), Data: data}, nil
+}
+
+func (w *warmStorage) DeleteDataSet(ctx context.Context, dataSetID *big.Int) (*Call, error) {
+	info, err := w.view.GetDataSet(ctx, dataSetID)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := w.signer.SignDeleteDataSet(ctx, w.issuer, info.ClientDataSetId)
+	if err != nil {
+		return nil, fmt.Errorf("signing DeleteDataSet: %w", err)
+	}
+	extraData, err := w.edc.EncodeDeleteDataSetExtraData(signature)
+	if err != nil {
+		return nil, fmt.Errorf("encoding DeleteDataSet extraData: %w", err)
+	}
+	data, err := w.abi.Pack("deleteDataSet", dataSetID, extraData)
+	if err != nil {
+		return nil, fmt.Errorf("packing deleteDataSet: %w", err)
+	}
+	return &Call{To: w.verifier, Value: big.NewInt(0), Data: data}, nil
+}
+
+func (w *warmStorage) Address() common.Address {
+	return w.address
+}
+
+func randomNonce() (*big.Int, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return new(big.Int).SetBytes(buf), nil
+}
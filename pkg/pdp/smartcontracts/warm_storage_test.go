@@ -0,0 +1,119 @@
+package smartcontracts
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/storacha/filecoin-services/go/bindings"
+	"github.com/storacha/filecoin-services/go/eip712"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeView struct {
+	Service
+	clientDataSetID *big.Int
+}
+
+func (v fakeView) GetDataSet(ctx context.Context, dataSetId *big.Int) (*DataSetInfo, error) {
+	return &DataSetInfo{DataSetId: dataSetId, ClientDataSetId: v.clientDataSetID}, nil
+}
+
+// fakeSigner records the client data set ID of the operations it signs.
+type fakeSigner struct {
+	WarmStorageSigner
+	dataSet *big.Int
+}
+
+func (s *fakeSigner) signature() *eip712.AuthSignature {
+	return &eip712.AuthSignature{
+		Signer: common.HexToAddress("0x1234567890123456789012345678901234567890"),
+		R:      common.BigToHash(big.NewInt(12345)),
+		S:      common.BigToHash(big.NewInt(67890)),
+		V:      27,
+	}
+}
+
+func (s *fakeSigner) SignSchedulePieceRemovals(ctx context.Context, issuer ucan.Signer, dataSet *big.Int, pieceIds []*big.Int, options ...delegation.Option) (*eip712.AuthSignature, error) {
+	s.dataSet = dataSet
+	return s.signature(), nil
+}
+
+func (s *fakeSigner) SignDeleteDataSet(ctx context.Context, issuer ucan.Signer, dataSet *big.Int, options ...delegation.Option) (*eip712.AuthSignature, error) {
+	s.dataSet = dataSet
+	return s.signature(), nil
+}
+
+// unpackCall returns the name and arguments of the verifier method called.
+func unpackCall(t *testing.T, call *Call) (string, []any) {
+	verifierABI, err := bindings.PDPVerifierMetaData.GetAbi()
+	require.NoError(t, err)
+	method, err := verifierABI.MethodById(call.Data[:4])
+	require.NoError(t, err)
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	require.NoError(t, err)
+	return method.Name, args
+}
+
+// requireSignature checks extraData holds the 65 byte signature of fakeSigner.
+func requireSignature(t *testing.T, extraData []byte) {
+	bytesType, err := abi.NewType("bytes", "", nil)
+	require.NoError(t, err)
+	out, err := abi.Arguments{{Type: bytesType}}.Unpack(extraData)
+	require.NoError(t, err)
+	sig := out[0].([]byte)
+	require.Len(t, sig, 65)
+	require.Equal(t, big.NewInt(12345), new(big.Int).SetBytes(sig[:32]))
+	require.Equal(t, big.NewInt(67890), new(big.Int).SetBytes(sig[32:64]))
+	require.Equal(t, byte(27), sig[64])
+}
+
+func TestWarmStorage(t *testing.T) {
+	service := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	verifier := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	clientDataSetID := big.NewInt(42)
+	dataSetID := big.NewInt(7)
+
+	signer := &fakeSigner{}
+	ws, err := NewWarmStorage(service, verifier, fakeView{clientDataSetID: clientDataSetID}, signer, eip712.NewExtraDataEncoder(), testutil.Alice)
+	require.NoError(t, err)
+
+	t.Run("schedules piece removals", func(t *testing.T) {
+		pieceIDs := []*big.Int{big.NewInt(1), big.NewInt(2)}
+		call, err := ws.SchedulePieceRemovals(t.Context(), dataSetID, pieceIDs)
+		require.NoError(t, err)
+		require.Equal(t, verifier, call.To)
+		require.Zero(t, call.Value.Sign())
+		require.Equal(t, clientDataSetID, signer.dataSet)
+
+		name, args := unpackCall(t, call)
+		require.Equal(t, "schedulePieceDeletions", name)
+		require.Equal(t, dataSetID, args[0])
+		require.Equal(t, pieceIDs, args[1])
+		requireSignature(t, args[2].([]byte))
+
+		_, err = ws.SchedulePieceRemovals(t.Context(), dataSetID, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("deletes a data set", func(t *testing.T) {
+		call, err := ws.DeleteDataSet(t.Context(), dataSetID)
+		require.NoError(t, err)
+		require.Equal(t, verifier, call.To)
+		require.Equal(t, clientDataSetID, signer.dataSet)
+
+		name, args := unpackCall(t, call)
+		require.Equal(t, "deleteDataSet", name)
+		require.Equal(t, dataSetID, args[0])
+		requireSignature(t, args[1].([]byte))
+
+		tx := call.Transaction()
+		require.Equal(t, verifier, *tx.To())
+		require.Equal(t, call.Data, tx.Data())
+	})
+}
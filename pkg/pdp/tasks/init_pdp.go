@@ -147,7 +147,8 @@ func NewInitProvingPeriodTask(
 
 func (ipp *InitProvingPeriodTask) TypeDetails() scheduler.TaskTypeDetails {
 	return scheduler.TaskTypeDetails{
-		Name: "PDPInitPP",
+		Name:     "PDPInitPP",
+		Priority: scheduler.PriorityChain,
 	}
 }
 
@@ -240,7 +240,8 @@ func (n *NextProvingPeriodTask) CanAccept(ids []scheduler.TaskID, engine *schedu
 
 func (n *NextProvingPeriodTask) TypeDetails() scheduler.TaskTypeDetails {
 	return scheduler.TaskTypeDetails{
-		Name: "PDPProvingPeriod",
+		Name:     "PDPProvingPeriod",
+		Priority: scheduler.PriorityChain,
 	}
 }
 
@@ -0,0 +1,145 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	chaintypes "github.com/filecoin-project/lotus/chain/types"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/pdp/smartcontracts"
+)
+
+var (
+	// ErrUnknownProofSet is returned by DryRun for proof sets the node does
+	// not store.
+	ErrUnknownProofSet = errors.New("proof set not stored by this node")
+	// ErrNoChallenge is returned by DryRun for proof sets without a
+	// scheduled challenge, e.g. before their first root is added.
+	ErrNoChallenge = errors.New("no challenge scheduled")
+)
+
+// DryRunResult is the outcome of proving a proof set without submitting the
+// proof.
+type DryRunResult struct {
+	ProofSetID     int64
+	ChallengeEpoch int64
+	HeadEpoch      int64
+	// SeedEpoch is the epoch of the randomness the challenges are drawn from.
+	SeedEpoch int64
+	// Simulated is true if the challenge epoch has not been reached, the
+	// randomness of the head is used instead so the challenged leaves differ
+	// from those of the actual challenge.
+	Simulated  bool
+	Challenges []ChallengeResult
+	// Duration is the time taken to generate every proof.
+	Duration time.Duration
+}
+
+// ChallengeResult is the outcome of proving one challenged leaf.
+type ChallengeResult struct {
+	PieceID  int64
+	Leaf     int64
+	Duration time.Duration
+	// Err is the reason the leaf could not be proven, nil if it was.
+	Err error
+}
+
+// OK reports whether every challenge was proven, i.e. whether the proof would
+// be accepted.
+func (r *DryRunResult) OK() bool {
+	for _, c := range r.Challenges {
+		if c.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// DryRun generates the proofs of the next challenge of a proof set against
+// the stored pieces, as Do does, without sending a transaction. Unlike Do, it
+// proves every challenge rather than stopping at the first failure, so all
+// the unprovable leaves are reported.
+func (p *ProveTask) DryRun(ctx context.Context, proofSetID int64) (*DryRunResult, error) {
+	ctx, span := tracer.Start(ctx, "pdp.ProveDryRun", trace.WithAttributes(attribute.Int64("proof_set_id", proofSetID)))
+	defer span.End()
+
+	if err := p.db.WithContext(ctx).Where("id = ?", proofSetID).First(&models.PDPProofSet{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownProofSet, proofSetID)
+		}
+		return nil, fmt.Errorf("failed to get proof set: %w", err)
+	}
+
+	challengeEpoch, err := p.verifier.GetNextChallengeEpoch(ctx, big.NewInt(proofSetID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get next challenge epoch: %w", err)
+	}
+	if challengeEpoch == nil || challengeEpoch.Sign() == 0 {
+		return nil, fmt.Errorf("%w for proof set %d", ErrNoChallenge, proofSetID)
+	}
+
+	head := p.head.Load()
+	if head == nil {
+		if head, err = p.api.ChainHead(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get chain head: %w", err)
+		}
+	}
+
+	res := &DryRunResult{
+		ProofSetID:     proofSetID,
+		ChallengeEpoch: challengeEpoch.Int64(),
+		HeadEpoch:      int64(head.Height()),
+		SeedEpoch:      challengeEpoch.Int64(),
+	}
+	// randomness is only available once the challenge epoch is reached
+	if res.SeedEpoch > res.HeadEpoch {
+		res.SeedEpoch = res.HeadEpoch
+		res.Simulated = true
+	}
+
+	seed, err := p.api.StateGetRandomnessDigestFromBeacon(ctx, abi.ChainEpoch(res.SeedEpoch), chaintypes.EmptyTSK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain randomness from beacon: %w", err)
+	}
+
+	start := time.Now()
+	totalLeafCount, err := p.verifier.GetChallengeRange(ctx, big.NewInt(proofSetID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proof set leaf count: %w", err)
+	}
+	challenges := lo.Times(smartcontracts.NumChallenges, func(i int) int64 {
+		return generateChallengeIndex(seed, proofSetID, i, totalLeafCount.Uint64())
+	})
+	pieceIds, err := p.verifier.FindPieceIds(ctx, big.NewInt(proofSetID), lo.Map(challenges, func(i int64, _ int) *big.Int { return big.NewInt(i) }))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find piece IDs: %w", err)
+	}
+
+	for _, piece := range pieceIds {
+		c := ChallengeResult{PieceID: piece.PieceId.Int64(), Leaf: piece.Offset.Int64()}
+		proveStart := time.Now()
+		_, c.Err = p.proveRoot(ctx, proofSetID, c.PieceID, c.Leaf)
+		c.Duration = time.Since(proveStart)
+		res.Challenges = append(res.Challenges, c)
+	}
+	res.Duration = time.Since(start)
+
+	span.SetAttributes(attribute.Bool("simulated", res.Simulated), attribute.Bool("ok", res.OK()))
+	log.Infow("PDP prove dry run",
+		"proof_set_id", proofSetID,
+		"challenge_epoch", res.ChallengeEpoch,
+		"seed_epoch", res.SeedEpoch,
+		"ok", res.OK(),
+		"duration", res.Duration,
+	)
+	return res, nil
+}
@@ -11,19 +11,25 @@ import (
 	"math/bits"
 	"sort"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/filecoin-project/go-commp-utils/zerocomm"
 	commcid "github.com/filecoin-project/go-fil-commcid"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
 	chaintypes "github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/storage/pipeline/lib/nullreader"
 	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
 	pool "github.com/libp2p/go-buffer-pool"
 	"github.com/minio/sha256-simd"
 	"github.com/samber/lo"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/crypto/sha3"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -37,6 +43,7 @@ import (
 	"github.com/storacha/piri/pkg/pdp/service/models"
 	"github.com/storacha/piri/pkg/pdp/smartcontracts"
 	"github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/piecelog"
 	"github.com/storacha/piri/pkg/store/blobstore"
 )
 
@@ -53,12 +60,16 @@ type ProveTask struct {
 	api       ChainAPI
 	reader    types.PieceReaderAPI
 	resolver  types.PieceResolverAPI
+	pieces    *piecelog.Log
 
 	head atomic.Pointer[chaintypes.TipSet]
+	// paused stops new proofs from being computed, see Pause.
+	paused atomic.Bool
 
 	addFunc promise.Promise[scheduler.AddTaskFunc]
 
-	taskFailure *telemetry.Counter
+	taskFailure   *telemetry.Counter
+	proveDuration *telemetry.Timer
 }
 
 func NewProveTask(
@@ -71,6 +82,7 @@ func NewProveTask(
 	bs blobstore.Blobstore,
 	reader types.PieceReaderAPI,
 	resolver types.PieceResolverAPI,
+	pieces *piecelog.Log,
 ) (*ProveTask, error) {
 	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/pdp/tasks")
 	pdpProveFailure, err := telemetry.NewCounter(
@@ -82,16 +94,27 @@ func NewProveTask(
 	if err != nil {
 		return nil, err
 	}
+	pdpProveDuration, err := telemetry.NewTimer(
+		meter,
+		"pdp_prove_duration",
+		"records duration of generating and submitting a pdp proof",
+		proveDurationBounds,
+	)
+	if err != nil {
+		return nil, err
+	}
 	pt := &ProveTask{
-		db:          db,
-		ethClient:   ethClient,
-		verifier:    verifier,
-		sender:      sender,
-		api:         api,
-		bs:          bs,
-		reader:      reader,
-		resolver:    resolver,
-		taskFailure: pdpProveFailure,
+		db:            db,
+		ethClient:     ethClient,
+		verifier:      verifier,
+		sender:        sender,
+		api:           api,
+		bs:            bs,
+		reader:        reader,
+		resolver:      resolver,
+		pieces:        pieces,
+		taskFailure:   pdpProveFailure,
+		proveDuration: pdpProveDuration,
 	}
 
 	// ProveTasks are created on pdp_proof_sets entries where
@@ -175,11 +198,17 @@ func NewProveTask(
 }
 
 func (p *ProveTask) Do(taskID scheduler.TaskID) (done bool, err error) {
-	ctx := context.Background()
+	ctx, span := tracer.Start(context.Background(), "pdp.Prove", trace.WithAttributes(attribute.Int64("task_id", int64(taskID))))
+	stopwatch := p.proveDuration.Start()
 	defer func() {
 		if err != nil {
 			p.taskFailure.Inc(ctx)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to prove")
 		}
+		// recorded within the span so sampled proofs are linked as exemplars
+		stopwatch.Stop(ctx, attribute.Bool("success", err == nil))
+		span.End()
 	}()
 
 	// Retrieve proof set and challenge epoch for the task
@@ -188,6 +217,7 @@ func (p *ProveTask) Do(taskID scheduler.TaskID) (done bool, err error) {
 		return false, fmt.Errorf("failed to get task details: %w", err)
 	}
 	proofSetID := proveTask.ProofsetID
+	span.SetAttributes(attribute.Int64("proof_set_id", proofSetID))
 
 	// Proof parameters
 	challengeEpoch, err := p.verifier.GetNextChallengeEpoch(ctx, big.NewInt(proofSetID))
@@ -202,6 +232,7 @@ func (p *ProveTask) Do(taskID scheduler.TaskID) (done bool, err error) {
 
 	proofs, err := p.GenerateProofs(ctx, proofSetID, seed, smartcontracts.NumChallenges)
 	if err != nil {
+		recordProofSetRoots(ctx, p.db, p.pieces, proofSetID, piecelog.Event{Kind: piecelog.Faulted, Error: err.Error()})
 		return false, fmt.Errorf("failed to generate proofs: %w", err)
 	}
 
@@ -266,12 +297,47 @@ func (p *ProveTask) Do(taskID scheduler.TaskID) (done bool, err error) {
 		"tx_eth", txEth,
 	)
 
+	// The proof must land within the challenge window, which the
+	// deadline-aware gas strategy prices against
+	var proofSet models.PDPProofSet
+	if err := p.db.Where("id = ?", proofSetID).First(&proofSet).Error; err != nil {
+		return false, fmt.Errorf("failed to get proof set: %w", err)
+	}
+	sendCtx := ctx
+	if proofSet.ChallengeWindow != nil {
+		sendCtx = ethereum.WithDeadline(ctx, ethereum.Deadline{
+			Opens:  challengeEpoch.Int64(),
+			Closes: challengeEpoch.Int64() + *proofSet.ChallengeWindow,
+		})
+	}
+
 	reason := "pdp-prove"
-	txHash, err := p.sender.Send(ctx, fromAddress, txEth, reason)
+	txHash, err := p.sender.Send(sendCtx, fromAddress, txEth, reason)
 	if err != nil {
 		return false, fmt.Errorf("failed to send transaction: %w", err)
 	}
 
+	// the roots are recorded as proven once the proof is confirmed on chain
+	if err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		msg := models.MessageWaitsEth{
+			SignedTxHash: txHash.Hex(),
+			TxStatus:     "pending",
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&msg).Error; err != nil {
+			return fmt.Errorf("failed to insert into message_waits_eth: %w", err)
+		}
+		wait := models.PDPProveWait{
+			ProveMessageHash: txHash.Hex(),
+			ProofsetID:       proofSetID,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&wait).Error; err != nil {
+			return fmt.Errorf("failed to insert into pdp_prove_waits: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return false, fmt.Errorf("failed to track proof message: %w", err)
+	}
+
 	// Remove the roots previously scheduled for deletion
 	err = p.cleanupDeletedRoots(ctx, proofSetID)
 	if err != nil {
@@ -643,6 +709,7 @@ func (p *ProveTask) cleanupDeletedRoots(ctx context.Context, proofSetID int64) e
 	}
 
 	// Execute cleanup in a transaction
+	var removed []string
 	err = p.db.Transaction(func(tx *gorm.DB) error {
 		for _, removeID := range removals {
 			var proofsetRoot models.PDPProofsetRoot
@@ -661,18 +728,68 @@ func (p *ProveTask) cleanupDeletedRoots(ctx context.Context, proofSetID int64) e
 			if err := tx.Where("proofset_id = ? AND root_id = ?", proofSetID, removeID).Delete(&models.PDPProofsetRoot{}).Error; err != nil {
 				return fmt.Errorf("failed to delete root %d: %w", removeID, err)
 			}
+			removed = append(removed, proofsetRoot.Root)
 		}
 		return nil
 	})
 	if err != nil {
 		return fmt.Errorf("failed to cleanup deleted roots: %w", err)
 	}
-	// TODO(forrest): also probably want to delete the data of these roots from the store.
+	for _, root := range removed {
+		recordRoot(ctx, p.pieces, root, piecelog.Event{Kind: piecelog.Removed, ProofSet: uint64(proofSetID)})
+	}
+	// the data of roots removed because no space references them any more is
+	// deleted by the collector once their removal has taken effect
 	return nil
 }
 
+// recordProofSetRoots appends an event to the piece log of every root of the
+// proof set. Failures are logged, the piece log does not affect proving.
+func recordProofSetRoots(ctx context.Context, db *gorm.DB, pieces *piecelog.Log, proofSetID int64, ev piecelog.Event) {
+	if pieces == nil {
+		return
+	}
+	var roots []string
+	if err := db.WithContext(ctx).Model(&models.PDPProofsetRoot{}).
+		Where("proofset_id = ?", proofSetID).
+		Distinct().
+		Pluck("root", &roots).Error; err != nil {
+		log.Warnw("listing proof set roots for piece log", "proof_set_id", proofSetID, "error", err)
+		return
+	}
+	for _, root := range roots {
+		recordRoot(ctx, pieces, root, ev)
+	}
+}
+
+func recordRoot(ctx context.Context, pieces *piecelog.Log, root string, ev piecelog.Event) {
+	if pieces == nil {
+		return
+	}
+	rootCid, err := cid.Parse(root)
+	if err != nil {
+		log.Warnw("parsing root for piece log", "root", root, "error", err)
+		return
+	}
+	if err := pieces.AppendAggregate(ctx, cidlink.Link{Cid: rootCid}, ev); err != nil {
+		log.Warnw("recording root in piece log", "root", root, "kind", ev.Kind, "error", err)
+	}
+}
+
+// Pause stops the task from accepting new proofs until Resume is called.
+// Proofs already in progress complete, pending proofs wait for Resume and may
+// miss their challenge window.
+func (p *ProveTask) Pause() {
+	p.paused.Store(true)
+}
+
+// Resume allows the task to accept proofs again after a Pause.
+func (p *ProveTask) Resume() {
+	p.paused.Store(false)
+}
+
 func (p *ProveTask) CanAccept(ids []scheduler.TaskID, engine *scheduler.TaskEngine) (*scheduler.TaskID, error) {
-	if len(ids) == 0 {
+	if len(ids) == 0 || p.paused.Load() {
 		return nil, nil
 	}
 	id := ids[0]
@@ -683,9 +800,42 @@ func (p *ProveTask) TypeDetails() scheduler.TaskTypeDetails {
 	return scheduler.TaskTypeDetails{
 		Name:        "PDPProve",
 		MaxFailures: 5,
+		Priority:    scheduler.PriorityProving,
+		Deadline:    p.deadline,
 	}
 }
 
+// deadline estimates when the challenge window of the proof set of the task
+// closes, from the chain head. Tasks of proof sets without a challenge window,
+// or seen before the first head, have no deadline.
+func (p *ProveTask) deadline(taskID scheduler.TaskID) (time.Time, error) {
+	head := p.head.Load()
+	if head == nil {
+		return time.Time{}, nil
+	}
+	var window struct {
+		ProveAtEpoch    *int64
+		ChallengeWindow *int64
+	}
+	if err := p.db.Table("pdp_prove_tasks as t").
+		Select("p.prove_at_epoch, p.challenge_window").
+		Joins("INNER JOIN pdp_proof_sets as p ON p.id = t.proofset_id").
+		Where("t.task_id = ?", taskID).
+		Scan(&window).Error; err != nil {
+		return time.Time{}, fmt.Errorf("failed to get challenge window: %w", err)
+	}
+	if window.ProveAtEpoch == nil || window.ChallengeWindow == nil {
+		return time.Time{}, nil
+	}
+	remaining := *window.ProveAtEpoch + *window.ChallengeWindow - int64(head.Height())
+	if remaining <= 0 {
+		// the challenge window has closed, so the proof can no longer land
+		// in time and there is nothing to hurry for
+		return time.Time{}, nil
+	}
+	return time.Now().Add(time.Duration(remaining) * builtin.EpochDurationSeconds * time.Second), nil
+}
+
 func (p *ProveTask) Adder(taskFunc scheduler.AddTaskFunc) {
 	p.addFunc.Set(taskFunc)
 }
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/raulk/clock"
 	"github.com/storacha/filecoin-services/go/evmerrors"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -23,6 +25,8 @@ import (
 	"github.com/storacha/piri/pkg/config"
 	"github.com/storacha/piri/pkg/config/app"
 	"github.com/storacha/piri/pkg/config/dynamic"
+	ethsender "github.com/storacha/piri/pkg/pdp/ethereum"
+	"github.com/storacha/piri/pkg/pdp/gasoracle"
 	"github.com/storacha/piri/pkg/pdp/promise"
 	"github.com/storacha/piri/pkg/pdp/scheduler"
 	"github.com/storacha/piri/pkg/pdp/service/models"
@@ -38,12 +42,58 @@ var sendReasonToConfigKey = map[string]config.Key{
 	"pdp-addroots":       config.GasMaxFeeAddRoots,
 }
 
+// sendReasonToStrategyKey maps SendReason strings to their per-type gas
+// strategy config keys.
+var sendReasonToStrategyKey = map[string]config.Key{
+	"pdp-prove":          config.GasStrategyProve,
+	"pdp-proving-period": config.GasStrategyProvingPeriod,
+	"pdp-proving-init":   config.GasStrategyProvingInit,
+	"pdp-addroots":       config.GasStrategyAddRoots,
+}
+
+// Categories of non-urgent messages that can wait for a low-fee window.
+const (
+	GasCategorySettlement = "settlement"
+	GasCategoryWithdrawal = "withdrawal"
+	GasCategoryAnchoring  = "anchoring"
+	GasCategoryAddRoots   = "add_roots"
+)
+
+// deadlineBound are the SendReasons of messages that must land before a
+// proving deadline. They are never deferred to a low-fee window.
+var deadlineBound = map[string]bool{
+	"pdp-prove":          true,
+	"pdp-proving-period": true,
+	"pdp-proving-init":   true,
+}
+
+// gasCategory returns the wait category of a SendReason, or "" for messages
+// that are always sent as soon as possible.
+func gasCategory(reason string) string {
+	switch {
+	case deadlineBound[reason]:
+		return ""
+	case strings.HasPrefix(reason, "settle_rail_"):
+		return GasCategorySettlement
+	case reason == "withdraw":
+		return GasCategoryWithdrawal
+	case reason == "anchor":
+		return GasCategoryAnchoring
+	case reason == "pdp-addroots":
+		return GasCategoryAddRoots
+	default:
+		return ""
+	}
+}
+
 // SenderETHOption configures optional dependencies for NewSenderETH.
 type SenderETHOption func(*senderETHOptions)
 
 type senderETHOptions struct {
 	registry  *dynamic.Registry
 	gasConfig app.GasConfig
+	oracle    *gasoracle.Oracle
+	clock     clock.Clock
 }
 
 // WithGasConfig provides a dynamic config registry for gas fee limits.
@@ -61,6 +111,22 @@ func WithGasDefaults(cfg app.GasConfig) SenderETHOption {
 	}
 }
 
+// WithGasOracle provides the base fee history used to defer non-urgent
+// messages until fees drop below the configured percentile.
+func WithGasOracle(oracle *gasoracle.Oracle) SenderETHOption {
+	return func(o *senderETHOptions) {
+		o.oracle = oracle
+	}
+}
+
+// WithSenderClock sets the clock sends are timed and awaited by. Defaults to
+// the real clock.
+func WithSenderClock(clock clock.Clock) SenderETHOption {
+	return func(o *senderETHOptions) {
+		o.clock = clock
+	}
+}
+
 var SendLockedWait = 100 * time.Millisecond
 
 var _ scheduler.TaskInterface = &SendTaskETH{}
@@ -79,14 +145,16 @@ type SenderETH struct {
 
 	sendTask *SendTaskETH
 
-	db *gorm.DB
+	db       *gorm.DB
+	registry *dynamic.Registry
+	clock    clock.Clock
 
 	messageEstimateGasFailureCounter *telemetry.Counter
 }
 
 // NewSenderETH creates a new SenderETH.
 func NewSenderETH(client SenderETHClient, wallet wallet.Wallet, db *gorm.DB, opts ...SenderETHOption) (*SenderETH, *SendTaskETH, error) {
-	var options senderETHOptions
+	options := senderETHOptions{clock: clock.New()}
 	for _, o := range opts {
 		o(&options)
 	}
@@ -107,8 +175,14 @@ func NewSenderETH(client SenderETHClient, wallet wallet.Wallet, db *gorm.DB, opt
 		wallet:                    wallet,
 		db:                        db,
 		registry:                  options.registry,
+		oracle:                    options.oracle,
+		clock:                     options.clock,
+		waitCategories:            map[string]bool{},
 		messageSendFailureCounter: sendFailure,
 	}
+	for _, c := range options.gasConfig.Wait.Categories {
+		st.waitCategories[c] = true
+	}
 
 	// Register gas config entries if registry is provided.
 	// Initial values come from static config (TOML); 0 means no limit.
@@ -119,13 +193,30 @@ func NewSenderETH(client SenderETHClient, wallet wallet.Wallet, db *gorm.DB, opt
 		if retryWait == 0 {
 			retryWait = 5 * time.Minute
 		}
+		maxDelay := gcfg.Wait.MaxDelay
+		if maxDelay == 0 {
+			maxDelay = 24 * time.Hour
+		}
+		defaultStrategy := gcfg.Strategy.Default
+		if defaultStrategy == "" {
+			defaultStrategy = ethsender.GasStrategyConservative
+		}
+		// per-type strategies may be unset, to use the default strategy
+		strategySchema := dynamic.EnumSchema{Values: ethsender.GasStrategies, Optional: true}
 		_ = options.registry.RegisterEntries(map[config.Key]dynamic.ConfigEntry{
-			config.GasMaxFeeProve:         {Value: gcfg.MaxFee.Prove, Schema: dynamic.UintSchema{Max: ^uint(0)}},
-			config.GasMaxFeeProvingPeriod: {Value: gcfg.MaxFee.ProvingPeriod, Schema: dynamic.UintSchema{Max: ^uint(0)}},
-			config.GasMaxFeeProvingInit:   {Value: gcfg.MaxFee.ProvingInit, Schema: dynamic.UintSchema{Max: ^uint(0)}},
-			config.GasMaxFeeAddRoots:      {Value: gcfg.MaxFee.AddRoots, Schema: dynamic.UintSchema{Max: ^uint(0)}},
-			config.GasMaxFeeDefault:       {Value: gcfg.MaxFee.Default, Schema: dynamic.UintSchema{Max: ^uint(0)}},
-			config.GasRetryWait:           {Value: retryWait, Schema: dynamic.DurationSchema{Min: time.Second, Max: time.Hour}},
+			config.GasMaxFeeProve:           {Value: gcfg.MaxFee.Prove, Schema: dynamic.UintSchema{Max: ^uint(0)}},
+			config.GasMaxFeeProvingPeriod:   {Value: gcfg.MaxFee.ProvingPeriod, Schema: dynamic.UintSchema{Max: ^uint(0)}},
+			config.GasMaxFeeProvingInit:     {Value: gcfg.MaxFee.ProvingInit, Schema: dynamic.UintSchema{Max: ^uint(0)}},
+			config.GasMaxFeeAddRoots:        {Value: gcfg.MaxFee.AddRoots, Schema: dynamic.UintSchema{Max: ^uint(0)}},
+			config.GasMaxFeeDefault:         {Value: gcfg.MaxFee.Default, Schema: dynamic.UintSchema{Max: ^uint(0)}},
+			config.GasRetryWait:             {Value: retryWait, Schema: dynamic.DurationSchema{Min: time.Second, Max: time.Hour}},
+			config.GasWaitPercentile:        {Value: gcfg.Wait.Percentile, Schema: dynamic.UintSchema{Max: 100}},
+			config.GasWaitMaxDelay:          {Value: maxDelay, Schema: dynamic.DurationSchema{Min: time.Minute, Max: 7 * 24 * time.Hour}},
+			config.GasStrategyProve:         {Value: gcfg.Strategy.Prove, Schema: strategySchema},
+			config.GasStrategyProvingPeriod: {Value: gcfg.Strategy.ProvingPeriod, Schema: strategySchema},
+			config.GasStrategyProvingInit:   {Value: gcfg.Strategy.ProvingInit, Schema: strategySchema},
+			config.GasStrategyAddRoots:      {Value: gcfg.Strategy.AddRoots, Schema: strategySchema},
+			config.GasStrategyDefault:       {Value: defaultStrategy, Schema: dynamic.EnumSchema{Values: ethsender.GasStrategies}},
 		})
 	}
 
@@ -142,6 +233,8 @@ func NewSenderETH(client SenderETHClient, wallet wallet.Wallet, db *gorm.DB, opt
 	return &SenderETH{
 		client:                           client,
 		db:                               db,
+		registry:                         options.registry,
+		clock:                            options.clock,
 		sendTask:                         st,
 		messageEstimateGasFailureCounter: estimateGasFailureCounter,
 	}, st, nil
@@ -198,14 +291,34 @@ func (s *SenderETH) Send(ctx context.Context, fromAddress common.Address, tx *et
 			return common.Hash{}, fmt.Errorf("base fee not available; network might not support EIP-1559")
 		}
 
-		// Set GasTipCap (maxPriorityFeePerGas)
-		gasTipCap, err := s.client.SuggestGasTipCap(ctx)
+		suggestedTipCap, err := s.client.SuggestGasTipCap(ctx)
 		if err != nil {
 			return common.Hash{}, xerrors.Errorf("estimating gas premium: %w", err)
 		}
 
-		// Calculate GasFeeCap (maxFeePerGas)
-		gasFeeCap := new(big.Int).Add(baseFee, gasTipCap)
+		// Set GasFeeCap (maxFeePerGas) and GasTipCap (maxPriorityFeePerGas)
+		// according to the strategy of the message type
+		name := strategyForReason(s.registry, reason)
+		strategy, err := ethsender.NewGasStrategy(name)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		var epoch int64
+		if header.Number != nil {
+			epoch = header.Number.Int64()
+		}
+		gasFeeCap, gasTipCap := strategy.Fees(ctx, ethsender.FeeInputs{
+			BaseFee:   baseFee,
+			GasTipCap: suggestedTipCap,
+			Epoch:     epoch,
+		})
+		log.Debugw("priced transaction",
+			"strategy", name,
+			"send_reason", reason,
+			"base_fee", baseFee.String(),
+			"gas_fee_cap", gasFeeCap.String(),
+			"gas_tip_cap", gasTipCap.String(),
+		)
 
 		chainID, err := s.client.NetworkID(ctx)
 		if err != nil {
@@ -252,6 +365,19 @@ func (s *SenderETH) Send(ctx context.Context, fromAddress common.Address, tx *et
 
 		sendTaskID = &id
 
+		// messages waiting for a low-fee window are not picked up until
+		// fees are checked again
+		if runAt, wait := s.sendTask.waitForLowFees(s.clock.Now(), reason); wait {
+			log.Infow("base fee above target percentile, deferring message",
+				"run_at", runAt,
+				"send_reason", reason,
+				"task_id", id,
+			)
+			if err := scheduler.DeferTask(txdb, id, runAt); err != nil {
+				return false, err
+			}
+		}
+
 		return true, nil
 	})
 
@@ -278,7 +404,7 @@ func (s *SenderETH) Send(ctx context.Context, fromAddress common.Address, tx *et
 		}
 
 		if row.SendSuccess == nil {
-			time.Sleep(pollInterval)
+			s.clock.Sleep(pollInterval)
 			pollLoops++
 			pollInterval *= time.Duration(pollIntervalMul)
 			if pollInterval > maxPollInterval {
@@ -311,6 +437,10 @@ type SendTaskETH struct {
 	client   SenderETHClient
 	wallet   wallet.Wallet
 	registry *dynamic.Registry
+	oracle   *gasoracle.Oracle
+	clock    clock.Clock
+	// waitCategories are the message categories deferred to low-fee windows
+	waitCategories map[string]bool
 
 	db                        *gorm.DB
 	messageSendFailureCounter *telemetry.Counter
@@ -359,6 +489,20 @@ func (s *SendTaskETH) Do(taskID scheduler.TaskID) (done bool, err error) {
 		}
 	}
 
+	// Non-urgent messages wait for a low-fee window, up to a deadline
+	var task models.Task
+	if err := s.db.WithContext(ctx).Where("id = ?", taskID).First(&task).Error; err != nil {
+		return false, fmt.Errorf("checking gas wait deadline: getting task: %w", err)
+	}
+	if runAt, wait := s.waitForLowFees(task.PostedTime, dbTx.SendReason); wait {
+		log.Infow("base fee above target percentile, deferring message",
+			"run_at", runAt,
+			"send_reason", dbTx.SendReason,
+			"task_id", taskID,
+		)
+		return false, scheduler.DeferUntil(runAt, scheduler.ErrGasTooHigh)
+	}
+
 	// Acquire lock on from_address
 	for {
 
@@ -367,7 +511,7 @@ func (s *SendTaskETH) Do(taskID scheduler.TaskID) (done bool, err error) {
 			Columns: []clause.Column{{Name: "from_address"}},
 			DoUpdates: clause.Assignments(map[string]interface{}{
 				"task_id":    taskID,
-				"claimed_at": time.Now(),
+				"claimed_at": s.clock.Now(),
 			}),
 			Where: clause.Where{
 				Exprs: []clause.Expression{
@@ -377,7 +521,7 @@ func (s *SendTaskETH) Do(taskID scheduler.TaskID) (done bool, err error) {
 		}).Create(&models.MessageSendEthLock{
 			FromAddress: dbTx.FromAddress,
 			TaskID:      int64(taskID),
-			ClaimedAt:   time.Now(),
+			ClaimedAt:   s.clock.Now(),
 		})
 		if res.Error != nil {
 			return false, fmt.Errorf("aquiring send lock: %w", res.Error)
@@ -390,7 +534,7 @@ func (s *SendTaskETH) Do(taskID scheduler.TaskID) (done bool, err error) {
 
 		// Wait and retry
 		log.Infow("waiting for send lock", "task_id", taskID, "from", dbTx.FromAddress)
-		time.Sleep(SendLockedWait)
+		s.clock.Sleep(SendLockedWait)
 	}
 
 	// Defer release of the lock
@@ -409,24 +553,9 @@ func (s *SendTaskETH) Do(taskID scheduler.TaskID) (done bool, err error) {
 	var signedTx *ethtypes.Transaction
 
 	if dbTx.Nonce == nil {
-		// Get the latest nonce
-		pendingNonce, err := s.client.PendingNonceAt(ctx, fromAddress)
-		if err != nil {
-			return false, xerrors.Errorf("getting pending nonce: %w", err)
-		}
-
-		// Get max nonce from successful transactions in DB
-		var dbNonce *int64
-		err = s.db.Model(&models.MessageSendsEth{}).
-			Where("from_address = ? AND send_success = ?", dbTx.FromAddress, true).
-			Select("MAX(nonce)").Scan(&dbNonce).Error
+		assignedNonce, err := nextNonce(ctx, s.db, s.client, fromAddress)
 		if err != nil {
-			return false, xerrors.Errorf("getting max nonce from db: %w", err)
-		}
-
-		assignedNonce := pendingNonce
-		if dbNonce != nil && uint64(*dbNonce)+1 > pendingNonce {
-			assignedNonce = uint64(*dbNonce) + 1
+			return false, err
 		}
 
 		// Update the transaction with the assigned nonce
@@ -484,7 +613,7 @@ func (s *SendTaskETH) Do(taskID scheduler.TaskID) (done bool, err error) {
 		Updates(map[string]interface{}{
 			"send_success": sendSuccess,
 			"send_error":   sendError,
-			"send_time":    time.Now(),
+			"send_time":    s.clock.Now(),
 		}).Error
 	if err != nil {
 		return false, xerrors.Errorf("updating db record: %w", err)
@@ -523,25 +652,92 @@ func (s *SendTaskETH) CanAccept(ids []scheduler.TaskID, engine *scheduler.TaskEn
 // Returns the per-type limit if set (non-zero), otherwise the default limit.
 // Returns 0 if no limit is configured (bypass gas check).
 func (s *SendTaskETH) maxFeeForReason(reason string) uint64 {
-	if s.registry == nil {
+	return maxFeeForReason(s.registry, reason)
+}
+
+func maxFeeForReason(registry *dynamic.Registry, reason string) uint64 {
+	if registry == nil {
 		return 0
 	}
 
 	// Check per-type limit first
 	if key, ok := sendReasonToConfigKey[reason]; ok {
-		if v := s.registry.GetUint(key, 0); v > 0 {
+		if v := registry.GetUint(key, 0); v > 0 {
 			return uint64(v)
 		}
 	}
 
 	// Fall back to default
-	return uint64(s.registry.GetUint(config.GasMaxFeeDefault, 0))
+	return uint64(registry.GetUint(config.GasMaxFeeDefault, 0))
+}
+
+// strategyForReason returns the name of the gas strategy configured for the
+// given SendReason, the default strategy for reasons without their own, or
+// conservative if no strategies are configured.
+func strategyForReason(registry *dynamic.Registry, reason string) string {
+	if registry == nil {
+		return ethsender.GasStrategyConservative
+	}
+	if key, ok := sendReasonToStrategyKey[reason]; ok {
+		if v := registry.GetString(key, ""); v != "" {
+			return v
+		}
+	}
+	return registry.GetString(config.GasStrategyDefault, ethsender.GasStrategyConservative)
+}
+
+// waitForLowFees reports whether a message posted at posted should be
+// deferred because the current base fee is above the configured percentile of
+// the recent history, and when its send task should run again: after the
+// retry wait, or at the configured max delay since it was posted if sooner.
+// Messages are never deferred past the max delay, nor while the oracle has
+// too little history to estimate from.
+func (s *SendTaskETH) waitForLowFees(posted time.Time, reason string) (time.Time, bool) {
+	if s.oracle == nil || s.registry == nil || !s.waitCategories[gasCategory(reason)] {
+		return time.Time{}, false
+	}
+	p := s.registry.GetUint(config.GasWaitPercentile, 0)
+	if p == 0 {
+		return time.Time{}, false
+	}
+
+	maxDelay := s.registry.GetDuration(config.GasWaitMaxDelay, 24*time.Hour)
+	deadline := posted.Add(maxDelay)
+	now := s.clock.Now()
+	if !now.Before(deadline) {
+		log.Infow("gas wait deadline reached, sending message regardless of fees",
+			"waited", now.Sub(posted).String(),
+			"send_reason", reason,
+		)
+		return time.Time{}, false
+	}
+
+	threshold, ok := s.oracle.Percentile(p)
+	if !ok {
+		return time.Time{}, false
+	}
+	latest, ok := s.oracle.Latest()
+	if !ok || latest.BaseFee.Cmp(threshold) <= 0 {
+		return time.Time{}, false
+	}
+	log.Debugw("base fee above target percentile",
+		"base_fee", latest.BaseFee.String(),
+		"percentile", p,
+		"threshold", threshold.String(),
+		"send_reason", reason,
+	)
+	runAt := now.Add(s.registry.GetDuration(config.GasRetryWait, 5*time.Minute))
+	if runAt.After(deadline) {
+		runAt = deadline
+	}
+	return runAt, true
 }
 
 func (s *SendTaskETH) TypeDetails() scheduler.TaskTypeDetails {
 	details := scheduler.TaskTypeDetails{
 		Name:        "SendTransaction",
 		MaxFailures: 1000,
+		Priority:    scheduler.PriorityMessages,
 	}
 	if s.registry != nil {
 		details.RetryWait = func(retries int) time.Duration {
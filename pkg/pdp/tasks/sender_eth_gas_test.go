@@ -11,13 +11,16 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/raulk/clock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 
 	"github.com/storacha/piri/pkg/config"
+	"github.com/storacha/piri/pkg/config/app"
 	"github.com/storacha/piri/pkg/config/dynamic"
 	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/gasoracle"
 	"github.com/storacha/piri/pkg/pdp/scheduler"
 	"github.com/storacha/piri/pkg/pdp/service/models"
 	"github.com/storacha/piri/pkg/pdp/tasks"
@@ -641,3 +644,114 @@ func TestSendTaskETH_GasCheckWithAllSendReasons(t *testing.T) {
 		})
 	}
 }
+
+// TestSendTaskETH_GasWaitPercentile tests that non-urgent messages wait while
+// the base fee is above the configured percentile of the oracle's history, and
+// are sent regardless once the max delay has passed.
+func TestSendTaskETH_GasWaitPercentile(t *testing.T) {
+	gasLimit := uint64(100_000)
+	baseFee := big.NewInt(10_000_000_000)
+	gasTipCap := big.NewInt(1_000_000_000)
+
+	setup := func(t *testing.T, sendReason string, postedTime time.Time, opts ...tasks.SenderETHOption) (*tasks.SendTaskETH, *gasoracle.Oracle) {
+		db := setupGasTestDB(t)
+		client := &mockSenderETHClient{
+			networkID: big.NewInt(1),
+			baseFee:   baseFee,
+			gasTipCap: gasTipCap,
+			gasLimit:  gasLimit,
+		}
+		oracle := gasoracle.New(db, time.Hour)
+		for i := range gasoracle.MinSamples {
+			require.NoError(t, oracle.Record(t.Context(), int64(i), big.NewInt(int64(i+1))))
+		}
+
+		_, sendTask, err := tasks.NewSenderETH(client, &mockWallet{}, db, append([]tasks.SenderETHOption{
+			tasks.WithGasConfig(dynamic.NewRegistry(nil)),
+			tasks.WithGasDefaults(app.GasConfig{
+				Wait: app.GasWaitConfig{
+					Percentile: 50,
+					MaxDelay:   time.Hour,
+					Categories: []string{tasks.GasCategorySettlement},
+				},
+			}),
+			tasks.WithGasOracle(oracle),
+		}, opts...)...)
+		require.NoError(t, err)
+
+		insertTestMessageSend(t, db, 1, sendReason, createUnsignedTx(t, gasLimit, baseFee, gasTipCap))
+		require.NoError(t, db.Create(&models.Task{
+			ID:         1,
+			Name:       "SendTransaction",
+			PostedTime: postedTime,
+			UpdateTime: postedTime,
+		}).Error)
+		return sendTask, oracle
+	}
+
+	t.Run("defers while fees are above the percentile", func(t *testing.T) {
+		sendTask, oracle := setup(t, "settle_rail_1", time.Now())
+		require.NoError(t, oracle.Record(t.Context(), gasoracle.MinSamples, big.NewInt(1000)))
+
+		_, doErr := sendTask.Do(scheduler.TaskID(1))
+		require.ErrorIs(t, doErr, scheduler.ErrGasTooHigh)
+		// checked again after the retry wait rather than right away
+		var deferred *scheduler.DeferredError
+		require.ErrorAs(t, doErr, &deferred)
+		require.WithinDuration(t, time.Now().Add(5*time.Minute), deferred.RunAt, time.Minute)
+	})
+
+	t.Run("sends once fees drop", func(t *testing.T) {
+		sendTask, oracle := setup(t, "settle_rail_1", time.Now())
+		require.NoError(t, oracle.Record(t.Context(), gasoracle.MinSamples, big.NewInt(1)))
+
+		_, doErr := sendTask.Do(scheduler.TaskID(1))
+		if doErr != nil {
+			assert.False(t, errors.Is(doErr, scheduler.ErrGasTooHigh), "got: %v", doErr)
+		}
+	})
+
+	t.Run("sends after the max delay", func(t *testing.T) {
+		sendTask, oracle := setup(t, "settle_rail_1", time.Now().Add(-2*time.Hour))
+		require.NoError(t, oracle.Record(t.Context(), gasoracle.MinSamples, big.NewInt(1000)))
+
+		_, doErr := sendTask.Do(scheduler.TaskID(1))
+		if doErr != nil {
+			assert.False(t, errors.Is(doErr, scheduler.ErrGasTooHigh), "got: %v", doErr)
+		}
+	})
+
+	t.Run("sends once the max delay passes on the clock", func(t *testing.T) {
+		clk := clock.NewMock()
+		posted := time.Now()
+		clk.Set(posted)
+		sendTask, oracle := setup(t, "settle_rail_1", posted, tasks.WithSenderClock(clk))
+		require.NoError(t, oracle.Record(t.Context(), gasoracle.MinSamples, big.NewInt(1000)))
+
+		_, doErr := sendTask.Do(scheduler.TaskID(1))
+		require.ErrorIs(t, doErr, scheduler.ErrGasTooHigh)
+
+		// the last deferral runs the task at the max delay
+		clk.Add(time.Hour - time.Minute)
+		_, doErr = sendTask.Do(scheduler.TaskID(1))
+		var deferred *scheduler.DeferredError
+		require.ErrorAs(t, doErr, &deferred)
+		require.WithinDuration(t, posted.Add(time.Hour), deferred.RunAt, time.Millisecond)
+
+		clk.Add(time.Minute + time.Second)
+		_, doErr = sendTask.Do(scheduler.TaskID(1))
+		if doErr != nil {
+			assert.False(t, errors.Is(doErr, scheduler.ErrGasTooHigh), "got: %v", doErr)
+		}
+	})
+
+	t.Run("does not defer other categories", func(t *testing.T) {
+		sendTask, oracle := setup(t, "pdp-prove", time.Now())
+		require.NoError(t, oracle.Record(t.Context(), gasoracle.MinSamples, big.NewInt(1000)))
+
+		_, doErr := sendTask.Do(scheduler.TaskID(1))
+		if doErr != nil {
+			assert.False(t, errors.Is(doErr, scheduler.ErrGasTooHigh), "got: %v", doErr)
+		}
+	})
+}
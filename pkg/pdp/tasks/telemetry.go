@@ -0,0 +1,24 @@
+package tasks
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	tracer = otel.Tracer("github.com/storacha/piri/pkg/pdp/tasks")
+)
+
+// proveDurationBounds covering 1s up to 30 minutes.
+var proveDurationBounds = []float64{
+	(time.Second).Seconds(),
+	(5 * time.Second).Seconds(),
+	(10 * time.Second).Seconds(),
+	(30 * time.Second).Seconds(),
+	(time.Minute).Seconds(),
+	(2 * time.Minute).Seconds(),
+	(5 * time.Minute).Seconds(),
+	(10 * time.Minute).Seconds(),
+	(30 * time.Minute).Seconds(),
+}
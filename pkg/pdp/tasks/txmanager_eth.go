@@ -0,0 +1,343 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/raulk/clock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/lib/telemetry"
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/config/dynamic"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/wallet"
+)
+
+// FeeBumpPercent is the percentage by which the fees of a replacement exceed
+// those of the transaction it replaces. Filecoin nodes reject replacements
+// raising the premium by less than 25%.
+const FeeBumpPercent = 25
+
+// ReplaceCheckInterval is how often sent transactions are checked for stalls.
+// A check that has not finished within the interval is cancelled.
+var ReplaceCheckInterval = time.Minute
+
+type TxManagerETHClient interface {
+	SenderETHClient
+	MessageWatcherEthClient
+}
+
+// TxManagerETH keeps sent transactions moving until they are included on
+// chain. Transactions pending for longer than the stall timeout are replaced
+// by a transaction with the same nonce at higher fees, and transactions
+// dropped from the mempool are resubmitted, so that a stuck nonce does not
+// block every later transaction from the same address.
+//
+// Only transactions with a pending wait are managed. Waits keep referencing
+// the hash of the original transaction; the message watcher confirms them
+// with whichever replacement is included.
+type TxManagerETH struct {
+	db       *gorm.DB
+	client   TxManagerETHClient
+	wallet   wallet.Wallet
+	registry *dynamic.Registry
+	cfg      app.GasReplaceConfig
+	clock    clock.Clock
+
+	replacements *telemetry.Counter
+
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// NewTxManagerETH creates a transaction manager. The registry provides the
+// max fee limits replacements are held to, it may be nil for no limits.
+// Stalls are measured against, and checks scheduled by, the clock.
+func NewTxManagerETH(db *gorm.DB, client TxManagerETHClient, wallet wallet.Wallet, registry *dynamic.Registry, cfg app.GasReplaceConfig, clock clock.Clock) (*TxManagerETH, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/pdp/tasks")
+	replacements, err := telemetry.NewCounter(
+		meter,
+		"message_replacements",
+		"records transactions resent because they stalled or were dropped, by kind (bump or resubmit)",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &TxManagerETH{
+		db:           db,
+		client:       client,
+		wallet:       wallet,
+		registry:     registry,
+		cfg:          cfg,
+		clock:        clock,
+		replacements: replacements,
+		stopped:      make(chan struct{}),
+	}, nil
+}
+
+// Start checks sent transactions every ReplaceCheckInterval until stopped or
+// ctx is done.
+func (m *TxManagerETH) Start(ctx context.Context) {
+	ctx, m.cancel = context.WithCancel(ctx)
+	go m.run(ctx)
+}
+
+// Stop cancels the check in progress and waits for it to return, or for ctx
+// to be done.
+func (m *TxManagerETH) Stop(ctx context.Context) error {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	select {
+	case <-m.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (m *TxManagerETH) run(ctx context.Context) {
+	defer close(m.stopped)
+
+	ticker := m.clock.Ticker(ReplaceCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, ReplaceCheckInterval)
+			if err := m.Reconcile(checkCtx); err != nil {
+				log.Errorw("reconciling sent transactions", "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Reconcile checks every sent transaction that is still waited on, bumping
+// the fees of stalled transactions and resubmitting dropped ones.
+func (m *TxManagerETH) Reconcile(ctx context.Context) error {
+	var sends []models.MessageSendsEth
+	err := m.db.WithContext(ctx).
+		Where("send_success = ?", true).
+		Where("signed_hash IN (?)", m.db.Model(&models.MessageWaitsEth{}).
+			Select("signed_tx_hash").
+			Where("tx_status = ?", "pending")).
+		Order("nonce").
+		Find(&sends).Error
+	if err != nil {
+		return fmt.Errorf("getting pending transactions: %w", err)
+	}
+
+	for _, send := range sends {
+		if err := m.reconcile(ctx, send); err != nil {
+			log.Errorw("reconciling transaction", "task_id", send.SendTaskID, "hash", send.SignedHash, "error", err)
+		}
+	}
+	return nil
+}
+
+func (m *TxManagerETH) reconcile(ctx context.Context, send models.MessageSendsEth) error {
+	if send.SignedHash == nil || send.SendTime == nil {
+		return nil
+	}
+	var reps []models.MessageReplacementsEth
+	if err := m.db.WithContext(ctx).
+		Where("send_task_id = ?", send.SendTaskID).
+		Order("sent_at").
+		Find(&reps).Error; err != nil {
+		return fmt.Errorf("getting replacements: %w", err)
+	}
+
+	hashes := []common.Hash{common.HexToHash(*send.SignedHash)}
+	latest, sentAt := send.SignedTx, *send.SendTime
+	for _, r := range reps {
+		hashes = append(hashes, common.HexToHash(r.SignedHash))
+		latest, sentAt = r.SignedTx, r.SentAt
+	}
+	if m.clock.Now().Sub(sentAt) < m.cfg.StallTimeout {
+		return nil
+	}
+
+	// included transactions are confirmed by the message watcher
+	for _, h := range hashes {
+		_, err := m.client.TransactionReceipt(ctx, h)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return fmt.Errorf("getting receipt of %s: %w", h, err)
+		}
+	}
+
+	tx := new(ethtypes.Transaction)
+	if err := tx.UnmarshalBinary(latest); err != nil {
+		return fmt.Errorf("unmarshaling signed transaction: %w", err)
+	}
+	_, pending, err := m.client.TransactionByHash(ctx, tx.Hash())
+	if errors.Is(err, ethereum.NotFound) {
+		return m.resubmit(ctx, send, reps, tx)
+	}
+	if err != nil {
+		return fmt.Errorf("getting transaction %s: %w", tx.Hash(), err)
+	}
+	if !pending {
+		// included, but the receipt is not available yet
+		return nil
+	}
+	if uint(len(reps)) >= m.cfg.MaxBumps {
+		log.Warnw("transaction stalled after max fee bumps", "task_id", send.SendTaskID, "hash", tx.Hash(), "nonce", tx.Nonce(), "bumps", len(reps))
+		return nil
+	}
+	return m.bump(ctx, send, tx)
+}
+
+// resubmit sends a transaction dropped from the mempool again.
+func (m *TxManagerETH) resubmit(ctx context.Context, send models.MessageSendsEth, reps []models.MessageReplacementsEth, tx *ethtypes.Transaction) error {
+	log.Infow("resubmitting dropped transaction", "task_id", send.SendTaskID, "hash", tx.Hash(), "nonce", tx.Nonce())
+	if err := m.client.SendTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("resubmitting transaction: %w", err)
+	}
+	m.replacements.Inc(ctx, attribute.String("kind", "resubmit"), attribute.String("method", send.SendReason))
+
+	now := m.clock.Now()
+	if len(reps) == 0 {
+		return m.db.WithContext(ctx).Model(&models.MessageSendsEth{}).
+			Where("send_task_id = ?", send.SendTaskID).
+			Update("send_time", now).Error
+	}
+	return m.db.WithContext(ctx).Model(&models.MessageReplacementsEth{}).
+		Where("signed_hash = ?", reps[len(reps)-1].SignedHash).
+		Update("sent_at", now).Error
+}
+
+// bump replaces a stalled transaction with one at higher fees.
+func (m *TxManagerETH) bump(ctx context.Context, send models.MessageSendsEth, tx *ethtypes.Transaction) error {
+	header, err := m.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("getting latest header: %w", err)
+	}
+	if header.BaseFee == nil {
+		return errors.New("base fee not available")
+	}
+	tip, err := m.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return fmt.Errorf("suggesting gas tip cap: %w", err)
+	}
+
+	replacement := bumpFees(tx, header.BaseFee, tip)
+	if maxFee := maxFeeForReason(m.registry, send.SendReason); maxFee > 0 {
+		cost := new(big.Int).Mul(replacement.GasFeeCap(), new(big.Int).SetUint64(replacement.Gas()))
+		if cost.Cmp(new(big.Int).SetUint64(maxFee)) > 0 {
+			log.Warnw("replacement fee exceeds configured max, not bumping",
+				"task_id", send.SendTaskID,
+				"estimated_cost_wei", cost.String(),
+				"max_fee_wei", maxFee,
+			)
+			return nil
+		}
+	}
+
+	chainID, err := m.client.NetworkID(ctx)
+	if err != nil {
+		return fmt.Errorf("getting network ID: %w", err)
+	}
+	from := common.HexToAddress(send.FromAddress)
+	signed, err := m.wallet.SignTransaction(ctx, from, ethtypes.LatestSignerForChainID(chainID), replacement)
+	if err != nil {
+		return fmt.Errorf("signing replacement: %w", err)
+	}
+	signedData, err := signed.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("serializing replacement: %w", err)
+	}
+
+	log.Infow("replacing stalled transaction",
+		"task_id", send.SendTaskID,
+		"hash", tx.Hash(),
+		"replacement", signed.Hash(),
+		"nonce", tx.Nonce(),
+		"fee_cap", replacement.GasFeeCap().String(),
+	)
+	if err := m.client.SendTransaction(ctx, signed); err != nil {
+		return fmt.Errorf("sending replacement: %w", err)
+	}
+	m.replacements.Inc(ctx, attribute.String("kind", "bump"), attribute.String("method", send.SendReason))
+
+	return m.db.WithContext(ctx).Create(&models.MessageReplacementsEth{
+		SignedHash:   signed.Hash().Hex(),
+		OriginalHash: *send.SignedHash,
+		SendTaskID:   send.SendTaskID,
+		SignedTx:     signedData,
+		SentAt:       m.clock.Now(),
+	}).Error
+}
+
+// bumpFees returns an unsigned copy of tx with its fees raised by
+// [FeeBumpPercent], and at least to the current base fee plus tip.
+func bumpFees(tx *ethtypes.Transaction, baseFee, tip *big.Int) *ethtypes.Transaction {
+	if tx.Type() == ethtypes.DynamicFeeTxType {
+		tipCap := maxBig(bumped(tx.GasTipCap()), tip)
+		feeCap := maxBig(bumped(tx.GasFeeCap()), new(big.Int).Add(baseFee, tipCap))
+		return ethtypes.NewTx(&ethtypes.DynamicFeeTx{
+			ChainID:   tx.ChainId(),
+			Nonce:     tx.Nonce(),
+			GasTipCap: tipCap,
+			GasFeeCap: feeCap,
+			Gas:       tx.Gas(),
+			To:        tx.To(),
+			Value:     tx.Value(),
+			Data:      tx.Data(),
+		})
+	}
+	price := maxBig(bumped(tx.GasPrice()), new(big.Int).Add(baseFee, tip))
+	return ethtypes.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), price, tx.Data())
+}
+
+func bumped(v *big.Int) *big.Int {
+	b := new(big.Int).Mul(v, big.NewInt(100+FeeBumpPercent))
+	return b.Div(b, big.NewInt(100)).Add(b, big.NewInt(1))
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// nextNonce returns the nonce of the next transaction sent from an address:
+// the pending nonce of the chain, or the nonce after the last transaction sent
+// if the chain has not seen it, e.g. because it was dropped. The transaction
+// manager resubmits dropped transactions to fill such gaps.
+func nextNonce(ctx context.Context, db *gorm.DB, client SenderETHClient, from common.Address) (uint64, error) {
+	pendingNonce, err := client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return 0, fmt.Errorf("getting pending nonce: %w", err)
+	}
+
+	// Get max nonce from successful transactions in DB
+	var dbNonce *int64
+	err = db.Model(&models.MessageSendsEth{}).
+		Where("from_address = ? AND send_success = ?", from.Hex(), true).
+		Select("MAX(nonce)").Scan(&dbNonce).Error
+	if err != nil {
+		return 0, fmt.Errorf("getting max nonce from db: %w", err)
+	}
+
+	if dbNonce != nil && uint64(*dbNonce)+1 > pendingNonce {
+		return uint64(*dbNonce) + 1, nil
+	}
+	return pendingNonce, nil
+}
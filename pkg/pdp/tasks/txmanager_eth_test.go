@@ -0,0 +1,294 @@
+package tasks
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/raulk/clock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/store/local/keystore"
+)
+
+// fakeTxManagerClient adds the sending methods of TxManagerETHClient to
+// fakeEthClient, recording the transactions sent.
+type fakeTxManagerClient struct {
+	*fakeEthClient
+
+	sentMu sync.Mutex
+	sent   []*types.Transaction
+}
+
+func (c *fakeTxManagerClient) NetworkID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(314159), nil
+}
+
+func (c *fakeTxManagerClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{BaseFee: big.NewInt(100)}, nil
+}
+
+func (c *fakeTxManagerClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return 0, nil
+}
+
+func (c *fakeTxManagerClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return 21000, nil
+}
+
+func (c *fakeTxManagerClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.sentMu.Lock()
+	defer c.sentMu.Unlock()
+	c.sent = append(c.sent, tx)
+	return nil
+}
+
+func (c *fakeTxManagerClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(10), nil
+}
+
+func (c *fakeTxManagerClient) setPending(tx *types.Transaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.txResponses[tx.Hash()] = &txResponse{tx: tx, isPending: true}
+}
+
+// unsignedWallet returns transactions unsigned.
+type unsignedWallet struct{}
+
+func (unsignedWallet) Import(ctx context.Context, ki *keystore.KeyInfo) (common.Address, error) {
+	return common.Address{}, nil
+}
+
+func (unsignedWallet) SignTransaction(ctx context.Context, addr common.Address, signer types.Signer, tx *types.Transaction) (*types.Transaction, error) {
+	return tx, nil
+}
+
+// insertSentTx records tx as sent at sentAt and waited on.
+func insertSentTx(t *testing.T, db *gorm.DB, tx *types.Transaction, sentAt time.Time) models.MessageSendsEth {
+	data, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	send := models.MessageSendsEth{
+		FromAddress:  common.HexToAddress("0x01").Hex(),
+		ToAddress:    tx.To().Hex(),
+		SendReason:   "pdp-addroots",
+		UnsignedTx:   data,
+		UnsignedHash: tx.Hash().Hex(),
+		Nonce:        models.Ptr(int64(tx.Nonce())),
+		SignedTx:     data,
+		SignedHash:   models.Ptr(tx.Hash().Hex()),
+		SendTime:     models.Ptr(sentAt),
+		SendSuccess:  models.Ptr(true),
+		SendError:    models.Ptr(""),
+	}
+	require.NoError(t, db.Create(&send).Error)
+	require.NoError(t, db.Create(&models.MessageWaitsEth{
+		SignedTxHash: tx.Hash().Hex(),
+		TxStatus:     "pending",
+	}).Error)
+	return send
+}
+
+// newTestTxManager creates a transaction manager whose clock starts at the
+// current time.
+func newTestTxManager(t *testing.T, db *gorm.DB, client *fakeTxManagerClient, maxBumps uint) (*TxManagerETH, *clock.Mock) {
+	clk := clock.NewMock()
+	clk.Set(time.Now())
+	tm, err := NewTxManagerETH(db, client, unsignedWallet{}, nil, app.GasReplaceConfig{
+		StallTimeout: 10 * time.Minute,
+		MaxBumps:     maxBumps,
+	}, clk)
+	require.NoError(t, err)
+	return tm, clk
+}
+
+func (c *fakeTxManagerClient) sentCount() int {
+	c.sentMu.Lock()
+	defer c.sentMu.Unlock()
+	return len(c.sent)
+}
+
+func TestTxManager(t *testing.T) {
+	t.Run("replaces stalled transactions at a higher fee", func(t *testing.T) {
+		db := setupTestDB(t)
+		client := &fakeTxManagerClient{fakeEthClient: newFakeEthClient()}
+		tm, clk := newTestTxManager(t, db, client, 5)
+
+		tx := createTestTransaction(7)
+		client.setPending(tx)
+		insertSentTx(t, db, tx, clk.Now().Add(-time.Hour))
+
+		require.NoError(t, tm.Reconcile(t.Context()))
+		require.Len(t, client.sent, 1)
+		replacement := client.sent[0]
+		require.Equal(t, tx.Nonce(), replacement.Nonce())
+		// the base fee plus tip exceeds the bumped price of 1
+		require.Equal(t, big.NewInt(110), replacement.GasPrice())
+
+		var reps []models.MessageReplacementsEth
+		require.NoError(t, db.Find(&reps).Error)
+		require.Len(t, reps, 1)
+		require.Equal(t, tx.Hash().Hex(), reps[0].OriginalHash)
+		require.Equal(t, replacement.Hash().Hex(), reps[0].SignedHash)
+
+		// the replacement has not stalled yet
+		require.NoError(t, tm.Reconcile(t.Context()))
+		require.Len(t, client.sent, 1)
+	})
+
+	t.Run("resubmits dropped transactions", func(t *testing.T) {
+		db := setupTestDB(t)
+		client := &fakeTxManagerClient{fakeEthClient: newFakeEthClient()}
+		tm, clk := newTestTxManager(t, db, client, 5)
+
+		tx := createTestTransaction(3)
+		send := insertSentTx(t, db, tx, clk.Now().Add(-time.Hour))
+
+		require.NoError(t, tm.Reconcile(t.Context()))
+		require.Len(t, client.sent, 1)
+		require.Equal(t, tx.Hash(), client.sent[0].Hash())
+
+		var row models.MessageSendsEth
+		require.NoError(t, db.Where("send_task_id = ?", send.SendTaskID).First(&row).Error)
+		require.WithinDuration(t, clk.Now(), *row.SendTime, time.Second)
+	})
+
+	t.Run("leaves included and recent transactions", func(t *testing.T) {
+		db := setupTestDB(t)
+		client := &fakeTxManagerClient{fakeEthClient: newFakeEthClient()}
+		tm, clk := newTestTxManager(t, db, client, 5)
+
+		included := createTestTransaction(1)
+		client.addReceipt(included.Hash(), createTestReceipt(100, 1), 0)
+		insertSentTx(t, db, included, clk.Now().Add(-time.Hour))
+
+		recent := createTestTransaction(2)
+		client.setPending(recent)
+		insertSentTx(t, db, recent, clk.Now().Add(-time.Minute))
+
+		require.NoError(t, tm.Reconcile(t.Context()))
+		require.Empty(t, client.sent)
+	})
+
+	t.Run("replaces transactions once they stall on the clock", func(t *testing.T) {
+		db := setupTestDB(t)
+		client := &fakeTxManagerClient{fakeEthClient: newFakeEthClient()}
+		tm, clk := newTestTxManager(t, db, client, 5)
+
+		tx := createTestTransaction(4)
+		client.setPending(tx)
+		insertSentTx(t, db, tx, clk.Now())
+
+		clk.Add(9 * time.Minute)
+		require.NoError(t, tm.Reconcile(t.Context()))
+		require.Empty(t, client.sent)
+
+		clk.Add(2 * time.Minute)
+		require.NoError(t, tm.Reconcile(t.Context()))
+		require.Len(t, client.sent, 1)
+
+		// the replacement stalls a stall timeout after it was sent
+		client.setPending(client.sent[0])
+		clk.Add(11 * time.Minute)
+		require.NoError(t, tm.Reconcile(t.Context()))
+		require.Len(t, client.sent, 2)
+	})
+
+	t.Run("checks for stalls every interval", func(t *testing.T) {
+		db := setupTestDB(t)
+		client := &fakeTxManagerClient{fakeEthClient: newFakeEthClient()}
+		tm, clk := newTestTxManager(t, db, client, 5)
+
+		tx := createTestTransaction(6)
+		client.setPending(tx)
+		insertSentTx(t, db, tx, clk.Now().Add(-time.Hour))
+
+		tm.Start(t.Context())
+		t.Cleanup(func() {
+			require.NoError(t, tm.Stop(context.Background()))
+		})
+		require.Eventually(t, func() bool {
+			clk.Add(ReplaceCheckInterval)
+			return client.sentCount() > 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("stops bumping after max bumps", func(t *testing.T) {
+		db := setupTestDB(t)
+		client := &fakeTxManagerClient{fakeEthClient: newFakeEthClient()}
+		tm, clk := newTestTxManager(t, db, client, 0)
+
+		tx := createTestTransaction(5)
+		client.setPending(tx)
+		insertSentTx(t, db, tx, clk.Now().Add(-time.Hour))
+
+		require.NoError(t, tm.Reconcile(t.Context()))
+		require.Empty(t, client.sent)
+	})
+}
+
+func TestBumpFees(t *testing.T) {
+	t.Run("legacy", func(t *testing.T) {
+		tx := types.NewTransaction(1, common.HexToAddress("0x02"), big.NewInt(0), 21000, big.NewInt(1000), nil)
+		bumped := bumpFees(tx, big.NewInt(100), big.NewInt(10))
+		require.Equal(t, big.NewInt(1251), bumped.GasPrice())
+		require.Equal(t, tx.Nonce(), bumped.Nonce())
+	})
+
+	t.Run("dynamic fee", func(t *testing.T) {
+		to := common.HexToAddress("0x02")
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(314159),
+			Nonce:     1,
+			GasTipCap: big.NewInt(100),
+			GasFeeCap: big.NewInt(1000),
+			Gas:       21000,
+			To:        &to,
+		})
+		bumped := bumpFees(tx, big.NewInt(2000), big.NewInt(10))
+		require.Equal(t, big.NewInt(126), bumped.GasTipCap())
+		// the base fee has risen above the bumped fee cap
+		require.Equal(t, big.NewInt(2126), bumped.GasFeeCap())
+	})
+}
+
+func TestCheckTransaction_Replacement(t *testing.T) {
+	db := setupTestDB(t)
+	client := newFakeEthClient()
+	mw := &MessageWatcherEth{
+		db:               db,
+		api:              client,
+		maxEthAPIRetries: 3,
+	}
+
+	original := createTestTransaction(1)
+	replacement := types.NewTransaction(1, *original.To(), original.Value(), original.Gas(), big.NewInt(2), original.Data())
+	data, err := replacement.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.MessageReplacementsEth{
+		SignedHash:   replacement.Hash().Hex(),
+		OriginalHash: original.Hash().Hex(),
+		SendTaskID:   1,
+		SignedTx:     data,
+		SentAt:       time.Now(),
+	}).Error)
+
+	receipt := createTestReceipt(100, 1)
+	client.addReceipt(replacement.Hash(), receipt, 0)
+	client.addTransaction(replacement.Hash(), replacement, 0)
+
+	result, err := mw.checkTransaction(t.Context(), original.Hash(), big.NewInt(100+MinConfidence))
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	require.Equal(t, original.Hash().Hex(), result.TxHash)
+	require.Equal(t, receipt, result.Receipt)
+	require.Equal(t, replacement.Hash(), result.Transaction.Hash())
+}
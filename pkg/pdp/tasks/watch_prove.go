@@ -0,0 +1,64 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	chainyypes "github.com/filecoin-project/lotus/chain/types"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/pdp/chainsched"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/piecelog"
+)
+
+// NewWatcherProve sets up the watcher for proofs sent by the prove task. The
+// roots of a proof set are recorded in the piece log as proven once the proof
+// message is confirmed on chain, or as faulted if it failed.
+func NewWatcherProve(db *gorm.DB, pcs *chainsched.Scheduler, pieces *piecelog.Log) error {
+	if err := pcs.AddHandler(func(ctx context.Context, revert, apply *chainyypes.TipSet) error {
+		err := processPendingProves(ctx, db, pieces)
+		if err != nil {
+			log.Errorf("Failed to process pending proves: %v", err)
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// processPendingProves processes proof messages that have been confirmed on-chain
+func processPendingProves(ctx context.Context, db *gorm.DB, pieces *piecelog.Log) error {
+	var waits []struct {
+		ProveMessageHash string
+		ProofsetID       int64
+		TxSuccess        *bool
+	}
+	err := db.WithContext(ctx).
+		Model(&models.PDPProveWait{}).
+		Select("pdp_prove_waits.prove_message_hash, pdp_prove_waits.proofset_id, message_waits_eth.tx_success").
+		Joins("JOIN message_waits_eth ON message_waits_eth.signed_tx_hash = pdp_prove_waits.prove_message_hash").
+		Where("message_waits_eth.tx_status = ?", "confirmed").
+		Scan(&waits).Error
+	if err != nil {
+		return fmt.Errorf("failed to select pending proves: %w", err)
+	}
+
+	for _, wait := range waits {
+		ev := piecelog.Event{Kind: piecelog.Proven, ProofSet: uint64(wait.ProofsetID)}
+		if wait.TxSuccess == nil || !*wait.TxSuccess {
+			ev = piecelog.Event{Kind: piecelog.Faulted, Error: fmt.Sprintf("proof message %s failed", wait.ProveMessageHash)}
+		}
+		recordProofSetRoots(ctx, db, pieces, wait.ProofsetID, ev)
+
+		if err := db.WithContext(ctx).
+			Where("prove_message_hash = ?", wait.ProveMessageHash).
+			Delete(&models.PDPProveWait{}).Error; err != nil {
+			log.Warnf("Failed to delete prove wait for tx %s: %v", wait.ProveMessageHash, err)
+		}
+	}
+
+	return nil
+}
@@ -14,21 +14,26 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 
 	types2 "github.com/filecoin-project/lotus/chain/types"
 
+	"github.com/storacha/piri/lib/telemetry"
 	"github.com/storacha/piri/pkg/pdp/chainsched"
 	"github.com/storacha/piri/pkg/pdp/service/models"
 )
 
-// TODO allow this to be tuned based on network and user preferences for risk, ideally through config
-
-// MinConfidence defines how many blocks must be applied before we accept the message as applied.
-// Synonymous with finality
+// MinConfidence defines how many blocks must be applied before we accept the
+// message as applied, unless configured with [WithConfirmations].
 const MinConfidence = 6
 
+// DefaultReorgWindow is the number of blocks after which confirmed messages
+// are no longer checked for reorgs, unless configured with [WithReorgWindow].
+const DefaultReorgWindow = 30
+
 // Retry and concurrency configuration
 const (
 	// Maximum number of concurrent transaction checks
@@ -40,6 +45,9 @@ const (
 	// Timeout for sets of Ethereum client calls per transaction
 	// (i.e. receipt and transaction data)
 	defaultAPITimeout = 3 * time.Second
+
+	// Maximum number of receipts requested in a single batch of calls
+	receiptBatchSize = 100
 )
 
 type MessageWatcherEthClient interface {
@@ -47,6 +55,12 @@ type MessageWatcherEthClient interface {
 	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
 }
 
+// batchClient is implemented by clients able to send batches of calls in a
+// single request, such as *ethclient.Client.
+type batchClient interface {
+	Client() *rpc.Client
+}
+
 // TransactionResult holds all the data needed to update a transaction in the database
 type TransactionResult struct {
 	TxHash               string
@@ -69,6 +83,14 @@ type MessageWatcherEth struct {
 
 	maxEthAPIRetries uint
 	ethAPITimeout    time.Duration
+
+	// confirmations is the number of blocks a message must be buried under
+	// to be confirmed, MinConfidence if 0.
+	confirmations uint64
+	// reorgWindow is the number of blocks confirmed messages are checked for
+	// reorgs for. 0 disables the check.
+	reorgWindow uint64
+	reorgs      *telemetry.Counter
 }
 
 // WatcherOption is a functional option for configuring MessageWatcherEth
@@ -81,7 +103,35 @@ func WithMaxEthAPIRetries(n uint) WatcherOption {
 	}
 }
 
+// WithConfirmations sets the number of blocks a message must be buried under
+// to be confirmed.
+func WithConfirmations(n uint64) WatcherOption {
+	return func(mw *MessageWatcherEth) {
+		if n > 0 {
+			mw.confirmations = n
+		}
+	}
+}
+
+// WithReorgWindow sets the number of blocks confirmed messages are checked
+// for reorgs for, 0 to disable the check.
+func WithReorgWindow(n uint64) WatcherOption {
+	return func(mw *MessageWatcherEth) {
+		mw.reorgWindow = n
+	}
+}
+
 func NewMessageWatcherEth(db *gorm.DB, pcs *chainsched.Scheduler, api MessageWatcherEthClient, opts ...WatcherOption) (*MessageWatcherEth, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/pdp/tasks")
+	reorgs, err := telemetry.NewCounter(
+		meter,
+		"piri_message_reorgs",
+		"records confirmed messages whose block was reorged out of the chain",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
 	mw := &MessageWatcherEth{
 		db:               db,
 		api:              api,
@@ -90,6 +140,9 @@ func NewMessageWatcherEth(db *gorm.DB, pcs *chainsched.Scheduler, api MessageWat
 		updateCh:         make(chan struct{}, 1),
 		maxEthAPIRetries: defaultMaxAPIRetries,
 		ethAPITimeout:    defaultAPITimeout,
+		confirmations:    MinConfidence,
+		reorgWindow:      DefaultReorgWindow,
+		reorgs:           reorgs,
 	}
 
 	// Apply options
@@ -140,12 +193,16 @@ func (mw *MessageWatcherEth) update() {
 		return
 	}
 
-	confirmedBlockNumber := new(big.Int).Sub(bestBlockNumber, big.NewInt(MinConfidence))
+	confirmedBlockNumber := new(big.Int).Sub(bestBlockNumber, new(big.Int).SetUint64(mw.confidence()))
 	if confirmedBlockNumber.Sign() < 0 {
 		// Not enough blocks yet
 		return
 	}
 
+	// Roll back confirmations reorged out of the chain first, so the messages
+	// are waited on again below
+	mw.checkReorgs(ctx, bestBlockNumber)
+
 	machineID := 1
 
 	// Assign pending transactions with null owner to ourselves
@@ -246,8 +303,13 @@ func (mw *MessageWatcherEth) update() {
 
 // checkTransaction fetches transaction data with retry logic
 func (mw *MessageWatcherEth) checkTransaction(ctx context.Context, txHash common.Hash, bestBlockNumber *big.Int) (*TransactionResult, error) {
-	// First, get the receipt with retries
+	// First, get the receipt with retries, of the transaction or of one
+	// replacing it at a higher fee
 	receipt, err := mw.getReceiptWithRetry(ctx, txHash)
+	includedHash := txHash
+	if errors.Is(err, ethereum.NotFound) {
+		receipt, includedHash, err = mw.getReplacementReceipt(ctx, txHash)
+	}
 	if err != nil {
 		if errors.Is(err, ethereum.NotFound) {
 			// Transaction is still pending
@@ -258,13 +320,13 @@ func (mw *MessageWatcherEth) checkTransaction(ctx context.Context, txHash common
 
 	// Check if the transaction has enough confirmations
 	confirmations := new(big.Int).Sub(bestBlockNumber, receipt.BlockNumber)
-	if confirmations.Cmp(big.NewInt(MinConfidence)) < 0 {
+	if confirmations.Cmp(new(big.Int).SetUint64(mw.confidence())) < 0 {
 		// Not enough confirmations yet
 		return nil, nil
 	}
 
 	// Get the transaction data with retries
-	txData, err := mw.getTransactionWithRetry(ctx, txHash)
+	txData, err := mw.getTransactionWithRetry(ctx, includedHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction data after retries: %w", err)
 	}
@@ -291,6 +353,26 @@ func (mw *MessageWatcherEth) checkTransaction(ctx context.Context, txHash common
 	}, nil
 }
 
+// getReplacementReceipt fetches the receipt of a transaction replacing
+// txHash, returning ethereum.NotFound if none was included.
+func (mw *MessageWatcherEth) getReplacementReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, common.Hash, error) {
+	var reps []models.MessageReplacementsEth
+	if err := mw.db.WithContext(ctx).
+		Where("original_hash = ?", txHash.Hex()).
+		Find(&reps).Error; err != nil {
+		return nil, common.Hash{}, fmt.Errorf("getting replacements: %w", err)
+	}
+	for _, r := range reps {
+		h := common.HexToHash(r.SignedHash)
+		receipt, err := mw.getReceiptWithRetry(ctx, h)
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		return receipt, h, err
+	}
+	return nil, common.Hash{}, ethereum.NotFound
+}
+
 // getReceiptWithRetry fetches a transaction receipt with exponential backoff retry
 func (mw *MessageWatcherEth) getReceiptWithRetry(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
 	return backoff.Retry(ctx, func() (*types.Receipt, error) {
@@ -328,6 +410,7 @@ func (mw *MessageWatcherEth) updateTransaction(result *TransactionResult) error
 		Updates(models.MessageWaitsEth{
 			WaiterMachineID:      nil,
 			ConfirmedBlockNumber: models.Ptr(result.ConfirmedBlockNumber),
+			ConfirmedBlockHash:   result.Receipt.BlockHash.Hex(),
 			ConfirmedTxHash:      result.Receipt.TxHash.Hex(),
 			ConfirmedTxData:      result.TxDataJSON,
 			TxStatus:             "confirmed",
@@ -336,6 +419,161 @@ func (mw *MessageWatcherEth) updateTransaction(result *TransactionResult) error
 		}).Error
 }
 
+func (mw *MessageWatcherEth) confidence() uint64 {
+	if mw.confirmations == 0 {
+		return MinConfidence
+	}
+	return mw.confirmations
+}
+
+// checkReorgs rolls back the confirmation of messages confirmed within the
+// reorg window whose block is no longer on the chain. The messages are
+// pending again: they are confirmed once included again, and resubmitted by
+// the transaction manager if they were dropped.
+//
+// A block is either still on the chain or not, so the receipt of a single
+// message is checked per block, and the receipts of all blocks in the window
+// are fetched together.
+func (mw *MessageWatcherEth) checkReorgs(ctx context.Context, bestBlockNumber *big.Int) {
+	if mw.reorgWindow == 0 {
+		return
+	}
+	from := bestBlockNumber.Int64() - int64(mw.reorgWindow)
+	var waits []models.MessageWaitsEth
+	err := mw.db.WithContext(ctx).
+		Where("tx_status = ?", "confirmed").
+		Where("confirmed_block_number >= ?", from).
+		Where("confirmed_block_hash <> ''").
+		Order("confirmed_block_number").
+		Find(&waits).Error
+	if err != nil {
+		log.Errorf("failed to get confirmed transactions: %+v", err)
+		return
+	}
+
+	// group the messages by the block they were confirmed in
+	var blocks []string
+	byBlock := map[string][]models.MessageWaitsEth{}
+	for _, wait := range waits {
+		if _, ok := byBlock[wait.ConfirmedBlockHash]; !ok {
+			blocks = append(blocks, wait.ConfirmedBlockHash)
+		}
+		byBlock[wait.ConfirmedBlockHash] = append(byBlock[wait.ConfirmedBlockHash], wait)
+	}
+	hashes := make([]common.Hash, len(blocks))
+	for i, block := range blocks {
+		hashes[i] = common.HexToHash(byBlock[block][0].ConfirmedTxHash)
+	}
+	receipts, errs := mw.getReceipts(ctx, hashes)
+
+	for i, block := range blocks {
+		receipt, err := receipts[i], errs[i]
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			log.Errorf("failed to check confirmed transactions of block %s: %+v", block, err)
+			continue
+		}
+		if err == nil && receipt.BlockHash.Hex() == block {
+			continue
+		}
+
+		for _, wait := range byBlock[block] {
+			log.Warnw("confirmed transaction reorged, waiting for it again",
+				"hash", wait.SignedTxHash,
+				"block", *wait.ConfirmedBlockNumber,
+				"blockHash", wait.ConfirmedBlockHash)
+			if err := mw.rollbackTransaction(ctx, wait.SignedTxHash); err != nil {
+				log.Errorf("failed to roll back transaction %s: %+v", wait.SignedTxHash, err)
+				continue
+			}
+			if mw.reorgs != nil {
+				mw.reorgs.Inc(ctx)
+			}
+		}
+	}
+}
+
+// getReceipts fetches the receipts of transactions, with the error of each
+// fetch. A transaction with no receipt has the error ethereum.NotFound.
+// Clients able to batch calls are sent receiptBatchSize calls per request,
+// other clients a call per receipt.
+func (mw *MessageWatcherEth) getReceipts(ctx context.Context, hashes []common.Hash) ([]*types.Receipt, []error) {
+	receipts := make([]*types.Receipt, len(hashes))
+	errs := make([]error, len(hashes))
+	bc, ok := mw.api.(batchClient)
+	if !ok {
+		for i, h := range hashes {
+			receipts[i], errs[i] = mw.getReceiptWithRetry(ctx, h)
+		}
+		return receipts, errs
+	}
+
+	for start := 0; start < len(hashes); start += receiptBatchSize {
+		end := min(start+receiptBatchSize, len(hashes))
+		batch := make([]rpc.BatchElem, 0, end-start)
+		for i := start; i < end; i++ {
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []any{hashes[i]},
+				Result: &receipts[i],
+			})
+		}
+		_, err := backoff.Retry(ctx, func() (struct{}, error) {
+			batchCtx, cancel := context.WithTimeout(ctx, mw.ethAPITimeout)
+			defer cancel()
+			return struct{}{}, bc.Client().BatchCallContext(batchCtx, batch)
+		}, backoff.WithMaxTries(mw.maxEthAPIRetries), backoff.WithBackOff(backoff.NewConstantBackOff(time.Second)))
+		for j, elem := range batch {
+			i := start + j
+			switch {
+			case err != nil:
+				errs[i] = err
+			case elem.Error != nil:
+				errs[i] = elem.Error
+			case receipts[i] == nil:
+				errs[i] = ethereum.NotFound
+			}
+		}
+	}
+	return receipts, errs
+}
+
+// rollbackTransaction marks a confirmed transaction pending again, along with
+// the outcome of the proof set creation or root addition it sent, if it was
+// not processed yet.
+func (mw *MessageWatcherEth) rollbackTransaction(ctx context.Context, txHash string) error {
+	return mw.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.MessageWaitsEth{}).
+			Where("signed_tx_hash = ? AND tx_status = ?", txHash, "confirmed").
+			Updates(map[string]any{
+				"waiter_machine_id":      nil,
+				"confirmed_block_number": nil,
+				"confirmed_block_hash":   "",
+				"confirmed_tx_hash":      "",
+				"confirmed_tx_data":      nil,
+				"tx_status":              "pending",
+				"tx_receipt":             nil,
+				"tx_success":             nil,
+			})
+		if res.Error != nil {
+			return fmt.Errorf("updating message wait: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.PDPProofsetCreate{}).
+			Where("create_message_hash = ? AND proofset_created = ?", txHash, false).
+			Update("ok", nil).Error; err != nil {
+			return fmt.Errorf("resetting proof set creation: %w", err)
+		}
+		if err := tx.Model(&models.PDPProofsetRootAdd{}).
+			Where("add_message_hash = ?", txHash).
+			Update("add_message_ok", nil).Error; err != nil {
+			return fmt.Errorf("resetting root addition: %w", err)
+		}
+		return nil
+	})
+}
+
 func (mw *MessageWatcherEth) processHeadChange(ctx context.Context, revert, apply *types2.TipSet) error {
 	if apply != nil {
 		mw.bestBlockNumber.Store(big.NewInt(int64(apply.Height())))
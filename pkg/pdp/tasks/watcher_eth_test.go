@@ -420,3 +420,135 @@ func TestUpdate_NoBestBlockNumber(t *testing.T) {
 	assert.Equal(t, int32(0), client.getCallCount("TransactionReceipt"))
 	assert.Equal(t, int32(0), client.getCallCount("TransactionByHash"))
 }
+
+func TestUpdate_Reorg(t *testing.T) {
+	db := setupTestDB(t)
+	client := newFakeEthClient()
+
+	mw := &MessageWatcherEth{
+		db:               db,
+		api:              client,
+		maxEthAPIRetries: 3,
+		reorgWindow:      DefaultReorgWindow,
+	}
+
+	canonical := common.HexToHash("0xaaaa")
+	orphaned := common.HexToHash("0xbbbb")
+	stable := common.HexToHash("0x1111")
+	reorged := common.HexToHash("0x2222")
+	for _, txHash := range []common.Hash{stable, reorged} {
+		receipt := createTestReceipt(990, 1)
+		receipt.TxHash = txHash
+		receipt.BlockHash = canonical
+		if txHash == reorged {
+			receipt.BlockHash = orphaned
+		}
+		client.addReceipt(txHash, receipt, 0)
+		client.addTransaction(txHash, createTestTransaction(1), 0)
+		err := db.Create(&models.MessageWaitsEth{SignedTxHash: txHash.Hex(), TxStatus: "pending"}).Error
+		require.NoError(t, err)
+	}
+
+	mw.bestBlockNumber.Store(big.NewInt(1000))
+	mw.update()
+	var waits []models.MessageWaitsEth
+	require.NoError(t, db.Order("signed_tx_hash").Find(&waits).Error)
+	require.Len(t, waits, 2)
+	require.Equal(t, "confirmed", waits[1].TxStatus)
+	require.Equal(t, orphaned.Hex(), waits[1].ConfirmedBlockHash)
+
+	// the block of the message is reorged out, and the message is included
+	// in a later block
+	receipt := createTestReceipt(1003, 1)
+	receipt.TxHash = reorged
+	included := common.HexToHash("0xcccc")
+	receipt.BlockHash = included
+	client.addReceipt(reorged, receipt, 0)
+
+	mw.bestBlockNumber.Store(big.NewInt(1005))
+	mw.update()
+	var wait models.MessageWaitsEth
+	require.NoError(t, db.Where("signed_tx_hash = ?", reorged.Hex()).Take(&wait).Error)
+	require.Equal(t, "pending", wait.TxStatus)
+	require.Nil(t, wait.ConfirmedBlockNumber)
+	var stableWait models.MessageWaitsEth
+	require.NoError(t, db.Where("signed_tx_hash = ?", stable.Hex()).Take(&stableWait).Error)
+	require.Equal(t, "confirmed", stableWait.TxStatus)
+
+	// confirmed again once buried in its new block
+	mw.bestBlockNumber.Store(big.NewInt(1003 + MinConfidence))
+	mw.update()
+	var rewait models.MessageWaitsEth
+	require.NoError(t, db.Where("signed_tx_hash = ?", reorged.Hex()).Take(&rewait).Error)
+	require.Equal(t, "confirmed", rewait.TxStatus)
+	require.Equal(t, int64(1003), *rewait.ConfirmedBlockNumber)
+	require.Equal(t, included.Hex(), rewait.ConfirmedBlockHash)
+}
+
+func TestCheckReorgs(t *testing.T) {
+	db := setupTestDB(t)
+	client := newFakeEthClient()
+
+	mw := &MessageWatcherEth{
+		db:               db,
+		api:              client,
+		maxEthAPIRetries: 3,
+		reorgWindow:      DefaultReorgWindow,
+	}
+
+	orphaned := common.HexToHash("0xbbbb")
+	confirm := func(txHash common.Hash, block int64, blockHash common.Hash) {
+		t.Helper()
+		require.NoError(t, db.Create(&models.MessageWaitsEth{
+			SignedTxHash:         txHash.Hex(),
+			TxStatus:             "confirmed",
+			ConfirmedBlockNumber: models.Ptr(block),
+			ConfirmedBlockHash:   blockHash.Hex(),
+			ConfirmedTxHash:      txHash.Hex(),
+		}).Error)
+	}
+	// three messages in a block reorged out of the chain, and one confirmed
+	// before the reorg window
+	for _, txHash := range []common.Hash{common.HexToHash("0x1111"), common.HexToHash("0x2222"), common.HexToHash("0x3333")} {
+		confirm(txHash, 990, orphaned)
+	}
+	old := common.HexToHash("0x4444")
+	confirm(old, 900, orphaned)
+
+	mw.checkReorgs(t.Context(), big.NewInt(1000))
+
+	// a single receipt is checked for the block
+	require.Equal(t, int32(1), client.getCallCount("TransactionReceipt"))
+	var pending int64
+	require.NoError(t, db.Model(&models.MessageWaitsEth{}).Where("tx_status = ?", "pending").Count(&pending).Error)
+	require.Equal(t, int64(3), pending)
+	var wait models.MessageWaitsEth
+	require.NoError(t, db.Where("signed_tx_hash = ?", old.Hex()).Take(&wait).Error)
+	require.Equal(t, "confirmed", wait.TxStatus)
+}
+
+func TestProcessPendingProves(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := t.Context()
+
+	confirmed, pending := "0xconfirmed", "0xpending"
+	require.NoError(t, db.Create(&models.MessageWaitsEth{
+		SignedTxHash: confirmed,
+		TxStatus:     "confirmed",
+		TxSuccess:    models.Ptr(true),
+	}).Error)
+	require.NoError(t, db.Create(&models.MessageWaitsEth{
+		SignedTxHash: pending,
+		TxStatus:     "pending",
+	}).Error)
+	require.NoError(t, db.Create(&models.PDPProveWait{ProveMessageHash: confirmed, ProofsetID: 1}).Error)
+	require.NoError(t, db.Create(&models.PDPProveWait{ProveMessageHash: pending, ProofsetID: 2}).Error)
+
+	require.NoError(t, processPendingProves(ctx, db, nil))
+
+	// only the proof confirmed on chain is processed
+	var waits []models.PDPProveWait
+	require.NoError(t, db.Find(&waits).Error)
+	require.Len(t, waits, 1)
+	require.Equal(t, pending, waits[0].ProveMessageHash)
+}
@@ -90,6 +90,15 @@ type RootAdd struct {
 	SubRoots []cid.Cid
 }
 
+// PieceRoot is a root of a proof set, with the blobs its subroots were
+// aggregated from.
+type PieceRoot struct {
+	ProofSetID uint64
+	RootID     uint64
+	Root       string
+	Blobs      []multihash.Multihash
+}
+
 type PieceAllocation struct {
 	Piece  Piece
 	Notify *url.URL
@@ -270,6 +279,10 @@ type PieceAPI interface {
 	WritePieceURL(blob uuid.UUID) (url.URL, error)
 	// ReadPieceURL returns the URL a blob may be retrieved from.
 	ReadPieceURL(blob cid.Cid) (url.URL, error)
+	// ReadPiece returns a `PieceReader` for the unpadded bytes of the piece
+	// with the given v2 piece CID: the blob it was derived from followed by
+	// its zero padding.
+	ReadPiece(ctx context.Context, piece cid.Cid, options ...ReadPieceOption) (*PieceReader, error)
 }
 
 type PieceWriterAPI interface {
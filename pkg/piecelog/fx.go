@@ -0,0 +1,20 @@
+package piecelog
+
+import (
+	"github.com/ipfs/go-datastore"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("piecelog",
+	fx.Provide(NewFromParams),
+)
+
+type Params struct {
+	fx.In
+
+	Datastore datastore.Datastore `name:"piecelog_datastore"`
+}
+
+func NewFromParams(params Params) *Log {
+	return New(params.Datastore)
+}
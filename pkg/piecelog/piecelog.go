@@ -0,0 +1,490 @@
+// Package piecelog records the lifecycle of the pieces stored on the node as
+// an append-only log of events, from allocation to removal.
+//
+// Each stage of the pipeline appends an event for the blob it handled, and the
+// state of a piece is derived by replaying its events. A projection of the
+// latest state of every piece is kept alongside the log so the status API,
+// analytics and reconciliation all read the same history, and can be rebuilt
+// from the log at any time.
+//
+// Events of an aggregate, such as it being proven, are appended to every piece
+// in the aggregate.
+package piecelog
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+)
+
+var log = logging.Logger("piecelog")
+
+const (
+	seqKey               = "/seq"
+	eventsPrefix         = "/events/"
+	statePrefix          = "/state/"
+	piecesPrefix         = "/pieces/"
+	aggregatesPrefix     = "/aggregates/"
+	aggregateStatePrefix = "/aggregate-state/"
+)
+
+// Kind is the kind of a lifecycle event. The state of a piece is the kind of
+// its last event.
+type Kind string
+
+const (
+	// Allocated is recorded when space is allocated for the blob.
+	Allocated Kind = "allocated"
+	// Uploaded is recorded when the blob has been received.
+	Uploaded Kind = "uploaded"
+	// Aggregated is recorded when the piece is added to an aggregate.
+	Aggregated Kind = "aggregated"
+	// Submitted is recorded when the aggregate is added as a root of a
+	// proof set.
+	Submitted Kind = "submitted"
+	// Proven is recorded when a proof of the aggregate is confirmed on chain.
+	Proven Kind = "proven"
+	// Faulted is recorded when the node fails to prove the aggregate.
+	Faulted Kind = "faulted"
+	// Removed is recorded when the aggregate is removed from its proof set,
+	// or the blob is removed from the last space it was stored in.
+	Removed Kind = "removed"
+)
+
+// Kinds lists the kinds of events in lifecycle order.
+var Kinds = []Kind{Allocated, Uploaded, Aggregated, Submitted, Proven, Faulted, Removed}
+
+// Event is an entry of the lifecycle log of a piece. Fields other than Kind
+// are set by the events they apply to.
+type Event struct {
+	// Seq orders events across all pieces. It is assigned on append.
+	Seq uint64 `json:"seq"`
+	// At is when the event happened. It is the time of append if not set.
+	At   time.Time `json:"at"`
+	Kind Kind      `json:"kind"`
+	// Space is the space the blob was allocated in.
+	Space string `json:"space,omitempty"`
+	// Piece is the piece CID of the blob.
+	Piece string `json:"piece,omitempty"`
+	// Aggregate is the root of the aggregate the piece is in.
+	Aggregate string `json:"aggregate,omitempty"`
+	// ProofSet is the proof set the aggregate was added to.
+	ProofSet uint64 `json:"proof_set,omitempty"`
+	// Error is the reason of a fault.
+	Error string `json:"error,omitempty"`
+}
+
+// Log persists the lifecycle events of pieces and their projected state in a
+// datastore. A nil Log records nothing, so stages can record events whether
+// or not a log is configured.
+type Log struct {
+	ds  datastore.Datastore
+	now func() time.Time
+
+	// mu serializes appends, so sequence numbers and projected states are
+	// updated in order.
+	mu     sync.Mutex
+	seq    uint64
+	seqSet bool
+}
+
+// New creates a Log persisting events in ds.
+func New(ds datastore.Datastore) *Log {
+	return &Log{ds: ds, now: time.Now}
+}
+
+// Append appends an event to the log of the blob and updates its projected
+// state.
+func (l *Log) Append(ctx context.Context, blob multihash.Multihash, ev Event) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.append(ctx, blob, ev)
+}
+
+func (l *Log) append(ctx context.Context, blob multihash.Multihash, ev Event) error {
+	if !l.seqSet {
+		seq, err := l.loadSeq(ctx)
+		if err != nil {
+			return err
+		}
+		l.seq, l.seqSet = seq, true
+	}
+	ev.Seq = l.seq + 1
+	if ev.At.IsZero() {
+		ev.At = l.now()
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+	if err := l.ds.Put(ctx, eventKey(blob, ev.Seq), data); err != nil {
+		return fmt.Errorf("putting event: %w", err)
+	}
+	if err := l.ds.Put(ctx, datastore.NewKey(seqKey), binary.BigEndian.AppendUint64(nil, ev.Seq)); err != nil {
+		return fmt.Errorf("putting sequence: %w", err)
+	}
+	l.seq = ev.Seq
+
+	state, err := l.state(ctx, blob)
+	if err != nil {
+		return err
+	}
+	state.Apply(ev)
+	return l.putState(ctx, state)
+}
+
+// LinkPiece records the piece CID of a blob, so events recorded against the
+// piece are appended to the log of the blob.
+func (l *Log) LinkPiece(ctx context.Context, piece datamodel.Link, blob multihash.Multihash) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.ds.Put(ctx, datastore.NewKey(piecesPrefix+piece.String()), blob); err != nil {
+		return fmt.Errorf("putting piece: %w", err)
+	}
+	return nil
+}
+
+// AppendAggregated appends an Aggregated event to each piece of an aggregate
+// and records the pieces of the aggregate for its later events. Pieces not
+// linked to a blob with LinkPiece are skipped.
+func (l *Log) AppendAggregated(ctx context.Context, aggregate datamodel.Link, pieces []datamodel.Link) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	at := l.now()
+	for _, p := range pieces {
+		blob, err := l.ds.Get(ctx, datastore.NewKey(piecesPrefix+p.String()))
+		if err != nil {
+			if errors.Is(err, datastore.ErrNotFound) {
+				log.Debugw("skipping aggregated piece of unknown blob", "piece", p, "aggregate", aggregate)
+				continue
+			}
+			return fmt.Errorf("getting blob of piece %s: %w", p, err)
+		}
+		if err := l.ds.Put(ctx, aggregateKey(aggregate, blob), nil); err != nil {
+			return fmt.Errorf("putting aggregate piece: %w", err)
+		}
+		if err := l.append(ctx, blob, Event{
+			At:        at,
+			Kind:      Aggregated,
+			Piece:     p.String(),
+			Aggregate: aggregate.String(),
+		}); err != nil {
+			return err
+		}
+	}
+	if err := l.ds.Put(ctx, datastore.NewKey(aggregateStatePrefix+aggregate.String()), []byte(Aggregated)); err != nil {
+		return fmt.Errorf("putting aggregate state: %w", err)
+	}
+	return nil
+}
+
+// AppendAggregate appends an event to every piece of an aggregate. Repeated
+// events of the same kind, such as a proof every proving period, are appended
+// once until the aggregate changes state.
+func (l *Log) AppendAggregate(ctx context.Context, aggregate datamodel.Link, ev Event) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stateKey := datastore.NewKey(aggregateStatePrefix + aggregate.String())
+	last, err := l.ds.Get(ctx, stateKey)
+	if err != nil && !errors.Is(err, datastore.ErrNotFound) {
+		return fmt.Errorf("getting aggregate state: %w", err)
+	}
+	if Kind(last) == ev.Kind {
+		return nil
+	}
+
+	blobs, err := l.aggregateBlobs(ctx, aggregate)
+	if err != nil {
+		return err
+	}
+	if ev.At.IsZero() {
+		ev.At = l.now()
+	}
+	ev.Aggregate = aggregate.String()
+	for _, blob := range blobs {
+		if err := l.append(ctx, blob, ev); err != nil {
+			return err
+		}
+	}
+	if err := l.ds.Put(ctx, stateKey, []byte(ev.Kind)); err != nil {
+		return fmt.Errorf("putting aggregate state: %w", err)
+	}
+	return nil
+}
+
+// Events returns the events of a blob in the order they were appended.
+func (l *Log) Events(ctx context.Context, blob multihash.Multihash) ([]Event, error) {
+	if l == nil {
+		return nil, nil
+	}
+	results, err := l.ds.Query(ctx, query.Query{
+		Prefix: eventsPrefix + digestutil.Format(blob) + "/",
+		Orders: []query.Order{query.OrderByKey{}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying events: %w", err)
+	}
+	defer results.Close()
+
+	var events []Event
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating events: %w", entry.Error)
+		}
+		var ev Event
+		if err := json.Unmarshal(entry.Value, &ev); err != nil {
+			return nil, fmt.Errorf("decoding event %s: %w", entry.Key, err)
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// State returns the projected state of a blob, and false if no event has
+// been recorded for it.
+func (l *Log) State(ctx context.Context, blob multihash.Multihash) (State, bool, error) {
+	if l == nil {
+		return State{}, false, nil
+	}
+	state, err := l.state(ctx, blob)
+	if err != nil {
+		return State{}, false, err
+	}
+	return state, state.Kind != "", nil
+}
+
+// Counts returns the number of pieces in each state.
+func (l *Log) Counts(ctx context.Context) (map[Kind]int, error) {
+	counts := map[Kind]int{}
+	err := l.eachState(ctx, func(s State) {
+		counts[s.Kind]++
+	})
+	return counts, err
+}
+
+// Stuck returns the pieces that have been in a state other than proven or
+// removed for longer than d, oldest first. These are the pieces to
+// reconcile: uploads never completed, pieces never aggregated or submitted,
+// and faulted aggregates.
+func (l *Log) Stuck(ctx context.Context, d time.Duration) ([]State, error) {
+	if l == nil {
+		return nil, nil
+	}
+	cutoff := l.now().Add(-d)
+	var stuck []State
+	err := l.eachState(ctx, func(s State) {
+		if s.Kind == Proven || s.Kind == Removed {
+			return
+		}
+		if s.Since.Before(cutoff) {
+			stuck = append(stuck, s)
+		}
+	})
+	sort.Slice(stuck, func(i, j int) bool { return stuck[i].Since.Before(stuck[j].Since) })
+	return stuck, err
+}
+
+// Rebuild recomputes the projected state of every piece from the log.
+func (l *Log) Rebuild(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.deletePrefix(ctx, statePrefix); err != nil {
+		return err
+	}
+	results, err := l.ds.Query(ctx, query.Query{
+		Prefix: eventsPrefix,
+		Orders: []query.Order{query.OrderByKey{}},
+	})
+	if err != nil {
+		return fmt.Errorf("querying events: %w", err)
+	}
+	defer results.Close()
+
+	// events are ordered by blob then sequence, so each state is complete
+	// once the next blob starts
+	var state State
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return fmt.Errorf("iterating events: %w", entry.Error)
+		}
+		blob, err := blobOfEventKey(entry.Key)
+		if err != nil {
+			log.Warnw("skipping event with invalid key", "key", entry.Key, "error", err)
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal(entry.Value, &ev); err != nil {
+			return fmt.Errorf("decoding event %s: %w", entry.Key, err)
+		}
+		if string(state.Blob) != string(blob) {
+			if state.Kind != "" {
+				if err := l.putState(ctx, state); err != nil {
+					return err
+				}
+			}
+			state = State{Blob: blob}
+		}
+		state.Apply(ev)
+	}
+	if state.Kind != "" {
+		return l.putState(ctx, state)
+	}
+	return nil
+}
+
+func (l *Log) eachState(ctx context.Context, fn func(State)) error {
+	if l == nil {
+		return nil
+	}
+	results, err := l.ds.Query(ctx, query.Query{Prefix: statePrefix})
+	if err != nil {
+		return fmt.Errorf("querying states: %w", err)
+	}
+	defer results.Close()
+
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return fmt.Errorf("iterating states: %w", entry.Error)
+		}
+		var s State
+		if err := json.Unmarshal(entry.Value, &s); err != nil {
+			return fmt.Errorf("decoding state %s: %w", entry.Key, err)
+		}
+		blob, err := digestutil.Parse(strings.TrimPrefix(entry.Key, statePrefix))
+		if err != nil {
+			log.Warnw("skipping state with invalid blob", "key", entry.Key, "error", err)
+			continue
+		}
+		s.Blob = blob
+		fn(s)
+	}
+	return nil
+}
+
+func (l *Log) state(ctx context.Context, blob multihash.Multihash) (State, error) {
+	data, err := l.ds.Get(ctx, stateKey(blob))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return State{Blob: blob}, nil
+		}
+		return State{}, fmt.Errorf("getting state: %w", err)
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return State{}, fmt.Errorf("decoding state: %w", err)
+	}
+	s.Blob = blob
+	return s, nil
+}
+
+func (l *Log) putState(ctx context.Context, s State) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+	if err := l.ds.Put(ctx, stateKey(s.Blob), data); err != nil {
+		return fmt.Errorf("putting state: %w", err)
+	}
+	return nil
+}
+
+func (l *Log) aggregateBlobs(ctx context.Context, aggregate datamodel.Link) ([]multihash.Multihash, error) {
+	prefix := aggregatesPrefix + aggregate.String() + "/"
+	results, err := l.ds.Query(ctx, query.Query{Prefix: prefix, KeysOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("querying aggregate pieces: %w", err)
+	}
+	defer results.Close()
+
+	var blobs []multihash.Multihash
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating aggregate pieces: %w", entry.Error)
+		}
+		blob, err := digestutil.Parse(strings.TrimPrefix(entry.Key, prefix))
+		if err != nil {
+			log.Warnw("skipping aggregate piece with invalid blob", "key", entry.Key, "error", err)
+			continue
+		}
+		blobs = append(blobs, blob)
+	}
+	return blobs, nil
+}
+
+func (l *Log) loadSeq(ctx context.Context) (uint64, error) {
+	data, err := l.ds.Get(ctx, datastore.NewKey(seqKey))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("getting sequence: %w", err)
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid sequence of %d bytes", len(data))
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+func (l *Log) deletePrefix(ctx context.Context, prefix string) error {
+	results, err := l.ds.Query(ctx, query.Query{Prefix: prefix, KeysOnly: true})
+	if err != nil {
+		return fmt.Errorf("querying %s: %w", prefix, err)
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		return fmt.Errorf("iterating %s: %w", prefix, err)
+	}
+	for _, entry := range entries {
+		if err := l.ds.Delete(ctx, datastore.NewKey(entry.Key)); err != nil {
+			return fmt.Errorf("deleting %s: %w", entry.Key, err)
+		}
+	}
+	return nil
+}
+
+func eventKey(blob multihash.Multihash, seq uint64) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("%s%s/%020d", eventsPrefix, digestutil.Format(blob), seq))
+}
+
+func blobOfEventKey(key string) (multihash.Multihash, error) {
+	digest, _, _ := strings.Cut(strings.TrimPrefix(key, eventsPrefix), "/")
+	return digestutil.Parse(digest)
+}
+
+func stateKey(blob multihash.Multihash) datastore.Key {
+	return datastore.NewKey(statePrefix + digestutil.Format(blob))
+}
+
+func aggregateKey(aggregate datamodel.Link, blob []byte) datastore.Key {
+	return datastore.NewKey(aggregatesPrefix + aggregate.String() + "/" + digestutil.Format(blob))
+}
@@ -0,0 +1,121 @@
+package piecelog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	ds := datastore.NewMapDatastore()
+	l := New(ds)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	ctx := t.Context()
+
+	space := testutil.RandomDID(t).String()
+	blob := testutil.RandomMultihash(t)
+	piece := testutil.RandomCID(t)
+	aggregate := testutil.RandomCID(t)
+
+	require.NoError(t, l.Append(ctx, blob, Event{Kind: Allocated, Space: space}))
+	now = now.Add(time.Minute)
+	require.NoError(t, l.Append(ctx, blob, Event{Kind: Uploaded}))
+
+	t.Run("projects state", func(t *testing.T) {
+		state, ok, err := l.State(ctx, blob)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, Uploaded, state.Kind)
+		require.Equal(t, now, state.Since)
+		require.Equal(t, space, state.Space)
+		require.Equal(t, now.Add(-time.Minute), state.Reached[Allocated])
+	})
+
+	t.Run("unknown blob", func(t *testing.T) {
+		_, ok, err := l.State(ctx, testutil.RandomMultihash(t))
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("appends aggregate events to its pieces", func(t *testing.T) {
+		require.NoError(t, l.LinkPiece(ctx, piece, blob))
+		// a piece of another node's blob is skipped
+		require.NoError(t, l.AppendAggregated(ctx, aggregate, []datamodel.Link{piece, testutil.RandomCID(t)}))
+		require.NoError(t, l.AppendAggregate(ctx, aggregate, Event{Kind: Submitted, ProofSet: 7}))
+		require.NoError(t, l.AppendAggregate(ctx, aggregate, Event{Kind: Faulted, Error: "missing data"}))
+		require.NoError(t, l.AppendAggregate(ctx, aggregate, Event{Kind: Proven}))
+		// proofs of later proving periods are not recorded again
+		require.NoError(t, l.AppendAggregate(ctx, aggregate, Event{Kind: Proven}))
+
+		events, err := l.Events(ctx, blob)
+		require.NoError(t, err)
+		var kinds []Kind
+		for i, ev := range events {
+			require.Equal(t, uint64(i+1), ev.Seq)
+			kinds = append(kinds, ev.Kind)
+		}
+		require.Equal(t, []Kind{Allocated, Uploaded, Aggregated, Submitted, Faulted, Proven}, kinds)
+
+		state, _, err := l.State(ctx, blob)
+		require.NoError(t, err)
+		require.Equal(t, Proven, state.Kind)
+		require.Equal(t, piece.String(), state.Piece)
+		require.Equal(t, aggregate.String(), state.Aggregate)
+		require.Equal(t, uint64(7), state.ProofSet)
+		require.Empty(t, state.Error)
+		require.Equal(t, Project(blob, events), state)
+	})
+
+	t.Run("counts and stuck pieces", func(t *testing.T) {
+		stuck := testutil.RandomMultihash(t)
+		require.NoError(t, l.Append(ctx, stuck, Event{Kind: Allocated, At: now.Add(-48 * time.Hour)}))
+
+		counts, err := l.Counts(ctx)
+		require.NoError(t, err)
+		require.Equal(t, map[Kind]int{Proven: 1, Allocated: 1}, counts)
+
+		states, err := l.Stuck(ctx, 24*time.Hour)
+		require.NoError(t, err)
+		require.Len(t, states, 1)
+		require.Equal(t, stuck, states[0].Blob)
+		require.Equal(t, Allocated, states[0].Kind)
+	})
+
+	t.Run("rebuilds projection from the log", func(t *testing.T) {
+		before, err := l.Counts(ctx)
+		require.NoError(t, err)
+		want, _, err := l.State(ctx, blob)
+		require.NoError(t, err)
+
+		require.NoError(t, ds.Delete(ctx, stateKey(blob)))
+		require.NoError(t, l.Rebuild(ctx))
+
+		after, err := l.Counts(ctx)
+		require.NoError(t, err)
+		require.Equal(t, before, after)
+		got, _, err := l.State(ctx, blob)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	})
+
+	t.Run("continues sequence after restart", func(t *testing.T) {
+		reopened := New(ds)
+		require.NoError(t, reopened.Append(ctx, blob, Event{Kind: Removed}))
+		events, err := reopened.Events(ctx, blob)
+		require.NoError(t, err)
+		require.Equal(t, uint64(8), events[len(events)-1].Seq)
+	})
+
+	t.Run("nil log records nothing", func(t *testing.T) {
+		var nl *Log
+		require.NoError(t, nl.Append(ctx, blob, Event{Kind: Allocated}))
+		_, ok, err := nl.State(ctx, blob)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,65 @@
+package piecelog
+
+import (
+	"time"
+
+	"github.com/multiformats/go-multihash"
+)
+
+// State is the projection of the events of a piece.
+type State struct {
+	Blob multihash.Multihash `json:"-"`
+	// Kind is the kind of the last event of the piece.
+	Kind Kind `json:"kind"`
+	// Since is when the piece entered its state.
+	Since     time.Time `json:"since"`
+	Space     string    `json:"space,omitempty"`
+	Piece     string    `json:"piece,omitempty"`
+	Aggregate string    `json:"aggregate,omitempty"`
+	ProofSet  uint64    `json:"proof_set,omitempty"`
+	// Error is the reason of the last fault, cleared once the piece is
+	// proven again.
+	Error string `json:"error,omitempty"`
+	// Reached is when the piece first entered each state.
+	Reached map[Kind]time.Time `json:"reached,omitempty"`
+}
+
+// Apply folds an event into the state.
+func (s *State) Apply(ev Event) {
+	s.Kind = ev.Kind
+	s.Since = ev.At
+	if s.Reached == nil {
+		s.Reached = map[Kind]time.Time{}
+	}
+	if _, ok := s.Reached[ev.Kind]; !ok {
+		s.Reached[ev.Kind] = ev.At
+	}
+
+	if ev.Space != "" {
+		s.Space = ev.Space
+	}
+	if ev.Piece != "" {
+		s.Piece = ev.Piece
+	}
+	if ev.Aggregate != "" {
+		s.Aggregate = ev.Aggregate
+	}
+	if ev.ProofSet != 0 {
+		s.ProofSet = ev.ProofSet
+	}
+	switch ev.Kind {
+	case Faulted:
+		s.Error = ev.Error
+	case Proven:
+		s.Error = ""
+	}
+}
+
+// Project replays events into the state they lead to.
+func Project(blob multihash.Multihash, events []Event) State {
+	s := State{Blob: blob}
+	for _, ev := range events {
+		s.Apply(ev)
+	}
+	return s
+}
@@ -2,11 +2,19 @@ package presets
 
 import (
 	"crypto/sha256"
+	"crypto/sha512"
 	"hash"
 
 	"github.com/multiformats/go-multicodec"
+	"lukechampine.com/blake3"
 )
 
+// HasherRegistry holds the hash functions of the multihash digests the node
+// computes, by multicodec name. Blobs can only be allocated with digests of
+// these functions, as their content is verified as it is written.
 var HasherRegistry = map[string]func() hash.Hash{
 	multicodec.Sha2_256.String(): sha256.New,
+	multicodec.Sha2_512.String(): sha512.New,
+	// 32 byte digests, the default output size of BLAKE3
+	multicodec.Blake3.String(): func() hash.Hash { return blake3.New(32, nil) },
 }
@@ -22,3 +22,23 @@ type RequestPresigner interface {
 	// returns the _signed_ URL and headers or error if the signature is invalid.
 	VerifyUploadURL(ctx context.Context, url url.URL, headers http.Header) (url.URL, http.Header, error)
 }
+
+// ByteRange is the bytes of a blob from Start to End inclusive.
+type ByteRange struct {
+	Start uint64
+	End   uint64
+}
+
+type RetrievalPresigner interface {
+	// SignRetrievalURL creates and signs a URL that allows a GET request to
+	// retrieve the given range of the blob with the given digest from the
+	// service, without any other authorization. The range is part of the
+	// signed URL, so the URL cannot be used to retrieve other bytes.
+	//
+	// The ttl parameter determines the number of seconds the signed URL will be
+	// valid for.
+	SignRetrievalURL(ctx context.Context, digest multihash.Multihash, rng ByteRange, ttl uint64) (url.URL, error)
+	// VerifyRetrievalURL ensures the retrieval URL was signed by this service
+	// and has not expired. It returns the range of the blob the URL retrieves.
+	VerifyRetrievalURL(ctx context.Context, url url.URL) (ByteRange, error)
+}
@@ -14,9 +14,13 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/multiformats/go-multicodec"
 	"github.com/multiformats/go-multihash"
 	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/presets"
 )
 
 const ISO8601BasicFormat = "20060102T150405Z"
@@ -25,6 +29,7 @@ type S3RequestPresigner struct {
 	endpoint      url.URL
 	bucketName    string
 	presignClient *s3.PresignClient
+	now           func() time.Time
 }
 
 func encodeKey(digest multihash.Multihash) string {
@@ -36,17 +41,22 @@ func (ss *S3RequestPresigner) SignUploadURL(ctx context.Context, digest multihas
 	if err != nil {
 		return url.URL{}, nil, fmt.Errorf("decoding digest: %w", err)
 	}
-	if digestInfo.Code != uint64(multicodec.Sha2_256) {
+	if _, ok := presets.HasherRegistry[multicodec.Code(digestInfo.Code).String()]; !ok {
 		return url.URL{}, nil, fmt.Errorf("unsupported digest: %d", digestInfo.Code)
 	}
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(ss.bucketName),
+		Key:           aws.String(encodeKey(digest)),
+		ContentLength: aws.Int64(int64(size)),
+	}
+	// S3 only checksums sha2-256 digests, others are verified by the blob store
+	// as the data is written
+	if digestInfo.Code == uint64(multicodec.Sha2_256) {
+		input.ChecksumSHA256 = aws.String(base64.StdEncoding.EncodeToString(digestInfo.Digest))
+	}
 	signedReq, err := ss.presignClient.PresignPutObject(
 		ctx,
-		&s3.PutObjectInput{
-			Bucket:         aws.String(ss.bucketName),
-			Key:            aws.String(encodeKey(digest)),
-			ContentLength:  aws.Int64(int64(size)),
-			ChecksumSHA256: aws.String(base64.StdEncoding.EncodeToString(digestInfo.Digest)),
-		},
+		input,
 		s3.WithPresignExpires(time.Duration(int64(ttl)*int64(time.Second))),
 	)
 	if err != nil {
@@ -86,9 +96,11 @@ func (ss *S3RequestPresigner) VerifyUploadURL(ctx context.Context, requestURL ur
 		return url.URL{}, nil, fmt.Errorf("parsing Content-Length header: %w", err)
 	}
 
-	checksum := requestHeaders.Get("X-Amz-Checksum-Sha256")
-	if checksum == "" {
-		return url.URL{}, nil, errors.New("missing X-Amz-Checksum-Sha256 header")
+	// the checksum is only signed for sha2-256 digests, dropping it from a
+	// request that was signed with it fails verification
+	var checksum *string
+	if h := requestHeaders.Get("X-Amz-Checksum-Sha256"); h != "" {
+		checksum = aws.String(h)
 	}
 
 	expires, err := strconv.ParseInt(requestURL.Query().Get("X-Amz-Expires"), 10, 64)
@@ -107,7 +119,7 @@ func (ss *S3RequestPresigner) VerifyUploadURL(ctx context.Context, requestURL ur
 			Bucket:         aws.String(ss.bucketName),
 			Key:            aws.String(key),
 			ContentLength:  aws.Int64(contentLength),
-			ChecksumSHA256: aws.String(checksum),
+			ChecksumSHA256: checksum,
 		},
 		s3.WithPresignExpires(time.Duration(expires*int64(time.Second))),
 		func(opts *s3.PresignOptions) {
@@ -133,12 +145,136 @@ func (ss *S3RequestPresigner) VerifyUploadURL(ctx context.Context, requestURL ur
 	return *u, signedReq.SignedHeader, nil
 }
 
+// RangeParam is the query parameter of a signed retrieval URL holding the
+// range of the blob it retrieves, e.g. 0-1023.
+const RangeParam = "x-piri-range"
+
+func (ss *S3RequestPresigner) SignRetrievalURL(ctx context.Context, digest multihash.Multihash, rng ByteRange, ttl uint64) (url.URL, error) {
+	if rng.End < rng.Start {
+		return url.URL{}, fmt.Errorf("invalid range: %d-%d", rng.Start, rng.End)
+	}
+	signedReq, err := ss.presignClient.PresignGetObject(
+		ctx,
+		&s3.GetObjectInput{
+			Bucket: aws.String(ss.bucketName),
+			Key:    aws.String(encodeKey(digest)),
+		},
+		s3.WithPresignExpires(time.Duration(int64(ttl)*int64(time.Second))),
+		withQueryParam(RangeParam, formatRange(rng)),
+	)
+	if err != nil {
+		return url.URL{}, fmt.Errorf("signing request: %w", err)
+	}
+
+	reqURL, err := url.Parse(signedReq.URL)
+	if err != nil {
+		return url.URL{}, fmt.Errorf("parsing signed URL: %w", err)
+	}
+
+	return *reqURL, nil
+}
+
+func (ss *S3RequestPresigner) VerifyRetrievalURL(ctx context.Context, requestURL url.URL) (ByteRange, error) {
+	requestURL = *ss.endpoint.ResolveReference(&requestURL)
+	key := strings.Join(strings.Split(requestURL.Path, "/")[2:], "/")
+
+	rng, err := parseRange(requestURL.Query().Get(RangeParam))
+	if err != nil {
+		return ByteRange{}, fmt.Errorf("parsing %s parameter: %w", RangeParam, err)
+	}
+
+	expires, err := strconv.ParseInt(requestURL.Query().Get("X-Amz-Expires"), 10, 64)
+	if err != nil {
+		return ByteRange{}, fmt.Errorf("parsing X-Amz-Expires parameter: %w", err)
+	}
+
+	signingTime, err := time.Parse(ISO8601BasicFormat, requestURL.Query().Get("X-Amz-Date"))
+	if err != nil {
+		return ByteRange{}, fmt.Errorf("parsing X-Amz-Date parameter: %w", err)
+	}
+
+	// unlike uploads, which also need an allocation, a retrieval URL is the
+	// only authorization of the request so its expiry is enforced here
+	if ss.now().After(signingTime.Add(time.Duration(expires) * time.Second)) {
+		return ByteRange{}, errors.New("signed URL expired")
+	}
+
+	signedReq, err := ss.presignClient.PresignGetObject(
+		ctx,
+		&s3.GetObjectInput{
+			Bucket: aws.String(ss.bucketName),
+			Key:    aws.String(key),
+		},
+		s3.WithPresignExpires(time.Duration(expires*int64(time.Second))),
+		withQueryParam(RangeParam, formatRange(rng)),
+		func(opts *s3.PresignOptions) {
+			// configure the presigner for the time the original signing took place.
+			ps := opts.Presigner
+			stp := pointInTimePresigner{signingTime, ps}
+			opts.Presigner = stp
+		},
+	)
+	if err != nil {
+		return ByteRange{}, fmt.Errorf("signing request: %w", err)
+	}
+
+	if requestURL.String() != signedReq.URL {
+		return ByteRange{}, errors.New("signature verification failed")
+	}
+	return rng, nil
+}
+
+// withQueryParam adds a query parameter to the request before it is signed,
+// so the signature covers it.
+func withQueryParam(key, value string) func(*s3.PresignOptions) {
+	addParam := middleware.BuildMiddlewareFunc("AddQueryParam", func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+		if req, ok := in.Request.(*smithyhttp.Request); ok {
+			q := req.URL.Query()
+			q.Set(key, value)
+			req.URL.RawQuery = q.Encode()
+		}
+		return next.HandleBuild(ctx, in)
+	})
+	return func(opts *s3.PresignOptions) {
+		opts.ClientOptions = append(opts.ClientOptions, func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+				return stack.Build.Add(addParam, middleware.After)
+			})
+		})
+	}
+}
+
+func formatRange(rng ByteRange) string {
+	return fmt.Sprintf("%d-%d", rng.Start, rng.End)
+}
+
+func parseRange(s string) (ByteRange, error) {
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return ByteRange{}, fmt.Errorf("invalid range: %q", s)
+	}
+	var rng ByteRange
+	var err error
+	if rng.Start, err = strconv.ParseUint(start, 10, 64); err != nil {
+		return ByteRange{}, fmt.Errorf("invalid range start: %q", start)
+	}
+	if rng.End, err = strconv.ParseUint(end, 10, 64); err != nil {
+		return ByteRange{}, fmt.Errorf("invalid range end: %q", end)
+	}
+	if rng.End < rng.Start {
+		return ByteRange{}, fmt.Errorf("invalid range: %q", s)
+	}
+	return rng, nil
+}
+
 var _ RequestPresigner = (*S3RequestPresigner)(nil)
+var _ RetrievalPresigner = (*S3RequestPresigner)(nil)
 
 // NewS3RequestPresigner creates a signer that the S3 SDK to sign and verify
 // requests. The bucketName parameter is optional and defaults to "blob".
 //
-// Signed upload URLs take the form {endpoint}/{bucketName}/{b58digest}
+// Signed upload and retrieval URLs take the form
+// {endpoint}/{bucketName}/{b58digest}
 func NewS3RequestPresigner(accessKeyID string, secretAcessKey string, endpoint url.URL, bucketName string) (*S3RequestPresigner, error) {
 	endpointstr := endpoint.String()
 
@@ -178,5 +314,5 @@ func NewS3RequestPresigner(accessKeyID string, secretAcessKey string, endpoint u
 		bucketName = "blob"
 	}
 
-	return &S3RequestPresigner{endpoint, bucketName, presign}, nil
+	return &S3RequestPresigner{endpoint, bucketName, presign, time.Now}, nil
 }
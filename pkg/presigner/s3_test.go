@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/multiformats/go-multicodec"
 	"github.com/multiformats/go-multihash"
@@ -68,15 +69,85 @@ func TestS3Signer(t *testing.T) {
 		require.Equal(t, err.Error(), "signature verification failed")
 	})
 
-	t.Run("requires sha2-256 digest", func(t *testing.T) {
+	t.Run("signs digests of supported hash functions", func(t *testing.T) {
 		reqSigner, err := NewS3RequestPresigner(accessKeyID, secretAccessKey, *endpoint, "data")
 		require.NoError(t, err)
 
 		data := testutil.RandomBytes(t, 32)
-		digest, err := multihash.Sum(data, uint64(multicodec.Sha2_512), -1)
+		for _, code := range []multicodec.Code{multicodec.Sha2_512, multicodec.Blake3} {
+			digest, err := multihash.Sum(data, uint64(code), -1)
+			require.NoError(t, err)
+
+			url, headers, err := reqSigner.SignUploadURL(t.Context(), digest, uint64(len(data)), 900)
+			require.NoError(t, err)
+			require.Empty(t, headers.Get("X-Amz-Checksum-Sha256"))
+
+			_, _, err = reqSigner.VerifyUploadURL(t.Context(), url, headers)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("requires checksum of sha2-256 digests", func(t *testing.T) {
+		reqSigner, err := NewS3RequestPresigner(accessKeyID, secretAccessKey, *endpoint, "data")
+		require.NoError(t, err)
+
+		url, headers, err := reqSigner.SignUploadURL(t.Context(), testutil.RandomMultihash(t), 138, 900)
+		require.NoError(t, err)
+
+		headers.Del("X-Amz-Checksum-Sha256")
+		_, _, err = reqSigner.VerifyUploadURL(t.Context(), url, headers)
+		require.EqualError(t, err, "signature verification failed")
+	})
+
+	t.Run("rejects digests of unsupported hash functions", func(t *testing.T) {
+		reqSigner, err := NewS3RequestPresigner(accessKeyID, secretAccessKey, *endpoint, "data")
+		require.NoError(t, err)
+
+		data := testutil.RandomBytes(t, 32)
+		digest, err := multihash.Sum(data, uint64(multicodec.Sha3_256), -1)
 		require.NoError(t, err)
 
 		_, _, err = reqSigner.SignUploadURL(t.Context(), digest, uint64(len(data)), 900)
-		require.EqualError(t, err, fmt.Sprintf("unsupported digest: %d", multicodec.Sha2_512))
+		require.EqualError(t, err, fmt.Sprintf("unsupported digest: %d", multicodec.Sha3_256))
+	})
+
+	t.Run("sign and verify retrieval", func(t *testing.T) {
+		reqSigner, err := NewS3RequestPresigner(accessKeyID, secretAccessKey, *endpoint, "")
+		require.NoError(t, err)
+
+		digest := testutil.RandomMultihash(t)
+		rng := ByteRange{Start: 10, End: 99}
+		signed, err := reqSigner.SignRetrievalURL(t.Context(), digest, rng, 300)
+		require.NoError(t, err)
+		require.Equal(t, "/blob/"+encodeKey(digest), signed.Path)
+		require.Equal(t, "10-99", signed.Query().Get(RangeParam))
+
+		// the server sees the URL relative to its endpoint
+		verified, err := reqSigner.VerifyRetrievalURL(t.Context(), url.URL{Path: signed.Path, RawQuery: signed.RawQuery})
+		require.NoError(t, err)
+		require.Equal(t, rng, verified)
+
+		other := signed
+		other.Path = "/blob/" + encodeKey(testutil.RandomMultihash(t))
+		_, err = reqSigner.VerifyRetrievalURL(t.Context(), other)
+		require.EqualError(t, err, "signature verification failed")
+
+		// the range cannot be changed
+		widened := signed
+		q := widened.Query()
+		q.Set(RangeParam, "0-99")
+		widened.RawQuery = q.Encode()
+		_, err = reqSigner.VerifyRetrievalURL(t.Context(), widened)
+		require.EqualError(t, err, "signature verification failed")
+
+		// upload URLs do not authorize retrievals
+		upload, _, err := reqSigner.SignUploadURL(t.Context(), digest, 138, 300)
+		require.NoError(t, err)
+		_, err = reqSigner.VerifyRetrievalURL(t.Context(), upload)
+		require.Error(t, err)
+
+		reqSigner.now = func() time.Time { return time.Now().Add(301 * time.Second) }
+		_, err = reqSigner.VerifyRetrievalURL(t.Context(), signed)
+		require.EqualError(t, err, "signed URL expired")
 	})
 }
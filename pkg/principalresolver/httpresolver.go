@@ -72,6 +72,10 @@ type HTTPResolver struct {
 type config struct {
 	timeout  time.Duration
 	insecure bool
+	// used by WebResolver only
+	cacheTTL         time.Duration
+	negativeCacheTTL time.Duration
+	fallback         validator.PrincipalResolver
 }
 
 type Option func(*config) error
@@ -93,6 +97,39 @@ func InsecureResolution() Option {
 	}
 }
 
+// WithCacheTTL sets how long a WebResolver uses a key resolved from a DID
+// document before fetching the document again.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(c *config) error {
+		if ttl <= 0 {
+			return fmt.Errorf("cache ttl must be positive")
+		}
+		c.cacheTTL = ttl
+		return nil
+	}
+}
+
+// WithNegativeCacheTTL sets how long a WebResolver waits before fetching a
+// DID document that could not be fetched again.
+func WithNegativeCacheTTL(ttl time.Duration) Option {
+	return func(c *config) error {
+		if ttl <= 0 {
+			return fmt.Errorf("negative cache ttl must be positive")
+		}
+		c.negativeCacheTTL = ttl
+		return nil
+	}
+}
+
+// WithFallback sets the resolver a WebResolver consults for DIDs it cannot
+// resolve from their DID document.
+func WithFallback(fallback validator.PrincipalResolver) Option {
+	return func(c *config) error {
+		c.fallback = fallback
+		return nil
+	}
+}
+
 const didWebPrefix = "did:web:"
 
 // ExtractDomainFromDID extracts the domain from a DID web string
@@ -132,6 +169,10 @@ func validateDomain(domain string) error {
 
 const WellKnownDIDPath = "/.well-known/did.json"
 
+// maxDocumentSize bounds the size of DID documents read, since documents may
+// be fetched from any domain named by a did:web.
+const maxDocumentSize = 64 << 10
+
 func NewHTTPResolver(webKeys []did.DID, opts ...Option) (*HTTPResolver, error) {
 	cfg := &config{
 		timeout:  10 * time.Second,
@@ -149,26 +190,10 @@ func NewHTTPResolver(webKeys []did.DID, opts ...Option) (*HTTPResolver, error) {
 		if _, ok := didMap[w]; ok {
 			return nil, fmt.Errorf("duplicate did's provided")
 		}
-		domain, err := ExtractDomainFromDID(w)
+		endpoint, err := documentURL(w, cfg.insecure)
 		if err != nil {
 			return nil, err
 		}
-
-		schema := "https"
-		if cfg.insecure {
-			schema = "http"
-		}
-
-		endpoint := url.URL{
-			Scheme: schema,
-			Host:   domain,
-			Path:   WellKnownDIDPath,
-		}
-
-		if _, err := url.Parse(endpoint.String()); err != nil {
-			return nil, fmt.Errorf("invalid did domain: %w", err)
-		}
-
 		didMap[w] = endpoint
 	}
 	// default timeout of 10 seconds, options can override
@@ -186,26 +211,60 @@ func (r *HTTPResolver) ResolveDIDKey(ctx context.Context, input did.DID) (did.DI
 	}
 	ctx, cancel := context.WithTimeout(ctx, r.cfg.timeout)
 	defer cancel()
+	didKey, err := resolveDocumentKey(ctx, endpoint)
+	if err != nil {
+		return did.Undef, validator.NewDIDKeyResolutionError(input, err)
+	}
+	return didKey, nil
+}
+
+// documentURL returns the URL the DID document of a did:web is served at.
+func documentURL(didWeb did.DID, insecure bool) (url.URL, error) {
+	domain, err := ExtractDomainFromDID(didWeb)
+	if err != nil {
+		return url.URL{}, err
+	}
+
+	schema := "https"
+	if insecure {
+		schema = "http"
+	}
+
+	endpoint := url.URL{
+		Scheme: schema,
+		Host:   domain,
+		Path:   WellKnownDIDPath,
+	}
+
+	if _, err := url.Parse(endpoint.String()); err != nil {
+		return url.URL{}, fmt.Errorf("invalid did domain: %w", err)
+	}
+	return endpoint, nil
+}
+
+// resolveDocumentKey fetches the DID document at endpoint and returns the
+// did:key of its first verification method.
+func resolveDocumentKey(ctx context.Context, endpoint url.URL) (did.DID, error) {
 	didDoc, err := fetchDIDDocument(ctx, endpoint)
 	if err != nil {
 		log.Errorf("failed to resolve DID document from endpoint %s: %s", endpoint.String(), err)
-		return did.Undef, validator.NewDIDKeyResolutionError(input, fmt.Errorf("failed to resolve DID document: %w", err))
+		return did.Undef, fmt.Errorf("failed to resolve DID document: %w", err)
 	}
 	if len(didDoc.VerificationMethod) == 0 {
 		log.Errorf("failed to resolve DID document from endpoint %s: no verification methods", endpoint.String())
-		return did.Undef, validator.NewDIDKeyResolutionError(input, fmt.Errorf("no verificationMethod found in DID document"))
+		return did.Undef, fmt.Errorf("no verificationMethod found in DID document")
 	}
 
 	pubKeyStr := didDoc.VerificationMethod[0].PublicKeyMultibase
 	if pubKeyStr == "" {
 		log.Errorf("failed to resolve DID document from endpoint %s: no public key", endpoint.String())
-		return did.Undef, validator.NewDIDKeyResolutionError(input, fmt.Errorf("no public key found in DID document"))
+		return did.Undef, fmt.Errorf("no public key found in DID document")
 	}
 
 	didKey, err := did.Parse(fmt.Sprintf("did:key:%s", pubKeyStr))
 	if err != nil {
 		log.Errorf("failed to parse DID document from endpoint %s: %s", endpoint.String(), err)
-		return did.Undef, validator.NewDIDKeyResolutionError(input, fmt.Errorf("failed to parse public multibase key: %w", err))
+		return did.Undef, fmt.Errorf("failed to parse public multibase key: %w", err)
 	}
 
 	return didKey, nil
@@ -232,7 +291,7 @@ func fetchDIDDocument(ctx context.Context, endpoint url.URL) (*Document, error)
 		return nil, fmt.Errorf("received status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
 	if err != nil {
 		log.Errorf("failed to read response body for DID document at endpoint %s: %s", endpoint.String(), err)
 		return nil, fmt.Errorf("failed to read response: %w", err)
@@ -0,0 +1,151 @@
+package principalresolver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/validator"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultCacheTTL is how long a key resolved from a DID document is used
+	// before the document is fetched again.
+	DefaultCacheTTL = time.Hour
+	// DefaultNegativeCacheTTL is how long a DID document that could not be
+	// fetched is not fetched again.
+	DefaultNegativeCacheTTL = time.Minute
+)
+
+// Resolution results recorded in metrics.
+const (
+	resultCached   = "cached"
+	resultFetched  = "fetched"
+	resultFallback = "fallback"
+	resultFailed   = "failed"
+)
+
+var _ validator.PrincipalResolver = (*WebResolver)(nil)
+
+// WebResolver resolves any did:web to the key of the first verification
+// method of the DID document served on its domain. Documents are fetched
+// when first needed and the keys cached, so a service rotating its key is
+// picked up once the cached key expires.
+//
+// When the document cannot be fetched, or the DID is not a did:web, the
+// fallback resolver, typically a MapResolver of known services, is used.
+// Failed fetches are cached for a shorter time, so an unreachable domain is
+// not fetched on every invocation.
+type WebResolver struct {
+	cfg   config
+	cache *cache.Cache
+	group singleflight.Group
+
+	resolutions metric.Int64Counter
+}
+
+// cachedKey is the outcome of a fetch, err is set for failed fetches.
+type cachedKey struct {
+	key did.DID
+	err error
+}
+
+func NewWebResolver(opts ...Option) (*WebResolver, error) {
+	cfg := &config{
+		timeout:          10 * time.Second,
+		cacheTTL:         DefaultCacheTTL,
+		negativeCacheTTL: DefaultNegativeCacheTTL,
+	}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &WebResolver{
+		cfg: *cfg,
+		// expired items are purged every hour
+		cache: cache.New(cfg.cacheTTL, time.Hour),
+	}
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/principalresolver")
+	resolutions, err := meter.Int64Counter(
+		"piri_principal_resolutions",
+		metric.WithDescription("did:web principals resolved to a key, by result: cached, fetched, fallback or failed"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		log.Warnw("creating principal resolver metrics", "error", err)
+	}
+	r.resolutions = resolutions
+	return r, nil
+}
+
+func (r *WebResolver) ResolveDIDKey(ctx context.Context, input did.DID) (did.DID, validator.UnresolvedDID) {
+	if !strings.HasPrefix(input.String(), didWebPrefix) {
+		return r.resolveFallback(ctx, input, fmt.Errorf("not a did:web"))
+	}
+
+	if v, found := r.cache.Get(input.String()); found {
+		entry := v.(cachedKey)
+		if entry.err != nil {
+			return r.resolveFallback(ctx, input, entry.err)
+		}
+		r.record(ctx, resultCached)
+		return entry.key, nil
+	}
+
+	// concurrent resolutions of a DID share a single fetch
+	v, _, _ := r.group.Do(input.String(), func() (any, error) {
+		entry := r.fetch(ctx, input)
+		ttl := r.cfg.cacheTTL
+		if entry.err != nil {
+			ttl = r.cfg.negativeCacheTTL
+		}
+		r.cache.Set(input.String(), entry, ttl)
+		return entry, nil
+	})
+	entry := v.(cachedKey)
+	if entry.err != nil {
+		return r.resolveFallback(ctx, input, entry.err)
+	}
+	r.record(ctx, resultFetched)
+	return entry.key, nil
+}
+
+func (r *WebResolver) fetch(ctx context.Context, input did.DID) cachedKey {
+	endpoint, err := documentURL(input, r.cfg.insecure)
+	if err != nil {
+		return cachedKey{err: err}
+	}
+	// the fetch is shared by concurrent resolutions, it must not be cancelled
+	// with the context of the first of them
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.cfg.timeout)
+	defer cancel()
+	key, err := resolveDocumentKey(ctx, endpoint)
+	return cachedKey{key: key, err: err}
+}
+
+func (r *WebResolver) resolveFallback(ctx context.Context, input did.DID, cause error) (did.DID, validator.UnresolvedDID) {
+	if r.cfg.fallback != nil {
+		if key, err := r.cfg.fallback.ResolveDIDKey(ctx, input); err == nil {
+			r.record(ctx, resultFallback)
+			return key, nil
+		}
+	}
+	r.record(ctx, resultFailed)
+	return did.Undef, validator.NewDIDKeyResolutionError(input, cause)
+}
+
+func (r *WebResolver) record(ctx context.Context, result string) {
+	if r.resolutions == nil {
+		return
+	}
+	r.resolutions.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+}
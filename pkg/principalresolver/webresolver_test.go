@@ -0,0 +1,167 @@
+package principalresolver_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/storacha/go-ucanto/did"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/principalresolver"
+)
+
+const (
+	webResolverKey1 = "did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+	webResolverKey2 = "did:key:z6Mkfriq1MqLBoPWecGoDLjguo1sB9brj6wT3qZ5BxkKpuP6"
+)
+
+// newDocumentServer serves a DID document with the key returned by key, or a
+// 500 if it returns an empty string. It returns the did:web of the server
+// and the number of requests served.
+func newDocumentServer(t *testing.T, key func() string) (did.DID, *atomic.Int64) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		k := key()
+		if k == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		doc := principalresolver.Document{
+			Context: []string{"https://w3id.org/did/v1"},
+			ID:      "did:web:" + r.Host,
+			VerificationMethod: []principalresolver.VerificationMethod{
+				{ID: "did:web:" + r.Host + "#key1", PublicKeyMultibase: k[len(did.KeyPrefix):]},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	id, err := did.Parse("did:web:" + u.Host)
+	require.NoError(t, err)
+	return id, &requests
+}
+
+func TestWebResolver_ResolveDIDKey(t *testing.T) {
+	t.Run("resolves any did:web and caches the key", func(t *testing.T) {
+		id, requests := newDocumentServer(t, func() string { return webResolverKey1 })
+		resolver, err := principalresolver.NewWebResolver(principalresolver.InsecureResolution())
+		require.NoError(t, err)
+
+		for range 3 {
+			key, unresolved := resolver.ResolveDIDKey(t.Context(), id)
+			require.Nil(t, unresolved)
+			require.Equal(t, webResolverKey1, key.String())
+		}
+		require.Equal(t, int64(1), requests.Load())
+	})
+
+	t.Run("picks up a rotated key once the cached key expires", func(t *testing.T) {
+		var current atomic.Value
+		current.Store(webResolverKey1)
+		id, requests := newDocumentServer(t, func() string { return current.Load().(string) })
+		resolver, err := principalresolver.NewWebResolver(
+			principalresolver.InsecureResolution(),
+			principalresolver.WithCacheTTL(50*time.Millisecond),
+		)
+		require.NoError(t, err)
+
+		key, unresolved := resolver.ResolveDIDKey(t.Context(), id)
+		require.Nil(t, unresolved)
+		require.Equal(t, webResolverKey1, key.String())
+
+		current.Store(webResolverKey2)
+		key, unresolved = resolver.ResolveDIDKey(t.Context(), id)
+		require.Nil(t, unresolved)
+		require.Equal(t, webResolverKey1, key.String(), "cached key is used until it expires")
+
+		time.Sleep(100 * time.Millisecond)
+		key, unresolved = resolver.ResolveDIDKey(t.Context(), id)
+		require.Nil(t, unresolved)
+		require.Equal(t, webResolverKey2, key.String())
+		require.Equal(t, int64(2), requests.Load())
+	})
+
+	t.Run("caches failed fetches", func(t *testing.T) {
+		id, requests := newDocumentServer(t, func() string { return "" })
+		resolver, err := principalresolver.NewWebResolver(
+			principalresolver.InsecureResolution(),
+			principalresolver.WithNegativeCacheTTL(50*time.Millisecond),
+		)
+		require.NoError(t, err)
+
+		for range 3 {
+			key, unresolved := resolver.ResolveDIDKey(t.Context(), id)
+			require.NotNil(t, unresolved)
+			require.Contains(t, unresolved.Error(), "Unable to resolve")
+			require.Equal(t, did.Undef, key)
+		}
+		require.Equal(t, int64(1), requests.Load())
+
+		time.Sleep(100 * time.Millisecond)
+		_, unresolved := resolver.ResolveDIDKey(t.Context(), id)
+		require.NotNil(t, unresolved)
+		require.Equal(t, int64(2), requests.Load())
+	})
+
+	t.Run("falls back to the static mapping", func(t *testing.T) {
+		id, _ := newDocumentServer(t, func() string { return "" })
+		fallback, err := principalresolver.NewMapResolver(map[string]string{
+			id.String():           webResolverKey2,
+			"did:plc:example.com": webResolverKey1,
+		})
+		require.NoError(t, err)
+		resolver, err := principalresolver.NewWebResolver(
+			principalresolver.InsecureResolution(),
+			principalresolver.WithFallback(fallback),
+		)
+		require.NoError(t, err)
+
+		key, unresolved := resolver.ResolveDIDKey(t.Context(), id)
+		require.Nil(t, unresolved)
+		require.Equal(t, webResolverKey2, key.String())
+
+		// DIDs that are not did:web are only resolved by the fallback
+		plc, err := did.Parse("did:plc:example.com")
+		require.NoError(t, err)
+		key, unresolved = resolver.ResolveDIDKey(t.Context(), plc)
+		require.Nil(t, unresolved)
+		require.Equal(t, webResolverKey1, key.String())
+
+		unknown, err := did.Parse("did:plc:unknown.com")
+		require.NoError(t, err)
+		_, unresolved = resolver.ResolveDIDKey(t.Context(), unknown)
+		require.NotNil(t, unresolved)
+	})
+
+	t.Run("prefers the DID document over the static mapping", func(t *testing.T) {
+		id, _ := newDocumentServer(t, func() string { return webResolverKey1 })
+		fallback, err := principalresolver.NewMapResolver(map[string]string{id.String(): webResolverKey2})
+		require.NoError(t, err)
+		resolver, err := principalresolver.NewWebResolver(
+			principalresolver.InsecureResolution(),
+			principalresolver.WithFallback(fallback),
+		)
+		require.NoError(t, err)
+
+		key, unresolved := resolver.ResolveDIDKey(t.Context(), id)
+		require.Nil(t, unresolved)
+		require.Equal(t, webResolverKey1, key.String())
+	})
+}
+
+func TestNewWebResolver(t *testing.T) {
+	_, err := principalresolver.NewWebResolver(principalresolver.WithCacheTTL(0))
+	require.Error(t, err)
+	_, err = principalresolver.NewWebResolver(principalresolver.WithNegativeCacheTTL(-time.Second))
+	require.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package privatespace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	leveldb "github.com/ipfs/go-ds-leveldb"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/presigner"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+)
+
+// DataDir is the directory, relative to the data directory, holding the
+// signed URLs already used.
+const DataDir = "privatespace"
+
+// Module provides the Policy restricting retrieval of the blobs of private
+// spaces, or a nil Policy if no space is private.
+var Module = fx.Module("privatespace",
+	fx.Provide(NewFromConfig),
+)
+
+type Params struct {
+	fx.In
+
+	Config     app.ServerConfig
+	StorageCfg app.StorageConfig
+	Allocs     allocationstore.AllocationStore
+	Presigner  presigner.RetrievalPresigner
+}
+
+// NewFromConfig creates the Policy for the configured private spaces. Used
+// signed URLs are persisted in the data directory, or kept in memory without
+// one.
+func NewFromConfig(lc fx.Lifecycle, params Params) (*Policy, error) {
+	cfg := params.Config.PrivateRetrieval
+	if len(cfg.Spaces) == 0 {
+		return nil, nil
+	}
+
+	var used datastore.Batching
+	if params.StorageCfg.DataDir == "" {
+		log.Warn("no data dir configured, signed retrieval URLs can be used again after a restart")
+		used = sync.MutexWrap(datastore.NewMapDatastore())
+	} else {
+		dir := filepath.Join(params.StorageCfg.DataDir, DataDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("creating directory: %s: %w", dir, err)
+		}
+		ldb, err := leveldb.NewDatastore(dir, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating used URL store: %w", err)
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return ldb.Close()
+			},
+		})
+		used = ldb
+	}
+
+	log.Infow("Restricting retrieval of private spaces", "spaces", len(cfg.Spaces))
+	return New(cfg.Spaces, params.Allocs, params.Presigner, cfg.URLTTL, used), nil
+}
@@ -0,0 +1,101 @@
+package privatespace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/cdn"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// redeemedKey marks the context of a request whose signed URL was redeemed.
+type redeemedKey struct{}
+
+// Redeemed reports whether ctx is of a request for a private blob whose
+// signed URL was redeemed, which must be served by the node rather than
+// redirected to the CDN, so only the signed range is served, once.
+func Redeemed(ctx context.Context) bool {
+	ok, _ := ctx.Value(redeemedKey{}).(bool)
+	return ok
+}
+
+// Middleware rejects requests for private blobs, whose digest is the "blob"
+// path parameter, with 401 Unauthorized unless their URL was signed by the
+// node and not used before. Only the range the URL was signed for is served.
+// Fetches by the CDN, if not nil, are passed through since the CDN only
+// serves blobs from URLs signed by the node. Private blobs are not stored in
+// shared caches. Requests with an invalid digest are passed through for the
+// handler to reject. With a nil Policy requests are passed through.
+func (p *Policy) Middleware(offload *cdn.CDN) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if p == nil {
+			return next
+		}
+		return func(c echo.Context) error {
+			r := c.Request()
+			digest, err := digestutil.Parse(c.Param("blob"))
+			if err != nil {
+				return next(c)
+			}
+			private, err := p.IsPrivate(r.Context(), digest)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			if !private {
+				return next(c)
+			}
+			if offload == nil || !offload.IsOriginRequest(r) {
+				rng, err := p.Redeem(r.Context(), *r.URL)
+				if err != nil {
+					log.Debugw("refused retrieval of private blob", "digest", digestutil.Format(digest), "error", err)
+					if errors.Is(err, ErrURLUsed) {
+						return echo.NewHTTPError(http.StatusUnauthorized, "signed URL already used, request another with a space/content/retrieve invocation")
+					}
+					return echo.NewHTTPError(http.StatusUnauthorized, "blob is in a private space, retrieve it with a space/content/retrieve invocation or a signed URL")
+				}
+				// serve the range the URL was signed for, whatever was requested
+				r.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", rng.Start, rng.End))
+				r.Header.Del("If-Range")
+				c.SetRequest(r.WithContext(context.WithValue(r.Context(), redeemedKey{}, true)))
+			}
+			res := c.Response()
+			res.Before(func() {
+				res.Header().Set("Cache-Control", "private, no-store")
+			})
+			return next(c)
+		}
+	}
+}
+
+// BlobGetter wraps blobs so private blobs are not found, e.g. to refuse their
+// retrieval over Bitswap, which cannot be authorized. With a nil Policy blobs
+// is returned as is.
+func (p *Policy) BlobGetter(blobs blobstore.BlobGetter) blobstore.BlobGetter {
+	if p == nil {
+		return blobs
+	}
+	return &blobGetter{policy: p, blobs: blobs}
+}
+
+type blobGetter struct {
+	policy *Policy
+	blobs  blobstore.BlobGetter
+}
+
+func (g *blobGetter) Get(ctx context.Context, digest multihash.Multihash, opts ...blobstore.GetOption) (blobstore.Object, error) {
+	private, err := g.policy.IsPrivate(ctx, digest)
+	if err != nil {
+		return nil, err
+	}
+	if private {
+		return nil, fmt.Errorf("%w: blob is in a private space", store.ErrNotFound)
+	}
+	return g.blobs.Get(ctx, digest, opts...)
+}
@@ -0,0 +1,181 @@
+// Package privatespace restricts retrieval of the blobs of private spaces.
+//
+// Blobs are retrievable by anyone holding their URL unless they are stored in
+// a private space. Blobs of private spaces are not served from their public
+// URL nor over Bitswap. They are retrieved with a space/content/retrieve
+// invocation, which the UCAN retrieval server validates against the
+// delegation chain of the space, or from a short-lived URL signed by the
+// node's presigner. Signed URLs are issued in response to a
+// space/content/retrieve invocation sent with the [PresignHeader], so browser
+// clients can load blobs without sending an invocation with each request. A
+// signed URL retrieves only the range of the invocation it was issued for,
+// and only once.
+package privatespace
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-ucanto/did"
+
+	"github.com/storacha/piri/pkg/presigner"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+)
+
+var log = logging.Logger("privatespace")
+
+// PresignHeader is the header of a space/content/retrieve invocation
+// requesting a signed URL of the blob rather than its content. The URL is
+// returned in the Location header of a 204 No Content response.
+const PresignHeader = "X-Piri-Presign"
+
+// ErrURLUsed is returned when a signed URL is used again.
+var ErrURLUsed = errors.New("signed URL already used")
+
+const usedPrefix = "/used/"
+
+// Policy decides which blobs are private and signs their retrieval URLs.
+type Policy struct {
+	spaces    []did.DID
+	allocs    allocationstore.AllocationStore
+	presigner presigner.RetrievalPresigner
+	ttl       time.Duration
+	// used holds the signed URLs already used, until they expire.
+	used datastore.Datastore
+
+	// mu serializes the check and marking of used URLs.
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+// New creates a Policy for the private spaces, whose blobs are looked up in
+// allocs. Retrieval URLs are signed by presigner and valid for ttl, and are
+// recorded in used once used.
+func New(spaces []did.DID, allocs allocationstore.AllocationStore, presigner presigner.RetrievalPresigner, ttl time.Duration, used datastore.Datastore) *Policy {
+	return &Policy{spaces: spaces, allocs: allocs, presigner: presigner, ttl: ttl, used: used}
+}
+
+// IsPrivateSpace reports whether space is private. A nil Policy has no private
+// spaces.
+func (p *Policy) IsPrivateSpace(space did.DID) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.spaces {
+		if s == space {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPrivate reports whether the blob is stored in a private space. A blob
+// stored in both a private and a public space is private, since the node
+// cannot tell which space a request is for. A nil Policy has no private
+// blobs.
+func (p *Policy) IsPrivate(ctx context.Context, digest multihash.Multihash) (bool, error) {
+	if p == nil {
+		return false, nil
+	}
+	for _, space := range p.spaces {
+		_, err := p.allocs.Get(ctx, digest, space)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return false, fmt.Errorf("getting allocation in space %s: %w", space, err)
+		}
+	}
+	return false, nil
+}
+
+// SignURL returns a URL retrieving the bytes from start to end inclusive of
+// the blob without other authorization, once, until it expires.
+func (p *Policy) SignURL(ctx context.Context, digest multihash.Multihash, start, end uint64) (url.URL, error) {
+	rng := presigner.ByteRange{Start: start, End: end}
+	u, err := p.presigner.SignRetrievalURL(ctx, digest, rng, uint64(p.ttl.Seconds()))
+	if err != nil {
+		return url.URL{}, fmt.Errorf("signing retrieval URL: %w", err)
+	}
+	return u, nil
+}
+
+// Redeem verifies a signed retrieval URL and marks it used, returning the
+// range of the blob it retrieves. It returns [ErrURLUsed] if the URL was
+// already used.
+func (p *Policy) Redeem(ctx context.Context, u url.URL) (presigner.ByteRange, error) {
+	rng, err := p.presigner.VerifyRetrievalURL(ctx, u)
+	if err != nil {
+		return presigner.ByteRange{}, err
+	}
+
+	sum := sha256.Sum256([]byte(u.Path + "?" + u.RawQuery))
+	key := datastore.NewKey(usedPrefix + hex.EncodeToString(sum[:]))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(p.lastPruned) >= p.ttl {
+		if err := p.prune(ctx, now); err != nil {
+			log.Warnw("pruning used retrieval URLs", "error", err)
+		}
+		p.lastPruned = now
+	}
+
+	used, err := p.used.Has(ctx, key)
+	if err != nil {
+		return presigner.ByteRange{}, fmt.Errorf("checking retrieval URL: %w", err)
+	}
+	if used {
+		return presigner.ByteRange{}, ErrURLUsed
+	}
+	// a URL is valid for at most the TTL from now, it can be forgotten after
+	expires := binary.BigEndian.AppendUint64(nil, uint64(now.Add(p.ttl).Unix()))
+	if err := p.used.Put(ctx, key, expires); err != nil {
+		return presigner.ByteRange{}, fmt.Errorf("recording used retrieval URL: %w", err)
+	}
+	return rng, nil
+}
+
+// prune forgets the used URLs that have expired.
+func (p *Policy) prune(ctx context.Context, now time.Time) error {
+	results, err := p.used.Query(ctx, query.Query{Prefix: usedPrefix})
+	if err != nil {
+		return err
+	}
+	var expired []datastore.Key
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			results.Close()
+			return entry.Error
+		}
+		if len(entry.Value) != 8 || int64(binary.BigEndian.Uint64(entry.Value)) < now.Unix() {
+			expired = append(expired, datastore.NewKey(entry.Key))
+		}
+	}
+	results.Close()
+	for _, key := range expired {
+		if err := p.used.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TTL is how long signed URLs are valid for.
+func (p *Policy) TTL() time.Duration {
+	return p.ttl
+}
@@ -0,0 +1,84 @@
+package privatespace
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+func TestPolicy(t *testing.T) {
+	ctx := t.Context()
+	allocs := allocationstore.NewDatastoreStore(datastore.NewMapDatastore())
+	blobs := blobstore.NewDatastoreStore(datastore.NewMapDatastore())
+	private := testutil.RandomDID(t)
+	public := testutil.RandomDID(t)
+	p := New([]did.DID{private}, allocs, nil, time.Minute, datastore.NewMapDatastore())
+
+	put := func(t *testing.T, spaces ...did.DID) []byte {
+		data := testutil.RandomBytes(t, 32)
+		digest := testutil.MultihashFromBytes(t, data)
+		for _, space := range spaces {
+			err := allocs.Put(ctx, allocation.Allocation{
+				Space:   space,
+				Blob:    allocation.Blob{Digest: digest, Size: uint64(len(data))},
+				Expires: uint64(time.Now().Unix() + 900),
+				Cause:   testutil.RandomCID(t),
+			})
+			require.NoError(t, err)
+		}
+		require.NoError(t, blobs.Put(ctx, digest, uint64(len(data)), bytes.NewReader(data)))
+		return data
+	}
+
+	require.True(t, p.IsPrivateSpace(private))
+	require.False(t, p.IsPrivateSpace(public))
+
+	for _, tc := range []struct {
+		name    string
+		spaces  []did.DID
+		private bool
+	}{
+		{"private space", []did.DID{private}, true},
+		{"public space", []did.DID{public}, false},
+		{"private and public space", []did.DID{public, private}, true},
+		{"no allocation", nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := put(t, tc.spaces...)
+			digest := testutil.MultihashFromBytes(t, data)
+
+			isPrivate, err := p.IsPrivate(ctx, digest)
+			require.NoError(t, err)
+			require.Equal(t, tc.private, isPrivate)
+
+			_, err = p.BlobGetter(blobs).Get(ctx, digest)
+			if tc.private {
+				require.ErrorIs(t, err, store.ErrNotFound)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("nil policy", func(t *testing.T) {
+		var nilPolicy *Policy
+		data := put(t, private)
+		digest := testutil.MultihashFromBytes(t, data)
+
+		isPrivate, err := nilPolicy.IsPrivate(ctx, digest)
+		require.NoError(t, err)
+		require.False(t, isPrivate)
+		require.False(t, nilPolicy.IsPrivateSpace(private))
+		require.Equal(t, blobstore.BlobGetter(blobs), nilPolicy.BlobGetter(blobs))
+	})
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/result/failure/datamodel"
+)
+
+// RateLimitedErrorName is the name of the failure returned in receipts when
+// a request is over the rate limit.
+const RateLimitedErrorName = "RateLimited"
+
+// RateLimitedError is returned when a request is over the rate limit of its
+// key.
+type RateLimitedError struct {
+	Scope      string
+	Key        string
+	RetryAfter time.Duration
+}
+
+func (re RateLimitedError) Name() string {
+	return RateLimitedErrorName
+}
+
+func (re RateLimitedError) Error() string {
+	return fmt.Sprintf("%s %s is over the rate limit, retry after %s", re.Scope, re.Key, re.RetryAfter)
+}
+
+func (re RateLimitedError) ToIPLD() (ipld.Node, error) {
+	name := re.Name()
+	model := datamodel.FailureModel{Name: &name, Message: re.Error()}
+	return model.ToIPLD()
+}
+
+func NewRateLimitedError(scope, key string, retryAfter time.Duration) *RateLimitedError {
+	return &RateLimitedError{scope, key, retryAfter}
+}
+
+// RetryAfter formats a delay as the value of a Retry-After header, in whole
+// seconds rounded up.
+func RetryAfter(d time.Duration) string {
+	secs := int64((d + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%d", max(1, secs))
+}
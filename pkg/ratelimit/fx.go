@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+var Module = fx.Module("ratelimit",
+	fx.Provide(NewLimiters),
+)
+
+// Limiters are the rate limits of retrievals. A nil limiter does not limit.
+type Limiters struct {
+	// IP limits blob and claim downloads per client IP.
+	IP *Limiter
+	// Space limits space/content/retrieve invocations per space.
+	Space      *Limiter
+	trustProxy bool
+}
+
+func NewLimiters(cfg app.ServerConfig) *Limiters {
+	rl := cfg.RateLimit
+	l := &Limiters{trustProxy: rl.TrustProxyHeaders}
+	if rl.IP.Rate > 0 {
+		l.IP = New("ip", rl.IP.Rate, rl.IP.Burst)
+	}
+	if rl.Space.Rate > 0 {
+		l.Space = New("space", rl.Space.Rate, rl.Space.Burst)
+	}
+	return l
+}
+
+// IPMiddleware limits requests per client IP.
+func (l *Limiters) IPMiddleware() echo.MiddlewareFunc {
+	if l == nil {
+		return Middleware(nil, nil)
+	}
+	return Middleware(l.IP, ClientIP(l.trustProxy))
+}
+
+// SpaceLimiter returns the per-space limiter, nil if spaces are not limited.
+func (l *Limiters) SpaceLimiter() *Limiter {
+	if l == nil {
+		return nil
+	}
+	return l.Space
+}
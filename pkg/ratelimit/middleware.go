@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// KeyFunc returns the key a request is limited by.
+type KeyFunc func(c echo.Context) string
+
+// ClientIP keys requests by the IP of the client. The X-Forwarded-For header
+// is only trusted if trustProxy is set, and then only from proxies on
+// loopback, link-local and private addresses, otherwise any client could
+// evade the limit by setting it.
+func ClientIP(trustProxy bool) KeyFunc {
+	extract := echo.ExtractIPDirect()
+	if trustProxy {
+		extract = echo.ExtractIPFromXFFHeader()
+	}
+	return func(c echo.Context) string {
+		return extract(c.Request())
+	}
+}
+
+// Middleware responds 429 Too Many Requests, with a Retry-After header, to
+// requests over the limit of their key. A nil limiter allows every request.
+func Middleware(l *Limiter, key KeyFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if l == nil {
+			return next
+		}
+		return func(c echo.Context) error {
+			k := key(c)
+			allowed, retryAfter := l.Allow(c.Request().Context(), k)
+			if !allowed {
+				c.Response().Header().Set("Retry-After", RetryAfter(retryAfter))
+				return echo.NewHTTPError(http.StatusTooManyRequests, NewRateLimitedError(l.scope, k, retryAfter).Error())
+			}
+			return next(c)
+		}
+	}
+}
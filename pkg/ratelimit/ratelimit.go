@@ -0,0 +1,120 @@
+// Package ratelimit throttles retrievals with a token bucket per key, such as
+// the IP of the client or the space retrieved from, so a single client cannot
+// monopolise the node.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+)
+
+var log = logging.Logger("ratelimit")
+
+// sweepInterval is how often buckets of idle keys are dropped.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter allows a sustained rate of requests per key, with bursts of up to
+// burst requests.
+type Limiter struct {
+	scope string
+	limit rate.Limit
+	burst int
+	// idle is how long a bucket takes to refill completely, after which it
+	// is equivalent to a new bucket and can be dropped.
+	idle time.Duration
+	now  func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	requests metric.Int64Counter
+}
+
+// New creates a Limiter allowing perSecond requests per second per key, with
+// bursts of up to burst requests. A burst of 0 is the rate rounded up. scope
+// names the limiter in metrics, e.g. "ip" or "space".
+func New(scope string, perSecond float64, burst int) *Limiter {
+	if burst <= 0 {
+		burst = max(1, int(math.Ceil(perSecond)))
+	}
+	l := &Limiter{
+		scope:   scope,
+		limit:   rate.Limit(perSecond),
+		burst:   burst,
+		idle:    time.Duration(float64(burst) / perSecond * float64(time.Second)),
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/ratelimit")
+	requests, err := meter.Int64Counter(
+		"piri_ratelimit_requests",
+		metric.WithDescription("Requests checked against a rate limit, by scope and whether they were allowed or limited"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		log.Warnw("creating rate limit metrics", "error", err)
+	}
+	l.requests = requests
+	return l
+}
+
+// Allow takes a token from the bucket of key. If the bucket is empty it
+// returns false and how long until a token is available.
+func (l *Limiter) Allow(ctx context.Context, key string) (bool, time.Duration) {
+	now := l.now()
+
+	l.mu.Lock()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	l.mu.Unlock()
+
+	r := b.limiter.ReserveN(now, 1)
+	delay := r.DelayFrom(now)
+	allowed := delay == 0
+	if !allowed {
+		// do not take a token from a request that is refused
+		r.CancelAt(now)
+	}
+
+	if l.requests != nil {
+		result := "allowed"
+		if !allowed {
+			result = "limited"
+		}
+		l.requests.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("scope", l.scope),
+			attribute.String("result", result),
+		))
+	}
+	return allowed, delay
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > l.idle {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
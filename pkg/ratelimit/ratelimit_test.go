@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := New("ip", 2, 3)
+	l.now = func() time.Time { return now }
+
+	t.Run("allows bursts", func(t *testing.T) {
+		for range 3 {
+			ok, _ := l.Allow(t.Context(), "a")
+			require.True(t, ok)
+		}
+		ok, retryAfter := l.Allow(t.Context(), "a")
+		require.False(t, ok)
+		require.Equal(t, 500*time.Millisecond, retryAfter)
+	})
+
+	t.Run("limits keys independently", func(t *testing.T) {
+		ok, _ := l.Allow(t.Context(), "b")
+		require.True(t, ok)
+	})
+
+	t.Run("refills at the rate", func(t *testing.T) {
+		now = now.Add(500 * time.Millisecond)
+		ok, _ := l.Allow(t.Context(), "a")
+		require.True(t, ok)
+		ok, _ = l.Allow(t.Context(), "a")
+		require.False(t, ok)
+	})
+
+	t.Run("drops idle keys", func(t *testing.T) {
+		now = now.Add(2 * sweepInterval)
+		ok, _ := l.Allow(t.Context(), "c")
+		require.True(t, ok)
+		require.Len(t, l.buckets, 1)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	e := echo.New()
+	l := New("ip", 0.001, 1)
+	e.GET("/blob/:blob", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, Middleware(l, ClientIP(false)))
+
+	get := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/blob/z", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusOK, get("203.0.113.1:1234", "").Code)
+
+	rec := get("203.0.113.1:1234", "")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "1000", rec.Header().Get("Retry-After"))
+
+	// forwarding headers are not trusted, so cannot evade the limit
+	require.Equal(t, http.StatusTooManyRequests, get("203.0.113.1:1234", "198.51.100.7").Code)
+	require.Equal(t, http.StatusOK, get("203.0.113.2:1234", "").Code)
+}
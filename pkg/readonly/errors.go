@@ -0,0 +1,37 @@
+package readonly
+
+import (
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/result/failure/datamodel"
+)
+
+// ServiceReadOnlyErrorName is the name of the failure returned in receipts
+// when an invocation writing to the node is refused in read-only mode.
+const ServiceReadOnlyErrorName = "ServiceReadOnly"
+
+// ServiceReadOnlyError is returned for allocations, replicas and uploads the
+// node refuses while read-only.
+type ServiceReadOnlyError struct {
+	Reason string
+}
+
+func (re ServiceReadOnlyError) Name() string {
+	return ServiceReadOnlyErrorName
+}
+
+func (re ServiceReadOnlyError) Error() string {
+	if re.Reason == "" {
+		return "storage node is read-only"
+	}
+	return "storage node is read-only: " + re.Reason
+}
+
+func (re ServiceReadOnlyError) ToIPLD() (ipld.Node, error) {
+	name := re.Name()
+	model := datamodel.FailureModel{Name: &name, Message: re.Error()}
+	return model.ToIPLD()
+}
+
+func NewServiceReadOnlyError(reason string) *ServiceReadOnlyError {
+	return &ServiceReadOnlyError{reason}
+}
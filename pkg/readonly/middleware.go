@@ -0,0 +1,20 @@
+package readonly
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware rejects requests with 503 Service Unavailable while the node is
+// read-only. With a nil mode requests are passed through.
+func (m *Mode) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := m.Check(); err != nil {
+				return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+			}
+			return next(c)
+		}
+	}
+}
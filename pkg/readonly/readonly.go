@@ -0,0 +1,180 @@
+// Package readonly puts the node in read-only mode, e.g. while its disks are
+// under pressure or its data is migrated. A read-only node keeps serving
+// retrievals and claims, but refuses new allocations, replicas and uploads.
+// The mode set by an operator is persisted so it survives restarts.
+package readonly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+var log = logging.Logger("readonly")
+
+// StateFile is the name of the file in the data directory that persists the
+// read-only mode set by an operator.
+const StateFile = "readonly.json"
+
+// ErrForced is returned when disabling read-only mode enabled by the node
+// configuration.
+var ErrForced = errors.New("read-only mode is enabled by configuration")
+
+// Status describes whether the node is read-only.
+type Status struct {
+	Enabled bool `json:"enabled"`
+	// Forced is true if the mode is enabled by configuration, in which case it
+	// cannot be disabled while the node runs.
+	Forced bool       `json:"forced,omitempty"`
+	Reason string     `json:"reason,omitempty"`
+	Since  *time.Time `json:"since,omitempty"`
+}
+
+type state struct {
+	Enabled bool      `json:"enabled"`
+	Reason  string    `json:"reason,omitempty"`
+	Since   time.Time `json:"since"`
+}
+
+// Mode is the read-only mode of the node. A nil *Mode is never read-only.
+type Mode struct {
+	mu     sync.RWMutex
+	path   string
+	forced bool
+	state  state
+}
+
+// New creates the read-only mode, persisted to the file at path, or kept in
+// memory only if path is empty. If forced, the node is read-only whatever the
+// persisted state.
+func New(path string, forced bool) (*Mode, error) {
+	m := &Mode{path: path, forced: forced}
+	if forced {
+		m.state = state{Enabled: true, Reason: "enabled by configuration", Since: time.Now().UTC()}
+	}
+	if path == "" {
+		return m, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("reading read-only state: %w", err)
+	}
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("decoding read-only state %s: %w", path, err)
+	}
+	if s.Enabled {
+		m.state = s
+	}
+	return m, nil
+}
+
+// Enabled reports whether the node is read-only.
+func (m *Mode) Enabled() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.Enabled
+}
+
+// Check returns a [ServiceReadOnlyError] if the node is read-only.
+func (m *Mode) Check() error {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.state.Enabled {
+		return nil
+	}
+	return NewServiceReadOnlyError(m.state.Reason)
+}
+
+// Enable makes the node read-only for the given reason and persists the mode.
+// Enabling it again updates the reason.
+func (m *Mode) Enable(reason string) (Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prev := m.state
+	m.state.Reason = reason
+	if !prev.Enabled {
+		m.state.Enabled = true
+		m.state.Since = time.Now().UTC()
+	}
+	if err := m.persist(); err != nil {
+		m.state = prev
+		return Status{}, err
+	}
+	log.Warnw("enabled read-only mode", "reason", reason)
+	return m.status(), nil
+}
+
+// Disable makes the node writable again and persists the mode. It returns
+// [ErrForced] if the mode is enabled by configuration.
+func (m *Mode) Disable() (Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.forced {
+		return Status{}, ErrForced
+	}
+	prev := m.state
+	m.state = state{}
+	if err := m.persist(); err != nil {
+		m.state = prev
+		return Status{}, err
+	}
+	log.Infow("disabled read-only mode")
+	return m.status(), nil
+}
+
+// Status returns whether the node is read-only, and why.
+func (m *Mode) Status() Status {
+	if m == nil {
+		return Status{}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status()
+}
+
+func (m *Mode) status() Status {
+	if !m.state.Enabled {
+		return Status{}
+	}
+	since := m.state.Since
+	return Status{Enabled: true, Forced: m.forced, Reason: m.state.Reason, Since: &since}
+}
+
+// persist writes the mode to disk. Callers must hold m.mu.
+func (m *Mode) persist() error {
+	if m.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(m.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding read-only state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return fmt.Errorf("creating read-only state directory: %w", err)
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing read-only state: %w", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return fmt.Errorf("writing read-only state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package readonly
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMode(t *testing.T) {
+	t.Run("enables and disables", func(t *testing.T) {
+		m, err := New("", false)
+		require.NoError(t, err)
+		require.False(t, m.Enabled())
+		require.NoError(t, m.Check())
+
+		s, err := m.Enable("disk pressure")
+		require.NoError(t, err)
+		require.True(t, s.Enabled)
+		require.False(t, s.Forced)
+		require.Equal(t, "disk pressure", s.Reason)
+		require.NotNil(t, s.Since)
+
+		var re *ServiceReadOnlyError
+		require.ErrorAs(t, m.Check(), &re)
+		require.Equal(t, ServiceReadOnlyErrorName, re.Name())
+		require.Equal(t, "disk pressure", re.Reason)
+
+		s, err = m.Disable()
+		require.NoError(t, err)
+		require.False(t, s.Enabled)
+		require.NoError(t, m.Check())
+	})
+
+	t.Run("persists the mode", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), StateFile)
+		m, err := New(path, false)
+		require.NoError(t, err)
+		_, err = m.Enable("migration")
+		require.NoError(t, err)
+
+		// simulate a restart
+		m, err = New(path, false)
+		require.NoError(t, err)
+		require.True(t, m.Enabled())
+		require.Equal(t, "migration", m.Status().Reason)
+
+		_, err = m.Disable()
+		require.NoError(t, err)
+		m, err = New(path, false)
+		require.NoError(t, err)
+		require.False(t, m.Enabled())
+	})
+
+	t.Run("cannot disable mode forced by configuration", func(t *testing.T) {
+		m, err := New("", true)
+		require.NoError(t, err)
+		require.True(t, m.Enabled())
+		require.True(t, m.Status().Forced)
+
+		_, err = m.Disable()
+		require.ErrorIs(t, err, ErrForced)
+		require.True(t, m.Enabled())
+	})
+
+	t.Run("nil mode is never read-only", func(t *testing.T) {
+		var m *Mode
+		require.False(t, m.Enabled())
+		require.NoError(t, m.Check())
+		require.False(t, m.Status().Enabled)
+	})
+}
@@ -89,7 +89,7 @@ func NewServer(storageSvc storage.Service, retrievalSvc retrieval.Service, optio
 	}
 	httpClaimsSrv.RegisterRoutes(mux)
 
-	httpBlobsSrv, err := blobs.NewServer(storageSvc.Blobs().Presigner(), storageSvc.Blobs().Allocations(), storageSvc.Blobs().Store())
+	httpBlobsSrv, err := blobs.NewServer(storageSvc.Blobs().Presigner(), storageSvc.Blobs().Allocations(), storageSvc.Blobs().Store(), storageSvc.Blobs().Latency(), storageSvc.Blobs().CDN(), storageSvc.ReadOnly(), storageSvc.Blocklist(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating blobs server: %w", err)
 	}
@@ -0,0 +1,172 @@
+// Package admission estimates the ongoing cost of accepting a blob and
+// flags or rejects allocations that cost more than they earn.
+//
+// A blob added to a proof set has to be proven every proving period, stored
+// and served for as long as it is held. The monthly cost of an allocation is
+// estimated from its share of the proof gas and fees of the proof set, the
+// configured storage cost and an egress reserve. It is compared against the
+// revenue the allocation earns at the payment rate of the proof set's rail.
+package admission
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/storacha/go-ucanto/did"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+var log = logging.Logger("admission")
+
+const (
+	// DefaultProofGas is the gas used by a proof submission when not
+	// configured.
+	DefaultProofGas = 200_000_000
+	// DefaultMinRatio is the ratio of revenue to cost below which an
+	// allocation is uneconomic when not configured.
+	DefaultMinRatio = 1.0
+
+	// EpochsPerMonth is the number of 30 second chain epochs in 30 days.
+	EpochsPerMonth = 30 * 24 * 60 * 2
+	// TiB is the number of bytes in a tebibyte.
+	TiB = 1 << 40
+)
+
+// tokenUnit is the number of base units in a FIL or payment token, both of
+// which have 18 decimals.
+var tokenUnit = big.NewFloat(1e18)
+
+// DataSet is the on chain state of the proof set allocations are added to.
+type DataSet struct {
+	// Size is the number of bytes in the proof set.
+	Size uint64
+	// PaymentRate is the payment per epoch of the rail paying for the proof
+	// set, in payment token base units.
+	PaymentRate *big.Int
+	// ProvingPeriod is the number of epochs between proofs.
+	ProvingPeriod uint64
+	// ProofFee is the fee paid per proof, in attoFIL.
+	ProofFee *big.Int
+	// BaseFee is the representative base fee proofs are sent at, in attoFIL.
+	BaseFee *big.Int
+}
+
+// Source provides the state of the proof set allocations are added to.
+type Source interface {
+	DataSet(ctx context.Context) (DataSet, error)
+}
+
+// Estimate is the projected monthly cost and revenue of an allocation, in
+// whole payment tokens.
+type Estimate struct {
+	Size uint64
+	// ProofShare is the allocation's share of the proof set proving costs,
+	// proportional to its size.
+	ProofShare float64
+	Storage    float64
+	Egress     float64
+	// Revenue is the payment earned by the allocation at the average rate
+	// per byte of the proof set.
+	Revenue float64
+}
+
+// Cost is the total monthly cost of the allocation.
+func (e Estimate) Cost() float64 {
+	return e.ProofShare + e.Storage + e.Egress
+}
+
+// Calculator estimates the cost of allocations and decides their admission.
+type Calculator struct {
+	cfg     app.AdmissionConfig
+	source  Source
+	metrics *metrics
+}
+
+// New creates a calculator estimating costs from the proof set state
+// provided by source.
+func New(cfg app.AdmissionConfig, source Source) (*Calculator, error) {
+	if cfg.ProofGas == 0 {
+		cfg.ProofGas = DefaultProofGas
+	}
+	if cfg.MinRatio == 0 {
+		cfg.MinRatio = DefaultMinRatio
+	}
+	m, err := newMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("creating admission metrics: %w", err)
+	}
+	return &Calculator{cfg: cfg, source: source, metrics: m}, nil
+}
+
+// Estimate returns the projected monthly cost and revenue of allocating size
+// bytes.
+func (c *Calculator) Estimate(ctx context.Context, size uint64) (Estimate, error) {
+	ds, err := c.source.DataSet(ctx)
+	if err != nil {
+		return Estimate{}, fmt.Errorf("getting data set: %w", err)
+	}
+	return estimate(c.cfg, ds, size), nil
+}
+
+// Admit checks whether allocating size bytes in space is economic. It
+// returns an *UneconomicAllocationError if it is not and allocations are
+// rejected. Allocations are admitted if their cost cannot be estimated.
+func (c *Calculator) Admit(ctx context.Context, space did.DID, size uint64) error {
+	est, err := c.Estimate(ctx, size)
+	if err != nil {
+		log.Warnw("estimating allocation cost, admitting", "space", space, "size", size, "error", err)
+		c.metrics.decisions.Inc(ctx, attribute.String("result", "unknown"))
+		return nil
+	}
+	if est.Revenue >= est.Cost()*c.cfg.MinRatio {
+		c.metrics.decisions.Inc(ctx, attribute.String("result", "admitted"))
+		return nil
+	}
+	if c.cfg.Mode != app.AdmissionReject {
+		log.Warnw("admitting uneconomic allocation", "space", space, "size", size, "cost", est.Cost(), "revenue", est.Revenue)
+		c.metrics.decisions.Inc(ctx, attribute.String("result", "flagged"))
+		return nil
+	}
+	log.Infow("rejecting uneconomic allocation", "space", space, "size", size, "cost", est.Cost(), "revenue", est.Revenue)
+	c.metrics.decisions.Inc(ctx, attribute.String("result", "rejected"))
+	return NewUneconomicAllocationError(size, est.Cost(), est.Revenue)
+}
+
+func estimate(cfg app.AdmissionConfig, ds DataSet, size uint64) Estimate {
+	est := Estimate{
+		Size:    size,
+		Storage: cfg.StorageCost * float64(size) / TiB,
+		Egress:  cfg.EgressReserve * float64(size) / TiB,
+	}
+
+	if ds.ProvingPeriod > 0 {
+		// proving costs are per proof set, shared by its bytes
+		perProof := new(big.Int)
+		if ds.BaseFee != nil {
+			perProof.Mul(new(big.Int).SetUint64(cfg.ProofGas), ds.BaseFee)
+		}
+		if ds.ProofFee != nil {
+			perProof.Add(perProof, ds.ProofFee)
+		}
+		proofs := float64(EpochsPerMonth) / float64(ds.ProvingPeriod)
+		fil, _ := new(big.Float).Quo(new(big.Float).SetInt(perProof), tokenUnit).Float64()
+		share := float64(size) / float64(ds.Size+size)
+		est.ProofShare = fil * proofs * cfg.FILPrice * share
+	}
+
+	if ds.PaymentRate != nil {
+		monthly, _ := new(big.Float).Quo(new(big.Float).SetInt(new(big.Int).Mul(ds.PaymentRate, big.NewInt(EpochsPerMonth))), tokenUnit).Float64()
+		if ds.Size == 0 {
+			// the rate of an empty proof set is its minimum, all of it is
+			// earned by the first allocation
+			est.Revenue = monthly
+		} else {
+			est.Revenue = monthly * float64(size) / float64(ds.Size)
+		}
+	}
+	return est
+}
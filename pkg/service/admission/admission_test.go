@@ -0,0 +1,104 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+type mockSource struct {
+	ds  DataSet
+	err error
+}
+
+func (m mockSource) DataSet(ctx context.Context) (DataSet, error) {
+	return m.ds, m.err
+}
+
+// testDataSet is a TiB proof set proven daily, each proof costing 0.1 FIL.
+func testDataSet(rate int64) DataSet {
+	return DataSet{
+		Size:          TiB,
+		PaymentRate:   big.NewInt(rate),
+		ProvingPeriod: 2880,
+		ProofFee:      big.NewInt(0),
+		BaseFee:       big.NewInt(1_000_000_000),
+	}
+}
+
+var testConfig = app.AdmissionConfig{
+	Mode:          app.AdmissionReject,
+	StorageCost:   0.5,
+	EgressReserve: 0.1,
+	FILPrice:      2,
+	ProofGas:      100_000_000,
+}
+
+func TestEstimate(t *testing.T) {
+	calc, err := New(testConfig, mockSource{ds: testDataSet(10_000_000_000_000)})
+	require.NoError(t, err)
+
+	est, err := calc.Estimate(t.Context(), TiB)
+	require.NoError(t, err)
+	// 30 proofs of 0.1 FIL at 2 tokens, half of which is the allocation's share
+	require.InDelta(t, 3, est.ProofShare, 1e-9)
+	require.InDelta(t, 0.5, est.Storage, 1e-9)
+	require.InDelta(t, 0.1, est.Egress, 1e-9)
+	require.InDelta(t, 3.6, est.Cost(), 1e-9)
+	// 1e13 base units per epoch over 86400 epochs
+	require.InDelta(t, 0.864, est.Revenue, 1e-9)
+
+	t.Run("empty proof set earns its whole rate", func(t *testing.T) {
+		ds := testDataSet(10_000_000_000_000)
+		ds.Size = 0
+		calc, err := New(testConfig, mockSource{ds: ds})
+		require.NoError(t, err)
+
+		est, err := calc.Estimate(t.Context(), TiB)
+		require.NoError(t, err)
+		require.InDelta(t, 6, est.ProofShare, 1e-9)
+		require.InDelta(t, 0.864, est.Revenue, 1e-9)
+	})
+}
+
+func TestAdmit(t *testing.T) {
+	space := testutil.RandomDID(t)
+
+	t.Run("admits economic allocations", func(t *testing.T) {
+		calc, err := New(testConfig, mockSource{ds: testDataSet(100_000_000_000_000)})
+		require.NoError(t, err)
+		require.NoError(t, calc.Admit(t.Context(), space, TiB))
+	})
+
+	t.Run("rejects uneconomic allocations", func(t *testing.T) {
+		calc, err := New(testConfig, mockSource{ds: testDataSet(10_000_000_000_000)})
+		require.NoError(t, err)
+
+		err = calc.Admit(t.Context(), space, TiB)
+		var ue *UneconomicAllocationError
+		require.ErrorAs(t, err, &ue)
+		require.Equal(t, uint64(TiB), ue.Size)
+		require.InDelta(t, 3.6, ue.Cost, 1e-9)
+		require.InDelta(t, 0.864, ue.Revenue, 1e-9)
+	})
+
+	t.Run("flags uneconomic allocations", func(t *testing.T) {
+		cfg := testConfig
+		cfg.Mode = app.AdmissionFlag
+		calc, err := New(cfg, mockSource{ds: testDataSet(10_000_000_000_000)})
+		require.NoError(t, err)
+		require.NoError(t, calc.Admit(t.Context(), space, TiB))
+	})
+
+	t.Run("admits allocations when the cost is unknown", func(t *testing.T) {
+		calc, err := New(testConfig, mockSource{err: errors.New("chain unavailable")})
+		require.NoError(t, err)
+		require.NoError(t, calc.Admit(t.Context(), space, TiB))
+	})
+}
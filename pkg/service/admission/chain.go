@@ -0,0 +1,108 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/storacha/piri/pkg/pdp/gasoracle"
+	"github.com/storacha/piri/pkg/pdp/smartcontracts"
+)
+
+// CacheTTL is how long the state of the proof set is reused before it is
+// read from the chain again.
+const CacheTTL = 5 * time.Minute
+
+// leafSize is the number of bytes in a proof set leaf.
+const leafSize = 32
+
+// ChainSource reads the state of a proof set from the PDP contracts, caching
+// it for [CacheTTL].
+type ChainSource struct {
+	proofSet *big.Int
+	verifier smartcontracts.Verifier
+	service  smartcontracts.Service
+	payment  smartcontracts.Payment
+	// oracle is nil if base fees are not tracked.
+	oracle *gasoracle.Oracle
+	now    func() time.Time
+
+	mu        sync.Mutex
+	cached    DataSet
+	fetchedAt time.Time
+}
+
+var _ Source = (*ChainSource)(nil)
+
+// NewChainSource creates a source reading the state of proofSet.
+func NewChainSource(proofSet uint64, verifier smartcontracts.Verifier, service smartcontracts.Service, payment smartcontracts.Payment, oracle *gasoracle.Oracle) *ChainSource {
+	return &ChainSource{
+		proofSet: new(big.Int).SetUint64(proofSet),
+		verifier: verifier,
+		service:  service,
+		payment:  payment,
+		oracle:   oracle,
+		now:      time.Now,
+	}
+}
+
+func (s *ChainSource) DataSet(ctx context.Context) (DataSet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.fetchedAt.IsZero() && s.now().Sub(s.fetchedAt) < CacheTTL {
+		return s.cached, nil
+	}
+	ds, err := s.fetch(ctx)
+	if err != nil {
+		return DataSet{}, err
+	}
+	s.cached, s.fetchedAt = ds, s.now()
+	return ds, nil
+}
+
+func (s *ChainSource) fetch(ctx context.Context) (DataSet, error) {
+	if s.proofSet.Sign() == 0 {
+		return DataSet{}, errors.New("no proof set configured")
+	}
+	leaves, err := s.verifier.GetDataSetLeafCount(ctx, s.proofSet)
+	if err != nil {
+		return DataSet{}, fmt.Errorf("getting leaf count: %w", err)
+	}
+	fee, err := s.verifier.CalculateProofFee(ctx, s.proofSet)
+	if err != nil {
+		return DataSet{}, fmt.Errorf("calculating proof fee: %w", err)
+	}
+	pdpCfg, err := s.service.PDPConfig(ctx)
+	if err != nil {
+		return DataSet{}, fmt.Errorf("getting PDP config: %w", err)
+	}
+	info, err := s.service.GetDataSet(ctx, s.proofSet)
+	if err != nil {
+		return DataSet{}, fmt.Errorf("getting data set: %w", err)
+	}
+	rail, err := s.payment.GetRail(ctx, info.PdpRailId)
+	if err != nil {
+		return DataSet{}, fmt.Errorf("getting rail %s: %w", info.PdpRailId, err)
+	}
+
+	ds := DataSet{
+		Size:          leaves.Uint64() * leafSize,
+		PaymentRate:   rail.PaymentRate,
+		ProvingPeriod: pdpCfg.MaxProvingPeriod,
+		ProofFee:      fee,
+	}
+	if s.oracle != nil {
+		if median, ok := s.oracle.Percentile(50); ok {
+			ds.BaseFee = median
+		} else if latest, ok := s.oracle.Latest(); ok {
+			ds.BaseFee = latest.BaseFee
+		}
+	}
+	if ds.BaseFee == nil {
+		return DataSet{}, errors.New("no base fee history")
+	}
+	return ds, nil
+}
@@ -0,0 +1,39 @@
+package admission
+
+import (
+	"fmt"
+
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/result/failure/datamodel"
+)
+
+// UneconomicAllocationErrorName is the name of the failure returned in
+// receipts when an allocation is rejected for costing more than it earns.
+const UneconomicAllocationErrorName = "UneconomicAllocation"
+
+// UneconomicAllocationError is returned when the projected monthly cost of an
+// allocation exceeds the revenue it earns.
+type UneconomicAllocationError struct {
+	Size uint64
+	// Cost and Revenue are monthly, in payment tokens.
+	Cost    float64
+	Revenue float64
+}
+
+func (ue UneconomicAllocationError) Name() string {
+	return UneconomicAllocationErrorName
+}
+
+func (ue UneconomicAllocationError) Error() string {
+	return fmt.Sprintf("allocation of %d bytes is uneconomic: projected monthly cost %g exceeds revenue %g", ue.Size, ue.Cost, ue.Revenue)
+}
+
+func (ue UneconomicAllocationError) ToIPLD() (ipld.Node, error) {
+	name := ue.Name()
+	model := datamodel.FailureModel{Name: &name, Message: ue.Error()}
+	return model.ToIPLD()
+}
+
+func NewUneconomicAllocationError(size uint64, cost, revenue float64) *UneconomicAllocationError {
+	return &UneconomicAllocationError{size, cost, revenue}
+}
@@ -0,0 +1,39 @@
+package admission
+
+import (
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/pdp/gasoracle"
+	"github.com/storacha/piri/pkg/pdp/smartcontracts"
+)
+
+var Module = fx.Module("admission",
+	fx.Provide(NewCalculatorFromParams),
+)
+
+type Params struct {
+	fx.In
+
+	Config    app.UCANServiceConfig
+	Verifier  smartcontracts.Verifier `optional:"true"`
+	Service   smartcontracts.Service  `optional:"true"`
+	Payment   smartcontracts.Payment  `optional:"true"`
+	GasOracle *gasoracle.Oracle       `optional:"true"`
+}
+
+// NewCalculatorFromParams creates the admission calculator, nil when
+// admission control is off or the node does not run PDP, as allocations then
+// have no proving cost or payment rail to weigh.
+func NewCalculatorFromParams(params Params) (*Calculator, error) {
+	cfg := params.Config.Admission
+	if cfg.Mode == app.AdmissionOff {
+		return nil, nil
+	}
+	if params.Verifier == nil || params.Service == nil || params.Payment == nil {
+		log.Warn("admission control is configured but PDP is not enabled, allocations are not checked")
+		return nil, nil
+	}
+	source := NewChainSource(params.Config.ProofSetID, params.Verifier, params.Service, params.Payment, params.GasOracle)
+	return New(cfg, source)
+}
@@ -0,0 +1,25 @@
+package admission
+
+import (
+	"go.opentelemetry.io/otel"
+
+	"github.com/storacha/piri/lib/telemetry"
+)
+
+type metrics struct {
+	decisions *telemetry.Counter
+}
+
+func newMetrics() (*metrics, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/service/admission")
+	decisions, err := telemetry.NewCounter(
+		meter,
+		"piri_admission_decisions",
+		"allocations checked for admission, by result (admitted, flagged, rejected or unknown when the cost could not be estimated)",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &metrics{decisions: decisions}, nil
+}
@@ -0,0 +1,260 @@
+// Package billing prices the usage of spaces at configurable rate plans and
+// produces monthly invoices.
+//
+// Each space is billed at the plan assigned to it, or else the default plan.
+// The usage of every space is sampled from the quota manager each meter
+// interval. Storage is accumulated in GiB-hours per calendar month (UTC),
+// prorated between samples, so it is billed per GiB-month for as long as it
+// was held. Egress is billed per GiB downloaded in the month, as counted at
+// the last sample. Invoices are priced only from the metered usage.
+//
+// Invoices list a line item per space and resource. They can be exported as
+// JSON or CSV at any time, and are pushed to the billing webhook once their
+// month has been closed by the first sample after it ended.
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-ucanto/did"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/service/quota"
+)
+
+var log = logging.Logger("billing")
+
+const (
+	// DataDir is the directory, relative to the data directory, holding plan
+	// assignments and metered storage.
+	DataDir = "billing"
+	// DefaultMeterInterval is how often storage is sampled if no interval is
+	// configured.
+	DefaultMeterInterval = time.Hour
+	// DefaultCurrency is the currency of plan prices if none is configured.
+	DefaultCurrency = "USD"
+	// PeriodLayout formats the calendar month an invoice is for.
+	PeriodLayout = "2006-01"
+
+	assignmentsPrefix = "/spaces/"
+)
+
+// ErrUnknownPlan is returned when assigning a plan that is not configured.
+var ErrUnknownPlan = errors.New("unknown rate plan")
+
+// UsageSource lists the bytes spaces store and download.
+type UsageSource interface {
+	ListUsage(ctx context.Context, period string) (map[did.DID]quota.Usage, error)
+}
+
+var _ UsageSource = (*quota.Manager)(nil)
+
+// Assignment assigns a rate plan to a space.
+type Assignment struct {
+	Space did.DID `json:"-"`
+	Plan  string  `json:"plan"`
+	// Tenant is the customer the space belongs to, copied to its line items
+	// so invoices can be split per customer.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Service meters the usage of spaces and prices it in invoices.
+type Service struct {
+	ds       datastore.Batching
+	usage    UsageSource
+	plans    []app.RatePlan
+	currency string
+	def      string
+	interval time.Duration
+	webhook  app.BillingWebhookConfig
+	client   *http.Client
+	clock    clock.Clock
+
+	// mu serializes the read-modify-write of metered storage.
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock sets the clock storage is metered and invoices are dated by.
+// Defaults to the real clock.
+func WithClock(clock clock.Clock) Option {
+	return func(s *Service) {
+		s.clock = clock
+	}
+}
+
+// WithHTTPClient sets the client invoices are pushed with.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Service) {
+		s.client = client
+	}
+}
+
+// New creates a Service pricing the usage listed by usage at the plans of
+// cfg, persisting assignments and metered storage in ds.
+func New(ds datastore.Batching, usage UsageSource, cfg app.BillingConfig, opts ...Option) (*Service, error) {
+	if len(cfg.Plans) == 0 {
+		return nil, errors.New("no rate plans configured")
+	}
+	s := &Service{
+		ds:       ds,
+		usage:    usage,
+		plans:    cfg.Plans,
+		currency: cfg.Currency,
+		def:      cfg.DefaultPlan,
+		interval: cfg.MeterInterval,
+		webhook:  cfg.Webhook,
+		client:   &http.Client{Timeout: pushTimeout},
+		clock:    clock.New(),
+		done:     make(chan struct{}),
+	}
+	if s.currency == "" {
+		s.currency = DefaultCurrency
+	}
+	if s.interval <= 0 {
+		s.interval = DefaultMeterInterval
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Start meters storage every interval, and pushes the invoice of the
+// previous month once it has ended, until stopped.
+func (s *Service) Start(ctx context.Context) error {
+	ctx, s.cancel = context.WithCancel(ctx)
+	go s.run(ctx)
+	return nil
+}
+
+// Stop stops metering. Storage held while stopped is metered on the next
+// start.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for billing to stop: %w", ctx.Err())
+	}
+}
+
+func (s *Service) run(ctx context.Context) {
+	defer close(s.done)
+	ticker := s.clock.Ticker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Meter(ctx); err != nil && ctx.Err() == nil {
+			log.Errorw("metering storage", "error", err)
+		}
+		if s.webhook.URL != "" {
+			if err := s.pushEnded(ctx); err != nil && ctx.Err() == nil {
+				log.Errorw("pushing invoice", "error", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Plans returns the configured rate plans.
+func (s *Service) Plans() []app.RatePlan {
+	return slices.Clone(s.plans)
+}
+
+// DefaultPlan returns the plan of spaces not assigned one, empty if they are
+// not billed.
+func (s *Service) DefaultPlan() string {
+	return s.def
+}
+
+// Currency returns the currency plan prices are in.
+func (s *Service) Currency() string {
+	return s.currency
+}
+
+func (s *Service) plan(name string) (app.RatePlan, bool) {
+	i := slices.IndexFunc(s.plans, func(p app.RatePlan) bool { return p.Name == name })
+	if i < 0 {
+		return app.RatePlan{}, false
+	}
+	return s.plans[i], true
+}
+
+// SetSpacePlan assigns a plan to a space, with the tenant it belongs to. An
+// empty plan removes the assignment, so the space is billed at the default
+// plan.
+func (s *Service) SetSpacePlan(ctx context.Context, space did.DID, plan, tenant string) error {
+	key := datastore.NewKey(assignmentsPrefix + space.String())
+	if plan == "" {
+		if err := s.ds.Delete(ctx, key); err != nil && !errors.Is(err, datastore.ErrNotFound) {
+			return fmt.Errorf("deleting rate plan assignment: %w", err)
+		}
+		return nil
+	}
+	if _, ok := s.plan(plan); !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownPlan, plan)
+	}
+	data, err := json.Marshal(Assignment{Plan: plan, Tenant: tenant})
+	if err != nil {
+		return fmt.Errorf("encoding rate plan assignment: %w", err)
+	}
+	if err := s.ds.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("putting rate plan assignment: %w", err)
+	}
+	return nil
+}
+
+// Assignments returns the spaces assigned a plan, ordered by space.
+func (s *Service) Assignments(ctx context.Context) ([]Assignment, error) {
+	results, err := s.ds.Query(ctx, query.Query{Prefix: assignmentsPrefix})
+	if err != nil {
+		return nil, fmt.Errorf("querying rate plan assignments: %w", err)
+	}
+	defer results.Close()
+
+	var out []Assignment
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating rate plan assignments: %w", entry.Error)
+		}
+		space, err := did.Parse(strings.TrimPrefix(entry.Key, assignmentsPrefix))
+		if err != nil {
+			log.Warnw("skipping rate plan assignment with invalid space", "key", entry.Key, "error", err)
+			continue
+		}
+		var a Assignment
+		if err := json.Unmarshal(entry.Value, &a); err != nil {
+			return nil, fmt.Errorf("decoding rate plan assignment of %s: %w", space, err)
+		}
+		a.Space = space
+		out = append(out, a)
+	}
+	slices.SortFunc(out, func(a, b Assignment) int {
+		return strings.Compare(a.Space.String(), b.Space.String())
+	})
+	return out, nil
+}
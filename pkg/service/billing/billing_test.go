@@ -0,0 +1,245 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/service/quota"
+	"github.com/storacha/piri/pkg/webhook"
+)
+
+// mockUsage reports fixed storage, and egress per period.
+type mockUsage struct {
+	storage map[did.DID]uint64
+	egress  map[string]map[did.DID]uint64
+}
+
+func (m *mockUsage) ListUsage(_ context.Context, period string) (map[did.DID]quota.Usage, error) {
+	out := map[did.DID]quota.Usage{}
+	for space, n := range m.storage {
+		out[space] = quota.Usage{Storage: n, Period: period}
+	}
+	for space, n := range m.egress[period] {
+		u := out[space]
+		u.Egress, u.Period = n, period
+		out[space] = u
+	}
+	return out, nil
+}
+
+var testPlans = []app.RatePlan{
+	{Name: "standard", StorageGiBMonth: 0.02, EgressGiB: 0.01},
+	{Name: "archive", StorageGiBMonth: 0.004, EgressGiB: 0.05},
+}
+
+func newTestService(t *testing.T, usage UsageSource, cfg app.BillingConfig, clk clock.Clock) *Service {
+	t.Helper()
+	if cfg.Plans == nil {
+		cfg.Plans = testPlans
+	}
+	s, err := New(sync.MutexWrap(datastore.NewMapDatastore()), usage, cfg, WithClock(clk))
+	require.NoError(t, err)
+	return s
+}
+
+func TestMeter(t *testing.T) {
+	space := testutil.RandomDID(t)
+	usage := &mockUsage{storage: map[did.DID]uint64{space: 2 * gib}}
+	clk := clock.NewMock()
+	clk.Set(time.Date(2026, time.September, 30, 12, 0, 0, 0, time.UTC))
+	s := newTestService(t, usage, app.BillingConfig{}, clk)
+
+	// the first sample only records its time
+	require.NoError(t, s.Meter(t.Context()))
+	hours, err := s.gibHours(t.Context(), "2026-09")
+	require.NoError(t, err)
+	require.Empty(t, hours)
+
+	// storage is split between the months it was held in
+	clk.Add(24 * time.Hour)
+	require.NoError(t, s.Meter(t.Context()))
+	hours, err = s.gibHours(t.Context(), "2026-09")
+	require.NoError(t, err)
+	require.InDelta(t, 24, hours[space], 1e-9)
+	hours, err = s.gibHours(t.Context(), "2026-10")
+	require.NoError(t, err)
+	require.InDelta(t, 24, hours[space], 1e-9)
+
+	// the sample after September ended closed it
+	closed, err := s.closed(t.Context(), "2026-09")
+	require.NoError(t, err)
+	require.True(t, closed)
+	closed, err = s.closed(t.Context(), "2026-10")
+	require.NoError(t, err)
+	require.False(t, closed)
+}
+
+func TestMeterProratesAcrossGaps(t *testing.T) {
+	grown, removed := testutil.RandomDID(t), testutil.RandomDID(t)
+	usage := &mockUsage{storage: map[did.DID]uint64{grown: 2 * gib, removed: 4 * gib}}
+	clk := clock.NewMock()
+	clk.Set(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, usage, app.BillingConfig{}, clk)
+	require.NoError(t, s.Meter(t.Context()))
+
+	// ten hours pass without a sample, while one space grows and the other
+	// is emptied
+	usage.storage = map[did.DID]uint64{grown: 4 * gib}
+	clk.Add(10 * time.Hour)
+	require.NoError(t, s.Meter(t.Context()))
+
+	hours, err := s.gibHours(t.Context(), "2026-10")
+	require.NoError(t, err)
+	require.InDelta(t, 30, hours[grown], 1e-9)
+	require.InDelta(t, 20, hours[removed], 1e-9)
+}
+
+func TestInvoice(t *testing.T) {
+	ctx := t.Context()
+	standard, archived, unassigned := testutil.RandomDID(t), testutil.RandomDID(t), testutil.RandomDID(t)
+	usage := &mockUsage{
+		storage: map[did.DID]uint64{standard: 10 * gib, archived: 100 * gib, unassigned: gib},
+		egress:  map[string]map[did.DID]uint64{"2026-02": {standard: 5 * gib, unassigned: gib}},
+	}
+	clk := clock.NewMock()
+	clk.Set(time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC))
+	s := newTestService(t, usage, app.BillingConfig{}, clk)
+
+	require.NoError(t, s.SetSpacePlan(ctx, standard, "standard", "acme"))
+	require.NoError(t, s.SetSpacePlan(ctx, archived, "archive", "acme"))
+	require.ErrorIs(t, s.SetSpacePlan(ctx, archived, "gold", ""), ErrUnknownPlan)
+
+	// meter the whole of February
+	require.NoError(t, s.Meter(ctx))
+	clk.Add(28 * 24 * time.Hour)
+	require.NoError(t, s.Meter(ctx))
+	// egress counted after the month was closed is not billed
+	usage.egress["2026-02"][standard] = 50 * gib
+
+	inv, err := s.Invoice(ctx, "2026-02")
+	require.NoError(t, err)
+	require.Equal(t, "USD", inv.Currency)
+	require.True(t, inv.Final)
+	// spaces without a plan are not billed without a default plan
+	require.Equal(t, []LineItem{
+		{Space: archived.String(), Tenant: "acme", Plan: "archive", Resource: ResourceStorage, Quantity: 100, Unit: UnitGiBMonth, UnitPrice: 0.004, Amount: 0.4},
+		{Space: standard.String(), Tenant: "acme", Plan: "standard", Resource: ResourceEgress, Quantity: 5, Unit: UnitGiB, UnitPrice: 0.01, Amount: 0.05},
+		{Space: standard.String(), Tenant: "acme", Plan: "standard", Resource: ResourceStorage, Quantity: 10, Unit: UnitGiBMonth, UnitPrice: 0.02, Amount: 0.2},
+	}, sortBySpace(inv.Lines, archived, standard))
+	require.Equal(t, 0.65, inv.Total)
+
+	t.Run("bills spaces without a plan at the default plan", func(t *testing.T) {
+		s.def = "standard"
+		inv, err := s.Invoice(ctx, "2026-02")
+		require.NoError(t, err)
+		require.Len(t, inv.Lines, 5)
+		require.Equal(t, 0.68, inv.Total)
+	})
+
+	t.Run("exports CSV", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteCSV(&buf, inv))
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		require.Len(t, lines, 4)
+		require.Equal(t, "period,tenant,space,plan,resource,quantity,unit,unit_price,amount,currency", string(lines[0]))
+		require.Contains(t, buf.String(), "2026-02,acme,"+archived.String()+",archive,storage,100,GiB-month,0.004,0.4,USD\n")
+	})
+
+	t.Run("rejects invalid periods", func(t *testing.T) {
+		_, err := s.Invoice(ctx, "2026-13")
+		require.Error(t, err)
+	})
+}
+
+// sortBySpace orders lines of the given spaces first, in the order given, for
+// comparison independent of the order of random DIDs.
+func sortBySpace(lines []LineItem, spaces ...did.DID) []LineItem {
+	var out []LineItem
+	for _, space := range spaces {
+		for _, l := range lines {
+			if l.Space == space.String() {
+				out = append(out, l)
+			}
+		}
+	}
+	return out
+}
+
+func TestPush(t *testing.T) {
+	ctx := t.Context()
+	secret := []byte("secret")
+	clk := clock.NewMock()
+	clk.Set(time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC))
+
+	var received []Invoice
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, webhook.Verify(secret, r.Header.Get(webhook.SignatureHeader), body, clk.Now(), time.Minute))
+		var inv Invoice
+		require.NoError(t, json.Unmarshal(body, &inv))
+		require.Equal(t, inv.Period, r.Header.Get(InvoiceHeader))
+		received = append(received, inv)
+	}))
+	t.Cleanup(srv.Close)
+
+	space := testutil.RandomDID(t)
+	usage := &mockUsage{storage: map[did.DID]uint64{space: gib}}
+	cfg := app.BillingConfig{
+		DefaultPlan: "standard",
+		Webhook:     app.BillingWebhookConfig{URL: srv.URL, Secret: string(secret)},
+	}
+	s := newTestService(t, usage, cfg, clk)
+	require.NoError(t, s.Meter(ctx))
+	clk.Add(time.Hour)
+	require.NoError(t, s.Meter(ctx))
+
+	t.Run("pushes on request", func(t *testing.T) {
+		inv, err := s.Push(ctx, "2026-10")
+		require.NoError(t, err)
+		require.False(t, inv.Final)
+		require.Len(t, received, 1)
+		require.Equal(t, inv, received[0])
+		_, pushed, err := s.Pushed(ctx, "2026-10")
+		require.NoError(t, err)
+		require.True(t, pushed)
+	})
+
+	t.Run("pushes the previous month once it has ended", func(t *testing.T) {
+		received = nil
+		s := newTestService(t, usage, cfg, clk)
+		require.NoError(t, s.Meter(ctx))
+		require.NoError(t, s.pushEnded(ctx))
+		require.Empty(t, received)
+
+		clk.Set(time.Date(2026, time.November, 1, 1, 0, 0, 0, time.UTC))
+		require.NoError(t, s.Meter(ctx))
+		require.NoError(t, s.pushEnded(ctx))
+		require.Len(t, received, 1)
+		require.Equal(t, "2026-10", received[0].Period)
+		require.True(t, received[0].Final)
+
+		require.NoError(t, s.pushEnded(ctx))
+		require.Len(t, received, 1)
+	})
+
+	t.Run("fails without a webhook", func(t *testing.T) {
+		s := newTestService(t, usage, app.BillingConfig{}, clk)
+		_, err := s.Push(ctx, "2026-10")
+		require.ErrorIs(t, err, ErrNoWebhook)
+	})
+}
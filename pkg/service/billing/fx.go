@@ -0,0 +1,81 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	leveldb "github.com/ipfs/go-ds-leveldb"
+	"github.com/raulk/clock"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/service/quota"
+)
+
+var Module = fx.Module("billing",
+	fx.Provide(
+		NewServiceFromParams,
+	),
+	// force construction, so storage is metered whether or not the admin
+	// API is served
+	fx.Invoke(func(*Service) {}),
+)
+
+type Params struct {
+	fx.In
+
+	UCANCfg    app.UCANServiceConfig
+	StorageCfg app.StorageConfig
+	Quotas     *quota.Manager
+	Clock      clock.Clock
+}
+
+// NewServiceFromParams creates the billing service, nil if no rate plans are
+// configured. Assignments and metered storage are persisted in the data
+// directory, or kept in memory without one.
+func NewServiceFromParams(lc fx.Lifecycle, params Params) (*Service, error) {
+	cfg := params.UCANCfg.Billing
+	if len(cfg.Plans) == 0 {
+		return nil, nil
+	}
+
+	var ds datastore.Batching
+	if params.StorageCfg.DataDir == "" {
+		log.Warn("no data dir configured, metered storage will not persist across restarts")
+		ds = sync.MutexWrap(datastore.NewMapDatastore())
+	} else {
+		dir := filepath.Join(params.StorageCfg.DataDir, DataDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("creating directory: %s: %w", dir, err)
+		}
+		ldb, err := leveldb.NewDatastore(dir, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating billing store: %w", err)
+		}
+		ds = ldb
+	}
+
+	svc, err := New(ds, params.Quotas, cfg, WithClock(params.Clock))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return svc.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			if err := svc.Stop(ctx); err != nil {
+				return err
+			}
+			return ds.Close()
+		},
+	})
+	return svc, nil
+}
@@ -0,0 +1,175 @@
+package billing
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/storacha/go-ucanto/did"
+)
+
+// Resources billed in line items, and their units.
+const (
+	ResourceStorage = "storage"
+	ResourceEgress  = "egress"
+
+	UnitGiBMonth = "GiB-month"
+	UnitGiB      = "GiB"
+)
+
+// LineItem is the charge for the use of a resource by a space in a month.
+type LineItem struct {
+	Space    string  `json:"space"`
+	Tenant   string  `json:"tenant,omitempty"`
+	Plan     string  `json:"plan"`
+	Resource string  `json:"resource"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	// UnitPrice is the price of a unit in the plan.
+	UnitPrice float64 `json:"unit_price"`
+	Amount    float64 `json:"amount"`
+}
+
+// Invoice lists the charges for the usage of spaces in a calendar month.
+type Invoice struct {
+	Period   string `json:"period"`
+	Currency string `json:"currency"`
+	// Final is true once the month has ended, false for a month in progress.
+	Final       bool       `json:"final"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	Lines       []LineItem `json:"lines"`
+	Total       float64    `json:"total"`
+}
+
+// Invoice prices the usage of spaces metered in period, a calendar month
+// formatted as PeriodLayout. It is final once the month is closed. Spaces not assigned a plan are billed at the default plan, or
+// not at all without one. Quantities and amounts are rounded to 6 decimal
+// places.
+func (s *Service) Invoice(ctx context.Context, period string) (Invoice, error) {
+	start, end, err := ParsePeriod(period)
+	if err != nil {
+		return Invoice{}, err
+	}
+	hoursInMonth := end.Sub(start).Hours()
+
+	stored, err := s.gibHours(ctx, period)
+	if err != nil {
+		return Invoice{}, err
+	}
+	egress, err := s.egress(ctx, period)
+	if err != nil {
+		return Invoice{}, err
+	}
+	final, err := s.closed(ctx, period)
+	if err != nil {
+		return Invoice{}, err
+	}
+	assignments, err := s.Assignments(ctx)
+	if err != nil {
+		return Invoice{}, err
+	}
+	assigned := map[did.DID]Assignment{}
+	for _, a := range assignments {
+		assigned[a.Space] = a
+	}
+
+	now := s.clock.Now().UTC()
+	inv := Invoice{
+		Period:      period,
+		Currency:    s.currency,
+		Final:       final,
+		GeneratedAt: now,
+		Lines:       []LineItem{},
+	}
+	line := func(space did.DID, a Assignment, plan string, resource string, quantity float64, unit string, price float64) {
+		if quantity <= 0 {
+			return
+		}
+		quantity = round(quantity)
+		inv.Lines = append(inv.Lines, LineItem{
+			Space:     space.String(),
+			Tenant:    a.Tenant,
+			Plan:      plan,
+			Resource:  resource,
+			Quantity:  quantity,
+			Unit:      unit,
+			UnitPrice: price,
+			Amount:    round(quantity * price),
+		})
+	}
+
+	spaces := map[did.DID]struct{}{}
+	for space := range stored {
+		spaces[space] = struct{}{}
+	}
+	for space := range egress {
+		spaces[space] = struct{}{}
+	}
+	for space := range spaces {
+		a, ok := assigned[space]
+		if !ok {
+			a.Plan = s.def
+		}
+		if a.Plan == "" {
+			continue
+		}
+		plan, ok := s.plan(a.Plan)
+		if !ok {
+			log.Warnw("not billing space assigned a plan that is no longer configured", "space", space, "plan", a.Plan)
+			continue
+		}
+		line(space, a, plan.Name, ResourceStorage, stored[space]/hoursInMonth, UnitGiBMonth, plan.StorageGiBMonth)
+		line(space, a, plan.Name, ResourceEgress, float64(egress[space])/gib, UnitGiB, plan.EgressGiB)
+	}
+
+	slices.SortFunc(inv.Lines, func(a, b LineItem) int {
+		return cmp.Or(
+			cmp.Compare(a.Tenant, b.Tenant),
+			cmp.Compare(a.Space, b.Space),
+			cmp.Compare(a.Resource, b.Resource),
+		)
+	})
+	for _, l := range inv.Lines {
+		inv.Total += l.Amount
+	}
+	inv.Total = round(inv.Total)
+	return inv, nil
+}
+
+// round rounds x to 6 decimal places.
+func round(x float64) float64 {
+	return math.Round(x*1e6) / 1e6
+}
+
+// WriteCSV writes the line items of an invoice as CSV, with a header row.
+func WriteCSV(w io.Writer, inv Invoice) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"period", "tenant", "space", "plan", "resource", "quantity", "unit", "unit_price", "amount", "currency"}); err != nil {
+		return fmt.Errorf("writing CSV header: %w", err)
+	}
+	for _, l := range inv.Lines {
+		err := cw.Write([]string{
+			inv.Period,
+			l.Tenant,
+			l.Space,
+			l.Plan,
+			l.Resource,
+			strconv.FormatFloat(l.Quantity, 'f', -1, 64),
+			l.Unit,
+			strconv.FormatFloat(l.UnitPrice, 'f', -1, 64),
+			strconv.FormatFloat(l.Amount, 'f', -1, 64),
+			inv.Currency,
+		})
+		if err != nil {
+			return fmt.Errorf("writing CSV line item: %w", err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
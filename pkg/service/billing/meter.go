@@ -0,0 +1,361 @@
+package billing
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/storacha/go-ucanto/did"
+
+	"github.com/storacha/piri/pkg/service/quota"
+)
+
+const (
+	gibHoursPrefix = "/gibhours/"
+	egressPrefix   = "/egress/"
+	closedPrefix   = "/closed/"
+
+	gib = 1 << 30
+)
+
+var (
+	sampleKey = datastore.NewKey("/meter/sample")
+	// lastSampleKey holds the time of the last sample of nodes that metered
+	// before sizes were recorded with it.
+	lastSampleKey = datastore.NewKey("/meter/last")
+)
+
+// sample is the time of a meter sample and the bytes each space stored then.
+type sample struct {
+	Time time.Time `json:"time"`
+	// Sizes is nil for a sample recorded without sizes.
+	Sizes map[string]uint64 `json:"sizes"`
+}
+
+// Meter samples the usage of every space, and bills storage for the time
+// since the previous sample. The first sample only records its time and
+// sizes.
+//
+// Storage held between two samples is prorated linearly from the size of the
+// first to the size of the second, so a gap in sampling, e.g. while the node
+// was stopped, is billed at the average size rather than the last. Egress
+// counted by the usage source is copied for every month the samples span, so
+// invoices are priced only from what was metered.
+//
+// Every month that ended by the sample is closed: its metered usage is
+// final and is not added to again. The usage of a sample, the closing of
+// months and the sample itself are written in a single batch, so a sample
+// interrupted part way is not billed twice.
+func (s *Service) Meter(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now().UTC()
+	last, ok, err := s.lastSample(ctx)
+	if err != nil {
+		return err
+	}
+	if ok && !now.After(last.Time) {
+		return nil
+	}
+	usage, err := s.usage.ListUsage(ctx, Period(now))
+	if err != nil {
+		return fmt.Errorf("listing usage: %w", err)
+	}
+
+	batch, err := s.ds.Batch(ctx)
+	if err != nil {
+		return fmt.Errorf("creating batch: %w", err)
+	}
+	periods := []string{Period(now)}
+	if ok {
+		segments := splitMonths(last.Time, now)
+		periods = periods[:0]
+		for _, seg := range segments {
+			periods = append(periods, seg.period)
+		}
+		hours := prorate(last, usage, segments, now)
+		for period, spaces := range hours {
+			if err := s.addGiBHours(ctx, batch, period, spaces); err != nil {
+				return err
+			}
+		}
+	}
+
+	closedAt, err := now.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("encoding sample time: %w", err)
+	}
+	for _, period := range periods {
+		listed := usage
+		if period != Period(now) {
+			listed = nil
+		}
+		if err := s.copyEgress(ctx, batch, period, listed); err != nil {
+			return err
+		}
+		if _, end, _ := ParsePeriod(period); !now.Before(end) {
+			if err := batch.Put(ctx, datastore.NewKey(closedPrefix+period), closedAt); err != nil {
+				return fmt.Errorf("closing %s: %w", period, err)
+			}
+		}
+	}
+
+	next := sample{Time: now, Sizes: map[string]uint64{}}
+	for space, u := range usage {
+		if u.Storage > 0 {
+			next.Sizes[space.String()] = u.Storage
+		}
+	}
+	data, err := json.Marshal(next)
+	if err != nil {
+		return fmt.Errorf("encoding sample: %w", err)
+	}
+	if err := batch.Put(ctx, sampleKey, data); err != nil {
+		return fmt.Errorf("putting sample: %w", err)
+	}
+	if err := batch.Delete(ctx, lastSampleKey); err != nil {
+		return fmt.Errorf("deleting sample time: %w", err)
+	}
+	if err := batch.Commit(ctx); err != nil {
+		return fmt.Errorf("committing sample: %w", err)
+	}
+	return nil
+}
+
+// prorate returns the GiB-hours stored by each space in each month from the
+// last sample to now, with sizes interpolated linearly between the two
+// samples. A space without a size in a sample recorded without sizes is
+// billed at its current size.
+func prorate(last sample, usage map[did.DID]quota.Usage, segments []segment, now time.Time) map[string]map[did.DID]float64 {
+	spaces := map[did.DID][2]float64{}
+	for space, u := range usage {
+		if u.Storage == 0 {
+			continue
+		}
+		size := float64(u.Storage) / gib
+		spaces[space] = [2]float64{size, size}
+	}
+	if last.Sizes != nil {
+		for space := range spaces {
+			v := spaces[space]
+			v[0] = float64(last.Sizes[space.String()]) / gib
+			spaces[space] = v
+		}
+		for key, n := range last.Sizes {
+			space, err := did.Parse(key)
+			if err != nil {
+				log.Warnw("skipping sampled size with invalid space", "space", key, "error", err)
+				continue
+			}
+			if _, ok := spaces[space]; !ok {
+				spaces[space] = [2]float64{float64(n) / gib, 0}
+			}
+		}
+	}
+
+	elapsed := now.Sub(last.Time).Hours()
+	out := map[string]map[did.DID]float64{}
+	for space, v := range spaces {
+		from, to := v[0], v[1]
+		at := func(t time.Time) float64 {
+			return from + (to-from)*t.Sub(last.Time).Hours()/elapsed
+		}
+		start := last.Time
+		for _, seg := range segments {
+			end := start.Add(seg.duration)
+			hours := (at(start) + at(end)) / 2 * seg.duration.Hours()
+			if hours > 0 {
+				if out[seg.period] == nil {
+					out[seg.period] = map[did.DID]float64{}
+				}
+				out[seg.period][space] = hours
+			}
+			start = end
+		}
+	}
+	return out
+}
+
+func (s *Service) lastSample(ctx context.Context) (sample, bool, error) {
+	data, err := s.ds.Get(ctx, sampleKey)
+	if err == nil {
+		var smp sample
+		if err := json.Unmarshal(data, &smp); err != nil {
+			return sample{}, false, fmt.Errorf("decoding sample: %w", err)
+		}
+		return smp, true, nil
+	}
+	if !errors.Is(err, datastore.ErrNotFound) {
+		return sample{}, false, fmt.Errorf("getting sample: %w", err)
+	}
+
+	data, err = s.ds.Get(ctx, lastSampleKey)
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return sample{}, false, nil
+		}
+		return sample{}, false, fmt.Errorf("getting sample time: %w", err)
+	}
+	var t time.Time
+	if err := t.UnmarshalBinary(data); err != nil {
+		return sample{}, false, fmt.Errorf("decoding sample time: %w", err)
+	}
+	return sample{Time: t}, true, nil
+}
+
+// closed reports whether period has ended and been metered to its end.
+func (s *Service) closed(ctx context.Context, period string) (bool, error) {
+	ok, err := s.ds.Has(ctx, datastore.NewKey(closedPrefix+period))
+	if err != nil {
+		return false, fmt.Errorf("checking whether %s is closed: %w", period, err)
+	}
+	return ok, nil
+}
+
+// addGiBHours adds the GiB-hours of each space to their totals in period.
+func (s *Service) addGiBHours(ctx context.Context, batch datastore.Batch, period string, hours map[did.DID]float64) error {
+	totals, err := s.gibHours(ctx, period)
+	if err != nil {
+		return err
+	}
+	for space, h := range hours {
+		key := datastore.NewKey(gibHoursPrefix + period + "/" + space.String())
+		if err := batch.Put(ctx, key, encodeFloat(totals[space]+h)); err != nil {
+			return fmt.Errorf("putting metered storage: %w", err)
+		}
+	}
+	return nil
+}
+
+// copyEgress records the egress of every space in period, as counted by the
+// usage source. usage is the usage already listed for period, or nil to list
+// it.
+func (s *Service) copyEgress(ctx context.Context, batch datastore.Batch, period string, usage map[did.DID]quota.Usage) error {
+	if usage == nil {
+		var err error
+		if usage, err = s.usage.ListUsage(ctx, period); err != nil {
+			return fmt.Errorf("listing usage of %s: %w", period, err)
+		}
+	}
+	for space, u := range usage {
+		if u.Egress == 0 {
+			continue
+		}
+		key := datastore.NewKey(egressPrefix + period + "/" + space.String())
+		if err := batch.Put(ctx, key, binary.BigEndian.AppendUint64(nil, u.Egress)); err != nil {
+			return fmt.Errorf("putting metered egress: %w", err)
+		}
+	}
+	return nil
+}
+
+// gibHours returns the GiB-hours stored by each space in period.
+func (s *Service) gibHours(ctx context.Context, period string) (map[did.DID]float64, error) {
+	out := map[did.DID]float64{}
+	err := s.each(ctx, gibHoursPrefix+period+"/", func(space did.DID, value []byte) error {
+		hours, err := decodeFloat(value)
+		if err != nil {
+			return fmt.Errorf("decoding metered storage of %s: %w", space, err)
+		}
+		out[space] = hours
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading metered storage: %w", err)
+	}
+	return out, nil
+}
+
+// egress returns the bytes downloaded from each space in period.
+func (s *Service) egress(ctx context.Context, period string) (map[did.DID]uint64, error) {
+	out := map[did.DID]uint64{}
+	err := s.each(ctx, egressPrefix+period+"/", func(space did.DID, value []byte) error {
+		if len(value) != 8 {
+			return fmt.Errorf("invalid metered egress of %s", space)
+		}
+		out[space] = binary.BigEndian.Uint64(value)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading metered egress: %w", err)
+	}
+	return out, nil
+}
+
+// each calls fn with the space and value of every entry under prefix.
+func (s *Service) each(ctx context.Context, prefix string, fn func(did.DID, []byte) error) error {
+	results, err := s.ds.Query(ctx, query.Query{Prefix: prefix})
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return entry.Error
+		}
+		space, err := did.Parse(strings.TrimPrefix(entry.Key, prefix))
+		if err != nil {
+			log.Warnw("skipping metered usage with invalid space", "key", entry.Key, "error", err)
+			continue
+		}
+		if err := fn(space, entry.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeFloat(f float64) []byte {
+	return binary.BigEndian.AppendUint64(nil, math.Float64bits(f))
+}
+
+func decodeFloat(data []byte) (float64, error) {
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid length %d", len(data))
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+}
+
+// Period returns the calendar month (UTC) t is in, formatted as PeriodLayout.
+func Period(t time.Time) string {
+	return t.UTC().Format(PeriodLayout)
+}
+
+// ParsePeriod parses a calendar month formatted as PeriodLayout, returning
+// its start and end.
+func ParsePeriod(period string) (start, end time.Time, err error) {
+	start, err = time.Parse(PeriodLayout, period)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q: must be a month formatted as YYYY-MM", period)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+type segment struct {
+	period   string
+	duration time.Duration
+}
+
+// splitMonths splits the time from from to to at the start of each calendar
+// month.
+func splitMonths(from, to time.Time) []segment {
+	var segments []segment
+	for from.Before(to) {
+		_, next, _ := ParsePeriod(Period(from))
+		if to.Before(next) {
+			next = to
+		}
+		segments = append(segments, segment{period: Period(from), duration: next.Sub(from)})
+		from = next
+	}
+	return segments
+}
@@ -0,0 +1,127 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+
+	"github.com/storacha/piri/pkg/webhook"
+)
+
+const (
+	// InvoiceHeader holds the period of a pushed invoice, for receivers to
+	// ignore invoices they have already processed.
+	InvoiceHeader = "X-Piri-Invoice"
+
+	pushTimeout  = 30 * time.Second
+	pushedPrefix = "/pushed/"
+)
+
+// ErrNoWebhook is returned when pushing an invoice without a billing webhook
+// configured.
+var ErrNoWebhook = errors.New("no billing webhook configured")
+
+// Push posts the invoice of period to the billing webhook, signed as webhook
+// notifications are so receivers can check it with [webhook.Verify]. The
+// invoice of a month is pushed again if requested, receivers should replace
+// an earlier invoice of the same period.
+func (s *Service) Push(ctx context.Context, period string) (Invoice, error) {
+	if s.webhook.URL == "" {
+		return Invoice{}, ErrNoWebhook
+	}
+	inv, err := s.Invoice(ctx, period)
+	if err != nil {
+		return Invoice{}, err
+	}
+	if err := s.send(ctx, inv); err != nil {
+		return Invoice{}, err
+	}
+	if err := s.markPushed(ctx, period); err != nil {
+		return Invoice{}, err
+	}
+	log.Infow("pushed invoice", "period", period, "lines", len(inv.Lines), "total", inv.Total, "currency", inv.Currency)
+	return inv, nil
+}
+
+// Pushed returns when the invoice of period was last pushed.
+func (s *Service) Pushed(ctx context.Context, period string) (time.Time, bool, error) {
+	data, err := s.ds.Get(ctx, datastore.NewKey(pushedPrefix+period))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, fmt.Errorf("getting push time: %w", err)
+	}
+	var t time.Time
+	if err := t.UnmarshalBinary(data); err != nil {
+		return time.Time{}, false, fmt.Errorf("decoding push time: %w", err)
+	}
+	return t, true, nil
+}
+
+// pushEnded pushes the invoice of the previous month once it is closed,
+// unless already pushed. An invoice with no line items is not sent.
+func (s *Service) pushEnded(ctx context.Context) error {
+	start, _, _ := ParsePeriod(Period(s.clock.Now()))
+	period := Period(start.AddDate(0, -1, 0))
+	if _, ok, err := s.Pushed(ctx, period); err != nil || ok {
+		return err
+	}
+	if ok, err := s.closed(ctx, period); err != nil || !ok {
+		return err
+	}
+	inv, err := s.Invoice(ctx, period)
+	if err != nil {
+		return err
+	}
+	if len(inv.Lines) > 0 {
+		if err := s.send(ctx, inv); err != nil {
+			return fmt.Errorf("pushing invoice of %s: %w", period, err)
+		}
+		log.Infow("pushed invoice", "period", period, "lines", len(inv.Lines), "total", inv.Total, "currency", inv.Currency)
+	}
+	return s.markPushed(ctx, period)
+}
+
+func (s *Service) send(ctx context.Context, inv Invoice) error {
+	body, err := json.Marshal(inv)
+	if err != nil {
+		return fmt.Errorf("encoding invoice: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign([]byte(s.webhook.Secret), s.clock.Now(), body))
+	req.Header.Set(InvoiceHeader, inv.Period)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending invoice: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 256))
+		return fmt.Errorf("unexpected status %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (s *Service) markPushed(ctx context.Context, period string) error {
+	data, err := s.clock.Now().UTC().MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("encoding push time: %w", err)
+	}
+	if err := s.ds.Put(ctx, datastore.NewKey(pushedPrefix+period), data); err != nil {
+		return fmt.Errorf("putting push time: %w", err)
+	}
+	return nil
+}
@@ -2,10 +2,14 @@ package blobs
 
 import (
 	"github.com/storacha/piri/pkg/access"
+	"github.com/storacha/piri/pkg/cdn"
+	"github.com/storacha/piri/pkg/contentindex"
+	"github.com/storacha/piri/pkg/piecelog"
 	"github.com/storacha/piri/pkg/presigner"
 	"github.com/storacha/piri/pkg/store/acceptancestore"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/storacha/piri/pkg/telemetry/latency"
 )
 
 type Blobs interface {
@@ -19,4 +23,14 @@ type Blobs interface {
 	Presigner() presigner.RequestPresigner
 	// Access provides an interface to allowing public access to download blobs.
 	Access() access.Access
+	// Latency records the time uploads spend in each stage of the pipeline.
+	Latency() *latency.Tracker
+	// PieceLog records the lifecycle events of pieces.
+	PieceLog() *piecelog.Log
+	// CDN generates signed CDN URLs blob downloads are redirected to, nil if
+	// downloads are served by the node.
+	CDN() *cdn.CDN
+	// ContentIndex records the blobs held by the node, whichever space they
+	// were uploaded to.
+	ContentIndex() *contentindex.Index
 }
@@ -10,18 +10,26 @@ import (
 	"github.com/storacha/go-libstoracha/digestutil"
 	"github.com/storacha/go-ucanto/principal"
 	"github.com/storacha/piri/pkg/access"
+	"github.com/storacha/piri/pkg/cdn"
+	"github.com/storacha/piri/pkg/contentindex"
+	"github.com/storacha/piri/pkg/piecelog"
 	"github.com/storacha/piri/pkg/presigner"
 	"github.com/storacha/piri/pkg/store/acceptancestore"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/storacha/piri/pkg/telemetry/latency"
 )
 
 type options struct {
-	access      access.Access
-	allocStore  allocationstore.AllocationStore
-	acceptStore acceptancestore.AcceptanceStore
-	blobStore   blobstore.Blobstore
-	presigner   presigner.RequestPresigner
+	access       access.Access
+	allocStore   allocationstore.AllocationStore
+	acceptStore  acceptancestore.AcceptanceStore
+	blobStore    blobstore.Blobstore
+	presigner    presigner.RequestPresigner
+	latency      *latency.Tracker
+	pieceLog     *piecelog.Log
+	cdn          *cdn.CDN
+	contentIndex *contentindex.Index
 }
 
 type Option func(*options) error
@@ -103,3 +111,38 @@ func WithAcceptanceStore(acceptanceStore acceptancestore.AcceptanceStore) Option
 		return nil
 	}
 }
+
+// WithLatencyTracker records the time uploads spend in each stage of the
+// pipeline with the tracker.
+func WithLatencyTracker(tracker *latency.Tracker) Option {
+	return func(o *options) error {
+		o.latency = tracker
+		return nil
+	}
+}
+
+// WithPieceLog records the allocation and upload of blobs in the piece
+// lifecycle log.
+func WithPieceLog(pieceLog *piecelog.Log) Option {
+	return func(o *options) error {
+		o.pieceLog = pieceLog
+		return nil
+	}
+}
+
+// WithCDN redirects blob downloads to signed URLs on the CDN.
+func WithCDN(c *cdn.CDN) Option {
+	return func(o *options) error {
+		o.cdn = c
+		return nil
+	}
+}
+
+// WithContentIndex skips the upload of blobs allocated in a space when the
+// node already holds them, whichever space they were uploaded to.
+func WithContentIndex(idx *contentindex.Index) Option {
+	return func(o *options) error {
+		o.contentIndex = idx
+		return nil
+	}
+}
@@ -0,0 +1,87 @@
+package blobs
+
+import (
+	"strconv"
+	"strings"
+)
+
+// byteRange is a single range from a Range header. A suffix range has a
+// negative start, the length of the suffix being -start.
+type byteRange struct {
+	start int64
+	// end is inclusive, -1 if the range is open ended.
+	end int64
+}
+
+// parseRange parses a Range header holding a single byte range. Headers that
+// are malformed, use another unit or hold multiple ranges are ignored, in
+// which case the full blob is served.
+func parseRange(header string) (byteRange, bool) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return byteRange{}, false
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return byteRange{}, false
+	}
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return byteRange{}, false
+		}
+		return byteRange{start: -n, end: -1}, true
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return byteRange{}, false
+	}
+	if last == "" {
+		return byteRange{start: start, end: -1}, true
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return byteRange{}, false
+	}
+	return byteRange{start: start, end: end}, true
+}
+
+// resolve returns the inclusive bounds of the range within a blob of the
+// given size, clamping the end to the last byte. It returns false if the
+// range is not satisfiable.
+func (r byteRange) resolve(size int64) (uint64, uint64, bool) {
+	if r.start < 0 {
+		n := -r.start
+		if n == 0 || size == 0 {
+			return 0, 0, false
+		}
+		return uint64(max(size-n, 0)), uint64(size - 1), true
+	}
+	if r.start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if r.end >= 0 && r.end < end {
+		end = r.end
+	}
+	return uint64(r.start), uint64(end), true
+}
+
+// etagMatches reports whether a list of entity tags from an If-None-Match
+// header matches etag, with the weak comparison the header calls for.
+func etagMatches(header string, etag string) bool {
+	for tag := range strings.SplitSeq(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifRangeMatches reports whether the validator of an If-Range header matches
+// etag. If-Range calls for strong comparison, so a weak tag never matches, and
+// neither does a date, since blobs are served without Last-Modified.
+func ifRangeMatches(header string, etag string) bool {
+	return strings.TrimSpace(header) == etag
+}
@@ -15,12 +15,17 @@ import (
 	"github.com/multiformats/go-multihash"
 	"github.com/storacha/go-libstoracha/digestutil"
 
+	"github.com/storacha/piri/pkg/blocklist"
+	"github.com/storacha/piri/pkg/cdn"
 	echofx "github.com/storacha/piri/pkg/fx/echo"
 	"github.com/storacha/piri/pkg/presigner"
+	"github.com/storacha/piri/pkg/privatespace"
+	"github.com/storacha/piri/pkg/readonly"
 	"github.com/storacha/piri/pkg/server/handler"
 	"github.com/storacha/piri/pkg/store"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/storacha/piri/pkg/telemetry/latency"
 )
 
 var log = logging.Logger("blobs")
@@ -31,18 +36,37 @@ type Server struct {
 	blobs     blobstore.Blobstore
 	presigner presigner.RequestPresigner
 	allocs    allocationstore.AllocationStore
+	latency   *latency.Tracker
+	cdn       *cdn.CDN
+	readOnly  *readonly.Mode
+	blocked   *blocklist.List
+	private   *privatespace.Policy
+	getMw     []echo.MiddlewareFunc
 }
 
-func NewServer(presigner presigner.RequestPresigner, allocs allocationstore.AllocationStore, blobs blobstore.Blobstore) (*Server, error) {
-	return &Server{blobs, presigner, allocs}, nil
+// NewServer creates the blob HTTP server. Downloads are redirected to the CDN
+// if it is not nil, and pass through getMw, e.g. to rate limit them. Uploads
+// are refused while the node is read-only, if readOnly is not nil. Uploads
+// and downloads of blobs in blocked are refused, if it is not nil. Downloads
+// of blobs of private spaces require a signed URL, if private is not nil.
+func NewServer(presigner presigner.RequestPresigner, allocs allocationstore.AllocationStore, blobs blobstore.Blobstore, latency *latency.Tracker, offload *cdn.CDN, readOnly *readonly.Mode, blocked *blocklist.List, private *privatespace.Policy, getMw ...echo.MiddlewareFunc) (*Server, error) {
+	return &Server{blobs, presigner, allocs, latency, offload, readOnly, blocked, private, getMw}, nil
 }
 
 func (srv *Server) RegisterRoutes(e *echo.Echo) {
-	e.GET("/blob/:blob", NewBlobGetHandler(srv.blobs).ToEcho())
-	e.PUT("/blob/:blob", NewBlobPutHandler(srv.presigner, srv.allocs, srv.blobs).ToEcho())
+	get := NewBlobGetHandler(srv.blobs, srv.cdn).ToEcho()
+	getMw := append([]echo.MiddlewareFunc{srv.blocked.Middleware(blocklist.Retrieve), srv.private.Middleware(srv.cdn)}, srv.getMw...)
+	e.GET("/blob/:blob", get, getMw...)
+	e.HEAD("/blob/:blob", get, getMw...)
+	e.PUT("/blob/:blob", NewBlobPutHandler(srv.presigner, srv.allocs, srv.blobs, srv.latency).ToEcho(), srv.readOnly.Middleware(), srv.blocked.Middleware(blocklist.Upload))
 }
 
-func NewBlobGetHandler(blobs blobstore.Blobstore) handler.Func {
+// NewBlobGetHandler serves blobs from the blob store, handling HEAD, single
+// byte Range requests and conditional requests against an ETag derived from
+// the blob multihash. With a CDN, requests for blobs held by the node are
+// redirected to a signed CDN URL, except fetches by the CDN itself and
+// redeemed signed URLs of private blobs.
+func NewBlobGetHandler(blobs blobstore.Blobstore, offload *cdn.CDN) handler.Func {
 	return func(ctx handler.Context) error {
 		r, w := ctx.Request(), ctx.Response()
 
@@ -63,12 +87,69 @@ func NewBlobGetHandler(blobs blobstore.Blobstore) handler.Func {
 			return fmt.Errorf("getting blob: %w", err)
 		}
 
-		w.Header().Set("Content-Type", "application/octet-stream")
-		w.Header().Set("Content-Length", strconv.FormatInt(obj.Size(), 10))
-		w.WriteHeader(http.StatusOK)
-
 		body := obj.Body()
-		defer body.Close()
+		defer func() { body.Close() }()
+
+		// blobs are immutable, so the digest identifies the representation
+		etag := fmt.Sprintf(`"%s"`, digestutil.Format(digest))
+		w.Header().Set("Etag", etag)
+		if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+
+		if offload != nil && !offload.IsOriginRequest(r) && !privatespace.Redeemed(r.Context()) {
+			loc, err := offload.BlobURL(digest)
+			if err != nil {
+				return fmt.Errorf("creating CDN URL: %w", err)
+			}
+			// signed URLs expire, so the redirect must not be cached
+			w.Header().Set("Cache-Control", "no-store")
+			http.Redirect(w, r, loc.String(), http.StatusTemporaryRedirect)
+			return nil
+		}
+
+		size := obj.Size()
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Cache-Control", "public, max-age=29030400, immutable")
+
+		status, length := http.StatusOK, size
+		rng, ok := parseRange(r.Header.Get("Range"))
+		if ok {
+			// a stale or weak If-Range validator gets the full blob
+			if ir := r.Header.Get("If-Range"); ir != "" && !ifRangeMatches(ir, etag) {
+				ok = false
+			}
+		}
+		if ok {
+			start, end, satisfiable := rng.resolve(size)
+			if !satisfiable {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+				return echo.NewHTTPError(http.StatusRequestedRangeNotSatisfiable, "range not satisfiable")
+			}
+			if r.Method != http.MethodHead {
+				body.Close()
+				obj, err = blobs.Get(r.Context(), digest, blobstore.WithRange(start, &end))
+				if err != nil {
+					var rnsErr blobstore.RangeNotSatisfiableError
+					if errors.As(err, &rnsErr) {
+						w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+						return echo.NewHTTPError(http.StatusRequestedRangeNotSatisfiable, "range not satisfiable")
+					}
+					return fmt.Errorf("getting blob range: %w", err)
+				}
+				body = obj.Body()
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+			status, length = http.StatusPartialContent, int64(end-start+1)
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+		w.WriteHeader(status)
+		if r.Method == http.MethodHead {
+			return nil
+		}
 
 		_, err = io.Copy(w, body)
 		if err != nil {
@@ -80,7 +161,7 @@ func NewBlobGetHandler(blobs blobstore.Blobstore) handler.Func {
 	}
 }
 
-func NewBlobPutHandler(presigner presigner.RequestPresigner, allocs allocationstore.AllocationStore, blobs blobstore.Blobstore) handler.Func {
+func NewBlobPutHandler(presigner presigner.RequestPresigner, allocs allocationstore.AllocationStore, blobs blobstore.Blobstore, tracker *latency.Tracker) handler.Func {
 	return func(ctx handler.Context) error {
 		r, w := ctx.Request(), ctx.Response()
 		_, sHeaders, err := presigner.VerifyUploadURL(r.Context(), *r.URL, r.Header)
@@ -115,12 +196,17 @@ func NewBlobPutHandler(presigner presigner.RequestPresigner, allocs allocationst
 			return fmt.Errorf("parsing signed Content-Length header: %w", err)
 		}
 
-		err = blobs.Put(r.Context(), digest, uint64(contentLength), r.Body)
+		uploadCtx, timer := tracker.Start(r.Context(), digest, latency.StageUpload)
+		err = blobs.Put(uploadCtx, digest, uint64(contentLength), r.Body)
+		timer.End(err)
 		if err != nil {
 			log.Errorf("writing to: z%s: %w", digest.B58String(), err)
 			if errors.Is(err, blobstore.ErrDataInconsistent) {
 				return echo.NewHTTPError(http.StatusConflict, "data consistency check failed")
 			}
+			if errors.Is(err, blobstore.ErrTooSmall) || errors.Is(err, blobstore.ErrTooLarge) {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("body does not match the allocated size: %s", err))
+			}
 
 			return fmt.Errorf("write failed: %w", err)
 		}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/ipfs/go-datastore"
 	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-ucanto/did"
 	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
 	"github.com/stretchr/testify/require"
 
@@ -21,8 +22,10 @@ import (
 
 	"github.com/storacha/go-libstoracha/digestutil"
 
+	"github.com/storacha/piri/pkg/cdn"
 	"github.com/storacha/piri/pkg/fx/echo"
 	"github.com/storacha/piri/pkg/presigner"
+	"github.com/storacha/piri/pkg/privatespace"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
 	"github.com/storacha/piri/pkg/store/blobstore"
@@ -47,12 +50,12 @@ func TestServer(t *testing.T) {
 	signer := testutil.RandomSigner(t)
 	accessKeyID := signer.DID().String()
 	secretAccessKey := testutil.Must(ed25519.Format(signer))(t)
-	presigner, err := presigner.NewS3RequestPresigner(accessKeyID, secretAccessKey, *srvurl, "blob")
+	reqPresigner, err := presigner.NewS3RequestPresigner(accessKeyID, secretAccessKey, *srvurl, "blob")
 	require.NoError(t, err)
 
 	allocs := allocationstore.NewDatastoreStore(datastore.NewMapDatastore())
 
-	srv, err := NewServer(presigner, allocs, blobs)
+	srv, err := NewServer(reqPresigner, allocs, blobs, nil, nil, nil, nil, nil)
 	require.NoError(t, err)
 
 	srv.RegisterRoutes(mux)
@@ -68,6 +71,244 @@ func TestServer(t *testing.T) {
 		requireRetrievableBlob(t, *srvurl, digest, data)
 	})
 
+	t.Run("get blob range", func(t *testing.T) {
+		data := testutil.RandomBytes(t, 32)
+		digest, err := multihash.Sum(data, multihash.SHA2_256, -1)
+		require.NoError(t, err)
+
+		err = blobs.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data))
+		require.NoError(t, err)
+
+		bloburl := srvurl.JoinPath("blob", digestutil.Format(digest)).String()
+		get := func(rng string) *http.Response {
+			req, err := http.NewRequest(http.MethodGet, bloburl, nil)
+			require.NoError(t, err)
+			req.Header.Set("Range", rng)
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			t.Cleanup(func() { res.Body.Close() })
+			return res
+		}
+
+		for _, tc := range []struct {
+			rng          string
+			contentRange string
+			want         []byte
+		}{
+			{"bytes=4-9", "bytes 4-9/32", data[4:10]},
+			{"bytes=28-", "bytes 28-31/32", data[28:]},
+			{"bytes=-5", "bytes 27-31/32", data[27:]},
+			{"bytes=30-100", "bytes 30-31/32", data[30:]},
+		} {
+			res := get(tc.rng)
+			require.Equal(t, http.StatusPartialContent, res.StatusCode, tc.rng)
+			require.Equal(t, tc.contentRange, res.Header.Get("Content-Range"))
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, body)
+		}
+
+		res := get("bytes=32-")
+		require.Equal(t, http.StatusRequestedRangeNotSatisfiable, res.StatusCode)
+		require.Equal(t, "bytes */32", res.Header.Get("Content-Range"))
+
+		// multiple ranges are not supported, the full blob is served
+		res = get("bytes=0-1,4-5")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, data, body)
+	})
+
+	t.Run("conditional get blob", func(t *testing.T) {
+		data := testutil.RandomBytes(t, 32)
+		digest, err := multihash.Sum(data, multihash.SHA2_256, -1)
+		require.NoError(t, err)
+
+		err = blobs.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data))
+		require.NoError(t, err)
+
+		bloburl := srvurl.JoinPath("blob", digestutil.Format(digest)).String()
+		res, err := http.Get(bloburl)
+		require.NoError(t, err)
+		res.Body.Close()
+		etag := res.Header.Get("Etag")
+		require.Equal(t, `"`+digestutil.Format(digest)+`"`, etag)
+
+		req, err := http.NewRequest(http.MethodGet, bloburl, nil)
+		require.NoError(t, err)
+		req.Header.Set("If-None-Match", `"other", `+etag)
+		res, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusNotModified, res.StatusCode)
+
+		// a stale If-Range validator gets the full blob
+		req, err = http.NewRequest(http.MethodGet, bloburl, nil)
+		require.NoError(t, err)
+		req.Header.Set("Range", "bytes=0-3")
+		req.Header.Set("If-Range", `"other"`)
+		res, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, data, body)
+
+		// If-Range uses strong comparison, a weak validator gets the full blob
+		req.Header.Set("If-Range", "W/"+etag)
+		res, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		req.Header.Set("If-Range", etag)
+		res, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusPartialContent, res.StatusCode)
+		body, err = io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, data[:4], body)
+	})
+
+	t.Run("head blob", func(t *testing.T) {
+		data := testutil.RandomBytes(t, 32)
+		digest, err := multihash.Sum(data, multihash.SHA2_256, -1)
+		require.NoError(t, err)
+
+		err = blobs.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data))
+		require.NoError(t, err)
+
+		res, err := http.Head(srvurl.JoinPath("blob", digestutil.Format(digest)).String())
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, int64(len(data)), res.ContentLength)
+		require.Equal(t, "bytes", res.Header.Get("Accept-Ranges"))
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Empty(t, body)
+	})
+
+	t.Run("redirect to CDN", func(t *testing.T) {
+		cdnmux := echo.NewEcho()
+		cdnsrv := httptest.NewServer(cdnmux)
+		t.Cleanup(cdnsrv.Close)
+
+		cdnurl, err := url.Parse(cdnsrv.URL)
+		require.NoError(t, err)
+
+		offload := cdn.New(url.URL{Scheme: "https", Host: "cdn.example.com"}, cdn.NewCloudflareSigner([]byte("secret")), nil, 0, "origin-secret")
+		offloadsrv, err := NewServer(reqPresigner, allocs, blobs, nil, offload, nil, nil, nil)
+		require.NoError(t, err)
+		offloadsrv.RegisterRoutes(cdnmux)
+
+		data := testutil.RandomBytes(t, 32)
+		digest, err := multihash.Sum(data, multihash.SHA2_256, -1)
+		require.NoError(t, err)
+
+		err = blobs.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data))
+		require.NoError(t, err)
+
+		bloburl := cdnurl.JoinPath("blob", digestutil.Format(digest))
+		client := &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+
+		res, err := client.Get(bloburl.String())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+		require.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+
+		loc, err := url.Parse(res.Header.Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, "cdn.example.com", loc.Host)
+		require.Equal(t, "/blob/"+digestutil.Format(digest), loc.Path)
+		require.NotEmpty(t, loc.Query().Get(cdn.VerifyParam))
+
+		// the CDN fetching from the origin is served the blob
+		req, err := http.NewRequest(http.MethodGet, bloburl.String(), nil)
+		require.NoError(t, err)
+		req.Header.Set(cdn.OriginSecretHeader, "origin-secret")
+		res, err = client.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, data, body)
+	})
+
+	t.Run("private space", func(t *testing.T) {
+		privmux := echo.NewEcho()
+		privsrv := httptest.NewServer(privmux)
+		t.Cleanup(privsrv.Close)
+
+		privurl, err := url.Parse(privsrv.URL)
+		require.NoError(t, err)
+
+		privpresigner, err := presigner.NewS3RequestPresigner(accessKeyID, secretAccessKey, *privurl, "blob")
+		require.NoError(t, err)
+
+		space := testutil.RandomDID(t)
+		policy := privatespace.New([]did.DID{space}, allocs, privpresigner, time.Minute, datastore.NewMapDatastore())
+		blobsrv, err := NewServer(privpresigner, allocs, blobs, nil, nil, nil, nil, policy)
+		require.NoError(t, err)
+		blobsrv.RegisterRoutes(privmux)
+
+		data := testutil.RandomBytes(t, 32)
+		digest, err := multihash.Sum(data, multihash.SHA2_256, -1)
+		require.NoError(t, err)
+
+		alloc := randomAllocation(t, digest, uint64(len(data)))
+		alloc.Space = space
+		err = allocs.Put(t.Context(), alloc)
+		require.NoError(t, err)
+		err = blobs.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data))
+		require.NoError(t, err)
+
+		res, err := http.Get(privurl.JoinPath("blob", digestutil.Format(digest)).String())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		signed, err := policy.SignURL(t.Context(), digest, 0, uint64(len(data)-1))
+		require.NoError(t, err)
+		res, err = http.Get(signed.String())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusPartialContent, res.StatusCode)
+		require.Equal(t, "private, no-store", res.Header.Get("Cache-Control"))
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, data, body)
+
+		// signed URLs are single use
+		res, err = http.Get(signed.String())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		// only the signed range is served, whatever range is requested
+		signed, err = policy.SignURL(t.Context(), digest, 4, 9)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodGet, signed.String(), nil)
+		require.NoError(t, err)
+		req.Header.Set("Range", "bytes=0-31")
+		res, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusPartialContent, res.StatusCode)
+		body, err = io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, data[4:10], body)
+
+		// blobs of other spaces are public
+		public := testutil.RandomBytes(t, 32)
+		publicDigest, err := multihash.Sum(public, multihash.SHA2_256, -1)
+		require.NoError(t, err)
+		err = blobs.Put(t.Context(), publicDigest, uint64(len(public)), bytes.NewReader(public))
+		require.NoError(t, err)
+		requireRetrievableBlob(t, *privurl, publicDigest, public)
+	})
+
 	t.Run("put blob", func(t *testing.T) {
 		t.Run("basic", func(t *testing.T) {
 			data := testutil.RandomBytes(t, 32)
@@ -78,7 +319,7 @@ func TestServer(t *testing.T) {
 			err = allocs.Put(t.Context(), randomAllocation(t, digest, uint64(len(data))))
 			require.NoError(t, err)
 
-			putBlob(t, presigner, digest, data, http.StatusOK)
+			putBlob(t, reqPresigner, digest, data, http.StatusOK)
 			requireRetrievableBlob(t, *srvurl, digest, data)
 		})
 
@@ -91,16 +332,24 @@ func TestServer(t *testing.T) {
 			err = allocs.Put(t.Context(), randomAllocation(t, digest, uint64(len(data))))
 			require.NoError(t, err)
 
-			putBlob(t, presigner, digest, data, http.StatusOK)
-			putBlob(t, presigner, digest, data, http.StatusOK)
+			putBlob(t, reqPresigner, digest, data, http.StatusOK)
+			putBlob(t, reqPresigner, digest, data, http.StatusOK)
 			requireRetrievableBlob(t, *srvurl, digest, data)
 		})
 
 		t.Run("persist previous blob on repeated write failure", func(t *testing.T) {
-			// Skip: The new flatfs-based Store doesn't do hash validation at the store level.
-			// Hash validation is expected to happen at the S3 layer via ChecksumSHA256 header.
-			// This test verified behavior specific to the old FsBlobstore.
-			t.Skip("Store doesn't validate hash on write - validation happens at S3 layer")
+			data := testutil.RandomBytes(t, 32)
+			digest, err := multihash.Sum(data, multihash.SHA2_256, -1)
+			require.NoError(t, err)
+
+			// create fake allocation
+			err = allocs.Put(t.Context(), randomAllocation(t, digest, uint64(len(data))))
+			require.NoError(t, err)
+
+			putBlob(t, reqPresigner, digest, data, http.StatusOK)
+			// same digest, different data
+			putBlob(t, reqPresigner, digest, testutil.RandomBytes(t, 32), http.StatusConflict)
+			requireRetrievableBlob(t, *srvurl, digest, data)
 		})
 	})
 }
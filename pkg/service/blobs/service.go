@@ -2,10 +2,14 @@ package blobs
 
 import (
 	"github.com/storacha/piri/pkg/access"
+	"github.com/storacha/piri/pkg/cdn"
+	"github.com/storacha/piri/pkg/contentindex"
+	"github.com/storacha/piri/pkg/piecelog"
 	"github.com/storacha/piri/pkg/presigner"
 	"github.com/storacha/piri/pkg/store/acceptancestore"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/storacha/piri/pkg/telemetry/latency"
 )
 
 type BlobService struct {
@@ -32,6 +36,22 @@ func (b *BlobService) Store() blobstore.Blobstore {
 	return b.blobStore
 }
 
+func (b *BlobService) Latency() *latency.Tracker {
+	return b.latency
+}
+
+func (b *BlobService) PieceLog() *piecelog.Log {
+	return b.pieceLog
+}
+
+func (b *BlobService) CDN() *cdn.CDN {
+	return b.cdn
+}
+
+func (b *BlobService) ContentIndex() *contentindex.Index {
+	return b.contentIndex
+}
+
 var _ Blobs = (*BlobService)(nil)
 
 func New(opts ...Option) (*BlobService, error) {
@@ -1,6 +1,10 @@
 package claims
 
 import (
+	"net/url"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+
 	"github.com/storacha/piri/pkg/service/publisher"
 	"github.com/storacha/piri/pkg/store/claimstore"
 )
@@ -11,4 +15,16 @@ type Claims interface {
 	// Publisher advertises content claims/commitments found on this node to the
 	// storacha network.
 	Publisher() publisher.Publisher
+	// Expiration returns the option setting the expiration of a location
+	// claim issued now.
+	Expiration() delegation.Option
+	// Locations returns the URLs to put in a location claim for a blob
+	// retrievable at loc, one per healthy public URL of the node.
+	Locations(loc url.URL) []url.URL
+}
+
+// Locator returns the locations of a blob on each of the public URLs of the
+// node, ordered by reachability.
+type Locator interface {
+	Locations(loc url.URL) []url.URL
 }
@@ -2,6 +2,7 @@ package claims
 
 import (
 	"net/url"
+	"time"
 
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/multiformats/go-multiaddr"
@@ -19,6 +20,8 @@ type options struct {
 	blobAddr              multiaddr.Multiaddr
 	indexingService       client.Connection
 	indexingServiceProofs delegation.Proofs
+	lifetime              time.Duration
+	locator               Locator
 }
 
 type Option func(*options) error
@@ -89,6 +92,24 @@ func WithPublisherIndexingServiceProof(proof ...delegation.Proof) Option {
 	}
 }
 
+// WithLocationClaimLifetime sets how long issued location claims are valid
+// for. Claims never expire by default.
+func WithLocationClaimLifetime(lifetime time.Duration) Option {
+	return func(o *options) error {
+		o.lifetime = lifetime
+		return nil
+	}
+}
+
+// WithLocator sets the locator of blobs on the public URLs of the node. Claims
+// locate blobs at the URL they are created for by default.
+func WithLocator(locator Locator) Option {
+	return func(o *options) error {
+		o.locator = locator
+		return nil
+	}
+}
+
 // WithLogLevel changes the log level for the claims subsystem.
 func WithLogLevel(level string) Option {
 	return func(c *options) error {
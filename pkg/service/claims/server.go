@@ -21,14 +21,17 @@ var _ echofx.RouteRegistrar = (*Server)(nil)
 
 type Server struct {
 	claims claimstore.ClaimStore
+	mw     []echo.MiddlewareFunc
 }
 
-func NewServer(claims claimstore.ClaimStore) (*Server, error) {
-	return &Server{claims}, nil
+// NewServer creates the claim HTTP server. Requests pass through mw, e.g. to
+// rate limit them.
+func NewServer(claims claimstore.ClaimStore, mw ...echo.MiddlewareFunc) (*Server, error) {
+	return &Server{claims, mw}, nil
 }
 
 func (srv *Server) RegisterRoutes(e *echo.Echo) {
-	e.GET("/claim/:claim", NewHandler(srv.claims).ToEcho())
+	e.GET("/claim/:claim", NewHandler(srv.claims).ToEcho(), srv.mw...)
 }
 
 func NewHandler(claims claimstore.ClaimStore) handler.Func {
@@ -1,9 +1,14 @@
 package claims
 
 import (
+	"net/url"
+	"time"
+
 	"github.com/multiformats/go-multiaddr"
 	"github.com/storacha/go-libstoracha/ipnipublisher/store"
+	"github.com/storacha/go-ucanto/core/delegation"
 	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/ucan"
 
 	"github.com/storacha/piri/pkg/service/publisher"
 	"github.com/storacha/piri/pkg/store/claimstore"
@@ -12,6 +17,8 @@ import (
 type ClaimService struct {
 	store     claimstore.ClaimStore
 	publisher publisher.Publisher
+	lifetime  time.Duration
+	locator   Locator
 }
 
 func (c *ClaimService) Publisher() publisher.Publisher {
@@ -22,6 +29,26 @@ func (c *ClaimService) Store() claimstore.ClaimStore {
 	return c.store
 }
 
+func (c *ClaimService) Expiration() delegation.Option {
+	return ExpirationOption(c.lifetime)
+}
+
+func (c *ClaimService) Locations(loc url.URL) []url.URL {
+	if c.locator == nil {
+		return []url.URL{loc}
+	}
+	return c.locator.Locations(loc)
+}
+
+// ExpirationOption returns the option setting the expiration of a claim
+// issued now and valid for lifetime. A zero lifetime is no expiration.
+func ExpirationOption(lifetime time.Duration) delegation.Option {
+	if lifetime <= 0 {
+		return delegation.WithNoExpiration()
+	}
+	return delegation.WithExpiration(ucan.UTCUnixTimestamp(time.Now().Add(lifetime).Unix()))
+}
+
 var _ Claims = (*ClaimService)(nil)
 
 func New(id principal.Signer, claimStore claimstore.ClaimStore, publisherStore store.PublisherStore, publicAddr multiaddr.Multiaddr, opts ...Option) (*ClaimService, error) {
@@ -48,12 +75,17 @@ func New(id principal.Signer, claimStore claimstore.ClaimStore, publisherStore s
 		return nil, err
 	}
 
-	return &ClaimService{claimStore, publisher}, nil
+	return &ClaimService{claimStore, publisher, o.lifetime, o.locator}, nil
 }
 
+// NewV2 creates a ClaimService issuing location claims valid for lifetime, 0
+// for claims that never expire. Claims locate blobs on the public URLs given
+// by locator, or only at the URL they are created for if it is nil.
 func NewV2(
 	claimStore claimstore.ClaimStore,
 	publisher publisher.Publisher,
+	lifetime time.Duration,
+	locator Locator,
 ) *ClaimService {
-	return &ClaimService{claimStore, publisher}
+	return &ClaimService{claimStore, publisher, lifetime, locator}
 }
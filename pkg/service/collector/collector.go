@@ -0,0 +1,453 @@
+// Package collector deletes blobs once no space references them.
+//
+// Removing a blob from a space deletes its allocation and acceptance in that
+// space, and marks the blob for collection. Blobs still referenced by another
+// space are kept. Without PDP an unreferenced blob is deleted on the next
+// pass. With PDP its bytes are proven as part of an aggregate root, so the
+// root is first scheduled for removal from the proof set, once every blob
+// aggregated into it is unreferenced, and the bytes are deleted after the
+// removal takes effect at the end of the proving period.
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/multiformats/go-multihash"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/storacha/piri/pkg/contentindex"
+	pdptypes "github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// DefaultInterval is how often blobs marked for collection are checked.
+const DefaultInterval = 10 * time.Minute
+
+const (
+	markedPrefix  = "/marked/"
+	removalPrefix = "/removals/"
+)
+
+// Roots finds and removes the proof set roots blobs were aggregated into. It
+// is implemented by the PDP service.
+type Roots interface {
+	// PieceRoot returns the root the blob was aggregated into, or false if
+	// the blob has not been added to a proof set.
+	PieceRoot(ctx context.Context, blob multihash.Multihash) (pdptypes.PieceRoot, bool, error)
+	// RemoveRoot schedules the removal of a root from a proof set.
+	RemoveRoot(ctx context.Context, proofSetID uint64, rootID uint64) (common.Hash, error)
+}
+
+// Record is a blob marked for collection.
+type Record struct {
+	Digest multihash.Multihash `json:"-"`
+	// MarkedAt is when the blob was marked, in seconds since the unix epoch.
+	MarkedAt int64 `json:"marked_at"`
+	// Root is the proof set root holding the blob, set once its removal is
+	// scheduled.
+	Root *RootRef `json:"root,omitempty"`
+}
+
+// RootRef identifies a root of a proof set.
+type RootRef struct {
+	ProofSetID uint64 `json:"proof_set_id"`
+	RootID     uint64 `json:"root_id"`
+}
+
+// Result summarises a collection pass.
+type Result struct {
+	// Deleted is the number of blobs whose bytes were deleted.
+	Deleted int
+	// Scheduled is the number of roots scheduled for removal.
+	Scheduled int
+	// Kept is the number of blobs unmarked because they are referenced again.
+	Kept int
+}
+
+// Service collects blobs no longer referenced by any space.
+type Service struct {
+	ds          datastore.Datastore
+	allocations allocationstore.AllocationStore
+	acceptances acceptancestore.AcceptanceStore
+	blobs       blobstore.Blobstore
+	index       *contentindex.Index
+	roots       Roots
+	interval    time.Duration
+	clock       clock.Clock
+	metrics     *metrics
+	cancel      context.CancelFunc
+	done        chan struct{}
+	paused      atomic.Bool
+	enabled     func() bool
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock sets the clock passes are scheduled by. Defaults to the real
+// clock.
+func WithClock(clock clock.Clock) Option {
+	return func(s *Service) {
+		s.clock = clock
+	}
+}
+
+// WithEnabled sets a function consulted before collecting, e.g. a feature
+// flag. Blobs are only collected while it returns true. Defaults to always
+// collecting.
+func WithEnabled(enabled func() bool) Option {
+	return func(s *Service) {
+		s.enabled = enabled
+	}
+}
+
+// WithContentIndex drops blobs from the content index when their bytes are
+// deleted, or their root is scheduled for removal, so later allocations
+// upload them again rather than share a copy that is going away.
+func WithContentIndex(index *contentindex.Index) Option {
+	return func(s *Service) {
+		s.index = index
+	}
+}
+
+// New creates a collector recording marked blobs in ds. Roots is nil when
+// PDP is disabled, blobs are then deleted as soon as they are unreferenced.
+func New(
+	ds datastore.Datastore,
+	allocations allocationstore.AllocationStore,
+	acceptances acceptancestore.AcceptanceStore,
+	blobs blobstore.Blobstore,
+	roots Roots,
+	interval time.Duration,
+	opts ...Option,
+) (*Service, error) {
+	m, err := newMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("creating collector metrics: %w", err)
+	}
+	s := &Service{
+		ds:          ds,
+		allocations: allocations,
+		acceptances: acceptances,
+		blobs:       blobs,
+		roots:       roots,
+		interval:    interval,
+		clock:       clock.New(),
+		metrics:     m,
+		done:        make(chan struct{}),
+		enabled:     func() bool { return true },
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Start starts periodic collection passes, the first immediately.
+func (s *Service) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	go s.run(runCtx)
+
+	log.Infof("collector started with interval: %v", s.interval)
+	return nil
+}
+
+// Stop stops collecting gracefully, interrupting a pass in progress.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	select {
+	case <-s.done:
+		log.Info("collector stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for collector to stop: %w", ctx.Err())
+	}
+}
+
+// Pause stops collecting blobs until Resume is called. A pass in progress
+// ends after the blob being collected.
+func (s *Service) Pause() {
+	s.paused.Store(true)
+}
+
+// Resume continues collecting after a Pause.
+func (s *Service) Resume() {
+	s.paused.Store(false)
+}
+
+// Removable reports whether a blob can be removed from a space now. With PDP
+// an accepted blob must have been added to a proof set first: adding it
+// needs its acceptance, which removal deletes.
+func (s *Service) Removable(ctx context.Context, digest multihash.Multihash) (bool, error) {
+	if s.roots == nil {
+		return true, nil
+	}
+	_, ok, err := s.roots.PieceRoot(ctx, digest)
+	if err != nil {
+		return false, fmt.Errorf("finding root of blob: %w", err)
+	}
+	return ok, nil
+}
+
+// Mark marks a blob for collection. It is deleted by a later pass unless it
+// is referenced again.
+func (s *Service) Mark(ctx context.Context, digest multihash.Multihash) error {
+	key := markedKey(digest)
+	has, err := s.ds.Has(ctx, key)
+	if err != nil {
+		return fmt.Errorf("checking collection mark: %w", err)
+	}
+	if has {
+		return nil
+	}
+	return s.put(ctx, Record{Digest: digest, MarkedAt: s.clock.Now().Unix()})
+}
+
+// Marked returns the blobs marked for collection.
+func (s *Service) Marked(ctx context.Context) ([]Record, error) {
+	results, err := s.ds.Query(ctx, query.Query{Prefix: markedPrefix})
+	if err != nil {
+		return nil, fmt.Errorf("querying marked blobs: %w", err)
+	}
+	defer results.Close()
+
+	var records []Record
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating marked blobs: %w", entry.Error)
+		}
+		rec, err := decodeRecord(entry.Key, entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func (s *Service) run(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := s.clock.Ticker(s.interval)
+	defer ticker.Stop()
+	for {
+		if !s.paused.Load() && s.enabled() {
+			res, err := s.Collect(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Errorw("collecting blobs", "error", err)
+			}
+			if res.Deleted > 0 || res.Scheduled > 0 || res.Kept > 0 {
+				log.Infow("collection pass finished", "deleted", res.Deleted, "scheduled", res.Scheduled, "kept", res.Kept)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect makes a single pass over the blobs marked for collection. A blob
+// that fails to be collected is retried on the next pass.
+func (s *Service) Collect(ctx context.Context) (Result, error) {
+	var res Result
+	marked, err := s.Marked(ctx)
+	if err != nil {
+		return res, err
+	}
+	defer func() {
+		s.metrics.marked.Record(ctx, int64(len(marked)-res.Deleted-res.Kept))
+	}()
+
+	for _, rec := range marked {
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		if s.paused.Load() {
+			log.Info("collection pass interrupted, collection is paused")
+			return res, nil
+		}
+		if !s.enabled() {
+			log.Info("collection pass interrupted, collection is disabled")
+			return res, nil
+		}
+		if err := s.collect(ctx, rec, &res); err != nil {
+			if ctx.Err() != nil {
+				return res, ctx.Err()
+			}
+			log.Errorw("collecting blob", "digest", digestutil.Format(rec.Digest), "error", err)
+		}
+	}
+	return res, nil
+}
+
+func (s *Service) collect(ctx context.Context, rec Record, res *Result) error {
+	referenced, err := s.referenced(ctx, rec.Digest)
+	if err != nil {
+		return err
+	}
+	if referenced {
+		if rec.Root != nil {
+			log.Warnw("blob referenced again after its root was scheduled for removal, it is no longer proven", "digest", digestutil.Format(rec.Digest), "proofSet", rec.Root.ProofSetID, "root", rec.Root.RootID)
+		}
+		res.Kept++
+		s.metrics.collected.Inc(ctx, attribute.String("result", "kept"))
+		return s.unmark(ctx, rec.Digest)
+	}
+
+	if s.roots == nil {
+		if err := s.delete(ctx, rec.Digest); err != nil {
+			return err
+		}
+		res.Deleted++
+		return nil
+	}
+
+	root, ok, err := s.roots.PieceRoot(ctx, rec.Digest)
+	if err != nil {
+		return fmt.Errorf("finding root of blob: %w", err)
+	}
+	if !ok {
+		if rec.Root == nil {
+			// not yet added to a proof set
+			return nil
+		}
+		// the root was removed from the proof set, the bytes are no longer
+		// proven
+		if err := s.delete(ctx, rec.Digest); err != nil {
+			return err
+		}
+		res.Deleted++
+		if err := s.ds.Delete(ctx, removalKey(*rec.Root)); err != nil {
+			return fmt.Errorf("deleting root removal: %w", err)
+		}
+		return nil
+	}
+
+	// the root can only be removed once nothing aggregated into it is
+	// referenced
+	for _, blob := range root.Blobs {
+		referenced, err := s.referenced(ctx, blob)
+		if err != nil {
+			return err
+		}
+		if referenced {
+			return nil
+		}
+	}
+
+	ref := RootRef{ProofSetID: root.ProofSetID, RootID: root.RootID}
+	scheduled, err := s.ds.Has(ctx, removalKey(ref))
+	if err != nil {
+		return fmt.Errorf("checking root removal: %w", err)
+	}
+	if !scheduled {
+		tx, err := s.roots.RemoveRoot(ctx, ref.ProofSetID, ref.RootID)
+		if err != nil {
+			return fmt.Errorf("scheduling removal of root %d from proof set %d: %w", ref.RootID, ref.ProofSetID, err)
+		}
+		if err := s.ds.Put(ctx, removalKey(ref), []byte(tx.Hex())); err != nil {
+			return fmt.Errorf("recording root removal: %w", err)
+		}
+		res.Scheduled++
+		s.metrics.removals.Inc(ctx)
+		log.Infow("scheduled removal of unreferenced root", "proofSet", ref.ProofSetID, "root", ref.RootID, "tx", tx.Hex())
+	}
+	if rec.Root == nil {
+		rec.Root = &ref
+		if err := s.index.Delete(ctx, rec.Digest); err != nil {
+			return err
+		}
+		return s.put(ctx, rec)
+	}
+	return nil
+}
+
+// referenced reports whether any space has an allocation or acceptance for
+// the blob.
+func (s *Service) referenced(ctx context.Context, digest multihash.Multihash) (bool, error) {
+	allocated, err := s.allocations.Exists(ctx, digest)
+	if err != nil {
+		return false, fmt.Errorf("checking allocations of blob: %w", err)
+	}
+	if allocated {
+		return true, nil
+	}
+	accepted, err := s.acceptances.Exists(ctx, digest)
+	if err != nil {
+		return false, fmt.Errorf("checking acceptances of blob: %w", err)
+	}
+	return accepted, nil
+}
+
+// delete deletes the bytes of the blob and unmarks it.
+func (s *Service) delete(ctx context.Context, digest multihash.Multihash) error {
+	if err := s.index.Delete(ctx, digest); err != nil {
+		return err
+	}
+	if err := s.blobs.Delete(ctx, digest); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("deleting blob: %w", err)
+	}
+	s.metrics.collected.Inc(ctx, attribute.String("result", "deleted"))
+	log.Infow("deleted unreferenced blob", "digest", digestutil.Format(digest))
+	return s.unmark(ctx, digest)
+}
+
+func (s *Service) unmark(ctx context.Context, digest multihash.Multihash) error {
+	if err := s.ds.Delete(ctx, markedKey(digest)); err != nil {
+		return fmt.Errorf("deleting collection mark: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) put(ctx context.Context, rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding collection mark: %w", err)
+	}
+	if err := s.ds.Put(ctx, markedKey(rec.Digest), data); err != nil {
+		return fmt.Errorf("putting collection mark: %w", err)
+	}
+	return nil
+}
+
+func decodeRecord(key string, data []byte) (Record, error) {
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return Record{}, fmt.Errorf("decoding collection mark: %w", err)
+	}
+	digest, err := digestutil.Parse(strings.TrimPrefix(key, markedPrefix))
+	if err != nil {
+		return Record{}, fmt.Errorf("parsing digest of %s: %w", key, err)
+	}
+	rec.Digest = digest
+	return rec, nil
+}
+
+func markedKey(digest multihash.Multihash) datastore.Key {
+	return datastore.NewKey(markedPrefix + digestutil.Format(digest))
+}
+
+func removalKey(ref RootRef) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("%s%d/%d", removalPrefix, ref.ProofSetID, ref.RootID))
+}
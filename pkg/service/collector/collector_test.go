@@ -0,0 +1,230 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/multiformats/go-multihash"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/contentindex"
+	pdptypes "github.com/storacha/piri/pkg/pdp/types"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/acceptancestore/acceptance"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// mockRoots holds blobs in roots keyed by root ID, all in proof set 1.
+type mockRoots struct {
+	roots   map[uint64][]multihash.Multihash
+	removed []uint64
+}
+
+func (m *mockRoots) PieceRoot(ctx context.Context, blob multihash.Multihash) (pdptypes.PieceRoot, bool, error) {
+	for id, blobs := range m.roots {
+		for _, b := range blobs {
+			if bytes.Equal(b, blob) {
+				return pdptypes.PieceRoot{ProofSetID: 1, RootID: id, Blobs: blobs}, true, nil
+			}
+		}
+	}
+	return pdptypes.PieceRoot{}, false, nil
+}
+
+func (m *mockRoots) RemoveRoot(ctx context.Context, proofSetID uint64, rootID uint64) (common.Hash, error) {
+	m.removed = append(m.removed, rootID)
+	return common.Hash{}, nil
+}
+
+type testEnv struct {
+	svc   *Service
+	accs  acceptancestore.AcceptanceStore
+	blobs blobstore.Blobstore
+}
+
+func newTestEnv(t *testing.T, roots Roots, opts ...Option) testEnv {
+	t.Helper()
+	allocs := allocationstore.NewDatastoreStore(datastore.NewMapDatastore())
+	accs := acceptancestore.NewDatastoreStore(datastore.NewMapDatastore())
+	blobs := blobstore.NewDatastoreStore(sync.MutexWrap(datastore.NewMapDatastore()))
+	svc, err := New(sync.MutexWrap(datastore.NewMapDatastore()), allocs, accs, blobs, roots, DefaultInterval, opts...)
+	require.NoError(t, err)
+	return testEnv{svc: svc, accs: accs, blobs: blobs}
+}
+
+func putBlob(t *testing.T, blobs blobstore.Blobstore) multihash.Multihash {
+	data := testutil.RandomBytes(t, 128)
+	digest := testutil.Must(multihash.Sum(data, multihash.SHA2_256, -1))(t)
+	require.NoError(t, blobs.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data)))
+	return digest
+}
+
+func accept(t *testing.T, accs acceptancestore.AcceptanceStore, digest multihash.Multihash) acceptance.Acceptance {
+	acc := acceptance.Acceptance{
+		Space:      testutil.RandomDID(t),
+		Blob:       acceptance.Blob{Digest: digest, Size: 128},
+		ExecutedAt: uint64(time.Now().Unix()),
+		Cause:      testutil.RandomCID(t),
+	}
+	require.NoError(t, accs.Put(t.Context(), acc))
+	return acc
+}
+
+func requireDeleted(t *testing.T, blobs blobstore.Blobstore, digest multihash.Multihash) {
+	_, err := blobs.Get(t.Context(), digest)
+	require.True(t, errors.Is(err, store.ErrNotFound), "expected blob to be deleted, got %v", err)
+}
+
+func TestCollect(t *testing.T) {
+	t.Run("deletes unreferenced blobs without PDP", func(t *testing.T) {
+		env := newTestEnv(t, nil)
+		digest := putBlob(t, env.blobs)
+		require.NoError(t, env.svc.Mark(t.Context(), digest))
+
+		res, err := env.svc.Collect(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, Result{Deleted: 1}, res)
+		requireDeleted(t, env.blobs, digest)
+
+		marked, err := env.svc.Marked(t.Context())
+		require.NoError(t, err)
+		require.Empty(t, marked)
+	})
+
+	t.Run("drops deleted blobs from the content index", func(t *testing.T) {
+		index := contentindex.New(datastore.NewMapDatastore())
+		env := newTestEnv(t, nil, WithContentIndex(index))
+		digest := putBlob(t, env.blobs)
+		require.NoError(t, index.Add(t.Context(), digest, 128))
+		require.NoError(t, env.svc.Mark(t.Context(), digest))
+
+		_, err := env.svc.Collect(t.Context())
+		require.NoError(t, err)
+		_, indexed, err := index.Get(t.Context(), digest)
+		require.NoError(t, err)
+		require.False(t, indexed)
+	})
+
+	t.Run("keeps blobs referenced again", func(t *testing.T) {
+		env := newTestEnv(t, nil)
+		digest := putBlob(t, env.blobs)
+		require.NoError(t, env.svc.Mark(t.Context(), digest))
+		accept(t, env.accs, digest)
+
+		res, err := env.svc.Collect(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, Result{Kept: 1}, res)
+
+		_, err = env.blobs.Get(t.Context(), digest)
+		require.NoError(t, err)
+	})
+
+	t.Run("removes roots once nothing in them is referenced", func(t *testing.T) {
+		roots := &mockRoots{}
+		env := newTestEnv(t, roots)
+		a := putBlob(t, env.blobs)
+		b := putBlob(t, env.blobs)
+		roots.roots = map[uint64][]multihash.Multihash{7: {a, b}}
+		acc := accept(t, env.accs, b)
+
+		removable, err := env.svc.Removable(t.Context(), a)
+		require.NoError(t, err)
+		require.True(t, removable)
+
+		// b is still referenced, the root is kept
+		require.NoError(t, env.svc.Mark(t.Context(), a))
+		res, err := env.svc.Collect(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, res)
+		require.Empty(t, roots.removed)
+
+		require.NoError(t, env.accs.Delete(t.Context(), b, acc.Space))
+		require.NoError(t, env.svc.Mark(t.Context(), b))
+		res, err = env.svc.Collect(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, Result{Scheduled: 1}, res)
+		require.Equal(t, []uint64{7}, roots.removed)
+
+		// the bytes are kept until the removal takes effect
+		res, err = env.svc.Collect(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, res)
+		_, err = env.blobs.Get(t.Context(), a)
+		require.NoError(t, err)
+
+		delete(roots.roots, 7)
+		res, err = env.svc.Collect(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, Result{Deleted: 2}, res)
+		require.Equal(t, []uint64{7}, roots.removed)
+		requireDeleted(t, env.blobs, a)
+		requireDeleted(t, env.blobs, b)
+	})
+
+	t.Run("waits for blobs to be added to a proof set", func(t *testing.T) {
+		env := newTestEnv(t, &mockRoots{})
+		digest := putBlob(t, env.blobs)
+
+		removable, err := env.svc.Removable(t.Context(), digest)
+		require.NoError(t, err)
+		require.False(t, removable)
+
+		require.NoError(t, env.svc.Mark(t.Context(), digest))
+		res, err := env.svc.Collect(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, res)
+
+		marked, err := env.svc.Marked(t.Context())
+		require.NoError(t, err)
+		require.Len(t, marked, 1)
+		require.Equal(t, digest, marked[0].Digest)
+	})
+}
+
+func TestMark(t *testing.T) {
+	clk := clock.NewMock()
+	clk.Set(time.Unix(1_700_000_000, 0))
+	env := newTestEnv(t, nil, WithClock(clk))
+	digest := putBlob(t, env.blobs)
+	require.NoError(t, env.svc.Mark(t.Context(), digest))
+
+	// marking again keeps the original mark
+	clk.Add(time.Hour)
+	require.NoError(t, env.svc.Mark(t.Context(), digest))
+
+	marked, err := env.svc.Marked(t.Context())
+	require.NoError(t, err)
+	require.Len(t, marked, 1)
+	require.Equal(t, int64(1_700_000_000), marked[0].MarkedAt)
+}
+
+func TestStart(t *testing.T) {
+	clk := clock.NewMock()
+	env := newTestEnv(t, nil, WithClock(clk))
+	require.NoError(t, env.svc.Start(t.Context()))
+	t.Cleanup(func() {
+		require.NoError(t, env.svc.Stop(context.Background()))
+	})
+
+	for range 2 {
+		digest := putBlob(t, env.blobs)
+		require.NoError(t, env.svc.Mark(t.Context(), digest))
+
+		require.Eventually(t, func() bool {
+			// a pass runs every interval
+			clk.Add(DefaultInterval)
+			_, err := env.blobs.Get(t.Context(), digest)
+			return errors.Is(err, store.ErrNotFound)
+		}, time.Second, 10*time.Millisecond)
+	}
+}
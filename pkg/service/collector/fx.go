@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/raulk/clock"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/feature"
+	"github.com/storacha/piri/pkg/contentindex"
+	"github.com/storacha/piri/pkg/pdp/service"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/storacha/piri/pkg/subsystem"
+)
+
+var log = logging.Logger("collector")
+
+// FeatureFlag guards the collector. It is enabled by default, and can be
+// disabled or rolled out to a percentage of nodes.
+var FeatureFlag = feature.Definition{
+	Name:        "collector",
+	Description: "Delete blobs no longer referenced by any space",
+	Default:     true,
+	Rollout:     true,
+}
+
+var Module = fx.Module("collector",
+	fx.Provide(
+		NewCollectorService,
+		fx.Annotate(
+			func() feature.Definition { return FeatureFlag },
+			fx.ResultTags(`group:"feature_flags"`),
+		),
+	),
+)
+
+type Params struct {
+	fx.In
+
+	Datastore       datastore.Datastore `name:"collector_datastore"`
+	AllocationStore allocationstore.AllocationStore
+	AcceptanceStore acceptancestore.AcceptanceStore
+	BlobStore       blobstore.Blobstore
+	ContentIndex    *contentindex.Index
+	PDP             *service.PDPService `optional:"true"`
+	Subsystems      *subsystem.Registry
+	Features        *feature.Flags
+	Clock           clock.Clock
+}
+
+func NewCollectorService(lc fx.Lifecycle, params Params) (*Service, error) {
+	// roots are only removed from proof sets when PDP is enabled
+	var roots Roots
+	if params.PDP != nil {
+		roots = params.PDP
+	}
+
+	svc, err := New(
+		params.Datastore,
+		params.AllocationStore,
+		params.AcceptanceStore,
+		params.BlobStore,
+		roots,
+		DefaultInterval,
+		WithClock(params.Clock),
+		WithContentIndex(params.ContentIndex),
+		WithEnabled(func() bool { return params.Features.Enabled(FeatureFlag.Name) }),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Subsystems.Register(subsystem.Collection, svc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return svc.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			return svc.Stop(ctx)
+		},
+	})
+
+	return svc, nil
+}
@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"go.opentelemetry.io/otel"
+
+	"github.com/storacha/piri/lib/telemetry"
+)
+
+type metrics struct {
+	collected *telemetry.Counter
+	removals  *telemetry.Counter
+	marked    *telemetry.Int64Gauge
+}
+
+func newMetrics() (*metrics, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/service/collector")
+	collected, err := telemetry.NewCounter(
+		meter,
+		"piri_collected_blobs",
+		"blobs marked for collection, by result (deleted or kept when referenced again)",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	removals, err := telemetry.NewCounter(
+		meter,
+		"piri_collected_root_removals",
+		"unreferenced roots scheduled for removal from the proof set",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	marked, err := telemetry.NewInt64Gauge(
+		meter,
+		"piri_collection_pending_blobs",
+		"blobs marked for collection that are not yet deleted",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &metrics{collected: collected, removals: removals, marked: marked}, nil
+}
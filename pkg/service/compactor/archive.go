@@ -0,0 +1,119 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/storacha/go-ucanto/core/car"
+	"github.com/storacha/go-ucanto/core/ipld"
+
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/receiptstore"
+)
+
+// Archive stores CAR files of receipts before they are pruned. It is
+// satisfied by object stores, such as an S3 bucket.
+type Archive interface {
+	Put(ctx context.Context, key string, size uint64, data io.Reader) error
+}
+
+// DirArchive writes CAR files of receipts to a directory.
+type DirArchive struct {
+	dir string
+}
+
+var _ Archive = (*DirArchive)(nil)
+
+// NewDirArchive creates an archive writing to dir, creating it if needed.
+func NewDirArchive(dir string) (*DirArchive, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating receipt archive directory: %w", err)
+	}
+	return &DirArchive{dir: dir}, nil
+}
+
+func (a *DirArchive) Put(_ context.Context, key string, _ uint64, data io.Reader) error {
+	// written to a temporary file first, so a partial CAR is never archived
+	tmp, err := os.CreateTemp(a.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing archive file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("syncing archive file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing archive file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(a.dir, key)); err != nil {
+		return fmt.Errorf("renaming archive file: %w", err)
+	}
+	return nil
+}
+
+// archiveKey names the CAR file of a batch of receipts after the first of
+// them, so names sort in the order receipts were stored.
+func archiveKey(first receiptstore.LogEntry) string {
+	return fmt.Sprintf("receipts-%s-%s.car", first.StoredAt.UTC().Format("20060102T150405Z"), first.Root)
+}
+
+// export writes the receipts of a batch to a CAR file in the archive, with
+// the receipts as its roots, and returns the number of receipts archived.
+// Receipts missing from the store are skipped.
+func (s *Service) export(ctx context.Context, batch []receiptstore.LogEntry) (int, error) {
+	var roots []ipld.Link
+	var blocks []ipld.Block
+	seen := map[string]struct{}{}
+	for _, entry := range batch {
+		rcpt, err := s.receipts.Get(ctx, entry.Root)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return 0, fmt.Errorf("getting receipt %s: %w", entry.Root, err)
+		}
+		roots = append(roots, rcpt.Root().Link())
+		for b, err := range rcpt.Blocks() {
+			if err != nil {
+				return 0, fmt.Errorf("reading blocks of receipt %s: %w", entry.Root, err)
+			}
+			if _, ok := seen[b.Link().String()]; ok {
+				continue
+			}
+			seen[b.Link().String()] = struct{}{}
+			blocks = append(blocks, b)
+		}
+	}
+	if len(roots) == 0 {
+		return 0, nil
+	}
+
+	r := car.Encode(roots, func(yield func(ipld.Block, error) bool) {
+		for _, b := range blocks {
+			if !yield(b, nil) {
+				return
+			}
+		}
+	})
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, fmt.Errorf("encoding CAR: %w", err)
+	}
+	key := archiveKey(batch[0])
+	if err := s.archive.Put(ctx, key, uint64(len(data)), bytes.NewReader(data)); err != nil {
+		return 0, fmt.Errorf("storing %s: %w", key, err)
+	}
+	log.Infow("archived receipts", "key", key, "receipts", len(roots), "bytes", len(data))
+	return len(roots), nil
+}
@@ -0,0 +1,290 @@
+// Package compactor prunes old receipts from the receipt store, so it does not
+// grow without bound.
+//
+// Receipts are pruned oldest first, once they are older than the maximum age
+// or are not among the most recent receipts kept. Before they are deleted
+// they can be exported in CAR files to an archive, such as a directory or an
+// S3 bucket. Receipts are only pruned from stores that log the receipts they
+// store, the local datastore receipt store.
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/raulk/clock"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/storacha/piri/pkg/store/receiptstore"
+)
+
+const (
+	// DefaultInterval is the time between compaction passes.
+	DefaultInterval = time.Hour
+	// DefaultBatchSize is the number of receipts pruned, and archived together
+	// in a CAR file, at a time.
+	DefaultBatchSize = 1000
+)
+
+// Policy is the retention policy of receipts. Receipts are kept forever if
+// neither limit is set.
+type Policy struct {
+	// MaxAge is how long receipts are kept after they are stored, 0 for no
+	// limit.
+	MaxAge time.Duration
+	// MaxCount is the number of most recent receipts kept, 0 for no limit.
+	MaxCount uint64
+}
+
+// Enabled reports whether the policy prunes receipts.
+func (p Policy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxCount > 0
+}
+
+// expired reports whether a receipt stored at storedAt is pruned, given the
+// number of receipts remaining from it onwards.
+func (p Policy) expired(now, storedAt time.Time, remaining uint64) bool {
+	if p.MaxAge > 0 && now.Sub(storedAt) > p.MaxAge {
+		return true
+	}
+	return p.MaxCount > 0 && remaining > p.MaxCount
+}
+
+// Result summarises a compaction pass.
+type Result struct {
+	// Pruned is the number of receipts deleted.
+	Pruned int
+	// Archived is the number of receipts exported before they were deleted.
+	Archived int
+	// Stored is the number of receipts in the store after the pass.
+	Stored uint64
+	// Bytes is the size of the receipts in the store after the pass.
+	Bytes uint64
+}
+
+// Service prunes receipts according to a retention policy.
+type Service struct {
+	receipts  receiptstore.Pruner
+	archive   Archive
+	policy    Policy
+	interval  time.Duration
+	batchSize int
+	clock     clock.Clock
+	metrics   *metrics
+	cancel    context.CancelFunc
+	done      chan struct{}
+	paused    atomic.Bool
+	enabled   func() bool
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithArchive exports receipts to archive before they are deleted. A batch of
+// receipts that fails to be archived is not deleted.
+func WithArchive(archive Archive) Option {
+	return func(s *Service) {
+		s.archive = archive
+	}
+}
+
+// WithBatchSize sets the number of receipts pruned, and archived together, at
+// a time. Defaults to DefaultBatchSize.
+func WithBatchSize(size int) Option {
+	return func(s *Service) {
+		if size > 0 {
+			s.batchSize = size
+		}
+	}
+}
+
+// WithEnabled sets a function consulted before a compaction pass, e.g. a
+// feature flag. Receipts are only pruned while it returns true. Defaults to
+// always compacting.
+func WithEnabled(enabled func() bool) Option {
+	return func(s *Service) {
+		s.enabled = enabled
+	}
+}
+
+// WithClock sets the clock receipt ages are measured and passes are
+// scheduled by. Defaults to the real clock.
+func WithClock(clock clock.Clock) Option {
+	return func(s *Service) {
+		s.clock = clock
+	}
+}
+
+// New creates a compactor pruning receipts according to policy every
+// interval. Without a policy passes only measure the size of the store.
+func New(receipts receiptstore.Pruner, policy Policy, interval time.Duration, opts ...Option) (*Service, error) {
+	m, err := newMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("creating compactor metrics: %w", err)
+	}
+	s := &Service{
+		receipts:  receipts,
+		policy:    policy,
+		interval:  interval,
+		batchSize: DefaultBatchSize,
+		clock:     clock.New(),
+		metrics:   m,
+		done:      make(chan struct{}),
+		enabled:   func() bool { return true },
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Start starts periodic compaction passes, the first immediately.
+func (s *Service) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	go s.run(runCtx)
+
+	log.Infof("receipt compactor started with interval: %v", s.interval)
+	return nil
+}
+
+// Stop stops compacting gracefully, interrupting a pass in progress.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	select {
+	case <-s.done:
+		log.Info("receipt compactor stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for receipt compactor to stop: %w", ctx.Err())
+	}
+}
+
+// Pause stops pruning receipts until Resume is called. A pass in progress
+// ends after the batch being pruned.
+func (s *Service) Pause() {
+	s.paused.Store(true)
+}
+
+// Resume continues pruning after a Pause.
+func (s *Service) Resume() {
+	s.paused.Store(false)
+}
+
+func (s *Service) run(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := s.clock.Ticker(s.interval)
+	defer ticker.Stop()
+	for {
+		if !s.paused.Load() && s.enabled() {
+			res, err := s.Compact(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Errorw("compacting receipts", "error", err)
+			}
+			if res.Pruned > 0 {
+				log.Infow("receipt compaction pass finished", "pruned", res.Pruned, "archived", res.Archived, "stored", res.Stored, "bytes", res.Bytes)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Compact makes a single pass over the receipt store, pruning the receipts
+// expired by the policy in batches, and records the size of the store.
+func (s *Service) Compact(ctx context.Context) (Result, error) {
+	var res Result
+	now := s.clock.Now()
+
+	// receipts stored before the store logged them are aged from now
+	backfilled, err := s.receipts.Backfill(ctx, now)
+	if err != nil {
+		return res, fmt.Errorf("logging existing receipts: %w", err)
+	}
+	if backfilled > 0 {
+		log.Infow("logged receipts stored before receipts were pruned", "count", backfilled)
+	}
+
+	for entry, err := range s.receipts.Stored(ctx) {
+		if err != nil {
+			return res, fmt.Errorf("listing receipts: %w", err)
+		}
+		res.Stored++
+		res.Bytes += entry.Size
+	}
+	defer func() {
+		s.metrics.stored.Record(ctx, int64(res.Stored))
+		s.metrics.bytes.Record(ctx, int64(res.Bytes))
+	}()
+
+	if !s.policy.Enabled() {
+		return res, nil
+	}
+	for {
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		if s.paused.Load() {
+			log.Info("receipt compaction pass interrupted, compaction is paused")
+			return res, nil
+		}
+		batch, err := s.expired(ctx, now, res.Stored)
+		if err != nil {
+			return res, err
+		}
+		if len(batch) == 0 {
+			return res, nil
+		}
+		if s.archive != nil {
+			archived, err := s.export(ctx, batch)
+			if err != nil {
+				s.metrics.archives.Inc(ctx, attribute.String("result", "failed"))
+				return res, fmt.Errorf("archiving receipts: %w", err)
+			}
+			s.metrics.archives.Inc(ctx, attribute.String("result", "ok"))
+			res.Archived += archived
+		}
+		for _, entry := range batch {
+			if err := s.receipts.Remove(ctx, entry); err != nil {
+				return res, fmt.Errorf("removing receipt %s: %w", entry.Root, err)
+			}
+			res.Pruned++
+			res.Stored--
+			res.Bytes -= min(entry.Size, res.Bytes)
+			s.metrics.pruned.Inc(ctx)
+		}
+	}
+}
+
+// expired returns the next batch of receipts expired by the policy, oldest
+// first, given the number of receipts stored.
+func (s *Service) expired(ctx context.Context, now time.Time, stored uint64) ([]receiptstore.LogEntry, error) {
+	var batch []receiptstore.LogEntry
+	var i uint64
+	for entry, err := range s.receipts.Stored(ctx) {
+		if err != nil {
+			return nil, fmt.Errorf("listing receipts: %w", err)
+		}
+		// entries are ordered by age, so no later entry is expired either.
+		// Receipts stored since the store was measured are never expired.
+		if i >= stored || !s.policy.expired(now, entry.StoredAt, stored-i) {
+			break
+		}
+		batch = append(batch, entry)
+		i++
+		if len(batch) == s.batchSize {
+			break
+		}
+	}
+	return batch, nil
+}
@@ -0,0 +1,178 @@
+package compactor_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-data-segment/merkletree"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/raulk/clock"
+	pdpcaps "github.com/storacha/go-libstoracha/capabilities/pdp"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/core/car"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/receipt/ran"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/service/compactor"
+	"github.com/storacha/piri/pkg/store/objectstore/dsadapter"
+	"github.com/storacha/piri/pkg/store/receiptstore"
+)
+
+func randomReceipt(t *testing.T) receipt.AnyReceipt {
+	t.Helper()
+	inv, err := pdpcaps.Accept.Invoke(
+		testutil.Alice,
+		testutil.Alice,
+		testutil.Alice.DID().String(),
+		pdpcaps.AcceptCaveats{Blob: testutil.RandomMultihash(t)},
+	)
+	require.NoError(t, err)
+	out := result.Ok[ipld.Builder, ipld.Builder](pdpcaps.AcceptOk{
+		Piece:          testutil.RandomPiece(t, 256),
+		Aggregate:      testutil.RandomPiece(t, 256*1024*1024),
+		InclusionProof: merkletree.ProofData{},
+	})
+	rcpt, err := receipt.Issue(testutil.Alice, out, ran.FromInvocation(inv))
+	require.NoError(t, err)
+	return rcpt
+}
+
+func putReceipts(t *testing.T, store receiptstore.ReceiptStore, n int) []receipt.AnyReceipt {
+	t.Helper()
+	var rcpts []receipt.AnyReceipt
+	for range n {
+		rcpt := randomReceipt(t)
+		require.NoError(t, store.Put(t.Context(), rcpt))
+		rcpts = append(rcpts, rcpt)
+	}
+	return rcpts
+}
+
+func TestCompact(t *testing.T) {
+	t.Run("keeps the most recent receipts and archives the others", func(t *testing.T) {
+		store := receiptstore.NewDatastoreStore(sync.MutexWrap(datastore.NewMapDatastore()))
+		rcpts := putReceipts(t, store, 5)
+		dir := t.TempDir()
+		archive, err := compactor.NewDirArchive(dir)
+		require.NoError(t, err)
+
+		svc, err := compactor.New(store, compactor.Policy{MaxCount: 2}, time.Hour,
+			compactor.WithArchive(archive),
+			compactor.WithBatchSize(2),
+		)
+		require.NoError(t, err)
+		res, err := svc.Compact(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 3, res.Pruned)
+		require.Equal(t, 3, res.Archived)
+		require.Equal(t, uint64(2), res.Stored)
+		require.NotZero(t, res.Bytes)
+
+		for _, rcpt := range rcpts[:3] {
+			_, err := store.Get(t.Context(), rcpt.Root().Link())
+			require.Error(t, err)
+			_, err = store.GetByRan(t.Context(), rcpt.Ran().Link())
+			require.Error(t, err)
+		}
+		for _, rcpt := range rcpts[3:] {
+			_, err := store.Get(t.Context(), rcpt.Root().Link())
+			require.NoError(t, err)
+		}
+
+		// pruned receipts are archived in batches, oldest first
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		var archived []string
+		for _, f := range files {
+			r, err := os.Open(filepath.Join(dir, f.Name()))
+			require.NoError(t, err)
+			roots, _, err := car.Decode(r)
+			require.NoError(t, err)
+			r.Close()
+			for _, root := range roots {
+				archived = append(archived, root.String())
+			}
+		}
+		require.ElementsMatch(t, []string{
+			rcpts[0].Root().Link().String(),
+			rcpts[1].Root().Link().String(),
+			rcpts[2].Root().Link().String(),
+		}, archived)
+
+		res, err = svc.Compact(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, res.Pruned)
+	})
+
+	t.Run("prunes receipts older than the maximum age", func(t *testing.T) {
+		store := receiptstore.NewDatastoreStore(sync.MutexWrap(datastore.NewMapDatastore()))
+		putReceipts(t, store, 3)
+		clk := clock.NewMock()
+		clk.Set(time.Now())
+
+		svc, err := compactor.New(store, compactor.Policy{MaxAge: time.Hour}, time.Hour, compactor.WithClock(clk))
+		require.NoError(t, err)
+		res, err := svc.Compact(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, res.Pruned)
+		require.Equal(t, uint64(3), res.Stored)
+
+		clk.Add(2 * time.Hour)
+		res, err = svc.Compact(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 3, res.Pruned)
+		require.Zero(t, res.Stored)
+		require.Zero(t, res.Bytes)
+	})
+
+	t.Run("measures the store without a policy", func(t *testing.T) {
+		store := receiptstore.NewDatastoreStore(sync.MutexWrap(datastore.NewMapDatastore()))
+		putReceipts(t, store, 3)
+
+		svc, err := compactor.New(store, compactor.Policy{}, time.Hour)
+		require.NoError(t, err)
+		res, err := svc.Compact(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, res.Pruned)
+		require.Equal(t, uint64(3), res.Stored)
+		require.NotZero(t, res.Bytes)
+	})
+
+	t.Run("logs receipts stored before the log was kept", func(t *testing.T) {
+		ds := sync.MutexWrap(datastore.NewMapDatastore())
+		// receipts laid out as by NewDatastoreStore, without the log
+		unlogged := receiptstore.New(
+			dsadapter.New(namespace.Wrap(ds, datastore.NewKey("receipts/"))),
+			receiptstore.DatastoreKeyEncoder{},
+			nopRanLinkIndex{},
+		)
+		putReceipts(t, unlogged, 2)
+		store := receiptstore.NewDatastoreStore(ds)
+		putReceipts(t, store, 1)
+
+		svc, err := compactor.New(store, compactor.Policy{MaxCount: 1}, time.Hour)
+		require.NoError(t, err)
+		res, err := svc.Compact(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 2, res.Pruned)
+		require.Equal(t, uint64(1), res.Stored)
+	})
+}
+
+type nopRanLinkIndex struct{}
+
+func (nopRanLinkIndex) Put(context.Context, datamodel.Link, datamodel.Link) error { return nil }
+
+func (nopRanLinkIndex) Get(context.Context, datamodel.Link) (datamodel.Link, error) {
+	return nil, datastore.ErrNotFound
+}
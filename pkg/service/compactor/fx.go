@@ -0,0 +1,121 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/raulk/clock"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/config/feature"
+	minio_store "github.com/storacha/piri/pkg/store/objectstore/minio"
+	"github.com/storacha/piri/pkg/store/receiptstore"
+	"github.com/storacha/piri/pkg/subsystem"
+)
+
+var log = logging.Logger("compactor")
+
+// FeatureFlag guards the compactor. It is enabled by default, and can be
+// disabled or rolled out to a percentage of nodes.
+var FeatureFlag = feature.Definition{
+	Name:        "compactor",
+	Description: "Prune receipts expired by the receipt retention policy",
+	Default:     true,
+	Rollout:     true,
+}
+
+var Module = fx.Module("compactor",
+	fx.Provide(
+		NewCompactorService,
+		fx.Annotate(
+			func() feature.Definition { return FeatureFlag },
+			fx.ResultTags(`group:"feature_flags"`),
+		),
+	),
+	// force construction, nothing else depends on the compactor
+	fx.Invoke(func(*Service) {}),
+)
+
+type Params struct {
+	fx.In
+
+	Cfg        app.AppConfig
+	Receipts   receiptstore.Pruner `optional:"true"`
+	Subsystems *subsystem.Registry
+	Features   *feature.Flags
+	Clock      clock.Clock
+}
+
+func NewCompactorService(lc fx.Lifecycle, params Params) (*Service, error) {
+	cfg := params.Cfg.ReceiptRetention
+	policy := Policy{MaxAge: cfg.MaxAge, MaxCount: cfg.MaxCount}
+	if params.Receipts == nil {
+		if policy.Enabled() {
+			log.Warn("receipt store does not log stored receipts, receipts are not pruned, use a lifecycle rule on the receipts bucket instead")
+		}
+		return nil, nil
+	}
+
+	interval := cfg.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	opts := []Option{
+		WithClock(params.Clock),
+		WithBatchSize(cfg.BatchSize),
+		WithEnabled(func() bool { return params.Features.Enabled(FeatureFlag.Name) }),
+	}
+	archive, err := newArchive(cfg.Archive)
+	if err != nil {
+		return nil, err
+	}
+	if archive != nil {
+		opts = append(opts, WithArchive(archive))
+	}
+
+	svc, err := New(params.Receipts, policy, interval, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Subsystems.Register(subsystem.Compaction, svc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return svc.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			return svc.Stop(ctx)
+		},
+	})
+
+	return svc, nil
+}
+
+func newArchive(cfg app.ReceiptArchiveConfig) (Archive, error) {
+	switch {
+	case cfg.Dir != "":
+		return NewDirArchive(cfg.Dir)
+	case cfg.S3 != nil:
+		options := minio.Options{Secure: !cfg.S3.Insecure}
+		if cfg.S3.Credentials.AccessKeyID != "" && cfg.S3.Credentials.SecretAccessKey != "" {
+			options.Creds = credentials.NewStaticV4(
+				cfg.S3.Credentials.AccessKeyID,
+				cfg.S3.Credentials.SecretAccessKey,
+				"",
+			)
+		}
+		archive, err := minio_store.New(cfg.S3.Endpoint, cfg.S3.Bucket, options)
+		if err != nil {
+			return nil, fmt.Errorf("creating receipt archive bucket: %w", err)
+		}
+		return archive, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,55 @@
+package compactor
+
+import (
+	"go.opentelemetry.io/otel"
+
+	"github.com/storacha/piri/lib/telemetry"
+)
+
+type metrics struct {
+	pruned   *telemetry.Counter
+	archives *telemetry.Counter
+	stored   *telemetry.Int64Gauge
+	bytes    *telemetry.Int64Gauge
+}
+
+func newMetrics() (*metrics, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/service/compactor")
+	pruned, err := telemetry.NewCounter(
+		meter,
+		"piri_receipts_pruned",
+		"receipts deleted by the retention policy",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	archives, err := telemetry.NewCounter(
+		meter,
+		"piri_receipt_archives",
+		"CAR files of receipts written to the archive before pruning, by result (ok or failed)",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := telemetry.NewInt64Gauge(
+		meter,
+		"piri_receipts_stored",
+		"receipts in the receipt store",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := telemetry.NewInt64Gauge(
+		meter,
+		"piri_receipt_store_bytes",
+		"size of the receipts in the receipt store",
+		"By",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &metrics{pruned: pruned, archives: archives, stored: stored, bytes: bytes}, nil
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/storacha/piri/lib/jobqueue/serializer"
 	"github.com/storacha/piri/pkg/client/receipts"
 	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/diagnostics"
 	echofx "github.com/storacha/piri/pkg/fx/echo"
 	"github.com/storacha/piri/pkg/store/consolidationstore"
 	"github.com/storacha/piri/pkg/store/local/retrievaljournal"
@@ -32,6 +33,13 @@ var log = logging.Logger("egresstracker")
 var Module = fx.Module("egresstracker",
 	fx.Provide(
 		ProvideEgressTrackerQueue,
+		fx.Annotate(
+			func(q EgressTrackerQueue) jobqueue.Snapshotter {
+				s, _ := q.(jobqueue.Snapshotter)
+				return s
+			},
+			fx.ResultTags(diagnostics.QueueGroup),
+		),
 		//ProvideConsolidationStore,
 		ProvideReceiptsClient,
 		NewEgressTrackerService,
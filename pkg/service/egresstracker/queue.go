@@ -31,3 +31,8 @@ func (a *jobQueueAdapter) Register(fn func(ctx context.Context, batchCID cid.Cid
 func (a *jobQueueAdapter) Enqueue(ctx context.Context, batchCID cid.Cid) error {
 	return a.queue.Enqueue(ctx, egressTrackTaskName, batchCID)
 }
+
+// Snapshot implements jobqueue.Snapshotter.
+func (a *jobQueueAdapter) Snapshot() jobqueue.Snapshot {
+	return a.queue.Snapshot()
+}
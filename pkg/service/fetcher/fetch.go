@@ -0,0 +1,90 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+
+	"github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/principal"
+
+	"github.com/storacha/piri/lib/jobqueue"
+	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/service/blobs"
+	"github.com/storacha/piri/pkg/service/claims"
+	fetchhandler "github.com/storacha/piri/pkg/service/storage/handlers/fetch"
+	"github.com/storacha/piri/pkg/storageclass"
+	"github.com/storacha/piri/pkg/store/receiptstore"
+)
+
+type Fetcher interface {
+	Fetch(context.Context, *fetchhandler.FetchRequest) error
+}
+
+type Service struct {
+	queue      *jobqueue.JobQueue[*fetchhandler.FetchRequest]
+	adapter    *adapter
+	downloader *fetchhandler.Downloader
+}
+
+type adapter struct {
+	id         principal.Signer
+	pdp        pdp.PDP
+	blobs      blobs.Blobs
+	claims     claims.Claims
+	receipts   receiptstore.ReceiptStore
+	uploadConn client.Connection
+	classes    *storageclass.Manager
+}
+
+func (a adapter) ID() principal.Signer                  { return a.id }
+func (a adapter) PDP() pdp.PDP                          { return a.pdp }
+func (a adapter) Blobs() blobs.Blobs                    { return a.blobs }
+func (a adapter) Claims() claims.Claims                 { return a.claims }
+func (a adapter) Receipts() receiptstore.ReceiptStore   { return a.receipts }
+func (a adapter) UploadConnection() client.Connection   { return a.uploadConn }
+func (a adapter) StorageClasses() *storageclass.Manager { return a.classes }
+
+func New(
+	id principal.Signer,
+	p pdp.PDP,
+	b blobs.Blobs,
+	c claims.Claims,
+	rstore receiptstore.ReceiptStore,
+	uploadConn client.Connection,
+	classes *storageclass.Manager,
+	queue *jobqueue.JobQueue[*fetchhandler.FetchRequest],
+	downloader *fetchhandler.Downloader,
+) *Service {
+	return &Service{
+		queue: queue,
+		adapter: &adapter{
+			id:         id,
+			pdp:        p,
+			blobs:      b,
+			claims:     c,
+			receipts:   rstore,
+			uploadConn: uploadConn,
+			classes:    classes,
+		},
+		downloader: downloader,
+	}
+}
+
+const FetchTaskName = "fetch-task"
+
+func (f *Service) Fetch(ctx context.Context, task *fetchhandler.FetchRequest) error {
+	return f.queue.Enqueue(ctx, FetchTaskName, task)
+}
+
+func (f *Service) RegisterFetchTask(queue *jobqueue.JobQueue[*fetchhandler.FetchRequest]) error {
+	return queue.Register(FetchTaskName, func(ctx context.Context, request *fetchhandler.FetchRequest) error {
+		err := fetchhandler.Fetch(ctx, f.adapter, f.downloader, request)
+		// content that does not match the blob will not match on retry either
+		if errors.Is(err, fetchhandler.ErrPrecondition) || errors.Is(err, fetchhandler.ErrForbiddenAddress) {
+			return jobqueue.NewPermanentError(err)
+		}
+		return err
+	}, jobqueue.WithOnFailure(func(ctx context.Context, msg *fetchhandler.FetchRequest, err error) error {
+		return fetchhandler.SendFailureReceipt(ctx, f.adapter, msg, err)
+	}))
+}
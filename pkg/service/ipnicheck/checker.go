@@ -0,0 +1,432 @@
+// Package ipnicheck checks the node's IPNI advertisements resolve to it on
+// the indexers they are announced to.
+//
+// Advertisements are announced without any acknowledgement: an indexer that
+// cannot fetch them, e.g. because of a broken announce address, or that
+// rejects them, e.g. because they are signed by a key other than the one it
+// knows the provider by, fails silently and blobs on the node are no longer
+// found through IPNI. Each check looks up a sample of recently advertised
+// multihashes on every indexer and reports those that do not resolve to the
+// node. An indexer failing consecutive checks raises an alert.
+package ipnicheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipni/go-libipni/ingest/schema"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-libstoracha/ipnipublisher/store"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/internal/periodic"
+)
+
+var log = logging.Logger("ipnicheck")
+
+const (
+	// DefaultInterval is the time between checks if none is configured.
+	DefaultInterval = time.Hour
+	// DefaultSampleSize is the number of multihashes looked up in each check
+	// if not configured.
+	DefaultSampleSize = 10
+	// DefaultMinVisible is the fraction of the sample that must resolve to
+	// the node for a check to pass, if not configured.
+	DefaultMinVisible = 0.8
+	// DefaultAlertAfter is the number of consecutive failed checks of an
+	// indexer after which the failure is reported, if not configured.
+	DefaultAlertAfter = 3
+
+	// FindPath is the path multihashes are looked up on, relative to the
+	// base URL of an indexer.
+	FindPath = "multihash"
+
+	// maxWalk bounds the advertisements read to collect a sample, as a
+	// multiple of the sample size, so a chain of removals is not walked to
+	// its end.
+	maxWalk        = 10
+	requestTimeout = 30 * time.Second
+)
+
+// Result is the outcome of looking up a multihash on an indexer.
+type Result string
+
+const (
+	// ResultVisible is a multihash resolving to the node.
+	ResultVisible Result = "visible"
+	// ResultMissing is a multihash the indexer has no provider for.
+	ResultMissing Result = "missing"
+	// ResultOtherProvider is a multihash resolving only to other providers,
+	// e.g. the node under a previous identity.
+	ResultOtherProvider Result = "other_provider"
+	// ResultError is a lookup that failed, it does not count towards the
+	// outcome of the check.
+	ResultError Result = "error"
+)
+
+// Lookup is the result of looking up a sampled multihash.
+type Lookup struct {
+	Digest multihash.Multihash
+	Result Result
+	// Error is the reason the lookup failed, for ResultError.
+	Error string
+}
+
+// Status is the outcome of the last check of an indexer.
+type Status struct {
+	Indexer url.URL
+	// Checked is false until the indexer has been checked with a non-empty
+	// sample.
+	Checked   bool
+	CheckedAt time.Time
+	// Visible is the number of sampled multihashes resolving to the node, out
+	// of Resolved lookups that did not fail.
+	Visible  int
+	Resolved int
+	Lookups  []Lookup
+	// Failures is the number of consecutive failed checks.
+	Failures int
+	// Alerting is true once Failures reaches the alert threshold.
+	Alerting bool
+}
+
+// Checker periodically looks up advertised multihashes on the indexers.
+type Checker struct {
+	provider   peer.ID
+	store      store.PublisherStore
+	indexers   []url.URL
+	client     *http.Client
+	interval   time.Duration
+	sampleSize int
+	minVisible float64
+	alertAfter int
+	clock      clock.Clock
+	metrics    *metrics
+
+	mu       sync.RWMutex
+	statuses []Status
+	// checkpoint is the head advertisement at the previous check. Samples are
+	// taken from advertisements up to it, so indexers have had at least an
+	// interval to ingest them.
+	checkpoint ipld.Link
+
+	loop *periodic.Loop
+}
+
+type Option func(*Checker)
+
+// WithClock sets the clock checks are scheduled by.
+func WithClock(clock clock.Clock) Option {
+	return func(c *Checker) {
+		c.clock = clock
+	}
+}
+
+// WithHTTPClient sets the client indexers are queried with.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Checker) {
+		c.client = client
+	}
+}
+
+// New creates a checker looking up multihashes advertised in publisherStore
+// by provider on the configured indexers.
+func New(provider peer.ID, publisherStore store.PublisherStore, cfg app.IPNICheckConfig, opts ...Option) (*Checker, error) {
+	if len(cfg.IndexerURLs) == 0 {
+		return nil, fmt.Errorf("no indexers to check")
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	sampleSize := int(cfg.SampleSize)
+	if sampleSize <= 0 {
+		sampleSize = DefaultSampleSize
+	}
+	minVisible := cfg.MinVisible
+	if minVisible <= 0 {
+		minVisible = DefaultMinVisible
+	}
+	alertAfter := int(cfg.AlertAfter)
+	if alertAfter <= 0 {
+		alertAfter = DefaultAlertAfter
+	}
+
+	m, err := newMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("creating IPNI check metrics: %w", err)
+	}
+	c := &Checker{
+		provider:   provider,
+		store:      publisherStore,
+		indexers:   cfg.IndexerURLs,
+		client:     http.DefaultClient,
+		interval:   interval,
+		sampleSize: sampleSize,
+		minVisible: minVisible,
+		alertAfter: alertAfter,
+		clock:      clock.New(),
+		metrics:    m,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	for _, u := range c.indexers {
+		c.statuses = append(c.statuses, Status{Indexer: u})
+	}
+	c.loop = periodic.New(c.clock, c.interval, c.runCheck)
+	return c, nil
+}
+
+// Start checks the indexers every interval until stopped.
+func (c *Checker) Start(ctx context.Context) error {
+	return c.loop.Start(ctx)
+}
+
+func (c *Checker) Stop(ctx context.Context) error {
+	return c.loop.Stop(ctx)
+}
+
+func (c *Checker) runCheck(ctx context.Context) {
+	if err := c.Check(ctx); err != nil && ctx.Err() == nil {
+		log.Errorw("checking IPNI advertisements", "error", err)
+	}
+}
+
+// Check looks up a sample of the multihashes advertised before the previous
+// check on every indexer, and records the results. The first check only
+// records the head advertisement to sample from on the next.
+func (c *Checker) Check(ctx context.Context) error {
+	head, err := c.store.Head(ctx)
+	if err != nil && !store.IsNotFound(err) {
+		return fmt.Errorf("getting head advertisement: %w", err)
+	}
+	var current ipld.Link
+	if head != nil {
+		current = head.Head
+	}
+
+	c.mu.RLock()
+	checkpoint := c.checkpoint
+	c.mu.RUnlock()
+
+	sample, err := c.sample(ctx, checkpoint)
+	if err != nil {
+		return err
+	}
+
+	if len(sample) > 0 {
+		results := make([]Status, len(c.indexers))
+		var wg sync.WaitGroup
+		for i, u := range c.indexers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = c.checkIndexer(ctx, u, sample)
+			}()
+		}
+		wg.Wait()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.record(ctx, results)
+	}
+
+	c.mu.Lock()
+	c.checkpoint = current
+	c.mu.Unlock()
+	return nil
+}
+
+// sample returns up to sampleSize multihashes, one from each of the latest
+// advertisements up to and including from. Content withdrawn by a later
+// removal advertisement is not sampled.
+func (c *Checker) sample(ctx context.Context, from ipld.Link) ([]multihash.Multihash, error) {
+	var digests []multihash.Multihash
+	removed := map[string]struct{}{}
+	link := from
+	for range c.sampleSize * maxWalk {
+		if link == nil || len(digests) >= c.sampleSize {
+			break
+		}
+		ad, err := c.store.Advert(ctx, link)
+		if err != nil {
+			return nil, fmt.Errorf("getting advertisement %s: %w", link, err)
+		}
+		link = ad.PreviousID
+
+		if ad.IsRm {
+			removed[string(ad.ContextID)] = struct{}{}
+			continue
+		}
+		if _, ok := removed[string(ad.ContextID)]; ok || ad.Entries == nil || ad.Entries == schema.NoEntries {
+			continue
+		}
+		for digest, err := range c.store.Entries(ctx, ad.Entries) {
+			if err != nil {
+				return nil, fmt.Errorf("getting entries of advertisement: %w", err)
+			}
+			digests = append(digests, digest)
+			break
+		}
+	}
+	return digests, nil
+}
+
+func (c *Checker) checkIndexer(ctx context.Context, indexer url.URL, sample []multihash.Multihash) Status {
+	status := Status{Indexer: indexer, Checked: true, CheckedAt: c.clock.Now()}
+	for _, digest := range sample {
+		lookup := c.lookup(ctx, indexer, digest)
+		status.Lookups = append(status.Lookups, lookup)
+		switch lookup.Result {
+		case ResultVisible:
+			status.Visible++
+			status.Resolved++
+		case ResultMissing, ResultOtherProvider:
+			status.Resolved++
+		}
+	}
+	return status
+}
+
+// findResponse is the subset of an IPNI find response the check needs.
+type findResponse struct {
+	MultihashResults []struct {
+		ProviderResults []struct {
+			Provider *struct {
+				ID string
+			}
+		}
+	}
+}
+
+func (c *Checker) lookup(ctx context.Context, indexer url.URL, digest multihash.Multihash) Lookup {
+	lookup := Lookup{Digest: digest, Result: ResultError}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexer.JoinPath(FindPath, digest.B58String()).String(), nil)
+	if err != nil {
+		lookup.Error = fmt.Sprintf("creating request: %s", err)
+		return lookup
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := c.client.Do(req)
+	if err != nil {
+		lookup.Error = err.Error()
+		return lookup
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		lookup.Result = ResultMissing
+		return lookup
+	default:
+		lookup.Error = fmt.Sprintf("unexpected status: %s", res.Status)
+		return lookup
+	}
+
+	var body findResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, 4<<20)).Decode(&body); err != nil {
+		lookup.Error = fmt.Sprintf("decoding response: %s", err)
+		return lookup
+	}
+	lookup.Result = ResultMissing
+	for _, mr := range body.MultihashResults {
+		for _, pr := range mr.ProviderResults {
+			if pr.Provider == nil {
+				continue
+			}
+			if pr.Provider.ID == c.provider.String() {
+				lookup.Result = ResultVisible
+				return lookup
+			}
+			lookup.Result = ResultOtherProvider
+		}
+	}
+	return lookup
+}
+
+// record stores the results of a check, counting consecutive failures of
+// each indexer. A check with no successful lookup, e.g. because the indexer
+// is unreachable, is inconclusive and leaves the failure count as is.
+func (c *Checker) record(ctx context.Context, results []Status) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, res := range results {
+		prev := c.statuses[i]
+		indexer := res.Indexer.String()
+		switch {
+		case res.Resolved == 0:
+			res.Failures = prev.Failures
+			log.Warnw("IPNI check inconclusive, no lookup succeeded", "indexer", indexer, "error", firstError(res.Lookups))
+		case float64(res.Visible) < c.minVisible*float64(res.Resolved):
+			res.Failures = prev.Failures + 1
+			log.Warnw("advertised multihashes do not resolve to this node", "indexer", indexer, "visible", res.Visible, "sampled", res.Resolved, "missing", missing(res.Lookups))
+		default:
+			res.Failures = 0
+			if prev.Alerting {
+				log.Infow("advertised multihashes resolve to this node again", "indexer", indexer, "visible", res.Visible, "sampled", res.Resolved)
+			}
+		}
+		res.Alerting = res.Failures >= c.alertAfter
+		if res.Alerting {
+			log.Errorw("IPNI announcements are not landing, blobs on this node cannot be found through the indexer: check the announce address and that the node's identity has not changed",
+				"indexer", indexer, "failed_checks", res.Failures, "visible", res.Visible, "sampled", res.Resolved)
+		}
+		c.statuses[i] = res
+
+		attr := attribute.String("indexer", indexer)
+		if res.Resolved > 0 {
+			c.metrics.visible.Record(ctx, int64(res.Visible), attr)
+			c.metrics.sampled.Record(ctx, int64(res.Resolved), attr)
+		}
+		var alerting int64
+		if res.Alerting {
+			alerting = 1
+		}
+		c.metrics.alerting.Record(ctx, alerting, attr)
+	}
+}
+
+// Statuses returns the outcome of the last check of each indexer.
+func (c *Checker) Statuses() []Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.statuses)
+}
+
+func missing(lookups []Lookup) []string {
+	var out []string
+	for _, l := range lookups {
+		if l.Result == ResultMissing || l.Result == ResultOtherProvider {
+			out = append(out, digestutil.Format(l.Digest))
+		}
+	}
+	return out
+}
+
+func firstError(lookups []Lookup) string {
+	for _, l := range lookups {
+		if l.Error != "" {
+			return l.Error
+		}
+	}
+	return ""
+}
@@ -0,0 +1,233 @@
+package ipnicheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-libstoracha/ipnipublisher/store"
+	"github.com/storacha/go-libstoracha/metadata"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/service/publisher"
+)
+
+// fakeIndexer resolves multihashes to the providers set for them.
+type fakeIndexer struct {
+	mu        sync.Mutex
+	providers map[string]string
+	requests  int
+	down      bool
+}
+
+func (f *fakeIndexer) set(digest multihash.Multihash, provider string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.providers[digest.B58String()] = provider
+}
+
+func (f *fakeIndexer) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+func (f *fakeIndexer) requestCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func (f *fakeIndexer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	if f.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+FindPath+"/")
+	provider, found := f.providers[key]
+	if !ok || !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	fmt.Fprintf(w, `{"MultihashResults":[{"ProviderResults":[{"ContextID":"","Metadata":"","Provider":{"ID":%q,"Addrs":[]}}]}]}`, provider)
+}
+
+func newTestIndexer(t *testing.T) (*fakeIndexer, url.URL) {
+	idx := &fakeIndexer{providers: map[string]string{}}
+	srv := httptest.NewServer(idx)
+	t.Cleanup(srv.Close)
+	return idx, *testutil.Must(url.Parse(srv.URL))(t)
+}
+
+func providerID(t *testing.T) peer.ID {
+	priv, err := crypto.UnmarshalEd25519PrivateKey(testutil.Alice.Raw())
+	require.NoError(t, err)
+	id, err := peer.IDFromPrivateKey(priv)
+	require.NoError(t, err)
+	return id
+}
+
+// publishBlob advertises a random blob in space, returning its digest.
+func publishBlob(t *testing.T, pub *publisher.PublisherService, space did.DID) multihash.Multihash {
+	digest := testutil.RandomMultihash(t)
+	location := testutil.Must(url.Parse(fmt.Sprintf("http://localhost:3000/blob/%s", digestutil.Format(digest))))(t)
+	claim, err := assert.Location.Delegate(
+		testutil.Alice,
+		space,
+		testutil.Alice.DID().String(),
+		assert.LocationCaveats{
+			Space:    space,
+			Content:  types.FromHash(digest),
+			Location: []url.URL{*location},
+		},
+		delegation.WithNoExpiration(),
+	)
+	require.NoError(t, err)
+	require.NoError(t, pub.Publish(t.Context(), claim))
+	return digest
+}
+
+func newTestPublisher(t *testing.T) (*publisher.PublisherService, store.PublisherStore) {
+	publisherStore := store.FromDatastore(dssync.MutexWrap(datastore.NewMapDatastore()), store.WithMetadataContext(metadata.MetadataContext))
+	addr, err := multiaddr.NewMultiaddr("/dns4/localhost/tcp/3000/http")
+	require.NoError(t, err)
+	pub, err := publisher.New(testutil.Alice, publisherStore, addr, publisher.WithLogLevel("info"))
+	require.NoError(t, err)
+	return pub, publisherStore
+}
+
+func TestChecker(t *testing.T) {
+	ctx := t.Context()
+	self := providerID(t).String()
+
+	t.Run("samples advertisements published before the previous check", func(t *testing.T) {
+		pub, publisherStore := newTestPublisher(t)
+		idx, indexer := newTestIndexer(t)
+		space := testutil.RandomDID(t)
+
+		c, err := New(providerID(t), publisherStore, app.IPNICheckConfig{IndexerURLs: []url.URL{indexer}})
+		require.NoError(t, err)
+
+		// nothing is advertised yet
+		require.NoError(t, c.Check(ctx))
+		require.False(t, c.Statuses()[0].Checked)
+
+		var digests []multihash.Multihash
+		for range 3 {
+			digest := publishBlob(t, pub, space)
+			idx.set(digest, self)
+			digests = append(digests, digest)
+		}
+
+		// advertisements since the previous check are given until the next
+		require.NoError(t, c.Check(ctx))
+		require.False(t, c.Statuses()[0].Checked)
+		require.Zero(t, idx.requestCount())
+
+		require.NoError(t, c.Check(ctx))
+		status := c.Statuses()[0]
+		require.True(t, status.Checked)
+		require.Equal(t, 3, status.Visible)
+		require.Equal(t, 3, status.Resolved)
+		require.Zero(t, status.Failures)
+		var sampled []multihash.Multihash
+		for _, l := range status.Lookups {
+			require.Equal(t, ResultVisible, l.Result)
+			sampled = append(sampled, l.Digest)
+		}
+		require.ElementsMatch(t, digests, sampled)
+	})
+
+	t.Run("alerts once checks keep failing", func(t *testing.T) {
+		pub, publisherStore := newTestPublisher(t)
+		idx, indexer := newTestIndexer(t)
+		space := testutil.RandomDID(t)
+
+		visible := publishBlob(t, pub, space)
+		idx.set(visible, self)
+		other := publishBlob(t, pub, space)
+		idx.set(other, "12D3KooWSomeOtherProvider")
+		publishBlob(t, pub, space)
+
+		c, err := New(providerID(t), publisherStore, app.IPNICheckConfig{IndexerURLs: []url.URL{indexer}, AlertAfter: 2})
+		require.NoError(t, err)
+		require.NoError(t, c.Check(ctx))
+
+		require.NoError(t, c.Check(ctx))
+		status := c.Statuses()[0]
+		require.Equal(t, 1, status.Visible)
+		require.Equal(t, 3, status.Resolved)
+		require.Equal(t, 1, status.Failures)
+		require.False(t, status.Alerting)
+		results := map[string]Result{}
+		for _, l := range status.Lookups {
+			results[l.Digest.B58String()] = l.Result
+		}
+		require.Equal(t, ResultOtherProvider, results[other.B58String()])
+
+		require.NoError(t, c.Check(ctx))
+		require.True(t, c.Statuses()[0].Alerting)
+
+		// an unreachable indexer is inconclusive
+		idx.setDown(true)
+		require.NoError(t, c.Check(ctx))
+		status = c.Statuses()[0]
+		require.Zero(t, status.Resolved)
+		require.Equal(t, 2, status.Failures)
+		require.True(t, status.Alerting)
+
+		// the alert clears once the advertisements resolve
+		idx.setDown(false)
+		for _, l := range status.Lookups {
+			idx.set(l.Digest, self)
+		}
+		require.NoError(t, c.Check(ctx))
+		status = c.Statuses()[0]
+		require.Zero(t, status.Failures)
+		require.False(t, status.Alerting)
+	})
+}
+
+func TestLookup(t *testing.T) {
+	digest := testutil.RandomMultihash(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/"+FindPath+"/"+digest.B58String(), r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Accept"))
+		w.WriteHeader(http.StatusOK)
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"MultihashResults": []any{map[string]any{
+				"Multihash":       digest,
+				"ProviderResults": []any{map[string]any{"Provider": map[string]any{"ID": providerID(t).String()}}},
+			}},
+		}))
+	}))
+	t.Cleanup(srv.Close)
+	indexer := *testutil.Must(url.Parse(srv.URL))(t)
+
+	c, err := New(providerID(t), nil, app.IPNICheckConfig{IndexerURLs: []url.URL{indexer}, Interval: time.Minute})
+	require.NoError(t, err)
+	lookup := c.lookup(t.Context(), indexer, digest)
+	require.Equal(t, ResultVisible, lookup.Result, lookup.Error)
+}
@@ -0,0 +1,58 @@
+package ipnicheck
+
+import (
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/ipnipublisher/store"
+	"github.com/storacha/go-ucanto/principal"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/hwsigner"
+	"github.com/storacha/piri/pkg/internal/periodic"
+)
+
+var Module = fx.Module("ipnicheck",
+	fx.Provide(
+		NewCheckerFromParams,
+	),
+	fx.Invoke(func(*Checker) {}),
+)
+
+type Params struct {
+	fx.In
+
+	UCANCfg        app.UCANServiceConfig
+	ID             principal.Signer
+	PublisherStore store.PublisherStore
+	Clock          clock.Clock
+}
+
+// NewCheckerFromParams creates the checker of advertisements, nil if the
+// check is disabled or there is no indexer to check.
+func NewCheckerFromParams(lc fx.Lifecycle, params Params) (*Checker, error) {
+	cfg := params.UCANCfg.IPNICheck
+	if !cfg.Enabled || len(cfg.IndexerURLs) == 0 {
+		return nil, nil
+	}
+
+	// advertisements are published under the peer ID of the node's key
+	priv, err := hwsigner.PrivKey(params.ID)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("creating libp2p peer ID from private key: %w", err)
+	}
+
+	c, err := New(provider, params.PublisherStore, cfg, WithClock(params.Clock))
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(periodic.Hook(c))
+	return c, nil
+}
@@ -0,0 +1,45 @@
+package ipnicheck
+
+import (
+	"go.opentelemetry.io/otel"
+
+	"github.com/storacha/piri/lib/telemetry"
+)
+
+type metrics struct {
+	visible  *telemetry.Int64Gauge
+	sampled  *telemetry.Int64Gauge
+	alerting *telemetry.Int64Gauge
+}
+
+func newMetrics() (*metrics, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/service/ipnicheck")
+	visible, err := telemetry.NewInt64Gauge(
+		meter,
+		"piri_ipni_visible",
+		"sampled advertised multihashes resolving to this node in the last check, by indexer",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	sampled, err := telemetry.NewInt64Gauge(
+		meter,
+		"piri_ipni_sampled",
+		"advertised multihashes looked up in the last check, by indexer",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	alerting, err := telemetry.NewInt64Gauge(
+		meter,
+		"piri_ipni_visibility_alerting",
+		"1 if advertisements repeatedly failed to resolve to this node on the indexer, else 0",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &metrics{visible: visible, sampled: sampled, alerting: alerting}, nil
+}
@@ -0,0 +1,278 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/ipni/go-libipni/ingest/schema"
+	ipnimeta "github.com/ipni/go-libipni/metadata"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+	ipnipub "github.com/storacha/go-libstoracha/ipnipublisher/publisher"
+	"github.com/storacha/go-libstoracha/ipnipublisher/store"
+)
+
+// batchPublisher publishes IPNI advertisements in batches, as the batch
+// publisher of the hosted storage nodes does. An advertisement is generated,
+// and its entries stored, when a claim is published, but it is only added to
+// the advertisement chain when its batch is committed: the head is replaced
+// and indexers are announced to once per batch rather than once per blob.
+//
+// A batch is committed when it holds maxSize advertisements, or by the
+// background flusher maxLatency after its first advertisement was added.
+//
+// The advertisements of the batch are kept in a datastore until committed.
+// Their entries are already stored, and the digests they advertise recorded
+// as advertised, so an advertisement lost before the batch is committed would
+// never be published. Advertisements left by a node that stopped before
+// committing are batched again when it restarts, and a batch that fails to
+// commit is kept to be committed with the next one.
+type batchPublisher struct {
+	publisher  *ipnipub.AdvertisementPublisher
+	store      store.PublisherStore
+	ds         datastore.Datastore
+	maxSize    int
+	maxLatency time.Duration
+
+	mu sync.Mutex
+	// ads are the advertisements of the batch, and keys their keys in ds.
+	ads  []schema.Advertisement
+	keys []datastore.Key
+	seq  uint64
+	// started signals the first advertisement of a batch to the flusher.
+	started chan struct{}
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var _ ipnipub.AsyncPublisher = (*batchPublisher)(nil)
+
+func newBatchPublisher(ctx context.Context, key crypto.PrivKey, publisherStore store.PublisherStore, ds datastore.Datastore, maxSize int, maxLatency time.Duration, opts ...ipnipub.Option) (*batchPublisher, error) {
+	p, err := ipnipub.NewAdvertisementPublisher(key, publisherStore, opts...)
+	if err != nil {
+		return nil, err
+	}
+	bp := &batchPublisher{
+		publisher:  p,
+		store:      publisherStore,
+		ds:         ds,
+		maxSize:    maxSize,
+		maxLatency: maxLatency,
+		started:    make(chan struct{}, 1),
+	}
+	if err := bp.load(ctx); err != nil {
+		return nil, err
+	}
+	return bp, nil
+}
+
+// load batches the advertisements left uncommitted when the node stopped.
+func (p *batchPublisher) load(ctx context.Context) error {
+	results, err := p.ds.Query(ctx, query.Query{Orders: []query.Order{query.OrderByKey{}}})
+	if err != nil {
+		return fmt.Errorf("querying batched advertisements: %w", err)
+	}
+	defer results.Close()
+	for r := range results.Next() {
+		if r.Error != nil {
+			return fmt.Errorf("reading batched advertisements: %w", r.Error)
+		}
+		node, err := ipld.DecodeUsingPrototype(r.Value, dagjson.Decode, schema.AdvertisementPrototype)
+		if err != nil {
+			return fmt.Errorf("decoding batched advertisement %s: %w", r.Key, err)
+		}
+		ad, err := schema.UnwrapAdvertisement(node)
+		if err != nil {
+			return fmt.Errorf("decoding batched advertisement %s: %w", r.Key, err)
+		}
+		k := datastore.NewKey(r.Key)
+		seq, err := strconv.ParseUint(k.BaseNamespace(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("parsing batched advertisement key %s: %w", r.Key, err)
+		}
+		if err := p.publisher.AddToBatch(*ad); err != nil {
+			return fmt.Errorf("adding advertisement to batch: %w", err)
+		}
+		p.ads = append(p.ads, *ad)
+		p.keys = append(p.keys, k)
+		p.seq = seq + 1
+	}
+	if len(p.ads) > 0 {
+		log.Infow("Batched advertisements left uncommitted", "count", len(p.ads))
+		p.signal()
+	}
+	return nil
+}
+
+// Publish adds an advertisement of the digests to the batch. It returns
+// [ipnipub.ErrAlreadyAdvertised] if the digests are already advertised with
+// the same metadata.
+func (p *batchPublisher) Publish(ctx context.Context, provider peer.AddrInfo, contextID string, digests iter.Seq[multihash.Multihash], meta ipnimeta.Metadata) error {
+	return p.add(ctx, provider, contextID, false, digests, meta)
+}
+
+// Remove adds a removal advertisement for the context ID to the batch. It is
+// a no-op if nothing was advertised for the context ID.
+func (p *batchPublisher) Remove(ctx context.Context, provider peer.AddrInfo, contextID string) error {
+	err := p.add(ctx, provider, contextID, true, nil, ipnimeta.Metadata{})
+	if errors.Is(err, ipnipub.ErrContextIDNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (p *batchPublisher) add(ctx context.Context, provider peer.AddrInfo, contextID string, isRm bool, digests iter.Seq[multihash.Multihash], meta ipnimeta.Metadata) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ad, err := ipnipub.GenerateAd(ctx, p.store, provider.ID, provider.Addrs, []byte(contextID), meta, isRm, digests)
+	if err != nil {
+		return err
+	}
+	node, err := ad.ToNode()
+	if err != nil {
+		return fmt.Errorf("encoding advertisement: %w", err)
+	}
+	data, err := ipld.Encode(node, dagjson.Encode)
+	if err != nil {
+		return fmt.Errorf("encoding advertisement: %w", err)
+	}
+	// zero padded, so keys sort in the order advertisements were added
+	key := datastore.NewKey(fmt.Sprintf("%020d", p.seq))
+	if err := p.ds.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("storing batched advertisement: %w", err)
+	}
+	p.seq++
+	if err := p.publisher.AddToBatch(ad); err != nil {
+		return fmt.Errorf("adding advertisement to batch: %w", err)
+	}
+	p.ads = append(p.ads, ad)
+	p.keys = append(p.keys, key)
+	if len(p.ads) >= p.maxSize {
+		return p.commit(ctx)
+	}
+	if len(p.ads) == 1 {
+		p.signal()
+	}
+	return nil
+}
+
+// signal has the flusher commit the batch after the max latency.
+func (p *batchPublisher) signal() {
+	select {
+	case p.started <- struct{}{}:
+	default:
+	}
+}
+
+// commit adds the advertisements of the batch to the advertisement chain and
+// announces the new head. A batch that fails to commit is kept. The caller
+// must hold the lock.
+func (p *batchPublisher) commit(ctx context.Context) error {
+	n := len(p.ads)
+	if n == 0 {
+		return nil
+	}
+	if _, err := p.publisher.Commit(ctx); err != nil {
+		err = fmt.Errorf("committing batch of %d advertisements: %w", n, err)
+		if rerr := p.requeue(ctx); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+		// retry with the next flush
+		p.signal()
+		return err
+	}
+	for _, k := range p.keys {
+		if err := p.ds.Delete(ctx, k); err != nil {
+			log.Errorw("Deleting committed advertisement", "key", k, "error", err)
+		}
+	}
+	p.ads, p.keys = nil, nil
+	log.Infow("Published batch of advertisements", "count", n)
+	return nil
+}
+
+// requeue adds the advertisements of a batch that failed to commit back to
+// the batch. The advertisement publisher drops a failed batch and forgets the
+// entries of its advertisements, so the entries are recorded again, in the
+// order the advertisements were generated.
+func (p *batchPublisher) requeue(ctx context.Context) error {
+	for _, ad := range p.ads {
+		provider, err := peer.Decode(ad.Provider)
+		if err != nil {
+			return fmt.Errorf("decoding provider of batched advertisement: %w", err)
+		}
+		if ad.IsRm {
+			err = p.store.DeleteChunkLinkForProviderAndContextID(ctx, provider, ad.ContextID)
+		} else {
+			err = p.store.PutChunkLinkForProviderAndContextID(ctx, provider, ad.ContextID, ad.Entries)
+		}
+		if err != nil && !store.IsNotFound(err) {
+			return fmt.Errorf("restoring entries of batched advertisement: %w", err)
+		}
+		if err := p.publisher.AddToBatch(ad); err != nil {
+			return fmt.Errorf("adding advertisement to batch: %w", err)
+		}
+	}
+	return nil
+}
+
+// Flush commits the advertisements batched so far.
+func (p *batchPublisher) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.commit(ctx)
+}
+
+// Start starts the background flusher.
+func (p *batchPublisher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	go p.run(ctx)
+}
+
+// Stop stops the background flusher and commits the advertisements batched
+// so far.
+func (p *batchPublisher) Stop(ctx context.Context) error {
+	if p.cancel != nil {
+		p.cancel()
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return p.Flush(ctx)
+}
+
+func (p *batchPublisher) run(ctx context.Context) {
+	defer close(p.done)
+
+	timer := time.NewTimer(p.maxLatency)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.started:
+			timer.Reset(p.maxLatency)
+		case <-timer.C:
+			if err := p.Flush(ctx); err != nil && ctx.Err() == nil {
+				log.Errorw("Flushing advertisements", "error", err)
+			}
+		}
+	}
+}
@@ -3,8 +3,10 @@ package publisher
 import (
 	"context"
 
+	"github.com/multiformats/go-multihash"
 	"github.com/storacha/go-libstoracha/ipnipublisher/store"
 	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
 )
 
 type Publisher interface {
@@ -13,4 +15,7 @@ type Publisher interface {
 	// Publish advertises content claims/commitments found on this node to the
 	// storacha network.
 	Publish(context.Context, delegation.Delegation) error
+	// Retract withdraws the advertisement of a blob (digest) in a space, so
+	// the blob is no longer found on this node through IPNI.
+	Retract(context.Context, did.DID, multihash.Multihash) error
 }
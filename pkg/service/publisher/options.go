@@ -1,8 +1,11 @@
 package publisher
 
 import (
+	"fmt"
 	"net/url"
+	"time"
 
+	"github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/multiformats/go-multiaddr"
 	ipnipub "github.com/storacha/go-libstoracha/ipnipublisher/publisher"
@@ -15,10 +18,15 @@ import (
 type options struct {
 	asyncPublisher        ipnipub.AsyncPublisher
 	blobAddr              multiaddr.Multiaddr
+	peerAddrs             []multiaddr.Multiaddr
 	announceAddr          multiaddr.Multiaddr
 	announceURLs          []url.URL
 	indexingService       client.Connection
 	indexingServiceProofs delegation.Proofs
+	publicURLs            func() []url.URL
+	batchSize             int
+	batchLatency          time.Duration
+	batchDatastore        datastore.Datastore
 }
 
 type Option func(*options) error
@@ -31,6 +39,26 @@ func WithAsyncPublisher(p ipnipub.AsyncPublisher) Option {
 	}
 }
 
+// WithBatching publishes advertisements in batches of up to maxSize, each
+// committed to the advertisement chain and announced at most maxLatency after
+// its first advertisement. A maxSize of 1 or less publishes each advertisement
+// as the claim is published. Batches are only committed by the background
+// flusher once the service is started.
+//
+// Advertisements are kept in ds until their batch is committed, so they are
+// published after a restart. A nil ds keeps them in memory.
+func WithBatching(maxSize int, maxLatency time.Duration, ds datastore.Datastore) Option {
+	return func(o *options) error {
+		if maxSize > 1 && maxLatency <= 0 {
+			return fmt.Errorf("batch latency must be greater than zero")
+		}
+		o.batchSize = maxSize
+		o.batchLatency = maxLatency
+		o.batchDatastore = ds
+		return nil
+	}
+}
+
 // WithAnnounceAddress sets the address put into announce messages to tell
 // indexers where to fetch advertisements from.
 func WithAnnounceAddress(addr multiaddr.Multiaddr) Option {
@@ -48,6 +76,25 @@ func WithBlobAddress(addr multiaddr.Multiaddr) Option {
 	}
 }
 
+// WithPeerAddresses adds libp2p addresses blobs can also be fetched from,
+// e.g. over Bitswap, to the provider addresses advertised to indexers.
+func WithPeerAddresses(addrs ...multiaddr.Multiaddr) Option {
+	return func(o *options) error {
+		o.peerAddrs = append(o.peerAddrs, addrs...)
+		return nil
+	}
+}
+
+// WithPublicURLs sets the source of the public URLs of the node, ordered by
+// reachability. Provider addresses on the public address are advertised on
+// each of the URLs it returns when a claim is published.
+func WithPublicURLs(urls func() []url.URL) Option {
+	return func(o *options) error {
+		o.publicURLs = urls
+		return nil
+	}
+}
+
 // WithDirectAnnounce sets indexer URLs to send direct HTTP announcements to.
 func WithDirectAnnounce(announceURLs ...url.URL) Option {
 	return func(o *options) error {
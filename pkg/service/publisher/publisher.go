@@ -5,21 +5,26 @@ import (
 	"errors"
 	"fmt"
 	"iter"
+	"net/url"
 	"slices"
+	"strings"
 	"sync"
 
 	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/ipld/go-ipld-prime"
 	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipni/go-libipni/maurl"
 	ipnimeta "github.com/ipni/go-libipni/metadata"
-	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/multiformats/go-multihash"
 	"github.com/storacha/go-libstoracha/advertisement"
 	"github.com/storacha/go-libstoracha/capabilities/assert"
 	"github.com/storacha/go-libstoracha/capabilities/claim"
+	"github.com/storacha/go-libstoracha/digestutil"
 	ipnipub "github.com/storacha/go-libstoracha/ipnipublisher/publisher"
 	"github.com/storacha/go-libstoracha/ipnipublisher/store"
 	"github.com/storacha/go-libstoracha/metadata"
@@ -29,8 +34,10 @@ import (
 	"github.com/storacha/go-ucanto/core/receipt"
 	"github.com/storacha/go-ucanto/core/result"
 	"github.com/storacha/go-ucanto/core/result/ok"
+	"github.com/storacha/go-ucanto/did"
 	"github.com/storacha/go-ucanto/principal"
 	"github.com/storacha/piri/lib"
+	"github.com/storacha/piri/pkg/hwsigner"
 )
 
 type threadSafeAsyncPublisher struct {
@@ -44,6 +51,26 @@ func (p *threadSafeAsyncPublisher) Publish(ctx context.Context, pi peer.AddrInfo
 	return p.AsyncPublisher.Publish(ctx, pi, contextID, digests, meta)
 }
 
+// Remove publishes a removal advertisement for the context ID, if the wrapped
+// publisher supports it.
+func (p *threadSafeAsyncPublisher) Remove(ctx context.Context, pi peer.AddrInfo, contextID string) error {
+	r, ok := p.AsyncPublisher.(advertRemover)
+	if !ok {
+		return errRemoveUnsupported
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return r.Remove(ctx, pi, contextID)
+}
+
+// advertRemover is implemented by IPNI publishers that can publish removal
+// advertisements.
+type advertRemover interface {
+	Remove(ctx context.Context, provider peer.AddrInfo, contextID string) error
+}
+
+var errRemoveUnsupported = errors.New("IPNI publisher does not support removal advertisements")
+
 var log = logging.Logger("publisher")
 
 type PublisherService struct {
@@ -51,8 +78,11 @@ type PublisherService struct {
 	store                 store.PublisherStore
 	asyncPublisher        ipnipub.AsyncPublisher
 	provider              peer.AddrInfo
+	publicAddr            multiaddr.Multiaddr
+	publicURLs            func() []url.URL
 	indexingService       client.Connection
 	indexingServiceProofs delegation.Proofs
+	batch                 *batchPublisher
 }
 
 func (pub *PublisherService) Store() store.PublisherStore {
@@ -63,16 +93,39 @@ func (pub *PublisherService) Publish(ctx context.Context, claim delegation.Deleg
 	ability := claim.Capabilities()[0].Can()
 	switch ability {
 	case assert.LocationAbility:
-		err := PublishLocationCommitment(ctx, pub.asyncPublisher, pub.provider, claim)
+		provider := pub.providerInfo()
+		err := PublishLocationCommitment(ctx, pub.asyncPublisher, provider, claim)
 		if err != nil {
 			return err
 		}
-		return CacheClaim(ctx, pub.id, pub.indexingService, pub.indexingServiceProofs, claim, pub.provider.Addrs)
+		return CacheClaim(ctx, pub.id, pub.indexingService, pub.indexingServiceProofs, claim, provider.Addrs)
 	default:
 		return fmt.Errorf("unknown claim: %s", ability)
 	}
 }
 
+// Retract publishes a removal advertisement for the blob in the space. The
+// removal is skipped, with a warning, if the IPNI publisher cannot publish
+// removals: the advertisement then lapses with the location commitment.
+func (pub *PublisherService) Retract(ctx context.Context, space did.DID, digest multihash.Multihash) error {
+	contextid, err := advertisement.EncodeContextID(space, digest)
+	if err != nil {
+		return fmt.Errorf("encoding advertisement context ID: %w", err)
+	}
+	r, ok := pub.asyncPublisher.(advertRemover)
+	if ok {
+		err = r.Remove(ctx, pub.provider, string(contextid))
+	}
+	if !ok || errors.Is(err, errRemoveUnsupported) {
+		log.Warnw("Not retracting advertisement, removals are not supported by the IPNI publisher", "space", space, "digest", digestutil.Format(digest))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("retracting advertisement: %w", err)
+	}
+	return nil
+}
+
 func PublishLocationCommitment(
 	ctx context.Context,
 	asyncPublisher ipnipub.AsyncPublisher,
@@ -188,7 +241,7 @@ func CacheClaim(
 	return result.MatchResultR1(
 		rcpt.Out(),
 		func(ok ok.Unit) error {
-			log.Info("Cached location commitment with indexing service")
+			log.Info("Cached claim with indexing service")
 			return nil
 		},
 		func(node ipld.Node) error {
@@ -237,12 +290,13 @@ func New(
 			return nil, err
 		}
 	}
-	priv, err := crypto.UnmarshalEd25519PrivateKey(id.Raw())
+	priv, err := hwsigner.PrivKey(id)
 	if err != nil {
-		return nil, fmt.Errorf("unmarshaling private key: %w", err)
+		return nil, err
 	}
 
 	asyncPublisher := o.asyncPublisher
+	var batch *batchPublisher
 	if asyncPublisher == nil {
 
 		announceAddr := o.announceAddr
@@ -255,12 +309,23 @@ func New(
 			log.Infof("Announcing new IPNI adverts to: %s", u.String())
 			ipnipubOpts = append(ipnipubOpts, ipnipub.WithDirectAnnounce(u.String()))
 		}
-		ipniPublisher, err := ipnipub.New(priv, publisherStore, ipnipubOpts...)
-		if err != nil {
-			return nil, fmt.Errorf("creating IPNI publisher instance: %w", err)
+		if o.batchSize > 1 {
+			ds := o.batchDatastore
+			if ds == nil {
+				ds = dssync.MutexWrap(datastore.NewMapDatastore())
+			}
+			batch, err = newBatchPublisher(context.Background(), priv, publisherStore, ds, o.batchSize, o.batchLatency, ipnipubOpts...)
+			if err != nil {
+				return nil, fmt.Errorf("creating IPNI batch publisher instance: %w", err)
+			}
+			asyncPublisher = batch
+		} else {
+			ipniPublisher, err := ipnipub.New(priv, publisherStore, ipnipubOpts...)
+			if err != nil {
+				return nil, fmt.Errorf("creating IPNI publisher instance: %w", err)
+			}
+			asyncPublisher = &threadSafeAsyncPublisher{AsyncPublisher: ipnipub.AsyncFrom(ipniPublisher)}
 		}
-
-		asyncPublisher = &threadSafeAsyncPublisher{AsyncPublisher: ipnipub.AsyncFrom(ipniPublisher)}
 	}
 
 	found := false
@@ -282,6 +347,7 @@ func New(
 	if err != nil {
 		return nil, fmt.Errorf("building provider info: %w", err)
 	}
+	provInfo.Addrs = append(provInfo.Addrs, o.peerAddrs...)
 
 	if o.indexingService == nil {
 		log.Errorf("Indexing service is not configured - claims will not be cached")
@@ -292,11 +358,107 @@ func New(
 		store:                 publisherStore,
 		asyncPublisher:        asyncPublisher,
 		provider:              provInfo,
+		publicAddr:            publicAddr,
+		publicURLs:            o.publicURLs,
 		indexingService:       o.indexingService,
 		indexingServiceProofs: o.indexingServiceProofs,
+		batch:                 batch,
 	}, nil
 }
 
+// Start starts the flusher of batched advertisements, if advertisements are
+// published in batches.
+func (pub *PublisherService) Start() {
+	if pub.batch != nil {
+		pub.batch.Start()
+	}
+}
+
+// Stop stops the flusher of batched advertisements and publishes the
+// advertisements batched so far.
+func (pub *PublisherService) Stop(ctx context.Context) error {
+	if pub.batch == nil {
+		return nil
+	}
+	return pub.batch.Stop(ctx)
+}
+
+// providerInfo returns the provider to advertise claims under. Addresses on
+// the public address are repeated on each public URL of the node, in the
+// order the URLs are given.
+func (pub *PublisherService) providerInfo() peer.AddrInfo {
+	if pub.publicURLs == nil {
+		return pub.provider
+	}
+	var bases []multiaddr.Multiaddr
+	for _, u := range pub.publicURLs() {
+		addr, err := maurl.FromURL(&u)
+		if err != nil {
+			log.Warnw("converting public URL to multiaddr", "url", u.String(), "error", err)
+			continue
+		}
+		bases = append(bases, addr)
+	}
+	if len(bases) == 0 {
+		return pub.provider
+	}
+
+	provider := peer.AddrInfo{ID: pub.provider.ID}
+	for _, addr := range pub.provider.Addrs {
+		path, ok := relativeHTTPPath(pub.publicAddr, addr)
+		if !ok {
+			provider.Addrs = append(provider.Addrs, addr)
+			continue
+		}
+		for _, base := range bases {
+			a, err := lib.JoinHTTPPath(base, path)
+			if err != nil {
+				log.Warnw("joining path to public multiaddr", "addr", base, "path", path, "error", err)
+				continue
+			}
+			provider.Addrs = append(provider.Addrs, a)
+		}
+	}
+	return provider
+}
+
+// relativeHTTPPath returns the HTTP path of addr relative to base, if addr is
+// on base.
+func relativeHTTPPath(base, addr multiaddr.Multiaddr) (string, bool) {
+	baseTransport, basePath, err := splitHTTPPath(base)
+	if err != nil {
+		return "", false
+	}
+	transport, path, err := splitHTTPPath(addr)
+	if err != nil || !transport.Equal(baseTransport) {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(path, basePath)
+	if !ok || (basePath != "" && rest != "" && !strings.HasPrefix(rest, "/")) {
+		return "", false
+	}
+	return strings.TrimPrefix(rest, "/"), true
+}
+
+// splitHTTPPath splits addr into its transport and its unescaped HTTP path,
+// without leading or trailing slashes.
+func splitHTTPPath(addr multiaddr.Multiaddr) (multiaddr.Multiaddr, string, error) {
+	var transport multiaddr.Multiaddr
+	var path string
+	for _, comp := range addr {
+		if comp.Code() == multiaddr.P_HTTP_PATH {
+			p, err := url.PathUnescape(comp.Value())
+			if err != nil {
+				return nil, "", err
+			}
+			path = strings.Trim(p, "/")
+			continue
+		}
+		transport = append(transport, comp)
+	}
+	return transport, path, nil
+}
+
 func providerInfo(peerID peer.ID, publicAddr multiaddr.Multiaddr, blobAddr multiaddr.Multiaddr) (peer.AddrInfo, error) {
 	provider := peer.AddrInfo{ID: peerID}
 	if blobAddr == nil {
@@ -2,13 +2,19 @@ package publisher
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 	dssync "github.com/ipfs/go-datastore/sync"
 
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipni/go-libipni/dagsync/ipnisync/head"
+	"github.com/ipni/go-libipni/maurl"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/multiformats/go-multihash"
 	"github.com/storacha/go-libstoracha/capabilities/assert"
@@ -28,6 +34,7 @@ import (
 	"github.com/storacha/go-ucanto/principal"
 	"github.com/storacha/go-ucanto/server"
 	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/piri/lib"
 	"github.com/storacha/piri/pkg/service/publisher/advertisement"
 	"github.com/stretchr/testify/require"
 )
@@ -129,6 +136,231 @@ func TestPublisherService(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("advertises peer addresses", func(t *testing.T) {
+		dstore := dssync.MutexWrap(datastore.NewMapDatastore())
+		publisherStore := store.FromDatastore(dstore, store.WithMetadataContext(metadata.MetadataContext))
+		peerAddr, err := multiaddr.NewMultiaddr("/ip4/203.0.113.1/tcp/4001")
+		require.NoError(t, err)
+
+		svc, err := New(testutil.Alice, publisherStore, addr, WithPeerAddresses(peerAddr), WithLogLevel("info"))
+		require.NoError(t, err)
+
+		require.Len(t, svc.provider.Addrs, 3)
+		require.True(t, svc.provider.Addrs[2].Equal(peerAddr))
+	})
+
+	t.Run("advertises addresses on each public URL", func(t *testing.T) {
+		dstore := dssync.MutexWrap(datastore.NewMapDatastore())
+		publisherStore := store.FromDatastore(dstore, store.WithMetadataContext(metadata.MetadataContext))
+		peerAddr, err := multiaddr.NewMultiaddr("/ip4/203.0.113.1/tcp/4001")
+		require.NoError(t, err)
+		urls := []url.URL{
+			*testutil.Must(url.Parse("http://[2001:db8::1]:3000"))(t),
+			*testutil.Must(url.Parse("http://localhost:3000"))(t),
+		}
+
+		svc, err := New(
+			testutil.Alice,
+			publisherStore,
+			addr,
+			WithPeerAddresses(peerAddr),
+			WithPublicURLs(func() []url.URL { return urls }),
+			WithLogLevel("info"),
+		)
+		require.NoError(t, err)
+
+		var expected []multiaddr.Multiaddr
+		for _, path := range []string{"blob/{blob}", "claim/{claim}"} {
+			for _, u := range urls {
+				base, err := maurl.FromURL(&u)
+				require.NoError(t, err)
+				a, err := lib.JoinHTTPPath(base, path)
+				require.NoError(t, err)
+				expected = append(expected, a)
+			}
+		}
+		expected = append(expected, peerAddr)
+
+		provider := svc.providerInfo()
+		require.Len(t, provider.Addrs, len(expected))
+		for i, a := range expected {
+			require.True(t, provider.Addrs[i].Equal(a), "expected %s, got %s", a, provider.Addrs[i])
+		}
+
+		// addresses not on the public address are advertised once
+		urls = urls[:1]
+		provider = svc.providerInfo()
+		require.Len(t, provider.Addrs, 3)
+		require.True(t, provider.Addrs[2].Equal(peerAddr))
+	})
+
+	t.Run("publishes advertisements in batches", func(t *testing.T) {
+		dstore := dssync.MutexWrap(datastore.NewMapDatastore())
+		publisherStore := store.FromDatastore(dstore, store.WithMetadataContext(metadata.MetadataContext))
+
+		svc, err := New(testutil.Alice, publisherStore, addr, WithBatching(3, time.Hour, nil), WithLogLevel("info"))
+		require.NoError(t, err)
+		svc.Start()
+
+		locationClaim := func(t *testing.T) delegation.Delegation {
+			space := testutil.RandomDID(t)
+			shard := testutil.RandomMultihash(t)
+			location := testutil.Must(url.Parse(fmt.Sprintf("http://localhost:3000/blob/%s", digestutil.Format(shard))))(t)
+			claim, err := assert.Location.Delegate(
+				testutil.Alice,
+				space,
+				testutil.Alice.DID().String(),
+				assert.LocationCaveats{
+					Space:    space,
+					Content:  types.FromHash(shard),
+					Location: []url.URL{*location},
+				},
+				delegation.WithNoExpiration(),
+			)
+			require.NoError(t, err)
+			return claim
+		}
+
+		// the chain is not extended until the batch is full
+		for range 2 {
+			require.NoError(t, svc.Publish(ctx, locationClaim(t)))
+		}
+		_, err = publisherStore.Head(ctx)
+		require.True(t, store.IsNotFound(err))
+
+		require.NoError(t, svc.Publish(ctx, locationClaim(t)))
+		hd, err := publisherStore.Head(ctx)
+		require.NoError(t, err)
+		ads := 0
+		for link := hd.Head; link != nil; ads++ {
+			ad, err := publisherStore.Advert(ctx, link)
+			require.NoError(t, err)
+			link = ad.PreviousID
+		}
+		require.Equal(t, 3, ads)
+
+		// stopping publishes the advertisements still batched
+		require.NoError(t, svc.Publish(ctx, locationClaim(t)))
+		require.NoError(t, svc.Stop(ctx))
+		next, err := publisherStore.Head(ctx)
+		require.NoError(t, err)
+		ad, err := publisherStore.Advert(ctx, next.Head)
+		require.NoError(t, err)
+		require.Equal(t, hd.Head.String(), ad.PreviousID.String())
+	})
+
+	t.Run("flushes batches after the max latency", func(t *testing.T) {
+		dstore := dssync.MutexWrap(datastore.NewMapDatastore())
+		publisherStore := store.FromDatastore(dstore, store.WithMetadataContext(metadata.MetadataContext))
+
+		svc, err := New(testutil.Alice, publisherStore, addr, WithBatching(100, 10*time.Millisecond, nil), WithLogLevel("info"))
+		require.NoError(t, err)
+		svc.Start()
+		t.Cleanup(func() { require.NoError(t, svc.Stop(context.Background())) })
+
+		space := testutil.RandomDID(t)
+		shard := testutil.RandomMultihash(t)
+		location := testutil.Must(url.Parse(fmt.Sprintf("http://localhost:3000/blob/%s", digestutil.Format(shard))))(t)
+		claim, err := assert.Location.Delegate(
+			testutil.Alice,
+			space,
+			testutil.Alice.DID().String(),
+			assert.LocationCaveats{
+				Space:    space,
+				Content:  types.FromHash(shard),
+				Location: []url.URL{*location},
+			},
+			delegation.WithNoExpiration(),
+		)
+		require.NoError(t, err)
+		require.NoError(t, svc.Publish(ctx, claim))
+
+		require.Eventually(t, func() bool {
+			_, err := publisherStore.Head(ctx)
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+
+		// retracting is batched too
+		require.NoError(t, svc.Retract(ctx, space, shard))
+		require.Eventually(t, func() bool {
+			hd, err := publisherStore.Head(ctx)
+			if err != nil {
+				return false
+			}
+			ad, err := publisherStore.Advert(ctx, hd.Head)
+			return err == nil && ad.IsRm
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("batches removal advertisements", func(t *testing.T) {
+		dstore := dssync.MutexWrap(datastore.NewMapDatastore())
+		publisherStore := store.FromDatastore(dstore, store.WithMetadataContext(metadata.MetadataContext))
+
+		svc, err := New(testutil.Alice, publisherStore, addr, WithBatching(2, time.Hour, nil), WithLogLevel("info"))
+		require.NoError(t, err)
+
+		claim := randomLocationClaim(t)
+		require.NoError(t, svc.Publish(ctx, claim))
+		nb, err := assert.LocationCaveatsReader.Read(claim.Capabilities()[0].Nb())
+		require.NoError(t, err)
+
+		// removing a blob that was never advertised adds nothing to the batch
+		require.NoError(t, svc.Retract(ctx, nb.Space, testutil.RandomMultihash(t)))
+		_, err = publisherStore.Head(ctx)
+		require.True(t, store.IsNotFound(err))
+
+		// the removal fills the batch, which is committed
+		require.NoError(t, svc.Retract(ctx, nb.Space, nb.Content.Hash()))
+		require.Equal(t, 2, countAdverts(t, publisherStore))
+		hd, err := publisherStore.Head(ctx)
+		require.NoError(t, err)
+		ad, err := publisherStore.Advert(ctx, hd.Head)
+		require.NoError(t, err)
+		require.True(t, ad.IsRm)
+		require.NoError(t, svc.Stop(ctx))
+	})
+
+	t.Run("publishes batched advertisements after a restart", func(t *testing.T) {
+		dstore := dssync.MutexWrap(datastore.NewMapDatastore())
+		publisherStore := store.FromDatastore(dstore, store.WithMetadataContext(metadata.MetadataContext))
+		batchStore := dssync.MutexWrap(datastore.NewMapDatastore())
+
+		svc, err := New(testutil.Alice, publisherStore, addr, WithBatching(100, time.Hour, batchStore), WithLogLevel("info"))
+		require.NoError(t, err)
+		for range 2 {
+			require.NoError(t, svc.Publish(ctx, randomLocationClaim(t)))
+		}
+		// the node stops without committing the batch
+
+		svc, err = New(testutil.Alice, publisherStore, addr, WithBatching(100, time.Hour, batchStore), WithLogLevel("info"))
+		require.NoError(t, err)
+		require.NoError(t, svc.Publish(ctx, randomLocationClaim(t)))
+		require.NoError(t, svc.Stop(ctx))
+
+		require.Equal(t, 3, countAdverts(t, publisherStore))
+		keys, err := batchStore.Query(ctx, query.Query{KeysOnly: true})
+		require.NoError(t, err)
+		rest, err := keys.Rest()
+		require.NoError(t, err)
+		require.Empty(t, rest)
+	})
+
+	t.Run("keeps batches that fail to commit", func(t *testing.T) {
+		dstore := dssync.MutexWrap(datastore.NewMapDatastore())
+		publisherStore := &failingHeadStore{PublisherStore: store.FromDatastore(dstore, store.WithMetadataContext(metadata.MetadataContext))}
+
+		svc, err := New(testutil.Alice, publisherStore, addr, WithBatching(2, time.Hour, nil), WithLogLevel("info"))
+		require.NoError(t, err)
+
+		publisherStore.fail = true
+		require.NoError(t, svc.Publish(ctx, randomLocationClaim(t)))
+		require.Error(t, svc.Publish(ctx, randomLocationClaim(t)))
+
+		publisherStore.fail = false
+		require.NoError(t, svc.Stop(ctx))
+		require.Equal(t, 2, countAdverts(t, publisherStore))
+	})
+
 	t.Run("caches claims", func(t *testing.T) {
 		dstore := dssync.MutexWrap(datastore.NewMapDatastore())
 		publisherStore := store.FromDatastore(dstore, store.WithMetadataContext(metadata.MetadataContext))
@@ -214,3 +446,49 @@ func mockIndexingService(t *testing.T, id principal.Signer, handler server.Handl
 		),
 	)(t)
 }
+
+func randomLocationClaim(t *testing.T) delegation.Delegation {
+	space := testutil.RandomDID(t)
+	shard := testutil.RandomMultihash(t)
+	location := testutil.Must(url.Parse(fmt.Sprintf("http://localhost:3000/blob/%s", digestutil.Format(shard))))(t)
+	claim, err := assert.Location.Delegate(
+		testutil.Alice,
+		space,
+		testutil.Alice.DID().String(),
+		assert.LocationCaveats{
+			Space:    space,
+			Content:  types.FromHash(shard),
+			Location: []url.URL{*location},
+		},
+		delegation.WithNoExpiration(),
+	)
+	require.NoError(t, err)
+	return claim
+}
+
+// countAdverts returns the length of the advertisement chain.
+func countAdverts(t *testing.T, publisherStore store.PublisherStore) int {
+	hd, err := publisherStore.Head(t.Context())
+	require.NoError(t, err)
+	ads := 0
+	for link := hd.Head; link != nil; ads++ {
+		ad, err := publisherStore.Advert(t.Context(), link)
+		require.NoError(t, err)
+		link = ad.PreviousID
+	}
+	return ads
+}
+
+// failingHeadStore fails to replace the head of the advertisement chain while
+// fail is set.
+type failingHeadStore struct {
+	store.PublisherStore
+	fail bool
+}
+
+func (s *failingHeadStore) ReplaceHead(ctx context.Context, oldHead *head.SignedHead, newHead *head.SignedHead) (ipld.Link, error) {
+	if s.fail {
+		return nil, errors.New("head store unavailable")
+	}
+	return s.PublisherStore.ReplaceHead(ctx, oldHead, newHead)
+}
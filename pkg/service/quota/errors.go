@@ -0,0 +1,41 @@
+package quota
+
+import (
+	"fmt"
+
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/result/failure/datamodel"
+	"github.com/storacha/go-ucanto/did"
+)
+
+// QuotaExceededErrorName is the name of the failure returned in receipts
+// when a space exceeds its quota.
+const QuotaExceededErrorName = "QuotaExceeded"
+
+// QuotaExceededError is returned when a reservation would take a space over
+// its limit.
+type QuotaExceededError struct {
+	Space     did.DID
+	Resource  Resource
+	Limit     uint64
+	Used      uint64
+	Requested uint64
+}
+
+func (qe QuotaExceededError) Name() string {
+	return QuotaExceededErrorName
+}
+
+func (qe QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota of space %s exceeded: %d of %d bytes used, %d requested", qe.Resource, qe.Space, qe.Used, qe.Limit, qe.Requested)
+}
+
+func (qe QuotaExceededError) ToIPLD() (ipld.Node, error) {
+	name := qe.Name()
+	model := datamodel.FailureModel{Name: &name, Message: qe.Error()}
+	return model.ToIPLD()
+}
+
+func NewQuotaExceededError(space did.DID, resource Resource, limit, used, requested uint64) *QuotaExceededError {
+	return &QuotaExceededError{space, resource, limit, used, requested}
+}
@@ -0,0 +1,26 @@
+package quota
+
+import (
+	"github.com/ipfs/go-datastore"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("quota",
+	fx.Provide(
+		fx.Annotate(
+			NewManagerFromParams,
+			fx.As(fx.Self()),
+			fx.As(new(Enforcer)),
+		),
+	),
+)
+
+type Params struct {
+	fx.In
+
+	Datastore datastore.Datastore `name:"quota_datastore"`
+}
+
+func NewManagerFromParams(params Params) *Manager {
+	return NewManager(params.Datastore)
+}
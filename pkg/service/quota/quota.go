@@ -0,0 +1,308 @@
+// Package quota limits the bytes a space can allocate on the node and
+// download from it.
+//
+// Limits are set per space by the node operator and are unlimited by default.
+// Usage is tracked for every space, so a limit applies to bytes allocated
+// before it was set. Egress is counted per calendar month (UTC). Storage
+// usage is the total size of the blobs allocated in the space.
+package quota
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/storacha/go-ucanto/did"
+)
+
+var log = logging.Logger("quota")
+
+const (
+	limitsPrefix  = "/limits/"
+	storagePrefix = "/storage/"
+	egressPrefix  = "/egress/"
+
+	// periodLayout formats the calendar month egress is counted in.
+	periodLayout = "2006-01"
+)
+
+// Resource is a resource a quota limits.
+type Resource string
+
+const (
+	Storage Resource = "storage"
+	Egress  Resource = "egress"
+)
+
+// Limits are the byte limits of a space, 0 for no limit.
+type Limits struct {
+	Storage uint64 `json:"storage,omitempty"`
+	// Egress is the number of bytes the space may download per calendar
+	// month.
+	Egress uint64 `json:"egress,omitempty"`
+}
+
+// Usage is the bytes a space has used.
+type Usage struct {
+	Storage uint64
+	Egress  uint64
+	// Period is the calendar month Egress is counted in, e.g. 2026-10.
+	Period string
+}
+
+// Enforcer reserves bytes against the quotas of a space. Reserve methods
+// return a *QuotaExceededError if the reservation would exceed the limit.
+type Enforcer interface {
+	ReserveStorage(ctx context.Context, space did.DID, size uint64) error
+	ReleaseStorage(ctx context.Context, space did.DID, size uint64) error
+	ReserveEgress(ctx context.Context, space did.DID, size uint64) error
+	ReleaseEgress(ctx context.Context, space did.DID, size uint64) error
+}
+
+// Manager persists the limits and usage of spaces in a datastore.
+type Manager struct {
+	ds  datastore.Datastore
+	now func() time.Time
+	// mu serializes the read-modify-write of usage counters.
+	mu sync.Mutex
+}
+
+var _ Enforcer = (*Manager)(nil)
+
+// NewManager creates a Manager persisting limits and usage in ds.
+func NewManager(ds datastore.Datastore) *Manager {
+	return &Manager{ds: ds, now: time.Now}
+}
+
+// SetLimits sets the limits of a space. Zero limits remove them.
+func (m *Manager) SetLimits(ctx context.Context, space did.DID, limits Limits) error {
+	key := limitsKey(space)
+	if limits == (Limits{}) {
+		if err := m.ds.Delete(ctx, key); err != nil && !errors.Is(err, datastore.ErrNotFound) {
+			return fmt.Errorf("deleting limits: %w", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(limits)
+	if err != nil {
+		return fmt.Errorf("encoding limits: %w", err)
+	}
+	if err := m.ds.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("putting limits: %w", err)
+	}
+	return nil
+}
+
+// Limits returns the limits of a space.
+func (m *Manager) Limits(ctx context.Context, space did.DID) (Limits, error) {
+	data, err := m.ds.Get(ctx, limitsKey(space))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return Limits{}, nil
+		}
+		return Limits{}, fmt.Errorf("getting limits: %w", err)
+	}
+	var limits Limits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return Limits{}, fmt.Errorf("decoding limits: %w", err)
+	}
+	return limits, nil
+}
+
+// ListLimits returns the limits of every space that has any.
+func (m *Manager) ListLimits(ctx context.Context) (map[did.DID]Limits, error) {
+	results, err := m.ds.Query(ctx, query.Query{Prefix: limitsPrefix})
+	if err != nil {
+		return nil, fmt.Errorf("querying limits: %w", err)
+	}
+	defer results.Close()
+
+	out := map[did.DID]Limits{}
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating limits: %w", entry.Error)
+		}
+		space, err := did.Parse(strings.TrimPrefix(entry.Key, limitsPrefix))
+		if err != nil {
+			log.Warnw("skipping limits with invalid space", "key", entry.Key, "error", err)
+			continue
+		}
+		var limits Limits
+		if err := json.Unmarshal(entry.Value, &limits); err != nil {
+			return nil, fmt.Errorf("decoding limits for %s: %w", space, err)
+		}
+		out[space] = limits
+	}
+	return out, nil
+}
+
+// Usage returns the bytes a space has allocated, and downloaded in the
+// current calendar month.
+func (m *Manager) Usage(ctx context.Context, space did.DID) (Usage, error) {
+	period := m.period()
+	stored, err := m.counter(ctx, storageKey(space))
+	if err != nil {
+		return Usage{}, err
+	}
+	egress, err := m.counter(ctx, egressKey(period, space))
+	if err != nil {
+		return Usage{}, err
+	}
+	return Usage{Storage: stored, Egress: egress, Period: period}, nil
+}
+
+// ListUsage returns the usage of every space that has allocated or downloaded
+// bytes, with egress counted in period, a calendar month formatted as
+// 2006-01.
+func (m *Manager) ListUsage(ctx context.Context, period string) (map[did.DID]Usage, error) {
+	out := map[did.DID]Usage{}
+	err := m.counters(ctx, storagePrefix, func(space did.DID, n uint64) {
+		u := out[space]
+		u.Storage, u.Period = n, period
+		out[space] = u
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = m.counters(ctx, egressPrefix+period+"/", func(space did.DID, n uint64) {
+		u := out[space]
+		u.Egress, u.Period = n, period
+		out[space] = u
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// counters calls fn with the space and value of every counter under prefix.
+func (m *Manager) counters(ctx context.Context, prefix string, fn func(did.DID, uint64)) error {
+	results, err := m.ds.Query(ctx, query.Query{Prefix: prefix})
+	if err != nil {
+		return fmt.Errorf("querying usage: %w", err)
+	}
+	defer results.Close()
+
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return fmt.Errorf("iterating usage: %w", entry.Error)
+		}
+		// egress of other periods is under a longer prefix
+		name := strings.TrimPrefix(entry.Key, prefix)
+		if strings.Contains(name, "/") {
+			continue
+		}
+		space, err := did.Parse(name)
+		if err != nil {
+			log.Warnw("skipping usage with invalid space", "key", entry.Key, "error", err)
+			continue
+		}
+		if len(entry.Value) != 8 {
+			return fmt.Errorf("invalid usage value for %s", entry.Key)
+		}
+		fn(space, binary.BigEndian.Uint64(entry.Value))
+	}
+	return nil
+}
+
+func (m *Manager) ReserveStorage(ctx context.Context, space did.DID, size uint64) error {
+	return m.reserve(ctx, space, Storage, storageKey(space), size)
+}
+
+func (m *Manager) ReleaseStorage(ctx context.Context, space did.DID, size uint64) error {
+	return m.release(ctx, storageKey(space), size)
+}
+
+func (m *Manager) ReserveEgress(ctx context.Context, space did.DID, size uint64) error {
+	return m.reserve(ctx, space, Egress, egressKey(m.period(), space), size)
+}
+
+func (m *Manager) ReleaseEgress(ctx context.Context, space did.DID, size uint64) error {
+	return m.release(ctx, egressKey(m.period(), space), size)
+}
+
+func (m *Manager) reserve(ctx context.Context, space did.DID, resource Resource, key datastore.Key, size uint64) error {
+	if size == 0 {
+		return nil
+	}
+	limits, err := m.Limits(ctx, space)
+	if err != nil {
+		return err
+	}
+	limit := limits.Storage
+	if resource == Egress {
+		limit = limits.Egress
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	used, err := m.counter(ctx, key)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && used+size > limit {
+		return NewQuotaExceededError(space, resource, limit, used, size)
+	}
+	return m.putCounter(ctx, key, used+size)
+}
+
+func (m *Manager) release(ctx context.Context, key datastore.Key, size uint64) error {
+	if size == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	used, err := m.counter(ctx, key)
+	if err != nil {
+		return err
+	}
+	return m.putCounter(ctx, key, used-min(used, size))
+}
+
+func (m *Manager) counter(ctx context.Context, key datastore.Key) (uint64, error) {
+	data, err := m.ds.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("getting usage: %w", err)
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid usage value for %s", key)
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+func (m *Manager) putCounter(ctx context.Context, key datastore.Key, n uint64) error {
+	if err := m.ds.Put(ctx, key, binary.BigEndian.AppendUint64(nil, n)); err != nil {
+		return fmt.Errorf("putting usage: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) period() string {
+	return m.now().UTC().Format(periodLayout)
+}
+
+func limitsKey(space did.DID) datastore.Key {
+	return datastore.NewKey(limitsPrefix + space.String())
+}
+
+func storageKey(space did.DID) datastore.Key {
+	return datastore.NewKey(storagePrefix + space.String())
+}
+
+func egressKey(period string, space did.DID) datastore.Key {
+	return datastore.NewKey(egressPrefix + period + "/" + space.String())
+}
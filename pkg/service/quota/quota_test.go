@@ -0,0 +1,117 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	return NewManager(sync.MutexWrap(datastore.NewMapDatastore()))
+}
+
+func TestStorageQuota(t *testing.T) {
+	m := newTestManager(t)
+	space := testutil.RandomDID(t)
+
+	t.Run("tracks usage without limits", func(t *testing.T) {
+		require.NoError(t, m.ReserveStorage(t.Context(), space, 512))
+		usage, err := m.Usage(t.Context(), space)
+		require.NoError(t, err)
+		require.Equal(t, uint64(512), usage.Storage)
+	})
+
+	t.Run("rejects reservations over the limit", func(t *testing.T) {
+		require.NoError(t, m.SetLimits(t.Context(), space, Limits{Storage: 1024}))
+		require.NoError(t, m.ReserveStorage(t.Context(), space, 512))
+
+		err := m.ReserveStorage(t.Context(), space, 1)
+		var qe *QuotaExceededError
+		require.ErrorAs(t, err, &qe)
+		require.Equal(t, Storage, qe.Resource)
+		require.Equal(t, uint64(1024), qe.Limit)
+		require.Equal(t, uint64(1024), qe.Used)
+		require.Equal(t, uint64(1), qe.Requested)
+	})
+
+	t.Run("released bytes can be reserved again", func(t *testing.T) {
+		require.NoError(t, m.ReleaseStorage(t.Context(), space, 256))
+		require.NoError(t, m.ReserveStorage(t.Context(), space, 256))
+	})
+
+	t.Run("removing limits removes the quota", func(t *testing.T) {
+		require.NoError(t, m.SetLimits(t.Context(), space, Limits{}))
+		require.NoError(t, m.ReserveStorage(t.Context(), space, 1))
+		limits, err := m.Limits(t.Context(), space)
+		require.NoError(t, err)
+		require.Zero(t, limits)
+	})
+}
+
+func TestEgressQuota(t *testing.T) {
+	m := newTestManager(t)
+	now := time.Date(2026, time.October, 31, 23, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	space := testutil.RandomDID(t)
+
+	require.NoError(t, m.SetLimits(t.Context(), space, Limits{Egress: 100}))
+	require.NoError(t, m.ReserveEgress(t.Context(), space, 100))
+	err := m.ReserveEgress(t.Context(), space, 1)
+	require.ErrorAs(t, err, new(*QuotaExceededError))
+
+	usage, err := m.Usage(t.Context(), space)
+	require.NoError(t, err)
+	require.Equal(t, "2026-10", usage.Period)
+	require.Equal(t, uint64(100), usage.Egress)
+
+	// egress is counted per calendar month
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, m.ReserveEgress(t.Context(), space, 1))
+	usage, err = m.Usage(t.Context(), space)
+	require.NoError(t, err)
+	require.Equal(t, "2026-11", usage.Period)
+	require.Equal(t, uint64(1), usage.Egress)
+}
+
+func TestListLimits(t *testing.T) {
+	m := newTestManager(t)
+	a, b, c := testutil.RandomDID(t), testutil.RandomDID(t), testutil.RandomDID(t)
+
+	require.NoError(t, m.SetLimits(t.Context(), a, Limits{Storage: 1}))
+	require.NoError(t, m.SetLimits(t.Context(), b, Limits{Egress: 2}))
+	require.NoError(t, m.ReserveStorage(t.Context(), c, 3))
+
+	limits, err := m.ListLimits(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, map[did.DID]Limits{a: {Storage: 1}, b: {Egress: 2}}, limits)
+}
+
+func TestListUsage(t *testing.T) {
+	m := newTestManager(t)
+	now := time.Date(2026, time.September, 30, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	a, b := testutil.RandomDID(t), testutil.RandomDID(t)
+
+	require.NoError(t, m.ReserveStorage(t.Context(), a, 10))
+	require.NoError(t, m.ReserveEgress(t.Context(), a, 1))
+	now = now.AddDate(0, 1, 0)
+	require.NoError(t, m.ReserveEgress(t.Context(), a, 2))
+	require.NoError(t, m.ReserveEgress(t.Context(), b, 3))
+
+	usage, err := m.ListUsage(t.Context(), "2026-09")
+	require.NoError(t, err)
+	require.Equal(t, map[did.DID]Usage{a: {Storage: 10, Egress: 1, Period: "2026-09"}}, usage)
+
+	usage, err = m.ListUsage(t.Context(), "2026-10")
+	require.NoError(t, err)
+	require.Equal(t, map[did.DID]Usage{
+		a: {Storage: 10, Egress: 2, Period: "2026-10"},
+		b: {Egress: 3, Period: "2026-10"},
+	}, usage)
+}
@@ -0,0 +1,42 @@
+package reachability
+
+import (
+	"github.com/raulk/clock"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/internal/periodic"
+)
+
+var Module = fx.Module("reachability",
+	fx.Provide(
+		NewMonitor,
+	),
+)
+
+type Params struct {
+	fx.In
+
+	Cfg   app.AppConfig
+	Clock clock.Clock
+}
+
+// NewMonitor creates the monitor of the public URLs, nil if the node has a
+// single public URL, which is then always advertised.
+func NewMonitor(lc fx.Lifecycle, params Params) *Monitor {
+	srvCfg := params.Cfg.Server
+	if len(srvCfg.AdditionalPublicURLs) == 0 {
+		return nil
+	}
+
+	m := New(
+		srvCfg.PublicURL,
+		srvCfg.AdditionalPublicURLs,
+		srvCfg.URLCheck.Interval,
+		srvCfg.URLCheck.Timeout,
+		WithClock(params.Clock),
+	)
+
+	lc.Append(periodic.Hook(m))
+	return m
+}
@@ -0,0 +1,255 @@
+// Package reachability checks the public URLs of the node are reachable, so
+// that only healthy URLs are advertised in location claims and IPNI
+// advertisements, fastest first.
+//
+// Each URL is checked by requesting its /livez endpoint. The latency of the
+// request orders healthy URLs, so clients trying locations in turn reach the
+// node over the fastest route first.
+//
+// Only URLs on public hosts are checked. The node cannot tell from where it
+// runs whether clients reach it on an onion service, which it cannot connect
+// to, or on a loopback or private address, which it always reaches. Those URLs
+// are not checked and are advertised after the healthy URLs.
+package reachability
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/raulk/clock"
+
+	"github.com/storacha/piri/pkg/internal/periodic"
+)
+
+var log = logging.Logger("reachability")
+
+// CheckPath is the path requested on each URL to check it is reachable.
+const CheckPath = "/livez"
+
+// Status is the result of the last check of a URL.
+type Status struct {
+	URL url.URL
+	// Checked is false until the URL has been checked once, and for URLs that
+	// are not checked.
+	Checked bool
+	Healthy bool
+	// Latency is the duration of the last successful check.
+	Latency   time.Duration
+	CheckedAt time.Time
+	// Error is the reason the last check failed, empty if it succeeded.
+	Error string
+}
+
+// Monitor periodically checks the public URLs of the node.
+type Monitor struct {
+	primary  url.URL
+	urls     []url.URL
+	client   *http.Client
+	interval time.Duration
+	timeout  time.Duration
+	clock    clock.Clock
+	// checkable reports whether a URL is checked.
+	checkable func(url.URL) bool
+	loop      *periodic.Loop
+
+	mu       sync.RWMutex
+	statuses []Status
+}
+
+type Option func(*Monitor)
+
+// WithClock sets the clock checks are scheduled by.
+func WithClock(clock clock.Clock) Option {
+	return func(m *Monitor) {
+		m.clock = clock
+	}
+}
+
+// WithHTTPClient sets the client URLs are checked with.
+func WithHTTPClient(client *http.Client) Option {
+	return func(m *Monitor) {
+		m.client = client
+	}
+}
+
+// New creates a monitor checking primary and additional every interval, each
+// check timing out after timeout. Until checked, URLs are assumed healthy, in
+// the order they are given.
+func New(primary url.URL, additional []url.URL, interval, timeout time.Duration, opts ...Option) *Monitor {
+	m := &Monitor{
+		primary:   primary,
+		urls:      append([]url.URL{primary}, additional...),
+		client:    http.DefaultClient,
+		interval:  interval,
+		timeout:   timeout,
+		clock:     clock.New(),
+		checkable: IsPublic,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	for _, u := range m.urls {
+		m.statuses = append(m.statuses, Status{URL: u})
+	}
+	m.loop = periodic.New(m.clock, m.interval, m.Check)
+	return m
+}
+
+// Start checks the URLs every interval until stopped.
+func (m *Monitor) Start(ctx context.Context) error {
+	return m.loop.Start(ctx)
+}
+
+func (m *Monitor) Stop(ctx context.Context) error {
+	return m.loop.Stop(ctx)
+}
+
+// Check checks every URL on a public host concurrently and records the
+// results.
+func (m *Monitor) Check(ctx context.Context) {
+	results := make([]Status, len(m.urls))
+	var wg sync.WaitGroup
+	for i, u := range m.urls {
+		if !m.checkable(u) {
+			results[i] = Status{URL: u}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = m.check(ctx, u)
+		}()
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, res := range results {
+		if !res.Checked {
+			continue
+		}
+		if res.Healthy != m.statuses[i].Healthy || !m.statuses[i].Checked {
+			if res.Healthy {
+				log.Infow("public URL is reachable", "url", res.URL.String(), "latency", res.Latency)
+			} else {
+				log.Warnw("public URL is unreachable", "url", res.URL.String(), "error", res.Error)
+			}
+		}
+		m.statuses[i] = res
+	}
+}
+
+func (m *Monitor) check(ctx context.Context, u url.URL) Status {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	start := m.clock.Now()
+	status := Status{URL: u, Checked: true, CheckedAt: start}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.JoinPath(CheckPath).String(), nil)
+	if err != nil {
+		status.Error = fmt.Sprintf("creating request: %s", err)
+		return status
+	}
+	res, err := m.client.Do(req)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		status.Error = fmt.Sprintf("unexpected status: %s", res.Status)
+		return status
+	}
+	status.Healthy = true
+	status.Latency = m.clock.Since(start)
+	return status
+}
+
+// Statuses returns the result of the last check of each URL, the primary URL
+// first.
+func (m *Monitor) Statuses() []Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.statuses)
+}
+
+// URLs returns the healthy public URLs, ordered by latency. URLs not checked,
+// or not checked yet, follow in the order configured. If no URL is healthy, e.g. because the
+// node cannot reach itself through its router, all URLs are returned in the
+// order configured rather than advertising none.
+func (m *Monitor) URLs() []url.URL {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var checked, unchecked []Status
+	for _, s := range m.statuses {
+		switch {
+		case !s.Checked:
+			unchecked = append(unchecked, s)
+		case s.Healthy:
+			checked = append(checked, s)
+		}
+	}
+	if len(checked) == 0 && len(unchecked) == 0 {
+		return slices.Clone(m.urls)
+	}
+	slices.SortStableFunc(checked, func(a, b Status) int {
+		return cmp.Compare(a.Latency, b.Latency)
+	})
+
+	urls := make([]url.URL, 0, len(checked)+len(unchecked))
+	for _, s := range append(checked, unchecked...) {
+		urls = append(urls, s.URL)
+	}
+	return urls
+}
+
+// Locations returns loc on each of the healthy public URLs, ordered as
+// [Monitor.URLs]. loc is returned as is if it is not on the primary public
+// URL, e.g. a presigned URL on a separate blob store.
+func (m *Monitor) Locations(loc url.URL) []url.URL {
+	base := strings.TrimSuffix(m.primary.String(), "/")
+	rest, ok := strings.CutPrefix(loc.String(), base)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "?")) {
+		return []url.URL{loc}
+	}
+
+	var locs []url.URL
+	for _, u := range m.URLs() {
+		l, err := url.Parse(strings.TrimSuffix(u.String(), "/") + rest)
+		if err != nil {
+			log.Warnw("rebasing location on public URL", "url", u.String(), "location", loc.String(), "error", err)
+			continue
+		}
+		locs = append(locs, *l)
+	}
+	if len(locs) == 0 {
+		return []url.URL{loc}
+	}
+	return locs
+}
+
+// IsPublic reports whether u is on a host the node can check from where it
+// runs: not an onion service, nor a loopback, private or link-local address.
+// Hostnames other than onion and local ones are assumed public.
+func IsPublic(u url.URL) bool {
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		strings.HasSuffix(host, ".onion") || strings.HasSuffix(host, ".local") {
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return true
+	}
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
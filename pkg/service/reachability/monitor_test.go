@@ -0,0 +1,170 @@
+package reachability
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer serves CheckPath after delay, or fails it if down is set.
+func newTestServer(t *testing.T, delay time.Duration, down *atomic.Bool) url.URL {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != CheckPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		time.Sleep(delay)
+		if down != nil && down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return *testutil.Must(url.Parse(srv.URL))(t)
+}
+
+// checkAll checks URLs on any host, as test servers listen on loopback.
+func checkAll(m *Monitor) {
+	m.checkable = func(url.URL) bool { return true }
+}
+
+func TestMonitor(t *testing.T) {
+	t.Run("orders healthy URLs by latency", func(t *testing.T) {
+		var down atomic.Bool
+		down.Store(true)
+		slow := newTestServer(t, 50*time.Millisecond, nil)
+		fast := newTestServer(t, 0, nil)
+		unhealthy := newTestServer(t, 0, &down)
+
+		m := New(slow, []url.URL{unhealthy, fast}, time.Minute, time.Second, checkAll)
+		// unchecked URLs are advertised in the order configured
+		require.Equal(t, []url.URL{slow, unhealthy, fast}, m.URLs())
+
+		m.Check(t.Context())
+		require.Equal(t, []url.URL{fast, slow}, m.URLs())
+
+		statuses := m.Statuses()
+		require.Len(t, statuses, 3)
+		require.Equal(t, slow, statuses[0].URL)
+		require.True(t, statuses[0].Healthy)
+		require.GreaterOrEqual(t, statuses[0].Latency, 50*time.Millisecond)
+		require.False(t, statuses[1].Healthy)
+		require.Contains(t, statuses[1].Error, "503")
+
+		down.Store(false)
+		m.Check(t.Context())
+		require.Len(t, m.URLs(), 3)
+		require.Equal(t, slow, m.URLs()[2])
+	})
+
+	t.Run("advertises all URLs if none is healthy", func(t *testing.T) {
+		var down atomic.Bool
+		down.Store(true)
+		primary := newTestServer(t, 0, &down)
+		other := newTestServer(t, 0, &down)
+
+		m := New(primary, []url.URL{other}, time.Minute, time.Second, checkAll)
+		m.Check(t.Context())
+		require.Equal(t, []url.URL{primary, other}, m.URLs())
+	})
+
+	t.Run("times out unresponsive URLs", func(t *testing.T) {
+		primary := newTestServer(t, 0, nil)
+		hung := newTestServer(t, time.Second, nil)
+
+		m := New(primary, []url.URL{hung}, time.Minute, 100*time.Millisecond, checkAll)
+		m.Check(t.Context())
+		require.Equal(t, []url.URL{primary}, m.URLs())
+	})
+
+	t.Run("checks URLs every interval", func(t *testing.T) {
+		var down atomic.Bool
+		primary := newTestServer(t, 0, nil)
+		other := newTestServer(t, 0, &down)
+
+		clk := clock.NewMock()
+		m := New(primary, []url.URL{other}, time.Minute, time.Second, WithClock(clk), checkAll)
+		require.NoError(t, m.Start(context.Background()))
+		t.Cleanup(func() {
+			require.NoError(t, m.Stop(context.Background()))
+		})
+		require.Eventually(t, func() bool {
+			return m.Statuses()[1].Checked
+		}, time.Second, 10*time.Millisecond)
+
+		down.Store(true)
+		require.Eventually(t, func() bool {
+			clk.Add(time.Minute)
+			return len(m.URLs()) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("does not check URLs on private hosts", func(t *testing.T) {
+		primary := newTestServer(t, 0, nil)
+		onion := *testutil.Must(url.Parse("http://piri.onion"))(t)
+
+		m := New(primary, []url.URL{onion}, time.Minute, time.Second)
+		m.Check(t.Context())
+		require.False(t, m.Statuses()[0].Checked)
+		require.False(t, m.Statuses()[1].Checked)
+		require.Equal(t, []url.URL{primary, onion}, m.URLs())
+	})
+}
+
+func TestLocations(t *testing.T) {
+	primary := *testutil.Must(url.Parse("https://piri.example.com"))(t)
+	v6 := *testutil.Must(url.Parse("https://[2001:db8::1]"))(t)
+	onion := *testutil.Must(url.Parse("http://piri.onion/"))(t)
+	m := New(primary, []url.URL{v6, onion}, time.Minute, time.Second)
+
+	t.Run("rebases locations on each public URL", func(t *testing.T) {
+		loc := *testutil.Must(url.Parse("https://piri.example.com/blob/zQm?x=1"))(t)
+		var locs []string
+		for _, l := range m.Locations(loc) {
+			locs = append(locs, l.String())
+		}
+		require.Equal(t, []string{
+			"https://piri.example.com/blob/zQm?x=1",
+			"https://[2001:db8::1]/blob/zQm?x=1",
+			"http://piri.onion/blob/zQm?x=1",
+		}, locs)
+	})
+
+	t.Run("keeps locations elsewhere", func(t *testing.T) {
+		for _, s := range []string{
+			"https://bucket.s3.example.com/blob/zQm",
+			"https://piri.example.community/blob/zQm",
+		} {
+			loc := *testutil.Must(url.Parse(s))(t)
+			require.Equal(t, []url.URL{loc}, m.Locations(loc))
+		}
+	})
+}
+
+func TestIsPublic(t *testing.T) {
+	for s, public := range map[string]bool{
+		"https://piri.example.com": true,
+		"https://[2001:db8::1]":    true,
+		"http://203.0.113.7:3000":  true,
+		"http://piri.onion":        false,
+		"http://localhost:3000":    false,
+		"http://piri.local":        false,
+		"http://127.0.0.1:3000":    false,
+		"http://192.168.1.20:3000": false,
+		"http://10.0.0.2":          false,
+		"http://[fe80::1]":         false,
+		"http://[fd00::1]:3000":    false,
+	} {
+		u := *testutil.Must(url.Parse(s))(t)
+		require.Equal(t, public, IsPublic(u), s)
+	}
+}
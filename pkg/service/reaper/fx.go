@@ -0,0 +1,93 @@
+package reaper
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multihash"
+	"github.com/raulk/clock"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/service/quota"
+	"github.com/storacha/piri/pkg/service/usage"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/storacha/piri/pkg/subsystem"
+)
+
+var log = logging.Logger("reaper")
+
+var Module = fx.Module("reaper",
+	fx.Provide(
+		NewReaperService,
+	),
+	// force construction, nothing else depends on the reaper
+	fx.Invoke(func(*Service) {}),
+)
+
+type Params struct {
+	fx.In
+
+	Cfg             app.AppConfig
+	AllocationStore allocationstore.AllocationStore
+	AcceptanceStore acceptancestore.AcceptanceStore
+	BlobStore       blobstore.Blobstore
+	Datastore       datastore.Datastore `name:"reaper_datastore"`
+	PDP             pdp.PDP             `optional:"true"`
+	Quotas          quota.Enforcer      `optional:"true"`
+	Usage           *usage.Tracker      `optional:"true"`
+	Subsystems      *subsystem.Registry
+	Clock           clock.Clock
+}
+
+func NewReaperService(lc fx.Lifecycle, params Params) (*Service, error) {
+	cfg := params.Cfg.UCANService.Reaper
+
+	// blobs are held by PDP when it is enabled, otherwise in the blob store
+	has := func(ctx context.Context, digest multihash.Multihash) (bool, error) {
+		obj, err := params.BlobStore.Get(ctx, digest)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, obj.Body().Close()
+	}
+	if params.PDP != nil {
+		has = params.PDP.API().Has
+	}
+
+	svc := New(
+		params.AllocationStore,
+		params.AcceptanceStore,
+		has,
+		params.Datastore,
+		cfg.Interval,
+		cfg.ReconcileInterval,
+		WithClock(params.Clock),
+		WithQuotas(params.Quotas),
+		WithUsage(params.Usage),
+	)
+
+	params.Subsystems.Register(subsystem.Reaper, svc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return svc.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			return svc.Stop(ctx)
+		},
+	})
+
+	return svc, nil
+}
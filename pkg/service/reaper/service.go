@@ -0,0 +1,303 @@
+package reaper
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/multiformats/go-multihash"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/service/quota"
+	"github.com/storacha/piri/pkg/service/usage"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/acceptancestore/acceptance"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+)
+
+// HasFunc reports whether the bytes for a blob are held by this node.
+type HasFunc func(ctx context.Context, digest multihash.Multihash) (bool, error)
+
+// reportedPrefix is the datastore prefix of the accepted blobs found missing
+// that were already reported.
+var reportedPrefix = datastore.NewKey("reported")
+
+// Service periodically removes allocations whose blobs were never received
+// and reconciles accepted blobs with the blobs held by the node.
+//
+// Allocations reserve capacity on the node, and count against the storage
+// quota and usage of their space, until the blob is received. When a client
+// never uploads, the allocation would otherwise be held forever. Once an
+// allocation has expired without the blob being received, it is deleted and
+// its bytes are released from the quota and usage of the space.
+//
+// Reconciliation finds blobs that were accepted, and are therefore believed
+// to be stored by the upload service, but for which the node holds no bytes.
+// Each missing blob is reported once, and reported again only if it goes
+// missing again after being found.
+type Service struct {
+	allocations       allocationstore.AllocationStore
+	acceptances       acceptancestore.AcceptanceStore
+	has               HasFunc
+	reported          datastore.Datastore
+	quotas            quota.Enforcer
+	usage             *usage.Tracker
+	reapInterval      time.Duration
+	reconcileInterval time.Duration
+	clock             clock.Clock
+	cancel            context.CancelFunc
+	done              chan struct{}
+	paused            atomic.Bool
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock sets the clock the periodic tasks are scheduled by and
+// allocations expire against. Defaults to the real clock.
+func WithClock(clock clock.Clock) Option {
+	return func(s *Service) {
+		s.clock = clock
+	}
+}
+
+// WithQuotas releases the storage reserved by reaped allocations from the
+// quotas of their space.
+func WithQuotas(quotas quota.Enforcer) Option {
+	return func(s *Service) {
+		s.quotas = quotas
+	}
+}
+
+// WithUsage records reaped allocations as removals from the usage of their
+// space.
+func WithUsage(usage *usage.Tracker) Option {
+	return func(s *Service) {
+		s.usage = usage
+	}
+}
+
+// New creates a Service. Accepted blobs found missing are recorded in ds once
+// reported.
+func New(
+	allocations allocationstore.AllocationStore,
+	acceptances acceptancestore.AcceptanceStore,
+	has HasFunc,
+	ds datastore.Datastore,
+	reapInterval time.Duration,
+	reconcileInterval time.Duration,
+	opts ...Option,
+) *Service {
+	s := &Service{
+		allocations:       allocations,
+		acceptances:       acceptances,
+		has:               has,
+		reported:          namespace.Wrap(ds, reportedPrefix),
+		reapInterval:      reapInterval,
+		reconcileInterval: reconcileInterval,
+		clock:             clock.New(),
+		done:              make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Start starts the periodic reap and reconciliation tasks. A task is disabled
+// when its interval is 0.
+func (s *Service) Start(ctx context.Context) error {
+	if s.reapInterval <= 0 && s.reconcileInterval <= 0 {
+		log.Info("allocation reaper disabled (intervals are 0)")
+		close(s.done)
+		return nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	go s.run(runCtx)
+
+	log.Infof("allocation reaper started with reap interval: %v, reconcile interval: %v", s.reapInterval, s.reconcileInterval)
+	return nil
+}
+
+// Stop stops the periodic tasks gracefully.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	select {
+	case <-s.done:
+		log.Info("allocation reaper stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for allocation reaper to stop: %w", ctx.Err())
+	}
+}
+
+// Pause skips the periodic tasks until Resume is called. Reap and Reconcile
+// may still be called directly.
+func (s *Service) Pause() {
+	s.paused.Store(true)
+}
+
+// Resume continues running the periodic tasks after a Pause.
+func (s *Service) Resume() {
+	s.paused.Store(false)
+}
+
+func (s *Service) run(ctx context.Context) {
+	defer close(s.done)
+
+	// a nil channel blocks forever, disabling the corresponding task
+	var reapC, reconcileC <-chan time.Time
+	if s.reapInterval > 0 {
+		ticker := s.clock.Ticker(s.reapInterval)
+		defer ticker.Stop()
+		reapC = ticker.C
+	}
+	if s.reconcileInterval > 0 {
+		ticker := s.clock.Ticker(s.reconcileInterval)
+		defer ticker.Stop()
+		reconcileC = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("allocation reaper context cancelled")
+			return
+		case <-reapC:
+			if s.paused.Load() {
+				continue
+			}
+			if _, err := s.Reap(ctx); err != nil {
+				log.Errorw("reaping expired allocations", "error", err)
+			}
+		case <-reconcileC:
+			if s.paused.Load() {
+				continue
+			}
+			if _, err := s.Reconcile(ctx); err != nil {
+				log.Errorw("reconciling acceptances", "error", err)
+			}
+		}
+	}
+}
+
+// Reap deletes expired allocations for blobs that were never received,
+// releasing their bytes from the quota and usage of their space. It returns
+// the number of allocations removed.
+//
+// Allocations for received blobs are kept, since they record the spaces the
+// blob was stored for.
+func (s *Service) Reap(ctx context.Context) (int, error) {
+	now := uint64(s.clock.Now().Unix())
+
+	var expired []allocation.Allocation
+	for alloc, err := range s.allocations.List(ctx) {
+		if err != nil {
+			return 0, fmt.Errorf("listing allocations: %w", err)
+		}
+		if alloc.Expires <= now {
+			expired = append(expired, alloc)
+		}
+	}
+
+	reaped := 0
+	for _, alloc := range expired {
+		received, err := s.has(ctx, alloc.Blob.Digest)
+		if err != nil {
+			return reaped, fmt.Errorf("checking blob: %w", err)
+		}
+		if received {
+			continue
+		}
+
+		if err := s.allocations.Delete(ctx, alloc.Blob.Digest, alloc.Space); err != nil {
+			return reaped, fmt.Errorf("deleting allocation: %w", err)
+		}
+		if s.quotas != nil {
+			if err := s.quotas.ReleaseStorage(ctx, alloc.Space, alloc.Blob.Size); err != nil {
+				log.Errorw("releasing storage quota", "space", alloc.Space, "error", err)
+			}
+		}
+		if s.usage != nil {
+			if err := s.usage.RecordRemoval(ctx, alloc.Space, alloc.Blob.Size, alloc.Cause); err != nil {
+				log.Errorw("recording space usage", "space", alloc.Space, "error", err)
+			}
+		}
+		log.Infow("reaped expired allocation", "space", alloc.Space, "digest", alloc.Blob.Digest, "size", alloc.Blob.Size)
+		reaped++
+	}
+	return reaped, nil
+}
+
+// Reconcile reports accepted blobs that are missing from this node, once
+// each. It returns the number of missing blobs newly reported.
+//
+// Blobs found again, or whose acceptance was removed, are forgotten so they
+// are reported again if they go missing later.
+func (s *Service) Reconcile(ctx context.Context) (int, error) {
+	missing := map[datastore.Key]acceptance.Acceptance{}
+	for acc, err := range s.acceptances.List(ctx) {
+		if err != nil {
+			return 0, fmt.Errorf("listing acceptances: %w", err)
+		}
+		has, err := s.has(ctx, acc.Blob.Digest)
+		if err != nil {
+			return 0, fmt.Errorf("checking blob: %w", err)
+		}
+		if !has {
+			missing[reportedKey(acc)] = acc
+		}
+	}
+
+	results, err := s.reported.Query(ctx, query.Query{KeysOnly: true})
+	if err != nil {
+		return 0, fmt.Errorf("querying reported blobs: %w", err)
+	}
+	defer results.Close()
+	reported := map[datastore.Key]bool{}
+	for r := range results.Next() {
+		if r.Error != nil {
+			return 0, fmt.Errorf("reading reported blobs: %w", r.Error)
+		}
+		k := datastore.NewKey(r.Key)
+		if _, ok := missing[k]; !ok {
+			if err := s.reported.Delete(ctx, k); err != nil {
+				return 0, fmt.Errorf("forgetting reported blob: %w", err)
+			}
+			continue
+		}
+		reported[k] = true
+	}
+
+	count := 0
+	for k, acc := range missing {
+		if reported[k] {
+			continue
+		}
+		log.Warnw("accepted blob is missing from the node", "space", acc.Space, "digest", digestutil.Format(acc.Blob.Digest), "size", acc.Blob.Size, "cause", acc.Cause)
+		if err := s.reported.Put(ctx, k, []byte{}); err != nil {
+			return count, fmt.Errorf("recording reported blob: %w", err)
+		}
+		count++
+	}
+	if len(missing) > 0 {
+		log.Warnw("accepted blobs missing from the node", "missing", len(missing), "new", count)
+	}
+	return count, nil
+}
+
+func reportedKey(acc acceptance.Acceptance) datastore.Key {
+	return datastore.KeyWithNamespaces([]string{acc.Space.String(), digestutil.Format(acc.Blob.Digest)})
+}
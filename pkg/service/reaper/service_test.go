@@ -0,0 +1,193 @@
+package reaper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/multiformats/go-multihash"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/service/quota"
+	"github.com/storacha/piri/pkg/service/usage"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/acceptancestore/acceptance"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+)
+
+func hasBlobs(digests ...multihash.Multihash) HasFunc {
+	return func(ctx context.Context, digest multihash.Multihash) (bool, error) {
+		for _, d := range digests {
+			if string(d) == string(digest) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+func randomAllocation(t *testing.T, expires time.Time) allocation.Allocation {
+	return allocation.Allocation{
+		Space: testutil.RandomDID(t),
+		Blob: allocation.Blob{
+			Digest: testutil.RandomMultihash(t),
+			Size:   1024,
+		},
+		Expires: uint64(expires.Unix()),
+		Cause:   testutil.RandomCID(t),
+	}
+}
+
+func TestReap(t *testing.T) {
+	now := time.Now()
+	clk := clock.NewMock()
+	clk.Set(now)
+
+	t.Run("removes expired allocations for missing blobs", func(t *testing.T) {
+		allocs := allocationstore.NewDatastoreStore(datastore.NewMapDatastore())
+		accs := acceptancestore.NewDatastoreStore(datastore.NewMapDatastore())
+		quotas := quota.NewManager(datastore.NewMapDatastore())
+		tracker := usage.New(datastore.NewMapDatastore(), quotas)
+
+		stale := randomAllocation(t, now.Add(-time.Hour))
+		received := randomAllocation(t, now.Add(-time.Hour))
+		pending := randomAllocation(t, now.Add(time.Hour))
+		for _, a := range []allocation.Allocation{stale, received, pending} {
+			require.NoError(t, allocs.Put(t.Context(), a))
+			require.NoError(t, quotas.ReserveStorage(t.Context(), a.Space, a.Blob.Size))
+			require.NoError(t, tracker.RecordAllocation(t.Context(), a.Space, a.Blob.Size, a.Cause))
+		}
+
+		svc := New(allocs, accs, hasBlobs(received.Blob.Digest), datastore.NewMapDatastore(), 0, 0,
+			WithClock(clk), WithQuotas(quotas), WithUsage(tracker))
+
+		reaped, err := svc.Reap(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 1, reaped)
+
+		_, err = allocs.Get(t.Context(), stale.Blob.Digest, stale.Space)
+		require.ErrorIs(t, err, store.ErrNotFound)
+		_, err = allocs.Get(t.Context(), received.Blob.Digest, received.Space)
+		require.NoError(t, err)
+		_, err = allocs.Get(t.Context(), pending.Blob.Digest, pending.Space)
+		require.NoError(t, err)
+
+		// the bytes of the reaped allocation no longer count against its space
+		used, err := quotas.Usage(t.Context(), stale.Space)
+		require.NoError(t, err)
+		require.Zero(t, used.Storage)
+		size, err := tracker.Size(t.Context(), stale.Space)
+		require.NoError(t, err)
+		require.Zero(t, size)
+
+		used, err = quotas.Usage(t.Context(), received.Space)
+		require.NoError(t, err)
+		require.Equal(t, received.Blob.Size, used.Storage)
+		size, err = tracker.Size(t.Context(), received.Space)
+		require.NoError(t, err)
+		require.Equal(t, received.Blob.Size, size)
+	})
+
+	t.Run("without quotas or usage", func(t *testing.T) {
+		allocs := allocationstore.NewDatastoreStore(datastore.NewMapDatastore())
+		accs := acceptancestore.NewDatastoreStore(datastore.NewMapDatastore())
+
+		stale := randomAllocation(t, now.Add(-time.Hour))
+		require.NoError(t, allocs.Put(t.Context(), stale))
+
+		svc := New(allocs, accs, hasBlobs(), datastore.NewMapDatastore(), 0, 0, WithClock(clk))
+
+		reaped, err := svc.Reap(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 1, reaped)
+	})
+}
+
+func TestReconcile(t *testing.T) {
+	allocs := allocationstore.NewDatastoreStore(datastore.NewMapDatastore())
+	accs := acceptancestore.NewDatastoreStore(datastore.NewMapDatastore())
+
+	stored := map[string]bool{}
+	var missing []acceptance.Acceptance
+	for i := range 20 {
+		acc := acceptance.Acceptance{
+			Space: testutil.RandomDID(t),
+			Blob: acceptance.Blob{
+				Digest: testutil.RandomMultihash(t),
+				Size:   1024,
+			},
+			ExecutedAt: uint64(time.Now().Unix()),
+			Cause:      testutil.RandomCID(t),
+		}
+		require.NoError(t, accs.Put(t.Context(), acc))
+		if i%4 == 0 {
+			stored[string(acc.Blob.Digest)] = true
+		} else {
+			missing = append(missing, acc)
+		}
+	}
+	has := func(ctx context.Context, digest multihash.Multihash) (bool, error) {
+		return stored[string(digest)], nil
+	}
+
+	svc := New(allocs, accs, has, datastore.NewMapDatastore(), 0, 0)
+
+	reported, err := svc.Reconcile(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, len(missing), reported)
+
+	t.Run("missing blobs are reported once", func(t *testing.T) {
+		reported, err := svc.Reconcile(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, reported)
+	})
+
+	t.Run("blobs missing again are reported again", func(t *testing.T) {
+		found := missing[0].Blob.Digest
+		stored[string(found)] = true
+		reported, err := svc.Reconcile(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, reported)
+
+		delete(stored, string(found))
+		reported, err = svc.Reconcile(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 1, reported)
+	})
+}
+
+func TestStart(t *testing.T) {
+	clk := clock.NewMock()
+	clk.Set(time.Now())
+	allocs := allocationstore.NewDatastoreStore(sync.MutexWrap(datastore.NewMapDatastore()))
+	accs := acceptancestore.NewDatastoreStore(datastore.NewMapDatastore())
+
+	stale := randomAllocation(t, clk.Now().Add(30*time.Minute))
+	require.NoError(t, allocs.Put(t.Context(), stale))
+
+	svc := New(allocs, accs, hasBlobs(), datastore.NewMapDatastore(), time.Hour, 0, WithClock(clk))
+	require.NoError(t, svc.Start(t.Context()))
+	t.Cleanup(func() {
+		require.NoError(t, svc.Stop(context.Background()))
+	})
+
+	require.Eventually(t, func() bool {
+		// the allocation expires before the first reap
+		clk.Add(time.Hour)
+		_, err := allocs.Get(t.Context(), stale.Blob.Digest, stale.Space)
+		return errors.Is(err, store.ErrNotFound)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStartStopDisabled(t *testing.T) {
+	svc := New(nil, nil, hasBlobs(), datastore.NewMapDatastore(), 0, 0)
+	require.NoError(t, svc.Start(t.Context()))
+	require.NoError(t, svc.Stop(t.Context()))
+}
@@ -0,0 +1,84 @@
+package renewer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	leveldb "github.com/ipfs/go-ds-leveldb"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-ucanto/principal"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/service/publisher"
+	"github.com/storacha/piri/pkg/storageclass"
+	"github.com/storacha/piri/pkg/store/claimstore"
+)
+
+// Module renews expiring location claims. It decorates the publisher, so it
+// must be included at the root of the app rather than in another module for
+// the decoration to apply to all consumers of the publisher.
+var Module = fx.Decorate(DecoratePublisher)
+
+type Params struct {
+	fx.In
+
+	Publisher  publisher.Publisher
+	ID         principal.Signer
+	ClaimStore claimstore.ClaimStore
+	UCANCfg    app.UCANServiceConfig
+	StorageCfg app.StorageConfig
+	Classes    *storageclass.Manager `optional:"true"`
+	Clock      clock.Clock
+}
+
+// DecoratePublisher tracks the location claims published, renewing them
+// before they expire. Records are persisted in the data directory, or kept
+// in memory without one. Claims that do not expire need no renewal, the
+// publisher is returned unchanged.
+func DecoratePublisher(lc fx.Lifecycle, params Params) (publisher.Publisher, error) {
+	cfg := params.UCANCfg.LocationClaims
+	if cfg.Expiration == 0 {
+		return params.Publisher, nil
+	}
+
+	var ds datastore.Batching
+	if params.StorageCfg.DataDir == "" {
+		log.Warn("no data dir configured, expiring location claims issued before a restart will not be renewed")
+		ds = sync.MutexWrap(datastore.NewMapDatastore())
+	} else {
+		dir := filepath.Join(params.StorageCfg.DataDir, DataDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("creating directory: %s: %w", dir, err)
+		}
+		ldb, err := leveldb.NewDatastore(dir, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating claim renewal store: %w", err)
+		}
+		ds = ldb
+	}
+
+	svc, err := New(params.ID, ds, params.ClaimStore, params.Publisher, cfg, params.Classes, WithClock(params.Clock))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return svc.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			if err := svc.Stop(ctx); err != nil {
+				return err
+			}
+			return ds.Close()
+		},
+	})
+	return svc, nil
+}
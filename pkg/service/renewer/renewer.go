@@ -0,0 +1,531 @@
+// Package renewer renews location claims before they expire.
+//
+// Location claims issued with an expiration are no longer served by the
+// indexing service once they lapse, leaving their blob unretrievable through
+// it. Every location claim the node publishes is tracked with its expiry, and
+// claims close to expiring are re-issued with a new expiration, stored,
+// advertised to IPNI and cached with the indexing service. A renewal that
+// fails is retried on the next check and reported once it keeps failing.
+package renewer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/ucan"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/hwsigner"
+	"github.com/storacha/piri/pkg/service/publisher"
+	"github.com/storacha/piri/pkg/storageclass"
+	"github.com/storacha/piri/pkg/store/claimstore"
+)
+
+var log = logging.Logger("renewer")
+
+const (
+	// DataDir is the directory, relative to the data directory, holding the
+	// records of tracked claims.
+	DataDir = "claimrenewal"
+	// DefaultRenewInterval is how often claims due for renewal are checked if
+	// no interval is configured.
+	DefaultRenewInterval = time.Hour
+	// DefaultAlertAfter is the number of consecutive failed renewals of a
+	// claim after which the failure is reported, if not configured.
+	DefaultAlertAfter = 3
+
+	claimsPrefix  = "/claims/"
+	expiryPrefix  = "/expiry/"
+	failingPrefix = "/failing/"
+)
+
+// Record tracks the latest location claim for a blob in a space.
+type Record struct {
+	Space  did.DID             `json:"-"`
+	Digest multihash.Multihash `json:"-"`
+	// Claim is the CID of the claim.
+	Claim string `json:"claim"`
+	// Expiration is when the claim expires, in seconds since the unix epoch.
+	Expiration int64 `json:"expiration"`
+	// Failures is the number of consecutive failed renewals of the claim.
+	Failures  int    `json:"failures,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Expires returns when the claim expires.
+func (r Record) Expires() time.Time {
+	return time.Unix(r.Expiration, 0)
+}
+
+// Result summarises a check of the claims due for renewal.
+type Result struct {
+	Renewed int
+	Failed  int
+}
+
+// Service is a [publisher.Publisher] tracking the expiry of the location
+// claims it publishes, and renewing them before they expire.
+type Service struct {
+	publisher.Publisher
+
+	id          principal.Signer
+	ds          datastore.Datastore
+	claims      claimstore.ClaimStore
+	classes     *storageclass.Manager
+	lifetime    time.Duration
+	renewBefore time.Duration
+	interval    time.Duration
+	alertAfter  int
+	clock       clock.Clock
+	metrics     *metrics
+
+	// mu serializes the read-modify-write of records.
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var _ publisher.Publisher = (*Service)(nil)
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock sets the clock checks are scheduled by and claims expire
+// against. Defaults to the real clock.
+func WithClock(clock clock.Clock) Option {
+	return func(s *Service) {
+		s.clock = clock
+	}
+}
+
+// New creates a Service publishing claims with pub and persisting records in
+// ds. Renewed claims are stored in claimStore. The storage class manager is
+// optional, with it renewed claims keep the storage class of their blob.
+func New(
+	id principal.Signer,
+	ds datastore.Datastore,
+	claimStore claimstore.ClaimStore,
+	pub publisher.Publisher,
+	cfg app.LocationClaimsConfig,
+	classes *storageclass.Manager,
+	opts ...Option,
+) (*Service, error) {
+	if cfg.Expiration <= 0 {
+		return nil, errors.New("location claims do not expire, there is nothing to renew")
+	}
+	renewBefore := cfg.RenewBefore
+	if renewBefore <= 0 || renewBefore >= cfg.Expiration {
+		renewBefore = cfg.Expiration / 4
+	}
+	interval := cfg.RenewInterval
+	if interval <= 0 {
+		interval = DefaultRenewInterval
+	}
+	// check at least twice in the renewal window, so a claim gets another
+	// chance to renew if the first attempt fails
+	interval = min(interval, renewBefore/2)
+	alertAfter := int(cfg.AlertAfter)
+	if alertAfter <= 0 {
+		alertAfter = DefaultAlertAfter
+	}
+
+	m, err := newMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("creating renewer metrics: %w", err)
+	}
+	s := &Service{
+		Publisher:   pub,
+		id:          id,
+		ds:          ds,
+		claims:      claimStore,
+		classes:     classes,
+		lifetime:    cfg.Expiration,
+		renewBefore: renewBefore,
+		interval:    interval,
+		alertAfter:  alertAfter,
+		clock:       clock.New(),
+		metrics:     m,
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Publish publishes the claim and, for location claims, tracks its expiry.
+// Failing to track the claim does not fail the publication.
+func (s *Service) Publish(ctx context.Context, claim delegation.Delegation) error {
+	if err := s.Publisher.Publish(ctx, claim); err != nil {
+		return err
+	}
+	if err := s.Track(ctx, claim); err != nil {
+		log.Errorw("tracking location claim expiry", "claim", claim.Link(), "error", err)
+	}
+	return nil
+}
+
+// Retract retracts the advertisement of the blob and stops renewing its
+// location claim.
+func (s *Service) Retract(ctx context.Context, space did.DID, digest multihash.Multihash) error {
+	if err := s.Publisher.Retract(ctx, space, digest); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.remove(ctx, recordKey(space, digest))
+}
+
+// Track records the claim as the latest location claim for its blob in its
+// space, replacing any earlier claim. Claims that do not expire are not
+// renewed.
+func (s *Service) Track(ctx context.Context, claim delegation.Delegation) error {
+	capability := claim.Capabilities()[0]
+	if capability.Can() != assert.LocationAbility {
+		return nil
+	}
+	nb, err := assert.LocationCaveatsReader.Read(capability.Nb())
+	if err != nil {
+		return fmt.Errorf("reading location claim caveats: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := recordKey(nb.Space, nb.Content.Hash())
+	if err := s.remove(ctx, key); err != nil {
+		return err
+	}
+	if claim.Expiration() == nil {
+		return nil
+	}
+
+	rec := Record{
+		Space:      nb.Space,
+		Digest:     nb.Content.Hash(),
+		Claim:      claim.Link().String(),
+		Expiration: int64(*claim.Expiration()),
+	}
+	if err := s.put(ctx, key, rec); err != nil {
+		return err
+	}
+	if err := s.ds.Put(ctx, expiryKey(rec), []byte(key.String())); err != nil {
+		return fmt.Errorf("indexing location claim expiry: %w", err)
+	}
+	return nil
+}
+
+// Start checks for claims due for renewal in the background, immediately and
+// then every renew interval.
+func (s *Service) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	go func() {
+		defer close(s.done)
+		ticker := s.clock.Ticker(s.interval)
+		defer ticker.Stop()
+		for {
+			if res, err := s.RenewDue(runCtx); err != nil {
+				if runCtx.Err() != nil {
+					return
+				}
+				log.Errorw("renewing location claims", "error", err)
+			} else if res.Renewed > 0 || res.Failed > 0 {
+				log.Infow("renewed location claims", "renewed", res.Renewed, "failed", res.Failed)
+			}
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops checking for claims due for renewal.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for renewer to stop: %w", ctx.Err())
+	}
+}
+
+// RenewDue renews the claims expiring within the renewal window, including
+// claims that have already expired.
+func (s *Service) RenewDue(ctx context.Context) (Result, error) {
+	due, err := s.due(ctx, s.clock.Now().Add(s.renewBefore))
+	if err != nil {
+		return Result{}, err
+	}
+
+	var res Result
+	for _, key := range due {
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		if err := s.renew(ctx, key); err != nil {
+			if ctx.Err() != nil {
+				return res, ctx.Err()
+			}
+			res.Failed++
+			s.metrics.renewals.Inc(ctx, attribute.String("result", "failed"))
+			if err := s.fail(ctx, key, err); err != nil {
+				log.Errorw("recording failed location claim renewal", "key", key, "error", err)
+			}
+			continue
+		}
+		res.Renewed++
+		s.metrics.renewals.Inc(ctx, attribute.String("result", "ok"))
+	}
+
+	failing, err := s.Failing(ctx)
+	if err != nil {
+		return res, err
+	}
+	s.metrics.failing.Record(ctx, int64(len(failing)))
+	return res, nil
+}
+
+// Failing returns the records of claims whose renewal has failed at least
+// as many consecutive times as the alert threshold.
+func (s *Service) Failing(ctx context.Context) ([]Record, error) {
+	results, err := s.ds.Query(ctx, query.Query{Prefix: failingPrefix, KeysOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("querying failing location claims: %w", err)
+	}
+	var keys []datastore.Key
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			results.Close()
+			return nil, fmt.Errorf("iterating failing location claims: %w", entry.Error)
+		}
+		keys = append(keys, datastore.NewKey(claimsPrefix+strings.TrimPrefix(entry.Key, failingPrefix)))
+	}
+	results.Close()
+
+	records := make([]Record, 0, len(keys))
+	for _, key := range keys {
+		rec, ok, err := s.get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+// due returns the keys of the records of claims expiring before deadline,
+// soonest first.
+func (s *Service) due(ctx context.Context, deadline time.Time) ([]datastore.Key, error) {
+	results, err := s.ds.Query(ctx, query.Query{
+		Prefix: expiryPrefix,
+		Orders: []query.Order{query.OrderByKey{}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying location claim expiries: %w", err)
+	}
+	defer results.Close()
+
+	var keys []datastore.Key
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating location claim expiries: %w", entry.Error)
+		}
+		ts, _, _ := strings.Cut(strings.TrimPrefix(entry.Key, expiryPrefix), "/")
+		exp, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing location claim expiry of %s: %w", entry.Key, err)
+		}
+		if time.Unix(exp, 0).After(deadline) {
+			break
+		}
+		keys = append(keys, datastore.NewKey(string(entry.Value)))
+	}
+	return keys, nil
+}
+
+// renew issues a new claim for the blob of the record, valid for the
+// configured lifetime, and stores, publishes and tracks it.
+func (s *Service) renew(ctx context.Context, key datastore.Key) error {
+	rec, ok, err := s.get(ctx, key)
+	if err != nil || !ok {
+		// a claim tracked since the expiry was read no longer needs renewal
+		return err
+	}
+	c, err := cid.Parse(rec.Claim)
+	if err != nil {
+		return fmt.Errorf("parsing location claim CID: %w", err)
+	}
+	prev, err := s.claims.Get(ctx, cidlink.Link{Cid: c})
+	if err != nil {
+		return fmt.Errorf("getting location claim %s: %w", c, err)
+	}
+	nb, err := assert.LocationCaveatsReader.Read(prev.Capabilities()[0].Nb())
+	if err != nil {
+		return fmt.Errorf("reading location claim caveats: %w", err)
+	}
+
+	opts := []delegation.Option{
+		delegation.WithExpiration(ucan.UTCUnixTimestamp(s.clock.Now().Add(s.lifetime).Unix())),
+	}
+	facts := hwsigner.ClaimFacts(s.id)
+	if s.classes != nil {
+		name, ok, err := s.classes.ClassOf(ctx, nb.Content.Hash())
+		if err != nil {
+			return fmt.Errorf("getting storage class of blob: %w", err)
+		}
+		if class, known := s.classes.Class(name); ok && known {
+			facts = append(facts, storageclass.Fact{Class: class})
+		}
+	}
+	if len(facts) > 0 {
+		opts = append(opts, delegation.WithFacts(facts))
+	}
+
+	claim, err := assert.Location.Delegate(s.id, prev.Audience(), s.id.DID().String(), nb, opts...)
+	if err != nil {
+		return fmt.Errorf("creating location commitment: %w", err)
+	}
+	if err := s.claims.Put(ctx, claim); err != nil {
+		return fmt.Errorf("putting location claim: %w", err)
+	}
+	if err := s.Publisher.Publish(ctx, claim); err != nil {
+		return fmt.Errorf("publishing location commitment: %w", err)
+	}
+	return s.Track(ctx, claim)
+}
+
+// fail records a failed renewal, reporting it once the claim has failed to
+// renew the alert threshold number of times in a row.
+func (s *Service) fail(ctx context.Context, key datastore.Key, cause error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok, err := s.get(ctx, key)
+	if err != nil || !ok {
+		return err
+	}
+	rec.Failures++
+	rec.LastError = cause.Error()
+	if err := s.put(ctx, key, rec); err != nil {
+		return err
+	}
+
+	l := log.With("space", rec.Space, "digest", digestutil.Format(rec.Digest), "claim", rec.Claim, "expires", rec.Expires(), "failures", rec.Failures, "error", cause)
+	if rec.Failures < s.alertAfter {
+		l.Warnw("failed to renew location claim, retrying on the next check")
+		return nil
+	}
+	if s.clock.Now().After(rec.Expires()) {
+		l.Errorw("location claim has expired and repeatedly failed to renew, the blob may not be retrievable through the indexing service")
+	} else {
+		l.Errorw("location claim repeatedly failed to renew")
+	}
+	if err := s.ds.Put(ctx, failingKey(key), nil); err != nil {
+		return fmt.Errorf("marking location claim renewal as failing: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the record at key and its index entries, if it exists.
+func (s *Service) remove(ctx context.Context, key datastore.Key) error {
+	rec, ok, err := s.get(ctx, key)
+	if err != nil || !ok {
+		return err
+	}
+	for _, k := range []datastore.Key{expiryKey(rec), failingKey(key), key} {
+		if err := s.ds.Delete(ctx, k); err != nil {
+			return fmt.Errorf("removing location claim record: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) get(ctx context.Context, key datastore.Key) (Record, bool, error) {
+	data, err := s.ds.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return Record{}, false, nil
+		}
+		return Record{}, false, fmt.Errorf("getting location claim record: %w", err)
+	}
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return Record{}, false, fmt.Errorf("decoding location claim record: %w", err)
+	}
+	space, digest, err := parseRecordKey(key)
+	if err != nil {
+		return Record{}, false, err
+	}
+	rec.Space, rec.Digest = space, digest
+	return rec, true, nil
+}
+
+func (s *Service) put(ctx context.Context, key datastore.Key, rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding location claim record: %w", err)
+	}
+	if err := s.ds.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("putting location claim record: %w", err)
+	}
+	return nil
+}
+
+func recordKey(space did.DID, digest multihash.Multihash) datastore.Key {
+	return datastore.NewKey(claimsPrefix + space.String() + "/" + digestutil.Format(digest))
+}
+
+func parseRecordKey(key datastore.Key) (did.DID, multihash.Multihash, error) {
+	spaceStr, digestStr, ok := strings.Cut(strings.TrimPrefix(key.String(), claimsPrefix), "/")
+	if !ok {
+		return did.DID{}, nil, fmt.Errorf("invalid location claim record key %s", key)
+	}
+	space, err := did.Parse(spaceStr)
+	if err != nil {
+		return did.DID{}, nil, fmt.Errorf("parsing space of %s: %w", key, err)
+	}
+	digest, err := digestutil.Parse(digestStr)
+	if err != nil {
+		return did.DID{}, nil, fmt.Errorf("parsing digest of %s: %w", key, err)
+	}
+	return space, digest, nil
+}
+
+// expiryKey orders records by expiry: the expiration is zero padded so keys
+// sort in time order.
+func expiryKey(rec Record) datastore.Key {
+	return datastore.NewKey(fmt.Sprintf("%s%020d/%s/%s", expiryPrefix, rec.Expiration, rec.Space, digestutil.Format(rec.Digest)))
+}
+
+func failingKey(key datastore.Key) datastore.Key {
+	return datastore.NewKey(failingPrefix + strings.TrimPrefix(key.String(), claimsPrefix))
+}
@@ -0,0 +1,196 @@
+package renewer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/multiformats/go-multihash"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-libstoracha/ipnipublisher/store"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/store/claimstore"
+	"github.com/storacha/piri/pkg/store/delegationstore"
+)
+
+type mockPublisher struct {
+	published []delegation.Delegation
+	err       error
+}
+
+func (m *mockPublisher) Store() store.PublisherStore {
+	return nil
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, d delegation.Delegation) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.published = append(m.published, d)
+	return nil
+}
+
+func (m *mockPublisher) Retract(ctx context.Context, space did.DID, digest multihash.Multihash) error {
+	return nil
+}
+
+func newTestService(t *testing.T, pub *mockPublisher, opts ...Option) (*Service, claimstore.ClaimStore) {
+	t.Helper()
+	claims := delegationstore.NewDatastoreStore(datastore.NewMapDatastore())
+	svc, err := New(testutil.Alice, datastore.NewMapDatastore(), claims, pub, app.LocationClaimsConfig{
+		Expiration:  time.Hour,
+		RenewBefore: 30 * time.Minute,
+		AlertAfter:  2,
+	}, nil, opts...)
+	require.NoError(t, err)
+	return svc, claims
+}
+
+// issue stores a location claim expiring after d, no expiration if d is 0,
+// and publishes it through the service.
+func issue(t *testing.T, svc *Service, claims claimstore.ClaimStore, d time.Duration) delegation.Delegation {
+	t.Helper()
+	u, err := url.Parse("https://storage.example.com/blob/1")
+	require.NoError(t, err)
+	opt := delegation.WithNoExpiration()
+	if d > 0 {
+		opt = delegation.WithExpiration(ucan.UTCUnixTimestamp(time.Now().Add(d).Unix()))
+	}
+	claim, err := assert.Location.Delegate(
+		testutil.Alice,
+		testutil.Bob,
+		testutil.Alice.DID().String(),
+		assert.LocationCaveats{
+			Space:    testutil.RandomDID(t),
+			Content:  types.FromHash(testutil.RandomMultihash(t)),
+			Location: []url.URL{*u},
+		},
+		opt,
+	)
+	require.NoError(t, err)
+	require.NoError(t, claims.Put(t.Context(), claim))
+	require.NoError(t, svc.Publish(t.Context(), claim))
+	return claim
+}
+
+func TestRenewDue(t *testing.T) {
+	t.Run("renews claims expiring within the renewal window", func(t *testing.T) {
+		pub := &mockPublisher{}
+		svc, claims := newTestService(t, pub)
+		expiring := issue(t, svc, claims, 10*time.Minute)
+		issue(t, svc, claims, 50*time.Minute)
+
+		res, err := svc.RenewDue(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, Result{Renewed: 1}, res)
+
+		require.Len(t, pub.published, 3)
+		renewed := pub.published[2]
+		require.NotEqual(t, expiring.Link(), renewed.Link())
+		require.Greater(t, *renewed.Expiration(), *expiring.Expiration())
+		require.Equal(t, expiring.Audience().DID(), renewed.Audience().DID())
+
+		prev, err := assert.LocationCaveatsReader.Read(expiring.Capabilities()[0].Nb())
+		require.NoError(t, err)
+		next, err := assert.LocationCaveatsReader.Read(renewed.Capabilities()[0].Nb())
+		require.NoError(t, err)
+		require.Equal(t, prev.Space, next.Space)
+		require.Equal(t, prev.Content.Hash(), next.Content.Hash())
+
+		_, err = claims.Get(t.Context(), renewed.Link())
+		require.NoError(t, err)
+
+		// the renewed claim is not due again
+		res, err = svc.RenewDue(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, res)
+	})
+
+	t.Run("renews claims once the clock reaches their renewal window", func(t *testing.T) {
+		clk := clock.NewMock()
+		clk.Set(time.Now())
+		pub := &mockPublisher{}
+		svc, claims := newTestService(t, pub, WithClock(clk))
+		issue(t, svc, claims, 50*time.Minute)
+
+		res, err := svc.RenewDue(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, res)
+
+		clk.Add(25 * time.Minute)
+		res, err = svc.RenewDue(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, Result{Renewed: 1}, res)
+
+		// the renewed claim expires a lifetime after the clock
+		require.Len(t, pub.published, 2)
+		renewed := pub.published[1]
+		require.Equal(t, ucan.UTCUnixTimestamp(clk.Now().Add(time.Hour).Unix()), *renewed.Expiration())
+	})
+
+	t.Run("does not track claims that do not expire", func(t *testing.T) {
+		pub := &mockPublisher{}
+		svc, claims := newTestService(t, pub)
+		issue(t, svc, claims, 0)
+
+		due, err := svc.due(t.Context(), time.Now().Add(100*365*24*time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, due)
+	})
+
+	t.Run("stops renewing retracted claims", func(t *testing.T) {
+		pub := &mockPublisher{}
+		svc, claims := newTestService(t, pub)
+		claim := issue(t, svc, claims, 10*time.Minute)
+		nb, err := assert.LocationCaveatsReader.Read(claim.Capabilities()[0].Nb())
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Retract(t.Context(), nb.Space, nb.Content.Hash()))
+
+		res, renewErr := svc.RenewDue(t.Context())
+		require.NoError(t, renewErr)
+		require.Zero(t, res)
+	})
+
+	t.Run("reports claims that repeatedly fail to renew", func(t *testing.T) {
+		pub := &mockPublisher{}
+		svc, claims := newTestService(t, pub)
+		issue(t, svc, claims, 10*time.Minute)
+		pub.err = errors.New("indexing service unavailable")
+
+		res, err := svc.RenewDue(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, Result{Failed: 1}, res)
+		failing, err := svc.Failing(t.Context())
+		require.NoError(t, err)
+		require.Empty(t, failing)
+
+		_, err = svc.RenewDue(t.Context())
+		require.NoError(t, err)
+		failing, err = svc.Failing(t.Context())
+		require.NoError(t, err)
+		require.Len(t, failing, 1)
+		require.Equal(t, 2, failing[0].Failures)
+		require.Equal(t, "publishing location commitment: indexing service unavailable", failing[0].LastError)
+
+		// a successful renewal clears the failure
+		pub.err = nil
+		res, err = svc.RenewDue(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, Result{Renewed: 1}, res)
+		failing, err = svc.Failing(t.Context())
+		require.NoError(t, err)
+		require.Empty(t, failing)
+	})
+}
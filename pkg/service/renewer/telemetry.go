@@ -0,0 +1,35 @@
+package renewer
+
+import (
+	"go.opentelemetry.io/otel"
+
+	"github.com/storacha/piri/lib/telemetry"
+)
+
+type metrics struct {
+	renewals *telemetry.Counter
+	failing  *telemetry.Int64Gauge
+}
+
+func newMetrics() (*metrics, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/service/renewer")
+	renewals, err := telemetry.NewCounter(
+		meter,
+		"piri_claim_renewals",
+		"location claim renewals, by result (ok or failed)",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	failing, err := telemetry.NewInt64Gauge(
+		meter,
+		"piri_claim_renewal_failing",
+		"location claims that repeatedly failed to renew",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &metrics{renewals: renewals, failing: failing}, nil
+}
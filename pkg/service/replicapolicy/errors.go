@@ -0,0 +1,41 @@
+package replicapolicy
+
+import (
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/ipld"
+)
+
+// RejectedErrorName is the name of the failure returned in receipts when a
+// replica allocation breaks the replica policy.
+const RejectedErrorName = "ReplicaRejected"
+
+// RejectedError is returned when a replica allocation breaks a rule of the
+// policy. The rule is included in the failure, so the upload service can
+// tell rejections apart and pick another node.
+type RejectedError struct {
+	// Rule is the name of the rule broken.
+	Rule   string
+	Reason string
+}
+
+func (re RejectedError) Name() string {
+	return RejectedErrorName
+}
+
+func (re RejectedError) Error() string {
+	return "replica rejected by " + re.Rule + " policy: " + re.Reason
+}
+
+func (re RejectedError) ToIPLD() (ipld.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Map, 3, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "name", qp.String(re.Name()))
+		qp.MapEntry(ma, "message", qp.String(re.Error()))
+		qp.MapEntry(ma, "rule", qp.String(re.Rule))
+	})
+}
+
+func NewRejectedError(rule, reason string) *RejectedError {
+	return &RejectedError{rule, reason}
+}
@@ -0,0 +1,46 @@
+package replicapolicy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/shirou/gopsutil/v4/disk"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+var Module = fx.Module("replicapolicy",
+	fx.Provide(NewEngineFromParams),
+)
+
+type Params struct {
+	fx.In
+
+	Datastore     datastore.Datastore `name:"replicapolicy_datastore"`
+	StorageConfig app.StorageConfig
+	Rules         []Rule `group:"replica_policy_rules"`
+}
+
+// NewEngineFromParams creates the replica policy engine. Free capacity is
+// that of the data directory, and is not checked when blobs are stored in
+// S3.
+func NewEngineFromParams(params Params) (*Engine, error) {
+	opts := []Option{WithRules(params.Rules...)}
+	if params.StorageConfig.S3 == nil && params.StorageConfig.DataDir != "" {
+		dir := params.StorageConfig.DataDir
+		opts = append(opts, WithCapacity(func(ctx context.Context) (uint64, error) {
+			usage, err := disk.UsageWithContext(ctx, dir)
+			if err != nil {
+				return 0, err
+			}
+			return usage.Free, nil
+		}))
+	}
+	e, err := New(context.Background(), params.Datastore, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating replica policy engine: %w", err)
+	}
+	return e, nil
+}
@@ -0,0 +1,196 @@
+// Package replicapolicy decides whether the node accepts replica allocations.
+//
+// The upload service chooses the nodes a blob is replicated to, and a node
+// otherwise accepts every `blob/replica/allocate` it is sent. A policy lets
+// operators limit the replicas the node takes on: by size, by the node the
+// replica is transferred from, by the hosts it is transferred from and by the
+// capacity left free on the node. The policy is evaluated by an [Engine] of
+// [Rule]s before a replica is allocated, and requests breaking a rule are
+// rejected with a [RejectedError] naming it.
+package replicapolicy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/did"
+)
+
+var log = logging.Logger("replicapolicy")
+
+var policyKey = datastore.NewKey("policy")
+
+// Policy are the limits replica allocations must be within. Zero values
+// impose no limit, so the zero policy accepts every replica.
+type Policy struct {
+	// MaxReplicaSize is the size in bytes of the largest blob accepted as a
+	// replica.
+	MaxReplicaSize uint64 `json:"max_replica_size,omitempty"`
+	// AllowedSources are the DIDs of the nodes replicas may be transferred
+	// from. Empty allows any node.
+	AllowedSources []string `json:"allowed_sources,omitempty"`
+	// ExcludedSources are the DIDs of nodes replicas are never transferred
+	// from.
+	ExcludedSources []string `json:"excluded_sources,omitempty"`
+	// ExcludedHosts are hosts replicas are never transferred from. An entry
+	// starting with a dot excludes every host in the domain, e.g. to exclude
+	// a provider or a region.
+	ExcludedHosts []string `json:"excluded_hosts,omitempty"`
+	// MinFreeCapacity is the number of bytes that must remain free on the
+	// node once a replica is stored.
+	MinFreeCapacity uint64 `json:"min_free_capacity,omitempty"`
+}
+
+// Validate checks the sources are DIDs and the hosts are not empty.
+func (p Policy) Validate() error {
+	for _, s := range append(append([]string{}, p.AllowedSources...), p.ExcludedSources...) {
+		if _, err := did.Parse(s); err != nil {
+			return fmt.Errorf("invalid source DID %q: %w", s, err)
+		}
+	}
+	for _, h := range p.ExcludedHosts {
+		if strings.Trim(h, ".") == "" {
+			return fmt.Errorf("invalid excluded host %q", h)
+		}
+	}
+	return nil
+}
+
+// Request is a replica allocation evaluated against the policy.
+type Request struct {
+	Space did.DID
+	Blob  types.Blob
+	// Source is the node holding the blob, the issuer of its location claim.
+	Source did.DID
+	// Locations are the URLs the blob may be transferred from.
+	Locations []url.URL
+}
+
+// Rule is a condition replica allocations must meet. Rules beyond the built
+// in ones are added to an engine with [WithRules].
+type Rule interface {
+	// Name identifies the rule in rejections.
+	Name() string
+	// Check returns a [RejectedError] if the request breaks the rule under
+	// the policy, or another error if it cannot be checked.
+	Check(ctx context.Context, policy Policy, req Request) error
+}
+
+// CapacityFunc returns the number of bytes free to store blobs in.
+type CapacityFunc func(ctx context.Context) (uint64, error)
+
+// Engine evaluates replica allocations against the policy set by the
+// operator, which is persisted in a datastore.
+type Engine struct {
+	ds    datastore.Datastore
+	rules []Rule
+
+	mu     sync.RWMutex
+	policy Policy
+}
+
+type Option func(*Engine)
+
+// WithRules adds rules evaluated after the built in ones.
+func WithRules(rules ...Rule) Option {
+	return func(e *Engine) {
+		e.rules = append(e.rules, rules...)
+	}
+}
+
+// WithCapacity enables the free capacity rule, with fn reporting the space
+// free to store blobs in.
+func WithCapacity(fn CapacityFunc) Option {
+	return func(e *Engine) {
+		e.rules = append(e.rules, freeCapacityRule{capacity: fn})
+	}
+}
+
+// New creates an engine evaluating the policy stored in ds.
+func New(ctx context.Context, ds datastore.Datastore, opts ...Option) (*Engine, error) {
+	e := &Engine{
+		ds: ds,
+		rules: []Rule{
+			maxSizeRule{},
+			allowedSourcesRule{},
+			excludedSourcesRule{},
+			excludedHostsRule{},
+		},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	data, err := ds.Get(ctx, policyKey)
+	if err != nil && !errors.Is(err, datastore.ErrNotFound) {
+		return nil, fmt.Errorf("reading replica policy: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &e.policy); err != nil {
+			return nil, fmt.Errorf("decoding replica policy: %w", err)
+		}
+	}
+	return e, nil
+}
+
+// Policy returns the policy in effect.
+func (e *Engine) Policy() Policy {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.policy
+}
+
+// SetPolicy replaces the policy, taking effect for the next allocation.
+func (e *Engine) SetPolicy(ctx context.Context, policy Policy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("encoding replica policy: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.ds.Put(ctx, policyKey, data); err != nil {
+		return fmt.Errorf("storing replica policy: %w", err)
+	}
+	e.policy = policy
+	log.Infow("replica policy updated", "policy", string(data))
+	return nil
+}
+
+// Evaluate checks the request against every rule, returning a
+// [RejectedError] for the first rule it breaks. Accepted requests return the
+// locations the replica may be transferred from, those not on excluded hosts.
+func (e *Engine) Evaluate(ctx context.Context, req Request) ([]url.URL, error) {
+	policy := e.Policy()
+	for _, rule := range e.rules {
+		err := rule.Check(ctx, policy, req)
+		if err == nil {
+			continue
+		}
+		var re *RejectedError
+		if errors.As(err, &re) {
+			log.Infow("rejected replica", "space", req.Space, "blob", req.Blob.Digest, "source", req.Source, "rule", re.Rule, "reason", re.Reason)
+			return nil, err
+		}
+		return nil, fmt.Errorf("checking replica policy rule %s: %w", rule.Name(), err)
+	}
+
+	locations := make([]url.URL, 0, len(req.Locations))
+	for _, l := range req.Locations {
+		if !excludedHost(policy.ExcludedHosts, l.Hostname()) {
+			locations = append(locations, l)
+		}
+	}
+	return locations, nil
+}
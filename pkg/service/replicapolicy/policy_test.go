@@ -0,0 +1,125 @@
+package replicapolicy
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func testLocations(t *testing.T, urls ...string) []url.URL {
+	var out []url.URL
+	for _, s := range urls {
+		u, err := url.Parse(s)
+		require.NoError(t, err)
+		out = append(out, *u)
+	}
+	return out
+}
+
+type denyAll struct{}
+
+func (denyAll) Name() string { return "deny_all" }
+
+func (denyAll) Check(context.Context, Policy, Request) error {
+	return NewRejectedError("deny_all", "no")
+}
+
+func TestEngine(t *testing.T) {
+	ctx := context.Background()
+	source := testutil.RandomDID(t)
+	other := testutil.RandomDID(t)
+	request := Request{
+		Space:     testutil.RandomDID(t),
+		Blob:      types.Blob{Digest: testutil.RandomMultihash(t), Size: 1000},
+		Source:    source,
+		Locations: testLocations(t, "https://a.eu.example.com/blob", "https://b.us.example.com/blob"),
+	}
+
+	newEngine := func(t *testing.T, policy Policy, opts ...Option) *Engine {
+		e, err := New(ctx, sync.MutexWrap(datastore.NewMapDatastore()), opts...)
+		require.NoError(t, err)
+		require.NoError(t, e.SetPolicy(ctx, policy))
+		return e
+	}
+	rejectedBy := func(t *testing.T, err error) string {
+		var re *RejectedError
+		require.True(t, errors.As(err, &re), "expected a rejection, got %v", err)
+		return re.Rule
+	}
+
+	t.Run("accepts everything without a policy", func(t *testing.T) {
+		locations, err := newEngine(t, Policy{}).Evaluate(ctx, request)
+		require.NoError(t, err)
+		require.Equal(t, request.Locations, locations)
+	})
+
+	t.Run("rejects replicas over the maximum size", func(t *testing.T) {
+		_, err := newEngine(t, Policy{MaxReplicaSize: 999}).Evaluate(ctx, request)
+		require.Equal(t, MaxSizeRule, rejectedBy(t, err))
+
+		_, err = newEngine(t, Policy{MaxReplicaSize: 1000}).Evaluate(ctx, request)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects sources not allowed", func(t *testing.T) {
+		_, err := newEngine(t, Policy{AllowedSources: []string{other.String()}}).Evaluate(ctx, request)
+		require.Equal(t, AllowedSourcesRule, rejectedBy(t, err))
+
+		_, err = newEngine(t, Policy{AllowedSources: []string{other.String(), source.String()}}).Evaluate(ctx, request)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects excluded sources", func(t *testing.T) {
+		_, err := newEngine(t, Policy{ExcludedSources: []string{source.String()}}).Evaluate(ctx, request)
+		require.Equal(t, ExcludedSourcesRule, rejectedBy(t, err))
+	})
+
+	t.Run("transfers only from hosts not excluded", func(t *testing.T) {
+		locations, err := newEngine(t, Policy{ExcludedHosts: []string{".eu.example.com"}}).Evaluate(ctx, request)
+		require.NoError(t, err)
+		require.Equal(t, request.Locations[1:], locations)
+
+		_, err = newEngine(t, Policy{ExcludedHosts: []string{".example.com"}}).Evaluate(ctx, request)
+		require.Equal(t, ExcludedHostsRule, rejectedBy(t, err))
+	})
+
+	t.Run("keeps free capacity", func(t *testing.T) {
+		capacity := WithCapacity(func(context.Context) (uint64, error) { return 5000, nil })
+		_, err := newEngine(t, Policy{MinFreeCapacity: 4001}, capacity).Evaluate(ctx, request)
+		require.Equal(t, FreeCapacityRule, rejectedBy(t, err))
+
+		_, err = newEngine(t, Policy{MinFreeCapacity: 4000}, capacity).Evaluate(ctx, request)
+		require.NoError(t, err)
+	})
+
+	t.Run("evaluates added rules", func(t *testing.T) {
+		_, err := newEngine(t, Policy{}, WithRules(denyAll{})).Evaluate(ctx, request)
+		require.Equal(t, "deny_all", rejectedBy(t, err))
+	})
+
+	t.Run("persists the policy", func(t *testing.T) {
+		ds := sync.MutexWrap(datastore.NewMapDatastore())
+		e, err := New(ctx, ds)
+		require.NoError(t, err)
+		policy := Policy{MaxReplicaSize: 10, ExcludedHosts: []string{"bad.example.com"}}
+		require.NoError(t, e.SetPolicy(ctx, policy))
+
+		e, err = New(ctx, ds)
+		require.NoError(t, err)
+		require.Equal(t, policy, e.Policy())
+	})
+
+	t.Run("rejects invalid policies", func(t *testing.T) {
+		e := newEngine(t, Policy{})
+		require.Error(t, e.SetPolicy(ctx, Policy{AllowedSources: []string{"not a did"}}))
+		require.Error(t, e.SetPolicy(ctx, Policy{ExcludedHosts: []string{"."}}))
+		require.Equal(t, Policy{}, e.Policy())
+	})
+}
@@ -0,0 +1,104 @@
+package replicapolicy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Names of the built in rules, reported in rejections.
+const (
+	MaxSizeRule         = "max_replica_size"
+	AllowedSourcesRule  = "allowed_sources"
+	ExcludedSourcesRule = "excluded_sources"
+	ExcludedHostsRule   = "excluded_hosts"
+	FreeCapacityRule    = "min_free_capacity"
+)
+
+type maxSizeRule struct{}
+
+func (maxSizeRule) Name() string { return MaxSizeRule }
+
+func (maxSizeRule) Check(_ context.Context, p Policy, req Request) error {
+	if p.MaxReplicaSize > 0 && req.Blob.Size > p.MaxReplicaSize {
+		return NewRejectedError(MaxSizeRule, fmt.Sprintf("replica of %d bytes exceeds the maximum of %d bytes", req.Blob.Size, p.MaxReplicaSize))
+	}
+	return nil
+}
+
+type allowedSourcesRule struct{}
+
+func (allowedSourcesRule) Name() string { return AllowedSourcesRule }
+
+func (allowedSourcesRule) Check(_ context.Context, p Policy, req Request) error {
+	if len(p.AllowedSources) > 0 && !slices.Contains(p.AllowedSources, req.Source.String()) {
+		return NewRejectedError(AllowedSourcesRule, fmt.Sprintf("replicas are not accepted from %s", req.Source))
+	}
+	return nil
+}
+
+type excludedSourcesRule struct{}
+
+func (excludedSourcesRule) Name() string { return ExcludedSourcesRule }
+
+func (excludedSourcesRule) Check(_ context.Context, p Policy, req Request) error {
+	if slices.Contains(p.ExcludedSources, req.Source.String()) {
+		return NewRejectedError(ExcludedSourcesRule, fmt.Sprintf("replicas are not accepted from %s", req.Source))
+	}
+	return nil
+}
+
+// excludedHostsRule rejects replicas that can only be transferred from
+// excluded hosts. Replicas with other locations are accepted, and only
+// transferred from those.
+type excludedHostsRule struct{}
+
+func (excludedHostsRule) Name() string { return ExcludedHostsRule }
+
+func (excludedHostsRule) Check(_ context.Context, p Policy, req Request) error {
+	if len(p.ExcludedHosts) == 0 {
+		return nil
+	}
+	for _, l := range req.Locations {
+		if !excludedHost(p.ExcludedHosts, l.Hostname()) {
+			return nil
+		}
+	}
+	return NewRejectedError(ExcludedHostsRule, "every location of the blob is on an excluded host")
+}
+
+func excludedHost(excluded []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, e := range excluded {
+		e = strings.ToLower(e)
+		if strings.HasPrefix(e, ".") {
+			if host == e[1:] || strings.HasSuffix(host, e) {
+				return true
+			}
+		} else if host == e {
+			return true
+		}
+	}
+	return false
+}
+
+type freeCapacityRule struct {
+	capacity CapacityFunc
+}
+
+func (freeCapacityRule) Name() string { return FreeCapacityRule }
+
+func (r freeCapacityRule) Check(ctx context.Context, p Policy, req Request) error {
+	if p.MinFreeCapacity == 0 {
+		return nil
+	}
+	free, err := r.capacity(ctx)
+	if err != nil {
+		return fmt.Errorf("reading free capacity: %w", err)
+	}
+	if free < req.Blob.Size || free-req.Blob.Size < p.MinFreeCapacity {
+		return NewRejectedError(FreeCapacityRule, fmt.Sprintf("storing %d bytes would leave less than %d of %d bytes free", req.Blob.Size, p.MinFreeCapacity, free))
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package replicator
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"go.opentelemetry.io/otel"
+
+	"github.com/storacha/piri/lib/jobqueue/dialect"
+	"github.com/storacha/piri/lib/telemetry"
+	replicahandler "github.com/storacha/piri/pkg/service/storage/handlers/replica"
+)
+
+// ErrDeadLetterNotFound is returned when managing a transfer that is not
+// dead-lettered.
+var ErrDeadLetterNotFound = errors.New("dead-lettered transfer not found")
+
+// DefaultDeadLetterLimit is the number of dead-lettered transfers listed if
+// no limit is given.
+const DefaultDeadLetterLimit = 100
+
+// DeadLetter is a transfer that failed permanently or too many times, kept
+// with the cause of its failure until it is requeued or discarded.
+type DeadLetter struct {
+	// JobID is the ID of the transfer job in the replication queue.
+	JobID  string
+	Digest multihash.Multihash
+	Space  string
+	// Source is the URL the blob was requested from.
+	Source string
+	// Sink is the URL the blob was to be written to, empty if the blob was
+	// already held.
+	Sink string
+	// Error is the error of the last attempt.
+	Error    string
+	Cause    replicahandler.Cause
+	FailedAt time.Time
+}
+
+// failedAtFormat sorts failure times as text.
+const failedAtFormat = "2006-01-02T15:04:05.000000000Z"
+
+const deadLetterSchema = `
+create table if not exists replica_dead_letters (
+  job_id text primary key,
+  digest text not null,
+  space text not null,
+  source text not null,
+  sink text not null,
+  error text not null,
+  cause text not null,
+  failed_at text not null
+)`
+
+// DeadLetters persists failed transfers in the replicator database, and
+// reports their number as piri_replication_dead_letters.
+type DeadLetters struct {
+	db      *sql.DB
+	dialect dialect.Dialect
+	depth   *telemetry.Int64Gauge
+}
+
+// NewDeadLetters creates the store of failed transfers in db.
+func NewDeadLetters(ctx context.Context, db *sql.DB, d dialect.Dialect) (*DeadLetters, error) {
+	if _, err := db.ExecContext(ctx, deadLetterSchema); err != nil {
+		return nil, fmt.Errorf("creating dead letter table: %w", err)
+	}
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/service/replicator")
+	depth, err := telemetry.NewInt64Gauge(
+		meter,
+		"piri_replication_dead_letters",
+		"replica transfers that failed and were not requeued or discarded",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	dl := &DeadLetters{db: db, dialect: d, depth: depth}
+	dl.recordDepth(ctx)
+	return dl, nil
+}
+
+// Put records the failed transfer, replacing an earlier failure of its job.
+func (d *DeadLetters) Put(ctx context.Context, l DeadLetter) error {
+	cause, err := json.Marshal(l.Cause)
+	if err != nil {
+		return fmt.Errorf("encoding cause: %w", err)
+	}
+	query := d.dialect.Rebind(`
+		INSERT INTO replica_dead_letters (job_id, digest, space, source, sink, error, cause, failed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (job_id) DO UPDATE SET
+			error = excluded.error, cause = excluded.cause, failed_at = excluded.failed_at`)
+	if _, err := d.db.ExecContext(ctx, query,
+		l.JobID, digestutil.Format(l.Digest), l.Space, l.Source, l.Sink, l.Error, string(cause),
+		l.FailedAt.UTC().Format(failedAtFormat),
+	); err != nil {
+		return fmt.Errorf("recording dead letter %s: %w", l.JobID, err)
+	}
+	d.recordDepth(ctx)
+	return nil
+}
+
+// List returns up to limit failed transfers, oldest first. A limit of zero
+// uses DefaultDeadLetterLimit.
+func (d *DeadLetters) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	if limit <= 0 {
+		limit = DefaultDeadLetterLimit
+	}
+	query := d.dialect.Rebind(`
+		SELECT job_id, digest, space, source, sink, error, cause, failed_at
+		FROM replica_dead_letters ORDER BY failed_at LIMIT ?`)
+	rows, err := d.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing dead letters: %w", err)
+	}
+	defer rows.Close()
+	var letters []DeadLetter
+	for rows.Next() {
+		l, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+// Get returns the failed transfer of the job.
+func (d *DeadLetters) Get(ctx context.Context, jobID string) (DeadLetter, error) {
+	query := d.dialect.Rebind(`
+		SELECT job_id, digest, space, source, sink, error, cause, failed_at
+		FROM replica_dead_letters WHERE job_id = ?`)
+	l, err := scanDeadLetter(d.db.QueryRowContext(ctx, query, jobID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return DeadLetter{}, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, jobID)
+	}
+	return l, err
+}
+
+// Remove removes the failed transfer of the job, if any.
+func (d *DeadLetters) Remove(ctx context.Context, jobID string) error {
+	res, err := d.db.ExecContext(ctx, d.dialect.Rebind(`DELETE FROM replica_dead_letters WHERE job_id = ?`), jobID)
+	if err != nil {
+		return fmt.Errorf("removing dead letter %s: %w", jobID, err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		d.recordDepth(ctx)
+	}
+	return nil
+}
+
+// Count returns the number of failed transfers.
+func (d *DeadLetters) Count(ctx context.Context) (int64, error) {
+	var n int64
+	if err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM replica_dead_letters`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("counting dead letters: %w", err)
+	}
+	return n, nil
+}
+
+func (d *DeadLetters) recordDepth(ctx context.Context) {
+	n, err := d.Count(ctx)
+	if err != nil {
+		log.Warnw("counting replica dead letters", "error", err)
+		return
+	}
+	d.depth.Record(ctx, n)
+}
+
+func scanDeadLetter(row interface{ Scan(...any) error }) (DeadLetter, error) {
+	var (
+		l             DeadLetter
+		digest, cause string
+		failedAt      string
+	)
+	if err := row.Scan(&l.JobID, &digest, &l.Space, &l.Source, &l.Sink, &l.Error, &cause, &failedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return DeadLetter{}, err
+		}
+		return DeadLetter{}, fmt.Errorf("reading dead letter: %w", err)
+	}
+	var err error
+	if l.Digest, err = digestutil.Parse(digest); err != nil {
+		return DeadLetter{}, fmt.Errorf("parsing digest of dead letter %s: %w", l.JobID, err)
+	}
+	if err := json.Unmarshal([]byte(cause), &l.Cause); err != nil {
+		return DeadLetter{}, fmt.Errorf("decoding cause of dead letter %s: %w", l.JobID, err)
+	}
+	if l.FailedAt, err = time.Parse(failedAtFormat, failedAt); err != nil {
+		return DeadLetter{}, fmt.Errorf("parsing failure time of dead letter %s: %w", l.JobID, err)
+	}
+	return l, nil
+}
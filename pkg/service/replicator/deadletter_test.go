@@ -0,0 +1,79 @@
+package replicator
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/lib/jobqueue/dialect"
+	"github.com/storacha/piri/pkg/database/sqlitedb"
+	replicahandler "github.com/storacha/piri/pkg/service/storage/handlers/replica"
+)
+
+func TestDeadLetters(t *testing.T) {
+	db, err := sqlitedb.New(filepath.Join(t.TempDir(), "replicator.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	dl, err := NewDeadLetters(t.Context(), db, dialect.SQLite)
+	require.NoError(t, err)
+
+	failedAt := time.Date(2026, 10, 16, 9, 12, 44, 0, time.UTC)
+	first := DeadLetter{
+		JobID:  "m_1",
+		Digest: testutil.RandomMultihash(t),
+		Space:  testutil.RandomDID(t).String(),
+		Source: "https://storage-2.example.com",
+		Sink:   "https://piri.example.com/blob",
+		Error:  "failed: status 500",
+		Cause: replicahandler.Cause{
+			URL:        "https://piri.example.com/blob",
+			StatusCode: 500,
+			Chain:      []string{"failed", "status 500"},
+		},
+		FailedAt: failedAt,
+	}
+	second := DeadLetter{
+		JobID:    "m_2",
+		Digest:   testutil.RandomMultihash(t),
+		Space:    testutil.RandomDID(t).String(),
+		Source:   "https://storage-3.example.com",
+		Error:    replicahandler.ErrDigestMismatch.Error(),
+		Cause:    replicahandler.Cause{DigestMismatch: true, Chain: []string{replicahandler.ErrDigestMismatch.Error()}},
+		FailedAt: failedAt.Add(time.Minute),
+	}
+	require.NoError(t, dl.Put(t.Context(), second))
+	require.NoError(t, dl.Put(t.Context(), first))
+
+	t.Run("lists oldest first", func(t *testing.T) {
+		letters, err := dl.List(t.Context(), 0)
+		require.NoError(t, err)
+		require.Equal(t, []DeadLetter{first, second}, letters)
+	})
+
+	t.Run("replaces an earlier failure of the job", func(t *testing.T) {
+		again := first
+		again.Error = "failed again"
+		again.FailedAt = failedAt.Add(2 * time.Minute)
+		require.NoError(t, dl.Put(t.Context(), again))
+
+		l, err := dl.Get(t.Context(), "m_1")
+		require.NoError(t, err)
+		require.Equal(t, again, l)
+
+		n, err := dl.Count(t.Context())
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+	})
+
+	t.Run("removes", func(t *testing.T) {
+		require.NoError(t, dl.Remove(t.Context(), "m_1"))
+		_, err := dl.Get(t.Context(), "m_1")
+		require.ErrorIs(t, err, ErrDeadLetterNotFound)
+		// removing twice is a no-op
+		require.NoError(t, dl.Remove(t.Context(), "m_1"))
+	})
+}
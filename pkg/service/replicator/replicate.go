@@ -2,11 +2,17 @@ package replicator
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	logging "github.com/ipfs/go-log/v2"
 	"github.com/storacha/go-ucanto/client"
 	"github.com/storacha/go-ucanto/principal"
 
 	"github.com/storacha/piri/lib/jobqueue"
+	"github.com/storacha/piri/lib/jobqueue/queue"
+	"github.com/storacha/piri/pkg/blocklist"
 	"github.com/storacha/piri/pkg/pdp"
 	"github.com/storacha/piri/pkg/service/blobs"
 	"github.com/storacha/piri/pkg/service/claims"
@@ -14,14 +20,42 @@ import (
 	"github.com/storacha/piri/pkg/store/receiptstore"
 )
 
+var log = logging.Logger("replicator")
+
 type Replicator interface {
 	Replicate(context.Context, *replicahandler.TransferRequest) error
 }
 
 type Service struct {
-	queue   *jobqueue.JobQueue[*replicahandler.TransferRequest]
-	adapter *adapter
-	metrics *replicahandler.Metrics
+	queue     *jobqueue.JobQueue[*replicahandler.TransferRequest]
+	adapter   *adapter
+	selector  *replicahandler.SourceSelector
+	scheduler *replicahandler.Scheduler
+	metrics   *replicahandler.Metrics
+	// deadLetters records failed transfers, nil if they are not recorded
+	deadLetters *DeadLetters
+	// blocked refuses transfers of blocked blobs, nil if none are blocked
+	blocked *blocklist.List
+}
+
+// Option configures a Service.
+type Option func(s *Service)
+
+// WithDeadLetters records transfers that fail permanently or too many times
+// in dl, with the cause of their failure, until they are requeued or
+// discarded.
+func WithDeadLetters(dl *DeadLetters) Option {
+	return func(s *Service) {
+		s.deadLetters = dl
+	}
+}
+
+// WithBlocklist fails transfers of blobs in blocked without retrying them,
+// e.g. blobs blocked after their replica was allocated.
+func WithBlocklist(blocked *blocklist.List) Option {
+	return func(s *Service) {
+		s.blocked = blocked
+	}
 }
 
 type adapter struct {
@@ -48,6 +82,9 @@ func New(
 	rstore receiptstore.ReceiptStore,
 	uploadConn client.Connection,
 	queue *jobqueue.JobQueue[*replicahandler.TransferRequest],
+	selector *replicahandler.SourceSelector,
+	scheduler *replicahandler.Scheduler,
+	opts ...Option,
 ) (*Service, error) {
 	metrics, err := replicahandler.NewMetrics()
 	if err != nil {
@@ -63,7 +100,12 @@ func New(
 			receipts:   rstore,
 			uploadConn: uploadConn,
 		},
-		metrics: metrics,
+		selector:  selector,
+		scheduler: scheduler,
+		metrics:   metrics,
+	}
+	for _, opt := range opts {
+		opt(svc)
 	}
 	return svc, nil
 }
@@ -76,8 +118,106 @@ func (r *Service) Replicate(ctx context.Context, task *replicahandler.TransferRe
 
 func (r *Service) RegisterTransferTask(queue *jobqueue.JobQueue[*replicahandler.TransferRequest]) error {
 	return queue.Register(TransferTaskName, func(ctx context.Context, request *replicahandler.TransferRequest) error {
-		return replicahandler.Transfer(ctx, r.adapter, request, r.metrics)
+		if err := r.blocked.Check(ctx, blocklist.Replicate, request.Blob.Digest); err != nil {
+			return jobqueue.NewPermanentError(err)
+		}
+		if err := replicahandler.Transfer(ctx, r.adapter, request, r.selector, r.scheduler, r.metrics); err != nil {
+			return err
+		}
+		// the transfer may have been requeued after failing
+		r.forgetDeadLetter(ctx)
+		return nil
 	}, jobqueue.WithOnFailure(func(ctx context.Context, msg *replicahandler.TransferRequest, err error) error {
-		return replicahandler.SendFailureReceipt(ctx, r.adapter, msg, err)
+		return errors.Join(
+			r.recordDeadLetter(ctx, msg, err),
+			replicahandler.SendFailureReceipt(ctx, r.adapter, msg, err),
+		)
 	}))
 }
+
+func (r *Service) recordDeadLetter(ctx context.Context, request *replicahandler.TransferRequest, err error) error {
+	id, ok := jobqueue.JobID(ctx)
+	if r.deadLetters == nil || !ok {
+		return nil
+	}
+	l := DeadLetter{
+		JobID:    string(id),
+		Digest:   request.Blob.Digest,
+		Space:    request.Space.String(),
+		Source:   request.Source.URL.String(),
+		Error:    err.Error(),
+		Cause:    replicahandler.CauseOf(err),
+		FailedAt: time.Now(),
+	}
+	if request.Sink != nil {
+		l.Sink = request.Sink.String()
+	}
+	return r.deadLetters.Put(ctx, l)
+}
+
+func (r *Service) forgetDeadLetter(ctx context.Context) {
+	id, ok := jobqueue.JobID(ctx)
+	if r.deadLetters == nil || !ok {
+		return
+	}
+	if err := r.deadLetters.Remove(ctx, string(id)); err != nil {
+		log.Warnw("removing dead letter of transfer", "job", id, "error", err)
+	}
+}
+
+// DeadLetters returns up to limit failed transfers, oldest first. A limit of
+// zero uses DefaultDeadLetterLimit.
+func (r *Service) DeadLetters(ctx context.Context, limit int) ([]DeadLetter, error) {
+	if r.deadLetters == nil {
+		return nil, nil
+	}
+	return r.deadLetters.List(ctx, limit)
+}
+
+// RequeueDeadLetter moves the job of a failed transfer back to the
+// replication queue, to run again with a fresh retry count.
+func (r *Service) RequeueDeadLetter(ctx context.Context, jobID string) (DeadLetter, error) {
+	l, err := r.deadLetter(ctx, jobID)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	if _, err := r.queue.RetryJob(ctx, queue.ID(jobID)); err != nil {
+		if !errors.Is(err, queue.ErrJobNotFound) {
+			return DeadLetter{}, fmt.Errorf("requeueing transfer job %s: %w", jobID, err)
+		}
+		// the job was retried or cancelled through the job queue
+		if err := r.deadLetters.Remove(ctx, jobID); err != nil {
+			return DeadLetter{}, err
+		}
+		return DeadLetter{}, fmt.Errorf("%w: job %s is no longer dead-lettered", ErrDeadLetterNotFound, jobID)
+	}
+	if err := r.deadLetters.Remove(ctx, jobID); err != nil {
+		return DeadLetter{}, err
+	}
+	log.Infow("requeued failed transfer", "job", jobID, "blob", l.Digest)
+	return l, nil
+}
+
+// DiscardDeadLetter removes a failed transfer, and its job from the
+// replication queue.
+func (r *Service) DiscardDeadLetter(ctx context.Context, jobID string) (DeadLetter, error) {
+	l, err := r.deadLetter(ctx, jobID)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	if _, err := r.queue.CancelJob(ctx, queue.ID(jobID)); err != nil && !errors.Is(err, queue.ErrJobNotFound) {
+		return DeadLetter{}, fmt.Errorf("cancelling transfer job %s: %w", jobID, err)
+	}
+	if err := r.deadLetters.Remove(ctx, jobID); err != nil {
+		return DeadLetter{}, err
+	}
+	log.Infow("discarded failed transfer", "job", jobID, "blob", l.Digest)
+	return l, nil
+}
+
+func (r *Service) deadLetter(ctx context.Context, jobID string) (DeadLetter, error) {
+	if r.deadLetters == nil {
+		return DeadLetter{}, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, jobID)
+	}
+	return r.deadLetters.Get(ctx, jobID)
+}
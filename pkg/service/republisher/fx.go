@@ -0,0 +1,89 @@
+package republisher
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-ucanto/principal"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/service/blobs"
+	"github.com/storacha/piri/pkg/service/claims"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+)
+
+var log = logging.Logger("republisher")
+
+// StateFile is the name of the file in the data directory recording the last
+// public URL and the progress of a republication.
+const StateFile = "republish.json"
+
+var Module = fx.Module("republisher",
+	fx.Provide(
+		NewRepublisherService,
+	),
+	// force construction, nothing else depends on the republisher
+	fx.Invoke(func(*Service) {}),
+)
+
+type Params struct {
+	fx.In
+
+	Cfg             app.AppConfig
+	ID              principal.Signer
+	AcceptanceStore acceptancestore.AcceptanceStore
+	Blobs           blobs.Blobs
+	Claims          claims.Claims
+	PDP             pdp.PDP `optional:"true"`
+}
+
+func NewRepublisherService(lc fx.Lifecycle, params Params) (*Service, error) {
+	if params.Cfg.Storage.DataDir == "" {
+		log.Warn("no data directory configured, location claims are not republished on public URL change")
+		return nil, nil
+	}
+
+	// blobs are served by PDP when it is enabled, otherwise from the blob store
+	locate := func(_ context.Context, digest multihash.Multihash) (url.URL, error) {
+		return params.Blobs.Access().GetDownloadURL(digest)
+	}
+	if params.PDP != nil {
+		locate = func(_ context.Context, digest multihash.Multihash) (url.URL, error) {
+			return params.PDP.API().ReadPieceURL(cid.NewCidV1(cid.Raw, digest))
+		}
+	}
+
+	svc, err := New(
+		params.ID,
+		params.Cfg.Server.PublicURL,
+		filepath.Join(params.Cfg.Storage.DataDir, StateFile),
+		params.AcceptanceStore,
+		locate,
+		params.Claims.Store(),
+		params.Claims.Publisher(),
+		params.Cfg.UCANService.LocationClaims.Expiration,
+		WithLocator(params.Claims),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return svc.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			return svc.Stop(ctx)
+		},
+	})
+
+	return svc, nil
+}
@@ -0,0 +1,310 @@
+// Package republisher republishes location claims when the public URL of the
+// node changes.
+//
+// Location claims name the URL blobs can be retrieved from, which is derived
+// from the node's public URL. When the public URL changes, claims issued
+// before the change, and the IPNI adverts and indexing service cache entries
+// referencing them, point at the old host. The last public URL is recorded in
+// the data directory, and on a change a new location claim is issued for
+// every accepted blob, stored, advertised to IPNI and cached with the
+// indexing service.
+package republisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/principal"
+
+	"github.com/storacha/piri/pkg/hwsigner"
+	"github.com/storacha/piri/pkg/service/claims"
+	"github.com/storacha/piri/pkg/service/publisher"
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/acceptancestore/acceptance"
+	"github.com/storacha/piri/pkg/store/claimstore"
+)
+
+// progressLogInterval is how many blobs are republished between progress log
+// messages.
+const progressLogInterval = 1000
+
+// LocateFunc returns the URL a blob can be retrieved from.
+type LocateFunc func(ctx context.Context, digest multihash.Multihash) (url.URL, error)
+
+// Progress reports a republication following a public URL change.
+type Progress struct {
+	From        string     `json:"from"`
+	To          string     `json:"to"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// Republished is the number of blobs whose location claim was
+	// republished, Failed the number that could not be.
+	Republished int    `json:"republished"`
+	Failed      int    `json:"failed"`
+	LastError   string `json:"last_error,omitempty"`
+}
+
+// Done reports whether the republication has completed.
+func (p Progress) Done() bool {
+	return p.CompletedAt != nil
+}
+
+type state struct {
+	PublicURL string    `json:"public_url"`
+	Progress  *Progress `json:"progress,omitempty"`
+}
+
+// Service detects public URL changes and republishes location claims.
+type Service struct {
+	id          principal.Signer
+	publicURL   url.URL
+	path        string
+	acceptances acceptancestore.AcceptanceStore
+	locate      LocateFunc
+	claims      claimstore.ClaimStore
+	publisher   publisher.Publisher
+	lifetime    time.Duration
+	locator     claims.Locator
+
+	mu        sync.Mutex
+	state     state
+	cancel    context.CancelFunc
+	done      chan struct{}
+	persistMu sync.Mutex
+}
+
+type Option func(*Service)
+
+// WithLocator sets the locator of blobs on the public URLs of the node.
+// Republished claims locate blobs only at the URL returned by locate by
+// default.
+func WithLocator(locator claims.Locator) Option {
+	return func(s *Service) {
+		s.locator = locator
+	}
+}
+
+// New creates a Service recording the public URL in the state file at path.
+// Republished claims are valid for lifetime, 0 for claims that never expire.
+func New(
+	id principal.Signer,
+	publicURL url.URL,
+	path string,
+	acceptances acceptancestore.AcceptanceStore,
+	locate LocateFunc,
+	claims claimstore.ClaimStore,
+	pub publisher.Publisher,
+	lifetime time.Duration,
+	opts ...Option,
+) (*Service, error) {
+	s := &Service{
+		id:          id,
+		publicURL:   publicURL,
+		path:        path,
+		acceptances: acceptances,
+		locate:      locate,
+		claims:      claims,
+		publisher:   pub,
+		lifetime:    lifetime,
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading republish state: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &s.state); err != nil {
+			return nil, fmt.Errorf("decoding republish state %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Start republishes location claims in the background if the public URL has
+// changed since the node last ran, or a previous republication did not
+// complete. On first run the public URL is only recorded.
+func (s *Service) Start(ctx context.Context) error {
+	current := s.publicURL.String()
+
+	s.mu.Lock()
+	previous := s.state.PublicURL
+	resume := s.state.Progress != nil && !s.state.Progress.Done()
+	switch {
+	case previous == "":
+		s.state.PublicURL = current
+	case previous != current:
+		log.Warnw("public URL changed, republishing location claims", "from", previous, "to", current)
+		s.state = state{PublicURL: current, Progress: &Progress{From: previous, To: current, StartedAt: time.Now().UTC()}}
+	case resume:
+		log.Warnw("resuming republication of location claims", "from", s.state.Progress.From, "to", current)
+		s.state.Progress = &Progress{From: s.state.Progress.From, To: current, StartedAt: time.Now().UTC()}
+	}
+	republish := s.state.Progress != nil && !s.state.Progress.Done()
+	s.mu.Unlock()
+
+	if err := s.persist(); err != nil {
+		return err
+	}
+	if !republish {
+		close(s.done)
+		return nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	go func() {
+		defer close(s.done)
+		if err := s.Republish(runCtx); err != nil {
+			log.Errorw("republishing location claims", "error", err)
+		}
+	}()
+	return nil
+}
+
+// Stop stops a republication in progress. It is resumed on the next start.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for republisher to stop: %w", ctx.Err())
+	}
+}
+
+// PublicURL returns the public URL location claims are issued for.
+func (s *Service) PublicURL() string {
+	return s.publicURL.String()
+}
+
+// Progress returns the progress of the last republication, or nil if the
+// public URL has never changed.
+func (s *Service) Progress() *Progress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state.Progress == nil {
+		return nil
+	}
+	p := *s.state.Progress
+	return &p
+}
+
+// Republish issues and publishes a new location claim for every accepted
+// blob.
+func (s *Service) Republish(ctx context.Context) error {
+	for acc, err := range s.acceptances.List(ctx) {
+		if err != nil {
+			return fmt.Errorf("listing acceptances: %w", err)
+		}
+		if err := s.republish(ctx, acc); err != nil {
+			if ctx.Err() != nil {
+				// the republication restarts on the next start
+				return errors.Join(ctx.Err(), s.persist())
+			}
+			log.Errorw("republishing location claim", "space", acc.Space, "digest", acc.Blob.Digest, "error", err)
+			s.update(func(p *Progress) {
+				p.Failed++
+				p.LastError = err.Error()
+			})
+		} else {
+			s.update(func(p *Progress) { p.Republished++ })
+		}
+
+		if p := s.Progress(); p != nil && (p.Republished+p.Failed)%progressLogInterval == 0 {
+			log.Infow("republishing location claims", "republished", p.Republished, "failed", p.Failed)
+			if err := s.persist(); err != nil {
+				log.Errorw("saving republish progress", "error", err)
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	s.update(func(p *Progress) { p.CompletedAt = &now })
+	p := s.Progress()
+	log.Infow("republished location claims", "republished", p.Republished, "failed", p.Failed, "duration", now.Sub(p.StartedAt))
+	return s.persist()
+}
+
+func (s *Service) republish(ctx context.Context, acc acceptance.Acceptance) error {
+	loc, err := s.locate(ctx, acc.Blob.Digest)
+	if err != nil {
+		return fmt.Errorf("creating retrieval URL for blob: %w", err)
+	}
+
+	locs := []url.URL{loc}
+	if s.locator != nil {
+		locs = s.locator.Locations(loc)
+	}
+
+	byteRange := assert.Range{Offset: 0, Length: &acc.Blob.Size}
+	claim, err := assert.Location.Delegate(
+		s.id,
+		acc.Space,
+		s.id.DID().String(),
+		assert.LocationCaveats{
+			Space:    acc.Space,
+			Content:  types.FromHash(acc.Blob.Digest),
+			Location: locs,
+			Range:    &byteRange,
+		},
+		append([]delegation.Option{claims.ExpirationOption(s.lifetime)}, hwsigner.ClaimOptions(s.id)...)...,
+	)
+	if err != nil {
+		return fmt.Errorf("creating location commitment: %w", err)
+	}
+	if err := s.claims.Put(ctx, claim); err != nil {
+		return fmt.Errorf("putting location claim: %w", err)
+	}
+	if err := s.publisher.Publish(ctx, claim); err != nil {
+		return fmt.Errorf("publishing location commitment: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) update(fn func(p *Progress)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state.Progress != nil {
+		fn(s.state.Progress)
+	}
+}
+
+// persist writes the state to disk.
+func (s *Service) persist() error {
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encoding republish state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating republish state directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing republish state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("writing republish state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package republisher
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/ipnipublisher/store"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/acceptancestore/acceptance"
+	"github.com/storacha/piri/pkg/store/delegationstore"
+)
+
+type mockPublisher struct {
+	published []delegation.Delegation
+}
+
+func (m *mockPublisher) Store() store.PublisherStore {
+	return nil
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, d delegation.Delegation) error {
+	m.published = append(m.published, d)
+	return nil
+}
+
+func (m *mockPublisher) Retract(ctx context.Context, space did.DID, digest multihash.Multihash) error {
+	return nil
+}
+
+func locateAt(base string) LocateFunc {
+	return func(ctx context.Context, digest multihash.Multihash) (url.URL, error) {
+		u, err := url.Parse(base)
+		if err != nil {
+			return url.URL{}, err
+		}
+		return *u.JoinPath("blob", digest.B58String()), nil
+	}
+}
+
+func newTestService(t *testing.T, path, publicURL string, accs acceptancestore.AcceptanceStore, locate LocateFunc) (*Service, *mockPublisher) {
+	t.Helper()
+	u, err := url.Parse(publicURL)
+	require.NoError(t, err)
+	pub := &mockPublisher{}
+	claims := delegationstore.NewDatastoreStore(datastore.NewMapDatastore())
+	svc, err := New(testutil.Alice, *u, path, accs, locate, claims, pub, 0)
+	require.NoError(t, err)
+	return svc, pub
+}
+
+// run starts the service and waits for any republication to complete.
+func run(t *testing.T, svc *Service) {
+	t.Helper()
+	require.NoError(t, svc.Start(t.Context()))
+	select {
+	case <-svc.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for republication")
+	}
+}
+
+func putAcceptances(t *testing.T, accs acceptancestore.AcceptanceStore, n int) {
+	t.Helper()
+	for range n {
+		require.NoError(t, accs.Put(t.Context(), acceptance.Acceptance{
+			Space: testutil.RandomDID(t),
+			Blob: acceptance.Blob{
+				Digest: testutil.RandomMultihash(t),
+				Size:   1024,
+			},
+			ExecutedAt: uint64(time.Now().Unix()),
+			Cause:      testutil.RandomCID(t),
+		}))
+	}
+}
+
+func TestStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFile)
+	accs := acceptancestore.NewDatastoreStore(datastore.NewMapDatastore())
+	putAcceptances(t, accs, 3)
+
+	t.Run("first run records the public URL", func(t *testing.T) {
+		svc, pub := newTestService(t, path, "https://old.example.com", accs, locateAt("https://old.example.com"))
+		run(t, svc)
+		require.Empty(t, pub.published)
+		require.Nil(t, svc.Progress())
+	})
+
+	t.Run("unchanged public URL republishes nothing", func(t *testing.T) {
+		svc, pub := newTestService(t, path, "https://old.example.com", accs, locateAt("https://old.example.com"))
+		run(t, svc)
+		require.Empty(t, pub.published)
+		require.Nil(t, svc.Progress())
+	})
+
+	t.Run("changed public URL republishes location claims", func(t *testing.T) {
+		svc, pub := newTestService(t, path, "https://new.example.com", accs, locateAt("https://new.example.com"))
+		run(t, svc)
+		require.Len(t, pub.published, 3)
+		for _, claim := range pub.published {
+			nb, err := assert.LocationCaveatsReader.Read(claim.Capabilities()[0].Nb())
+			require.NoError(t, err)
+			require.Len(t, nb.Location, 1)
+			require.Equal(t, "new.example.com", nb.Location[0].Host)
+		}
+
+		p := svc.Progress()
+		require.NotNil(t, p)
+		require.True(t, p.Done())
+		require.Equal(t, "https://old.example.com", p.From)
+		require.Equal(t, "https://new.example.com", p.To)
+		require.Equal(t, 3, p.Republished)
+		require.Zero(t, p.Failed)
+	})
+
+	t.Run("completed republication is not repeated", func(t *testing.T) {
+		svc, pub := newTestService(t, path, "https://new.example.com", accs, locateAt("https://new.example.com"))
+		run(t, svc)
+		require.Empty(t, pub.published)
+		require.True(t, svc.Progress().Done())
+	})
+}
+
+func TestRepublishFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFile)
+	accs := acceptancestore.NewDatastoreStore(datastore.NewMapDatastore())
+	putAcceptances(t, accs, 2)
+
+	svc, _ := newTestService(t, path, "https://old.example.com", accs, locateAt("https://old.example.com"))
+	run(t, svc)
+
+	failing := func(ctx context.Context, digest multihash.Multihash) (url.URL, error) {
+		return url.URL{}, errors.New("boom")
+	}
+	svc, pub := newTestService(t, path, "https://new.example.com", accs, failing)
+	run(t, svc)
+	require.Empty(t, pub.published)
+
+	p := svc.Progress()
+	require.True(t, p.Done())
+	require.Equal(t, 2, p.Failed)
+	require.Contains(t, p.LastError, "boom")
+}
@@ -2,6 +2,9 @@ package retrieval
 
 import (
 	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/piri/pkg/privatespace"
+	"github.com/storacha/piri/pkg/ratelimit"
+	"github.com/storacha/piri/pkg/service/quota"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/blobstore"
 )
@@ -14,4 +17,13 @@ type Service interface {
 	// Blobs is the storage interface for retrieving blobs. It MUST be keyed by
 	// hash that a client will request. i.e. not a piece hash.
 	Blobs() blobstore.BlobGetter
+	// Quotas enforces per-space egress quotas, nil if quotas are not
+	// enforced.
+	Quotas() quota.Enforcer
+	// SpaceLimiter rate limits retrievals per space, nil if retrievals are
+	// not rate limited.
+	SpaceLimiter() *ratelimit.Limiter
+	// Private decides which blobs are private and signs their retrieval URLs,
+	// nil if no spaces are private.
+	Private() *privatespace.Policy
 }
@@ -3,6 +3,9 @@ package retrieval
 import (
 	"github.com/storacha/go-ucanto/principal"
 
+	"github.com/storacha/piri/pkg/privatespace"
+	"github.com/storacha/piri/pkg/ratelimit"
+	"github.com/storacha/piri/pkg/service/quota"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/blobstore"
 )
@@ -11,6 +14,9 @@ type RetrievalService struct {
 	id          principal.Signer
 	blobs       blobstore.BlobGetter
 	allocations allocationstore.AllocationStore
+	quotas      quota.Enforcer
+	limiter     *ratelimit.Limiter
+	private     *privatespace.Policy
 }
 
 func (r *RetrievalService) Allocations() allocationstore.AllocationStore {
@@ -25,8 +31,23 @@ func (r *RetrievalService) ID() principal.Signer {
 	return r.id
 }
 
+func (r *RetrievalService) Quotas() quota.Enforcer {
+	return r.quotas
+}
+
+func (r *RetrievalService) SpaceLimiter() *ratelimit.Limiter {
+	return r.limiter
+}
+
+func (r *RetrievalService) Private() *privatespace.Policy {
+	return r.private
+}
+
 var _ Service = (*RetrievalService)(nil)
 
-func New(id principal.Signer, blobs blobstore.BlobGetter, allocations allocationstore.AllocationStore) *RetrievalService {
-	return &RetrievalService{id, blobs, allocations}
+// New creates a retrieval service. quotas may be nil to not enforce egress
+// quotas, limiter nil to not rate limit retrievals per space, and private nil
+// if no spaces are private.
+func New(id principal.Signer, blobs blobstore.BlobGetter, allocations allocationstore.AllocationStore, quotas quota.Enforcer, limiter *ratelimit.Limiter, private *privatespace.Policy) *RetrievalService {
+	return &RetrievalService{id, blobs, allocations, quotas, limiter, private}
 }
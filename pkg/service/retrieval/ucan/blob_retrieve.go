@@ -3,6 +3,9 @@ package ucan
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/storacha/go-libstoracha/capabilities/blob"
 	"github.com/storacha/go-libstoracha/capabilities/space/content"
@@ -14,6 +17,7 @@ import (
 	"github.com/storacha/go-ucanto/server"
 	"github.com/storacha/go-ucanto/server/retrieval"
 	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/piri/pkg/compression"
 	"github.com/storacha/piri/pkg/service/retrieval/handlers/spacecontent"
 	"github.com/storacha/piri/pkg/store/blobstore"
 )
@@ -27,7 +31,26 @@ type BlobRetrievalService interface {
 	Blobs() blobstore.BlobGetter
 }
 
-func WithBlobRetrieveMethod(service BlobRetrievalService) retrieval.Option {
+// BlobRetrieveOption configures the `blob/retrieve` method.
+type BlobRetrieveOption func(*blobRetrieveConfig)
+
+type blobRetrieveConfig struct {
+	compression bool
+}
+
+// WithCompression has whole blobs served zstd compressed to nodes that accept
+// it in the Accept-Encoding header.
+func WithCompression(enabled bool) BlobRetrieveOption {
+	return func(c *blobRetrieveConfig) {
+		c.compression = enabled
+	}
+}
+
+func WithBlobRetrieveMethod(service BlobRetrievalService, opts ...BlobRetrieveOption) retrieval.Option {
+	cfg := blobRetrieveConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	return retrieval.WithServiceMethod(
 		blob.RetrieveAbility,
 		retrieval.Provide(
@@ -39,11 +62,22 @@ func WithBlobRetrieveMethod(service BlobRetrievalService) retrieval.Option {
 						Message:   fmt.Sprintf("resource is %s not %s", cap.With(), service.ID().DID()),
 					}), nil, retrieval.Response{}, nil
 				}
-				// no range, pass nil for byteRange
-				res, resp, err := spacecontent.Retrieve(ctx, service.Blobs(), inv, cap.Nb().Blob.Digest, nil)
+				// the caveats have no range, but a single byte range may be
+				// requested in the Range header, e.g. by nodes replicating the
+				// blob from several sources in parallel
+				byteRange := parseRange(request.Headers.Get("Range"))
+				res, resp, err := spacecontent.Retrieve(ctx, service.Blobs(), inv, cap.Nb().Blob.Digest, byteRange)
 				if err != nil {
 					return nil, nil, retrieval.Response{}, err
 				}
+				// ranges are served uncompressed, so they can be resumed and
+				// transferred in parallel
+				if cfg.compression && byteRange == nil && resp.Status == http.StatusOK && resp.Body != nil &&
+					compression.Accepts(request.Headers.Get("Accept-Encoding"), compression.Zstd) {
+					resp.Headers.Del("Content-Length")
+					resp.Headers.Set("Content-Encoding", compression.Zstd)
+					resp.Body = compression.NewZstdReader(resp.Body)
+				}
 				return result.MapOk(res, func(o content.RetrieveOk) blob.RetrieveOk {
 					return blob.RetrieveOk{}
 				}), nil, resp, nil
@@ -51,3 +85,29 @@ func WithBlobRetrieveMethod(service BlobRetrievalService) retrieval.Option {
 		),
 	)
 }
+
+// parseRange parses a Range header requesting a single range of bytes, i.e.
+// "bytes=start-end" or "bytes=start-". Other headers return nil, and the
+// whole blob is served.
+func parseRange(header string) *blobstore.Range {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok || first == "" {
+		return nil
+	}
+	start, err := strconv.ParseUint(first, 10, 64)
+	if err != nil {
+		return nil
+	}
+	if last == "" {
+		return &blobstore.Range{Start: start}
+	}
+	end, err := strconv.ParseUint(last, 10, 64)
+	if err != nil || end < start {
+		return nil
+	}
+	return &blobstore.Range{Start: start, End: &end}
+}
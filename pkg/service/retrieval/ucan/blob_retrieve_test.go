@@ -204,3 +204,25 @@ func TestBlobRetrieve(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRange(t *testing.T) {
+	end := func(n uint64) *uint64 { return &n }
+	testCases := []struct {
+		header string
+		expect *blobstore.Range
+	}{
+		{"", nil},
+		{"bytes=0-9", &blobstore.Range{Start: 0, End: end(9)}},
+		{"bytes=10-", &blobstore.Range{Start: 10}},
+		{"bytes=-10", nil},
+		{"bytes=9-0", nil},
+		{"bytes=0-9,20-29", nil},
+		{"items=0-9", nil},
+		{"bytes=a-b", nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.header, func(t *testing.T) {
+			require.Equal(t, tc.expect, parseRange(tc.header))
+		})
+	}
+}
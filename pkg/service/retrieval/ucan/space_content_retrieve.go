@@ -20,6 +20,9 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 
+	"github.com/storacha/piri/pkg/privatespace"
+	"github.com/storacha/piri/pkg/ratelimit"
+	"github.com/storacha/piri/pkg/service/quota"
 	"github.com/storacha/piri/pkg/service/retrieval/handlers/spacecontent"
 	"github.com/storacha/piri/pkg/store"
 	"github.com/storacha/piri/pkg/store/allocationstore"
@@ -31,6 +34,12 @@ var log = logging.Logger("retrieval/ucan")
 type SpaceContentRetrievalService interface {
 	Allocations() allocationstore.AllocationStore
 	Blobs() blobstore.BlobGetter
+	// Quotas is nil if quotas are not enforced.
+	Quotas() quota.Enforcer
+	// SpaceLimiter is nil if retrievals are not rate limited.
+	SpaceLimiter() *ratelimit.Limiter
+	// Private is nil if no spaces are private.
+	Private() *privatespace.Policy
 }
 
 func WithSpaceContentRetrieveMethod(retrievalService SpaceContentRetrievalService) retrieval.Option {
@@ -76,6 +85,18 @@ func WithSpaceContentRetrieveMethod(retrievalService SpaceContentRetrievalServic
 					"range", fmt.Sprintf("%d-%d", start, end),
 				)
 
+				if limiter := retrievalService.SpaceLimiter(); limiter != nil {
+					if ok, retryAfter := limiter.Allow(ctx, space.String()); !ok {
+						log.Debugw("space rate limited", "status", http.StatusTooManyRequests)
+						rle := ratelimit.NewRateLimitedError("space", space.String(), retryAfter)
+						res := result.Error[content.RetrieveOk, failure.IPLDBuilderFailure](rle)
+						headers := http.Header{}
+						headers.Set("Retry-After", ratelimit.RetryAfter(retryAfter))
+						resp := retrieval.NewResponse(http.StatusTooManyRequests, headers, nil)
+						return res, nil, resp, nil
+					}
+				}
+
 				_, err = retrievalService.Allocations().Get(ctx, digest, space)
 				if err != nil {
 					if errors.Is(err, store.ErrNotFound) {
@@ -89,7 +110,52 @@ func WithSpaceContentRetrieveMethod(retrievalService SpaceContentRetrievalServic
 					return nil, nil, retrieval.Response{}, fmt.Errorf("getting allocation: %w", err)
 				}
 
+				// reserve the requested range against the space's egress quota,
+				// released again if nothing is retrieved
+				quotas := retrievalService.Quotas()
+				reserved := end - start + 1
+				if quotas != nil {
+					if err := quotas.ReserveEgress(ctx, space, reserved); err != nil {
+						var qe *quota.QuotaExceededError
+						if errors.As(err, &qe) {
+							log.Debugw("egress quota exceeded", "status", http.StatusTooManyRequests)
+							res := result.Error[content.RetrieveOk, failure.IPLDBuilderFailure](qe)
+							resp := retrieval.NewResponse(http.StatusTooManyRequests, nil, nil)
+							return res, nil, resp, nil
+						}
+						log.Errorw("reserving egress quota", "error", err)
+						return nil, nil, retrieval.Response{}, fmt.Errorf("reserving egress quota: %w", err)
+					}
+				}
+
+				// issue a signed URL of a private blob rather than its content, the
+				// URL retrieves the requested range once, and the range stays
+				// reserved against the space's egress quota
+				if private := retrievalService.Private(); private.IsPrivateSpace(space) && request.Headers.Get(privatespace.PresignHeader) != "" {
+					u, err := private.SignURL(ctx, digest, start, end)
+					if err != nil {
+						log.Errorw("signing retrieval URL", "error", err)
+						if quotas != nil {
+							if rerr := quotas.ReleaseEgress(ctx, space, reserved); rerr != nil {
+								log.Errorw("releasing egress quota", "error", rerr)
+							}
+						}
+						return nil, nil, retrieval.Response{}, err
+					}
+					log.Debugw("issued signed retrieval URL", "status", http.StatusNoContent, "ttl", private.TTL())
+					headers := http.Header{}
+					headers.Set("Location", u.String())
+					headers.Set("Cache-Control", "no-store")
+					resp := retrieval.NewResponse(http.StatusNoContent, headers, nil)
+					return result.Ok[content.RetrieveOk, failure.IPLDBuilderFailure](content.RetrieveOk{}), nil, resp, nil
+				}
+
 				res, resp, err = spacecontent.Retrieve(ctx, retrievalService.Blobs(), inv, digest, &blobstore.Range{Start: start, End: &end})
+				if quotas != nil && (err != nil || failed(res)) {
+					if rerr := quotas.ReleaseEgress(ctx, space, reserved); rerr != nil {
+						log.Errorw("releasing egress quota", "error", rerr)
+					}
+				}
 				if err != nil {
 					return nil, nil, retrieval.Response{}, err
 				}
@@ -98,3 +164,8 @@ func WithSpaceContentRetrieveMethod(retrievalService SpaceContentRetrievalServic
 		),
 	)
 }
+
+func failed(res result.Result[content.RetrieveOk, failure.IPLDBuilderFailure]) bool {
+	_, x := result.Unwrap(res)
+	return x != nil
+}
@@ -28,6 +28,9 @@ import (
 	"github.com/storacha/go-ucanto/ucan"
 	"github.com/stretchr/testify/require"
 
+	"github.com/storacha/piri/pkg/privatespace"
+	"github.com/storacha/piri/pkg/ratelimit"
+	"github.com/storacha/piri/pkg/service/quota"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
 	"github.com/storacha/piri/pkg/store/blobstore"
@@ -36,6 +39,8 @@ import (
 type retrievalService struct {
 	allocations allocationstore.AllocationStore
 	blobs       blobstore.BlobGetter
+	quotas      quota.Enforcer
+	limiter     *ratelimit.Limiter
 }
 
 func (rs *retrievalService) Allocations() allocationstore.AllocationStore {
@@ -46,6 +51,18 @@ func (rs *retrievalService) Blobs() blobstore.BlobGetter {
 	return rs.blobs
 }
 
+func (rs *retrievalService) Quotas() quota.Enforcer {
+	return rs.quotas
+}
+
+func (rs *retrievalService) SpaceLimiter() *ratelimit.Limiter {
+	return rs.limiter
+}
+
+func (rs *retrievalService) Private() *privatespace.Policy {
+	return nil
+}
+
 func TestSpaceContentRetrieve(t *testing.T) {
 	logging.SetLogLevel("retrieval/ucan", "DEBUG")
 	alice := testutil.Alice
@@ -78,6 +95,8 @@ func TestSpaceContentRetrieve(t *testing.T) {
 		allocations   []allocation.Allocation
 		blobs         [][]byte
 		caveats       content.RetrieveCaveats
+		egressLimit   uint64
+		rateLimited   bool
 		expectStatus  int
 		expectHeaders http.Header
 		expectBody    []byte
@@ -131,6 +150,69 @@ func TestSpaceContentRetrieve(t *testing.T) {
 				require.Equal(t, content.NotFoundErrorName, x.Name())
 			},
 		},
+		{
+			name:  "quota exceeded when egress limit reached",
+			agent: alice,
+			space: space.DID(),
+			proof: proof,
+			allocations: []allocation.Allocation{
+				{
+					Space: space.DID(),
+					Blob: allocation.Blob{
+						Digest: blob.digest,
+						Size:   uint64(len(blob.bytes)),
+					},
+					Expires: uint64(time.Now().Unix() + 30),
+					Cause:   testutil.RandomCID(t),
+				},
+			},
+			blobs: [][]byte{blob.bytes},
+			caveats: content.RetrieveCaveats{
+				Blob:  content.BlobDigest{Digest: blob.digest},
+				Range: content.Range{Start: 0, End: uint64(len(blob.bytes) - 1)},
+			},
+			egressLimit:  16,
+			expectStatus: http.StatusTooManyRequests,
+			expectBody:   []byte{},
+			assertError: func(n ipld.Node) {
+				x, err := ipld.Rebind[fdm.FailureModel](n, fdm.FailureType())
+				require.NoError(t, err)
+				require.Equal(t, quota.QuotaExceededErrorName, *x.Name)
+			},
+		},
+		{
+			name:  "rate limited when space over the limit",
+			agent: alice,
+			space: space.DID(),
+			proof: proof,
+			allocations: []allocation.Allocation{
+				{
+					Space: space.DID(),
+					Blob: allocation.Blob{
+						Digest: blob.digest,
+						Size:   uint64(len(blob.bytes)),
+					},
+					Expires: uint64(time.Now().Unix() + 30),
+					Cause:   testutil.RandomCID(t),
+				},
+			},
+			blobs: [][]byte{blob.bytes},
+			caveats: content.RetrieveCaveats{
+				Blob:  content.BlobDigest{Digest: blob.digest},
+				Range: content.Range{Start: 0, End: 0},
+			},
+			rateLimited:  true,
+			expectStatus: http.StatusTooManyRequests,
+			expectHeaders: http.Header{
+				http.CanonicalHeaderKey("Retry-After"): []string{"1000"},
+			},
+			expectBody: []byte{},
+			assertError: func(n ipld.Node) {
+				x, err := ipld.Rebind[fdm.FailureModel](n, fdm.FailureType())
+				require.NoError(t, err)
+				require.Equal(t, ratelimit.RateLimitedErrorName, *x.Name)
+			},
+		},
 		{
 			name:  "not found when allocation for other space",
 			agent: alice,
@@ -320,7 +402,19 @@ func TestSpaceContentRetrieve(t *testing.T) {
 				require.NoError(t, err)
 			}
 
-			service := retrievalService{allocations, blobs}
+			service := retrievalService{allocations: allocations, blobs: blobs}
+			if test.egressLimit > 0 {
+				quotas := quota.NewManager(datastore.NewMapDatastore())
+				require.NoError(t, quotas.SetLimits(t.Context(), test.space, quota.Limits{Egress: test.egressLimit}))
+				service.quotas = quotas
+			}
+			if test.rateLimited {
+				// one request per 1000s, with the only token already taken
+				limiter := ratelimit.New("space", 0.001, 1)
+				ok, _ := limiter.Allow(t.Context(), test.space.String())
+				require.True(t, ok)
+				service.limiter = limiter
+			}
 			server, err := retrieval.NewServer(testutil.Service, WithSpaceContentRetrieveMethod(&service))
 			require.NoError(t, err)
 
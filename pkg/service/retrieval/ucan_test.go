@@ -40,7 +40,7 @@ func TestSpaceContentRetrieve(t *testing.T) {
 		storageSvc.Close(ctx)
 	})
 
-	retrievalSvc := retrieval.New(testutil.Alice, storageSvc.Blobs().Store(), storageSvc.Blobs().Allocations())
+	retrievalSvc := retrieval.New(testutil.Alice, storageSvc.Blobs().Store(), storageSvc.Blobs().Allocations(), nil, nil, nil)
 
 	port := piritutil.GetFreePort(t)
 	srvMux, err := server.NewServer(storageSvc, retrievalSvc)
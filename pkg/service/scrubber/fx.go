@@ -0,0 +1,81 @@
+package scrubber
+
+import (
+	"context"
+
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/store/blobstore"
+	"github.com/storacha/piri/pkg/subsystem"
+)
+
+var log = logging.Logger("scrubber")
+
+var Module = fx.Module("scrubber",
+	fx.Provide(
+		NewScrubberService,
+	),
+	// force construction, nothing else depends on the scrubber
+	fx.Invoke(func(*Service) {}),
+)
+
+type Params struct {
+	fx.In
+
+	Cfg        app.AppConfig
+	BlobStore  blobstore.Blobstore
+	Datastore  datastore.Datastore `name:"scrubber_datastore"`
+	Subsystems *subsystem.Registry
+}
+
+func NewScrubberService(lc fx.Lifecycle, params Params) (*Service, error) {
+	cfg := params.Cfg.Scrubber
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	lister, ok := params.BlobStore.(blobstore.BlobLister)
+	if !ok {
+		log.Warn("blob store cannot list its blobs, scrubber is disabled")
+		return nil, nil
+	}
+
+	interval := cfg.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	bytesPerSecond := cfg.BytesPerSecond
+	if bytesPerSecond == 0 {
+		bytesPerSecond = DefaultBytesPerSecond
+	}
+	var repairer Repairer
+	if cfg.Repair {
+		if len(cfg.Peers) == 0 {
+			log.Warn("scrubber repair is enabled but no peers are configured, corrupt blobs will only be recorded")
+		} else {
+			repairer = NewPeerRepairer(cfg.Peers, nil, params.BlobStore)
+		}
+	}
+
+	svc, err := New(params.BlobStore, lister, params.Datastore, repairer, interval, bytesPerSecond)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Subsystems.Register(subsystem.Scrubbing, svc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return svc.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			return svc.Stop(ctx)
+		},
+	})
+
+	return svc, nil
+}
@@ -0,0 +1,77 @@
+package scrubber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+// Repairer replaces the content of a corrupt blob with a good copy.
+type Repairer interface {
+	Repair(ctx context.Context, digest multihash.Multihash, size uint64) error
+}
+
+// PeerRepairer re-fetches corrupt blobs from the /blob endpoint of peer nodes
+// holding the same blob. Peers are tried in order until one returns content
+// that matches the digest.
+type PeerRepairer struct {
+	peers  []url.URL
+	client *http.Client
+	blobs  blobstore.Blobstore
+}
+
+// NewPeerRepairer creates a Repairer writing blobs fetched from peers to
+// blobs. A nil client uses [http.DefaultClient].
+func NewPeerRepairer(peers []url.URL, client *http.Client, blobs blobstore.Blobstore) *PeerRepairer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &PeerRepairer{peers: peers, client: client, blobs: blobs}
+}
+
+func (r *PeerRepairer) Repair(ctx context.Context, digest multihash.Multihash, size uint64) error {
+	if len(r.peers) == 0 {
+		return errors.New("no peers configured")
+	}
+	var errs []error
+	for _, peer := range r.peers {
+		err := r.fetch(ctx, peer, digest, size)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", peer.Host, err))
+	}
+	return errors.Join(errs...)
+}
+
+func (r *PeerRepairer) fetch(ctx context.Context, peer url.URL, digest multihash.Multihash, size uint64) error {
+	u := peer.JoinPath("blob", digestutil.Format(digest))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	res, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching blob: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching blob: unexpected status: %s", res.Status)
+	}
+	// the store verifies the content against the digest before replacing the
+	// corrupt copy
+	if err := r.blobs.Put(ctx, digest, size, res.Body); err != nil {
+		return fmt.Errorf("writing blob: %w", err)
+	}
+	return nil
+}
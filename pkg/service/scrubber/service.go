@@ -0,0 +1,396 @@
+// Package scrubber detects bitrot by periodically re-hashing the blobs held by
+// the node and comparing them to their digest, rather than waiting for a PDP
+// challenge of the corrupt data to fail.
+package scrubber
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/time/rate"
+
+	"github.com/storacha/piri/pkg/presets"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+const (
+	// DefaultInterval is the time between the start of passes if no interval
+	// is configured.
+	DefaultInterval = 7 * 24 * time.Hour
+	// DefaultBytesPerSecond is the read rate if no rate is configured.
+	DefaultBytesPerSecond = 16 << 20
+
+	corruptPrefix = "/corrupt/"
+	// maxBurst bounds the bytes read from the store at once when rate
+	// limited.
+	maxBurst = 1 << 20
+)
+
+// Corruption records a blob whose content does not hash to its digest.
+type Corruption struct {
+	Digest     multihash.Multihash `json:"-"`
+	Size       uint64              `json:"size"`
+	DetectedAt time.Time           `json:"detected_at"`
+	// RepairedAt is when a good copy was fetched from a peer, zero if the blob
+	// has not been repaired.
+	RepairedAt time.Time `json:"repaired_at,omitzero"`
+	// RepairError is why the last repair failed.
+	RepairError string `json:"repair_error,omitempty"`
+}
+
+// Result summarises a pass over the blob store.
+type Result struct {
+	// Checked is the number of blobs re-hashed.
+	Checked int
+	// Bytes is the number of bytes re-hashed.
+	Bytes uint64
+	// Skipped is the number of blobs whose digest uses a hash function the
+	// node cannot compute, such as piece commitments.
+	Skipped  int
+	Corrupt  int
+	Repaired int
+}
+
+// Service periodically iterates the blob store, re-hashing the content of
+// each blob at a limited rate. Blobs that no longer match their digest are
+// recorded and, if a Repairer is set, replaced with a copy from a peer.
+//
+// A record is removed once its blob verifies again, whether it was repaired
+// by the scrubber or uploaded again.
+type Service struct {
+	blobs    blobstore.Blobstore
+	lister   blobstore.BlobLister
+	ds       datastore.Datastore
+	repairer Repairer
+	limiter  *rate.Limiter
+	interval time.Duration
+	now      func() time.Time
+	metrics  *metrics
+	cancel   context.CancelFunc
+	done     chan struct{}
+	paused   atomic.Bool
+}
+
+// New creates a scrubber of blobs, recording corrupt blobs in ds. A nil
+// repairer only records corrupt blobs, and a bytesPerSecond of 0 reads
+// without limit.
+func New(
+	blobs blobstore.Blobstore,
+	lister blobstore.BlobLister,
+	ds datastore.Datastore,
+	repairer Repairer,
+	interval time.Duration,
+	bytesPerSecond uint64,
+) (*Service, error) {
+	m, err := newMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("creating scrubber metrics: %w", err)
+	}
+	var limiter *rate.Limiter
+	if bytesPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(bytesPerSecond), int(min(bytesPerSecond, maxBurst)))
+	}
+	return &Service{
+		blobs:    blobs,
+		lister:   lister,
+		ds:       ds,
+		repairer: repairer,
+		limiter:  limiter,
+		interval: interval,
+		now:      time.Now,
+		metrics:  m,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Start starts periodic passes over the blob store, the first immediately.
+// Passes are disabled when the interval is 0.
+func (s *Service) Start(ctx context.Context) error {
+	if s.interval <= 0 {
+		log.Info("scrubber disabled (interval is 0)")
+		close(s.done)
+		return nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	go s.run(runCtx)
+
+	log.Infof("scrubber started with interval: %v", s.interval)
+	return nil
+}
+
+// Stop stops scrubbing gracefully, interrupting a pass in progress.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	select {
+	case <-s.done:
+		log.Info("scrubber stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for scrubber to stop: %w", ctx.Err())
+	}
+}
+
+// Pause stops checking blobs until Resume is called. A pass in progress ends
+// after the blob being checked.
+func (s *Service) Pause() {
+	s.paused.Store(true)
+}
+
+// Resume continues scrubbing after a Pause.
+func (s *Service) Resume() {
+	s.paused.Store(false)
+}
+
+func (s *Service) run(ctx context.Context) {
+	defer close(s.done)
+
+	s.reportCorrupt(ctx)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if !s.paused.Load() {
+			res, err := s.Scrub(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Errorw("scrubbing blobs", "error", err)
+			}
+			log.Infow("scrub pass finished", "checked", res.Checked, "bytes", res.Bytes, "skipped", res.Skipped, "corrupt", res.Corrupt, "repaired", res.Repaired)
+		}
+		select {
+		case <-ctx.Done():
+			log.Info("scrubber context cancelled")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scrub makes a single pass over the blob store, returning what it found.
+func (s *Service) Scrub(ctx context.Context) (Result, error) {
+	var res Result
+	defer s.reportCorrupt(ctx)
+
+	for digest, err := range s.lister.List(ctx) {
+		if err != nil {
+			return res, fmt.Errorf("listing blobs: %w", err)
+		}
+		if s.paused.Load() {
+			log.Info("scrub pass interrupted, scrubbing is paused")
+			return res, nil
+		}
+
+		dmh, err := multihash.Decode(digest)
+		if err != nil {
+			res.Skipped++
+			continue
+		}
+		newHasher, ok := presets.HasherRegistry[multicodec.Code(dmh.Code).String()]
+		if !ok {
+			res.Skipped++
+			continue
+		}
+
+		size, intact, err := s.verify(ctx, digest, dmh.Digest, newHasher)
+		if err != nil {
+			if ctx.Err() != nil {
+				return res, ctx.Err()
+			}
+			// removed since it was listed
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			s.metrics.blobs.Inc(ctx, attribute.String("result", "error"))
+			log.Errorw("checking blob", "digest", digestutil.Format(digest), "error", err)
+			continue
+		}
+		res.Checked++
+		res.Bytes += size
+		s.metrics.bytes.Add(ctx, int64(size))
+
+		if intact {
+			s.metrics.blobs.Inc(ctx, attribute.String("result", "ok"))
+			if err := s.ds.Delete(ctx, corruptKey(digest)); err != nil {
+				return res, fmt.Errorf("clearing corruption record: %w", err)
+			}
+			continue
+		}
+
+		res.Corrupt++
+		s.metrics.blobs.Inc(ctx, attribute.String("result", "corrupt"))
+		log.Errorw("blob is corrupt, its content does not match its digest", "digest", digestutil.Format(digest), "size", size)
+		repaired, err := s.corrupt(ctx, digest, size)
+		if err != nil {
+			return res, err
+		}
+		if repaired {
+			res.Repaired++
+		}
+	}
+	return res, nil
+}
+
+// verify re-hashes the content of a blob, reporting whether it matches the
+// digest.
+func (s *Service) verify(ctx context.Context, digest multihash.Multihash, want []byte, newHasher func() hash.Hash) (uint64, bool, error) {
+	obj, err := s.blobs.Get(ctx, digest)
+	if err != nil {
+		return 0, false, err
+	}
+	body := obj.Body()
+	defer body.Close()
+
+	var r io.Reader = body
+	if s.limiter != nil {
+		r = &limitedReader{ctx: ctx, r: body, limiter: s.limiter}
+	}
+	h := newHasher()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return 0, false, fmt.Errorf("reading blob: %w", err)
+	}
+	return uint64(n), string(h.Sum(nil)) == string(want), nil
+}
+
+// corrupt records a corrupt blob and attempts to repair it.
+func (s *Service) corrupt(ctx context.Context, digest multihash.Multihash, size uint64) (bool, error) {
+	c, ok, err := s.get(ctx, digest)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		c = Corruption{Digest: digest, Size: size, DetectedAt: s.now()}
+	}
+
+	repaired := false
+	if s.repairer != nil {
+		if err := s.repairer.Repair(ctx, digest, size); err != nil {
+			s.metrics.repairs.Inc(ctx, attribute.String("result", "failed"))
+			log.Errorw("repairing corrupt blob", "digest", digestutil.Format(digest), "error", err)
+			c.RepairError = err.Error()
+		} else {
+			s.metrics.repairs.Inc(ctx, attribute.String("result", "ok"))
+			log.Infow("repaired corrupt blob from peer", "digest", digestutil.Format(digest))
+			c.RepairedAt = s.now()
+			c.RepairError = ""
+			repaired = true
+		}
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return false, fmt.Errorf("encoding corruption record: %w", err)
+	}
+	if err := s.ds.Put(ctx, corruptKey(digest), b); err != nil {
+		return false, fmt.Errorf("recording corrupt blob: %w", err)
+	}
+	return repaired, nil
+}
+
+func (s *Service) get(ctx context.Context, digest multihash.Multihash) (Corruption, bool, error) {
+	b, err := s.ds.Get(ctx, corruptKey(digest))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return Corruption{}, false, nil
+		}
+		return Corruption{}, false, fmt.Errorf("getting corruption record: %w", err)
+	}
+	var c Corruption
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Corruption{}, false, fmt.Errorf("decoding corruption record: %w", err)
+	}
+	c.Digest = digest
+	return c, true, nil
+}
+
+// Corrupt lists the blobs found corrupt that have not verified since, oldest
+// first.
+func (s *Service) Corrupt(ctx context.Context) ([]Corruption, error) {
+	results, err := s.ds.Query(ctx, query.Query{Prefix: corruptPrefix})
+	if err != nil {
+		return nil, fmt.Errorf("querying corruption records: %w", err)
+	}
+	defer results.Close()
+
+	var out []Corruption
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, fmt.Errorf("iterating corruption records: %w", r.Error)
+		}
+		digest, err := digestutil.Parse(strings.TrimPrefix(r.Key, corruptPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("parsing corruption record key: %w", err)
+		}
+		var c Corruption
+		if err := json.Unmarshal(r.Value, &c); err != nil {
+			return nil, fmt.Errorf("decoding corruption record: %w", err)
+		}
+		c.Digest = digest
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DetectedAt.Before(out[j].DetectedAt) })
+	return out, nil
+}
+
+// reportCorrupt updates the gauge of unrepaired corrupt blobs, which
+// operators alert on.
+func (s *Service) reportCorrupt(ctx context.Context) {
+	records, err := s.Corrupt(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warnw("counting corrupt blobs", "error", err)
+		}
+		return
+	}
+	var unrepaired int64
+	for _, c := range records {
+		if c.RepairedAt.IsZero() {
+			unrepaired++
+		}
+	}
+	s.metrics.corrupt.Record(ctx, unrepaired)
+}
+
+func corruptKey(digest multihash.Multihash) datastore.Key {
+	return datastore.NewKey(corruptPrefix + digestutil.Format(digest))
+}
+
+// limitedReader reads at the rate allowed by a limiter.
+type limitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.limiter.WaitN(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
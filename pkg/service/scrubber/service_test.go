@@ -0,0 +1,183 @@
+package scrubber
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/store/blobstore"
+)
+
+type repairFunc func(ctx context.Context, digest multihash.Multihash, size uint64) error
+
+func (f repairFunc) Repair(ctx context.Context, digest multihash.Multihash, size uint64) error {
+	return f(ctx, digest, size)
+}
+
+func putBlob(t *testing.T, blobs blobstore.Blobstore) (multihash.Multihash, []byte) {
+	data := testutil.RandomBytes(t, 128)
+	digest := testutil.Must(multihash.Sum(data, multihash.SHA2_256, -1))(t)
+	require.NoError(t, blobs.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data)))
+	return digest, data
+}
+
+// rot overwrites the content of a blob held in the datastore backing the
+// blob store, bypassing verification.
+func rot(t *testing.T, ds datastore.Datastore, digest multihash.Multihash) {
+	require.NoError(t, ds.Put(t.Context(), datastore.NewKey(digestutil.Format(digest)), testutil.RandomBytes(t, 128)))
+}
+
+func TestScrub(t *testing.T) {
+	t.Run("records corrupt blobs", func(t *testing.T) {
+		blobDS := sync.MutexWrap(datastore.NewMapDatastore())
+		blobs := blobstore.NewDatastoreStore(blobDS)
+		putBlob(t, blobs)
+		bad, _ := putBlob(t, blobs)
+		rot(t, blobDS, bad)
+
+		svc, err := New(blobs, blobs, datastore.NewMapDatastore(), nil, DefaultInterval, 0)
+		require.NoError(t, err)
+
+		res, err := svc.Scrub(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 2, res.Checked)
+		require.Equal(t, uint64(256), res.Bytes)
+		require.Equal(t, 1, res.Corrupt)
+		require.Equal(t, 0, res.Repaired)
+
+		corrupt, err := svc.Corrupt(t.Context())
+		require.NoError(t, err)
+		require.Len(t, corrupt, 1)
+		require.Equal(t, bad, corrupt[0].Digest)
+		require.Equal(t, uint64(128), corrupt[0].Size)
+		require.True(t, corrupt[0].RepairedAt.IsZero())
+	})
+
+	t.Run("skips digests it cannot compute", func(t *testing.T) {
+		blobDS := sync.MutexWrap(datastore.NewMapDatastore())
+		blobs := blobstore.NewDatastoreStore(blobDS)
+		data := testutil.RandomBytes(t, 32)
+		digest := testutil.Must(multihash.Sum(data, multihash.IDENTITY, -1))(t)
+		require.NoError(t, blobs.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data)))
+
+		svc, err := New(blobs, blobs, datastore.NewMapDatastore(), nil, DefaultInterval, 0)
+		require.NoError(t, err)
+
+		res, err := svc.Scrub(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, Result{Skipped: 1}, res)
+	})
+
+	t.Run("repairs corrupt blobs and clears the record once verified", func(t *testing.T) {
+		blobDS := sync.MutexWrap(datastore.NewMapDatastore())
+		blobs := blobstore.NewDatastoreStore(blobDS)
+		digest, data := putBlob(t, blobs)
+		rot(t, blobDS, digest)
+
+		repairer := repairFunc(func(ctx context.Context, d multihash.Multihash, size uint64) error {
+			return blobs.Put(ctx, d, size, bytes.NewReader(data))
+		})
+		svc, err := New(blobs, blobs, datastore.NewMapDatastore(), repairer, DefaultInterval, 0)
+		require.NoError(t, err)
+
+		res, err := svc.Scrub(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 1, res.Corrupt)
+		require.Equal(t, 1, res.Repaired)
+
+		corrupt, err := svc.Corrupt(t.Context())
+		require.NoError(t, err)
+		require.Len(t, corrupt, 1)
+		require.False(t, corrupt[0].RepairedAt.IsZero())
+
+		res, err = svc.Scrub(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Corrupt)
+
+		corrupt, err = svc.Corrupt(t.Context())
+		require.NoError(t, err)
+		require.Empty(t, corrupt)
+	})
+
+	t.Run("records failed repairs", func(t *testing.T) {
+		blobDS := sync.MutexWrap(datastore.NewMapDatastore())
+		blobs := blobstore.NewDatastoreStore(blobDS)
+		digest, _ := putBlob(t, blobs)
+		rot(t, blobDS, digest)
+
+		repairer := repairFunc(func(ctx context.Context, d multihash.Multihash, size uint64) error {
+			return errors.New("no peer has the blob")
+		})
+		svc, err := New(blobs, blobs, datastore.NewMapDatastore(), repairer, DefaultInterval, 0)
+		require.NoError(t, err)
+
+		res, err := svc.Scrub(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 0, res.Repaired)
+
+		corrupt, err := svc.Corrupt(t.Context())
+		require.NoError(t, err)
+		require.Len(t, corrupt, 1)
+		require.Equal(t, "no peer has the blob", corrupt[0].RepairError)
+	})
+
+	t.Run("stops when paused", func(t *testing.T) {
+		blobs := blobstore.NewDatastoreStore(sync.MutexWrap(datastore.NewMapDatastore()))
+		putBlob(t, blobs)
+
+		svc, err := New(blobs, blobs, datastore.NewMapDatastore(), nil, DefaultInterval, 0)
+		require.NoError(t, err)
+		svc.Pause()
+
+		res, err := svc.Scrub(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, Result{}, res)
+	})
+}
+
+func TestPeerRepairer(t *testing.T) {
+	data := testutil.RandomBytes(t, 64)
+	digest := testutil.Must(multihash.Sum(data, multihash.SHA2_256, -1))(t)
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	liar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testutil.RandomBytes(t, 64))
+	}))
+	defer liar.Close()
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/blob/"+digestutil.Format(digest) {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer peer.Close()
+
+	var peers []url.URL
+	for _, s := range []*httptest.Server{missing, liar, peer} {
+		peers = append(peers, *testutil.Must(url.Parse(s.URL))(t))
+	}
+
+	blobs := blobstore.NewDatastoreStore(sync.MutexWrap(datastore.NewMapDatastore()))
+	r := NewPeerRepairer(peers, nil, blobs)
+	require.NoError(t, r.Repair(t.Context(), digest, uint64(len(data))))
+
+	obj, err := blobs.Get(t.Context(), digest)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), obj.Size())
+
+	r = NewPeerRepairer(peers[:2], nil, blobs)
+	require.Error(t, r.Repair(t.Context(), digest, uint64(len(data))))
+}
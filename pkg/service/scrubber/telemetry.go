@@ -0,0 +1,55 @@
+package scrubber
+
+import (
+	"go.opentelemetry.io/otel"
+
+	"github.com/storacha/piri/lib/telemetry"
+)
+
+type metrics struct {
+	blobs   *telemetry.Counter
+	bytes   *telemetry.Counter
+	repairs *telemetry.Counter
+	corrupt *telemetry.Int64Gauge
+}
+
+func newMetrics() (*metrics, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/service/scrubber")
+	blobs, err := telemetry.NewCounter(
+		meter,
+		"piri_scrub_blobs",
+		"blobs checked by the integrity scrubber, by result (ok, corrupt or error)",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := telemetry.NewCounter(
+		meter,
+		"piri_scrub_bytes",
+		"bytes of blobs re-hashed by the integrity scrubber",
+		"By",
+	)
+	if err != nil {
+		return nil, err
+	}
+	repairs, err := telemetry.NewCounter(
+		meter,
+		"piri_scrub_repairs",
+		"corrupt blobs re-fetched from peers, by result (ok or failed)",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	corrupt, err := telemetry.NewInt64Gauge(
+		meter,
+		"piri_scrub_corrupt_blobs",
+		"blobs found corrupt that have not been repaired",
+		"1",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &metrics{blobs: blobs, bytes: bytes, repairs: repairs, corrupt: corrupt}, nil
+}
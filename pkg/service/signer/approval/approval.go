@@ -0,0 +1,358 @@
+// Package approval holds the EIP-712 sign requests of the node for approval
+// by the operator, so the signing service does not blind-sign every
+// operation.
+//
+// Requests of the operations the policy does not approve automatically are
+// recorded in the database and wait to be approved or rejected through the
+// admin API. A sign call waits for the decision for a while, then fails so
+// the operation is retried later. A retry with the same arguments is matched
+// to the recorded request, and is signed once the request is approved. An
+// approved request is claimed by a single sign call, so it is signed once.
+//
+// The request table is created by the shared migrations of the PDP database,
+// [models.AutoMigrateDB].
+package approval
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+var log = logging.Logger("signer/approval")
+
+// Operation is a signed operation of the warm storage service.
+type Operation = models.SignOperation
+
+const (
+	OpCreateDataSet         Operation = "create-dataset"
+	OpAddPieces             Operation = "add-pieces"
+	OpSchedulePieceRemovals Operation = "schedule-piece-removals"
+	OpDeleteDataSet         Operation = "delete-dataset"
+)
+
+// Operations are the operations that can be held for approval.
+var Operations = []Operation{OpCreateDataSet, OpAddPieces, OpSchedulePieceRemovals, OpDeleteDataSet}
+
+// Status is the state of a sign request.
+type Status = models.SignStatus
+
+const (
+	// StatusPending requests wait for the operator.
+	StatusPending Status = "pending"
+	// StatusApproved requests are signed by the next matching sign call.
+	StatusApproved Status = "approved"
+	// StatusSigning requests are being signed by the sign call that claimed
+	// them.
+	StatusSigning Status = "signing"
+	// StatusRejected requests fail their sign calls until approved.
+	StatusRejected Status = "rejected"
+	// StatusSigned requests were signed, a new request is made for the same
+	// operation.
+	StatusSigned Status = "signed"
+)
+
+const (
+	// DefaultWait is how long a sign call waits for its request to be
+	// approved when no wait is configured.
+	DefaultWait = time.Minute
+	// DefaultLimit is the number of requests returned by [Queue.List] when no
+	// limit is given.
+	DefaultLimit = 100
+
+	// pollInterval is the interval waiting sign calls check their request at,
+	// in case it was decided by another process sharing the database.
+	pollInterval = 10 * time.Second
+)
+
+var (
+	// ErrPending is returned by sign calls whose request was not decided in
+	// time.
+	ErrPending = errors.New("sign request awaiting approval")
+	// ErrRejected is returned by sign calls whose request was rejected.
+	ErrRejected = errors.New("sign request rejected")
+	// ErrNotFound is returned when deciding a request that does not exist.
+	ErrNotFound = errors.New("sign request not found")
+	// ErrDecided is returned when deciding a request that was already signed,
+	// or rejecting one that was approved or is being signed.
+	ErrDecided = errors.New("sign request already decided")
+)
+
+// Policy selects the operations held for approval.
+type Policy struct {
+	// Manual lists the operations whose requests wait for approval. Requests
+	// of other operations are signed right away.
+	Manual []Operation
+	// AutoApproveAddPiecesBelow approves add-pieces requests whose pieces
+	// total fewer padded bytes, even if add-pieces is manual. 0 disables it.
+	AutoApproveAddPiecesBelow uint64
+	// Wait is how long a sign call waits for its request to be approved
+	// before failing with [ErrPending]. Zero uses [DefaultWait].
+	Wait time.Duration
+}
+
+// approves reports whether the request is approved without the operator.
+func (p Policy) approves(req *Request) bool {
+	if !slices.Contains(p.Manual, req.Operation) {
+		return true
+	}
+	return req.Operation == OpAddPieces && req.Size > 0 && req.Size < p.AutoApproveAddPiecesBelow
+}
+
+// Request is a sign request held for approval.
+type Request = models.SignRequest
+
+func fingerprint(r *Request) string {
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(r.Operation), []byte(r.DataSet), []byte(r.Payee), r.Pieces, r.Metadata} {
+		fmt.Fprintf(h, "%d:", len(part))
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Queue records the sign requests held for approval and waits for their
+// decision.
+type Queue struct {
+	db     *gorm.DB
+	policy Policy
+
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+// New creates a queue holding requests for approval according to the
+// policy, in the request table of db.
+func New(db *gorm.DB, policy Policy) (*Queue, error) {
+	for _, op := range policy.Manual {
+		if !slices.Contains(Operations, op) {
+			return nil, fmt.Errorf("unknown operation %q", op)
+		}
+	}
+	if policy.Wait <= 0 {
+		policy.Wait = DefaultWait
+	}
+	return &Queue{
+		db:      db,
+		policy:  policy,
+		changed: make(chan struct{}),
+	}, nil
+}
+
+// Await returns once the request is approved and claimed for signing.
+// Requests approved by the policy return right away with an ID of 0, others
+// are recorded, or matched to a recorded request with the same arguments, and
+// their ID is returned once approved and claimed by this call. The caller must
+// then mark the request [Queue.Signed], or [Queue.Release] it if signing
+// fails. It fails with [ErrRejected] if the request is rejected, and with
+// [ErrPending] if it is not decided within the wait of the policy or is signed
+// by another call.
+func (q *Queue) Await(ctx context.Context, req Request) (uint, error) {
+	if q.policy.approves(&req) {
+		return 0, nil
+	}
+
+	req.Fingerprint = fingerprint(&req)
+	rec, err := q.record(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, q.policy.Wait)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		switch rec.Status {
+		case StatusApproved:
+			claimed, err := q.claim(ctx, rec.ID)
+			if err != nil {
+				return 0, err
+			}
+			if claimed {
+				return rec.ID, nil
+			}
+			// claimed by a concurrent call, wait for it to be signed
+		case StatusRejected:
+			if rec.Reason != "" {
+				return 0, fmt.Errorf("%w: request %d: %s", ErrRejected, rec.ID, rec.Reason)
+			}
+			return 0, fmt.Errorf("%w: request %d", ErrRejected, rec.ID)
+		case StatusSigned:
+			// signed by a concurrent call, it must be approved again
+			return 0, fmt.Errorf("%w: request %d was signed by another call", ErrPending, rec.ID)
+		}
+
+		changed := q.changes()
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return 0, fmt.Errorf("%w: request %d, approve it with `piri client admin signing approve %d`", ErrPending, rec.ID, rec.ID)
+			}
+			return 0, ctx.Err()
+		case <-changed:
+		case <-ticker.C:
+		}
+		if err := q.db.WithContext(ctx).Take(&rec, rec.ID).Error; err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			return 0, fmt.Errorf("reading sign request %d: %w", rec.ID, err)
+		}
+	}
+}
+
+// record returns the undecided or rejected request matching req, or records
+// req if there is none.
+func (q *Queue) record(ctx context.Context, req Request) (Request, error) {
+	var rec Request
+	err := q.db.WithContext(ctx).
+		Where("fingerprint = ? AND status <> ?", req.Fingerprint, StatusSigned).
+		Order("id").
+		Take(&rec).Error
+	if err == nil {
+		return rec, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return Request{}, fmt.Errorf("finding sign request: %w", err)
+	}
+
+	req.ID = 0
+	req.Status = StatusPending
+	req.CreatedAt = time.Now().UTC()
+	if err := q.db.WithContext(ctx).Create(&req).Error; err != nil {
+		return Request{}, fmt.Errorf("recording sign request: %w", err)
+	}
+	log.Warnw("sign request awaiting approval", "id", req.ID, "operation", req.Operation, "dataSet", req.DataSet)
+	return req, nil
+}
+
+// claim moves an approved request to signing, reporting whether this call
+// claimed it. Only one of the calls waiting for a request claims it.
+func (q *Queue) claim(ctx context.Context, id uint) (bool, error) {
+	res := q.db.WithContext(ctx).Model(&Request{}).
+		Where("id = ? AND status = ?", id, StatusApproved).
+		Update("status", StatusSigning)
+	if res.Error != nil {
+		return false, fmt.Errorf("claiming sign request %d: %w", id, res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Signed marks a claimed request as signed, so a later call with the same
+// arguments is approved again.
+func (q *Queue) Signed(ctx context.Context, id uint) error {
+	res := q.db.WithContext(ctx).Model(&Request{}).
+		Where("id = ? AND status = ?", id, StatusSigning).
+		Update("status", StatusSigned)
+	if res.Error != nil {
+		return fmt.Errorf("marking sign request %d signed: %w", id, res.Error)
+	}
+	q.notify()
+	return nil
+}
+
+// Release returns a claimed request that failed to sign to approved, for the
+// retry of the operation to claim.
+func (q *Queue) Release(ctx context.Context, id uint) error {
+	res := q.db.WithContext(ctx).Model(&Request{}).
+		Where("id = ? AND status = ?", id, StatusSigning).
+		Update("status", StatusApproved)
+	if res.Error != nil {
+		return fmt.Errorf("releasing sign request %d: %w", id, res.Error)
+	}
+	q.notify()
+	return nil
+}
+
+// List returns the requests of the given status, all of them if status is
+// empty, most recent first. A limit of zero uses [DefaultLimit].
+func (q *Queue) List(ctx context.Context, status Status, limit int) ([]Request, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	db := q.db.WithContext(ctx).Order("id DESC").Limit(limit)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	var reqs []Request
+	if err := db.Find(&reqs).Error; err != nil {
+		return nil, fmt.Errorf("listing sign requests: %w", err)
+	}
+	return reqs, nil
+}
+
+// Approve approves a pending or rejected request. The waiting sign call, or
+// the next one with the same arguments, is signed. A request left signing by
+// a node that stopped while signing it can be approved again.
+func (q *Queue) Approve(ctx context.Context, id uint) (Request, error) {
+	return q.decide(ctx, id, StatusApproved, "", StatusPending, StatusRejected, StatusSigning)
+}
+
+// Reject rejects a pending request. Sign calls with the same arguments fail
+// until it is approved.
+func (q *Queue) Reject(ctx context.Context, id uint, reason string) (Request, error) {
+	return q.decide(ctx, id, StatusRejected, reason, StatusPending)
+}
+
+func (q *Queue) decide(ctx context.Context, id uint, status Status, reason string, from ...Status) (Request, error) {
+	var rec Request
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Take(&rec, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %d", ErrNotFound, id)
+			}
+			return fmt.Errorf("reading sign request %d: %w", id, err)
+		}
+		if rec.Status == status {
+			return nil
+		}
+		if !slices.Contains(from, rec.Status) {
+			return fmt.Errorf("%w: request %d is %s", ErrDecided, id, rec.Status)
+		}
+		now := time.Now().UTC()
+		rec.Status, rec.Reason, rec.DecidedAt = status, reason, &now
+		if err := tx.Save(&rec).Error; err != nil {
+			return fmt.Errorf("updating sign request %d: %w", id, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Request{}, err
+	}
+	log.Infow("sign request decided", "id", id, "operation", rec.Operation, "status", status)
+	q.notify()
+	return rec, nil
+}
+
+// changes returns a channel closed at the next decision.
+func (q *Queue) changes() <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.changed
+}
+
+func (q *Queue) notify() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// toJSON encodes strings and metadata entries, which cannot fail.
+func toJSON(v any) datatypes.JSON {
+	b, _ := json.Marshal(v)
+	return b
+}
@@ -0,0 +1,258 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/storacha/filecoin-services/go/eip712"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/ucan"
+	signertypes "github.com/storacha/piri-signing-service/pkg/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+)
+
+// fakeSigner counts the operations it signs, failing with fail if set.
+type fakeSigner struct {
+	signertypes.SigningService
+	signed int
+	fail   error
+}
+
+func (f *fakeSigner) SignCreateDataSet(context.Context, ucan.Signer, *big.Int, common.Address, []eip712.MetadataEntry, ...delegation.Option) (*eip712.AuthSignature, error) {
+	f.signed++
+	return &eip712.AuthSignature{}, nil
+}
+
+func (f *fakeSigner) SignAddPieces(context.Context, ucan.Signer, *big.Int, *big.Int, [][]byte, [][]eip712.MetadataEntry, [][]ipld.Link, [][]message.AgentMessage, ...delegation.Option) (*eip712.AuthSignature, error) {
+	f.signed++
+	return &eip712.AuthSignature{}, nil
+}
+
+func (f *fakeSigner) SignDeleteDataSet(context.Context, ucan.Signer, *big.Int, ...delegation.Option) (*eip712.AuthSignature, error) {
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	f.signed++
+	return &eip712.AuthSignature{}, nil
+}
+
+func newSigner(t *testing.T, policy Policy) (*Signer, *Queue, *fakeSigner) {
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+	q, err := New(db, policy)
+	require.NoError(t, err)
+	fake := &fakeSigner{}
+	return NewSigner(fake, q), q, fake
+}
+
+func TestApproval(t *testing.T) {
+	ctx := t.Context()
+	s, q, fake := newSigner(t, Policy{
+		Manual:                    []Operation{OpAddPieces, OpDeleteDataSet},
+		AutoApproveAddPiecesBelow: 1 << 20,
+		Wait:                      50 * time.Millisecond,
+	})
+	dataSet := big.NewInt(7)
+
+	t.Run("manual request waits for approval", func(t *testing.T) {
+		_, err := s.SignDeleteDataSet(ctx, testutil.Alice, dataSet)
+		require.ErrorIs(t, err, ErrPending)
+		require.Zero(t, fake.signed)
+
+		// a retry is matched to the same request
+		_, err = s.SignDeleteDataSet(ctx, testutil.Alice, dataSet)
+		require.ErrorIs(t, err, ErrPending)
+		pending, err := q.List(ctx, StatusPending, 0)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		require.Equal(t, OpDeleteDataSet, pending[0].Operation)
+		require.Equal(t, "7", pending[0].DataSet)
+
+		_, err = q.Approve(ctx, pending[0].ID)
+		require.NoError(t, err)
+		_, err = s.SignDeleteDataSet(ctx, testutil.Alice, dataSet)
+		require.NoError(t, err)
+		require.Equal(t, 1, fake.signed)
+
+		signed, err := q.List(ctx, StatusSigned, 0)
+		require.NoError(t, err)
+		require.Len(t, signed, 1)
+		_, err = q.Approve(ctx, signed[0].ID)
+		require.ErrorIs(t, err, ErrDecided)
+	})
+
+	t.Run("rejected request fails until approved", func(t *testing.T) {
+		fake.signed = 0
+		_, err := s.SignDeleteDataSet(ctx, testutil.Alice, dataSet)
+		require.ErrorIs(t, err, ErrPending)
+		pending, err := q.List(ctx, StatusPending, 0)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+
+		_, err = q.Reject(ctx, pending[0].ID, "still in use")
+		require.NoError(t, err)
+		_, err = s.SignDeleteDataSet(ctx, testutil.Alice, dataSet)
+		require.ErrorIs(t, err, ErrRejected)
+		require.ErrorContains(t, err, "still in use")
+
+		_, err = q.Approve(ctx, pending[0].ID)
+		require.NoError(t, err)
+		_, err = s.SignDeleteDataSet(ctx, testutil.Alice, dataSet)
+		require.NoError(t, err)
+		require.Equal(t, 1, fake.signed)
+	})
+
+	t.Run("add pieces below the size limit are approved", func(t *testing.T) {
+		fake.signed = 0
+		small := testutil.RandomPiece(t, 1000)
+		_, err := s.SignAddPieces(ctx, testutil.Alice, dataSet, big.NewInt(1), [][]byte{pieceData(small.Link())}, nil, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, fake.signed)
+
+		large := testutil.RandomPiece(t, 4<<20)
+		_, err = s.SignAddPieces(ctx, testutil.Alice, dataSet, big.NewInt(2), [][]byte{pieceData(large.Link())}, nil, nil, nil)
+		require.ErrorIs(t, err, ErrPending)
+		pending, err := q.List(ctx, StatusPending, 0)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		require.Equal(t, large.PaddedSize(), pending[0].Size)
+		var pieces []string
+		require.NoError(t, json.Unmarshal(pending[0].Pieces, &pieces))
+		require.Equal(t, []string{large.Link().String()}, pieces)
+
+		// signed with a new nonce once approved
+		_, err = q.Approve(ctx, pending[0].ID)
+		require.NoError(t, err)
+		_, err = s.SignAddPieces(ctx, testutil.Alice, dataSet, big.NewInt(3), [][]byte{pieceData(large.Link())}, nil, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, 2, fake.signed)
+	})
+
+	t.Run("failed signing is approved for the retry", func(t *testing.T) {
+		fake.signed = 0
+		other := big.NewInt(8)
+		_, err := s.SignDeleteDataSet(ctx, testutil.Alice, other)
+		require.ErrorIs(t, err, ErrPending)
+		pending, err := q.List(ctx, StatusPending, 0)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		_, err = q.Approve(ctx, pending[0].ID)
+		require.NoError(t, err)
+
+		fake.fail = errors.New("signing service unavailable")
+		_, err = s.SignDeleteDataSet(ctx, testutil.Alice, other)
+		require.ErrorIs(t, err, fake.fail)
+		approved, err := q.List(ctx, StatusApproved, 0)
+		require.NoError(t, err)
+		require.Len(t, approved, 1)
+
+		fake.fail = nil
+		_, err = s.SignDeleteDataSet(ctx, testutil.Alice, other)
+		require.NoError(t, err)
+		require.Equal(t, 1, fake.signed)
+	})
+}
+
+func TestApprovalOfCreateDataSet(t *testing.T) {
+	ctx := t.Context()
+	s, q, fake := newSigner(t, Policy{Manual: []Operation{OpCreateDataSet}, Wait: 50 * time.Millisecond})
+	payee := common.HexToAddress("0x0000000000000000000000000000000000000def")
+
+	_, err := s.SignCreateDataSet(ctx, testutil.Alice, big.NewInt(1), payee, nil)
+	require.ErrorIs(t, err, ErrPending)
+	pending, err := q.List(ctx, StatusPending, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, "1", pending[0].DataSet)
+	_, err = q.Approve(ctx, pending[0].ID)
+	require.NoError(t, err)
+
+	// the approval is for the data set it was requested for
+	_, err = s.SignCreateDataSet(ctx, testutil.Alice, big.NewInt(2), payee, nil)
+	require.ErrorIs(t, err, ErrPending)
+	require.Zero(t, fake.signed)
+
+	_, err = s.SignCreateDataSet(ctx, testutil.Alice, big.NewInt(1), payee, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, fake.signed)
+}
+
+func TestApprovalWhileWaiting(t *testing.T) {
+	ctx := t.Context()
+	s, q, fake := newSigner(t, Policy{Manual: []Operation{OpDeleteDataSet}, Wait: 10 * time.Second})
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := s.SignDeleteDataSet(ctx, testutil.Alice, big.NewInt(1))
+		errc <- err
+	}()
+
+	var pending []Request
+	require.Eventually(t, func() bool {
+		var err error
+		pending, err = q.List(ctx, StatusPending, 0)
+		return err == nil && len(pending) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err := q.Approve(ctx, pending[0].ID)
+	require.NoError(t, err)
+
+	select {
+	case err := <-errc:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("sign call not released by the approval")
+	}
+	require.Equal(t, 1, fake.signed)
+}
+
+func TestApprovalIsSignedOnce(t *testing.T) {
+	ctx := t.Context()
+	s, q, fake := newSigner(t, Policy{Manual: []Operation{OpDeleteDataSet}, Wait: time.Second})
+
+	// two calls wait for the same request
+	errc := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := s.SignDeleteDataSet(ctx, testutil.Alice, big.NewInt(1))
+			errc <- err
+		}()
+	}
+	var pending []Request
+	require.Eventually(t, func() bool {
+		var err error
+		pending, err = q.List(ctx, StatusPending, 0)
+		return err == nil && len(pending) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err := q.Approve(ctx, pending[0].ID)
+	require.NoError(t, err)
+
+	var errs []error
+	for range 2 {
+		select {
+		case err := <-errc:
+			errs = append(errs, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("sign call not released by the approval")
+		}
+	}
+	require.Equal(t, 1, fake.signed)
+	require.ElementsMatch(t, []bool{true, false}, []bool{errs[0] == nil, errs[1] == nil})
+}
+
+func pieceData(link ipld.Link) []byte {
+	return link.(cidlink.Link).Cid.Bytes()
+}
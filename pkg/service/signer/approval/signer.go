@@ -0,0 +1,166 @@
+package approval
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/storacha/filecoin-services/go/eip712"
+	"github.com/storacha/go-libstoracha/piece/piece"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/ucan"
+	signertypes "github.com/storacha/piri-signing-service/pkg/types"
+)
+
+// Signer is a signing service whose sign calls wait for their request to be
+// approved before being passed on.
+type Signer struct {
+	signertypes.SigningService
+	queue *Queue
+}
+
+var _ signertypes.SigningService = (*Signer)(nil)
+
+// NewSigner wraps a signing service so operations are only signed once
+// approved in the queue.
+func NewSigner(signer signertypes.SigningService, queue *Queue) *Signer {
+	return &Signer{SigningService: signer, queue: queue}
+}
+
+func (s *Signer) SignCreateDataSet(
+	ctx context.Context,
+	issuer ucan.Signer,
+	dataSet *big.Int,
+	payee common.Address,
+	metadata []eip712.MetadataEntry,
+	options ...delegation.Option,
+) (*eip712.AuthSignature, error) {
+	// the data set ID is a nonce, but it is signed: an approval is only for
+	// the data set it was requested for, which retries reuse until signed
+	req := Request{
+		Operation: OpCreateDataSet,
+		DataSet:   dataSet.String(),
+		Payee:     payee.Hex(),
+		Metadata:  toJSON(metadata),
+	}
+	return s.sign(ctx, req, func() (*eip712.AuthSignature, error) {
+		return s.SigningService.SignCreateDataSet(ctx, issuer, dataSet, payee, metadata, options...)
+	})
+}
+
+func (s *Signer) SignAddPieces(
+	ctx context.Context,
+	issuer ucan.Signer,
+	dataSet *big.Int,
+	firstAdded *big.Int,
+	pieceData [][]byte,
+	metadata [][]eip712.MetadataEntry,
+	prfs [][]ipld.Link,
+	prfData [][]message.AgentMessage,
+	options ...delegation.Option,
+) (*eip712.AuthSignature, error) {
+	// firstAdded is a nonce, a retry signs a different one
+	pieces, size := addedPieces(pieceData)
+	req := Request{
+		Operation: OpAddPieces,
+		DataSet:   dataSet.String(),
+		Pieces:    toJSON(pieces),
+		Size:      size,
+		Metadata:  toJSON(metadata),
+	}
+	return s.sign(ctx, req, func() (*eip712.AuthSignature, error) {
+		return s.SigningService.SignAddPieces(ctx, issuer, dataSet, firstAdded, pieceData, metadata, prfs, prfData, options...)
+	})
+}
+
+func (s *Signer) SignSchedulePieceRemovals(
+	ctx context.Context,
+	issuer ucan.Signer,
+	dataSet *big.Int,
+	pieceIds []*big.Int,
+	options ...delegation.Option,
+) (*eip712.AuthSignature, error) {
+	ids := make([]string, len(pieceIds))
+	for i, id := range pieceIds {
+		ids[i] = id.String()
+	}
+	req := Request{
+		Operation: OpSchedulePieceRemovals,
+		DataSet:   dataSet.String(),
+		Pieces:    toJSON(ids),
+	}
+	return s.sign(ctx, req, func() (*eip712.AuthSignature, error) {
+		return s.SigningService.SignSchedulePieceRemovals(ctx, issuer, dataSet, pieceIds, options...)
+	})
+}
+
+func (s *Signer) SignDeleteDataSet(
+	ctx context.Context,
+	issuer ucan.Signer,
+	dataSet *big.Int,
+	options ...delegation.Option,
+) (*eip712.AuthSignature, error) {
+	req := Request{
+		Operation: OpDeleteDataSet,
+		DataSet:   dataSet.String(),
+	}
+	return s.sign(ctx, req, func() (*eip712.AuthSignature, error) {
+		return s.SigningService.SignDeleteDataSet(ctx, issuer, dataSet, options...)
+	})
+}
+
+// sign waits for the request to be approved, signs, and marks the request
+// signed. A request that fails to sign is approved again for the retry.
+func (s *Signer) sign(ctx context.Context, req Request, sign func() (*eip712.AuthSignature, error)) (*eip712.AuthSignature, error) {
+	id, err := s.queue.Await(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sign()
+	if err != nil {
+		if id != 0 {
+			if rerr := s.queue.Release(context.WithoutCancel(ctx), id); rerr != nil {
+				log.Errorw("releasing sign request", "id", id, "error", rerr)
+			}
+		}
+		return nil, err
+	}
+	if id != 0 {
+		if err := s.queue.Signed(ctx, id); err != nil {
+			// the signature is valid, the request is signed again if retried
+			log.Errorw("marking sign request signed", "id", id, "error", err)
+		}
+	}
+	return sig, nil
+}
+
+// addedPieces returns the CIDs of the added pieces and their total padded
+// size. The size is 0 if a piece CID does not encode its size.
+func addedPieces(pieceData [][]byte) ([]string, uint64) {
+	pieces := make([]string, len(pieceData))
+	var size uint64
+	known := true
+	for i, data := range pieceData {
+		c, err := cid.Cast(data)
+		if err != nil {
+			pieces[i] = "0x" + common.Bytes2Hex(data)
+			known = false
+			continue
+		}
+		pieces[i] = c.String()
+		pl, err := piece.FromLink(cidlink.Link{Cid: c})
+		if err != nil {
+			known = false
+			continue
+		}
+		size += pl.PaddedSize()
+	}
+	if !known {
+		return pieces, 0
+	}
+	return pieces, size
+}
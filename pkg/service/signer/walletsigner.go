@@ -0,0 +1,153 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/storacha/filecoin-services/go/eip712"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/ucan"
+	signertypes "github.com/storacha/piri-signing-service/pkg/types"
+
+	"github.com/storacha/piri/pkg/wallet"
+)
+
+// WalletSigner signs PDP operations in-process with a key of the wallet, so
+// the EIP-712 signing key is held wherever the key store keeps it, e.g. in
+// KMS. Like the in-process signer of the signing service, it performs no
+// authorization checks.
+type WalletSigner struct {
+	wallet            wallet.HashSigner
+	address           common.Address
+	chainID           *big.Int
+	verifyingContract common.Address
+}
+
+var _ signertypes.SigningService = (*WalletSigner)(nil)
+
+// NewWalletSigner creates a signing service signing with the wallet key of
+// address for the service contract verifyingContract.
+func NewWalletSigner(w wallet.HashSigner, address common.Address, chainID *big.Int, verifyingContract common.Address) *WalletSigner {
+	return &WalletSigner{
+		wallet:            w,
+		address:           address,
+		chainID:           chainID,
+		verifyingContract: verifyingContract,
+	}
+}
+
+func (s *WalletSigner) SignCreateDataSet(
+	ctx context.Context,
+	issuer ucan.Signer,
+	dataSet *big.Int,
+	payee common.Address,
+	metadata []eip712.MetadataEntry,
+	options ...delegation.Option,
+) (*eip712.AuthSignature, error) {
+	return s.signTypedData(ctx, "CreateDataSet", map[string]any{
+		"clientDataSetId": dataSet,
+		"payee":           strings.ToLower(payee.Hex()),
+		"metadata":        metadataMessage(metadata),
+	})
+}
+
+func (s *WalletSigner) SignAddPieces(
+	ctx context.Context,
+	issuer ucan.Signer,
+	dataSet *big.Int,
+	nonce *big.Int,
+	pieceData [][]byte,
+	metadata [][]eip712.MetadataEntry,
+	proofs [][]ipld.Link,
+	proofData [][]message.AgentMessage,
+	options ...delegation.Option,
+) (*eip712.AuthSignature, error) {
+	cids := make([]map[string]any, len(pieceData))
+	for i, data := range pieceData {
+		cids[i] = map[string]any{"data": data}
+	}
+	pieceMetadata := make([]map[string]any, len(metadata))
+	for i, meta := range metadata {
+		pieceMetadata[i] = map[string]any{
+			"pieceIndex": big.NewInt(int64(i)),
+			"metadata":   metadataMessage(meta),
+		}
+	}
+	return s.signTypedData(ctx, "AddPieces", map[string]any{
+		"clientDataSetId": dataSet,
+		"nonce":           nonce,
+		"pieceData":       cids,
+		"pieceMetadata":   pieceMetadata,
+	})
+}
+
+func (s *WalletSigner) SignSchedulePieceRemovals(
+	ctx context.Context,
+	issuer ucan.Signer,
+	dataSet *big.Int,
+	pieceIds []*big.Int,
+	options ...delegation.Option,
+) (*eip712.AuthSignature, error) {
+	return s.signTypedData(ctx, "SchedulePieceRemovals", map[string]any{
+		"clientDataSetId": dataSet,
+		"pieceIds":        pieceIds,
+	})
+}
+
+func (s *WalletSigner) SignDeleteDataSet(
+	ctx context.Context,
+	issuer ucan.Signer,
+	dataSet *big.Int,
+	options ...delegation.Option,
+) (*eip712.AuthSignature, error) {
+	return s.signTypedData(ctx, "DeleteDataSet", map[string]any{
+		"clientDataSetId": dataSet,
+	})
+}
+
+func (s *WalletSigner) signTypedData(ctx context.Context, primaryType string, msg map[string]any) (*eip712.AuthSignature, error) {
+	domain := eip712.GetDomain(s.chainID, s.verifyingContract)
+	hash, err := eip712.GetMessageHash(domain, primaryType, msg)
+	if err != nil {
+		return nil, fmt.Errorf("hashing %s typed data: %w", primaryType, err)
+	}
+	sig, err := s.wallet.SignHash(ctx, s.address, hash)
+	if err != nil {
+		return nil, fmt.Errorf("signing %s: %w", primaryType, err)
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return nil, fmt.Errorf("recovering %s signer: %w", primaryType, err)
+	}
+	if recovered := crypto.PubkeyToAddress(*pub); recovered != s.address {
+		return nil, fmt.Errorf("%s signature recovers to %s, expected %s", primaryType, recovered, s.address)
+	}
+
+	// contracts expect V as 27 or 28
+	v := sig[64] + 27
+	full := make([]byte, 65)
+	copy(full, sig[:64])
+	full[64] = v
+	return &eip712.AuthSignature{
+		Signature:  full,
+		V:          v,
+		R:          common.BytesToHash(sig[:32]),
+		S:          common.BytesToHash(sig[32:64]),
+		SignedData: hash,
+		Signer:     s.address,
+	}, nil
+}
+
+func metadataMessage(metadata []eip712.MetadataEntry) []map[string]any {
+	out := make([]map[string]any, len(metadata))
+	for i, entry := range metadata {
+		out[i] = map[string]any{"key": entry.Key, "value": entry.Value}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package signer_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/storacha/filecoin-services/go/eip712"
+	"github.com/storacha/go-libstoracha/testutil"
+	signingservice "github.com/storacha/piri-signing-service/pkg/signer"
+	"github.com/stretchr/testify/require"
+
+	signerclient "github.com/storacha/piri/pkg/service/signer"
+)
+
+type keyHashSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (k keyHashSigner) SignHash(_ context.Context, addr common.Address, hash []byte) ([]byte, error) {
+	if addr != crypto.PubkeyToAddress(k.key.PublicKey) {
+		return nil, fmt.Errorf("key not found for address (%s)", addr)
+	}
+	return crypto.Sign(hash, k.key)
+}
+
+func TestWalletSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(314159)
+	contract := common.HexToAddress("0x0000000000000000000000000000000000000abc")
+
+	s := signerclient.NewWalletSigner(keyHashSigner{key}, addr, chainID, contract)
+	// signatures are deterministic, so they must match the signing service's
+	expected := signingservice.NewSigner(key, chainID, contract)
+	id := testutil.Alice
+	dataSet := big.NewInt(7)
+	metadata := []eip712.MetadataEntry{{Key: "withIPFSIndexing", Value: ""}}
+
+	t.Run("create data set", func(t *testing.T) {
+		payee := common.HexToAddress("0x0000000000000000000000000000000000000def")
+		sig, err := s.SignCreateDataSet(t.Context(), id, dataSet, payee, metadata)
+		require.NoError(t, err)
+		want, err := expected.SignCreateDataSet(dataSet, payee, metadata)
+		require.NoError(t, err)
+		require.Equal(t, want, sig)
+	})
+
+	t.Run("add pieces", func(t *testing.T) {
+		pieces := [][]byte{{1, 2, 3}}
+		pieceMetadata := [][]eip712.MetadataEntry{metadata}
+		sig, err := s.SignAddPieces(t.Context(), id, dataSet, big.NewInt(1), pieces, pieceMetadata, nil, nil)
+		require.NoError(t, err)
+		want, err := expected.SignAddPieces(dataSet, big.NewInt(1), pieces, pieceMetadata)
+		require.NoError(t, err)
+		require.Equal(t, want, sig)
+	})
+
+	t.Run("schedule piece removals", func(t *testing.T) {
+		ids := []*big.Int{big.NewInt(1), big.NewInt(2)}
+		sig, err := s.SignSchedulePieceRemovals(t.Context(), id, dataSet, ids)
+		require.NoError(t, err)
+		want, err := expected.SignSchedulePieceRemovals(dataSet, ids)
+		require.NoError(t, err)
+		require.Equal(t, want, sig)
+	})
+
+	t.Run("delete data set", func(t *testing.T) {
+		sig, err := s.SignDeleteDataSet(t.Context(), id, dataSet)
+		require.NoError(t, err)
+		want, err := expected.SignDeleteDataSet(dataSet)
+		require.NoError(t, err)
+		require.Equal(t, want, sig)
+	})
+
+	t.Run("unknown address", func(t *testing.T) {
+		other := signerclient.NewWalletSigner(keyHashSigner{key}, common.HexToAddress("0x01"), chainID, contract)
+		_, err := other.SignDeleteDataSet(t.Context(), id, dataSet)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/message"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/receipt/ran"
+	"github.com/storacha/go-ucanto/core/result"
+	ufailure "github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/storacha/go-ucanto/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/storacha/piri/lib/telemetry/traces"
+)
+
+var tracer = otel.Tracer("github.com/storacha/piri/pkg/service/storage")
+
+const (
+	// DefaultMaxBatchInvocations is the default maximum number of invocations
+	// accepted in a single agent message.
+	DefaultMaxBatchInvocations = 1000
+	// DefaultMaxBatchBodySize is the default maximum size in bytes of the body
+	// of a request carrying an agent message.
+	DefaultMaxBatchBodySize int64 = 32 << 20
+)
+
+// DefaultMaxBatchConcurrency is the default number of invocations from a
+// single agent message that are executed in parallel.
+var DefaultMaxBatchConcurrency = runtime.NumCPU()
+
+type handlerConfig struct {
+	maxInvocations int
+	maxConcurrency int
+	maxBodySize    int64
+}
+
+// HandlerOption configures the UCAN HTTP handler.
+type HandlerOption func(*handlerConfig)
+
+// WithMaxBatchInvocations sets the maximum number of invocations accepted in a
+// single agent message. Larger messages are rejected.
+func WithMaxBatchInvocations(n int) HandlerOption {
+	return func(c *handlerConfig) {
+		if n > 0 {
+			c.maxInvocations = n
+		}
+	}
+}
+
+// WithMaxBatchConcurrency sets the number of invocations from a single agent
+// message that are executed in parallel.
+func WithMaxBatchConcurrency(n int) HandlerOption {
+	return func(c *handlerConfig) {
+		if n > 0 {
+			c.maxConcurrency = n
+		}
+	}
+}
+
+// WithMaxBatchBodySize sets the maximum size in bytes of the body of a
+// request carrying an agent message. Larger requests are rejected before the
+// message is decoded.
+func WithMaxBatchBodySize(n int64) HandlerOption {
+	return func(c *handlerConfig) {
+		if n > 0 {
+			c.maxBodySize = n
+		}
+	}
+}
+
+// ErrTooManyInvocations is returned when an agent message contains more
+// invocations than allowed.
+type ErrTooManyInvocations struct {
+	Count int
+	Max   int
+}
+
+func (e ErrTooManyInvocations) Error() string {
+	return fmt.Sprintf("agent message contains %d invocations, maximum is %d", e.Count, e.Max)
+}
+
+// executeBatch executes all invocations in an agent message using a bounded
+// pool of workers and returns an agent message with a receipt per invocation.
+//
+// Failures are isolated to the invocation they occurred in: an invocation
+// that cannot be executed results in an error receipt for that invocation
+// only, the remaining invocations in the message are still executed. If an
+// error receipt cannot be issued, the whole message fails rather than leave
+// the invocation without a receipt.
+func executeBatch(ctx context.Context, srv server.ServerView[server.Service], msg message.AgentMessage, cfg handlerConfig) (message.AgentMessage, error) {
+	links := msg.Invocations()
+	if len(links) > cfg.maxInvocations {
+		return nil, ErrTooManyInvocations{Count: len(links), Max: cfg.maxInvocations}
+	}
+
+	br, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(msg.Blocks()))
+	if err != nil {
+		return nil, fmt.Errorf("reading agent message blocks: %w", err)
+	}
+
+	invs := make([]invocation.Invocation, 0, len(links))
+	for _, l := range links {
+		inv, err := invocation.NewInvocationView(l, br)
+		if err != nil {
+			return nil, fmt.Errorf("reading invocation %s: %w", l, err)
+		}
+		invs = append(invs, inv)
+	}
+
+	rcpts := make([]receipt.AnyReceipt, len(invs))
+	sem := make(chan struct{}, cfg.maxConcurrency)
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		issueErr error
+	)
+	for i, inv := range invs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			ctx, span := startInvocationSpan(ctx, inv)
+			defer span.End()
+			log := traces.Logger(ctx, log)
+
+			rcpt, err := srv.Run(ctx, inv)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				log.Errorw("executing invocation", "invocation", inv.Link(), "error", err)
+				rcpt, err = issueFailure(srv, inv, err)
+				if err != nil {
+					log.Errorw("issuing failure receipt", "invocation", inv.Link(), "error", err)
+					errMu.Lock()
+					if issueErr == nil {
+						issueErr = fmt.Errorf("issuing failure receipt for invocation %s: %w", inv.Link(), err)
+					}
+					errMu.Unlock()
+					return
+				}
+			}
+			rcpts[i] = rcpt
+		}()
+	}
+	wg.Wait()
+
+	if issueErr != nil {
+		return nil, issueErr
+	}
+	return message.Build(nil, rcpts)
+}
+
+// startInvocationSpan starts a span for the execution of an invocation, so
+// the spans and logs of the handler are grouped by invocation within the
+// trace of the request.
+func startInvocationSpan(ctx context.Context, inv invocation.Invocation) (context.Context, trace.Span) {
+	name := "ucan.invoke"
+	attrs := []attribute.KeyValue{attribute.String("ucan.invocation", inv.Link().String())}
+	if caps := inv.Capabilities(); len(caps) > 0 {
+		name = caps[0].Can()
+		attrs = append(attrs, attribute.String("ucan.ability", caps[0].Can()), attribute.String("ucan.resource", caps[0].With()))
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func issueFailure(srv server.ServerView[server.Service], inv invocation.Invocation, cause error) (receipt.AnyReceipt, error) {
+	out := result.Error[ipld.Builder, ipld.Builder](ufailure.FromError(cause))
+	return receipt.Issue(srv.ID(), out, ran.FromInvocation(inv))
+}
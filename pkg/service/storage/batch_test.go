@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/storacha/go-libstoracha/capabilities/access"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	ufailure "github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/storacha/go-ucanto/core/result/ok"
+	"github.com/storacha/go-ucanto/server"
+	ucan_http "github.com/storacha/go-ucanto/transport/http"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchHandler(t *testing.T) {
+	var inflight, maxInflight atomic.Int32
+	srv := testutil.Must(server.NewServer(
+		testutil.Service,
+		server.WithServiceMethod(
+			access.GrantAbility,
+			server.Provide(
+				access.Grant,
+				func(ctx context.Context, cap ucan.Capability[access.GrantCaveats], inv invocation.Invocation, iCtx server.InvocationContext) (result.Result[ok.Unit, ufailure.IPLDBuilderFailure], fx.Effects, error) {
+					n := inflight.Add(1)
+					defer inflight.Add(-1)
+					for {
+						m := maxInflight.Load()
+						if n <= m || maxInflight.CompareAndSwap(m, n) {
+							break
+						}
+					}
+					time.Sleep(10 * time.Millisecond)
+
+					if strings.HasPrefix(cap.Nb().Att[0].Can, "test/fail") {
+						return result.Error[ok.Unit](ufailure.FromError(fmt.Errorf("boom"))), nil, nil
+					}
+					return result.Ok[ok.Unit, ufailure.IPLDBuilderFailure](ok.Unit{}), nil, nil
+				},
+			),
+		),
+	))(t)
+
+	newConn := func(t *testing.T, options ...HandlerOption) client.Connection {
+		e := echo.New()
+		e.POST("/", NewHandler(srv, options...).ToEcho())
+		ts := httptest.NewServer(e)
+		t.Cleanup(ts.Close)
+		u := testutil.Must(url.Parse(ts.URL))(t)
+		return testutil.Must(client.NewConnection(testutil.Service, ucan_http.NewChannel(u)))(t)
+	}
+
+	newInvocations := func(t *testing.T, n int) []invocation.Invocation {
+		invs := make([]invocation.Invocation, 0, n)
+		for i := range n {
+			ability := fmt.Sprintf("test/ok/%d", i)
+			if i%3 == 0 {
+				ability = fmt.Sprintf("test/fail/%d", i)
+			}
+			nb := access.GrantCaveats{Att: []access.CapabilityRequest{{Can: ability}}}
+			inv, err := access.Grant.Invoke(testutil.Alice, testutil.Service, testutil.Alice.DID().String(), nb)
+			require.NoError(t, err)
+			invs = append(invs, inv)
+		}
+		return invs
+	}
+
+	t.Run("returns a receipt per invocation", func(t *testing.T) {
+		maxInflight.Store(0)
+		conn := newConn(t, WithMaxBatchConcurrency(4))
+		invs := newInvocations(t, 30)
+
+		res, err := client.Execute(t.Context(), invs, conn)
+		require.NoError(t, err)
+
+		for i, inv := range invs {
+			rcptLink, ok := res.Get(inv.Link())
+			require.True(t, ok, "missing receipt for invocation: %s", inv.Link())
+
+			rcpt, err := receipt.NewAnyReceiptReader().Read(rcptLink, res.Blocks())
+			require.NoError(t, err)
+			_, x := result.Unwrap(rcpt.Out())
+			if i%3 == 0 {
+				require.NotNil(t, x, "expected invocation %d to fail", i)
+			} else {
+				require.Nil(t, x, "expected invocation %d to succeed", i)
+			}
+		}
+		require.LessOrEqual(t, maxInflight.Load(), int32(4))
+		require.Greater(t, maxInflight.Load(), int32(1))
+	})
+
+	t.Run("rejects too many invocations", func(t *testing.T) {
+		conn := newConn(t, WithMaxBatchInvocations(5))
+
+		_, err := client.Execute(t.Context(), newInvocations(t, 6), conn)
+		require.Error(t, err)
+	})
+
+	t.Run("rejects bodies over the size limit", func(t *testing.T) {
+		e := echo.New()
+		e.POST("/", NewHandler(srv, WithMaxBatchBodySize(16)).ToEcho())
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 17)))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+}
@@ -20,11 +20,15 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 
+	"github.com/storacha/piri/pkg/hwsigner"
 	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/piecelog"
 	"github.com/storacha/piri/pkg/service/blobs"
 	"github.com/storacha/piri/pkg/service/claims"
+	"github.com/storacha/piri/pkg/storageclass"
 	"github.com/storacha/piri/pkg/store"
 	"github.com/storacha/piri/pkg/store/acceptancestore/acceptance"
+	"github.com/storacha/piri/pkg/telemetry/latency"
 )
 
 type AcceptService interface {
@@ -40,6 +44,9 @@ type AcceptRequest struct {
 	Put   blob.Promise
 	// Cause is a link to the `blob/accept` or `blob/replica/transfer` invocation.
 	Cause ipld.Link
+	// Class is the storage class recorded in the location claim, if classes
+	// are tracked.
+	Class *storageclass.Class
 }
 
 type AcceptResponse struct {
@@ -50,7 +57,9 @@ type AcceptResponse struct {
 
 func Accept(ctx context.Context, s AcceptService, req *AcceptRequest) (resp *AcceptResponse, err error) {
 	ctx, span := tracer.Start(ctx, "blob.accept")
+	ctx, timer := s.Blobs().Latency().Start(ctx, req.Blob.Digest, latency.StageAccept)
 	defer func() {
+		timer.End(err)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, err.Error())
@@ -105,7 +114,10 @@ func Accept(ctx context.Context, s AcceptService, req *AcceptRequest) (resp *Acc
 			return nil, fmt.Errorf("creating retrieval URL for blob: %w", err)
 		}
 		// submit the piece for aggregation
-		if err := s.PDP().CommpCalculate().Enqueue(ctx, req.Blob.Digest); err != nil {
+		enqueueCtx, enqueue := s.Blobs().Latency().Start(ctx, req.Blob.Digest, latency.StageAggregateEnqueue)
+		err = s.PDP().CommpCalculate().Enqueue(enqueueCtx, req.Blob.Digest)
+		enqueue.End(err)
+		if err != nil {
 			log.Errorw("submitting piece for aggregation", "error", err)
 			return nil, fmt.Errorf("submitting piece for aggregation: %w", err)
 		}
@@ -123,6 +135,21 @@ func Accept(ctx context.Context, s AcceptService, req *AcceptRequest) (resp *Acc
 		}
 		pdpAcceptInv = pieceAccept
 	}
+	if err := s.Blobs().PieceLog().Append(ctx, req.Blob.Digest, piecelog.Event{
+		Kind:  piecelog.Uploaded,
+		Space: req.Space.String(),
+	}); err != nil {
+		log.Warnw("recording upload in piece log", "error", err)
+	}
+
+	opts := []delegation.Option{s.Claims().Expiration()}
+	facts := hwsigner.ClaimFacts(s.ID())
+	if req.Class != nil {
+		facts = append(facts, storageclass.Fact{Class: *req.Class})
+	}
+	if len(facts) > 0 {
+		opts = append(opts, delegation.WithFacts(facts))
+	}
 
 	byteRange := assert.Range{Offset: 0, Length: &req.Blob.Size}
 	claim, err := assert.Location.Delegate(
@@ -132,10 +159,10 @@ func Accept(ctx context.Context, s AcceptService, req *AcceptRequest) (resp *Acc
 		assert.LocationCaveats{
 			Space:    req.Space,
 			Content:  types.FromHash(req.Blob.Digest),
-			Location: []url.URL{loc},
+			Location: s.Claims().Locations(loc),
 			Range:    &byteRange,
 		},
-		delegation.WithNoExpiration(),
+		opts...,
 	)
 	if err != nil {
 		log.Errorw("creating location commitment", "error", err)
@@ -164,6 +191,10 @@ func Accept(ctx context.Context, s AcceptService, req *AcceptRequest) (resp *Acc
 		log.Errorw("putting acceptance for blob", "error", err)
 		return nil, fmt.Errorf("putting acceptance for blob: %w", err)
 	}
+	// later allocations of the blob, in any space, skip the upload
+	if err := s.Blobs().ContentIndex().Add(ctx, req.Blob.Digest, req.Blob.Size); err != nil {
+		log.Warnw("adding blob to content index", "error", err)
+	}
 
 	err = s.Claims().Store().Put(ctx, claim)
 	if err != nil {
@@ -171,7 +202,9 @@ func Accept(ctx context.Context, s AcceptService, req *AcceptRequest) (resp *Acc
 		return nil, fmt.Errorf("putting location claim for blob: %w", err)
 	}
 
-	err = s.Claims().Publisher().Publish(ctx, claim)
+	publishCtx, publish := s.Blobs().Latency().Start(ctx, req.Blob.Digest, latency.StageClaimPublish)
+	err = s.Claims().Publisher().Publish(publishCtx, claim)
+	publish.End(err)
 	if err != nil {
 		log.Errorw("publishing location commitment", "error", err)
 		return nil, fmt.Errorf("publishing location commitment: %w", err)
@@ -182,3 +215,22 @@ func Accept(ctx context.Context, s AcceptService, req *AcceptRequest) (resp *Acc
 		PDP:   pdpAcceptInv,
 	}, nil
 }
+
+// AcceptedClass returns the storage class the blob was allocated in. Blobs
+// allocated before classes were tracked are in the class of their space.
+func AcceptedClass(ctx context.Context, classes *storageclass.Manager, req *AcceptRequest) (storageclass.Class, error) {
+	name, ok, err := classes.ClassOf(ctx, req.Blob.Digest)
+	if err != nil {
+		return storageclass.Class{}, fmt.Errorf("getting storage class of blob: %w", err)
+	}
+	if ok {
+		if class, ok := classes.Class(name); ok {
+			return class, nil
+		}
+	}
+	class, err := classes.Resolve(ctx, req.Space, "")
+	if err != nil {
+		return storageclass.Class{}, fmt.Errorf("resolving storage class: %w", err)
+	}
+	return class, nil
+}
@@ -23,9 +23,11 @@ import (
 	"github.com/storacha/go-libstoracha/digestutil"
 
 	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/piecelog"
 	"github.com/storacha/piri/pkg/service/blobs"
 	"github.com/storacha/piri/pkg/store"
 	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+	"github.com/storacha/piri/pkg/telemetry/latency"
 )
 
 var log = logging.Logger("storage/handlers/blob")
@@ -48,7 +50,9 @@ type AllocateResponse struct {
 
 func Allocate(ctx context.Context, s AllocateService, req *AllocateRequest) (resp *AllocateResponse, err error) {
 	ctx, span := tracer.Start(ctx, "blob.allocate")
+	ctx, timer := s.Blobs().Latency().Start(ctx, req.Blob.Digest, latency.StageAllocate)
 	defer func() {
+		timer.End(err)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, err.Error())
@@ -72,34 +76,23 @@ func Allocate(ctx context.Context, s AllocateService, req *AllocateRequest) (res
 		return nil, fmt.Errorf("getting allocation: %w", err)
 	}
 
-	// check if any allocation exists for the blob (skip if we already found one above)
-	anyAllocation := allocated
-	if !allocated {
-		anyAllocation, err = s.Blobs().Allocations().Exists(ctx, req.Blob.Digest)
-		if err != nil {
-			log.Errorw("checking allocation exists", "error", err)
-			return nil, fmt.Errorf("checking allocation exists: %w", err)
-		}
+	// the content index records the blobs held by the node, whichever space
+	// they were uploaded to, so content already uploaded to another space is
+	// not uploaded again
+	entry, indexed, err := s.Blobs().ContentIndex().Get(ctx, req.Blob.Digest)
+	if err != nil {
+		log.Errorw("getting content index entry", "error", err)
+		return nil, fmt.Errorf("getting content index entry: %w", err)
 	}
+	received := indexed && entry.Size == req.Blob.Size
+	span.SetAttributes(attribute.Bool("blob.indexed", received))
 
-	received := false
-	// check if we received the blob (only possible if we have an allocation)
-	if anyAllocation {
-		if s.PDP() != nil {
-			has, err := s.PDP().API().Has(ctx, req.Blob.Digest)
-			if err != nil {
-				return nil, fmt.Errorf("getting blob: %w", err)
-			}
-			received = has
-		} else {
-			_, err = s.Blobs().Store().Get(ctx, req.Blob.Digest)
-			if err != nil && !errors.Is(err, store.ErrNotFound) {
-				log.Errorw("getting blob", "error", err)
-				return nil, fmt.Errorf("getting blob: %w", err)
-			}
-			if err == nil {
-				received = true
-			}
+	// blobs not yet accepted, or received before the content index was
+	// kept, are only found through their allocations
+	if !received {
+		received, err = receivedUnindexed(ctx, s, req.Blob, allocated)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -126,48 +119,17 @@ func Allocate(ctx context.Context, s AllocateService, req *AllocateRequest) (res
 	expiresAt := uint64(time.Now().Unix()) + expiresIn
 
 	var address *blob.Address
-	// if not received yet, we need to generate a signed URL for the
-	// upload, and include it in the receipt.
-	if !received {
-		var uploadURL url.URL
-		headers := http.Header{}
-		if s.PDP() == nil {
-			// use standard blob upload
-			uploadURL, headers, err = s.Blobs().Presigner().SignUploadURL(ctx, req.Blob.Digest, req.Blob.Size, expiresIn)
-			if err != nil {
-				log.Errorw("signing upload URL", "error", err)
-				return nil, fmt.Errorf("signing upload URL: %w", err)
-			}
-		} else {
-			dmh, err := multihash.Decode(req.Blob.Digest)
-			if err != nil {
-				log.Errorw("decoding digest", "error", err)
-				return nil, fmt.Errorf("decoding digest: %w", err)
-			}
-			if _, ok := presets.HasherRegistry[dmh.Name]; !ok {
-				return nil, fmt.Errorf("unsupported hash: %s", dmh.Name)
-			}
-			// use pdp service upload
-			// TODO we need to provide backpressure to the upload service here
-			// based on the number of roots we are currently allocating.
-			resp, err := s.PDP().API().AllocatePiece(ctx, types.PieceAllocation{
-				Piece: types.Piece{
-					Name: dmh.Name,
-					Hash: req.Blob.Digest,
-					Size: int64(req.Blob.Size),
-				},
-			})
-			if err != nil {
-				log.Errorw("adding to pdp service", "error", err)
-				return nil, fmt.Errorf("adding to pdp service: %w", err)
-			}
-			if resp.Allocated {
-				uploadURL, err = s.PDP().API().WritePieceURL(resp.UploadID)
-				if err != nil {
-					log.Errorw("getting piece write URL", "error", err)
-					return nil, fmt.Errorf("getting piece write URL: %w", err)
-				}
-			}
+	if received {
+		log.Info("blob already held by the node, skipping upload")
+	} else {
+		// not received yet, we need to generate a signed URL for the
+		// upload, and include it in the receipt.
+		presignCtx, presign := s.Blobs().Latency().Start(ctx, req.Blob.Digest, latency.StagePresign)
+		uploadURL, headers, err := presignUpload(presignCtx, s, req, expiresIn)
+		presign.End(err)
+		if err != nil {
+			log.Errorw("presigning upload", "error", err)
+			return nil, err
 		}
 		address = &blob.Address{
 			URL:     uploadURL,
@@ -188,9 +150,100 @@ func Allocate(ctx context.Context, s AllocateService, req *AllocateRequest) (res
 		log.Errorw("putting allocation", "error", err)
 		return nil, fmt.Errorf("putting allocation: %w", err)
 	}
+	if err := s.Blobs().PieceLog().Append(ctx, req.Blob.Digest, piecelog.Event{
+		Kind:  piecelog.Allocated,
+		Space: req.Space.String(),
+	}); err != nil {
+		log.Warnw("recording allocation in piece log", "error", err)
+	}
 
 	return &AllocateResponse{
 		Size:    size,
 		Address: address,
 	}, nil
 }
+
+// receivedUnindexed reports whether a blob missing from the content index was
+// received, which is only possible if it has an allocation. Received blobs
+// are added to the index.
+func receivedUnindexed(ctx context.Context, s AllocateService, b captypes.Blob, allocated bool) (bool, error) {
+	// check if any allocation exists for the blob (skip if we already found
+	// one in the space)
+	anyAllocation := allocated
+	if !allocated {
+		var err error
+		anyAllocation, err = s.Blobs().Allocations().Exists(ctx, b.Digest)
+		if err != nil {
+			log.Errorw("checking allocation exists", "error", err)
+			return false, fmt.Errorf("checking allocation exists: %w", err)
+		}
+	}
+	if !anyAllocation {
+		return false, nil
+	}
+
+	received := false
+	if s.PDP() != nil {
+		has, err := s.PDP().API().Has(ctx, b.Digest)
+		if err != nil {
+			return false, fmt.Errorf("getting blob: %w", err)
+		}
+		received = has
+	} else {
+		_, err := s.Blobs().Store().Get(ctx, b.Digest)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			log.Errorw("getting blob", "error", err)
+			return false, fmt.Errorf("getting blob: %w", err)
+		}
+		received = err == nil
+	}
+	if received {
+		if err := s.Blobs().ContentIndex().Add(ctx, b.Digest, b.Size); err != nil {
+			log.Warnw("adding blob to content index", "error", err)
+		}
+	}
+	return received, nil
+}
+
+// presignUpload returns the URL and headers the blob must be uploaded with.
+// The URL is empty if PDP already holds the piece.
+func presignUpload(ctx context.Context, s AllocateService, req *AllocateRequest, expiresIn uint64) (url.URL, http.Header, error) {
+	if s.PDP() == nil {
+		// use standard blob upload
+		uploadURL, headers, err := s.Blobs().Presigner().SignUploadURL(ctx, req.Blob.Digest, req.Blob.Size, expiresIn)
+		if err != nil {
+			return url.URL{}, nil, fmt.Errorf("signing upload URL: %w", err)
+		}
+		return uploadURL, headers, nil
+	}
+
+	dmh, err := multihash.Decode(req.Blob.Digest)
+	if err != nil {
+		return url.URL{}, nil, fmt.Errorf("decoding digest: %w", err)
+	}
+	if _, ok := presets.HasherRegistry[dmh.Name]; !ok {
+		return url.URL{}, nil, fmt.Errorf("unsupported hash: %s", dmh.Name)
+	}
+	// use pdp service upload
+	// TODO we need to provide backpressure to the upload service here
+	// based on the number of roots we are currently allocating.
+	resp, err := s.PDP().API().AllocatePiece(ctx, types.PieceAllocation{
+		Piece: types.Piece{
+			Name: dmh.Name,
+			Hash: req.Blob.Digest,
+			Size: int64(req.Blob.Size),
+		},
+	})
+	if err != nil {
+		return url.URL{}, nil, fmt.Errorf("adding to pdp service: %w", err)
+	}
+	headers := http.Header{}
+	if !resp.Allocated {
+		return url.URL{}, headers, nil
+	}
+	uploadURL, err := s.PDP().API().WritePieceURL(resp.UploadID)
+	if err != nil {
+		return url.URL{}, nil, fmt.Errorf("getting piece write URL: %w", err)
+	}
+	return uploadURL, headers, nil
+}
@@ -0,0 +1,162 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/storacha/piri/pkg/piecelog"
+	"github.com/storacha/piri/pkg/service/blobs"
+	"github.com/storacha/piri/pkg/service/claims"
+	"github.com/storacha/piri/pkg/service/collector"
+	"github.com/storacha/piri/pkg/service/quota"
+	"github.com/storacha/piri/pkg/service/usage"
+	"github.com/storacha/piri/pkg/store"
+)
+
+// ErrNotRemovable is returned when removing a blob that is accepted but not
+// yet added to a proof set. Removal should be retried later.
+var ErrNotRemovable = errors.New("blob has not been added to a proof set yet")
+
+type RemoveService interface {
+	Blobs() blobs.Blobs
+	Claims() claims.Claims
+	// Quotas is nil if quotas are not enforced.
+	Quotas() quota.Enforcer
+	// Collector is nil if unreferenced blobs are not collected.
+	Collector() *collector.Service
+	// Usage is nil if space usage is not tracked.
+	Usage() *usage.Tracker
+}
+
+type RemoveRequest struct {
+	Space  did.DID
+	Digest multihash.Multihash
+	// Cause is the invocation removing the blob.
+	Cause ucan.Link
+}
+
+type RemoveResponse struct {
+	// Size is the size of the blob removed from the space, 0 if the blob was
+	// not stored in the space.
+	Size uint64
+}
+
+// Remove removes a blob from a space: its advertisement is retracted, its
+// allocation and acceptance in the space are deleted and its size released
+// from the space's quota and usage. A blob no other space references is
+// recorded as removed in the piece log and marked for collection.
+func Remove(ctx context.Context, s RemoveService, req *RemoveRequest) (resp *RemoveResponse, err error) {
+	ctx, span := tracer.Start(ctx, "blob.remove")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	log := log.With("blob", digestutil.Format(req.Digest), "space", req.Space)
+	span.SetAttributes(
+		attribute.Stringer("space.did", req.Space),
+		attribute.Stringer("blob.digest", req.Digest),
+	)
+
+	var size uint64
+	allocated := true
+	alloc, err := s.Blobs().Allocations().Get(ctx, req.Digest, req.Space)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("getting allocation: %w", err)
+		}
+		allocated = false
+	} else {
+		size = alloc.Blob.Size
+	}
+	accepted := true
+	acc, err := s.Blobs().Acceptances().Get(ctx, req.Digest, req.Space)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("getting acceptance: %w", err)
+		}
+		accepted = false
+	} else {
+		size = acc.Blob.Size
+	}
+	if !allocated && !accepted {
+		log.Info("blob not stored in space, nothing to remove")
+		return &RemoveResponse{}, nil
+	}
+
+	if accepted {
+		if c := s.Collector(); c != nil {
+			removable, err := c.Removable(ctx, req.Digest)
+			if err != nil {
+				return nil, err
+			}
+			if !removable {
+				return nil, ErrNotRemovable
+			}
+		}
+		// the location commitment was advertised on accept
+		if err := s.Claims().Publisher().Retract(ctx, req.Space, req.Digest); err != nil {
+			return nil, fmt.Errorf("retracting advertisement: %w", err)
+		}
+		if err := s.Blobs().Acceptances().Delete(ctx, req.Digest, req.Space); err != nil {
+			return nil, fmt.Errorf("deleting acceptance: %w", err)
+		}
+	}
+	if allocated {
+		if err := s.Blobs().Allocations().Delete(ctx, req.Digest, req.Space); err != nil {
+			return nil, fmt.Errorf("deleting allocation: %w", err)
+		}
+	}
+	log.Infow("removed blob from space", "size", size)
+
+	if quotas := s.Quotas(); quotas != nil {
+		if err := quotas.ReleaseStorage(ctx, req.Space, size); err != nil {
+			log.Errorw("releasing storage quota", "error", err)
+		}
+	}
+	if u := s.Usage(); u != nil {
+		if err := u.RecordRemoval(ctx, req.Space, size, req.Cause); err != nil {
+			log.Errorw("recording space usage", "error", err)
+		}
+	}
+
+	referenced, err := referenced(ctx, s.Blobs(), req.Digest)
+	if err != nil {
+		log.Errorw("checking references to blob", "error", err)
+	} else if !referenced {
+		if err := s.Blobs().PieceLog().Append(ctx, req.Digest, piecelog.Event{
+			Kind:  piecelog.Removed,
+			Space: req.Space.String(),
+		}); err != nil {
+			log.Warnw("recording removal in piece log", "error", err)
+		}
+		if c := s.Collector(); c != nil {
+			if err := c.Mark(ctx, req.Digest); err != nil {
+				log.Errorw("marking blob for collection", "error", err)
+			}
+		}
+	}
+
+	return &RemoveResponse{Size: size}, nil
+}
+
+// referenced reports whether any space has an allocation or acceptance for
+// the blob.
+func referenced(ctx context.Context, b blobs.Blobs, digest multihash.Multihash) (bool, error) {
+	allocated, err := b.Allocations().Exists(ctx, digest)
+	if err != nil || allocated {
+		return allocated, err
+	}
+	return b.Acceptances().Exists(ctx, digest)
+}
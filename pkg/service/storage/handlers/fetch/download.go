@@ -0,0 +1,329 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/multiformats/go-multihash"
+
+	"github.com/storacha/piri/pkg/internal/ranged"
+)
+
+const (
+	// DefaultChunkSize is the size of the ranges a blob is fetched in.
+	DefaultChunkSize = 16 << 20
+	// DefaultConcurrency is the number of ranges fetched in parallel.
+	DefaultConcurrency = 4
+	// DefaultStallTimeout is how long a source may send no data before a
+	// request to it fails.
+	DefaultStallTimeout = time.Minute
+	// chunkAttempts is the number of times a range is requested before the
+	// fetch fails.
+	chunkAttempts = 3
+)
+
+var (
+	// ErrPrecondition is returned when the content of a source does not have
+	// the size or digest of the blob fetched. Retrying does not help.
+	ErrPrecondition = errors.New("source content does not match blob")
+	// ErrForbiddenAddress is returned when a source resolves to an address the
+	// node is not allowed to fetch from.
+	ErrForbiddenAddress = errors.New("source address not allowed")
+	// ErrSourceStalled is returned when a source sends no data for the stall
+	// timeout.
+	ErrSourceStalled = errors.New("source stalled")
+)
+
+// Downloader fetches blobs over HTTP. Sources serving byte ranges are fetched
+// in parallel ranges, others in a single request.
+type Downloader struct {
+	client       *http.Client
+	chunkSize    int64
+	concurrency  int
+	stallTimeout time.Duration
+}
+
+type DownloaderOption func(*Downloader)
+
+// WithChunkSize sets the size of the ranges a blob is fetched in.
+func WithChunkSize(size int64) DownloaderOption {
+	return func(d *Downloader) {
+		if size > 0 {
+			d.chunkSize = size
+		}
+	}
+}
+
+// WithConcurrency sets the number of ranges fetched in parallel. Memory use
+// is bounded by the chunk size times the concurrency.
+func WithConcurrency(n int) DownloaderOption {
+	return func(d *Downloader) {
+		if n > 0 {
+			d.concurrency = n
+		}
+	}
+}
+
+// WithStallTimeout sets how long a source may send no data before a request
+// to it fails.
+func WithStallTimeout(timeout time.Duration) DownloaderOption {
+	return func(d *Downloader) {
+		if timeout > 0 {
+			d.stallTimeout = timeout
+		}
+	}
+}
+
+// WithHTTPClient sets the client requests are made with, in place of one
+// refusing to connect to private addresses.
+func WithHTTPClient(client *http.Client) DownloaderOption {
+	return func(d *Downloader) {
+		d.client = client
+	}
+}
+
+func NewDownloader(opts ...DownloaderOption) *Downloader {
+	d := &Downloader{
+		chunkSize:    DefaultChunkSize,
+		concurrency:  DefaultConcurrency,
+		stallTimeout: DefaultStallTimeout,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.client == nil {
+		d.client = PublicClient()
+	}
+	return d
+}
+
+// PublicClient returns an HTTP client that refuses to connect to loopback,
+// private and link-local addresses, so invokers cannot make the node request
+// services on its own network.
+func PublicClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublic(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.ResponseHeaderTimeout = DefaultStallTimeout
+	// proxies would be dialed in place of the source
+	transport.Proxy = nil
+	return &http.Client{Transport: transport}
+}
+
+func isPublic(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified())
+}
+
+// Open returns a reader of the content at source, which must have the given
+// size and digest. The reader fails with [ErrPrecondition] before returning
+// its last bytes if the content does not match.
+func (d *Downloader) Open(ctx context.Context, source url.URL, size uint64, digest multihash.Multihash) (io.ReadCloser, error) {
+	if source.Scheme != "http" && source.Scheme != "https" {
+		return nil, fmt.Errorf("%w: unsupported URL scheme %q", ErrPrecondition, source.Scheme)
+	}
+	decoded, err := multihash.Decode(digest)
+	if err != nil {
+		return nil, fmt.Errorf("decoding digest: %w", err)
+	}
+	hasher, err := multihash.GetHasher(decoded.Code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unsupported hash function %s", ErrPrecondition, decoded.Name)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	body, err := d.open(ctx, source, int64(size))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &verifyingReader{
+		r:      ranged.VerifyingReader(body, int64(size), hasher, decoded.Digest),
+		body:   body,
+		cancel: cancel,
+	}, nil
+}
+
+func (d *Downloader) open(ctx context.Context, source url.URL, size int64) (io.ReadCloser, error) {
+	end := min(d.chunkSize, size) - 1
+	res, err := d.get(ctx, source, 0, end)
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		// the source does not serve ranges, read the whole body
+		if res.ContentLength >= 0 && res.ContentLength != size {
+			res.Body.Close()
+			return nil, fmt.Errorf("%w: source has %d bytes, expected %d", ErrPrecondition, res.ContentLength, size)
+		}
+		return d.watch(res.Body), nil
+	case http.StatusPartialContent:
+		total, err := ranged.ContentRangeTotal(res.Header.Get("Content-Range"))
+		if err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+		if total != size {
+			res.Body.Close()
+			return nil, fmt.Errorf("%w: source has %d bytes, expected %d", ErrPrecondition, total, size)
+		}
+		first, err := d.readChunk(res, end+1)
+		if err != nil {
+			return nil, err
+		}
+		fetch := func(ctx context.Context, _ int, start, end int64) ([]byte, error) {
+			return d.fetchChunk(ctx, source, start, end)
+		}
+		return ranged.NewReader(ctx, first, size, d.chunkSize, d.concurrency, fetch), nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		res.Body.Close()
+		return nil, fmt.Errorf("%w: source has fewer than %d bytes", ErrPrecondition, end+1)
+	default:
+		res.Body.Close()
+		return nil, fmt.Errorf("source returned unexpected status: %d", res.StatusCode)
+	}
+}
+
+// get requests the bytes from start to end inclusive, or the whole content
+// if it is empty.
+func (d *Downloader) get(ctx context.Context, source url.URL, start, end int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if end >= start {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	}
+	// transparent decompression would change the bytes hashed
+	req.Header.Set("Accept-Encoding", "identity")
+	res, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting %s: %w", source.Redacted(), err)
+	}
+	return res, nil
+}
+
+// readChunk reads a range response of n bytes, closing it.
+func (d *Downloader) readChunk(res *http.Response, n int64) ([]byte, error) {
+	body := d.watch(res.Body)
+	defer body.Close()
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(body, buf); err != nil {
+		return nil, fmt.Errorf("reading range: %w", err)
+	}
+	return buf, nil
+}
+
+// fetchChunk fetches the bytes from start to end inclusive, retrying
+// failed requests.
+func (d *Downloader) fetchChunk(ctx context.Context, source url.URL, start, end int64) ([]byte, error) {
+	var err error
+	for attempt := range chunkAttempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+		var res *http.Response
+		res, err = d.get(ctx, source, start, end)
+		if err != nil {
+			continue
+		}
+		if res.StatusCode != http.StatusPartialContent {
+			res.Body.Close()
+			err = fmt.Errorf("source returned unexpected status for range %d-%d: %d", start, end, res.StatusCode)
+			continue
+		}
+		var buf []byte
+		buf, err = d.readChunk(res, end-start+1)
+		if err == nil {
+			return buf, nil
+		}
+	}
+	return nil, err
+}
+
+// watch fails reads of body once the source sends no data for the stall
+// timeout.
+func (d *Downloader) watch(body io.ReadCloser) io.ReadCloser {
+	w := &stallReader{body: body, timeout: d.stallTimeout}
+	w.timer = time.AfterFunc(d.stallTimeout, func() {
+		w.stalled.Store(true)
+		body.Close()
+	})
+	return w
+}
+
+type stallReader struct {
+	body    io.ReadCloser
+	timer   *time.Timer
+	timeout time.Duration
+	stalled atomic.Bool
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	n, err := s.body.Read(p)
+	if n > 0 {
+		s.timer.Reset(s.timeout)
+	}
+	if err != nil && s.stalled.Load() {
+		return n, fmt.Errorf("%w: no data for %s", ErrSourceStalled, s.timeout)
+	}
+	return n, err
+}
+
+func (s *stallReader) Close() error {
+	s.timer.Stop()
+	return s.body.Close()
+}
+
+// verifyingReader checks the content read has the expected size and digest,
+// failing with [ErrPrecondition] if it does not.
+type verifyingReader struct {
+	r      io.Reader
+	body   io.Closer
+	cancel context.CancelFunc
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	if errors.Is(err, ranged.ErrSizeMismatch) || errors.Is(err, ranged.ErrDigestMismatch) {
+		return n, fmt.Errorf("%w: %w", ErrPrecondition, err)
+	}
+	return n, err
+}
+
+func (v *verifyingReader) Close() error {
+	defer v.cancel()
+	return v.body.Close()
+}
@@ -0,0 +1,121 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func serverURL(t *testing.T, srv *httptest.Server) url.URL {
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	return *u
+}
+
+func TestDownloaderOpen(t *testing.T) {
+	data := testutil.RandomBytes(t, 1000)
+	digest := testutil.MultihashFromBytes(t, data)
+
+	// local test servers are only reachable with a client allowing private
+	// addresses
+	newDownloader := func(opts ...DownloaderOption) *Downloader {
+		return NewDownloader(append([]DownloaderOption{
+			WithHTTPClient(http.DefaultClient),
+			WithChunkSize(64),
+			WithConcurrency(3),
+		}, opts...)...)
+	}
+
+	t.Run("fetches ranges in parallel", func(t *testing.T) {
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+		}))
+		t.Cleanup(srv.Close)
+
+		body, err := newDownloader().Open(context.Background(), serverURL(t, srv), uint64(len(data)), digest)
+		require.NoError(t, err)
+		defer body.Close()
+		got, err := io.ReadAll(body)
+		require.NoError(t, err)
+		require.Equal(t, data, got)
+		require.Equal(t, int32(16), requests.Load())
+	})
+
+	t.Run("falls back to a single request", func(t *testing.T) {
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.Write(data)
+		}))
+		t.Cleanup(srv.Close)
+
+		body, err := newDownloader().Open(context.Background(), serverURL(t, srv), uint64(len(data)), digest)
+		require.NoError(t, err)
+		defer body.Close()
+		got, err := io.ReadAll(body)
+		require.NoError(t, err)
+		require.Equal(t, data, got)
+		require.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("rejects content of another size", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data[:900]))
+		}))
+		t.Cleanup(srv.Close)
+
+		_, err := newDownloader().Open(context.Background(), serverURL(t, srv), uint64(len(data)), digest)
+		require.ErrorIs(t, err, ErrPrecondition)
+	})
+
+	t.Run("rejects content with another digest", func(t *testing.T) {
+		other := testutil.RandomBytes(t, len(data))
+		for name, handler := range map[string]http.HandlerFunc{
+			"ranged": func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(other))
+			},
+			"single": func(w http.ResponseWriter, r *http.Request) {
+				w.Write(other)
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				srv := httptest.NewServer(handler)
+				t.Cleanup(srv.Close)
+
+				body, err := newDownloader().Open(context.Background(), serverURL(t, srv), uint64(len(data)), digest)
+				require.NoError(t, err)
+				defer body.Close()
+				_, err = io.ReadAll(body)
+				require.ErrorIs(t, err, ErrPrecondition)
+			})
+		}
+	})
+
+	t.Run("rejects unsupported schemes", func(t *testing.T) {
+		u, err := url.Parse("file:///etc/passwd")
+		require.NoError(t, err)
+		_, err = newDownloader().Open(context.Background(), *u, uint64(len(data)), digest)
+		require.ErrorIs(t, err, ErrPrecondition)
+	})
+
+	t.Run("refuses private addresses by default", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(data)
+		}))
+		t.Cleanup(srv.Close)
+
+		_, err := NewDownloader().Open(context.Background(), serverURL(t, srv), uint64(len(data)), digest)
+		require.ErrorIs(t, err, ErrForbiddenAddress)
+	})
+}
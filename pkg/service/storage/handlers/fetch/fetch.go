@@ -0,0 +1,288 @@
+// Package fetch pulls blobs from URLs into the node on behalf of `blob/fetch`
+// invocations, and concludes the `blob/accept` task the invocation resolves
+// to.
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/receipt/ran"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/validator"
+
+	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/service/blobs"
+	"github.com/storacha/piri/pkg/service/claims"
+	blobhandler "github.com/storacha/piri/pkg/service/storage/handlers/blob"
+	replicahandler "github.com/storacha/piri/pkg/service/storage/handlers/replica"
+	"github.com/storacha/piri/pkg/storageclass"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/receiptstore"
+)
+
+var log = logging.Logger("storage/handlers/fetch")
+
+type FetchService interface {
+	// ID is the storage service identity, used to sign UCAN invocations and receipts.
+	ID() principal.Signer
+	// PDP handles PDP aggregation
+	PDP() pdp.PDP
+	// Blobs provides access to the blobs service.
+	Blobs() blobs.Blobs
+	// Claims provides access to the claims service.
+	Claims() claims.Claims
+	// Receipts provides access to receipts
+	Receipts() receiptstore.ReceiptStore
+	// UploadConnection provides access to an upload service connection
+	UploadConnection() client.Connection
+	// StorageClasses is nil if storage classes are not tracked.
+	StorageClasses() *storageclass.Manager
+}
+
+type FetchRequest struct {
+	// Space is the space the blob was allocated in.
+	Space did.DID
+	// Blob is the blob fetched.
+	Blob types.Blob
+	// Source is the URL the blob is fetched from.
+	Source url.URL
+	// Sink is the upload URL of the allocation, nil if the node already holds
+	// the blob.
+	Sink *url.URL
+	// SinkHeaders are the headers the blob must be uploaded with.
+	SinkHeaders http.Header
+	// Cause is the `blob/accept` invocation issued by the node, concluded
+	// once the blob is fetched.
+	Cause invocation.Invocation
+}
+
+type fetchRequestModel struct {
+	Space       string      `json:"space"`
+	Blob        types.Blob  `json:"blob"`
+	Source      string      `json:"source"`
+	Sink        *string     `json:"sink,omitempty"`
+	SinkHeaders http.Header `json:"sink_headers,omitempty"`
+	Cause       []byte      `json:"cause"`
+}
+
+func (f *FetchRequest) MarshalJSON() ([]byte, error) {
+	aux := fetchRequestModel{
+		Space:       f.Space.String(),
+		Blob:        f.Blob,
+		Source:      f.Source.String(),
+		SinkHeaders: f.SinkHeaders,
+	}
+	if f.Sink != nil {
+		sink := f.Sink.String()
+		aux.Sink = &sink
+	}
+	cause, err := io.ReadAll(f.Cause.Archive())
+	if err != nil {
+		return nil, fmt.Errorf("marshaling cause: %w", err)
+	}
+	aux.Cause = cause
+	return json.Marshal(aux)
+}
+
+func (f *FetchRequest) UnmarshalJSON(b []byte) error {
+	aux := fetchRequestModel{}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return fmt.Errorf("unmarshaling FetchRequest: %w", err)
+	}
+	space, err := did.Parse(aux.Space)
+	if err != nil {
+		return fmt.Errorf("parsing space DID: %w", err)
+	}
+	f.Space = space
+	f.Blob = aux.Blob
+	source, err := url.Parse(aux.Source)
+	if err != nil {
+		return fmt.Errorf("parsing source URL: %w", err)
+	}
+	f.Source = *source
+	f.Sink = nil
+	if aux.Sink != nil {
+		sink, err := url.Parse(*aux.Sink)
+		if err != nil {
+			return fmt.Errorf("parsing sink URL: %w", err)
+		}
+		f.Sink = sink
+	}
+	f.SinkHeaders = aux.SinkHeaders
+	inv, err := delegation.Extract(aux.Cause)
+	if err != nil {
+		return fmt.Errorf("unmarshaling cause: %w", err)
+	}
+	f.Cause = inv
+	return nil
+}
+
+// Fetch fetches the blob from its source, stores it through the upload URL
+// of its allocation and accepts it, issuing the receipt of the `blob/accept`
+// task.
+//
+// Like replica transfers it is run by a job queue retrying failures, so the
+// blob is only fetched if the node does not already hold it.
+func Fetch(ctx context.Context, service FetchService, downloader *Downloader, request *FetchRequest) error {
+	accept, err := acceptCaveats(request.Cause)
+	if err != nil {
+		return err
+	}
+
+	exists, err := blobExists(ctx, service, request.Blob)
+	if err != nil {
+		return fmt.Errorf("checking if blob has been received before fetch: %w", err)
+	}
+	if request.Sink != nil && !exists {
+		if err := fetch(ctx, downloader, request); err != nil {
+			return fmt.Errorf("fetching blob %s: %w", request.Blob.Digest, err)
+		}
+	}
+
+	req := &blobhandler.AcceptRequest{
+		Space: request.Space,
+		Blob:  request.Blob,
+		Put:   accept.Put,
+		Cause: request.Cause.Link(),
+	}
+	if classes := service.StorageClasses(); classes != nil {
+		class, err := blobhandler.AcceptedClass(ctx, classes, req)
+		if err != nil {
+			return err
+		}
+		req.Class = &class
+	}
+	resp, err := blobhandler.Accept(ctx, service, req)
+	if err != nil {
+		return fmt.Errorf("accepting fetched blob %s: %w", request.Blob.Digest, err)
+	}
+
+	forks := []fx.Effect{fx.FromInvocation(resp.Claim)}
+	ok := blob.AcceptOk{Site: resp.Claim.Link()}
+	if resp.PDP != nil {
+		forks = append(forks, fx.FromInvocation(resp.PDP))
+		pdpLink := resp.PDP.Link()
+		ok.PDP = &pdpLink
+	}
+	rcpt, err := receipt.Issue(
+		service.ID(),
+		result.Ok[blob.AcceptOk, ipld.Builder](ok),
+		ran.FromInvocation(request.Cause),
+		receipt.WithFork(forks...),
+	)
+	if err != nil {
+		return fmt.Errorf("issuing receipt: %w", err)
+	}
+	return putReceipt(ctx, service, rcpt)
+}
+
+// fetch streams the blob from the source to the sink.
+func fetch(ctx context.Context, downloader *Downloader, request *FetchRequest) error {
+	body, err := downloader.Open(ctx, request.Source, request.Blob.Size, request.Blob.Digest)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, request.Sink.String(), body)
+	if err != nil {
+		return fmt.Errorf("creating sink request: %w", err)
+	}
+	req.Header = request.SinkHeaders.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.ContentLength = int64(request.Blob.Size)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// failures reading the source surface as failures of the upload
+		if errors.Is(err, ErrPrecondition) || errors.Is(err, ErrSourceStalled) {
+			return fmt.Errorf("reading source: %w", err)
+		}
+		return fmt.Errorf("uploading to sink: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("sink returned unexpected status %d: %s", res.StatusCode, data)
+	}
+	return nil
+}
+
+// acceptCaveats reads the caveats of the `blob/accept` invocation.
+func acceptCaveats(inv invocation.Invocation) (blob.AcceptCaveats, error) {
+	if len(inv.Capabilities()) != 1 {
+		return blob.AcceptCaveats{}, fmt.Errorf("invalid %s invocation", blob.AcceptAbility)
+	}
+	match, err := blob.Accept.Match(validator.NewSource(inv.Capabilities()[0], inv))
+	if err != nil {
+		return blob.AcceptCaveats{}, fmt.Errorf("matching %s invocation: %w", blob.AcceptAbility, err)
+	}
+	return match.Value().Nb(), nil
+}
+
+// blobExists checks if the blob already exists in either PDP or Blobs store
+func blobExists(ctx context.Context, service FetchService, b types.Blob) (bool, error) {
+	if service.PDP() != nil {
+		has, err := service.PDP().API().Has(ctx, b.Digest)
+		if err != nil {
+			return false, fmt.Errorf("resolving piece: %w", err)
+		}
+		return has, nil
+	}
+	_, err := service.Blobs().Store().Get(ctx, b.Digest)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	return false, fmt.Errorf("checking if blob exists: %w", err)
+}
+
+// putReceipt stores the receipt and sends it to the upload service. The
+// receipt may also be delivered to webhooks, or retrieved from the node, so
+// failing to send it does not fail the fetch.
+func putReceipt(ctx context.Context, service FetchService, rcpt receipt.AnyReceipt) error {
+	if err := service.Receipts().Put(ctx, rcpt); err != nil {
+		return fmt.Errorf("putting receipt: %w", err)
+	}
+	if err := replicahandler.SendReceipt(ctx, service, rcpt); err != nil {
+		log.Warnw("sending receipt to upload service", "receipt", rcpt.Root().Link(), "error", err)
+	}
+	return nil
+}
+
+// SendFailureReceipt issues a failure receipt for the `blob/accept` task
+// when the fetch fails after all retries, or with an error retrying does not
+// resolve.
+func SendFailureReceipt(ctx context.Context, service FetchService, request *FetchRequest, fetchErr error) error {
+	x := failure.FromError(fmt.Errorf("failed to fetch blob from %s: %w", request.Source.Redacted(), fetchErr))
+	rcpt, err := receipt.Issue(
+		service.ID(),
+		result.Error[blob.AcceptOk, failure.IPLDBuilderFailure](x),
+		ran.FromInvocation(request.Cause),
+	)
+	if err != nil {
+		return fmt.Errorf("issuing failure receipt: %w", err)
+	}
+	return putReceipt(ctx, service, rcpt)
+}
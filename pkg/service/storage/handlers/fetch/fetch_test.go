@@ -0,0 +1,62 @@
+package fetch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchRequestJSON(t *testing.T) {
+	b := types.Blob{Digest: testutil.RandomMultihash(t), Size: 1024}
+	space := testutil.RandomDID(t)
+	cause, err := blob.Accept.Invoke(
+		testutil.Alice,
+		testutil.Alice,
+		testutil.Alice.DID().String(),
+		blob.AcceptCaveats{
+			Space: space,
+			Blob:  b,
+			Put:   blob.Promise{UcanAwait: blob.Await{Selector: ".out.ok", Link: testutil.RandomCID(t)}},
+		},
+	)
+	require.NoError(t, err)
+
+	source, err := url.Parse("https://example.com/data.car")
+	require.NoError(t, err)
+	sink, err := url.Parse("https://node.example.com/upload")
+	require.NoError(t, err)
+
+	for name, sink := range map[string]*url.URL{"with sink": sink, "without sink": nil} {
+		t.Run(name, func(t *testing.T) {
+			req := &FetchRequest{
+				Space:       space,
+				Blob:        b,
+				Source:      *source,
+				Sink:        sink,
+				SinkHeaders: http.Header{"Content-Length": []string{"1024"}},
+				Cause:       cause,
+			}
+			data, err := json.Marshal(req)
+			require.NoError(t, err)
+
+			var got FetchRequest
+			require.NoError(t, json.Unmarshal(data, &got))
+			require.Equal(t, req.Space, got.Space)
+			require.Equal(t, req.Blob, got.Blob)
+			require.Equal(t, req.Source, got.Source)
+			require.Equal(t, req.Sink, got.Sink)
+			require.Equal(t, req.SinkHeaders, got.SinkHeaders)
+			require.Equal(t, cause.Link(), got.Cause.Link())
+
+			accept, err := acceptCaveats(got.Cause)
+			require.NoError(t, err)
+			require.Equal(t, space, accept.Space)
+		})
+	}
+}
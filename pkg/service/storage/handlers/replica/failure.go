@@ -0,0 +1,98 @@
+package replica
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/storacha/piri/pkg/internal/ranged"
+)
+
+var (
+	// ErrDigestMismatch is returned when the content replicated from the
+	// sources does not match the digest of the blob.
+	ErrDigestMismatch = ranged.ErrDigestMismatch
+	// ErrSizeMismatch is returned when the content replicated from the sources
+	// does not have the size of the blob.
+	ErrSizeMismatch = ranged.ErrSizeMismatch
+)
+
+// HTTPError is an unsuccessful response of a source or the sink of a
+// transfer.
+type HTTPError struct {
+	// URL is the URL of the source or sink.
+	URL        string
+	StatusCode int
+	Err        error
+}
+
+func (e *HTTPError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Cause describes why a transfer failed, for operators.
+type Cause struct {
+	// URL is the URL of the source or sink whose response failed the
+	// transfer, empty if the failure was not a response.
+	URL string `json:"url,omitempty"`
+	// StatusCode is the HTTP status of that response.
+	StatusCode int `json:"status_code,omitempty"`
+	// DigestMismatch is set if the content replicated did not match the
+	// size or digest of the blob.
+	DigestMismatch bool `json:"digest_mismatch,omitempty"`
+	// Stalled is set if a source stopped sending data.
+	Stalled bool `json:"stalled,omitempty"`
+	// Chain is the errors the failure is wrapped in, outermost first, each
+	// without the message of the error it wraps.
+	Chain []string `json:"chain"`
+}
+
+// CauseOf describes the failure of a transfer from its error.
+func CauseOf(err error) Cause {
+	c := Cause{
+		DigestMismatch: errors.Is(err, ErrDigestMismatch) || errors.Is(err, ErrSizeMismatch),
+		Stalled:        errors.Is(err, ErrSourceStalled),
+		Chain:          errorChain(err),
+	}
+	var herr *HTTPError
+	if errors.As(err, &herr) {
+		c.URL = herr.URL
+		c.StatusCode = herr.StatusCode
+	}
+	return c
+}
+
+func errorChain(err error) []string {
+	var chain []string
+	for err != nil {
+		msg := err.Error()
+		next := unwrap(err)
+		if next != nil {
+			if trimmed, ok := strings.CutSuffix(msg, next.Error()); ok {
+				msg = strings.TrimSuffix(trimmed, ": ")
+			}
+		}
+		if msg != "" {
+			chain = append(chain, msg)
+		}
+		err = next
+	}
+	return chain
+}
+
+// unwrap returns the error wrapped by err. Of errors wrapping several, it is
+// the last, whose message ends theirs, e.g. the cause of [ErrSourceStalled].
+func unwrap(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Unwrap() []error }:
+		if errs := e.Unwrap(); len(errs) > 0 {
+			return errs[len(errs)-1]
+		}
+	}
+	return nil
+}
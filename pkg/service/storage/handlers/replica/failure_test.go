@@ -0,0 +1,45 @@
+package replica
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCauseOf(t *testing.T) {
+	t.Run("sink response", func(t *testing.T) {
+		err := fmt.Errorf("failed to accept replication source blob zQm: %w", &HTTPError{
+			URL:        "https://sink.example.com/blob",
+			StatusCode: 500,
+			Err:        errors.New("unsuccessful http PUT status code 500"),
+		})
+		c := CauseOf(err)
+		require.Equal(t, "https://sink.example.com/blob", c.URL)
+		require.Equal(t, 500, c.StatusCode)
+		require.False(t, c.DigestMismatch)
+		require.Equal(t, []string{
+			"failed to accept replication source blob zQm",
+			"unsuccessful http PUT status code 500",
+		}, c.Chain)
+	})
+
+	t.Run("digest mismatch", func(t *testing.T) {
+		err := fmt.Errorf("failed http PUT: %w", ErrDigestMismatch)
+		c := CauseOf(err)
+		require.True(t, c.DigestMismatch)
+		require.Zero(t, c.StatusCode)
+		require.Equal(t, []string{"failed http PUT", ErrDigestMismatch.Error()}, c.Chain)
+	})
+
+	t.Run("stalled source", func(t *testing.T) {
+		err := fmt.Errorf("%w: https://source.example.com sent no data for 1m0s: %w", ErrSourceStalled, errors.New("context canceled"))
+		c := CauseOf(err)
+		require.True(t, c.Stalled)
+		require.Equal(t, []string{
+			"replication source stalled: https://source.example.com sent no data for 1m0s",
+			"context canceled",
+		}, c.Chain)
+	})
+}
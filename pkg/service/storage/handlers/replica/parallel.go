@@ -0,0 +1,314 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+
+	"github.com/storacha/piri/pkg/internal/ranged"
+)
+
+const (
+	// DefaultChunkSize is the size of the byte ranges a blob is transferred in
+	// when it is replicated from several sources in parallel.
+	DefaultChunkSize = 16 << 20
+	// DefaultConcurrency is the number of ranges transferred in parallel.
+	DefaultConcurrency = 4
+	// rangeAttempts is the number of passes over the sources made for a range
+	// before the transfer fails.
+	rangeAttempts = 3
+	// maxSourceFailures is the number of consecutive ranges a source may fail
+	// before it is not used for the rest of the transfer.
+	maxSourceFailures = 3
+)
+
+// errRangesUnsupported is returned when no source serves byte ranges of the
+// blob, and it must be transferred from a single source.
+var errRangesUnsupported = errors.New("sources do not serve byte ranges")
+
+// permanentError is a failure of a source that retrying does not help, e.g.
+// the source not serving ranges.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Scheduler splits the transfer of a blob that can be replicated from several
+// sources into byte ranges, transferred from the sources in parallel and
+// streamed to the sink in order. Ranges are spread over the sources in the
+// order ranked by the selector, and a range that fails is retried from the
+// next source, with passes over the sources repeated after a backoff. A
+// source is not used for the rest of the transfer once it fails
+// maxSourceFailures ranges in a row, or fails in a way retrying does not
+// help, e.g. by not serving ranges.
+//
+// A nil *Scheduler transfers every blob from a single source.
+type Scheduler struct {
+	chunkSize   int64
+	concurrency int
+	client      *http.Client
+}
+
+// SchedulerOption configures a Scheduler.
+type SchedulerOption func(*Scheduler)
+
+// WithChunkSize sets the size of the ranges a blob is transferred in. Blobs
+// no larger than a range are transferred from a single source.
+func WithChunkSize(size int64) SchedulerOption {
+	return func(s *Scheduler) {
+		if size > 0 {
+			s.chunkSize = size
+		}
+	}
+}
+
+// WithConcurrency sets the number of ranges transferred in parallel. Memory
+// use is bounded by the chunk size times the concurrency. A concurrency of 1
+// transfers every blob from a single source.
+func WithConcurrency(n int) SchedulerOption {
+	return func(s *Scheduler) {
+		if n > 0 {
+			s.concurrency = n
+		}
+	}
+}
+
+// WithHTTPClient sets the HTTP client blobs are written to the sink with.
+func WithHTTPClient(c *http.Client) SchedulerOption {
+	return func(s *Scheduler) {
+		if c != nil {
+			s.client = c
+		}
+	}
+}
+
+func NewScheduler(opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		chunkSize:   DefaultChunkSize,
+		concurrency: DefaultConcurrency,
+		client:      http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// parallel reports whether a blob of the given size is transferred in ranges
+// from the given number of sources.
+func (s *Scheduler) parallel(size uint64, sources int) bool {
+	return s != nil && s.concurrency > 1 && sources > 1 && size > uint64(s.chunkSize)
+}
+
+// httpClient returns the HTTP client blobs are written to the sink with.
+func (s *Scheduler) httpClient() *http.Client {
+	if s == nil {
+		return http.DefaultClient
+	}
+	return s.client
+}
+
+// transfer transfers the blob from the ranked sources to the sink in ranges.
+// The first range is transferred before the sink is written to, and
+// [errRangesUnsupported] is returned if no source serves it as a range.
+func (s *Scheduler) transfer(ctx context.Context, service TransferService, request *TransferRequest, allocInv invocation.Invocation, sources []TransferSource, selector *SourceSelector) error {
+	decoded, err := multihash.Decode(request.Blob.Digest)
+	if err != nil {
+		return fmt.Errorf("decoding digest: %w", err)
+	}
+	hasher, err := multihash.GetHasher(decoded.Code)
+	if err != nil {
+		return fmt.Errorf("unsupported hash function %s: %w", decoded.Name, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p := &rangePool{
+		service:  service,
+		allocInv: allocInv,
+		selector: selector,
+		digest:   request.Blob.Digest,
+		size:     int64(request.Blob.Size),
+	}
+	for _, src := range sources {
+		p.sources = append(p.sources, &rangeSource{TransferSource: src})
+	}
+
+	first, err := p.fetch(ctx, 0, 0, s.chunkSize-1)
+	if err != nil {
+		return err
+	}
+
+	r := ranged.NewReader(ctx, first, p.size, s.chunkSize, s.concurrency, p.fetch)
+	defer r.Close()
+	body := ranged.VerifyingReader(r, p.size, hasher, decoded.Digest)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, request.Sink.String(), body)
+	if err != nil {
+		return fmt.Errorf("failed to create replication sink request: %w", err)
+	}
+	req.ContentLength = p.size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res, err := s.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed http PUT to replicate blob %s in ranges to %s: %w", request.Blob.Digest, request.Sink.String(), err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 || res.StatusCode < 200 {
+		data, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return &HTTPError{
+			URL:        request.Sink.String(),
+			StatusCode: res.StatusCode,
+			Err:        fmt.Errorf("unsuccessful http PUT to replicate blob %s in ranges to %s status code %d response body: %s", request.Blob.Digest, request.Sink.String(), res.StatusCode, data),
+		}
+	}
+	return nil
+}
+
+// rangePool transfers ranges of a blob from its sources.
+type rangePool struct {
+	service  TransferService
+	allocInv invocation.Invocation
+	selector *SourceSelector
+	digest   multihash.Multihash
+	size     int64
+	sources  []*rangeSource
+}
+
+// rangeSource is a source ranges are transferred from, reusing the
+// `blob/retrieve` delegation it granted.
+type rangeSource struct {
+	TransferSource
+	once   sync.Once
+	dlg    delegation.Delegation
+	dlgErr error
+	// failures is the number of consecutive ranges the source failed.
+	failures atomic.Int32
+	dropped  atomic.Bool
+}
+
+// fetch transfers the i-th range, the bytes from start to end inclusive,
+// starting with the i-th source and moving on to the next sources on failure.
+// Passes over the sources are retried with backoff until rangeAttempts are
+// made or no sources are left.
+func (p *rangePool) fetch(ctx context.Context, i int, start, end int64) ([]byte, error) {
+	var (
+		errs        []error
+		unsupported = true
+	)
+	for attempt := range rangeAttempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+		tried := false
+		for k := range len(p.sources) {
+			src := p.sources[(i+k)%len(p.sources)]
+			if src.dropped.Load() {
+				continue
+			}
+			tried = true
+			began := time.Now()
+			data, err := p.fetchFrom(ctx, src, start, end)
+			p.selector.Observe(src.TransferSource, int64(len(data)), time.Since(began), err)
+			if err == nil {
+				src.failures.Store(0)
+				return data, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Warnw("replication of range from source failed", "source", src.URL.String(), "blob", p.digest, "start", start, "end", end, "attempt", attempt+1, "error", err)
+			var perr *permanentError
+			if errors.As(err, &perr) || src.failures.Add(1) >= maxSourceFailures {
+				src.dropped.Store(true)
+			}
+			unsupported = unsupported && errors.Is(err, errRangesUnsupported)
+			errs = append(errs, err)
+		}
+		if !tried {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no sources left to replicate range %d-%d from", start, end)
+	}
+	err := errors.Join(errs...)
+	if unsupported {
+		return nil, fmt.Errorf("%w: %w", errRangesUnsupported, err)
+	}
+	return nil, fmt.Errorf("replicating range %d-%d: %w", start, end, err)
+}
+
+// fetchFrom transfers the bytes from start to end inclusive from the source.
+// The request is aborted with [ErrSourceStalled] if the source sends no data
+// for the stall timeout.
+func (p *rangePool) fetchFrom(ctx context.Context, src *rangeSource, start, end int64) ([]byte, error) {
+	src.once.Do(func() {
+		src.dlg, src.dlgErr = requestBlobRetrieveDelegation(ctx, src.URL, p.service.ID(), src.ID, p.allocInv)
+	})
+	if src.dlgErr != nil {
+		return nil, &permanentError{fmt.Errorf("requesting %s delegation: %w", blob.RetrieveAbility, src.dlgErr)}
+	}
+
+	stallTimeout := p.selector.StallTimeout()
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var watchdog *time.Timer
+	if stallTimeout > 0 {
+		watchdog = time.AfterFunc(stallTimeout, func() { cancel(ErrSourceStalled) })
+		defer watchdog.Stop()
+	}
+
+	data, err := p.read(ctx, src, start, end, watchdog, stallTimeout)
+	if err != nil && errors.Is(context.Cause(ctx), ErrSourceStalled) {
+		return nil, fmt.Errorf("%w: %s sent no data for %s: %w", ErrSourceStalled, src.URL.String(), stallTimeout, err)
+	}
+	return data, err
+}
+
+func (p *rangePool) read(ctx context.Context, src *rangeSource, start, end int64, watchdog *time.Timer, stallTimeout time.Duration) ([]byte, error) {
+	headers := http.Header{}
+	headers.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	// ranges are retrieved with otherwise identical invocations
+	nonce := delegation.WithNonce(strconv.FormatInt(start, 10))
+	res, err := retrieveBlob(ctx, p.service, src.TransferSource, src.dlg, p.digest, headers, nonce)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body().Close()
+
+	if res.Status() != http.StatusPartialContent {
+		return nil, &permanentError{fmt.Errorf("%w: %s returned status %d", errRangesUnsupported, src.URL.String(), res.Status())}
+	}
+	total, err := ranged.ContentRangeTotal(res.Headers().Get("Content-Range"))
+	if err != nil {
+		return nil, &permanentError{fmt.Errorf("replication source (%s): %w", src.URL.String(), err)}
+	}
+	if total != p.size {
+		return nil, &permanentError{fmt.Errorf("%w: replication source (%s) has %d bytes, expected %d", ranged.ErrSizeMismatch, src.URL.String(), total, p.size)}
+	}
+	buf := make([]byte, end-start+1)
+	body := &watchdogReader{r: res.Body(), timer: watchdog, timeout: stallTimeout}
+	if _, err := io.ReadFull(body, buf); err != nil {
+		return nil, fmt.Errorf("reading range from %s: %w", src.URL.String(), err)
+	}
+	return buf, nil
+}
@@ -0,0 +1,19 @@
+package replica
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerParallel(t *testing.T) {
+	s := NewScheduler(WithChunkSize(1024))
+	require.True(t, s.parallel(2048, 2))
+	require.False(t, s.parallel(2048, 1), "single source")
+	require.False(t, s.parallel(1024, 2), "blob fits in one range")
+
+	require.False(t, NewScheduler(WithChunkSize(1024), WithConcurrency(1)).parallel(2048, 2))
+
+	var nilScheduler *Scheduler
+	require.False(t, nilScheduler.parallel(2048, 2))
+}
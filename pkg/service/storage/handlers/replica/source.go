@@ -0,0 +1,305 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrSourceStalled is the cause of a transfer being aborted because the
+// source stopped sending data.
+var ErrSourceStalled = errors.New("replication source stalled")
+
+const (
+	// DefaultStallTimeout is the time a source may go without sending any data
+	// before the transfer fails over to the next source.
+	DefaultStallTimeout = 30 * time.Second
+	// DefaultProbeTimeout bounds the latency probe of a source.
+	DefaultProbeTimeout = 2 * time.Second
+
+	// probeTTL is how long a latency probe result is reused for.
+	probeTTL = 5 * time.Minute
+	// assumedThroughput is used for sources with no transfer history, in
+	// bytes per second.
+	assumedThroughput = 10 << 20
+	// throughputWeight is the weight of the latest sample in the moving
+	// average of source throughput.
+	throughputWeight = 0.3
+	// failurePenalty is added to the score of a source for each consecutive
+	// failed transfer.
+	failurePenalty = 30 * time.Second
+	// unreachablePenalty is added to the score of a source whose latency probe
+	// failed.
+	unreachablePenalty = 10 * time.Second
+)
+
+type sourceStats struct {
+	// throughput is a moving average of successful transfers in bytes/second.
+	throughput float64
+	// failures is the number of consecutive failed transfers.
+	failures int
+	latency  time.Duration
+	probeErr error
+	probedAt time.Time
+}
+
+// SourceSelector ranks the sources a blob can be replicated from. Sources are
+// scored by the estimated time to transfer the blob from them, using a latency
+// probe, the throughput of previous transfers and recent failures. Healthy
+// sources on preferred hosts (e.g. in the same region) are ranked before all
+// others, whatever their score. A preferred source that failed its last
+// transfer or latency probe is ranked by score alongside the others.
+//
+// A nil *SourceSelector keeps sources in the order given and disables stall
+// detection.
+type SourceSelector struct {
+	client         *http.Client
+	preferredHosts []string
+	stallTimeout   time.Duration
+	probeTimeout   time.Duration
+	compression    bool
+	now            func() time.Time
+
+	mu    sync.Mutex
+	stats map[string]*sourceStats
+}
+
+// SourceSelectorOption configures a SourceSelector.
+type SourceSelectorOption func(*SourceSelector)
+
+// WithPreferredHosts ranks healthy sources on the given hosts first, for
+// example hosts known to be in the same region as this node.
+func WithPreferredHosts(hosts ...string) SourceSelectorOption {
+	return func(s *SourceSelector) {
+		s.preferredHosts = append(s.preferredHosts, hosts...)
+	}
+}
+
+// WithStallTimeout sets the time a source may go without sending data before
+// the transfer fails over to the next source.
+func WithStallTimeout(d time.Duration) SourceSelectorOption {
+	return func(s *SourceSelector) {
+		if d > 0 {
+			s.stallTimeout = d
+		}
+	}
+}
+
+// WithCompression requests whole blobs zstd compressed from sources. Sources
+// not enabling compression send them uncompressed.
+func WithCompression(enabled bool) SourceSelectorOption {
+	return func(s *SourceSelector) {
+		s.compression = enabled
+	}
+}
+
+// WithProbeTimeout sets the timeout of source latency probes.
+func WithProbeTimeout(d time.Duration) SourceSelectorOption {
+	return func(s *SourceSelector) {
+		if d > 0 {
+			s.probeTimeout = d
+		}
+	}
+}
+
+// WithProbeClient sets the HTTP client used to probe source latency.
+func WithProbeClient(c *http.Client) SourceSelectorOption {
+	return func(s *SourceSelector) {
+		s.client = c
+	}
+}
+
+func NewSourceSelector(opts ...SourceSelectorOption) *SourceSelector {
+	s := &SourceSelector{
+		client:       http.DefaultClient,
+		stallTimeout: DefaultStallTimeout,
+		probeTimeout: DefaultProbeTimeout,
+		now:          time.Now,
+		stats:        map[string]*sourceStats{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// StallTimeout returns the time a source may go without sending data before
+// it is considered stalled, zero if stall detection is disabled.
+func (s *SourceSelector) StallTimeout() time.Duration {
+	if s == nil {
+		return 0
+	}
+	return s.stallTimeout
+}
+
+// Compression reports whether whole blobs are requested zstd compressed.
+func (s *SourceSelector) Compression() bool {
+	return s != nil && s.compression
+}
+
+// Rank orders the sources from best to worst for transferring a blob of the
+// given size.
+func (s *SourceSelector) Rank(ctx context.Context, sources []TransferSource, size uint64) []TransferSource {
+	if s == nil || len(sources) < 2 {
+		return sources
+	}
+
+	s.probe(ctx, sources)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ranked := slices.Clone(sources)
+	slices.SortStableFunc(ranked, func(a, b TransferSource) int {
+		pa, pb := s.preferred(a.URL.Host), s.preferred(b.URL.Host)
+		if pa != pb {
+			if pa {
+				return -1
+			}
+			return 1
+		}
+		sa, sb := s.score(a.URL.Host, size), s.score(b.URL.Host, size)
+		switch {
+		case sa < sb:
+			return -1
+		case sa > sb:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return ranked
+}
+
+// Observe records the outcome of a transfer from a source.
+func (s *SourceSelector) Observe(source TransferSource, n int64, elapsed time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.statsFor(source.URL.Host)
+	if err != nil {
+		st.failures++
+		return
+	}
+	st.failures = 0
+	if n <= 0 || elapsed <= 0 {
+		return
+	}
+	sample := float64(n) / elapsed.Seconds()
+	if st.throughput == 0 {
+		st.throughput = sample
+	} else {
+		st.throughput = throughputWeight*sample + (1-throughputWeight)*st.throughput
+	}
+}
+
+// score estimates the time in seconds to transfer size bytes from host.
+// Callers must hold s.mu.
+func (s *SourceSelector) score(host string, size uint64) float64 {
+	st := s.statsFor(host)
+	throughput := st.throughput
+	if throughput == 0 {
+		throughput = assumedThroughput
+	}
+	est := st.latency.Seconds() + float64(size)/throughput
+	if st.probeErr != nil {
+		est += unreachablePenalty.Seconds()
+	}
+	return est + float64(st.failures)*failurePenalty.Seconds()
+}
+
+// preferred reports whether host is a preferred host that is healthy, i.e. its
+// last transfer and latency probe did not fail. Callers must hold s.mu.
+func (s *SourceSelector) preferred(host string) bool {
+	if !slices.Contains(s.preferredHosts, host) {
+		return false
+	}
+	st := s.statsFor(host)
+	return st.failures == 0 && st.probeErr == nil
+}
+
+// statsFor returns the stats for host, creating them if needed. Callers must
+// hold s.mu.
+func (s *SourceSelector) statsFor(host string) *sourceStats {
+	st, ok := s.stats[host]
+	if !ok {
+		st = &sourceStats{}
+		s.stats[host] = st
+	}
+	return st
+}
+
+// probe measures the round trip time to each source host whose last probe has
+// expired. Hosts are probed in parallel.
+func (s *SourceSelector) probe(ctx context.Context, sources []TransferSource) {
+	now := s.now()
+	var stale []TransferSource
+	s.mu.Lock()
+	for _, src := range sources {
+		st := s.statsFor(src.URL.Host)
+		if now.Sub(st.probedAt) > probeTTL {
+			st.probedAt = now
+			stale = append(stale, src)
+		}
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, src := range stale {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latency, err := s.probeLatency(ctx, src)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			st := s.statsFor(src.URL.Host)
+			st.latency, st.probeErr = latency, err
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *SourceSelector) probeLatency(ctx context.Context, source TransferSource) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.probeTimeout)
+	defer cancel()
+
+	u := source.URL
+	u.Path, u.RawPath, u.RawQuery = "/", "", ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	// any response will do, we are only interested in the round trip time
+	return time.Since(start), nil
+}
+
+// watchdogReader resets a watchdog timer whenever data is read, and counts the
+// bytes read.
+type watchdogReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+	n       int64
+}
+
+func (w *watchdogReader) Read(p []byte) (int, error) {
+	n, err := w.r.Read(p)
+	if n > 0 {
+		w.n += int64(n)
+		if w.timer != nil {
+			w.timer.Reset(w.timeout)
+		}
+	}
+	return n, err
+}
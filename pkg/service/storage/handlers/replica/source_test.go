@@ -0,0 +1,122 @@
+package replica
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func testSource(t *testing.T, rawURL string) TransferSource {
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return TransferSource{ID: testutil.Alice, URL: *u}
+}
+
+func hosts(sources []TransferSource) []string {
+	var out []string
+	for _, s := range sources {
+		out = append(out, s.URL.Host)
+	}
+	return out
+}
+
+// probed records a probe of src that measured latency, or failed with err, so
+// ranking does not depend on the timing of real requests.
+func probed(s *SourceSelector, src TransferSource, latency time.Duration, err error) {
+	s.stats[src.URL.Host] = &sourceStats{latency: latency, probeErr: err, probedAt: s.now()}
+}
+
+func TestSourceSelectorRank(t *testing.T) {
+	slowSrc := testSource(t, "http://slow.example.com/blob/z1")
+	fastSrc := testSource(t, "http://fast.example.com/blob/z1")
+
+	newSelector := func(opts ...SourceSelectorOption) *SourceSelector {
+		s := NewSourceSelector(opts...)
+		probed(s, slowSrc, 200*time.Millisecond, nil)
+		probed(s, fastSrc, time.Millisecond, nil)
+		return s
+	}
+
+	t.Run("prefers lower latency", func(t *testing.T) {
+		s := newSelector()
+		ranked := s.Rank(t.Context(), []TransferSource{slowSrc, fastSrc}, 1024)
+		require.Equal(t, hosts([]TransferSource{fastSrc, slowSrc}), hosts(ranked))
+	})
+
+	t.Run("prefers preferred hosts", func(t *testing.T) {
+		s := newSelector(WithPreferredHosts(slowSrc.URL.Host))
+		// historically much faster to transfer from the other host
+		s.Observe(fastSrc, 1<<30, time.Second, nil)
+		ranked := s.Rank(t.Context(), []TransferSource{fastSrc, slowSrc}, 100<<20)
+		require.Equal(t, hosts([]TransferSource{slowSrc, fastSrc}), hosts(ranked))
+	})
+
+	t.Run("ranks failing preferred hosts by score", func(t *testing.T) {
+		s := newSelector(WithPreferredHosts(slowSrc.URL.Host))
+		s.Observe(slowSrc, 0, time.Second, ErrSourceStalled)
+		ranked := s.Rank(t.Context(), []TransferSource{slowSrc, fastSrc}, 1024)
+		require.Equal(t, hosts([]TransferSource{fastSrc, slowSrc}), hosts(ranked))
+	})
+
+	t.Run("prefers higher throughput", func(t *testing.T) {
+		s := newSelector()
+		// slow to respond but historically fast to transfer
+		s.Observe(slowSrc, 1<<30, time.Second, nil)
+		s.Observe(fastSrc, 1<<20, time.Second, nil)
+		ranked := s.Rank(t.Context(), []TransferSource{fastSrc, slowSrc}, 100<<20)
+		require.Equal(t, hosts([]TransferSource{slowSrc, fastSrc}), hosts(ranked))
+	})
+
+	t.Run("demotes failing sources", func(t *testing.T) {
+		s := newSelector()
+		s.Observe(fastSrc, 0, time.Second, ErrSourceStalled)
+		ranked := s.Rank(t.Context(), []TransferSource{fastSrc, slowSrc}, 1024)
+		require.Equal(t, hosts([]TransferSource{slowSrc, fastSrc}), hosts(ranked))
+
+		// a success resets the failure count
+		s.Observe(fastSrc, 1024, time.Millisecond, nil)
+		ranked = s.Rank(t.Context(), []TransferSource{slowSrc, fastSrc}, 1024)
+		require.Equal(t, hosts([]TransferSource{fastSrc, slowSrc}), hosts(ranked))
+	})
+
+	t.Run("demotes unreachable sources", func(t *testing.T) {
+		unreachable := testSource(t, "http://unreachable.example.com/blob/z1")
+		s := newSelector()
+		probed(s, unreachable, 0, errors.New("connection refused"))
+		ranked := s.Rank(t.Context(), []TransferSource{unreachable, slowSrc}, 1024)
+		require.Equal(t, hosts([]TransferSource{slowSrc, unreachable}), hosts(ranked))
+	})
+
+	t.Run("nil selector keeps order", func(t *testing.T) {
+		var s *SourceSelector
+		ranked := s.Rank(t.Context(), []TransferSource{slowSrc, fastSrc}, 1024)
+		require.Equal(t, hosts([]TransferSource{slowSrc, fastSrc}), hosts(ranked))
+		require.Zero(t, s.StallTimeout())
+	})
+}
+
+func TestSourceSelectorProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+	reachable := testSource(t, srv.URL+"/blob/z1")
+	unreachable := testSource(t, "http://127.0.0.1:1/blob/z1")
+
+	s := NewSourceSelector()
+	s.Rank(t.Context(), []TransferSource{reachable, unreachable}, 1024)
+
+	st := s.stats[reachable.URL.Host]
+	require.NoError(t, st.probeErr)
+	require.Positive(t, st.latency)
+	require.Error(t, s.stats[unreachable.URL.Host].probeErr)
+
+	// probe results are reused until they expire
+	probedAt := st.probedAt
+	s.Rank(t.Context(), []TransferSource{reachable, unreachable}, 1024)
+	require.Equal(t, probedAt, s.stats[reachable.URL.Host].probedAt)
+}
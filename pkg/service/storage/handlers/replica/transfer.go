@@ -8,11 +8,13 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
 	basicnode "github.com/ipld/go-ipld-prime/node/basic"
 	"github.com/ipld/go-ipld-prime/printer"
+	"github.com/multiformats/go-multihash"
 	"github.com/storacha/go-libstoracha/capabilities/access"
 	"github.com/storacha/go-libstoracha/capabilities/assert"
 	"github.com/storacha/go-libstoracha/capabilities/blob"
@@ -32,11 +34,16 @@ import (
 	"github.com/storacha/go-ucanto/core/result"
 	"github.com/storacha/go-ucanto/did"
 	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/transport"
 	ucan_http "github.com/storacha/go-ucanto/transport/http"
 	"github.com/storacha/go-ucanto/ucan"
 	"github.com/storacha/go-ucanto/validator"
 	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/storacha/piri/lib/jobqueue/traceutil"
+	"github.com/storacha/piri/pkg/compression"
+	"github.com/storacha/piri/pkg/hwsigner"
+	"github.com/storacha/piri/pkg/internal/ranged"
 	"github.com/storacha/piri/pkg/pdp"
 	"github.com/storacha/piri/pkg/service/blobs"
 	"github.com/storacha/piri/pkg/service/claims"
@@ -81,6 +88,9 @@ type TransferRequest struct {
 	Blob types.Blob
 	// Source is the location to replicate the blob from.
 	Source TransferSource
+	// Alternatives are other locations the blob may be replicated from, used
+	// when the source is slower or fails.
+	Alternatives []TransferSource
 	// Sink is the location to replicate the blob to.
 	Sink *url.URL
 	// Cause is the invocation responsible for spawning this replication
@@ -89,21 +99,46 @@ type TransferRequest struct {
 }
 
 type transferRequestModel struct {
-	Space  string              `json:"space"`
-	Blob   types.Blob          `json:"blob"`
-	Source transferSourceModel `json:"source"`
-	Sink   *string             `json:"sink,omitempty"`
-	Cause  []byte              `json:"cause"`
+	Space        string                `json:"space"`
+	Blob         types.Blob            `json:"blob"`
+	Source       transferSourceModel   `json:"source"`
+	Alternatives []transferSourceModel `json:"alternatives,omitempty"`
+	Sink         *string               `json:"sink,omitempty"`
+	Cause        []byte                `json:"cause"`
+}
+
+func (s TransferSource) toModel() transferSourceModel {
+	return transferSourceModel{
+		ID:  s.ID.DID().String(),
+		URL: s.URL.String(),
+	}
+}
+
+func (m transferSourceModel) toSource() (TransferSource, error) {
+	id, err := did.Parse(m.ID)
+	if err != nil {
+		return TransferSource{}, fmt.Errorf("parsing source DID: %w", err)
+	}
+	u, err := url.Parse(m.URL)
+	if err != nil {
+		return TransferSource{}, fmt.Errorf("parsing source URL: %w", err)
+	}
+	return TransferSource{ID: id, URL: *u}, nil
+}
+
+// Sources returns the source followed by the alternatives.
+func (t *TransferRequest) Sources() []TransferSource {
+	return append([]TransferSource{t.Source}, t.Alternatives...)
 }
 
 func (t *TransferRequest) MarshalJSON() ([]byte, error) {
 	aux := transferRequestModel{
-		Space: t.Space.String(),
-		Blob:  t.Blob,
-		Source: transferSourceModel{
-			ID:  t.Source.ID.DID().String(),
-			URL: t.Source.URL.String(),
-		},
+		Space:  t.Space.String(),
+		Blob:   t.Blob,
+		Source: t.Source.toModel(),
+	}
+	for _, alt := range t.Alternatives {
+		aux.Alternatives = append(aux.Alternatives, alt.toModel())
 	}
 
 	if t.Sink != nil {
@@ -134,16 +169,18 @@ func (t *TransferRequest) UnmarshalJSON(b []byte) error {
 
 	t.Blob = aux.Blob
 
-	sourceID, err := did.Parse(aux.Source.ID)
+	t.Source, err = aux.Source.toSource()
 	if err != nil {
-		return fmt.Errorf("parsing source DID: %w", err)
+		return err
 	}
-	t.Source.ID = sourceID
-	sourceURL, err := url.Parse(aux.Source.URL)
-	if err != nil {
-		return fmt.Errorf("parsing source URL: %w", err)
+	t.Alternatives = nil
+	for _, m := range aux.Alternatives {
+		alt, err := m.toSource()
+		if err != nil {
+			return fmt.Errorf("alternative source: %w", err)
+		}
+		t.Alternatives = append(t.Alternatives, alt)
 	}
-	t.Source.URL = *sourceURL
 
 	if aux.Sink != nil {
 		sinkURL, err := url.Parse(*aux.Sink)
@@ -178,7 +215,12 @@ func (t *TransferRequest) UnmarshalJSON(b []byte) error {
 //
 // Both paths end with sending the receipt to the upload service, which confirms
 // successful replication to the requesting node.
-func Transfer(ctx context.Context, service TransferService, request *TransferRequest, metrics *Metrics) (err error) {
+//
+// When the request has alternative sources they are tried in the order ranked
+// by the selector, failing over to the next source if a transfer fails or
+// stalls. Large blobs are instead transferred in ranges from all the sources
+// in parallel, when the scheduler allows it.
+func Transfer(ctx context.Context, service TransferService, request *TransferRequest, selector *SourceSelector, scheduler *Scheduler, metrics *Metrics) (err error) {
 	var (
 		rcpt  receipt.AnyReceipt
 		forks []fx.Effect
@@ -190,7 +232,7 @@ func Transfer(ctx context.Context, service TransferService, request *TransferReq
 		if err != nil {
 			success = false
 		}
-		stopwatch.Stop(ctx, attribute.Bool("success", success))
+		stopwatch.Stop(traceutil.ExemplarContext(ctx), attribute.Bool("success", success))
 	}()
 
 	// Check if the blob already exists
@@ -201,7 +243,7 @@ func Transfer(ctx context.Context, service TransferService, request *TransferReq
 
 	if request.Sink != nil && !blobExists {
 		// Need to transfer the blob from source to sink
-		acceptResp, err := transferBlobFromSource(ctx, service, request)
+		acceptResp, err := transferBlobFromSources(ctx, service, request, selector, scheduler)
 		if err != nil {
 			return fmt.Errorf("failed to accept replication source blob %s: %w", request.Blob.Digest, err)
 		}
@@ -240,7 +282,7 @@ func Transfer(ctx context.Context, service TransferService, request *TransferReq
 	}
 
 	// Build and send message to upload service
-	return sendMessageToUploadService(ctx, service, rcpt)
+	return SendReceipt(ctx, service, rcpt)
 }
 
 func sinkLabel(sink *url.URL) string {
@@ -277,112 +319,245 @@ func checkBlobExists(ctx context.Context, service TransferService, blob types.Bl
 	return false, fmt.Errorf("checking if blob exists: %w", err)
 }
 
-// transferBlobFromSource fetches blob from source and PUTs it to sink
-func transferBlobFromSource(ctx context.Context, service TransferService, request *TransferRequest) (*blobhandler.AcceptResponse, error) {
+// transferBlobFromSources transfers the blob from the best available source to
+// the sink and accepts it, failing over to the next best source on error. Large
+// blobs with several sources are transferred in ranges from the sources in
+// parallel, if the scheduler allows and the sources serve ranges.
+func transferBlobFromSources(ctx context.Context, service TransferService, request *TransferRequest, selector *SourceSelector, scheduler *Scheduler) (*blobhandler.AcceptResponse, error) {
 	allocInv, err := extractReplicaAllocateInvocation(request.Cause)
 	if err != nil {
 		return nil, fmt.Errorf("extracting %s invocation: %w", replica.AllocateAbility, err)
 	}
 
-	dlg, err := requestBlobRetrieveDelegation(ctx, request.Source.URL, service.ID(), request.Source.ID, allocInv)
+	sources := selector.Rank(ctx, request.Sources(), request.Blob.Size)
+	if scheduler.parallel(request.Blob.Size, len(sources)) {
+		err := scheduler.transfer(ctx, service, request, allocInv, sources, selector)
+		if err == nil {
+			return acceptReplica(ctx, service, request)
+		}
+		if !errors.Is(err, errRangesUnsupported) {
+			return nil, err
+		}
+		log.Infow("sources do not serve ranges, replicating from a single source", "blob", request.Blob.Digest, "error", err)
+	}
+
+	var errs []error
+	for _, source := range sources {
+		start := time.Now()
+		n, err := transferBlobFromSource(ctx, service, request, allocInv, source, scheduler.httpClient(), selector.StallTimeout(), selector.Compression())
+		selector.Observe(source, n, time.Since(start), err)
+		if err == nil {
+			return acceptReplica(ctx, service, request)
+		}
+		log.Warnw("replication from source failed", "source", source.URL.String(), "blob", request.Blob.Digest, "error", err)
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// acceptReplica accepts the blob once it has been transferred to the sink.
+func acceptReplica(ctx context.Context, service TransferService, request *TransferRequest) (*blobhandler.AcceptResponse, error) {
+	return blobhandler.Accept(ctx, service, &blobhandler.AcceptRequest{
+		Space: request.Space,
+		Blob:  request.Blob,
+		Put: blob.Promise{
+			UcanAwait: blob.Await{
+				Selector: ".out.ok",
+				Link:     request.Cause.Link(),
+			},
+		},
+		Cause: request.Cause.Link(),
+	})
+}
+
+// transferBlobFromSource fetches blob from source and PUTs it to sink with
+// sinkClient, returning the number of bytes read from the source. The
+// transfer is aborted with [ErrSourceStalled] if the source sends no data for
+// stallTimeout. If compress is set the blob is requested zstd compressed, and
+// decompressed before it is written to the sink.
+func transferBlobFromSource(ctx context.Context, service TransferService, request *TransferRequest, allocInv invocation.Invocation, source TransferSource, sinkClient *http.Client, stallTimeout time.Duration, compress bool) (int64, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var watchdog *time.Timer
+	if stallTimeout > 0 {
+		watchdog = time.AfterFunc(stallTimeout, func() { cancel(ErrSourceStalled) })
+		defer watchdog.Stop()
+	}
+
+	n, err := streamBlobFromSource(ctx, service, request, allocInv, source, sinkClient, watchdog, stallTimeout, compress)
+	if err != nil && errors.Is(context.Cause(ctx), ErrSourceStalled) {
+		return n, fmt.Errorf("%w: %s sent no data for %s: %w", ErrSourceStalled, source.URL.String(), stallTimeout, err)
+	}
+	return n, err
+}
+
+func streamBlobFromSource(ctx context.Context, service TransferService, request *TransferRequest, allocInv invocation.Invocation, source TransferSource, sinkClient *http.Client, watchdog *time.Timer, stallTimeout time.Duration, compress bool) (int64, error) {
+	dlg, err := requestBlobRetrieveDelegation(ctx, source.URL, service.ID(), source.ID, allocInv)
+	if err != nil {
+		return 0, fmt.Errorf("requesting %s delegation: %w", blob.RetrieveAbility, err)
+	}
+
+	var headers http.Header
+	if compress {
+		headers = http.Header{"Accept-Encoding": []string{compression.Zstd}}
+	}
+	replicaResp, err := retrieveBlob(ctx, service, source, dlg, request.Blob.Digest, headers)
+	if err != nil {
+		return 0, err
+	}
+	defer replicaResp.Body().Close()
+
+	// Stream source to sink
+	body := &watchdogReader{r: replicaResp.Body(), timer: watchdog, timeout: stallTimeout}
+	var content io.Reader = body
+	encoding := replicaResp.Headers().Get("Content-Encoding")
+	switch encoding {
+	case "":
+	case compression.Zstd:
+		// the sink receives the decompressed blob
+		dec, err := decompressBlob(body, request.Blob)
+		if err != nil {
+			return 0, err
+		}
+		defer dec.Close()
+		content = dec
+	default:
+		return 0, fmt.Errorf("replication source (%s) sent unsupported content encoding: %s", source.URL.String(), encoding)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, request.Sink.String(), content)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create replication sink request: %w", err)
+	}
+	req.Header = replicaResp.Headers()
+	if encoding != "" {
+		req.Header = req.Header.Clone()
+		req.Header.Del("Content-Encoding")
+		req.ContentLength = int64(request.Blob.Size)
+	}
+	res, err := sinkClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"failed http PUT to replicate blob %s from %s to %s failed: %w",
+			request.Blob.Digest,
+			source.URL.String(),
+			request.Sink.String(),
+			err,
+		)
+	}
+	defer res.Body.Close()
+
+	// Verify status
+	if res.StatusCode >= 300 || res.StatusCode < 200 {
+		topErr := fmt.Errorf(
+			"unsuccessful http PUT to replicate blob %s from %s to %s status code %d",
+			request.Blob.Digest,
+			source.URL.String(),
+			request.Sink.String(),
+			res.StatusCode,
+		)
+		resData, err := io.ReadAll(res.Body)
+		if err != nil {
+			return 0, fmt.Errorf("%s failed to read replication sink response body: %w", topErr, err)
+		}
+		return 0, &HTTPError{
+			URL:        request.Sink.String(),
+			StatusCode: res.StatusCode,
+			Err:        fmt.Errorf("%s response body: %s", topErr, resData),
+		}
+	}
+
+	return body.n, nil
+}
+
+// decompressBlob decompresses a zstd compressed blob, verifying the
+// decompressed content against the blob digest before the last bytes are
+// handed to the sink.
+func decompressBlob(r io.Reader, b types.Blob) (io.ReadCloser, error) {
+	decoded, err := multihash.Decode(b.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("decoding digest: %w", err)
+	}
+	hasher, err := multihash.GetHasher(decoded.Code)
 	if err != nil {
-		return nil, fmt.Errorf("requesting %s delegation: %w", blob.RetrieveAbility, err)
+		return nil, fmt.Errorf("unsupported hash function %s: %w", decoded.Name, err)
 	}
+	dec, err := compression.NewZstdDecoder(r)
+	if err != nil {
+		return nil, fmt.Errorf("creating zstd decoder: %w", err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{
+		// read one byte past the size so oversized content is rejected
+		Reader: ranged.VerifyingReader(io.LimitReader(dec, int64(b.Size)+1), int64(b.Size), hasher, decoded.Digest),
+		Closer: dec,
+	}, nil
+}
 
-	// perform authorized retrieval from source using the delegation
+// retrieveBlob performs an authorized `blob/retrieve` of the blob from the
+// source using the delegation it granted, sending the given extra headers,
+// e.g. a Range header. The caller must close the body of the response.
+func retrieveBlob(ctx context.Context, service TransferService, source TransferSource, dlg delegation.Delegation, digest multihash.Multihash, headers http.Header, opts ...delegation.Option) (transport.HTTPResponse, error) {
 	inv, err := blob.Retrieve.Invoke(
 		service.ID(),
-		request.Source.ID,
-		request.Source.ID.DID().String(),
-		blob.RetrieveCaveats{Blob: blob.Blob{Digest: request.Blob.Digest}},
-		delegation.WithProof(delegation.FromDelegation(dlg)),
+		source.ID,
+		source.ID.DID().String(),
+		blob.RetrieveCaveats{Blob: blob.Blob{Digest: digest}},
+		append([]delegation.Option{delegation.WithProof(delegation.FromDelegation(dlg))}, opts...)...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("creating %s invocation: %w", blob.RetrieveAbility, err)
 	}
 
-	conn, err := rclient.NewConnection(request.Source.ID, &request.Source.URL)
+	conn, err := rclient.NewConnection(source.ID, &source.URL, rclient.WithHeaders(headers))
 	if err != nil {
-		return nil, fmt.Errorf("creating connection to %s: %w", request.Source.ID.DID(), err)
+		return nil, fmt.Errorf("creating connection to %s: %w", source.ID.DID(), err)
 	}
 
 	replicaExecResp, replicaResp, err := rclient.Execute(ctx, inv, conn)
 	if err != nil {
 		return nil, fmt.Errorf("executing %s invocation: %w", blob.RetrieveAbility, err)
 	}
-	defer replicaResp.Body().Close()
 
 	rcptLink, ok := replicaExecResp.Get(inv.Link())
 	if !ok {
+		replicaResp.Body().Close()
 		return nil, fmt.Errorf("missing %s receipt: %s", blob.RetrieveAbility, inv.Link())
 	}
 
 	rcptReader, err := blob.NewRetrieveReceiptReader()
 	if err != nil {
+		replicaResp.Body().Close()
 		return nil, err
 	}
 
 	rcpt, err := rcptReader.Read(rcptLink, replicaExecResp.Blocks())
 	if err != nil {
+		replicaResp.Body().Close()
 		return nil, fmt.Errorf("reading %s receipt: %w", blob.RetrieveAbility, err)
 	}
 
 	_, x := result.Unwrap(rcpt.Out())
 	if !errors.Is(x, blob.RetrieveError{}) {
-		return nil, fmt.Errorf("replication source (%s) returned failure in receipt: %w", request.Source.URL.String(), x)
+		replicaResp.Body().Close()
+		return nil, fmt.Errorf("replication source (%s) returned failure in receipt: %w", source.URL.String(), x)
 	}
 
 	// Verify status from source
 	if replicaResp.Status() >= 300 || replicaResp.Status() < 200 {
-		return nil, fmt.Errorf("replication source (%s) returned unexpected status: %d", request.Source.URL.String(), replicaResp.Status())
-	}
-
-	// Stream source to sink
-	req, err := http.NewRequest(http.MethodPut, request.Sink.String(), replicaResp.Body())
-	if err != nil {
-		return nil, fmt.Errorf("failed to create replication sink request: %w", err)
-	}
-	req.Header = replicaResp.Headers()
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"failed http PUT to replicate blob %s from %s to %s failed: %w",
-			request.Blob.Digest,
-			request.Source.URL.String(),
-			request.Sink.String(),
-			err,
-		)
-	}
-	defer res.Body.Close()
-
-	// Verify status
-	if res.StatusCode >= 300 || res.StatusCode < 200 {
-		topErr := fmt.Errorf(
-			"unsuccessful http PUT to replicate blob %s from %s to %s status code %d",
-			request.Blob.Digest,
-			request.Source.URL.String(),
-			request.Sink.String(),
-			res.StatusCode,
-		)
-		resData, err := io.ReadAll(res.Body)
-		if err != nil {
-			return nil, fmt.Errorf("%s failed to read replication sink response body: %w", topErr, err)
+		replicaResp.Body().Close()
+		return nil, &HTTPError{
+			URL:        source.URL.String(),
+			StatusCode: replicaResp.Status(),
+			Err:        fmt.Errorf("replication source (%s) returned unexpected status: %d", source.URL.String(), replicaResp.Status()),
 		}
-		return nil, fmt.Errorf("%s response body: %s", topErr, resData)
 	}
 
-	// Accept the blob
-	return blobhandler.Accept(ctx, service, &blobhandler.AcceptRequest{
-		Space: request.Space,
-		Blob:  request.Blob,
-		Put: blob.Promise{
-			UcanAwait: blob.Await{
-				Selector: ".out.ok",
-				Link:     request.Cause.Link(),
-			},
-		},
-		Cause: request.Cause.Link(),
-	})
+	return replicaResp, nil
 }
 
 // extractReplicaAllocateInvocation extracts the `blob/replica/allocate`
@@ -529,9 +704,9 @@ func createLocationAssertion(ctx context.Context, service TransferService, reque
 		assert.LocationCaveats{
 			Space:    request.Space,
 			Content:  types.FromHash(request.Blob.Digest),
-			Location: []url.URL{loc},
+			Location: service.Claims().Locations(loc),
 		},
-		delegation.WithNoExpiration(),
+		append([]delegation.Option{service.Claims().Expiration()}, hwsigner.ClaimOptions(service.ID())...)...,
 	)
 	if err != nil {
 		return nil, nil, fmt.Errorf("creating location commitment: %w", err)
@@ -580,8 +755,16 @@ func (f linksFact) ToIPLD() (map[string]ipld.Node, error) {
 	return m, nil
 }
 
-// sendMessageToUploadService sends the message containing invocations and receipts to the upload service
-func sendMessageToUploadService(ctx context.Context, service TransferService, rcpt receipt.AnyReceipt) error {
+// ConcludeService is what is needed to conclude a receipt with the upload
+// service.
+type ConcludeService interface {
+	ID() principal.Signer
+	UploadConnection() client.Connection
+}
+
+// SendReceipt sends the receipt to the upload service in a `ucan/conclude`
+// invocation.
+func SendReceipt(ctx context.Context, service ConcludeService, rcpt receipt.AnyReceipt) error {
 	var rcptBlocks []ipld.Block
 	var rcptBlockLinks linksFact
 	for b, err := range rcpt.Blocks() {
@@ -658,7 +841,7 @@ func SendFailureReceipt(ctx context.Context, service TransferService, request *T
 		return fmt.Errorf("failed to store failure receipt: %w", err)
 	}
 
-	if err := sendMessageToUploadService(ctx, service, rcpt); err != nil {
+	if err := SendReceipt(ctx, service, rcpt); err != nil {
 		return fmt.Errorf("sending failure receipt: %w", err)
 	}
 
@@ -0,0 +1,51 @@
+package replica
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/compression"
+)
+
+func TestDecompressBlob(t *testing.T) {
+	content := bytes.Repeat([]byte("replica"), 1000)
+	digest, err := multihash.Sum(content, multihash.SHA2_256, -1)
+	require.NoError(t, err)
+	blob := types.Blob{Digest: digest, Size: uint64(len(content))}
+
+	compress := func(t *testing.T, data []byte) io.Reader {
+		compressed, err := io.ReadAll(compression.NewZstdReader(io.NopCloser(bytes.NewReader(data))))
+		require.NoError(t, err)
+		return bytes.NewReader(compressed)
+	}
+
+	t.Run("matching content", func(t *testing.T) {
+		r, err := decompressBlob(compress(t, content), blob)
+		require.NoError(t, err)
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+	})
+
+	t.Run("mismatched content", func(t *testing.T) {
+		r, err := decompressBlob(compress(t, bytes.Repeat([]byte("REPLICA"), 1000)), blob)
+		require.NoError(t, err)
+		defer r.Close()
+		_, err = io.ReadAll(r)
+		require.ErrorContains(t, err, "does not match blob digest")
+	})
+
+	t.Run("oversized content", func(t *testing.T) {
+		r, err := decompressBlob(compress(t, append(bytes.Clone(content), 'x')), blob)
+		require.NoError(t, err)
+		defer r.Close()
+		_, err = io.ReadAll(r)
+		require.Error(t, err)
+	})
+}
@@ -5,10 +5,20 @@ import (
 	"github.com/storacha/go-ucanto/principal"
 	"github.com/storacha/go-ucanto/validator"
 
+	"github.com/storacha/piri/pkg/blocklist"
+	"github.com/storacha/piri/pkg/diskspace"
 	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/readonly"
+	"github.com/storacha/piri/pkg/service/admission"
 	"github.com/storacha/piri/pkg/service/blobs"
 	"github.com/storacha/piri/pkg/service/claims"
+	"github.com/storacha/piri/pkg/service/collector"
+	"github.com/storacha/piri/pkg/service/fetcher"
+	"github.com/storacha/piri/pkg/service/quota"
+	"github.com/storacha/piri/pkg/service/replicapolicy"
 	"github.com/storacha/piri/pkg/service/replicator"
+	"github.com/storacha/piri/pkg/service/usage"
+	"github.com/storacha/piri/pkg/storageclass"
 	"github.com/storacha/piri/pkg/store/receiptstore"
 )
 
@@ -25,8 +35,37 @@ type Service interface {
 	Receipts() receiptstore.ReceiptStore
 	// Replicator provides access to the replication service
 	Replicator() replicator.Replicator
+	// ReplicaPolicy decides which replica allocations are accepted, nil if
+	// all are.
+	ReplicaPolicy() *replicapolicy.Engine
+	// Fetcher fetches blobs from URLs for `blob/fetch` invocations, nil if
+	// fetching is disabled.
+	Fetcher() fetcher.Fetcher
 	// UploadConnection provides the connection details to an upload service
 	UploadConnection() client.Connection
 	// ClaimValidationContext provides the context required for validating UCANs.
 	ClaimValidationContext() validator.ClaimContext
+	// Quotas enforces per-space storage quotas, nil if quotas are not
+	// enforced.
+	Quotas() quota.Enforcer
+	// StorageClasses resolves and records the storage class of allocated
+	// blobs, nil if classes are not tracked.
+	StorageClasses() *storageclass.Manager
+	// Collector collects blobs no longer referenced by any space, nil if
+	// unreferenced blobs are not collected.
+	Collector() *collector.Service
+	// Admission checks allocations are economic to prove and store, nil if
+	// allocations are not checked.
+	Admission() *admission.Calculator
+	// Usage records the bytes allocated and removed in spaces, nil if usage
+	// is not tracked.
+	Usage() *usage.Tracker
+	// ReadOnly is the read-only mode of the node, nil if the node is never
+	// read-only.
+	ReadOnly() *readonly.Mode
+	// DiskSpace rejects allocations while the node's disks are nearly full,
+	// nil if disk space is not monitored.
+	DiskSpace() *diskspace.Monitor
+	// Blocklist refuses blocked content, nil if no content is blocked.
+	Blocklist() *blocklist.List
 }
@@ -18,6 +18,7 @@ import (
 
 	"github.com/storacha/piri/pkg/access"
 	"github.com/storacha/piri/pkg/presigner"
+	"github.com/storacha/piri/pkg/service/quota"
 	"github.com/storacha/piri/pkg/store/acceptancestore"
 	"github.com/storacha/piri/pkg/store/allocationstore"
 	"github.com/storacha/piri/pkg/store/blobstore"
@@ -50,6 +51,7 @@ type config struct {
 	indexingServiceProofs delegation.Proofs
 	replicatorDB          *sql.DB
 	claimCtx              validator.ClaimContext
+	quotas                quota.Enforcer
 }
 
 type Option func(*config) error
@@ -282,3 +284,11 @@ func WithClaimValidationContext(ctx validator.ClaimContext) Option {
 		return nil
 	}
 }
+
+// WithQuotas enforces per-space storage quotas on blob allocations.
+func WithQuotas(quotas quota.Enforcer) Option {
+	return func(c *config) error {
+		c.quotas = quotas
+		return nil
+	}
+}
@@ -1,10 +1,17 @@
 package storage
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/storacha/go-ucanto/server"
+	"github.com/storacha/go-ucanto/transport"
 	ucanhttp "github.com/storacha/go-ucanto/transport/http"
 	"github.com/storacha/piri/pkg/server/handler"
 )
@@ -28,11 +35,37 @@ func (srv *Server) RegisterRoutes(e *echo.Echo) {
 	e.POST("/piece/:cid", handler)
 }
 
-func NewHandler(server server.ServerView[server.Service]) handler.Func {
+// NewHandler creates a HTTP handler for UCAN agent messages. Agent messages
+// may contain many invocations, which are executed in parallel by a bounded
+// pool of workers, returning a receipt for each.
+func NewHandler(server server.ServerView[server.Service], options ...HandlerOption) handler.Func {
+	cfg := handlerConfig{
+		maxInvocations: DefaultMaxBatchInvocations,
+		maxConcurrency: DefaultMaxBatchConcurrency,
+		maxBodySize:    DefaultMaxBatchBodySize,
+	}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
 	return func(ctx handler.Context) error {
 		r := ctx.Request()
-		res, err := server.Request(r.Context(), ucanhttp.NewRequest(r.Body, r.Header))
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Response(), r.Body, cfg.maxBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				msg := fmt.Sprintf("agent message is larger than %d bytes", tooLarge.Limit)
+				return ctx.Stream(http.StatusRequestEntityTooLarge, "text/plain", strings.NewReader(msg))
+			}
+			return fmt.Errorf("reading UCAN request: %w", err)
+		}
+
+		res, err := handleRequest(r.Context(), server, body, r.Header, cfg)
 		if err != nil {
+			var tooMany ErrTooManyInvocations
+			if errors.As(err, &tooMany) {
+				return ctx.Stream(http.StatusRequestEntityTooLarge, "text/plain", strings.NewReader(tooMany.Error()))
+			}
 			return fmt.Errorf("handling UCAN request: %w", err)
 		}
 
@@ -46,3 +79,22 @@ func NewHandler(server server.ServerView[server.Service]) handler.Func {
 		return ctx.Stream(res.Status(), "", res.Body())
 	}
 }
+
+func handleRequest(ctx context.Context, srv server.ServerView[server.Service], body []byte, header http.Header, cfg handlerConfig) (transport.HTTPResponse, error) {
+	req := ucanhttp.NewRequest(bytes.NewReader(body), header)
+	accept, aerr := srv.Codec().Accept(req)
+	if aerr != nil {
+		// let the server respond with the appropriate error
+		return srv.Request(ctx, ucanhttp.NewRequest(bytes.NewReader(body), header))
+	}
+	msg, err := accept.Decoder().Decode(req)
+	if err != nil {
+		return srv.Request(ctx, ucanhttp.NewRequest(bytes.NewReader(body), header))
+	}
+
+	out, err := executeBatch(ctx, srv, msg, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return accept.Encoder().Encode(out)
+}
@@ -21,12 +21,22 @@ import (
 
 	"github.com/storacha/piri/lib/jobqueue"
 	"github.com/storacha/piri/lib/jobqueue/serializer"
+	"github.com/storacha/piri/pkg/blocklist"
 	"github.com/storacha/piri/pkg/database/sqlitedb"
+	"github.com/storacha/piri/pkg/diskspace"
 	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/readonly"
+	"github.com/storacha/piri/pkg/service/admission"
 	"github.com/storacha/piri/pkg/service/blobs"
 	"github.com/storacha/piri/pkg/service/claims"
+	"github.com/storacha/piri/pkg/service/collector"
+	"github.com/storacha/piri/pkg/service/fetcher"
+	"github.com/storacha/piri/pkg/service/quota"
+	"github.com/storacha/piri/pkg/service/replicapolicy"
 	"github.com/storacha/piri/pkg/service/replicator"
 	replicahandler "github.com/storacha/piri/pkg/service/storage/handlers/replica"
+	"github.com/storacha/piri/pkg/service/usage"
+	"github.com/storacha/piri/pkg/storageclass"
 	"github.com/storacha/piri/pkg/store/acceptancestore"
 	"github.com/storacha/piri/pkg/store/blobstore"
 	"github.com/storacha/piri/pkg/store/delegationstore"
@@ -41,6 +51,7 @@ type StorageService struct {
 	replicator    replicator.Replicator
 	uploadService client.Connection
 	claimCtx      validator.ClaimContext
+	quotas        quota.Enforcer
 	startFuncs    []func(ctx context.Context) error
 	closeFuncs    []func(ctx context.Context) error
 	io.Closer
@@ -50,6 +61,16 @@ func (s *StorageService) Replicator() replicator.Replicator {
 	return s.replicator
 }
 
+func (s *StorageService) ReplicaPolicy() *replicapolicy.Engine {
+	// This instance of the storage service accepts all replicas
+	return nil
+}
+
+func (s *StorageService) Fetcher() fetcher.Fetcher {
+	// This instance of the storage service does not fetch blobs from URLs
+	return nil
+}
+
 func (s *StorageService) UploadConnection() client.Connection {
 	return s.uploadService
 }
@@ -97,6 +118,45 @@ func (s *StorageService) ClaimValidationContext() validator.ClaimContext {
 	return s.claimCtx
 }
 
+func (s *StorageService) Quotas() quota.Enforcer {
+	return s.quotas
+}
+
+func (s *StorageService) StorageClasses() *storageclass.Manager {
+	// This instance of the storage service does not track storage classes
+	return nil
+}
+
+func (s *StorageService) Collector() *collector.Service {
+	// This instance of the storage service does not collect unreferenced blobs
+	return nil
+}
+
+func (s *StorageService) Admission() *admission.Calculator {
+	// This instance of the storage service does not check allocation costs
+	return nil
+}
+
+func (s *StorageService) Usage() *usage.Tracker {
+	// This instance of the storage service does not track space usage
+	return nil
+}
+
+func (s *StorageService) ReadOnly() *readonly.Mode {
+	// This instance of the storage service is never read-only
+	return nil
+}
+
+func (s *StorageService) DiskSpace() *diskspace.Monitor {
+	// This instance of the storage service does not monitor disk space
+	return nil
+}
+
+func (s *StorageService) Blocklist() *blocklist.List {
+	// This instance of the storage service blocks no content
+	return nil
+}
+
 var _ Service = (*StorageService)(nil)
 
 func New(uploadServiceConn client.Connection, opts ...Option) (*StorageService, error) {
@@ -261,7 +321,7 @@ func New(uploadServiceConn client.Connection, opts ...Option) (*StorageService,
 	}
 
 	// replicator does not require a PDP service, so we pass nil.
-	repl, err := replicator.New(id, nil, blobs, claims, receiptStore, uploadServiceConn, replicationQueue)
+	repl, err := replicator.New(id, nil, blobs, claims, receiptStore, uploadServiceConn, replicationQueue, replicahandler.NewSourceSelector(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating replicator service: %w", err)
 	}
@@ -311,5 +371,6 @@ func New(uploadServiceConn client.Connection, opts ...Option) (*StorageService,
 		replicator:    repl,
 		uploadService: uploadServiceConn,
 		claimCtx:      claimCtx,
+		quotas:        c.quotas,
 	}, nil
 }
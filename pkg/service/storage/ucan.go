@@ -15,8 +15,11 @@ func NewUCANServer(storageService Service, options ...server.Option) (server.Ser
 		ucan.WithAccessGrantMethod(storageService),
 		ucan.WithBlobAllocateMethod(storageService),
 		ucan.WithBlobAcceptMethod(storageService),
+		ucan.WithBlobFetchMethod(storageService),
+		ucan.WithBlobRemoveMethod(storageService),
 		ucan.WithPDPInfoMethod(storageService),
 		ucan.WithReplicaAllocateMethod(storageService),
+		ucan.WithUsageReportMethod(storageService),
 	)
 
 	return server.NewServer(storageService.ID(), options...)
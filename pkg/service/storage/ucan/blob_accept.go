@@ -16,6 +16,7 @@ import (
 	"github.com/storacha/piri/pkg/service/blobs"
 	"github.com/storacha/piri/pkg/service/claims"
 	blobhandler "github.com/storacha/piri/pkg/service/storage/handlers/blob"
+	"github.com/storacha/piri/pkg/storageclass"
 )
 
 type BlobAcceptService interface {
@@ -23,6 +24,8 @@ type BlobAcceptService interface {
 	PDP() pdp.PDP
 	Blobs() blobs.Blobs
 	Claims() claims.Claims
+	// StorageClasses is nil if storage classes are not tracked.
+	StorageClasses() *storageclass.Manager
 }
 
 func WithBlobAcceptMethod(storageService BlobAcceptService) server.Option {
@@ -44,12 +47,21 @@ func WithBlobAcceptMethod(storageService BlobAcceptService) server.Option {
 				// end UCAN Validation
 				//
 
-				resp, err := blobhandler.Accept(ctx, storageService, &blobhandler.AcceptRequest{
+				req := &blobhandler.AcceptRequest{
 					Space: cap.Nb().Space,
 					Blob:  cap.Nb().Blob,
 					Put:   cap.Nb().Put,
 					Cause: inv.Link(),
-				})
+				}
+				if classes := storageService.StorageClasses(); classes != nil {
+					class, err := blobhandler.AcceptedClass(ctx, classes, req)
+					if err != nil {
+						return nil, nil, err
+					}
+					req.Class = &class
+				}
+
+				resp, err := blobhandler.Accept(ctx, storageService, req)
 				if err != nil {
 					return nil, nil, err
 				}
@@ -2,18 +2,29 @@ package ucan
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/capabilities/types"
 	"github.com/storacha/go-ucanto/core/invocation"
 	"github.com/storacha/go-ucanto/core/receipt/fx"
 	"github.com/storacha/go-ucanto/core/result"
 	"github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/storacha/go-ucanto/did"
 	"github.com/storacha/go-ucanto/server"
 	"github.com/storacha/go-ucanto/ucan"
 
+	"github.com/storacha/piri/pkg/blocklist"
+	"github.com/storacha/piri/pkg/diskspace"
 	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/readonly"
+	"github.com/storacha/piri/pkg/service/admission"
 	"github.com/storacha/piri/pkg/service/blobs"
+	"github.com/storacha/piri/pkg/service/quota"
 	blobhandler "github.com/storacha/piri/pkg/service/storage/handlers/blob"
+	"github.com/storacha/piri/pkg/service/usage"
+	"github.com/storacha/piri/pkg/storageclass"
 )
 
 const maxUploadSize = 127 * (1 << 25)
@@ -21,6 +32,20 @@ const maxUploadSize = 127 * (1 << 25)
 type BlobAllocateService interface {
 	PDP() pdp.PDP
 	Blobs() blobs.Blobs
+	// Quotas is nil if quotas are not enforced.
+	Quotas() quota.Enforcer
+	// StorageClasses is nil if storage classes are not tracked.
+	StorageClasses() *storageclass.Manager
+	// Admission is nil if allocations are not checked to be economic.
+	Admission() *admission.Calculator
+	// Usage is nil if space usage is not tracked.
+	Usage() *usage.Tracker
+	// ReadOnly is nil if the node is never read-only.
+	ReadOnly() *readonly.Mode
+	// DiskSpace is nil if disk space is not monitored.
+	DiskSpace() *diskspace.Monitor
+	// Blocklist is nil if no content is blocked.
+	Blocklist() *blocklist.List
 }
 
 func WithBlobAllocateMethod(storageService BlobAllocateService) server.Option {
@@ -47,14 +72,13 @@ func WithBlobAllocateMethod(storageService BlobAllocateService) server.Option {
 				// end UCAN Validation
 				//
 
-				resp, err := blobhandler.Allocate(ctx, storageService, &blobhandler.AllocateRequest{
-					Space: cap.Nb().Space,
-					Blob:  cap.Nb().Blob,
-					Cause: inv.Link(),
-				})
+				resp, x, err := allocate(ctx, storageService, cap.Nb().Space, cap.Nb().Blob, inv)
 				if err != nil {
 					return nil, nil, err
 				}
+				if x != nil {
+					return result.Error[blob.AllocateOk, failure.IPLDBuilderFailure](x), nil, nil
+				}
 
 				return result.Ok[blob.AllocateOk, failure.IPLDBuilderFailure](
 					blob.AllocateOk{
@@ -66,3 +90,108 @@ func WithBlobAllocateMethod(storageService BlobAllocateService) server.Option {
 		),
 	)
 }
+
+// allocate allocates the blob in the space, in the storage class requested by
+// the invocation. Allocations the node refuses return a failure.
+func allocate(ctx context.Context, storageService BlobAllocateService, space did.DID, b types.Blob, inv invocation.Invocation) (*blobhandler.AllocateResponse, failure.IPLDBuilderFailure, error) {
+	// refuse new allocations while the node is read-only
+	if err := storageService.ReadOnly().Check(); err != nil {
+		var re *readonly.ServiceReadOnlyError
+		if errors.As(err, &re) {
+			return nil, re, nil
+		}
+		return nil, nil, err
+	}
+
+	// refuse new allocations while the node's disks are nearly full, the
+	// client may retry later or allocate on another node
+	if err := storageService.DiskSpace().Admit(); err != nil {
+		var de *diskspace.InsufficientDiskSpaceError
+		if errors.As(err, &de) {
+			return nil, de, nil
+		}
+		return nil, nil, err
+	}
+
+	// refuse content the operator has blocked
+	if err := storageService.Blocklist().Check(ctx, blocklist.Allocate, b.Digest); err != nil {
+		var be *blocklist.ContentBlockedError
+		if errors.As(err, &be) {
+			return nil, be, nil
+		}
+		return nil, nil, err
+	}
+
+	// resolve the storage class the blob is stored in, rejecting requests for
+	// classes the node does not offer
+	var class storageclass.Class
+	classes := storageService.StorageClasses()
+	if classes != nil {
+		var err error
+		class, err = classes.Resolve(ctx, space, storageclass.Requested(inv))
+		if err != nil {
+			var ue *storageclass.UnknownClassError
+			if errors.As(err, &ue) {
+				return nil, ue, nil
+			}
+			return nil, nil, fmt.Errorf("resolving storage class: %w", err)
+		}
+	}
+
+	// reject allocations costing more to prove and store than they earn, when
+	// configured to
+	if calc := storageService.Admission(); calc != nil {
+		if err := calc.Admit(ctx, space, b.Size); err != nil {
+			var ue *admission.UneconomicAllocationError
+			if errors.As(err, &ue) {
+				return nil, ue, nil
+			}
+			return nil, nil, fmt.Errorf("checking allocation admission: %w", err)
+		}
+	}
+
+	// reserve the blob size against the space's storage quota, the
+	// reservation is adjusted to the size actually allocated below
+	reserved := b.Size
+	quotas := storageService.Quotas()
+	if quotas != nil {
+		if err := quotas.ReserveStorage(ctx, space, reserved); err != nil {
+			var qe *quota.QuotaExceededError
+			if errors.As(err, &qe) {
+				return nil, qe, nil
+			}
+			return nil, nil, fmt.Errorf("reserving storage quota: %w", err)
+		}
+	}
+
+	resp, err := blobhandler.Allocate(ctx, storageService, &blobhandler.AllocateRequest{
+		Space: space,
+		Blob:  b,
+		Cause: inv.Link(),
+	})
+	if quotas != nil {
+		// nothing is allocated if the blob was already allocated in the
+		// space, or on error
+		allocated := uint64(0)
+		if err == nil {
+			allocated = resp.Size
+		}
+		if rerr := quotas.ReleaseStorage(ctx, space, reserved-allocated); rerr != nil {
+			log.Errorw("releasing storage quota", "space", space, "error", rerr)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if u := storageService.Usage(); u != nil && resp.Size > 0 {
+		if err := u.RecordAllocation(ctx, space, resp.Size, inv.Link()); err != nil {
+			log.Errorw("recording space usage", "space", space, "error", err)
+		}
+	}
+	if classes != nil {
+		if err := classes.Record(ctx, b.Digest, class.Name, b.Size); err != nil {
+			log.Errorw("recording storage class of blob", "blob", b.Digest, "class", class.Name, "error", err)
+		}
+	}
+	return resp, nil, nil
+}
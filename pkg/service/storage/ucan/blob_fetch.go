@@ -0,0 +1,135 @@
+package ucan
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/server"
+	"github.com/storacha/go-ucanto/ucan"
+
+	fetchcap "github.com/storacha/piri/pkg/capabilities/fetch"
+	"github.com/storacha/piri/pkg/service/fetcher"
+	fetchhandler "github.com/storacha/piri/pkg/service/storage/handlers/fetch"
+)
+
+// Time we allow ourselves to fetch the blob and conclude the accept task
+const fetchTimeout = 24 * time.Hour
+
+type BlobFetchService interface {
+	BlobAllocateService
+	ID() principal.Signer
+	// Fetcher is nil if fetching is disabled.
+	Fetcher() fetcher.Fetcher
+}
+
+// WithBlobFetchMethod handles `blob/fetch` invocations, which allocate a blob
+// like `blob/allocate` and have the node pull it from a URL. The blob is
+// accepted by a `blob/accept` task issued by the node, concluded once the
+// blob has been fetched and checked against its size and digest.
+func WithBlobFetchMethod(storageService BlobFetchService) server.Option {
+	return server.WithServiceMethod(
+		fetchcap.FetchAbility,
+		server.Provide(
+			fetchcap.Fetch,
+			func(ctx context.Context, cap ucan.Capability[fetchcap.FetchCaveats], inv invocation.Invocation, iCtx server.InvocationContext) (result.Result[fetchcap.FetchOk, failure.IPLDBuilderFailure], fx.Effects, error) {
+				//
+				// UCAN Validation
+				//
+
+				// only service principal can perform an allocation, and only
+				// nodes fetching blobs provide the capability
+				if cap.With() != iCtx.ID().DID().String() || storageService.Fetcher() == nil {
+					return result.Error[fetchcap.FetchOk, failure.IPLDBuilderFailure](NewUnsupportedCapabilityError(cap)), nil, nil
+				}
+
+				// enforce max upload size requirements
+				if cap.Nb().Blob.Size > maxUploadSize {
+					return result.Error[fetchcap.FetchOk, failure.IPLDBuilderFailure](NewBlobSizeLimitExceededError(cap.Nb().Blob.Size, maxUploadSize)), nil, nil
+				}
+
+				source := cap.Nb().URL
+				if source.Scheme != "http" && source.Scheme != "https" {
+					return result.Error[fetchcap.FetchOk, failure.IPLDBuilderFailure](NewUnsupportedFetchURLError(source)), nil, nil
+				}
+
+				//
+				// end UCAN Validation
+				//
+
+				resp, x, err := allocate(ctx, storageService, cap.Nb().Space, cap.Nb().Blob, inv)
+				if err != nil {
+					return nil, nil, err
+				}
+				if x != nil {
+					return result.Error[fetchcap.FetchOk, failure.IPLDBuilderFailure](x), nil, nil
+				}
+
+				// create the accept invocation: an fx of the fetch invocation
+				// receipt, concluded by the fetcher.
+				acceptInv, err := blob.Accept.Invoke(
+					storageService.ID(),
+					storageService.ID(),
+					storageService.ID().DID().String(),
+					blob.AcceptCaveats{
+						Space: cap.Nb().Space,
+						Blob:  cap.Nb().Blob,
+						Put: blob.Promise{
+							UcanAwait: blob.Await{
+								Selector: ".out.ok",
+								Link:     inv.Link(),
+							},
+						},
+					},
+					delegation.WithExpiration(
+						ucan.UTCUnixTimestamp(time.Now().Add(fetchTimeout).Unix()),
+					),
+				)
+				if err != nil {
+					return nil, nil, fmt.Errorf("creating %s invocation: %w", blob.AcceptAbility, err)
+				}
+
+				// a nil address means the blob is already stored on this node,
+				// and only needs accepting in the space
+				var sink *url.URL
+				var sinkHeaders http.Header
+				if resp.Address != nil {
+					sink = &resp.Address.URL
+					sinkHeaders = resp.Address.Headers
+				}
+				if err := storageService.Fetcher().Fetch(ctx, &fetchhandler.FetchRequest{
+					Space:       cap.Nb().Space,
+					Blob:        cap.Nb().Blob,
+					Source:      source,
+					Sink:        sink,
+					SinkHeaders: sinkHeaders,
+					Cause:       acceptInv,
+				}); err != nil {
+					return nil, nil, fmt.Errorf("enqueuing fetch task: %w", err)
+				}
+
+				return result.Ok[fetchcap.FetchOk, failure.IPLDBuilderFailure](
+					fetchcap.FetchOk{
+						Size: resp.Size,
+						Site: types.Promise{
+							UcanAwait: types.Await{
+								Selector: fetchcap.FetchSiteSelector,
+								Link:     acceptInv.Link(),
+							},
+						},
+					},
+				), fx.NewEffects(fx.WithFork(fx.FromInvocation(acceptInv))), nil
+			},
+		),
+	)
+}
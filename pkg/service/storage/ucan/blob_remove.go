@@ -0,0 +1,67 @@
+package ucan
+
+import (
+	"context"
+	"errors"
+
+	spaceblob "github.com/storacha/go-libstoracha/capabilities/space/blob"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/server"
+	"github.com/storacha/go-ucanto/ucan"
+
+	"github.com/storacha/piri/pkg/service/blobs"
+	"github.com/storacha/piri/pkg/service/claims"
+	"github.com/storacha/piri/pkg/service/collector"
+	"github.com/storacha/piri/pkg/service/quota"
+	blobhandler "github.com/storacha/piri/pkg/service/storage/handlers/blob"
+	"github.com/storacha/piri/pkg/service/usage"
+)
+
+type BlobRemoveService interface {
+	Blobs() blobs.Blobs
+	Claims() claims.Claims
+	// Quotas is nil if quotas are not enforced.
+	Quotas() quota.Enforcer
+	// Collector is nil if unreferenced blobs are not collected.
+	Collector() *collector.Service
+	// Usage is nil if space usage is not tracked.
+	Usage() *usage.Tracker
+}
+
+// WithBlobRemoveMethod handles space/blob/remove invocations, removing a blob
+// from the space the capability is on. The invocation must be authorized by
+// the space.
+func WithBlobRemoveMethod(storageService BlobRemoveService) server.Option {
+	return server.WithServiceMethod(
+		spaceblob.RemoveAbility,
+		server.Provide(
+			spaceblob.Remove,
+			func(ctx context.Context, cap ucan.Capability[spaceblob.RemoveCaveats], inv invocation.Invocation, iCtx server.InvocationContext) (result.Result[spaceblob.RemoveOk, failure.IPLDBuilderFailure], fx.Effects, error) {
+				space, err := did.Parse(cap.With())
+				if err != nil {
+					return result.Error[spaceblob.RemoveOk, failure.IPLDBuilderFailure](NewUnsupportedCapabilityError(cap)), nil, nil
+				}
+
+				resp, err := blobhandler.Remove(ctx, storageService, &blobhandler.RemoveRequest{
+					Space:  space,
+					Digest: cap.Nb().Digest,
+					Cause:  inv.Link(),
+				})
+				if err != nil {
+					if errors.Is(err, blobhandler.ErrNotRemovable) {
+						return result.Error[spaceblob.RemoveOk, failure.IPLDBuilderFailure](NewBlobNotRemovableError(err)), nil, nil
+					}
+					return nil, nil, err
+				}
+
+				return result.Ok[spaceblob.RemoveOk, failure.IPLDBuilderFailure](
+					spaceblob.RemoveOk{Size: resp.Size},
+				), nil, nil
+			},
+		),
+	)
+}
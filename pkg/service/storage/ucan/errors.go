@@ -2,10 +2,13 @@ package ucan
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/storacha/go-ucanto/core/ipld"
 	"github.com/storacha/go-ucanto/core/result/failure/datamodel"
 	"github.com/storacha/go-ucanto/ucan"
+
+	usagecap "github.com/storacha/piri/pkg/capabilities/usage"
 )
 
 type UnsupportedCapabilityError[C any] struct {
@@ -76,3 +79,70 @@ func (ae AllocatedMemoryNotWrittenError) ToIPLD() (ipld.Node, error) {
 func NewAllocatedMemoryNotWrittenError() AllocatedMemoryNotWrittenError {
 	return AllocatedMemoryNotWrittenError{}
 }
+
+type BlobNotRemovableError struct {
+	message string
+}
+
+func (be BlobNotRemovableError) Name() string {
+	return "BlobNotRemovable"
+}
+
+func (be BlobNotRemovableError) Error() string {
+	return be.message
+}
+
+func (be BlobNotRemovableError) ToIPLD() (ipld.Node, error) {
+	name := be.Name()
+	model := datamodel.FailureModel{Name: &name, Message: be.Error()}
+	return model.ToIPLD()
+}
+
+func NewBlobNotRemovableError(cause error) BlobNotRemovableError {
+	return BlobNotRemovableError{fmt.Sprintf("%s, retry later", cause)}
+}
+
+type UnsupportedFetchURLError struct {
+	url string
+}
+
+func (ue UnsupportedFetchURLError) Name() string {
+	return "UnsupportedFetchURL"
+}
+
+func (ue UnsupportedFetchURLError) Error() string {
+	return fmt.Sprintf("blobs can only be fetched from http and https URLs: %s", ue.url)
+}
+
+func (ue UnsupportedFetchURLError) ToIPLD() (ipld.Node, error) {
+	name := ue.Name()
+	model := datamodel.FailureModel{Name: &name, Message: ue.Error()}
+	return model.ToIPLD()
+}
+
+func NewUnsupportedFetchURLError(u url.URL) UnsupportedFetchURLError {
+	return UnsupportedFetchURLError{u.Redacted()}
+}
+
+type InvalidUsagePeriodError struct {
+	from int64
+	to   int64
+}
+
+func (ie InvalidUsagePeriodError) Name() string {
+	return "InvalidUsagePeriod"
+}
+
+func (ie InvalidUsagePeriodError) Error() string {
+	return fmt.Sprintf("usage period must end after it starts: from %d, to %d", ie.from, ie.to)
+}
+
+func (ie InvalidUsagePeriodError) ToIPLD() (ipld.Node, error) {
+	name := ie.Name()
+	model := datamodel.FailureModel{Name: &name, Message: ie.Error()}
+	return model.ToIPLD()
+}
+
+func NewInvalidUsagePeriodError(period usagecap.Period) InvalidUsagePeriodError {
+	return InvalidUsagePeriodError{period.From, period.To}
+}
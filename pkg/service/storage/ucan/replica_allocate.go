@@ -2,6 +2,7 @@ package ucan
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"time"
@@ -19,8 +20,12 @@ import (
 	"github.com/storacha/go-ucanto/server"
 	"github.com/storacha/go-ucanto/ucan"
 
+	"github.com/storacha/piri/pkg/blocklist"
+	"github.com/storacha/piri/pkg/diskspace"
 	"github.com/storacha/piri/pkg/pdp"
+	"github.com/storacha/piri/pkg/readonly"
 	"github.com/storacha/piri/pkg/service/blobs"
+	"github.com/storacha/piri/pkg/service/replicapolicy"
 	"github.com/storacha/piri/pkg/service/replicator"
 	blobhandler "github.com/storacha/piri/pkg/service/storage/handlers/blob"
 	replicahandler "github.com/storacha/piri/pkg/service/storage/handlers/replica"
@@ -34,6 +39,14 @@ type ReplicaAllocateService interface {
 	PDP() pdp.PDP
 	Blobs() blobs.Blobs
 	Replicator() replicator.Replicator
+	// ReplicaPolicy is nil if all replicas are accepted.
+	ReplicaPolicy() *replicapolicy.Engine
+	// ReadOnly is nil if the node is never read-only.
+	ReadOnly() *readonly.Mode
+	// DiskSpace is nil if disk space is not monitored.
+	DiskSpace() *diskspace.Monitor
+	// Blocklist is nil if no content is blocked.
+	Blocklist() *blocklist.List
 }
 
 func WithReplicaAllocateMethod(storageService ReplicaAllocateService) server.Option {
@@ -55,6 +68,33 @@ func WithReplicaAllocateMethod(storageService ReplicaAllocateService) server.Opt
 				// end UCAN Validation
 				//
 
+				// refuse new replicas while the node is read-only
+				if err := storageService.ReadOnly().Check(); err != nil {
+					var re *readonly.ServiceReadOnlyError
+					if errors.As(err, &re) {
+						return result.Error[replica.AllocateOk, failure.IPLDBuilderFailure](re), nil, nil
+					}
+					return nil, nil, err
+				}
+
+				// refuse new replicas while the node's disks are nearly full
+				if err := storageService.DiskSpace().Admit(); err != nil {
+					var de *diskspace.InsufficientDiskSpaceError
+					if errors.As(err, &de) {
+						return result.Error[replica.AllocateOk, failure.IPLDBuilderFailure](de), nil, nil
+					}
+					return nil, nil, err
+				}
+
+				// refuse replicas of content the operator has blocked
+				if err := storageService.Blocklist().Check(ctx, blocklist.Replicate, cap.Nb().Blob.Digest); err != nil {
+					var be *blocklist.ContentBlockedError
+					if errors.As(err, &be) {
+						return result.Error[replica.AllocateOk, failure.IPLDBuilderFailure](be), nil, nil
+					}
+					return nil, nil, err
+				}
+
 				// read the location claim from this invocation to obtain the DID of the URL
 				// to replicate from on the primary storage node.
 				br, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(inv.Blocks()))
@@ -77,8 +117,25 @@ func WithReplicaAllocateMethod(storageService ReplicaAllocateService) server.Opt
 					return nil, nil, fmt.Errorf("URI missing in location commitment")
 				}
 
-				// TODO: which one do we pick if > 1?
-				replicaAddress := lc.Location[0]
+				// reject replicas the operator's policy does not accept, and
+				// only transfer from the locations it allows
+				locations := lc.Location
+				if policy := storageService.ReplicaPolicy(); policy != nil {
+					allowed, err := policy.Evaluate(ctx, replicapolicy.Request{
+						Space:     cap.Nb().Space,
+						Blob:      cap.Nb().Blob,
+						Source:    claim.Issuer().DID(),
+						Locations: lc.Location,
+					})
+					if err != nil {
+						var re *replicapolicy.RejectedError
+						if errors.As(err, &re) {
+							return result.Error[replica.AllocateOk, failure.IPLDBuilderFailure](re), nil, nil
+						}
+						return nil, nil, fmt.Errorf("evaluating replica policy: %w", err)
+					}
+					locations = allowed
+				}
 
 				resp, err := blobhandler.Allocate(ctx, storageService, &blobhandler.AllocateRequest{
 					Space: cap.Nb().Space,
@@ -134,12 +191,19 @@ func WithReplicaAllocateMethod(storageService ReplicaAllocateService) server.Opt
 
 				// will run replication async, sending the receipt of the transfer invocation
 				// to the upload service.
+				// all locations in the claim are candidate sources, the replicator
+				// picks the best of them and fails over to the others.
+				sources := make([]replicahandler.TransferSource, 0, len(locations))
+				for _, loc := range locations {
+					sources = append(sources, replicahandler.TransferSource{ID: claim.Issuer(), URL: loc})
+				}
 				if err := storageService.Replicator().Replicate(ctx, &replicahandler.TransferRequest{
-					Space:  cap.Nb().Space,
-					Blob:   cap.Nb().Blob,
-					Source: replicahandler.TransferSource{ID: claim.Issuer(), URL: replicaAddress},
-					Sink:   sink,
-					Cause:  trnsfInv,
+					Space:        cap.Nb().Space,
+					Blob:         cap.Nb().Blob,
+					Source:       sources[0],
+					Alternatives: sources[1:],
+					Sink:         sink,
+					Cause:        trnsfInv,
 				}); err != nil {
 					return nil, nil, fmt.Errorf("failed to enqueue replication task: %w", err)
 				}
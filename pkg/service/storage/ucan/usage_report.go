@@ -0,0 +1,71 @@
+package ucan
+
+import (
+	"context"
+	"time"
+
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/receipt/fx"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/storacha/go-ucanto/server"
+	"github.com/storacha/go-ucanto/ucan"
+
+	usagecap "github.com/storacha/piri/pkg/capabilities/usage"
+	"github.com/storacha/piri/pkg/service/usage"
+)
+
+type UsageReportService interface {
+	// Usage is nil if space usage is not tracked.
+	Usage() *usage.Tracker
+}
+
+// WithUsageReportMethod handles `usage/report` invocations, reporting the
+// bytes a space stored on the node over a period, and the allocations and
+// removals that changed it.
+func WithUsageReportMethod(storageService UsageReportService) server.Option {
+	return server.WithServiceMethod(
+		usagecap.ReportAbility,
+		server.Provide(
+			usagecap.Report,
+			func(ctx context.Context, cap ucan.Capability[usagecap.ReportCaveats], inv invocation.Invocation, iCtx server.InvocationContext) (result.Result[usagecap.ReportOk, failure.IPLDBuilderFailure], fx.Effects, error) {
+				// only the service principal can report usage, and only nodes
+				// tracking usage provide the capability
+				tracker := storageService.Usage()
+				if cap.With() != iCtx.ID().DID().String() || tracker == nil {
+					return result.Error[usagecap.ReportOk, failure.IPLDBuilderFailure](NewUnsupportedCapabilityError(cap)), nil, nil
+				}
+
+				period := cap.Nb().Period
+				if period.From >= period.To {
+					return result.Error[usagecap.ReportOk, failure.IPLDBuilderFailure](NewInvalidUsagePeriodError(period)), nil, nil
+				}
+
+				report, err := tracker.Report(ctx, cap.Nb().Space, time.Unix(period.From, 0), time.Unix(period.To, 0))
+				if err != nil {
+					return nil, nil, err
+				}
+
+				events := make([]usagecap.Event, 0, len(report.Events))
+				for _, e := range report.Events {
+					events = append(events, usagecap.Event{
+						Cause:     e.Cause,
+						Delta:     e.Delta,
+						ReceiptAt: e.At.Unix(),
+					})
+				}
+				return result.Ok[usagecap.ReportOk, failure.IPLDBuilderFailure](
+					usagecap.ReportOk{
+						Space:  cap.Nb().Space,
+						Period: period,
+						Size: usagecap.Size{
+							Initial: report.Initial,
+							Final:   report.Final,
+						},
+						Events: events,
+					},
+				), nil, nil
+			},
+		),
+	)
+}
@@ -0,0 +1,73 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	leveldb "github.com/ipfs/go-ds-leveldb"
+	"github.com/raulk/clock"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/service/quota"
+)
+
+var Module = fx.Module("usage",
+	fx.Provide(
+		NewTrackerFromParams,
+	),
+)
+
+type Params struct {
+	fx.In
+
+	UCANCfg    app.UCANServiceConfig
+	StorageCfg app.StorageConfig
+	Quotas     *quota.Manager
+	Clock      clock.Clock
+}
+
+// NewTrackerFromParams creates the usage tracker, reading the size of spaces
+// from their storage quota. Events and snapshots are persisted in the data
+// directory, or kept in memory without one.
+func NewTrackerFromParams(lc fx.Lifecycle, params Params) (*Tracker, error) {
+	var ds datastore.Batching
+	if params.StorageCfg.DataDir == "" {
+		log.Warn("no data dir configured, space usage will not persist across restarts")
+		ds = sync.MutexWrap(datastore.NewMapDatastore())
+	} else {
+		dir := filepath.Join(params.StorageCfg.DataDir, DataDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("creating directory: %s: %w", dir, err)
+		}
+		ldb, err := leveldb.NewDatastore(dir, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating usage store: %w", err)
+		}
+		ds = ldb
+	}
+
+	tracker := New(ds, params.Quotas,
+		WithClock(params.Clock),
+		WithSnapshotInterval(params.UCANCfg.Usage.SnapshotInterval),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return tracker.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			if err := tracker.Stop(ctx); err != nil {
+				return err
+			}
+			return ds.Close()
+		},
+	})
+	return tracker, nil
+}
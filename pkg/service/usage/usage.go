@@ -0,0 +1,406 @@
+// Package usage accounts for the bytes each space stores on the node over
+// time.
+//
+// Every allocation and removal of a blob in a space is recorded as an event
+// changing the size of the space, with the invocation that caused it. The
+// size of a space is not counted from the events but read from the storage
+// quota of the space, which every path allocating or releasing bytes
+// updates, e.g. the reaper of expired allocations. The size of every space is
+// snapshotted each snapshot interval, so the size of a space at any time is
+// the snapshot preceding it plus the events since.
+// Reports list the size of a space at the start and end of a period, and the
+// events in between, so the upload service can reconcile what it bills with
+// what the node stores.
+package usage
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+
+	"github.com/storacha/piri/pkg/service/quota"
+)
+
+var log = logging.Logger("usage")
+
+const (
+	// DataDir is the directory, relative to the data directory, holding usage
+	// events and snapshots.
+	DataDir = "usage"
+	// DefaultSnapshotInterval is how often the size of spaces is snapshotted
+	// if no interval is configured.
+	DefaultSnapshotInterval = time.Hour
+
+	// periodLayout formats the calendar month egress is counted in by the
+	// quota store, which sizes do not depend on.
+	periodLayout = "2006-01"
+
+	eventsPrefix    = "/events/"
+	snapshotsPrefix = "/snapshots/"
+)
+
+// Event is a change of the size of a space.
+type Event struct {
+	// Cause is the invocation that allocated or removed the bytes.
+	Cause ucan.Link
+	// Delta is the number of bytes allocated, negative if removed.
+	Delta int64
+	At    time.Time
+}
+
+// Snapshot is the size of a space at a point in time.
+type Snapshot struct {
+	Space did.DID
+	Size  uint64
+	At    time.Time
+}
+
+// Report is the usage of a space in the period from From to To. Events at
+// From are in the period, events at To are not.
+type Report struct {
+	Space did.DID
+	From  time.Time
+	To    time.Time
+	// Initial is the size of the space at From.
+	Initial uint64
+	// Final is the size of the space at To.
+	Final  uint64
+	Events []Event
+}
+
+// SizeSource reads the bytes spaces store.
+type SizeSource interface {
+	Usage(ctx context.Context, space did.DID) (quota.Usage, error)
+	ListUsage(ctx context.Context, period string) (map[did.DID]quota.Usage, error)
+}
+
+var _ SizeSource = (*quota.Manager)(nil)
+
+// Tracker persists the events and snapshots of the size of spaces in a
+// datastore.
+type Tracker struct {
+	ds       datastore.Datastore
+	sizes    SizeSource
+	clock    clock.Clock
+	interval time.Duration
+
+	// mu serializes the ordering of events and snapshots.
+	mu     sync.Mutex
+	last   int64
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Option configures a Tracker.
+type Option func(*Tracker)
+
+// WithClock sets the clock events and snapshots are timed by. Defaults to the
+// real clock.
+func WithClock(clock clock.Clock) Option {
+	return func(t *Tracker) {
+		t.clock = clock
+	}
+}
+
+// WithSnapshotInterval sets how often the size of spaces is snapshotted.
+func WithSnapshotInterval(interval time.Duration) Option {
+	return func(t *Tracker) {
+		if interval > 0 {
+			t.interval = interval
+		}
+	}
+}
+
+// New creates a Tracker persisting events and snapshots in ds, with the size
+// of spaces read from sizes.
+func New(ds datastore.Datastore, sizes SizeSource, opts ...Option) *Tracker {
+	t := &Tracker{
+		ds:       ds,
+		sizes:    sizes,
+		clock:    clock.New(),
+		interval: DefaultSnapshotInterval,
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Start snapshots the size of spaces every interval until stopped.
+func (t *Tracker) Start(ctx context.Context) error {
+	ctx, t.cancel = context.WithCancel(ctx)
+	go t.run(ctx)
+	return nil
+}
+
+// Stop stops snapshotting.
+func (t *Tracker) Stop(ctx context.Context) error {
+	if t.cancel == nil {
+		return nil
+	}
+	t.cancel()
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for usage tracker to stop: %w", ctx.Err())
+	}
+}
+
+func (t *Tracker) run(ctx context.Context) {
+	defer close(t.done)
+	ticker := t.clock.Ticker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := t.Snapshot(ctx); err != nil && ctx.Err() == nil {
+			log.Errorw("snapshotting space usage", "error", err)
+		}
+	}
+}
+
+// RecordAllocation records size bytes allocated in a space.
+func (t *Tracker) RecordAllocation(ctx context.Context, space did.DID, size uint64, cause ucan.Link) error {
+	return t.record(ctx, space, int64(size), cause)
+}
+
+// RecordRemoval records size bytes removed from a space.
+func (t *Tracker) RecordRemoval(ctx context.Context, space did.DID, size uint64, cause ucan.Link) error {
+	return t.record(ctx, space, -int64(size), cause)
+}
+
+type eventRecord struct {
+	Cause string `json:"cause"`
+	Delta int64  `json:"delta"`
+}
+
+func (t *Tracker) record(ctx context.Context, space did.DID, delta int64, cause ucan.Link) error {
+	if delta == 0 {
+		return nil
+	}
+	data, err := json.Marshal(eventRecord{Cause: cause.String(), Delta: delta})
+	if err != nil {
+		return fmt.Errorf("encoding usage event: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// events are keyed by time, keep them unique and ordered if the clock
+	// does not advance between them
+	at := t.clock.Now().UnixNano()
+	if at <= t.last {
+		at = t.last + 1
+	}
+	t.last = at
+
+	if err := t.ds.Put(ctx, eventKey(space, at), data); err != nil {
+		return fmt.Errorf("putting usage event: %w", err)
+	}
+	return nil
+}
+
+// Size returns the bytes a space stores.
+func (t *Tracker) Size(ctx context.Context, space did.DID) (uint64, error) {
+	u, err := t.sizes.Usage(ctx, space)
+	if err != nil {
+		return 0, fmt.Errorf("getting space size: %w", err)
+	}
+	return u.Storage, nil
+}
+
+// Sizes returns the bytes stored by every space that stores any.
+func (t *Tracker) Sizes(ctx context.Context) (map[did.DID]uint64, error) {
+	usage, err := t.sizes.ListUsage(ctx, t.clock.Now().UTC().Format(periodLayout))
+	if err != nil {
+		return nil, fmt.Errorf("listing space sizes: %w", err)
+	}
+	out := map[did.DID]uint64{}
+	for space, u := range usage {
+		if u.Storage > 0 {
+			out[space] = u.Storage
+		}
+	}
+	return out, nil
+}
+
+// Snapshot persists the size of every space at the current time. An
+// allocation whose bytes are reserved before the snapshot and whose event is
+// recorded after it counts twice in reports spanning both, until the next
+// snapshot.
+func (t *Tracker) Snapshot(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sizes, err := t.Sizes(ctx)
+	if err != nil {
+		return err
+	}
+	// the sizes include the bytes of every event recorded so far
+	at := t.clock.Now().UnixNano()
+	if at <= t.last {
+		at = t.last + 1
+	}
+	t.last = at
+	for space, size := range sizes {
+		if err := t.ds.Put(ctx, snapshotKey(space, at), binary.BigEndian.AppendUint64(nil, size)); err != nil {
+			return fmt.Errorf("putting usage snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// Snapshots returns the snapshots of a space taken from from until to, in the
+// order they were taken.
+func (t *Tracker) Snapshots(ctx context.Context, space did.DID, from, to time.Time) ([]Snapshot, error) {
+	var out []Snapshot
+	err := t.entries(ctx, snapshotsPrefix+space.String()+"/", func(at int64, value []byte) (bool, error) {
+		if at >= to.UnixNano() {
+			return false, nil
+		}
+		if at < from.UnixNano() {
+			return true, nil
+		}
+		if len(value) != 8 {
+			return false, fmt.Errorf("invalid snapshot value for %s", space)
+		}
+		out = append(out, Snapshot{Space: space, Size: binary.BigEndian.Uint64(value), At: time.Unix(0, at).UTC()})
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Report returns the usage of a space in the period from from to to. The
+// size at the start of the period is the latest snapshot taken before it,
+// plus the events since.
+func (t *Tracker) Report(ctx context.Context, space did.DID, from, to time.Time) (Report, error) {
+	if !from.Before(to) {
+		return Report{}, fmt.Errorf("invalid period: %s is not before %s", from, to)
+	}
+
+	var base uint64
+	var baseAt int64 = -1
+	err := t.entries(ctx, snapshotsPrefix+space.String()+"/", func(at int64, value []byte) (bool, error) {
+		if at >= from.UnixNano() {
+			return false, nil
+		}
+		if len(value) != 8 {
+			return false, fmt.Errorf("invalid snapshot value for %s", space)
+		}
+		base, baseAt = binary.BigEndian.Uint64(value), at
+		return true, nil
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Space: space, From: from.UTC(), To: to.UTC(), Initial: base}
+	err = t.entries(ctx, eventsPrefix+space.String()+"/", func(at int64, value []byte) (bool, error) {
+		if at >= to.UnixNano() {
+			return false, nil
+		}
+		if at <= baseAt {
+			return true, nil
+		}
+		var rec eventRecord
+		if err := json.Unmarshal(value, &rec); err != nil {
+			return false, fmt.Errorf("decoding usage event: %w", err)
+		}
+		if at < from.UnixNano() {
+			report.Initial = apply(report.Initial, rec.Delta)
+			return true, nil
+		}
+		cause, err := cid.Parse(rec.Cause)
+		if err != nil {
+			return false, fmt.Errorf("parsing cause of usage event: %w", err)
+		}
+		report.Events = append(report.Events, Event{
+			Cause: cidlink.Link{Cid: cause},
+			Delta: rec.Delta,
+			At:    time.Unix(0, at).UTC(),
+		})
+		return true, nil
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	report.Final = report.Initial
+	for _, e := range report.Events {
+		report.Final = apply(report.Final, e.Delta)
+	}
+	return report, nil
+}
+
+// entries calls fn with the time and value of the entries under prefix, in
+// time order, until it returns false.
+func (t *Tracker) entries(ctx context.Context, prefix string, fn func(at int64, value []byte) (bool, error)) error {
+	results, err := t.ds.Query(ctx, query.Query{Prefix: prefix, Orders: []query.Order{query.OrderByKey{}}})
+	if err != nil {
+		return fmt.Errorf("querying usage: %w", err)
+	}
+	defer results.Close()
+
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return fmt.Errorf("iterating usage: %w", entry.Error)
+		}
+		at, err := strconv.ParseInt(strings.TrimPrefix(entry.Key, prefix), 10, 64)
+		if err != nil {
+			log.Warnw("skipping usage entry with invalid time", "key", entry.Key, "error", err)
+			continue
+		}
+		more, err := fn(at, entry.Value)
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+	return nil
+}
+
+// apply changes size by delta, never below 0.
+func apply(size uint64, delta int64) uint64 {
+	if delta < 0 {
+		return size - min(size, uint64(-delta))
+	}
+	return size + uint64(delta)
+}
+
+// timeKey formats times as zero padded nanoseconds, so keys sort in time
+// order.
+func timeKey(at int64) string {
+	return fmt.Sprintf("%020d", at)
+}
+
+func eventKey(space did.DID, at int64) datastore.Key {
+	return datastore.NewKey(eventsPrefix + space.String() + "/" + timeKey(at))
+}
+
+func snapshotKey(space did.DID, at int64) datastore.Key {
+	return datastore.NewKey(snapshotsPrefix + space.String() + "/" + timeKey(at))
+}
@@ -0,0 +1,113 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/raulk/clock"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/service/quota"
+)
+
+// allocate reserves size bytes in the quota of space and records the
+// allocation, as blob/allocate does.
+func allocate(t *testing.T, quotas *quota.Manager, tracker *Tracker, space did.DID, size uint64, cause ucan.Link) {
+	require.NoError(t, quotas.ReserveStorage(t.Context(), space, size))
+	require.NoError(t, tracker.RecordAllocation(t.Context(), space, size, cause))
+}
+
+// remove releases size bytes from the quota of space and records the
+// removal, as space/blob/remove does.
+func remove(t *testing.T, quotas *quota.Manager, tracker *Tracker, space did.DID, size uint64, cause ucan.Link) {
+	require.NoError(t, quotas.ReleaseStorage(t.Context(), space, size))
+	require.NoError(t, tracker.RecordRemoval(t.Context(), space, size, cause))
+}
+
+func TestSizes(t *testing.T) {
+	quotas := quota.NewManager(datastore.NewMapDatastore())
+	tracker := New(sync.MutexWrap(datastore.NewMapDatastore()), quotas, WithClock(clock.NewMock()))
+	space := testutil.RandomDID(t)
+	other := testutil.RandomDID(t)
+
+	allocate(t, quotas, tracker, space, 100, testutil.RandomCID(t))
+	allocate(t, quotas, tracker, space, 50, testutil.RandomCID(t))
+	allocate(t, quotas, tracker, other, 10, testutil.RandomCID(t))
+	remove(t, quotas, tracker, space, 30, testutil.RandomCID(t))
+
+	size, err := tracker.Size(t.Context(), space)
+	require.NoError(t, err)
+	require.Equal(t, uint64(120), size)
+
+	// bytes released without an event, e.g. by a path not recording usage,
+	// are still reflected
+	require.NoError(t, quotas.ReleaseStorage(t.Context(), other, 10))
+
+	sizes, err := tracker.Sizes(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, map[did.DID]uint64{space: 120}, sizes)
+}
+
+func TestReport(t *testing.T) {
+	clk := clock.NewMock()
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	clk.Set(start)
+	quotas := quota.NewManager(datastore.NewMapDatastore())
+	tracker := New(sync.MutexWrap(datastore.NewMapDatastore()), quotas, WithClock(clk))
+	space := testutil.RandomDID(t)
+
+	allocate(t, quotas, tracker, space, 100, testutil.RandomCID(t))
+	clk.Add(time.Hour)
+	require.NoError(t, tracker.Snapshot(t.Context()))
+	clk.Add(time.Hour)
+	allocate(t, quotas, tracker, space, 40, testutil.RandomCID(t))
+
+	// the period starts after the snapshot and the second allocation
+	from := clk.Now().Add(time.Minute)
+	clk.Add(time.Hour)
+	added := testutil.RandomCID(t)
+	allocate(t, quotas, tracker, space, 25, added)
+	clk.Add(time.Hour)
+	removed := testutil.RandomCID(t)
+	remove(t, quotas, tracker, space, 100, removed)
+	to := clk.Now().Add(time.Minute)
+
+	// outside the period
+	clk.Add(time.Hour)
+	allocate(t, quotas, tracker, space, 1000, testutil.RandomCID(t))
+
+	report, err := tracker.Report(t.Context(), space, from, to)
+	require.NoError(t, err)
+	require.Equal(t, uint64(140), report.Initial)
+	require.Equal(t, uint64(65), report.Final)
+	require.Len(t, report.Events, 2)
+	require.Equal(t, added.String(), report.Events[0].Cause.String())
+	require.Equal(t, int64(25), report.Events[0].Delta)
+	require.Equal(t, removed.String(), report.Events[1].Cause.String())
+	require.Equal(t, int64(-100), report.Events[1].Delta)
+
+	t.Run("without snapshot", func(t *testing.T) {
+		report, err := tracker.Report(t.Context(), space, start.Add(-time.Hour), start.Add(90*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), report.Initial)
+		require.Equal(t, uint64(100), report.Final)
+		require.Len(t, report.Events, 1)
+	})
+
+	t.Run("snapshots", func(t *testing.T) {
+		snapshots, err := tracker.Snapshots(t.Context(), space, start, clk.Now())
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		require.Equal(t, uint64(100), snapshots[0].Size)
+	})
+
+	t.Run("invalid period", func(t *testing.T) {
+		_, err := tracker.Report(t.Context(), space, to, from)
+		require.Error(t, err)
+	})
+}
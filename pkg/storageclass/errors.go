@@ -0,0 +1,35 @@
+package storageclass
+
+import (
+	"fmt"
+
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/result/failure/datamodel"
+)
+
+// UnknownClassErrorName is the name of the failure returned in receipts when
+// an allocation requests a class that is not configured.
+const UnknownClassErrorName = "UnknownStorageClass"
+
+// UnknownClassError is returned when a class is not configured on the node.
+type UnknownClassError struct {
+	Class string
+}
+
+func (ue UnknownClassError) Name() string {
+	return UnknownClassErrorName
+}
+
+func (ue UnknownClassError) Error() string {
+	return fmt.Sprintf("storage class %q is not configured", ue.Class)
+}
+
+func (ue UnknownClassError) ToIPLD() (ipld.Node, error) {
+	name := ue.Name()
+	model := datamodel.FailureModel{Name: &name, Message: ue.Error()}
+	return model.ToIPLD()
+}
+
+func NewUnknownClassError(class string) *UnknownClassError {
+	return &UnknownClassError{class}
+}
@@ -0,0 +1,68 @@
+package storageclass
+
+import (
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// FactKey is the key of the fact carrying the storage class, both in
+// blob/allocate invocations requesting a class and in location claims.
+const FactKey = "storage-class"
+
+// Fact records the class of a blob in its location claim.
+type Fact struct {
+	Class Class
+}
+
+var _ ucan.FactBuilder = Fact{}
+
+// ToIPLD implements [ucan.FactBuilder]. The fact is
+// {"storage-class": {"name": string, "replicas": int}}, with replicas omitted
+// if the class leaves it to the upload service.
+func (f Fact) ToIPLD() (map[string]ipld.Node, error) {
+	n, err := qp.BuildMap(basicnode.Prototype.Map, 2, func(ma ipld.MapAssembler) {
+		qp.MapEntry(ma, "name", qp.String(f.Class.Name))
+		if f.Class.Replicas > 0 {
+			qp.MapEntry(ma, "replicas", qp.Int(int64(f.Class.Replicas)))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]ipld.Node{FactKey: n}, nil
+}
+
+// Requested returns the class requested by a fact of the invocation, empty if
+// it requests none. The fact is either {"storage-class": string} or, as in
+// location claims, {"storage-class": {"name": string}}.
+func Requested(inv invocation.Invocation) string {
+	for _, fact := range inv.Facts() {
+		v, ok := fact[FactKey]
+		if !ok {
+			continue
+		}
+		if name := className(v); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func className(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case ipld.Node:
+		if s, err := v.AsString(); err == nil {
+			return s
+		}
+		if n, err := v.LookupByString("name"); err == nil {
+			s, _ := n.AsString()
+			return s
+		}
+	}
+	return ""
+}
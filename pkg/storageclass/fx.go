@@ -0,0 +1,55 @@
+package storageclass
+
+import (
+	"github.com/ipfs/go-datastore"
+	"github.com/storacha/go-ucanto/did"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/config/feature"
+	"github.com/storacha/piri/pkg/pdp/proofset"
+)
+
+var _ proofset.ClassResolver = (*Manager)(nil)
+
+// FeatureFlag guards storage classes. It is enabled by default, and can be
+// disabled or rolled out to a percentage of spaces, whose blobs are otherwise
+// stored in the standard class.
+var FeatureFlag = feature.Definition{
+	Name:        "storage-classes",
+	Description: "Store blobs in the storage class requested or assigned to their space",
+	Default:     true,
+	Rollout:     true,
+}
+
+var Module = fx.Module("storageclass",
+	fx.Provide(
+		fx.Annotate(
+			NewManagerFromParams,
+			fx.As(fx.Self()),
+			fx.As(new(proofset.ClassResolver)),
+		),
+		fx.Annotate(
+			func() feature.Definition { return FeatureFlag },
+			fx.ResultTags(`group:"feature_flags"`),
+		),
+	),
+)
+
+type Params struct {
+	fx.In
+
+	Datastore datastore.Datastore `name:"storageclass_datastore"`
+	Config    app.UCANServiceConfig
+	Features  *feature.Flags
+}
+
+func NewManagerFromParams(params Params) (*Manager, error) {
+	return NewManager(
+		params.Datastore,
+		params.Config.StorageClasses,
+		WithEnabled(func(space did.DID) bool {
+			return params.Features.EnabledFor(FeatureFlag.Name, space.String())
+		}),
+	)
+}
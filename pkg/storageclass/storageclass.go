@@ -0,0 +1,363 @@
+// Package storageclass assigns the blobs stored by the node to storage
+// classes, such as "standard", "archive" or "hot", which differ in how the
+// blobs are stored.
+//
+// A class sets the replication factor advertised for its blobs and the proof
+// sets their aggregates are added to. Blobs are aggregated with blobs of the
+// same class only, so every aggregate belongs to a single class.
+//
+// The class of a blob is chosen when it is allocated: the class requested by a
+// fact of the blob/allocate invocation, else the class assigned to the space by
+// the node operator, else the configured default. The class is recorded in the
+// location claim of the blob, and the bytes allocated in each class are
+// counted for usage reporting.
+package storageclass
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/did"
+
+	"github.com/storacha/piri/pkg/config/app"
+	aggtypes "github.com/storacha/piri/pkg/pdp/aggregation/types"
+)
+
+var log = logging.Logger("storageclass")
+
+// Standard is the class that exists even if no class is configured.
+const Standard = "standard"
+
+const (
+	spacesPrefix     = "/spaces/"
+	blobsPrefix      = "/blobs/"
+	usagePrefix      = "/usage/"
+	aggregatesPrefix = "/aggregates/"
+)
+
+// Class is a storage class.
+type Class struct {
+	Name string `json:"name"`
+	// Replicas is the replication factor advertised for blobs of the class, 0
+	// to leave it to the upload service.
+	Replicas uint `json:"replicas,omitempty"`
+	// ProofSetClass restricts the aggregates of blobs of the class to proof
+	// sets of this class. Empty for any proof set.
+	ProofSetClass string `json:"proof_set_class,omitempty"`
+}
+
+// Usage is the blobs allocated in a class.
+type Usage struct {
+	Blobs uint64 `json:"blobs"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// Assignment is the class assigned to a space.
+type Assignment struct {
+	Space did.DID
+	Class string
+}
+
+// blobRecord is the class a blob was allocated in.
+type blobRecord struct {
+	Class string `json:"class"`
+	Size  uint64 `json:"size"`
+}
+
+// Manager resolves the class of allocated blobs and persists it, with the
+// classes assigned to spaces and the usage of every class, in a datastore.
+type Manager struct {
+	ds      datastore.Datastore
+	classes map[string]Class
+	// names are the class names, standard first and then as configured.
+	names []string
+	def   string
+	// enabled reports whether classes are applied to blobs of a space.
+	enabled func(space did.DID) bool
+	// mu serializes the read-modify-write of usage counters.
+	mu sync.Mutex
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithEnabled sets a function consulted when resolving the class of a blob,
+// e.g. a feature flag rolled out to spaces. Blobs allocated in spaces it
+// returns false for are stored in the standard class. Defaults to applying
+// classes to every space.
+func WithEnabled(enabled func(space did.DID) bool) Option {
+	return func(m *Manager) {
+		m.enabled = enabled
+	}
+}
+
+// NewManager creates a Manager with the configured classes, persisting
+// assignments and usage in ds.
+func NewManager(ds datastore.Datastore, cfg app.StorageClassesConfig, opts ...Option) (*Manager, error) {
+	m := &Manager{
+		ds:      ds,
+		classes: map[string]Class{Standard: {Name: Standard}},
+		names:   []string{Standard},
+		def:     Standard,
+		enabled: func(did.DID) bool { return true },
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	for _, c := range cfg.Classes {
+		if c.Name != Standard {
+			if _, ok := m.classes[c.Name]; ok {
+				return nil, fmt.Errorf("storage class %q configured more than once", c.Name)
+			}
+			m.names = append(m.names, c.Name)
+		}
+		m.classes[c.Name] = Class{
+			Name:          c.Name,
+			Replicas:      c.Replicas,
+			ProofSetClass: c.ProofSetClass,
+		}
+	}
+	if cfg.Default != "" {
+		if _, ok := m.classes[cfg.Default]; !ok {
+			return nil, fmt.Errorf("default storage class %q is not configured", cfg.Default)
+		}
+		m.def = cfg.Default
+	}
+	return m, nil
+}
+
+// Classes returns the configured classes, standard first.
+func (m *Manager) Classes() []Class {
+	classes := make([]Class, 0, len(m.names))
+	for _, name := range m.names {
+		classes = append(classes, m.classes[name])
+	}
+	return classes
+}
+
+// Class returns a configured class.
+func (m *Manager) Class(name string) (Class, bool) {
+	c, ok := m.classes[name]
+	return c, ok
+}
+
+// Default returns the class of blobs in spaces with no assigned class.
+func (m *Manager) Default() Class {
+	return m.classes[m.def]
+}
+
+// Resolve returns the class a blob allocated in the space is stored in. A
+// requested class that is not configured is an *UnknownClassError. Blobs of
+// spaces classes are not enabled for are stored in the standard class.
+func (m *Manager) Resolve(ctx context.Context, space did.DID, requested string) (Class, error) {
+	if !m.enabled(space) {
+		return m.classes[Standard], nil
+	}
+	if requested != "" {
+		c, ok := m.classes[requested]
+		if !ok {
+			return Class{}, NewUnknownClassError(requested)
+		}
+		return c, nil
+	}
+	name, ok, err := m.SpaceClass(ctx, space)
+	if err != nil {
+		return Class{}, err
+	}
+	if ok {
+		if c, ok := m.classes[name]; ok {
+			return c, nil
+		}
+		log.Warnw("space is assigned a storage class that is no longer configured, using the default", "space", space, "class", name)
+	}
+	return m.Default(), nil
+}
+
+// SetSpaceClass assigns a class to the blobs later allocated in the space. An
+// empty class removes the assignment.
+func (m *Manager) SetSpaceClass(ctx context.Context, space did.DID, class string) error {
+	key := datastore.NewKey(spacesPrefix + space.String())
+	if class == "" {
+		if err := m.ds.Delete(ctx, key); err != nil {
+			return fmt.Errorf("removing storage class of space %s: %w", space, err)
+		}
+		return nil
+	}
+	if _, ok := m.classes[class]; !ok {
+		return NewUnknownClassError(class)
+	}
+	if err := m.ds.Put(ctx, key, []byte(class)); err != nil {
+		return fmt.Errorf("setting storage class of space %s: %w", space, err)
+	}
+	return nil
+}
+
+// SpaceClass returns the class assigned to the space, if any.
+func (m *Manager) SpaceClass(ctx context.Context, space did.DID) (string, bool, error) {
+	data, err := m.ds.Get(ctx, datastore.NewKey(spacesPrefix+space.String()))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("getting storage class of space %s: %w", space, err)
+	}
+	return string(data), true, nil
+}
+
+// Assignments returns the spaces assigned a class, ordered by space.
+func (m *Manager) Assignments(ctx context.Context) ([]Assignment, error) {
+	results, err := m.ds.Query(ctx, query.Query{Prefix: spacesPrefix})
+	if err != nil {
+		return nil, fmt.Errorf("querying space storage classes: %w", err)
+	}
+	defer results.Close()
+
+	var assignments []Assignment
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating space storage classes: %w", entry.Error)
+		}
+		space, err := did.Parse(strings.TrimPrefix(entry.Key, spacesPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("parsing space of %s: %w", entry.Key, err)
+		}
+		assignments = append(assignments, Assignment{Space: space, Class: string(entry.Value)})
+	}
+	slices.SortFunc(assignments, func(a, b Assignment) int {
+		return strings.Compare(a.Space.String(), b.Space.String())
+	})
+	return assignments, nil
+}
+
+// Record records the class a blob was allocated in and counts its size in
+// the usage of the class. A blob keeps the class it was first recorded in.
+func (m *Manager) Record(ctx context.Context, digest multihash.Multihash, class string, size uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := blobKey(digest)
+	if _, err := m.ds.Get(ctx, key); err == nil {
+		return nil
+	} else if !errors.Is(err, datastore.ErrNotFound) {
+		return fmt.Errorf("getting storage class of blob: %w", err)
+	}
+	data, err := json.Marshal(blobRecord{Class: class, Size: size})
+	if err != nil {
+		return fmt.Errorf("encoding storage class of blob: %w", err)
+	}
+	if err := m.ds.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("putting storage class of blob: %w", err)
+	}
+
+	usage, err := m.usage(ctx, class)
+	if err != nil {
+		return err
+	}
+	usage.Blobs++
+	usage.Bytes += size
+	data, err = json.Marshal(usage)
+	if err != nil {
+		return fmt.Errorf("encoding usage of storage class %s: %w", class, err)
+	}
+	if err := m.ds.Put(ctx, datastore.NewKey(usagePrefix+class), data); err != nil {
+		return fmt.Errorf("putting usage of storage class %s: %w", class, err)
+	}
+	return nil
+}
+
+// ClassOf returns the class a blob was allocated in, if it was recorded.
+func (m *Manager) ClassOf(ctx context.Context, digest multihash.Multihash) (string, bool, error) {
+	data, err := m.ds.Get(ctx, blobKey(digest))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("getting storage class of blob: %w", err)
+	}
+	var rec blobRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return "", false, fmt.Errorf("decoding storage class of blob: %w", err)
+	}
+	return rec.Class, true, nil
+}
+
+// Usage returns the usage of every class blobs were allocated in, including
+// classes no longer configured.
+func (m *Manager) Usage(ctx context.Context) (map[string]Usage, error) {
+	results, err := m.ds.Query(ctx, query.Query{Prefix: usagePrefix})
+	if err != nil {
+		return nil, fmt.Errorf("querying storage class usage: %w", err)
+	}
+	defer results.Close()
+
+	usage := map[string]Usage{}
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating storage class usage: %w", entry.Error)
+		}
+		var u Usage
+		if err := json.Unmarshal(entry.Value, &u); err != nil {
+			return nil, fmt.Errorf("decoding usage of %s: %w", entry.Key, err)
+		}
+		usage[strings.TrimPrefix(entry.Key, usagePrefix)] = u
+	}
+	return usage, nil
+}
+
+func (m *Manager) usage(ctx context.Context, class string) (Usage, error) {
+	data, err := m.ds.Get(ctx, datastore.NewKey(usagePrefix+class))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return Usage{}, nil
+		}
+		return Usage{}, fmt.Errorf("getting usage of storage class %s: %w", class, err)
+	}
+	var u Usage
+	if err := json.Unmarshal(data, &u); err != nil {
+		return Usage{}, fmt.Errorf("decoding usage of storage class %s: %w", class, err)
+	}
+	return u, nil
+}
+
+// RecordAggregate records the class of the blobs aggregated under root.
+func (m *Manager) RecordAggregate(ctx context.Context, root ipld.Link, class string) error {
+	if err := m.ds.Put(ctx, datastore.NewKey(aggregatesPrefix+root.String()), []byte(class)); err != nil {
+		return fmt.Errorf("putting storage class of aggregate %s: %w", root, err)
+	}
+	return nil
+}
+
+// ProofSetClass returns the class of the proof sets the aggregate may be
+// added to, empty for any proof set. Aggregates with no recorded class, such
+// as those created before classes were configured, are in the default class.
+func (m *Manager) ProofSetClass(ctx context.Context, aggregate aggtypes.Aggregate) (string, error) {
+	root := aggregate.Root.Link()
+	data, err := m.ds.Get(ctx, datastore.NewKey(aggregatesPrefix+root.String()))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return m.Default().ProofSetClass, nil
+		}
+		return "", fmt.Errorf("getting storage class of aggregate %s: %w", root, err)
+	}
+	c, ok := m.classes[string(data)]
+	if !ok {
+		log.Warnw("aggregate is in a storage class that is no longer configured, using the default", "aggregate", root, "class", string(data))
+		return m.Default().ProofSetClass, nil
+	}
+	return c.ProofSetClass, nil
+}
+
+func blobKey(digest multihash.Multihash) datastore.Key {
+	return datastore.NewKey(blobsPrefix + digestutil.Format(digest))
+}
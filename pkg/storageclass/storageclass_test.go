@@ -0,0 +1,185 @@
+package storageclass
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	"github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	blobcaps "github.com/storacha/go-libstoracha/capabilities/blob"
+	captypes "github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/config/app"
+	aggtypes "github.com/storacha/piri/pkg/pdp/aggregation/types"
+)
+
+func newTestManager(t *testing.T, cfg app.StorageClassesConfig) *Manager {
+	t.Helper()
+	m, err := NewManager(sync.MutexWrap(datastore.NewMapDatastore()), cfg)
+	require.NoError(t, err)
+	return m
+}
+
+var testConfig = app.StorageClassesConfig{
+	Classes: []app.StorageClass{
+		{Name: "archive", Replicas: 2, ProofSetClass: "cold"},
+		{Name: "hot", Replicas: 5},
+	},
+}
+
+func TestNewManager(t *testing.T) {
+	m := newTestManager(t, app.StorageClassesConfig{})
+	require.Equal(t, []Class{{Name: Standard}}, m.Classes())
+	require.Equal(t, Standard, m.Default().Name)
+
+	m = newTestManager(t, app.StorageClassesConfig{
+		Default: "archive",
+		Classes: append([]app.StorageClass{{Name: Standard, Replicas: 3}}, testConfig.Classes...),
+	})
+	require.Equal(t, []string{Standard, "archive", "hot"}, names(m.Classes()))
+	require.Equal(t, uint(3), m.Classes()[0].Replicas)
+	require.Equal(t, "archive", m.Default().Name)
+
+	_, err := NewManager(datastore.NewMapDatastore(), app.StorageClassesConfig{Default: "missing"})
+	require.Error(t, err)
+}
+
+func TestResolve(t *testing.T) {
+	m := newTestManager(t, testConfig)
+	space := testutil.RandomDID(t)
+
+	c, err := m.Resolve(t.Context(), space, "")
+	require.NoError(t, err)
+	require.Equal(t, Standard, c.Name)
+
+	require.NoError(t, m.SetSpaceClass(t.Context(), space, "archive"))
+	c, err = m.Resolve(t.Context(), space, "")
+	require.NoError(t, err)
+	require.Equal(t, "archive", c.Name)
+
+	// a requested class overrides the class of the space
+	c, err = m.Resolve(t.Context(), space, "hot")
+	require.NoError(t, err)
+	require.Equal(t, "hot", c.Name)
+
+	var ue *UnknownClassError
+	_, err = m.Resolve(t.Context(), space, "missing")
+	require.ErrorAs(t, err, &ue)
+	require.ErrorAs(t, m.SetSpaceClass(t.Context(), space, "missing"), &ue)
+
+	assignments, err := m.Assignments(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []Assignment{{Space: space, Class: "archive"}}, assignments)
+
+	require.NoError(t, m.SetSpaceClass(t.Context(), space, ""))
+	c, err = m.Resolve(t.Context(), space, "")
+	require.NoError(t, err)
+	require.Equal(t, Standard, c.Name)
+}
+
+func TestRecord(t *testing.T) {
+	m := newTestManager(t, testConfig)
+	blob := testutil.RandomMultihash(t)
+
+	_, ok, err := m.ClassOf(t.Context(), blob)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, m.Record(t.Context(), blob, "archive", 100))
+	// allocating the blob again, e.g. in another space, keeps its class
+	require.NoError(t, m.Record(t.Context(), blob, "hot", 100))
+	require.NoError(t, m.Record(t.Context(), testutil.RandomMultihash(t), "archive", 50))
+
+	class, ok, err := m.ClassOf(t.Context(), blob)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "archive", class)
+
+	usage, err := m.Usage(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, map[string]Usage{"archive": {Blobs: 2, Bytes: 150}}, usage)
+}
+
+func TestProofSetClass(t *testing.T) {
+	m := newTestManager(t, testConfig)
+	archived := aggtypes.Aggregate{Root: testutil.RandomPiece(t, 1024)}
+	unrecorded := aggtypes.Aggregate{Root: testutil.RandomPiece(t, 1024)}
+
+	require.NoError(t, m.RecordAggregate(t.Context(), archived.Root.Link(), "archive"))
+
+	class, err := m.ProofSetClass(t.Context(), archived)
+	require.NoError(t, err)
+	require.Equal(t, "cold", class)
+
+	class, err = m.ProofSetClass(t.Context(), unrecorded)
+	require.NoError(t, err)
+	require.Empty(t, class)
+}
+
+func TestFacts(t *testing.T) {
+	fact, err := Fact{Class: Class{Name: "archive", Replicas: 2}}.ToIPLD()
+	require.NoError(t, err)
+	replicas, err := fact[FactKey].LookupByString("replicas")
+	require.NoError(t, err)
+	n, err := replicas.AsInt()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+
+	invoke := func(facts ...ucan.FactBuilder) string {
+		inv, err := blobcaps.Allocate.Invoke(
+			testutil.Alice,
+			testutil.Service,
+			testutil.Service.DID().String(),
+			blobcaps.AllocateCaveats{
+				Space: testutil.RandomDID(t),
+				Blob:  captypes.Blob{Digest: testutil.RandomMultihash(t), Size: 1},
+				Cause: testutil.RandomCID(t),
+			},
+			delegation.WithFacts(facts),
+		)
+		require.NoError(t, err)
+		return Requested(inv)
+	}
+	require.Empty(t, invoke())
+	require.Equal(t, "hot", invoke(stringFact("hot")))
+	require.Equal(t, "archive", invoke(Fact{Class: Class{Name: "archive"}}))
+}
+
+type stringFact string
+
+func (f stringFact) ToIPLD() (map[string]ipld.Node, error) {
+	return map[string]ipld.Node{FactKey: basicnode.NewString(string(f))}, nil
+}
+
+func names(classes []Class) []string {
+	var out []string
+	for _, c := range classes {
+		out = append(out, c.Name)
+	}
+	return out
+}
+
+func TestResolveDisabled(t *testing.T) {
+	enabled := testutil.RandomDID(t)
+	m, err := NewManager(sync.MutexWrap(datastore.NewMapDatastore()), testConfig, WithEnabled(func(space did.DID) bool {
+		return space == enabled
+	}))
+	require.NoError(t, err)
+	space := testutil.RandomDID(t)
+	require.NoError(t, m.SetSpaceClass(t.Context(), space, "archive"))
+
+	// classes are not applied to spaces they are not enabled for
+	c, err := m.Resolve(t.Context(), space, "hot")
+	require.NoError(t, err)
+	require.Equal(t, Standard, c.Name)
+
+	c, err = m.Resolve(t.Context(), enabled, "hot")
+	require.NoError(t, err)
+	require.Equal(t, "hot", c.Name)
+}
@@ -3,6 +3,7 @@ package acceptancestore
 import (
 	"context"
 	"fmt"
+	"iter"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/multiformats/go-multihash"
@@ -29,6 +30,11 @@ type AcceptanceStore interface {
 	Exists(context.Context, multihash.Multihash) (bool, error)
 	// Put adds or replaces acceptance data in the store.
 	Put(context.Context, acceptance.Acceptance) error
+	// Delete removes the acceptance for a blob (digest) in a space (DID).
+	// Deleting an acceptance that does not exist is not an error.
+	Delete(context.Context, multihash.Multihash, did.DID) error
+	// List returns an iterator over all acceptances in the store.
+	List(context.Context) iter.Seq2[acceptance.Acceptance, error]
 }
 
 // KeyEncoder defines how to encode keys for a specific backend.
@@ -77,6 +83,17 @@ func (s *Store) Put(ctx context.Context, acc acceptance.Acceptance) error {
 	return s.store.Put(ctx, s.encoder.EncodeKey(acc.Blob.Digest, acc.Space), acc)
 }
 
+func (s *Store) Delete(ctx context.Context, digest multihash.Multihash, space did.DID) error {
+	if err := s.store.Delete(ctx, s.encoder.EncodeKey(digest, space)); err != nil {
+		return fmt.Errorf("deleting acceptance: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) List(ctx context.Context) iter.Seq2[acceptance.Acceptance, error] {
+	return s.store.ListPrefix(ctx, "")
+}
+
 // S3KeyEncoder encodes keys for S3/MinIO backends (keys end with .cbor).
 type S3KeyEncoder struct{}
 
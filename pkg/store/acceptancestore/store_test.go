@@ -91,6 +91,37 @@ func TestDsAcceptanceStore(t *testing.T) {
 		require.True(t, exists)
 	})
 
+	t.Run("delete", func(t *testing.T) {
+		s := acceptancestore.NewDatastoreStore(datastore.NewMapDatastore())
+
+		acc := acceptance.Acceptance{
+			Space: testutil.RandomDID(t),
+			Blob: acceptance.Blob{
+				Digest: testutil.RandomMultihash(t),
+				Size:   uint64(1 + rand.IntN(1000)),
+			},
+			ExecutedAt: uint64(time.Now().Unix()),
+			Cause:      testutil.RandomCID(t),
+		}
+
+		err := s.Put(t.Context(), acc)
+		require.NoError(t, err)
+
+		err = s.Delete(t.Context(), acc.Blob.Digest, acc.Space)
+		require.NoError(t, err)
+
+		_, err = s.Get(t.Context(), acc.Blob.Digest, acc.Space)
+		require.ErrorIs(t, err, store.ErrNotFound)
+
+		exists, err := s.Exists(t.Context(), acc.Blob.Digest)
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		// deleting again is not an error
+		err = s.Delete(t.Context(), acc.Blob.Digest, acc.Space)
+		require.NoError(t, err)
+	})
+
 	t.Run("not found", func(t *testing.T) {
 		s := acceptancestore.NewDatastoreStore(datastore.NewMapDatastore())
 
@@ -0,0 +1,226 @@
+package allocationstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-ucanto/did"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+)
+
+// batchSize is the number of allocations read from or written to the
+// database at a time.
+const batchSize = 1000
+
+// AllocationRecord is a row of the allocations table.
+type AllocationRecord = models.AllocationRecord
+
+// SQLStore implements AllocationStore in a SQL database, with indexes on the
+// digest, space and expiry of allocations so lookups of a blob do not scan
+// the allocations of other blobs. It is safe for concurrent use.
+type SQLStore struct {
+	db *gorm.DB
+}
+
+var _ AllocationStore = (*SQLStore)(nil)
+
+// NewSQLStore creates an AllocationStore in db. The allocations table and its
+// indexes are created by the shared migrations of the PDP database,
+// [models.AutoMigrateDB].
+func NewSQLStore(db *gorm.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+func (s *SQLStore) Get(ctx context.Context, digest multihash.Multihash, space did.DID) (allocation.Allocation, error) {
+	var rec AllocationRecord
+	err := s.db.WithContext(ctx).
+		Where("digest = ? AND space = ?", []byte(digest), space.String()).
+		Take(&rec).Error
+	if err != nil {
+		return allocation.Allocation{}, fmt.Errorf("getting allocation: %w", notFound(err))
+	}
+	return toAllocation(rec)
+}
+
+func (s *SQLStore) GetAny(ctx context.Context, digest multihash.Multihash) (allocation.Allocation, error) {
+	var rec AllocationRecord
+	err := s.db.WithContext(ctx).
+		Where("digest = ?", []byte(digest)).
+		Take(&rec).Error
+	if err != nil {
+		return allocation.Allocation{}, fmt.Errorf("getting any allocation: %w", notFound(err))
+	}
+	return toAllocation(rec)
+}
+
+func (s *SQLStore) GetAnyNonExpired(ctx context.Context, digest multihash.Multihash, now uint64) (allocation.Allocation, error) {
+	var rec AllocationRecord
+	err := s.db.WithContext(ctx).
+		Where("digest = ? AND expires > ?", []byte(digest), int64(now)).
+		Take(&rec).Error
+	if err != nil {
+		return allocation.Allocation{}, fmt.Errorf("getting non-expired allocation: %w", notFound(err))
+	}
+	return toAllocation(rec)
+}
+
+func (s *SQLStore) Exists(ctx context.Context, digest multihash.Multihash) (bool, error) {
+	var n int64
+	err := s.db.WithContext(ctx).
+		Model(&AllocationRecord{}).
+		Where("digest = ?", []byte(digest)).
+		Count(&n).Error
+	if err != nil {
+		return false, fmt.Errorf("checking allocation exists: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *SQLStore) Put(ctx context.Context, alloc allocation.Allocation) error {
+	// replace the allocation of the blob in the space, if any, in a single
+	// statement so concurrent puts do not conflict
+	rec := newRecord(alloc)
+	err := s.db.WithContext(ctx).Clauses(upsert).Create(&rec).Error
+	if err != nil {
+		return fmt.Errorf("putting allocation: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Delete(ctx context.Context, digest multihash.Multihash, space did.DID) error {
+	err := s.db.WithContext(ctx).
+		Where("digest = ? AND space = ?", []byte(digest), space.String()).
+		Delete(&AllocationRecord{}).Error
+	if err != nil {
+		return fmt.Errorf("deleting allocation: %w", err)
+	}
+	return nil
+}
+
+// List returns an iterator over all allocations in the store, ordered by
+// digest and space. Allocations are read in batches, so the iteration does
+// not hold a connection of the database between batches.
+func (s *SQLStore) List(ctx context.Context) iter.Seq2[allocation.Allocation, error] {
+	return func(yield func(allocation.Allocation, error) bool) {
+		var last *AllocationRecord
+		for {
+			q := s.db.WithContext(ctx).Order("digest, space").Limit(batchSize)
+			if last != nil {
+				q = q.Where("digest > ? OR (digest = ? AND space > ?)", last.Digest, last.Digest, last.Space)
+			}
+			var recs []AllocationRecord
+			if err := q.Find(&recs).Error; err != nil {
+				yield(allocation.Allocation{}, fmt.Errorf("listing allocations: %w", err))
+				return
+			}
+			for _, rec := range recs {
+				if !yield(toAllocation(rec)) {
+					return
+				}
+			}
+			if len(recs) < batchSize {
+				return
+			}
+			last = &recs[len(recs)-1]
+		}
+	}
+}
+
+// Count returns the number of allocations in the store.
+func (s *SQLStore) Count(ctx context.Context) (int64, error) {
+	var n int64
+	if err := s.db.WithContext(ctx).Model(&AllocationRecord{}).Count(&n).Error; err != nil {
+		return 0, fmt.Errorf("counting allocations: %w", err)
+	}
+	return n, nil
+}
+
+// Import puts the allocations of src in the store, replacing allocations of
+// the same blob in the same space, and returns the number imported. They are
+// written in batches, each in a transaction.
+func (s *SQLStore) Import(ctx context.Context, src iter.Seq2[allocation.Allocation, error]) (int, error) {
+	n := 0
+	batch := make([]AllocationRecord, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return tx.Clauses(upsert).Create(&batch).Error
+		})
+		if err != nil {
+			return fmt.Errorf("importing allocations: %w", err)
+		}
+		n += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	for alloc, err := range src {
+		if err != nil {
+			return n, fmt.Errorf("reading allocation: %w", err)
+		}
+		batch = append(batch, newRecord(alloc))
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// upsert replaces the allocation of a blob in a space on insert.
+var upsert = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "digest"}, {Name: "space"}},
+	DoUpdates: clause.AssignmentColumns([]string{"size", "expires", "cause"}),
+}
+
+func newRecord(alloc allocation.Allocation) AllocationRecord {
+	return AllocationRecord{
+		Digest:  alloc.Blob.Digest,
+		Space:   alloc.Space.String(),
+		Size:    int64(alloc.Blob.Size),
+		Expires: int64(alloc.Expires),
+		Cause:   alloc.Cause.String(),
+	}
+}
+
+func toAllocation(r AllocationRecord) (allocation.Allocation, error) {
+	space, err := did.Parse(r.Space)
+	if err != nil {
+		return allocation.Allocation{}, fmt.Errorf("parsing allocation space: %w", err)
+	}
+	cause, err := cid.Parse(r.Cause)
+	if err != nil {
+		return allocation.Allocation{}, fmt.Errorf("parsing allocation cause: %w", err)
+	}
+	return allocation.Allocation{
+		Space: space,
+		Blob: allocation.Blob{
+			Digest: multihash.Multihash(r.Digest),
+			Size:   uint64(r.Size),
+		},
+		Expires: uint64(r.Expires),
+		Cause:   cidlink.Link{Cid: cause},
+	}, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return store.ErrNotFound
+	}
+	return err
+}
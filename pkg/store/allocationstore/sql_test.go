@@ -0,0 +1,154 @@
+package allocationstore
+
+import (
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/database/gormdb"
+	"github.com/storacha/piri/pkg/pdp/service/models"
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+)
+
+func newSQLStore(t *testing.T) *SQLStore {
+	db, err := gormdb.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrateDB(t.Context(), db))
+	return NewSQLStore(db)
+}
+
+func randomAllocation(t *testing.T, blob allocation.Blob, expires uint64) allocation.Allocation {
+	return allocation.Allocation{
+		Space:   testutil.RandomDID(t),
+		Blob:    blob,
+		Expires: expires,
+		Cause:   testutil.RandomCID(t),
+	}
+}
+
+func randomBlob(t *testing.T) allocation.Blob {
+	return allocation.Blob{
+		Digest: testutil.RandomMultihash(t),
+		Size:   uint64(1 + rand.IntN(1000)),
+	}
+}
+
+func TestSQLAllocationStore(t *testing.T) {
+	now := uint64(time.Now().Unix())
+
+	t.Run("roundtrip", func(t *testing.T) {
+		s := newSQLStore(t)
+		alloc := randomAllocation(t, randomBlob(t), now)
+		require.NoError(t, s.Put(t.Context(), alloc))
+
+		got, err := s.Get(t.Context(), alloc.Blob.Digest, alloc.Space)
+		require.NoError(t, err)
+		require.Equal(t, alloc, got)
+
+		got, err = s.GetAny(t.Context(), alloc.Blob.Digest)
+		require.NoError(t, err)
+		require.Equal(t, alloc, got)
+
+		exists, err := s.Exists(t.Context(), alloc.Blob.Digest)
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("put replaces", func(t *testing.T) {
+		s := newSQLStore(t)
+		alloc := randomAllocation(t, randomBlob(t), now)
+		require.NoError(t, s.Put(t.Context(), alloc))
+		alloc.Expires = now + 3600
+		alloc.Cause = testutil.RandomCID(t)
+		require.NoError(t, s.Put(t.Context(), alloc))
+
+		got, err := s.Get(t.Context(), alloc.Blob.Digest, alloc.Space)
+		require.NoError(t, err)
+		require.Equal(t, alloc, got)
+
+		n, err := s.Count(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), n)
+	})
+
+	t.Run("get any non-expired", func(t *testing.T) {
+		s := newSQLStore(t)
+		blob := randomBlob(t)
+		expired := randomAllocation(t, blob, now-100)
+		valid := randomAllocation(t, blob, now+3600)
+		require.NoError(t, s.Put(t.Context(), expired))
+		require.NoError(t, s.Put(t.Context(), valid))
+
+		got, err := s.GetAnyNonExpired(t.Context(), blob.Digest, now)
+		require.NoError(t, err)
+		require.Equal(t, valid, got)
+
+		_, err = s.GetAnyNonExpired(t.Context(), blob.Digest, now+3600)
+		require.ErrorIs(t, err, store.ErrNotFound)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s := newSQLStore(t)
+		digest := testutil.RandomMultihash(t)
+
+		_, err := s.Get(t.Context(), digest, testutil.RandomDID(t))
+		require.ErrorIs(t, err, store.ErrNotFound)
+
+		_, err = s.GetAny(t.Context(), digest)
+		require.ErrorIs(t, err, store.ErrNotFound)
+
+		exists, err := s.Exists(t.Context(), digest)
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := newSQLStore(t)
+		blob := randomBlob(t)
+		alloc0 := randomAllocation(t, blob, now)
+		alloc1 := randomAllocation(t, blob, now)
+		require.NoError(t, s.Put(t.Context(), alloc0))
+		require.NoError(t, s.Put(t.Context(), alloc1))
+
+		require.NoError(t, s.Delete(t.Context(), blob.Digest, alloc0.Space))
+		_, err := s.Get(t.Context(), blob.Digest, alloc0.Space)
+		require.ErrorIs(t, err, store.ErrNotFound)
+
+		// the allocation in the other space remains
+		got, err := s.GetAny(t.Context(), blob.Digest)
+		require.NoError(t, err)
+		require.Equal(t, alloc1, got)
+
+		// deleting a missing allocation is not an error
+		require.NoError(t, s.Delete(t.Context(), blob.Digest, alloc0.Space))
+	})
+
+	t.Run("import and list", func(t *testing.T) {
+		src := NewDatastoreStore(datastore.NewMapDatastore())
+		want := map[string]allocation.Allocation{}
+		// more than a batch, so both are paged
+		for range batchSize + 10 {
+			alloc := randomAllocation(t, randomBlob(t), now)
+			require.NoError(t, src.Put(t.Context(), alloc))
+			want[alloc.Cause.String()] = alloc
+		}
+
+		s := newSQLStore(t)
+		n, err := s.Import(t.Context(), src.List(t.Context()))
+		require.NoError(t, err)
+		require.Equal(t, len(want), n)
+
+		got := map[string]allocation.Allocation{}
+		for alloc, err := range s.List(t.Context()) {
+			require.NoError(t, err)
+			got[alloc.Cause.String()] = alloc
+		}
+		require.Equal(t, want, got)
+	})
+}
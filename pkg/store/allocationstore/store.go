@@ -3,6 +3,7 @@ package allocationstore
 import (
 	"context"
 	"fmt"
+	"iter"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/multiformats/go-multihash"
@@ -34,6 +35,11 @@ type AllocationStore interface {
 	Exists(context.Context, multihash.Multihash) (bool, error)
 	// Put adds or replaces allocation data in the store.
 	Put(context.Context, allocation.Allocation) error
+	// Delete removes the allocation for a blob (digest) in a space (DID).
+	// Deleting an allocation that does not exist is not an error.
+	Delete(context.Context, multihash.Multihash, did.DID) error
+	// List returns an iterator over all allocations in the store.
+	List(context.Context) iter.Seq2[allocation.Allocation, error]
 }
 
 // KeyEncoder defines how to encode keys for a specific backend.
@@ -92,6 +98,17 @@ func (s *Store) Put(ctx context.Context, alloc allocation.Allocation) error {
 	return s.store.Put(ctx, s.encoder.EncodeKey(alloc.Blob.Digest, alloc.Space), alloc)
 }
 
+func (s *Store) Delete(ctx context.Context, digest multihash.Multihash, space did.DID) error {
+	if err := s.store.Delete(ctx, s.encoder.EncodeKey(digest, space)); err != nil {
+		return fmt.Errorf("deleting allocation: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) List(ctx context.Context) iter.Seq2[allocation.Allocation, error] {
+	return s.store.ListPrefix(ctx, "")
+}
+
 // S3KeyEncoder encodes keys for S3/MinIO backends (keys end with .cbor).
 type S3KeyEncoder struct{}
 
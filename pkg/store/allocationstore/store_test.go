@@ -212,4 +212,56 @@ func TestDatastoreAllocationStore(t *testing.T) {
 		_, err := s.GetAnyNonExpired(t.Context(), digest, now)
 		require.ErrorIs(t, err, store.ErrNotFound)
 	})
+	t.Run("delete", func(t *testing.T) {
+		s := NewDatastoreStore(datastore.NewMapDatastore())
+
+		alloc := allocation.Allocation{
+			Space: testutil.RandomDID(t),
+			Blob: allocation.Blob{
+				Digest: testutil.RandomMultihash(t),
+				Size:   uint64(1 + rand.IntN(1000)),
+			},
+			Expires: uint64(time.Now().Unix()),
+			Cause:   testutil.RandomCID(t),
+		}
+
+		err := s.Put(t.Context(), alloc)
+		require.NoError(t, err)
+
+		err = s.Delete(t.Context(), alloc.Blob.Digest, alloc.Space)
+		require.NoError(t, err)
+
+		_, err = s.Get(t.Context(), alloc.Blob.Digest, alloc.Space)
+		require.ErrorIs(t, err, store.ErrNotFound)
+
+		exists, err := s.Exists(t.Context(), alloc.Blob.Digest)
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		s := NewDatastoreStore(datastore.NewMapDatastore())
+
+		want := map[string]allocation.Allocation{}
+		for range 3 {
+			alloc := allocation.Allocation{
+				Space: testutil.RandomDID(t),
+				Blob: allocation.Blob{
+					Digest: testutil.RandomMultihash(t),
+					Size:   uint64(1 + rand.IntN(1000)),
+				},
+				Expires: uint64(time.Now().Unix()),
+				Cause:   testutil.RandomCID(t),
+			}
+			require.NoError(t, s.Put(t.Context(), alloc))
+			want[alloc.Cause.String()] = alloc
+		}
+
+		got := map[string]allocation.Allocation{}
+		for alloc, err := range s.List(t.Context()) {
+			require.NoError(t, err)
+			got[alloc.Cause.String()] = alloc
+		}
+		require.Equal(t, want, got)
+	})
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 
 	"github.com/multiformats/go-multihash"
 )
@@ -19,6 +20,10 @@ var ErrTooLarge = errors.New("payload too large")
 // ErrTooSmall is returned when the data being written is smaller than expected.
 var ErrTooSmall = errors.New("payload too small")
 
+// ErrListUnsupported is returned when listing a store whose backend cannot
+// list its objects.
+var ErrListUnsupported = errors.New("listing not supported by blob store backend")
+
 // RangeNotSatisfiableError is returned when the byte range option falls outside
 // of the total size of the blob.
 type RangeNotSatisfiableError struct {
@@ -94,6 +99,12 @@ type Blobstore interface {
 	Delete(ctx context.Context, digest multihash.Multihash) error
 }
 
+// BlobLister is implemented by blob stores that can enumerate their contents.
+type BlobLister interface {
+	// List iterates the digests of all stored blobs, in no particular order.
+	List(ctx context.Context) iter.Seq2[multihash.Multihash, error]
+}
+
 // PDPStore is deprecated: use Blobstore directly.
 type PDPStore = Blobstore
 
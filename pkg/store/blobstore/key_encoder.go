@@ -1,7 +1,9 @@
 package blobstore
 
 import (
+	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/multiformats/go-multibase"
 	"github.com/multiformats/go-multihash"
@@ -12,6 +14,9 @@ import (
 // KeyEncoder defines how to encode blob keys for a specific backend.
 type KeyEncoder interface {
 	EncodeKey(digest multihash.Multihash) string
+	// DecodeKey returns the digest encoded in a key, it is the inverse of
+	// EncodeKey.
+	DecodeKey(key string) (multihash.Multihash, error)
 }
 
 // Base32KeyEncoder encodes keys as base32 (S3/MinIO compatible with IPFS boxo).
@@ -25,6 +30,18 @@ func (Base32KeyEncoder) EncodeKey(digest multihash.Multihash) string {
 	return b32[1:] // strip base indicator
 }
 
+func (Base32KeyEncoder) DecodeKey(key string) (multihash.Multihash, error) {
+	_, b, err := multibase.Decode(string(multibase.Base32) + key)
+	if err != nil {
+		return nil, fmt.Errorf("decoding key %q: %w", key, err)
+	}
+	digest, err := multihash.Cast(b)
+	if err != nil {
+		return nil, fmt.Errorf("decoding key %q: %w", key, err)
+	}
+	return digest, nil
+}
+
 // PlainKeyEncoder encodes keys as plain digest format using digestutil.Format.
 // This is the default encoder for in-memory and datastore backends.
 type PlainKeyEncoder struct{}
@@ -33,6 +50,10 @@ func (PlainKeyEncoder) EncodeKey(digest multihash.Multihash) string {
 	return digestutil.Format(digest)
 }
 
+func (PlainKeyEncoder) DecodeKey(key string) (multihash.Multihash, error) {
+	return digestutil.Parse(key)
+}
+
 // Base32FlatFSKeyEncoder is a [Base32KeyEncoder] that also adds a sharding
 // directory prefix and ".data" suffix, making it compatible with FlatFS
 // NextToLast(2) sharding.
@@ -49,3 +70,7 @@ func (f *Base32FlatFSKeyEncoder) EncodeKey(digest multihash.Multihash) string {
 	dir := f.shard(b32)
 	return filepath.Join(dir, b32+".data")
 }
+
+func (f *Base32FlatFSKeyEncoder) DecodeKey(key string) (multihash.Multihash, error) {
+	return Base32KeyEncoder{}.DecodeKey(strings.TrimSuffix(filepath.Base(key), ".data"))
+}
@@ -3,7 +3,10 @@ package blobstore
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"iter"
+	"os"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/multiformats/go-multihash"
@@ -13,14 +16,38 @@ import (
 	"github.com/storacha/piri/pkg/store/objectstore/dsadapter"
 	"github.com/storacha/piri/pkg/store/objectstore/flatfs"
 	minio_store "github.com/storacha/piri/pkg/store/objectstore/minio"
+	"github.com/storacha/piri/pkg/store/objectstore/pack"
+	"github.com/storacha/piri/pkg/store/stash"
 )
 
-var _ Blobstore = (*Store)(nil)
+var (
+	_ Blobstore  = (*Store)(nil)
+	_ BlobLister = (*Store)(nil)
+)
 
 // Store wraps an objectstore.Store with a KeyEncoder for S3/MinIO/flatfs backends.
 type Store struct {
 	backend objectstore.Store
 	encoder KeyEncoder
+	stash   *stash.Manager
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithStash writes blobs to the stash before moving them to the backend, so
+// uploads interrupted by a crash can be resumed with [Store.Resume] when the
+// node starts.
+func WithStash(m *stash.Manager) Option {
+	return func(s *Store) {
+		s.stash = m
+	}
+}
+
+// filePutter is implemented by backends that can move a file in place rather
+// than copying it.
+type filePutter interface {
+	PutFile(ctx context.Context, key string, path string) error
 }
 
 // NewS3Store creates a Blobstore backed by an S3/MinIO object store.
@@ -32,11 +59,28 @@ func NewS3Store(backend *minio_store.Store) *Store {
 }
 
 // NewFlatfsStore creates a Blobstore backed by a flatfs object store.
-func NewFlatfsStore(backend *flatfs.Store) *Store {
-	return &Store{
+func NewFlatfsStore(backend *flatfs.Store, opts ...Option) *Store {
+	s := &Store{
+		backend: backend,
+		encoder: Base32KeyEncoder{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewPackStore creates a Blobstore backed by a pack object store, which
+// appends small blobs to pack files and stores larger ones in flatfs.
+func NewPackStore(backend *pack.Store, opts ...Option) *Store {
+	s := &Store{
 		backend: backend,
 		encoder: Base32KeyEncoder{},
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // NewDatastoreStore creates a Blobstore backed by a datastore.Datastore.
@@ -67,10 +111,100 @@ func (s *Store) Get(ctx context.Context, digest multihash.Multihash, opts ...Get
 	return obj, nil
 }
 
+// Put streams the body to the backend. Writes that do not match the expected
+// size or digest fail with [ErrTooSmall], [ErrTooLarge] or
+// [ErrDataInconsistent] and do not replace an existing blob.
 func (s *Store) Put(ctx context.Context, digest multihash.Multihash, size uint64, body io.Reader) error {
-	return s.backend.Put(ctx, s.encoder.EncodeKey(digest), size, body)
+	vr := newVerifyingReader(ctx, body, digest, size)
+	var err error
+	if s.stash != nil {
+		err = s.putStashed(ctx, s.encoder.EncodeKey(digest), size, vr)
+	} else {
+		err = s.backend.Put(ctx, s.encoder.EncodeKey(digest), size, vr)
+	}
+	// backends may wrap or replace reader errors, report the verification
+	// failure itself so callers can match on it
+	if vr.err != nil {
+		return vr.err
+	}
+	return err
+}
+
+func (s *Store) putStashed(ctx context.Context, key string, size uint64, body io.Reader) error {
+	e, err := s.stash.Stash(ctx, key, size, body)
+	if err != nil {
+		return err
+	}
+	err = s.moveStashed(ctx, e)
+	if doneErr := s.stash.Done(e); doneErr != nil {
+		log.Warnw("removing blob from stash", "key", key, "error", doneErr)
+	}
+	return err
+}
+
+// moveStashed writes a stashed blob to the backend, moving the stash file in
+// place if the backend supports it.
+func (s *Store) moveStashed(ctx context.Context, e stash.Entry) error {
+	if fp, ok := s.backend.(filePutter); ok {
+		return fp.PutFile(ctx, e.Key, e.Path)
+	}
+	f, err := os.Open(e.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.backend.Put(ctx, e.Key, e.Size, f)
+}
+
+// Resume verifies a blob found whole in the stash when the node starts and
+// moves it to the backend. It is the [stash.ResumeFunc] of the store's stash.
+func (s *Store) Resume(ctx context.Context, e stash.Entry) error {
+	digest, err := s.encoder.DecodeKey(e.Key)
+	if err != nil {
+		return fmt.Errorf("decoding stashed blob key: %w", err)
+	}
+	f, err := os.Open(e.Path)
+	if err != nil {
+		return err
+	}
+	vr := newVerifyingReader(ctx, f, digest, e.Size)
+	_, err = io.Copy(io.Discard, vr)
+	f.Close()
+	if vr.err != nil {
+		return vr.err
+	}
+	if err != nil {
+		return err
+	}
+	return s.moveStashed(ctx, e)
 }
 
 func (s *Store) Delete(ctx context.Context, digest multihash.Multihash) error {
 	return s.backend.Delete(ctx, s.encoder.EncodeKey(digest))
 }
+
+// List iterates the digests of all stored blobs. It fails with
+// [ErrListUnsupported] if the backend cannot list its objects.
+func (s *Store) List(ctx context.Context) iter.Seq2[multihash.Multihash, error] {
+	return func(yield func(multihash.Multihash, error) bool) {
+		ls, ok := s.backend.(objectstore.ListableStore)
+		if !ok {
+			yield(nil, ErrListUnsupported)
+			return
+		}
+		for key, err := range ls.ListPrefix(ctx, "") {
+			if err != nil {
+				yield(nil, fmt.Errorf("listing blobs: %w", err))
+				return
+			}
+			digest, err := s.encoder.DecodeKey(key)
+			if err != nil {
+				// not a blob, e.g. an object written by another tool
+				continue
+			}
+			if !yield(digest, nil) {
+				return
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package blobstore
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -23,6 +24,8 @@ import (
 	"github.com/storacha/piri/pkg/store"
 	"github.com/storacha/piri/pkg/store/objectstore/flatfs"
 	minio_store "github.com/storacha/piri/pkg/store/objectstore/minio"
+	"github.com/storacha/piri/pkg/store/objectstore/pack"
+	"github.com/storacha/piri/pkg/store/stash"
 )
 
 func TestBlobstore(t *testing.T) {
@@ -33,9 +36,19 @@ func TestBlobstore(t *testing.T) {
 	err := os.MkdirAll(flatfsDir, 0755)
 	require.NoError(t, err)
 
+	stashMgr := testutil.Must(stash.New(path.Join(rootdir, "stash")))(t)
+	_, err = stashMgr.Recover(t.Context(), nil)
+	require.NoError(t, err)
+
 	impls := map[string]Blobstore{
-		"memory": NewDatastoreStore(sync.MutexWrap(datastore.NewMapDatastore())),
-		"flatfs": NewFlatfsStore(testutil.Must(flatfs.New(flatfsDir, flatfs.NextToLast(2), false))(t)),
+		"memory":       NewDatastoreStore(sync.MutexWrap(datastore.NewMapDatastore())),
+		"flatfs":       NewFlatfsStore(testutil.Must(flatfs.New(flatfsDir, flatfs.NextToLast(2), false))(t)),
+		"flatfs+stash": NewFlatfsStore(testutil.Must(flatfs.New(path.Join(rootdir, "flatfs-stash"), flatfs.NextToLast(2), false))(t), WithStash(stashMgr)),
+		"pack+stash": NewPackStore(testutil.Must(pack.New(
+			path.Join(rootdir, "packs"),
+			testutil.Must(flatfs.New(path.Join(rootdir, "flatfs-pack"), flatfs.NextToLast(2), false))(t),
+			sync.MutexWrap(datastore.NewMapDatastore()),
+		))(t), WithStash(stashMgr)),
 	}
 
 	if piritestutil.IsDockerAvailable(t) {
@@ -67,6 +80,25 @@ func TestBlobstore(t *testing.T) {
 				require.Equal(t, data, testutil.Must(io.ReadAll(obj.Body()))(t))
 			})
 
+			t.Run("hash functions", func(t *testing.T) {
+				for _, code := range []uint64{multihash.SHA2_512, multihash.BLAKE3} {
+					data := testutil.RandomBytes(t, 10)
+					digest := testutil.Must(multihash.Sum(data, code, -1))(t)
+
+					err := s.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data))
+					require.NoError(t, err)
+					obj, err := s.Get(t.Context(), digest)
+					require.NoError(t, err)
+					require.Equal(t, data, testutil.Must(io.ReadAll(obj.Body()))(t))
+
+					other := testutil.Must(multihash.Sum(testutil.RandomBytes(t, 10), code, -1))(t)
+					err = s.Put(t.Context(), other, uint64(len(data)), bytes.NewReader(data))
+					require.ErrorIs(t, err, ErrDataInconsistent)
+					_, err = s.Get(t.Context(), other)
+					require.Equal(t, store.ErrNotFound, err)
+				}
+			})
+
 			t.Run("not found", func(t *testing.T) {
 				data := testutil.RandomBytes(t, 10)
 				digest := testutil.Must(multihash.Sum(data, multihash.SHA2_256, -1))(t)
@@ -91,6 +123,62 @@ func TestBlobstore(t *testing.T) {
 				require.Equal(t, store.ErrNotFound, err)
 			})
 
+			t.Run("data inconsistent", func(t *testing.T) {
+				data := testutil.RandomBytes(t, 10)
+				digest := testutil.Must(multihash.Sum(data, multihash.SHA2_256, -1))(t)
+
+				err := s.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(testutil.RandomBytes(t, 10)))
+				require.ErrorIs(t, err, ErrDataInconsistent)
+
+				_, err = s.Get(t.Context(), digest)
+				require.Equal(t, store.ErrNotFound, err)
+			})
+
+			t.Run("size mismatch", func(t *testing.T) {
+				data := testutil.RandomBytes(t, 10)
+				digest := testutil.Must(multihash.Sum(data, multihash.SHA2_256, -1))(t)
+
+				err := s.Put(t.Context(), digest, uint64(len(data)+1), bytes.NewReader(data))
+				require.ErrorIs(t, err, ErrTooSmall)
+
+				// backends that read exactly size bytes see a truncated blob that
+				// does not hash to the digest rather than the excess data
+				err = s.Put(t.Context(), digest, uint64(len(data)-1), &oneShotReader{data})
+				require.True(t, errors.Is(err, ErrTooLarge) || errors.Is(err, ErrDataInconsistent), "unexpected error: %v", err)
+			})
+
+			t.Run("failed write keeps existing blob", func(t *testing.T) {
+				data := testutil.RandomBytes(t, 10)
+				digest := testutil.Must(multihash.Sum(data, multihash.SHA2_256, -1))(t)
+
+				err := s.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data))
+				require.NoError(t, err)
+
+				err = s.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(testutil.RandomBytes(t, 10)))
+				require.ErrorIs(t, err, ErrDataInconsistent)
+
+				obj, err := s.Get(t.Context(), digest)
+				require.NoError(t, err)
+				require.Equal(t, data, testutil.Must(io.ReadAll(obj.Body()))(t))
+			})
+
+			t.Run("list", func(t *testing.T) {
+				data := testutil.RandomBytes(t, 10)
+				digest := testutil.Must(multihash.Sum(data, multihash.SHA2_256, -1))(t)
+
+				err := s.Put(t.Context(), digest, uint64(len(data)), bytes.NewReader(data))
+				require.NoError(t, err)
+
+				var found bool
+				for d, err := range s.(BlobLister).List(t.Context()) {
+					require.NoError(t, err)
+					if bytes.Equal(d, digest) {
+						found = true
+					}
+				}
+				require.True(t, found)
+			})
+
 			t.Run("range not satisfiable", func(t *testing.T) {
 				data := testutil.RandomBytes(t, 10)
 				digest := testutil.Must(multihash.Sum(data, multihash.SHA2_256, -1))(t)
@@ -105,3 +193,50 @@ func TestBlobstore(t *testing.T) {
 		})
 	}
 }
+
+func TestResume(t *testing.T) {
+	rootdir := t.TempDir()
+	objStore := testutil.Must(flatfs.New(path.Join(rootdir, "flatfs"), flatfs.NextToLast(2), false))(t)
+
+	data := testutil.RandomBytes(t, 10)
+	digest := testutil.Must(multihash.Sum(data, multihash.SHA2_256, -1))(t)
+	corrupt := testutil.Must(multihash.Sum(testutil.RandomBytes(t, 10), multihash.SHA2_256, -1))(t)
+
+	// uploads stashed before a crash, that never left the stash
+	m := testutil.Must(stash.New(path.Join(rootdir, "stash")))(t)
+	_, err := m.Recover(t.Context(), nil)
+	require.NoError(t, err)
+	enc := Base32KeyEncoder{}
+	_, err = m.Stash(t.Context(), enc.EncodeKey(digest), uint64(len(data)), bytes.NewReader(data))
+	require.NoError(t, err)
+	_, err = m.Stash(t.Context(), enc.EncodeKey(corrupt), uint64(len(data)), bytes.NewReader(data))
+	require.NoError(t, err)
+	require.NoError(t, m.Close())
+
+	m = testutil.Must(stash.New(path.Join(rootdir, "stash")))(t)
+	defer m.Close()
+	s := NewFlatfsStore(objStore, WithStash(m))
+	rec, err := m.Recover(t.Context(), s.Resume)
+	require.NoError(t, err)
+	require.Equal(t, stash.Recovery{Recovered: 1, Discarded: 1}, rec)
+
+	obj, err := s.Get(t.Context(), digest)
+	require.NoError(t, err)
+	require.Equal(t, data, testutil.Must(io.ReadAll(obj.Body()))(t))
+	_, err = s.Get(t.Context(), corrupt)
+	require.Equal(t, store.ErrNotFound, err)
+}
+
+// oneShotReader returns all of its data in a single read.
+type oneShotReader struct {
+	data []byte
+}
+
+func (r *oneShotReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
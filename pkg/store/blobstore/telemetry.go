@@ -0,0 +1,53 @@
+package blobstore
+
+import (
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.opentelemetry.io/otel"
+
+	"github.com/storacha/piri/lib/telemetry"
+)
+
+var log = logging.Logger("blobstore")
+
+// Results of verifying a blob as it is written.
+const (
+	verifyResultOK       = "ok"
+	verifyResultMismatch = "mismatch"
+	verifyResultTooSmall = "too_small"
+	verifyResultTooLarge = "too_large"
+)
+
+// verifyDurationBounds covers hashing small blobs up to the largest shards.
+var verifyDurationBounds = []float64{
+	(time.Millisecond).Seconds(),
+	(5 * time.Millisecond).Seconds(),
+	(10 * time.Millisecond).Seconds(),
+	(50 * time.Millisecond).Seconds(),
+	(100 * time.Millisecond).Seconds(),
+	(250 * time.Millisecond).Seconds(),
+	(500 * time.Millisecond).Seconds(),
+	(time.Second).Seconds(),
+	(2 * time.Second).Seconds(),
+	(5 * time.Second).Seconds(),
+	(10 * time.Second).Seconds(),
+}
+
+// verifyTimer records the time spent hashing blobs as they are written, by
+// hash function and result. It is nil if the timer could not be created.
+var verifyTimer = sync.OnceValue(func() *telemetry.Timer {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/store/blobstore")
+	timer, err := telemetry.NewTimer(
+		meter,
+		"blob_verify_duration",
+		"time spent hashing a blob to verify its digest as it is written",
+		verifyDurationBounds,
+	)
+	if err != nil {
+		log.Warnw("creating blob verification metrics", "error", err)
+		return nil
+	}
+	return timer
+})
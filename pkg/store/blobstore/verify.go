@@ -0,0 +1,100 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/storacha/piri/pkg/presets"
+)
+
+// verifyingReader enforces the expected size and digest of data written to a
+// backend, hashing the data as the backend reads it. The final bytes are only
+// released once the digest has been verified, so a backend never commits
+// inconsistent data - the write fails before it completes.
+type verifyingReader struct {
+	ctx      context.Context
+	src      io.Reader
+	hash     hash.Hash // nil if the digest algorithm is not verified
+	hashName string
+	digest   []byte
+	size     uint64
+	read     uint64
+	verified bool
+	err      error
+	// hashing is the time spent hashing the data read so far.
+	hashing time.Duration
+}
+
+func newVerifyingReader(ctx context.Context, src io.Reader, digest multihash.Multihash, size uint64) *verifyingReader {
+	r := &verifyingReader{ctx: ctx, src: src, size: size}
+	dmh, err := multihash.Decode(digest)
+	if err != nil {
+		return r
+	}
+	// only digests we know how to compute are verified, others (e.g. piece
+	// commitments) are verified by the caller
+	name := multicodec.Code(dmh.Code).String()
+	if newHasher, ok := presets.HasherRegistry[name]; ok {
+		r.hash = newHasher()
+		r.hashName = name
+		r.digest = dmh.Digest
+	}
+	return r
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err := r.src.Read(p)
+	if uint64(n) > r.size-r.read {
+		return 0, r.fail(ErrTooLarge, verifyResultTooLarge)
+	}
+	r.read += uint64(n)
+	if r.hash != nil {
+		start := time.Now()
+		r.hash.Write(p[:n])
+		r.hashing += time.Since(start)
+	}
+
+	if r.read == r.size && !r.verified {
+		r.verified = true
+		if r.hash != nil {
+			start := time.Now()
+			sum := r.hash.Sum(nil)
+			r.hashing += time.Since(start)
+			if !bytes.Equal(sum, r.digest) {
+				return 0, r.fail(ErrDataInconsistent, verifyResultMismatch)
+			}
+			r.record(verifyResultOK)
+		}
+	}
+	if err == io.EOF && r.read < r.size {
+		return 0, r.fail(ErrTooSmall, verifyResultTooSmall)
+	}
+	return n, err
+}
+
+func (r *verifyingReader) fail(err error, result string) error {
+	r.err = err
+	r.record(result)
+	return err
+}
+
+// record reports the time spent hashing the blob once its verification
+// concludes.
+func (r *verifyingReader) record(result string) {
+	timer := verifyTimer()
+	if r.hash == nil || timer == nil {
+		return
+	}
+	timer.Record(r.ctx, r.hashing, attribute.String("hash", r.hashName), attribute.String("result", result))
+}
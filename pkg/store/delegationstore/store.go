@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"iter"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/storacha/go-ucanto/core/delegation"
@@ -23,6 +24,16 @@ type DelegationStore interface {
 	Put(context.Context, delegation.Delegation) error
 }
 
+// ListableDelegationStore is a DelegationStore whose delegations can be
+// listed and removed.
+type ListableDelegationStore interface {
+	DelegationStore
+	// List iterates over all delegations in the store.
+	List(context.Context) iter.Seq2[delegation.Delegation, error]
+	// Delete removes a delegation from the store.
+	Delete(context.Context, ucan.Link) error
+}
+
 // KeyEncoder defines how to encode keys for a specific backend.
 type KeyEncoder interface {
 	EncodeKey(link ucan.Link) string
@@ -34,7 +45,7 @@ type Store struct {
 	encoder KeyEncoder
 }
 
-var _ DelegationStore = (*Store)(nil)
+var _ ListableDelegationStore = (*Store)(nil)
 
 // New creates a DelegationStore with the given backend and key encoder.
 func New(backend objectstore.ListableStore, encoder KeyEncoder) *Store {
@@ -56,6 +67,17 @@ func (s *Store) Put(ctx context.Context, dlg delegation.Delegation) error {
 	return s.store.Put(ctx, s.encoder.EncodeKey(dlg.Link()), dlg)
 }
 
+func (s *Store) List(ctx context.Context) iter.Seq2[delegation.Delegation, error] {
+	return s.store.ListPrefix(ctx, "")
+}
+
+func (s *Store) Delete(ctx context.Context, link ucan.Link) error {
+	if err := s.store.Delete(ctx, s.encoder.EncodeKey(link)); err != nil {
+		return fmt.Errorf("deleting delegation: %w", err)
+	}
+	return nil
+}
+
 // Codec implements genericstore.Codec for delegation.Delegation.
 type Codec struct{}
 
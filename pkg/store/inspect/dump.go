@@ -0,0 +1,89 @@
+package inspect
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// Entry is a line of a dump: a JSON object holding a key and its base64
+// encoded value.
+type Entry struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// restoreBatchSize is the number of entries written per batch by Restore.
+const restoreBatchSize = 1000
+
+// Dump writes the entries of ds whose keys are under prefix to w, one JSON
+// object per line, and returns the number written. An empty prefix dumps
+// every entry.
+func Dump(ctx context.Context, w io.Writer, ds datastore.Read, prefix string) (int, error) {
+	results, err := ds.Query(ctx, query.Query{Prefix: prefix, Orders: []query.Order{query.OrderByKey{}}})
+	if err != nil {
+		return 0, fmt.Errorf("querying datastore: %w", err)
+	}
+	defer results.Close()
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	n := 0
+	for r := range results.Next() {
+		if r.Error != nil {
+			return n, fmt.Errorf("iterating datastore: %w", r.Error)
+		}
+		if err := enc.Encode(Entry{Key: r.Key, Value: r.Value}); err != nil {
+			return n, fmt.Errorf("writing entry %s: %w", r.Key, err)
+		}
+		n++
+	}
+	if err := bw.Flush(); err != nil {
+		return n, fmt.Errorf("writing dump: %w", err)
+	}
+	return n, nil
+}
+
+// Restore writes the entries of a dump read from r to ds and returns the
+// number written. Existing entries with the same keys are overwritten.
+func Restore(ctx context.Context, ds datastore.Batching, r io.Reader) (int, error) {
+	b, err := ds.Batch(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("creating batch: %w", err)
+	}
+	dec := json.NewDecoder(r)
+	n := 0
+	for {
+		var e Entry
+		if err := dec.Decode(&e); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return n, fmt.Errorf("reading entry %d: %w", n+1, err)
+		}
+		if e.Key == "" {
+			return n, fmt.Errorf("reading entry %d: missing key", n+1)
+		}
+		if err := b.Put(ctx, datastore.NewKey(e.Key), e.Value); err != nil {
+			return n, fmt.Errorf("writing entry %s: %w", e.Key, err)
+		}
+		n++
+		if n%restoreBatchSize == 0 {
+			if err := b.Commit(ctx); err != nil {
+				return n, fmt.Errorf("committing batch: %w", err)
+			}
+			if b, err = ds.Batch(ctx); err != nil {
+				return n, fmt.Errorf("creating batch: %w", err)
+			}
+		}
+	}
+	if err := b.Commit(ctx); err != nil {
+		return n, fmt.Errorf("committing batch: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,304 @@
+package inspect
+
+import (
+	"bytes"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/capabilities/space/content"
+	"github.com/storacha/go-libstoracha/capabilities/types"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/receipt/ran"
+	"github.com/storacha/go-ucanto/core/result"
+	ufailure "github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/store/acceptancestore"
+	"github.com/storacha/piri/pkg/store/acceptancestore/acceptance"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+	"github.com/storacha/piri/pkg/store/delegationstore"
+	"github.com/storacha/piri/pkg/store/receiptstore"
+)
+
+func TestPrefixes(t *testing.T) {
+	ds := datastore.NewMapDatastore()
+	for key, value := range map[string]string{
+		"/receipts/a":     "aaaa",
+		"/receipts/b":     "bb",
+		"/ranLinkIndex/a": "c",
+		"/root":           "",
+	} {
+		require.NoError(t, ds.Put(t.Context(), datastore.NewKey(key), []byte(value)))
+	}
+
+	stats, err := Prefixes(t.Context(), ds, 1)
+	require.NoError(t, err)
+	require.Equal(t, []PrefixStat{
+		{Prefix: "/ranLinkIndex", Entries: 1, Bytes: 1},
+		{Prefix: "/receipts", Entries: 2, Bytes: 6},
+		{Prefix: "/root", Entries: 1, Bytes: 0},
+	}, stats)
+
+	stats, err = Prefixes(t.Context(), ds, 2)
+	require.NoError(t, err)
+	require.Len(t, stats, 4)
+}
+
+func TestDumpRestore(t *testing.T) {
+	src := datastore.NewMapDatastore()
+	for _, key := range []string{"/a/1", "/a/2", "/b/1"} {
+		require.NoError(t, src.Put(t.Context(), datastore.NewKey(key), testutil.RandomBytes(t, 32)))
+	}
+
+	var buf bytes.Buffer
+	n, err := Dump(t.Context(), &buf, src, "/a")
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	dst := datastore.NewMapDatastore()
+	n, err = Restore(t.Context(), dst, &buf)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	for _, key := range []string{"/a/1", "/a/2"} {
+		want, err := src.Get(t.Context(), datastore.NewKey(key))
+		require.NoError(t, err)
+		got, err := dst.Get(t.Context(), datastore.NewKey(key))
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+	has, err := dst.Has(t.Context(), datastore.NewKey("/b/1"))
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func TestSnapshot(t *testing.T) {
+	allocations, receipts := datastore.NewMapDatastore(), datastore.NewMapDatastore()
+	for _, key := range []string{"/a/1", "/a/2"} {
+		require.NoError(t, allocations.Put(t.Context(), datastore.NewKey(key), testutil.RandomBytes(t, 32)))
+	}
+	require.NoError(t, receipts.Put(t.Context(), datastore.NewKey("/r/1"), testutil.RandomBytes(t, 32)))
+	blobs := []Blob{{Digest: "zQm1", Size: 10}, {Digest: "zQm2", Size: 20}}
+
+	var buf bytes.Buffer
+	manifest, err := CreateSnapshot(t.Context(), &buf, []SnapshotStore{
+		{Name: AllocationStore, DS: allocations},
+		{Name: "aggregator/datastore", DS: receipts},
+	}, func(yield func(Blob, error) bool) {
+		for _, b := range blobs {
+			if !yield(b, nil) {
+				return
+			}
+		}
+	})
+	require.NoError(t, err)
+	require.Equal(t, []StoreManifest{{Name: AllocationStore, Entries: 2}, {Name: "aggregator/datastore", Entries: 1}}, manifest.Stores)
+	require.Equal(t, BlobsManifest{Count: 2, Bytes: 30}, manifest.Blobs)
+
+	t.Run("restores stores and lists blobs", func(t *testing.T) {
+		dsts := map[string]datastore.Batching{}
+		var listed []Blob
+		restored, err := RestoreSnapshot(t.Context(), bytes.NewReader(buf.Bytes()), func(name string) (datastore.Batching, error) {
+			dsts[name] = datastore.NewMapDatastore()
+			return dsts[name], nil
+		}, func(b Blob) error {
+			listed = append(listed, b)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, manifest.Stores, restored.Stores)
+		require.Equal(t, blobs, listed)
+
+		got, err := dsts[AllocationStore].Get(t.Context(), datastore.NewKey("/a/2"))
+		require.NoError(t, err)
+		want, err := allocations.Get(t.Context(), datastore.NewKey("/a/2"))
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+		has, err := dsts["aggregator/datastore"].Has(t.Context(), datastore.NewKey("/r/1"))
+		require.NoError(t, err)
+		require.True(t, has)
+	})
+
+	t.Run("rejects archives without a manifest", func(t *testing.T) {
+		var dump bytes.Buffer
+		_, err := Dump(t.Context(), &dump, allocations, "")
+		require.NoError(t, err)
+		_, err = RestoreSnapshot(t.Context(), &dump, func(string) (datastore.Batching, error) {
+			return datastore.NewMapDatastore(), nil
+		}, nil)
+		require.Error(t, err)
+	})
+}
+
+type testStores struct {
+	Stores
+	allocations *allocationstore.Store
+	acceptances *acceptancestore.Store
+	claims      *delegationstore.Store
+	receipts    *receiptstore.Store
+}
+
+func newTestStores() testStores {
+	s := Stores{
+		Allocations: datastore.NewMapDatastore(),
+		Acceptances: datastore.NewMapDatastore(),
+		Claims:      datastore.NewMapDatastore(),
+		Receipts:    datastore.NewMapDatastore(),
+	}
+	return testStores{
+		Stores:      s,
+		allocations: allocationstore.NewDatastoreStore(s.Allocations),
+		acceptances: acceptancestore.NewDatastoreStore(s.Acceptances),
+		claims:      delegationstore.NewDatastoreStore(s.Claims),
+		receipts:    receiptstore.NewDatastoreStore(s.Receipts),
+	}
+}
+
+// putBlob stores the records of a blob accepted in space, skipping those
+// listed.
+func (s testStores) putBlob(t *testing.T, space did.DID, skip ...string) multihash.Multihash {
+	digest := testutil.RandomMultihash(t)
+	if !slices.Contains(skip, AllocationStore) {
+		require.NoError(t, s.allocations.Put(t.Context(), allocation.Allocation{
+			Space:   space,
+			Blob:    allocation.Blob{Digest: digest, Size: 128},
+			Expires: uint64(time.Now().Add(time.Hour).Unix()),
+			Cause:   testutil.RandomCID(t),
+		}))
+	}
+	if !slices.Contains(skip, AcceptanceStore) {
+		require.NoError(t, s.acceptances.Put(t.Context(), acceptance.Acceptance{
+			Space:      space,
+			Blob:       acceptance.Blob{Digest: digest, Size: 128},
+			ExecutedAt: uint64(time.Now().Unix()),
+			Cause:      testutil.RandomCID(t),
+		}))
+	}
+	if !slices.Contains(skip, ClaimStore) {
+		claim, err := assert.Location.Delegate(
+			testutil.Service,
+			space,
+			testutil.Service.DID().String(),
+			assert.LocationCaveats{
+				Space:    space,
+				Content:  types.FromHash(digest),
+				Location: []url.URL{*testutil.Must(url.Parse("https://piri.example.com/blob"))(t)},
+			},
+			delegation.WithNoExpiration(),
+		)
+		require.NoError(t, err)
+		require.NoError(t, s.claims.Put(t.Context(), claim))
+	}
+	return digest
+}
+
+func (s testStores) putReceipt(t *testing.T) receipt.AnyReceipt {
+	inv, err := content.Retrieve.Invoke(
+		testutil.Alice,
+		testutil.Service,
+		testutil.RandomDID(t).String(),
+		content.RetrieveCaveats{
+			Blob:  content.BlobDigest{Digest: testutil.RandomMultihash(t)},
+			Range: content.Range{Start: 0, End: 1023},
+		},
+	)
+	require.NoError(t, err)
+	rcpt, err := receipt.Issue(
+		testutil.Service,
+		result.Ok[content.RetrieveOk, ufailure.IPLDBuilderFailure](content.RetrieveOk{}),
+		ran.FromInvocation(inv),
+	)
+	require.NoError(t, err)
+	require.NoError(t, s.receipts.Put(t.Context(), rcpt))
+	return rcpt
+}
+
+func kinds(issues []Issue) []string {
+	var out []string
+	for _, i := range issues {
+		out = append(out, i.Kind)
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestVerify(t *testing.T) {
+	t.Run("consistent stores", func(t *testing.T) {
+		s := newTestStores()
+		s.putBlob(t, testutil.RandomDID(t))
+		s.putReceipt(t)
+
+		issues, err := Verify(t.Context(), s.Stores)
+		require.NoError(t, err)
+		require.Empty(t, issues)
+	})
+
+	t.Run("missing references", func(t *testing.T) {
+		s := newTestStores()
+		space := testutil.RandomDID(t)
+		s.putBlob(t, space, AllocationStore)
+		s.putBlob(t, space, ClaimStore)
+		s.putBlob(t, space, AcceptanceStore)
+
+		issues, err := Verify(t.Context(), s.Stores)
+		require.NoError(t, err)
+		require.Equal(t, []string{AcceptanceWithoutAllocation, AcceptanceWithoutClaim, ClaimWithoutAcceptance}, kinds(issues))
+		for _, i := range issues {
+			require.False(t, i.Repairable())
+		}
+	})
+
+	t.Run("repairs the receipt index", func(t *testing.T) {
+		s := newTestStores()
+		index := namespace.Wrap(s.Receipts, datastore.NewKey("ranLinkIndex"))
+
+		unindexed := s.putReceipt(t)
+		require.NoError(t, index.Delete(t.Context(), datastore.NewKey(unindexed.Ran().Link().String())))
+		dangling := testutil.RandomCID(t)
+		require.NoError(t, index.Put(t.Context(), datastore.NewKey(testutil.RandomCID(t).String()), []byte(dangling.Binary())))
+
+		issues, err := Verify(t.Context(), s.Stores)
+		require.NoError(t, err)
+		require.Equal(t, []string{DanglingRanIndex, UnindexedReceipt}, kinds(issues))
+
+		n, err := Repair(t.Context(), issues)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+
+		issues, err = Verify(t.Context(), s.Stores)
+		require.NoError(t, err)
+		require.Empty(t, issues)
+
+		got, err := s.receipts.GetByRan(t.Context(), unindexed.Ran().Link())
+		require.NoError(t, err)
+		require.Equal(t, unindexed.Root().Link(), got.Root().Link())
+	})
+
+	t.Run("deletes corrupt records", func(t *testing.T) {
+		s := newTestStores()
+		key := datastore.NewKey("/bafkqaaa/did:key:z6Mkcorrupt")
+		require.NoError(t, s.Acceptances.Put(t.Context(), key, []byte("not cbor")))
+
+		issues, err := Verify(t.Context(), s.Stores)
+		require.NoError(t, err)
+		require.Len(t, issues, 1)
+		require.Equal(t, CorruptRecord, issues[0].Kind)
+		require.Equal(t, key, issues[0].Key)
+
+		_, err = Repair(t.Context(), issues)
+		require.NoError(t, err)
+		has, err := s.Acceptances.Has(t.Context(), key)
+		require.NoError(t, err)
+		require.False(t, has)
+	})
+}
@@ -0,0 +1,62 @@
+// Package inspect examines and repairs the datastores of the local stores
+// while the node is stopped.
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+// PrefixStat counts the entries under a key prefix.
+type PrefixStat struct {
+	Prefix  string
+	Entries int
+	// Bytes is the total size of the values under the prefix.
+	Bytes int64
+}
+
+// Prefixes groups the entries of ds by the first depth namespaces of their
+// keys, sorted by prefix. A depth below 1 is treated as 1.
+func Prefixes(ctx context.Context, ds datastore.Read, depth int) ([]PrefixStat, error) {
+	depth = max(depth, 1)
+	results, err := ds.Query(ctx, query.Query{KeysOnly: true, ReturnsSizes: true})
+	if err != nil {
+		return nil, fmt.Errorf("querying datastore: %w", err)
+	}
+	defer results.Close()
+
+	stats := map[string]*PrefixStat{}
+	for r := range results.Next() {
+		if r.Error != nil {
+			return nil, fmt.Errorf("iterating datastore: %w", r.Error)
+		}
+		prefix := keyPrefix(r.Key, depth)
+		s, ok := stats[prefix]
+		if !ok {
+			s = &PrefixStat{Prefix: prefix}
+			stats[prefix] = s
+		}
+		s.Entries++
+		s.Bytes += int64(r.Size)
+	}
+
+	out := make([]PrefixStat, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Prefix < out[j].Prefix })
+	return out, nil
+}
+
+func keyPrefix(key string, depth int) string {
+	ns := datastore.RawKey(key).Namespaces()
+	if len(ns) > depth {
+		ns = ns[:depth]
+	}
+	return "/" + strings.Join(ns, "/")
+}
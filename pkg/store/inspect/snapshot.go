@@ -0,0 +1,276 @@
+package inspect
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// SnapshotVersion is the version of the snapshot format written by
+// CreateSnapshot.
+const SnapshotVersion = 1
+
+const (
+	manifestFile  = "manifest.json"
+	blobsFile     = "blobs.jsonl"
+	storesDir     = "stores/"
+	storeFileExt  = ".jsonl"
+	snapshotPerms = 0o600
+)
+
+// ErrNoManifest is returned when restoring a snapshot that does not start
+// with a manifest.
+var ErrNoManifest = errors.New("snapshot has no manifest")
+
+// Manifest describes the contents of a snapshot. It is the first file of the
+// archive.
+type Manifest struct {
+	Version int             `json:"version"`
+	Created time.Time       `json:"created"`
+	Stores  []StoreManifest `json:"stores"`
+	// Blobs counts the blobs listed in the blob manifest of the snapshot. The
+	// blobs themselves are not part of it.
+	Blobs BlobsManifest `json:"blobs"`
+}
+
+// StoreManifest is the name and number of entries of a store in a snapshot.
+type StoreManifest struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+}
+
+// BlobsManifest is the number and total size of the blobs listed in a
+// snapshot.
+type BlobsManifest struct {
+	Count int   `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Blob is a line of the blob manifest of a snapshot.
+type Blob struct {
+	// Digest is the multibase encoded multihash of the blob.
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
+
+// SnapshotStore is a store to include in a snapshot, named after its directory
+// relative to the data directory.
+type SnapshotStore struct {
+	Name string
+	DS   datastore.Read
+}
+
+// CreateSnapshot writes a gzipped tar archive of the entries of the stores,
+// in the format of Dump, and a manifest of the blobs, to w. Stores must not be
+// written to while the snapshot is created for it to be consistent. blobs may
+// be nil to list no blobs.
+//
+// The stores and blobs are first written to temporary files, so the manifest
+// leading the archive can count them.
+func CreateSnapshot(ctx context.Context, w io.Writer, stores []SnapshotStore, blobs iter.Seq2[Blob, error]) (Manifest, error) {
+	manifest := Manifest{Version: SnapshotVersion, Created: time.Now().UTC()}
+
+	dir, err := os.MkdirTemp("", "piri-snapshot-")
+	if err != nil {
+		return Manifest{}, fmt.Errorf("creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var files []string
+	for i, s := range stores {
+		tmp := filepath.Join(dir, fmt.Sprintf("store-%d", i))
+		n, err := writeTempFile(tmp, func(w io.Writer) (int, error) {
+			return Dump(ctx, w, s.DS, "")
+		})
+		if err != nil {
+			return Manifest{}, fmt.Errorf("snapshotting store %s: %w", s.Name, err)
+		}
+		manifest.Stores = append(manifest.Stores, StoreManifest{Name: s.Name, Entries: n})
+		files = append(files, tmp)
+	}
+
+	if blobs != nil {
+		tmp := filepath.Join(dir, "blobs")
+		_, err := writeTempFile(tmp, func(w io.Writer) (int, error) {
+			enc := json.NewEncoder(w)
+			for b, err := range blobs {
+				if err != nil {
+					return 0, err
+				}
+				if err := enc.Encode(b); err != nil {
+					return 0, err
+				}
+				manifest.Blobs.Count++
+				manifest.Blobs.Bytes += b.Size
+			}
+			return manifest.Blobs.Count, nil
+		})
+		if err != nil {
+			return Manifest{}, fmt.Errorf("listing blobs: %w", err)
+		}
+		files = append(files, tmp)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return Manifest{}, fmt.Errorf("encoding manifest: %w", err)
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	if err := writeFile(tw, manifestFile, int64(len(data)), bytes.NewReader(data)); err != nil {
+		return Manifest{}, err
+	}
+	for i, tmp := range files {
+		name := blobsFile
+		if i < len(stores) {
+			name = storesDir + stores[i].Name + storeFileExt
+		}
+		if err := copyFile(tw, name, tmp); err != nil {
+			return Manifest{}, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return Manifest{}, fmt.Errorf("writing snapshot: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return Manifest{}, fmt.Errorf("writing snapshot: %w", err)
+	}
+	return manifest, nil
+}
+
+func writeTempFile(name string, write func(io.Writer) (int, error)) (int, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+	n, err := write(bw)
+	if err != nil {
+		return n, err
+	}
+	if err := bw.Flush(); err != nil {
+		return n, err
+	}
+	return n, f.Close()
+}
+
+// copyFile adds the file at src to the archive as name.
+func copyFile(tw *tar.Writer, name, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return writeFile(tw, name, info.Size(), f)
+}
+
+func writeFile(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{Name: name, Size: size, Mode: snapshotPerms, ModTime: time.Now()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return nil
+}
+
+// RestoreSnapshot writes the entries of the stores in a snapshot read from r
+// to the datastores returned by open, and calls blob for every blob listed in
+// the snapshot, if it is not nil. Existing entries with the same keys are
+// overwritten. The number of entries restored to each store is checked
+// against the manifest, which is returned.
+func RestoreSnapshot(ctx context.Context, r io.Reader, open func(name string) (datastore.Batching, error), blob func(Blob) error) (Manifest, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("reading snapshot: %w", err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	hdr, err := tr.Next()
+	if err != nil && err != io.EOF {
+		return Manifest{}, fmt.Errorf("reading snapshot: %w", err)
+	}
+	if err == io.EOF || path.Clean(hdr.Name) != manifestFile {
+		return Manifest{}, ErrNoManifest
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return Manifest{}, fmt.Errorf("reading manifest: %w", err)
+	}
+	if manifest.Version != SnapshotVersion {
+		return manifest, fmt.Errorf("unsupported snapshot version %d", manifest.Version)
+	}
+
+	restored := map[string]int{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return manifest, fmt.Errorf("reading snapshot: %w", err)
+		}
+		name := path.Clean(hdr.Name)
+		switch {
+		case name == blobsFile:
+			if blob == nil {
+				continue
+			}
+			dec := json.NewDecoder(tr)
+			for {
+				var b Blob
+				if err := dec.Decode(&b); err != nil {
+					if err == io.EOF {
+						break
+					}
+					return manifest, fmt.Errorf("reading blob manifest: %w", err)
+				}
+				if err := blob(b); err != nil {
+					return manifest, err
+				}
+			}
+		case strings.HasPrefix(name, storesDir) && strings.HasSuffix(name, storeFileExt):
+			store := strings.TrimSuffix(strings.TrimPrefix(name, storesDir), storeFileExt)
+			ds, err := open(store)
+			if err != nil {
+				return manifest, err
+			}
+			n, err := Restore(ctx, ds, tr)
+			if err != nil {
+				return manifest, fmt.Errorf("restoring store %s: %w", store, err)
+			}
+			restored[store] = n
+		default:
+			return manifest, fmt.Errorf("unexpected file in snapshot: %s", hdr.Name)
+		}
+	}
+
+	for _, s := range manifest.Stores {
+		if restored[s.Name] != s.Entries {
+			return manifest, fmt.Errorf("restored %d entries of store %s, the manifest lists %d", restored[s.Name], s.Name, s.Entries)
+		}
+	}
+	return manifest, nil
+}
@@ -0,0 +1,309 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/multiformats/go-multihash"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/digestutil"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/did"
+
+	"github.com/storacha/piri/pkg/store/acceptancestore/acceptance"
+	"github.com/storacha/piri/pkg/store/allocationstore"
+	"github.com/storacha/piri/pkg/store/allocationstore/allocation"
+	"github.com/storacha/piri/pkg/store/delegationstore"
+	"github.com/storacha/piri/pkg/store/receiptstore"
+)
+
+// Kinds of inconsistency found by Verify.
+const (
+	// CorruptRecord is an entry whose value cannot be decoded. Repairing it
+	// deletes the entry.
+	CorruptRecord = "corrupt-record"
+	// AcceptanceWithoutAllocation is a blob accepted in a space it was never
+	// allocated in.
+	AcceptanceWithoutAllocation = "acceptance-without-allocation"
+	// AcceptanceWithoutClaim is an accepted blob with no location claim, left
+	// behind when the node stopped between storing the two.
+	AcceptanceWithoutClaim = "acceptance-without-claim"
+	// ClaimWithoutAcceptance is a location claim for a blob that was never
+	// accepted.
+	ClaimWithoutAcceptance = "claim-without-acceptance"
+	// DanglingRanIndex is an entry of the receipt index pointing at a receipt
+	// that is not stored. Repairing it deletes the entry.
+	DanglingRanIndex = "dangling-ran-index"
+	// UnindexedReceipt is a receipt missing from the index of receipts by the
+	// invocation they are for. Repairing it adds the index entry.
+	UnindexedReceipt = "unindexed-receipt"
+)
+
+// Store names, the directories of the stores relative to the data directory.
+const (
+	AllocationStore = "allocation"
+	AcceptanceStore = "acceptance"
+	ClaimStore      = "claim"
+	ReceiptStore    = "receipt"
+)
+
+// Stores are the datastores checked by Verify.
+type Stores struct {
+	Allocations datastore.Datastore
+	Acceptances datastore.Datastore
+	Claims      datastore.Datastore
+	Receipts    datastore.Datastore
+}
+
+// Issue is an inconsistency found by Verify.
+type Issue struct {
+	Kind string
+	// Store is the name of the store holding the entry.
+	Store  string
+	Key    datastore.Key
+	Detail string
+
+	fix func(context.Context) error
+}
+
+// Repairable reports whether [Repair] can fix the issue.
+func (i Issue) Repairable() bool {
+	return i.fix != nil
+}
+
+// Verify checks the records of the allocation, acceptance, claim and receipt
+// stores and the references between them.
+func Verify(ctx context.Context, s Stores) ([]Issue, error) {
+	var issues []Issue
+
+	// blobs accepted in a space, by digest and space
+	accepted := map[string]bool{}
+	err := each(ctx, s.Acceptances, func(key datastore.Key, value []byte) error {
+		acc, err := acceptance.Codec{}.Decode(value)
+		if err != nil {
+			issues = append(issues, corrupt(s.Acceptances, AcceptanceStore, key, err))
+			return nil
+		}
+		accepted[blobKey(acc.Blob.Digest, acc.Space)] = true
+
+		allocKey := datastore.NewKey(allocationstore.DatastoreKeyEncoder{}.EncodeKey(acc.Blob.Digest, acc.Space))
+		has, err := s.Allocations.Has(ctx, allocKey)
+		if err != nil {
+			return fmt.Errorf("checking allocation %s: %w", allocKey, err)
+		}
+		if !has {
+			issues = append(issues, Issue{
+				Kind:   AcceptanceWithoutAllocation,
+				Store:  AcceptanceStore,
+				Key:    key,
+				Detail: fmt.Sprintf("no allocation of %s in %s", digestutil.Format(acc.Blob.Digest), acc.Space),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking acceptances: %w", err)
+	}
+
+	err = each(ctx, s.Allocations, func(key datastore.Key, value []byte) error {
+		if _, err := (allocation.Codec{}).Decode(value); err != nil {
+			issues = append(issues, corrupt(s.Allocations, AllocationStore, key, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking allocations: %w", err)
+	}
+
+	// blobs with a location claim, by digest and space
+	claimed := map[string]bool{}
+	err = each(ctx, s.Claims, func(key datastore.Key, value []byte) error {
+		dlg, err := delegationstore.Codec{}.Decode(value)
+		if err != nil {
+			issues = append(issues, corrupt(s.Claims, ClaimStore, key, err))
+			return nil
+		}
+		caps := dlg.Capabilities()
+		if len(caps) == 0 || caps[0].Can() != assert.LocationAbility {
+			return nil
+		}
+		nb, rerr := assert.LocationCaveatsReader.Read(caps[0].Nb())
+		if rerr != nil {
+			issues = append(issues, corrupt(s.Claims, ClaimStore, key, rerr))
+			return nil
+		}
+		bk := blobKey(nb.Content.Hash(), nb.Space)
+		claimed[bk] = true
+		if !accepted[bk] {
+			issues = append(issues, Issue{
+				Kind:   ClaimWithoutAcceptance,
+				Store:  ClaimStore,
+				Key:    key,
+				Detail: fmt.Sprintf("no acceptance of %s in %s", digestutil.Format(nb.Content.Hash()), nb.Space),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking claims: %w", err)
+	}
+	err = each(ctx, s.Acceptances, func(key datastore.Key, value []byte) error {
+		acc, err := acceptance.Codec{}.Decode(value)
+		if err != nil {
+			// already reported
+			return nil
+		}
+		if !claimed[blobKey(acc.Blob.Digest, acc.Space)] {
+			issues = append(issues, Issue{
+				Kind:   AcceptanceWithoutClaim,
+				Store:  AcceptanceStore,
+				Key:    key,
+				Detail: fmt.Sprintf("no location claim for %s in %s", digestutil.Format(acc.Blob.Digest), acc.Space),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking acceptances: %w", err)
+	}
+
+	receiptIssues, err := verifyReceipts(ctx, s.Receipts)
+	if err != nil {
+		return nil, fmt.Errorf("checking receipts: %w", err)
+	}
+	return append(issues, receiptIssues...), nil
+}
+
+// verifyReceipts checks the receipts and the index of receipts by the
+// invocation they are for, laid out as by [receiptstore.NewDatastoreStore].
+func verifyReceipts(ctx context.Context, ds datastore.Datastore) ([]Issue, error) {
+	var issues []Issue
+	receipts := namespace.Wrap(ds, datastore.NewKey("receipts"))
+	index := namespace.Wrap(ds, datastore.NewKey("ranLinkIndex"))
+	// root of a stored receipt, by the invocation it is for
+	stored := map[string]ipld.Link{}
+
+	err := each(ctx, receipts, func(key datastore.Key, value []byte) error {
+		fullKey := datastore.NewKey("receipts").Child(key)
+		rcpt, err := receiptstore.Codec{}.Decode(value)
+		if err != nil {
+			issues = append(issues, corrupt(ds, ReceiptStore, fullKey, err))
+			return nil
+		}
+		ran := rcpt.Ran().Link()
+		root := rcpt.Root().Link()
+		stored[ran.String()] = root
+		indexKey := datastore.NewKey(ran.String())
+		// an entry pointing at another receipt for the same invocation is
+		// left alone, a dangling one is reported below
+		has, err := index.Has(ctx, indexKey)
+		if err != nil {
+			return fmt.Errorf("checking index entry %s: %w", indexKey, err)
+		}
+		if has {
+			return nil
+		}
+		issues = append(issues, Issue{
+			Kind:   UnindexedReceipt,
+			Store:  ReceiptStore,
+			Key:    fullKey,
+			Detail: fmt.Sprintf("receipt for %s is not indexed", ran),
+			fix: func(ctx context.Context) error {
+				return index.Put(ctx, indexKey, []byte(root.Binary()))
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = each(ctx, index, func(key datastore.Key, value []byte) error {
+		fullKey := datastore.NewKey("ranLinkIndex").Child(key)
+		c, err := cid.Cast(value)
+		if err != nil {
+			issues = append(issues, corrupt(ds, ReceiptStore, fullKey, err))
+			return nil
+		}
+		has, err := receipts.Has(ctx, datastore.NewKey(c.String()))
+		if err != nil {
+			return fmt.Errorf("checking receipt %s: %w", c, err)
+		}
+		if !has {
+			issue := Issue{
+				Kind:   DanglingRanIndex,
+				Store:  ReceiptStore,
+				Key:    fullKey,
+				Detail: fmt.Sprintf("receipt %s is not stored", c),
+				fix: func(ctx context.Context) error {
+					return ds.Delete(ctx, fullKey)
+				},
+			}
+			// point the entry at another stored receipt for the invocation
+			if root, ok := stored[key.Name()]; ok {
+				issue.fix = func(ctx context.Context) error {
+					return index.Put(ctx, key, []byte(root.Binary()))
+				}
+			}
+			issues = append(issues, issue)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// Repair fixes the repairable issues and returns the number fixed.
+func Repair(ctx context.Context, issues []Issue) (int, error) {
+	n := 0
+	for _, i := range issues {
+		if i.fix == nil {
+			continue
+		}
+		if err := i.fix(ctx); err != nil {
+			return n, fmt.Errorf("repairing %s %s: %w", i.Kind, i.Key, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+func corrupt(ds datastore.Datastore, store string, key datastore.Key, err error) Issue {
+	return Issue{
+		Kind:   CorruptRecord,
+		Store:  store,
+		Key:    key,
+		Detail: err.Error(),
+		fix: func(ctx context.Context) error {
+			return ds.Delete(ctx, key)
+		},
+	}
+}
+
+func blobKey(digest multihash.Multihash, space did.DID) string {
+	return digestutil.Format(digest) + "/" + space.String()
+}
+
+// each calls fn with every entry of ds.
+func each(ctx context.Context, ds datastore.Read, fn func(datastore.Key, []byte) error) error {
+	results, err := ds.Query(ctx, query.Query{})
+	if err != nil {
+		return fmt.Errorf("querying datastore: %w", err)
+	}
+	defer results.Close()
+	for r := range results.Next() {
+		if r.Error != nil {
+			return fmt.Errorf("iterating datastore: %w", r.Error)
+		}
+		if err := fn(datastore.RawKey(r.Key), r.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
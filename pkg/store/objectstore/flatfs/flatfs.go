@@ -8,8 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -33,10 +35,11 @@ var (
 
 const (
 	opPut = iota
+	opPutFile
 	opDelete
 )
 
-var _ objectstore.Store = (*Store)(nil)
+var _ objectstore.ListableStore = (*Store)(nil)
 
 var (
 	ErrStoreExists         = errors.New("datastore already exists")
@@ -78,6 +81,7 @@ type op struct {
 	key   string    // datastore key. Mandatory.
 	size  uint64    // value size in bytes
 	value io.Reader // value
+	file  string    // path of the file to move in place, for opPutFile
 }
 
 // opMap is a synchronisation structure where a single op can be stored
@@ -304,10 +308,34 @@ func (fs *Store) Put(ctx context.Context, key string, size uint64, value io.Read
 	return err
 }
 
+// PutFile stores the file at path under key, moving it in place rather than
+// copying its content when it is on the same filesystem as the datastore. The
+// file is removed once stored.
+func (fs *Store) PutFile(ctx context.Context, key string, path string) error {
+	if !keyIsValid(key) {
+		return fmt.Errorf("when putting %q: %w", key, ErrInvalidKey)
+	}
+
+	fs.shutdownLock.RLock()
+	defer fs.shutdownLock.RUnlock()
+	if fs.shutdown {
+		return ErrClosed
+	}
+
+	_, err := fs.doWriteOp(&op{
+		typ:  opPutFile,
+		key:  key,
+		file: path,
+	})
+	return err
+}
+
 func (fs *Store) doOp(oper *op) error {
 	switch oper.typ {
 	case opPut:
 		return fs.doPut(oper.key, oper.size, oper.value)
+	case opPutFile:
+		return fs.doPutFile(oper.key, oper.file)
 	case opDelete:
 		return fs.doDelete(oper.key)
 	default:
@@ -404,6 +432,45 @@ func (fs *Store) doPut(key string, size uint64, val io.Reader) error {
 	return nil
 }
 
+func (fs *Store) doPutFile(key string, file string) error {
+	dir, path := fs.encode(key)
+	if err := fs.makeDir(dir); err != nil {
+		return err
+	}
+
+	err := fs.rename(file, path)
+	if errors.Is(err, syscall.EXDEV) {
+		// on another filesystem, copy the content instead
+		err = fs.copyFile(key, file)
+	}
+	if err != nil {
+		return err
+	}
+
+	if fs.sync {
+		if err := syncDir(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *Store) copyFile(key string, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := fs.doPut(key, uint64(info.Size()), f); err != nil {
+		return err
+	}
+	return os.Remove(file)
+}
+
 func (fs *Store) Get(ctx context.Context, key string, opts ...objectstore.GetOption) (objectstore.Object, error) {
 	// Can't exist in datastore.
 	if !keyIsValid(key) {
@@ -484,6 +551,63 @@ func (fs *Store) doDelete(key string) error {
 	return err
 }
 
+// Exists reports whether an object is stored under the key.
+func (fs *Store) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := fs.getSize(key)
+	if err != nil {
+		if errors.Is(err, objectstore.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ListPrefix iterates the keys of the stored objects that start with prefix,
+// walking each shard directory in turn. Keys are not ordered, and objects
+// written or removed while listing may or may not be included.
+func (fs *Store) ListPrefix(ctx context.Context, prefix string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		shards, err := os.ReadDir(fs.path)
+		if err != nil {
+			yield("", fmt.Errorf("reading datastore directory: %w", err))
+			return
+		}
+		for _, shard := range shards {
+			// skips the temp directory as well as regular files such as
+			// SHARDING
+			if !shard.IsDir() || strings.HasPrefix(shard.Name(), ".") {
+				continue
+			}
+			entries, err := os.ReadDir(filepath.Join(fs.path, shard.Name()))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				yield("", fmt.Errorf("reading shard directory %s: %w", shard.Name(), err))
+				return
+			}
+			for _, e := range entries {
+				if ctx.Err() != nil {
+					yield("", ctx.Err())
+					return
+				}
+				name := e.Name()
+				if e.IsDir() || !strings.HasSuffix(name, extension) {
+					continue
+				}
+				key := strings.TrimSuffix(name, extension)
+				if !keyIsValid(key) || !strings.HasPrefix(key, prefix) {
+					continue
+				}
+				if !yield(key, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
 func (fs *Store) tempFile() (*os.File, error) {
 	file, err := tempFile(fs.tempPath, "temp-")
 	return file, err
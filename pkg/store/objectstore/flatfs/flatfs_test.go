@@ -9,6 +9,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -124,6 +125,44 @@ func testGet(dirFunc mkShardFunc, t *testing.T) {
 
 func TestGet(t *testing.T) { tryAllShardFuncs(t, testGet) }
 
+func testPutFile(dirFunc mkShardFunc, t *testing.T) {
+	temp, cleanup := tempdir(t)
+	defer cleanup()
+	defer checkTemp(t, filepath.Join(temp, "datastore"))
+
+	fs, err := flatfs.New(filepath.Join(temp, "datastore"), dirFunc(2), false)
+	if err != nil {
+		t.Fatalf("New fail: %v\n", err)
+	}
+	defer fs.Close()
+
+	const input = "foobar"
+	file := filepath.Join(temp, "stashed")
+	if err := os.WriteFile(file, []byte(input), 0644); err != nil {
+		t.Fatalf("WriteFile fail: %v\n", err)
+	}
+	if err := fs.PutFile(bg, "quux", file); err != nil {
+		t.Fatalf("PutFile fail: %v\n", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatalf("expected the file to be moved, got: %v", err)
+	}
+
+	obj, err := fs.Get(bg, "quux")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	g, err := io.ReadAll(obj.Body())
+	if err != nil {
+		t.Fatalf("Read all failed: %v", err)
+	}
+	if string(g) != input {
+		t.Fatalf("Get gave wrong content: %q != %q", string(g), input)
+	}
+}
+
+func TestPutFile(t *testing.T) { tryAllShardFuncs(t, testPutFile) }
+
 func testGetRange(dirFunc mkShardFunc, t *testing.T) {
 	temp, cleanup := tempdir(t)
 	defer cleanup()
@@ -474,3 +513,44 @@ func BenchmarkConsecutivePut(b *testing.B) {
 	}
 	b.StopTimer() // avoid counting cleanup
 }
+
+func testListPrefix(dirFunc mkShardFunc, t *testing.T) {
+	temp, cleanup := tempdir(t)
+	defer cleanup()
+
+	fs, err := flatfs.New(temp, dirFunc(2), false)
+	if err != nil {
+		t.Fatalf("New fail: %v\n", err)
+	}
+	defer fs.Close()
+
+	value := []byte("foobar")
+	for _, k := range []string{"quux", "quuz", "qaax"} {
+		if err := fs.Put(bg, k, uint64(len(value)), bytes.NewReader(value)); err != nil {
+			t.Fatalf("Put fail: %v\n", err)
+		}
+	}
+
+	var keys []string
+	for k, err := range fs.ListPrefix(bg, "quu") {
+		if err != nil {
+			t.Fatalf("ListPrefix fail: %v\n", err)
+		}
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"quux", "quuz"}) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+
+	ok, err := fs.Exists(bg, "qaax")
+	if err != nil || !ok {
+		t.Fatalf("expected qaax to exist: %v", err)
+	}
+	ok, err = fs.Exists(bg, "nope")
+	if err != nil || ok {
+		t.Fatalf("expected nope not to exist: %v", err)
+	}
+}
+
+func TestListPrefix(t *testing.T) { tryAllShardFuncs(t, testListPrefix) }
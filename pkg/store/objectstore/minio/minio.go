@@ -17,11 +17,24 @@ import (
 var log = logging.Logger("objectstore/minio")
 
 type Store struct {
-	client *minio.Client
-	bucket string
+	client   *minio.Client
+	bucket   string
+	partSize uint64
 }
 
-func New(endpoint, bucket string, opts minio.Options) (*Store, error) {
+// Option configures a Store.
+type Option func(*Store)
+
+// WithPartSize sets the part size used for multipart uploads. Objects larger
+// than the part size are streamed to the bucket in parts, so they never need
+// to be held in memory or on disk in full. Zero lets the client choose.
+func WithPartSize(size uint64) Option {
+	return func(s *Store) {
+		s.partSize = size
+	}
+}
+
+func New(endpoint, bucket string, opts minio.Options, options ...Option) (*Store, error) {
 	client, err := minio.New(endpoint, &opts)
 	if err != nil {
 		return nil, err
@@ -38,10 +51,14 @@ func New(endpoint, bucket string, opts minio.Options) (*Store, error) {
 		}
 	}
 
-	return &Store{
+	s := &Store{
 		client: client,
 		bucket: bucket,
-	}, nil
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s, nil
 }
 
 func (s *Store) IsOnline() bool {
@@ -61,7 +78,7 @@ func (s *Store) Put(ctx context.Context, key string, size uint64, body io.Reader
 		key,
 		body,
 		int64(size),
-		minio.PutObjectOptions{},
+		minio.PutObjectOptions{PartSize: s.partSize},
 	)
 	if err != nil {
 		log.Errorw("failed to put object", "bucket", s.bucket, "key", key, "size", size, "error", err)
@@ -0,0 +1,267 @@
+package pack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+const (
+	// DefaultCompactInterval is the time between compactions.
+	DefaultCompactInterval = time.Hour
+	// compactLiveRatio is the share of a sealed pack that must still be live
+	// for it to be kept as is.
+	compactLiveRatio = 0.5
+)
+
+// CompactStats reports the work done by a compaction.
+type CompactStats struct {
+	// Packs is the number of packs rewritten or emptied.
+	Packs int
+	// Objects is the number of objects moved to the active pack.
+	Objects int
+	// Reclaimed is the size of the packs removed.
+	Reclaimed uint64
+}
+
+type indexEntry struct {
+	key string
+	loc location
+}
+
+// Compact rewrites sealed packs that are mostly deleted objects, or small
+// because the node restarted before they filled, by moving their live objects
+// to the active pack. The emptied packs are removed by the next compaction,
+// so reads of objects located before they moved can complete.
+func (s *Store) Compact(ctx context.Context) (CompactStats, error) {
+	var stats CompactStats
+
+	s.mu.Lock()
+	retired := s.retired
+	s.retired = nil
+	activeNum := s.activeNum
+	s.mu.Unlock()
+	for _, num := range retired {
+		path := s.packPath(num)
+		info, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return stats, fmt.Errorf("removing pack %d: %w", num, err)
+		}
+		if err := os.Remove(path); err != nil {
+			return stats, fmt.Errorf("removing pack %d: %w", num, err)
+		}
+		stats.Reclaimed += uint64(info.Size())
+	}
+
+	packs, err := s.packs()
+	if err != nil {
+		return stats, err
+	}
+	live := map[uint64]uint64{}
+	for e, err := range s.entries(ctx) {
+		if err != nil {
+			return stats, err
+		}
+		live[e.loc.pack] += e.loc.size
+	}
+
+	rewrite := map[uint64]bool{}
+	for _, num := range packs {
+		if num >= activeNum {
+			continue
+		}
+		info, err := os.Stat(s.packPath(num))
+		if err != nil {
+			return stats, fmt.Errorf("reading pack %d: %w", num, err)
+		}
+		size := uint64(info.Size())
+		switch {
+		case live[num] == 0:
+			// nothing to move, e.g. the pack of an earlier run that was
+			// emptied before the node stopped
+			s.retire(num)
+			stats.Packs++
+		case float64(live[num]) < float64(size)*compactLiveRatio || size < s.maxSize/4:
+			rewrite[num] = true
+		}
+	}
+	if len(rewrite) == 0 {
+		return stats, nil
+	}
+
+	var moves []indexEntry
+	for e, err := range s.entries(ctx) {
+		if err != nil {
+			return stats, err
+		}
+		if rewrite[e.loc.pack] {
+			moves = append(moves, e)
+		}
+	}
+	for _, e := range moves {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		moved, err := s.move(ctx, e)
+		if err != nil {
+			return stats, fmt.Errorf("moving %s from pack %d: %w", e.key, e.loc.pack, err)
+		}
+		if moved {
+			stats.Objects++
+		}
+	}
+	for num := range rewrite {
+		s.retire(num)
+		stats.Packs++
+	}
+	return stats, nil
+}
+
+func (s *Store) retire(num uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retired = append(s.retired, num)
+}
+
+// move copies a packed object to the active pack. It is not moved if it was
+// deleted or written again since it was listed.
+func (s *Store) move(ctx context.Context, e indexEntry) (bool, error) {
+	f, err := os.Open(s.packPath(e.loc.pack))
+	if err != nil {
+		return false, err
+	}
+	data := make([]byte, e.loc.size)
+	_, err = f.ReadAt(data, int64(e.loc.offset))
+	f.Close()
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.index.Get(ctx, indexKey(e.key))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	cur, err := decodeLocation(b)
+	if err != nil {
+		return false, err
+	}
+	if cur != e.loc {
+		return false, nil
+	}
+	loc, err := s.appendLocked(e.key, data)
+	if err != nil {
+		return false, err
+	}
+	if err := s.index.Put(ctx, indexKey(e.key), loc.encode()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// entries iterates the index.
+func (s *Store) entries(ctx context.Context) iter.Seq2[indexEntry, error] {
+	return func(yield func(indexEntry, error) bool) {
+		results, err := s.index.Query(ctx, query.Query{})
+		if err != nil {
+			yield(indexEntry{}, fmt.Errorf("querying pack index: %w", err))
+			return
+		}
+		defer results.Close()
+		for res := range results.Next() {
+			if res.Error != nil {
+				yield(indexEntry{}, fmt.Errorf("iterating pack index: %w", res.Error))
+				return
+			}
+			loc, err := decodeLocation(res.Value)
+			if err != nil {
+				yield(indexEntry{}, fmt.Errorf("pack index entry %s: %w", res.Key, err))
+				return
+			}
+			if !yield(indexEntry{key: res.Key[1:], loc: loc}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Compactor compacts a store periodically.
+type Compactor struct {
+	store    *Store
+	interval time.Duration
+	cancel   context.CancelFunc
+	stopped  chan struct{}
+}
+
+// NewCompactor creates a compactor running every interval, or every
+// [DefaultCompactInterval] if interval is zero.
+func NewCompactor(store *Store, interval time.Duration) *Compactor {
+	if interval <= 0 {
+		interval = DefaultCompactInterval
+	}
+	return &Compactor{
+		store:    store,
+		interval: interval,
+		stopped:  make(chan struct{}),
+	}
+}
+
+func (c *Compactor) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	go c.run(ctx)
+}
+
+func (c *Compactor) run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	defer close(c.stopped)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			stats, err := c.store.Compact(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				// continue with the next compaction
+				log.Errorw("compacting packs", "error", err)
+				continue
+			}
+			if stats.Packs > 0 || stats.Reclaimed > 0 {
+				log.Infow("compacted packs", "packs", stats.Packs, "objects", stats.Objects, "reclaimed", stats.Reclaimed, "duration", time.Since(start))
+			}
+		}
+	}
+}
+
+// Stop interrupts a running compaction and waits for it to return.
+func (c *Compactor) Stop(ctx context.Context) error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+	select {
+	case <-c.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package pack
+
+import (
+	"io"
+	"os"
+
+	"github.com/storacha/piri/pkg/store/objectstore"
+)
+
+// packObject reads a packed object from its pack. The pack is opened when the
+// body is read.
+type packObject struct {
+	path      string
+	loc       location
+	byteRange objectstore.Range
+}
+
+func (o packObject) Size() int64 {
+	return int64(o.loc.size)
+}
+
+func (o packObject) Body() io.ReadCloser {
+	f, err := os.Open(o.path)
+	if err != nil {
+		r, w := io.Pipe()
+		w.CloseWithError(err)
+		return r
+	}
+	length := o.loc.size - o.byteRange.Start
+	if o.byteRange.End != nil {
+		length = *o.byteRange.End - o.byteRange.Start + 1
+	}
+	return sectionReadCloser{
+		SectionReader: io.NewSectionReader(f, int64(o.loc.offset+o.byteRange.Start), int64(length)),
+		f:             f,
+	}
+}
+
+type sectionReadCloser struct {
+	*io.SectionReader
+	f *os.File
+}
+
+func (r sectionReadCloser) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,493 @@
+// Package pack is an object store that appends small objects to large pack
+// files rather than writing a file per object, so that nodes holding many
+// small blobs do not run out of inodes. Objects above a size threshold are
+// stored in a backend store as before.
+//
+// A pack file starts with a magic header followed by records of
+//
+//	uvarint(len(key)) | key | uvarint(len(data)) | data
+//
+// Packs are only appended to. The location of each packed object is kept in
+// an index, deleting an object removes it from the index and leaves its
+// record in place until [Store.Compact] rewrites the pack.
+package pack
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/storacha/piri/pkg/store/objectstore"
+)
+
+var log = logging.Logger("objectstore/pack")
+
+const (
+	// DefaultThreshold is the size up to which objects are packed.
+	DefaultThreshold = 1 << 20
+	// DefaultMaxPackSize is the size a pack is sealed at and a new one
+	// started.
+	DefaultMaxPackSize = 256 << 20
+
+	packExt = ".pack"
+)
+
+// magic starts every pack file.
+var magic = []byte("piripack\x01")
+
+var (
+	ErrClosed     = errors.New("pack store closed")
+	ErrInvalidKey = errors.New("key not supported by pack store")
+	ErrCorrupt    = errors.New("corrupt pack record")
+)
+
+// Backend stores the objects too large to be packed.
+type Backend interface {
+	objectstore.ListableStore
+	PutFile(ctx context.Context, key string, path string) error
+}
+
+var _ objectstore.ListableStore = (*Store)(nil)
+
+// Store packs objects up to a threshold in size into pack files and stores
+// larger objects in a backend.
+type Store struct {
+	dir       string
+	backend   Backend
+	index     datastore.Batching
+	threshold uint64
+	maxSize   uint64
+	sync      bool
+
+	// mu serialises appends to the active pack and changes to the index, so
+	// compaction does not overwrite the location of an object written or
+	// deleted while it was being moved.
+	mu         sync.Mutex
+	active     *os.File
+	activeNum  uint64
+	activeSize uint64
+	closed     bool
+
+	// retired are packs emptied by the last compaction, removed by the next
+	// so reads that looked up a location in them can complete.
+	retired []uint64
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithThreshold sets the size up to which objects are packed. Zero packs
+// nothing, objects already packed stay readable.
+func WithThreshold(size uint64) Option {
+	return func(s *Store) {
+		s.threshold = size
+	}
+}
+
+// WithMaxPackSize sets the size a pack is sealed at.
+func WithMaxPackSize(size uint64) Option {
+	return func(s *Store) {
+		s.maxSize = size
+	}
+}
+
+// WithSync syncs packs to disk after every write.
+func WithSync(sync bool) Option {
+	return func(s *Store) {
+		s.sync = sync
+	}
+}
+
+// New opens the pack store in dir, creating it if needed. The first object
+// written after opening starts a new pack, records torn by a crash at the end
+// of the previous one are never indexed and are dropped by compaction.
+func New(dir string, backend Backend, index datastore.Batching, opts ...Option) (*Store, error) {
+	s := &Store{
+		dir:       dir,
+		backend:   backend,
+		index:     index,
+		threshold: DefaultThreshold,
+		maxSize:   DefaultMaxPackSize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating pack directory: %w", err)
+	}
+	packs, err := s.packs()
+	if err != nil {
+		return nil, err
+	}
+	s.activeNum = 1
+	if len(packs) > 0 {
+		s.activeNum = packs[len(packs)-1] + 1
+	}
+	return s, nil
+}
+
+// location is where the data of a packed object is.
+type location struct {
+	pack   uint64
+	offset uint64
+	size   uint64
+}
+
+func (l location) encode() []byte {
+	buf := make([]byte, 0, 3*binary.MaxVarintLen64)
+	buf = binary.AppendUvarint(buf, l.pack)
+	buf = binary.AppendUvarint(buf, l.offset)
+	return binary.AppendUvarint(buf, l.size)
+}
+
+func decodeLocation(b []byte) (location, error) {
+	var l location
+	for _, v := range []*uint64{&l.pack, &l.offset, &l.size} {
+		n, read := binary.Uvarint(b)
+		if read <= 0 {
+			return location{}, fmt.Errorf("decoding pack location: %w", ErrCorrupt)
+		}
+		*v = n
+		b = b[read:]
+	}
+	return l, nil
+}
+
+func indexKey(key string) datastore.Key {
+	return datastore.NewKey(key)
+}
+
+func keyIsValid(key string) bool {
+	return key != "" && !strings.ContainsAny(key, "/\\")
+}
+
+func (s *Store) packPath(num uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%08d%s", num, packExt))
+}
+
+// packs lists the numbers of the packs in the directory in ascending order.
+func (s *Store) packs() ([]uint64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading pack directory: %w", err)
+	}
+	var nums []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, packExt) {
+			continue
+		}
+		num, err := strconv.ParseUint(strings.TrimSuffix(name, packExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		nums = append(nums, num)
+	}
+	// ReadDir sorts by name and the names are zero padded
+	return nums, nil
+}
+
+// openPack creates the active pack. It must be called with mu held.
+func (s *Store) openPack() error {
+	num := s.activeNum
+	f, err := os.OpenFile(s.packPath(num), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("creating pack %d: %w", num, err)
+	}
+	if _, err := f.Write(magic); err != nil {
+		f.Close()
+		return fmt.Errorf("writing pack %d header: %w", num, err)
+	}
+	if s.sync {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return fmt.Errorf("syncing pack %d: %w", num, err)
+		}
+	}
+	s.active = f
+	s.activeSize = uint64(len(magic))
+	return nil
+}
+
+// appendLocked appends a record to the active pack, sealing it first if the
+// record does not fit, and returns the location of its data. It must be
+// called with mu held.
+func (s *Store) appendLocked(key string, data []byte) (location, error) {
+	if s.closed {
+		return location{}, ErrClosed
+	}
+	header := binary.AppendUvarint(nil, uint64(len(key)))
+	header = append(header, key...)
+	header = binary.AppendUvarint(header, uint64(len(data)))
+	recordSize := uint64(len(header) + len(data))
+
+	if s.active != nil && s.activeSize > uint64(len(magic)) && s.activeSize+recordSize > s.maxSize {
+		err := s.active.Close()
+		s.active = nil
+		s.activeNum++
+		if err != nil {
+			return location{}, fmt.Errorf("sealing pack %d: %w", s.activeNum-1, err)
+		}
+	}
+	if s.active == nil {
+		if err := s.openPack(); err != nil {
+			return location{}, err
+		}
+	}
+
+	record := make([]byte, 0, recordSize)
+	record = append(record, header...)
+	record = append(record, data...)
+	if _, err := s.active.Write(record); err != nil {
+		// a partial write leaves bytes no index entry points to, keep
+		// appending after them
+		if info, statErr := s.active.Stat(); statErr == nil {
+			s.activeSize = uint64(info.Size())
+		}
+		return location{}, fmt.Errorf("appending to pack %d: %w", s.activeNum, err)
+	}
+	if s.sync {
+		if err := s.active.Sync(); err != nil {
+			return location{}, fmt.Errorf("syncing pack %d: %w", s.activeNum, err)
+		}
+	}
+	loc := location{
+		pack:   s.activeNum,
+		offset: s.activeSize + uint64(len(header)),
+		size:   uint64(len(data)),
+	}
+	s.activeSize += recordSize
+	return loc, nil
+}
+
+// packed returns the location of a packed object, or false if the object is
+// not packed.
+func (s *Store) packed(ctx context.Context, key string) (location, bool, error) {
+	b, err := s.index.Get(ctx, indexKey(key))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return location{}, false, nil
+		}
+		return location{}, false, fmt.Errorf("reading pack index: %w", err)
+	}
+	loc, err := decodeLocation(b)
+	if err != nil {
+		return location{}, false, err
+	}
+	return loc, true, nil
+}
+
+func (s *Store) packable(size uint64) bool {
+	return s.threshold > 0 && size <= s.threshold
+}
+
+// Put packs objects up to the threshold in size and stores larger ones in the
+// backend.
+func (s *Store) Put(ctx context.Context, key string, size uint64, data io.Reader) error {
+	if !s.packable(size) {
+		return s.backend.Put(ctx, key, size, data)
+	}
+	if !keyIsValid(key) {
+		return fmt.Errorf("when putting %q: %w", key, ErrInvalidKey)
+	}
+	var buf bytes.Buffer
+	buf.Grow(int(size))
+	// read one more byte than expected to detect an oversized body
+	n, err := io.Copy(&buf, io.LimitReader(data, int64(size)+1))
+	if err != nil {
+		return fmt.Errorf("reading object: %w", err)
+	}
+	if uint64(n) != size {
+		return fmt.Errorf("put object size mismatch: got %d, expected %d", n, size)
+	}
+	return s.put(ctx, key, buf.Bytes())
+}
+
+func (s *Store) put(ctx context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loc, err := s.appendLocked(key, data)
+	if err != nil {
+		return err
+	}
+	if err := s.index.Put(ctx, indexKey(key), loc.encode()); err != nil {
+		return fmt.Errorf("indexing packed object: %w", err)
+	}
+	return nil
+}
+
+// PutFile packs the file at path if it is small enough and removes it, or
+// moves it to the backend otherwise.
+func (s *Store) PutFile(ctx context.Context, key string, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !s.packable(uint64(info.Size())) {
+		return s.backend.PutFile(ctx, key, path)
+	}
+	if !keyIsValid(key) {
+		return fmt.Errorf("when putting %q: %w", key, ErrInvalidKey)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := s.put(ctx, key, data); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Get returns a packed object, or the object from the backend if it is not
+// packed.
+func (s *Store) Get(ctx context.Context, key string, opts ...objectstore.GetOption) (objectstore.Object, error) {
+	if !keyIsValid(key) {
+		return nil, objectstore.ErrNotExist
+	}
+	loc, ok, err := s.packed(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return s.backend.Get(ctx, key, opts...)
+	}
+
+	cfg := objectstore.NewGetConfig()
+	cfg.ProcessOptions(opts)
+	r := cfg.Range()
+	if !rangeSatisfiable(r.Start, r.End, loc.size) {
+		return nil, objectstore.ErrRangeNotSatisfiable{Range: r}
+	}
+	return packObject{path: s.packPath(loc.pack), loc: loc, byteRange: r}, nil
+}
+
+// Delete removes the object from the index and the backend. The space of a
+// packed object is reclaimed when its pack is compacted.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	if !keyIsValid(key) {
+		return nil
+	}
+	s.mu.Lock()
+	err := s.index.Delete(ctx, indexKey(key))
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("removing packed object: %w", err)
+	}
+	return s.backend.Delete(ctx, key)
+}
+
+// Exists reports whether the object is packed or in the backend.
+func (s *Store) Exists(ctx context.Context, key string) (bool, error) {
+	if !keyIsValid(key) {
+		return false, nil
+	}
+	ok, err := s.index.Has(ctx, indexKey(key))
+	if err != nil {
+		return false, fmt.Errorf("reading pack index: %w", err)
+	}
+	if ok {
+		return true, nil
+	}
+	return s.backend.Exists(ctx, key)
+}
+
+// ListPrefix iterates the keys of the packed objects and then of the objects
+// in the backend that start with prefix. An object in both is listed once.
+func (s *Store) ListPrefix(ctx context.Context, prefix string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for key, err := range s.indexed(ctx) {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			if !yield(key, nil) {
+				return
+			}
+		}
+		for key, err := range s.backend.ListPrefix(ctx, prefix) {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			ok, err := s.index.Has(ctx, indexKey(key))
+			if err != nil {
+				yield("", fmt.Errorf("reading pack index: %w", err))
+				return
+			}
+			if ok {
+				continue
+			}
+			if !yield(key, nil) {
+				return
+			}
+		}
+	}
+}
+
+// indexed iterates the keys of the packed objects.
+func (s *Store) indexed(ctx context.Context) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		results, err := s.index.Query(ctx, query.Query{KeysOnly: true})
+		if err != nil {
+			yield("", fmt.Errorf("querying pack index: %w", err))
+			return
+		}
+		defer results.Close()
+		for res := range results.Next() {
+			if res.Error != nil {
+				yield("", fmt.Errorf("iterating pack index: %w", res.Error))
+				return
+			}
+			if !yield(strings.TrimPrefix(res.Key, "/"), nil) {
+				return
+			}
+		}
+	}
+}
+
+// Close closes the active pack, if one was started. The index and the backend are closed by
+// their owners.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.active == nil {
+		return nil
+	}
+	return s.active.Close()
+}
+
+func rangeSatisfiable(start uint64, end *uint64, size uint64) bool {
+	if size > 0 && start >= size {
+		return false
+	}
+	if end != nil {
+		if start > *end {
+			return false
+		}
+		if *end >= size {
+			return false
+		}
+	}
+	return true
+}
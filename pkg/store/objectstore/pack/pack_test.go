@@ -0,0 +1,175 @@
+package pack
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+
+	"github.com/storacha/piri/pkg/store/objectstore"
+	"github.com/storacha/piri/pkg/store/objectstore/flatfs"
+)
+
+func newStore(t *testing.T, index datastore.Batching, opts ...Option) (*Store, *flatfs.Store) {
+	dir := t.TempDir()
+	backend, err := flatfs.New(filepath.Join(dir, "flatfs"), flatfs.NextToLast(2), false)
+	require.NoError(t, err)
+	s, err := New(filepath.Join(dir, "packs"), backend, index, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s, backend
+}
+
+func readAll(t *testing.T, s objectstore.Store, key string, opts ...objectstore.GetOption) []byte {
+	obj, err := s.Get(t.Context(), key, opts...)
+	require.NoError(t, err)
+	body := obj.Body()
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	return data
+}
+
+func TestStore(t *testing.T) {
+	ctx := t.Context()
+	s, backend := newStore(t, dssync.MutexWrap(datastore.NewMapDatastore()), WithThreshold(16))
+
+	small := []byte("small object")
+	large := bytes.Repeat([]byte("l"), 64)
+	require.NoError(t, s.Put(ctx, "small", uint64(len(small)), bytes.NewReader(small)))
+	require.NoError(t, s.Put(ctx, "large", uint64(len(large)), bytes.NewReader(large)))
+
+	// only the large object has a file of its own
+	ok, err := backend.Exists(ctx, "small")
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = backend.Exists(ctx, "large")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Equal(t, small, readAll(t, s, "small"))
+	require.Equal(t, large, readAll(t, s, "large"))
+	end := uint64(4)
+	require.Equal(t, []byte("mall"), readAll(t, s, "small", objectstore.WithRange(objectstore.Range{Start: 1, End: &end})))
+	require.Equal(t, []byte("object"), readAll(t, s, "small", objectstore.WithRange(objectstore.Range{Start: 6})))
+	_, err = s.Get(ctx, "small", objectstore.WithRange(objectstore.Range{Start: 100}))
+	require.ErrorAs(t, err, &objectstore.ErrRangeNotSatisfiable{})
+
+	var keys []string
+	for key, err := range s.ListPrefix(ctx, "") {
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	require.Equal(t, []string{"large", "small"}, keys)
+
+	t.Run("size mismatch", func(t *testing.T) {
+		err := s.Put(ctx, "short", 10, strings.NewReader("abc"))
+		require.Error(t, err)
+		err = s.Put(ctx, "long", 2, strings.NewReader("abc"))
+		require.Error(t, err)
+		ok, err := s.Exists(ctx, "long")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("put file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "stashed")
+		require.NoError(t, os.WriteFile(path, []byte("from a file"), 0644))
+		require.NoError(t, s.PutFile(ctx, "file", path))
+		require.NoFileExists(t, path)
+		require.Equal(t, []byte("from a file"), readAll(t, s, "file"))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, s.Delete(ctx, "small"))
+		require.NoError(t, s.Delete(ctx, "large"))
+		for _, key := range []string{"small", "large"} {
+			ok, err := s.Exists(ctx, key)
+			require.NoError(t, err)
+			require.False(t, ok)
+			_, err = s.Get(ctx, key)
+			require.ErrorIs(t, err, objectstore.ErrNotExist)
+		}
+	})
+}
+
+func TestStoreReopen(t *testing.T) {
+	ctx := t.Context()
+	index := dssync.MutexWrap(datastore.NewMapDatastore())
+	dir := t.TempDir()
+	backend, err := flatfs.New(filepath.Join(dir, "flatfs"), flatfs.NextToLast(2), false)
+	require.NoError(t, err)
+
+	s, err := New(filepath.Join(dir, "packs"), backend, index)
+	require.NoError(t, err)
+	require.NoError(t, s.Put(ctx, "a", 1, strings.NewReader("a")))
+	require.NoError(t, s.Close())
+
+	// packing disabled, packed objects stay readable
+	s, err = New(filepath.Join(dir, "packs"), backend, index, WithThreshold(0))
+	require.NoError(t, err)
+	defer s.Close()
+	require.Equal(t, []byte("a"), readAll(t, s, "a"))
+	require.NoError(t, s.Put(ctx, "b", 1, strings.NewReader("b")))
+	ok, err := backend.Exists(ctx, "b")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// no pack is started until an object is packed
+	packs, err := s.packs()
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1}, packs)
+}
+
+func TestCompact(t *testing.T) {
+	ctx := t.Context()
+	s, _ := newStore(t, dssync.MutexWrap(datastore.NewMapDatastore()), WithMaxPackSize(64))
+
+	data := bytes.Repeat([]byte("x"), 20)
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		require.NoError(t, s.Put(ctx, key, uint64(len(data)), bytes.NewReader(data)))
+	}
+	// two objects fit in a pack
+	packs, err := s.packs()
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2, 3}, packs)
+
+	// pack 1 is emptied, pack 2 is half deleted, pack 3 is active
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, s.Delete(ctx, key))
+	}
+
+	stats, err := s.Compact(ctx)
+	require.NoError(t, err)
+	require.Equal(t, CompactStats{Packs: 2, Objects: 1}, stats)
+	for _, key := range []string{"d", "e", "f"} {
+		require.Equal(t, data, readAll(t, s, key))
+	}
+	loc, ok, err := s.packed(ctx, "d")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(4), loc.pack)
+
+	// emptied packs are removed by the next compaction
+	packs, err = s.packs()
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2, 3, 4}, packs)
+	stats, err = s.Compact(ctx)
+	require.NoError(t, err)
+	require.NotZero(t, stats.Reclaimed)
+	packs, err = s.packs()
+	require.NoError(t, err)
+	require.NotContains(t, packs, uint64(1))
+	require.NotContains(t, packs, uint64(2))
+	for _, key := range []string{"d", "e", "f"} {
+		require.Equal(t, data, readAll(t, s, key))
+	}
+}
@@ -0,0 +1,189 @@
+package receiptstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/ucan"
+
+	"github.com/storacha/piri/pkg/store"
+	"github.com/storacha/piri/pkg/store/objectstore"
+)
+
+// LogEntry records a receipt stored in a Store.
+type LogEntry struct {
+	Root ucan.Link
+	Ran  ucan.Link
+	// Size is the total size of the blocks of the receipt in bytes.
+	Size     uint64
+	StoredAt time.Time
+}
+
+// Log records the receipts stored in a Store in the order they were stored,
+// so they can be expired by age or count.
+type Log interface {
+	// Append records a stored receipt.
+	Append(ctx context.Context, entry LogEntry) error
+	// Entries iterates the recorded receipts, oldest first.
+	Entries(ctx context.Context) iter.Seq2[LogEntry, error]
+	// Remove removes the record of a receipt.
+	Remove(ctx context.Context, entry LogEntry) error
+	// Backfilled reports whether receipts stored before the log was kept
+	// have been recorded.
+	Backfilled(ctx context.Context) (bool, error)
+	// SetBackfilled records that receipts stored before the log was kept
+	// have been recorded.
+	SetBackfilled(ctx context.Context) error
+}
+
+// Pruner removes receipts from a store, oldest first. It is implemented by
+// stores that keep a Log.
+type Pruner interface {
+	// Get retrieves a receipt by its CID.
+	Get(context.Context, ucan.Link) (receipt.AnyReceipt, error)
+	// Stored iterates the receipts in the store, oldest first.
+	Stored(ctx context.Context) iter.Seq2[LogEntry, error]
+	// Remove deletes a receipt, its ran index entry and its log entry.
+	Remove(ctx context.Context, entry LogEntry) error
+	// Backfill records the receipts stored before the log was kept, as
+	// stored at the given time. It does nothing once they are recorded.
+	Backfill(ctx context.Context, at time.Time) (int, error)
+}
+
+const (
+	entriesPrefix = "/entries/"
+	backfilledKey = "/backfilled"
+)
+
+// DatastoreLog implements Log using a datastore. Entries are keyed by the
+// time the receipt was stored followed by its root, so they are listed in
+// the order receipts were stored.
+type DatastoreLog struct {
+	ds datastore.Datastore
+}
+
+var _ Log = (*DatastoreLog)(nil)
+
+func NewDatastoreLog(ds datastore.Datastore) *DatastoreLog {
+	return &DatastoreLog{ds: ds}
+}
+
+type logValue struct {
+	Ran  string `json:"ran"`
+	Size uint64 `json:"size"`
+}
+
+func logKey(entry LogEntry) datastore.Key {
+	// zero padded so keys sort by time
+	return datastore.NewKey(fmt.Sprintf("%s%020d/%s", entriesPrefix, entry.StoredAt.UnixNano(), entry.Root.String()))
+}
+
+func (l *DatastoreLog) Append(ctx context.Context, entry LogEntry) error {
+	data, err := json.Marshal(logValue{Ran: entry.Ran.String(), Size: entry.Size})
+	if err != nil {
+		return fmt.Errorf("encoding receipt log entry: %w", err)
+	}
+	if err := l.ds.Put(ctx, logKey(entry), data); err != nil {
+		return fmt.Errorf("storing receipt log entry: %w", err)
+	}
+	return nil
+}
+
+func (l *DatastoreLog) Entries(ctx context.Context) iter.Seq2[LogEntry, error] {
+	return func(yield func(LogEntry, error) bool) {
+		results, err := l.ds.Query(ctx, query.Query{
+			Prefix: strings.TrimSuffix(entriesPrefix, "/"),
+			Orders: []query.Order{query.OrderByKey{}},
+		})
+		if err != nil {
+			yield(LogEntry{}, fmt.Errorf("querying receipt log: %w", err))
+			return
+		}
+		defer results.Close()
+
+		for res := range results.Next() {
+			if res.Error != nil {
+				yield(LogEntry{}, fmt.Errorf("iterating receipt log: %w", res.Error))
+				return
+			}
+			entry, err := decodeLogEntry(res.Key, res.Value)
+			if !yield(entry, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (l *DatastoreLog) Remove(ctx context.Context, entry LogEntry) error {
+	if err := l.ds.Delete(ctx, logKey(entry)); err != nil {
+		return fmt.Errorf("removing receipt log entry: %w", err)
+	}
+	return nil
+}
+
+func (l *DatastoreLog) Backfilled(ctx context.Context) (bool, error) {
+	return l.ds.Has(ctx, datastore.NewKey(backfilledKey))
+}
+
+func (l *DatastoreLog) SetBackfilled(ctx context.Context) error {
+	return l.ds.Put(ctx, datastore.NewKey(backfilledKey), []byte{1})
+}
+
+func decodeLogEntry(key string, value []byte) (LogEntry, error) {
+	ts, root, ok := strings.Cut(strings.TrimPrefix(key, entriesPrefix), "/")
+	if !ok {
+		return LogEntry{}, fmt.Errorf("invalid receipt log key: %s", key)
+	}
+	nanos, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return LogEntry{}, fmt.Errorf("parsing receipt log time %s: %w", ts, err)
+	}
+	rootCid, err := cid.Parse(root)
+	if err != nil {
+		return LogEntry{}, fmt.Errorf("parsing receipt log root %s: %w", root, err)
+	}
+	var v logValue
+	if err := json.Unmarshal(value, &v); err != nil {
+		return LogEntry{}, fmt.Errorf("decoding receipt log entry %s: %w", key, err)
+	}
+	ranCid, err := cid.Parse(v.Ran)
+	if err != nil {
+		return LogEntry{}, fmt.Errorf("parsing receipt log ran %s: %w", v.Ran, err)
+	}
+	return LogEntry{
+		Root:     cidlink.Link{Cid: rootCid},
+		Ran:      cidlink.Link{Cid: ranCid},
+		Size:     v.Size,
+		StoredAt: time.Unix(0, nanos),
+	}, nil
+}
+
+// receiptSize returns the total size of the blocks of a receipt.
+func receiptSize(rcpt receipt.AnyReceipt) uint64 {
+	var size uint64
+	for b, err := range rcpt.Blocks() {
+		if err != nil {
+			break
+		}
+		size += uint64(len(b.Bytes()))
+	}
+	return size
+}
+
+// isNotFound reports whether err is a not found error of a backend.
+func isNotFound(err error) bool {
+	return errors.Is(err, store.ErrNotFound) ||
+		errors.Is(err, datastore.ErrNotFound) ||
+		errors.Is(err, objectstore.ErrNotExist)
+}
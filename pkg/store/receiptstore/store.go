@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"strings"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
@@ -42,6 +44,11 @@ type RanLinkIndex interface {
 	Get(ctx context.Context, ran datamodel.Link) (datamodel.Link, error)
 }
 
+// RanLinkDeleter is implemented by ran link indexes that can remove entries.
+type RanLinkDeleter interface {
+	Delete(ctx context.Context, ran datamodel.Link) error
+}
+
 // KeyEncoder defines how to encode keys for a specific backend.
 type KeyEncoder interface {
 	EncodeKey(link ucan.Link) string
@@ -52,17 +59,35 @@ type Store struct {
 	store        *genericstore.Store[receipt.AnyReceipt]
 	ranLinkIndex RanLinkIndex
 	encoder      KeyEncoder
+	log          Log
 }
 
-var _ ReceiptStore = (*Store)(nil)
+var (
+	_ ReceiptStore = (*Store)(nil)
+	_ Pruner       = (*Store)(nil)
+)
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithLog records stored receipts in log, so they can be pruned.
+func WithLog(log Log) Option {
+	return func(s *Store) {
+		s.log = log
+	}
+}
 
 // New creates a ReceiptStore with the given backend,  key encoder, and ran link index.
-func New(backend objectstore.ListableStore, encoder KeyEncoder, ranLinkIndex RanLinkIndex) *Store {
-	return &Store{
+func New(backend objectstore.ListableStore, encoder KeyEncoder, ranLinkIndex RanLinkIndex, opts ...Option) *Store {
+	s := &Store{
 		store:        genericstore.New[receipt.AnyReceipt](backend, Codec{}),
 		ranLinkIndex: ranLinkIndex,
 		encoder:      encoder,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *Store) Get(ctx context.Context, link ucan.Link) (receipt.AnyReceipt, error) {
@@ -95,9 +120,100 @@ func (s *Store) Put(ctx context.Context, rcpt receipt.AnyReceipt) error {
 	if err != nil {
 		return fmt.Errorf("indexing receipt by ran: %w", err)
 	}
+	if s.log != nil {
+		err = s.log.Append(ctx, LogEntry{
+			Root:     rcpt.Root().Link(),
+			Ran:      rcpt.Ran().Link(),
+			Size:     receiptSize(rcpt),
+			StoredAt: time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("logging receipt: %w", err)
+		}
+	}
+	return nil
+}
+
+// Stored iterates the receipts in the store, oldest first. Nothing is
+// iterated if the store keeps no log.
+func (s *Store) Stored(ctx context.Context) iter.Seq2[LogEntry, error] {
+	if s.log == nil {
+		return func(func(LogEntry, error) bool) {}
+	}
+	return s.log.Entries(ctx)
+}
+
+// Remove deletes a receipt, its ran index entry, unless the ran is indexed to
+// a newer receipt, and its log entry.
+func (s *Store) Remove(ctx context.Context, entry LogEntry) error {
+	if err := s.store.Delete(ctx, s.encoder.EncodeKey(entry.Root)); err != nil && !isNotFound(err) {
+		return fmt.Errorf("deleting receipt: %w", err)
+	}
+	if idx, ok := s.ranLinkIndex.(RanLinkDeleter); ok {
+		root, err := s.ranLinkIndex.Get(ctx, entry.Ran)
+		switch {
+		case err == nil && root.String() == entry.Root.String():
+			if err := idx.Delete(ctx, entry.Ran); err != nil {
+				return fmt.Errorf("deleting ran index entry: %w", err)
+			}
+		case err != nil && !isNotFound(err):
+			return fmt.Errorf("looking up root by ran: %w", err)
+		}
+	}
+	if s.log != nil {
+		if err := s.log.Remove(ctx, entry); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Backfill records the receipts stored before the store kept a log, as
+// stored at the given time, and returns how many were recorded.
+func (s *Store) Backfill(ctx context.Context, at time.Time) (int, error) {
+	if s.log == nil {
+		return 0, nil
+	}
+	done, err := s.log.Backfilled(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("checking receipt log backfill: %w", err)
+	}
+	if done {
+		return 0, nil
+	}
+	// receipts stored since the log was kept are already recorded
+	logged := map[string]struct{}{}
+	for entry, err := range s.log.Entries(ctx) {
+		if err != nil {
+			return 0, fmt.Errorf("listing receipt log: %w", err)
+		}
+		logged[entry.Root.String()] = struct{}{}
+	}
+	count := 0
+	for rcpt, err := range s.store.ListPrefix(ctx, "") {
+		if err != nil {
+			return count, fmt.Errorf("listing receipts: %w", err)
+		}
+		if _, ok := logged[rcpt.Root().Link().String()]; ok {
+			continue
+		}
+		err := s.log.Append(ctx, LogEntry{
+			Root:     rcpt.Root().Link(),
+			Ran:      rcpt.Ran().Link(),
+			Size:     receiptSize(rcpt),
+			StoredAt: at,
+		})
+		if err != nil {
+			return count, fmt.Errorf("logging receipt: %w", err)
+		}
+		count++
+	}
+	if err := s.log.SetBackfilled(ctx); err != nil {
+		return count, fmt.Errorf("recording receipt log backfill: %w", err)
+	}
+	return count, nil
+}
+
 // Codec implements genericstore.Codec for receipt.AnyReceipt.
 type Codec struct{}
 
@@ -148,6 +264,10 @@ func (idx *S3RanLinkIndex) Put(ctx context.Context, ran datamodel.Link, lnk data
 	return idx.store.Put(ctx, key, uint64(len(cidStr)), strings.NewReader(cidStr))
 }
 
+func (idx *S3RanLinkIndex) Delete(ctx context.Context, ran datamodel.Link) error {
+	return idx.store.Delete(ctx, idx.prefix+ran.String()+".ref")
+}
+
 func (idx *S3RanLinkIndex) Get(ctx context.Context, ran datamodel.Link) (datamodel.Link, error) {
 	key := idx.prefix + ran.String() + ".ref"
 	obj, err := idx.store.Get(ctx, key)
@@ -178,6 +298,10 @@ func (idx *DatastoreRanLinkIndex) Put(ctx context.Context, ran datamodel.Link, l
 	return idx.ds.Put(ctx, datastore.NewKey(ran.String()), []byte(lnk.Binary()))
 }
 
+func (idx *DatastoreRanLinkIndex) Delete(ctx context.Context, ran datamodel.Link) error {
+	return idx.ds.Delete(ctx, datastore.NewKey(ran.String()))
+}
+
 func (idx *DatastoreRanLinkIndex) Get(ctx context.Context, ran datamodel.Link) (datamodel.Link, error) {
 	data, err := idx.ds.Get(ctx, datastore.NewKey(ran.String()))
 	if err != nil {
@@ -201,12 +325,15 @@ func NewS3Store(backend *minio.Store) *Store {
 }
 
 // NewDatastoreStore creates a ReceiptStore for LevelDB/datastore backends.
+// Stored receipts are logged so they can be pruned.
 func NewDatastoreStore(ds datastore.Datastore) *Store {
 	receiptsDs := namespace.Wrap(ds, datastore.NewKey("receipts/"))
 	ranIndexDs := namespace.Wrap(ds, datastore.NewKey("ranLinkIndex/"))
+	logDs := namespace.Wrap(ds, datastore.NewKey("receiptLog/"))
 	return New(
 		dsadapter.New(receiptsDs),
 		DatastoreKeyEncoder{},
 		&DatastoreRanLinkIndex{ds: ranIndexDs},
+		WithLog(NewDatastoreLog(logDs)),
 	)
 }
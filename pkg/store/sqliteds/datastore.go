@@ -0,0 +1,287 @@
+// Package sqliteds is a datastore backed by a single SQLite database.
+//
+// Keys and values are kept in one table, so several stores can share a
+// database by wrapping it in a [namespace]. Reads use a pool of connections
+// and writes a single connection, so iterating a query while writing does not
+// block.
+//
+// [namespace]: https://pkg.go.dev/github.com/ipfs/go-datastore/namespace
+package sqliteds
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+
+	"github.com/storacha/piri/pkg/database/sqlitedb"
+)
+
+const schema = `CREATE TABLE IF NOT EXISTS datastore (
+	key TEXT PRIMARY KEY,
+	value BLOB NOT NULL
+) WITHOUT ROWID`
+
+// Datastore is a [datastore.Batching] backed by a SQLite database.
+type Datastore struct {
+	read  *sql.DB
+	write *sql.DB
+}
+
+var _ datastore.Batching = (*Datastore)(nil)
+
+// New opens, creating if needed, the SQLite database at path.
+func New(path string) (*Datastore, error) {
+	write, err := sqlitedb.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening datastore for writing: %w", err)
+	}
+	if _, err := write.Exec(schema); err != nil {
+		write.Close()
+		return nil, fmt.Errorf("creating datastore table: %w", err)
+	}
+	read, err := sqlitedb.New(path)
+	if err != nil {
+		write.Close()
+		return nil, fmt.Errorf("opening datastore for reading: %w", err)
+	}
+	read.SetMaxOpenConns(runtime.NumCPU())
+	read.SetMaxIdleConns(runtime.NumCPU())
+	return &Datastore{read: read, write: write}, nil
+}
+
+func (d *Datastore) Get(ctx context.Context, key datastore.Key) ([]byte, error) {
+	var value []byte
+	err := d.read.QueryRowContext(ctx, `SELECT value FROM datastore WHERE key = ?`, key.String()).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datastore.ErrNotFound
+		}
+		return nil, fmt.Errorf("getting %s: %w", key, err)
+	}
+	return value, nil
+}
+
+func (d *Datastore) Has(ctx context.Context, key datastore.Key) (bool, error) {
+	var exists bool
+	err := d.read.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM datastore WHERE key = ?)`, key.String()).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("checking %s: %w", key, err)
+	}
+	return exists, nil
+}
+
+func (d *Datastore) GetSize(ctx context.Context, key datastore.Key) (int, error) {
+	var size int
+	err := d.read.QueryRowContext(ctx, `SELECT length(value) FROM datastore WHERE key = ?`, key.String()).Scan(&size)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, datastore.ErrNotFound
+		}
+		return -1, fmt.Errorf("getting size of %s: %w", key, err)
+	}
+	return size, nil
+}
+
+func (d *Datastore) Put(ctx context.Context, key datastore.Key, value []byte) error {
+	return put(ctx, d.write, key, value)
+}
+
+func (d *Datastore) Delete(ctx context.Context, key datastore.Key) error {
+	return del(ctx, d.write, key)
+}
+
+// Query returns the entries matching q. Only the prefix is applied by
+// SQLite, filters, orders, offset and limit are applied to its results.
+func (d *Datastore) Query(ctx context.Context, q query.Query) (query.Results, error) {
+	columns := "key, value"
+	if q.KeysOnly {
+		columns = "key, length(value)"
+	}
+	stmt := `SELECT ` + columns + ` FROM datastore`
+	var args []any
+	if prefix := prefixOf(q.Prefix); prefix != "/" {
+		// keys from prefix up to, but excluding, the next possible prefix
+		stmt += ` WHERE key >= ? AND key < ?`
+		args = append(args, prefix, prefix[:len(prefix)-1]+"0")
+	}
+	stmt += ` ORDER BY key`
+
+	rows, err := d.read.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying datastore: %w", err)
+	}
+
+	// the prefix has been applied, the remaining parts of the query are
+	// applied naively
+	naive := q
+	naive.Prefix = ""
+	results := query.ResultsFromIterator(naive, query.Iterator{
+		Next: func() (query.Result, bool) {
+			if !rows.Next() {
+				if err := rows.Err(); err != nil {
+					return query.Result{Error: err}, true
+				}
+				return query.Result{}, false
+			}
+			var (
+				e    query.Entry
+				data []byte
+				size int
+			)
+			var err error
+			if q.KeysOnly {
+				err = rows.Scan(&e.Key, &size)
+			} else {
+				err = rows.Scan(&e.Key, &data)
+				size = len(data)
+			}
+			if err != nil {
+				return query.Result{Error: err}, true
+			}
+			if !q.KeysOnly {
+				e.Value = data
+			}
+			if q.ReturnsSizes || q.KeysOnly {
+				e.Size = size
+			}
+			return query.Result{Entry: e}, true
+		},
+		Close: rows.Close,
+	})
+	// report the query as asked, not as applied naively
+	return query.ResultsReplaceQuery(query.NaiveQueryApply(naive, results), q), nil
+}
+
+func (d *Datastore) Sync(context.Context, datastore.Key) error {
+	return nil
+}
+
+func (d *Datastore) Batch(context.Context) (datastore.Batch, error) {
+	return &batch{ds: d, ops: map[datastore.Key]op{}}, nil
+}
+
+func (d *Datastore) Close() error {
+	return errors.Join(d.read.Close(), d.write.Close())
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func put(ctx context.Context, db execer, key datastore.Key, value []byte) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO datastore (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value`,
+		key.String(), value,
+	)
+	if err != nil {
+		return fmt.Errorf("putting %s: %w", key, err)
+	}
+	return nil
+}
+
+func del(ctx context.Context, db execer, key datastore.Key) error {
+	if _, err := db.ExecContext(ctx, `DELETE FROM datastore WHERE key = ?`, key.String()); err != nil {
+		return fmt.Errorf("deleting %s: %w", key, err)
+	}
+	return nil
+}
+
+// prefixOf returns the prefix of a query as a key prefix ending in "/".
+func prefixOf(prefix string) string {
+	p := datastore.NewKey(prefix).String()
+	if !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	return p
+}
+
+type op struct {
+	delete bool
+	value  []byte
+}
+
+// batch buffers writes and commits them in a single transaction.
+type batch struct {
+	ds  *Datastore
+	ops map[datastore.Key]op
+}
+
+func (b *batch) Put(_ context.Context, key datastore.Key, value []byte) error {
+	b.ops[key] = op{value: value}
+	return nil
+}
+
+func (b *batch) Delete(_ context.Context, key datastore.Key) error {
+	b.ops[key] = op{delete: true}
+	return nil
+}
+
+func (b *batch) Commit(ctx context.Context) error {
+	tx, err := b.ds.write.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning batch: %w", err)
+	}
+	for key, o := range b.ops {
+		if o.delete {
+			err = del(ctx, tx, key)
+		} else {
+			err = put(ctx, tx, key, o.value)
+		}
+		if err != nil {
+			return errors.Join(err, tx.Rollback())
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing batch: %w", err)
+	}
+	clear(b.ops)
+	return nil
+}
+
+// copyBatchSize is the number of entries written per transaction by Copy.
+const copyBatchSize = 1000
+
+// Copy copies every entry of src to dst, returning the number copied.
+// Entries already in dst are overwritten, so an interrupted copy can be
+// repeated.
+func Copy(ctx context.Context, dst datastore.Batching, src datastore.Datastore) (int, error) {
+	results, err := src.Query(ctx, query.Query{})
+	if err != nil {
+		return 0, fmt.Errorf("querying source: %w", err)
+	}
+	defer results.Close()
+
+	b, err := dst.Batch(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("creating batch: %w", err)
+	}
+	n := 0
+	for r := range results.Next() {
+		if r.Error != nil {
+			return n, fmt.Errorf("iterating source: %w", r.Error)
+		}
+		if err := b.Put(ctx, datastore.NewKey(r.Key), r.Value); err != nil {
+			return n, err
+		}
+		n++
+		if n%copyBatchSize == 0 {
+			if err := b.Commit(ctx); err != nil {
+				return n, err
+			}
+			if b, err = dst.Batch(ctx); err != nil {
+				return n, fmt.Errorf("creating batch: %w", err)
+			}
+		}
+	}
+	if err := b.Commit(ctx); err != nil {
+		return n, err
+	}
+	return n, nil
+}
@@ -0,0 +1,87 @@
+package sqliteds
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	dstest "github.com/ipfs/go-datastore/test"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDatastore(t *testing.T) *Datastore {
+	t.Helper()
+	ds, err := New(filepath.Join(t.TempDir(), "datastore.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { ds.Close() })
+	return ds
+}
+
+func TestSuite(t *testing.T) {
+	dstest.SubtestAll(t, newTestDatastore(t))
+}
+
+func TestNamespaces(t *testing.T) {
+	ds := newTestDatastore(t)
+	claims := namespace.Wrap(ds, datastore.NewKey("claim"))
+	receipts := namespace.Wrap(ds, datastore.NewKey("receipt"))
+
+	require.NoError(t, claims.Put(t.Context(), datastore.NewKey("a"), []byte("claim")))
+	require.NoError(t, receipts.Put(t.Context(), datastore.NewKey("a"), []byte("receipt")))
+
+	v, err := claims.Get(t.Context(), datastore.NewKey("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("claim"), v)
+
+	results, err := receipts.Query(t.Context(), query.Query{})
+	require.NoError(t, err)
+	entries, err := results.Rest()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "/a", entries[0].Key)
+	require.Equal(t, []byte("receipt"), entries[0].Value)
+}
+
+func TestWriteWhileQuerying(t *testing.T) {
+	ds := newTestDatastore(t)
+	for _, k := range []string{"/a", "/b", "/c"} {
+		require.NoError(t, ds.Put(t.Context(), datastore.NewKey(k), []byte(k)))
+	}
+
+	results, err := ds.Query(t.Context(), query.Query{})
+	require.NoError(t, err)
+	defer results.Close()
+	for r := range results.Next() {
+		require.NoError(t, r.Error)
+		require.NoError(t, ds.Put(t.Context(), datastore.NewKey("/copy"+r.Key), r.Value))
+	}
+
+	has, err := ds.Has(t.Context(), datastore.NewKey("/copy/c"))
+	require.NoError(t, err)
+	require.True(t, has)
+}
+
+func TestCopy(t *testing.T) {
+	src := datastore.NewMapDatastore()
+	for i := range copyBatchSize + 1 {
+		require.NoError(t, src.Put(t.Context(), datastore.NewKey(fmt.Sprintf("/k/%d", i)), []byte{byte(i)}))
+	}
+	dst := newTestDatastore(t)
+	ns := namespace.Wrap(dst, datastore.NewKey("store"))
+
+	n, err := Copy(t.Context(), ns, src)
+	require.NoError(t, err)
+	require.Equal(t, copyBatchSize+1, n)
+
+	v, err := dst.Get(t.Context(), datastore.NewKey("/store/k/7"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{7}, v)
+
+	// copying again overwrites
+	n, err = Copy(t.Context(), ns, src)
+	require.NoError(t, err)
+	require.Equal(t, copyBatchSize+1, n)
+}
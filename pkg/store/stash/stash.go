@@ -0,0 +1,408 @@
+// Package stash holds uploads on disk while they are written, until they are
+// moved to the blob store. A write-ahead journal records the state of every
+// upload in the stash, so uploads interrupted by a crash or a restart are
+// resumed, when they were completely written, or cleaned up when the node
+// starts again. The journal is compacted each time an upload leaves the
+// stash, so it only holds the uploads still in the stash.
+package stash
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+var log = logging.Logger("stash")
+
+const (
+	journalFile = "journal"
+	// compactFile holds the compacted journal until it replaces the journal.
+	compactFile = "journal.compact"
+)
+
+type op string
+
+const (
+	// opBegin is journaled before an upload is written to its stash file.
+	opBegin op = "begin"
+	// opStashed is journaled once the stash file holds the whole upload.
+	opStashed op = "stashed"
+	// opDone is journaled once the upload has been moved out of the stash or
+	// discarded.
+	opDone op = "done"
+)
+
+type record struct {
+	Op   op     `json:"op"`
+	ID   string `json:"id"`
+	Key  string `json:"key,omitempty"`
+	Size uint64 `json:"size,omitempty"`
+}
+
+// Entry is an upload held in the stash.
+type Entry struct {
+	ID string
+	// Key is the key the upload is stored under once moved out of the stash.
+	Key  string
+	Size uint64
+	// Path is the stash file holding the upload.
+	Path string
+}
+
+// ResumeFunc completes an upload found whole in the stash when the node
+// starts. The stash file is removed once it returns, unless it was moved.
+type ResumeFunc func(ctx context.Context, e Entry) error
+
+// Recovery counts the uploads left in the stash when the node stopped.
+type Recovery struct {
+	// Recovered is the number of uploads that were completely written and
+	// have been resumed.
+	Recovered int
+	// Discarded is the number of uploads that were incomplete, failed to
+	// resume or were not journaled, and have been removed.
+	Discarded int
+}
+
+// Manager writes uploads to stash files and journals their state.
+type Manager struct {
+	dir     string
+	mu      sync.Mutex
+	journal *os.File
+	// live holds the uploads journaled that have not left the stash, whose
+	// records are kept when the journal is compacted.
+	live map[string]*liveEntry
+	seq  uint64
+}
+
+type liveEntry struct {
+	seq     uint64
+	begin   record
+	stashed bool
+}
+
+// New opens the stash in dir, creating it if needed. [Manager.Recover] must
+// be called before uploads are stashed.
+func New(dir string) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating stash directory: %w", err)
+	}
+	journal, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening stash journal: %w", err)
+	}
+	return &Manager{dir: dir, journal: journal, live: map[string]*liveEntry{}}, nil
+}
+
+// Stash writes an upload of the given size to a stash file. The upload is
+// journaled as stashed once its content is on disk, and must then be passed
+// to [Manager.Done] when it has been moved out of the stash. An upload that
+// fails to be written, or is not of the expected size, is removed.
+func (m *Manager) Stash(ctx context.Context, key string, size uint64, body io.Reader) (Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return Entry{}, err
+	}
+	id, err := newID()
+	if err != nil {
+		return Entry{}, err
+	}
+	e := Entry{ID: id, Key: key, Size: size, Path: filepath.Join(m.dir, id)}
+	if err := m.append(record{Op: opBegin, ID: id, Key: key, Size: size}); err != nil {
+		return Entry{}, err
+	}
+
+	if err := writeFile(e.Path, size, body); err != nil {
+		if doneErr := m.Done(e); doneErr != nil {
+			log.Warnw("discarding stash entry", "key", key, "error", doneErr)
+		}
+		return Entry{}, err
+	}
+	if err := m.append(record{Op: opStashed, ID: id}); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+func writeFile(path string, size uint64, body io.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("creating stash file: %w", err)
+	}
+	defer f.Close()
+	n, err := io.Copy(f, body)
+	if err != nil {
+		return err
+	}
+	if uint64(n) != size {
+		return fmt.Errorf("stash size mismatch: got %d, expected %d", n, size)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("syncing stash file: %w", err)
+	}
+	return f.Close()
+}
+
+// Done removes the stash file of an upload, if it is still there, journals
+// that it has left the stash and compacts the journal.
+func (m *Manager) Done(e Entry) error {
+	if err := os.Remove(e.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing stash file: %w", err)
+	}
+	if err := m.append(record{Op: opDone, ID: e.ID}); err != nil {
+		return err
+	}
+	// the upload has left the stash, a failure to compact only leaves the
+	// journal longer than it needs to be
+	if err := m.compact(); err != nil {
+		log.Warnw("compacting stash journal", "error", err)
+	}
+	return nil
+}
+
+func (m *Manager) append(r record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.journal.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing stash journal: %w", err)
+	}
+	if err := m.journal.Sync(); err != nil {
+		return fmt.Errorf("syncing stash journal: %w", err)
+	}
+	switch r.Op {
+	case opBegin:
+		m.seq++
+		m.live[r.ID] = &liveEntry{seq: m.seq, begin: r}
+	case opStashed:
+		if e, ok := m.live[r.ID]; ok {
+			e.stashed = true
+		}
+	case opDone:
+		delete(m.live, r.ID)
+	}
+	return nil
+}
+
+// compact rewrites the journal with the records of the uploads still in the
+// stash. The compacted journal is written aside and renamed over the
+// journal, so a crash leaves either journal whole.
+func (m *Manager) compact() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.live) == 0 {
+		if err := m.journal.Truncate(0); err != nil {
+			return fmt.Errorf("truncating stash journal: %w", err)
+		}
+		return m.journal.Sync()
+	}
+
+	entries := make([]*liveEntry, 0, len(m.live))
+	for _, e := range m.live {
+		entries = append(entries, e)
+	}
+	slices.SortFunc(entries, func(a, b *liveEntry) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+	var buf []byte
+	for _, e := range entries {
+		records := []record{e.begin}
+		if e.stashed {
+			records = append(records, record{Op: opStashed, ID: e.begin.ID})
+		}
+		for _, r := range records {
+			line, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			buf = append(append(buf, line...), '\n')
+		}
+	}
+
+	path := filepath.Join(m.dir, compactFile)
+	if err := writeSynced(path, buf); err != nil {
+		return err
+	}
+	if err := os.Rename(path, filepath.Join(m.dir, journalFile)); err != nil {
+		return fmt.Errorf("replacing stash journal: %w", err)
+	}
+	journal, err := os.OpenFile(filepath.Join(m.dir, journalFile), os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening stash journal: %w", err)
+	}
+	if err := m.journal.Close(); err != nil {
+		log.Warnw("closing replaced stash journal", "error", err)
+	}
+	m.journal = journal
+	return nil
+}
+
+func writeSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("creating compacted stash journal: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("writing compacted stash journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("syncing compacted stash journal: %w", err)
+	}
+	return f.Close()
+}
+
+// Recover replays the journal of uploads left in the stash when the node
+// stopped. Uploads that were completely written are passed to resume, if it
+// is not nil, others are removed along with any file of the stash that is not
+// journaled. The journal is then emptied.
+func (m *Manager) Recover(ctx context.Context, resume ResumeFunc) (Recovery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending, err := m.replay()
+	if err != nil {
+		return Recovery{}, err
+	}
+
+	var rec Recovery
+	known := map[string]struct{}{journalFile: {}}
+	for _, p := range pending {
+		if err := ctx.Err(); err != nil {
+			return rec, err
+		}
+		known[p.entry.ID] = struct{}{}
+		if resume != nil && p.stashed && hasSize(p.entry.Path, p.entry.Size) {
+			if err := resume(ctx, p.entry); err != nil {
+				log.Warnw("resuming stashed upload", "key", p.entry.Key, "error", err)
+				rec.Discarded++
+			} else {
+				rec.Recovered++
+			}
+		} else {
+			rec.Discarded++
+		}
+		if err := os.Remove(p.entry.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return rec, fmt.Errorf("removing stash file: %w", err)
+		}
+	}
+
+	// files left by uploads that were not journaled
+	files, err := os.ReadDir(m.dir)
+	if err != nil {
+		return rec, fmt.Errorf("reading stash directory: %w", err)
+	}
+	for _, f := range files {
+		if _, ok := known[f.Name()]; ok {
+			continue
+		}
+		// a compaction interrupted before it replaced the journal
+		if f.Name() == compactFile {
+			if err := os.Remove(filepath.Join(m.dir, f.Name())); err != nil {
+				return rec, fmt.Errorf("removing compacted stash journal: %w", err)
+			}
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(m.dir, f.Name())); err != nil {
+			return rec, fmt.Errorf("removing stash file: %w", err)
+		}
+		rec.Discarded++
+	}
+
+	if err := m.journal.Truncate(0); err != nil {
+		return rec, fmt.Errorf("truncating stash journal: %w", err)
+	}
+	if err := m.journal.Sync(); err != nil {
+		return rec, fmt.Errorf("syncing stash journal: %w", err)
+	}
+	clear(m.live)
+
+	recordRecovery(ctx, rec)
+	if rec.Recovered > 0 || rec.Discarded > 0 {
+		log.Infow("recovered stash", "recovered", rec.Recovered, "discarded", rec.Discarded)
+	}
+	return rec, nil
+}
+
+type pendingEntry struct {
+	entry   Entry
+	stashed bool
+}
+
+// replay returns the uploads journaled that have not left the stash, in the
+// order they were started.
+func (m *Manager) replay() ([]*pendingEntry, error) {
+	f, err := os.Open(filepath.Join(m.dir, journalFile))
+	if err != nil {
+		return nil, fmt.Errorf("opening stash journal: %w", err)
+	}
+	defer f.Close()
+
+	var order []*pendingEntry
+	byID := map[string]*pendingEntry{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// the last record may have been cut short by a crash, the upload
+			// it refers to is left incomplete
+			log.Warnw("skipping invalid stash journal record", "error", err)
+			continue
+		}
+		switch r.Op {
+		case opBegin:
+			p := &pendingEntry{entry: Entry{ID: r.ID, Key: r.Key, Size: r.Size, Path: filepath.Join(m.dir, r.ID)}}
+			byID[r.ID] = p
+			order = append(order, p)
+		case opStashed:
+			if p, ok := byID[r.ID]; ok {
+				p.stashed = true
+			}
+		case opDone:
+			delete(byID, r.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading stash journal: %w", err)
+	}
+
+	pending := order[:0]
+	for _, p := range order {
+		if _, ok := byID[p.entry.ID]; ok {
+			pending = append(pending, p)
+		}
+	}
+	return pending, nil
+}
+
+func hasSize(path string, size uint64) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && uint64(info.Size()) == size
+}
+
+// Close closes the journal.
+func (m *Manager) Close() error {
+	return m.journal.Close()
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating stash entry id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
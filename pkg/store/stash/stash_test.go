@@ -0,0 +1,142 @@
+package stash
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStash(t *testing.T) {
+	dir := t.TempDir()
+	m, err := New(dir)
+	require.NoError(t, err)
+	defer m.Close()
+	_, err = m.Recover(t.Context(), nil)
+	require.NoError(t, err)
+
+	data := []byte("hello world")
+	e, err := m.Stash(t.Context(), "key", uint64(len(data)), bytes.NewReader(data))
+	require.NoError(t, err)
+	stashed, err := os.ReadFile(e.Path)
+	require.NoError(t, err)
+	require.Equal(t, data, stashed)
+
+	require.NoError(t, m.Done(e))
+	_, err = os.Stat(e.Path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	// the journal is compacted once no upload is left in the stash
+	info, err := os.Stat(filepath.Join(dir, journalFile))
+	require.NoError(t, err)
+	require.Zero(t, info.Size())
+
+	_, err = m.Stash(t.Context(), "key", uint64(len(data)+1), bytes.NewReader(data))
+	require.Error(t, err)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1, "only the journal is left")
+}
+
+func TestRecover(t *testing.T) {
+	dir := t.TempDir()
+	m, err := New(dir)
+	require.NoError(t, err)
+	_, err = m.Recover(t.Context(), nil)
+	require.NoError(t, err)
+
+	data := []byte("hello world")
+	// stashed but not moved out of the stash
+	whole, err := m.Stash(t.Context(), "whole", uint64(len(data)), bytes.NewReader(data))
+	require.NoError(t, err)
+	failing, err := m.Stash(t.Context(), "failing", uint64(len(data)), bytes.NewReader(data))
+	require.NoError(t, err)
+	// moved out of the stash
+	done, err := m.Stash(t.Context(), "done", uint64(len(data)), bytes.NewReader(data))
+	require.NoError(t, err)
+	require.NoError(t, m.Done(done))
+	// interrupted while written
+	require.NoError(t, m.append(record{Op: opBegin, ID: "partial", Key: "partial", Size: uint64(len(data))}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "partial"), data[:5], 0644))
+	// not journaled
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stray"), data, 0644))
+	require.NoError(t, m.Close())
+
+	m, err = New(dir)
+	require.NoError(t, err)
+	defer m.Close()
+	var resumed []string
+	rec, err := m.Recover(t.Context(), func(ctx context.Context, e Entry) error {
+		stashed, err := os.ReadFile(e.Path)
+		require.NoError(t, err)
+		require.Equal(t, data, stashed)
+		if e.Key == failing.Key {
+			return errors.New("boom")
+		}
+		resumed = append(resumed, e.Key)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, Recovery{Recovered: 1, Discarded: 3}, rec)
+	require.Equal(t, []string{whole.Key}, resumed)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1, "only the journal is left")
+	info, err := os.Stat(filepath.Join(dir, journalFile))
+	require.NoError(t, err)
+	require.Zero(t, info.Size())
+
+	rec, err = m.Recover(t.Context(), nil)
+	require.NoError(t, err)
+	require.Equal(t, Recovery{}, rec)
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	m, err := New(dir)
+	require.NoError(t, err)
+	_, err = m.Recover(t.Context(), nil)
+	require.NoError(t, err)
+
+	data := []byte("hello world")
+	kept, err := m.Stash(t.Context(), "kept", uint64(len(data)), bytes.NewReader(data))
+	require.NoError(t, err)
+	for range 10 {
+		e, err := m.Stash(t.Context(), "done", uint64(len(data)), bytes.NewReader(data))
+		require.NoError(t, err)
+		require.NoError(t, m.Done(e))
+	}
+
+	// only the records of the upload still in the stash are kept
+	pending, err := m.replay()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, kept.ID, pending[0].entry.ID)
+	require.True(t, pending[0].stashed)
+	journal, err := os.ReadFile(filepath.Join(dir, journalFile))
+	require.NoError(t, err)
+	require.Equal(t, 2, bytes.Count(journal, []byte("\n")))
+
+	// the upload is recovered from the compacted journal, and a compaction
+	// interrupted before it replaced the journal is discarded
+	require.NoError(t, os.WriteFile(filepath.Join(dir, compactFile), []byte("partial"), 0644))
+	require.NoError(t, m.Close())
+	m, err = New(dir)
+	require.NoError(t, err)
+	defer m.Close()
+	var resumed []string
+	rec, err := m.Recover(t.Context(), func(ctx context.Context, e Entry) error {
+		resumed = append(resumed, e.Key)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, Recovery{Recovered: 1}, rec)
+	require.Equal(t, []string{kept.Key}, resumed)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1, "only the journal is left")
+}
@@ -0,0 +1,43 @@
+package stash
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/storacha/piri/lib/telemetry"
+)
+
+// Results of recovering an upload left in the stash.
+const (
+	recoveryResultRecovered = "recovered"
+	recoveryResultDiscarded = "discarded"
+)
+
+// recoveryCounter counts the uploads left in the stash when the node stopped,
+// by result. It is nil if the counter could not be created.
+var recoveryCounter = sync.OnceValue(func() *telemetry.Counter {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/store/stash")
+	counter, err := telemetry.NewCounter(
+		meter,
+		"stash_recovered_entries",
+		"uploads left in the stash when the node stopped, recovered or discarded on start",
+		"1",
+	)
+	if err != nil {
+		log.Warnw("creating stash metrics", "error", err)
+		return nil
+	}
+	return counter
+})
+
+func recordRecovery(ctx context.Context, rec Recovery) {
+	counter := recoveryCounter()
+	if counter == nil {
+		return
+	}
+	counter.Add(ctx, int64(rec.Recovered), attribute.String("result", recoveryResultRecovered))
+	counter.Add(ctx, int64(rec.Discarded), attribute.String("result", recoveryResultDiscarded))
+}
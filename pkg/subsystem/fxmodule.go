@@ -0,0 +1,72 @@
+package subsystem
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/health"
+)
+
+// StateFile is the name of the file in the data directory that persists the
+// pause state of subsystems.
+const StateFile = "subsystems.json"
+
+var Module = fx.Module("subsystem",
+	fx.Provide(
+		NewRegistryFromConfig,
+		fx.Annotate(
+			NewHealthChecks,
+			fx.As(new(health.CheckProvider)),
+			fx.ResultTags(`group:"health_checks"`),
+		),
+	),
+)
+
+// NewRegistryFromConfig creates a Registry persisting pause state in the data
+// directory, and reports subsystem state as metrics while the node runs.
+func NewRegistryFromConfig(lc fx.Lifecycle, cfg app.StorageConfig) (*Registry, error) {
+	path := ""
+	if cfg.DataDir != "" {
+		path = filepath.Join(cfg.DataDir, StateFile)
+	}
+	r, err := NewRegistry(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating subsystem registry: %w", err)
+	}
+	reg, err := registerMetrics(r)
+	if err != nil {
+		return nil, err
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return reg.Unregister()
+		},
+	})
+	return r, nil
+}
+
+// HealthChecks reports the state of each subsystem in the health check.
+type HealthChecks struct {
+	registry *Registry
+}
+
+func NewHealthChecks(r *Registry) *HealthChecks {
+	return &HealthChecks{registry: r}
+}
+
+// Checks implements health.CheckProvider.
+func (h *HealthChecks) Checks() []health.Check {
+	var checks []health.Check
+	for _, s := range h.registry.Status() {
+		status := health.StatusOK
+		if s.Paused {
+			status = health.StatusPaused
+		}
+		checks = append(checks, health.Check{Name: "subsystem:" + s.Name, Status: status})
+	}
+	return checks
+}
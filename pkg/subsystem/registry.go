@@ -0,0 +1,225 @@
+// Package subsystem allows operators to pause and resume background
+// subsystems (aggregation, proving, replication...) of a running node. Pause
+// state is persisted so a paused subsystem stays paused across restarts.
+package subsystem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+)
+
+var log = logging.Logger("subsystem")
+
+// Names of the subsystems that can be paused.
+const (
+	Aggregation = "aggregation"
+	Proving     = "proving"
+	Replication = "replication"
+	Reaper      = "reaper"
+	Anchoring   = "anchoring"
+	Scrubbing   = "scrubbing"
+	Collection  = "collection"
+	Compaction  = "compaction"
+)
+
+// ErrUnknownSubsystem is returned when pausing or resuming a subsystem that
+// is not running on this node.
+var ErrUnknownSubsystem = errors.New("unknown subsystem")
+
+// Pausable is a component of a subsystem that can be paused. Pausing must not
+// interrupt work in progress, only prevent new work from starting.
+type Pausable interface {
+	Pause()
+	Resume()
+}
+
+// Switch is a Pausable for components that check whether they are paused
+// before doing work. A nil *Switch is never paused.
+type Switch struct {
+	paused atomic.Bool
+}
+
+func (s *Switch) Pause()  { s.paused.Store(true) }
+func (s *Switch) Resume() { s.paused.Store(false) }
+
+// Paused reports whether the switch is paused.
+func (s *Switch) Paused() bool {
+	return s != nil && s.paused.Load()
+}
+
+// Status describes the pause state of a subsystem.
+type Status struct {
+	Name     string     `json:"name"`
+	Paused   bool       `json:"paused"`
+	PausedAt *time.Time `json:"paused_at,omitempty"`
+}
+
+type subsystem struct {
+	components []Pausable
+}
+
+type state struct {
+	Paused map[string]time.Time `json:"paused"`
+}
+
+// Registry tracks the subsystems of a node and their pause state.
+type Registry struct {
+	mu         sync.RWMutex
+	path       string
+	subsystems map[string]*subsystem
+	// paused holds the persisted pause state, it may contain subsystems that
+	// have not been registered yet.
+	paused map[string]time.Time
+}
+
+// NewRegistry creates a registry persisting pause state to the file at path.
+// Pause state is kept in memory only if path is empty.
+func NewRegistry(path string) (*Registry, error) {
+	r := &Registry{
+		path:       path,
+		subsystems: map[string]*subsystem{},
+		paused:     map[string]time.Time{},
+	}
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("reading subsystem state: %w", err)
+	}
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("decoding subsystem state %s: %w", path, err)
+	}
+	for name, at := range s.Paused {
+		r.paused[name] = at
+	}
+	return r, nil
+}
+
+// Register adds components to the named subsystem. If the subsystem was
+// paused before the node restarted the components are paused immediately.
+func (r *Registry) Register(name string, components ...Pausable) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.subsystems[name]
+	if !ok {
+		sub = &subsystem{}
+		r.subsystems[name] = sub
+	}
+	sub.components = append(sub.components, components...)
+	if _, paused := r.paused[name]; paused {
+		log.Warnw("subsystem is paused", "subsystem", name)
+		for _, c := range components {
+			c.Pause()
+		}
+	}
+}
+
+// Pause pauses the named subsystem and persists its state.
+func (r *Registry) Pause(name string) (Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.subsystems[name]
+	if !ok {
+		return Status{}, fmt.Errorf("%w: %s", ErrUnknownSubsystem, name)
+	}
+	if _, paused := r.paused[name]; !paused {
+		r.paused[name] = time.Now().UTC()
+		if err := r.persist(); err != nil {
+			delete(r.paused, name)
+			return Status{}, err
+		}
+	}
+	for _, c := range sub.components {
+		c.Pause()
+	}
+	log.Warnw("paused subsystem", "subsystem", name)
+	return r.status(name), nil
+}
+
+// Resume resumes the named subsystem and persists its state.
+func (r *Registry) Resume(name string) (Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.subsystems[name]
+	if !ok {
+		return Status{}, fmt.Errorf("%w: %s", ErrUnknownSubsystem, name)
+	}
+	if at, paused := r.paused[name]; paused {
+		delete(r.paused, name)
+		if err := r.persist(); err != nil {
+			r.paused[name] = at
+			return Status{}, err
+		}
+	}
+	for _, c := range sub.components {
+		c.Resume()
+	}
+	log.Infow("resumed subsystem", "subsystem", name)
+	return r.status(name), nil
+}
+
+// Paused reports whether the named subsystem is paused.
+func (r *Registry) Paused(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, paused := r.paused[name]
+	return paused
+}
+
+// Status returns the state of all registered subsystems, sorted by name.
+func (r *Registry) Status() []Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Status, 0, len(r.subsystems))
+	for name := range r.subsystems {
+		out = append(out, r.status(name))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (r *Registry) status(name string) Status {
+	s := Status{Name: name}
+	if at, paused := r.paused[name]; paused {
+		s.Paused = true
+		s.PausedAt = &at
+	}
+	return s
+}
+
+// persist writes the pause state to disk. Callers must hold r.mu.
+func (r *Registry) persist() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(state{Paused: r.paused}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding subsystem state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("creating subsystem state directory: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing subsystem state: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("writing subsystem state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package subsystem
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("pauses and resumes components", func(t *testing.T) {
+		r, err := NewRegistry("")
+		require.NoError(t, err)
+
+		a, b := &Switch{}, &Switch{}
+		r.Register(Aggregation, a, b)
+
+		s, err := r.Pause(Aggregation)
+		require.NoError(t, err)
+		require.True(t, s.Paused)
+		require.NotNil(t, s.PausedAt)
+		require.True(t, a.Paused())
+		require.True(t, b.Paused())
+		require.True(t, r.Paused(Aggregation))
+
+		s, err = r.Resume(Aggregation)
+		require.NoError(t, err)
+		require.False(t, s.Paused)
+		require.Nil(t, s.PausedAt)
+		require.False(t, a.Paused())
+		require.False(t, b.Paused())
+	})
+
+	t.Run("unknown subsystem", func(t *testing.T) {
+		r, err := NewRegistry("")
+		require.NoError(t, err)
+
+		_, err = r.Pause("scrubber")
+		require.ErrorIs(t, err, ErrUnknownSubsystem)
+		_, err = r.Resume("scrubber")
+		require.ErrorIs(t, err, ErrUnknownSubsystem)
+	})
+
+	t.Run("persists pause state", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), StateFile)
+		r, err := NewRegistry(path)
+		require.NoError(t, err)
+		r.Register(Proving, &Switch{})
+		r.Register(Replication, &Switch{})
+
+		_, err = r.Pause(Proving)
+		require.NoError(t, err)
+
+		// simulate a restart
+		r, err = NewRegistry(path)
+		require.NoError(t, err)
+		proving, replication := &Switch{}, &Switch{}
+		r.Register(Proving, proving)
+		r.Register(Replication, replication)
+		require.True(t, proving.Paused())
+		require.False(t, replication.Paused())
+
+		status := r.Status()
+		require.Len(t, status, 2)
+		require.Equal(t, Proving, status[0].Name)
+		require.True(t, status[0].Paused)
+		require.Equal(t, Replication, status[1].Name)
+		require.False(t, status[1].Paused)
+
+		_, err = r.Resume(Proving)
+		require.NoError(t, err)
+		r, err = NewRegistry(path)
+		require.NoError(t, err)
+		require.False(t, r.Paused(Proving))
+	})
+
+	t.Run("nil switch is never paused", func(t *testing.T) {
+		var s *Switch
+		require.False(t, s.Paused())
+	})
+}
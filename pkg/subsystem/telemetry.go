@@ -0,0 +1,41 @@
+package subsystem
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// registerMetrics reports the pause state of each registered subsystem as
+// piri_subsystem_paused, 1 if paused and 0 otherwise.
+func registerMetrics(r *Registry) (metric.Registration, error) {
+	meter := otel.GetMeterProvider().Meter("github.com/storacha/piri/pkg/subsystem")
+	paused, err := meter.Int64ObservableGauge(
+		"piri_subsystem_paused",
+		metric.WithDescription("Whether a subsystem has been paused by an operator (1) or is running (0)"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create subsystem paused gauge: %w", err)
+	}
+	reg, err := meter.RegisterCallback(
+		func(ctx context.Context, o metric.Observer) error {
+			for _, s := range r.Status() {
+				var v int64
+				if s.Paused {
+					v = 1
+				}
+				o.ObserveInt64(paused, v, metric.WithAttributes(attribute.String("subsystem", s.Name)))
+			}
+			return nil
+		},
+		paused,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("register subsystem metrics callback: %w", err)
+	}
+	return reg, nil
+}
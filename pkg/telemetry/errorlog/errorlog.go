@@ -0,0 +1,168 @@
+// Package errorlog keeps the most recent error logs of the node in memory, so
+// operators can see what has been going wrong without access to the log
+// output.
+//
+// Entries are read from a pipe registered with the logging system, which
+// receives every log at error level or above whatever the level of the
+// logger that emitted it.
+package errorlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+)
+
+// DefaultSize is the number of entries kept.
+const DefaultSize = 100
+
+// timeLayout is the layout of the timestamps of JSON logs.
+const timeLayout = "2006-01-02T15:04:05.000Z0700"
+
+// maxLine bounds the size of a log line read, longer lines are skipped.
+const maxLine = 1 << 20
+
+var Module = fx.Module("errorlog",
+	fx.Provide(NewFromLifecycle),
+)
+
+// Entry is an error log.
+type Entry struct {
+	At      time.Time `json:"at"`
+	Level   string    `json:"level"`
+	Logger  string    `json:"logger"`
+	Message string    `json:"message"`
+	Caller  string    `json:"caller,omitempty"`
+	// Fields are the structured fields of the log, other than the error.
+	Fields map[string]any `json:"fields,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// Log is a ring buffer of the most recent error logs.
+type Log struct {
+	size int
+
+	mu      sync.RWMutex
+	entries []Entry
+	// next is the index the next entry is written at once the buffer is full.
+	next int
+
+	pipe *logging.PipeReader
+	done chan struct{}
+}
+
+// New creates a Log keeping the last size entries. It collects nothing until
+// started.
+func New(size int) *Log {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Log{size: size, done: make(chan struct{})}
+}
+
+// NewFromLifecycle creates a Log of DefaultSize entries, collecting error
+// logs while the app runs.
+func NewFromLifecycle(lc fx.Lifecycle) *Log {
+	l := New(DefaultSize)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			l.Start()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			return l.Stop()
+		},
+	})
+	return l
+}
+
+// Start collects error logs until stopped.
+func (l *Log) Start() {
+	l.pipe = logging.NewPipeReader(logging.PipeFormat(logging.JSONOutput), logging.PipeLevel(logging.LevelError))
+	go func() {
+		defer close(l.done)
+		scanner := bufio.NewScanner(l.pipe)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLine)
+		for scanner.Scan() {
+			if e, ok := parse(scanner.Bytes()); ok {
+				l.Add(e)
+			}
+		}
+	}()
+}
+
+// Stop stops collecting error logs.
+func (l *Log) Stop() error {
+	if l.pipe == nil {
+		return nil
+	}
+	err := l.pipe.Close()
+	<-l.done
+	return err
+}
+
+// Add records an entry, evicting the oldest if the log is full.
+func (l *Log) Add(e Entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) < l.size {
+		l.entries = append(l.entries, e)
+		return
+	}
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % l.size
+}
+
+// Recent returns up to n of the most recent entries, newest first. All
+// entries are returned if n is not positive.
+func (l *Log) Recent(n int) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make([]Entry, 0, len(l.entries))
+	out = append(out, l.entries[l.next:]...)
+	out = append(out, l.entries[:l.next]...)
+	slices.Reverse(out)
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// parse decodes a JSON log line.
+func parse(line []byte) (Entry, bool) {
+	var fields map[string]any
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return Entry{}, false
+	}
+	str := func(key string) string {
+		v, _ := fields[key].(string)
+		delete(fields, key)
+		return v
+	}
+	e := Entry{
+		Level:   str("level"),
+		Logger:  str("logger"),
+		Message: str("msg"),
+		Caller:  str("caller"),
+		Error:   str("error"),
+	}
+	if ts := str("ts"); ts != "" {
+		if at, err := time.Parse(timeLayout, ts); err == nil {
+			e.At = at
+		}
+	}
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	delete(fields, "stacktrace")
+	if len(fields) > 0 {
+		e.Fields = fields
+	}
+	return e, true
+}
@@ -0,0 +1,47 @@
+package errorlog
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	t.Run("keeps the most recent entries, newest first", func(t *testing.T) {
+		l := New(3)
+		for i := range 5 {
+			l.Add(Entry{Message: fmt.Sprint(i)})
+		}
+		var msgs []string
+		for _, e := range l.Recent(0) {
+			msgs = append(msgs, e.Message)
+		}
+		require.Equal(t, []string{"4", "3", "2"}, msgs)
+		require.Len(t, l.Recent(2), 2)
+	})
+
+	t.Run("collects error logs", func(t *testing.T) {
+		l := New(10)
+		l.Start()
+		t.Cleanup(func() { require.NoError(t, l.Stop()) })
+
+		logger := logging.Logger("errorlog-test")
+		logger.Infow("not an error")
+		logger.Errorw("proving failed", "proof_set", 7, "error", errors.New("boom"))
+
+		require.Eventually(t, func() bool {
+			return len(l.Recent(0)) > 0
+		}, time.Second, 10*time.Millisecond)
+		e := l.Recent(0)[0]
+		require.Equal(t, "error", e.Level)
+		require.Equal(t, "errorlog-test", e.Logger)
+		require.Equal(t, "proving failed", e.Message)
+		require.Equal(t, "boom", e.Error)
+		require.Equal(t, float64(7), e.Fields["proof_set"])
+		require.WithinDuration(t, time.Now(), e.At, time.Minute)
+	})
+}
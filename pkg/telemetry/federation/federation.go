@@ -0,0 +1,228 @@
+// Package federation serves the metrics of a fleet of nodes on a single
+// Prometheus endpoint, so a small deployment can be monitored with one
+// scrape target instead of a Prometheus per node.
+//
+// Each request scrapes the peers concurrently and adds a node label to every
+// sample. A node label already present on a sample is kept as exported_node,
+// as Prometheus does for conflicting target labels.
+package federation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+var log = logging.Logger("telemetry/federation")
+
+const (
+	// NodeLabel is the label identifying the node a sample was scraped from.
+	NodeLabel = "node"
+	// ExportedNodeLabel holds a node label the sample already had.
+	ExportedNodeLabel = "exported_node"
+
+	// UpMetric is 1 for each node whose metrics were gathered, 0 otherwise.
+	UpMetric = "piri_federation_up"
+	// ScrapeDurationMetric is how long gathering the metrics of each node
+	// took.
+	ScrapeDurationMetric = "piri_federation_scrape_duration_seconds"
+
+	// DefaultTimeout bounds the scrape of each peer if no timeout is set.
+	DefaultTimeout = 10 * time.Second
+
+	acceptHeader = "text/plain;version=0.0.4"
+)
+
+// Peer is a node of the fleet.
+type Peer struct {
+	Name string
+	// URL is the metrics endpoint of the node.
+	URL string
+}
+
+// Federator gathers the metrics of the local node and its peers.
+type Federator struct {
+	node    string
+	local   prometheus.Gatherer
+	peers   []Peer
+	timeout time.Duration
+	client  *http.Client
+}
+
+var _ http.Handler = (*Federator)(nil)
+
+// New creates a Federator labelling the metrics gathered from local as node.
+// local may be nil to serve only the metrics of the peers.
+func New(node string, local prometheus.Gatherer, peers []Peer, timeout time.Duration, client *http.Client) *Federator {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Federator{
+		node:    node,
+		local:   local,
+		peers:   peers,
+		timeout: timeout,
+		client:  client,
+	}
+}
+
+type result struct {
+	node     string
+	families []*dto.MetricFamily
+	duration time.Duration
+	err      error
+}
+
+// Gather returns the metrics of every node, labelled by node and sorted by
+// name. Nodes that fail to be scraped are reported by the up metric.
+func (f *Federator) Gather(ctx context.Context) []*dto.MetricFamily {
+	var results []result
+	if f.local != nil {
+		start := time.Now()
+		families, err := f.local.Gather()
+		results = append(results, result{f.node, families, time.Since(start), err})
+	}
+
+	peerResults := make([]result, len(f.peers))
+	var wg sync.WaitGroup
+	for i, peer := range f.peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			families, err := f.scrape(ctx, peer)
+			peerResults[i] = result{peer.Name, families, time.Since(start), err}
+		}()
+	}
+	wg.Wait()
+	results = append(results, peerResults...)
+
+	merged := map[string]*dto.MetricFamily{}
+	up := newGauge(UpMetric, "Whether the metrics of the node were gathered.")
+	duration := newGauge(ScrapeDurationMetric, "Duration of gathering the metrics of the node.")
+	for _, res := range results {
+		duration.Metric = append(duration.Metric, sample(res.node, res.duration.Seconds()))
+		if res.err != nil {
+			log.Warnw("gathering node metrics", "node", res.node, "error", res.err)
+			up.Metric = append(up.Metric, sample(res.node, 0))
+			continue
+		}
+		up.Metric = append(up.Metric, sample(res.node, 1))
+		merge(merged, res.node, res.families)
+	}
+	merged[UpMetric] = up
+	merged[ScrapeDurationMetric] = duration
+
+	out := make([]*dto.MetricFamily, 0, len(merged))
+	for _, mf := range merged {
+		out = append(out, mf)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].GetName() < out[j].GetName() })
+	return out
+}
+
+func (f *Federator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	format := expfmt.NewFormat(expfmt.TypeTextPlain)
+	w.Header().Set("Content-Type", string(format))
+	enc := expfmt.NewEncoder(w, format)
+	for _, mf := range f.Gather(r.Context()) {
+		if err := enc.Encode(mf); err != nil {
+			log.Errorw("writing federated metrics", "error", err)
+			return
+		}
+	}
+}
+
+func (f *Federator) scrape(ctx context.Context, peer Peer) ([]*dto.MetricFamily, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peer.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", acceptHeader)
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scraping %s: %w", peer.URL, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scraping %s: unexpected status %d", peer.URL, res.StatusCode)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing metrics from %s: %w", peer.URL, err)
+	}
+	out := make([]*dto.MetricFamily, 0, len(families))
+	for _, mf := range families {
+		out = append(out, mf)
+	}
+	return out, nil
+}
+
+// merge adds the metrics of node to merged, labelled by node. A family with
+// a different type than the same family of another node, e.g. from a
+// different piri version, is dropped.
+func merge(merged map[string]*dto.MetricFamily, node string, families []*dto.MetricFamily) {
+	for _, mf := range families {
+		name := mf.GetName()
+		into, ok := merged[name]
+		if !ok {
+			into = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type, Unit: mf.Unit}
+			merged[name] = into
+		} else if into.GetType() != mf.GetType() {
+			log.Warnw("dropping metric with conflicting type", "node", node, "metric", name, "type", mf.GetType(), "expected", into.GetType())
+			continue
+		}
+		for _, m := range mf.Metric {
+			m.Label = relabel(m.Label, node)
+			into.Metric = append(into.Metric, m)
+		}
+	}
+}
+
+func relabel(labels []*dto.LabelPair, node string) []*dto.LabelPair {
+	out := make([]*dto.LabelPair, 0, len(labels)+1)
+	for _, l := range labels {
+		if l.GetName() == NodeLabel {
+			l = &dto.LabelPair{Name: ptr(ExportedNodeLabel), Value: l.Value}
+		}
+		out = append(out, l)
+	}
+	out = append(out, &dto.LabelPair{Name: ptr(NodeLabel), Value: ptr(node)})
+	slices.SortFunc(out, func(a, b *dto.LabelPair) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+	return out
+}
+
+func newGauge(name, help string) *dto.MetricFamily {
+	return &dto.MetricFamily{Name: ptr(name), Help: ptr(help), Type: dto.MetricType_GAUGE.Enum()}
+}
+
+func sample(node string, v float64) *dto.Metric {
+	return &dto.Metric{
+		Label: []*dto.LabelPair{{Name: ptr(NodeLabel), Value: ptr(node)}},
+		Gauge: &dto.Gauge{Value: &v},
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
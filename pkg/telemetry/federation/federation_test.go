@@ -0,0 +1,88 @@
+package federation
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/require"
+)
+
+const peerMetrics = `# HELP piri_blobs_total Blobs stored.
+# TYPE piri_blobs_total counter
+piri_blobs_total{node="disk-1"} 7
+# HELP piri_queue_depth Jobs waiting.
+# TYPE piri_queue_depth gauge
+piri_queue_depth{queue="commp"} 3
+`
+
+func TestFederator(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	blobs := prometheus.NewCounter(prometheus.CounterOpts{Name: "piri_blobs_total", Help: "Blobs stored."})
+	reg.MustRegister(blobs)
+	blobs.Add(5)
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, peerMetrics)
+	}))
+	t.Cleanup(peer.Close)
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+
+	f := New("local", reg, []Peer{
+		{Name: "peer", URL: peer.URL},
+		{Name: "down", URL: down.URL},
+	}, 0, peer.Client())
+
+	rec := httptest.NewRecorder()
+	f.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/federate", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(rec.Body.String()))
+	require.NoError(t, err)
+
+	t.Run("labels samples by node", func(t *testing.T) {
+		values := map[string]float64{}
+		for _, m := range families["piri_blobs_total"].Metric {
+			values[labels(m)] = m.GetCounter().GetValue()
+		}
+		require.Equal(t, map[string]float64{
+			"node=local":                     5,
+			"exported_node=disk-1,node=peer": 7,
+		}, values)
+
+		depth := families["piri_queue_depth"].Metric
+		require.Len(t, depth, 1)
+		require.Equal(t, "node=peer,queue=commp", labels(depth[0]))
+	})
+
+	t.Run("reports up nodes", func(t *testing.T) {
+		up := map[string]float64{}
+		for _, m := range families[UpMetric].Metric {
+			up[labels(m)] = m.GetGauge().GetValue()
+		}
+		require.Equal(t, map[string]float64{
+			"node=local": 1,
+			"node=peer":  1,
+			"node=down":  0,
+		}, up)
+		require.Len(t, families[ScrapeDurationMetric].Metric, 3)
+	})
+}
+
+func labels(m *dto.Metric) string {
+	var pairs []string
+	for _, l := range m.Label {
+		pairs = append(pairs, l.GetName()+"="+l.GetValue())
+	}
+	return strings.Join(pairs, ",")
+}
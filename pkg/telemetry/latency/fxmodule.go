@@ -0,0 +1,11 @@
+package latency
+
+import (
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("latency",
+	fx.Provide(func() *Tracker {
+		return NewTracker(DefaultRetention, DefaultMaxUploads)
+	}),
+)
@@ -0,0 +1,210 @@
+// Package latency breaks down the time an upload spends in each stage of the
+// pipeline, from allocation to the aggregate being enqueued.
+//
+// Each stage is timed with a span, and its duration is also recorded against
+// the blob digest in an in-memory Tracker. Breakdowns are kept for a short
+// retention period so operators can query recent uploads while an incident is
+// ongoing, whether or not the spans were sampled.
+package latency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/multiformats/go-multihash"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/storacha/piri/pkg/telemetry/latency")
+
+const (
+	// DefaultRetention is how long a breakdown is kept after its last stage.
+	DefaultRetention = time.Hour
+	// DefaultMaxUploads bounds the number of breakdowns kept.
+	DefaultMaxUploads = 100_000
+)
+
+// Stage is a stage of the upload pipeline.
+type Stage string
+
+const (
+	StageAllocate         Stage = "allocate"
+	StagePresign          Stage = "presign"
+	StageUpload           Stage = "upload"
+	StageAccept           Stage = "accept"
+	StageClaimPublish     Stage = "claim_publish"
+	StageAggregateEnqueue Stage = "aggregate_enqueue"
+)
+
+// Stages lists the stages of the upload pipeline in order.
+var Stages = []Stage{
+	StageAllocate,
+	StagePresign,
+	StageUpload,
+	StageAccept,
+	StageClaimPublish,
+	StageAggregateEnqueue,
+}
+
+// Timing is the time spent in a stage.
+type Timing struct {
+	Stage     Stage
+	StartedAt time.Time
+	Duration  time.Duration
+	// Error is set if the stage failed.
+	Error string
+}
+
+// Breakdown is the time an upload spent in each stage it has been through,
+// in pipeline order. A stage that ran more than once, e.g. a retried upload,
+// reports its last run.
+type Breakdown struct {
+	Digest multihash.Multihash
+	Stages []Timing
+}
+
+// Total returns the time from the start of the first stage to the end of the
+// last.
+func (b Breakdown) Total() time.Duration {
+	if len(b.Stages) == 0 {
+		return 0
+	}
+	start := b.Stages[0].StartedAt
+	var end time.Time
+	for _, s := range b.Stages {
+		if s.StartedAt.Before(start) {
+			start = s.StartedAt
+		}
+		if e := s.StartedAt.Add(s.Duration); e.After(end) {
+			end = e
+		}
+	}
+	return end.Sub(start)
+}
+
+type entry struct {
+	stages    map[Stage]Timing
+	updatedAt time.Time
+}
+
+// Tracker records stage timings per blob. A nil Tracker only emits spans.
+type Tracker struct {
+	retention  time.Duration
+	maxUploads int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	uploads map[string]*entry
+}
+
+// NewTracker creates a Tracker keeping breakdowns for retention after their
+// last stage, and at most maxUploads of them.
+func NewTracker(retention time.Duration, maxUploads int) *Tracker {
+	return &Tracker{
+		retention:  retention,
+		maxUploads: maxUploads,
+		now:        time.Now,
+		uploads:    map[string]*entry{},
+	}
+}
+
+// Timer times a stage started with Tracker.Start.
+type Timer struct {
+	tracker *Tracker
+	digest  multihash.Multihash
+	stage   Stage
+	start   time.Time
+	span    trace.Span
+}
+
+// Start starts timing a stage for the blob, returning a context carrying the
+// stage span. End must be called on the returned Timer.
+func (t *Tracker) Start(ctx context.Context, digest multihash.Multihash, stage Stage) (context.Context, *Timer) {
+	ctx, span := tracer.Start(ctx, "upload."+string(stage), trace.WithAttributes(
+		attribute.Stringer("blob.digest", digest),
+		attribute.String("upload.stage", string(stage)),
+	))
+	return ctx, &Timer{tracker: t, digest: digest, stage: stage, start: time.Now(), span: span}
+}
+
+// End ends the stage span and records the time spent in the stage. err is
+// the error the stage failed with, if any.
+func (tm *Timer) End(err error) {
+	timing := Timing{Stage: tm.stage, StartedAt: tm.start, Duration: time.Since(tm.start)}
+	if err != nil {
+		timing.Error = err.Error()
+		tm.span.RecordError(err)
+		tm.span.SetStatus(codes.Error, err.Error())
+	}
+	tm.span.End()
+	tm.tracker.Record(tm.digest, timing)
+}
+
+// Record records the time a blob spent in a stage.
+func (t *Tracker) Record(digest multihash.Multihash, timing Timing) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	key := string(digest)
+	e, ok := t.uploads[key]
+	if !ok {
+		if len(t.uploads) >= t.maxUploads {
+			t.evict(now)
+		}
+		e = &entry{stages: map[Stage]Timing{}}
+		t.uploads[key] = e
+	}
+	e.stages[timing.Stage] = timing
+	e.updatedAt = now
+}
+
+// Get returns the breakdown for a blob, if it has been recorded within the
+// retention period.
+func (t *Tracker) Get(digest multihash.Multihash) (Breakdown, bool) {
+	if t == nil {
+		return Breakdown{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.uploads[string(digest)]
+	if !ok || t.now().Sub(e.updatedAt) > t.retention {
+		return Breakdown{}, false
+	}
+	b := Breakdown{Digest: digest}
+	for _, s := range Stages {
+		if timing, ok := e.stages[s]; ok {
+			b.Stages = append(b.Stages, timing)
+		}
+	}
+	return b, true
+}
+
+// evict removes expired breakdowns, and the least recently updated one if
+// none have expired.
+func (t *Tracker) evict(now time.Time) {
+	var (
+		oldestKey string
+		oldest    time.Time
+	)
+	for k, e := range t.uploads {
+		if now.Sub(e.updatedAt) > t.retention {
+			delete(t.uploads, k)
+			continue
+		}
+		if oldestKey == "" || e.updatedAt.Before(oldest) {
+			oldestKey, oldest = k, e.updatedAt
+		}
+	}
+	if len(t.uploads) >= t.maxUploads && oldestKey != "" {
+		delete(t.uploads, oldestKey)
+	}
+}
@@ -0,0 +1,79 @@
+package latency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	now := time.Now()
+	tr := NewTracker(time.Hour, 2)
+	tr.now = func() time.Time { return now }
+
+	t.Run("reports stages in pipeline order", func(t *testing.T) {
+		digest := testutil.RandomMultihash(t)
+		tr.Record(digest, Timing{Stage: StageAccept, StartedAt: now.Add(3 * time.Second), Duration: 2 * time.Second})
+		tr.Record(digest, Timing{Stage: StageAllocate, StartedAt: now, Duration: time.Second})
+		tr.Record(digest, Timing{Stage: StageUpload, StartedAt: now.Add(time.Second), Duration: time.Second, Error: "boom"})
+		// a retried upload replaces the failed one
+		tr.Record(digest, Timing{Stage: StageUpload, StartedAt: now.Add(time.Second), Duration: 2 * time.Second})
+
+		b, ok := tr.Get(digest)
+		require.True(t, ok)
+		require.Len(t, b.Stages, 3)
+		require.Equal(t, StageAllocate, b.Stages[0].Stage)
+		require.Equal(t, StageUpload, b.Stages[1].Stage)
+		require.Empty(t, b.Stages[1].Error)
+		require.Equal(t, StageAccept, b.Stages[2].Stage)
+		require.Equal(t, 5*time.Second, b.Total())
+	})
+
+	t.Run("expires breakdowns after the retention period", func(t *testing.T) {
+		digest := testutil.RandomMultihash(t)
+		tr.Record(digest, Timing{Stage: StageAllocate, StartedAt: now, Duration: time.Second})
+
+		now = now.Add(2 * time.Hour)
+		_, ok := tr.Get(digest)
+		require.False(t, ok)
+	})
+
+	t.Run("evicts the least recently updated breakdown when full", func(t *testing.T) {
+		first, second, third := testutil.RandomMultihash(t), testutil.RandomMultihash(t), testutil.RandomMultihash(t)
+		for _, d := range [][]byte{first, second, third} {
+			now = now.Add(time.Second)
+			tr.Record(d, Timing{Stage: StageAllocate, StartedAt: now})
+		}
+
+		_, ok := tr.Get(first)
+		require.False(t, ok)
+		_, ok = tr.Get(second)
+		require.True(t, ok)
+		_, ok = tr.Get(third)
+		require.True(t, ok)
+	})
+
+	t.Run("timer records the stage error", func(t *testing.T) {
+		digest := testutil.RandomMultihash(t)
+		_, timer := tr.Start(t.Context(), digest, StageClaimPublish)
+		timer.End(errors.New("publish failed"))
+
+		b, ok := tr.Get(digest)
+		require.True(t, ok)
+		require.Len(t, b.Stages, 1)
+		require.Equal(t, "publish failed", b.Stages[0].Error)
+	})
+}
+
+func TestNilTracker(t *testing.T) {
+	var tr *Tracker
+	digest := testutil.RandomMultihash(t)
+	_, timer := tr.Start(t.Context(), digest, StageUpload)
+	timer.End(nil)
+
+	_, ok := tr.Get(digest)
+	require.False(t, ok)
+}
@@ -2,10 +2,15 @@ package telemetry
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconvhttp "go.opentelemetry.io/otel/semconv/v1.37.0/httpconv"
 
@@ -21,6 +26,10 @@ const (
 	defaultPublishInterval = 30 * time.Second
 )
 
+// Registry holds the metrics served for scraping when the Prometheus endpoint
+// is enabled.
+var Registry = prometheus.NewRegistry()
+
 func Setup(ctx context.Context, network string, id string, cfg app.TelemetryConfig) (*telemetry.Telemetry, error) {
 	if network == "" {
 		log.Warn("network not configured; telemetry will use 'custom' as deployment environment")
@@ -55,6 +64,15 @@ func Setup(ctx context.Context, network string, id string, cfg app.TelemetryConf
 		})
 	}
 
+	var metricReaders []sdkmetric.Reader
+	if cfg.Prometheus {
+		exporter, err := otelprom.New(otelprom.WithRegisterer(Registry))
+		if err != nil {
+			return nil, fmt.Errorf("creating prometheus exporter: %w", err)
+		}
+		metricReaders = append(metricReaders, exporter)
+	}
+
 	// Build trace collectors list
 	var traceCollectors []traces.CollectorConfig
 
@@ -67,6 +85,17 @@ func Setup(ctx context.Context, network string, id string, cfg app.TelemetryConf
 			PublishInterval: c.PublishInterval,
 		})
 	}
+	// Add the collector configured by the standard OTLP environment variables
+	if TracesFromEnv() {
+		traceCollectors = append(traceCollectors, traces.CollectorConfig{FromEnv: true})
+	}
+
+	// Only sample when there is a parent trace; never start local roots unless
+	// a sample ratio is configured for background work (e.g. proof submission).
+	rootSampler := sdktrace.NeverSample()
+	if cfg.TraceSampleRatio > 0 {
+		rootSampler = sdktrace.TraceIDRatioBased(cfg.TraceSampleRatio)
+	}
 
 	return telemetry.New(
 		ctx,
@@ -76,7 +105,11 @@ func Setup(ctx context.Context, network string, id string, cfg app.TelemetryConf
 		id,
 		metrics.Config{
 			Collectors: metricCollectors,
+			Readers:    metricReaders,
 			Options: []sdkmetric.Option{
+				// attach the trace ID of sampled spans to histogram buckets as
+				// exemplars, linking latency metrics to the traces behind them
+				sdkmetric.WithExemplarFilter(exemplar.TraceBasedFilter),
 				sdkmetric.WithView(
 					// custom views for http metics with more buckets for histograms
 					DefaultHTTPServicerRequestDurationView,
@@ -88,15 +121,31 @@ func Setup(ctx context.Context, network string, id string, cfg app.TelemetryConf
 		traces.Config{
 			Collectors: traceCollectors,
 			Options: []sdktrace.TracerProviderOption{
-				// Only sample when there is a parent trace; never start local roots.
 				sdktrace.WithSampler(
-					sdktrace.ParentBased(sdktrace.NeverSample()),
+					sdktrace.ParentBased(rootSampler),
 				),
 			},
 		},
 	)
 }
 
+// TracesFromEnv reports whether an OTLP trace exporter is configured by the
+// standard OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+// environment variables.
+func TracesFromEnv() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != ""
+}
+
+// SetupCorrelation propagates trace context and starts spans without
+// exporting any telemetry, so the logs of a request carry its trace ID when
+// telemetry is not set up.
+func SetupCorrelation() {
+	otel.SetTextMapPropagator(traces.Propagator)
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.NeverSample())),
+	))
+}
+
 var HTTPServerDurationBounds = []float64{
 	(5 * time.Millisecond).Seconds(),
 	(10 * time.Millisecond).Seconds(),
@@ -0,0 +1,191 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/storacha/piri/pkg/config/app"
+)
+
+const (
+	// DefaultGCPKMSEndpoint is the endpoint of the Cloud KMS REST API.
+	DefaultGCPKMSEndpoint = "https://cloudkms.googleapis.com"
+	// GCPAccessTokenEnv holds an OAuth access token for Cloud KMS, used
+	// instead of the token of the instance service account.
+	GCPAccessTokenEnv = "GOOGLE_OAUTH_ACCESS_TOKEN"
+
+	gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// GCPTokenSource returns an OAuth access token authorizing Cloud KMS calls.
+type GCPTokenSource func(ctx context.Context) (string, error)
+
+// NewGCPKMSWalletFromConfig creates a KMSWallet for a Cloud KMS key, using the
+// token in GOOGLE_OAUTH_ACCESS_TOKEN or else the token of the service account
+// of the GCE/GKE instance.
+func NewGCPKMSWalletFromConfig(ctx context.Context, cfg app.GCPKMSConfig) (*KMSWallet, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultGCPKMSEndpoint
+	}
+	tokens := MetadataTokenSource(http.DefaultClient)
+	if token := os.Getenv(GCPAccessTokenEnv); token != "" {
+		tokens = func(context.Context) (string, error) { return token, nil }
+	}
+	return NewGCPKMSWallet(ctx, cfg.KeyName, endpoint, tokens, http.DefaultClient)
+}
+
+// NewGCPKMSWallet creates a KMSWallet for the Cloud KMS key version keyName
+// (projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*),
+// fetching its public key to derive the wallet address.
+func NewGCPKMSWallet(ctx context.Context, keyName, endpoint string, tokens GCPTokenSource, httpClient *http.Client) (*KMSWallet, error) {
+	key := &gcpKMSKey{
+		name:     keyName,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		tokens:   tokens,
+		http:     httpClient,
+	}
+
+	var res struct {
+		PEM       string `json:"pem"`
+		Algorithm string `json:"algorithm"`
+	}
+	if err := key.call(ctx, http.MethodGet, "/publicKey", nil, &res); err != nil {
+		return nil, fmt.Errorf("getting public key of KMS key %s: %w", keyName, err)
+	}
+	if res.Algorithm != "" && res.Algorithm != "EC_SIGN_SECP256K1_SHA256" {
+		return nil, fmt.Errorf("KMS key %s has algorithm %s, expected EC_SIGN_SECP256K1_SHA256", keyName, res.Algorithm)
+	}
+	block, _ := pem.Decode([]byte(res.PEM))
+	if block == nil {
+		return nil, fmt.Errorf("KMS key %s has no PEM encoded public key", keyName)
+	}
+	pub, err := parseSecp256k1PublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key of KMS key %s: %w", keyName, err)
+	}
+	return newKMSWallet(key, pub), nil
+}
+
+// gcpKMSKey calls the Cloud KMS REST API for a key version. Only the two
+// operations needed for signing are used, so the Cloud SDK is not required.
+type gcpKMSKey struct {
+	name     string
+	endpoint string
+	tokens   GCPTokenSource
+	http     *http.Client
+}
+
+func (k *gcpKMSKey) signDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	req := map[string]any{"digest": map[string][]byte{"sha256": digest}}
+	var res struct {
+		Signature []byte `json:"signature"`
+	}
+	if err := k.call(ctx, http.MethodPost, ":asymmetricSign", req, &res); err != nil {
+		return nil, err
+	}
+	return res.Signature, nil
+}
+
+func (k *gcpKMSKey) String() string {
+	return k.name
+}
+
+func (k *gcpKMSKey) call(ctx context.Context, method, suffix string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, k.endpoint+"/v1/"+k.name+suffix, body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	token, err := k.tokens(ctx)
+	if err != nil {
+		return fmt.Errorf("getting GCP access token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := k.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		var gcpErr struct {
+			Error struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(data, &gcpErr)
+		return fmt.Errorf("KMS request failed with status %d: %s %s", res.StatusCode, gcpErr.Error.Status, gcpErr.Error.Message)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// MetadataTokenSource returns tokens of the service account of the GCE/GKE
+// instance from the metadata server, cached until shortly before they expire.
+func MetadataTokenSource(httpClient *http.Client) GCPTokenSource {
+	var (
+		mu      sync.Mutex
+		token   string
+		expires time.Time
+	)
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if token != "" && time.Now().Before(expires) {
+			return token, nil
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataTokenURL, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		res, err := httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("requesting token from metadata server: %w", err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("metadata server returned status %d", res.StatusCode)
+		}
+		var tok struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int64  `json:"expires_in"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&tok); err != nil {
+			return "", fmt.Errorf("decoding metadata server token: %w", err)
+		}
+		token = tok.AccessToken
+		// refresh a minute early so a token does not expire in flight
+		expires = time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second - time.Minute)
+		return token, nil
+	}
+}
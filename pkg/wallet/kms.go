@@ -0,0 +1,260 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/store/local/keystore"
+)
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// KMSWallet signs transactions with a single secp256k1 key held in a cloud
+// KMS, AWS KMS or GCP Cloud KMS. The private key never leaves KMS.
+type KMSWallet struct {
+	key     kmsKey
+	pubKey  []byte // uncompressed
+	address common.Address
+}
+
+var (
+	_ Wallet     = (*KMSWallet)(nil)
+	_ HashSigner = (*KMSWallet)(nil)
+)
+
+// kmsKey is a secp256k1 key held in a KMS.
+type kmsKey interface {
+	// signDigest returns the DER encoded ECDSA signature of a 32 byte digest.
+	signDigest(ctx context.Context, digest []byte) ([]byte, error)
+	String() string
+}
+
+func newKMSWallet(key kmsKey, pub *ecdsa.PublicKey) *KMSWallet {
+	return &KMSWallet{
+		key:     key,
+		pubKey:  crypto.FromECDSAPub(pub),
+		address: crypto.PubkeyToAddress(*pub),
+	}
+}
+
+// NewKMSWalletFromConfig creates a KMSWallet using credentials from the AWS
+// environment.
+func NewKMSWalletFromConfig(ctx context.Context, cfg app.AWSKMSConfig) (*KMSWallet, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region configured for KMS key %s", cfg.KeyID)
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com", awsCfg.Region)
+	}
+	return NewKMSWallet(ctx, cfg.KeyID, endpoint, awsCfg.Region, awsCfg.Credentials, http.DefaultClient)
+}
+
+// NewKMSWallet creates a KMSWallet for the AWS KMS key, fetching its public key to
+// derive the wallet address.
+func NewKMSWallet(ctx context.Context, keyID, endpoint, region string, creds aws.CredentialsProvider, httpClient *http.Client) (*KMSWallet, error) {
+	client := &kmsClient{
+		endpoint: endpoint,
+		region:   region,
+		creds:    creds,
+		signer:   v4.NewSigner(),
+		http:     httpClient,
+	}
+
+	var res struct {
+		PublicKey []byte `json:"PublicKey"`
+		KeySpec   string `json:"KeySpec"`
+	}
+	if err := client.call(ctx, "GetPublicKey", map[string]string{"KeyId": keyID}, &res); err != nil {
+		return nil, fmt.Errorf("getting public key of KMS key %s: %w", keyID, err)
+	}
+	if res.KeySpec != "" && res.KeySpec != "ECC_SECG_P256K1" {
+		return nil, fmt.Errorf("KMS key %s has key spec %s, expected ECC_SECG_P256K1", keyID, res.KeySpec)
+	}
+	pub, err := parseSecp256k1PublicKey(res.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key of KMS key %s: %w", keyID, err)
+	}
+
+	return newKMSWallet(&awsKMSKey{client: client, keyID: keyID}, pub), nil
+}
+
+// Address returns the address of the KMS key.
+func (w *KMSWallet) Address() common.Address {
+	return w.address
+}
+
+// Import is not supported, keys are created in KMS.
+func (w *KMSWallet) Import(context.Context, *keystore.KeyInfo) (common.Address, error) {
+	return common.Address{}, fmt.Errorf("importing keys is not supported by the KMS key stores, the key is managed in KMS")
+}
+
+func (w *KMSWallet) SignTransaction(ctx context.Context, addr common.Address, signer types.Signer, tx *types.Transaction) (*types.Transaction, error) {
+	sig, err := w.SignHash(ctx, addr, signer.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+// SignHash signs hash with the KMS key, which must have address addr.
+func (w *KMSWallet) SignHash(ctx context.Context, addr common.Address, hash []byte) ([]byte, error) {
+	if addr != w.address {
+		return nil, fmt.Errorf("key not found for address (%s): KMS key has address %s", addr, w.address)
+	}
+	der, err := w.key.signDigest(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("signing with KMS key %s: %w", w.key, err)
+	}
+	return recoverableSignature(hash, der, w.pubKey)
+}
+
+// recoverableSignature converts a DER encoded ECDSA signature into the 65 byte
+// [R || S || V] form used by Ethereum, with S in the lower half of the curve
+// order as required for transactions.
+func recoverableSignature(digest, der, pubKey []byte) ([]byte, error) {
+	var rs struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, fmt.Errorf("decoding signature: %w", err)
+	}
+	if rs.S.Cmp(secp256k1HalfN) > 0 {
+		rs.S = new(big.Int).Sub(secp256k1N, rs.S)
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	rs.R.FillBytes(sig[0:32])
+	rs.S.FillBytes(sig[32:64])
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		recovered, err := crypto.Ecrecover(digest, sig)
+		if err == nil && bytes.Equal(recovered, pubKey) {
+			return sig, nil
+		}
+	}
+	return nil, fmt.Errorf("signature does not match KMS public key")
+}
+
+// parseSecp256k1PublicKey parses a DER encoded SubjectPublicKeyInfo. The
+// standard library does not support the secp256k1 curve.
+func parseSecp256k1PublicKey(der []byte) (*ecdsa.PublicKey, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, err
+	}
+	return crypto.UnmarshalPubkey(spki.PublicKey.Bytes)
+}
+
+type awsKMSKey struct {
+	client *kmsClient
+	keyID  string
+}
+
+func (k *awsKMSKey) signDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	var res struct {
+		Signature []byte `json:"Signature"`
+	}
+	req := map[string]any{
+		"KeyId":            k.keyID,
+		"Message":          digest,
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": "ECDSA_SHA_256",
+	}
+	if err := k.client.call(ctx, "Sign", req, &res); err != nil {
+		return nil, err
+	}
+	return res.Signature, nil
+}
+
+func (k *awsKMSKey) String() string {
+	return k.keyID
+}
+
+// kmsClient calls the AWS KMS JSON API. Only the two operations needed for
+// signing are used, so the full KMS SDK is not required.
+type kmsClient struct {
+	endpoint string
+	region   string
+	creds    aws.CredentialsProvider
+	signer   *v4.Signer
+	http     *http.Client
+}
+
+func (c *kmsClient) call(ctx context.Context, op string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+op)
+
+	creds, err := c.creds.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieving AWS credentials: %w", err)
+	}
+	payloadHash := sha256.Sum256(body)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), "kms", c.region, time.Now()); err != nil {
+		return fmt.Errorf("signing request: %w", err)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		var kmsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &kmsErr)
+		return fmt.Errorf("KMS %s failed with status %d: %s %s", op, res.StatusCode, kmsErr.Type, kmsErr.Message)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
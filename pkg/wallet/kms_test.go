@@ -0,0 +1,183 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// testKMSKey generates a secp256k1 key and its DER encoded
+// SubjectPublicKeyInfo, as returned by KMS.
+func testKMSKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	oidECPublicKey := asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSecp256k1, err := asn1.Marshal(asn1.ObjectIdentifier{1, 3, 132, 0, 10})
+	require.NoError(t, err)
+	pub := crypto.FromECDSAPub(&key.PublicKey)
+	spki, err := asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidECPublicKey, Parameters: asn1.RawValue{FullBytes: oidSecp256k1}},
+		PublicKey: asn1.BitString{Bytes: pub, BitLength: len(pub) * 8},
+	})
+	require.NoError(t, err)
+	return key, spki
+}
+
+// derSign returns the DER encoded signature of digest. highS returns
+// signatures with S in the upper half of the curve order, which KMS may do.
+func derSign(t *testing.T, key *ecdsa.PrivateKey, digest []byte, highS bool) []byte {
+	sig, err := crypto.Sign(digest, key)
+	require.NoError(t, err)
+	sigR, sigS := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	if highS {
+		sigS.Sub(secp256k1N, sigS)
+	}
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{sigR, sigS})
+	require.NoError(t, err)
+	return der
+}
+
+// fakeGCPKMS implements the publicKey and asymmetricSign methods of the Cloud
+// KMS REST API with a local key.
+func fakeGCPKMS(t *testing.T, keyName string, highS bool) (*httptest.Server, common.Address) {
+	key, spki := testKMSKey(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/v1/" + keyName + "/publicKey":
+			require.Equal(t, http.MethodGet, r.Method)
+			pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: spki})
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+				"pem":       string(pemKey),
+				"algorithm": "EC_SIGN_SECP256K1_SHA256",
+			}))
+		case "/v1/" + keyName + ":asymmetricSign":
+			require.Equal(t, http.MethodPost, r.Method)
+			var req struct {
+				Digest struct {
+					SHA256 []byte `json:"sha256"`
+				} `json:"digest"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			der := derSign(t, key, req.Digest.SHA256, highS)
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"signature": der}))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"status":"NOT_FOUND","message":"unknown key"}}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+// fakeKMS implements GetPublicKey and Sign of the AWS KMS JSON API with a local
+// key.
+func fakeKMS(t *testing.T, highS bool) (*httptest.Server, common.Address) {
+	key, spki := testKMSKey(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256"))
+		var req struct {
+			KeyId   string
+			Message []byte
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "test-key", req.KeyId)
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GetPublicKey":
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+				"KeyId":     req.KeyId,
+				"KeySpec":   "ECC_SECG_P256K1",
+				"PublicKey": spki,
+			}))
+		case "TrentService.Sign":
+			der := derSign(t, key, req.Message, highS)
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"Signature": der}))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"UnknownOperationException"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func TestKMSWallet(t *testing.T) {
+	creds := credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")
+
+	for _, highS := range []bool{false, true} {
+		srv, addr := fakeKMS(t, highS)
+		w, err := NewKMSWallet(t.Context(), "test-key", srv.URL, "us-east-1", creds, srv.Client())
+		require.NoError(t, err)
+		requireKMSWalletSigns(t, w, addr)
+	}
+}
+
+func TestGCPKMSWallet(t *testing.T) {
+	keyName := "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+	tokens := func(context.Context) (string, error) { return "test-token", nil }
+
+	for _, highS := range []bool{false, true} {
+		srv, addr := fakeGCPKMS(t, keyName, highS)
+		w, err := NewGCPKMSWallet(t.Context(), keyName, srv.URL, tokens, srv.Client())
+		require.NoError(t, err)
+		requireKMSWalletSigns(t, w, addr)
+	}
+
+	t.Run("unknown key", func(t *testing.T) {
+		srv, _ := fakeGCPKMS(t, keyName, false)
+		_, err := NewGCPKMSWallet(t.Context(), "projects/p/other", srv.URL, tokens, srv.Client())
+		require.ErrorContains(t, err, "NOT_FOUND")
+	})
+}
+
+func requireKMSWalletSigns(t *testing.T, w *KMSWallet, addr common.Address) {
+	require.Equal(t, addr, w.Address())
+
+	signer := types.LatestSignerForChainID(big.NewInt(314159))
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(314159),
+		Nonce:     1,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+		Gas:       21000,
+		To:        &common.Address{},
+		Value:     big.NewInt(0),
+	})
+	signed, err := w.SignTransaction(t.Context(), addr, signer, tx)
+	require.NoError(t, err)
+
+	from, err := types.Sender(signer, signed)
+	require.NoError(t, err)
+	require.Equal(t, addr, from)
+
+	_, err = w.SignTransaction(t.Context(), common.HexToAddress("0x01"), signer, tx)
+	require.Error(t, err)
+
+	hash := crypto.Keccak256([]byte("typed data"))
+	sig, err := w.SignHash(t.Context(), addr, hash)
+	require.NoError(t, err)
+	pub, err := crypto.SigToPub(hash, sig)
+	require.NoError(t, err)
+	require.Equal(t, addr, crypto.PubkeyToAddress(*pub))
+}
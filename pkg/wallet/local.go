@@ -41,6 +41,12 @@ type Wallet interface {
 	SignTransaction(ctx context.Context, addr common.Address, signer types.Signer, tx *types.Transaction) (*types.Transaction, error)
 }
 
+// HashSigner signs 32 byte hashes, e.g. of EIP-712 typed data, with the key of
+// an address. Signatures are in the 65 byte [R || S || V] form with V 0 or 1.
+type HashSigner interface {
+	SignHash(ctx context.Context, addr common.Address, hash []byte) ([]byte, error)
+}
+
 type LocalWallet struct {
 	keys     map[common.Address]*Key
 	keystore keystore.KeyStore
@@ -68,6 +74,18 @@ func (w *LocalWallet) SignTransaction(ctx context.Context, addr common.Address,
 	return types.SignTx(tx, signer, privateKey)
 }
 
+func (w *LocalWallet) SignHash(ctx context.Context, addr common.Address, hash []byte) ([]byte, error) {
+	k, err := w.findKey(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := crypto.ToECDSA(k.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("converting private key: %w", err)
+	}
+	return crypto.Sign(hash, privateKey)
+}
+
 func (w *LocalWallet) Import(ctx context.Context, ki *keystore.KeyInfo) (common.Address, error) {
 	w.keysMu.Lock()
 	defer w.keysMu.Unlock()
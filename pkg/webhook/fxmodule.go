@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	leveldb "github.com/ipfs/go-ds-leveldb"
+	"go.uber.org/fx"
+
+	"github.com/storacha/piri/pkg/config/app"
+	"github.com/storacha/piri/pkg/store/receiptstore"
+)
+
+// Module delivers notifications of issued receipts to registered webhooks. It
+// decorates the receipt store, so it must be included at the root of the app
+// rather than in another module for the decoration to apply to all consumers
+// of the store.
+var Module = fx.Options(
+	fx.Module("webhook",
+		fx.Provide(NewServiceFromConfig),
+		// force construction, so notifications queued before a restart are
+		// delivered
+		fx.Invoke(func(*Service) {}),
+	),
+	fx.Decorate(DecorateReceiptStore),
+)
+
+// NewServiceFromConfig creates the webhook service, persisting endpoints in
+// the data directory. Without a data directory endpoints are kept in memory.
+func NewServiceFromConfig(lc fx.Lifecycle, storageCfg app.StorageConfig) (*Service, error) {
+	var ds datastore.Batching
+	if storageCfg.DataDir == "" {
+		log.Warn("no data dir configured, registered webhooks will not persist across restarts")
+		ds = sync.MutexWrap(datastore.NewMapDatastore())
+	} else {
+		dir := filepath.Join(storageCfg.DataDir, DataDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("creating directory: %s: %w", dir, err)
+		}
+		ldb, err := leveldb.NewDatastore(dir, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating webhook store: %w", err)
+		}
+		ds = ldb
+	}
+
+	svc := New(ds, nil)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return svc.Start(context.Background())
+		},
+		OnStop: func(ctx context.Context) error {
+			if err := svc.Stop(ctx); err != nil {
+				return err
+			}
+			return ds.Close()
+		},
+	})
+	return svc, nil
+}
+
+// DecorateReceiptStore queues webhook notifications of stored receipts.
+func DecorateReceiptStore(receipts receiptstore.ReceiptStore, webhooks *Service) receiptstore.ReceiptStore {
+	return NewReceiptStore(receipts, webhooks)
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/storacha/go-ucanto/core/receipt"
+
+	"github.com/storacha/piri/pkg/store/receiptstore"
+)
+
+// ReceiptStore notifies the registered endpoints of every receipt put in the
+// wrapped store.
+type ReceiptStore struct {
+	receiptstore.ReceiptStore
+	webhooks *Service
+}
+
+var _ receiptstore.ReceiptStore = (*ReceiptStore)(nil)
+
+func NewReceiptStore(receipts receiptstore.ReceiptStore, webhooks *Service) *ReceiptStore {
+	return &ReceiptStore{ReceiptStore: receipts, webhooks: webhooks}
+}
+
+func (s *ReceiptStore) Put(ctx context.Context, rcpt receipt.AnyReceipt) error {
+	if err := s.ReceiptStore.Put(ctx, rcpt); err != nil {
+		return err
+	}
+	// failing to queue notifications must not fail the operation issuing the
+	// receipt
+	if err := s.webhooks.Notify(ctx, rcpt); err != nil {
+		log.Errorw("queueing webhook notifications", "receipt", rcpt.Root().Link(), "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+)
+
+const (
+	// SignatureHeader holds the signature of a notification, in the form
+	// t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed by the
+	// endpoint secret>.
+	SignatureHeader = "X-Piri-Signature"
+	// DeliveryHeader holds the receipt CID identifying the notification, for
+	// receivers to ignore notifications they have already processed.
+	DeliveryHeader = "X-Piri-Delivery"
+	// AbilityHeader holds the ability of the invocation the receipt is for.
+	AbilityHeader = "X-Piri-Ability"
+
+	// MaxAttempts is the number of times a notification is sent before it is
+	// given up on.
+	MaxAttempts = 10
+	// MinBackoff is the delay before the first retry. It doubles with every
+	// failed attempt, up to MaxBackoff.
+	MinBackoff = 30 * time.Second
+	MaxBackoff = time.Hour
+	// Timeout bounds a single delivery attempt.
+	Timeout = 10 * time.Second
+
+	// pollInterval is how often retries that have become due are sent.
+	pollInterval = 5 * time.Second
+)
+
+// Service stores the registered endpoints and delivers notifications to them.
+type Service struct {
+	ds     datastore.Datastore
+	client *http.Client
+	now    func() time.Time
+	// mu serializes updates of endpoints and deliveries.
+	mu     sync.Mutex
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a Service persisting endpoints and pending deliveries in ds.
+func New(ds datastore.Datastore, client *http.Client) *Service {
+	if client == nil {
+		client = &http.Client{Timeout: Timeout}
+	}
+	return &Service{
+		ds:     ds,
+		client: client,
+		now:    time.Now,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start starts delivering notifications in the background.
+func (s *Service) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	go s.run(runCtx)
+	return nil
+}
+
+// Stop stops delivering notifications. Pending notifications are delivered
+// after the next start.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for webhook delivery to stop: %w", ctx.Err())
+	}
+}
+
+func (s *Service) run(ctx context.Context) {
+	defer close(s.done)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.deliverDue(ctx); err != nil && ctx.Err() == nil {
+			log.Errorw("delivering webhook notifications", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// deliverDue sends the notifications whose next attempt is due.
+func (s *Service) deliverDue(ctx context.Context) error {
+	due, err := s.due(ctx)
+	if err != nil {
+		return err
+	}
+	endpoints := map[string]Endpoint{}
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		ep, ok := endpoints[d.Endpoint]
+		if !ok {
+			ep, err = s.Get(ctx, d.Endpoint)
+			if err != nil {
+				if errors.Is(err, ErrNotFound) {
+					// removed while the notification was being queued
+					if err := s.ds.Delete(ctx, d.key()); err != nil {
+						return fmt.Errorf("deleting delivery: %w", err)
+					}
+					continue
+				}
+				return err
+			}
+			endpoints[d.Endpoint] = ep
+		}
+		s.attempt(ctx, ep, d)
+	}
+	return nil
+}
+
+func (s *Service) due(ctx context.Context) ([]delivery, error) {
+	results, err := s.ds.Query(ctx, query.Query{Prefix: deliveryPrefix.String()})
+	if err != nil {
+		return nil, fmt.Errorf("querying deliveries: %w", err)
+	}
+	defer results.Close()
+
+	now := s.now()
+	var due []delivery
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating deliveries: %w", entry.Error)
+		}
+		var d delivery
+		if err := json.Unmarshal(entry.Value, &d); err != nil {
+			log.Warnw("dropping undecodable delivery", "key", entry.Key, "error", err)
+			if err := s.ds.Delete(ctx, datastore.NewKey(entry.Key)); err != nil {
+				return nil, fmt.Errorf("deleting delivery: %w", err)
+			}
+			continue
+		}
+		if !d.NextAttempt.After(now) {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+// attempt sends a notification and records the outcome.
+func (s *Service) attempt(ctx context.Context, ep Endpoint, d delivery) {
+	sendErr := s.send(ctx, ep, d)
+	if sendErr != nil && ctx.Err() != nil {
+		// interrupted by shutdown, not a failure of the endpoint
+		return
+	}
+	now := s.now()
+	d.Attempts++
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the endpoint may have been removed while sending
+	current, err := s.Get(ctx, ep.ID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Errorw("getting webhook", "id", ep.ID, "error", err)
+		}
+		return
+	}
+	current.LastAttemptAt = now.UTC()
+
+	switch {
+	case sendErr == nil:
+		current.Delivered++
+		current.LastError = ""
+		err = s.ds.Delete(ctx, d.key())
+	case d.Attempts >= MaxAttempts:
+		log.Errorw("giving up on webhook notification", "id", ep.ID, "receipt", d.Receipt, "attempts", d.Attempts, "error", sendErr)
+		current.Failed++
+		current.LastError = trimError(sendErr.Error())
+		err = s.ds.Delete(ctx, d.key())
+	default:
+		log.Warnw("webhook notification failed, will retry", "id", ep.ID, "receipt", d.Receipt, "attempts", d.Attempts, "error", sendErr)
+		current.LastError = trimError(sendErr.Error())
+		d.NextAttempt = now.Add(Backoff(d.Attempts))
+		err = s.putDelivery(ctx, d)
+	}
+	if err != nil {
+		log.Errorw("updating webhook delivery", "id", ep.ID, "receipt", d.Receipt, "error", err)
+	}
+	if err := s.putEndpoint(ctx, current); err != nil {
+		log.Errorw("updating webhook", "id", ep.ID, "error", err)
+	}
+}
+
+func (s *Service) send(ctx context.Context, ep Endpoint, d delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign([]byte(ep.Secret), s.now(), d.Body))
+	req.Header.Set(DeliveryHeader, d.Receipt)
+	if d.Ability != "" {
+		req.Header.Set(AbilityHeader, d.Ability)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		err := fmt.Errorf("unexpected status: %d %s", res.StatusCode, http.StatusText(res.StatusCode))
+		if msg, _ := io.ReadAll(io.LimitReader(res.Body, 256)); len(bytes.TrimSpace(msg)) > 0 {
+			err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(msg))
+		}
+		return err
+	}
+	return nil
+}
+
+// Backoff returns the delay before the next attempt of a notification that
+// failed the given number of times.
+func Backoff(attempts int) time.Duration {
+	d := MinBackoff
+	for i := 1; i < attempts && d < MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, MaxBackoff)
+}
+
+// Sign returns the value of the SignatureHeader of a notification body sent
+// at time t.
+func Sign(secret []byte, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+// Verify checks the SignatureHeader of a notification body, and that it was
+// signed within tolerance of now, to reject replayed notifications.
+func Verify(secret []byte, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid signature timestamp %q", ts)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("signature timestamp outside tolerance: %s", age)
+	}
+	want, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(want, mac(secret, ts, body)) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func mac(secret []byte, ts string, body []byte) []byte {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(ts))
+	m.Write([]byte("."))
+	m.Write(body)
+	return m.Sum(nil)
+}
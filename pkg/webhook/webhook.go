@@ -0,0 +1,355 @@
+// Package webhook notifies external systems of the receipts issued by the
+// node, such as billing or CRM integrations that would otherwise have to poll.
+//
+// Operators register endpoints that receive a signed JSON notification for
+// every receipt of the abilities they subscribe to, e.g. blob/accept when a
+// blob is accepted, blob/replica/transfer when a replica transfer completes and
+// pdp/accept when a piece is added to a proof set. Notifications are queued in
+// a datastore when the receipt is stored and delivered in the background, so
+// an unavailable endpoint never delays the operation that issued the receipt.
+// Failed deliveries are retried with exponential backoff, across restarts.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result"
+)
+
+var log = logging.Logger("webhook")
+
+// DataDir is the directory, relative to the data directory, holding the
+// registered endpoints and pending deliveries.
+const DataDir = "webhook"
+
+var (
+	endpointPrefix = datastore.NewKey("endpoints")
+	deliveryPrefix = datastore.NewKey("deliveries")
+)
+
+// ErrNotFound is returned when no endpoint has the requested ID.
+var ErrNotFound = errors.New("webhook not found")
+
+// Endpoint is a URL notified of issued receipts.
+type Endpoint struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Secret is the key notifications to the endpoint are signed with.
+	Secret string `json:"secret"`
+	// Abilities are the abilities of the invocations whose receipts are sent
+	// to the endpoint, every receipt if empty.
+	Abilities []string  `json:"abilities,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Delivered is the number of notifications the endpoint accepted.
+	Delivered uint64 `json:"delivered"`
+	// Failed is the number of notifications given up on after MaxAttempts.
+	Failed uint64 `json:"failed"`
+	// LastError is the error of the last failed attempt, cleared when a
+	// notification is delivered.
+	LastError     string    `json:"last_error,omitempty"`
+	LastAttemptAt time.Time `json:"last_attempt_at,omitzero"`
+
+	// Pending is the number of notifications waiting to be delivered. It is
+	// set by List.
+	Pending int `json:"-"`
+}
+
+// Subscribed reports whether receipts of the ability are sent to the endpoint.
+func (e Endpoint) Subscribed(ability string) bool {
+	return len(e.Abilities) == 0 || slices.Contains(e.Abilities, ability)
+}
+
+// Notification is the JSON body posted to endpoints.
+type Notification struct {
+	// Receipt is the CID of the receipt. It identifies the notification, and
+	// is the same on every retry.
+	Receipt string `json:"receipt"`
+	// Invocation is the CID of the invocation the receipt is for.
+	Invocation string `json:"invocation"`
+	// Ability is the ability of the invocation, empty if the receipt does not
+	// include the invocation.
+	Ability string `json:"ability,omitempty"`
+	OK      bool   `json:"ok"`
+	// Error is the message of a failure receipt.
+	Error string `json:"error,omitempty"`
+	// Archive is the receipt encoded as a CAR, for receivers that verify its
+	// signature or decode its result.
+	Archive []byte `json:"archive"`
+	// IssuedAt is the time the receipt was stored.
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// NewNotification creates the notification of a receipt.
+func NewNotification(rcpt receipt.AnyReceipt, issuedAt time.Time) (Notification, error) {
+	archive, err := io.ReadAll(rcpt.Archive())
+	if err != nil {
+		return Notification{}, fmt.Errorf("archiving receipt: %w", err)
+	}
+	n := Notification{
+		Receipt:    rcpt.Root().Link().String(),
+		Invocation: rcpt.Ran().Link().String(),
+		Ability:    abilityOf(rcpt),
+		OK:         true,
+		Archive:    archive,
+		IssuedAt:   issuedAt.UTC(),
+	}
+	if _, x := result.Unwrap(rcpt.Out()); x != nil {
+		n.OK = false
+		if msg, err := x.LookupByString("message"); err == nil {
+			n.Error, _ = msg.AsString()
+		}
+	}
+	return n, nil
+}
+
+func abilityOf(rcpt receipt.AnyReceipt) string {
+	inv, ok := rcpt.Ran().Invocation()
+	if !ok || len(inv.Capabilities()) == 0 {
+		return ""
+	}
+	return inv.Capabilities()[0].Can()
+}
+
+// delivery is a notification queued for an endpoint.
+type delivery struct {
+	Endpoint    string    `json:"endpoint"`
+	Receipt     string    `json:"receipt"`
+	Ability     string    `json:"ability,omitempty"`
+	Body        []byte    `json:"body"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+func (d delivery) key() datastore.Key {
+	return deliveryPrefix.ChildString(d.Endpoint).ChildString(d.Receipt)
+}
+
+// Add registers an endpoint. A secret is generated if the endpoint has none.
+func (s *Service) Add(ctx context.Context, ep Endpoint) (Endpoint, error) {
+	u, err := url.Parse(ep.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Endpoint{}, fmt.Errorf("invalid webhook URL %q: must be an absolute http or https URL", ep.URL)
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	if ep.Secret == "" {
+		ep.Secret, err = randomHex(32)
+		if err != nil {
+			return Endpoint{}, err
+		}
+	}
+	added := Endpoint{
+		ID:        id,
+		URL:       u.String(),
+		Secret:    ep.Secret,
+		Abilities: ep.Abilities,
+		CreatedAt: s.now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.putEndpoint(ctx, added); err != nil {
+		return Endpoint{}, err
+	}
+	log.Infow("registered webhook", "id", added.ID, "url", added.URL, "abilities", added.Abilities)
+	return added, nil
+}
+
+// Remove unregisters an endpoint and drops its pending notifications.
+func (s *Service) Remove(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := endpointPrefix.ChildString(id)
+	if _, err := s.ds.Get(ctx, key); err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("getting webhook: %w", err)
+	}
+	keys, err := s.keys(ctx, deliveryPrefix.ChildString(id))
+	if err != nil {
+		return err
+	}
+	for _, k := range append(keys, key) {
+		if err := s.ds.Delete(ctx, k); err != nil {
+			return fmt.Errorf("deleting %s: %w", k, err)
+		}
+	}
+	log.Infow("removed webhook", "id", id, "dropped", len(keys))
+	return nil
+}
+
+// Get returns a registered endpoint.
+func (s *Service) Get(ctx context.Context, id string) (Endpoint, error) {
+	data, err := s.ds.Get(ctx, endpointPrefix.ChildString(id))
+	if err != nil {
+		if errors.Is(err, datastore.ErrNotFound) {
+			return Endpoint{}, ErrNotFound
+		}
+		return Endpoint{}, fmt.Errorf("getting webhook: %w", err)
+	}
+	var ep Endpoint
+	if err := json.Unmarshal(data, &ep); err != nil {
+		return Endpoint{}, fmt.Errorf("decoding webhook %s: %w", id, err)
+	}
+	return ep, nil
+}
+
+// List returns the registered endpoints, oldest first, with the number of
+// notifications pending for each.
+func (s *Service) List(ctx context.Context) ([]Endpoint, error) {
+	endpoints, err := s.endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range endpoints {
+		keys, err := s.keys(ctx, deliveryPrefix.ChildString(endpoints[i].ID))
+		if err != nil {
+			return nil, err
+		}
+		endpoints[i].Pending = len(keys)
+	}
+	slices.SortFunc(endpoints, func(a, b Endpoint) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return endpoints, nil
+}
+
+// Notify queues a notification of the receipt for every endpoint subscribed
+// to its ability.
+func (s *Service) Notify(ctx context.Context, rcpt receipt.AnyReceipt) error {
+	endpoints, err := s.endpoints(ctx)
+	if err != nil {
+		return err
+	}
+	ability := abilityOf(rcpt)
+	endpoints = slices.DeleteFunc(endpoints, func(ep Endpoint) bool {
+		return !ep.Subscribed(ability)
+	})
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	n, err := NewNotification(rcpt, s.now())
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("encoding notification: %w", err)
+	}
+	for _, ep := range endpoints {
+		d := delivery{
+			Endpoint:    ep.ID,
+			Receipt:     n.Receipt,
+			Ability:     n.Ability,
+			Body:        body,
+			NextAttempt: n.IssuedAt,
+		}
+		if err := s.putDelivery(ctx, d); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *Service) endpoints(ctx context.Context) ([]Endpoint, error) {
+	results, err := s.ds.Query(ctx, query.Query{Prefix: endpointPrefix.String()})
+	if err != nil {
+		return nil, fmt.Errorf("querying webhooks: %w", err)
+	}
+	defer results.Close()
+
+	var endpoints []Endpoint
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating webhooks: %w", entry.Error)
+		}
+		var ep Endpoint
+		if err := json.Unmarshal(entry.Value, &ep); err != nil {
+			return nil, fmt.Errorf("decoding webhook %s: %w", entry.Key, err)
+		}
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, nil
+}
+
+func (s *Service) keys(ctx context.Context, prefix datastore.Key) ([]datastore.Key, error) {
+	results, err := s.ds.Query(ctx, query.Query{Prefix: prefix.String(), KeysOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("querying %s: %w", prefix, err)
+	}
+	defer results.Close()
+
+	var keys []datastore.Key
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, fmt.Errorf("iterating %s: %w", prefix, entry.Error)
+		}
+		keys = append(keys, datastore.NewKey(entry.Key))
+	}
+	return keys, nil
+}
+
+func (s *Service) putEndpoint(ctx context.Context, ep Endpoint) error {
+	data, err := json.Marshal(ep)
+	if err != nil {
+		return fmt.Errorf("encoding webhook: %w", err)
+	}
+	if err := s.ds.Put(ctx, endpointPrefix.ChildString(ep.ID), data); err != nil {
+		return fmt.Errorf("putting webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) putDelivery(ctx context.Context, d delivery) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("encoding delivery: %w", err)
+	}
+	if err := s.ds.Put(ctx, d.key(), data); err != nil {
+		return fmt.Errorf("putting delivery: %w", err)
+	}
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// trimError shortens an error stored with an endpoint.
+func trimError(msg string) string {
+	const max = 256
+	msg = strings.TrimSpace(msg)
+	if len(msg) > max {
+		return msg[:max] + "..."
+	}
+	return msg
+}
@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-data-segment/merkletree"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/sync"
+	pdpcaps "github.com/storacha/go-libstoracha/capabilities/pdp"
+	"github.com/storacha/go-libstoracha/testutil"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/receipt/ran"
+	"github.com/storacha/go-ucanto/core/result"
+	ufailure "github.com/storacha/go-ucanto/core/result/failure"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, now *time.Time) *Service {
+	t.Helper()
+	s := New(sync.MutexWrap(datastore.NewMapDatastore()), nil)
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func acceptReceipt(t *testing.T, fail bool) receipt.AnyReceipt {
+	t.Helper()
+	inv, err := pdpcaps.Accept.Invoke(
+		testutil.Alice,
+		testutil.Alice,
+		testutil.Alice.DID().String(),
+		pdpcaps.AcceptCaveats{Blob: testutil.RandomMultihash(t)},
+	)
+	require.NoError(t, err)
+	out := result.Ok[ipld.Builder, ipld.Builder](pdpcaps.AcceptOk{
+		Piece:          testutil.RandomPiece(t, 256),
+		Aggregate:      testutil.RandomPiece(t, 256*1024*1024),
+		InclusionProof: merkletree.ProofData{},
+	})
+	if fail {
+		out = result.Error[ipld.Builder, ipld.Builder](ufailure.FromError(errors.New("piece not found")))
+	}
+	rcpt, err := receipt.Issue(testutil.Alice, out, ran.FromInvocation(inv))
+	require.NoError(t, err)
+	return rcpt
+}
+
+func TestDelivery(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	secret := []byte("secret")
+
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Run("delivers signed notifications to subscribed endpoints", func(t *testing.T) {
+		s := newTestService(t, &now)
+		ep, err := s.Add(t.Context(), Endpoint{URL: srv.URL, Secret: string(secret), Abilities: []string{pdpcaps.AcceptAbility}})
+		require.NoError(t, err)
+		_, err = s.Add(t.Context(), Endpoint{URL: srv.URL + "/other", Abilities: []string{"blob/accept"}})
+		require.NoError(t, err)
+
+		rcpt := acceptReceipt(t, false)
+		require.NoError(t, s.Notify(t.Context(), rcpt))
+		require.NoError(t, s.deliverDue(t.Context()))
+
+		require.Len(t, received, 1)
+		req, body := <-received, <-bodies
+		require.Equal(t, "/", req.URL.Path)
+		require.Equal(t, rcpt.Root().Link().String(), req.Header.Get(DeliveryHeader))
+		require.Equal(t, pdpcaps.AcceptAbility, req.Header.Get(AbilityHeader))
+		require.NoError(t, Verify(secret, req.Header.Get(SignatureHeader), body, now, time.Minute))
+		require.Error(t, Verify([]byte("wrong"), req.Header.Get(SignatureHeader), body, now, time.Minute))
+		require.Error(t, Verify(secret, req.Header.Get(SignatureHeader), body, now.Add(time.Hour), time.Minute))
+
+		var n Notification
+		require.NoError(t, json.Unmarshal(body, &n))
+		require.Equal(t, rcpt.Root().Link().String(), n.Receipt)
+		require.Equal(t, rcpt.Ran().Link().String(), n.Invocation)
+		require.Equal(t, pdpcaps.AcceptAbility, n.Ability)
+		require.True(t, n.OK)
+		require.NotEmpty(t, n.Archive)
+
+		got, err := s.Get(t.Context(), ep.ID)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), got.Delivered)
+	})
+
+	t.Run("retries failed notifications with backoff", func(t *testing.T) {
+		s := newTestService(t, &now)
+		ep, err := s.Add(t.Context(), Endpoint{URL: srv.URL})
+		require.NoError(t, err)
+		status.Store(http.StatusInternalServerError)
+		t.Cleanup(func() { status.Store(http.StatusOK) })
+
+		require.NoError(t, s.Notify(t.Context(), acceptReceipt(t, true)))
+		require.NoError(t, s.deliverDue(t.Context()))
+		require.Len(t, received, 1)
+		<-received
+		var n Notification
+		require.NoError(t, json.Unmarshal(<-bodies, &n))
+		require.False(t, n.OK)
+		require.Equal(t, "piece not found", n.Error)
+
+		endpoints, err := s.List(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, 1, endpoints[0].Pending)
+		require.Contains(t, endpoints[0].LastError, "500")
+
+		// not retried before the backoff has elapsed
+		require.NoError(t, s.deliverDue(t.Context()))
+		require.Empty(t, received)
+
+		for i := 2; i <= MaxAttempts; i++ {
+			s.now = func() time.Time { return now.Add(time.Duration(i) * MaxBackoff) }
+			require.NoError(t, s.deliverDue(t.Context()))
+			<-received
+			<-bodies
+		}
+		got, err := s.Get(t.Context(), ep.ID)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), got.Failed)
+		endpoints, err = s.List(t.Context())
+		require.NoError(t, err)
+		require.Zero(t, endpoints[0].Pending)
+	})
+
+	t.Run("removing an endpoint drops its notifications", func(t *testing.T) {
+		s := newTestService(t, &now)
+		ep, err := s.Add(t.Context(), Endpoint{URL: srv.URL})
+		require.NoError(t, err)
+		require.NotEmpty(t, ep.Secret)
+
+		require.NoError(t, s.Notify(t.Context(), acceptReceipt(t, false)))
+		require.NoError(t, s.Remove(t.Context(), ep.ID))
+		require.NoError(t, s.deliverDue(t.Context()))
+		require.Empty(t, received)
+
+		require.ErrorIs(t, s.Remove(t.Context(), ep.ID), ErrNotFound)
+		_, err = s.Add(t.Context(), Endpoint{URL: "ftp://example.com"})
+		require.Error(t, err)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, MinBackoff, Backoff(1))
+	require.Equal(t, 2*MinBackoff, Backoff(2))
+	require.Equal(t, MaxBackoff, Backoff(MaxAttempts))
+}